@@ -90,25 +90,8 @@ func main() {
 			fullMessage = fmt.Sprintf("Context: %s\nUser Input: %s", history.String(), userInput)
 		}
 
-		// Retry Logic (Exponential Backoff)
-		maxRetries := 3
-		backoff := 1 * time.Second
-		var response string
-		var err error
-
-		for i := 0; i < maxRetries; i++ {
-			response, err = planner.PlanTrip(ctx, fullMessage, "Taipei Main Station", userContextInfo)
-			if err == nil {
-				break // Success
-			}
-
-			if i < maxRetries-1 {
-				fmt.Println("(連線繁忙，正在重試...)")
-				time.Sleep(backoff)
-				backoff *= 2
-			}
-		}
-
+		// The provider retries timeouts, 5xx and quota errors itself.
+		response, err := planner.PlanTrip(ctx, fullMessage, "Taipei Main Station", userContextInfo)
 		if err != nil {
 			lastFailedInput = userInput // Save for retry
 			fmt.Printf("ZooZoo: 抱歉，連線持續失敗 (%v)。\n", err)
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"ark/internal/config"
+	"ark/internal/diag"
+	"ark/internal/timeout"
+)
+
+// runCheck prints a diagnosis of the configuration and every dependency and
+// returns the process exit code: 1 if any check failed. Deploy pipelines run
+// `ark-api --check` before switching traffic to a new release.
+func runCheck(ctx context.Context, cfg config.Config, cfgErr error, external bool) int {
+	results := []diag.Result{diag.ConfigResult(cfgErr)}
+	if cfgErr == nil {
+		timeout.Set(timeout.Policy{Maps: cfg.Timeouts.Maps, LLM: cfg.Timeouts.LLM})
+		results = append(results, diag.Run(ctx, cfg, diag.Options{External: external})...)
+	}
+	diag.Print(os.Stdout, results)
+	if diag.Failed(results) {
+		return 1
+	}
+	return 0
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
@@ -10,99 +11,425 @@ import (
 	"syscall"
 	"time"
 
-	firebase "firebase.google.com/go/v4"
-	"google.golang.org/api/option"
-
 	"ark/internal/config"
+	"ark/internal/errreport"
+	"ark/internal/flags"
 	httptransport "ark/internal/http"
 	"ark/internal/http/middleware"
 	"ark/internal/infra"
 	"ark/internal/modules/aiusage"
+	"ark/internal/modules/arrival"
+	"ark/internal/modules/attachment"
 	"ark/internal/modules/calendar"
+	"ark/internal/modules/campaign"
+	"ark/internal/modules/closure"
+	"ark/internal/modules/commission"
+	"ark/internal/modules/departure"
+	"ark/internal/modules/device"
 	"ark/internal/modules/driver"
+	"ark/internal/modules/driverbot"
+	"ark/internal/modules/earnings"
+	"ark/internal/modules/giftcard"
+	"ark/internal/modules/ledger"
 	"ark/internal/modules/location"
 	"ark/internal/modules/matching"
 	"ark/internal/modules/notification"
 	"ark/internal/modules/order"
+	"ark/internal/modules/organization"
+	"ark/internal/modules/payout"
+	"ark/internal/modules/place"
+	"ark/internal/modules/pretrip"
 	"ark/internal/modules/pricing"
+	"ark/internal/modules/receipt"
+	"ark/internal/modules/referral"
+	"ark/internal/modules/subscription"
+	"ark/internal/modules/region"
 	"ark/internal/modules/relation"
+	"ark/internal/modules/risk"
+	"ark/internal/modules/sla"
+	"ark/internal/modules/tracking"
+	"ark/internal/modules/transit"
+	"ark/internal/modules/tripaudit"
+	"ark/internal/modules/triproute"
 	"ark/internal/ai"
 	"ark/internal/maps"
 	"ark/internal/modules/rideassistant"
 	"ark/internal/modules/user"
+	"ark/internal/modules/vehicle"
+	"ark/internal/pii"
+	"ark/internal/service"
+	"ark/internal/timeout"
+	"ark/internal/types"
 	"ark/internal/worker"
 )
 
 func main() {
+	check := flag.Bool("check", false, "check config, Postgres, migrations, Redis and Firebase, print a diagnosis and exit")
+	checkExternal := flag.Bool("check-external", false, "with -check, also call the Maps and Gemini APIs")
+	flag.Parse()
+
 	cfg, err := config.Load()
+	if *check {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := runCheck(ctx, cfg, err, *checkExternal)
+		stop()
+		os.Exit(code)
+	}
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("config: %v", err)
 	}
+	log.Printf("config: %s", cfg)
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	dbPool, err := infra.NewDB(ctx, cfg.DB.DSN)
+	if cfg.Errors.SentryDSN != "" {
+		sink, err := errreport.NewSentrySink(cfg.Errors.SentryDSN, cfg.Errors.Environment)
+		if err != nil {
+			log.Fatal(err)
+		}
+		errreport.SetSink(sink)
+	}
+
+	// Every call to Postgres, Redis, Maps and the LLM runs under its own
+	// deadline, whatever the caller's context allows.
+	timeout.Set(timeout.Policy{Maps: cfg.Timeouts.Maps, LLM: cfg.Timeouts.LLM})
+
+	dbPool, err := infra.NewDB(ctx, cfg.DB, cfg.Timeouts.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	redisClient, err := infra.NewRedis(ctx, cfg.Redis, cfg.Timeouts.Redis)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	redisClient := infra.NewRedis(cfg.Redis.Addr)
+	// Regions: each city's timezone, rate set, service area and matching
+	// radius, loaded up front so the first quotes see them.
+	regionSvc := region.NewService(region.NewStore(dbPool), region.Default(cfg.Region.Default))
+	if err := regionSvc.Refresh(ctx); err != nil {
+		log.Printf("region: initial load: %v", err)
+	}
+
+	// Feature flags: loaded up front and kept in sync over Redis, so a
+	// feature switched off in ops stops on every instance at once.
+	flagsSvc := flags.NewService(flags.NewStore(dbPool))
+	flagsSvc.SetBroadcast(redisClient)
+	if err := flagsSvc.Refresh(ctx); err != nil {
+		log.Printf("flags: initial load: %v", err)
+	}
+
+	// Road closures ops register are reloaded with the regions; trip routes
+	// detour around them and matching searches wider inside them.
+	closureSvc := closure.NewService(closure.NewStore(dbPool))
+	if err := closureSvc.Refresh(ctx); err != nil {
+		log.Printf("closure: initial load: %v", err)
+	}
 
 	pricingStore := pricing.NewStore(dbPool)
 	pricingSvc := pricing.NewService(pricingStore)
+	pricingSvc.SetRegions(regionSvc)
+	// Holiday surcharges and the ops import/export of rates and calendars.
+	pricingSvc.SetConfigStore(pricingStore)
+	if cfg.Pricing.Weather {
+		pricingSvc.SetWeather(pricing.NewOpenMeteo())
+	}
 
 	orderStore := order.NewStore(dbPool)
 	orderSvc := order.NewService(orderStore, pricingSvc)
+	orderSvc.ConfigureScheduling(cfg.Scheduling)
+	orderSvc.SetRegions(regionSvc)
+	orderSvc.SetCancellationFees(pricingSvc, cfg.Pricing.CancelGrace)
+	orderSvc.SetCharterPricing(pricingSvc)
+	orderSvc.SetThrottles(order.NewRedisThrottleCounter(redisClient), cfg.OrderThrottle)
+
+	// One Firebase app is shared by auth, FCM and RTDB; nil when no credentials are configured.
+	fbApp, err := infra.NewFirebaseApp(ctx, cfg.Firebase)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// PII columns (phones, device tokens, parcel recipients, saved places) are
+	// sealed with the keyring; without keys they are stored as plaintext, which
+	// is only acceptable in development.
+	keyring, err := pii.NewKeyringFromConfig(cfg.PII)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if keyring == nil {
+		log.Println("pii: no encryption keys configured; phone numbers and device tokens are stored in plaintext")
+	}
 
 	notificationStore := notification.NewStore(dbPool)
-	notificationSvc, err := notification.NewService(notificationStore, []byte(cfg.Notification.FirebaseCredentialsJSON))
+	notificationStore.SetKeyring(keyring)
+	orderStore.SetKeyring(keyring)
+	notificationSvc, err := notification.NewService(notificationStore, fbApp)
 	if err != nil {
 		log.Fatal(err)
 	}
+	notificationSvc.SetPreferenceStore(notificationStore)
+	notificationSvc.SetDeadLetterStore(notificationStore)
+	smsProvider, err := notification.NewSMSProvider(cfg.SMS)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if smsProvider != nil {
+		notificationSvc.SetSMSFallback(smsProvider, notificationStore, cfg.SMS.CostPerMessage, cfg.SMS.MonthlyCapPerUser)
+	}
 
 	matchingStore := matching.NewStore(redisClient, dbPool)
 
-	locationStore, err := location.NewStore(ctx, dbPool, redisClient, []byte(cfg.Notification.FirebaseCredentialsJSON))
+	locationStore, err := location.NewStore(ctx, dbPool, redisClient, fbApp)
 	if err != nil {
 		log.Fatal(err)
 	}
 	locationSvc := location.NewService(locationStore)
+	orderSvc.SetDriverTraces(locationSvc)
+	// Trips start near the pickup and complete near the dropoff (or after a
+	// minimum trip), within each region's thresholds.
+	orderSvc.SetTripChecks(locationSvc, regionSvc)
+	locationBackend, err := location.NewBackend(locationStore, cfg.Location.Backend)
+	if err != nil {
+		log.Fatal(err)
+	}
+	locationSvc.SetBackend(locationBackend)
+	orderSvc.OnTransition(locationSvc.OrderPresenceHook())
+	orderSvc.OnTransition(notificationSvc.OrderEventHook())
+	orderSvc.OnTransition(notificationSvc.DeliveryEventHook(orderSvc))
+	orderSvc.OnScheduleChange(notificationSvc.ScheduleChangeHook())
+	orderSvc.OnDropoffChange(notificationSvc.DropoffChangeHook())
+	orderSvc.OnRequote(notificationSvc.RequoteHook())
+	locationSvc.OnDriverOffline(notificationSvc.DriverOfflineHook(orderSvc))
 
 	matchingSvc := matching.NewService(matchingStore, orderSvc, notificationSvc, locationSvc, cfg.Matching)
+	orderSvc.SetPickupEstimator(matchingSvc, cfg.Matching.FastPickupMaxETA)
+	orderSvc.SetWAVPickupWindow(cfg.Matching.WAVPickupMaxETA)
+	orderSvc.SetDispatchLock(matchingSvc)
+	if cfg.Matching.DirectFCM {
+		if notificationSvc.PushEnabled() {
+			matchingSvc.SetNotifier(matching.NewFCMNotifier(notificationSvc))
+		} else {
+			log.Printf("matching: ARK_MATCH_DIRECT_FCM set but Firebase is not configured; using generic notifications")
+		}
+	}
 
+	// Every Gemini caller retries timeouts, 5xx and 429s under one policy.
+	aiRetry := ai.RetryPolicy{
+		MaxAttempts: cfg.AI.MaxAttempts,
+		Timeout:     cfg.AI.Timeout,
+		Backoff:     cfg.AI.Backoff,
+		MaxBackoff:  cfg.AI.MaxBackoff,
+	}
+	// Versioned prompt templates; the served version is switched at runtime
+	// from the ops API and shared through Postgres.
+	prompts, err := ai.NewPrompts(cfg.AI.PromptDir)
+	if err != nil {
+		log.Fatalf("ai prompts: %v", err)
+	}
+	prompts.SetStore(ai.NewPromptStore(dbPool))
 	aiStore := aiusage.NewStore(dbPool)
 	aiSvc, err := aiusage.NewService(aiStore, cfg.AI.GeminiKey)
 	if err != nil {
 		log.Fatal(err)
 	}
+	aiSvc.SetRetryPolicy(aiRetry)
 	defer aiSvc.Close()
+	// Voice messages are transcribed by Gemini and answered like typed ones.
+	if cfg.AI.GeminiKey != "" {
+		stt, err := ai.NewGeminiTranscriber(ctx, cfg.AI.GeminiKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		stt.SetRetryPolicy(aiRetry)
+		stt.SetUsageRecorder(aiStore)
+		aiSvc.SetTranscriber(stt)
+		defer stt.Close()
+	}
 
 	calendarStore := calendar.NewStore(dbPool)
 	calendarSvc := calendar.NewService(calendarStore, orderSvc)
 
 	driverStore := driver.NewStore(dbPool)
 	driverSvc := driver.NewService(driverStore)
+	matchingSvc.SetCapabilityFilter(driverSvc)
+	driverSvc.SetRegions(regionSvc)
+	matchingSvc.SetRegions(regionSvc, driverSvc)
+	matchingSvc.SetClosures(closureSvc)
+	orderSvc.SetDriverCapabilities(driverSvc)
 	userStore := user.NewStore(dbPool)
+	userStore.SetKeyring(keyring)
 	userSvc := user.NewService(userStore)
+	// Priority passengers: orders carry the passenger's class, which dispatch
+	// boosts per region; wait times per class are published as expvars.
+	orderSvc.SetPassengerPriority(userSvc)
+	orderSvc.OnTransition(matchingSvc.WaitHook(orderSvc))
+	// Shadow mode: a candidate dispatch strategy picks drivers beside the live
+	// one; only its divergence is recorded, in the matching_shadow expvars.
+	if cfg.Matching.ShadowStrategy != "" {
+		shadow, err := matching.NewSelector(cfg.Matching.ShadowStrategy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		matchingSvc.SetShadowSelector(shadow)
+		orderSvc.OnTransition(matchingSvc.ShadowHook())
+	}
+	orderSvc.OnTransition(matchingSvc.UnclaimedHook())
+	// Dispatch offers: every offer round is recorded with a TTL and streamed to
+	// driver apps; offers close on acceptance and expire without an answer.
+	matchingSvc.SetOffers(matchingStore, cfg.Matching.OfferTTL)
+	orderSvc.SetOffers(matchingStore)
+	matchingSvc.SetMatchStats(matchingStore)
+	orderSvc.OnTransition(matchingSvc.OfferHook())
 	relationStore := relation.NewStore(dbPool)
+	relationStore.SetKeyring(keyring)
 	relationSvc := relation.NewService(relationStore)
-	// Initialize Firebase auth client for token verification.
-	// If FIREBASE_CREDENTIALS_JSON is not set, auth middleware is disabled (dev mode).
-	var tokenVerifier middleware.TokenVerifier
-	if creds := cfg.Notification.FirebaseCredentialsJSON; creds != "" {
-		fbApp, err := firebase.NewApp(ctx, nil, option.WithCredentialsJSON([]byte(creds)))
+
+	// Trip tracking grants are enforced by RTDB rules; without Firebase the
+	// endpoints report the feature as unavailable.
+	var trackingGrants tracking.GrantWriter
+	if fbApp != nil {
+		rtdb, err := fbApp.Database(ctx)
+		if err != nil {
+			log.Fatalf("initialising firebase RTDB client for tracking: %v", err)
+		}
+		trackingGrants = tracking.NewRTDBGrants(rtdb)
+	}
+	trackingSvc := tracking.NewService(tracking.NewStore(dbPool), trackingGrants, orderSvc, relationSvc)
+	orderSvc.OnTransition(trackingSvc.OrderHook())
+
+	// Email receipts and monthly summaries are skipped when no sender is configured.
+	emailSender, err := notification.NewEmailSender(cfg.Email)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var receiptSvc *receipt.Service
+	if emailSender != nil {
+		receiptSvc = receipt.NewService(receipt.NewStore(dbPool), orderSvc, emailSender)
+		orderSvc.OnTransition(receiptSvc.OrderHook())
+	}
+	// Business rides: organization policy at order creation, settlement to the
+	// monthly invoice at payment, and invoice emails when a sender is configured.
+	orgSvc := organization.NewService(organization.NewStore(dbPool), orderSvc)
+	orderSvc.SetOrgPolicy(orgSvc)
+	orderSvc.OnTransition(orgSvc.OrderHook())
+	if emailSender != nil {
+		orgSvc.SetInvoiceSender(emailSender)
+	}
+	// Referrals: rewards on the referee's first completed ride, and credits
+	// taken off every personal fare as it reaches payment.
+	referralSvc := referral.NewService(referral.NewStore(dbPool), referral.Rewards{
+		ReferrerCredit: cfg.Referral.ReferrerCredit,
+		RefereeCredit:  cfg.Referral.RefereeCredit,
+		MaxPerReferrer: cfg.Referral.MaxPerReferrer,
+	})
+	orderSvc.SetCredits(referralSvc)
+	orderSvc.OnTransition(referralSvc.OrderHook())
+	// Ride plans: the plan's discount and included credit come off fares
+	// before ride credits.
+	subscriptionSvc := subscription.NewService(subscription.NewStore(dbPool))
+	orderSvc.SetSubscriptions(subscriptionSvc)
+	log.Printf("subscription: no payment provider configured; ride plans cannot be bought or renewed")
+	// Arrival guarantee: arrive-by rides are scored against their promised
+	// window and passengers credited when the platform misses it.
+	arrivalSvc := arrival.NewService(arrival.NewStore(dbPool), orderSvc, arrival.Policy{
+		CreditAfter: cfg.Arrival.CreditAfter,
+		CreditBps:   cfg.Arrival.CreditBps,
+	})
+	arrivalSvc.SetCredits(referralSvc)
+	orderSvc.OnTransition(arrivalSvc.OrderHook())
+	// Pre-trip reminders: drivers of claimed scheduled rides are pushed a
+	// navigation link when the window starts and escalated to ops if they
+	// have not departed shortly before pickup.
+	pretripSvc := pretrip.NewService(pretrip.NewStore(dbPool), orderSvc, cfg.Pretrip.Interval, cfg.Pretrip.EscalateBefore)
+	pretripSvc.OnReminder(notificationSvc.PretripReminderHook())
+	orderSvc.OnTransition(pretripSvc.OrderHook())
+	// Vehicle upkeep: drivers are reminded of inspections and services due,
+	// and regions may stop offering orders to overdue drivers.
+	vehicleStore := vehicle.NewStore(dbPool)
+	vehicleSvc := vehicle.NewService(vehicleStore, cfg.Vehicle.Interval, cfg.Vehicle.InspectionLead, cfg.Vehicle.ServiceIntervalKm)
+	vehicleSvc.OnReminder(notificationSvc.VehicleReminderHook())
+	vehicleSvc.SetFleetStore(vehicleStore)
+	matchingSvc.SetInspections(vehicleSvc)
+	driverSvc.SetVehicles(vehicleSvc)
+	// Order attachments: photos are uploaded straight to Cloud Storage
+	// through signed URLs and deleted some time after their order ends.
+	var attachmentSvc *attachment.Service
+	if cfg.Attachment.Bucket != "" && fbApp != nil {
+		bucket, err := infra.NewFirebaseBucket(ctx, fbApp, cfg.Attachment.Bucket)
 		if err != nil {
-			log.Fatalf("initialising Firebase app for auth: %v", err)
+			log.Fatalf("attachment bucket: %v", err)
 		}
-		authClient, err := fbApp.Auth(ctx)
+		attachmentSvc = attachment.NewService(attachment.NewStore(dbPool), orderSvc, attachment.NewGCSBucket(bucket), cfg.Attachment.Retention, cfg.Attachment.Interval)
+		orderSvc.SetDeliveryProof(attachmentSvc)
+	}
+	// Driver quests: completed trips count toward running campaigns and
+	// rewards are paid into the earnings ledger.
+	earningsSvc := earnings.NewService(earnings.NewStore(dbPool))
+	campaignSvc := campaign.NewService(campaign.NewStore(dbPool), earningsSvc)
+	orderSvc.OnTransition(campaignSvc.OrderHook())
+	// Trip earnings: the driver's share of each completed fare, at the
+	// commission rule in force at completion.
+	commissionSvc := commission.NewService(commission.NewStore(dbPool), cfg.Commission.DefaultBps)
+	orderSvc.OnTransition(earningsSvc.TripHook(orderSvc, commissionSvc))
+	payouts := earnings.NewPayouts(commissionSvc)
+	orderSvc.SetPayoutEstimator(payouts)
+	orderSvc.SetCancellationCommission(payouts)
+	// Daily settlement: each day's earnings become one payout per driver.
+	payoutSvc := payout.NewService(payout.NewStore(dbPool))
+	if cfg.Payout.Dir != "" {
+		payoutSvc.SetSubmitter(payout.NewFileSubmitter(cfg.Payout.Dir))
+	} else {
+		log.Printf("payout: ARK_PAYOUT_DIR not set; settled batches stay pending")
+	}
+	// Fraud signals from driver positions and completed trips feed the risk
+	// review queue, optionally holding payouts on high-risk trips.
+	riskSvc := risk.NewService(risk.NewStore(dbPool), orderSvc)
+	if cfg.Payout.HoldRiskyTrips {
+		riskSvc.SetPayoutHolder(payoutSvc)
+	}
+	locationSvc.OnPosition(riskSvc.SpeedHook())
+	// SLA monitor: orders stuck waiting, approaching or in payment are listed
+	// for ops and alerted once each.
+	slaSvc := sla.NewService(sla.NewStore(dbPool), sla.Limits{
+		Waiting:     cfg.SLA.WaitingAfter,
+		Approaching: cfg.SLA.ApproachingAfter,
+		Payment:     cfg.SLA.PaymentAfter,
+	}, cfg.SLA.Interval)
+	if cfg.SLA.SlackWebhookURL != "" {
+		slaSvc.SetAlerter(sla.NewSlackAlerter(cfg.SLA.SlackWebhookURL))
+	}
+	orderSvc.OnTransition(riskSvc.TripHook())
+	orderSvc.OnLocationDiscrepancy(riskSvc.LocationHook())
+	// Installs seen at registration and ordering; many accounts on one
+	// install raise a multi-account risk signal.
+	deviceSvc := device.NewService(device.NewStore(dbPool))
+	deviceSvc.OnSighting(riskSvc.DeviceHook())
+	// Sandbox orders are played end to end by a simulated driver.
+	if cfg.Sandbox.Enabled() {
+		orderSvc.OnTransition(driverbot.NewService(orderSvc, cfg.Sandbox.BotStep).OrderHook())
+		log.Printf("sandbox: test mode enabled (bot step %s)", cfg.Sandbox.BotStep)
+	}
+	// Initialize Firebase auth client for token verification.
+	// If no Firebase credentials are configured, auth middleware is disabled (dev mode).
+	var tokenVerifier middleware.TokenVerifier
+	var identity user.IdentityProvider
+	if fbApp != nil {
+		authClient, err := infra.NewFirebaseVerifier(ctx, fbApp)
 		if err != nil {
-			log.Fatalf("initialising Firebase auth client: %v", err)
+			log.Fatal(err)
 		}
 		tokenVerifier = authClient
+		identity = infra.NewFirebaseIdentity(authClient)
 	} else {
-		log.Printf("SECURITY WARNING: FIREBASE_CREDENTIALS_JSON not set; auth middleware disabled (dev mode)")
+		log.Printf("SECURITY WARNING: Firebase credentials not set; auth middleware disabled (dev mode)")
 	}
+	userSvc.ConfigureDeletion(userStore, identity, cfg.Account.DeletionGrace)
+	userSvc.OnDeletionRequested(func(ctx context.Context, id types.ID) {
+		if err := locationSvc.ClearPassengerSeeking(ctx, id); err != nil {
+			log.Printf("user: clear presence for deleted account %s: %v", id, err)
+		}
+	})
 
 	// Ride assistant — wired with Gemini AI, Maps geocoding, and order service.
 	raStore := rideassistant.NewStore()
@@ -115,20 +442,95 @@ func main() {
 		log.Printf("ride assistant: Gemini init failed, using stub planner: %v", err)
 		raPlanner = rideassistant.NewStubPlanner()
 	} else {
+		geminiProvider.SetRetryPolicy(aiRetry)
+		geminiProvider.SetUsageRecorder(aiStore)
+		geminiProvider.SetPrompts(prompts)
 		raPlanner = rideassistant.NewGeminiAdapter(geminiProvider)
 		defer geminiProvider.Close()
 	}
 
-	if cfg.AI.MapsAPIKey != "" {
-		routeSvc, err := maps.NewRouteService(cfg.AI.MapsAPIKey)
+	var mapsRoutes *maps.RouteService
+	var stopSearch *service.StopSearch
+	if cfg.Maps.APIKey != "" {
+		routeSvc, err := maps.NewRouteService(cfg.Maps.APIKey)
 		if err != nil {
 			log.Printf("ride assistant: Maps RouteService init failed, geocoding disabled: %v", err)
 		} else {
 			raGeocoder = rideassistant.NewMapsGeocoder(routeSvc)
+			// Mid-trip dropoff changes are priced on the driving route.
+			orderSvc.SetRouteDistance(routeSvc)
+			mapsRoutes = routeSvc
 		}
+		// Apps search for stops along their route without the assistant.
+		placesSvc, err := maps.NewPlacesService(cfg.Maps.APIKey)
+		if err != nil {
+			log.Printf("route search: Maps PlacesService init failed, stop search disabled: %v", err)
+		} else if mapsRoutes != nil {
+			stopSearch = service.NewStopSearch(mapsRoutes, placesSvc)
+		}
+	}
+
+	// Trips are recorded from the order lifecycle and, with Maps, their GPS
+	// traces measured against the driving route for the fraud-review queue.
+	locationSvc.SetSnapshotInterval(cfg.Location.SnapshotInterval)
+	tripAuditSvc := tripaudit.NewService(tripaudit.NewStore(dbPool), orderSvc, locationSvc, mapsRoutes, cfg.TripAudit.Interval)
+	orderSvc.OnTransition(tripAuditSvc.TripHook())
+
+	// Scheduled rides that must arrive by a flight or train are re-routed
+	// against traffic as pickup nears; late ones get an earlier pickup offer.
+	var departureSvc *departure.Service
+	if mapsRoutes != nil {
+		departureSvc = departure.NewService(departure.NewStore(dbPool), orderSvc, mapsRoutes, cfg.Departure.Interval, cfg.Departure.Lookahead)
+		departureSvc.OnSuggestion(notificationSvc.DepartureSuggestionHook())
+	}
+
+	// Both apps draw the trip from the route looked up when a driver accepts it.
+	var tripRouteSvc *triproute.Service
+	if mapsRoutes != nil {
+		tripRouteSvc = triproute.NewService(triproute.NewStore(dbPool), orderSvc, mapsRoutes)
+		tripRouteSvc.SetClosures(closureSvc)
+		orderSvc.OnTransition(tripRouteSvc.OrderHook())
+		orderSvc.OnDropoffChange(tripRouteSvc.DropoffHook())
+		// Drivers get the navigation handoff pushed when they take a ride.
+		tripRouteSvc.OnNavigation(notificationSvc.NavigationHook())
 	}
 
 	raSvc := rideassistant.NewService(raStore, raPlanner, raOrderAdapter, raGeocoder)
+	raConversations := rideassistant.NewConversationStore(dbPool)
+	raSvc.SetConversationLog(raConversations)
+	raSvc.SetConversationHistory(raConversations)
+
+	// Ride destinations become recent places; with favorites they tell the
+	// assistant where "the usual café" is.
+	placeStore := place.NewStore(dbPool)
+	placeStore.SetKeyring(keyring)
+	placeSvc := place.NewService(placeStore, orderSvc)
+	if mapsRoutes != nil {
+		placeSvc.SetAddressLookup(mapsRoutes)
+	}
+	orderSvc.OnTransition(placeSvc.OrderHook())
+	raSvc.SetUserContext(placeSvc)
+	// The assistant replies in the language the user writes in and remembers
+	// it on the profile, where notifications pick it up.
+	raSvc.SetLanguages(userSvc)
+	notificationSvc.SetLanguages(userSvc)
+	// Deleted accounts lose their assistant chat history right away; the
+	// purge clears anything recorded since.
+	userSvc.OnDeletionRequested(func(ctx context.Context, id types.ID) {
+		if _, err := raSvc.DeleteHistory(ctx, string(id)); err != nil {
+			log.Printf("rideassistant: delete history of %s: %v", id, err)
+		}
+	})
+
+	var orderTokens *middleware.OrderTokens
+	if cfg.OrderLink.SigningKey != "" {
+		orderTokens, err = middleware.NewOrderTokens([]byte(cfg.OrderLink.SigningKey), cfg.OrderLink.TTL)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		log.Printf("order links: ORDER_LINK_SIGNING_KEY not set; share links disabled")
+	}
 
 	workerRegistry := worker.NewRegistry()
 
@@ -143,7 +545,36 @@ func main() {
 		Driver:       driverSvc,
 		User:         userSvc,
 		Relation:     relationSvc,
+		Tracking:     trackingSvc,
+		Organization: orgSvc,
+		Referral:     referralSvc,
+		Campaign:     campaignSvc,
+		Earnings:     earningsSvc,
+		Commission:   commissionSvc,
+		Payout:       payoutSvc,
+		Ledger:       ledger.NewService(ledger.NewStore(dbPool)),
+		TripAudit:    tripAuditSvc,
+		Risk:         riskSvc,
+		Device:       deviceSvc,
+		Departure:    departureSvc,
+		Place:        placeSvc,
+		Stops:        stopSearch,
+		TripRoute:    tripRouteSvc,
+		Arrival:      arrivalSvc,
+		Pretrip:      pretripSvc,
+		Vehicle:      vehicleSvc,
+		Attachment:   attachmentSvc,
+		SLA:          slaSvc,
+		Region:       regionSvc,
+		Closure:      closureSvc,
+		Flags:        flagsSvc,
+		Subscription: subscriptionSvc,
+		GiftCard:     giftcard.NewService(giftcard.NewStore(dbPool)),
 		Auth:          tokenVerifier,
+		OrderTokens:   orderTokens,
+		SandboxKey:    cfg.Sandbox.Key,
+		OpsKey:        cfg.Ops.Key,
+		Prompts:       prompts,
 		RideAssistant: raSvc,
 		DB:            dbPool,
 		Redis:        redisClient,
@@ -154,14 +585,75 @@ func main() {
 
 	restartDelay := 5 * time.Second
 	reg := workerRegistry
-	go worker.RunWithRecovery(ctx, "rtdb-poller", func(c context.Context) {
-		locationSvc.RunRTDBPoller(c, 30*time.Second)
+	if locationStore.RTDBEnabled() {
+		go worker.RunWithRecovery(ctx, "rtdb-poller", func(c context.Context) {
+			locationSvc.RunRTDBPoller(c, 30*time.Second)
+		}, restartDelay, reg)
+	} else {
+		log.Printf("location: Firebase not configured; RTDB poller disabled")
+	}
+	if cfg.Location.Backend == location.BackendDual {
+		go worker.RunWithRecovery(ctx, "location-consistency", func(c context.Context) {
+			locationSvc.RunConsistencyChecker(c, cfg.Location.ConsistencyInterval)
+		}, restartDelay, reg)
+	}
+	if cfg.Location.Backend == location.BackendRTDB {
+		log.Printf("location: RTDB backend writes no Redis heartbeats; driver heartbeat monitor disabled")
+	} else {
+		go worker.RunWithRecovery(ctx, "driver-heartbeat", func(c context.Context) {
+			locationSvc.RunHeartbeatMonitor(c, cfg.Location.HeartbeatInterval, cfg.Location.HeartbeatTimeout)
+		}, restartDelay, reg)
+	}
+	go worker.RunWithRecovery(ctx, "ai-prompt-refresh", func(c context.Context) {
+		prompts.RunRefresh(c, cfg.AI.PromptRefresh)
+	}, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "region-refresh", func(c context.Context) {
+		regionSvc.RunRefresh(c, cfg.Region.Refresh)
+	}, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "closure-refresh", func(c context.Context) {
+		closureSvc.RunRefresh(c, cfg.Region.Refresh)
+	}, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "flags-refresh", func(c context.Context) {
+		flagsSvc.RunRefresh(c, cfg.Flags.Refresh)
+	}, restartDelay, reg)
+	if cfg.Location.SnapshotInterval > 0 {
+		go worker.RunWithRecovery(ctx, "snapshot-prune", func(c context.Context) {
+			locationSvc.RunSnapshotPruner(c, cfg.Location.SnapshotRetention)
+		}, restartDelay, reg)
+	}
+	if mapsRoutes != nil {
+		go worker.RunWithRecovery(ctx, "trip-audit", tripAuditSvc.RunAuditJob, restartDelay, reg)
+		go worker.RunWithRecovery(ctx, "departure-advice", departureSvc.RunJob, restartDelay, reg)
+	} else {
+		log.Printf("trip audit: GOOGLE_MAPS_API_KEY not set; distance audits and departure advice disabled")
+	}
+	if receiptSvc != nil {
+		go worker.RunWithRecovery(ctx, "monthly-rollup", receiptSvc.RunMonthlyRollup, restartDelay, reg)
+	}
+	go worker.RunWithRecovery(ctx, "org-invoices", orgSvc.RunInvoiceJob, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "driver-settlement", payoutSvc.RunSettlementJob, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "subscription-renewal", subscriptionSvc.RunRenewalJob, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "account-purge", func(c context.Context) {
+		userSvc.RunPurgeJob(c, cfg.Account.PurgeInterval)
 	}, restartDelay, reg)
 	go worker.RunWithRecovery(ctx, "matching-scheduler", matchingSvc.RunScheduler, restartDelay, reg)
 	go worker.RunWithRecovery(ctx, "notification-scheduler", matchingSvc.RunNotificationScheduler, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "offer-expiry", matchingSvc.RunOfferExpiry, restartDelay, reg)
 	go worker.RunWithRecovery(ctx, "timeout-monitor", orderSvc.RunTimeoutMonitor, restartDelay, reg)
 	go worker.RunWithRecovery(ctx, "schedule-incentive", orderSvc.RunScheduleIncentiveTicker, restartDelay, reg)
 	go worker.RunWithRecovery(ctx, "schedule-expire", orderSvc.RunScheduleExpireTicker, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "schedule-requote", orderSvc.RunRequoteTicker, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "pretrip-reminders", pretripSvc.RunJob, restartDelay, reg)
+	go worker.RunWithRecovery(ctx, "vehicle-reminders", vehicleSvc.RunJob, restartDelay, reg)
+	if attachmentSvc != nil {
+		go worker.RunWithRecovery(ctx, "attachment-cleanup", attachmentSvc.RunJob, restartDelay, reg)
+	}
+	go worker.RunWithRecovery(ctx, "sla-monitor", slaSvc.RunJob, restartDelay, reg)
+	// Airport pickups follow flight delays when a flight-status key is configured.
+	if cfg.Transit.FlightAPIKey != "" {
+		transitSvc := transit.NewService(orderSvc, transit.NewAviationStackProvider(cfg.Transit.FlightAPIKey), cfg.Transit.PollInterval, cfg.Transit.Lookahead)
+		go worker.RunWithRecovery(ctx, "transit-watcher", transitSvc.RunWatcher, restartDelay, reg)
+	}
 
 	// Start HTTP server in a goroutine.
 	go func() {
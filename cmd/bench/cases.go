@@ -3,9 +3,7 @@ package main
 
 import (
     "context"
-    "encoding/json"
     "fmt"
-    "io"
     "net/http"
     "os"
     "regexp"
@@ -15,11 +13,13 @@ import (
 
     "github.com/jackc/pgx/v5/pgxpool"
     "github.com/redis/go-redis/v9"
+
+    "ark/pkg/arkclient"
 )
 
 type Runner struct {
     cfg    Config
-    httpc  *http.Client
+    api    *arkclient.Client
     db     *pgxpool.Pool
     redis  *redis.Client
 }
@@ -38,9 +38,11 @@ type TestCase struct {
 }
 
 func NewRunner(cfg Config) *Runner {
+    api := arkclient.New(cfg.BaseURL)
+    api.SetHTTPClient(&http.Client{Timeout: 10 * time.Second})
     return &Runner{
-        cfg:   cfg,
-        httpc: &http.Client{Timeout: 10 * time.Second},
+        cfg: cfg,
+        api: api,
     }
 }
 
@@ -81,7 +83,6 @@ func (r *Runner) RunAll(ctx context.Context) []Result {
 }
 
 func (r *Runner) cases() []TestCase {
-    base := r.cfg.BaseURL
     return []TestCase{
         {
             Name:  "Env: Postgres connect",
@@ -168,17 +169,16 @@ func (r *Runner) cases() []TestCase {
             Focus: "API 可回應請求",
             Run: func(ctx context.Context, r *Runner) Result {
                 start := time.Now()
-                resp, err := r.httpc.Get(base + "/api/passenger/order_status/test")
+                status, err := r.call(ctx, http.MethodGet, "/api/passenger/order_status/test", nil)
                 if err != nil {
                     return Result{Status: "FAIL", Note: err.Error()}
                 }
-                _ = resp.Body.Close()
-                return Result{Status: "PASS", Latency: time.Since(start), Note: fmt.Sprintf("status=%d", resp.StatusCode)}
+                return Result{Status: "PASS", Latency: time.Since(start), Note: fmt.Sprintf("status=%d", status)}
             },
         },
 
         // Order flow
-        httpCase("Order: passenger request ride (valid)", base+"/api/rides/request", map[string]any{
+        httpCase("Order: passenger request ride (valid)", "/api/rides/request", map[string]any{
             "passenger_id": "p1",
             "pickup_lat": 25.033,
             "pickup_lng": 121.565,
@@ -187,9 +187,9 @@ func (r *Runner) cases() []TestCase {
             "ride_type": "economy",
         }, []int{200, 201}, []int{501, 404}),
 
-        httpCase("Order: passenger request ride (missing fields -> 400)", base+"/api/rides/request", map[string]any{}, []int{400}, []int{501, 404}),
+        httpCase("Order: passenger request ride (missing fields -> 400)", "/api/rides/request", map[string]any{}, []int{400}, []int{501, 404}),
 
-        httpCase("Order: passenger request ride (duplicate active)", base+"/api/rides/request", map[string]any{
+        httpCase("Order: passenger request ride (duplicate active)", "/api/rides/request", map[string]any{
             "passenger_id": "p1",
             "pickup_lat": 25.033,
             "pickup_lng": 121.565,
@@ -198,42 +198,42 @@ func (r *Runner) cases() []TestCase {
             "ride_type": "economy",
         }, []int{409}, []int{501, 404}),
 
-        httpCase("Order: driver accept (valid)", base+"/api/rides/accept", map[string]any{
+        httpCase("Order: driver accept (valid)", "/api/rides/accept", map[string]any{
             "driver_id": "d1",
             "order_id":  "o1",
         }, []int{200, 201}, []int{501, 404}),
 
-        httpCase("Order: driver accept (invalid state)", base+"/api/rides/accept", map[string]any{
+        httpCase("Order: driver accept (invalid state)", "/api/rides/accept", map[string]any{
             "driver_id": "d1",
             "order_id":  "o999",
         }, []int{409, 400}, []int{501, 404}),
 
-        httpCase("Order: driver start trip", base+"/api/rides/start", map[string]any{
+        httpCase("Order: driver start trip", "/api/rides/start", map[string]any{
             "driver_id": "d1",
             "order_id":  "o1",
         }, []int{200, 201}, []int{501, 404}),
 
-        httpCase("Order: driver complete trip", base+"/api/rides/complete", map[string]any{
+        httpCase("Order: driver complete trip", "/api/rides/complete", map[string]any{
             "driver_id": "d1",
             "order_id":  "o1",
         }, []int{200, 201}, []int{501, 404}),
 
-        httpCaseMethod("Order: get order status", http.MethodGet, base+"/api/passenger/order_status/o1", nil, []int{200}, []int{501, 404}),
+        httpCaseMethod("Order: get order status", http.MethodGet, "/api/passenger/order_status/o1", nil, []int{200}, []int{501, 404}),
 
         manualCase("Order: completed cannot transition", "需完成後嘗試再次變更狀態"),
 
         // Cancel flow
-        httpCase("Cancel: passenger cancel (created)", base+"/api/rides/cancel", map[string]any{
+        httpCase("Cancel: passenger cancel (created)", "/api/rides/cancel", map[string]any{
             "order_id": "o1",
             "reason":   "change_plans",
         }, []int{200, 201}, []int{501, 404}),
 
-        httpCase("Cancel: passenger cancel (accepted/in_progress -> reject)", base+"/api/rides/cancel", map[string]any{
+        httpCase("Cancel: passenger cancel (accepted/in_progress -> reject)", "/api/rides/cancel", map[string]any{
             "order_id": "o2",
             "reason":   "too_late",
         }, []int{409, 400}, []int{501, 404}),
 
-        httpCase("Cancel: driver reject order", base+"/api/rides/reject", map[string]any{
+        httpCase("Cancel: driver reject order", "/api/rides/reject", map[string]any{
             "driver_id": "d1",
             "order_id":  "o1",
             "reason":    "too_far",
@@ -241,7 +241,7 @@ func (r *Runner) cases() []TestCase {
 
         manualCase("Cancel: timeout cancel", "需等候超時或縮短 timeout 後觀察"),
 
-        httpCase("Matching: driver availability", base+"/api/driver/set_availability", map[string]any{
+        httpCase("Matching: driver availability", "/api/driver/set_availability", map[string]any{
             "driver_id": "d1",
             "is_available": true,
             "current_lat": 25.033,
@@ -254,22 +254,19 @@ func (r *Runner) cases() []TestCase {
         manualCase("Matching: remove from pool after match", "需查 Redis set 是否移除"),
 
         // Location
-        httpCase("Location: update passenger", base+"/api/location/update", map[string]any{
-            "user_id": "p1",
+        httpCase("Location: update passenger", "/api/location/update", map[string]any{
             "user_type": "passenger",
             "lat": 25.033,
             "lng": 121.565,
         }, []int{200, 201}, []int{501, 404}),
 
-        httpCase("Location: update driver", base+"/api/location/update", map[string]any{
-            "user_id": "d1",
+        httpCase("Location: update driver", "/api/location/update", map[string]any{
             "user_type": "driver",
             "lat": 25.033,
             "lng": 121.565,
         }, []int{200, 201}, []int{501, 404}),
 
-        httpCase("Location: invalid coords -> 400", base+"/api/location/update", map[string]any{
-            "user_id": "d1",
+        httpCase("Location: invalid coords -> 400", "/api/location/update", map[string]any{
             "user_type": "driver",
             "lat": 123.0,
             "lng": 456.0,
@@ -279,7 +276,7 @@ func (r *Runner) cases() []TestCase {
         manualCase("Location: snapshot persisted", "需查 DB location_snapshots 是否寫入"),
 
         // Pricing
-        httpCase("Pricing: estimate (valid)", base+"/api/rides/request", map[string]any{
+        httpCase("Pricing: estimate (valid)", "/api/rides/request", map[string]any{
             "passenger_id": "p1",
             "pickup_lat": 25.033,
             "pickup_lng": 121.565,
@@ -288,7 +285,7 @@ func (r *Runner) cases() []TestCase {
             "ride_type": "economy",
         }, []int{200, 201}, []int{501, 404}),
 
-        httpCase("Pricing: missing rate", base+"/api/rides/request", map[string]any{
+        httpCase("Pricing: missing rate", "/api/rides/request", map[string]any{
             "passenger_id": "p1",
             "pickup_lat": 25.033,
             "pickup_lng": 121.565,
@@ -309,7 +306,7 @@ func (r *Runner) cases() []TestCase {
             Name:  "Concurrency: multi accept same order",
             Focus: "只允許第一位司機接受",
             Run: func(ctx context.Context, r *Runner) Result {
-                return concurrentAccept(ctx, r, base+"/api/rides/accept")
+                return concurrentAccept(ctx, r, "/api/rides/accept")
             },
         },
         manualCase("Concurrency: cancel vs accept", "需同時送 cancel/accept 驗證先到者為準"),
@@ -325,8 +322,7 @@ func (r *Runner) cases() []TestCase {
             Name:  "Perf: location update throughput",
             Focus: "每秒 50~100 次位置更新",
             Run: func(ctx context.Context, r *Runner) Result {
-                return perfLoad(ctx, r, base+"/api/location/update", map[string]any{
-                    "user_id": "d1",
+                return perfLoad(ctx, r, "/api/location/update", map[string]any{
                     "user_type": "driver",
                     "lat": 25.033,
                     "lng": 121.565,
@@ -337,7 +333,7 @@ func (r *Runner) cases() []TestCase {
             Name:  "Perf: request ride throughput",
             Focus: "每秒 10~20 筆下單",
             Run: func(ctx context.Context, r *Runner) Result {
-                return perfLoad(ctx, r, base+"/api/rides/request", map[string]any{
+                return perfLoad(ctx, r, "/api/rides/request", map[string]any{
                     "passenger_id": "p1",
                     "pickup_lat": 25.033,
                     "pickup_lng": 121.565,
@@ -350,42 +346,46 @@ func (r *Runner) cases() []TestCase {
     }
 }
 
-func httpCase(name, url string, body any, okStatuses, pendingStatuses []int) TestCase {
-    return httpCaseMethod(name, http.MethodPost, url, body, okStatuses, pendingStatuses)
+func httpCase(name, path string, body any, okStatuses, pendingStatuses []int) TestCase {
+    return httpCaseMethod(name, http.MethodPost, path, body, okStatuses, pendingStatuses)
 }
 
-func httpCaseMethod(name, method, url string, body any, okStatuses, pendingStatuses []int) TestCase {
+func httpCaseMethod(name, method, path string, body any, okStatuses, pendingStatuses []int) TestCase {
     return TestCase{
         Name:  name,
         Focus: "HTTP API",
         Run: func(ctx context.Context, r *Runner) Result {
-            var reader io.Reader
-            if body != nil {
-                b, _ := json.Marshal(body)
-                reader = strings.NewReader(string(b))
-            }
-            req, _ := http.NewRequestWithContext(ctx, method, url, reader)
-            req.Header.Set("Content-Type", "application/json")
             start := time.Now()
-            resp, err := r.httpc.Do(req)
+            status, err := r.call(ctx, method, path, body)
             if err != nil {
                 return Result{Status: "FAIL", Note: err.Error()}
             }
-            io.Copy(io.Discard, resp.Body)
-            resp.Body.Close()
             latency := time.Since(start)
 
-            if contains(okStatuses, resp.StatusCode) {
-                return Result{Status: "PASS", Latency: latency, Note: fmt.Sprintf("status=%d", resp.StatusCode)}
+            if contains(okStatuses, status) {
+                return Result{Status: "PASS", Latency: latency, Note: fmt.Sprintf("status=%d", status)}
             }
-            if contains(pendingStatuses, resp.StatusCode) {
-                return Result{Status: "PENDING", Latency: latency, Note: fmt.Sprintf("status=%d", resp.StatusCode)}
+            if contains(pendingStatuses, status) {
+                return Result{Status: "PENDING", Latency: latency, Note: fmt.Sprintf("status=%d", status)}
             }
-            return Result{Status: "FAIL", Latency: latency, Note: fmt.Sprintf("status=%d", resp.StatusCode)}
+            return Result{Status: "FAIL", Latency: latency, Note: fmt.Sprintf("status=%d", status)}
         },
     }
 }
 
+// call sends one request and returns its HTTP status; err is only set when no
+// response came back. Successful responses count as 200.
+func (r *Runner) call(ctx context.Context, method, path string, body any) (int, error) {
+    err := r.api.Do(ctx, method, path, body, nil)
+    if err == nil {
+        return http.StatusOK, nil
+    }
+    if status := arkclient.StatusCode(err); status != 0 {
+        return status, nil
+    }
+    return 0, err
+}
+
 func manualCase(name, note string) TestCase {
     return TestCase{
         Name:  name,
@@ -396,12 +396,11 @@ func manualCase(name, note string) TestCase {
     }
 }
 
-func concurrentAccept(ctx context.Context, r *Runner, url string) Result {
+func concurrentAccept(ctx context.Context, r *Runner, path string) Result {
     payload := map[string]any{
         "driver_id": "d1",
         "order_id":  "o1",
     }
-    b, _ := json.Marshal(payload)
     wg := sync.WaitGroup{}
     succ := 0
     pend := 0
@@ -411,18 +410,14 @@ func concurrentAccept(ctx context.Context, r *Runner, url string) Result {
         wg.Add(1)
         go func(i int) {
             defer wg.Done()
-            req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(string(b)))
-            req.Header.Set("Content-Type", "application/json")
-            resp, err := r.httpc.Do(req)
+            status, err := r.call(ctx, http.MethodPost, path, payload)
             if err != nil {
                 return
             }
-            io.Copy(io.Discard, resp.Body)
-            resp.Body.Close()
             mu.Lock()
-            if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+            if status >= 200 && status < 300 {
                 succ++
-            } else if resp.StatusCode == 501 || resp.StatusCode == 404 {
+            } else if status == 501 || status == 404 {
                 pend++
             }
             mu.Unlock()
@@ -439,8 +434,7 @@ func concurrentAccept(ctx context.Context, r *Runner, url string) Result {
     return Result{Status: "FAIL", Note: fmt.Sprintf("success=%d", succ)}
 }
 
-func perfLoad(ctx context.Context, r *Runner, url string, payload any) Result {
-    b, _ := json.Marshal(payload)
+func perfLoad(ctx context.Context, r *Runner, path string, payload any) Result {
     end := time.Now().Add(r.cfg.Duration)
     var count int64
     var errCount int64
@@ -452,17 +446,12 @@ func perfLoad(ctx context.Context, r *Runner, url string, payload any) Result {
         go func() {
             defer wg.Done()
             for time.Now().Before(end) {
-                req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(string(b)))
-                req.Header.Set("Content-Type", "application/json")
-                resp, err := r.httpc.Do(req)
-                if err != nil {
+                if _, err := r.call(ctx, http.MethodPost, path, payload); err != nil {
                     mu.Lock()
                     errCount++
                     mu.Unlock()
                     continue
                 }
-                io.Copy(io.Discard, resp.Body)
-                resp.Body.Close()
                 mu.Lock()
                 count++
                 mu.Unlock()
@@ -0,0 +1,302 @@
+// driversim plays a driver for one order during demos: it accepts the order if
+// it is still waiting, streams GPS points along the Google driving route from
+// the driver's position to the pickup and on to the dropoff, and calls the
+// arrive, meet and complete endpoints when each leg ends. Positions are written
+// to RTDB driver_locations/<driver> exactly like the driver app, so the
+// passenger map and the tracking stream follow the simulated car.
+//
+// Requests authenticate with -token (a Firebase ID token for the driver, or
+// ARK_DRIVERSIM_TOKEN); leave it empty against a dev server without auth.
+// Routes come from GOOGLE_MAPS_API_KEY, falling back to a straight line. RTDB
+// credentials are read like cmd/seed-firebase; without them positions are only
+// logged.
+//
+//	go run ./cmd/driversim -order <id> -driver <uid> -from 25.04,121.56 \
+//	    -pickup 25.033,121.565 -dropoff 25.047,121.517 -speedup 5
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"firebase.google.com/go/v4/db"
+
+	"ark/internal/config"
+	"ark/internal/infra"
+	"ark/internal/maps"
+	"ark/internal/modules/location"
+	"ark/internal/types"
+	"ark/pkg/arkclient"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := config.LoadDotEnv(""); err != nil {
+		log.Fatalf("load .env: %v", err)
+	}
+
+	var (
+		apiURL   = flag.String("api", "http://localhost:8080", "Ark API base URL")
+		token    = flag.String("token", os.Getenv("ARK_DRIVERSIM_TOKEN"), "driver Firebase ID token (empty for a dev server without auth)")
+		driverID = flag.String("driver", "", "driver UID; names the RTDB driver_locations entry")
+		orderID  = flag.String("order", "", "order to drive (required)")
+		fromStr  = flag.String("from", "", "driver start position lat,lng (required)")
+		pickStr  = flag.String("pickup", "", "order pickup lat,lng (required)")
+		dropStr  = flag.String("dropoff", "", "order dropoff lat,lng (required)")
+		speedKmh = flag.Float64("speed", 30, "average driving speed in km/h")
+		speedup  = flag.Float64("speedup", 1, "time compression factor (5 drives five times faster)")
+		tick     = flag.Duration("tick", time.Second, "interval between GPS points")
+		board    = flag.Duration("board", 10*time.Second, "wait at the pickup before the passenger boards")
+	)
+	fb := config.FirebaseConfig{
+		CredentialsJSON: os.Getenv("FIREBASE_CREDENTIALS_JSON"),
+		CredentialsPath: os.Getenv("ARK_FIREBASE_CREDENTIALS_PATH"),
+		ProjectID:       os.Getenv("ARK_FIREBASE_PROJECT_ID"),
+		DatabaseURL:     os.Getenv("ARK_FIREBASE_RTDB_URL"),
+		RTDBRegion:      os.Getenv("ARK_FIREBASE_RTDB_REGION"),
+	}
+	flag.StringVar(&fb.CredentialsPath, "creds", fb.CredentialsPath, "path to the Firebase service-account key file")
+	flag.Parse()
+	if fb.CredentialsPath != "" {
+		fb.CredentialsJSON = ""
+	}
+
+	if *orderID == "" {
+		log.Fatal("-order is required")
+	}
+	from, err := parsePoint(*fromStr)
+	if err != nil {
+		log.Fatalf("-from: %v", err)
+	}
+	pickup, err := parsePoint(*pickStr)
+	if err != nil {
+		log.Fatalf("-pickup: %v", err)
+	}
+	dropoff, err := parsePoint(*dropStr)
+	if err != nil {
+		log.Fatalf("-dropoff: %v", err)
+	}
+	if *speedKmh <= 0 || *speedup <= 0 || *tick <= 0 {
+		log.Fatal("-speed, -speedup and -tick must be positive")
+	}
+
+	api := arkclient.New(*apiURL)
+	api.SetHTTPClient(&http.Client{Timeout: 10 * time.Second})
+	if *token != "" {
+		api.SetToken(*token)
+	}
+	sim := &simulator{
+		api:       api,
+		driverID:  *driverID,
+		kmPerTick: *speedKmh * *speedup * tick.Hours(),
+		tick:      *tick,
+	}
+
+	if key := os.Getenv("GOOGLE_MAPS_API_KEY"); key != "" {
+		routes, err := maps.NewRouteService(key)
+		if err != nil {
+			log.Fatalf("maps: %v", err)
+		}
+		sim.routes = routes
+	} else {
+		log.Println("GOOGLE_MAPS_API_KEY not set; driving in straight lines")
+	}
+
+	if fb.Enabled() {
+		if *driverID == "" {
+			log.Fatal("-driver is required to write positions to RTDB")
+		}
+		app, err := infra.NewFirebaseApp(ctx, fb)
+		if err != nil {
+			log.Fatalf("firebase init: %v", err)
+		}
+		if sim.rtdb, err = app.Database(ctx); err != nil {
+			log.Fatalf("RTDB client: %v", err)
+		}
+	} else {
+		log.Println("no Firebase credentials; GPS points are only logged")
+	}
+
+	if err := sim.run(ctx, *orderID, from, pickup, dropoff, *board); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Simulation
+// ---------------------------------------------------------------------------
+
+type simulator struct {
+	api       *arkclient.Client
+	routes    *maps.RouteService
+	rtdb      *db.Client
+	driverID  string
+	kmPerTick float64
+	tick      time.Duration
+}
+
+func (s *simulator) run(ctx context.Context, orderID string, from, pickup, dropoff types.Point, board time.Duration) error {
+	st, err := s.api.OrderStatus(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	status := st.Status
+	log.Printf("order %s is %s", orderID, status)
+	if status == arkclient.StatusWaiting {
+		if status, err = s.api.AcceptOrder(ctx, orderID, nil); err != nil {
+			return err
+		}
+		log.Printf("accept → %s", status)
+	}
+	if status != arkclient.StatusApproaching {
+		return fmt.Errorf("order %s is %s; driversim starts from waiting or approaching", orderID, status)
+	}
+
+	if err := s.drive(ctx, "to pickup", from, pickup); err != nil {
+		return err
+	}
+	if err := s.step(ctx, "arrived", s.api.Arrive, orderID); err != nil {
+		return err
+	}
+	log.Printf("arrived; waiting %s for the passenger", board)
+	if err := sleep(ctx, board); err != nil {
+		return err
+	}
+	if err := s.step(ctx, "meet", s.api.Meet, orderID); err != nil {
+		return err
+	}
+	if err := s.drive(ctx, "to dropoff", pickup, dropoff); err != nil {
+		return err
+	}
+	if err := s.step(ctx, "complete", s.api.Complete, orderID); err != nil {
+		return err
+	}
+	log.Printf("order %s completed; awaiting payment", orderID)
+	return nil
+}
+
+// step runs one order transition and logs the resulting status.
+func (s *simulator) step(ctx context.Context, action string, call func(context.Context, string) (string, error), orderID string) error {
+	status, err := call(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	log.Printf("%s → %s", action, status)
+	return nil
+}
+
+// drive streams one GPS point per tick along the route from a to b.
+func (s *simulator) drive(ctx context.Context, leg string, a, b types.Point) error {
+	path := []types.Point{a, b}
+	if s.routes != nil {
+		p, err := s.routes.GetRoutePath(ctx, a, b)
+		if err != nil {
+			log.Printf("route %s: %v; driving in a straight line", leg, err)
+		} else if len(p) > 1 {
+			path = p
+		}
+	}
+	points := samplePath(path, s.kmPerTick)
+	log.Printf("driving %s: %.2f km, %d points", leg, pathKm(path), len(points))
+
+	for i, p := range points {
+		if i > 0 {
+			if err := sleep(ctx, s.tick); err != nil {
+				return err
+			}
+		}
+		s.report(ctx, p)
+	}
+	return nil
+}
+
+// report writes p to RTDB in the shape the driver app uses, or logs it.
+func (s *simulator) report(ctx context.Context, p types.Point) {
+	if s.rtdb == nil {
+		log.Printf("gps %.6f,%.6f", p.Lat, p.Lng)
+		return
+	}
+	err := s.rtdb.NewRef("driver_locations/"+s.driverID).Set(ctx, map[string]interface{}{
+		"lat":       p.Lat,
+		"lng":       p.Lng,
+		"status":    "online",
+		"geohash":   location.Geohash(p.Lat, p.Lng),
+		"timestamp": time.Now().UnixMilli(),
+	})
+	if err != nil {
+		log.Printf("gps write: %v", err)
+	}
+}
+
+// samplePath returns points every stepKm along path, always ending on its last vertex.
+func samplePath(path []types.Point, stepKm float64) []types.Point {
+	out := []types.Point{path[0]}
+	carry := 0.0 // distance travelled since the last emitted point
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		seg := haversineKm(a, b)
+		for pos := stepKm - carry; pos < seg; pos += stepKm {
+			f := pos / seg
+			out = append(out, types.Point{Lat: a.Lat + (b.Lat-a.Lat)*f, Lng: a.Lng + (b.Lng-a.Lng)*f})
+		}
+		carry = math.Mod(carry+seg, stepKm)
+	}
+	if last := path[len(path)-1]; out[len(out)-1] != last {
+		out = append(out, last)
+	}
+	return out
+}
+
+func pathKm(path []types.Point) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += haversineKm(path[i-1], path[i])
+	}
+	return total
+}
+
+func haversineKm(a, b types.Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+func parsePoint(s string) (types.Point, error) {
+	latStr, lngStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return types.Point{}, fmt.Errorf("want lat,lng, got %q", s)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	lng, err2 := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err1 != nil || err2 != nil || math.Abs(lat) > 90 || math.Abs(lng) > 180 {
+		return types.Point{}, fmt.Errorf("invalid coordinates %q", s)
+	}
+	return types.Point{Lat: lat, Lng: lng}, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
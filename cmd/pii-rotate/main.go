@@ -0,0 +1,87 @@
+// pii-rotate re-encrypts phone numbers, FCM device tokens, parcel recipients and
+// saved places with the active PII key and backfills their blind indexes. Run
+// it after adding a new key to PII_ENCRYPTION_KEYS and switching
+// ARK_PII_ACTIVE_KEY to it, or once after the first deploy with encryption
+// enabled to seal legacy plaintext rows. Once it reports zero remaining rows
+// the retired key can be dropped from the keyring.
+//
+// Configuration is read exactly like the API server (config.Load).
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ark/internal/config"
+	"ark/internal/infra"
+	"ark/internal/modules/notification"
+	"ark/internal/modules/order"
+	"ark/internal/modules/place"
+	"ark/internal/modules/user"
+	"ark/internal/pii"
+)
+
+func main() {
+	batch := flag.Int("batch", 500, "rows re-encrypted per batch")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	keyring, err := pii.NewKeyringFromConfig(cfg.PII)
+	if err != nil {
+		log.Fatalf("pii keyring: %v", err)
+	}
+	if keyring == nil {
+		log.Fatal("no PII keys configured: set PII_ENCRYPTION_KEYS, ARK_PII_ACTIVE_KEY and PII_INDEX_KEY")
+	}
+
+	dbPool, err := infra.NewDB(ctx, cfg.DB, cfg.Timeouts.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbPool.Close()
+
+	userStore := user.NewStore(dbPool)
+	userStore.SetKeyring(keyring)
+	notificationStore := notification.NewStore(dbPool)
+	notificationStore.SetKeyring(keyring)
+	orderStore := order.NewStore(dbPool)
+	orderStore.SetKeyring(keyring)
+	placeStore := place.NewStore(dbPool)
+	placeStore.SetKeyring(keyring)
+
+	jobs := []struct {
+		name   string
+		rotate func(context.Context, int) (int, error)
+	}{
+		{"users.phone", userStore.RotatePhones},
+		{"user_fcm_tokens.fcm_token", notificationStore.RotateTokens},
+		{"orders.recipient_*", orderStore.RotateRecipients},
+		{"user_places", placeStore.RotatePlaces},
+	}
+	for _, job := range jobs {
+		total := 0
+		for {
+			n, err := job.rotate(ctx, *batch)
+			if err != nil {
+				log.Fatalf("%s: rotated %d rows before failing: %v", job.name, total, err)
+			}
+			total += n
+			if n == 0 {
+				break
+			}
+			log.Printf("%s: rotated %d rows", job.name, total)
+		}
+		log.Printf("%s: done, %d rows rotated", job.name, total)
+	}
+}
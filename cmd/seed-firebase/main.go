@@ -1,11 +1,15 @@
 // seed-firebase seeds 5 mock drivers near Taipei 101 directly into Firebase
 // RTDB so the app shows live vehicles immediately during development.
 // The frontend (and the RTDB poller) will pick them up automatically.
+//
+// Credentials come from -creds or ARK_FIREBASE_CREDENTIALS_PATH (falling back to
+// FIREBASE_CREDENTIALS_JSON); the RTDB location from ARK_FIREBASE_PROJECT_ID,
+// ARK_FIREBASE_RTDB_URL and ARK_FIREBASE_RTDB_REGION, the same as the API server.
 package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
@@ -14,12 +18,12 @@ import (
 	"syscall"
 	"time"
 
-	firebase "firebase.google.com/go/v4"
-	"google.golang.org/api/option"
+	"ark/internal/config"
+	"ark/internal/infra"
+	"ark/internal/modules/location"
 )
 
 const (
-	credFile    = "zoozoo-v1-firebase-adminsdk-fbsvc-2adb5592ce.json"
 	baseLat     = 25.033964
 	baseLng     = 121.564468
 	offsetRange = 0.02 // ±0.01 degrees ≈ ±1 km
@@ -29,14 +33,31 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	projectID, err := readProjectID(credFile)
-	if err != nil {
-		log.Fatalf("read credentials: %v", err)
+	if err := config.LoadDotEnv(""); err != nil {
+		log.Fatalf("load .env: %v", err)
+	}
+
+	fb := config.FirebaseConfig{
+		CredentialsJSON: os.Getenv("FIREBASE_CREDENTIALS_JSON"),
+		CredentialsPath: os.Getenv("ARK_FIREBASE_CREDENTIALS_PATH"),
+		ProjectID:       os.Getenv("ARK_FIREBASE_PROJECT_ID"),
+		DatabaseURL:     os.Getenv("ARK_FIREBASE_RTDB_URL"),
+		RTDBRegion:      os.Getenv("ARK_FIREBASE_RTDB_REGION"),
+	}
+	flag.StringVar(&fb.CredentialsPath, "creds", fb.CredentialsPath, "path to the Firebase service-account key file")
+	flag.StringVar(&fb.ProjectID, "project", fb.ProjectID, "Firebase project ID (default: project_id from the credentials)")
+	flag.StringVar(&fb.DatabaseURL, "rtdb-url", fb.DatabaseURL, "RTDB URL (default: derived from project and region)")
+	flag.StringVar(&fb.RTDBRegion, "rtdb-region", fb.RTDBRegion, "RTDB region (default "+config.DefaultRTDBRegion+")")
+	flag.Parse()
+	if fb.CredentialsPath != "" {
+		// An explicit key file wins over credentials inherited from the environment.
+		fb.CredentialsJSON = ""
+	}
+	if !fb.Enabled() {
+		log.Fatal("no Firebase credentials: pass -creds or set ARK_FIREBASE_CREDENTIALS_PATH / FIREBASE_CREDENTIALS_JSON")
 	}
 
-	app, err := firebase.NewApp(ctx, &firebase.Config{
-		DatabaseURL: fmt.Sprintf("https://%s-default-rtdb.asia-southeast1.firebasedatabase.app", projectID),
-	}, option.WithCredentialsFile(credFile))
+	app, err := infra.NewFirebaseApp(ctx, fb)
 	if err != nil {
 		log.Fatalf("firebase init: %v", err)
 	}
@@ -58,6 +79,7 @@ func main() {
 			"lat":       lat,
 			"lng":       lng,
 			"status":    "online",
+			"geohash":   location.Geohash(lat, lng),
 			"timestamp": time.Now().UnixMilli(),
 		})
 		if err != nil {
@@ -69,20 +91,3 @@ func main() {
 
 	log.Println("done — the RTDB poller will sync these to Redis within 30 s.")
 }
-
-func readProjectID(path string) (string, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", fmt.Errorf("reading %s: %w", path, err)
-	}
-	var sa struct {
-		ProjectID string `json:"project_id"`
-	}
-	if err := json.Unmarshal(data, &sa); err != nil {
-		return "", fmt.Errorf("parsing %s: %w", path, err)
-	}
-	if sa.ProjectID == "" {
-		return "", fmt.Errorf("project_id is empty in %s", path)
-	}
-	return sa.ProjectID, nil
-}
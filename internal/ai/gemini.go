@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
@@ -12,10 +13,16 @@ import (
 
 // GeminiProvider implements LLMProvider using Google's Gemini models.
 type GeminiProvider struct {
-	client *genai.Client
-	model  *genai.GenerativeModel
+	client  *genai.Client
+	model   *genai.GenerativeModel
+	retry   RetryPolicy
+	prompts *Prompts
+	usage   UsageRecorder
 }
 
+// geminiModel is the model behind the intent parser and the transcriber.
+const geminiModel = "gemini-2.0-flash"
+
 // NewGeminiProvider initializes a new Gemini client.
 // apiKey should be provided from environment variables.
 func NewGeminiProvider(ctx context.Context, apiKey string) (*GeminiProvider, error) {
@@ -25,7 +32,7 @@ func NewGeminiProvider(ctx context.Context, apiKey string) (*GeminiProvider, err
 	}
 
 	// Use Gemini 2.0 Flash for low latency and cost efficiency.
-	model := client.GenerativeModel("gemini-2.0-flash")
+	model := client.GenerativeModel(geminiModel)
 
 	// Force JSON response for structured parsing.
 	model.ResponseMIMEType = "application/json"
@@ -34,35 +41,82 @@ func NewGeminiProvider(ctx context.Context, apiKey string) (*GeminiProvider, err
 	model.SetTemperature(0.4)
 
 	return &GeminiProvider{
-		client: client,
-		model:  model,
+		client:  client,
+		model:   model,
+		retry:   DefaultRetryPolicy,
+		prompts: defaultPrompts(),
 	}, nil
 }
 
+// SetPrompts serves the intent prompt from prompts instead of the embedded
+// templates alone. Must be called before the provider is shared between goroutines.
+func (p *GeminiProvider) SetPrompts(prompts *Prompts) {
+	p.prompts = prompts
+}
+
+// SetRetryPolicy replaces DefaultRetryPolicy for subsequent calls.
+// Must be called before the provider is shared between goroutines.
+func (p *GeminiProvider) SetRetryPolicy(policy RetryPolicy) {
+	p.retry = policy
+}
+
+// SetUsageRecorder records the tokens, cost and outcome of every call.
+// Must be called before the provider is shared between goroutines.
+func (p *GeminiProvider) SetUsageRecorder(r UsageRecorder) {
+	p.usage = r
+}
+
 // Close cleans up the Gemini client resources.
 func (p *GeminiProvider) Close() {
 	p.client.Close()
 }
 
+// Ping checks the API key and model by counting the tokens of a short text,
+// which is not billed.
+func (p *GeminiProvider) Ping(ctx context.Context) error {
+	if _, err := p.model.CountTokens(ctx, genai.Text("ping")); err != nil {
+		return fmt.Errorf("gemini ping: %w", err)
+	}
+	return nil
+}
+
 // ParseUserIntent analyzes user input to extract ride-hailing intent.
 func (p *GeminiProvider) ParseUserIntent(ctx context.Context, userMessage string, currentContext map[string]string) (*IntentResult, error) {
-	// Construct a powerful system prompt with context injection.
-	systemPrompt := buildSystemPrompt(currentContext)
-
-	// Combine system prompt and user message.
-	// Note: While Gemini supports SystemInstruction, appending context directly to the prompt
-	// is often more flexible for dynamic context injection per request.
-	// We'll use a combined prompt approach here for clarity and context binding.
-
-	fullPrompt := fmt.Sprintf("%s\n\nUser Message: %s", systemPrompt, userMessage)
+	version, prompt, err := p.prompts.Render(PromptIntent, intentPromptVars(userMessage, currentContext))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := p.generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	result, err := parseIntentResponse(raw)
+	if err != nil {
+		recordOutcome(PromptIntent, version, "")
+		return nil, err
+	}
+	recordOutcome(PromptIntent, version, result.Intent)
+	return result, nil
+}
 
-	resp, err := p.model.GenerateContent(ctx, genai.Text(fullPrompt))
+// generate sends prompt and returns the model's raw text.
+func (p *GeminiProvider) generate(ctx context.Context, prompt string) (string, error) {
+	// Timeouts, 5xx and 429 responses are retried with backoff; once retries
+	// run out the error wraps ErrUnavailable or ErrQuotaExceeded.
+	var resp *genai.GenerateContentResponse
+	start := time.Now()
+	err := p.retry.Do(ctx, "gemini", func(ctx context.Context) error {
+		var err error
+		resp, err = p.model.GenerateContent(ctx, genai.Text(prompt))
+		return err
+	})
+	RecordUsage(ctx, p.usage, GeminiUsage(ctx, geminiModel, "intent", start, resp, err))
 	if err != nil {
-		return nil, fmt.Errorf("gemini generation error: %w", err)
+		return "", fmt.Errorf("gemini generation error: %w", err)
 	}
 
 	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
-		return nil, fmt.Errorf("no response candidates from Gemini")
+		return "", fmt.Errorf("no response candidates from Gemini")
 	}
 
 	// Extract text from the response parts.
@@ -72,9 +126,13 @@ func (p *GeminiProvider) ParseUserIntent(ctx context.Context, userMessage string
 			responseText.WriteString(string(txt))
 		}
 	}
+	return responseText.String(), nil
+}
 
+// parseIntentResponse decodes the model's raw text into an IntentResult.
+func parseIntentResponse(raw string) (*IntentResult, error) {
 	// Clean up potential markdown formatting (though json mode should handle this, safety first).
-	cleanJSON := cleanJSONString(responseText.String())
+	cleanJSON := cleanJSONString(raw)
 
 	var result IntentResult
 	if err := json.Unmarshal([]byte(cleanJSON), &result); err != nil {
@@ -89,178 +147,35 @@ func (p *GeminiProvider) PlanItinerary(ctx context.Context, constraints string)
 	return "", fmt.Errorf("not implemented yet")
 }
 
-// buildSystemPrompt constructs the instructions for the AI.
-func buildSystemPrompt(ctxMap map[string]string) string {
-	currentTime := ctxMap["current_time"]
-	userLocation := ctxMap["user_location"]
-	userContextInfo := ctxMap["user_context_info"]
+// IntentPromptVars are the variables available to the intent prompt templates.
+type IntentPromptVars struct {
+	CurrentTime     string
+	UserLocation    string
+	UserContextInfo string
+	// ReplyLanguage names the language the reply must be written in.
+	ReplyLanguage string
+	UserMessage   string
+}
 
-	if currentTime == "" {
-		currentTime = "UNKNOWN_TIME"
+// intentPromptVars fills the template variables from the caller's context map.
+func intentPromptVars(userMessage string, ctxMap map[string]string) IntentPromptVars {
+	vars := IntentPromptVars{
+		CurrentTime:     ctxMap["current_time"],
+		UserLocation:    ctxMap["user_location"],
+		UserContextInfo: ctxMap["user_context_info"],
+		ReplyLanguage:   languageName(ctxMap["reply_language"]),
+		UserMessage:     userMessage,
 	}
-	if userLocation == "" {
-		userLocation = "UNKNOWN_LOCATION"
+	if vars.CurrentTime == "" {
+		vars.CurrentTime = "UNKNOWN_TIME"
 	}
-	if userContextInfo == "" {
-		userContextInfo = "NONE"
+	if vars.UserLocation == "" {
+		vars.UserLocation = "UNKNOWN_LOCATION"
 	}
-
-	return fmt.Sprintf(`Role: You are the intelligent dispatch core for "ZooZoo", a ride-hailing app in Taiwan.
-Context: 
-- Current System Time: %s
-- User Location: %s
-- Personal Context: %s
-
-STRICT DECISION GATE (MUST READ):
-You MUST NOT set "intent": "booking" unless ALL FOUR conditions are met:
-1. [ ] Destination is CLEAR.
-2. [ ] Origin is CONFIRMED (explicitly stated OR user confirmed suggestion).
-3. [ ] Time Type is CLEAR ("Arrival" vs "Pickup" MUST be known. AM/PM alone is NOT enough).
-4. [ ] Time is AM/PM SPECIFIC (e.g., "Morning 9:00", "Afternoon 3:00", "21:00").
-
-RULES:
-
-1. SMART ORIGIN SUGGESTION (Context Awareness):
-   - CHECK the "Target Time" of the trip (or Current Time if immediate).
-   - **Rule A (Commute):** IF Target Time is Weekday (Mon-Fri) & 17:30-19:30 -> Suggest "Company" (extract from Personal Context).
-   - **Rule B (Future/Morning):** IF Target Time is > 5 hours from now OR Tomorrow/Future -> Suggest "Home".
-   - **Rule C (Immediate):** IF Target Time is soon (< 5 hours) -> Suggest "Current Location" (or ask generally).
-   - **Constraint:** Only suggest if the location exists in "Personal Context". Otherwise, ask "Where from?".
-
-2. LOCATION LOGIC (PRESERVE CONTEXT):
-   - KEYWORDS "從", "From", "Start", "Leave" -> Implies "start_location".
-   - KEYWORDS "去", "To", "Arrive", "到" -> Implies "destination".
-   - **CRITICAL**: If the user provides a "Star Location" (e.g., "From Home"), and a Destination was already mentioned/known, YOU MUST PRESERVE the Destination. Do NOT overwrite Destination with the Start Location.
-   - User says "Home"/"Company" -> EXTRACT address from Personal Context to "start_location".
-
-3. SMART TIME INTENT (Populate fields, but DO NOT bypass Gate):
-   - Keywords "到", "抵達", "Arrive" -> Implies "arrival_time".
-   - Keywords "出發", "走", "Depart" -> Implies "pickup_time".
-   - Keywords "早上", "上午", "AM" -> Implies morning.
-   - Keywords "晚上", "下午", "PM" -> Implies afternoon/evening.
-
-4. AM/PM & TIME TYPE CHECK:
-   - IF user says "9點" (Ambiguous): Ask for AM/PM AND Arrival/Departure.
-   - IF user says "晚上9點" but not "Arrival/Departure": You MUST ask "請問是晚上9點出發，還是抵達？"
-
-5. PAST TIME AUTO-CORRECTION (CRITICAL):
-   - Compare the user's requested time with "Current System Time".
-   - IF user requests a time that is EARLIER than "Current System Time" TODAY:
-     - The time has already passed. Set "intent": "clarification".
-     - Calculate tomorrow's date from the context.
-     - Set "reply" to: "由於現在時間已晚，請問您是指 **明天 (M/DD)** [TIME_PERIOD][HH:MM] 抵達 [DESTINATION] 嗎？"
-       - Replace M/DD with tomorrow's month/day (e.g., 2/17).
-       - Replace [TIME_PERIOD] with 早上/下午/晚上 as appropriate.
-       - Replace [HH:MM] with the requested time.
-       - Replace [DESTINATION] with the destination.
-     - Set "iso_time" to the NEXT DAY's datetime in RFC3339, NOT today's.
-   - IF user confirms the "Tomorrow" suggestion, proceed normally with the corrected date.
-
-6. LOCATION & CONTEXT:
-   - IF Origin is missing AND Current Location is UNKNOWN -> Set "needs_origin": true.
-   - Suggest locations from "Personal Context" if available.
-
-7. SEARCH INTENT (V2):
-   - IF user mentions a secondary task (e.g., "買花", "get coffee"):
-     - **ORIGIN PRECONDITION (MANDATORY):** IF the origin/start_location is NOT yet confirmed in context:
-       - Set "intent": "clarification", "needs_search": false.
-       - Set "reply" to NATURALLY ask for origin FIRST:
-         E.g., "收到您的買花需求！請問您預計從哪裡出發，以便為您尋找順路的花店？"
-       - NEVER set "needs_search": true until origin is confirmed.
-     - ELSE (origin is known):
-       - Set "needs_search": true.
-       - Set "search_category": Translate to SPECIFIC PRECISE TERMS (English preferred for Places API).
-         - E.g., "買花" -> "florist" (Avoid "花" which matches "豆花").
-         - E.g., "買咖啡" -> "coffee shop".
-       - Set "search_keywords": Any POSITIVE refinement the user specifies.
-         - E.g., user says "要買鮮花" -> "search_keywords": "鮮花"
-         - Leave null if no refinement specified.
-       - Set "exclude_keywords": Terms the user explicitly wants to avoid.
-         - **FLORIST DEFAULT (CRITICAL):** When search_category is "florist" and user has NOT mentioned specific flower types,
-           you MUST automatically set: "exclude_keywords": ["乾燥花", "永生花", "人造花", "香皂花", "塑膠花"]
-         - If the user explicitly requests one of the above (e.g., "我要永生花"), REMOVE it from exclude_keywords.
-         - Leave empty [] only for NON-florist searches.
-       - On a REFINEMENT turn (user says "不要那間" or adds conditions to prior search):
-         - Keep "needs_search": true, update keywords accordingly. PRESERVE all other context fields.
-
-8. INTERMEDIATE STOP SELECTION & STATE PRESERVATION (CRITICAL):
-   - IF user selects an option from a provided list (e.g., "好", "第一個", "就那間", "confirm", a shop name):
-     - This is a CONFIRMATION turn. You MUST preserve ALL prior booking state.
-     - Set "intent": "booking".
-     - Set "intermediate_stop": The full name of the selected place (from the list in context).
-     - Set "needs_search": false.
-     - **MANDATORY FIELD CARRY-FORWARD** — Read from conversation context and copy EXACTLY:
-       - "destination": preserve the destination from context (DO NOT set to null).
-       - "start_location": preserve origin from context.
-       - "iso_time": preserve the exact RFC3339 timestamp from context (DO NOT lose this).
-       - "time_type": preserve "arrival_time" or "pickup_time" from context.
-     - If ANY of the above cannot be found in context, set "intent": "clarification" and ask.
-     - NEVER reset iso_time to null on a confirmation turn.
-
-9. STRICT BOOKING GATES (CRITICAL):
-   - BEFORE setting "intent": "booking", YOU MUST HAVE:
-     1. SPECIFIC DESTINATION:
-        - "HSR" (High Speed Rail) is INVALID. Ask "Taipei HSR or Nangang HSR?".
-        - "Train Station" is INVALID. Ask "Which station?".
-     2. SPECIFIC TIME:
-        - "9:00" is AMBIGUOUS. Ask "Morning or Evening?" (unless context implies it).
-     3. CONFIRMED ORIGIN.
-   - If ANY are missing, set "intent": "clarification" and ASK.
-
-10. SEQUENTIAL CLARIFICATION (The Gatekeeper):
-   - IF ANY field is missing (Destination, Origin, etc.) -> Ask for it.
-   - Bundle questions naturally.
-
-11. RESPONSE FORMAT & ABSOLUTE CONTENT RULES:
-   ⛔ ABSOLUTE BAN: The "reply" field MUST NEVER contain any of these internal state codes:
-      SEARCHING, BOOKING_INITIALIZED, COMPLETED, CLARIFICATION, or ANY ALL-CAPS system token.
-   ✅ Instead, use natural, conversational Traditional Chinese (台灣繁體中文口語):
-      - When processing a search: reply with something like "正在尋找順路的花店，請稍候..."
-      - When booking is initiated: reply with something like "行程已確認，多一根建立成功！"
-      - When clarifying: ask naturally in conversational Mandarin.
-      - When completed: use a warm farewell.
-   - DO NOT use markdown bolding IN THE reply FIELD.
-
-12. PASSENGER & PET DETECTION (Scan ALL conversation history):
-   - "passenger_count": Extract number of passengers from ANY turn in the conversation.
-     - Trigger phrases: "我們X個人", "X位", "一行X人", "X people", "X passengers".
-     - Default: 1 if never mentioned. PERSIST across turns.
-   - "has_pet": Set true if ANY mention of pet in conversation.
-     - Trigger phrases: "帶狗", "帶貓", "寵物", "毛子", "小狗", "pet", "dog", "cat".
-     - Default: false. PERSIST: once true, never reset to false.
-
-13. UPSELL RESPONSE & COMPLETED STATE (CRITICAL):
-   - CONTEXT: The system has already sent a booking confirmation and asked the user about vehicle UPGRADE.
-   - IF the conversation history shows "ZooZoo" already asked an upsell question AND user is now responding:
-     - Identify this as a COMPLETED turn.
-     - Set "intent": "completed".
-     - Determine what the user's response means:
-       A. User DECLINES upgrade (e.g., "不用", "不要", "普通就好", "no"):
-          - Set "selected_upgrade": "" (empty string).
-       B. User ACCEPTS or names a vehicle (e.g., "好", "要豪車", "豪華速速", "寵物專車"):
-          - Set "selected_upgrade": the car name (e.g., "豪華速速" or "寵物專車").
-     - NEVER set intent to "booking" or "clarification" on a completed upsell turn.
-     - PRESERVE all context fields as usual.
-
-14. Output JSON Schema:
-{
-  "intent": "booking" | "clarification" | "chat" | "completed",
-  "destination": "string or null",
-  "start_location": "string (default: 'Current Location')",
-  "needs_origin": boolean,
-  "needs_search": boolean,
-  "search_category": "string or null",
-  "search_keywords": "string or null",
-  "exclude_keywords": ["string"],
-  "intermediate_stop": "string or null",
-  "time_type": "arrival_time" | "pickup_time" | null,
-  "iso_time": "YYYY-MM-DDTHH:mm:ssZ07:00 (RFC3339 with Offset)" | null,
-  "passenger_count": integer (default 1),
-  "has_pet": boolean (default false),
-  "selected_upgrade": "string (car type chosen by user, empty = declined)",
-  "reply": "string (User facing response)"
-}
-`, currentTime, userLocation, userContextInfo)
+	if vars.UserContextInfo == "" {
+		vars.UserContextInfo = "NONE"
+	}
+	return vars
 }
 
 // cleanJSONString removes markdown code blocks if present (e.g. ```json ... ```)
@@ -0,0 +1,182 @@
+package ai
+
+// Intent regression suite. Each file in testdata/intents holds one user
+// message with its prompt context, the model's recorded raw response, and the
+// IntentResult fields that must come out of it:
+//
+//	{
+//	  "message": "明天早上9點要到桃園機場，從家裡出發",
+//	  "context": {"current_time": "2026-03-09T18:00:00+08:00", ...},
+//	  "expect": {"intent": "booking", "iso_time": "2026-03-10T09:00:00+08:00"},
+//	  "reply_contains": ["明天"],
+//	  "response": "{\"intent\": \"booking\", ...}"
+//	}
+//
+// Only the fields listed in expect are checked, so the reply wording may
+// change freely. By default the recorded responses are parsed offline. To
+// check a prompt change against the real model:
+//
+//	GEMINI_API_KEY=... go test ./internal/ai -run TestIntentRegression -live
+//
+// and add -record to overwrite the recorded responses with the live ones.
+// -prompt picks the prompt version to test (default: the latest embedded);
+// the live run logs the share of fixtures passing as that version's accuracy.
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	liveIntents   = flag.Bool("live", false, "run the intent regression suite against Gemini (needs GEMINI_API_KEY)")
+	recordIntents = flag.Bool("record", false, "with -live, overwrite the recorded responses in testdata/intents")
+	promptVersion = flag.String("prompt", "", "with -live, the intent prompt version to test (default: latest embedded)")
+)
+
+type intentFixture struct {
+	Message       string            `json:"message"`
+	Context       map[string]string `json:"context"`
+	Expect        map[string]any    `json:"expect"`
+	ReplyContains []string          `json:"reply_contains,omitempty"`
+	Response      string            `json:"response"`
+}
+
+// internalTokens must never reach the user-facing reply.
+var internalTokens = []string{"SEARCHING", "BOOKING_INITIALIZED", "COMPLETED", "CLARIFICATION"}
+
+func TestIntentRegression(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "intents", "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no intent fixtures: %v", err)
+	}
+
+	var provider *GeminiProvider
+	version := *promptVersion
+	if version == "" {
+		version = defaultPrompts().Active(PromptIntent)
+	}
+	if *liveIntents {
+		key := os.Getenv("GEMINI_API_KEY")
+		if key == "" {
+			t.Fatal("-live needs GEMINI_API_KEY")
+		}
+		provider, err = NewGeminiProvider(context.Background(), key)
+		if err != nil {
+			t.Fatalf("NewGeminiProvider: %v", err)
+		}
+		defer provider.Close()
+	}
+
+	passed := 0
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		ok := t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fx intentFixture
+			if err := json.Unmarshal(data, &fx); err != nil {
+				t.Fatalf("decode fixture: %v", err)
+			}
+
+			raw := fx.Response
+			if provider != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+				prompt, err := defaultPrompts().RenderVersion(PromptIntent, version, intentPromptVars(fx.Message, fx.Context))
+				if err != nil {
+					t.Fatalf("render prompt: %v", err)
+				}
+				if raw, err = provider.generate(ctx, prompt); err != nil {
+					t.Fatalf("live call: %v", err)
+				}
+				if *recordIntents {
+					fx.Response = raw
+					writeFixture(t, path, &fx)
+				}
+			}
+
+			got, err := parseIntentResponse(raw)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			checkIntent(t, &fx, got)
+		})
+		if ok {
+			passed++
+		}
+	}
+	if *liveIntents {
+		t.Logf("prompt %s.%s: %d/%d fixtures pass (%.0f%%)", PromptIntent, version, passed, len(paths), 100*float64(passed)/float64(len(paths)))
+	}
+}
+
+// checkIntent compares the fields named in fx.Expect against got. A field
+// the model omitted matches an expected zero value.
+func checkIntent(t *testing.T, fx *intentFixture, got *IntentResult) {
+	t.Helper()
+	b, _ := json.Marshal(got)
+	var fields map[string]any
+	_ = json.Unmarshal(b, &fields)
+
+	for key, want := range fx.Expect {
+		have, ok := fields[key]
+		if !ok {
+			if want == nil || reflect.ValueOf(want).IsZero() {
+				continue
+			}
+		}
+		if !sameField(want, have) {
+			t.Errorf("%s = %v, want %v", key, have, want)
+		}
+	}
+	for _, s := range fx.ReplyContains {
+		if !strings.Contains(got.Reply, s) {
+			t.Errorf("reply %q does not contain %q", got.Reply, s)
+		}
+	}
+	for _, tok := range internalTokens {
+		if strings.Contains(got.Reply, tok) {
+			t.Errorf("reply %q leaks internal token %s", got.Reply, tok)
+		}
+	}
+}
+
+// sameField compares decoded JSON values; lists compare as sets since the
+// model does not keep their order stable.
+func sameField(want, have any) bool {
+	wl, ok1 := want.([]any)
+	hl, ok2 := have.([]any)
+	if !ok1 || !ok2 {
+		return reflect.DeepEqual(want, have)
+	}
+	if len(wl) != len(hl) {
+		return false
+	}
+	for _, w := range wl {
+		if !slices.ContainsFunc(hl, func(h any) bool { return reflect.DeepEqual(w, h) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeFixture(t *testing.T, path string, fx *intentFixture) {
+	t.Helper()
+	b, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
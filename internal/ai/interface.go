@@ -8,10 +8,18 @@ import (
 // This interface allows for swapping different AI providers (Gemini, OpenAI, etc.) in the future.
 type LLMProvider interface {
 	// ParseUserIntent analyzes the user's natural language input and extracts structured intent.
-	// contextMap contains dynamic information like "current_time", "user_location", etc.
+	// contextMap contains dynamic information like "current_time", "user_location",
+	// and "reply_language" (one of the Language constants), etc.
 	ParseUserIntent(ctx context.Context, userMessage string, currentContext map[string]string) (*IntentResult, error)
 
 	// PlanItinerary is a placeholder for V2 advanced route planning features.
 	// It takes constraints and returns a suggested itinerary.
 	PlanItinerary(ctx context.Context, constraints string) (string, error)
 }
+
+// Transcriber turns recorded speech into text, so voice input can go through
+// the same pipeline as typed messages.
+type Transcriber interface {
+	// Transcribe returns what was said in audio, or ErrNoSpeech.
+	Transcribe(ctx context.Context, audio Audio) (string, error)
+}
@@ -0,0 +1,56 @@
+package ai
+
+import (
+	"unicode"
+)
+
+// Reply languages the assistant detects and answers in.
+const (
+	LanguageZhTW = "zh-TW"
+	LanguageEn   = "en"
+	LanguageJa   = "ja"
+
+	// DefaultLanguage is used until a user's language is known.
+	DefaultLanguage = LanguageZhTW
+)
+
+// languageNames is how each language is named to the model.
+var languageNames = map[string]string{
+	LanguageZhTW: "Traditional Chinese as used in Taiwan (zh-TW)",
+	LanguageEn:   "English (en)",
+	LanguageJa:   "Japanese (ja)",
+}
+
+// DetectLanguage guesses the language of a user message: any kana makes it
+// Japanese; otherwise Han characters, which carry about a word each, are
+// weighed against Latin letters. It returns "" when the text has too little
+// of either to tell, e.g. a bare time or address number.
+func DetectLanguage(text string) string {
+	var han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			return LanguageJa
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	switch {
+	case han > 0 && han*3 >= latin:
+		return LanguageZhTW
+	case latin >= 2:
+		return LanguageEn
+	}
+	return ""
+}
+
+// languageName returns how lang is named in the prompt, falling back to the
+// default language for unknown values.
+func languageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	return languageNames[DefaultLanguage]
+}
@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"明天早上9點到桃園機場":                    LanguageZhTW,
+		"我要去Taipei 101":                  LanguageZhTW,
+		"Take me to Taipei Main Station": LanguageEn,
+		"Take me to 台北101":               LanguageEn,
+		"明日の朝9時に桃園空港まで":                  LanguageJa,
+		"タクシーを呼んで":                       LanguageJa,
+		"9:30":                           "",
+		"":                               "",
+	}
+	for in, want := range cases {
+		if got := DetectLanguage(in); got != want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIntentPrompt_ReplyLanguage(t *testing.T) {
+	p := defaultPrompts()
+	_, text, err := p.Render(PromptIntent, intentPromptVars("Take me to the airport", map[string]string{"reply_language": LanguageEn}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "Reply Language: English (en)") {
+		t.Errorf("active intent prompt does not name the reply language:\n%s", text)
+	}
+	if got := intentPromptVars("hi", map[string]string{"reply_language": "fr"}).ReplyLanguage; got != languageName(DefaultLanguage) {
+		t.Errorf("unknown language: ReplyLanguage = %q, want the default", got)
+	}
+}
@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PGPromptStore is the PostgreSQL implementation of PromptStore.
+type PGPromptStore struct {
+	db *pgxpool.Pool
+}
+
+// NewPromptStore creates a PGPromptStore backed by the given connection pool.
+func NewPromptStore(db *pgxpool.Pool) *PGPromptStore {
+	return &PGPromptStore{db: db}
+}
+
+func (s *PGPromptStore) ActivePrompts(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.Query(ctx, `SELECT name, version FROM ai_prompt_versions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var name, version string
+		if err := rows.Scan(&name, &version); err != nil {
+			return nil, err
+		}
+		out[name] = version
+	}
+	return out, rows.Err()
+}
+
+func (s *PGPromptStore) SetActivePrompt(ctx context.Context, name, version string, at time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO ai_prompt_versions (name, version, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET version = EXCLUDED.version, updated_at = EXCLUDED.updated_at`,
+		name, version, at,
+	)
+	return err
+}
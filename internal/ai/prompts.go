@@ -0,0 +1,304 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"expvar"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Prompt names.
+const (
+	// PromptIntent is the ride-hailing intent extraction prompt used by
+	// ParseUserIntent.
+	PromptIntent = "intent"
+)
+
+//go:embed prompts/*.tmpl
+var promptFS embed.FS
+
+var (
+	// ErrUnknownPrompt is returned for a prompt name or version that was
+	// never loaded.
+	ErrUnknownPrompt = errors.New("unknown prompt version")
+)
+
+// promptOutcomes counts model outcomes per prompt version, keyed
+// "<name>.<version>.<intent>" plus "<name>.<version>.parse_error" for
+// responses that did not decode. Exposed on /api/ops/debug/vars.
+var promptOutcomes = expvar.NewMap("ai_prompt_outcomes")
+
+// PromptStore persists which version of each prompt is active, so a switch
+// made on one instance reaches the others.
+type PromptStore interface {
+	ActivePrompts(ctx context.Context) (map[string]string, error)
+	SetActivePrompt(ctx context.Context, name, version string, at time.Time) error
+}
+
+// Prompts holds every loaded version of each prompt template and the version
+// currently served. Templates are files named <name>.<version>.tmpl: the
+// ones embedded in the binary plus any found in an optional directory, so a
+// new version can be dropped in and activated, and a bad one rolled back,
+// without a deploy.
+type Prompts struct {
+	templates map[string]map[string]*template.Template // name -> version -> template
+	defaults  map[string]string                        // latest embedded version per name
+	store     PromptStore
+
+	mu     sync.RWMutex
+	active map[string]string
+}
+
+// NewPrompts loads the embedded templates and, when dir is not empty, the
+// templates in dir. A file in dir replaces an embedded one of the same
+// name and version. Each prompt starts on its latest embedded version.
+func NewPrompts(dir string) (*Prompts, error) {
+	p := &Prompts{
+		templates: make(map[string]map[string]*template.Template),
+		defaults:  make(map[string]string),
+		active:    make(map[string]string),
+	}
+	if err := p.load(promptFS, "prompts"); err != nil {
+		return nil, err
+	}
+	for name, versions := range p.templates {
+		p.defaults[name] = latestVersion(versions)
+	}
+	if dir != "" {
+		if err := p.load(os.DirFS(dir), "."); err != nil {
+			return nil, fmt.Errorf("prompt dir %s: %w", dir, err)
+		}
+	}
+	for name, v := range p.defaults {
+		p.active[name] = v
+	}
+	return p, nil
+}
+
+// defaultPrompts is used by providers that were not given a Prompts.
+var defaultPrompts = sync.OnceValue(func() *Prompts {
+	p, err := NewPrompts("")
+	if err != nil {
+		panic(err) // embedded templates are checked by the tests
+	}
+	return p
+})
+
+func (p *Prompts) load(fsys fs.FS, dir string) error {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	for _, file := range paths {
+		base := path.Base(file)
+		name, version, ok := strings.Cut(strings.TrimSuffix(base, ".tmpl"), ".")
+		if !ok || name == "" || version == "" {
+			return fmt.Errorf("%s: want <name>.<version>.tmpl", file)
+		}
+		t, err := template.New(base).Option("missingkey=error").ParseFS(fsys, file)
+		if err != nil {
+			return err
+		}
+		if p.templates[name] == nil {
+			p.templates[name] = make(map[string]*template.Template)
+		}
+		p.templates[name][version] = t
+	}
+	return nil
+}
+
+// SetStore makes Activate persist its choice and Refresh read it back.
+// Must be called before the Prompts is shared between goroutines.
+func (p *Prompts) SetStore(store PromptStore) {
+	p.store = store
+}
+
+// Versions lists the loaded versions of name, oldest first.
+func (p *Prompts) Versions(name string) []string {
+	out := make([]string, 0, len(p.templates[name]))
+	for v := range p.templates[name] {
+		out = append(out, v)
+	}
+	slices.SortFunc(out, compareVersions)
+	return out
+}
+
+// Names lists the loaded prompt names.
+func (p *Prompts) Names() []string {
+	out := make([]string, 0, len(p.templates))
+	for name := range p.templates {
+		out = append(out, name)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Active returns the version of name currently served.
+func (p *Prompts) Active(name string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.active[name]
+}
+
+// Activate serves version of name from now on and persists the choice.
+func (p *Prompts) Activate(ctx context.Context, name, version string) error {
+	if p.templates[name][version] == nil {
+		return ErrUnknownPrompt
+	}
+	if p.store != nil {
+		if err := p.store.SetActivePrompt(ctx, name, version, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+	p.mu.Lock()
+	p.active[name] = version
+	p.mu.Unlock()
+	log.Printf("ai: prompt %s now serving %s", name, version)
+	return nil
+}
+
+// Refresh adopts the active versions persisted in the store. A persisted
+// version this instance has not loaded is skipped with a log line.
+func (p *Prompts) Refresh(ctx context.Context) error {
+	if p.store == nil {
+		return nil
+	}
+	persisted, err := p.store.ActivePrompts(ctx)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, version := range persisted {
+		if p.templates[name][version] == nil {
+			log.Printf("ai: prompt %s version %s is active but not loaded here; keeping %s", name, version, p.active[name])
+			continue
+		}
+		p.active[name] = version
+	}
+	return nil
+}
+
+// RunRefresh calls Refresh every interval until ctx is done.
+func (p *Prompts) RunRefresh(ctx context.Context, interval time.Duration) {
+	if err := p.Refresh(ctx); err != nil {
+		log.Printf("ai: refresh prompts: %v", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Refresh(ctx); err != nil {
+				log.Printf("ai: refresh prompts: %v", err)
+			}
+		}
+	}
+}
+
+// Render executes the active version of name with vars and returns the
+// version used alongside the text.
+func (p *Prompts) Render(name string, vars any) (string, string, error) {
+	version := p.Active(name)
+	text, err := p.RenderVersion(name, version, vars)
+	return version, text, err
+}
+
+// RenderVersion executes a specific version of name with vars.
+func (p *Prompts) RenderVersion(name, version string, vars any) (string, error) {
+	t := p.templates[name][version]
+	if t == nil {
+		return "", ErrUnknownPrompt
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("render prompt %s.%s: %w", name, version, err)
+	}
+	return buf.String(), nil
+}
+
+// PromptStats summarises the outcomes of one prompt version since start-up.
+type PromptStats struct {
+	Responses int64 `json:"responses"`
+	// ClarificationRate is the share of responses asking the user a question.
+	ClarificationRate float64 `json:"clarification_rate"`
+	// ParseErrorRate is the share of responses that were not valid JSON.
+	ParseErrorRate float64 `json:"parse_error_rate"`
+	// Intents counts responses per intent.
+	Intents map[string]int64 `json:"intents"`
+}
+
+// Stats returns this instance's outcome counts for version of name.
+func (p *Prompts) Stats(name, version string) PromptStats {
+	st := PromptStats{Intents: make(map[string]int64)}
+	prefix := name + "." + version + "."
+	var parseErrors int64
+	promptOutcomes.Do(func(kv expvar.KeyValue) {
+		outcome, ok := strings.CutPrefix(kv.Key, prefix)
+		if !ok {
+			return
+		}
+		n := kv.Value.(*expvar.Int).Value()
+		st.Responses += n
+		if outcome == "parse_error" {
+			parseErrors = n
+			return
+		}
+		st.Intents[outcome] = n
+	})
+	if st.Responses > 0 {
+		st.ClarificationRate = float64(st.Intents["clarification"]) / float64(st.Responses)
+		st.ParseErrorRate = float64(parseErrors) / float64(st.Responses)
+	}
+	return st
+}
+
+// recordOutcome counts one response to version of name; intent is empty
+// when the response did not decode.
+func recordOutcome(name, version, intent string) {
+	if intent == "" {
+		intent = "parse_error"
+	}
+	promptOutcomes.Add(name+"."+version+"."+intent, 1)
+}
+
+// latestVersion returns the highest of versions by compareVersions.
+func latestVersion(versions map[string]*template.Template) string {
+	var latest string
+	for v := range versions {
+		if latest == "" || compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// compareVersions orders "v2" before "v10"; versions without a numeric
+// suffix sort by name after numbered ones.
+func compareVersions(a, b string) int {
+	na, errA := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	nb, errB := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	switch {
+	case errA == nil && errB == nil:
+		return na - nb
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
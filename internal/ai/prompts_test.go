@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakePromptStore struct {
+	active map[string]string
+}
+
+func (f *fakePromptStore) ActivePrompts(context.Context) (map[string]string, error) {
+	out := make(map[string]string, len(f.active))
+	for k, v := range f.active {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (f *fakePromptStore) SetActivePrompt(_ context.Context, name, version string, _ time.Time) error {
+	f.active[name] = version
+	return nil
+}
+
+func TestEmbeddedPromptsRender(t *testing.T) {
+	p := defaultPrompts()
+	vars := intentPromptVars("明天早上9點到桃園機場", map[string]string{"current_time": "2026-03-09T18:00:00+08:00"})
+	for _, name := range p.Names() {
+		for _, v := range p.Versions(name) {
+			text, err := p.RenderVersion(name, v, vars)
+			if err != nil {
+				t.Fatalf("%s.%s: %v", name, v, err)
+			}
+			if name == PromptIntent && (!strings.Contains(text, "2026-03-09T18:00:00+08:00") || !strings.Contains(text, "UNKNOWN_LOCATION") || !strings.HasSuffix(text, "User Message: 明天早上9點到桃園機場\n")) {
+				t.Errorf("%s.%s did not inject the variables:\n%s", name, v, text)
+			}
+		}
+	}
+	if p.Active(PromptIntent) == "" {
+		t.Error("intent prompt has no active version")
+	}
+}
+
+func TestPrompts_ActivateAndRollBack(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "intent.v99.tmpl"), []byte("v99 {{.UserMessage}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPrompts(dir)
+	if err != nil {
+		t.Fatalf("NewPrompts: %v", err)
+	}
+	embedded := p.Active(PromptIntent)
+	if embedded == "v99" || !slices.Contains(p.Versions(PromptIntent), "v99") {
+		t.Fatalf("active %s, versions %v: want v99 loaded but not served", embedded, p.Versions(PromptIntent))
+	}
+
+	store := &fakePromptStore{active: map[string]string{}}
+	p.SetStore(store)
+	ctx := context.Background()
+	if err := p.Activate(ctx, PromptIntent, "v100"); !errors.Is(err, ErrUnknownPrompt) {
+		t.Errorf("unknown version: got %v, want ErrUnknownPrompt", err)
+	}
+	if err := p.Activate(ctx, PromptIntent, "v99"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	version, text, err := p.Render(PromptIntent, IntentPromptVars{UserMessage: "hi"})
+	if err != nil || version != "v99" || text != "v99 hi" || store.active[PromptIntent] != "v99" {
+		t.Fatalf("Render = %s %q %v, store %v; want v99 served and persisted", version, text, err, store.active)
+	}
+
+	// Another instance without the file keeps its version; one with it follows.
+	other, _ := NewPrompts("")
+	other.SetStore(store)
+	if err := other.Refresh(ctx); err != nil || other.Active(PromptIntent) != embedded {
+		t.Errorf("instance without v99: active %s, %v; want %s", other.Active(PromptIntent), err, embedded)
+	}
+
+	// Roll back.
+	if err := p.Activate(ctx, PromptIntent, embedded); err != nil {
+		t.Fatalf("roll back: %v", err)
+	}
+	peer, _ := NewPrompts(dir)
+	peer.SetStore(store)
+	store.active[PromptIntent] = "v99"
+	if err := peer.Refresh(ctx); err != nil || peer.Active(PromptIntent) != "v99" {
+		t.Errorf("peer after refresh: active %s, %v; want v99", peer.Active(PromptIntent), err)
+	}
+}
+
+func TestPrompts_Stats(t *testing.T) {
+	p := defaultPrompts()
+	recordOutcome("stats_test", "v1", "booking")
+	recordOutcome("stats_test", "v1", "clarification")
+	recordOutcome("stats_test", "v1", "clarification")
+	recordOutcome("stats_test", "v1", "")
+	recordOutcome("stats_test", "v2", "booking")
+
+	st := p.Stats("stats_test", "v1")
+	if st.Responses != 4 || st.ClarificationRate != 0.5 || st.ParseErrorRate != 0.25 || st.Intents["booking"] != 1 {
+		t.Errorf("stats = %+v, want 4 responses, 50%% clarifications, 25%% parse errors", st)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	got := []string{"v10", "draft", "v2", "v1"}
+	slices.SortFunc(got, compareVersions)
+	if want := []string{"v1", "v2", "v10", "draft"}; !slices.Equal(got, want) {
+		t.Errorf("sorted = %v, want %v", got, want)
+	}
+}
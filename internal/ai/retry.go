@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/grpc/codes"
+
+	"ark/internal/timeout"
+)
+
+// Errors returned once a provider call has failed for good. They wrap the
+// provider's last error, so callers can tell a busy provider from a bad request.
+var (
+	// ErrQuotaExceeded means the provider kept rejecting calls for rate or
+	// quota limits (HTTP 429 / RESOURCE_EXHAUSTED).
+	ErrQuotaExceeded = errors.New("ai provider quota exceeded")
+	// ErrUnavailable means every attempt timed out or hit a server error.
+	ErrUnavailable = errors.New("ai provider unavailable")
+)
+
+// providerCalls counts calls per provider and outcome, e.g. "gemini.retries".
+// Exposed on /api/ops/debug/vars.
+var providerCalls = expvar.NewMap("ai_provider_calls")
+
+// RetryPolicy bounds how a provider call is attempted.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, the first included.
+	MaxAttempts int
+	// Timeout bounds each attempt; the caller's context and the LLM timeout
+	// budget bound them all.
+	Timeout time.Duration
+	// Backoff is the wait before the first retry; it doubles per retry up
+	// to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used by providers that were not given one.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Timeout:     10 * time.Second,
+	Backoff:     500 * time.Millisecond,
+	MaxBackoff:  8 * time.Second,
+}
+
+// errClass says whether a failed attempt is worth repeating.
+type errClass int
+
+const (
+	classPermanent errClass = iota
+	classTransient          // timeouts and 5xx
+	classQuota              // 429
+)
+
+// sleep waits d or until ctx is done. Replaced in tests.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Do runs call under p, retrying transient and quota failures with
+// exponential backoff. A quota error whose suggested retry delay exceeds
+// MaxBackoff is not retried. provider names the metrics. The whole call,
+// retries included, stays within the LLM timeout budget.
+func (p RetryPolicy) Do(ctx context.Context, provider string, call func(ctx context.Context) error) error {
+	ctx, cancel := timeout.LLM(ctx)
+	defer cancel()
+	attempts := max(p.MaxAttempts, 1)
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		providerCalls.Add(provider+".calls", 1)
+		err := p.attempt(ctx, call)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			providerCalls.Add(provider+".failures", 1)
+			return err
+		}
+		class, wait := classify(err)
+		switch class {
+		case classQuota:
+			providerCalls.Add(provider+".quota", 1)
+		case classTransient:
+			if errors.Is(err, context.DeadlineExceeded) {
+				providerCalls.Add(provider+".timeouts", 1)
+			}
+		}
+		if wait == 0 {
+			wait = jitter(backoff)
+		}
+		if class == classPermanent || attempt >= attempts || wait > p.MaxBackoff {
+			providerCalls.Add(provider+".failures", 1)
+			switch class {
+			case classQuota:
+				return fmt.Errorf("%w: %w", ErrQuotaExceeded, err)
+			case classTransient:
+				return fmt.Errorf("%w: %w", ErrUnavailable, err)
+			}
+			return err
+		}
+		providerCalls.Add(provider+".retries", 1)
+		if err := sleep(ctx, wait); err != nil {
+			providerCalls.Add(provider+".failures", 1)
+			return err
+		}
+		backoff = min(backoff*2, p.MaxBackoff)
+	}
+}
+
+func (p RetryPolicy) attempt(ctx context.Context, call func(ctx context.Context) error) error {
+	if p.Timeout <= 0 {
+		return call(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	return call(ctx)
+}
+
+// jitter spreads d over [d/2, d) so clients backing off together do not
+// retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// classify sorts a provider error and returns the server's suggested retry
+// delay, if any. Errors arrive as gRPC statuses or HTTP errors depending on
+// the client transport.
+func classify(err error) (errClass, time.Duration) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return classTransient, 0
+	}
+	ae, ok := apierror.FromError(err)
+	if !ok {
+		return classPermanent, 0
+	}
+	var wait time.Duration
+	if ri := ae.Details().RetryInfo; ri != nil && ri.GetRetryDelay() != nil {
+		wait = ri.GetRetryDelay().AsDuration()
+	}
+	if code := ae.HTTPCode(); code > 0 {
+		switch {
+		case code == http.StatusTooManyRequests:
+			return classQuota, wait
+		case code == http.StatusRequestTimeout || code >= http.StatusInternalServerError:
+			return classTransient, wait
+		}
+		return classPermanent, 0
+	}
+	switch ae.GRPCStatus().Code() {
+	case codes.ResourceExhausted:
+		return classQuota, wait
+	case codes.Unavailable, codes.Internal, codes.DeadlineExceeded, codes.Aborted:
+		return classTransient, wait
+	}
+	return classPermanent, 0
+}
@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// recordSleeps replaces sleep for the test and returns the waits requested.
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	orig := sleep
+	sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	t.Cleanup(func() { sleep = orig })
+	return &waits
+}
+
+func quotaErr(t *testing.T, retryAfter time.Duration) error {
+	t.Helper()
+	st, err := status.New(codes.ResourceExhausted, "quota").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st.Err()
+}
+
+var testPolicy = RetryPolicy{MaxAttempts: 3, Timeout: time.Second, Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+func TestRetryPolicy_RetriesTransientErrors(t *testing.T) {
+	waits := recordSleeps(t)
+	calls := 0
+	err := testPolicy.Do(context.Background(), "test", func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return status.Error(codes.Unavailable, "overloaded")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("err = %v after %d calls, want success on the third", err, calls)
+	}
+	if len(*waits) != 2 || (*waits)[0] >= 100*time.Millisecond || (*waits)[1] < 100*time.Millisecond {
+		t.Errorf("waits = %v, want a jittered 100ms then 200ms", *waits)
+	}
+}
+
+func TestRetryPolicy_GivesUp(t *testing.T) {
+	recordSleeps(t)
+	cases := []struct {
+		name  string
+		err   error
+		calls int
+		want  error
+	}{
+		{"server errors", status.Error(codes.Internal, "boom"), 3, ErrUnavailable},
+		{"timeouts", context.DeadlineExceeded, 3, ErrUnavailable},
+		{"quota", quotaErr(t, 200*time.Millisecond), 3, ErrQuotaExceeded},
+		{"quota with a long retry delay", quotaErr(t, time.Minute), 1, ErrQuotaExceeded},
+		{"bad request", status.Error(codes.InvalidArgument, "bad prompt"), 1, nil},
+		{"other errors", errors.New("blocked"), 1, nil},
+	}
+	for _, c := range cases {
+		calls := 0
+		err := testPolicy.Do(context.Background(), "test", func(context.Context) error {
+			calls++
+			return c.err
+		})
+		if calls != c.calls {
+			t.Errorf("%s: %d calls, want %d", c.name, calls, c.calls)
+		}
+		if err == nil || (c.want != nil && !errors.Is(err, c.want)) {
+			t.Errorf("%s: err = %v, want %v", c.name, err, c.want)
+		}
+		if c.want == nil && (errors.Is(err, ErrUnavailable) || errors.Is(err, ErrQuotaExceeded)) {
+			t.Errorf("%s: permanent error classified as %v", c.name, err)
+		}
+	}
+}
+
+func TestRetryPolicy_HonoursRetryDelay(t *testing.T) {
+	waits := recordSleeps(t)
+	calls := 0
+	_ = testPolicy.Do(context.Background(), "test", func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return quotaErr(t, 700*time.Millisecond)
+		}
+		return nil
+	})
+	if len(*waits) != 1 || (*waits)[0] != 700*time.Millisecond {
+		t.Errorf("waits = %v, want the server's 700ms", *waits)
+	}
+}
+
+func TestRetryPolicy_TimesOutEachAttempt(t *testing.T) {
+	recordSleeps(t)
+	p := testPolicy
+	p.Timeout = 10 * time.Millisecond
+	calls := 0
+	err := p.Do(context.Background(), "test", func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if calls != 3 || !errors.Is(err, ErrUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v after %d calls, want ErrUnavailable wrapping the deadline after 3", err, calls)
+	}
+}
@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// MaxAudioBytes caps an uploaded voice message (about a minute of compressed
+// speech is well under it).
+const MaxAudioBytes = 10 << 20
+
+var (
+	// ErrUnsupportedAudio is returned for audio in a format the provider
+	// cannot read.
+	ErrUnsupportedAudio = errors.New("unsupported audio format")
+	// ErrNoSpeech is returned when the audio holds no recognisable speech.
+	ErrNoSpeech = errors.New("no speech in audio")
+)
+
+// audioTypes are the formats Gemini accepts inline, with common aliases.
+var audioTypes = map[string]string{
+	"audio/wav":   "audio/wav",
+	"audio/x-wav": "audio/wav",
+	"audio/wave":  "audio/wav",
+	"audio/mp3":   "audio/mp3",
+	"audio/mpeg":  "audio/mp3",
+	"audio/aiff":  "audio/aiff",
+	"audio/aac":   "audio/aac",
+	"audio/ogg":   "audio/ogg",
+	"audio/flac":  "audio/flac",
+}
+
+// Audio is a recorded voice message.
+type Audio struct {
+	Data     []byte
+	MIMEType string
+	// Language is an optional BCP 47 hint such as "zh-TW".
+	Language string
+}
+
+// AudioMIMEType normalises a Content-Type to a supported audio type, or
+// returns ErrUnsupportedAudio.
+func AudioMIMEType(contentType string) (string, error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnsupportedAudio
+	}
+	if t, ok := audioTypes[mt]; ok {
+		return t, nil
+	}
+	return "", ErrUnsupportedAudio
+}
+
+const transcribePrompt = `Transcribe the speech in this audio verbatim, in the language spoken (usually Mandarin Chinese, written in Traditional Chinese characters, or Taiwanese Hokkien).
+Output only the transcript: no timestamps, speaker labels or commentary.
+If there is no intelligible speech, output nothing.`
+
+// GeminiTranscriber implements Transcriber with Gemini's audio input.
+type GeminiTranscriber struct {
+	client *genai.Client
+	model  *genai.GenerativeModel
+	retry  RetryPolicy
+	usage  UsageRecorder
+}
+
+// NewGeminiTranscriber creates a Gemini client for transcription.
+func NewGeminiTranscriber(ctx context.Context, apiKey string) (*GeminiTranscriber, error) {
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+	model := client.GenerativeModel(geminiModel)
+	// Transcripts should be faithful, not creative.
+	model.SetTemperature(0)
+	return &GeminiTranscriber{client: client, model: model, retry: DefaultRetryPolicy}, nil
+}
+
+// SetRetryPolicy replaces DefaultRetryPolicy for subsequent calls.
+// Must be called before the transcriber is shared between goroutines.
+func (t *GeminiTranscriber) SetRetryPolicy(policy RetryPolicy) {
+	t.retry = policy
+}
+
+// SetUsageRecorder records the tokens, cost and outcome of every call.
+// Must be called before the transcriber is shared between goroutines.
+func (t *GeminiTranscriber) SetUsageRecorder(r UsageRecorder) {
+	t.usage = r
+}
+
+// Close cleans up the Gemini client resources.
+func (t *GeminiTranscriber) Close() {
+	t.client.Close()
+}
+
+// Transcribe implements Transcriber.
+func (t *GeminiTranscriber) Transcribe(ctx context.Context, audio Audio) (string, error) {
+	mimeType, err := AudioMIMEType(audio.MIMEType)
+	if err != nil {
+		return "", err
+	}
+	if len(audio.Data) == 0 {
+		return "", ErrNoSpeech
+	}
+	prompt := transcribePrompt
+	if audio.Language != "" {
+		prompt += "\nThe speaker's language is probably " + audio.Language + "."
+	}
+
+	var resp *genai.GenerateContentResponse
+	start := time.Now()
+	err = t.retry.Do(ctx, "gemini_stt", func(ctx context.Context) error {
+		var err error
+		resp, err = t.model.GenerateContent(ctx, genai.Blob{MIMEType: mimeType, Data: audio.Data}, genai.Text(prompt))
+		return err
+	})
+	RecordUsage(ctx, t.usage, GeminiUsage(ctx, geminiModel, "transcribe", start, resp, err))
+	if err != nil {
+		return "", fmt.Errorf("gemini transcription error: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", ErrNoSpeech
+	}
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if txt, ok := part.(genai.Text); ok {
+			text.WriteString(string(txt))
+		}
+	}
+	transcript := strings.TrimSpace(text.String())
+	if transcript == "" {
+		return "", ErrNoSpeech
+	}
+	return transcript, nil
+}
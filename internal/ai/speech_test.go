@@ -0,0 +1,35 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAudioMIMEType(t *testing.T) {
+	cases := map[string]string{
+		"audio/mpeg":               "audio/mp3",
+		"audio/x-wav":              "audio/wav",
+		"audio/ogg; codecs=opus":   "audio/ogg",
+		"audio/webm":               "",
+		"application/octet-stream": "",
+		"":                         "",
+	}
+	for in, want := range cases {
+		got, err := AudioMIMEType(in)
+		if got != want || (want == "") != errors.Is(err, ErrUnsupportedAudio) {
+			t.Errorf("AudioMIMEType(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestGeminiTranscriber_RejectsBeforeCalling(t *testing.T) {
+	// No client: these must fail before any provider call.
+	tr := &GeminiTranscriber{retry: DefaultRetryPolicy}
+	if _, err := tr.Transcribe(context.Background(), Audio{Data: []byte("x"), MIMEType: "video/mp4"}); !errors.Is(err, ErrUnsupportedAudio) {
+		t.Errorf("video: err = %v, want ErrUnsupportedAudio", err)
+	}
+	if _, err := tr.Transcribe(context.Background(), Audio{MIMEType: "audio/wav"}); !errors.Is(err, ErrNoSpeech) {
+		t.Errorf("empty audio: err = %v, want ErrNoSpeech", err)
+	}
+}
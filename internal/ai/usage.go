@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// Usage error classes, as recorded in UsageEvent.Error.
+const (
+	UsageErrQuota       = "quota"
+	UsageErrUnavailable = "unavailable"
+	UsageErrOther       = "error"
+)
+
+// UsageEvent is one LLM call, successful or not, for tracking spend.
+type UsageEvent struct {
+	Provider string // e.g. "gemini"
+	Model    string
+	// Operation is what the call was for: "chat", "intent" or "transcribe".
+	Operation    string
+	UserID       string // empty when the call was not made for a user
+	InputTokens  int
+	OutputTokens int
+	// CostMicros is the estimated cost in millionths of a US dollar.
+	CostMicros int64
+	// Error is empty for a successful call, otherwise one of the UsageErr
+	// classes.
+	Error   string
+	Latency time.Duration
+	At      time.Time
+}
+
+// UsageRecorder stores UsageEvents.
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, e UsageEvent) error
+}
+
+// modelPrice is a model's list price per million tokens, in micro-dollars.
+type modelPrice struct {
+	input, output int64
+}
+
+// modelPrices holds the models Ark calls. Unknown models cost nothing, so
+// add a model here before switching to it.
+var modelPrices = map[string]modelPrice{
+	"gemini-2.0-flash": {input: 100_000, output: 400_000},
+}
+
+// EstimateCost returns what model charges for the tokens, in micro-dollars.
+func EstimateCost(model string, inputTokens, outputTokens int) int64 {
+	p := modelPrices[model]
+	return (int64(inputTokens)*p.input + int64(outputTokens)*p.output) / 1_000_000
+}
+
+// UsageErrorClass returns the UsageEvent.Error class of a call's error.
+func UsageErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrQuotaExceeded):
+		return UsageErrQuota
+	case errors.Is(err, ErrUnavailable), errors.Is(err, context.DeadlineExceeded):
+		return UsageErrUnavailable
+	}
+	return UsageErrOther
+}
+
+type usageUserKey struct{}
+
+// WithUsageUser attributes the LLM calls made with ctx to userID.
+func WithUsageUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, usageUserKey{}, userID)
+}
+
+func usageUser(ctx context.Context) string {
+	uid, _ := ctx.Value(usageUserKey{}).(string)
+	return uid
+}
+
+// GeminiUsage describes a Gemini call started at start that returned resp or
+// err. The user is taken from ctx (see WithUsageUser).
+func GeminiUsage(ctx context.Context, model, operation string, start time.Time, resp *genai.GenerateContentResponse, err error) UsageEvent {
+	now := time.Now()
+	e := UsageEvent{
+		Provider:  "gemini",
+		Model:     model,
+		Operation: operation,
+		UserID:    usageUser(ctx),
+		Error:     UsageErrorClass(err),
+		Latency:   now.Sub(start),
+		At:        now.UTC(),
+	}
+	if resp != nil && resp.UsageMetadata != nil {
+		e.InputTokens = int(resp.UsageMetadata.PromptTokenCount)
+		e.OutputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+		e.CostMicros = EstimateCost(model, e.InputTokens, e.OutputTokens)
+	}
+	return e
+}
+
+// RecordUsage stores e with r, if set. A failure is logged, never returned:
+// losing a usage row must not fail the call it describes.
+func RecordUsage(ctx context.Context, r UsageRecorder, e UsageEvent) {
+	if r == nil {
+		return
+	}
+	// The call's own deadline may be spent; the row is still worth writing.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+	if err := r.RecordUsage(ctx, e); err != nil {
+		log.Printf("ai: record %s %s usage: %v", e.Provider, e.Operation, err)
+	}
+}
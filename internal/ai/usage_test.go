@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+type usageLog []UsageEvent
+
+func (l *usageLog) RecordUsage(_ context.Context, e UsageEvent) error {
+	*l = append(*l, e)
+	return nil
+}
+
+func TestGeminiUsage(t *testing.T) {
+	ctx := WithUsageUser(context.Background(), "u1")
+	resp := &genai.GenerateContentResponse{UsageMetadata: &genai.UsageMetadata{PromptTokenCount: 2000, CandidatesTokenCount: 500}}
+	var log usageLog
+	RecordUsage(ctx, &log, GeminiUsage(ctx, geminiModel, "chat", time.Now(), resp, nil))
+	RecordUsage(ctx, &log, GeminiUsage(ctx, geminiModel, "chat", time.Now(), nil, fmt.Errorf("%w: 429", ErrQuotaExceeded)))
+	RecordUsage(ctx, nil, GeminiUsage(ctx, geminiModel, "chat", time.Now(), resp, nil))
+
+	if len(log) != 2 {
+		t.Fatalf("recorded %d events, want 2", len(log))
+	}
+	// 2000 input tokens at $0.10/M and 500 output at $0.40/M.
+	if e := log[0]; e.UserID != "u1" || e.InputTokens != 2000 || e.OutputTokens != 500 || e.CostMicros != 400 || e.Error != "" {
+		t.Errorf("success = %+v", e)
+	}
+	if e := log[1]; e.Error != UsageErrQuota || e.InputTokens != 0 || e.CostMicros != 0 {
+		t.Errorf("failure = %+v", e)
+	}
+	if got := UsageErrorClass(errors.New("bad request")); got != UsageErrOther {
+		t.Errorf("UsageErrorClass(permanent) = %q, want %q", got, UsageErrOther)
+	}
+	if got := EstimateCost("unknown-model", 1000, 1000); got != 0 {
+		t.Errorf("EstimateCost(unknown) = %d, want 0", got)
+	}
+}
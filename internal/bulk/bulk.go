@@ -0,0 +1,188 @@
+// README: Bulk configuration transfer shared by the modules ops manage from spreadsheets and GIS tools — file formats, CSV rows, per-row validation errors and dry-run diffs.
+package bulk
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// MaxRows caps the rows of one import.
+const MaxRows = 10000
+
+var (
+	// ErrFormat is returned for a format other than csv or json.
+	ErrFormat = errors.New("format must be csv or json")
+	// ErrInvalid is returned for an import with invalid rows; nothing of it
+	// is applied. Use errors.As with *ValidationError for the rows.
+	ErrInvalid = errors.New("import has invalid rows")
+	// ErrMalformed is returned for a file that cannot be read as its format.
+	ErrMalformed = errors.New("malformed file")
+)
+
+// Format is the encoding of an exported or imported file.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// ParseFormat reads a format query parameter; empty is JSON.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "json":
+		return JSON, nil
+	case "csv":
+		return CSV, nil
+	}
+	return "", ErrFormat
+}
+
+// ContentType returns the MIME type of files in f.
+func (f Format) ContentType() string {
+	if f == CSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// RowError is one problem with an imported row. Rows are numbered from 1 in
+// file order, not counting a CSV header.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found in an import. It wraps
+// ErrInvalid.
+type ValidationError struct {
+	Rows []RowError
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %d problems", ErrInvalid, len(e.Rows))
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalid }
+
+// Add records a problem with field of row.
+func (e *ValidationError) Add(row int, field, message string) {
+	e.Rows = append(e.Rows, RowError{Row: row, Field: field, Message: message})
+}
+
+// Err returns e, or nil when no problem was recorded.
+func (e *ValidationError) Err() error {
+	if len(e.Rows) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Change actions.
+const (
+	Add    = "add"
+	Update = "update"
+	Remove = "remove"
+)
+
+// Change is one difference an import makes to the stored configuration.
+type Change struct {
+	Action string `json:"action"`
+	// Key identifies the row, e.g. "tpe/standard".
+	Key string `json:"key"`
+	// Fields are the columns an update changes.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Result is what an import changed, or with DryRun would change.
+type Result struct {
+	DryRun    bool     `json:"dry_run"`
+	Changes   []Change `json:"changes"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// Diff returns the columns whose values differ between two rows laid out as
+// columns.
+func Diff(columns, before, after []string) []string {
+	var out []string
+	for i, c := range columns {
+		if before[i] != after[i] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// WriteCSV writes a header of columns followed by rows.
+func WriteCSV(w io.Writer, columns []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// ReadCSV reads a file with a header row naming columns, in any order, and
+// returns its rows laid out as columns. Columns in optional may be left out
+// of the header and read as empty; any column not in columns is refused so
+// a misspelt header is not silently ignored.
+func ReadCSV(r io.Reader, columns, optional []string) ([][]string, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: no header row", ErrMalformed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	// at[i] is the file column holding columns[i], -1 when left out.
+	at := make([]int, len(columns))
+	for i := range at {
+		at[i] = -1
+	}
+	for j, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		i := slices.Index(columns, name)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrMalformed, name)
+		}
+		if at[i] >= 0 {
+			return nil, fmt.Errorf("%w: column %q repeated", ErrMalformed, name)
+		}
+		at[i] = j
+	}
+	for i, c := range columns {
+		if at[i] < 0 && !slices.Contains(optional, c) {
+			return nil, fmt.Errorf("%w: missing column %q", ErrMalformed, c)
+		}
+	}
+	var rows [][]string
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrMalformed, MaxRows)
+		}
+		row := make([]string, len(columns))
+		for i, j := range at {
+			if j >= 0 {
+				row[i] = strings.TrimSpace(rec[j])
+			}
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,39 @@
+package bulk
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV_HeaderOrderAndOptionalColumns(t *testing.T) {
+	cols := []string{"rate_set", "date", "surcharge_bps"}
+	rows, err := ReadCSV(strings.NewReader("\ufeffSurcharge_BPS, date\n2000, 2026-10-10\n"), cols, []string{"rate_set"})
+	want := [][]string{{"", "2026-10-10", "2000"}}
+	if err != nil || !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, %v; want %q", rows, err, want)
+	}
+
+	for _, in := range []string{
+		"",                                 // no header
+		"date\n2026-10-10\n",               // missing surcharge_bps
+		"date,surcharge_bps,dat\n1,2,3\n",  // misspelt column
+		"date,date,surcharge_bps\n1,2,3\n", // repeated column
+		"date,surcharge_bps\n2026-10-10\n", // short row
+	} {
+		if _, err := ReadCSV(strings.NewReader(in), cols, []string{"rate_set"}); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%q: err = %v, want ErrMalformed", in, err)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	cols := []string{"a", "b", "c"}
+	if got := Diff(cols, []string{"1", "2", "3"}, []string{"1", "5", "6"}); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("Diff = %v", got)
+	}
+	if got := Diff(cols, []string{"1", "2", "3"}, []string{"1", "2", "3"}); got != nil {
+		t.Errorf("Diff of equal rows = %v", got)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	content := `
+# comment
+ARK_HTTP_ADDR=:9090
+export ARK_REDIS_ADDR=redis:6379
+ARK_MATCH_TICK=3          # seconds between ticks
+QUOTED="a # not a comment"
+SINGLE='x'
+EMPTY=
+=novalue
+garbage
+`
+	got := parseDotEnv(content)
+	want := map[string]string{
+		"ARK_HTTP_ADDR":  ":9090",
+		"ARK_REDIS_ADDR": "redis:6379",
+		"ARK_MATCH_TICK": "3",
+		"QUOTED":         "a # not a comment",
+		"SINGLE":         "x",
+		"EMPTY":          "",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseDotEnv returned %d keys, want %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestLoadDotEnv_DoesNotOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("ARK_TEST_CFG_A=from-file\nARK_TEST_CFG_B=from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ARK_TEST_CFG_A", "from-env")
+	t.Setenv("ARK_TEST_CFG_B", "")
+	os.Unsetenv("ARK_TEST_CFG_B")
+
+	if err := LoadDotEnv(path); err != nil {
+		t.Fatalf("LoadDotEnv: %v", err)
+	}
+	if v := os.Getenv("ARK_TEST_CFG_A"); v != "from-env" {
+		t.Errorf("ARK_TEST_CFG_A = %q, want existing env value to win", v)
+	}
+	if v := os.Getenv("ARK_TEST_CFG_B"); v != "from-file" {
+		t.Errorf("ARK_TEST_CFG_B = %q, want value from file", v)
+	}
+}
+
+func TestLoad_InvalidNumberFails(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "k")
+	t.Setenv("ARK_MATCH_TICK", "three")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ARK_MATCH_TICK") {
+		t.Fatalf("expected ARK_MATCH_TICK parse error, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Config{
+		HTTP:       HTTPConfig{Addr: ":8080"},
+		DB:         DBConfig{DSN: "postgres://x"},
+		Redis:      RedisConfig{Addr: "localhost:6379"},
+		AI:         AIConfig{GeminiKey: "k", Timeout: 10 * time.Second, MaxAttempts: 3, Backoff: time.Second, MaxBackoff: 8 * time.Second, PromptRefresh: time.Minute},
+		Matching:   MatchingConfig{TickSeconds: 3, RadiusKm: 3, PickupSpeedKmh: 25, OfferTTL: 30 * time.Second},
+		Location:   LocationConfig{HeartbeatTimeout: 30 * time.Second, HeartbeatInterval: 15 * time.Second},
+		Account:    AccountConfig{DeletionGrace: 720 * time.Hour, PurgeInterval: time.Hour},
+		OrderLink:  OrderLinkConfig{TTL: 2 * time.Hour},
+		SLA:        SLAConfig{Interval: time.Minute, WaitingAfter: 10 * time.Minute, ApproachingAfter: 30 * time.Minute, PaymentAfter: 15 * time.Minute},
+		Pretrip:    PretripConfig{Interval: time.Minute, EscalateBefore: 10 * time.Minute},
+		Vehicle:    VehicleConfig{Interval: time.Hour, InspectionLead: 14 * 24 * time.Hour, ServiceIntervalKm: 10000},
+		Region:     RegionConfig{Default: "tpe", Refresh: time.Minute},
+		Flags:      FlagsConfig{Refresh: 30 * time.Second},
+		Scheduling: DefaultScheduling(),
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	bad := valid
+	bad.AI.GeminiKey = ""
+	bad.Matching.TickSeconds = 0
+	err := bad.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	bad.SMS.Provider = "twilio"
+	bad.OrderLink.SigningKey = "short"
+	bad.Sandbox.Key = "staging-key"
+	bad.Transit.FlightAPIKey = "flight-key"
+	bad.Referral.MaxPerReferrer = -1
+	bad.Ops.Key = "short"
+	bad.Commission.DefaultBps = 12000
+	bad.Arrival.CreditBps = -1
+	bad.Region.Refresh = 0
+	bad.Flags.Refresh = 0
+	bad.Matching.OfferTTL = 0
+	bad.Pricing.CancelGrace = -time.Minute
+	bad.OrderThrottle.MaxCancelsPerDay = -1
+	bad.Vehicle.ServiceIntervalKm = 0
+	bad.Attachment.Bucket = "ark-attachments"
+	err = bad.Validate()
+	for _, want := range []string{"GEMINI_API_KEY", "ARK_MATCH_TICK", "TWILIO_AUTH_TOKEN", "ORDER_LINK_SIGNING_KEY", "ARK_SANDBOX_BOT_STEP", "ARK_TRANSIT_POLL_INTERVAL", "ARK_REFERRAL_MAX_PER_REFERRER", "ARK_OPS_KEY", "ARK_COMMISSION_DEFAULT_BPS", "ARK_ARRIVAL_CREDIT_BPS", "ARK_REGION_REFRESH", "ARK_FLAGS_REFRESH", "ARK_MATCH_OFFER_TTL", "ARK_CANCEL_GRACE", "ARK_ORDER_MAX_CANCELS_PER_DAY", "ARK_VEHICLE_SERVICE_INTERVAL_KM", "ARK_ATTACHMENT_RETENTION"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validation error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestString_RedactsSecrets(t *testing.T) {
+	cfg := Config{
+		DB:        DBConfig{DSN: "postgres://postgres:hunter2@db:5432/ark"},
+		Firebase:  FirebaseConfig{CredentialsJSON: `{"private_key":"secret"}`},
+		Maps:      MapsConfig{APIKey: "maps-secret"},
+		AI:        AIConfig{GeminiKey: "gemini-secret"},
+		SMS:       SMSConfig{Provider: "twilio", TwilioAuthToken: "twilio-secret"},
+		Email:     EmailConfig{Provider: "sendgrid", SendGridAPIKey: "sendgrid-secret"},
+		PII:       PIIConfig{Keys: "k1:pii-secret", IndexKey: "index-secret"},
+		OrderLink: OrderLinkConfig{SigningKey: "link-secret"},
+		Sandbox:   SandboxConfig{Key: "sandbox-secret"},
+		Ops:       OpsConfig{Key: "ops-secret"},
+		Transit:   TransitConfig{FlightAPIKey: "flight-secret"},
+	}
+	s := cfg.String()
+	for _, leaked := range []string{"hunter2", "private_key", "maps-secret", "gemini-secret", "twilio-secret", "sendgrid-secret", "pii-secret", "index-secret", "link-secret", "sandbox-secret", "ops-secret", "flight-secret"} {
+		if strings.Contains(s, leaked) {
+			t.Errorf("String() leaks %q: %s", leaked, s)
+		}
+	}
+	if !strings.Contains(s, "postgres://postgres:<redacted>@db:5432/ark") {
+		t.Errorf("String() did not keep DSN host visible: %s", s)
+	}
+}
+
+func TestFirebaseConfig_RTDBURL(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  FirebaseConfig
+		want string
+	}{
+		{"default region", FirebaseConfig{}, "https://p-default-rtdb.asia-southeast1.firebasedatabase.app"},
+		{"custom region", FirebaseConfig{RTDBRegion: "europe-west1"}, "https://p-default-rtdb.europe-west1.firebasedatabase.app"},
+		{"us-central1", FirebaseConfig{RTDBRegion: "us-central1"}, "https://p-default-rtdb.firebaseio.com"},
+		{"explicit url", FirebaseConfig{DatabaseURL: "https://other.firebaseio.com", RTDBRegion: "europe-west1"}, "https://other.firebaseio.com"},
+	}
+	for _, tc := range cases {
+		if got := tc.cfg.RTDBURL("p"); got != tc.want {
+			t.Errorf("%s: RTDBURL = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// README: Secret providers — GCP Secret Manager with a local environment fallback.
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"google.golang.org/api/googleapi"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// ErrSecretNotFound is returned by a SecretProvider that has no value for the requested name.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider resolves a secret by name (the same name as its env var, e.g. GEMINI_API_KEY).
+type SecretProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// EnvSecrets reads secrets from process environment variables (local development fallback).
+type EnvSecrets struct{}
+
+func (EnvSecrets) Secret(_ context.Context, name string) (string, error) {
+	if v := os.Getenv(name); v != "" {
+		return v, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+// ChainSecrets tries each provider in order and returns the first value found.
+// Any error other than ErrSecretNotFound stops the chain.
+type ChainSecrets []SecretProvider
+
+func (c ChainSecrets) Secret(ctx context.Context, name string) (string, error) {
+	for _, p := range c {
+		v, err := p.Secret(ctx, name)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrSecretNotFound) {
+			return "", err
+		}
+	}
+	return "", ErrSecretNotFound
+}
+
+// SecretManager reads the latest version of each secret from GCP Secret Manager.
+// Secret IDs are the env var names, e.g. projects/<project>/secrets/GEMINI_API_KEY.
+type SecretManager struct {
+	svc     *secretmanager.Service
+	project string
+}
+
+// NewSecretManager creates a Secret Manager provider using Application Default Credentials.
+func NewSecretManager(ctx context.Context, project string) (*SecretManager, error) {
+	if project == "" {
+		return nil, errors.New("secret manager: missing project")
+	}
+	svc, err := secretmanager.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("secret manager: create client: %w", err)
+	}
+	return &SecretManager{svc: svc, project: project}, nil
+}
+
+func (m *SecretManager) Secret(ctx context.Context, name string) (string, error) {
+	resource := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", m.project, name)
+	resp, err := m.svc.Projects.Secrets.Versions.Access(resource).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return "", ErrSecretNotFound
+		}
+		return "", fmt.Errorf("secret manager: access %s: %w", name, err)
+	}
+	if resp.Payload == nil {
+		return "", ErrSecretNotFound
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("secret manager: decode %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// newSecretProvider returns Secret Manager backed by the env fallback when project
+// is set, and the env provider alone otherwise.
+func newSecretProvider(ctx context.Context, project string) (SecretProvider, error) {
+	if project == "" {
+		return EnvSecrets{}, nil
+	}
+	sm, err := NewSecretManager(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return ChainSecrets{sm, EnvSecrets{}}, nil
+}
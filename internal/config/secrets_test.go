@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type mapSecrets map[string]string
+
+func (m mapSecrets) Secret(_ context.Context, name string) (string, error) {
+	if v, ok := m[name]; ok {
+		return v, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+type failingSecrets struct{ err error }
+
+func (f failingSecrets) Secret(context.Context, string) (string, error) { return "", f.err }
+
+func TestChainSecrets_FallsThroughNotFound(t *testing.T) {
+	chain := ChainSecrets{mapSecrets{"A": "primary"}, mapSecrets{"A": "fallback", "B": "fallback"}}
+	ctx := context.Background()
+
+	if v, err := chain.Secret(ctx, "A"); err != nil || v != "primary" {
+		t.Errorf("A = %q, %v; want primary", v, err)
+	}
+	if v, err := chain.Secret(ctx, "B"); err != nil || v != "fallback" {
+		t.Errorf("B = %q, %v; want fallback", v, err)
+	}
+	if _, err := chain.Secret(ctx, "C"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("C err = %v; want ErrSecretNotFound", err)
+	}
+}
+
+func TestChainSecrets_StopsOnProviderError(t *testing.T) {
+	boom := errors.New("permission denied")
+	chain := ChainSecrets{failingSecrets{err: boom}, mapSecrets{"A": "fallback"}}
+	if _, err := chain.Secret(context.Background(), "A"); !errors.Is(err, boom) {
+		t.Fatalf("err = %v; want provider error to surface", err)
+	}
+}
+
+func TestLoad_ReadsSecretsFromProvider(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	secrets := mapSecrets{
+		"GEMINI_API_KEY":            "gemini-from-sm",
+		"GOOGLE_MAPS_API_KEY":       "maps-from-sm",
+		"FIREBASE_CREDENTIALS_JSON": `{"project_id":"p"}`,
+	}
+	cfg, err := load(context.Background(), secrets, "my-project")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.AI.GeminiKey != "gemini-from-sm" || cfg.Maps.APIKey != "maps-from-sm" || cfg.Firebase.CredentialsJSON == "" {
+		t.Errorf("secrets not applied: %+v", cfg)
+	}
+	if cfg.Secrets.Project != "my-project" {
+		t.Errorf("Secrets.Project = %q", cfg.Secrets.Project)
+	}
+}
+
+func TestLoad_ProviderErrorFails(t *testing.T) {
+	_, err := load(context.Background(), failingSecrets{err: errors.New("unavailable")}, "p")
+	if err == nil {
+		t.Fatal("expected provider failure to fail Load")
+	}
+}
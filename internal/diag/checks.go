@@ -0,0 +1,87 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/ai"
+	"ark/internal/config"
+	"ark/internal/infra"
+	"ark/internal/maps"
+)
+
+// checkPostgres connects once, without the startup retry: a deploy check
+// should report what it finds rather than wait for the database.
+func checkPostgres(ctx context.Context, cfg config.Config) (*pgxpool.Pool, error) {
+	dbCfg := cfg.DB
+	dbCfg.ConnectWait = 0
+	return infra.NewDB(ctx, dbCfg, cfg.Timeouts.DB)
+}
+
+func checkRedis(ctx context.Context, cfg config.Config) (string, error) {
+	redisCfg := cfg.Redis
+	redisCfg.ConnectWait = 0
+	client, err := infra.NewRedis(ctx, redisCfg, cfg.Timeouts.Redis)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	return cfg.Redis.Addr, nil
+}
+
+// checkFirebase looks up a user that does not exist: "not found" proves the
+// credentials are accepted by Firebase Auth.
+func checkFirebase(ctx context.Context, cfg config.FirebaseConfig) (string, error) {
+	if !cfg.Enabled() {
+		return "", errSkip("not configured; auth is disabled (dev mode)")
+	}
+	app, err := infra.NewFirebaseApp(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	client, err := infra.NewFirebaseVerifier(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	if _, err := client.GetUser(ctx, "ark-diagnostic-check"); err != nil && !auth.IsUserNotFound(err) {
+		return "", err
+	}
+	return "auth reachable", nil
+}
+
+func checkMaps(ctx context.Context, apiKey string) (string, error) {
+	if apiKey == "" {
+		return "", errSkip("not configured")
+	}
+	svc, err := maps.NewRouteService(apiKey)
+	if err != nil {
+		return "", err
+	}
+	addr, err := svc.Geocode(ctx, "Taipei 101")
+	if err != nil {
+		return "", err
+	}
+	if addr == "" {
+		return "", errors.New("geocode returned no result")
+	}
+	return fmt.Sprintf("geocoded %q", addr), nil
+}
+
+func checkGemini(ctx context.Context, apiKey string) (string, error) {
+	if apiKey == "" {
+		return "", errSkip("not configured")
+	}
+	p, err := ai.NewGeminiProvider(ctx, apiKey)
+	if err != nil {
+		return "", err
+	}
+	defer p.Close()
+	if err := p.Ping(ctx); err != nil {
+		return "", err
+	}
+	return "model reachable", nil
+}
@@ -0,0 +1,140 @@
+// README: Deploy diagnostics — one-shot dependency checks run by `ark-api --check`, printed as a table.
+package diag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/config"
+)
+
+// Check outcomes. Skip is for optional dependencies that are not configured
+// or were not asked for.
+const (
+	Pass = "PASS"
+	Fail = "FAIL"
+	Skip = "SKIP"
+)
+
+// checkTimeout bounds each check, so one hung dependency cannot stall a deploy.
+const checkTimeout = 10 * time.Second
+
+// Result is one row of the diagnosis.
+type Result struct {
+	Name    string
+	Status  string
+	Latency time.Duration
+	Detail  string
+}
+
+// Options selects the optional checks.
+type Options struct {
+	// External also calls Maps and Gemini, which spends a little quota.
+	External bool
+}
+
+// errSkip marks a check that did not run; its message is the detail.
+type errSkip string
+
+func (e errSkip) Error() string { return string(e) }
+
+// ConfigResult reports the outcome of config.Load.
+func ConfigResult(err error) Result {
+	if err != nil {
+		return Result{Name: "config", Status: Fail, Detail: err.Error()}
+	}
+	return Result{Name: "config", Status: Pass, Detail: "valid"}
+}
+
+// Run checks every dependency of the API in turn: Postgres, the migration
+// level, Redis, Firebase and, with opts.External, Maps and Gemini. Checks
+// that depend on Postgres are skipped when it is unreachable.
+func Run(ctx context.Context, cfg config.Config, opts Options) []Result {
+	var db *pgxpool.Pool
+	defer func() {
+		if db != nil {
+			db.Close()
+		}
+	}()
+
+	checks := []struct {
+		name string
+		run  func(ctx context.Context) (string, error)
+	}{
+		{"postgres", func(ctx context.Context) (string, error) {
+			var err error
+			db, err = checkPostgres(ctx, cfg)
+			if err != nil {
+				return "", err
+			}
+			var version string
+			err = db.QueryRow(ctx, `SHOW server_version`).Scan(&version)
+			return "server " + version, err
+		}},
+		{"migrations", func(ctx context.Context) (string, error) {
+			if db == nil {
+				return "", errSkip("postgres unavailable")
+			}
+			return checkMigrations(ctx, db)
+		}},
+		{"redis", func(ctx context.Context) (string, error) { return checkRedis(ctx, cfg) }},
+		{"firebase", func(ctx context.Context) (string, error) { return checkFirebase(ctx, cfg.Firebase) }},
+		{"maps", func(ctx context.Context) (string, error) {
+			if !opts.External {
+				return "", errSkip("pass -check-external to call the Maps API")
+			}
+			return checkMaps(ctx, cfg.Maps.APIKey)
+		}},
+		{"gemini", func(ctx context.Context) (string, error) {
+			if !opts.External {
+				return "", errSkip("pass -check-external to call Gemini")
+			}
+			return checkGemini(ctx, cfg.AI.GeminiKey)
+		}},
+	}
+
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		cctx, cancel := context.WithTimeout(ctx, checkTimeout)
+		start := time.Now()
+		detail, err := c.run(cctx)
+		cancel()
+		r := Result{Name: c.name, Status: Pass, Latency: time.Since(start), Detail: detail}
+		if skip, ok := err.(errSkip); ok {
+			r.Status, r.Latency, r.Detail = Skip, 0, string(skip)
+		} else if err != nil {
+			r.Status, r.Detail = Fail, err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Failed reports whether any check failed.
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes results as an aligned table.
+func Print(w io.Writer, results []Result) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tTIME\tDETAIL")
+	for _, r := range results {
+		latency := "-"
+		if r.Latency > 0 {
+			latency = r.Latency.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, r.Status, latency, r.Detail)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,63 @@
+package diag
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+
+	"ark/migrations"
+)
+
+func TestParseMigration(t *testing.T) {
+	sql := `-- README: CREATE TABLE ignored_in_comment
+CREATE TABLE IF NOT EXISTS trips (id TEXT PRIMARY KEY);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trips_id ON trips (id);
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS notes TEXT,
+    ADD COLUMN pet BOOLEAN;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_pet;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_pet CHECK (pet IS NOT NULL) NOT VALID;`
+	objs := parseMigration(sql)
+	if !slices.Equal(objs.relations, []string{"trips", "idx_trips_id"}) {
+		t.Errorf("relations = %v", objs.relations)
+	}
+	if !slices.Equal(objs.columns, []string{"orders.notes", "orders.pet"}) {
+		t.Errorf("columns = %v", objs.columns)
+	}
+	if !slices.Equal(objs.constraints, []string{"chk_orders_pet"}) {
+		t.Errorf("constraints = %v", objs.constraints)
+	}
+}
+
+// Every migration must create something the check can look for, or it would
+// count as applied without being verified.
+func TestParseMigration_EveryMigrationVerifiable(t *testing.T) {
+	names, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil || len(names) == 0 {
+		t.Fatalf("embedded migrations: %v (%d files)", err, len(names))
+	}
+	for _, name := range names {
+		sql, _ := fs.ReadFile(migrations.FS, name)
+		if objs := parseMigration(string(sql)); len(objs.relations)+len(objs.columns)+len(objs.constraints) == 0 {
+			t.Errorf("%s creates no table, index, column or constraint", name)
+		}
+	}
+}
+
+func TestPrintAndFailed(t *testing.T) {
+	results := []Result{ConfigResult(nil), {Name: "redis", Status: Fail, Detail: "connection refused"}}
+	var buf bytes.Buffer
+	Print(&buf, results)
+	if out := buf.String(); !strings.Contains(out, "CHECK") || !strings.Contains(out, "connection refused") {
+		t.Fatalf("table = %q", out)
+	}
+	if !Failed(results) || Failed(results[:1]) {
+		t.Fatal("Failed should report only the failing set")
+	}
+	if r := ConfigResult(errors.New("bad")); r.Status != Fail {
+		t.Fatalf("ConfigResult(err) = %+v", r)
+	}
+}
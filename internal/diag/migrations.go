@@ -0,0 +1,138 @@
+package diag
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/migrations"
+)
+
+// Migrations are applied by the Postgres entrypoint, which keeps no record of
+// them, so a migration counts as applied when every table, index, added
+// column and added constraint it creates exists.
+var (
+	createTableRe = regexp.MustCompile(`(?i)create\s+table\s+(?:if\s+not\s+exists\s+)?(\w+)`)
+	createIndexRe = regexp.MustCompile(`(?i)create\s+(?:unique\s+)?index\s+(?:concurrently\s+)?(?:if\s+not\s+exists\s+)?(\w+)`)
+	alterTableRe  = regexp.MustCompile(`(?i)alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?(\w+)`)
+	addColumnRe   = regexp.MustCompile(`(?i)add\s+column\s+(?:if\s+not\s+exists\s+)?(\w+)`)
+	addConstrRe   = regexp.MustCompile(`(?i)add\s+constraint\s+(\w+)`)
+)
+
+// schemaObjects is what one migration creates.
+type schemaObjects struct {
+	// relations are tables and indexes.
+	relations []string
+	// columns are "table.column" pairs added to existing tables.
+	columns []string
+	// constraints are named constraints added to existing tables.
+	constraints []string
+}
+
+// parseMigration lists the objects created by the statements in sql.
+func parseMigration(sql string) schemaObjects {
+	var objs schemaObjects
+	for _, stmt := range strings.Split(stripComments(sql), ";") {
+		for _, m := range createTableRe.FindAllStringSubmatch(stmt, -1) {
+			objs.relations = append(objs.relations, strings.ToLower(m[1]))
+		}
+		for _, m := range createIndexRe.FindAllStringSubmatch(stmt, -1) {
+			objs.relations = append(objs.relations, strings.ToLower(m[1]))
+		}
+		if t := alterTableRe.FindStringSubmatch(stmt); t != nil {
+			for _, m := range addColumnRe.FindAllStringSubmatch(stmt, -1) {
+				objs.columns = append(objs.columns, strings.ToLower(t[1]+"."+m[1]))
+			}
+			for _, m := range addConstrRe.FindAllStringSubmatch(stmt, -1) {
+				objs.constraints = append(objs.constraints, strings.ToLower(m[1]))
+			}
+		}
+	}
+	return objs
+}
+
+func stripComments(sql string) string {
+	lines := strings.Split(sql, "\n")
+	for i, l := range lines {
+		if j := strings.Index(l, "--"); j >= 0 {
+			lines[i] = l[:j]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkMigrations reports the newest embedded migration the database has
+// applied and fails naming every migration with missing objects.
+func checkMigrations(ctx context.Context, db *pgxpool.Pool) (string, error) {
+	names, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		return "", err
+	}
+	slices.Sort(names)
+
+	var applied string
+	var pending []string
+	for _, name := range names {
+		sql, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			return "", err
+		}
+		missing, err := missingObjects(ctx, db, parseMigration(string(sql)))
+		if err != nil {
+			return "", err
+		}
+		if len(missing) > 0 {
+			pending = append(pending, fmt.Sprintf("%s (missing %s)", name, strings.Join(missing, ", ")))
+			continue
+		}
+		applied = name
+	}
+	if len(pending) > 0 {
+		return "", fmt.Errorf("%d of %d migrations not applied: %s", len(pending), len(names), strings.Join(pending, "; "))
+	}
+	return fmt.Sprintf("at %s (%d migrations)", applied, len(names)), nil
+}
+
+func missingObjects(ctx context.Context, db *pgxpool.Pool, objs schemaObjects) ([]string, error) {
+	var missing []string
+	for _, rel := range objs.relations {
+		var ok bool
+		if err := db.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, rel).Scan(&ok); err != nil {
+			return nil, err
+		}
+		if !ok {
+			missing = append(missing, rel)
+		}
+	}
+	for _, col := range objs.columns {
+		table, column, _ := strings.Cut(col, ".")
+		var ok bool
+		err := db.QueryRow(ctx, `SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2)`, table, column).Scan(&ok)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			missing = append(missing, col)
+		}
+	}
+	for _, con := range objs.constraints {
+		var ok bool
+		err := db.QueryRow(ctx, `SELECT EXISTS (
+			SELECT 1 FROM pg_constraint c JOIN pg_namespace n ON n.oid = c.connamespace
+			WHERE n.nspname = current_schema() AND c.conname = $1)`, con).Scan(&ok)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			missing = append(missing, con)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,84 @@
+// README: Error reporting for failures a code path cannot return — logged with context, counted, and optionally sent to Sentry.
+package errreport
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ark/internal/sandbox"
+)
+
+// reported counts reported errors by "<component>.<op>", e.g.
+// "order.append_event". Exposed on /api/ops/debug/vars.
+var reported = expvar.NewMap("errors_reported")
+
+// sinkTimeout bounds one delivery to the sink.
+const sinkTimeout = 5 * time.Second
+
+// Event is one reported error.
+type Event struct {
+	// Component is the module that hit the error, e.g. "order".
+	Component string
+	// Op names what failed, e.g. "append_event".
+	Op     string
+	Err    error
+	Fields map[string]string
+	Time   time.Time
+}
+
+// Sink forwards reported errors to an external tracker.
+type Sink interface {
+	Send(ctx context.Context, e Event) error
+}
+
+var (
+	mu   sync.RWMutex
+	sink Sink
+)
+
+// SetSink makes Report forward every error to s; nil only logs and counts.
+func SetSink(s Sink) {
+	mu.Lock()
+	sink = s
+	mu.Unlock()
+}
+
+// Report makes an error the caller cannot return observable. kv are
+// alternating keys and values describing what was being done, e.g.
+// "order_id", o.ID. Sandbox traffic is logged and counted but never sent
+// to the sink. A nil err is ignored.
+func Report(ctx context.Context, component, op string, err error, kv ...any) {
+	if err == nil {
+		return
+	}
+	reported.Add(component+"."+op, 1)
+
+	fields := make(map[string]string, len(kv)/2)
+	var b strings.Builder
+	for i := 0; i+1 < len(kv); i += 2 {
+		k, v := fmt.Sprint(kv[i]), fmt.Sprint(kv[i+1])
+		fields[k] = v
+		fmt.Fprintf(&b, " %s=%s", k, v)
+	}
+	log.Printf("%s: %s: %v%s", component, op, err, b.String())
+
+	mu.RLock()
+	s := sink
+	mu.RUnlock()
+	if s == nil || sandbox.Enabled(ctx) {
+		return
+	}
+	e := Event{Component: component, Op: op, Err: err, Fields: fields, Time: time.Now()}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sinkTimeout)
+		defer cancel()
+		if err := s.Send(ctx, e); err != nil {
+			log.Printf("errreport: send %s.%s: %v", component, op, err)
+		}
+	}()
+}
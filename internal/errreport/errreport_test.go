@@ -0,0 +1,107 @@
+package errreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ark/internal/sandbox"
+)
+
+type chanSink chan Event
+
+func (c chanSink) Send(_ context.Context, e Event) error {
+	c <- e
+	return nil
+}
+
+func count(key string) int64 {
+	if v, ok := reported.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestReport_CountsAndForwards(t *testing.T) {
+	sink := make(chanSink, 1)
+	SetSink(sink)
+	defer SetSink(nil)
+
+	before := count("test.forward")
+	Report(context.Background(), "test", "forward", errors.New("boom"), "order_id", "o1")
+	if got := count("test.forward") - before; got != 1 {
+		t.Fatalf("counted %d, want 1", got)
+	}
+	select {
+	case e := <-sink:
+		if e.Component != "test" || e.Op != "forward" || e.Err.Error() != "boom" || e.Fields["order_id"] != "o1" {
+			t.Fatalf("event = %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not forwarded to sink")
+	}
+}
+
+func TestReport_SandboxAndNilStayLocal(t *testing.T) {
+	sink := make(chanSink, 1)
+	SetSink(sink)
+	defer SetSink(nil)
+
+	before := count("test.local")
+	Report(sandbox.WithContext(context.Background()), "test", "local", errors.New("boom"))
+	Report(context.Background(), "test", "local", nil)
+	if got := count("test.local") - before; got != 1 {
+		t.Fatalf("counted %d, want 1 (sandbox counted, nil ignored)", got)
+	}
+	select {
+	case e := <-sink:
+		t.Fatalf("unexpected event %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNewSentrySink(t *testing.T) {
+	s, err := NewSentrySink("https://abc@o1.ingest.sentry.io/42", "staging")
+	if err != nil {
+		t.Fatalf("NewSentrySink: %v", err)
+	}
+	if s.Endpoint != "https://o1.ingest.sentry.io/api/42/store/" || s.Key != "abc" {
+		t.Fatalf("endpoint=%q key=%q", s.Endpoint, s.Key)
+	}
+	if _, err := NewSentrySink("https://o1.ingest.sentry.io/42", ""); err == nil {
+		t.Fatal("expected error for a DSN without key")
+	}
+}
+
+func TestSentrySink_Send(t *testing.T) {
+	var (
+		auth string
+		body map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("X-Sentry-Auth")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer srv.Close()
+
+	s, err := NewSentrySink(strings.Replace(srv.URL, "://", "://key1@", 1)+"/7", "staging")
+	if err != nil {
+		t.Fatalf("NewSentrySink: %v", err)
+	}
+	e := Event{Component: "order", Op: "append_event", Err: errors.New("db down"), Fields: map[string]string{"order_id": "o1"}, Time: time.Now()}
+	if err := s.Send(context.Background(), e); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !strings.Contains(auth, "sentry_key=key1") {
+		t.Fatalf("auth = %q", auth)
+	}
+	if body["message"] != "append_event: db down" || body["environment"] != "staging" {
+		t.Fatalf("body = %v", body)
+	}
+}
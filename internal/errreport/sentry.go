@@ -0,0 +1,94 @@
+// README: Sentry sink — posts reported errors to a Sentry project through its store endpoint.
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SentrySink sends events to the project of a Sentry DSN
+// (https://<key>@<host>/<project>).
+type SentrySink struct {
+	// Endpoint is the project's store URL and Key its public key, both
+	// taken from the DSN.
+	Endpoint    string
+	Key         string
+	Environment string
+	Client      *http.Client
+}
+
+// NewSentrySink returns a sink for dsn tagging events with environment.
+func NewSentrySink(dsn, environment string) (*SentrySink, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sentry dsn: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, fmt.Errorf("sentry dsn: want https://<key>@<host>/<project>")
+	}
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, project := path[:max(i, 0)], path[i+1:]
+	if project == "" {
+		return nil, fmt.Errorf("sentry dsn: missing project id")
+	}
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+	return &SentrySink{
+		Endpoint:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		Key:         u.User.Username(),
+		Environment: environment,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Send posts e as a Sentry error event.
+func (s *SentrySink) Send(ctx context.Context, e Event) error {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]any{
+		"event_id":    hex.EncodeToString(id[:]),
+		"timestamp":   e.Time.UTC().Format(time.RFC3339),
+		"level":       "error",
+		"platform":    "go",
+		"logger":      e.Component,
+		"environment": s.Environment,
+		"message":     e.Op + ": " + e.Err.Error(),
+		"tags":        map[string]string{"component": e.Component, "op": e.Op},
+		"extra":       e.Fields,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", "Sentry sentry_version=7, sentry_client=ark/1.0, sentry_key="+s.Key)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sentry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// README: Feature flags — features rolled out to a percentage of users or regions and switched off at once, served from memory.
+package flags
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ark/internal/types"
+)
+
+// Flags guarding features that are still being rolled out.
+const (
+	SurgePricing     = "surge_pricing"
+	DispatchV2       = "dispatch_v2"
+	AIBookingHandoff = "ai_booking_handoff"
+)
+
+// ErrInvalidFlag is returned when saving a flag without a key or with a
+// percent outside 0..100.
+var ErrInvalidFlag = errors.New("flags: key must be set and percent between 0 and 100")
+
+// changedChannel is the Redis channel a saved flag's key is published on, so
+// every instance reloads at once instead of at its next refresh.
+const changedChannel = "flags:changed"
+
+// Flag is one feature switch.
+type Flag struct {
+	Key     string
+	Enabled bool
+	// Percent of users (or of regions, for checks without a user) the flag
+	// is on for; the same user always lands in the same bucket.
+	Percent int
+	// Regions limits the flag to these region IDs; empty means everywhere.
+	Regions     []string
+	Description string
+	UpdatedAt   time.Time
+}
+
+func (f *Flag) validate() error {
+	if f.Key == "" || len(f.Key) > 64 || f.Percent < 0 || f.Percent > 100 {
+		return ErrInvalidFlag
+	}
+	return nil
+}
+
+// on reports whether f is on for the user in region. The bucket is derived
+// from the flag key too, so each flag rolls out to a different slice of users.
+func (f *Flag) on(userID types.ID, regionID string) bool {
+	if !f.Enabled {
+		return false
+	}
+	if len(f.Regions) > 0 && !slices.Contains(f.Regions, regionID) {
+		return false
+	}
+	if f.Percent >= 100 {
+		return true
+	}
+	subject := string(userID)
+	if subject == "" {
+		subject = regionID
+	}
+	if subject == "" {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(f.Key + ":" + subject))
+	return int(h.Sum32()%100) < f.Percent
+}
+
+// Service answers flag checks from memory. Refresh reloads the flags from the
+// store; Save applies a change locally and tells the other instances.
+type Service struct {
+	store FlagStore
+	redis *redis.Client
+	now   func() time.Time
+
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+// NewService returns a Service with no flags loaded; call Refresh or
+// RunRefresh before relying on it. Until then every flag is off.
+func NewService(store FlagStore) *Service {
+	return &Service{store: store, now: time.Now, flags: map[string]Flag{}}
+}
+
+// SetBroadcast publishes saved flags over rdb and makes RunRefresh reload
+// when another instance saves one, so a switched-off feature stops everywhere
+// within moments.
+func (s *Service) SetBroadcast(rdb *redis.Client) {
+	s.redis = rdb
+}
+
+// Enabled reports whether the flag key is on for the user in region. Unknown
+// flags are off. userID may be empty for checks not tied to a user, which
+// then roll out by region.
+func (s *Service) Enabled(key string, userID types.ID, regionID string) bool {
+	s.mu.RLock()
+	f, ok := s.flags[key]
+	s.mu.RUnlock()
+	return ok && f.on(userID, regionID)
+}
+
+// Refresh reloads every flag from the store.
+func (s *Service) Refresh(ctx context.Context) error {
+	fs, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]Flag, len(fs))
+	for _, f := range fs {
+		loaded[f.Key] = f
+	}
+	s.mu.Lock()
+	s.flags = loaded
+	s.mu.Unlock()
+	return nil
+}
+
+// RunRefresh calls Refresh every interval, and whenever another instance
+// saves a flag, until ctx is done.
+func (s *Service) RunRefresh(ctx context.Context, interval time.Duration) {
+	if err := s.Refresh(ctx); err != nil {
+		log.Printf("flags: refresh: %v", err)
+	}
+	var changed <-chan *redis.Message
+	if s.redis != nil {
+		sub := s.redis.Subscribe(ctx, changedChannel)
+		defer sub.Close()
+		changed = sub.Channel()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changed:
+		}
+		if err := s.Refresh(ctx); err != nil {
+			log.Printf("flags: refresh: %v", err)
+		}
+	}
+}
+
+// Get returns the flag with key as currently loaded.
+func (s *Service) Get(key string) (Flag, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.flags[key]
+	return f, ok
+}
+
+// List returns every flag ordered by key.
+func (s *Service) List() []Flag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Flag, 0, len(s.flags))
+	for _, f := range s.flags {
+		out = append(out, f)
+	}
+	slices.SortFunc(out, func(a, b Flag) int { return strings.Compare(a.Key, b.Key) })
+	return out
+}
+
+// Save creates or replaces f. It takes effect on this instance immediately
+// and on the others once the broadcast or their next refresh arrives.
+func (s *Service) Save(ctx context.Context, f Flag) (Flag, error) {
+	if err := f.validate(); err != nil {
+		return Flag{}, err
+	}
+	f.UpdatedAt = s.now()
+	if err := s.store.Save(ctx, &f); err != nil {
+		return Flag{}, err
+	}
+	s.mu.Lock()
+	s.flags[f.Key] = f
+	s.mu.Unlock()
+	log.Printf("flags: %s saved (enabled=%t percent=%d regions=%v)", f.Key, f.Enabled, f.Percent, f.Regions)
+	if s.redis != nil {
+		if err := s.redis.Publish(ctx, changedChannel, f.Key).Err(); err != nil {
+			log.Printf("flags: broadcast %s: %v", f.Key, err)
+		}
+	}
+	return f, nil
+}
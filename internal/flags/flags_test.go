@@ -0,0 +1,90 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"ark/internal/types"
+)
+
+type memStore struct {
+	flags map[string]Flag
+}
+
+func (m *memStore) List(context.Context) ([]Flag, error) {
+	out := make([]Flag, 0, len(m.flags))
+	for _, f := range m.flags {
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func (m *memStore) Save(_ context.Context, f *Flag) error {
+	m.flags[f.Key] = *f
+	return nil
+}
+
+func TestFlag_On(t *testing.T) {
+	f := Flag{Key: DispatchV2, Enabled: true, Percent: 30}
+	on := 0
+	for i := range 1000 {
+		id := types.ID(fmt.Sprintf("user-%d", i))
+		if f.on(id, "") != f.on(id, "") {
+			t.Fatalf("%s not bucketed consistently", id)
+		}
+		if f.on(id, "") {
+			on++
+		}
+	}
+	if on < 250 || on > 350 {
+		t.Errorf("on for %d of 1000 users at 30%%", on)
+	}
+
+	all := Flag{Key: SurgePricing, Enabled: true, Percent: 100, Regions: []string{"tpe"}}
+	if !all.on("u1", "tpe") || all.on("u1", "khh") {
+		t.Error("region list not applied")
+	}
+	all.Enabled = false
+	if all.on("u1", "tpe") {
+		t.Error("disabled flag is on")
+	}
+	if (&Flag{Key: SurgePricing, Enabled: true, Percent: 50}).on("", "") {
+		t.Error("partial rollout on without a user or region")
+	}
+}
+
+func TestService_SaveAndRefresh(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{flags: map[string]Flag{
+		AIBookingHandoff: {Key: AIBookingHandoff, Enabled: true, Percent: 100},
+	}}
+	svc := NewService(store)
+	if svc.Enabled(AIBookingHandoff, "u1", "tpe") {
+		t.Fatal("flag on before the first refresh")
+	}
+	if err := svc.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !svc.Enabled(AIBookingHandoff, "u1", "tpe") || svc.Enabled("unknown", "u1", "tpe") {
+		t.Fatal("Enabled does not follow the loaded flags")
+	}
+
+	// Killing a flag takes effect without waiting for a refresh.
+	f, _ := svc.Get(AIBookingHandoff)
+	f.Enabled = false
+	if _, err := svc.Save(ctx, f); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Enabled(AIBookingHandoff, "u1", "tpe") || store.flags[AIBookingHandoff].Enabled {
+		t.Fatal("killed flag still on")
+	}
+
+	if _, err := svc.Save(ctx, Flag{Key: SurgePricing, Percent: 120}); !errors.Is(err, ErrInvalidFlag) {
+		t.Fatalf("percent 120: err = %v, want ErrInvalidFlag", err)
+	}
+	if got := svc.List(); len(got) != 1 || got[0].Key != AIBookingHandoff {
+		t.Fatalf("List = %+v", got)
+	}
+}
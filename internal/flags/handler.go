@@ -0,0 +1,116 @@
+// README: Feature flag HTTP handlers — ops listing and flipping of feature flags.
+//
+// Endpoints:
+//
+//	GET /api/ops/flags       — every flag and its rollout (ops key)
+//	PUT /api/ops/flags/:key  — create or change a flag (ops key)
+//
+// Auth: routes require the ops key middleware.
+package flags
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler holds the feature flag HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// saveFlagReq changes only the fields it sets, so {"enabled": false} kills a
+// feature without losing its rollout. A new flag defaults to off at 100%.
+type saveFlagReq struct {
+	Enabled     *bool     `json:"enabled"`
+	Percent     *int      `json:"percent"`
+	Regions     *[]string `json:"regions"`
+	Description *string   `json:"description"`
+}
+
+type flagResp struct {
+	Key         string   `json:"key"`
+	Enabled     bool     `json:"enabled"`
+	Percent     int      `json:"percent"`
+	Regions     []string `json:"regions"`
+	Description string   `json:"description"`
+	UpdatedAt   int64    `json:"updated_at,omitempty"`
+}
+
+func toFlagResp(f Flag) flagResp {
+	out := flagResp{
+		Key:         f.Key,
+		Enabled:     f.Enabled,
+		Percent:     f.Percent,
+		Regions:     f.Regions,
+		Description: f.Description,
+	}
+	if out.Regions == nil {
+		out.Regions = []string{}
+	}
+	if !f.UpdatedAt.IsZero() {
+		out.UpdatedAt = f.UpdatedAt.Unix()
+	}
+	return out
+}
+
+// List handles GET /api/ops/flags.
+func (h *Handler) List(c *gin.Context) {
+	fs := h.svc.List()
+	out := make([]flagResp, len(fs))
+	for i, f := range fs {
+		out[i] = toFlagResp(f)
+	}
+	c.JSON(http.StatusOK, map[string]any{"flags": out})
+}
+
+// Save handles PUT /api/ops/flags/:key.
+func (h *Handler) Save(c *gin.Context) {
+	var req saveFlagReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	key := c.Param("key")
+	f, ok := h.svc.Get(key)
+	if !ok {
+		f = Flag{Key: key, Percent: 100}
+	}
+	if req.Enabled != nil {
+		f.Enabled = *req.Enabled
+	}
+	if req.Percent != nil {
+		f.Percent = *req.Percent
+	}
+	if req.Regions != nil {
+		f.Regions = *req.Regions
+	}
+	if req.Description != nil {
+		f.Description = *req.Description
+	}
+	saved, err := h.svc.Save(c.Request.Context(), f)
+	if err != nil {
+		writeFlagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toFlagResp(saved))
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeFlagError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidFlag):
+		writeError(c, http.StatusBadRequest, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,13 @@
+// README: Feature flag route registration — mounts the ops flag endpoints.
+package flags
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the feature flag endpoints onto the provided ops router group.
+//
+//	GET /api/ops/flags
+//	PUT /api/ops/flags/:key
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/flags", h.List)
+	rg.PUT("/api/ops/flags/:key", h.Save)
+}
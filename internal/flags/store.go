@@ -0,0 +1,61 @@
+// README: Feature flag store — PostgreSQL persistence for feature flags.
+package flags
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FlagStore defines the persistence operations required by the Service.
+type FlagStore interface {
+	// List returns every flag.
+	List(ctx context.Context) ([]Flag, error)
+	// Save creates f or replaces the flag with its key.
+	Save(ctx context.Context, f *Flag) error
+}
+
+// Store is the PostgreSQL implementation of FlagStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) List(ctx context.Context) ([]Flag, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT key, enabled, percent, regions, description, updated_at
+		FROM feature_flags
+		ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Flag, error) {
+		var f Flag
+		err := row.Scan(&f.Key, &f.Enabled, &f.Percent, &f.Regions, &f.Description, &f.UpdatedAt)
+		return f, err
+	})
+}
+
+func (s *Store) Save(ctx context.Context, f *Flag) error {
+	regions := f.Regions
+	if regions == nil {
+		regions = []string{}
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO feature_flags (key, enabled, percent, regions, description, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET
+		    enabled     = EXCLUDED.enabled,
+		    percent     = EXCLUDED.percent,
+		    regions     = EXCLUDED.regions,
+		    description = EXCLUDED.description,
+		    updated_at  = EXCLUDED.updated_at`,
+		f.Key, f.Enabled, f.Percent, regions, f.Description, f.UpdatedAt,
+	)
+	return err
+}
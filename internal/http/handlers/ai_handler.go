@@ -1,16 +1,20 @@
-// README: AI chat handler (token-guarded Gemini chat).
+// README: AI chat handler (token-guarded Gemini chat, typed or voice) and the admin LLM spend report.
 package handlers
 
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"ark/internal/ai"
 	"ark/internal/modules/aiusage"
+	"ark/internal/types"
 )
 
 type AIHandler struct {
@@ -24,10 +28,27 @@ func NewAIHandler(aiSvc *aiusage.Service) *AIHandler {
 type aiChatReq struct {
 	UID     string `json:"uid"`
 	Message string `json:"message"`
+	// AudioRef identifies the recording a client transcribed on the device;
+	// Message then holds that transcript. It is echoed back for correlation.
+	AudioRef string `json:"audio_ref,omitempty"`
 }
 
+// maxAudioRefLen bounds aiChatReq.AudioRef.
+const maxAudioRefLen = 256
+
 // Chat handles POST /api/ai/chat.
+//
+// Text, or a transcript made on the device, is sent as JSON
+// ({"uid", "message", "audio_ref"}). Voice is sent as multipart/form-data
+// with the fields uid and language (optional, e.g. "zh-TW") and the
+// recording in the file field audio; the transcript is answered like a
+// typed message and returned alongside the reply.
 func (h *AIHandler) Chat(c *gin.Context) {
+	if c.ContentType() == "multipart/form-data" {
+		h.voiceChat(c)
+		return
+	}
+
 	var req aiChatReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		writeError(c, http.StatusBadRequest, "invalid json")
@@ -40,24 +61,145 @@ func (h *AIHandler) Chat(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "missing uid or message")
 		return
 	}
-	if !isValidID(req.UID) {
+	if !types.ValidExternalID(req.UID) {
 		writeError(c, http.StatusBadRequest, "invalid uid")
 		return
 	}
+	if len(req.AudioRef) > maxAudioRefLen {
+		writeError(c, http.StatusBadRequest, "audio_ref too long")
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	// Leaves room for the Gemini retry policy.
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 
 	reply, err := h.ai.Chat(ctx, req.UID, req.Message)
 	if err != nil {
-		switch {
-		case errors.Is(err, aiusage.ErrInsufficientTokens):
-			writeError(c, http.StatusTooManyRequests, err.Error())
-		default:
-			writeError(c, http.StatusInternalServerError, "internal error")
+		writeAIError(c, err)
+		return
+	}
+
+	resp := map[string]any{"reply": reply}
+	if req.AudioRef != "" {
+		resp["transcript"] = req.Message
+		resp["audio_ref"] = req.AudioRef
+	}
+	writeJSON(c, http.StatusOK, resp)
+}
+
+// voiceChat handles the multipart form of Chat.
+func (h *AIHandler) voiceChat(c *gin.Context) {
+	// Room for the form fields on top of the recording.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ai.MaxAudioBytes+64<<10)
+	file, err := c.FormFile("audio")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(c, http.StatusRequestEntityTooLarge, "audio too large")
+			return
 		}
+		writeError(c, http.StatusBadRequest, "missing audio")
+		return
+	}
+	uid := strings.TrimSpace(c.PostForm("uid"))
+	if uid == "" {
+		writeError(c, http.StatusBadRequest, "missing uid")
+		return
+	}
+	if !types.ValidExternalID(uid) {
+		writeError(c, http.StatusBadRequest, "invalid uid")
 		return
 	}
+	if file.Size > ai.MaxAudioBytes {
+		writeError(c, http.StatusRequestEntityTooLarge, "audio too large")
+		return
+	}
+	mimeType, err := ai.AudioMIMEType(file.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(c, http.StatusUnsupportedMediaType, "unsupported audio format; use wav, mp3, aiff, aac, ogg or flac")
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "unreadable audio")
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "unreadable audio")
+		return
+	}
+
+	// Transcription and the reply each get the Gemini retry policy.
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	transcript, reply, err := h.ai.VoiceChat(ctx, uid, ai.Audio{
+		Data:     data,
+		MIMEType: mimeType,
+		Language: strings.TrimSpace(c.PostForm("language")),
+	})
+	if err != nil {
+		writeAIError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"reply": reply, "transcript": transcript})
+}
 
-	writeJSON(c, http.StatusOK, map[string]any{"reply": reply})
+// UsageReport handles GET /api/admin/ai/usage?from=&to=&top= (RFC3339
+// bounds, default the last 30 days): tokens and estimated cost per day, the
+// top consumers by cost and each provider's error rate.
+func (h *AIHandler) UsageReport(c *gin.Context) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+	var err error
+	if s := c.Query("from"); s != "" {
+		if from, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid from; expected RFC3339")
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid to; expected RFC3339")
+			return
+		}
+	}
+	top := 0
+	if s := c.Query("top"); s != "" {
+		if top, err = strconv.Atoi(s); err != nil || top < 1 {
+			writeError(c, http.StatusBadRequest, "invalid top")
+			return
+		}
+	}
+
+	report, err := h.ai.UsageReport(c.Request.Context(), from, to, top)
+	switch {
+	case errors.Is(err, aiusage.ErrInvalidRange):
+		writeError(c, http.StatusBadRequest, "from must be before to, at most a year apart")
+	case err != nil:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	default:
+		writeJSON(c, http.StatusOK, report)
+	}
+}
+
+func writeAIError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, aiusage.ErrInsufficientTokens):
+		writeError(c, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, aiusage.ErrVoiceUnavailable):
+		writeError(c, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, ai.ErrUnsupportedAudio):
+		writeError(c, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, ai.ErrNoSpeech):
+		writeError(c, http.StatusUnprocessableEntity, "could not hear any speech; please try again")
+	case errors.Is(err, ai.ErrQuotaExceeded), errors.Is(err, ai.ErrUnavailable):
+		c.Header("Retry-After", "30")
+		writeError(c, http.StatusServiceUnavailable, "assistant is busy, try again shortly")
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
 }
@@ -2,30 +2,29 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"ark/internal/modules/order"
+	"ark/internal/types"
 )
 
 type errorResponse struct {
 	Error string `json:"error"`
 }
 
-// isValidID ensures IDs contain only alphanumeric characters, hyphens, and
-// underscores (compatible with both internal hex IDs and Firebase UIDs).
-func isValidID(v string) bool {
-	if len(v) == 0 || len(v) > 128 {
-		return false
+// optionalID returns nil for an empty ID so optional references stay unset.
+func optionalID(v string) *types.ID {
+	if v == "" {
+		return nil
 	}
-	for _, c := range v {
-		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '-' || c == '_' {
-			continue
-		}
-		return false
-	}
-	return true
+	id := types.ID(v)
+	return &id
 }
 
 func writeJSON(c *gin.Context, status int, v any) {
@@ -36,15 +35,92 @@ func writeError(c *gin.Context, status int, msg string) {
 	writeJSON(c, status, errorResponse{Error: msg})
 }
 
+// writeOrderError maps order errors, wrapped or not, to responses. State
+// errors also report the order's status and version so the client can refresh
+// before retrying.
 func writeOrderError(c *gin.Context, err error) {
-	switch err {
-	case order.ErrBadRequest:
+	var stateErr *order.StateError
+	var throttleErr *order.ThrottleError
+	var dupErr *order.DuplicateError
+	switch {
+	case errors.As(err, &throttleErr):
+		writeThrottleError(c, throttleErr)
+	case errors.As(err, &dupErr):
+		writeDuplicateError(c, dupErr)
+	case errors.As(err, &stateErr):
+		writeJSON(c, http.StatusConflict, map[string]any{
+			"error":          err.Error(),
+			"order_id":       string(stateErr.OrderID),
+			"status":         string(stateErr.Status),
+			"status_version": stateErr.Version,
+		})
+	case errors.Is(err, order.ErrBadRequest):
 		writeError(c, http.StatusBadRequest, err.Error())
-	case order.ErrNotFound:
+	case errors.Is(err, order.ErrOutsideServiceArea), errors.Is(err, order.ErrOutsideRegion):
+		writeError(c, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, order.ErrNotFound):
 		writeError(c, http.StatusNotFound, err.Error())
-	case order.ErrInvalidState, order.ErrActiveOrder, order.ErrConflict:
+	case errors.Is(err, order.ErrActorNotAllowed), errors.Is(err, order.ErrPolicyDenied):
+		writeError(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, order.ErrInvalidState), errors.Is(err, order.ErrActiveOrder), errors.Is(err, order.ErrConflict),
+		errors.Is(err, order.ErrVehicleMismatch), errors.Is(err, order.ErrDispatchInFlight),
+		errors.Is(err, order.ErrOutsideClaimWindow), errors.Is(err, order.ErrNotAtPickup), errors.Is(err, order.ErrNotAtDropoff),
+		errors.Is(err, order.ErrProofRequired), errors.Is(err, order.ErrNotDelivery), errors.Is(err, order.ErrNotCharter),
+		errors.Is(err, order.ErrOfferLapsed):
 		writeError(c, http.StatusConflict, err.Error())
 	default:
 		writeError(c, http.StatusInternalServerError, "internal error")
 	}
 }
+
+// writeDuplicateError answers 409 with the ride the order repeats. When
+// can_rebook is true the app asks "did you mean to rebook?" and resends the
+// order with rebook set; otherwise it points to the ride already booked.
+func writeDuplicateError(c *gin.Context, err *order.DuplicateError) {
+	of := err.Of
+	dup := map[string]any{
+		"order_id":    string(of.ID),
+		"status":      string(of.Status),
+		"ride_type":   of.RideType,
+		"pickup_lat":  of.Pickup.Lat,
+		"pickup_lng":  of.Pickup.Lng,
+		"dropoff_lat": of.Dropoff.Lat,
+		"dropoff_lng": of.Dropoff.Lng,
+	}
+	if of.ScheduledAt != nil {
+		dup["scheduled_at"] = of.ScheduledAt.UTC().Format(time.RFC3339)
+	}
+	if of.CancelledAt != nil {
+		dup["cancelled_at"] = of.CancelledAt.UTC().Format(time.RFC3339)
+	}
+	hint := "you already booked this ride for about the same time"
+	if err.Rebookable() {
+		hint = "did you mean to rebook the ride you just cancelled?"
+	}
+	writeJSON(c, http.StatusConflict, map[string]any{
+		"error":        err.Error(),
+		"code":         "possible_duplicate",
+		"hint":         hint,
+		"can_rebook":   err.Rebookable(),
+		"duplicate_of": dup,
+	})
+}
+
+// writeThrottleError answers 429 with a code the app tells apart: it offers
+// to retry after order_create_throttled but points to support after
+// cancel_limit_reached.
+func writeThrottleError(c *gin.Context, err *order.ThrottleError) {
+	code := "order_create_throttled"
+	if errors.Is(err, order.ErrCancelLimit) {
+		code = "cancel_limit_reached"
+	}
+	retryAfter := int(math.Ceil(err.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	writeJSON(c, http.StatusTooManyRequests, map[string]any{
+		"error":               err.Error(),
+		"code":                code,
+		"limit":               err.Limit,
+		"window_seconds":      int(err.Window.Seconds()),
+		"retry_after_seconds": retryAfter,
+	})
+}
@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -85,7 +86,7 @@ func (h *CalendarHandler) CreateEvent(c *gin.Context) {
 // EditEvent handles PUT /api/calendar/events/:id.
 func (h *CalendarHandler) EditEvent(c *gin.Context) {
 	id := c.Param("id")
-	if id == "" || !isValidID(id) {
+	if id == "" || !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid event id")
 		return
 	}
@@ -124,7 +125,7 @@ func (h *CalendarHandler) EditEvent(c *gin.Context) {
 // DeleteEvent handles DELETE /api/calendar/events/:id.
 func (h *CalendarHandler) DeleteEvent(c *gin.Context) {
 	id := c.Param("id")
-	if id == "" || !isValidID(id) {
+	if id == "" || !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid event id")
 		return
 	}
@@ -152,7 +153,7 @@ func (h *CalendarHandler) CreateAndTieOrder(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "missing fields")
 		return
 	}
-	if !isValidID(req.EventID) {
+	if !types.ValidID(req.EventID) {
 		writeError(c, http.StatusBadRequest, "invalid event_id")
 		return
 	}
@@ -179,7 +180,7 @@ func (h *CalendarHandler) CreateAndTieOrder(c *gin.Context) {
 // The authenticated user_id from context is used as the schedule UID.
 func (h *CalendarHandler) UntieOrder(c *gin.Context) {
 	eventID := c.Param("event_id")
-	if eventID == "" || !isValidID(eventID) {
+	if eventID == "" || !types.ValidID(eventID) {
 		writeError(c, http.StatusBadRequest, "invalid event id")
 		return
 	}
@@ -215,12 +216,26 @@ func (h *CalendarHandler) ListSchedules(c *gin.Context) {
 }
 
 func writeCalendarError(c *gin.Context, err error) {
-	switch err {
-	case calendar.ErrBadRequest, order.ErrBadRequest, order.ErrActiveOrder:
+	if errors.Is(err, order.ErrCancelFeeRequired) {
+		writeError(c, http.StatusConflict, "the tied ride now has a cancellation fee; cancel it from the ride instead")
+		return
+	}
+	var throttleErr *order.ThrottleError
+	if errors.As(err, &throttleErr) {
+		writeThrottleError(c, throttleErr)
+		return
+	}
+	var dupErr *order.DuplicateError
+	if errors.As(err, &dupErr) {
+		writeDuplicateError(c, dupErr)
+		return
+	}
+	switch {
+	case errors.Is(err, calendar.ErrBadRequest), errors.Is(err, order.ErrBadRequest), errors.Is(err, order.ErrActiveOrder):
 		writeError(c, http.StatusBadRequest, err.Error())
-	case calendar.ErrNotFound, order.ErrNotFound:
+	case errors.Is(err, calendar.ErrNotFound), errors.Is(err, order.ErrNotFound):
 		writeError(c, http.StatusNotFound, err.Error())
-	case order.ErrInvalidState, order.ErrConflict:
+	case errors.Is(err, order.ErrInvalidState), errors.Is(err, order.ErrConflict):
 		writeError(c, http.StatusConflict, err.Error())
 	default:
 		writeError(c, http.StatusInternalServerError, "internal error")
@@ -0,0 +1,214 @@
+// README: Location handler — live position updates (HTTP and driver WebSocket stream) and passenger "looking for ride" presence endpoints.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"ark/internal/http/middleware"
+	"ark/internal/modules/location"
+	"ark/internal/types"
+)
+
+// LocationHandler exposes live-location endpoints.
+type LocationHandler struct {
+	svc *location.Service
+}
+
+// NewLocationHandler returns a LocationHandler wired to the given service.
+func NewLocationHandler(svc *location.Service) *LocationHandler {
+	return &LocationHandler{svc: svc}
+}
+
+type presenceReq struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// SetPresence handles PUT /api/passenger/presence.
+// It marks the authenticated passenger as looking for a ride at the given
+// position; clients refresh it periodically while searching.
+func (h *LocationHandler) SetPresence(c *gin.Context) {
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req presenceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	err := h.svc.SetPassengerSeeking(c.Request.Context(), types.ID(userID), types.Point{Lat: req.Lat, Lng: req.Lng})
+	if err != nil {
+		if errors.Is(err, location.ErrInvalidPosition) {
+			writeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"status": "looking_for_ride"})
+}
+
+// ClearPresence handles DELETE /api/passenger/presence.
+func (h *LocationHandler) ClearPresence(c *gin.Context) {
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.ClearPassengerSeeking(c.Request.Context(), types.ID(userID)); err != nil {
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"status": "idle"})
+}
+
+type locationReq struct {
+	// UserID, when sent, must be the authenticated user.
+	UserID   string  `json:"user_id,omitempty"`
+	UserType string  `json:"user_type"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+	// Seq orders updates from one client; updates not newer than the last
+	// accepted one are rejected as stale. Omit to skip the check.
+	Seq int64 `json:"seq,omitempty"`
+}
+
+// UpdateLocation handles POST /api/location/update.
+// The position is recorded for the authenticated user as user_type.
+func (h *LocationHandler) UpdateLocation(c *gin.Context) {
+	var req locationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	h.updatePosition(c, req.UserID, location.Update{UserType: req.UserType, Position: types.Point{Lat: req.Lat, Lng: req.Lng}, Seq: req.Seq})
+}
+
+// UpdatePassengerLocation handles PUT /api/passengers/:id/location.
+// Passengers may only update their own position.
+func (h *LocationHandler) UpdatePassengerLocation(c *gin.Context) {
+	var req presenceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	h.updatePosition(c, c.Param("id"), location.Update{UserType: "passenger", Position: types.Point{Lat: req.Lat, Lng: req.Lng}})
+}
+
+// updatePosition records u for the caller; claimedID, when not empty, must
+// match the authenticated user. Throttled updates get 429 and stale ones 409;
+// while live positions cannot be stored it answers 503 with a Retry-After.
+func (h *LocationHandler) updatePosition(c *gin.Context, claimedID string, u location.Update) {
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if claimedID != "" && claimedID != userID {
+		writeError(c, http.StatusForbidden, "can only update your own location")
+		return
+	}
+	u.UserID = types.ID(userID)
+	if err := h.svc.UpdatePosition(c.Request.Context(), u); err != nil {
+		switch {
+		case errors.Is(err, location.ErrInvalidPosition) || errors.Is(err, location.ErrInvalidUserType):
+			writeError(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, location.ErrThrottled):
+			writeError(c, http.StatusTooManyRequests, err.Error())
+		case errors.Is(err, location.ErrStaleUpdate):
+			writeError(c, http.StatusConflict, err.Error())
+		case errors.Is(err, location.ErrUnavailable):
+			c.Header("Retry-After", "5")
+			writeError(c, http.StatusServiceUnavailable, "location updates are temporarily unavailable, try again shortly")
+		default:
+			writeError(c, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"user_id": userID, "user_type": u.UserType})
+}
+
+// Driver location stream limits: a connection idle for streamIdleTimeout is
+// closed, and frames larger than maxFrameBytes are refused.
+const (
+	streamIdleTimeout = time.Minute
+	maxFrameBytes     = 1 << 10
+)
+
+// locationFrame is one position sent on the driver location stream. Seq must
+// increase with every frame; the ack echoes it.
+type locationFrame struct {
+	Seq int64   `json:"seq"`
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// locationAck answers one frame. Result is accepted, throttled, stale,
+// invalid, unavailable or error; only accepted frames were recorded.
+type locationAck struct {
+	Seq    int64  `json:"seq"`
+	Result string `json:"result"`
+}
+
+// StreamDriverLocation handles GET /api/location/stream, a WebSocket on which
+// the authenticated driver sends locationFrame JSON messages and receives a
+// locationAck for each. Frames go through the same validation and throttle
+// as POST /api/location/update, without a request per update.
+func (h *LocationHandler) StreamDriverLocation(c *gin.Context) {
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	ctx := c.Request.Context()
+	// Server without a Handshake accepts clients that send no Origin (the
+	// driver app); the stream is authenticated like any API request.
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		ws.MaxPayloadBytes = maxFrameBytes
+		for {
+			_ = ws.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+			var f locationFrame
+			if err := websocket.JSON.Receive(ws, &f); err != nil {
+				return
+			}
+			ack := locationAck{Seq: f.Seq, Result: "invalid"}
+			if f.Seq > 0 {
+				ack.Result = updateResult(h.svc.UpdatePosition(ctx, location.Update{
+					UserID:   types.ID(userID),
+					UserType: "driver",
+					Position: types.Point{Lat: f.Lat, Lng: f.Lng},
+					Seq:      f.Seq,
+				}))
+			}
+			if err := websocket.JSON.Send(ws, ack); err != nil {
+				return
+			}
+		}
+	}}.ServeHTTP(c.Writer, c.Request)
+}
+
+// updateResult names the outcome of UpdatePosition for a stream ack.
+func updateResult(err error) string {
+	switch {
+	case err == nil:
+		return location.AdmitAccepted
+	case errors.Is(err, location.ErrThrottled):
+		return location.AdmitThrottled
+	case errors.Is(err, location.ErrStaleUpdate):
+		return location.AdmitStale
+	case errors.Is(err, location.ErrInvalidPosition):
+		return "invalid"
+	case errors.Is(err, location.ErrUnavailable):
+		return "unavailable"
+	default:
+		return "error"
+	}
+}
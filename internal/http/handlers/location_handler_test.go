@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/net/websocket"
+
+	"ark/internal/http/middleware"
+	"ark/internal/modules/location"
+	"ark/internal/types"
+)
+
+// fakeLocationBackend records position updates; queries are unused here.
+type fakeLocationBackend struct {
+	location.LocationBackend
+	mu      sync.Mutex
+	updated []location.GeoEntry
+}
+
+func (f *fakeLocationBackend) Update(_ context.Context, _ string, e location.GeoEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updated = append(f.updated, e)
+	return nil
+}
+
+type seqGate struct {
+	mu   sync.Mutex
+	last int64
+}
+
+// Admit orders by seq only, so the test does not depend on timing.
+func (g *seqGate) Admit(_ context.Context, _ string, _ types.ID, seq int64, _ time.Time) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if seq <= g.last {
+		return location.AdmitStale, nil
+	}
+	g.last = seq
+	return location.AdmitAccepted, nil
+}
+
+func TestLocationHandler_StreamDriverLocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	backend := &fakeLocationBackend{}
+	svc := location.NewService(nil)
+	svc.SetBackend(backend)
+	svc.SetUpdateGate(&seqGate{})
+	h := NewLocationHandler(svc)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if uid := c.GetHeader("X-Test-User"); uid != "" {
+			c.Request = c.Request.WithContext(middleware.WithUserIDContext(c.Request.Context(), uid))
+		}
+	})
+	r.GET("/api/location/stream", h.StreamDriverLocation)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/location/stream"
+	if _, err := websocket.Dial(url, "", srv.URL); err == nil {
+		t.Fatal("unauthenticated stream was accepted")
+	}
+
+	cfg, err := websocket.NewConfig(url, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Header.Set("X-Test-User", "driver-1")
+	ws, err := websocket.DialConfig(cfg)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+
+	frames := []struct {
+		frame locationFrame
+		want  string
+	}{
+		{locationFrame{Seq: 1, Lat: 25.033, Lng: 121.565}, location.AdmitAccepted},
+		{locationFrame{Seq: 2, Lat: 25.034, Lng: 121.566}, location.AdmitAccepted},
+		{locationFrame{Seq: 2, Lat: 25.035, Lng: 121.567}, location.AdmitStale},
+		{locationFrame{Seq: 3, Lat: 123, Lng: 456}, "invalid"},
+		{locationFrame{Lat: 25.036, Lng: 121.568}, "invalid"},
+	}
+	for _, f := range frames {
+		if err := websocket.JSON.Send(ws, f.frame); err != nil {
+			t.Fatalf("send %+v: %v", f.frame, err)
+		}
+		var ack locationAck
+		if err := websocket.JSON.Receive(ws, &ack); err != nil {
+			t.Fatalf("receive ack for %+v: %v", f.frame, err)
+		}
+		if ack.Seq != f.frame.Seq || ack.Result != f.want {
+			t.Errorf("frame %+v: ack %+v, want %s", f.frame, ack, f.want)
+		}
+	}
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if len(backend.updated) != 2 || backend.updated[1].ID != "driver-1" || backend.updated[1].Pos.Lat != 25.034 {
+		t.Errorf("recorded updates = %+v", backend.updated)
+	}
+}
+
+func TestLocationHandler_UpdateLocation_RedisDown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer rdb.Close()
+	store, err := location.NewStore(context.Background(), nil, rdb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewLocationHandler(location.NewService(store))
+
+	r := gin.New()
+	r.POST("/api/location/update", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(middleware.WithUserIDContext(c.Request.Context(), "driver-1"))
+		h.UpdateLocation(c)
+	})
+	w := httptest.NewRecorder()
+	body := `{"user_type":"driver","lat":25.033,"lng":121.565}`
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/api/location/update", strings.NewReader(body)))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("status = %d, Retry-After = %q; want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+}
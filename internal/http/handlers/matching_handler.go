@@ -0,0 +1,166 @@
+// README: Matching handler — passenger-facing nearby driver preview and wait estimate, and the driver's dispatch offer stream.
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/modules/matching"
+	"ark/internal/types"
+)
+
+// MatchingHandler exposes read-only matching queries to clients.
+type MatchingHandler struct {
+	svc *matching.Service
+}
+
+// NewMatchingHandler returns a MatchingHandler wired to the given service.
+func NewMatchingHandler(svc *matching.Service) *MatchingHandler {
+	return &MatchingHandler{svc: svc}
+}
+
+type previewPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// NearbyDrivers handles GET /api/passengers/nearby-drivers?lat=&lng=.
+// Positions are anonymized and carry no driver IDs.
+func (h *MatchingHandler) NearbyDrivers(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil {
+		writeError(c, http.StatusBadRequest, "lat and lng are required")
+		return
+	}
+	preview, err := h.svc.PreviewNearbyDrivers(c.Request.Context(), types.Point{Lat: lat, Lng: lng})
+	if err != nil {
+		if errors.Is(err, matching.ErrInvalidPosition) {
+			writeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	positions := make([]previewPoint, len(preview.Positions))
+	for i, p := range preview.Positions {
+		positions[i] = previewPoint{Lat: p.Lat, Lng: p.Lng}
+	}
+	resp := map[string]any{
+		"count":       preview.Count,
+		"drivers":     positions,
+		"eta_seconds": nil,
+	}
+	if preview.PickupETA != nil {
+		resp["eta_seconds"] = int(preview.PickupETA.Seconds())
+	}
+	writeJSON(c, http.StatusOK, resp)
+}
+
+// EstimateWait handles GET /api/estimates/wait?lat=&lng=&ride_type=, the
+// expected time-to-match shown before the passenger books.
+func (h *MatchingHandler) EstimateWait(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	rideType := c.Query("ride_type")
+	if errLat != nil || errLng != nil || rideType == "" {
+		writeError(c, http.StatusBadRequest, "lat, lng and ride_type are required")
+		return
+	}
+	est, err := h.svc.EstimateWait(c.Request.Context(), types.Point{Lat: lat, Lng: lng}, rideType)
+	if err != nil {
+		if errors.Is(err, matching.ErrInvalidPosition) {
+			writeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	resp := map[string]any{
+		"zone":         est.Zone,
+		"drivers":      est.Drivers,
+		"wait_seconds": nil,
+		"samples":      est.Samples,
+		"basis":        est.Basis,
+	}
+	if est.Wait != nil {
+		resp["wait_seconds"] = int(est.Wait.Seconds())
+	}
+	writeJSON(c, http.StatusOK, resp)
+}
+
+// StreamOffers handles GET /api/drivers/:id/offers/stream, a server-sent
+// event stream of the caller's dispatch offers. Each "offer" event carries the
+// TTL left when it was sent; "expired" and "withdrawn" events close an offer
+// the driver can no longer accept, and an accept naming its offer_id is then
+// refused. Idle streams get a keepalive comment.
+func (h *MatchingHandler) StreamOffers(c *gin.Context) {
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if c.Param("id") != userID {
+		writeError(c, http.StatusForbidden, "can only stream your own offers")
+		return
+	}
+
+	started := false
+	send := func(e matching.OfferEvent) error {
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+			started = true
+		}
+		if err := writeOfferEvent(c.Writer, e); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	if err := h.svc.StreamOffers(c.Request.Context(), types.ID(userID), send); err != nil {
+		switch {
+		case started:
+			log.Printf("matching: offer stream for driver %s: %v", userID, err)
+		case errors.Is(err, matching.ErrOffersDisabled):
+			writeError(c, http.StatusNotFound, "offer stream not enabled")
+		default:
+			writeError(c, http.StatusInternalServerError, "internal error")
+		}
+	}
+}
+
+// writeOfferEvent writes e in server-sent event format; keepalives are comments.
+func writeOfferEvent(w io.Writer, e matching.OfferEvent) error {
+	if e.Type == matching.EventKeepalive {
+		_, err := io.WriteString(w, ": keepalive\n\n")
+		return err
+	}
+	data := map[string]any{"offer_id": e.OfferID, "order_id": e.OrderID}
+	if o := e.Offer; o != nil {
+		data["pickup"] = map[string]float64{"lat": o.Pickup.Lat, "lng": o.Pickup.Lng}
+		data["dropoff"] = map[string]float64{"lat": o.Dropoff.Lat, "lng": o.Dropoff.Lng}
+		data["ride_type"] = o.RideType
+		data["estimated_fee"] = o.EstimatedFee.Amount
+		data["currency"] = o.EstimatedFee.Currency
+		data["expires_at"] = o.ExpiresAt
+		data["ttl_seconds"] = int(e.TTL.Seconds())
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, b)
+	return err
+}
@@ -2,8 +2,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -66,6 +70,205 @@ func (h *NotificationHandler) EnsureDevice(c *gin.Context) {
 	writeJSON(c, http.StatusOK, map[string]any{"message": "device registered"})
 }
 
+// ---------------------------------------------------------------------------
+// Preferences
+// ---------------------------------------------------------------------------
+
+type quietHoursJSON struct {
+	Start string `json:"start"` // "HH:MM" local time
+	End   string `json:"end"`
+}
+
+type channelsJSON struct {
+	Push *bool `json:"push,omitempty"`
+	SMS  *bool `json:"sms,omitempty"`
+}
+
+// preferencesReq is a partial update: omitted fields keep their current value.
+// Sending quiet_hours with empty start and end clears the window.
+type preferencesReq struct {
+	OrderUpdates *bool           `json:"order_updates,omitempty"`
+	Promos       *bool           `json:"promos,omitempty"`
+	Reminders    *bool           `json:"reminders,omitempty"`
+	QuietHours   *quietHoursJSON `json:"quiet_hours,omitempty"`
+	Timezone     *string         `json:"timezone,omitempty"`
+	Channels     *channelsJSON   `json:"channels,omitempty"`
+	Locale       *string         `json:"locale,omitempty"`
+	// MonthlySummary opts into the monthly trip summary email.
+	MonthlySummary *bool `json:"monthly_summary,omitempty"`
+	// SpokenText adds a read-aloud version of every push (accessibility).
+	SpokenText *bool `json:"spoken_text,omitempty"`
+}
+
+// GetPreferences handles GET /api/notifications/preferences.
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	p, err := h.svc.GetPreferences(c.Request.Context(), types.ID(userID))
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(c, http.StatusOK, preferencesResponse(p))
+}
+
+// UpdatePreferences handles PUT /api/notifications/preferences.
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req preferencesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	ctx := c.Request.Context()
+	p, err := h.svc.GetPreferences(ctx, types.ID(userID))
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if err := applyPreferences(&p, req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p, err = h.svc.UpdatePreferences(ctx, p)
+	if err != nil {
+		if errors.Is(err, notification.ErrInvalidPreferences) {
+			writeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(c, http.StatusOK, preferencesResponse(p))
+}
+
+func applyPreferences(p *notification.Preferences, req preferencesReq) error {
+	if req.OrderUpdates != nil {
+		p.OrderUpdates = *req.OrderUpdates
+	}
+	if req.Promos != nil {
+		p.Promos = *req.Promos
+	}
+	if req.Reminders != nil {
+		p.Reminders = *req.Reminders
+	}
+	if req.Timezone != nil {
+		p.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+	if req.Locale != nil {
+		p.Locale = strings.TrimSpace(*req.Locale)
+	}
+	if req.MonthlySummary != nil {
+		p.MonthlySummary = *req.MonthlySummary
+	}
+	if req.SpokenText != nil {
+		p.SpokenText = *req.SpokenText
+	}
+	if ch := req.Channels; ch != nil {
+		if ch.Push != nil {
+			p.PushEnabled = *ch.Push
+		}
+		if ch.SMS != nil {
+			p.SMSEnabled = *ch.SMS
+		}
+	}
+	if q := req.QuietHours; q != nil {
+		if q.Start == "" && q.End == "" {
+			p.QuietHours = nil
+			return nil
+		}
+		start, err := parseClock(q.Start)
+		if err != nil {
+			return fmt.Errorf("quiet_hours.start: %w", err)
+		}
+		end, err := parseClock(q.End)
+		if err != nil {
+			return fmt.Errorf("quiet_hours.end: %w", err)
+		}
+		p.QuietHours = &notification.QuietHours{StartMin: start, EndMin: end}
+	}
+	return nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("must be HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatClock(min int) string {
+	return fmt.Sprintf("%02d:%02d", min/60, min%60)
+}
+
+func preferencesResponse(p notification.Preferences) map[string]any {
+	var quiet any
+	if q := p.QuietHours; q != nil {
+		quiet = map[string]string{"start": formatClock(q.StartMin), "end": formatClock(q.EndMin)}
+	}
+	return map[string]any{
+		"order_updates":   p.OrderUpdates,
+		"promos":          p.Promos,
+		"reminders":       p.Reminders,
+		"quiet_hours":     quiet,
+		"timezone":        p.Timezone,
+		"channels":        map[string]bool{"push": p.PushEnabled, "sms": p.SMSEnabled},
+		"locale":          p.Locale,
+		"monthly_summary": p.MonthlySummary,
+		"spoken_text":     p.SpokenText,
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Dead letters (ops)
+// ---------------------------------------------------------------------------
+
+// ListDeadLetters handles GET /api/ops/notifications/dead-letters.
+// Only undelivered messages are listed unless ?include_resolved=true.
+func (h *NotificationHandler) ListDeadLetters(c *gin.Context) {
+	list, err := h.svc.DeadLetters(c.Request.Context(), c.Query("include_resolved") == "true")
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"dead_letters": list})
+}
+
+// RetryDeadLetter handles POST /api/ops/notifications/dead-letters/:id/retry.
+// A failed resend answers 502 with the dead letter and its new error.
+func (h *NotificationHandler) RetryDeadLetter(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	d, err := h.svc.RetryDeadLetter(c.Request.Context(), id)
+	switch {
+	case err == nil:
+		writeJSON(c, http.StatusOK, d)
+	case errors.Is(err, notification.ErrRetryFailed):
+		writeJSON(c, http.StatusBadGateway, d)
+	case errors.Is(err, notification.ErrDeadLetterNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, notification.ErrDeadLetterResolved):
+		writeError(c, http.StatusConflict, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
+
 // SendNotification handles POST /api/notifications/send (staff only — TODO).
 func (h *NotificationHandler) SendNotification(c *gin.Context) {
 	writeError(c, http.StatusNotImplemented, "not implemented")
@@ -2,31 +2,116 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"io"
+	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"ark/internal/http/middleware"
 	"ark/internal/modules/order"
+	"ark/internal/modules/triproute"
 	"ark/internal/types"
 )
 
 type OrderHandler struct {
 	order *order.Service
+	nav   Navigator
 }
 
 func NewOrderHandler(svc *order.Service) *OrderHandler {
 	return &OrderHandler{order: svc}
 }
 
+// Navigator builds the navigation handoff of a driver's order
+// (triproute.Service).
+type Navigator interface {
+	Navigation(ctx context.Context, driverID, orderID types.ID) (*triproute.Navigation, error)
+}
+
+// SetNavigator makes accepting and claiming a ride return its navigation
+// handoff, so the driver app needs no Directions quota of its own.
+func (h *OrderHandler) SetNavigator(n Navigator) {
+	h.nav = n
+}
+
+// writeHandoff answers a driver taking on an order with its new status and,
+// when available, the navigation handoff. A failed lookup only leaves it
+// out: the driver can fetch it from the navigation endpoint.
+func (h *OrderHandler) writeHandoff(c *gin.Context, status order.Status, driverID, orderID types.ID) {
+	out := map[string]any{"status": status}
+	if h.nav != nil {
+		n, err := h.nav.Navigation(c.Request.Context(), driverID, orderID)
+		if err != nil {
+			log.Printf("order: navigation for order %s: %v", orderID, err)
+		} else {
+			out["navigation"] = triproute.ToNavigationResp(n)
+		}
+	}
+	writeJSON(c, http.StatusOK, out)
+}
+
 type createOrderReq struct {
 	PickupLat  float64 `json:"pickup_lat"`
 	PickupLng  float64 `json:"pickup_lng"`
 	DropoffLat float64 `json:"dropoff_lat"`
 	DropoffLng float64 `json:"dropoff_lng"`
 	RideType   string  `json:"ride_type"`
+	// Mode "fastest" quotes the best nearby driver's pickup ETA and only books
+	// when it is within the threshold; empty books a regular instant order.
+	Mode string `json:"mode,omitempty"`
+	// Notes and Requirements are shown to the assigned driver only;
+	// requirements also restrict which drivers are offered the order.
+	Notes        string   `json:"notes,omitempty"`
+	Requirements []string `json:"requirements,omitempty"`
+	// PassengerCount of 5-6 requires a six-seater; HasPet a pet-friendly car.
+	PassengerCount int  `json:"passenger_count,omitempty"`
+	HasPet         bool `json:"has_pet,omitempty"`
+	// OrgID bills the ride to the passenger's organization, subject to its policy.
+	OrgID string `json:"org_id,omitempty"`
+	// Rebook confirms repeating a ride cancelled moments ago, after a 409
+	// possible_duplicate answer.
+	Rebook bool `json:"rebook,omitempty"`
+	// Delivery is required for ride_type "delivery": the parcel's recipient
+	// and weight class.
+	Delivery *deliveryReq `json:"delivery,omitempty"`
+	// Car is required for ride_type "designated": the passenger's own car
+	// the driver drives.
+	Car *carReq `json:"car,omitempty"`
+	// CharterHours is required for ride_type "charter": how many hours the
+	// driver is booked for. The dropoff may be left out to end at the pickup.
+	CharterHours int `json:"charter_hours,omitempty"`
+}
+
+type deliveryReq struct {
+	RecipientName  string `json:"recipient_name"`
+	RecipientPhone string `json:"recipient_phone"`
+	// WeightClass is small (up to 5 kg, the default), medium (20 kg) or
+	// large (50 kg).
+	WeightClass string `json:"weight_class,omitempty"`
+}
+
+type carReq struct {
+	Plate string `json:"plate"`
+	Model string `json:"model,omitempty"`
+	Color string `json:"color,omitempty"`
+	// Transmission is automatic (the default) or manual.
+	Transmission string `json:"transmission,omitempty"`
+}
+
+type deliverReq struct {
+	SignedBy string `json:"signed_by"`
+}
+
+type stopReq struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
 }
 
 func (h *OrderHandler) Create(c *gin.Context) {
@@ -44,12 +129,35 @@ func (h *OrderHandler) Create(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "missing fields")
 		return
 	}
-	id, err := h.order.Create(c.Request.Context(), order.CreateCommand{
-		PassengerID: types.ID(userID),
-		Pickup:      types.Point{Lat: req.PickupLat, Lng: req.PickupLng},
-		Dropoff:     types.Point{Lat: req.DropoffLat, Lng: req.DropoffLng},
-		RideType:    req.RideType,
-	})
+	cmd := order.CreateCommand{
+		PassengerID:    types.ID(userID),
+		Pickup:         types.Point{Lat: req.PickupLat, Lng: req.PickupLng},
+		Dropoff:        types.Point{Lat: req.DropoffLat, Lng: req.DropoffLng},
+		RideType:       req.RideType,
+		Notes:          req.Notes,
+		Requirements:   req.Requirements,
+		PassengerCount: req.PassengerCount,
+		HasPet:         req.HasPet,
+		OrgID:          optionalID(req.OrgID),
+		Rebook:         req.Rebook,
+		CharterHours:   req.CharterHours,
+	}
+	if d := req.Delivery; d != nil {
+		cmd.Delivery = &order.Delivery{RecipientName: d.RecipientName, RecipientPhone: d.RecipientPhone, WeightClass: d.WeightClass}
+	}
+	if car := req.Car; car != nil {
+		cmd.Car = &order.Car{Plate: car.Plate, Model: car.Model, Color: car.Color, Transmission: car.Transmission}
+	}
+	switch req.Mode {
+	case "":
+	case "fastest":
+		h.createFastest(c, cmd)
+		return
+	default:
+		writeError(c, http.StatusBadRequest, "mode must be empty or fastest")
+		return
+	}
+	id, err := h.order.Create(c.Request.Context(), cmd)
 	if err != nil {
 		writeOrderError(c, err)
 		return
@@ -57,13 +165,40 @@ func (h *OrderHandler) Create(c *gin.Context) {
 	writeJSON(c, http.StatusCreated, map[string]any{"order_id": id, "status": order.StatusWaiting})
 }
 
+// createFastest books a fastest-pickup order. When no driver can arrive within
+// the threshold it responds 409 with the quote and a scheduled-order suggestion.
+func (h *OrderHandler) createFastest(c *gin.Context, cmd order.CreateCommand) {
+	id, quote, err := h.order.CreateFastest(c.Request.Context(), cmd)
+	if errors.Is(err, order.ErrPickupTooFar) {
+		resp := map[string]any{"error": err.Error(), "suggest": "scheduled", "eta_seconds": nil}
+		if quote.DriverAvailable {
+			resp["eta_seconds"] = int(quote.ETA.Seconds())
+		}
+		writeJSON(c, http.StatusConflict, resp)
+		return
+	}
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	if quote.Queued {
+		writeJSON(c, http.StatusCreated, map[string]any{"order_id": id, "status": order.StatusWaiting, "pickup_eta_seconds": nil, "queued": true})
+		return
+	}
+	writeJSON(c, http.StatusCreated, map[string]any{
+		"order_id":           id,
+		"status":             order.StatusWaiting,
+		"pickup_eta_seconds": int(quote.ETA.Seconds()),
+	})
+}
+
 func (h *OrderHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		writeError(c, http.StatusBadRequest, "missing order id")
 		return
 	}
-	if !isValidID(id) {
+	if !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid order id")
 		return
 	}
@@ -75,19 +210,22 @@ func (h *OrderHandler) Get(c *gin.Context) {
 	writeJSON(c, http.StatusOK, map[string]any{"order_id": o.ID, "status": o.Status})
 }
 
+// Status returns the order's status. It supports conditional GET: the ETag is
+// the status_version, so a poll carrying a current If-None-Match gets an empty
+// 304. With ?wait=<duration> (at most maxStatusWait) such a poll is held open
+// until the version changes or the wait ends.
 func (h *OrderHandler) Status(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		writeError(c, http.StatusBadRequest, "missing order id")
 		return
 	}
-	if !isValidID(id) {
+	if !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid order id")
 		return
 	}
-	o, err := h.order.Get(c.Request.Context(), types.ID(id))
-	if err != nil {
-		writeOrderError(c, err)
+	o, ok := pollOrderStatus(c, h.order, types.ID(id))
+	if !ok {
 		return
 	}
 	resp := map[string]any{
@@ -98,39 +236,261 @@ func (h *OrderHandler) Status(c *gin.Context) {
 	if o.DriverID != nil {
 		resp["driver_id"] = *o.DriverID
 	}
+	// Notes carry things like gate codes: only the passenger and the assigned driver see them.
+	if uid, ok := middleware.UserIDFromContext(c.Request.Context()); ok && isParticipant(o, uid) {
+		resp["notes"] = o.Notes
+		resp["requirements"] = o.Requirements
+		resp["fare"] = fareBreakdown(o)
+		resp["dropoff"] = map[string]float64{"lat": o.Dropoff.Lat, "lng": o.Dropoff.Lng}
+		if o.OriginalDropoff != nil {
+			resp["original_dropoff"] = map[string]float64{"lat": o.OriginalDropoff.Lat, "lng": o.OriginalDropoff.Lng}
+		}
+		if o.PendingDropoff != nil && o.Status == order.StatusDriving {
+			resp["pending_dropoff"] = pendingDropoff(o.PendingDropoff)
+		}
+		if o.PendingRequote != nil && uid == string(o.PassengerID) {
+			resp["pending_requote"] = pendingRequote(o.PendingRequote)
+		}
+		if o.ArrivalWindow != nil {
+			resp["arrival_window"] = arrivalWindow(o.ArrivalWindow, o.ArrivedAt)
+		}
+		if o.Delivery != nil {
+			resp["delivery"] = delivery(o.Delivery)
+		}
+		// On a designated drive the vehicle is the passenger's.
+		if o.Car != nil {
+			resp["car"] = map[string]any{
+				"plate":        o.Car.Plate,
+				"model":        o.Car.Model,
+				"color":        o.Car.Color,
+				"transmission": o.Car.Transmission,
+			}
+		}
+		if o.CharterHours > 0 {
+			charter := map[string]any{"hours": o.CharterHours}
+			if end := o.CharterEndsAt(); end != nil {
+				charter["ends_at"] = end.Unix()
+			}
+			resp["charter"] = charter
+		}
+	}
 	writeJSON(c, http.StatusOK, resp)
 }
 
+// fareBreakdown shows the fare and the ride plan benefit and ride credits
+// taken off it; due is what the passenger is charged. A designated drive's
+// return allowance and a charter's overtime are part of the fare and shown
+// as their own lines.
+func fareBreakdown(o *order.Order) map[string]any {
+	fare := o.Fare()
+	out := map[string]any{
+		"estimated":            o.EstimatedFee.Amount,
+		"subscription_applied": o.SubscriptionApplied,
+		"credits_applied":      o.CreditsApplied,
+		"due":                  o.AmountDue().Amount,
+		"currency":             fare.Currency,
+	}
+	if o.ActualFee != nil {
+		out["actual"] = o.ActualFee.Amount
+	}
+	if o.CancelFee > 0 {
+		out["cancellation_fee"] = o.CancelFee
+	}
+	if o.ReturnAllowance > 0 {
+		out["return_allowance"] = o.ReturnAllowance
+	}
+	if o.OvertimeFee > 0 {
+		out["overtime"] = o.OvertimeFee
+	}
+	return out
+}
+
+// delivery shows a delivery's recipient and, once delivered, who signed for
+// the parcel.
+func delivery(d *order.Delivery) map[string]any {
+	out := map[string]any{
+		"recipient_name":  d.RecipientName,
+		"recipient_phone": d.RecipientPhone,
+		"weight_class":    d.WeightClass,
+	}
+	if d.SignedBy != "" {
+		out["signed_by"] = d.SignedBy
+	}
+	return out
+}
+
+func isParticipant(o *order.Order, uid string) bool {
+	return string(o.PassengerID) == uid || (o.DriverID != nil && string(*o.DriverID) == uid)
+}
+
+// maxStatusWait caps ?wait on status polls so long-polls stay under common proxy idle timeouts.
+const maxStatusWait = 30 * time.Second
+
+// pollOrderStatus loads order id for a status poll and handles If-None-Match
+// and ?wait. It sets the ETag and returns the order when the caller should
+// write a body; otherwise it has already written the response.
+func pollOrderStatus(c *gin.Context, svc *order.Service, id types.ID) (*order.Order, bool) {
+	var wait time.Duration
+	if raw := c.Query("wait"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			writeError(c, http.StatusBadRequest, "invalid wait")
+			return nil, false
+		}
+		wait = min(d, maxStatusWait)
+	}
+
+	ctx := c.Request.Context()
+	o, err := svc.Get(ctx, id)
+	if err != nil {
+		writeOrderError(c, err)
+		return nil, false
+	}
+	if wait > 0 && etagMatches(c.GetHeader("If-None-Match"), statusETag(o.StatusVersion)) {
+		waitCtx, cancel := context.WithTimeout(ctx, wait)
+		o, err = svc.WaitForChange(waitCtx, id, o.StatusVersion)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				writeOrderError(c, err)
+			}
+			return nil, false
+		}
+	}
+
+	etag := statusETag(o.StatusVersion)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return nil, false
+	}
+	return o, true
+}
+
+func statusETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// etagMatches implements the weak comparison If-None-Match uses.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+type cancelReq struct {
+	// AcceptFee confirms the cancellation fee last quoted to the passenger;
+	// the cancellation goes through if the fee is still at most this much.
+	AcceptFee *int64 `json:"accept_fee,omitempty"`
+}
+
+// Cancel handles POST /api/orders/:id/cancel. A passenger cancelling an
+// instant ride past its free-cancel grace period gets 409 with the fee until
+// they send it back as accept_fee.
 func (h *OrderHandler) Cancel(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		writeError(c, http.StatusBadRequest, "missing order id")
+	o, role, ok := h.authorizeParticipant(c, order.ActorPassenger, order.ActorDriver)
+	if !ok {
 		return
 	}
-	if !isValidID(id) {
-		writeError(c, http.StatusBadRequest, "invalid order id")
+	var req cancelReq
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		writeError(c, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	// Check before cancellation whether this is a scheduled order past its free-cancel deadline.
 	// The order is still cancelled (MVP), but we inform the client so they can show the appropriate message.
-	lateCancel := false
-	if o, err := h.order.Get(c.Request.Context(), types.ID(id)); err == nil {
-		if o.OrderType == "scheduled" && o.CancelDeadlineAt != nil && time.Now().After(*o.CancelDeadlineAt) {
-			lateCancel = true
-		}
-	}
+	lateCancel := o.OrderType == "scheduled" && o.CancelDeadlineAt != nil && time.Now().After(*o.CancelDeadlineAt)
 
-	err := h.order.Cancel(c.Request.Context(), order.CancelCommand{
-		OrderID:   types.ID(id),
-		ActorType: "passenger",
-		Reason:    "user_cancel",
+	reason := "user_cancel"
+	if role == order.ActorDriver {
+		reason = "driver_cancel"
+	}
+	q, err := h.order.CancelQuoted(c.Request.Context(), order.CancelCommand{
+		OrderID:   o.ID,
+		ActorType: role,
+		Reason:    reason,
+		AcceptFee: req.AcceptFee,
+	})
+	if errors.Is(err, order.ErrCancelFeeRequired) {
+		writeJSON(c, http.StatusConflict, map[string]any{"error": err.Error(), "cancellation": cancellationQuote(q)})
+		return
+	}
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{
+		"status":           order.StatusCancelled,
+		"late_cancel":      lateCancel,
+		"cancellation_fee": q.Fee.Amount,
+		"currency":         q.Fee.Currency,
 	})
+}
+
+// CancellationQuote handles GET /api/orders/:id/cancellation: what the
+// passenger would pay to cancel now, to show before they confirm.
+func (h *OrderHandler) CancellationQuote(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorPassenger)
+	if !ok {
+		return
+	}
+	q, err := h.order.QuoteCancellation(c.Request.Context(), o.ID)
 	if err != nil {
 		writeOrderError(c, err)
 		return
 	}
-	writeJSON(c, http.StatusOK, map[string]any{"status": order.StatusCancelled, "late_cancel": lateCancel})
+	writeJSON(c, http.StatusOK, cancellationQuote(q))
+}
+
+func cancellationQuote(q order.CancellationQuote) map[string]any {
+	return map[string]any{
+		"fee":                q.Fee.Amount,
+		"currency":           q.Fee.Currency,
+		"free_until":         q.FreeUntil,
+		"driver_progress_km": q.DriverProgressKm,
+	}
+}
+
+// authorizeParticipant loads the :id order and returns the caller's role in it,
+// which must be one of roles (order.ActorPassenger or order.ActorDriver). It
+// writes the error response itself and returns ok=false when the caller is not
+// authenticated or does not take part in the order in an allowed role.
+func (h *OrderHandler) authorizeParticipant(c *gin.Context, roles ...string) (*order.Order, string, bool) {
+	id := c.Param("id")
+	if id == "" {
+		writeError(c, http.StatusBadRequest, "missing order id")
+		return nil, "", false
+	}
+	if !types.ValidID(id) {
+		writeError(c, http.StatusBadRequest, "invalid order id")
+		return nil, "", false
+	}
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return nil, "", false
+	}
+	o, err := h.order.Get(c.Request.Context(), types.ID(id))
+	if err != nil {
+		writeOrderError(c, err)
+		return nil, "", false
+	}
+	for _, role := range roles {
+		switch {
+		case role == order.ActorPassenger && o.PassengerID == types.ID(userID):
+			return o, role, true
+		case role == order.ActorDriver && o.DriverID != nil && *o.DriverID == types.ID(userID):
+			return o, role, true
+		}
+	}
+	writeError(c, http.StatusForbidden, "not a participant of this order")
+	return nil, "", false
 }
 
 // Match is a temporary MVP endpoint to move order from waiting -> approaching.
@@ -140,7 +500,7 @@ func (h *OrderHandler) Match(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "missing order id")
 		return
 	}
-	if !isValidID(id) {
+	if !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid order id")
 		return
 	}
@@ -160,13 +520,22 @@ func (h *OrderHandler) Match(c *gin.Context) {
 	writeJSON(c, http.StatusOK, map[string]any{"status": order.StatusApproaching})
 }
 
+type acceptReq struct {
+	// StatusVersion, when sent, is the status_version of the offer; the
+	// accept is refused with 409 if the order (e.g. its pickup) changed since.
+	StatusVersion *int `json:"status_version,omitempty"`
+	// OfferID, when sent, is the streamed offer being accepted; the accept is
+	// refused with 409 once that offer has expired or been withdrawn.
+	OfferID int64 `json:"offer_id,omitempty"`
+}
+
 func (h *OrderHandler) Accept(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		writeError(c, http.StatusBadRequest, "missing order id")
 		return
 	}
-	if !isValidID(id) {
+	if !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid order id")
 		return
 	}
@@ -175,15 +544,22 @@ func (h *OrderHandler) Accept(c *gin.Context) {
 		writeError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
+	var req acceptReq
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		writeError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
 	err := h.order.Accept(c.Request.Context(), order.AcceptCommand{
-		OrderID:  types.ID(id),
-		DriverID: types.ID(driverID),
+		OrderID:       types.ID(id),
+		DriverID:      types.ID(driverID),
+		ExpectVersion: req.StatusVersion,
+		OfferID:       req.OfferID,
 	})
 	if err != nil {
 		writeOrderError(c, err)
 		return
 	}
-	writeJSON(c, http.StatusOK, map[string]any{"status": order.StatusApproaching})
+	h.writeHandoff(c, order.StatusApproaching, types.ID(driverID), types.ID(id))
 }
 
 func (h *OrderHandler) Deny(c *gin.Context) {
@@ -192,7 +568,7 @@ func (h *OrderHandler) Deny(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "missing order id")
 		return
 	}
-	if !isValidID(id) {
+	if !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid order id")
 		return
 	}
@@ -214,16 +590,11 @@ func (h *OrderHandler) Deny(c *gin.Context) {
 }
 
 func (h *OrderHandler) Arrive(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		writeError(c, http.StatusBadRequest, "missing order id")
-		return
-	}
-	if !isValidID(id) {
-		writeError(c, http.StatusBadRequest, "invalid order id")
+	o, _, ok := h.authorizeParticipant(c, order.ActorDriver)
+	if !ok {
 		return
 	}
-	err := h.order.Arrive(c.Request.Context(), order.ArriveCommand{OrderID: types.ID(id)})
+	err := h.order.Arrive(c.Request.Context(), order.ArriveCommand{OrderID: o.ID})
 	if err != nil {
 		writeOrderError(c, err)
 		return
@@ -232,16 +603,11 @@ func (h *OrderHandler) Arrive(c *gin.Context) {
 }
 
 func (h *OrderHandler) Meet(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		writeError(c, http.StatusBadRequest, "missing order id")
-		return
-	}
-	if !isValidID(id) {
-		writeError(c, http.StatusBadRequest, "invalid order id")
+	o, _, ok := h.authorizeParticipant(c, order.ActorDriver)
+	if !ok {
 		return
 	}
-	err := h.order.Meet(c.Request.Context(), order.MeetCommand{OrderID: types.ID(id)})
+	err := h.order.Meet(c.Request.Context(), order.MeetCommand{OrderID: o.ID})
 	if err != nil {
 		writeOrderError(c, err)
 		return
@@ -250,16 +616,32 @@ func (h *OrderHandler) Meet(c *gin.Context) {
 }
 
 func (h *OrderHandler) Complete(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		writeError(c, http.StatusBadRequest, "missing order id")
+	o, _, ok := h.authorizeParticipant(c, order.ActorDriver)
+	if !ok {
 		return
 	}
-	if !isValidID(id) {
-		writeError(c, http.StatusBadRequest, "invalid order id")
+	err := h.order.Complete(c.Request.Context(), order.CompleteCommand{OrderID: o.ID})
+	if err != nil {
+		writeOrderError(c, err)
 		return
 	}
-	err := h.order.Complete(c.Request.Context(), order.CompleteCommand{OrderID: types.ID(id)})
+	writeJSON(c, http.StatusOK, map[string]any{"status": order.StatusPayment})
+}
+
+// Deliver finishes a delivery at the dropoff once the driver has uploaded the
+// proof of delivery photo and the recipient's signature; deliveries cannot
+// use Complete.
+func (h *OrderHandler) Deliver(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorDriver)
+	if !ok {
+		return
+	}
+	var req deliverReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	err := h.order.Deliver(c.Request.Context(), order.DeliverCommand{OrderID: o.ID, DriverID: *o.DriverID, SignedBy: req.SignedBy})
 	if err != nil {
 		writeOrderError(c, err)
 		return
@@ -267,18 +649,65 @@ func (h *OrderHandler) Complete(c *gin.Context) {
 	writeJSON(c, http.StatusOK, map[string]any{"status": order.StatusPayment})
 }
 
-// Pay is a temporary MVP endpoint to move order from payment -> complete.
-func (h *OrderHandler) Pay(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		writeError(c, http.StatusBadRequest, "missing order id")
+// LogStop handles POST /api/orders/:id/stops: the chartered driver logs a
+// stop while driving. Body: {"lat": 25.03, "lng": 121.56}
+func (h *OrderHandler) LogStop(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorDriver)
+	if !ok {
 		return
 	}
-	if !isValidID(id) {
-		writeError(c, http.StatusBadRequest, "invalid order id")
+	var req stopReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
 		return
 	}
-	err := h.order.Pay(c.Request.Context(), order.PayCommand{OrderID: types.ID(id)})
+	stop, err := h.order.LogStop(c.Request.Context(), order.StopCommand{
+		OrderID:  o.ID,
+		DriverID: *o.DriverID,
+		Position: types.Point{Lat: req.Lat, Lng: req.Lng},
+	})
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusCreated, stopView(*stop))
+}
+
+// Stops handles GET /api/orders/:id/stops: the stops logged on a charter,
+// for its passenger and driver.
+func (h *OrderHandler) Stops(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorPassenger, order.ActorDriver)
+	if !ok {
+		return
+	}
+	stops, err := h.order.Stops(c.Request.Context(), o.ID)
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	items := make([]map[string]any, len(stops))
+	for i, st := range stops {
+		items[i] = stopView(st)
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"items": items})
+}
+
+func stopView(st order.Stop) map[string]any {
+	return map[string]any{
+		"id":  st.ID,
+		"lat": st.Position.Lat,
+		"lng": st.Position.Lng,
+		"at":  st.At.Unix(),
+	}
+}
+
+// Pay is a temporary MVP endpoint to move order from payment -> complete.
+func (h *OrderHandler) Pay(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorPassenger)
+	if !ok {
+		return
+	}
+	err := h.order.Pay(c.Request.Context(), order.PayCommand{OrderID: o.ID})
 	if err != nil {
 		writeOrderError(c, err)
 		return
@@ -289,13 +718,22 @@ func (h *OrderHandler) Pay(c *gin.Context) {
 // --- Scheduled-order endpoints ---
 
 type createScheduledReq struct {
-	PickupLat          float64 `json:"pickup_lat"`
-	PickupLng          float64 `json:"pickup_lng"`
-	DropoffLat         float64 `json:"dropoff_lat"`
-	DropoffLng         float64 `json:"dropoff_lng"`
-	RideType           string  `json:"ride_type"`
-	ScheduledAt        string  `json:"scheduled_at"`         // RFC3339
-	ScheduleWindowMins int     `json:"schedule_window_mins"` // minutes before scheduled_at to open for claiming
+	PickupLat          float64  `json:"pickup_lat"`
+	PickupLng          float64  `json:"pickup_lng"`
+	DropoffLat         float64  `json:"dropoff_lat"`
+	DropoffLng         float64  `json:"dropoff_lng"`
+	RideType           string   `json:"ride_type"`
+	ScheduledAt        string   `json:"scheduled_at"`         // RFC3339
+	ScheduleWindowMins int      `json:"schedule_window_mins"` // minutes before scheduled_at to open for claiming
+	Notes              string   `json:"notes,omitempty"`
+	Requirements       []string `json:"requirements,omitempty"`
+	PassengerCount     int      `json:"passenger_count,omitempty"`
+	HasPet             bool     `json:"has_pet,omitempty"`
+	TransitType        string   `json:"transit_type,omitempty"`   // "flight" or "train" for airport/HSR pickups
+	TransitNumber      string   `json:"transit_number,omitempty"` // e.g. "BR12" or "0123"
+	ArriveBy           string   `json:"arrive_by,omitempty"`      // RFC3339; when the passenger must reach the dropoff
+	OrgID              string   `json:"org_id,omitempty"`
+	Rebook             bool     `json:"rebook,omitempty"` // confirms repeating a ride cancelled moments ago
 }
 
 // CreateScheduled handles POST /api/orders/scheduled.
@@ -323,6 +761,15 @@ func (h *OrderHandler) CreateScheduled(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "invalid scheduled_at; expected RFC3339")
 		return
 	}
+	var arriveBy *time.Time
+	if req.ArriveBy != "" {
+		t, err := time.Parse(time.RFC3339, req.ArriveBy)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "invalid arrive_by; expected RFC3339")
+			return
+		}
+		arriveBy = &t
+	}
 	id, err := h.order.CreateScheduled(c.Request.Context(), order.CreateScheduledCommand{
 		PassengerID:        types.ID(userID),
 		Pickup:             types.Point{Lat: req.PickupLat, Lng: req.PickupLng},
@@ -330,6 +777,15 @@ func (h *OrderHandler) CreateScheduled(c *gin.Context) {
 		RideType:           req.RideType,
 		ScheduledAt:        scheduledAt,
 		ScheduleWindowMins: req.ScheduleWindowMins,
+		Notes:              req.Notes,
+		Requirements:       req.Requirements,
+		PassengerCount:     req.PassengerCount,
+		HasPet:             req.HasPet,
+		TransitType:        req.TransitType,
+		TransitNumber:      req.TransitNumber,
+		ArriveBy:           arriveBy,
+		OrgID:              optionalID(req.OrgID),
+		Rebook:             req.Rebook,
 	})
 	if err != nil {
 		writeOrderError(c, err)
@@ -338,6 +794,205 @@ func (h *OrderHandler) CreateScheduled(c *gin.Context) {
 	writeJSON(c, http.StatusCreated, map[string]any{"order_id": id, "status": order.StatusScheduled})
 }
 
+type amendScheduleReq struct {
+	ScheduledAt string `json:"scheduled_at"` // RFC3339
+}
+
+// AmendSchedule handles PATCH /api/orders/:id/schedule (passenger moves a scheduled pickup).
+func (h *OrderHandler) AmendSchedule(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorPassenger)
+	if !ok {
+		return
+	}
+	var req amendScheduleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	scheduledAt, err := time.Parse(time.RFC3339, req.ScheduledAt)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid scheduled_at; expected RFC3339")
+		return
+	}
+	err = h.order.AmendSchedule(c.Request.Context(), order.AmendScheduleCommand{
+		OrderID:     o.ID,
+		ScheduledAt: scheduledAt,
+		ActorType:   order.ActorPassenger,
+		Reason:      "passenger",
+	})
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"order_id": o.ID, "scheduled_at": scheduledAt})
+}
+
+type changePickupReq struct {
+	PickupLat float64 `json:"pickup_lat"`
+	PickupLng float64 `json:"pickup_lng"`
+	// StatusVersion, when sent, must match the order's current status_version.
+	StatusVersion *int `json:"status_version,omitempty"`
+}
+
+// ChangePickup handles PATCH /api/orders/:id/pickup (passenger moves the
+// pickup while still waiting for a driver). Refused with 409 while the order
+// is being offered to drivers; the client retries shortly after.
+func (h *OrderHandler) ChangePickup(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorPassenger)
+	if !ok {
+		return
+	}
+	var req changePickupReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	updated, err := h.order.ChangePickup(c.Request.Context(), order.ChangePickupCommand{
+		OrderID:       o.ID,
+		PassengerID:   o.PassengerID,
+		Pickup:        types.Point{Lat: req.PickupLat, Lng: req.PickupLng},
+		ExpectVersion: req.StatusVersion,
+	})
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{
+		"order_id":       updated.ID,
+		"pickup":         map[string]float64{"lat": updated.Pickup.Lat, "lng": updated.Pickup.Lng},
+		"estimated_fee":  updated.EstimatedFee.Amount,
+		"currency":       updated.EstimatedFee.Currency,
+		"status_version": updated.StatusVersion,
+	})
+}
+
+type changeDropoffReq struct {
+	DropoffLat float64 `json:"dropoff_lat"`
+	DropoffLng float64 `json:"dropoff_lng"`
+	// StatusVersion, when sent, must match the order's current status_version.
+	StatusVersion *int `json:"status_version,omitempty"`
+}
+
+// ChangeDropoff handles PATCH /api/orders/:id/dropoff (passenger proposes a new
+// dropoff mid-trip). The re-estimated fare applies once the driver accepts.
+func (h *OrderHandler) ChangeDropoff(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorPassenger)
+	if !ok {
+		return
+	}
+	var req changeDropoffReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	p, err := h.order.RequestDropoffChange(c.Request.Context(), order.ChangeDropoffCommand{
+		OrderID:       o.ID,
+		PassengerID:   o.PassengerID,
+		Dropoff:       types.Point{Lat: req.DropoffLat, Lng: req.DropoffLng},
+		ExpectVersion: req.StatusVersion,
+	})
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusAccepted, map[string]any{
+		"order_id":        o.ID,
+		"pending_dropoff": pendingDropoff(p),
+	})
+}
+
+type ackDropoffReq struct {
+	Accept        bool `json:"accept"`
+	StatusVersion *int `json:"status_version,omitempty"`
+}
+
+// AckDropoff handles POST /api/orders/:id/dropoff/ack (driver accepts or
+// rejects the passenger's proposed dropoff).
+func (h *OrderHandler) AckDropoff(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorDriver)
+	if !ok {
+		return
+	}
+	var req ackDropoffReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	err := h.order.AckDropoffChange(c.Request.Context(), order.AckDropoffCommand{
+		OrderID:       o.ID,
+		DriverID:      *o.DriverID,
+		Accept:        req.Accept,
+		ExpectVersion: req.StatusVersion,
+	})
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"order_id": o.ID, "accepted": req.Accept})
+}
+
+func pendingDropoff(p *order.DropoffProposal) map[string]any {
+	return map[string]any{
+		"dropoff_lat":   p.Dropoff.Lat,
+		"dropoff_lng":   p.Dropoff.Lng,
+		"estimated_fee": p.Fee.Amount,
+		"currency":      p.Fee.Currency,
+		"requested_at":  p.RequestedAt,
+	}
+}
+
+type answerRequoteReq struct {
+	Accept        bool `json:"accept"`
+	StatusVersion *int `json:"status_version,omitempty"`
+}
+
+// AnswerRequote handles POST /api/orders/:id/requote (passenger accepts the
+// re-quoted fare of a scheduled ride, or declines it and cancels the ride).
+func (h *OrderHandler) AnswerRequote(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorPassenger)
+	if !ok {
+		return
+	}
+	var req answerRequoteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	err := h.order.AnswerRequote(c.Request.Context(), order.AnswerRequoteCommand{
+		OrderID:       o.ID,
+		PassengerID:   o.PassengerID,
+		Accept:        req.Accept,
+		ExpectVersion: req.StatusVersion,
+	})
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"order_id": o.ID, "accepted": req.Accept})
+}
+
+func pendingRequote(p *order.RequoteProposal) map[string]any {
+	return map[string]any{
+		"estimated_fee": p.Fee.Amount,
+		"currency":      p.Fee.Currency,
+		"requested_at":  p.RequestedAt,
+	}
+}
+
+// arrivalWindow shows the promised arrival of an arrive-by booking and, once
+// the trip is over, when it actually arrived and by how much it was late.
+func arrivalWindow(w *order.ArrivalWindow, arrivedAt *time.Time) map[string]any {
+	out := map[string]any{
+		"from": w.From,
+		"to":   w.To,
+	}
+	if arrivedAt != nil {
+		out["arrived_at"] = *arrivedAt
+		out["late_seconds"] = int64(w.Late(*arrivedAt).Seconds())
+	}
+	return out
+}
+
 // ListScheduledByPassenger handles GET /api/orders/scheduled.
 func (h *OrderHandler) ListScheduledByPassenger(c *gin.Context) {
 	passengerID, ok := middleware.UserIDFromContext(c.Request.Context())
@@ -353,8 +1008,46 @@ func (h *OrderHandler) ListScheduledByPassenger(c *gin.Context) {
 	writeJSON(c, http.StatusOK, map[string]any{"orders": orders})
 }
 
+// ListByDriver handles GET /api/drivers/:id/orders?status=assigned,approaching.
+// Drivers may only list their own orders; status defaults to every active status.
+func (h *OrderHandler) ListByDriver(c *gin.Context) {
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	driverID := c.Param("id")
+	if driverID != userID {
+		writeError(c, http.StatusForbidden, "can only list your own orders")
+		return
+	}
+	var statuses []order.Status
+	if raw := c.Query("status"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			st := order.Status(strings.TrimSpace(part))
+			if !slices.Contains(order.DriverActiveStatuses, st) {
+				writeError(c, http.StatusBadRequest, "invalid status "+string(st))
+				return
+			}
+			statuses = append(statuses, st)
+		}
+	}
+	orders, err := h.order.ListByDriver(c.Request.Context(), types.ID(driverID), statuses)
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"orders": orders})
+}
+
 // ListAvailableScheduled handles GET /api/orders/scheduled/available?from=...&to=...
+// Each order carries the calling driver's estimated payout.
 func (h *OrderHandler) ListAvailableScheduled(c *gin.Context) {
+	driverID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
 	fromStr := c.Query("from")
 	toStr := c.Query("to")
 	if fromStr == "" || toStr == "" {
@@ -375,7 +1068,7 @@ func (h *OrderHandler) ListAvailableScheduled(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "from must be before to")
 		return
 	}
-	orders, err := h.order.ListAvailableScheduled(c.Request.Context(), from, to)
+	orders, err := h.order.ListAvailableForDriver(c.Request.Context(), types.ID(driverID), from, to)
 	if err != nil {
 		writeOrderError(c, err)
 		return
@@ -390,7 +1083,7 @@ func (h *OrderHandler) Claim(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "missing order id")
 		return
 	}
-	if !isValidID(id) {
+	if !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid order id")
 		return
 	}
@@ -407,7 +1100,22 @@ func (h *OrderHandler) Claim(c *gin.Context) {
 		writeOrderError(c, err)
 		return
 	}
-	writeJSON(c, http.StatusOK, map[string]any{"status": order.StatusAssigned})
+	h.writeHandoff(c, order.StatusAssigned, types.ID(driverID), types.ID(id))
+}
+
+// Depart handles POST /api/orders/:id/depart (the driver of a claimed
+// scheduled order sets off for the pickup).
+func (h *OrderHandler) Depart(c *gin.Context) {
+	o, _, ok := h.authorizeParticipant(c, order.ActorDriver)
+	if !ok {
+		return
+	}
+	err := h.order.Depart(c.Request.Context(), order.DepartCommand{OrderID: o.ID, DriverID: *o.DriverID})
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"status": order.StatusApproaching})
 }
 
 type driverCancelReq struct {
@@ -421,7 +1129,7 @@ func (h *OrderHandler) DriverCancel(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, "missing order id")
 		return
 	}
-	if !isValidID(id) {
+	if !types.ValidID(id) {
 		writeError(c, http.StatusBadRequest, "invalid order id")
 		return
 	}
@@ -446,3 +1154,55 @@ func (h *OrderHandler) DriverCancel(c *gin.Context) {
 	}
 	writeJSON(c, http.StatusOK, map[string]any{"status": order.StatusScheduled})
 }
+
+type wavStatsResp struct {
+	RegionID        string  `json:"region_id"`
+	Requested       int     `json:"requested"`
+	Served          int     `json:"served"`
+	Completed       int     `json:"completed"`
+	Unserved        int     `json:"unserved"`
+	Open            int     `json:"open"`
+	FulfillmentRate float64 `json:"fulfillment_rate"`
+	AvgWaitSeconds  int64   `json:"avg_wait_seconds"`
+}
+
+// WAVReport handles GET /api/ops/reports/wav?from=...&to=... (RFC3339; default
+// the last 7 days): how many wheelchair orders placed in the range found a
+// driver, per region.
+func (h *OrderHandler) WAVReport(c *gin.Context) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+	var err error
+	if s := c.Query("from"); s != "" {
+		if from, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid from; expected RFC3339")
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid to; expected RFC3339")
+			return
+		}
+	}
+
+	stats, err := h.order.WAVFulfillment(c.Request.Context(), from, to)
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	out := make([]wavStatsResp, len(stats))
+	for i, w := range stats {
+		out[i] = wavStatsResp{
+			RegionID:        w.RegionID,
+			Requested:       w.Requested,
+			Served:          w.Served,
+			Completed:       w.Completed,
+			Unserved:        w.Unserved,
+			Open:            w.Open(),
+			FulfillmentRate: w.FulfillmentRate(),
+			AvgWaitSeconds:  int64(w.AvgWait.Seconds()),
+		}
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"from": from, "to": to, "regions": out})
+}
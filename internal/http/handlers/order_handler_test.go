@@ -0,0 +1,653 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/maps"
+	"ark/internal/modules/order"
+	"ark/internal/modules/triproute"
+	"ark/internal/types"
+)
+
+// fakeOrderStore implements the parts of order.OrderStore used by transitions;
+// any other method panics through the nil embedded interface.
+// Order IDs of the fixtures; order handlers only accept IDs Ark issues.
+const (
+	order1       = "01JBQ9Z8Y2V4M6XK3T5R7N1P0A"
+	order2       = "01JBQ9Z8Y2V4M6XK3T5R7N1P0B"
+	order3       = "01JBQ9Z8Y2V4M6XK3T5R7N1P0C"
+	unknownOrder = "01JBQ9Z8Y2V4M6XK3T5R7N1P0Z"
+)
+
+type fakeOrderStore struct {
+	order.OrderStore
+	mu     sync.Mutex
+	orders map[types.ID]*order.Order
+}
+
+func (f *fakeOrderStore) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	cp := *o
+	return &cp, nil
+}
+
+func (f *fakeOrderStore) UpdateStatus(_ context.Context, id types.ID, from, to order.Status, version int, driverID *types.ID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := f.orders[id]
+	if o.Status != from || o.StatusVersion != version {
+		return false, nil
+	}
+	o.Status = to
+	o.StatusVersion++
+	if driverID != nil {
+		o.DriverID = driverID
+	}
+	return true, nil
+}
+
+func (f *fakeOrderStore) ListByDriver(_ context.Context, driverID types.ID, statuses []order.Status) ([]*order.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*order.Order
+	for _, o := range f.orders {
+		if o.DriverID != nil && *o.DriverID == driverID && slices.Contains(statuses, o.Status) {
+			cp := *o
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrderStore) UpdatePickup(_ context.Context, id types.ID, version int, pickup types.Point, regionID string, fee order.Quote) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := f.orders[id]
+	if o.Status != order.StatusWaiting || o.StatusVersion != version {
+		return false, nil
+	}
+	o.Pickup = pickup
+	o.RegionID = regionID
+	o.EstimatedFee = fee.Fare
+	o.StatusVersion++
+	return true, nil
+}
+
+func (f *fakeOrderStore) ProposeDropoff(_ context.Context, id types.ID, version int, dropoff types.Point, fee order.Quote, at time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := f.orders[id]
+	if o.Status != order.StatusDriving || o.StatusVersion != version {
+		return false, nil
+	}
+	o.PendingDropoff = &order.DropoffProposal{Dropoff: dropoff, Fee: fee.Fare, RequestedAt: at}
+	o.StatusVersion++
+	return true, nil
+}
+
+func (f *fakeOrderStore) ResolveDropoff(_ context.Context, id types.ID, version int, accept bool) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := f.orders[id]
+	if o.Status != order.StatusDriving || o.StatusVersion != version || o.PendingDropoff == nil {
+		return false, nil
+	}
+	if accept {
+		if o.OriginalDropoff == nil {
+			orig := o.Dropoff
+			o.OriginalDropoff = &orig
+		}
+		o.Dropoff = o.PendingDropoff.Dropoff
+		o.EstimatedFee = o.PendingDropoff.Fee
+	}
+	o.PendingDropoff = nil
+	o.StatusVersion++
+	return true, nil
+}
+
+func (f *fakeOrderStore) UpdateSchedule(_ context.Context, id types.ID, version int, scheduledAt, deadline time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := f.orders[id]
+	if o.StatusVersion != version {
+		return false, nil
+	}
+	o.ScheduledAt = &scheduledAt
+	o.CancelDeadlineAt = &deadline
+	o.StatusVersion++
+	return true, nil
+}
+
+func (f *fakeOrderStore) AppendEvent(context.Context, *order.Event) error { return nil }
+
+func (f *fakeOrderStore) CancelCharged(ctx context.Context, id types.ID, from order.Status, version int, c order.CancelCharge) (bool, error) {
+	ok, err := f.UpdateStatus(ctx, id, from, order.StatusCancelled, version, nil)
+	if ok {
+		f.mu.Lock()
+		f.orders[id].CancelFee = c.Fee
+		f.mu.Unlock()
+	}
+	return ok, err
+}
+
+func (f *fakeOrderStore) SetDeliverySignature(_ context.Context, id types.ID, signedBy string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.orders[id].Delivery.SignedBy = signedBy
+	return nil
+}
+
+func (f *fakeOrderStore) AddStop(context.Context, *order.Stop) error { return nil }
+
+func (f *fakeOrderStore) ListStops(context.Context, types.ID) ([]order.Stop, error) {
+	return []order.Stop{}, nil
+}
+
+func (f *fakeOrderStore) SetOvertimeFee(context.Context, types.ID, int64) error { return nil }
+
+func (f *fakeOrderStore) WAVFulfillment(context.Context, time.Time, time.Time) ([]order.WAVStats, error) {
+	return []order.WAVStats{{RegionID: "tpe", Requested: 5, Served: 3, Completed: 2, Unserved: 1, AvgWait: 7 * time.Minute}}, nil
+}
+
+func newOrderTestRouter(status order.Status) (*gin.Engine, *fakeOrderStore) {
+	gin.SetMode(gin.TestMode)
+	driver := types.ID("driver-1")
+	store := &fakeOrderStore{orders: map[types.ID]*order.Order{
+		order1: {ID: order1, PassengerID: "pax-1", DriverID: &driver, Status: status, EstimatedFee: types.Money{Currency: "TWD"}},
+	}}
+	return newOrderTestRouterWith(order.NewService(store, nil)), store
+}
+
+func newOrderTestRouterWith(svc *order.Service) *gin.Engine {
+	h := NewOrderHandler(svc)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if uid := c.GetHeader("X-Test-User"); uid != "" {
+			c.Request = c.Request.WithContext(middleware.WithUserIDContext(c.Request.Context(), uid))
+		}
+	})
+	r.GET("/api/orders/:id/status", h.Status)
+	r.POST("/api/orders/:id/cancel", h.Cancel)
+	r.GET("/api/drivers/:id/orders", h.ListByDriver)
+	r.POST("/api/orders/:id/arrived", h.Arrive)
+	r.POST("/api/orders/:id/meet", h.Meet)
+	r.POST("/api/orders/:id/complete", h.Complete)
+	r.POST("/api/orders/:id/pay", h.Pay)
+	r.PATCH("/api/orders/:id/schedule", h.AmendSchedule)
+	r.PATCH("/api/orders/:id/pickup", h.ChangePickup)
+	r.PATCH("/api/orders/:id/dropoff", h.ChangeDropoff)
+	r.POST("/api/orders/:id/dropoff/ack", h.AckDropoff)
+	r.GET("/api/ops/reports/wav", h.WAVReport)
+	return r
+}
+
+func TestOrderHandler_ParticipantChecks(t *testing.T) {
+	cases := []struct {
+		name   string
+		status order.Status
+		action string
+		user   string
+		want   int
+	}{
+		{"passenger cancels", order.StatusApproaching, "cancel", "pax-1", http.StatusOK},
+		{"driver cancels", order.StatusApproaching, "cancel", "driver-1", http.StatusOK},
+		{"stranger cannot cancel", order.StatusApproaching, "cancel", "someone-else", http.StatusForbidden},
+		{"anonymous cannot cancel", order.StatusApproaching, "cancel", "", http.StatusUnauthorized},
+
+		{"driver arrives", order.StatusApproaching, "arrived", "driver-1", http.StatusOK},
+		{"passenger cannot arrive", order.StatusApproaching, "arrived", "pax-1", http.StatusForbidden},
+		{"other driver cannot arrive", order.StatusApproaching, "arrived", "driver-2", http.StatusForbidden},
+
+		{"driver meets", order.StatusArrived, "meet", "driver-1", http.StatusOK},
+		{"passenger cannot meet", order.StatusArrived, "meet", "pax-1", http.StatusForbidden},
+
+		{"driver completes", order.StatusDriving, "complete", "driver-1", http.StatusOK},
+		{"stranger cannot complete", order.StatusDriving, "complete", "someone-else", http.StatusForbidden},
+
+		{"passenger pays", order.StatusPayment, "pay", "pax-1", http.StatusOK},
+		{"driver cannot pay", order.StatusPayment, "pay", "driver-1", http.StatusForbidden},
+		{"stranger cannot pay", order.StatusPayment, "pay", "someone-else", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, store := newOrderTestRouter(tc.status)
+			req := httptest.NewRequest(http.MethodPost, "/api/orders/"+order1+"/"+tc.action, nil)
+			if tc.user != "" {
+				req.Header.Set("X-Test-User", tc.user)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("got %d, want %d: %s", w.Code, tc.want, w.Body.String())
+			}
+			if tc.want != http.StatusOK && store.orders[order1].Status != tc.status {
+				t.Errorf("rejected request still moved the order to %s", store.orders[order1].Status)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CancelUnknownOrder(t *testing.T) {
+	r, _ := newOrderTestRouter(order.StatusWaiting)
+	req := httptest.NewRequest(http.MethodPost, "/api/orders/"+unknownOrder+"/cancel", nil)
+	req.Header.Set("X-Test-User", "pax-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("got %d, want 404", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/orders/not-an-order/cancel", nil)
+	req.Header.Set("X-Test-User", "pax-1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed id: got %d, want 400", w.Code)
+	}
+}
+
+type flatCancelFee int64
+
+func (f flatCancelFee) CancellationFee(_ context.Context, req order.CancellationFeeRequest) (types.Money, error) {
+	return types.Money{Amount: int64(f), Currency: req.Fare.Currency}, nil
+}
+
+func TestOrderHandler_CancelConfirmsFee(t *testing.T) {
+	driver := types.ID("driver-1")
+	accepted := time.Now().Add(-10 * time.Minute)
+	store := &fakeOrderStore{orders: map[types.ID]*order.Order{
+		order1: {ID: order1, PassengerID: "pax-1", DriverID: &driver, Status: order.StatusApproaching, OrderType: "instant",
+			AcceptedAt: &accepted, EstimatedFee: types.Money{Amount: 20000, Currency: "TWD"}},
+	}}
+	svc := order.NewService(store, nil)
+	svc.SetCancellationFees(flatCancelFee(3000), 2*time.Minute)
+	r := newOrderTestRouterWith(svc)
+	r.GET("/api/orders/:id/cancellation", NewOrderHandler(svc).CancellationQuote)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Test-User", "pax-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/orders/"+order1+"/cancellation", "")
+	var quote struct {
+		Fee       int64      `json:"fee"`
+		FreeUntil *time.Time `json:"free_until"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &quote); err != nil || w.Code != http.StatusOK || quote.Fee != 3000 || quote.FreeUntil == nil {
+		t.Fatalf("quote: %d %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodPost, "/api/orders/"+order1+"/cancel", "")
+	var refused struct {
+		Cancellation struct {
+			Fee int64 `json:"fee"`
+		} `json:"cancellation"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &refused); err != nil || w.Code != http.StatusConflict || refused.Cancellation.Fee != 3000 {
+		t.Fatalf("unconfirmed cancel: %d %s", w.Code, w.Body.String())
+	}
+	if store.orders[order1].Status != order.StatusApproaching {
+		t.Fatalf("unconfirmed cancel moved the order to %s", store.orders[order1].Status)
+	}
+
+	w = do(http.MethodPost, "/api/orders/"+order1+"/cancel", `{"accept_fee":3000}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cancellation_fee":3000`) {
+		t.Fatalf("confirmed cancel: %d %s", w.Code, w.Body.String())
+	}
+	if o := store.orders[order1]; o.Status != order.StatusCancelled || o.CancelFee != 3000 {
+		t.Errorf("order = %s with fee %d", o.Status, o.CancelFee)
+	}
+}
+
+type fakeNavigator struct{}
+
+func (fakeNavigator) Navigation(_ context.Context, driverID, orderID types.ID) (*triproute.Navigation, error) {
+	return &triproute.Navigation{OrderID: orderID, Polyline: "abc", ToPickup: maps.NavLinks{Web: "https://maps/" + string(driverID)}}, nil
+}
+
+func TestOrderHandler_AcceptReturnsNavigation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeOrderStore{orders: map[types.ID]*order.Order{
+		order1: {ID: order1, PassengerID: "pax-1", Status: order.StatusWaiting, OrderType: "instant"},
+	}}
+	h := NewOrderHandler(order.NewService(store, nil))
+	h.SetNavigator(fakeNavigator{})
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(middleware.WithUserIDContext(c.Request.Context(), "driver-1"))
+	})
+	r.POST("/api/orders/:id/accept", h.Accept)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/orders/"+order1+"/accept", nil))
+	var resp struct {
+		Status     string                    `json:"status"`
+		Navigation *triproute.NavigationResp `json:"navigation"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("accept: %d %s", w.Code, w.Body.String())
+	}
+	if resp.Status != string(order.StatusApproaching) || resp.Navigation == nil || resp.Navigation.Polyline != "abc" ||
+		resp.Navigation.ToPickup.Web != "https://maps/driver-1" {
+		t.Fatalf("response = %s", w.Body.String())
+	}
+}
+
+func getStatus(r *gin.Engine, query, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/orders/"+order1+"/status"+query, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestOrderHandler_StatusConditionalGet(t *testing.T) {
+	r, store := newOrderTestRouter(order.StatusApproaching)
+	store.orders[order1].StatusVersion = 3
+
+	w := getStatus(r, "", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("first poll: got %d etag %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = getStatus(r, "", `"3"`)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("unchanged poll: got %d body %q, want empty 304", w.Code, w.Body.String())
+	}
+	if w = getStatus(r, "", `W/"1", W/"3"`); w.Code != http.StatusNotModified {
+		t.Fatalf("weak tag list: got %d, want 304", w.Code)
+	}
+	if w = getStatus(r, "", `"2"`); w.Code != http.StatusOK {
+		t.Fatalf("stale tag: got %d, want 200", w.Code)
+	}
+	if w = getStatus(r, "?wait=soon", `"3"`); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad wait: got %d, want 400", w.Code)
+	}
+}
+
+func TestOrderHandler_StatusLongPoll(t *testing.T) {
+	r, store := newOrderTestRouter(order.StatusApproaching)
+	store.orders[order1].StatusVersion = 3
+
+	start := time.Now()
+	if w := getStatus(r, "?wait=50ms", `"3"`); w.Code != http.StatusNotModified {
+		t.Fatalf("timed-out wait: got %d, want 304", w.Code)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Error("long-poll returned before the wait elapsed")
+	}
+
+	// A transition committed while the poll is held open answers it at once.
+	svc := order.NewService(store, nil)
+	r = newOrderTestRouterWith(svc)
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- getStatus(r, "?wait=10s", `"3"`) }()
+	time.Sleep(20 * time.Millisecond)
+	if err := svc.Arrive(context.Background(), order.ArriveCommand{OrderID: order1}); err != nil {
+		t.Fatalf("arrive: %v", err)
+	}
+	select {
+	case w := <-done:
+		if w.Code != http.StatusOK || w.Header().Get("ETag") != `"4"` {
+			t.Fatalf("woken poll: got %d etag %q", w.Code, w.Header().Get("ETag"))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("long-poll was not woken by the transition")
+	}
+}
+
+func TestOrderHandler_ListByDriver(t *testing.T) {
+	r, store := newOrderTestRouter(order.StatusApproaching)
+	driver := types.ID("driver-1")
+	store.orders[order2] = &order.Order{ID: order2, PassengerID: "pax-2", DriverID: &driver, Status: order.StatusAssigned}
+	store.orders[order3] = &order.Order{ID: order3, PassengerID: "pax-3", DriverID: &driver, Status: order.StatusComplete}
+
+	list := func(user, query string) (int, int) {
+		req := httptest.NewRequest(http.MethodGet, "/api/drivers/driver-1/orders"+query, nil)
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body struct{ Orders []json.RawMessage }
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, len(body.Orders)
+	}
+
+	if code, n := list("driver-1", ""); code != http.StatusOK || n != 2 {
+		t.Errorf("default filter: got %d with %d orders, want 200 with 2", code, n)
+	}
+	if code, n := list("driver-1", "?status=assigned"); code != http.StatusOK || n != 1 {
+		t.Errorf("assigned only: got %d with %d orders, want 200 with 1", code, n)
+	}
+	if code, _ := list("driver-1", "?status=complete"); code != http.StatusBadRequest {
+		t.Errorf("inactive status: got %d, want 400", code)
+	}
+	if code, _ := list("driver-2", ""); code != http.StatusForbidden {
+		t.Errorf("other driver: got %d, want 403", code)
+	}
+}
+
+func TestOrderHandler_StatusNotesForParticipantsOnly(t *testing.T) {
+	r, store := newOrderTestRouter(order.StatusApproaching)
+	store.orders[order1].Notes = "gate code 1234"
+
+	for user, wantNotes := range map[string]bool{"pax-1": true, "driver-1": true, "driver-2": false} {
+		req := httptest.NewRequest(http.MethodGet, "/api/orders/"+order1+"/status", nil)
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode: %v", user, err)
+		}
+		if _, got := body["notes"]; got != wantNotes {
+			t.Errorf("%s: notes present = %v, want %v", user, got, wantNotes)
+		}
+	}
+}
+
+func TestOrderHandler_StatusFareBreakdown(t *testing.T) {
+	r, store := newOrderTestRouter(order.StatusPayment)
+	store.orders[order1].EstimatedFee = types.Money{Amount: 15000, Currency: "TWD"}
+	store.orders[order1].CreditsApplied = 4000
+
+	req := httptest.NewRequest(http.MethodGet, "/api/orders/"+order1+"/status", nil)
+	req.Header.Set("X-Test-User", "pax-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var body struct {
+		Fare map[string]any `json:"fare"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Fare["credits_applied"] != float64(4000) || body.Fare["due"] != float64(11000) {
+		t.Errorf("fare = %v, want 4000 credits and 11000 due", body.Fare)
+	}
+}
+
+func TestOrderHandler_AmendSchedule(t *testing.T) {
+	r, store := newOrderTestRouter(order.StatusAssigned)
+	pickup := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+	window := 30
+	store.orders[order1].ScheduledAt = &pickup
+	store.orders[order1].ScheduleWindowMins = &window
+
+	later := pickup.Add(time.Hour).Format(time.RFC3339)
+	cases := []struct {
+		name string
+		user string
+		body string
+		want int
+	}{
+		{"driver cannot amend", "driver-1", `{"scheduled_at":"` + later + `"}`, http.StatusForbidden},
+		{"bad time", "pax-1", `{"scheduled_at":"tomorrow"}`, http.StatusBadRequest},
+		{"inside lead time", "pax-1", `{"scheduled_at":"` + time.Now().Add(time.Minute).Format(time.RFC3339) + `"}`, http.StatusBadRequest},
+		{"passenger amends", "pax-1", `{"scheduled_at":"` + later + `"}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPatch, "/api/orders/"+order1+"/schedule", strings.NewReader(tc.body))
+		req.Header.Set("X-Test-User", tc.user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tc.name, w.Code, tc.want, w.Body)
+		}
+	}
+	o := store.orders[order1]
+	if want := pickup.Add(time.Hour); !o.ScheduledAt.Equal(want) {
+		t.Errorf("scheduled_at = %v, want %v", o.ScheduledAt, want)
+	}
+	if want := pickup.Add(30 * time.Minute); !o.CancelDeadlineAt.Equal(want) {
+		t.Errorf("cancel deadline = %v, want %v", o.CancelDeadlineAt, want)
+	}
+}
+
+// busyDispatch is an order.DispatchLock whose offer round is running while busy.
+type busyDispatch struct{ busy bool }
+
+func (b *busyDispatch) HoldDispatch(context.Context, types.ID) (func(context.Context), error) {
+	if b.busy {
+		return nil, order.ErrDispatchInFlight
+	}
+	return func(context.Context) {}, nil
+}
+
+func (b *busyDispatch) ResetDispatch(context.Context, types.ID) error { return nil }
+
+func TestOrderHandler_ChangePickup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeOrderStore{orders: map[types.ID]*order.Order{
+		order1: {ID: order1, PassengerID: "pax-1", Status: order.StatusWaiting, EstimatedFee: types.Money{Currency: "TWD"}},
+	}}
+	svc := order.NewService(store, nil)
+	dispatch := &busyDispatch{busy: true}
+	svc.SetDispatchLock(dispatch)
+	r := newOrderTestRouterWith(svc)
+
+	body := `{"pickup_lat":25.0418,"pickup_lng":121.508,"status_version":0}`
+	steps := []struct {
+		name string
+		user string
+		busy bool
+		want int
+	}{
+		{"stranger cannot move", "someone-else", false, http.StatusForbidden},
+		{"offer round running", "pax-1", true, http.StatusConflict},
+		{"passenger moves", "pax-1", false, http.StatusOK},
+		{"same version again", "pax-1", false, http.StatusConflict},
+	}
+	var w *httptest.ResponseRecorder
+	for _, s := range steps {
+		dispatch.busy = s.busy
+		req := httptest.NewRequest(http.MethodPatch, "/api/orders/"+order1+"/pickup", strings.NewReader(body))
+		req.Header.Set("X-Test-User", s.user)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != s.want {
+			t.Fatalf("%s: status = %d, want %d (%s)", s.name, w.Code, s.want, w.Body)
+		}
+	}
+	// The stale retry is told the order's current version.
+	var conflict struct {
+		OrderID string `json:"order_id"`
+		Status  string `json:"status"`
+		Version int    `json:"status_version"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &conflict); err != nil || conflict.OrderID != order1 || conflict.Status != "waiting" || conflict.Version != 1 {
+		t.Errorf("conflict body = %s", w.Body)
+	}
+	if o := store.orders[order1]; o.Pickup != (types.Point{Lat: 25.0418, Lng: 121.508}) || o.StatusVersion != 1 {
+		t.Errorf("pickup = %v v%d, want moved once", o.Pickup, o.StatusVersion)
+	}
+}
+
+func TestOrderHandler_ChangeDropoff(t *testing.T) {
+	r, store := newOrderTestRouter(order.StatusDriving)
+	store.orders[order1].Dropoff = types.Point{Lat: 25.048, Lng: 121.532}
+
+	steps := []struct {
+		name   string
+		method string
+		path   string
+		user   string
+		body   string
+		want   int
+	}{
+		{"driver cannot propose", http.MethodPatch, "/dropoff", "driver-1", `{"dropoff_lat":25.06,"dropoff_lng":121.52}`, http.StatusForbidden},
+		{"missing point", http.MethodPatch, "/dropoff", "pax-1", `{}`, http.StatusBadRequest},
+		{"stale version", http.MethodPatch, "/dropoff", "pax-1", `{"dropoff_lat":25.06,"dropoff_lng":121.52,"status_version":3}`, http.StatusConflict},
+		{"nothing to ack", http.MethodPost, "/dropoff/ack", "driver-1", `{"accept":true}`, http.StatusConflict},
+		{"passenger proposes", http.MethodPatch, "/dropoff", "pax-1", `{"dropoff_lat":25.06,"dropoff_lng":121.52,"status_version":0}`, http.StatusAccepted},
+		{"passenger cannot ack", http.MethodPost, "/dropoff/ack", "pax-1", `{"accept":true}`, http.StatusForbidden},
+		{"driver accepts", http.MethodPost, "/dropoff/ack", "driver-1", `{"accept":true}`, http.StatusOK},
+	}
+	for _, s := range steps {
+		req := httptest.NewRequest(s.method, "/api/orders/"+order1+s.path, strings.NewReader(s.body))
+		req.Header.Set("X-Test-User", s.user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != s.want {
+			t.Fatalf("%s: status = %d, want %d (%s)", s.name, w.Code, s.want, w.Body)
+		}
+	}
+	o := store.orders[order1]
+	if o.Dropoff != (types.Point{Lat: 25.06, Lng: 121.52}) || o.PendingDropoff != nil {
+		t.Errorf("dropoff = %v, pending = %v", o.Dropoff, o.PendingDropoff)
+	}
+	if o.OriginalDropoff == nil || *o.OriginalDropoff != (types.Point{Lat: 25.048, Lng: 121.532}) {
+		t.Errorf("original dropoff = %v", o.OriginalDropoff)
+	}
+}
+
+func TestOrderHandler_WAVReport(t *testing.T) {
+	r, _ := newOrderTestRouter(order.StatusWaiting)
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ops/reports/wav"+query, nil))
+		return w
+	}
+
+	w := get("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Regions []wavStatsResp `json:"regions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := wavStatsResp{RegionID: "tpe", Requested: 5, Served: 3, Completed: 2, Unserved: 1, Open: 1, FulfillmentRate: 0.75, AvgWaitSeconds: 420}
+	if len(resp.Regions) != 1 || resp.Regions[0] != want {
+		t.Errorf("regions = %+v, want [%+v]", resp.Regions, want)
+	}
+
+	if w := get("?from=2026-03-10T00:00:00Z&to=2026-03-01T00:00:00Z"); w.Code != http.StatusBadRequest {
+		t.Errorf("reversed period: got %d, want 400", w.Code)
+	}
+	if w := get("?from=yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad from: got %d, want 400", w.Code)
+	}
+}
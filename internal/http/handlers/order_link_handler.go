@@ -0,0 +1,70 @@
+// README: Order share-link handlers: issue scoped order tokens and serve the token-guarded public status.
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type OrderLinkHandler struct {
+	order  *order.Service
+	tokens *middleware.OrderTokens
+}
+
+func NewOrderLinkHandler(svc *order.Service, tokens *middleware.OrderTokens) *OrderLinkHandler {
+	return &OrderLinkHandler{order: svc, tokens: tokens}
+}
+
+// Issue returns a status-only token for an order the caller rides in or drives.
+func (h *OrderLinkHandler) Issue(c *gin.Context) {
+	id := c.Param("id")
+	if !types.ValidID(id) {
+		writeError(c, http.StatusBadRequest, "invalid order id")
+		return
+	}
+	userID, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	o, err := h.order.Get(c.Request.Context(), types.ID(id))
+	if err != nil {
+		writeOrderError(c, err)
+		return
+	}
+	if o.PassengerID != types.ID(userID) && (o.DriverID == nil || *o.DriverID != types.ID(userID)) {
+		// Same response as a missing order so IDs cannot be probed.
+		writeOrderError(c, order.ErrNotFound)
+		return
+	}
+	token, exp, err := h.tokens.Issue(id, middleware.ScopeOrderStatus)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{
+		"token":      token,
+		"scope":      middleware.ScopeOrderStatus,
+		"expires_at": exp.UTC().Format(time.RFC3339),
+	})
+}
+
+// Status serves the public order status with the same ETag and ?wait support as
+// the authenticated route; OrderAccess has already checked the token.
+func (h *OrderLinkHandler) Status(c *gin.Context) {
+	o, ok := pollOrderStatus(c, h.order, types.ID(c.Param("id")))
+	if !ok {
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{
+		"order_id":       o.ID,
+		"status":         o.Status,
+		"status_version": o.StatusVersion,
+	})
+}
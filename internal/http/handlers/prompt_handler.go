@@ -0,0 +1,71 @@
+// README: Ops handler for AI prompt templates — list versions with their outcome stats, switch or roll back the active one.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/ai"
+)
+
+// PromptHandler exposes prompt version management to ops.
+//
+//	GET /api/ops/ai/prompts        — every prompt with its versions, the active one and per-version stats
+//	PUT /api/ops/ai/prompts/:name  — serve another version ({"version": "v1"}); persisted for all instances
+type PromptHandler struct {
+	prompts *ai.Prompts
+}
+
+// NewPromptHandler creates a PromptHandler for the given prompts.
+func NewPromptHandler(prompts *ai.Prompts) *PromptHandler {
+	return &PromptHandler{prompts: prompts}
+}
+
+type promptVersionResp struct {
+	Version string         `json:"version"`
+	Stats   ai.PromptStats `json:"stats"`
+}
+
+type promptResp struct {
+	Name     string              `json:"name"`
+	Active   string              `json:"active"`
+	Versions []promptVersionResp `json:"versions"`
+}
+
+// List handles GET /api/ops/ai/prompts. Stats cover this instance since start-up.
+func (h *PromptHandler) List(c *gin.Context) {
+	out := make([]promptResp, 0)
+	for _, name := range h.prompts.Names() {
+		p := promptResp{Name: name, Active: h.prompts.Active(name)}
+		for _, v := range h.prompts.Versions(name) {
+			p.Versions = append(p.Versions, promptVersionResp{Version: v, Stats: h.prompts.Stats(name, v)})
+		}
+		out = append(out, p)
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"prompts": out})
+}
+
+type activatePromptReq struct {
+	Version string `json:"version"`
+}
+
+// Activate handles PUT /api/ops/ai/prompts/:name.
+func (h *PromptHandler) Activate(c *gin.Context) {
+	var req activatePromptReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.Version == "" {
+		writeError(c, http.StatusBadRequest, "version is required")
+		return
+	}
+	name := c.Param("name")
+	if err := h.prompts.Activate(c.Request.Context(), name, req.Version); err != nil {
+		if errors.Is(err, ai.ErrUnknownPrompt) {
+			writeError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"name": name, "active": req.Version})
+}
@@ -1,11 +1,14 @@
-// README: HTTP handler for the ride assistant — POST /api/assistant/ride/messages.
+// README: HTTP handler for the ride assistant — POST /api/assistant/ride/messages, the user's /api/assistant/conversations history, GET /api/ops/reports/ai-conversations.
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"ark/internal/ai"
 	"ark/internal/modules/rideassistant"
 )
 
@@ -39,10 +42,125 @@ func (h *RideAssistantHandler) HandleMessage(c *gin.Context) {
 	}
 
 	resp, err := h.svc.HandleMessage(c.Request.Context(), userID.(string), req)
-	if err != nil {
+	switch {
+	case errors.Is(err, ai.ErrQuotaExceeded), errors.Is(err, ai.ErrUnavailable):
+		c.Header("Retry-After", "30")
+		writeError(c, http.StatusServiceUnavailable, "assistant is busy, try again shortly")
+		return
+	case err != nil:
 		writeError(c, http.StatusInternalServerError, "internal error")
 		return
 	}
 
 	writeJSON(c, http.StatusOK, resp)
 }
+
+// ConversationReport handles GET /api/ops/reports/ai-conversations?from=...&to=...
+// (RFC3339; default the last 7 days): conversion rate, average turns to
+// booking and abandonment points for conversations started in the range.
+func (h *RideAssistantHandler) ConversationReport(c *gin.Context) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+	var err error
+	if s := c.Query("from"); s != "" {
+		if from, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid from; expected RFC3339")
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid to; expected RFC3339")
+			return
+		}
+	}
+	if !from.Before(to) {
+		writeError(c, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	report, err := h.svc.ConversationReport(c.Request.Context(), from, to)
+	switch {
+	case errors.Is(err, rideassistant.ErrNoConversationLog):
+		writeError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	case err != nil:
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(c, http.StatusOK, report)
+}
+
+// ListConversations handles GET /api/assistant/conversations, the caller's
+// conversations newest first.
+func (h *RideAssistantHandler) ListConversations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		writeError(c, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	convs, err := h.svc.Conversations(c.Request.Context(), userID.(string))
+	if err != nil {
+		writeHistoryError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"conversations": convs})
+}
+
+// ExportConversations handles GET /api/assistant/conversations/export, a JSON
+// download of the caller's conversations with every message.
+func (h *RideAssistantHandler) ExportConversations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		writeError(c, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	export, err := h.svc.ExportConversations(c.Request.Context(), userID.(string))
+	if err != nil {
+		writeHistoryError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="ai-conversations.json"`)
+	writeJSON(c, http.StatusOK, export)
+}
+
+// DeleteConversation handles DELETE /api/assistant/conversations/:id.
+func (h *RideAssistantHandler) DeleteConversation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		writeError(c, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if err := h.svc.DeleteConversation(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		writeHistoryError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteConversations handles DELETE /api/assistant/conversations, deleting
+// the caller's whole chat history.
+func (h *RideAssistantHandler) DeleteConversations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		writeError(c, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	n, err := h.svc.DeleteHistory(c.Request.Context(), userID.(string))
+	if err != nil {
+		writeHistoryError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"deleted": n})
+}
+
+func writeHistoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rideassistant.ErrConversationNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, rideassistant.ErrNoConversationHistory):
+		writeError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,110 @@
+// README: Route search handler — ranked stop suggestions along a trip, without the AI assistant.
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/maps"
+	"ark/internal/service"
+)
+
+const (
+	defaultStopLimit = 5
+	maxStopLimit     = 10
+	// maxSearchTextLen bounds each free-text field sent on to Google Maps.
+	maxSearchTextLen = 200
+	// stopSearchTimeout covers the route, the searches along it and one detour per result.
+	stopSearchTimeout = 20 * time.Second
+)
+
+// RouteSearchHandler exposes stop search along a route to apps.
+//
+//	POST /api/routes/search-along — ranked places to stop at between origin and destination
+type RouteSearchHandler struct {
+	stops *service.StopSearch
+}
+
+// NewRouteSearchHandler creates a RouteSearchHandler backed by stops.
+func NewRouteSearchHandler(stops *service.StopSearch) *RouteSearchHandler {
+	return &RouteSearchHandler{stops: stops}
+}
+
+// searchAlongReq takes origin and destination as addresses or "lat,lng".
+type searchAlongReq struct {
+	Origin      string   `json:"origin"`
+	Destination string   `json:"destination"`
+	Category    string   `json:"category"`
+	Keywords    string   `json:"keywords"`
+	Exclude     []string `json:"exclude"`
+	Limit       int      `json:"limit"`
+}
+
+type stopResp struct {
+	PlaceID          string  `json:"place_id"`
+	Name             string  `json:"name"`
+	Address          string  `json:"address"`
+	Rating           float32 `json:"rating"`
+	UserRatingsTotal int     `json:"user_ratings_total"`
+	PriceLevel       int     `json:"price_level,omitempty"`
+	OpenNow          *bool   `json:"open_now,omitempty"`
+	DetourMinutes    int     `json:"detour_minutes"`
+}
+
+// SearchAlong handles POST /api/routes/search-along.
+func (h *RouteSearchHandler) SearchAlong(c *gin.Context) {
+	var req searchAlongReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Origin = strings.TrimSpace(req.Origin)
+	req.Destination = strings.TrimSpace(req.Destination)
+	req.Category = strings.TrimSpace(req.Category)
+	if req.Origin == "" || req.Destination == "" || req.Category == "" {
+		writeError(c, http.StatusBadRequest, "origin, destination and category are required")
+		return
+	}
+	for _, v := range append([]string{req.Origin, req.Destination, req.Category, req.Keywords}, req.Exclude...) {
+		if utf8.RuneCountInString(v) > maxSearchTextLen {
+			writeError(c, http.StatusBadRequest, "search text too long")
+			return
+		}
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultStopLimit
+	}
+	if req.Limit > maxStopLimit {
+		req.Limit = maxStopLimit
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), stopSearchTimeout)
+	defer cancel()
+	opts := &maps.SearchOptions{SearchKeywords: strings.TrimSpace(req.Keywords), ExcludeKeywords: req.Exclude}
+	candidates, err := h.stops.SearchAlong(ctx, req.Origin, req.Destination, req.Category, opts, req.Limit)
+	if err != nil {
+		writeError(c, http.StatusServiceUnavailable, "place search unavailable")
+		return
+	}
+
+	out := make([]stopResp, 0, len(candidates))
+	for _, cand := range candidates {
+		out = append(out, stopResp{
+			PlaceID:          cand.Place.PlaceID,
+			Name:             cand.Place.Name,
+			Address:          cand.Place.Address,
+			Rating:           cand.Place.Rating,
+			UserRatingsTotal: cand.Place.UserRatingsTotal,
+			PriceLevel:       cand.Place.PriceLevel,
+			OpenNow:          cand.Place.OpenNow,
+			DetourMinutes:    int(math.Round(cand.Detour.Minutes())),
+		})
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"stops": out})
+}
@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -30,8 +31,10 @@ type createUserReq struct {
 	UserType string `json:"user_type"`
 }
 
-type updateUserNameReq struct {
+type updateMeReq struct {
 	Name string `json:"name"`
+	// PreferredLanguage is "zh-TW", "en" or "ja".
+	PreferredLanguage string `json:"preferred_language"`
 }
 
 // CreateUser handles POST /api/users.
@@ -69,49 +72,89 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 	writeJSON(c, http.StatusOK, u)
 }
 
-// UpdateMe handles PATCH /api/me — updates only the current user's name.
+// UpdateMe handles PATCH /api/me — updates the current user's name and/or
+// preferred language.
 func (h *UserHandler) UpdateMe(c *gin.Context) {
 	uid, ok := middleware.UserIDFromContext(c.Request.Context())
 	if !ok || uid == "" {
 		writeError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	var req updateUserNameReq
+	var req updateMeReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		writeError(c, http.StatusBadRequest, "invalid json")
 		return
 	}
-	if req.Name == "" {
-		writeError(c, http.StatusBadRequest, "name is required")
+	if req.Name == "" && req.PreferredLanguage == "" {
+		writeError(c, http.StatusBadRequest, "name or preferred_language is required")
 		return
 	}
-	if err := h.svc.UpdateName(c.Request.Context(), types.ID(uid), req.Name); err != nil {
-		writeUserError(c, err)
-		return
+	if req.PreferredLanguage != "" {
+		if err := h.svc.SetLanguage(c.Request.Context(), types.ID(uid), req.PreferredLanguage); err != nil {
+			writeUserError(c, err)
+			return
+		}
+	}
+	if req.Name != "" {
+		if err := h.svc.UpdateName(c.Request.Context(), types.ID(uid), req.Name); err != nil {
+			writeUserError(c, err)
+			return
+		}
 	}
 	c.Status(http.StatusNoContent)
 }
 
-// DeleteMe handles DELETE /api/me — deletes the current authenticated user.
+// DeleteMe handles DELETE /api/users/me (and the older DELETE /api/me).
+// It schedules the account for deletion: sessions are revoked immediately and
+// PII is purged after the grace period. Accounts with active orders get 409.
 func (h *UserHandler) DeleteMe(c *gin.Context) {
 	uid, ok := middleware.UserIDFromContext(c.Request.Context())
 	if !ok || uid == "" {
 		writeError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	if err := h.svc.Delete(c.Request.Context(), types.ID(uid)); err != nil {
+	req, err := h.svc.RequestDeletion(c.Request.Context(), types.ID(uid))
+	if err != nil {
 		writeUserError(c, err)
 		return
 	}
-	c.Status(http.StatusNoContent)
+	writeJSON(c, http.StatusAccepted, map[string]any{
+		"status":       "pending_deletion",
+		"requested_at": req.RequestedAt,
+		"purge_after":  req.PurgeAfter,
+	})
+}
+
+type setPriorityReq struct {
+	Priority string `json:"priority"`
+}
+
+// SetPriority handles PUT /api/ops/users/:id/priority. It is mounted on the
+// ops router group, so the passenger comes from the path.
+// Body: {"priority": "accessibility"} — "" makes them a normal passenger again.
+func (h *UserHandler) SetPriority(c *gin.Context) {
+	var req setPriorityReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.SetPriority(c.Request.Context(), types.ID(c.Param("id")), req.Priority); err != nil {
+		writeUserError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"user_id": c.Param("id"), "priority": req.Priority})
 }
 
 func writeUserError(c *gin.Context, err error) {
-	switch err {
-	case user.ErrBadRequest:
+	switch {
+	case errors.Is(err, user.ErrBadRequest):
 		writeError(c, http.StatusBadRequest, err.Error())
-	case user.ErrNotFound:
+	case errors.Is(err, user.ErrNotFound):
 		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, user.ErrActiveOrders):
+		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, user.ErrDeletionUnavailable):
+		writeError(c, http.StatusServiceUnavailable, err.Error())
 	default:
 		writeError(c, http.StatusInternalServerError, "internal error")
 	}
@@ -0,0 +1,81 @@
+// README: /health — dependency and worker checks. Postgres or a stale worker fails the check; Redis being down only degrades it.
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+
+	"ark/internal/worker"
+)
+
+// redisDegraded lists what stops working while Redis is down. Orders are
+// still booked and wait in Postgres until dispatch reaches drivers again, so
+// the instance stays in rotation.
+var redisDegraded = []string{"dispatch", "location_updates"}
+
+// health answers 503 when Postgres is down or a worker stopped beating, and
+// 200 with status "degraded" when only Redis is down.
+func health(dbPool *pgxpool.Pool, redisClient *redis.Client, workerRegistry *worker.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+
+		status := http.StatusOK
+		degraded := false
+		result := map[string]any{"status": "ok"}
+
+		// Check Postgres
+		if dbPool != nil {
+			if err := dbPool.Ping(ctx); err != nil {
+				status = http.StatusServiceUnavailable
+				result["db"] = "down"
+			} else {
+				result["db"] = "ok"
+			}
+		} else {
+			result["db"] = "not configured"
+		}
+
+		// Check Redis
+		if redisClient != nil {
+			if err := redisClient.Ping(ctx).Err(); err != nil {
+				degraded = true
+				result["redis"] = "down"
+				result["degraded"] = redisDegraded
+			} else {
+				result["redis"] = "ok"
+			}
+		} else {
+			result["redis"] = "not configured"
+		}
+
+		// Check workers
+		if workerRegistry != nil {
+			workerStatus := workerRegistry.Status()
+			workerInfo := make(map[string]string, len(workerStatus))
+			allHealthy := workerRegistry.AllHealthy(60 * time.Second)
+			for name, lastBeat := range workerStatus {
+				age := time.Since(lastBeat)
+				if age > 60*time.Second {
+					workerInfo[name] = "stale"
+				} else {
+					workerInfo[name] = "ok"
+				}
+			}
+			result["workers"] = workerInfo
+			if !allHealthy {
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		if status != http.StatusOK || degraded {
+			result["status"] = "degraded"
+		}
+		c.JSON(status, result)
+	}
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestHealth_RedisDownIsDegraded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer rdb.Close()
+
+	r := gin.New()
+	r.GET("/health", health(nil, rdb, nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 while only Redis is down", w.Code)
+	}
+	var body struct {
+		Status   string   `json:"status"`
+		Redis    string   `json:"redis"`
+		Degraded []string `json:"degraded"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Status != "degraded" || body.Redis != "down" || len(body.Degraded) == 0 {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+}
@@ -0,0 +1,30 @@
+// README: Ops middleware guarding internal back-office endpoints with a shared key.
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpsHeader carries the ops API key on back-office requests.
+const OpsHeader = "X-Ark-Ops-Key"
+
+// OpsKey returns a Gin middleware admitting only requests that present key in
+// OpsHeader. Ops endpoints are called by internal tooling rather than app
+// users, so they do not go through Firebase auth. When key is empty the ops
+// API is disabled and every request is rejected.
+func OpsKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "ops API is not enabled"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader(OpsHeader)), []byte(key)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid ops key"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+)
+
+func TestOpsKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name   string
+		key    string
+		header string
+		want   int
+	}{
+		{"matching key", "ops-secret", "ops-secret", http.StatusOK},
+		{"missing header", "ops-secret", "", http.StatusUnauthorized},
+		{"wrong key", "ops-secret", "guess", http.StatusUnauthorized},
+		{"disabled", "", "ops-secret", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Use(middleware.OpsKey(tc.key))
+		r.GET("/ops", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodGet, "/ops", nil)
+		if tc.header != "" {
+			req.Header.Set(middleware.OpsHeader, tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, w.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package middleware_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+)
+
+func newOrderTokens(t *testing.T, ttl time.Duration) *middleware.OrderTokens {
+	t.Helper()
+	tokens, err := middleware.NewOrderTokens(bytes.Repeat([]byte("k"), 32), ttl)
+	if err != nil {
+		t.Fatalf("NewOrderTokens: %v", err)
+	}
+	return tokens
+}
+
+func newOrderAccessRouter(tokens *middleware.OrderTokens) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/orders/:id/status", middleware.OrderAccess(tokens, middleware.ScopeOrderStatus), func(c *gin.Context) {
+		claims, ok := middleware.OrderClaimsFromContext(c.Request.Context())
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "no claims in context"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"order_id": claims.OrderID})
+	})
+	return r
+}
+
+func TestOrderTokens_RoundTrip(t *testing.T) {
+	tokens := newOrderTokens(t, time.Hour)
+	token, exp, err := tokens.Issue("order-1", middleware.ScopeOrderStatus)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if d := time.Until(exp); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("expiry %s out of range", exp)
+	}
+	claims, err := tokens.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.OrderID != "order-1" || claims.Scope != middleware.ScopeOrderStatus {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestOrderTokens_RejectsTamperedAndForeign(t *testing.T) {
+	tokens := newOrderTokens(t, time.Hour)
+	token, _, _ := tokens.Issue("order-1", middleware.ScopeOrderStatus)
+
+	if _, err := tokens.Verify(token[:len(token)-1] + "x"); !errors.Is(err, middleware.ErrInvalidOrderToken) {
+		t.Errorf("tampered signature err = %v", err)
+	}
+	other, _ := middleware.NewOrderTokens(bytes.Repeat([]byte("z"), 32), time.Hour)
+	if _, err := other.Verify(token); !errors.Is(err, middleware.ErrInvalidOrderToken) {
+		t.Errorf("foreign key err = %v", err)
+	}
+	if _, err := tokens.Verify("garbage"); !errors.Is(err, middleware.ErrInvalidOrderToken) {
+		t.Errorf("garbage err = %v", err)
+	}
+	if _, err := middleware.NewOrderTokens([]byte("short"), time.Hour); err == nil {
+		t.Error("short key accepted")
+	}
+}
+
+func TestOrderTokens_Expired(t *testing.T) {
+	tokens := newOrderTokens(t, time.Nanosecond)
+	token, _, _ := tokens.Issue("order-1", middleware.ScopeOrderStatus)
+	if _, err := tokens.Verify(token); !errors.Is(err, middleware.ErrExpiredOrderToken) {
+		t.Fatalf("err = %v; want ErrExpiredOrderToken", err)
+	}
+}
+
+func TestOrderAccess(t *testing.T) {
+	tokens := newOrderTokens(t, time.Hour)
+	r := newOrderAccessRouter(tokens)
+	good, _, _ := tokens.Issue("order-1", middleware.ScopeOrderStatus)
+	wrongScope, _, _ := tokens.Issue("order-1", "order:cancel")
+
+	cases := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"query token", "/orders/order-1/status?t=" + good, "", http.StatusOK},
+		{"header token", "/orders/order-1/status", good, http.StatusOK},
+		{"missing token", "/orders/order-1/status", "", http.StatusUnauthorized},
+		{"other order", "/orders/order-2/status?t=" + good, "", http.StatusForbidden},
+		{"wrong scope", "/orders/order-1/status?t=" + wrongScope, "", http.StatusForbidden},
+		{"bad token", "/orders/order-1/status?t=nope", "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set(middleware.OrderTokenHeader, tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: got %d, want %d: %s", tc.name, w.Code, tc.want, w.Body.String())
+		}
+	}
+}
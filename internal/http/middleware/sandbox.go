@@ -0,0 +1,36 @@
+// README: Test-mode middleware that marks requests carrying the sandbox API key.
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/sandbox"
+)
+
+// Sandbox returns a Gin middleware that puts requests presenting key in the
+// sandbox.Header into test mode (see package sandbox). Requests without the
+// header are untouched. When key is empty test mode is disabled and any
+// request asking for it is rejected, so a misconfigured client cannot
+// silently create real orders.
+func Sandbox(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader(sandbox.Header)
+		if got == "" {
+			c.Next()
+			return
+		}
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "sandbox mode is not enabled"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(key)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid sandbox key"})
+			return
+		}
+		c.Request = c.Request.WithContext(sandbox.WithContext(c.Request.Context()))
+		c.Next()
+	}
+}
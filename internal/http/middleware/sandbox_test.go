@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/sandbox"
+)
+
+func TestSandbox(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(key string) *gin.Engine {
+		r := gin.New()
+		r.Use(middleware.Sandbox(key))
+		r.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"sandbox": sandbox.Enabled(c.Request.Context())})
+		})
+		return r
+	}
+
+	cases := []struct {
+		name   string
+		key    string
+		header string
+		want   int
+		body   string
+	}{
+		{"no header", "secret", "", http.StatusOK, `{"sandbox":false}`},
+		{"matching key", "secret", "secret", http.StatusOK, `{"sandbox":true}`},
+		{"wrong key", "secret", "guess", http.StatusUnauthorized, ""},
+		{"disabled", "", "secret", http.StatusForbidden, ""},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		if tc.header != "" {
+			req.Header.Set(sandbox.Header, tc.header)
+		}
+		w := httptest.NewRecorder()
+		newRouter(tc.key).ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, w.Code, tc.want)
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("%s: body = %s, want %s", tc.name, w.Body.String(), tc.body)
+		}
+	}
+}
@@ -2,27 +2,51 @@
 package http
 
 import (
-	"context"
-	"net/http"
-	"time"
+	"expvar"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 
+	"ark/internal/ai"
+	"ark/internal/flags"
 	"ark/internal/http/handlers"
 	"ark/internal/http/middleware"
 	"ark/internal/modules/aiusage"
+	"ark/internal/modules/arrival"
+	"ark/internal/modules/attachment"
 	"ark/internal/modules/calendar"
+	"ark/internal/modules/campaign"
+	"ark/internal/modules/closure"
+	"ark/internal/modules/commission"
+	"ark/internal/modules/departure"
+	"ark/internal/modules/device"
 	"ark/internal/modules/driver"
+	"ark/internal/modules/earnings"
+	"ark/internal/modules/giftcard"
+	"ark/internal/modules/ledger"
 	"ark/internal/modules/location"
 	"ark/internal/modules/matching"
 	"ark/internal/modules/notification"
 	"ark/internal/modules/order"
+	"ark/internal/modules/organization"
+	"ark/internal/modules/payout"
+	"ark/internal/modules/place"
+	"ark/internal/modules/pretrip"
 	"ark/internal/modules/pricing"
+	"ark/internal/modules/referral"
+	"ark/internal/modules/region"
 	"ark/internal/modules/relation"
 	"ark/internal/modules/rideassistant"
+	"ark/internal/modules/risk"
+	"ark/internal/modules/sla"
+	"ark/internal/modules/subscription"
+	"ark/internal/modules/tracking"
+	"ark/internal/modules/tripaudit"
+	"ark/internal/modules/triproute"
 	"ark/internal/modules/user"
+	"ark/internal/modules/vehicle"
+	"ark/internal/service"
 	"ark/internal/worker"
 )
 
@@ -37,8 +61,37 @@ func NewRouter(
 	driverService *driver.Service,
 	userService *user.Service,
 	relationService *relation.Service,
+	trackingService *tracking.Service,
+	organizationService *organization.Service,
+	referralService *referral.Service,
+	campaignService *campaign.Service,
+	earningsService *earnings.Service,
+	commissionService *commission.Service,
+	payoutService *payout.Service,
+	ledgerService *ledger.Service,
+	tripAuditService *tripaudit.Service,
+	riskService *risk.Service,
+	deviceService *device.Service,
+	departureService *departure.Service,
+	placeService *place.Service,
+	stopSearch *service.StopSearch,
+	tripRouteService *triproute.Service,
+	arrivalService *arrival.Service,
+	pretripService *pretrip.Service,
+	vehicleService *vehicle.Service,
+	attachmentService *attachment.Service,
+	slaService *sla.Service,
+	regionService *region.Service,
+	closureService *closure.Service,
+	flagsService *flags.Service,
+	subscriptionService *subscription.Service,
+	giftCardService *giftcard.Service,
 	tokenVerifier middleware.TokenVerifier,
+	orderTokens *middleware.OrderTokens,
+	sandboxKey string,
+	opsKey string,
 	rideAssistantSvc *rideassistant.Service,
+	prompts *ai.Prompts,
 	dbPool *pgxpool.Pool,
 	redisClient *redis.Client,
 	workerRegistry *worker.Registry,
@@ -48,77 +101,120 @@ func NewRouter(
 	// r.Use(middleware.Logging())
 
 	r := gin.Default()
+	// Test mode is decided per request before routing so every handler sees it.
+	r.Use(middleware.Sandbox(sandboxKey))
 
 	// Public endpoints — no authentication required.
-	r.GET("/health", func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
-		defer cancel()
-
-		status := http.StatusOK
-		result := map[string]any{"status": "ok"}
-
-		// Check Postgres
-		if dbPool != nil {
-			if err := dbPool.Ping(ctx); err != nil {
-				status = http.StatusServiceUnavailable
-				result["db"] = "down"
-			} else {
-				result["db"] = "ok"
-			}
-		} else {
-			result["db"] = "not configured"
-		}
-
-		// Check Redis
-		if redisClient != nil {
-			if err := redisClient.Ping(ctx).Err(); err != nil {
-				status = http.StatusServiceUnavailable
-				result["redis"] = "down"
-			} else {
-				result["redis"] = "ok"
-			}
-		} else {
-			result["redis"] = "not configured"
-		}
-
-		// Check workers
-		if workerRegistry != nil {
-			workerStatus := workerRegistry.Status()
-			workerInfo := make(map[string]string, len(workerStatus))
-			allHealthy := workerRegistry.AllHealthy(60 * time.Second)
-			for name, lastBeat := range workerStatus {
-				age := time.Since(lastBeat)
-				if age > 60*time.Second {
-					workerInfo[name] = "stale"
-				} else {
-					workerInfo[name] = "ok"
-				}
-			}
-			result["workers"] = workerInfo
-			if !allHealthy {
-				status = http.StatusServiceUnavailable
-			}
-		}
-
-		if status != http.StatusOK {
-			result["status"] = "degraded"
-		}
-		c.JSON(status, result)
-	})
+	r.GET("/health", health(dbPool, redisClient, workerRegistry))
+
+	// Order share links: a signed order token stands in for Firebase login and
+	// only grants the status view of that one order.
+	var orderLinkHandler *handlers.OrderLinkHandler
+	if orderTokens != nil {
+		orderLinkHandler = handlers.NewOrderLinkHandler(orderService, orderTokens)
+		r.GET("/api/public/orders/:id/status", middleware.OrderAccess(orderTokens, middleware.ScopeOrderStatus), orderLinkHandler.Status)
+	}
+
+	// Back-office endpoints authenticate with the ops key instead of Firebase.
+	ops := r.Group("/", middleware.OpsKey(opsKey))
+	ops.PUT("/api/ops/drivers/:id/tier", driver.NewHandler(driverService).SetTier)
+	ops.PUT("/api/ops/drivers/:id/region", driver.NewHandler(driverService).SetRegion)
+	ops.PUT("/api/ops/drivers/:id/wav-certification", driver.NewHandler(driverService).SetWAVCertified)
+	ops.GET("/api/ops/reports/wav", handlers.NewOrderHandler(orderService).WAVReport)
+	ops.PUT("/api/ops/users/:id/priority", handlers.NewUserHandler(userService).SetPriority)
+	ops.GET("/api/ops/notifications/dead-letters", handlers.NewNotificationHandler(notificationService).ListDeadLetters)
+	ops.POST("/api/ops/notifications/dead-letters/:id/retry", handlers.NewNotificationHandler(notificationService).RetryDeadLetter)
+	// Runtime counters, including driver heartbeat drops per region and
+	// dispatch wait times per passenger priority class.
+	ops.GET("/api/ops/debug/vars", gin.WrapH(expvar.Handler()))
+	// LLM spend by day, consumer and provider, for finance.
+	ops.GET("/api/admin/ai/usage", handlers.NewAIHandler(aiService).UsageReport)
+	if campaignService != nil {
+		campaign.RegisterOpsRoutes(ops, campaign.NewHandler(campaignService))
+	}
+	if commissionService != nil {
+		commission.RegisterOpsRoutes(ops, commission.NewHandler(commissionService))
+	}
+	if tripAuditService != nil {
+		tripaudit.RegisterOpsRoutes(ops, tripaudit.NewHandler(tripAuditService))
+	}
+	if riskService != nil {
+		risk.RegisterOpsRoutes(ops, risk.NewHandler(riskService))
+	}
+	if deviceService != nil {
+		device.RegisterOpsRoutes(ops, device.NewHandler(deviceService))
+	}
+	if prompts != nil {
+		promptHandler := handlers.NewPromptHandler(prompts)
+		ops.GET("/api/ops/ai/prompts", promptHandler.List)
+		ops.PUT("/api/ops/ai/prompts/:name", promptHandler.Activate)
+	}
+	var payoutHandler *payout.Handler
+	if payoutService != nil {
+		payoutHandler = payout.NewHandler(payoutService)
+		payout.RegisterOpsRoutes(ops, payoutHandler)
+	}
+	if ledgerService != nil {
+		ledger.RegisterOpsRoutes(ops, ledger.NewHandler(ledgerService))
+	}
+	if arrivalService != nil {
+		arrival.RegisterOpsRoutes(ops, arrival.NewHandler(arrivalService))
+	}
+	if pretripService != nil {
+		pretrip.RegisterOpsRoutes(ops, pretrip.NewHandler(pretripService))
+	}
+	var vehicleHandler *vehicle.Handler
+	if vehicleService != nil {
+		vehicleHandler = vehicle.NewHandler(vehicleService)
+		vehicle.RegisterOpsRoutes(ops, vehicleHandler)
+	}
+	if slaService != nil {
+		sla.RegisterOpsRoutes(ops, sla.NewHandler(slaService))
+	}
+	if regionService != nil {
+		region.RegisterOpsRoutes(ops, region.NewHandler(regionService))
+	}
+	if closureService != nil {
+		closure.RegisterOpsRoutes(ops, closure.NewHandler(closureService))
+	}
+	if pricingService != nil {
+		pricing.RegisterOpsRoutes(ops, pricing.NewHandler(pricingService))
+	}
+	if flagsService != nil {
+		flags.RegisterOpsRoutes(ops, flags.NewHandler(flagsService))
+	}
+	if subscriptionService != nil {
+		subscription.RegisterOpsRoutes(ops, subscription.NewHandler(subscriptionService))
+	}
+	if giftCardService != nil {
+		giftcard.RegisterOpsRoutes(ops, giftcard.NewHandler(giftCardService))
+	}
 
 	// All API routes require authentication.
 	api := r.Group("/")
 	api.Use(middleware.Auth(tokenVerifier))
 
 	orderHandler := handlers.NewOrderHandler(orderService)
+	if tripRouteService != nil {
+		orderHandler.SetNavigator(tripRouteService)
+	}
 	// passenger — instant order
-	api.POST("/api/orders", orderHandler.Create)
+	api.POST("/api/orders", device.Capture(deviceService, device.SourceOrder), orderHandler.Create)
 	api.GET("/api/orders/:id/status", orderHandler.Status)
+	api.GET("/api/orders/:id/cancellation", orderHandler.CancellationQuote)
 	api.POST("/api/orders/:id/cancel", orderHandler.Cancel)
+	api.PATCH("/api/orders/:id/pickup", orderHandler.ChangePickup)
+	api.PATCH("/api/orders/:id/dropoff", orderHandler.ChangeDropoff)
+	if orderLinkHandler != nil {
+		api.POST("/api/orders/:id/share-link", orderLinkHandler.Issue)
+	}
 	// passenger — scheduled order
-	api.POST("/api/orders/scheduled", orderHandler.CreateScheduled)
+	api.POST("/api/orders/scheduled", device.Capture(deviceService, device.SourceOrder), orderHandler.CreateScheduled)
 	api.GET("/api/orders/scheduled", orderHandler.ListScheduledByPassenger)
 	api.GET("/api/orders/scheduled/available", orderHandler.ListAvailableScheduled)
+	api.PATCH("/api/orders/:id/schedule", orderHandler.AmendSchedule)
+	api.POST("/api/orders/:id/requote", orderHandler.AnswerRequote)
+	api.GET("/api/drivers/:id/orders", orderHandler.ListByDriver)
 	// driver — instant order
 	api.POST("/api/orders/:id/match", orderHandler.Match)
 	api.POST("/api/orders/:id/accept", orderHandler.Accept)
@@ -126,18 +222,49 @@ func NewRouter(
 	api.POST("/api/orders/:id/arrived", orderHandler.Arrive)
 	api.POST("/api/orders/:id/meet", orderHandler.Meet)
 	api.POST("/api/orders/:id/complete", orderHandler.Complete)
+	api.POST("/api/orders/:id/deliver", orderHandler.Deliver)
+	api.POST("/api/orders/:id/stops", orderHandler.LogStop)
+	api.GET("/api/orders/:id/stops", orderHandler.Stops)
+	api.POST("/api/orders/:id/dropoff/ack", orderHandler.AckDropoff)
 	api.POST("/api/orders/:id/pay", orderHandler.Pay)
 	// driver — scheduled order
 	api.POST("/api/orders/:id/claim", orderHandler.Claim)
+	api.POST("/api/orders/:id/depart", orderHandler.Depart)
 	api.POST("/api/orders/:id/driver-cancel", orderHandler.DriverCancel)
 
+	// passenger "cars around you" preview, wait estimate and driver dispatch offer stream
+	if matchingService != nil {
+		matchingHandler := handlers.NewMatchingHandler(matchingService)
+		api.GET("/api/passengers/nearby-drivers", matchingHandler.NearbyDrivers)
+		api.GET("/api/estimates/wait", matchingHandler.EstimateWait)
+		api.GET("/api/drivers/:id/offers/stream", matchingHandler.StreamOffers)
+	}
+
+	// live positions and passenger ride-seeking presence
+	if locationService != nil {
+		locationHandler := handlers.NewLocationHandler(locationService)
+		api.PUT("/api/passenger/presence", locationHandler.SetPresence)
+		api.DELETE("/api/passenger/presence", locationHandler.ClearPresence)
+		api.POST("/api/location/update", locationHandler.UpdateLocation)
+		api.GET("/api/location/stream", locationHandler.StreamDriverLocation)
+		api.PUT("/api/passengers/:id/location", locationHandler.UpdatePassengerLocation)
+	}
+
+	// stop suggestions along a route
+	if stopSearch != nil {
+		routeSearchHandler := handlers.NewRouteSearchHandler(stopSearch)
+		api.POST("/api/routes/search-along", routeSearchHandler.SearchAlong)
+	}
+
 	// ai model
 	aiHandler := handlers.NewAIHandler(aiService)
 	api.POST("/api/ai/chat", aiHandler.Chat)
 
 	// notifications
 	notificationHandler := handlers.NewNotificationHandler(notificationService)
-	api.POST("/api/notifications/register", notificationHandler.EnsureDevice)
+	api.POST("/api/notifications/register", device.Capture(deviceService, device.SourceRegistration), notificationHandler.EnsureDevice)
+	api.GET("/api/notifications/preferences", notificationHandler.GetPreferences)
+	api.PUT("/api/notifications/preferences", notificationHandler.UpdatePreferences)
 	// [TODO] for staff only
 	// api.POST("/api/notifications/send", notificationHandler.SendNotification)
 
@@ -156,20 +283,87 @@ func NewRouter(
 	api.GET("/api/me", userHandler.GetMe)
 	api.PATCH("/api/me", userHandler.UpdateMe)
 	api.DELETE("/api/me", userHandler.DeleteMe)
+	api.DELETE("/api/users/me", userHandler.DeleteMe)
 
 	// driver profile & status (auth required; driver_id always from context)
 	driverHandler := driver.NewHandler(driverService)
-	api.POST("/api/driver/create", driverHandler.Create)
+	api.POST("/api/driver/create", device.Capture(deviceService, device.SourceRegistration), driverHandler.Create)
 	api.PATCH("/api/driver/status", driverHandler.UpdateStatus)
+	api.PUT("/api/driver/capabilities", driverHandler.UpdateCapabilities)
+	// vehicle inspection and maintenance
+	if vehicleHandler != nil {
+		vehicle.RegisterRoutes(api, vehicleHandler)
+	}
+
+	// order photos (luggage, meeting point, proof of delivery)
+	if attachmentService != nil {
+		attachment.RegisterRoutes(api, attachment.NewHandler(attachmentService))
+	}
 
 	// relations (friend requests & friendships)
 	relationHandler := relation.NewHandler(relationService)
 	relation.RegisterRoutes(api, relationHandler)
 
+	// live trip tracking grants for friends/family
+	if trackingService != nil {
+		tracking.RegisterRoutes(api, tracking.NewHandler(trackingService))
+	}
+
+	// corporate accounts and business rides
+	if organizationService != nil {
+		organization.RegisterRoutes(api, organization.NewHandler(organizationService))
+	}
+
+	// earlier-pickup suggestions for arrive-by rides
+	if departureService != nil {
+		departure.RegisterRoutes(api, departure.NewHandler(departureService))
+	}
+
+	// driving route of accepted orders
+	if tripRouteService != nil {
+		triproute.RegisterRoutes(api, triproute.NewHandler(tripRouteService))
+	}
+
+	// saved favorite and recent places
+	if placeService != nil {
+		place.RegisterRoutes(api, place.NewHandler(placeService))
+	}
+
+	// referral codes and ride credits
+	if referralService != nil {
+		referral.RegisterRoutes(api, referral.NewHandler(referralService))
+	}
+
+	// gift cards redeemed into ride credits
+	if giftCardService != nil {
+		giftcard.RegisterRoutes(api, giftcard.NewHandler(giftCardService))
+	}
+
+	// ride plans
+	if subscriptionService != nil {
+		subscription.RegisterRoutes(api, subscription.NewHandler(subscriptionService))
+	}
+
+	// driver earnings and quests
+	if earningsService != nil {
+		earnings.RegisterRoutes(api, earnings.NewHandler(earningsService))
+	}
+	if payoutHandler != nil {
+		payout.RegisterRoutes(api, payoutHandler)
+	}
+	if campaignService != nil {
+		campaign.RegisterRoutes(api, campaign.NewHandler(campaignService))
+	}
+
 	// ride assistant
 	if rideAssistantSvc != nil {
 		raHandler := handlers.NewRideAssistantHandler(rideAssistantSvc)
 		api.POST("/api/assistant/ride/messages", raHandler.HandleMessage)
+		api.GET("/api/assistant/conversations", raHandler.ListConversations)
+		api.GET("/api/assistant/conversations/export", raHandler.ExportConversations)
+		api.DELETE("/api/assistant/conversations", raHandler.DeleteConversations)
+		api.DELETE("/api/assistant/conversations/:id", raHandler.DeleteConversation)
+		ops.GET("/api/ops/reports/ai-conversations", raHandler.ConversationReport)
 	}
 
 	return r
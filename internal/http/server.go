@@ -7,19 +7,45 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 
+	"ark/internal/ai"
+	"ark/internal/flags"
 	"ark/internal/http/middleware"
 	"ark/internal/worker"
 	"ark/internal/modules/aiusage"
+	"ark/internal/modules/arrival"
+	"ark/internal/modules/attachment"
 	"ark/internal/modules/rideassistant"
 	"ark/internal/modules/calendar"
+	"ark/internal/modules/campaign"
+	"ark/internal/modules/closure"
+	"ark/internal/modules/commission"
+	"ark/internal/modules/departure"
+	"ark/internal/modules/device"
 	"ark/internal/modules/driver"
+	"ark/internal/modules/earnings"
+	"ark/internal/modules/giftcard"
+	"ark/internal/modules/ledger"
 	"ark/internal/modules/location"
 	"ark/internal/modules/matching"
 	"ark/internal/modules/notification"
 	"ark/internal/modules/order"
+	"ark/internal/modules/organization"
+	"ark/internal/modules/payout"
+	"ark/internal/modules/subscription"
+	"ark/internal/modules/place"
+	"ark/internal/modules/pretrip"
 	"ark/internal/modules/pricing"
+	"ark/internal/modules/referral"
+	"ark/internal/modules/region"
 	"ark/internal/modules/relation"
+	"ark/internal/modules/risk"
+	"ark/internal/modules/sla"
+	"ark/internal/modules/tracking"
+	"ark/internal/modules/tripaudit"
+	"ark/internal/modules/triproute"
 	"ark/internal/modules/user"
+	"ark/internal/modules/vehicle"
+	"ark/internal/service"
 )
 
 type ServerDeps struct {
@@ -33,8 +59,37 @@ type ServerDeps struct {
 	Driver       *driver.Service
 	User         *user.Service
 	Relation     *relation.Service
+	Tracking     *tracking.Service
+	Organization *organization.Service
+	Referral     *referral.Service
+	Campaign     *campaign.Service
+	Earnings     *earnings.Service
+	Commission   *commission.Service
+	Payout       *payout.Service
+	Ledger       *ledger.Service
+	TripAudit    *tripaudit.Service
+	Risk         *risk.Service
+	Device       *device.Service
+	Departure    *departure.Service // nil without Maps
+	Place        *place.Service
+	Stops        *service.StopSearch // nil without Maps
+	TripRoute    *triproute.Service  // nil without Maps
+	Arrival      *arrival.Service
+	Pretrip      *pretrip.Service
+	Vehicle      *vehicle.Service
+	Attachment   *attachment.Service // nil without ARK_ATTACHMENT_BUCKET
+	SLA          *sla.Service
+	Region       *region.Service
+	Closure      *closure.Service
+	Flags        *flags.Service
+	Subscription *subscription.Service
+	GiftCard     *giftcard.Service
 	Auth         middleware.TokenVerifier // Firebase token verifier; nil disables auth (dev mode)
+	OrderTokens  *middleware.OrderTokens  // signs order share links; nil disables them
+	SandboxKey   string                   // X-Ark-Sandbox key enabling test mode; empty disables it
+	OpsKey       string                   // X-Ark-Ops-Key for back-office endpoints; empty disables them
 	RideAssistant *rideassistant.Service
+	Prompts       *ai.Prompts // AI prompt versions; nil hides the ops endpoints
 	DB            *pgxpool.Pool
 	Redis         *redis.Client
 	Workers       *worker.Registry
@@ -45,7 +100,7 @@ type Server struct {
 }
 
 func NewServer(deps ServerDeps) *Server {
-	engine := NewRouter(deps.Order, deps.Matching, deps.Location, deps.Pricing, deps.AI, deps.Notification, deps.Calendar, deps.Driver, deps.User, deps.Relation, deps.Auth, deps.RideAssistant, deps.DB, deps.Redis, deps.Workers)
+	engine := NewRouter(deps.Order, deps.Matching, deps.Location, deps.Pricing, deps.AI, deps.Notification, deps.Calendar, deps.Driver, deps.User, deps.Relation, deps.Tracking, deps.Organization, deps.Referral, deps.Campaign, deps.Earnings, deps.Commission, deps.Payout, deps.Ledger, deps.TripAudit, deps.Risk, deps.Device, deps.Departure, deps.Place, deps.Stops, deps.TripRoute, deps.Arrival, deps.Pretrip, deps.Vehicle, deps.Attachment, deps.SLA, deps.Region, deps.Closure, deps.Flags, deps.Subscription, deps.GiftCard, deps.Auth, deps.OrderTokens, deps.SandboxKey, deps.OpsKey, deps.RideAssistant, deps.Prompts, deps.DB, deps.Redis, deps.Workers)
 	return &Server{Engine: engine}
 }
 
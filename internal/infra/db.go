@@ -3,10 +3,66 @@ package infra
 
 import (
     "context"
+    "expvar"
+    "strconv"
+    "time"
 
     "github.com/jackc/pgx/v5/pgxpool"
+
+    "ark/internal/config"
 )
 
-func NewDB(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
-    return pgxpool.New(ctx, dsn)
+// NewDB opens the pool described by cfg and waits up to cfg.ConnectWait for
+// the database to answer. Postgres cancels statements after statementTimeout,
+// so one slow query cannot hold a request; 0 leaves them unbounded. Pool
+// usage is published as db_pool on /api/ops/debug/vars.
+func NewDB(ctx context.Context, cfg config.DBConfig, statementTimeout time.Duration) (*pgxpool.Pool, error) {
+    pc, err := pgxpool.ParseConfig(cfg.DSN)
+    if err != nil {
+        return nil, err
+    }
+    if cfg.MaxConns > 0 {
+        pc.MaxConns = int32(cfg.MaxConns)
+    }
+    pc.MinConns = int32(cfg.MinConns)
+    if cfg.MaxConnLifetime > 0 {
+        pc.MaxConnLifetime = cfg.MaxConnLifetime
+    }
+    if cfg.MaxConnIdleTime > 0 {
+        pc.MaxConnIdleTime = cfg.MaxConnIdleTime
+    }
+    if statementTimeout > 0 {
+        pc.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+    }
+
+    pool, err := pgxpool.NewWithConfig(ctx, pc)
+    if err != nil {
+        return nil, err
+    }
+    if err := waitReady(ctx, "postgres", cfg.ConnectWait, pool.Ping); err != nil {
+        pool.Close()
+        return nil, err
+    }
+    publish("db_pool", func() any {
+        st := pool.Stat()
+        return map[string]any{
+            "max_conns":           st.MaxConns(),
+            "total_conns":         st.TotalConns(),
+            "acquired_conns":      st.AcquiredConns(),
+            "idle_conns":          st.IdleConns(),
+            "utilization":         float64(st.AcquiredConns()) / float64(max(st.MaxConns(), 1)),
+            "acquire_count":       st.AcquireCount(),
+            "empty_acquire_count": st.EmptyAcquireCount(),
+            "canceled_acquires":   st.CanceledAcquireCount(),
+            "acquire_wait_ms":     st.AcquireDuration().Milliseconds(),
+        }
+    })
+    return pool, nil
+}
+
+// publish exposes fn under name once per process; later pools keep the first.
+func publish(name string, fn func() any) {
+    if expvar.Get(name) == nil {
+        expvar.Publish(name, expvar.Func(fn))
+    }
 }
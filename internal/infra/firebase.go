@@ -0,0 +1,130 @@
+// README: Firebase app initialization shared by auth, FCM and RTDB clients.
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	gcs "cloud.google.com/go/storage"
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
+	"google.golang.org/api/option"
+
+	"ark/internal/config"
+)
+
+// NewFirebaseApp initialises the single firebase.App used by every Firebase client.
+// It returns (nil, nil) when no credentials are configured (dev mode).
+func NewFirebaseApp(ctx context.Context, cfg config.FirebaseConfig) (*firebase.App, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	creds := []byte(cfg.CredentialsJSON)
+	opt := option.WithCredentialsJSON(creds)
+	if len(creds) == 0 {
+		b, err := os.ReadFile(cfg.CredentialsPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading firebase credentials: %w", err)
+		}
+		creds = b
+		opt = option.WithCredentialsFile(cfg.CredentialsPath)
+	}
+
+	projectID := cfg.ProjectID
+	if projectID == "" {
+		id, err := projectIDFromCredentials(creds)
+		if err != nil {
+			return nil, err
+		}
+		projectID = id
+	}
+
+	conf := &firebase.Config{
+		ProjectID:   projectID,
+		DatabaseURL: cfg.RTDBURL(projectID),
+	}
+	app, err := firebase.NewApp(ctx, conf, opt)
+	if err != nil {
+		return nil, fmt.Errorf("initialising firebase app: %w", err)
+	}
+	return app, nil
+}
+
+// NewFirebaseVerifier returns the ID-token verifier backed by app.
+// *auth.Client satisfies middleware.TokenVerifier.
+func NewFirebaseVerifier(ctx context.Context, app *firebase.App) (*auth.Client, error) {
+	if app == nil {
+		return nil, errors.New("firebase verifier: app is nil")
+	}
+	client, err := app.Auth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("initialising firebase auth client: %w", err)
+	}
+	return client, nil
+}
+
+// NewFirebaseBucket returns the Cloud Storage bucket name, accessed with the
+// Firebase app's credentials.
+func NewFirebaseBucket(ctx context.Context, app *firebase.App, name string) (*gcs.BucketHandle, error) {
+	if app == nil {
+		return nil, errors.New("firebase bucket: app is nil")
+	}
+	client, err := app.Storage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("initialising firebase storage client: %w", err)
+	}
+	bucket, err := client.Bucket(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening bucket %s: %w", name, err)
+	}
+	return bucket, nil
+}
+
+func projectIDFromCredentials(data []byte) (string, error) {
+	var sa struct {
+		ProjectID string `json:"project_id"`
+	}
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return "", fmt.Errorf("parsing firebase credentials JSON: %w", err)
+	}
+	if sa.ProjectID == "" {
+		return "", errors.New("project_id is empty in firebase credentials JSON")
+	}
+	return sa.ProjectID, nil
+}
+
+// FirebaseIdentity adapts the Firebase Auth client to user.IdentityProvider.
+type FirebaseIdentity struct {
+	client *auth.Client
+}
+
+func NewFirebaseIdentity(client *auth.Client) *FirebaseIdentity {
+	return &FirebaseIdentity{client: client}
+}
+
+// RevokeSessions revokes refresh tokens and disables the account so no new ID
+// tokens can be minted during the deletion grace period.
+func (f *FirebaseIdentity) RevokeSessions(ctx context.Context, uid string) error {
+	if err := f.client.RevokeRefreshTokens(ctx, uid); err != nil {
+		if auth.IsUserNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("revoking firebase tokens: %w", err)
+	}
+	if _, err := f.client.UpdateUser(ctx, uid, (&auth.UserToUpdate{}).Disabled(true)); err != nil {
+		return fmt.Errorf("disabling firebase user: %w", err)
+	}
+	return nil
+}
+
+// DeleteIdentity deletes the Firebase Auth user; an already-deleted user is not an error.
+func (f *FirebaseIdentity) DeleteIdentity(ctx context.Context, uid string) error {
+	if err := f.client.DeleteUser(ctx, uid); err != nil && !auth.IsUserNotFound(err) {
+		return fmt.Errorf("deleting firebase user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// README: Startup readiness — retries a dependency with backoff so the API can start before Postgres or Redis finish booting.
+package infra
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Backoff between readiness probes, doubling from readyBackoff up to readyMaxBackoff.
+const (
+	readyBackoff    = 500 * time.Millisecond
+	readyMaxBackoff = 5 * time.Second
+)
+
+// waitReady calls ping until it succeeds, retrying with backoff for up to
+// wait (0 tries once). It returns the last ping error once wait runs out.
+func waitReady(ctx context.Context, name string, wait time.Duration, ping func(context.Context) error) error {
+	deadline := time.Now().Add(wait)
+	backoff := readyBackoff
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("%s: ready after %d attempts", name, attempt)
+			}
+			return nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%s not ready after %d attempts: %w", name, attempt, err)
+		}
+		log.Printf("%s: not ready (attempt %d): %v; retrying in %v", name, attempt, err, min(backoff, remaining))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(backoff, remaining)):
+		}
+		backoff = min(backoff*2, readyMaxBackoff)
+	}
+}
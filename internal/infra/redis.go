@@ -1,8 +1,44 @@
 // README: Redis client initialization for GEO and queues.
 package infra
 
-import "github.com/redis/go-redis/v9"
+import (
+    "context"
+    "time"
 
-func NewRedis(addr string) *redis.Client {
-    return redis.NewClient(&redis.Options{Addr: addr})
+    "github.com/redis/go-redis/v9"
+
+    "ark/internal/config"
+)
+
+// NewRedis returns a client for the pool described by cfg, once Redis answers
+// or cfg.ConnectWait runs out. Dials, reads and writes each fail after
+// timeout; 0 keeps the go-redis defaults. Pool usage is published as
+// redis_pool on /api/ops/debug/vars.
+func NewRedis(ctx context.Context, cfg config.RedisConfig, timeout time.Duration) (*redis.Client, error) {
+    client := redis.NewClient(&redis.Options{
+        Addr:         cfg.Addr,
+        PoolSize:     cfg.PoolSize,
+        MinIdleConns: cfg.MinIdleConns,
+        DialTimeout:  timeout,
+        ReadTimeout:  timeout,
+        WriteTimeout: timeout,
+    })
+    ping := func(ctx context.Context) error { return client.Ping(ctx).Err() }
+    if err := waitReady(ctx, "redis", cfg.ConnectWait, ping); err != nil {
+        client.Close()
+        return nil, err
+    }
+    publish("redis_pool", func() any {
+        st := client.PoolStats()
+        return map[string]any{
+            "pool_size":   client.Options().PoolSize,
+            "total_conns": st.TotalConns,
+            "idle_conns":  st.IdleConns,
+            "stale_conns": st.StaleConns,
+            "hits":        st.Hits,
+            "misses":      st.Misses,
+            "timeouts":    st.Timeouts,
+        }
+    })
+    return client, nil
 }
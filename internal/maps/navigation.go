@@ -0,0 +1,28 @@
+package maps
+
+import (
+	"fmt"
+
+	"ark/internal/types"
+)
+
+// NavLinks open turn-by-turn driving directions to one destination in Google
+// Maps, starting from the device's current position.
+type NavLinks struct {
+	// Web is a universal link: the Maps app where installed, the browser
+	// elsewhere.
+	Web string
+	// Android starts navigation straight away in the Android Maps app.
+	Android string
+	// IOS opens the Google Maps app on iOS.
+	IOS string
+}
+
+// NavigationLinks returns the Google Maps links that navigate to dest.
+func NavigationLinks(dest types.Point) NavLinks {
+	return NavLinks{
+		Web:     fmt.Sprintf("https://www.google.com/maps/dir/?api=1&destination=%.6f,%.6f&travelmode=driving", dest.Lat, dest.Lng),
+		Android: fmt.Sprintf("google.navigation:q=%.6f,%.6f&mode=d", dest.Lat, dest.Lng),
+		IOS:     fmt.Sprintf("comgooglemaps://?daddr=%.6f,%.6f&directionsmode=driving", dest.Lat, dest.Lng),
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"googlemaps.github.io/maps"
+
+	"ark/internal/timeout"
 )
 
 // Place represents a simplified location result.
@@ -15,6 +17,10 @@ type Place struct {
 	Rating           float32
 	PlaceID          string
 	UserRatingsTotal int
+	// OpenNow is nil when Places does not know the opening hours.
+	OpenNow *bool
+	// PriceLevel runs from 1 (inexpensive) to 4 (very expensive); 0 is unknown.
+	PriceLevel int
 }
 
 // SearchOptions holds dynamic search refinement parameters from the AI.
@@ -72,6 +78,8 @@ func (s *PlacesService) SearchNearby(ctx context.Context, location string, query
 		r.Type = "florist"
 	}
 
+	ctx, cancel := timeout.Maps(ctx)
+	defer cancel()
 	resp, err := s.client.TextSearch(ctx, r)
 	if err != nil {
 		return nil, fmt.Errorf("places api error: %w", err)
@@ -113,13 +121,18 @@ func (s *PlacesService) SearchNearby(ctx context.Context, location string, query
 			continue
 		}
 
-		results = append(results, Place{
+		place := Place{
 			Name:             result.Name,
 			Address:          result.FormattedAddress,
 			Rating:           result.Rating,
 			PlaceID:          result.PlaceID,
 			UserRatingsTotal: result.UserRatingsTotal,
-		})
+			PriceLevel:       result.PriceLevel,
+		}
+		if result.OpeningHours != nil {
+			place.OpenNow = result.OpeningHours.OpenNow
+		}
+		results = append(results, place)
 
 		if len(results) >= 3 { // Limit to top 3
 			break
@@ -0,0 +1,78 @@
+// Package ranking orders places found along a route as stop recommendations.
+// It is shared by the AI trip planner and any caller that has already worked
+// out each candidate's detour.
+package ranking
+
+import (
+	"sort"
+	"time"
+
+	"ark/internal/maps"
+)
+
+// DefaultMaxDetour is the longest detour worth recommending.
+const DefaultMaxDetour = 15 * time.Minute
+
+// Weights turn a candidate's attributes into a cost; the cheapest candidate
+// ranks first. Each weight is the cost of one unit of its attribute, so
+// Rating: 4 with Detour: 1 trades half a star for two minutes of driving.
+type Weights struct {
+	// Detour is the cost per minute of detour.
+	Detour float64
+	// Rating is the credit per star of rating.
+	Rating float64
+	// PriceLevel is the cost per Places price level (1–4); unknown prices cost nothing.
+	PriceLevel float64
+	// UnknownHours is the cost of a place whose opening hours are unknown.
+	UnknownHours float64
+}
+
+// DefaultWeights favour short detours, then well-rated places.
+var DefaultWeights = Weights{Detour: 1, Rating: 4, PriceLevel: 0.5, UnknownHours: 2}
+
+// Options configure Rank.
+type Options struct {
+	Weights Weights
+	// MaxDetour drops candidates with a longer detour; zero means DefaultMaxDetour.
+	MaxDetour time.Duration
+	// Limit caps the number of results; zero returns all that qualify.
+	Limit int
+}
+
+// Candidate is a place with the extra driving time it adds to the trip.
+type Candidate struct {
+	Place  maps.Place
+	Detour time.Duration
+	// Score is the cost Rank assigned; lower is better.
+	Score float64
+}
+
+// Rank drops places that are closed or too far off the route and returns the
+// rest cheapest first. Equal costs keep their input order. cands is not modified.
+func Rank(cands []Candidate, opts Options) []Candidate {
+	maxDetour := opts.MaxDetour
+	if maxDetour == 0 {
+		maxDetour = DefaultMaxDetour
+	}
+	out := make([]Candidate, 0, len(cands))
+	for _, c := range cands {
+		if c.Detour > maxDetour || (c.Place.OpenNow != nil && !*c.Place.OpenNow) {
+			continue
+		}
+		c.Score = score(c, opts.Weights)
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score < out[j].Score })
+	if opts.Limit > 0 && len(out) > opts.Limit {
+		out = out[:opts.Limit]
+	}
+	return out
+}
+
+func score(c Candidate, w Weights) float64 {
+	s := w.Detour*c.Detour.Minutes() - w.Rating*float64(c.Place.Rating) + w.PriceLevel*float64(c.Place.PriceLevel)
+	if c.Place.OpenNow == nil {
+		s += w.UnknownHours
+	}
+	return s
+}
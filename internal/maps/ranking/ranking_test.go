@@ -0,0 +1,68 @@
+package ranking
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"ark/internal/maps"
+)
+
+func open(v bool) *bool { return &v }
+
+func names(cands []Candidate) []string {
+	out := make([]string, len(cands))
+	for i, c := range cands {
+		out[i] = c.Place.Name
+	}
+	return out
+}
+
+func TestRank_DetourThenRating(t *testing.T) {
+	cands := []Candidate{
+		{Place: maps.Place{Name: "far", Rating: 4.9, OpenNow: open(true)}, Detour: 12 * time.Minute},
+		{Place: maps.Place{Name: "near", Rating: 4.2, OpenNow: open(true)}, Detour: 3 * time.Minute},
+		// One minute further than "near" but half a star better.
+		{Place: maps.Place{Name: "better", Rating: 4.7, OpenNow: open(true)}, Detour: 4 * time.Minute},
+	}
+	got := names(Rank(cands, Options{Weights: DefaultWeights}))
+	if want := []string{"better", "near", "far"}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if cands[0].Score != 0 {
+		t.Errorf("input was modified")
+	}
+}
+
+func TestRank_Filters(t *testing.T) {
+	cands := []Candidate{
+		{Place: maps.Place{Name: "closed", Rating: 5, OpenNow: open(false)}, Detour: time.Minute},
+		{Place: maps.Place{Name: "detour", Rating: 5, OpenNow: open(true)}, Detour: 16 * time.Minute},
+		{Place: maps.Place{Name: "ok", Rating: 4, OpenNow: open(true)}, Detour: 5 * time.Minute},
+	}
+	got := names(Rank(cands, Options{Weights: DefaultWeights}))
+	if want := []string{"ok"}; !slices.Equal(got, want) {
+		t.Errorf("default options = %v, want %v", got, want)
+	}
+	got = names(Rank(cands, Options{Weights: DefaultWeights, MaxDetour: 20 * time.Minute}))
+	if want := []string{"ok", "detour"}; !slices.Equal(got, want) {
+		t.Errorf("max detour 20m = %v, want %v", got, want)
+	}
+}
+
+func TestRank_PriceAndHoursWeights(t *testing.T) {
+	cands := []Candidate{
+		{Place: maps.Place{Name: "pricey", Rating: 4.5, PriceLevel: 4, OpenNow: open(true)}, Detour: 5 * time.Minute},
+		{Place: maps.Place{Name: "unknown hours", Rating: 4.5}, Detour: 5 * time.Minute},
+		{Place: maps.Place{Name: "cheap", Rating: 4.5, PriceLevel: 1, OpenNow: open(true)}, Detour: 5 * time.Minute},
+	}
+	got := names(Rank(cands, Options{Weights: DefaultWeights, Limit: 2}))
+	if want := []string{"cheap", "pricey"}; !slices.Equal(got, want) {
+		t.Errorf("default weights = %v, want %v", got, want)
+	}
+	// Ignoring price and hours keeps the input order.
+	got = names(Rank(cands, Options{Weights: Weights{Detour: 1, Rating: 4}}))
+	if want := []string{"pricey", "unknown hours", "cheap"}; !slices.Equal(got, want) {
+		t.Errorf("detour and rating only = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package maps
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"googlemaps.github.io/maps"
+
+	"ark/internal/types"
+)
+
+// routeTTL is how long a looked-up route is reused for the same endpoints.
+const routeTTL = 10 * time.Minute
+
+// maxCachedRoutes bounds the route cache; it is emptied when full.
+const maxCachedRoutes = 1000
+
+// Route is a driving route with its geometry, for drawing it on a map.
+type Route struct {
+	// Polyline is the route overview in Google's encoded polyline format.
+	Polyline       string
+	DistanceMeters int
+	// DistanceText is the distance for display, e.g. "12.3 公里".
+	DistanceText string
+	Duration     time.Duration
+	Steps        []Step
+}
+
+// Step is one turn-by-turn instruction of a Route.
+type Step struct {
+	// Instruction is the plain-text direction, e.g. "向右轉，朝中山北路前進".
+	Instruction    string
+	DistanceMeters int
+	Duration       time.Duration
+	Start          types.Point
+	End            types.Point
+}
+
+type routeCache struct {
+	mu      sync.Mutex
+	entries map[string]routeEntry
+}
+
+type routeEntry struct {
+	route *Route
+	at    time.Time
+}
+
+func (c *routeCache) get(key string) (*Route, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Since(e.at) >= routeTTL {
+		return nil, false
+	}
+	return e.route, true
+}
+
+func (c *routeCache) put(key string, r *Route) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil || len(c.entries) >= maxCachedRoutes {
+		c.entries = make(map[string]routeEntry)
+	}
+	c.entries[key] = routeEntry{route: r, at: time.Now()}
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// GetRoute returns the driving route from origin to destination, given as
+// addresses or "lat,lng". Endpoints Directions cannot resolve are geocoded
+// and retried. Routes are cached for a few minutes; callers must not modify
+// the result.
+func (s *RouteService) GetRoute(ctx context.Context, origin, destination string) (*Route, error) {
+	return s.getRoute(ctx, origin, destination, nil)
+}
+
+func (s *RouteService) getRoute(ctx context.Context, origin, destination string, waypoints []string) (*Route, error) {
+	key := origin + "|" + destination + "|" + strings.Join(waypoints, "|")
+	if r, ok := s.routes.get(key); ok {
+		return r, nil
+	}
+
+	r := &maps.DirectionsRequest{
+		Origin:      origin,
+		Destination: destination,
+		Waypoints:   waypoints,
+		Mode:        maps.TravelModeDriving,
+		Language:    "zh-TW", // Traditional Chinese for consistency
+		Region:      "TW",    // Bias results to Taiwan
+	}
+
+	routes, err := s.directions(ctx, r)
+
+	// Fallback: If no route found, try Geocoding the endpoints first
+	if err != nil || len(routes) == 0 {
+		geoOrigin, err1 := s.Geocode(ctx, origin)
+		geoDest, err2 := s.Geocode(ctx, destination)
+
+		if err1 == nil && err2 == nil {
+			// Retry with Coordinates
+			r.Origin = geoOrigin
+			r.Destination = geoDest
+			routes, err = s.directions(ctx, r)
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("maps api error: %w", err)
+	}
+	if len(routes) == 0 || len(routes[0].Legs) == 0 {
+		return nil, fmt.Errorf("no route found")
+	}
+
+	route := toRoute(routes[0])
+	s.routes.put(key, route)
+	return route, nil
+}
+
+// GetPointRoute is GetRoute between two coordinates.
+func (s *RouteService) GetPointRoute(ctx context.Context, origin, destination types.Point) (*Route, error) {
+	return s.GetRoute(ctx, fmt.Sprintf("%f,%f", origin.Lat, origin.Lng), fmt.Sprintf("%f,%f", destination.Lat, destination.Lng))
+}
+
+// GetPointRouteVia is GetPointRoute passing through each of via in order
+// without stopping there, e.g. to steer around a road closure.
+func (s *RouteService) GetPointRouteVia(ctx context.Context, origin, destination types.Point, via []types.Point) (*Route, error) {
+	waypoints := make([]string, len(via))
+	for i, p := range via {
+		waypoints[i] = fmt.Sprintf("via:%f,%f", p.Lat, p.Lng)
+	}
+	return s.getRoute(ctx, fmt.Sprintf("%f,%f", origin.Lat, origin.Lng), fmt.Sprintf("%f,%f", destination.Lat, destination.Lng), waypoints)
+}
+
+// DecodePath returns the points of an encoded polyline, such as
+// Route.Polyline.
+func DecodePath(polyline string) ([]types.Point, error) {
+	ll, err := maps.DecodePolyline(polyline)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]types.Point, len(ll))
+	for i, p := range ll {
+		out[i] = types.Point{Lat: p.Lat, Lng: p.Lng}
+	}
+	return out, nil
+}
+
+func toRoute(r maps.Route) *Route {
+	out := &Route{Polyline: r.OverviewPolyline.Points}
+	for _, leg := range r.Legs {
+		out.DistanceMeters += leg.Distance.Meters
+		out.Duration += leg.Duration
+		for _, st := range leg.Steps {
+			out.Steps = append(out.Steps, Step{
+				Instruction:    html.UnescapeString(htmlTag.ReplaceAllString(st.HTMLInstructions, "")),
+				DistanceMeters: st.Distance.Meters,
+				Duration:       st.Duration,
+				Start:          types.Point{Lat: st.StartLocation.Lat, Lng: st.StartLocation.Lng},
+				End:            types.Point{Lat: st.EndLocation.Lat, Lng: st.EndLocation.Lng},
+			})
+		}
+	}
+	if len(r.Legs) == 1 {
+		out.DistanceText = r.Legs[0].Distance.HumanReadable
+	} else {
+		out.DistanceText = fmt.Sprintf("%.1f 公里", float64(out.DistanceMeters)/1000)
+	}
+	return out
+}
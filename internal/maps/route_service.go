@@ -3,14 +3,19 @@ package maps
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"googlemaps.github.io/maps"
+
+	"ark/internal/timeout"
+	"ark/internal/types"
 )
 
 // RouteService handles interactions with Google Maps API.
 type RouteService struct {
 	client *maps.Client
+	routes routeCache
 }
 
 // NewRouteService creates a new RouteService with the given API Key.
@@ -22,6 +27,14 @@ func NewRouteService(apiKey string) (*RouteService, error) {
 	return &RouteService{client: client}, nil
 }
 
+// directions calls the Directions API within the Maps timeout budget.
+func (s *RouteService) directions(ctx context.Context, r *maps.DirectionsRequest) ([]maps.Route, error) {
+	ctx, cancel := timeout.Maps(ctx)
+	defer cancel()
+	routes, _, err := s.client.Directions(ctx, r)
+	return routes, err
+}
+
 // Geocode converts an address string to a lat,lng string.
 func (s *RouteService) Geocode(ctx context.Context, address string) (string, error) {
 	r := &maps.GeocodingRequest{
@@ -30,6 +43,8 @@ func (s *RouteService) Geocode(ctx context.Context, address string) (string, err
 		Region:   "TW",
 	}
 
+	ctx, cancel := timeout.Maps(ctx)
+	defer cancel()
 	results, err := s.client.Geocode(ctx, r)
 	if err != nil {
 		return "", fmt.Errorf("geocoding error: %w", err)
@@ -43,43 +58,34 @@ func (s *RouteService) Geocode(ctx context.Context, address string) (string, err
 	return fmt.Sprintf("%f,%f", loc.Lat, loc.Lng), nil
 }
 
-// GetTravelEstimate returns the duration and distance string for a trip from origin to destination.
-// It assumes driving mode.
-func (s *RouteService) GetTravelEstimate(ctx context.Context, origin, destination string) (time.Duration, string, error) {
-	r := &maps.DirectionsRequest{
-		Origin:      origin,
-		Destination: destination,
-		Mode:        maps.TravelModeDriving,
-		Language:    "zh-TW", // Traditional Chinese for consistency
-		Region:      "TW",    // Bias results to Taiwan
-	}
-
-	routes, _, err := s.client.Directions(ctx, r)
-
-	// Fallback: If no route found, try Geocoding the endpoints first
-	if err != nil || len(routes) == 0 {
-		// Try Geocoding
-		geoOrigin, err1 := s.Geocode(ctx, origin)
-		geoDest, err2 := s.Geocode(ctx, destination)
-
-		if err1 == nil && err2 == nil {
-			// Retry with Coordinates
-			r.Origin = geoOrigin
-			r.Destination = geoDest
-			routes, _, err = s.client.Directions(ctx, r)
-		}
+// ReverseGeocode returns the formatted address nearest to p.
+func (s *RouteService) ReverseGeocode(ctx context.Context, p types.Point) (string, error) {
+	r := &maps.GeocodingRequest{
+		LatLng:   &maps.LatLng{Lat: p.Lat, Lng: p.Lng},
+		Language: "zh-TW",
+		Region:   "TW",
 	}
 
+	ctx, cancel := timeout.Maps(ctx)
+	defer cancel()
+	results, err := s.client.ReverseGeocode(ctx, r)
 	if err != nil {
-		return 0, "", fmt.Errorf("maps api error: %w", err)
+		return "", fmt.Errorf("reverse geocoding error: %w", err)
 	}
-
-	if len(routes) == 0 || len(routes[0].Legs) == 0 {
-		return 0, "", fmt.Errorf("no route found")
+	if len(results) == 0 {
+		return "", fmt.Errorf("no address at %f,%f", p.Lat, p.Lng)
 	}
+	return results[0].FormattedAddress, nil
+}
 
-	leg := routes[0].Legs[0]
-	return leg.Duration, leg.Distance.HumanReadable, nil
+// GetTravelEstimate returns the duration and distance string for a trip from origin to destination.
+// It assumes driving mode.
+func (s *RouteService) GetTravelEstimate(ctx context.Context, origin, destination string) (time.Duration, string, error) {
+	route, err := s.GetRoute(ctx, origin, destination)
+	if err != nil {
+		return 0, "", err
+	}
+	return route.Duration, route.DistanceText, nil
 }
 
 // GetDetourEstimate calculates the extra time needed to add a stop.
@@ -101,7 +107,7 @@ func (s *RouteService) GetDetourEstimate(ctx context.Context, origin, stop, dest
 		Region:      "TW",
 	}
 
-	routes, _, err := s.client.Directions(ctx, r)
+	routes, err := s.directions(ctx, r)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get detour route: %w", err)
 	}
@@ -136,7 +142,7 @@ func (s *RouteService) GetRouteWaypoints(ctx context.Context, origin, destinatio
 		Region:      "TW",
 	}
 
-	routes, _, err := s.client.Directions(ctx, r)
+	routes, err := s.directions(ctx, r)
 	if err != nil {
 		return nil, fmt.Errorf("directions error: %w", err)
 	}
@@ -164,3 +170,92 @@ func (s *RouteService) GetRouteWaypoints(ctx context.Context, origin, destinatio
 
 	return waypoints, nil
 }
+
+// GetRouteDistance returns the driving distance from origin to destination in km.
+func (s *RouteService) GetRouteDistance(ctx context.Context, origin, destination types.Point) (float64, error) {
+	r := &maps.DirectionsRequest{
+		Origin:      fmt.Sprintf("%f,%f", origin.Lat, origin.Lng),
+		Destination: fmt.Sprintf("%f,%f", destination.Lat, destination.Lng),
+		Mode:        maps.TravelModeDriving,
+		Region:      "TW",
+	}
+
+	routes, err := s.directions(ctx, r)
+	if err != nil {
+		return 0, fmt.Errorf("directions error: %w", err)
+	}
+	if len(routes) == 0 {
+		return 0, fmt.Errorf("no route found")
+	}
+
+	meters := 0
+	for _, leg := range routes[0].Legs {
+		meters += leg.Distance.Meters
+	}
+	return float64(meters) / 1000, nil
+}
+
+// GetRoutePath returns the driving route from origin to destination as the
+// decoded overview polyline, origin first.
+func (s *RouteService) GetRoutePath(ctx context.Context, origin, destination types.Point) ([]types.Point, error) {
+	r := &maps.DirectionsRequest{
+		Origin:      fmt.Sprintf("%f,%f", origin.Lat, origin.Lng),
+		Destination: fmt.Sprintf("%f,%f", destination.Lat, destination.Lng),
+		Mode:        maps.TravelModeDriving,
+		Region:      "TW",
+	}
+
+	routes, err := s.directions(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("directions error: %w", err)
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no route found")
+	}
+
+	latLngs, err := routes[0].OverviewPolyline.Decode()
+	if err != nil {
+		return nil, fmt.Errorf("decoding route polyline: %w", err)
+	}
+	path := make([]types.Point, len(latLngs))
+	for i, ll := range latLngs {
+		path[i] = types.Point{Lat: ll.Lat, Lng: ll.Lng}
+	}
+	return path, nil
+}
+
+// GetTrafficDuration returns the driving time from origin to destination when
+// leaving at departAt, using Google's traffic prediction (live traffic for
+// departures within the next few minutes). departAt in the past is treated as now.
+func (s *RouteService) GetTrafficDuration(ctx context.Context, origin, destination types.Point, departAt time.Time) (time.Duration, error) {
+	departure := "now"
+	if departAt.After(time.Now()) {
+		departure = strconv.FormatInt(departAt.Unix(), 10)
+	}
+	r := &maps.DirectionsRequest{
+		Origin:        fmt.Sprintf("%f,%f", origin.Lat, origin.Lng),
+		Destination:   fmt.Sprintf("%f,%f", destination.Lat, destination.Lng),
+		Mode:          maps.TravelModeDriving,
+		Region:        "TW",
+		DepartureTime: departure,
+		TrafficModel:  maps.TrafficModelPessimistic,
+	}
+
+	routes, err := s.directions(ctx, r)
+	if err != nil {
+		return 0, fmt.Errorf("directions error: %w", err)
+	}
+	if len(routes) == 0 || len(routes[0].Legs) == 0 {
+		return 0, fmt.Errorf("no route found")
+	}
+
+	var d time.Duration
+	for _, leg := range routes[0].Legs {
+		if leg.DurationInTraffic > 0 {
+			d += leg.DurationInTraffic
+		} else {
+			d += leg.Duration
+		}
+	}
+	return d, nil
+}
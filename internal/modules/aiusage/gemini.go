@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
+
+	"ark/internal/ai"
 )
 
 const (
@@ -26,13 +29,21 @@ func newGeminiModel(ctx context.Context, apiKey string) (*genai.Client, *genai.G
 	return client, client.GenerativeModel(geminiModel), nil
 }
 
-// generateText sends message to the provided Gemini model and returns the reply text.
-func generateText(ctx context.Context, model *genai.GenerativeModel, message string) (string, error) {
+// generateText sends message to the provided Gemini model under policy and returns the reply text.
+// The call is recorded with usage, if set.
+func generateText(ctx context.Context, model *genai.GenerativeModel, policy ai.RetryPolicy, usage ai.UsageRecorder, message string) (string, error) {
 	if strings.TrimSpace(message) == "" {
 		return "", fmt.Errorf("gemini: empty message")
 	}
 
-	resp, err := model.GenerateContent(ctx, genai.Text(message))
+	var resp *genai.GenerateContentResponse
+	start := time.Now()
+	err := policy.Do(ctx, "gemini_chat", func(ctx context.Context) error {
+		var err error
+		resp, err = model.GenerateContent(ctx, genai.Text(message))
+		return err
+	})
+	ai.RecordUsage(ctx, usage, ai.GeminiUsage(ctx, geminiModel, "chat", start, resp, err))
 	if err != nil {
 		return "", fmt.Errorf("gemini: generate content: %w", err)
 	}
@@ -1,9 +1,66 @@
 package aiusage
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // ErrInsufficientTokens is returned when a user has no tokens remaining for the current month.
 var ErrInsufficientTokens = errors.New("insufficient tokens")
 
+// ErrVoiceUnavailable is returned by VoiceChat when no speech-to-text
+// provider is configured.
+var ErrVoiceUnavailable = errors.New("voice input not available")
+
 // DefaultTokens is the number of tokens granted per month.
 const DefaultTokens = 100
+
+// UsageReport is LLM consumption over [From, To), for tracking spend. Costs
+// are estimates in millionths of a US dollar.
+type UsageReport struct {
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	Days         []DailyUsage    `json:"days"`
+	TopConsumers []ConsumerUsage `json:"top_consumers"`
+	Providers    []ProviderUsage `json:"providers"`
+}
+
+// DailyUsage is one UTC day of consumption.
+type DailyUsage struct {
+	Date         string `json:"date"` // YYYY-MM-DD
+	Calls        int    `json:"calls"`
+	InputTokens  int64  `json:"input_tokens"`
+	OutputTokens int64  `json:"output_tokens"`
+	CostMicros   int64  `json:"cost_micros"`
+}
+
+// ConsumerUsage is one user's consumption.
+type ConsumerUsage struct {
+	UserID     string `json:"user_id"`
+	Calls      int    `json:"calls"`
+	Tokens     int64  `json:"tokens"`
+	CostMicros int64  `json:"cost_micros"`
+}
+
+// ProviderUsage is one provider's calls and failures.
+type ProviderUsage struct {
+	Provider    string  `json:"provider"`
+	Calls       int     `json:"calls"`
+	Errors      int     `json:"errors"`
+	Quota       int     `json:"quota_errors"`
+	Unavailable int     `json:"unavailable_errors"`
+	ErrorRate   float64 `json:"error_rate"`
+	CostMicros  int64   `json:"cost_micros"`
+}
+
+// ErrInvalidRange is returned for a report range that is empty or too long.
+var ErrInvalidRange = errors.New("invalid report range")
+
+// maxReportRange bounds a UsageReport.
+const maxReportRange = 366 * 24 * time.Hour
+
+// DefaultTopConsumers and MaxTopConsumers bound UsageReport.TopConsumers.
+const (
+	DefaultTopConsumers = 10
+	MaxTopConsumers     = 100
+)
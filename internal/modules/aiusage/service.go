@@ -2,9 +2,14 @@ package aiusage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
+
+	"ark/internal/ai"
+	"ark/internal/sandbox"
 )
 
 // Service orchestrates AI token-usage logic.
@@ -12,13 +17,20 @@ type Service struct {
 	store  *Store
 	client *genai.Client
 	model  *genai.GenerativeModel
+	retry  ai.RetryPolicy
+	stt    ai.Transcriber // nil disables voice input
+	usage  ai.UsageRecorder
 }
 
 // NewService creates a Service backed by the given Store.
 // If geminiKey is non-empty, a long-lived Gemini client is initialized immediately.
 // Call Close() to release Gemini client resources when the Service is no longer needed.
 func NewService(store *Store, geminiKey string) (*Service, error) {
-	svc := &Service{store: store}
+	svc := &Service{store: store, retry: ai.DefaultRetryPolicy}
+	if store != nil {
+		// Chat calls land in ai_usage_events next to the quota they draw on.
+		svc.usage = store
+	}
 	if geminiKey == "" {
 		return svc, nil
 	}
@@ -38,12 +50,22 @@ func (s *Service) Close() {
 	}
 }
 
+// SetRetryPolicy replaces ai.DefaultRetryPolicy for Gemini chat calls.
+func (s *Service) SetRetryPolicy(policy ai.RetryPolicy) {
+	s.retry = policy
+}
+
+// SetTranscriber enables VoiceChat.
+func (s *Service) SetTranscriber(t ai.Transcriber) {
+	s.stt = t
+}
+
 // UseToken deducts one token from the user's monthly allowance.
 // If the user row does not exist yet it is initialised and the token is immediately consumed.
 // Returns ErrInsufficientTokens when the quota for the current month is exhausted.
 func (s *Service) UseToken(ctx context.Context, uid string) error {
 	err := s.store.UseToken(ctx, uid)
-	if err != ErrInsufficientTokens {
+	if !errors.Is(err, ErrInsufficientTokens) {
 		return err
 	}
 
@@ -64,11 +86,81 @@ func (s *Service) UseToken(ctx context.Context, uid string) error {
 // Returns ErrInsufficientTokens if the quota is exhausted before making the API call.
 // Returns an error if the Gemini client was not initialized (empty geminiKey at construction).
 func (s *Service) Chat(ctx context.Context, uid, message string) (string, error) {
+	if sandbox.Enabled(ctx) {
+		// Test mode: canned reply, no Gemini call and no quota charge.
+		return "sandbox reply: " + message, nil
+	}
 	if s.model == nil {
 		return "", fmt.Errorf("gemini: client not initialized (empty api key)")
 	}
 	if err := s.UseToken(ctx, uid); err != nil {
 		return "", err
 	}
-	return generateText(ctx, s.model, message)
+	return generateText(ai.WithUsageUser(ctx, uid), s.model, s.retry, s.usage, message)
+}
+
+// VoiceChat transcribes audio and answers the transcript as Chat would,
+// returning both. The quota is checked before transcribing, and a voice
+// message costs the same single token as a typed one.
+// Returns ErrVoiceUnavailable if no Transcriber was set.
+func (s *Service) VoiceChat(ctx context.Context, uid string, audio ai.Audio) (transcript, reply string, err error) {
+	if sandbox.Enabled(ctx) {
+		return "sandbox transcript", "sandbox reply: sandbox transcript", nil
+	}
+	if s.stt == nil {
+		return "", "", ErrVoiceUnavailable
+	}
+	if s.model == nil {
+		return "", "", fmt.Errorf("gemini: client not initialized (empty api key)")
+	}
+	if err := s.UseToken(ctx, uid); err != nil {
+		return "", "", err
+	}
+	ctx = ai.WithUsageUser(ctx, uid)
+	transcript, err = s.stt.Transcribe(ctx, audio)
+	if err != nil {
+		return "", "", err
+	}
+	reply, err = generateText(ctx, s.model, s.retry, s.usage, transcript)
+	return transcript, reply, err
+}
+
+// UsageReport sums LLM consumption in [from, to): per UTC day, for the top
+// users by cost and per provider with its error rate. top defaults to
+// DefaultTopConsumers and is capped at MaxTopConsumers.
+func (s *Service) UsageReport(ctx context.Context, from, to time.Time, top int) (*UsageReport, error) {
+	if !from.Before(to) || to.Sub(from) > maxReportRange {
+		return nil, ErrInvalidRange
+	}
+	if top <= 0 {
+		top = DefaultTopConsumers
+	}
+	top = min(top, MaxTopConsumers)
+
+	r := &UsageReport{From: from, To: to}
+	var err error
+	if r.Days, err = s.store.DailyUsage(ctx, from, to); err != nil {
+		return nil, err
+	}
+	if r.TopConsumers, err = s.store.TopConsumers(ctx, from, to, top); err != nil {
+		return nil, err
+	}
+	if r.Providers, err = s.store.ProviderUsage(ctx, from, to); err != nil {
+		return nil, err
+	}
+	for i := range r.Providers {
+		if p := &r.Providers[i]; p.Calls > 0 {
+			p.ErrorRate = float64(p.Errors) / float64(p.Calls)
+		}
+	}
+	if r.Days == nil {
+		r.Days = []DailyUsage{}
+	}
+	if r.TopConsumers == nil {
+		r.TopConsumers = []ConsumerUsage{}
+	}
+	if r.Providers == nil {
+		r.Providers = []ProviderUsage{}
+	}
+	return r, nil
 }
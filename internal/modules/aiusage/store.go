@@ -5,9 +5,11 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/ai"
 )
 
-// Store handles ai_usage persistence.
+// Store handles ai_usage and ai_usage_events persistence.
 type Store struct {
 	db *pgxpool.Pool
 }
@@ -51,3 +53,93 @@ func (s *Store) EnsureUser(ctx context.Context, uid string) (bool, error) {
 	}
 	return tag.RowsAffected() == 1, nil
 }
+
+// RecordUsage implements ai.UsageRecorder.
+func (s *Store) RecordUsage(ctx context.Context, e ai.UsageEvent) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO ai_usage_events
+			(provider, model, operation, user_id, input_tokens, output_tokens, cost_micros, error, latency_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, e.Provider, e.Model, e.Operation, e.UserID, e.InputTokens, e.OutputTokens, e.CostMicros, e.Error,
+		e.Latency.Milliseconds(), e.At)
+	return err
+}
+
+// DailyUsage sums the calls in [from, to) per UTC day, oldest first.
+func (s *Store) DailyUsage(ctx context.Context, from, to time.Time) ([]DailyUsage, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*),
+			COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0), COALESCE(SUM(cost_micros), 0)
+		FROM ai_usage_events
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY day
+		ORDER BY day
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DailyUsage
+	for rows.Next() {
+		var d DailyUsage
+		if err := rows.Scan(&d.Date, &d.Calls, &d.InputTokens, &d.OutputTokens, &d.CostMicros); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// TopConsumers returns the limit users who cost the most in [from, to).
+func (s *Store) TopConsumers(ctx context.Context, from, to time.Time, limit int) ([]ConsumerUsage, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT user_id, COUNT(*), COALESCE(SUM(input_tokens + output_tokens), 0), COALESCE(SUM(cost_micros), 0) AS cost
+		FROM ai_usage_events
+		WHERE created_at >= $1 AND created_at < $2 AND user_id <> ''
+		GROUP BY user_id
+		ORDER BY cost DESC, user_id
+		LIMIT $3
+	`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ConsumerUsage
+	for rows.Next() {
+		var u ConsumerUsage
+		if err := rows.Scan(&u.UserID, &u.Calls, &u.Tokens, &u.CostMicros); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// ProviderUsage counts each provider's calls and failures in [from, to).
+// ErrorRate is left for the caller.
+func (s *Store) ProviderUsage(ctx context.Context, from, to time.Time) ([]ProviderUsage, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT provider, COUNT(*),
+			COUNT(*) FILTER (WHERE error <> ''),
+			COUNT(*) FILTER (WHERE error = $3),
+			COUNT(*) FILTER (WHERE error = $4),
+			COALESCE(SUM(cost_micros), 0)
+		FROM ai_usage_events
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY provider
+		ORDER BY provider
+	`, from, to, ai.UsageErrQuota, ai.UsageErrUnavailable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ProviderUsage
+	for rows.Next() {
+		var p ProviderUsage
+		if err := rows.Scan(&p.Provider, &p.Calls, &p.Errors, &p.Quota, &p.Unavailable, &p.CostMicros); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,95 @@
+// README: Arrival guarantee HTTP handlers — ops report of per-driver on-time statistics for arrive-by rides.
+//
+// Endpoints:
+//
+//	GET /api/ops/reports/on-time  — on-time rate, lateness and credits per driver (ops key)
+//
+// Auth: routes require the ops key middleware.
+package arrival
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/types"
+)
+
+// Handler holds the arrival guarantee HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type driverStatsResp struct {
+	DriverID       types.ID `json:"driver_id"`
+	Trips          int      `json:"trips"`
+	OnTime         int      `json:"on_time"`
+	Late           int      `json:"late"`
+	OnTimeRate     float64  `json:"on_time_rate"`
+	AvgLateSeconds int64    `json:"avg_late_seconds"`
+	Credits        int64    `json:"credits"`
+}
+
+// Report handles GET /api/ops/reports/on-time?from=&to=&driver_id=. The
+// period defaults to the last 7 days.
+func (h *Handler) Report(c *gin.Context) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+	var err error
+	if s := c.Query("from"); s != "" {
+		if from, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid from; expected RFC3339")
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid to; expected RFC3339")
+			return
+		}
+	}
+
+	stats, err := h.svc.DriverStats(c.Request.Context(), from, to, types.ID(c.Query("driver_id")))
+	if err != nil {
+		writeArrivalError(c, err)
+		return
+	}
+	out := make([]driverStatsResp, len(stats))
+	for i, d := range stats {
+		out[i] = driverStatsResp{
+			DriverID:       d.DriverID,
+			Trips:          d.Trips,
+			OnTime:         d.OnTime,
+			Late:           d.Late(),
+			OnTimeRate:     d.OnTimeRate(),
+			AvgLateSeconds: int64(d.AvgLate.Seconds()),
+			Credits:        d.Credits,
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"from":     from,
+		"to":       to,
+		"currency": types.DefaultCurrency,
+		"drivers":  out,
+	})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeArrivalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, "from must be before to")
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,63 @@
+// README: Arrival guarantee domain model — how each arrive-by trip did against its promised window, and per-driver on-time statistics.
+package arrival
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// KindGuarantee is the credit ledger kind of a missed-guarantee credit; its
+// ref is the order ID.
+const KindGuarantee = "arrival_guarantee"
+
+var ErrBadRequest = errors.New("bad request")
+
+// Outcome is how a completed arrive-by trip did against its promised window.
+type Outcome struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    types.ID
+	WindowFrom  time.Time
+	WindowTo    time.Time
+	ArrivedAt   time.Time
+	// Late is how long after WindowTo the trip arrived; zero when on time.
+	Late time.Duration
+	// Credit is what the passenger was credited (TWD minor units) for a
+	// missed guarantee.
+	Credit    int64
+	CreatedAt time.Time
+}
+
+// DriverStats summarises one driver's arrive-by trips over a period.
+type DriverStats struct {
+	DriverID types.ID
+	Trips    int
+	OnTime   int
+	// AvgLate is the mean lateness of the late trips.
+	AvgLate time.Duration
+	Credits int64
+}
+
+// Late counts the trips that arrived after their window.
+func (d DriverStats) Late() int {
+	return d.Trips - d.OnTime
+}
+
+// OnTimeRate is the share of trips that arrived within their window.
+func (d DriverStats) OnTimeRate() float64 {
+	if d.Trips == 0 {
+		return 0
+	}
+	return float64(d.OnTime) / float64(d.Trips)
+}
+
+// Policy configures the credit for a missed guarantee.
+type Policy struct {
+	// CreditAfter is how late past the window a trip must arrive before the
+	// passenger is credited; 0 turns the credits off.
+	CreditAfter time.Duration
+	// CreditBps is the credit in basis points of the fare.
+	CreditBps int
+}
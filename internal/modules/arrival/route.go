@@ -0,0 +1,11 @@
+// README: Arrival guarantee route registration — mounts the ops on-time report.
+package arrival
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the on-time report onto the provided ops router group.
+//
+//	GET /api/ops/reports/on-time
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/reports/on-time", h.Report)
+}
@@ -0,0 +1,124 @@
+// README: Arrival guarantee service — records how arrive-by trips did against their promised window, credits passengers when the platform misses it, and reports on-time statistics per driver.
+package arrival
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// recordTimeout bounds recording one outcome from an order hook.
+const recordTimeout = 15 * time.Second
+
+// Orders is the subset of order.Service the service uses.
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// Credits grants ride credits once per kind and ref (referral.Service).
+type Credits interface {
+	Grant(ctx context.Context, userID types.ID, amount int64, kind, ref string) (bool, error)
+}
+
+// Service tracks the arrival guarantee of arrive-by rides.
+type Service struct {
+	store   OutcomeStore
+	orders  Orders
+	credits Credits
+	policy  Policy
+	now     func() time.Time
+}
+
+// NewService returns a Service crediting missed guarantees under policy.
+func NewService(store OutcomeStore, orders Orders, policy Policy) *Service {
+	return &Service{store: store, orders: orders, policy: policy, now: time.Now}
+}
+
+// SetCredits sets where missed-guarantee credits are granted. Without it
+// outcomes are still recorded but nobody is credited.
+func (s *Service) SetCredits(c Credits) {
+	s.credits = c
+}
+
+// OrderHook records the outcome in the background once the driver completes
+// a trip.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusPayment || t.Sandbox {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+			defer cancel()
+			if err := s.Record(ctx, t.OrderID); err != nil {
+				log.Printf("arrival: order %s: %v", t.OrderID, err)
+			}
+		}()
+	}
+}
+
+// Record stores how the order did against its promised arrival window and
+// credits the passenger when it arrived more than CreditAfter late. Orders
+// without a window are ignored, as are repeats for the same order.
+func (s *Service) Record(ctx context.Context, orderID types.ID) error {
+	o, err := s.orders.Get(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("get order: %w", err)
+	}
+	if o.ArrivalWindow == nil || o.ArrivedAt == nil || o.DriverID == nil {
+		return nil
+	}
+	out := &Outcome{
+		OrderID:     o.ID,
+		PassengerID: o.PassengerID,
+		DriverID:    *o.DriverID,
+		WindowFrom:  o.ArrivalWindow.From,
+		WindowTo:    o.ArrivalWindow.To,
+		ArrivedAt:   *o.ArrivedAt,
+		Late:        o.ArrivalWindow.Late(*o.ArrivedAt),
+		CreatedAt:   s.now(),
+	}
+	saved, err := s.store.Save(ctx, out)
+	if err != nil || !saved {
+		return err
+	}
+	amount := s.creditFor(o, out.Late)
+	if amount <= 0 || s.credits == nil {
+		return nil
+	}
+	granted, err := s.credits.Grant(ctx, o.PassengerID, amount, KindGuarantee, string(o.ID))
+	if err != nil {
+		return fmt.Errorf("grant credit: %w", err)
+	}
+	if !granted {
+		return nil
+	}
+	return s.store.SetCredit(ctx, o.ID, amount)
+}
+
+// creditFor returns the credit owed for arriving late. Business rides are
+// billed to the organization, so there is no passenger fare to credit.
+func (s *Service) creditFor(o *order.Order, late time.Duration) int64 {
+	if s.policy.CreditAfter <= 0 || late <= s.policy.CreditAfter || o.OrgID != nil {
+		return 0
+	}
+	fare := o.Fare()
+	// Credits are held in the platform currency and never converted.
+	if fare.Currency != types.DefaultCurrency {
+		return 0
+	}
+	return fare.Amount * int64(s.policy.CreditBps) / 10000
+}
+
+// DriverStats returns on-time statistics for trips arriving in [from, to),
+// per driver or for driverID alone when it is set.
+func (s *Service) DriverStats(ctx context.Context, from, to time.Time, driverID types.ID) ([]DriverStats, error) {
+	if !from.Before(to) {
+		return nil, ErrBadRequest
+	}
+	return s.store.DriverStats(ctx, from, to, driverID)
+}
@@ -0,0 +1,161 @@
+package arrival
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	outcomes map[types.ID]*Outcome
+}
+
+func (m *mockStore) Save(_ context.Context, o *Outcome) (bool, error) {
+	if _, ok := m.outcomes[o.OrderID]; ok {
+		return false, nil
+	}
+	m.outcomes[o.OrderID] = o
+	return true, nil
+}
+
+func (m *mockStore) SetCredit(_ context.Context, orderID types.ID, amount int64) error {
+	m.outcomes[orderID].Credit = amount
+	return nil
+}
+
+func (m *mockStore) DriverStats(_ context.Context, from, to time.Time, driverID types.ID) ([]DriverStats, error) {
+	return nil, nil
+}
+
+type fakeOrders map[types.ID]*order.Order
+
+func (f fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	o, ok := f[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	return o, nil
+}
+
+type grant struct {
+	user   types.ID
+	amount int64
+	ref    string
+}
+
+type fakeCredits struct {
+	grants []grant
+}
+
+func (f *fakeCredits) Grant(_ context.Context, userID types.ID, amount int64, kind, ref string) (bool, error) {
+	for _, g := range f.grants {
+		if g.user == userID && g.ref == ref {
+			return false, nil
+		}
+	}
+	f.grants = append(f.grants, grant{userID, amount, ref})
+	return true, nil
+}
+
+var arriveBy = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+// arrivedAfter returns an arrive-by order that reached the dropoff late after
+// its window.
+func arrivedAfter(id types.ID, late time.Duration) *order.Order {
+	driver := types.ID("d1")
+	arrived := arriveBy.Add(late)
+	return &order.Order{
+		ID:            id,
+		PassengerID:   "p1",
+		DriverID:      &driver,
+		EstimatedFee:  types.Money{Amount: 50000, Currency: types.DefaultCurrency},
+		ArriveBy:      &arriveBy,
+		ArrivalWindow: &order.ArrivalWindow{From: arriveBy.Add(-15 * time.Minute), To: arriveBy},
+		ArrivedAt:     &arrived,
+	}
+}
+
+func newTestService(policy Policy, orders fakeOrders) (*Service, *mockStore, *fakeCredits) {
+	store := &mockStore{outcomes: make(map[types.ID]*Outcome)}
+	credits := &fakeCredits{}
+	svc := NewService(store, orders, policy)
+	svc.SetCredits(credits)
+	return svc, store, credits
+}
+
+func TestRecord_CreditsOnlyPastThreshold(t *testing.T) {
+	orders := fakeOrders{
+		"early":  arrivedAfter("early", -5*time.Minute),
+		"slight": arrivedAfter("slight", 5*time.Minute),
+		"late":   arrivedAfter("late", 20*time.Minute),
+	}
+	svc, store, credits := newTestService(Policy{CreditAfter: 10 * time.Minute, CreditBps: 2000}, orders)
+	ctx := context.Background()
+	for id := range orders {
+		if err := svc.Record(ctx, id); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+	}
+	if got := store.outcomes["early"].Late; got != 0 {
+		t.Errorf("early: late = %v, want 0", got)
+	}
+	if got := store.outcomes["slight"].Late; got != 5*time.Minute {
+		t.Errorf("slight: late = %v, want 5m", got)
+	}
+	if len(credits.grants) != 1 || credits.grants[0] != (grant{"p1", 10000, "late"}) {
+		t.Fatalf("grants = %+v, want 20%% of the late fare", credits.grants)
+	}
+	if got := store.outcomes["late"].Credit; got != 10000 {
+		t.Errorf("late: credit = %d, want 10000", got)
+	}
+
+	// Recording again neither duplicates the outcome nor the credit.
+	if err := svc.Record(ctx, "late"); err != nil {
+		t.Fatalf("record again: %v", err)
+	}
+	if len(credits.grants) != 1 {
+		t.Errorf("grants after repeat = %d, want 1", len(credits.grants))
+	}
+}
+
+func TestRecord_NoCreditWhenOffOrNotApplicable(t *testing.T) {
+	org := types.ID("org1")
+	business := arrivedAfter("business", time.Hour)
+	business.OrgID = &org
+	plain := arrivedAfter("plain", time.Hour)
+	plain.ArrivalWindow, plain.ArriveBy = nil, nil
+	orders := fakeOrders{"business": business, "plain": plain, "late": arrivedAfter("late", time.Hour)}
+	ctx := context.Background()
+
+	svc, store, credits := newTestService(Policy{CreditAfter: 10 * time.Minute, CreditBps: 2000}, orders)
+	for _, id := range []types.ID{"business", "plain"} {
+		if err := svc.Record(ctx, id); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+	}
+	if len(credits.grants) != 0 {
+		t.Errorf("grants = %+v, want none", credits.grants)
+	}
+	if _, ok := store.outcomes["plain"]; ok {
+		t.Error("order without a window has an outcome")
+	}
+
+	svc, store, credits = newTestService(Policy{CreditBps: 2000}, orders)
+	if err := svc.Record(ctx, "late"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if len(credits.grants) != 0 || store.outcomes["late"] == nil {
+		t.Errorf("credits off: grants = %+v, outcome = %+v", credits.grants, store.outcomes["late"])
+	}
+}
+
+func TestDriverStats_RejectsEmptyPeriod(t *testing.T) {
+	svc, _, _ := newTestService(Policy{}, fakeOrders{})
+	if _, err := svc.DriverStats(context.Background(), arriveBy, arriveBy, ""); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("err = %v, want ErrBadRequest", err)
+	}
+}
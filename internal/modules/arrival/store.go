@@ -0,0 +1,80 @@
+// README: Arrival guarantee store — PostgreSQL persistence for arrival_outcomes.
+package arrival
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// OutcomeStore defines the persistence operations required by the Service.
+type OutcomeStore interface {
+	// Save stores o unless the order already has an outcome; it reports
+	// whether o was stored.
+	Save(ctx context.Context, o *Outcome) (bool, error)
+	// SetCredit records the credit granted for the order's outcome.
+	SetCredit(ctx context.Context, orderID types.ID, amount int64) error
+	// DriverStats summarises outcomes arriving in [from, to) per driver, or
+	// only driverID's when it is set, most trips first.
+	DriverStats(ctx context.Context, from, to time.Time, driverID types.ID) ([]DriverStats, error)
+}
+
+// Store is the PostgreSQL implementation of OutcomeStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Save(ctx context.Context, o *Outcome) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO arrival_outcomes
+		    (order_id, passenger_id, driver_id, window_from, window_to, arrived_at, late_secs, credit, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (order_id) DO NOTHING`,
+		string(o.OrderID), string(o.PassengerID), string(o.DriverID), o.WindowFrom, o.WindowTo, o.ArrivedAt,
+		int64(o.Late/time.Second), o.Credit, o.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) SetCredit(ctx context.Context, orderID types.ID, amount int64) error {
+	_, err := s.db.Exec(ctx, `UPDATE arrival_outcomes SET credit = $2 WHERE order_id = $1`, string(orderID), amount)
+	return err
+}
+
+func (s *Store) DriverStats(ctx context.Context, from, to time.Time, driverID types.ID) ([]DriverStats, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT driver_id, COUNT(*), COUNT(*) FILTER (WHERE late_secs = 0),
+		       COALESCE(AVG(late_secs) FILTER (WHERE late_secs > 0), 0)::BIGINT, COALESCE(SUM(credit), 0)
+		FROM arrival_outcomes
+		WHERE arrived_at >= $1 AND arrived_at < $2 AND ($3 = '' OR driver_id = $3)
+		GROUP BY driver_id
+		ORDER BY COUNT(*) DESC, driver_id`,
+		from, to, string(driverID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DriverStats
+	for rows.Next() {
+		var d DriverStats
+		var avgLateSecs int64
+		if err := rows.Scan(&d.DriverID, &d.Trips, &d.OnTime, &avgLateSecs, &d.Credits); err != nil {
+			return nil, err
+		}
+		d.AvgLate = time.Duration(avgLateSecs) * time.Second
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
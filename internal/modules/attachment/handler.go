@@ -0,0 +1,174 @@
+// README: Order attachment HTTP handlers — request signed upload URLs for order photos, confirm uploads, and list and delete photos.
+//
+// Endpoints:
+//
+//	POST   /api/orders/:id/attachments                              — request an upload URL ({"kind", "content_type"})
+//	POST   /api/orders/:id/attachments/:attachment_id/complete      — confirm the photo was uploaded
+//	GET    /api/orders/:id/attachments                              — uploaded photos with short-lived view URLs
+//	DELETE /api/orders/:id/attachments/:attachment_id               — delete one of the caller's photos
+//
+// Auth: the authenticated passenger or driver of the order. Passengers attach
+// luggage and meeting_point photos, the driver of a delivery the
+// delivery_proof photo and the recipient's signature.
+package attachment
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the order attachment HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type uploadReq struct {
+	Kind        Kind   `json:"kind"`
+	ContentType string `json:"content_type"`
+}
+
+type uploadResp struct {
+	AttachmentID types.ID          `json:"attachment_id"`
+	UploadURL    string            `json:"upload_url"`
+	Method       string            `json:"method"`
+	Headers      map[string]string `json:"headers"`
+	MaxBytes     int               `json:"max_bytes"`
+	ExpiresAt    int64             `json:"expires_at"`
+}
+
+type attachmentResp struct {
+	ID          types.ID `json:"attachment_id"`
+	OrderID     types.ID `json:"order_id"`
+	UploaderID  types.ID `json:"uploader_id"`
+	Kind        Kind     `json:"kind"`
+	ContentType string   `json:"content_type"`
+	Status      Status   `json:"status"`
+	// URL fetches the photo until URLExpiresAt; listed photos only.
+	URL          string `json:"url,omitempty"`
+	URLExpiresAt int64  `json:"url_expires_at,omitempty"`
+	CreatedAt    int64  `json:"created_at"`
+	UploadedAt   *int64 `json:"uploaded_at,omitempty"`
+}
+
+func toAttachmentResp(a *Attachment) attachmentResp {
+	out := attachmentResp{
+		ID:          a.ID,
+		OrderID:     a.OrderID,
+		UploaderID:  a.UploaderID,
+		Kind:        a.Kind,
+		ContentType: a.ContentType,
+		Status:      a.Status,
+		CreatedAt:   a.CreatedAt.Unix(),
+	}
+	if a.UploadedAt != nil {
+		t := a.UploadedAt.Unix()
+		out.UploadedAt = &t
+	}
+	return out
+}
+
+// RequestUpload handles POST /api/orders/:id/attachments.
+func (h *Handler) RequestUpload(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req uploadReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.RequestUpload(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid), req.Kind, req.ContentType)
+	if err != nil {
+		writeAttachmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, uploadResp{
+		AttachmentID: u.Attachment.ID,
+		UploadURL:    u.URL,
+		Method:       http.MethodPut,
+		Headers:      u.Headers,
+		MaxBytes:     maxUploadBytes,
+		ExpiresAt:    u.ExpiresAt.Unix(),
+	})
+}
+
+// Complete handles POST /api/orders/:id/attachments/:attachment_id/complete.
+func (h *Handler) Complete(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	a, err := h.svc.ConfirmUpload(c.Request.Context(), types.ID(c.Param("id")), types.ID(c.Param("attachment_id")), types.ID(uid))
+	if err != nil {
+		writeAttachmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toAttachmentResp(a))
+}
+
+// List handles GET /api/orders/:id/attachments.
+func (h *Handler) List(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	vs, err := h.svc.List(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid))
+	if err != nil {
+		writeAttachmentError(c, err)
+		return
+	}
+	items := make([]attachmentResp, len(vs))
+	for i, v := range vs {
+		items[i] = toAttachmentResp(&v.Attachment)
+		items[i].URL = v.URL
+		items[i].URLExpiresAt = v.ExpiresAt.Unix()
+	}
+	c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// Delete handles DELETE /api/orders/:id/attachments/:attachment_id.
+func (h *Handler) Delete(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.Delete(c.Request.Context(), types.ID(c.Param("id")), types.ID(c.Param("attachment_id")), types.ID(uid)); err != nil {
+		writeAttachmentError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeAttachmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, "kind must be luggage, meeting_point, delivery_proof or signature and content_type image/jpeg, image/png, image/webp or image/heic")
+	case errors.Is(err, ErrForbidden):
+		writeError(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrTooMany), errors.Is(err, ErrNotUploaded), errors.Is(err, ErrOrderClosed):
+		writeError(c, http.StatusConflict, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
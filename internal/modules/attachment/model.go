@@ -0,0 +1,113 @@
+// README: Order attachment models — photo kinds and who may attach them, accepted image types, and the errors of the attachment service.
+package attachment
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Kind is what an attached photo shows.
+type Kind string
+
+const (
+	// KindLuggage and KindMeetingPoint are attached by the passenger so the
+	// driver knows what to expect and where to stop.
+	KindLuggage      Kind = "luggage"
+	KindMeetingPoint Kind = "meeting_point"
+	// KindDeliveryProof and KindSignature are attached by the driver when
+	// dropping off a parcel: a photo of it at the dropoff and the recipient's
+	// signature, drawn on the driver's phone.
+	KindDeliveryProof Kind = "delivery_proof"
+	KindSignature     Kind = "signature"
+)
+
+// byDriver reports whether k is attached by the order's driver rather than
+// its passenger.
+func (k Kind) byDriver() bool {
+	return k == KindDeliveryProof || k == KindSignature
+}
+
+func (k Kind) valid() bool {
+	switch k {
+	case KindLuggage, KindMeetingPoint, KindDeliveryProof, KindSignature:
+		return true
+	}
+	return false
+}
+
+// Status is how far an attachment's upload got.
+type Status string
+
+const (
+	// StatusPending attachments have an upload URL but no confirmed upload.
+	StatusPending  Status = "pending"
+	StatusUploaded Status = "uploaded"
+)
+
+// extensions maps the accepted content types to the object name extension.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/heic": ".heic",
+}
+
+const (
+	// uploadURLTTL is how long an upload URL may be used.
+	uploadURLTTL = 15 * time.Minute
+	// viewURLTTL is how long a URL to view a photo is valid.
+	viewURLTTL = time.Hour
+	// maxUploadBytes caps the size of one photo.
+	maxUploadBytes = 10 << 20
+	// maxPerOrder caps the attachments of one order, uploaded or not.
+	maxPerOrder = 10
+	// pendingTTL is how long an upload may stay unconfirmed before the
+	// attachment is dropped.
+	pendingTTL = 24 * time.Hour
+	// purgeBatch bounds how many photos of purged accounts one cleanup run
+	// deletes.
+	purgeBatch = 500
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("attachment not found")
+	ErrForbidden  = errors.New("forbidden")
+	// ErrTooMany is returned when an order already has maxPerOrder
+	// attachments.
+	ErrTooMany = errors.New("too many attachments on this order")
+	// ErrNotUploaded is returned when confirming an upload that did not
+	// reach the bucket.
+	ErrNotUploaded = errors.New("photo has not been uploaded")
+	// ErrOrderClosed is returned when attaching to an order that has ended.
+	ErrOrderClosed = errors.New("order has ended")
+)
+
+// Attachment is a photo attached to an order, stored as Object in the bucket.
+type Attachment struct {
+	ID          types.ID
+	OrderID     types.ID
+	UploaderID  types.ID
+	Kind        Kind
+	ContentType string
+	Object      string
+	Status      Status
+	CreatedAt   time.Time
+	UploadedAt  *time.Time
+}
+
+// Upload is where and how the client uploads an attachment's photo.
+type Upload struct {
+	Attachment *Attachment
+	URL        string
+	// Headers must be sent with the PUT for the signature to match.
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// objectName is where an attachment's photo is stored in the bucket.
+func objectName(orderID, id types.ID, contentType string) string {
+	return "orders/" + string(orderID) + "/" + string(id) + extensions[contentType]
+}
@@ -0,0 +1,18 @@
+// README: Order attachment registration — mounts the order photo endpoints onto the given router group.
+package attachment
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the order attachment endpoints onto the provided
+// authenticated router group.
+//
+//	POST   /api/orders/:id/attachments
+//	POST   /api/orders/:id/attachments/:attachment_id/complete
+//	GET    /api/orders/:id/attachments
+//	DELETE /api/orders/:id/attachments/:attachment_id
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.POST("/api/orders/:id/attachments", h.RequestUpload)
+	rg.POST("/api/orders/:id/attachments/:attachment_id/complete", h.Complete)
+	rg.GET("/api/orders/:id/attachments", h.List)
+	rg.DELETE("/api/orders/:id/attachments/:attachment_id", h.Delete)
+}
@@ -0,0 +1,309 @@
+// README: Order attachment service — hands out signed upload URLs for order photos, confirms uploads, lists photos with signed view URLs, and deletes them some time after their order ends.
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Orders looks up the order a photo is attached to (order.Service).
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// Bucket is the object storage photos are uploaded to and served from.
+type Bucket interface {
+	// SignedURL returns a URL allowing method on object until expires without
+	// credentials. For a PUT, contentType and headers must be sent with the
+	// upload.
+	SignedURL(object, method, contentType string, headers map[string]string, expires time.Time) (string, error)
+	// Exists reports whether object has been uploaded.
+	Exists(ctx context.Context, object string) (bool, error)
+	// Delete removes object; a missing object is not an error.
+	Delete(ctx context.Context, object string) error
+}
+
+// Service manages the photos attached to orders.
+type Service struct {
+	store     AttachmentStore
+	orders    Orders
+	bucket    Bucket
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewService returns a Service storing photos in bucket and deleting them
+// retention after their order ended, checked every interval.
+func NewService(store AttachmentStore, orders Orders, bucket Bucket, retention, interval time.Duration) *Service {
+	return &Service{
+		store:     store,
+		orders:    orders,
+		bucket:    bucket,
+		retention: retention,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// participant returns the order and whether userID is its driver, ErrForbidden
+// if userID is neither its passenger nor its driver.
+func (s *Service) participant(ctx context.Context, orderID, userID types.ID) (*order.Order, bool, error) {
+	o, err := s.orders.Get(ctx, orderID)
+	if errors.Is(err, order.ErrNotFound) {
+		return nil, false, ErrNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if o.DriverID != nil && *o.DriverID == userID {
+		return o, true, nil
+	}
+	if o.PassengerID != userID {
+		return nil, false, ErrForbidden
+	}
+	return o, false, nil
+}
+
+// RequestUpload creates a pending attachment of kind to an order and returns
+// the signed URL its photo is PUT to. Passengers attach luggage and meeting
+// point photos while the order is open; the driver of a delivery attaches the
+// proof of delivery, also once the trip is complete.
+func (s *Service) RequestUpload(ctx context.Context, orderID, userID types.ID, kind Kind, contentType string) (*Upload, error) {
+	if _, ok := extensions[contentType]; !ok || !kind.valid() {
+		return nil, ErrBadRequest
+	}
+	o, isDriver, err := s.participant(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if kind.byDriver() != isDriver {
+		return nil, ErrForbidden
+	}
+	if kind.byDriver() && o.RideType != order.RideTypeDelivery {
+		return nil, ErrBadRequest
+	}
+	switch o.Status {
+	case order.StatusCancelled, order.StatusDenied, order.StatusExpired:
+		return nil, ErrOrderClosed
+	case order.StatusComplete:
+		if !kind.byDriver() {
+			return nil, ErrOrderClosed
+		}
+	}
+
+	now := s.now()
+	a := &Attachment{
+		ID:          types.NewID(),
+		OrderID:     orderID,
+		UploaderID:  userID,
+		Kind:        kind,
+		ContentType: contentType,
+		Status:      StatusPending,
+		CreatedAt:   now,
+	}
+	a.Object = objectName(orderID, a.ID, contentType)
+	ok, err := s.store.Create(ctx, a, maxPerOrder)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTooMany
+	}
+	expires := now.Add(uploadURLTTL)
+	headers := map[string]string{"x-goog-content-length-range": "0," + strconv.Itoa(maxUploadBytes)}
+	url, err := s.bucket.SignedURL(a.Object, "PUT", contentType, headers, expires)
+	if err != nil {
+		return nil, fmt.Errorf("signing upload url: %w", err)
+	}
+	headers["Content-Type"] = contentType
+	return &Upload{Attachment: a, URL: url, Headers: headers, ExpiresAt: expires}, nil
+}
+
+// attachment returns an attachment of orderID, ErrNotFound if the order has
+// no such attachment.
+func (s *Service) attachment(ctx context.Context, orderID, id types.ID) (*Attachment, error) {
+	a, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.OrderID != orderID {
+		return nil, ErrNotFound
+	}
+	return a, nil
+}
+
+// ConfirmUpload marks the uploader's attachment uploaded once its photo is in
+// the bucket, making it visible to the other party of the order.
+func (s *Service) ConfirmUpload(ctx context.Context, orderID, id, userID types.ID) (*Attachment, error) {
+	a, err := s.attachment(ctx, orderID, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.UploaderID != userID {
+		return nil, ErrForbidden
+	}
+	if a.Status == StatusUploaded {
+		return a, nil
+	}
+	ok, err := s.bucket.Exists(ctx, a.Object)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotUploaded
+	}
+	now := s.now()
+	if err := s.store.MarkUploaded(ctx, a.ID, now); err != nil {
+		return nil, err
+	}
+	a.Status = StatusUploaded
+	a.UploadedAt = &now
+	return a, nil
+}
+
+// HasDeliveryProof reports whether an order has an uploaded proof of delivery
+// photo and signature; it implements order.DeliveryProof.
+func (s *Service) HasDeliveryProof(ctx context.Context, orderID types.ID) (bool, error) {
+	as, err := s.store.ListByOrder(ctx, orderID)
+	if err != nil {
+		return false, err
+	}
+	var photo, signature bool
+	for _, a := range as {
+		if a.Status != StatusUploaded {
+			continue
+		}
+		photo = photo || a.Kind == KindDeliveryProof
+		signature = signature || a.Kind == KindSignature
+	}
+	return photo && signature, nil
+}
+
+// View is an uploaded attachment with a signed URL to fetch its photo.
+type View struct {
+	Attachment
+	URL       string
+	ExpiresAt time.Time
+}
+
+// List returns the uploaded photos of an order, oldest first, to its
+// passenger or driver.
+func (s *Service) List(ctx context.Context, orderID, userID types.ID) ([]View, error) {
+	if _, _, err := s.participant(ctx, orderID, userID); err != nil {
+		return nil, err
+	}
+	as, err := s.store.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	expires := s.now().Add(viewURLTTL)
+	out := make([]View, 0, len(as))
+	for _, a := range as {
+		if a.Status != StatusUploaded {
+			continue
+		}
+		url, err := s.bucket.SignedURL(a.Object, "GET", "", nil, expires)
+		if err != nil {
+			return nil, fmt.Errorf("signing view url: %w", err)
+		}
+		out = append(out, View{Attachment: a, URL: url, ExpiresAt: expires})
+	}
+	return out, nil
+}
+
+// Delete removes one of the uploader's attachments and its photo.
+func (s *Service) Delete(ctx context.Context, orderID, id, userID types.ID) error {
+	a, err := s.attachment(ctx, orderID, id)
+	if err != nil {
+		return err
+	}
+	if a.UploaderID != userID {
+		return ErrForbidden
+	}
+	return s.remove(ctx, *a)
+}
+
+// remove deletes the photo before the row, so a failure leaves the row to
+// retry with.
+func (s *Service) remove(ctx context.Context, a Attachment) error {
+	if err := s.bucket.Delete(ctx, a.Object); err != nil {
+		return fmt.Errorf("deleting %s: %w", a.Object, err)
+	}
+	return s.store.Delete(ctx, a.ID)
+}
+
+// RunJob deletes expired attachments every interval until ctx is done.
+func (s *Service) RunJob(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CleanupOnce(ctx)
+		}
+	}
+}
+
+// CleanupOnce deletes the attachments of orders that ended more than the
+// retention ago or no longer exist, uploads never confirmed within
+// pendingTTL, and the photos of purged accounts. It returns how many were
+// deleted.
+func (s *Service) CleanupOnce(ctx context.Context) int {
+	n := s.deletePurged(ctx)
+	now := s.now()
+	as, err := s.store.Expired(ctx, now.Add(-s.retention), now.Add(-pendingTTL))
+	if err != nil {
+		log.Printf("attachment: list expired: %v", err)
+		return n
+	}
+	for _, a := range as {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.remove(ctx, a); err != nil {
+			log.Printf("attachment: order %s: %v", a.OrderID, err)
+			continue
+		}
+		n++
+	}
+	if n > 0 {
+		log.Printf("attachment: deleted %d expired attachments", n)
+	}
+	return n
+}
+
+// deletePurged deletes the photos whose rows an account purge removed, up to
+// purgeBatch per run, and returns how many were deleted.
+func (s *Service) deletePurged(ctx context.Context) int {
+	objects, err := s.store.PurgedObjects(ctx, purgeBatch)
+	if err != nil {
+		log.Printf("attachment: list purged: %v", err)
+		return 0
+	}
+	n := 0
+	for _, object := range objects {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.bucket.Delete(ctx, object); err != nil {
+			log.Printf("attachment: deleting purged %s: %v", object, err)
+			continue
+		}
+		if err := s.store.ForgetPurged(ctx, object); err != nil {
+			log.Printf("attachment: purged %s: %v", object, err)
+			continue
+		}
+		n++
+	}
+	return n
+}
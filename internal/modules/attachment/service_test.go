@@ -0,0 +1,314 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	items map[types.ID]*Attachment
+	// ended is when each order ended; missing orders have not.
+	ended map[types.ID]time.Time
+	// purged are objects queued by account purges.
+	purged []string
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{items: map[types.ID]*Attachment{}, ended: map[types.ID]time.Time{}}
+}
+
+func (m *mockStore) Create(_ context.Context, a *Attachment, max int) (bool, error) {
+	n := 0
+	for _, x := range m.items {
+		if x.OrderID == a.OrderID {
+			n++
+		}
+	}
+	if n >= max {
+		return false, nil
+	}
+	cp := *a
+	m.items[a.ID] = &cp
+	return true, nil
+}
+
+func (m *mockStore) Get(_ context.Context, id types.ID) (*Attachment, error) {
+	a, ok := m.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (m *mockStore) ListByOrder(_ context.Context, orderID types.ID) ([]Attachment, error) {
+	var out []Attachment
+	for _, a := range m.items {
+		if a.OrderID == orderID {
+			out = append(out, *a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (m *mockStore) MarkUploaded(_ context.Context, id types.ID, now time.Time) error {
+	a, ok := m.items[id]
+	if !ok {
+		return ErrNotFound
+	}
+	a.Status = StatusUploaded
+	a.UploadedAt = &now
+	return nil
+}
+
+func (m *mockStore) Delete(_ context.Context, id types.ID) error {
+	delete(m.items, id)
+	return nil
+}
+
+func (m *mockStore) Expired(_ context.Context, endedBefore, pendingBefore time.Time) ([]Attachment, error) {
+	var out []Attachment
+	for _, a := range m.items {
+		ended, ok := m.ended[a.OrderID]
+		if (ok && ended.Before(endedBefore)) || (a.Status == StatusPending && a.CreatedAt.Before(pendingBefore)) {
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) PurgedObjects(_ context.Context, limit int) ([]string, error) {
+	return slices.Clone(m.purged[:min(limit, len(m.purged))]), nil
+}
+
+func (m *mockStore) ForgetPurged(_ context.Context, object string) error {
+	m.purged = slices.DeleteFunc(m.purged, func(o string) bool { return o == object })
+	return nil
+}
+
+type mockBucket struct {
+	objects map[string]bool
+	signed  []string
+}
+
+func (b *mockBucket) SignedURL(object, method, _ string, _ map[string]string, _ time.Time) (string, error) {
+	b.signed = append(b.signed, method+" "+object)
+	return "https://storage.example/" + object + "?sig", nil
+}
+
+func (b *mockBucket) Exists(_ context.Context, object string) (bool, error) {
+	return b.objects[object], nil
+}
+
+func (b *mockBucket) Delete(_ context.Context, object string) error {
+	delete(b.objects, object)
+	return nil
+}
+
+type fakeOrders map[types.ID]*order.Order
+
+func (f fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	o, ok := f[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	return o, nil
+}
+
+func newTestService(o *order.Order) (*Service, *mockStore, *mockBucket) {
+	store := newMockStore()
+	bucket := &mockBucket{objects: map[string]bool{}}
+	svc := NewService(store, fakeOrders{o.ID: o}, bucket, 90*24*time.Hour, time.Hour)
+	return svc, store, bucket
+}
+
+func TestAttachment_UploadConfirmList(t *testing.T) {
+	ctx := context.Background()
+	driver := types.ID("driver-1")
+	o := &order.Order{ID: "order-1", PassengerID: "passenger-1", DriverID: &driver, Status: order.StatusApproaching}
+	svc, _, bucket := newTestService(o)
+
+	if _, err := svc.RequestUpload(ctx, o.ID, "passenger-1", KindLuggage, "image/gif"); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("gif upload err = %v, want ErrBadRequest", err)
+	}
+	if _, err := svc.RequestUpload(ctx, o.ID, "stranger", KindLuggage, "image/jpeg"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("stranger upload err = %v, want ErrForbidden", err)
+	}
+	if _, err := svc.RequestUpload(ctx, o.ID, driver, KindLuggage, "image/jpeg"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("driver luggage upload err = %v, want ErrForbidden", err)
+	}
+	if _, err := svc.RequestUpload(ctx, o.ID, "passenger-1", KindDeliveryProof, "image/jpeg"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("passenger delivery proof err = %v, want ErrForbidden", err)
+	}
+
+	u, err := svc.RequestUpload(ctx, o.ID, "passenger-1", KindLuggage, "image/jpeg")
+	if err != nil {
+		t.Fatalf("RequestUpload: %v", err)
+	}
+	if u.Attachment.Object != "orders/order-1/"+string(u.Attachment.ID)+".jpg" {
+		t.Errorf("object = %q", u.Attachment.Object)
+	}
+	if u.Headers["Content-Type"] != "image/jpeg" || u.Headers["x-goog-content-length-range"] == "" {
+		t.Errorf("upload headers = %v", u.Headers)
+	}
+
+	if _, err := svc.ConfirmUpload(ctx, o.ID, u.Attachment.ID, "passenger-1"); !errors.Is(err, ErrNotUploaded) {
+		t.Fatalf("confirm before upload err = %v, want ErrNotUploaded", err)
+	}
+	if vs, _ := svc.List(ctx, o.ID, driver); len(vs) != 0 {
+		t.Fatalf("pending upload listed: %+v", vs)
+	}
+
+	bucket.objects[u.Attachment.Object] = true
+	if _, err := svc.ConfirmUpload(ctx, o.ID, u.Attachment.ID, driver); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("confirm by driver err = %v, want ErrForbidden", err)
+	}
+	a, err := svc.ConfirmUpload(ctx, o.ID, u.Attachment.ID, "passenger-1")
+	if err != nil || a.Status != StatusUploaded || a.UploadedAt == nil {
+		t.Fatalf("ConfirmUpload = %+v, %v", a, err)
+	}
+
+	vs, err := svc.List(ctx, o.ID, driver)
+	if err != nil || len(vs) != 1 || vs[0].URL == "" {
+		t.Fatalf("driver List = %+v, %v", vs, err)
+	}
+	if last := bucket.signed[len(bucket.signed)-1]; last != "GET "+u.Attachment.Object {
+		t.Errorf("last signed = %q, want a GET of the photo", last)
+	}
+	if _, err := svc.List(ctx, o.ID, "stranger"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("stranger List err = %v, want ErrForbidden", err)
+	}
+	if _, err := svc.List(ctx, "missing", driver); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing order List err = %v, want ErrNotFound", err)
+	}
+
+	if err := svc.Delete(ctx, o.ID, u.Attachment.ID, driver); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("driver Delete err = %v, want ErrForbidden", err)
+	}
+	if err := svc.Delete(ctx, o.ID, u.Attachment.ID, "passenger-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if bucket.objects[u.Attachment.Object] {
+		t.Error("photo left in the bucket after Delete")
+	}
+}
+
+func TestAttachment_EndedOrders(t *testing.T) {
+	ctx := context.Background()
+	driver := types.ID("driver-1")
+	o := &order.Order{ID: "order-1", PassengerID: "passenger-1", DriverID: &driver, RideType: order.RideTypeDelivery, Status: order.StatusComplete}
+	svc, _, _ := newTestService(o)
+
+	if _, err := svc.RequestUpload(ctx, o.ID, "passenger-1", KindMeetingPoint, "image/png"); !errors.Is(err, ErrOrderClosed) {
+		t.Fatalf("passenger upload on complete order err = %v, want ErrOrderClosed", err)
+	}
+	if _, err := svc.RequestUpload(ctx, o.ID, driver, KindDeliveryProof, "image/png"); err != nil {
+		t.Fatalf("delivery proof on complete order: %v", err)
+	}
+	o.Status = order.StatusCancelled
+	if _, err := svc.RequestUpload(ctx, o.ID, driver, KindDeliveryProof, "image/png"); !errors.Is(err, ErrOrderClosed) {
+		t.Fatalf("upload on cancelled order err = %v, want ErrOrderClosed", err)
+	}
+}
+
+func TestAttachment_HasDeliveryProof(t *testing.T) {
+	ctx := context.Background()
+	driver := types.ID("driver-1")
+	o := &order.Order{ID: "order-1", PassengerID: "passenger-1", DriverID: &driver, RideType: order.RideTypeDelivery, Status: order.StatusDriving}
+	svc, _, bucket := newTestService(o)
+
+	upload := func(kind Kind) {
+		t.Helper()
+		u, err := svc.RequestUpload(ctx, o.ID, driver, kind, "image/png")
+		if err != nil {
+			t.Fatalf("RequestUpload %s: %v", kind, err)
+		}
+		bucket.objects[u.Attachment.Object] = true
+		if _, err := svc.ConfirmUpload(ctx, o.ID, u.Attachment.ID, driver); err != nil {
+			t.Fatalf("ConfirmUpload %s: %v", kind, err)
+		}
+	}
+	upload(KindDeliveryProof)
+	if ok, err := svc.HasDeliveryProof(ctx, o.ID); err != nil || ok {
+		t.Fatalf("HasDeliveryProof with the photo only = %v, %v; want false", ok, err)
+	}
+	upload(KindSignature)
+	if ok, err := svc.HasDeliveryProof(ctx, o.ID); err != nil || !ok {
+		t.Fatalf("HasDeliveryProof with photo and signature = %v, %v; want true", ok, err)
+	}
+
+	ride := &order.Order{ID: "order-2", PassengerID: "passenger-1", DriverID: &driver, Status: order.StatusDriving}
+	svc, _, _ = newTestService(ride)
+	if _, err := svc.RequestUpload(ctx, ride.ID, driver, KindDeliveryProof, "image/png"); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("delivery proof on a ride err = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestAttachment_PerOrderLimit(t *testing.T) {
+	ctx := context.Background()
+	o := &order.Order{ID: "order-1", PassengerID: "passenger-1", Status: order.StatusWaiting}
+	svc, _, _ := newTestService(o)
+
+	for i := 0; i < maxPerOrder; i++ {
+		if _, err := svc.RequestUpload(ctx, o.ID, "passenger-1", KindLuggage, "image/webp"); err != nil {
+			t.Fatalf("upload %d: %v", i, err)
+		}
+	}
+	if _, err := svc.RequestUpload(ctx, o.ID, "passenger-1", KindLuggage, "image/webp"); !errors.Is(err, ErrTooMany) {
+		t.Fatalf("upload over the limit err = %v, want ErrTooMany", err)
+	}
+}
+
+func TestAttachment_CleanupOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	o := &order.Order{ID: "order-1", PassengerID: "passenger-1", Status: order.StatusWaiting}
+	svc, store, bucket := newTestService(o)
+	svc.now = func() time.Time { return now }
+
+	kept, _ := svc.RequestUpload(ctx, o.ID, "passenger-1", KindLuggage, "image/jpeg")
+	bucket.objects[kept.Attachment.Object] = true
+	if _, err := svc.ConfirmUpload(ctx, o.ID, kept.Attachment.ID, "passenger-1"); err != nil {
+		t.Fatalf("ConfirmUpload: %v", err)
+	}
+	stale, _ := svc.RequestUpload(ctx, o.ID, "passenger-1", KindMeetingPoint, "image/jpeg")
+	store.items[stale.Attachment.ID].CreatedAt = now.Add(-pendingTTL - time.Minute)
+
+	if n := svc.CleanupOnce(ctx); n != 1 {
+		t.Fatalf("CleanupOnce = %d, want only the stale pending upload", n)
+	}
+	if _, ok := store.items[kept.Attachment.ID]; !ok {
+		t.Fatal("uploaded photo of an open order deleted")
+	}
+
+	store.ended[o.ID] = now.Add(-svc.retention - time.Hour)
+	if n := svc.CleanupOnce(ctx); n != 1 {
+		t.Fatalf("CleanupOnce after retention = %d, want 1", n)
+	}
+	if len(store.items) != 0 || bucket.objects[kept.Attachment.Object] {
+		t.Errorf("photo kept past retention: rows %v, objects %v", store.items, bucket.objects)
+	}
+}
+
+func TestAttachment_CleanupDeletesPurgedObjects(t *testing.T) {
+	ctx := context.Background()
+	svc, store, bucket := newTestService(&order.Order{ID: "order-1", PassengerID: "passenger-1", Status: order.StatusWaiting})
+	bucket.objects["orders/order-1/a.jpg"] = true
+	bucket.objects["orders/order-1/b.jpg"] = true
+	store.purged = []string{"orders/order-1/a.jpg", "orders/order-1/b.jpg"}
+
+	if n := svc.CleanupOnce(ctx); n != 2 {
+		t.Fatalf("CleanupOnce = %d, want both purged photos", n)
+	}
+	if len(store.purged) != 0 || len(bucket.objects) != 0 {
+		t.Errorf("purged photos kept: queue %v, objects %v", store.purged, bucket.objects)
+	}
+}
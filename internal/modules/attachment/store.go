@@ -0,0 +1,159 @@
+// README: Order attachment store — PostgreSQL persistence for order_attachments.
+package attachment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// AttachmentStore defines the persistence operations required by the Service.
+type AttachmentStore interface {
+	// Create stores a new attachment unless its order already has max; it
+	// returns false, storing nothing, if it has.
+	Create(ctx context.Context, a *Attachment, max int) (bool, error)
+	// Get returns an attachment, ErrNotFound if there is none.
+	Get(ctx context.Context, id types.ID) (*Attachment, error)
+	// ListByOrder returns an order's attachments, oldest first.
+	ListByOrder(ctx context.Context, orderID types.ID) ([]Attachment, error)
+	// MarkUploaded records that an attachment's photo was uploaded at now.
+	MarkUploaded(ctx context.Context, id types.ID, now time.Time) error
+	// Delete removes an attachment; a missing one is not an error.
+	Delete(ctx context.Context, id types.ID) error
+	// Expired returns the attachments of orders that ended before endedBefore
+	// or no longer exist, and those still pending since before
+	// pendingBefore.
+	Expired(ctx context.Context, endedBefore, pendingBefore time.Time) ([]Attachment, error)
+	// PurgedObjects returns up to limit objects whose rows an account purge
+	// deleted, oldest first.
+	PurgedObjects(ctx context.Context, limit int) ([]string, error)
+	// ForgetPurged drops an object from the purge queue once it is deleted.
+	ForgetPurged(ctx context.Context, object string) error
+}
+
+// Store is the PostgreSQL implementation of AttachmentStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+const attachmentColumns = `a.id, a.order_id, a.uploader_id, a.kind, a.content_type, a.object, a.status, a.created_at, a.uploaded_at`
+
+func scanAttachment(row pgx.Row) (*Attachment, error) {
+	var a Attachment
+	if err := row.Scan(&a.ID, &a.OrderID, &a.UploaderID, &a.Kind, &a.ContentType, &a.Object,
+		&a.Status, &a.CreatedAt, &a.UploadedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func collectAttachments(rows pgx.Rows) ([]Attachment, error) {
+	defer rows.Close()
+	var out []Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) Create(ctx context.Context, a *Attachment, max int) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO order_attachments (id, order_id, uploader_id, kind, content_type, object, status, created_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8
+		WHERE (SELECT count(*) FROM order_attachments WHERE order_id = $2) < $9`,
+		string(a.ID), string(a.OrderID), string(a.UploaderID), string(a.Kind), a.ContentType, a.Object,
+		string(a.Status), a.CreatedAt, max,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) Get(ctx context.Context, id types.ID) (*Attachment, error) {
+	a, err := scanAttachment(s.db.QueryRow(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM order_attachments a
+		WHERE a.id = $1`, string(id)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+func (s *Store) ListByOrder(ctx context.Context, orderID types.ID) ([]Attachment, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM order_attachments a
+		WHERE a.order_id = $1
+		ORDER BY a.created_at, a.id`, string(orderID))
+	if err != nil {
+		return nil, err
+	}
+	return collectAttachments(rows)
+}
+
+func (s *Store) MarkUploaded(ctx context.Context, id types.ID, now time.Time) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE order_attachments
+		SET status = 'uploaded', uploaded_at = $2
+		WHERE id = $1`, string(id), now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, id types.ID) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM order_attachments WHERE id = $1`, string(id))
+	return err
+}
+
+// Expired treats denied and expired orders, which record no end time, as
+// ended when they were created.
+func (s *Store) Expired(ctx context.Context, endedBefore, pendingBefore time.Time) ([]Attachment, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM order_attachments a
+		LEFT JOIN orders o ON o.id = a.order_id
+		WHERE o.id IS NULL
+		   OR (o.status IN ('complete', 'cancelled', 'denied', 'expired')
+		       AND COALESCE(o.completed_at, o.cancelled_at, o.created_at) < $1)
+		   OR (a.status = 'pending' AND a.created_at < $2)
+		ORDER BY a.created_at, a.id`, endedBefore, pendingBefore)
+	if err != nil {
+		return nil, err
+	}
+	return collectAttachments(rows)
+}
+
+func (s *Store) PurgedObjects(ctx context.Context, limit int) ([]string, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT object FROM attachment_purges ORDER BY purged_at, object LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (s *Store) ForgetPurged(ctx context.Context, object string) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM attachment_purges WHERE object = $1`, object)
+	return err
+}
@@ -97,14 +97,14 @@ func BenchmarkService_ListSchedulesByUser(b *testing.B) {
 func BenchmarkNewID(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = newID()
+		_ = types.NewID()
 	}
 }
 
 func BenchmarkNewID_Parallel(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			_ = newID()
+			_ = types.NewID()
 		}
 	})
 }
@@ -3,11 +3,10 @@ package calendar
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"errors"
 	"time"
 
+	"ark/internal/errreport"
 	"ark/internal/modules/order"
 	"ark/internal/types"
 )
@@ -89,7 +88,7 @@ func (s *Service) CreateEvent(ctx context.Context, cmd CreateEventCommand) (type
 		return "", ErrBadRequest
 	}
 	e := &Event{
-		ID:          newID(),
+		ID:          types.NewID(),
 		From:        cmd.From,
 		To:          cmd.To,
 		Title:       cmd.Title,
@@ -148,11 +147,13 @@ func (s *Service) CreateAndTieOrder(ctx context.Context, cmd CreateAndTieOrderCo
 	}
 	if err := s.store.CreateSchedule(ctx, sc); err != nil {
 		// Best-effort: cancel the order to avoid an orphaned ride request.
-		_ = s.order.Cancel(ctx, order.CancelCommand{
+		if cerr := s.order.Cancel(ctx, order.CancelCommand{
 			OrderID:   orderID,
 			ActorType: "system",
 			Reason:    "schedule_creation_failed",
-		})
+		}); cerr != nil {
+			errreport.Report(ctx, "calendar", "cancel_orphaned_order", cerr, "order_id", orderID)
+		}
 		return nil, err
 	}
 	return sc, nil
@@ -187,9 +188,3 @@ func (s *Service) ListSchedulesByUser(ctx context.Context, uid types.ID) ([]*Sch
 	}
 	return s.store.ListSchedulesByUser(ctx, uid)
 }
-
-func newID() types.ID {
-	var b [16]byte
-	_, _ = rand.Read(b[:])
-	return types.ID(hex.EncodeToString(b[:]))
-}
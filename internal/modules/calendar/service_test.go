@@ -352,13 +352,13 @@ func TestListSchedulesByUser_Success(t *testing.T) {
 func TestNewIDGeneratesUniqueValues(t *testing.T) {
 	ids := make(map[types.ID]bool)
 	for i := 0; i < 1000; i++ {
-		id := newID()
+		id := types.NewID()
 		if ids[id] {
 			t.Fatalf("Duplicate ID generated: %s", id)
 		}
 		ids[id] = true
-		if len(string(id)) != 32 { // 16 bytes * 2 hex chars per byte
-			t.Errorf("Expected ID length 32, got %d for ID %s", len(string(id)), id)
+		if !types.IsULID(string(id)) {
+			t.Errorf("Expected a ULID, got %s", id)
 		}
 	}
 }
@@ -0,0 +1,161 @@
+// README: Campaign HTTP handlers — ops campaign management and the driver-facing quest list.
+//
+// Endpoints:
+//
+//	GET  /api/driver/quests             — running quests with the caller's progress (driver_id from context)
+//	POST /api/ops/campaigns             — create a quest (ops key)
+//	GET  /api/ops/campaigns             — current and upcoming quests (ops key)
+//	POST /api/ops/campaigns/:id/end     — stop a quest now (ops key)
+//
+// Auth: driver routes require the Auth middleware to set user_id in context;
+// ops routes require the ops key middleware instead.
+package campaign
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the campaign HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type createReq struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TargetTrips int    `json:"target_trips"`
+	Reward      int64  `json:"reward"`
+	StartsAt    int64  `json:"starts_at"` // unix seconds
+	EndsAt      int64  `json:"ends_at"`   // unix seconds
+}
+
+type campaignResp struct {
+	ID          types.ID `json:"campaign_id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	TargetTrips int      `json:"target_trips"`
+	Reward      int64    `json:"reward"`
+	Currency    string   `json:"currency"`
+	StartsAt    int64    `json:"starts_at"`
+	EndsAt      int64    `json:"ends_at"`
+}
+
+func toCampaignResp(c *Campaign) campaignResp {
+	return campaignResp{
+		ID:          c.ID,
+		Name:        c.Name,
+		Description: c.Description,
+		TargetTrips: c.TargetTrips,
+		Reward:      c.Reward,
+		Currency:    types.DefaultCurrency,
+		StartsAt:    c.StartsAt.Unix(),
+		EndsAt:      c.EndsAt.Unix(),
+	}
+}
+
+type questResp struct {
+	campaignResp
+	Trips     int    `json:"trips"`
+	Completed bool   `json:"completed"`
+	PaidAt    *int64 `json:"paid_at,omitempty"`
+}
+
+// Quests handles GET /api/driver/quests.
+func (h *Handler) Quests(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	qs, err := h.svc.Quests(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	out := make([]questResp, len(qs))
+	for i, q := range qs {
+		out[i] = questResp{
+			campaignResp: toCampaignResp(&q.Campaign),
+			Trips:        q.Trips,
+			Completed:    q.Trips >= q.Campaign.TargetTrips,
+		}
+		if q.PaidAt != nil {
+			ts := q.PaidAt.Unix()
+			out[i].PaidAt = &ts
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{"quests": out})
+}
+
+// Create handles POST /api/ops/campaigns.
+func (h *Handler) Create(c *gin.Context) {
+	var req createReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	camp, err := h.svc.Create(c.Request.Context(), CreateCommand{
+		Name:        req.Name,
+		Description: req.Description,
+		TargetTrips: req.TargetTrips,
+		Reward:      req.Reward,
+		StartsAt:    time.Unix(req.StartsAt, 0),
+		EndsAt:      time.Unix(req.EndsAt, 0),
+	})
+	if err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toCampaignResp(camp))
+}
+
+// List handles GET /api/ops/campaigns.
+func (h *Handler) List(c *gin.Context) {
+	cs, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	out := make([]campaignResp, len(cs))
+	for i := range cs {
+		out[i] = toCampaignResp(&cs[i])
+	}
+	c.JSON(http.StatusOK, map[string]any{"campaigns": out})
+}
+
+// End handles POST /api/ops/campaigns/:id/end.
+func (h *Handler) End(c *gin.Context) {
+	camp, err := h.svc.End(c.Request.Context(), types.ID(c.Param("id")))
+	if err != nil {
+		writeCampaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toCampaignResp(camp))
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeCampaignError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,51 @@
+// README: Campaign domain model — driver quests (trip targets inside a time window) and per-driver progress.
+package campaign
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Campaign is an ops-defined quest, e.g. "complete 20 trips Fri–Sun for +NT$500":
+// every completed trip in [StartsAt, EndsAt) counts, and the driver is paid
+// Reward once when Trips reaches TargetTrips.
+type Campaign struct {
+	ID          types.ID
+	Name        string
+	Description string
+	TargetTrips int
+	Reward      int64 // TWD minor units
+	StartsAt    time.Time
+	EndsAt      time.Time
+	CreatedAt   time.Time
+}
+
+// ActiveAt reports whether trips completed at t count toward c.
+func (c *Campaign) ActiveAt(t time.Time) bool {
+	return !t.Before(c.StartsAt) && t.Before(c.EndsAt)
+}
+
+// Progress is a driver's standing in one campaign.
+type Progress struct {
+	CampaignID types.ID
+	DriverID   types.ID
+	Trips      int
+	PaidAt     *time.Time
+}
+
+// Quest pairs a campaign with the driver's progress in it.
+type Quest struct {
+	Campaign Campaign
+	Trips    int
+	PaidAt   *time.Time
+}
+
+// MaxWindow caps how long one campaign may run.
+const MaxWindow = 90 * 24 * time.Hour
+
+var (
+	ErrNotFound   = errors.New("campaign not found")
+	ErrBadRequest = errors.New("bad request")
+)
@@ -0,0 +1,22 @@
+// README: Campaign route registration — mounts the driver quest and ops campaign endpoints.
+package campaign
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the driver-facing quest endpoint onto the provided authenticated router group.
+//
+//	GET /api/driver/quests
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/driver/quests", h.Quests)
+}
+
+// RegisterOpsRoutes mounts the campaign management endpoints onto the provided ops router group.
+//
+//	POST /api/ops/campaigns
+//	GET  /api/ops/campaigns
+//	POST /api/ops/campaigns/:id/end
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.POST("/api/ops/campaigns", h.Create)
+	rg.GET("/api/ops/campaigns", h.List)
+	rg.POST("/api/ops/campaigns/:id/end", h.End)
+}
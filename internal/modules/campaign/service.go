@@ -0,0 +1,201 @@
+// README: Campaign service — ops-defined driver quests, progress fed by order completions, and payout into earnings.
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ark/internal/modules/earnings"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// recordTimeout bounds counting one completed trip from an order transition.
+const recordTimeout = 15 * time.Second
+
+// Earnings is the subset of earnings.Service used to pay quest rewards.
+type Earnings interface {
+	Credit(ctx context.Context, e earnings.Entry) (bool, error)
+}
+
+// Service manages quest campaigns and pays drivers who reach their targets.
+type Service struct {
+	store    CampaignStore
+	earnings Earnings
+	now      func() time.Time
+}
+
+// NewService creates a Service paying rewards into earnings.
+func NewService(store CampaignStore, earnings Earnings) *Service {
+	return &Service{store: store, earnings: earnings, now: time.Now}
+}
+
+// ---------------------------------------------------------------------------
+// Ops
+// ---------------------------------------------------------------------------
+
+// CreateCommand defines a new quest.
+type CreateCommand struct {
+	Name        string
+	Description string
+	TargetTrips int
+	Reward      int64
+	StartsAt    time.Time
+	EndsAt      time.Time
+}
+
+// Create validates and stores a campaign. It may start in the past (trips
+// already completed are not counted) but must not have ended.
+func (s *Service) Create(ctx context.Context, cmd CreateCommand) (*Campaign, error) {
+	name := strings.TrimSpace(cmd.Name)
+	switch {
+	case name == "" || utf8.RuneCountInString(name) > 100 || utf8.RuneCountInString(cmd.Description) > 1000:
+		return nil, ErrBadRequest
+	case cmd.TargetTrips <= 0 || cmd.Reward <= 0:
+		return nil, ErrBadRequest
+	case !cmd.EndsAt.After(cmd.StartsAt) || cmd.EndsAt.Sub(cmd.StartsAt) > MaxWindow || !cmd.EndsAt.After(s.now()):
+		return nil, ErrBadRequest
+	}
+	c := &Campaign{
+		ID:          types.NewID(),
+		Name:        name,
+		Description: strings.TrimSpace(cmd.Description),
+		TargetTrips: cmd.TargetTrips,
+		Reward:      cmd.Reward,
+		StartsAt:    cmd.StartsAt,
+		EndsAt:      cmd.EndsAt,
+		CreatedAt:   s.now(),
+	}
+	if err := s.store.CreateCampaign(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// List returns current and upcoming campaigns.
+func (s *Service) List(ctx context.Context) ([]Campaign, error) {
+	return s.store.ListCampaigns(ctx, s.now())
+}
+
+// End stops a campaign now; trips completed afterwards no longer count.
+// Rewards already paid stand.
+func (s *Service) End(ctx context.Context, id types.ID) (*Campaign, error) {
+	if err := s.store.EndCampaign(ctx, id, s.now()); err != nil {
+		return nil, err
+	}
+	return s.store.GetCampaign(ctx, id)
+}
+
+// ---------------------------------------------------------------------------
+// Progress
+// ---------------------------------------------------------------------------
+
+// Quests returns the campaigns running now with driverID's progress in each.
+func (s *Service) Quests(ctx context.Context, driverID types.ID) ([]Quest, error) {
+	if driverID == "" {
+		return nil, ErrBadRequest
+	}
+	now := s.now()
+	cs, err := s.store.ListCampaigns(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	var active []Campaign
+	var ids []types.ID
+	for _, c := range cs {
+		if c.ActiveAt(now) {
+			active = append(active, c)
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(active) == 0 {
+		return []Quest{}, nil
+	}
+	ps, err := s.store.ListProgress(ctx, driverID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[types.ID]Progress, len(ps))
+	for _, p := range ps {
+		byID[p.CampaignID] = p
+	}
+	out := make([]Quest, len(active))
+	for i, c := range active {
+		p := byID[c.ID]
+		out[i] = Quest{Campaign: c, Trips: p.Trips, PaidAt: p.PaidAt}
+	}
+	return out, nil
+}
+
+// OrderHook counts completed trips toward every campaign running at the time
+// of completion. Sandbox rides never count.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusComplete || t.Sandbox || t.DriverID == nil {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+			defer cancel()
+			if err := s.RecordTrip(ctx, *t.DriverID, t.OrderID, t.At); err != nil {
+				log.Printf("campaign: record trip %s for %s: %v", t.OrderID, *t.DriverID, err)
+			}
+		}()
+	}
+}
+
+// RecordTrip counts a trip completed at `at` and pays any quest it completes.
+// Replaying the same order is harmless.
+func (s *Service) RecordTrip(ctx context.Context, driverID, orderID types.ID, at time.Time) error {
+	cs, err := s.store.ListCampaigns(ctx, at)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, c := range cs {
+		if !c.ActiveAt(at) {
+			continue
+		}
+		trips, _, err := s.store.AddTrip(ctx, c.ID, driverID, orderID, at)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("campaign %s: %w", c.ID, err))
+			continue
+		}
+		if trips < c.TargetTrips {
+			continue
+		}
+		if err := s.payout(ctx, c, driverID); err != nil {
+			errs = append(errs, fmt.Errorf("campaign %s payout: %w", c.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// payout credits the reward and then marks the progress paid. The earnings
+// entry is keyed by campaign, so retrying after a failed MarkPaid never pays twice.
+func (s *Service) payout(ctx context.Context, c Campaign, driverID types.ID) error {
+	now := s.now()
+	credited, err := s.earnings.Credit(ctx, earnings.Entry{
+		DriverID:  driverID,
+		Amount:    c.Reward,
+		Kind:      earnings.KindQuest,
+		Ref:       string(c.ID),
+		Note:      c.Name,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := s.store.MarkPaid(ctx, c.ID, driverID, now); err != nil {
+		return err
+	}
+	if credited {
+		log.Printf("campaign: paid %d to driver %s for %q", c.Reward, driverID, c.Name)
+	}
+	return nil
+}
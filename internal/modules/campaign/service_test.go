@@ -0,0 +1,219 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/earnings"
+	"ark/internal/types"
+)
+
+// ---------------------------------------------------------------------------
+// In-memory fakes
+// ---------------------------------------------------------------------------
+
+type progressKey struct{ campaign, driver types.ID }
+
+type mockStore struct {
+	campaigns map[types.ID]*Campaign
+	counted   map[progressKey]map[types.ID]bool // campaign+driver -> order IDs
+	paid      map[progressKey]time.Time
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{
+		campaigns: make(map[types.ID]*Campaign),
+		counted:   make(map[progressKey]map[types.ID]bool),
+		paid:      make(map[progressKey]time.Time),
+	}
+}
+
+func (m *mockStore) CreateCampaign(_ context.Context, c *Campaign) error {
+	cp := *c
+	m.campaigns[c.ID] = &cp
+	return nil
+}
+
+func (m *mockStore) GetCampaign(_ context.Context, id types.ID) (*Campaign, error) {
+	c, ok := m.campaigns[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (m *mockStore) ListCampaigns(_ context.Context, since time.Time) ([]Campaign, error) {
+	var out []Campaign
+	for _, c := range m.campaigns {
+		if c.EndsAt.After(since) {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) EndCampaign(_ context.Context, id types.ID, at time.Time) error {
+	c, ok := m.campaigns[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if at.Before(c.EndsAt) {
+		c.EndsAt = at
+	}
+	return nil
+}
+
+func (m *mockStore) AddTrip(_ context.Context, campaignID, driverID, orderID types.ID, _ time.Time) (int, bool, error) {
+	k := progressKey{campaignID, driverID}
+	if m.counted[k] == nil {
+		m.counted[k] = make(map[types.ID]bool)
+	}
+	added := !m.counted[k][orderID]
+	m.counted[k][orderID] = true
+	return len(m.counted[k]), added, nil
+}
+
+func (m *mockStore) MarkPaid(_ context.Context, campaignID, driverID types.ID, at time.Time) (bool, error) {
+	k := progressKey{campaignID, driverID}
+	if _, ok := m.paid[k]; ok {
+		return false, nil
+	}
+	m.paid[k] = at
+	return true, nil
+}
+
+func (m *mockStore) ListProgress(_ context.Context, driverID types.ID, campaignIDs []types.ID) ([]Progress, error) {
+	var out []Progress
+	for _, id := range campaignIDs {
+		k := progressKey{id, driverID}
+		if len(m.counted[k]) == 0 {
+			continue
+		}
+		p := Progress{CampaignID: id, DriverID: driverID, Trips: len(m.counted[k])}
+		if at, ok := m.paid[k]; ok {
+			p.PaidAt = &at
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+type fakeEarnings struct {
+	entries map[string]earnings.Entry // driver|kind|ref
+}
+
+func (f *fakeEarnings) Credit(_ context.Context, e earnings.Entry) (bool, error) {
+	k := string(e.DriverID) + "|" + e.Kind + "|" + e.Ref
+	if _, ok := f.entries[k]; ok {
+		return false, nil
+	}
+	f.entries[k] = e
+	return true, nil
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+var weekend = time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC) // a Friday
+
+func newTestSvc() (*Service, *mockStore, *fakeEarnings) {
+	store := newMockStore()
+	earn := &fakeEarnings{entries: make(map[string]earnings.Entry)}
+	svc := NewService(store, earn)
+	svc.now = func() time.Time { return weekend.Add(time.Hour) }
+	return svc, store, earn
+}
+
+func TestCreate_Validation(t *testing.T) {
+	svc, _, _ := newTestSvc()
+	ctx := context.Background()
+	ok := CreateCommand{Name: "Weekend 3", TargetTrips: 3, Reward: 50000, StartsAt: weekend, EndsAt: weekend.Add(72 * time.Hour)}
+	if _, err := svc.Create(ctx, ok); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	bad := []func(*CreateCommand){
+		func(c *CreateCommand) { c.Name = " " },
+		func(c *CreateCommand) { c.TargetTrips = 0 },
+		func(c *CreateCommand) { c.Reward = -1 },
+		func(c *CreateCommand) { c.EndsAt = c.StartsAt },
+		func(c *CreateCommand) { c.EndsAt = c.StartsAt.Add(MaxWindow + time.Hour) },
+		func(c *CreateCommand) { c.StartsAt, c.EndsAt = weekend.Add(-48*time.Hour), weekend.Add(-time.Hour) },
+	}
+	for i, mutate := range bad {
+		cmd := ok
+		mutate(&cmd)
+		if _, err := svc.Create(ctx, cmd); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("case %d: got %v, want ErrBadRequest", i, err)
+		}
+	}
+}
+
+func TestRecordTrip_PaysOnceAtTarget(t *testing.T) {
+	svc, store, earn := newTestSvc()
+	ctx := context.Background()
+	c, err := svc.Create(ctx, CreateCommand{Name: "Weekend 3", TargetTrips: 3, Reward: 50000, StartsAt: weekend, EndsAt: weekend.Add(72 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	at := weekend.Add(2 * time.Hour)
+
+	for _, o := range []types.ID{"o1", "o2", "o2"} { // o2 replayed
+		if err := svc.RecordTrip(ctx, "drv", o, at); err != nil {
+			t.Fatalf("RecordTrip %s: %v", o, err)
+		}
+	}
+	if len(earn.entries) != 0 {
+		t.Fatalf("paid before target: %v", earn.entries)
+	}
+	for _, o := range []types.ID{"o3", "o4"} {
+		if err := svc.RecordTrip(ctx, "drv", o, at); err != nil {
+			t.Fatalf("RecordTrip %s: %v", o, err)
+		}
+	}
+	e, ok := earn.entries["drv|quest|"+string(c.ID)]
+	if !ok || e.Amount != 50000 || len(earn.entries) != 1 {
+		t.Fatalf("earnings = %v, want one 50000 quest payout", earn.entries)
+	}
+	if _, ok := store.paid[progressKey{c.ID, "drv"}]; !ok {
+		t.Error("progress not marked paid")
+	}
+
+	// Trips outside the window do not count.
+	if err := svc.RecordTrip(ctx, "drv-2", "o9", weekend.Add(-time.Minute)); err != nil {
+		t.Fatalf("RecordTrip before start: %v", err)
+	}
+	if n := len(store.counted[progressKey{c.ID, "drv-2"}]); n != 0 {
+		t.Errorf("trip before start counted: %d", n)
+	}
+}
+
+func TestQuests_ShowsRunningCampaignsWithProgress(t *testing.T) {
+	svc, _, _ := newTestSvc()
+	ctx := context.Background()
+	running, _ := svc.Create(ctx, CreateCommand{Name: "Weekend", TargetTrips: 2, Reward: 30000, StartsAt: weekend, EndsAt: weekend.Add(72 * time.Hour)})
+	if _, err := svc.Create(ctx, CreateCommand{Name: "Next week", TargetTrips: 2, Reward: 30000, StartsAt: weekend.Add(7 * 24 * time.Hour), EndsAt: weekend.Add(8 * 24 * time.Hour)}); err != nil {
+		t.Fatalf("Create upcoming: %v", err)
+	}
+	if err := svc.RecordTrip(ctx, "drv", "o1", weekend.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordTrip: %v", err)
+	}
+
+	qs, err := svc.Quests(ctx, "drv")
+	if err != nil {
+		t.Fatalf("Quests: %v", err)
+	}
+	if len(qs) != 1 || qs[0].Campaign.ID != running.ID || qs[0].Trips != 1 || qs[0].PaidAt != nil {
+		t.Errorf("quests = %+v, want the running campaign at 1 trip", qs)
+	}
+
+	if _, err := svc.End(ctx, running.ID); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	if qs, _ := svc.Quests(ctx, "drv"); len(qs) != 0 {
+		t.Errorf("ended campaign still listed: %+v", qs)
+	}
+}
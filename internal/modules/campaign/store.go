@@ -0,0 +1,177 @@
+// README: Campaign store — PostgreSQL persistence for campaigns, counted quest trips and progress.
+package campaign
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// CampaignStore defines the persistence operations required by the campaign Service.
+type CampaignStore interface {
+	CreateCampaign(ctx context.Context, c *Campaign) error
+	GetCampaign(ctx context.Context, id types.ID) (*Campaign, error)
+	// ListCampaigns returns campaigns that have not ended by since, soonest start first.
+	ListCampaigns(ctx context.Context, since time.Time) ([]Campaign, error)
+	// EndCampaign moves the campaign's end to at when that is earlier.
+	EndCampaign(ctx context.Context, id types.ID, at time.Time) error
+
+	// AddTrip counts orderID toward the driver's progress and returns the new
+	// trip count. It reports false, with the current count, when the order was
+	// already counted.
+	AddTrip(ctx context.Context, campaignID, driverID, orderID types.ID, at time.Time) (int, bool, error)
+	// MarkPaid stamps the driver's progress paid and reports false when it already was.
+	MarkPaid(ctx context.Context, campaignID, driverID types.ID, at time.Time) (bool, error)
+	ListProgress(ctx context.Context, driverID types.ID, campaignIDs []types.ID) ([]Progress, error)
+}
+
+// Store is the PostgreSQL implementation of CampaignStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateCampaign(ctx context.Context, c *Campaign) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO campaigns (id, name, description, target_trips, reward, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		string(c.ID), c.Name, c.Description, c.TargetTrips, c.Reward, c.StartsAt, c.EndsAt, c.CreatedAt,
+	)
+	return err
+}
+
+const campaignColumns = `id, name, description, target_trips, reward, starts_at, ends_at, created_at`
+
+func scanCampaign(row pgx.Row) (*Campaign, error) {
+	var c Campaign
+	var id string
+	if err := row.Scan(&id, &c.Name, &c.Description, &c.TargetTrips, &c.Reward, &c.StartsAt, &c.EndsAt, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	c.ID = types.ID(id)
+	return &c, nil
+}
+
+func (s *Store) GetCampaign(ctx context.Context, id types.ID) (*Campaign, error) {
+	c, err := scanCampaign(s.db.QueryRow(ctx, `SELECT `+campaignColumns+` FROM campaigns WHERE id = $1`, string(id)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *Store) ListCampaigns(ctx context.Context, since time.Time) ([]Campaign, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+campaignColumns+` FROM campaigns
+		WHERE ends_at > $1 ORDER BY starts_at, id`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) EndCampaign(ctx context.Context, id types.ID, at time.Time) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE campaigns SET ends_at = LEAST(ends_at, GREATEST($2, starts_at + INTERVAL '1 second'))
+		WHERE id = $1`, string(id), at)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) AddTrip(ctx context.Context, campaignID, driverID, orderID types.ID, at time.Time) (int, bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO quest_trips (campaign_id, order_id, driver_id, counted_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (campaign_id, order_id) DO NOTHING`,
+		string(campaignID), string(orderID), string(driverID), at,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+	added := tag.RowsAffected() == 1
+	inc := 0
+	if added {
+		inc = 1
+	}
+	var trips int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO quest_progress (campaign_id, driver_id, trips, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (campaign_id, driver_id)
+		DO UPDATE SET trips = quest_progress.trips + $3, updated_at = $4
+		RETURNING trips`,
+		string(campaignID), string(driverID), inc, at,
+	).Scan(&trips)
+	if err != nil {
+		return 0, false, err
+	}
+	return trips, added, tx.Commit(ctx)
+}
+
+func (s *Store) MarkPaid(ctx context.Context, campaignID, driverID types.ID, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE quest_progress SET paid_at = $3, updated_at = $3
+		WHERE campaign_id = $1 AND driver_id = $2 AND paid_at IS NULL`,
+		string(campaignID), string(driverID), at,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) ListProgress(ctx context.Context, driverID types.ID, campaignIDs []types.ID) ([]Progress, error) {
+	ids := make([]string, len(campaignIDs))
+	for i, id := range campaignIDs {
+		ids[i] = string(id)
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT campaign_id, trips, paid_at FROM quest_progress
+		WHERE driver_id = $1 AND campaign_id = ANY($2)`,
+		string(driverID), ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Progress
+	for rows.Next() {
+		p := Progress{DriverID: driverID}
+		var cid string
+		if err := rows.Scan(&cid, &p.Trips, &p.PaidAt); err != nil {
+			return nil, err
+		}
+		p.CampaignID = types.ID(cid)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,128 @@
+// README: Road closure HTTP handlers — ops registration and lifting of temporary road closures and event areas.
+//
+// Endpoints:
+//
+//	GET    /api/ops/closures      — closures in force or still to come (ops key)
+//	POST   /api/ops/closures      — register a closure (ops key)
+//	DELETE /api/ops/closures/:id  — lift a closure now (ops key)
+//
+// Auth: routes require the ops key middleware.
+package closure
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/types"
+)
+
+// Handler holds the road closure HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type pointJSON struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// createClosureReq times are Unix seconds.
+type createClosureReq struct {
+	Kind     string      `json:"kind"`
+	Name     string      `json:"name"`
+	Area     []pointJSON `json:"area"`
+	StartsAt int64       `json:"starts_at"`
+	EndsAt   int64       `json:"ends_at"`
+}
+
+type closureResp struct {
+	ID        types.ID    `json:"closure_id"`
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	Area      []pointJSON `json:"area"`
+	StartsAt  int64       `json:"starts_at"`
+	EndsAt    int64       `json:"ends_at"`
+	CreatedAt int64       `json:"created_at"`
+}
+
+func toClosureResp(c Closure) closureResp {
+	out := closureResp{
+		ID:        c.ID,
+		Kind:      c.Kind,
+		Name:      c.Name,
+		Area:      make([]pointJSON, len(c.Area)),
+		StartsAt:  c.StartsAt.Unix(),
+		EndsAt:    c.EndsAt.Unix(),
+		CreatedAt: c.CreatedAt.Unix(),
+	}
+	for i, p := range c.Area {
+		out.Area[i] = pointJSON{Lat: p.Lat, Lng: p.Lng}
+	}
+	return out
+}
+
+// List handles GET /api/ops/closures.
+func (h *Handler) List(c *gin.Context) {
+	cs := h.svc.List()
+	out := make([]closureResp, len(cs))
+	for i, cl := range cs {
+		out[i] = toClosureResp(cl)
+	}
+	c.JSON(http.StatusOK, map[string]any{"closures": out})
+}
+
+// Create handles POST /api/ops/closures.
+func (h *Handler) Create(c *gin.Context) {
+	var req createClosureReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	cl := Closure{
+		Kind:     req.Kind,
+		Name:     req.Name,
+		StartsAt: time.Unix(req.StartsAt, 0).UTC(),
+		EndsAt:   time.Unix(req.EndsAt, 0).UTC(),
+	}
+	for _, p := range req.Area {
+		cl.Area = append(cl.Area, types.Point{Lat: p.Lat, Lng: p.Lng})
+	}
+	created, err := h.svc.Create(c.Request.Context(), cl)
+	if err != nil {
+		writeClosureError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toClosureResp(*created))
+}
+
+// End handles DELETE /api/ops/closures/:id.
+func (h *Handler) End(c *gin.Context) {
+	if err := h.svc.End(c.Request.Context(), types.ID(c.Param("id"))); err != nil {
+		writeClosureError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeClosureError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
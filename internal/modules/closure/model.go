@@ -0,0 +1,97 @@
+// README: Road closure domain model — a temporary roadworks or event area, its polygon and the time window it applies in.
+package closure
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Kinds of closure.
+const (
+	KindRoadworks = "roadworks"
+	KindEvent     = "event"
+)
+
+const (
+	// maxAreaPoints caps the vertices of a closure polygon.
+	maxAreaPoints = 200
+	// maxWindow caps how long a closure may last; longer closures are the
+	// road network's business, not an override.
+	maxWindow = 90 * 24 * time.Hour
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("closure not found")
+)
+
+// Closure is an area drivers cannot pass through between StartsAt and
+// EndsAt: roadworks, or a street event.
+type Closure struct {
+	ID   types.ID
+	Kind string
+	Name string
+	// Area is the closed-off polygon, vertices in order.
+	Area      []types.Point
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedAt time.Time
+}
+
+// ActiveAt reports whether the closure applies at t.
+func (c Closure) ActiveAt(t time.Time) bool {
+	return !t.Before(c.StartsAt) && t.Before(c.EndsAt)
+}
+
+// Contains reports whether p lies inside the closure's area.
+func (c Closure) Contains(p types.Point) bool {
+	n := len(c.Area)
+	if n < 3 {
+		return false
+	}
+	// Ray casting along the latitude through p; closures are small enough
+	// for lat/lng to be treated as plane coordinates.
+	in := false
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := c.Area[i], c.Area[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			in = !in
+		}
+	}
+	return in
+}
+
+// validate checks a closure about to be registered at now.
+func (c *Closure) validate(now time.Time) error {
+	if c.Kind != KindRoadworks && c.Kind != KindEvent {
+		return ErrBadRequest
+	}
+	if c.Name == "" || len(c.Name) > 100 {
+		return ErrBadRequest
+	}
+	if len(c.Area) < 3 || len(c.Area) > maxAreaPoints {
+		return ErrBadRequest
+	}
+	for _, p := range c.Area {
+		if p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+			return ErrBadRequest
+		}
+	}
+	if !c.EndsAt.After(c.StartsAt) || !c.EndsAt.After(now) || c.EndsAt.Sub(c.StartsAt) > maxWindow {
+		return ErrBadRequest
+	}
+	return nil
+}
+
+// distanceKm is the great-circle distance between a and b.
+func distanceKm(a, b types.Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat, dLng := lat2-lat1, (b.Lng-a.Lng)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,15 @@
+// README: Road closure route registration — mounts the ops closure endpoints.
+package closure
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the road closure endpoints onto the provided ops router group.
+//
+//	GET    /api/ops/closures
+//	POST   /api/ops/closures
+//	DELETE /api/ops/closures/:id
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/closures", h.List)
+	rg.POST("/api/ops/closures", h.Create)
+	rg.DELETE("/api/ops/closures/:id", h.End)
+}
@@ -0,0 +1,198 @@
+// README: Road closure service — keeps the current closures in memory and tells routing which closures a path runs through and where to detour, and matching which pickups lie in one.
+package closure
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"ark/internal/types"
+)
+
+// detourMargin is how far beyond a closure's vertex, as a share of its
+// distance from the centre, a detour waypoint is placed so the route passes
+// clear of the area.
+const detourMargin = 0.25
+
+// pathStepKm is the spacing paths are checked against closures at.
+const pathStepKm = 0.05
+
+// Service serves closures from memory; Refresh reloads them from the store
+// so closures registered on another instance are picked up.
+type Service struct {
+	store ClosureStore
+	now   func() time.Time
+
+	mu       sync.RWMutex
+	closures []Closure
+}
+
+// NewService returns a Service backed by store.
+func NewService(store ClosureStore) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// Refresh reloads the closures that have not ended.
+func (s *Service) Refresh(ctx context.Context) error {
+	cs, err := s.store.ListCurrent(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.closures = cs
+	s.mu.Unlock()
+	return nil
+}
+
+// RunRefresh calls Refresh every interval until ctx is done.
+func (s *Service) RunRefresh(ctx context.Context, interval time.Duration) {
+	if err := s.Refresh(ctx); err != nil {
+		log.Printf("closure: refresh: %v", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				log.Printf("closure: refresh: %v", err)
+			}
+		}
+	}
+}
+
+// List returns the closures in force or still to come, by start.
+func (s *Service) List() []Closure {
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Closure, 0, len(s.closures))
+	for _, c := range s.closures {
+		if c.EndsAt.After(now) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Create validates and registers c, then reloads the closures.
+func (s *Service) Create(ctx context.Context, c Closure) (*Closure, error) {
+	now := s.now()
+	if err := c.validate(now); err != nil {
+		return nil, err
+	}
+	c.ID = types.NewID()
+	c.CreatedAt = now
+	if err := s.store.Create(ctx, &c); err != nil {
+		return nil, err
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// End lifts the closure with id now, then reloads the closures.
+func (s *Service) End(ctx context.Context, id types.ID) error {
+	if err := s.store.End(ctx, id, s.now()); err != nil {
+		return err
+	}
+	return s.Refresh(ctx)
+}
+
+// Within reports whether p lies in a closure in force at at.
+func (s *Service) Within(p types.Point, at time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.closures {
+		if c.ActiveAt(at) && c.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Crossing returns the closures in force at at that path passes through.
+func (s *Service) Crossing(path []types.Point, at time.Time) []Closure {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Closure
+	var dense []types.Point
+	for _, c := range s.closures {
+		if !c.ActiveAt(at) {
+			continue
+		}
+		if dense == nil {
+			dense = densify(path)
+		}
+		if slices.ContainsFunc(dense, c.Contains) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// densify adds points along path so none are more than pathStepKm apart: a
+// route overview skips long straight stretches, which may run through a
+// closure between two of its points.
+func densify(path []types.Point) []types.Point {
+	out := make([]types.Point, 0, len(path))
+	for i, p := range path {
+		if i > 0 {
+			prev := path[i-1]
+			n := int(distanceKm(prev, p) / pathStepKm)
+			for k := 1; k <= n; k++ {
+				f := float64(k) / float64(n+1)
+				out = append(out, types.Point{Lat: prev.Lat + (p.Lat-prev.Lat)*f, Lng: prev.Lng + (p.Lng-prev.Lng)*f})
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Detour returns waypoints steering a trip from origin to destination
+// around c: just outside one vertex of c's area, or two adjacent ones, taken
+// in the order the trip reaches them. Of these, the waypoints adding the
+// least straight-line distance are chosen, preferring those from which
+// straight legs keep clear of the area.
+func Detour(c Closure, origin, destination types.Point) []types.Point {
+	var centre types.Point
+	for _, p := range c.Area {
+		centre.Lat += p.Lat / float64(len(c.Area))
+		centre.Lng += p.Lng / float64(len(c.Area))
+	}
+	out := make([]types.Point, len(c.Area))
+	for i, v := range c.Area {
+		out[i] = types.Point{
+			Lat: v.Lat + (v.Lat-centre.Lat)*detourMargin,
+			Lng: v.Lng + (v.Lng-centre.Lng)*detourMargin,
+		}
+	}
+	var candidates [][]types.Point
+	for i, w := range out {
+		next := out[(i+1)%len(out)]
+		if distanceKm(origin, next) < distanceKm(origin, w) {
+			w, next = next, w
+		}
+		candidates = append(candidates, []types.Point{out[i]}, []types.Point{w, next})
+	}
+	var best []types.Point
+	bestKm, bestClear := -1.0, false
+	for _, via := range candidates {
+		path := append(append([]types.Point{origin}, via...), destination)
+		var km float64
+		for i := 1; i < len(path); i++ {
+			km += distanceKm(path[i-1], path[i])
+		}
+		clear := !slices.ContainsFunc(densify(path), c.Contains)
+		if bestKm < 0 || (clear && !bestClear) || (clear == bestClear && km < bestKm) {
+			best, bestKm, bestClear = via, km, clear
+		}
+	}
+	return best
+}
@@ -0,0 +1,136 @@
+package closure
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	closures []Closure
+}
+
+func (m *mockStore) Create(_ context.Context, c *Closure) error {
+	m.closures = append(m.closures, *c)
+	return nil
+}
+
+func (m *mockStore) ListCurrent(_ context.Context, now time.Time) ([]Closure, error) {
+	var out []Closure
+	for _, c := range m.closures {
+		if c.EndsAt.After(now) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) End(_ context.Context, id types.ID, now time.Time) error {
+	for i, c := range m.closures {
+		if c.ID == id && c.EndsAt.After(now) {
+			m.closures[i].EndsAt = now
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+var now = time.Date(2026, 12, 31, 12, 0, 0, 0, time.UTC)
+
+// cityHall is the area around Taipei City Hall closed for the new year's eve
+// party.
+var cityHall = Closure{
+	Kind: KindEvent, Name: "跨年晚會",
+	Area:     []types.Point{{Lat: 25.035, Lng: 121.560}, {Lat: 25.041, Lng: 121.560}, {Lat: 25.041, Lng: 121.568}, {Lat: 25.035, Lng: 121.568}},
+	StartsAt: now.Add(6 * time.Hour), EndsAt: now.Add(14 * time.Hour),
+}
+
+func newTestService() (*Service, *mockStore) {
+	store := &mockStore{}
+	svc := NewService(store)
+	svc.now = func() time.Time { return now }
+	return svc, store
+}
+
+func TestCreate_Validates(t *testing.T) {
+	svc, _ := newTestService()
+	bad := []func(c *Closure){
+		func(c *Closure) { c.Kind = "parade" },
+		func(c *Closure) { c.Name = "" },
+		func(c *Closure) { c.Area = c.Area[:2] },
+		func(c *Closure) { c.Area = []types.Point{{Lat: 95}, {Lat: 25}, {Lng: 121}} },
+		func(c *Closure) { c.EndsAt = c.StartsAt },
+		func(c *Closure) { c.StartsAt, c.EndsAt = now.Add(-2*time.Hour), now.Add(-time.Hour) },
+		func(c *Closure) { c.EndsAt = c.StartsAt.Add(100 * 24 * time.Hour) },
+	}
+	for i, mutate := range bad {
+		c := cityHall
+		mutate(&c)
+		if _, err := svc.Create(context.Background(), c); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("case %d: err = %v, want ErrBadRequest", i, err)
+		}
+	}
+	if got := len(svc.List()); got != 0 {
+		t.Errorf("List has %d closures after rejected creates", got)
+	}
+}
+
+func TestWithinAndCrossing_OnlyWhileInForce(t *testing.T) {
+	svc, _ := newTestService()
+	ctx := context.Background()
+	c, err := svc.Create(ctx, cityHall)
+	if err != nil || c.ID == "" {
+		t.Fatalf("create: %+v, %v", c, err)
+	}
+	inside := types.Point{Lat: 25.038, Lng: 121.564}
+	party := now.Add(8 * time.Hour)
+	if !svc.Within(inside, party) || svc.Within(inside, now) || svc.Within(types.Point{Lat: 25.047, Lng: 121.517}, party) {
+		t.Error("Within must hold only inside the area during the party")
+	}
+
+	// A route overview straight through the area, with no point inside it.
+	path := []types.Point{{Lat: 25.038, Lng: 121.540}, {Lat: 25.038, Lng: 121.590}}
+	if got := svc.Crossing(path, party); len(got) != 1 || got[0].ID != c.ID {
+		t.Errorf("Crossing during the party = %+v, want the closure", got)
+	}
+	if got := svc.Crossing(path, now); len(got) != 0 {
+		t.Errorf("Crossing before the party = %+v, want none", got)
+	}
+	around := []types.Point{{Lat: 25.038, Lng: 121.540}, {Lat: 25.045, Lng: 121.564}, {Lat: 25.038, Lng: 121.590}}
+	if got := svc.Crossing(around, party); len(got) != 0 {
+		t.Errorf("Crossing round the area = %+v, want none", got)
+	}
+}
+
+func TestDetour_ClearsTheArea(t *testing.T) {
+	origin, destination := types.Point{Lat: 25.038, Lng: 121.540}, types.Point{Lat: 25.038, Lng: 121.590}
+	via := Detour(cityHall, origin, destination)
+	if len(via) == 0 || slices.ContainsFunc(via, cityHall.Contains) {
+		t.Fatalf("waypoints %v are inside the area", via)
+	}
+	svc, _ := newTestService()
+	svc.Create(context.Background(), cityHall)
+	path := append(append([]types.Point{origin}, via...), destination)
+	if got := svc.Crossing(path, now.Add(8*time.Hour)); len(got) != 0 {
+		t.Errorf("route via %v still crosses %+v", via, got)
+	}
+}
+
+func TestEnd(t *testing.T) {
+	svc, _ := newTestService()
+	ctx := context.Background()
+	c, _ := svc.Create(ctx, cityHall)
+	if err := svc.End(ctx, c.ID); err != nil {
+		t.Fatalf("end: %v", err)
+	}
+	if got := svc.List(); len(got) != 0 {
+		t.Errorf("List after end = %+v", got)
+	}
+	if err := svc.End(ctx, c.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ending twice: err = %v, want ErrNotFound", err)
+	}
+}
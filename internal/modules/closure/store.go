@@ -0,0 +1,99 @@
+// README: Road closure store — PostgreSQL persistence for road_closures.
+package closure
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// ClosureStore defines the persistence operations required by the Service.
+type ClosureStore interface {
+	// Create stores a new closure.
+	Create(ctx context.Context, c *Closure) error
+	// ListCurrent returns the closures that have not ended at now, by start.
+	ListCurrent(ctx context.Context, now time.Time) ([]Closure, error)
+	// End lifts the closure with id at now: one not started yet is removed,
+	// one in force ends now. ErrNotFound if it has already ended.
+	End(ctx context.Context, id types.ID, now time.Time) error
+}
+
+// Store is the PostgreSQL implementation of ClosureStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Create(ctx context.Context, c *Closure) error {
+	stored := make([][2]float64, len(c.Area))
+	for i, p := range c.Area {
+		stored[i] = [2]float64{p.Lat, p.Lng}
+	}
+	area, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO road_closures (id, kind, name, area, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		string(c.ID), c.Kind, c.Name, area, c.StartsAt, c.EndsAt, c.CreatedAt,
+	)
+	return err
+}
+
+func (s *Store) ListCurrent(ctx context.Context, now time.Time) ([]Closure, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, kind, name, area, starts_at, ends_at, created_at
+		FROM road_closures
+		WHERE ends_at > $1
+		ORDER BY starts_at, id`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Closure
+	for rows.Next() {
+		var c Closure
+		var area []byte
+		if err := rows.Scan(&c.ID, &c.Kind, &c.Name, &area, &c.StartsAt, &c.EndsAt, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		var stored [][2]float64
+		if err := json.Unmarshal(area, &stored); err != nil {
+			return nil, err
+		}
+		for _, v := range stored {
+			c.Area = append(c.Area, types.Point{Lat: v[0], Lng: v[1]})
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) End(ctx context.Context, id types.ID, now time.Time) error {
+	tag, err := s.db.Exec(ctx, `
+		DELETE FROM road_closures WHERE id = $1 AND starts_at >= $2`, string(id), now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	tag, err = s.db.Exec(ctx, `
+		UPDATE road_closures SET ends_at = $2 WHERE id = $1 AND ends_at > $2`, string(id), now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
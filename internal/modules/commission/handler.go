@@ -0,0 +1,140 @@
+// README: Commission HTTP handlers — ops management of commission rules.
+//
+// Endpoints:
+//
+//	POST /api/ops/commission-rules          — create a rule
+//	GET  /api/ops/commission-rules          — rules in force or scheduled
+//	POST /api/ops/commission-rules/:id/end  — stop a rule now
+//
+// Auth: all routes require the ops key middleware.
+package commission
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/types"
+)
+
+// Handler holds the commission HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type createRuleReq struct {
+	Name          string    `json:"name"`
+	Tier          string    `json:"tier"`
+	MinTenureDays int       `json:"min_tenure_days"`
+	CampaignID    *types.ID `json:"campaign_id"`
+	RateBps       int       `json:"rate_bps"`
+	Priority      int       `json:"priority"`
+	StartsAt      int64     `json:"starts_at"` // unix seconds; 0 = now
+	EndsAt        *int64    `json:"ends_at"`   // unix seconds; omitted = open-ended
+}
+
+type ruleResp struct {
+	ID            types.ID  `json:"rule_id"`
+	Name          string    `json:"name"`
+	Tier          string    `json:"tier,omitempty"`
+	MinTenureDays int       `json:"min_tenure_days,omitempty"`
+	CampaignID    *types.ID `json:"campaign_id,omitempty"`
+	RateBps       int       `json:"rate_bps"`
+	Priority      int       `json:"priority"`
+	StartsAt      int64     `json:"starts_at"`
+	EndsAt        *int64    `json:"ends_at,omitempty"`
+}
+
+func toRuleResp(r *Rule) ruleResp {
+	out := ruleResp{
+		ID:            r.ID,
+		Name:          r.Name,
+		Tier:          r.Tier,
+		MinTenureDays: r.MinTenureDays,
+		CampaignID:    r.CampaignID,
+		RateBps:       r.RateBps,
+		Priority:      r.Priority,
+		StartsAt:      r.StartsAt.Unix(),
+	}
+	if r.EndsAt != nil {
+		ts := r.EndsAt.Unix()
+		out.EndsAt = &ts
+	}
+	return out
+}
+
+// Create handles POST /api/ops/commission-rules.
+func (h *Handler) Create(c *gin.Context) {
+	var req createRuleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	cmd := CreateRuleCommand{
+		Name:          req.Name,
+		Tier:          req.Tier,
+		MinTenureDays: req.MinTenureDays,
+		CampaignID:    req.CampaignID,
+		RateBps:       req.RateBps,
+		Priority:      req.Priority,
+	}
+	if req.StartsAt != 0 {
+		cmd.StartsAt = time.Unix(req.StartsAt, 0)
+	}
+	if req.EndsAt != nil {
+		t := time.Unix(*req.EndsAt, 0)
+		cmd.EndsAt = &t
+	}
+	r, err := h.svc.CreateRule(c.Request.Context(), cmd)
+	if err != nil {
+		writeCommissionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toRuleResp(r))
+}
+
+// List handles GET /api/ops/commission-rules.
+func (h *Handler) List(c *gin.Context) {
+	rules, err := h.svc.ListRules(c.Request.Context())
+	if err != nil {
+		writeCommissionError(c, err)
+		return
+	}
+	out := make([]ruleResp, len(rules))
+	for i := range rules {
+		out[i] = toRuleResp(&rules[i])
+	}
+	c.JSON(http.StatusOK, map[string]any{"rules": out})
+}
+
+// End handles POST /api/ops/commission-rules/:id/end.
+func (h *Handler) End(c *gin.Context) {
+	r, err := h.svc.EndRule(c.Request.Context(), types.ID(c.Param("id")))
+	if err != nil {
+		writeCommissionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toRuleResp(r))
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeCommissionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,73 @@
+// README: Commission domain model — platform commission rules scoped by driver tier, tenure and campaign.
+package commission
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// FullRateBps is a 100% commission in basis points.
+const FullRateBps = 10000
+
+// Rule sets the platform's cut of trip fares for the drivers it matches.
+// Empty or nil scopes match every driver.
+type Rule struct {
+	ID   types.ID
+	Name string
+	// Tier limits the rule to drivers of one tier (see driver.Tiers).
+	Tier string
+	// MinTenureDays limits the rule to drivers onboarded at least this long ago.
+	MinTenureDays int
+	// CampaignID limits the rule to trips completed while the campaign runs.
+	CampaignID *types.ID
+	RateBps    int // 2000 = 20%
+	// Priority picks between matching rules; ties go to the lower rate.
+	Priority  int
+	StartsAt  time.Time
+	EndsAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Profile is the driver data rules are matched against.
+type Profile struct {
+	Tier        string
+	OnboardedAt time.Time
+}
+
+// matches reports whether r covers a trip by a driver with profile p
+// completed at `at`. Campaign scopes are checked by the store.
+func (r *Rule) matches(p Profile, at time.Time) bool {
+	if at.Before(r.StartsAt) || (r.EndsAt != nil && !at.Before(*r.EndsAt)) {
+		return false
+	}
+	if r.Tier != "" && r.Tier != p.Tier {
+		return false
+	}
+	if r.MinTenureDays > 0 && at.Sub(p.OnboardedAt) < time.Duration(r.MinTenureDays)*24*time.Hour {
+		return false
+	}
+	return true
+}
+
+// Quote is the split of one fare between driver and platform.
+type Quote struct {
+	Gross      int64
+	Commission int64
+	Net        int64
+	RateBps    int
+	// RuleID is the rule applied; nil when the default rate applied.
+	RuleID *types.ID
+}
+
+// split applies rateBps to gross, rounding the commission half up.
+func split(gross int64, rateBps int) Quote {
+	c := (gross*int64(rateBps) + FullRateBps/2) / FullRateBps
+	return Quote{Gross: gross, Commission: c, Net: gross - c, RateBps: rateBps}
+}
+
+var (
+	ErrNotFound   = errors.New("commission rule not found")
+	ErrBadRequest = errors.New("bad request")
+)
@@ -0,0 +1,15 @@
+// README: Commission route registration — mounts the commission rule endpoints onto the ops router group.
+package commission
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the commission rule endpoints onto the provided ops router group.
+//
+//	POST /api/ops/commission-rules
+//	GET  /api/ops/commission-rules
+//	POST /api/ops/commission-rules/:id/end
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.POST("/api/ops/commission-rules", h.Create)
+	rg.GET("/api/ops/commission-rules", h.List)
+	rg.POST("/api/ops/commission-rules/:id/end", h.End)
+}
@@ -0,0 +1,120 @@
+// README: Commission service — manages commission rules and resolves the driver/platform split when a trip completes.
+package commission
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"ark/internal/modules/driver"
+	"ark/internal/types"
+)
+
+// Service resolves commission rates from the configured rules.
+type Service struct {
+	store      CommissionStore
+	defaultBps int
+	now        func() time.Time
+}
+
+// NewService creates a Service that falls back to defaultBps when no rule matches.
+func NewService(store CommissionStore, defaultBps int) *Service {
+	return &Service{store: store, defaultBps: defaultBps, now: time.Now}
+}
+
+// CreateRuleCommand defines a commission rule. Zero scopes match every driver;
+// a nil EndsAt keeps the rule in force until ended.
+type CreateRuleCommand struct {
+	Name          string
+	Tier          string
+	MinTenureDays int
+	CampaignID    *types.ID
+	RateBps       int
+	Priority      int
+	StartsAt      time.Time
+	EndsAt        *time.Time
+}
+
+// CreateRule validates and stores a rule. A zero StartsAt starts it now.
+func (s *Service) CreateRule(ctx context.Context, cmd CreateRuleCommand) (*Rule, error) {
+	name := strings.TrimSpace(cmd.Name)
+	if cmd.StartsAt.IsZero() {
+		cmd.StartsAt = s.now()
+	}
+	switch {
+	case name == "" || len(name) > 200:
+		return nil, ErrBadRequest
+	case cmd.RateBps < 0 || cmd.RateBps > FullRateBps || cmd.MinTenureDays < 0:
+		return nil, ErrBadRequest
+	case cmd.Tier != "" && !slices.Contains(driver.Tiers, cmd.Tier):
+		return nil, ErrBadRequest
+	case cmd.CampaignID != nil && *cmd.CampaignID == "":
+		return nil, ErrBadRequest
+	case cmd.EndsAt != nil && !cmd.EndsAt.After(cmd.StartsAt):
+		return nil, ErrBadRequest
+	}
+	r := &Rule{
+		ID:            types.NewID(),
+		Name:          name,
+		Tier:          cmd.Tier,
+		MinTenureDays: cmd.MinTenureDays,
+		CampaignID:    cmd.CampaignID,
+		RateBps:       cmd.RateBps,
+		Priority:      cmd.Priority,
+		StartsAt:      cmd.StartsAt,
+		EndsAt:        cmd.EndsAt,
+		CreatedAt:     s.now(),
+	}
+	if err := s.store.CreateRule(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ListRules returns the rules in force now or scheduled to start.
+func (s *Service) ListRules(ctx context.Context) ([]Rule, error) {
+	return s.store.ListRules(ctx, s.now())
+}
+
+// EndRule stops a rule now. Trips already paid keep the rate they were paid at.
+func (s *Service) EndRule(ctx context.Context, id types.ID) (*Rule, error) {
+	if err := s.store.EndRule(ctx, id, s.now()); err != nil {
+		return nil, err
+	}
+	return s.store.GetRule(ctx, id)
+}
+
+// Resolve splits a trip fare of gross completed by driverID at `at`. The
+// highest-priority matching rule wins, ties going to the lower rate; with no
+// match the default rate applies.
+func (s *Service) Resolve(ctx context.Context, driverID types.ID, at time.Time, gross int64) (Quote, error) {
+	p, err := s.store.DriverProfile(ctx, driverID)
+	if errors.Is(err, ErrNotFound) {
+		// No driver profile: only unscoped rules can match.
+		p = Profile{OnboardedAt: at}
+	} else if err != nil {
+		return Quote{}, err
+	}
+	rules, err := s.store.RulesAt(ctx, at)
+	if err != nil {
+		return Quote{}, err
+	}
+	var best *Rule
+	for i := range rules {
+		r := &rules[i]
+		if !r.matches(p, at) {
+			continue
+		}
+		if best == nil || r.Priority > best.Priority || (r.Priority == best.Priority && r.RateBps < best.RateBps) {
+			best = r
+		}
+	}
+	if best == nil {
+		return split(gross, s.defaultBps), nil
+	}
+	q := split(gross, best.RateBps)
+	q.RuleID = &best.ID
+	return q, nil
+}
@@ -0,0 +1,188 @@
+package commission
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/driver"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	rules    map[types.ID]*Rule
+	profiles map[types.ID]Profile
+	// runningCampaigns are the campaigns treated as running for RulesAt.
+	runningCampaigns map[types.ID]bool
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{
+		rules:            make(map[types.ID]*Rule),
+		profiles:         make(map[types.ID]Profile),
+		runningCampaigns: make(map[types.ID]bool),
+	}
+}
+
+func (m *mockStore) CreateRule(_ context.Context, r *Rule) error {
+	cp := *r
+	m.rules[r.ID] = &cp
+	return nil
+}
+
+func (m *mockStore) GetRule(_ context.Context, id types.ID) (*Rule, error) {
+	r, ok := m.rules[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *r
+	return &cp, nil
+}
+
+func (m *mockStore) ListRules(_ context.Context, since time.Time) ([]Rule, error) {
+	var out []Rule
+	for _, r := range m.rules {
+		if r.EndsAt == nil || r.EndsAt.After(since) {
+			out = append(out, *r)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) RulesAt(_ context.Context, at time.Time) ([]Rule, error) {
+	var out []Rule
+	for _, r := range m.rules {
+		if r.CampaignID != nil && !m.runningCampaigns[*r.CampaignID] {
+			continue
+		}
+		out = append(out, *r)
+	}
+	return out, nil
+}
+
+func (m *mockStore) EndRule(_ context.Context, id types.ID, at time.Time) error {
+	r, ok := m.rules[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if r.EndsAt == nil || at.Before(*r.EndsAt) {
+		r.EndsAt = &at
+	}
+	return nil
+}
+
+func (m *mockStore) DriverProfile(_ context.Context, driverID types.ID) (Profile, error) {
+	p, ok := m.profiles[driverID]
+	if !ok {
+		return Profile{}, ErrNotFound
+	}
+	return p, nil
+}
+
+var now = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestSvc() (*Service, *mockStore) {
+	store := newMockStore()
+	svc := NewService(store, 2000)
+	svc.now = func() time.Time { return now }
+	return svc, store
+}
+
+func mustRule(t *testing.T, svc *Service, cmd CreateRuleCommand) *Rule {
+	t.Helper()
+	if cmd.StartsAt.IsZero() {
+		cmd.StartsAt = now.Add(-time.Hour)
+	}
+	r, err := svc.CreateRule(context.Background(), cmd)
+	if err != nil {
+		t.Fatalf("CreateRule %q: %v", cmd.Name, err)
+	}
+	return r
+}
+
+func TestSplit_RoundsCommissionHalfUp(t *testing.T) {
+	q := split(15005, 2000) // 3001.0
+	if q.Commission != 3001 || q.Net != 12004 {
+		t.Errorf("split = %+v", q)
+	}
+	q = split(12345, 1750) // 2160.375 -> 2160
+	if q.Commission != 2160 || q.Net+q.Commission != 12345 {
+		t.Errorf("split = %+v", q)
+	}
+}
+
+func TestResolve_PicksRule(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	store.profiles["gold-veteran"] = Profile{Tier: driver.TierGold, OnboardedAt: now.AddDate(-2, 0, 0)}
+	store.profiles["gold-new"] = Profile{Tier: driver.TierGold, OnboardedAt: now.AddDate(0, 0, -10)}
+	store.profiles["standard"] = Profile{Tier: driver.TierStandard, OnboardedAt: now.AddDate(-2, 0, 0)}
+
+	gold := mustRule(t, svc, CreateRuleCommand{Name: "gold", Tier: driver.TierGold, RateBps: 1500, Priority: 1})
+	veteran := mustRule(t, svc, CreateRuleCommand{Name: "gold veterans", Tier: driver.TierGold, MinTenureDays: 365, RateBps: 1200, Priority: 1})
+	future := now.Add(time.Hour)
+	mustRule(t, svc, CreateRuleCommand{Name: "not yet", RateBps: 0, Priority: 9, StartsAt: future})
+
+	cases := []struct {
+		driver types.ID
+		bps    int
+		rule   *types.ID
+	}{
+		{"gold-veteran", 1200, &veteran.ID}, // same priority: lower rate wins
+		{"gold-new", 1500, &gold.ID},
+		{"standard", 2000, nil},
+		{"no-profile", 2000, nil},
+	}
+	for _, c := range cases {
+		q, err := svc.Resolve(ctx, c.driver, now, 10000)
+		if err != nil {
+			t.Fatalf("%s: Resolve: %v", c.driver, err)
+		}
+		if q.RateBps != c.bps || q.Net != 10000-int64(c.bps) {
+			t.Errorf("%s: quote = %+v, want %d bps", c.driver, q, c.bps)
+		}
+		if (q.RuleID == nil) != (c.rule == nil) || (c.rule != nil && *q.RuleID != *c.rule) {
+			t.Errorf("%s: rule = %v, want %v", c.driver, q.RuleID, c.rule)
+		}
+	}
+
+	// A campaign rule with higher priority applies only while its campaign runs.
+	camp := types.ID("weekend")
+	promo := mustRule(t, svc, CreateRuleCommand{Name: "weekend promo", CampaignID: &camp, RateBps: 1000, Priority: 5})
+	if q, _ := svc.Resolve(ctx, "standard", now, 10000); q.RateBps != 2000 {
+		t.Errorf("campaign not running: got %d bps, want default", q.RateBps)
+	}
+	store.runningCampaigns[camp] = true
+	if q, _ := svc.Resolve(ctx, "gold-veteran", now, 10000); q.RateBps != 1000 || *q.RuleID != promo.ID {
+		t.Errorf("campaign running: got %+v, want promo rule", q)
+	}
+
+	// Ended rules stop applying; the quote at an earlier time is unaffected.
+	if _, err := svc.EndRule(ctx, promo.ID); err != nil {
+		t.Fatalf("EndRule: %v", err)
+	}
+	if q, _ := svc.Resolve(ctx, "gold-veteran", now.Add(time.Minute), 10000); q.RateBps != 1200 {
+		t.Errorf("after end: got %d bps, want 1200", q.RateBps)
+	}
+}
+
+func TestCreateRule_Validation(t *testing.T) {
+	svc, _ := newTestSvc()
+	empty := types.ID("")
+	past := now.Add(-2 * time.Hour)
+	bad := []CreateRuleCommand{
+		{Name: "", RateBps: 1000},
+		{Name: "too high", RateBps: 10001},
+		{Name: "negative", RateBps: -1},
+		{Name: "tier", Tier: "diamond", RateBps: 1000},
+		{Name: "tenure", MinTenureDays: -1, RateBps: 1000},
+		{Name: "campaign", CampaignID: &empty, RateBps: 1000},
+		{Name: "window", RateBps: 1000, StartsAt: now, EndsAt: &past},
+	}
+	for _, cmd := range bad {
+		if _, err := svc.CreateRule(context.Background(), cmd); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("%q: got %v, want ErrBadRequest", cmd.Name, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// README: Commission store — PostgreSQL persistence for commission_rules and the driver profile they match on.
+package commission
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// CommissionStore defines the persistence operations required by the commission Service.
+type CommissionStore interface {
+	CreateRule(ctx context.Context, r *Rule) error
+	GetRule(ctx context.Context, id types.ID) (*Rule, error)
+	// ListRules returns rules that have not ended by since, newest first.
+	ListRules(ctx context.Context, since time.Time) ([]Rule, error)
+	// RulesAt returns the rules in force at `at`, leaving out campaign-scoped
+	// rules whose campaign is not running then.
+	RulesAt(ctx context.Context, at time.Time) ([]Rule, error)
+	// EndRule moves the rule's end to at when that is earlier.
+	EndRule(ctx context.Context, id types.ID, at time.Time) error
+	DriverProfile(ctx context.Context, driverID types.ID) (Profile, error)
+}
+
+// Store is the PostgreSQL implementation of CommissionStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func nullInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+func (s *Store) CreateRule(ctx context.Context, r *Rule) error {
+	var campaignID *string
+	if r.CampaignID != nil {
+		campaignID = nullString(string(*r.CampaignID))
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO commission_rules (id, name, tier, min_tenure_days, campaign_id, rate_bps, priority, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		string(r.ID), r.Name, nullString(r.Tier), nullInt(r.MinTenureDays), campaignID,
+		r.RateBps, r.Priority, r.StartsAt, r.EndsAt, r.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrBadRequest // unknown campaign
+	}
+	return err
+}
+
+const ruleColumns = `r.id, r.name, r.tier, r.min_tenure_days, r.campaign_id, r.rate_bps, r.priority, r.starts_at, r.ends_at, r.created_at`
+
+func scanRule(row pgx.Row) (*Rule, error) {
+	var r Rule
+	var id string
+	var tier, campaignID *string
+	var tenure *int
+	if err := row.Scan(&id, &r.Name, &tier, &tenure, &campaignID, &r.RateBps, &r.Priority, &r.StartsAt, &r.EndsAt, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	r.ID = types.ID(id)
+	if tier != nil {
+		r.Tier = *tier
+	}
+	if tenure != nil {
+		r.MinTenureDays = *tenure
+	}
+	if campaignID != nil {
+		cid := types.ID(*campaignID)
+		r.CampaignID = &cid
+	}
+	return &r, nil
+}
+
+func (s *Store) queryRules(ctx context.Context, sql string, args ...any) ([]Rule, error) {
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Rule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetRule(ctx context.Context, id types.ID) (*Rule, error) {
+	r, err := scanRule(s.db.QueryRow(ctx, `SELECT `+ruleColumns+` FROM commission_rules r WHERE r.id = $1`, string(id)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+func (s *Store) ListRules(ctx context.Context, since time.Time) ([]Rule, error) {
+	return s.queryRules(ctx, `
+		SELECT `+ruleColumns+` FROM commission_rules r
+		WHERE r.ends_at IS NULL OR r.ends_at > $1
+		ORDER BY r.created_at DESC`, since)
+}
+
+func (s *Store) RulesAt(ctx context.Context, at time.Time) ([]Rule, error) {
+	return s.queryRules(ctx, `
+		SELECT `+ruleColumns+` FROM commission_rules r
+		LEFT JOIN campaigns c ON c.id = r.campaign_id
+		WHERE r.starts_at <= $1 AND (r.ends_at IS NULL OR r.ends_at > $1)
+		  AND (r.campaign_id IS NULL OR (c.starts_at <= $1 AND c.ends_at > $1))`, at)
+}
+
+func (s *Store) EndRule(ctx context.Context, id types.ID, at time.Time) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE commission_rules
+		SET ends_at = CASE WHEN ends_at IS NULL OR ends_at > $2 THEN GREATEST($2, starts_at) ELSE ends_at END
+		WHERE id = $1`, string(id), at)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) DriverProfile(ctx context.Context, driverID types.ID) (Profile, error) {
+	var p Profile
+	err := s.db.QueryRow(ctx, `SELECT tier, onboarded_at FROM drivers WHERE driver_id = $1`, string(driverID)).
+		Scan(&p.Tier, &p.OnboardedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Profile{}, ErrNotFound
+	}
+	return p, err
+}
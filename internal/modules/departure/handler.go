@@ -0,0 +1,111 @@
+// README: Departure advice HTTP handlers — the passenger reads and answers an earlier-pickup suggestion.
+//
+// Endpoints:
+//
+//	GET  /api/orders/:id/departure-suggestion  — the order's latest suggestion
+//	POST /api/orders/:id/departure-suggestion  — answer it ({"accept": true|false}); accepting moves the pickup
+//
+// Auth: all routes require the authenticated passenger of the order.
+package departure
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the departure advice HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type respondReq struct {
+	Accept *bool `json:"accept"`
+}
+
+type suggestionResp struct {
+	OrderID       types.ID `json:"order_id"`
+	Status        string   `json:"status"`
+	ScheduledAt   int64    `json:"scheduled_at"`
+	SuggestedAt   int64    `json:"suggested_at"`
+	ArriveBy      int64    `json:"arrive_by"`
+	TravelMinutes int      `json:"travel_minutes"`
+	CreatedAt     int64    `json:"created_at"`
+	RespondedAt   *int64   `json:"responded_at,omitempty"`
+}
+
+func toSuggestionResp(s *Suggestion) suggestionResp {
+	out := suggestionResp{
+		OrderID:       s.OrderID,
+		Status:        s.Status,
+		ScheduledAt:   s.ScheduledAt.Unix(),
+		SuggestedAt:   s.SuggestedAt.Unix(),
+		ArriveBy:      s.ArriveBy.Unix(),
+		TravelMinutes: int(s.TravelTime.Minutes()),
+		CreatedAt:     s.CreatedAt.Unix(),
+	}
+	if s.RespondedAt != nil {
+		ts := s.RespondedAt.Unix()
+		out.RespondedAt = &ts
+	}
+	return out
+}
+
+// Get handles GET /api/orders/:id/departure-suggestion.
+func (h *Handler) Get(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	s, err := h.svc.Get(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")))
+	if err != nil {
+		writeDepartureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toSuggestionResp(s))
+}
+
+// Respond handles POST /api/orders/:id/departure-suggestion.
+func (h *Handler) Respond(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req respondReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.Accept == nil {
+		writeError(c, http.StatusBadRequest, "accept is required")
+		return
+	}
+	s, err := h.svc.Respond(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")), *req.Accept)
+	if err != nil {
+		writeDepartureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toSuggestionResp(s))
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeDepartureError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		writeError(c, http.StatusConflict, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,52 @@
+// README: Departure advice domain model — earlier-pickup suggestions for scheduled rides that traffic would make late, and the passenger's answer.
+package departure
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Suggestion statuses. A suggestion is pending until the passenger accepts
+// (the pickup is moved) or declines it, or the pickup time passes.
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusDeclined = "declined"
+	StatusExpired  = "expired"
+)
+
+const (
+	// pickupSlack covers loading the car and luggage between the pickup time
+	// and actually driving off.
+	pickupSlack = 10 * time.Minute
+	// minAdvance is the smallest move worth bothering the passenger with.
+	minAdvance = 10 * time.Minute
+	// roundTo rounds suggested pickups down to a time people can act on.
+	roundTo = 5 * time.Minute
+)
+
+var (
+	ErrNotFound = errors.New("no departure suggestion for this order")
+	// ErrConflict is returned when answering a suggestion that is no longer
+	// pending or whose order can no longer be moved.
+	ErrConflict = errors.New("departure suggestion is no longer open")
+)
+
+// Suggestion is an earlier pickup proposed for a scheduled ride that, leaving
+// at ScheduledAt, would reach its dropoff after ArriveBy.
+type Suggestion struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	// ScheduledAt is the pickup time when the suggestion was made and
+	// SuggestedAt the proposed earlier one.
+	ScheduledAt time.Time
+	SuggestedAt time.Time
+	ArriveBy    time.Time
+	// TravelTime is the traffic-aware driving time the suggestion is based on.
+	TravelTime  time.Duration
+	Status      string
+	CreatedAt   time.Time
+	RespondedAt *time.Time
+}
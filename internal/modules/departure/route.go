@@ -0,0 +1,13 @@
+// README: Departure advice route registration — mounts the passenger suggestion endpoints onto the given router group.
+package departure
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the departure suggestion endpoints onto the provided authenticated router group.
+//
+//	GET  /api/orders/:id/departure-suggestion
+//	POST /api/orders/:id/departure-suggestion
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/orders/:id/departure-suggestion", h.Get)
+	rg.POST("/api/orders/:id/departure-suggestion", h.Respond)
+}
@@ -0,0 +1,205 @@
+// README: Departure advice service: re-checks traffic for scheduled arrive-by rides as pickup approaches and suggests an earlier pickup when they would be late.
+package departure
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Orders is the subset of order.Service the service uses.
+type Orders interface {
+	ListArriveByPickups(ctx context.Context, from, to time.Time) ([]order.ArriveByPickup, error)
+	AmendSchedule(ctx context.Context, cmd order.AmendScheduleCommand) error
+}
+
+// TrafficRoutes returns the traffic-aware driving time for a departure
+// (maps.RouteService).
+type TrafficRoutes interface {
+	GetTrafficDuration(ctx context.Context, origin, destination types.Point, departAt time.Time) (time.Duration, error)
+}
+
+// SuggestionHook is called after a new suggestion has been stored.
+type SuggestionHook func(ctx context.Context, s Suggestion)
+
+// Service suggests earlier pickups for scheduled rides that must arrive by a
+// deadline (flights, trains) when traffic makes the booked pickup too late.
+type Service struct {
+	store     SuggestionStore
+	orders    Orders
+	routes    TrafficRoutes
+	interval  time.Duration
+	lookahead time.Duration
+	hooks     []SuggestionHook
+	now       func() time.Time
+}
+
+// NewService returns a Service that checks pickups due within lookahead every interval.
+func NewService(store SuggestionStore, orders Orders, routes TrafficRoutes, interval, lookahead time.Duration) *Service {
+	return &Service{store: store, orders: orders, routes: routes, interval: interval, lookahead: lookahead, now: time.Now}
+}
+
+// OnSuggestion registers h to run after every new suggestion, e.g. to notify
+// the passenger. Must be called before the service starts.
+func (s *Service) OnSuggestion(h SuggestionHook) {
+	s.hooks = append(s.hooks, h)
+}
+
+// RunJob checks upcoming arrive-by pickups every interval until ctx is done.
+func (s *Service) RunJob(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckOnce(ctx)
+		}
+	}
+}
+
+// CheckOnce re-routes every arrive-by pickup due in the lookahead with the
+// traffic expected at its pickup time. Since the window moves with the
+// clock, each ride is checked on every run as its pickup approaches, against
+// ever fresher traffic.
+func (s *Service) CheckOnce(ctx context.Context) {
+	now := s.now()
+	pickups, err := s.orders.ListArriveByPickups(ctx, now, now.Add(s.lookahead))
+	if err != nil {
+		log.Printf("departure: list pickups: %v", err)
+		return
+	}
+	for _, p := range pickups {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.check(ctx, p, now); err != nil {
+			log.Printf("departure: order %s: %v", p.OrderID, err)
+		}
+	}
+}
+
+func (s *Service) check(ctx context.Context, p order.ArriveByPickup, now time.Time) error {
+	travel, err := s.routes.GetTrafficDuration(ctx, p.Pickup, p.Dropoff, p.ScheduledAt)
+	if err != nil {
+		return err
+	}
+	latest := p.ArriveBy.Add(-travel - pickupSlack)
+	if !p.ScheduledAt.After(latest) {
+		return nil
+	}
+	suggested := latest.Truncate(roundTo)
+	if suggested.Before(now) {
+		suggested = now.Truncate(time.Minute)
+	}
+	if p.ScheduledAt.Sub(suggested) < minAdvance {
+		return nil
+	}
+
+	prev, err := s.store.Get(ctx, p.OrderID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	// One suggestion per pickup time: a declined one is not repeated, and a
+	// pending one is only replaced when traffic has got materially worse.
+	if prev != nil && prev.ScheduledAt.Equal(p.ScheduledAt) && prev.Status != StatusExpired &&
+		prev.SuggestedAt.Sub(suggested) < minAdvance {
+		return nil
+	}
+
+	sg := Suggestion{
+		OrderID:     p.OrderID,
+		PassengerID: p.PassengerID,
+		ScheduledAt: p.ScheduledAt,
+		SuggestedAt: suggested,
+		ArriveBy:    p.ArriveBy,
+		TravelTime:  travel,
+		Status:      StatusPending,
+		CreatedAt:   now,
+	}
+	if err := s.store.Save(ctx, &sg); err != nil {
+		return err
+	}
+	log.Printf("departure: order %s would arrive late (%s drive); suggested pickup %s instead of %s",
+		p.OrderID, travel.Round(time.Minute), suggested.Format(time.RFC3339), p.ScheduledAt.Format(time.RFC3339))
+	for _, h := range s.hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("departure: suggestion hook panicked for %s: %v", p.OrderID, r)
+				}
+			}()
+			h(ctx, sg)
+		}()
+	}
+	return nil
+}
+
+// Get returns the passenger's latest suggestion for the order.
+func (s *Service) Get(ctx context.Context, passengerID, orderID types.ID) (*Suggestion, error) {
+	sg, err := s.store.Get(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if sg.PassengerID != passengerID {
+		return nil, ErrNotFound
+	}
+	return sg, nil
+}
+
+// Respond records the passenger's answer to a pending suggestion. Accepting
+// moves the pickup to the suggested time (or now, if that has passed).
+func (s *Service) Respond(ctx context.Context, passengerID, orderID types.ID, accept bool) (*Suggestion, error) {
+	sg, err := s.Get(ctx, passengerID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if sg.Status != StatusPending {
+		return nil, ErrConflict
+	}
+	now := s.now()
+	if !now.Before(sg.ScheduledAt) {
+		if _, err := s.store.Resolve(ctx, orderID, StatusExpired, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrConflict
+	}
+
+	status := StatusDeclined
+	if accept {
+		status = StatusAccepted
+		pickup := sg.SuggestedAt
+		if pickup.Before(now) {
+			pickup = now
+		}
+		// The system proposed the time, so the passenger lead-time rule does
+		// not apply; the passenger has confirmed it.
+		err := s.orders.AmendSchedule(ctx, order.AmendScheduleCommand{
+			OrderID:     orderID,
+			ScheduledAt: pickup,
+			ActorType:   order.ActorSystem,
+			Reason:      "traffic",
+		})
+		if errors.Is(err, order.ErrInvalidState) || errors.Is(err, order.ErrConflict) {
+			return nil, ErrConflict
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	ok, err := s.store.Resolve(ctx, orderID, status, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrConflict
+	}
+	sg.Status = status
+	sg.RespondedAt = &now
+	return sg, nil
+}
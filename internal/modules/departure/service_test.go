@@ -0,0 +1,171 @@
+package departure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	suggestions map[types.ID]*Suggestion
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{suggestions: make(map[types.ID]*Suggestion)}
+}
+
+func (m *mockStore) Get(_ context.Context, orderID types.ID) (*Suggestion, error) {
+	s, ok := m.suggestions[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (m *mockStore) Save(_ context.Context, s *Suggestion) error {
+	cp := *s
+	m.suggestions[s.OrderID] = &cp
+	return nil
+}
+
+func (m *mockStore) Resolve(_ context.Context, orderID types.ID, status string, at time.Time) (bool, error) {
+	s, ok := m.suggestions[orderID]
+	if !ok || s.Status != StatusPending {
+		return false, nil
+	}
+	s.Status, s.RespondedAt = status, &at
+	return true, nil
+}
+
+type fakeOrders struct {
+	pickups []order.ArriveByPickup
+	amended []order.AmendScheduleCommand
+}
+
+func (f *fakeOrders) ListArriveByPickups(context.Context, time.Time, time.Time) ([]order.ArriveByPickup, error) {
+	return f.pickups, nil
+}
+
+func (f *fakeOrders) AmendSchedule(_ context.Context, cmd order.AmendScheduleCommand) error {
+	f.amended = append(f.amended, cmd)
+	return nil
+}
+
+type fakeRoutes struct {
+	travel time.Duration
+}
+
+func (f *fakeRoutes) GetTrafficDuration(context.Context, types.Point, types.Point, time.Time) (time.Duration, error) {
+	return f.travel, nil
+}
+
+// newTestService has one ride picked up at 10:00 that must reach the
+// airport by 11:00; it is now 08:00.
+func newTestService(travel time.Duration) (*Service, *mockStore, *fakeOrders, *fakeRoutes, *[]Suggestion) {
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	store := newMockStore()
+	orders := &fakeOrders{pickups: []order.ArriveByPickup{{
+		OrderID:     "o1",
+		PassengerID: "p1",
+		ScheduledAt: now.Add(2 * time.Hour),
+		ArriveBy:    now.Add(3 * time.Hour),
+	}}}
+	routes := &fakeRoutes{travel: travel}
+	svc := NewService(store, orders, routes, time.Minute, 3*time.Hour)
+	svc.now = func() time.Time { return now }
+	var sent []Suggestion
+	svc.OnSuggestion(func(_ context.Context, s Suggestion) { sent = append(sent, s) })
+	return svc, store, orders, routes, &sent
+}
+
+func TestCheckOnce_OnTime(t *testing.T) {
+	svc, _, _, _, sent := newTestService(45 * time.Minute)
+	svc.CheckOnce(context.Background())
+	if len(*sent) != 0 {
+		t.Errorf("suggested %+v for a ride that arrives on time", *sent)
+	}
+}
+
+func TestCheckOnce_SuggestsEarlierPickup(t *testing.T) {
+	svc, _, _, routes, sent := newTestService(73 * time.Minute)
+	ctx := context.Background()
+	svc.CheckOnce(ctx)
+	if len(*sent) != 1 {
+		t.Fatalf("got %d suggestions, want 1", len(*sent))
+	}
+	// 11:00 - 73m drive - 10m slack = 09:37, rounded down to 09:35.
+	if got := (*sent)[0].SuggestedAt.Format("15:04"); got != "09:35" {
+		t.Errorf("suggested %s, want 09:35", got)
+	}
+
+	// Unchanged traffic is not repeated; traffic that got a little worse neither.
+	svc.CheckOnce(ctx)
+	routes.travel = 78 * time.Minute
+	svc.CheckOnce(ctx)
+	if len(*sent) != 1 {
+		t.Fatalf("got %d suggestions after re-checks, want 1", len(*sent))
+	}
+	// Much worse traffic replaces the pending suggestion.
+	routes.travel = 95 * time.Minute
+	svc.CheckOnce(ctx)
+	if len(*sent) != 2 || (*sent)[1].SuggestedAt.Format("15:04") != "09:15" {
+		t.Errorf("suggestions = %+v, want a second one at 09:15", *sent)
+	}
+}
+
+func TestCheckOnce_DeclinedIsNotRepeated(t *testing.T) {
+	svc, _, _, routes, sent := newTestService(73 * time.Minute)
+	ctx := context.Background()
+	svc.CheckOnce(ctx)
+	if _, err := svc.Respond(ctx, "p1", "o1", false); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	routes.travel = 78 * time.Minute
+	svc.CheckOnce(ctx)
+	if len(*sent) != 1 {
+		t.Errorf("got %d suggestions, want the declined one only", len(*sent))
+	}
+}
+
+func TestRespond_AcceptMovesPickup(t *testing.T) {
+	svc, _, orders, _, _ := newTestService(73 * time.Minute)
+	ctx := context.Background()
+	svc.CheckOnce(ctx)
+
+	if _, err := svc.Respond(ctx, "p2", "o1", true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other passenger: err = %v, want ErrNotFound", err)
+	}
+	sg, err := svc.Respond(ctx, "p1", "o1", true)
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if sg.Status != StatusAccepted || len(orders.amended) != 1 {
+		t.Fatalf("status %s, %d amendments; want accepted and one amendment", sg.Status, len(orders.amended))
+	}
+	if cmd := orders.amended[0]; !cmd.ScheduledAt.Equal(sg.SuggestedAt) || cmd.ActorType != order.ActorSystem || cmd.Reason != "traffic" {
+		t.Errorf("amendment = %+v", cmd)
+	}
+	if _, err := svc.Respond(ctx, "p1", "o1", true); !errors.Is(err, ErrConflict) {
+		t.Errorf("second answer: err = %v, want ErrConflict", err)
+	}
+}
+
+func TestRespond_AfterPickupExpires(t *testing.T) {
+	svc, store, orders, _, _ := newTestService(73 * time.Minute)
+	ctx := context.Background()
+	svc.CheckOnce(ctx)
+	later := time.Date(2026, 3, 10, 10, 5, 0, 0, time.UTC)
+	svc.now = func() time.Time { return later }
+
+	if _, err := svc.Respond(ctx, "p1", "o1", true); !errors.Is(err, ErrConflict) {
+		t.Errorf("err = %v, want ErrConflict", err)
+	}
+	if store.suggestions["o1"].Status != StatusExpired || len(orders.amended) != 0 {
+		t.Errorf("status %s with %d amendments; want expired and none", store.suggestions["o1"].Status, len(orders.amended))
+	}
+}
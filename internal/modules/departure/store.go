@@ -0,0 +1,85 @@
+// README: Departure advice store — PostgreSQL persistence for departure_suggestions.
+package departure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// SuggestionStore defines the persistence operations required by the Service.
+// An order has at most one suggestion; a newer one replaces it.
+type SuggestionStore interface {
+	// Get returns the order's latest suggestion or ErrNotFound.
+	Get(ctx context.Context, orderID types.ID) (*Suggestion, error)
+	// Save stores s, replacing any earlier suggestion for the order.
+	Save(ctx context.Context, s *Suggestion) error
+	// Resolve closes a pending suggestion with status; false when it is not pending.
+	Resolve(ctx context.Context, orderID types.ID, status string, at time.Time) (bool, error)
+}
+
+// Store is the PostgreSQL implementation of SuggestionStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Get(ctx context.Context, orderID types.ID) (*Suggestion, error) {
+	var sg Suggestion
+	var travelSecs int64
+	err := s.db.QueryRow(ctx, `
+		SELECT order_id, passenger_id, scheduled_at, suggested_at, arrive_by, travel_secs,
+		       status, created_at, responded_at
+		FROM departure_suggestions
+		WHERE order_id = $1`, string(orderID),
+	).Scan(&sg.OrderID, &sg.PassengerID, &sg.ScheduledAt, &sg.SuggestedAt, &sg.ArriveBy, &travelSecs,
+		&sg.Status, &sg.CreatedAt, &sg.RespondedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	sg.TravelTime = time.Duration(travelSecs) * time.Second
+	return &sg, nil
+}
+
+func (s *Store) Save(ctx context.Context, sg *Suggestion) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO departure_suggestions
+		    (order_id, passenger_id, scheduled_at, suggested_at, arrive_by, travel_secs, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (order_id) DO UPDATE SET
+		    scheduled_at = EXCLUDED.scheduled_at,
+		    suggested_at = EXCLUDED.suggested_at,
+		    arrive_by    = EXCLUDED.arrive_by,
+		    travel_secs  = EXCLUDED.travel_secs,
+		    status       = EXCLUDED.status,
+		    created_at   = EXCLUDED.created_at,
+		    responded_at = NULL`,
+		string(sg.OrderID), string(sg.PassengerID), sg.ScheduledAt, sg.SuggestedAt, sg.ArriveBy,
+		int64(sg.TravelTime/time.Second), sg.Status, sg.CreatedAt,
+	)
+	return err
+}
+
+func (s *Store) Resolve(ctx context.Context, orderID types.ID, status string, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE departure_suggestions SET status = $2, responded_at = $3
+		WHERE order_id = $1 AND status = $4`,
+		string(orderID), status, at, StatusPending,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
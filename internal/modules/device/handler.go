@@ -0,0 +1,100 @@
+// README: Device HTTP handlers — ops views of installs shared by several accounts.
+//
+// Endpoints:
+//
+//	GET /api/ops/devices/shared           — installs used by many accounts (?min=, default 3; ?limit=)
+//	GET /api/ops/devices/:install_id      — accounts seen on one install
+//
+// Auth: all routes require the ops key middleware.
+package device
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/types"
+)
+
+// Handler holds the device HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type deviceResp struct {
+	UserID      types.ID `json:"user_id"`
+	Model       string   `json:"model,omitempty"`
+	Platform    string   `json:"platform,omitempty"`
+	Source      string   `json:"source"`
+	FirstSeenAt int64    `json:"first_seen_at"`
+	LastSeenAt  int64    `json:"last_seen_at"`
+}
+
+type clusterResp struct {
+	InstallID  string     `json:"install_id"`
+	Model      string     `json:"model,omitempty"`
+	UserIDs    []types.ID `json:"user_ids"`
+	LastSeenAt int64      `json:"last_seen_at"`
+}
+
+// Clusters handles GET /api/ops/devices/shared.
+func (h *Handler) Clusters(c *gin.Context) {
+	minAccounts, _ := strconv.Atoi(c.Query("min"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	clusters, err := h.svc.Clusters(c.Request.Context(), minAccounts, limit)
+	if err != nil {
+		writeDeviceError(c, err)
+		return
+	}
+	out := make([]clusterResp, 0, len(clusters))
+	for _, cl := range clusters {
+		out = append(out, clusterResp{
+			InstallID:  cl.InstallID,
+			Model:      cl.Model,
+			UserIDs:    cl.UserIDs,
+			LastSeenAt: cl.LastSeenAt.Unix(),
+		})
+	}
+	c.JSON(http.StatusOK, map[string]any{"devices": out})
+}
+
+// Accounts handles GET /api/ops/devices/:install_id.
+func (h *Handler) Accounts(c *gin.Context) {
+	devices, err := h.svc.Accounts(c.Request.Context(), c.Param("install_id"))
+	if err != nil {
+		writeDeviceError(c, err)
+		return
+	}
+	out := make([]deviceResp, 0, len(devices))
+	for _, d := range devices {
+		out = append(out, deviceResp{
+			UserID:      d.UserID,
+			Model:       d.Model,
+			Platform:    d.Platform,
+			Source:      d.Source,
+			FirstSeenAt: d.FirstSeenAt.Unix(),
+			LastSeenAt:  d.LastSeenAt.Unix(),
+		})
+	}
+	c.JSON(http.StatusOK, map[string]any{"install_id": c.Param("install_id"), "accounts": out})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeDeviceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
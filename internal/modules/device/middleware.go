@@ -0,0 +1,49 @@
+// README: Device capture middleware — reads the app's device headers and records them for the signed-in user.
+package device
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/sandbox"
+	"ark/internal/types"
+)
+
+// Headers the apps send with registration and order requests.
+const (
+	HeaderInstallID = "X-Install-ID"
+	HeaderModel     = "X-Device-Model"
+	HeaderPlatform  = "X-Device-Platform"
+)
+
+// Capture returns a Gin middleware that records the request's device for the
+// authenticated user once the handler has succeeded. Requests without an
+// install id, failed requests and sandbox requests are not recorded. A nil
+// svc makes the middleware a no-op.
+func Capture(svc *Service, source string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if svc == nil || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		info := Info{
+			InstallID: c.GetHeader(HeaderInstallID),
+			Model:     c.GetHeader(HeaderModel),
+			Platform:  c.GetHeader(HeaderPlatform),
+		}
+		if info.InstallID == "" {
+			return
+		}
+		ctx := c.Request.Context()
+		uid, ok := middleware.UserIDFromContext(ctx)
+		if !ok || uid == "" || sandbox.Enabled(ctx) {
+			return
+		}
+		if err := svc.Register(ctx, types.ID(uid), info, source); err != nil {
+			log.Printf("device: record %s for %s: %v", source, uid, err)
+		}
+	}
+}
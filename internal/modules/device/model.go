@@ -0,0 +1,64 @@
+// README: Device domain model — app installs seen per account, for multi-account and shared-device fraud checks.
+package device
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Sources a device is recorded from.
+const (
+	SourceRegistration = "registration"
+	SourceOrder        = "order"
+)
+
+// MaxInstallIDLen and MaxModelLen bound the client-reported identifiers.
+const (
+	MaxInstallIDLen = 128
+	MaxModelLen     = 128
+)
+
+// Info identifies the device a request came from, as reported by the app.
+type Info struct {
+	// InstallID is the app's per-install identifier; it survives logouts but
+	// not a reinstall.
+	InstallID string
+	Model     string // e.g. "iPhone15,3"
+	Platform  string // ios, android or web
+}
+
+// Device is one account seen on one install.
+type Device struct {
+	InstallID   string
+	UserID      types.ID
+	Model       string
+	Platform    string
+	Source      string // where the pairing was first seen
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// Sighting describes a recorded device visit.
+type Sighting struct {
+	UserID types.ID
+	Info   Info
+	Source string
+	// New is set the first time UserID is seen on the install.
+	New bool
+	// Accounts is how many accounts have used the install, UserID included.
+	Accounts int
+}
+
+// Cluster is an install shared by several accounts.
+type Cluster struct {
+	InstallID  string
+	Model      string
+	UserIDs    []types.ID
+	LastSeenAt time.Time
+}
+
+var (
+	ErrBadRequest = errors.New("bad request")
+)
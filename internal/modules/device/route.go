@@ -0,0 +1,13 @@
+// README: Device route registration — mounts the shared-device views onto the ops router group.
+package device
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the device endpoints onto the provided ops router group.
+//
+//	GET /api/ops/devices/shared
+//	GET /api/ops/devices/:install_id
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/devices/shared", h.Clusters)
+	rg.GET("/api/ops/devices/:install_id", h.Accounts)
+}
@@ -0,0 +1,102 @@
+// README: Device service — records installs per account and answers multi-account queries for fraud checks.
+package device
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"ark/internal/types"
+)
+
+// DefaultClusterSize is the account count at which an install is reported as
+// shared by the ops listing when no minimum is given.
+const DefaultClusterSize = 3
+
+const maxClusterLimit = 200
+
+// SightingHook is called after a device visit has been recorded.
+type SightingHook func(ctx context.Context, s Sighting)
+
+// Service records devices and answers multi-account queries.
+type Service struct {
+	store DeviceStore
+	hooks []SightingHook
+}
+
+// NewService creates a Service backed by the given store.
+func NewService(store DeviceStore) *Service {
+	return &Service{store: store}
+}
+
+// OnSighting registers h to run after every recorded device visit.
+// Must be called before the service starts handling requests.
+func (s *Service) OnSighting(h SightingHook) {
+	s.hooks = append(s.hooks, h)
+}
+
+// Register records that userID used the install described by info. Requests
+// without an install id are ignored.
+func (s *Service) Register(ctx context.Context, userID types.ID, info Info, source string) error {
+	info.InstallID = strings.TrimSpace(info.InstallID)
+	if info.InstallID == "" {
+		return nil
+	}
+	if userID == "" || len(info.InstallID) > MaxInstallIDLen || len(info.Model) > MaxModelLen {
+		return ErrBadRequest
+	}
+	d := &Device{
+		InstallID:  info.InstallID,
+		UserID:     userID,
+		Model:      strings.TrimSpace(info.Model),
+		Platform:   strings.ToLower(strings.TrimSpace(info.Platform)),
+		Source:     source,
+		LastSeenAt: time.Now().UTC(),
+	}
+	isNew, accounts, err := s.store.Record(ctx, d)
+	if err != nil {
+		return err
+	}
+	sighting := Sighting{UserID: userID, Info: info, Source: source, New: isNew, Accounts: accounts}
+	for _, h := range s.hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("device: sighting hook panicked for %s: %v", userID, r)
+				}
+			}()
+			h(ctx, sighting)
+		}()
+	}
+	return nil
+}
+
+// Accounts lists the accounts seen on installID.
+func (s *Service) Accounts(ctx context.Context, installID string) ([]Device, error) {
+	if installID == "" {
+		return nil, ErrBadRequest
+	}
+	return s.store.Accounts(ctx, installID)
+}
+
+// LinkedAccounts counts the other accounts that share an install with userID.
+func (s *Service) LinkedAccounts(ctx context.Context, userID types.ID) (int, error) {
+	return s.store.LinkedAccounts(ctx, userID)
+}
+
+// Shared reports whether a and b were seen on the same install.
+func (s *Service) Shared(ctx context.Context, a, b types.ID) (bool, error) {
+	return s.store.Shared(ctx, a, b)
+}
+
+// Clusters lists installs used by at least minAccounts accounts.
+func (s *Service) Clusters(ctx context.Context, minAccounts, limit int) ([]Cluster, error) {
+	if minAccounts < 2 {
+		minAccounts = DefaultClusterSize
+	}
+	if limit <= 0 || limit > maxClusterLimit {
+		limit = 50
+	}
+	return s.store.Clusters(ctx, minAccounts, limit)
+}
@@ -0,0 +1,188 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/sandbox"
+	"ark/internal/types"
+)
+
+// ---------------------------------------------------------------------------
+// In-memory fake
+// ---------------------------------------------------------------------------
+
+type mockStore struct {
+	devices map[string]map[types.ID]*Device // install_id -> user -> device
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{devices: make(map[string]map[types.ID]*Device)}
+}
+
+func (m *mockStore) Record(_ context.Context, d *Device) (bool, int, error) {
+	users := m.devices[d.InstallID]
+	if users == nil {
+		users = make(map[types.ID]*Device)
+		m.devices[d.InstallID] = users
+	}
+	old, ok := users[d.UserID]
+	if ok {
+		old.LastSeenAt = d.LastSeenAt
+		return false, len(users), nil
+	}
+	cp := *d
+	cp.FirstSeenAt = d.LastSeenAt
+	users[d.UserID] = &cp
+	return true, len(users), nil
+}
+
+func (m *mockStore) Accounts(_ context.Context, installID string) ([]Device, error) {
+	var out []Device
+	for _, d := range m.devices[installID] {
+		out = append(out, *d)
+	}
+	return out, nil
+}
+
+func (m *mockStore) LinkedAccounts(_ context.Context, userID types.ID) (int, error) {
+	linked := map[types.ID]bool{}
+	for _, users := range m.devices {
+		if _, ok := users[userID]; !ok {
+			continue
+		}
+		for u := range users {
+			if u != userID {
+				linked[u] = true
+			}
+		}
+	}
+	return len(linked), nil
+}
+
+func (m *mockStore) Shared(_ context.Context, a, b types.ID) (bool, error) {
+	for _, users := range m.devices {
+		if users[a] != nil && users[b] != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockStore) Clusters(_ context.Context, minAccounts, limit int) ([]Cluster, error) {
+	var out []Cluster
+	for id, users := range m.devices {
+		if len(users) < minAccounts {
+			continue
+		}
+		c := Cluster{InstallID: id}
+		for u := range users {
+			c.UserIDs = append(c.UserIDs, u)
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return len(out[i].UserIDs) > len(out[j].UserIDs) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestRegister_CountsAccountsPerInstall(t *testing.T) {
+	svc := NewService(newMockStore())
+	ctx := context.Background()
+	var sightings []Sighting
+	svc.OnSighting(func(_ context.Context, s Sighting) { sightings = append(sightings, s) })
+
+	for _, uid := range []types.ID{"u1", "u1", "u2", "u3"} {
+		if err := svc.Register(ctx, uid, Info{InstallID: "i1", Model: "Pixel 8"}, SourceOrder); err != nil {
+			t.Fatalf("Register %s: %v", uid, err)
+		}
+	}
+	if err := svc.Register(ctx, "u1", Info{InstallID: " "}, SourceOrder); err != nil || len(sightings) != 4 {
+		t.Errorf("blank install id: err %v, %d sightings; want it ignored", err, len(sightings))
+	}
+
+	want := []struct {
+		new      bool
+		accounts int
+	}{{true, 1}, {false, 1}, {true, 2}, {true, 3}}
+	for i, w := range want {
+		if sightings[i].New != w.new || sightings[i].Accounts != w.accounts {
+			t.Errorf("sighting %d = %+v, want new=%v accounts=%d", i, sightings[i], w.new, w.accounts)
+		}
+	}
+
+	if n, _ := svc.LinkedAccounts(ctx, "u1"); n != 2 {
+		t.Errorf("LinkedAccounts(u1) = %d, want 2", n)
+	}
+	if ok, _ := svc.Shared(ctx, "u2", "u3"); !ok {
+		t.Error("u2 and u3 share i1")
+	}
+	clusters, err := svc.Clusters(ctx, 0, 0)
+	if err != nil || len(clusters) != 1 || len(clusters[0].UserIDs) != 3 {
+		t.Errorf("Clusters = %+v, %v; want i1 with 3 accounts", clusters, err)
+	}
+}
+
+func TestRegister_Validates(t *testing.T) {
+	svc := NewService(newMockStore())
+	long := string(make([]byte, MaxInstallIDLen+1))
+	if err := svc.Register(context.Background(), "", Info{InstallID: "i1"}, SourceOrder); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("missing user: got %v, want ErrBadRequest", err)
+	}
+	if err := svc.Register(context.Background(), "u1", Info{InstallID: "x" + long}, SourceOrder); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("long install id: got %v, want ErrBadRequest", err)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newMockStore()
+	svc := NewService(store)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx := middleware.WithUserIDContext(c.Request.Context(), c.GetHeader("X-Test-UID"))
+		if c.GetHeader("X-Test-Sandbox") != "" {
+			ctx = sandbox.WithContext(ctx)
+		}
+		c.Request = c.Request.WithContext(ctx)
+	})
+	r.POST("/ok", Capture(svc, SourceOrder), func(c *gin.Context) { c.Status(http.StatusCreated) })
+	r.POST("/fail", Capture(svc, SourceOrder), func(c *gin.Context) { c.Status(http.StatusConflict) })
+
+	send := func(path, uid, install string, sandboxed bool) {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("X-Test-UID", uid)
+		req.Header.Set(HeaderInstallID, install)
+		req.Header.Set(HeaderModel, "iPhone15,3")
+		if sandboxed {
+			req.Header.Set("X-Test-Sandbox", "1")
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send("/ok", "u1", "i1", false)
+	send("/fail", "u2", "i1", false)
+	send("/ok", "u3", "i1", true)
+	send("/ok", "u4", "", false)
+
+	accounts, _ := svc.Accounts(context.Background(), "i1")
+	if len(accounts) != 1 || accounts[0].UserID != "u1" || accounts[0].Model != "iPhone15,3" {
+		t.Fatalf("accounts on i1 = %+v, want only u1", accounts)
+	}
+	if len(store.devices) != 1 {
+		t.Errorf("recorded installs %v, want only i1", store.devices)
+	}
+}
@@ -0,0 +1,135 @@
+// README: Device store — PostgreSQL persistence for devices and the accounts-per-install queries.
+package device
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// DeviceStore defines the persistence operations required by the device Service.
+type DeviceStore interface {
+	// Record upserts the (install, user) pairing and reports whether it is
+	// new and how many accounts the install now has.
+	Record(ctx context.Context, d *Device) (isNew bool, accounts int, err error)
+	// Accounts lists the accounts seen on the install, earliest first.
+	Accounts(ctx context.Context, installID string) ([]Device, error)
+	// LinkedAccounts counts the other accounts sharing any install with userID.
+	LinkedAccounts(ctx context.Context, userID types.ID) (int, error)
+	// Shared reports whether two accounts were seen on the same install.
+	Shared(ctx context.Context, a, b types.ID) (bool, error)
+	// Clusters returns installs used by at least minAccounts accounts, most
+	// accounts first.
+	Clusters(ctx context.Context, minAccounts, limit int) ([]Cluster, error)
+}
+
+// Store is the PostgreSQL implementation of DeviceStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Record(ctx context.Context, d *Device) (bool, int, error) {
+	var isNew bool
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO devices (install_id, user_id, model, platform, source, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (install_id, user_id) DO UPDATE SET
+			model        = COALESCE(NULLIF(EXCLUDED.model, ''), devices.model),
+			platform     = COALESCE(NULLIF(EXCLUDED.platform, ''), devices.platform),
+			last_seen_at = EXCLUDED.last_seen_at
+		RETURNING xmax = 0`,
+		d.InstallID, string(d.UserID), d.Model, d.Platform, d.Source, d.LastSeenAt,
+	).Scan(&isNew)
+	if err != nil {
+		return false, 0, err
+	}
+	var accounts int
+	err = s.db.QueryRow(ctx, `SELECT COUNT(*) FROM devices WHERE install_id = $1`, d.InstallID).Scan(&accounts)
+	return isNew, accounts, err
+}
+
+func (s *Store) Accounts(ctx context.Context, installID string) ([]Device, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT install_id, user_id, model, platform, source, first_seen_at, last_seen_at
+		FROM devices WHERE install_id = $1
+		ORDER BY first_seen_at`,
+		installID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Device
+	for rows.Next() {
+		var d Device
+		var userID string
+		if err := rows.Scan(&d.InstallID, &userID, &d.Model, &d.Platform, &d.Source, &d.FirstSeenAt, &d.LastSeenAt); err != nil {
+			return nil, err
+		}
+		d.UserID = types.ID(userID)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) LinkedAccounts(ctx context.Context, userID types.ID) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT other.user_id)
+		FROM devices mine
+		JOIN devices other ON other.install_id = mine.install_id AND other.user_id <> mine.user_id
+		WHERE mine.user_id = $1`,
+		string(userID),
+	).Scan(&n)
+	return n, err
+}
+
+func (s *Store) Shared(ctx context.Context, a, b types.ID) (bool, error) {
+	var shared bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM devices x JOIN devices y ON x.install_id = y.install_id
+			WHERE x.user_id = $1 AND y.user_id = $2)`,
+		string(a), string(b),
+	).Scan(&shared)
+	return shared, err
+}
+
+func (s *Store) Clusters(ctx context.Context, minAccounts, limit int) ([]Cluster, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT install_id, MAX(model), ARRAY_AGG(user_id ORDER BY first_seen_at), MAX(last_seen_at)
+		FROM devices
+		GROUP BY install_id
+		HAVING COUNT(*) >= $1
+		ORDER BY COUNT(*) DESC, MAX(last_seen_at) DESC
+		LIMIT $2`,
+		minAccounts, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Cluster
+	for rows.Next() {
+		var c Cluster
+		var ids []string
+		var last time.Time
+		if err := rows.Scan(&c.InstallID, &c.Model, &ids, &last); err != nil {
+			return nil, err
+		}
+		c.LastSeenAt = last
+		for _, id := range ids {
+			c.UserIDs = append(c.UserIDs, types.ID(id))
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
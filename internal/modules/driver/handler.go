@@ -4,15 +4,22 @@
 //
 //	POST  /api/driver/create  — create driver profile (driver_id from context, body: license_number)
 //	PATCH /api/driver/status  — update driver status  (driver_id from context, body: status)
+//	PUT   /api/driver/capabilities — replace capability flags (driver_id from context, body: capabilities)
+//	PUT   /api/ops/drivers/:id/tier — set a driver's tier (ops key, body: tier)
+//	PUT   /api/ops/drivers/:id/region — assign a driver to a region (ops key, body: region_id)
+//	PUT   /api/ops/drivers/:id/wav-certification — certify a driver for wheelchair passengers (ops key, body: certified)
 //
 // Auth: The Auth middleware must set "user_id" in the request context before these handlers run.
 // Any request without a valid user_id in context is rejected with 401 Unauthorized.
 package driver
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"ark/internal/types"
 )
 
 // Handler holds the driver HTTP handlers.
@@ -59,11 +66,14 @@ func (h *Handler) Create(c *gin.Context) {
 
 type updateStatusReq struct {
 	Status string `json:"status"`
+	// VehicleID, when set, is the vehicle the driver is driving from now on.
+	VehicleID types.ID `json:"vehicle_id"`
 }
 
 // UpdateStatus handles PATCH /api/driver/status.
 // The driver_id is taken from the request context (set by Auth middleware).
-// Body: {"status": "available"|"on_trip"|"offline"}
+// Body: {"status": "available"|"on_trip"|"offline", "vehicle_id": "..."}
+// Going available with a fleet vehicle requires its current shift.
 func (h *Handler) UpdateStatus(c *gin.Context) {
 	var req updateStatusReq
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -75,13 +85,95 @@ func (h *Handler) UpdateStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.UpdateStatus(c.Request.Context(), req.Status); err != nil {
+	if err := h.svc.UpdateStatus(c.Request.Context(), req.Status, req.VehicleID); err != nil {
 		writeDriverError(c, err)
 		return
 	}
 	writeJSON(c, http.StatusOK, map[string]any{"status": req.Status})
 }
 
+type updateCapabilitiesReq struct {
+	Capabilities []string `json:"capabilities"`
+}
+
+// UpdateCapabilities handles PUT /api/driver/capabilities.
+// The driver_id is taken from the request context (set by Auth middleware).
+// Body: {"capabilities": ["wheelchair", "child_seat", ...]}
+func (h *Handler) UpdateCapabilities(c *gin.Context) {
+	var req updateCapabilitiesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	caps, err := h.svc.UpdateCapabilities(c.Request.Context(), req.Capabilities)
+	if err != nil {
+		writeDriverError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"capabilities": caps})
+}
+
+type setTierReq struct {
+	Tier string `json:"tier"`
+}
+
+// SetTier handles PUT /api/ops/drivers/:id/tier. It is mounted on the ops
+// router group, so the driver comes from the path.
+// Body: {"tier": "gold"}
+func (h *Handler) SetTier(c *gin.Context) {
+	var req setTierReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.SetTier(c.Request.Context(), types.ID(c.Param("id")), req.Tier); err != nil {
+		writeDriverError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"driver_id": c.Param("id"), "tier": req.Tier})
+}
+
+type setRegionReq struct {
+	RegionID string `json:"region_id"`
+}
+
+// SetRegion handles PUT /api/ops/drivers/:id/region. Like SetTier it is
+// mounted on the ops router group.
+// Body: {"region_id": "tpe"}
+func (h *Handler) SetRegion(c *gin.Context) {
+	var req setRegionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.SetRegion(c.Request.Context(), types.ID(c.Param("id")), req.RegionID); err != nil {
+		writeDriverError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"driver_id": c.Param("id"), "region_id": req.RegionID})
+}
+
+type setWAVCertifiedReq struct {
+	Certified *bool `json:"certified"`
+}
+
+// SetWAVCertified handles PUT /api/ops/drivers/:id/wav-certification. Like
+// SetTier it is mounted on the ops router group.
+// Body: {"certified": true}
+func (h *Handler) SetWAVCertified(c *gin.Context) {
+	var req setWAVCertifiedReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.Certified == nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.SetWAVCertified(c.Request.Context(), types.ID(c.Param("id")), *req.Certified); err != nil {
+		writeDriverError(c, err)
+		return
+	}
+	writeJSON(c, http.StatusOK, map[string]any{"driver_id": c.Param("id"), "wav_certified": *req.Certified})
+}
+
 func writeJSON(c *gin.Context, status int, v any) {
 	c.JSON(status, v)
 }
@@ -91,15 +183,17 @@ func writeError(c *gin.Context, status int, msg string) {
 }
 
 func writeDriverError(c *gin.Context, err error) {
-	switch err {
-	case ErrForbidden:
+	switch {
+	case errors.Is(err, ErrForbidden):
 		writeError(c, http.StatusUnauthorized, "authentication required")
-	case ErrBadRequest:
+	case errors.Is(err, ErrBadRequest):
 		writeError(c, http.StatusBadRequest, err.Error())
-	case ErrNotFound:
+	case errors.Is(err, ErrNotFound):
 		writeError(c, http.StatusNotFound, err.Error())
-	case ErrConflict:
+	case errors.Is(err, ErrConflict):
 		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrVehicleNotAssigned):
+		writeError(c, http.StatusForbidden, err.Error())
 	default:
 		writeError(c, http.StatusInternalServerError, "internal error")
 	}
@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"ark/internal/http/middleware"
+	"ark/internal/modules/order"
 	"ark/internal/types"
 )
 
@@ -66,6 +68,82 @@ func (m *mockStore) UpdateStatusWithLock(_ context.Context, id types.ID, newStat
 	return nil
 }
 
+func (m *mockStore) UpdateCapabilities(_ context.Context, id types.ID, capabilities []string) error {
+	d, ok := m.drivers[string(id)]
+	if !ok {
+		return ErrNotFound
+	}
+	d.Capabilities = capabilities
+	return nil
+}
+
+func (m *mockStore) UpdateTier(_ context.Context, id types.ID, tier string) error {
+	d, ok := m.drivers[string(id)]
+	if !ok {
+		return ErrNotFound
+	}
+	d.Tier = tier
+	return nil
+}
+
+func (m *mockStore) UpdateRegion(_ context.Context, id types.ID, regionID string) error {
+	d, ok := m.drivers[string(id)]
+	if !ok {
+		return ErrNotFound
+	}
+	d.RegionID = regionID
+	return nil
+}
+
+func (m *mockStore) UpdateWAVCertified(_ context.Context, id types.ID, certified bool) error {
+	d, ok := m.drivers[string(id)]
+	if !ok {
+		return ErrNotFound
+	}
+	d.WAVCertified = certified
+	return nil
+}
+
+func (m *mockStore) UpdateVehicle(_ context.Context, id types.ID, vehicleID types.ID) error {
+	d, ok := m.drivers[string(id)]
+	if !ok {
+		return ErrNotFound
+	}
+	d.VehicleID = &vehicleID
+	return nil
+}
+
+func (m *mockStore) Regions(_ context.Context, ids []types.ID) (map[types.ID]string, error) {
+	out := make(map[types.ID]string)
+	for _, id := range ids {
+		if d, ok := m.drivers[string(id)]; ok {
+			out[id] = d.RegionID
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) FilterCapable(_ context.Context, ids []types.ID, required []string) ([]types.ID, error) {
+	var out []types.ID
+	for _, id := range ids {
+		d, ok := m.drivers[string(id)]
+		if !ok {
+			continue
+		}
+		capable := true
+		for _, r := range required {
+			if !slices.Contains(d.Capabilities, r) || (r == order.RequireWheelchair && !d.WAVCertified) {
+				capable = false
+				break
+			}
+		}
+		if capable {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
 // --- test helpers ---
 
 func setupRouter(svc *Service) *gin.Engine {
@@ -73,6 +151,10 @@ func setupRouter(svc *Service) *gin.Engine {
 	h := NewHandler(svc)
 	r.PUT("/api/driver/create", h.Create)
 	r.PUT("/api/driver/status", h.UpdateStatus)
+	r.PUT("/api/driver/capabilities", h.UpdateCapabilities)
+	r.PUT("/api/ops/drivers/:id/tier", h.SetTier)
+	r.PUT("/api/ops/drivers/:id/region", h.SetRegion)
+	r.PUT("/api/ops/drivers/:id/wav-certification", h.SetWAVCertified)
 	return r
 }
 
@@ -337,3 +419,196 @@ func TestDriverInfo_NotFound(t *testing.T) {
 		t.Errorf("expected ErrNotFound, got %v", err)
 	}
 }
+
+func TestUpdateCapabilities(t *testing.T) {
+	store := newMockStore()
+	store.drivers["driver-6"] = &Driver{ID: "driver-6", LicenseNumber: "EF-1", Status: StatusAvailable}
+	r := setupRouter(NewService(store))
+
+	put := func(caps []string) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/driver/capabilities", jsonBody(map[string]any{"capabilities": caps}))
+		req.Header.Set("Content-Type", "application/json")
+		req = withUserID(req, "driver-6")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := put([]string{"wheelchair", "child_seat"}); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if got := store.drivers["driver-6"].Capabilities; !slices.Equal(got, []string{"child_seat", "wheelchair"}) {
+		t.Errorf("expected sorted capabilities, got %v", got)
+	}
+	if code := put([]string{"hovercraft"}); code != http.StatusBadRequest {
+		t.Errorf("unknown capability: expected 400, got %d", code)
+	}
+}
+
+func TestSetTier(t *testing.T) {
+	store := newMockStore()
+	store.drivers["driver-7"] = &Driver{ID: "driver-7", Tier: TierStandard}
+	r := setupRouter(NewService(store))
+
+	put := func(id, tier string) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/ops/drivers/"+id+"/tier", jsonBody(map[string]any{"tier": tier}))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := put("driver-7", TierGold); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if got := store.drivers["driver-7"].Tier; got != TierGold {
+		t.Errorf("tier = %q, want gold", got)
+	}
+	if code := put("driver-7", "diamond"); code != http.StatusBadRequest {
+		t.Errorf("unknown tier: expected 400, got %d", code)
+	}
+	if code := put("nobody", TierGold); code != http.StatusNotFound {
+		t.Errorf("unknown driver: expected 404, got %d", code)
+	}
+}
+
+type knownRegions []string
+
+func (k knownRegions) Exists(id string) bool { return slices.Contains(k, id) }
+
+func TestSetRegion(t *testing.T) {
+	store := newMockStore()
+	store.drivers["driver-7"] = &Driver{ID: "driver-7"}
+	svc := NewService(store)
+	svc.SetRegions(knownRegions{"tpe", "khh"})
+	r := setupRouter(svc)
+
+	put := func(id, regionID string) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/ops/drivers/"+id+"/region", jsonBody(map[string]any{"region_id": regionID}))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := put("driver-7", "khh"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	got, err := svc.DriverRegions(context.Background(), []types.ID{"driver-7", "nobody"})
+	if err != nil || len(got) != 1 || got["driver-7"] != "khh" {
+		t.Errorf("DriverRegions = %v, %v; want driver-7 in khh", got, err)
+	}
+	if code := put("driver-7", "tyo"); code != http.StatusBadRequest {
+		t.Errorf("unknown region: expected 400, got %d", code)
+	}
+	if code := put("nobody", "tpe"); code != http.StatusNotFound {
+		t.Errorf("unknown driver: expected 404, got %d", code)
+	}
+}
+
+func TestFilterCapable(t *testing.T) {
+	store := newMockStore()
+	store.drivers["d1"] = &Driver{ID: "d1", Capabilities: []string{"child_seat", "wheelchair"}, WAVCertified: true}
+	store.drivers["d2"] = &Driver{ID: "d2", Capabilities: []string{"child_seat"}}
+	svc := NewService(store)
+	ids := []types.ID{"d1", "d2"}
+
+	got, err := svc.FilterCapable(context.Background(), ids, []string{"wheelchair"})
+	if err != nil || !slices.Equal(got, []types.ID{"d1"}) {
+		t.Errorf("wheelchair: got %v, %v; want [d1]", got, err)
+	}
+	got, _ = svc.FilterCapable(context.Background(), ids, nil)
+	if !slices.Equal(got, ids) {
+		t.Errorf("no requirements: got %v, want every driver", got)
+	}
+}
+
+func TestSetWAVCertified(t *testing.T) {
+	store := newMockStore()
+	store.drivers["d1"] = &Driver{ID: "d1", Capabilities: []string{"child_seat", "wheelchair"}}
+	svc := NewService(store)
+	r := setupRouter(svc)
+	ctx := context.Background()
+
+	// A self-declared wheelchair flag does not count until ops certify the driver.
+	if got, _ := svc.Capabilities(ctx, "d1"); !slices.Equal(got, []string{"child_seat"}) {
+		t.Errorf("uncertified capabilities = %v, want [child_seat]", got)
+	}
+	if got, _ := svc.FilterCapable(ctx, []types.ID{"d1"}, []string{order.RequireWheelchair}); len(got) != 0 {
+		t.Errorf("uncertified driver offered a wheelchair order: %v", got)
+	}
+
+	put := func(id string, body any) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/ops/drivers/"+id+"/wav-certification", jsonBody(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := put("d1", map[string]any{"certified": true}); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if got, _ := svc.Capabilities(ctx, "d1"); !slices.Equal(got, []string{"child_seat", "wheelchair"}) {
+		t.Errorf("certified capabilities = %v, want both flags", got)
+	}
+	if got, _ := svc.FilterCapable(ctx, []types.ID{"d1"}, []string{order.RequireWheelchair}); !slices.Equal(got, []types.ID{"d1"}) {
+		t.Errorf("certified driver: got %v, want [d1]", got)
+	}
+	if code := put("d1", map[string]any{}); code != http.StatusBadRequest {
+		t.Errorf("missing flag: expected 400, got %d", code)
+	}
+	if code := put("nobody", map[string]any{"certified": true}); code != http.StatusNotFound {
+		t.Errorf("unknown driver: expected 404, got %d", code)
+	}
+}
+
+// fakeVehicles lets each vehicle be driven only by the driver named for it.
+type fakeVehicles map[types.ID]types.ID
+
+func (f fakeVehicles) MayDrive(_ context.Context, vehicleID, driverID types.ID) (bool, error) {
+	current, fleet := f[vehicleID]
+	return !fleet || current == driverID, nil
+}
+
+func TestUpdateStatus_FleetVehicleShift(t *testing.T) {
+	store := newMockStore()
+	store.drivers["d1"] = &Driver{ID: "d1", Status: StatusOffline}
+	store.drivers["d2"] = &Driver{ID: "d2", Status: StatusOffline}
+	svc := NewService(store)
+	svc.SetVehicles(fakeVehicles{"v1": "d1"})
+	r := setupRouter(svc)
+
+	put := func(driverID string, body any) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/driver/status", jsonBody(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, withUserID(req, driverID))
+		return w.Code
+	}
+
+	if code := put("d2", map[string]any{"status": StatusAvailable, "vehicle_id": "v1"}); code != http.StatusForbidden {
+		t.Errorf("off-shift driver: expected 403, got %d", code)
+	}
+	if store.drivers["d2"].Status != StatusOffline || store.drivers["d2"].VehicleID != nil {
+		t.Errorf("off-shift driver was updated: %+v", store.drivers["d2"])
+	}
+	if code := put("d1", map[string]any{"status": StatusAvailable, "vehicle_id": "v1"}); code != http.StatusOK {
+		t.Fatalf("driver on shift: expected 200, got %d", code)
+	}
+	if v := store.drivers["d1"].VehicleID; v == nil || *v != "v1" {
+		t.Errorf("vehicle not recorded: %v", v)
+	}
+
+	// The recorded vehicle is checked when none is given, e.g. after handover.
+	svc.SetVehicles(fakeVehicles{"v1": "d2"})
+	if code := put("d1", map[string]any{"status": StatusOffline}); code != http.StatusOK {
+		t.Errorf("going offline: expected 200, got %d", code)
+	}
+	if code := put("d1", map[string]any{"status": StatusAvailable}); code != http.StatusForbidden {
+		t.Errorf("after handover: expected 403, got %d", code)
+	}
+	// A vehicle outside any fleet may be driven by anyone.
+	if code := put("d1", map[string]any{"status": StatusAvailable, "vehicle_id": "own-car"}); code != http.StatusOK {
+		t.Errorf("own vehicle: expected 200, got %d", code)
+	}
+}
@@ -14,11 +14,24 @@ const (
 	StatusOffline   = "offline"
 )
 
+// Driver tiers, set by ops; commission rules may be scoped to a tier.
+const (
+	TierStandard = "standard"
+	TierGold     = "gold"
+	TierPlatinum = "platinum"
+)
+
+// Tiers lists every accepted driver tier.
+var Tiers = []string{TierStandard, TierGold, TierPlatinum}
+
 var (
 	ErrNotFound   = errors.New("driver not found")
 	ErrBadRequest = errors.New("bad request")
 	ErrForbidden  = errors.New("forbidden")
 	ErrConflict   = errors.New("driver already exists")
+	// ErrVehicleNotAssigned is returned when a driver goes online with a fleet
+	// vehicle whose current shift is someone else's.
+	ErrVehicleNotAssigned = errors.New("vehicle is not assigned to this driver right now")
 )
 
 // Driver holds the driver-specific attributes associated with a user account.
@@ -29,4 +42,13 @@ type Driver struct {
 	Rating        float64
 	Status        string
 	OnboardedAt   time.Time
+	// Capabilities are order requirement flags the driver can serve (see order.Requirements).
+	Capabilities []string
+	Tier         string
+	// RegionID is the region the driver serves, set by ops; empty means the
+	// default region.
+	RegionID string
+	// WAVCertified is set by ops once the driver and vehicle are certified for
+	// wheelchair passengers; the wheelchair capability counts only then.
+	WAVCertified bool
 }
@@ -3,15 +3,31 @@ package driver
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"time"
 
 	"ark/internal/http/middleware"
+	"ark/internal/modules/order"
 	"ark/internal/types"
 )
 
+// Regions tells whether a region ID is configured. Implemented by region.Service.
+type Regions interface {
+	Exists(id string) bool
+}
+
+// Vehicles tells whether a driver may go online with a vehicle. Implemented
+// by vehicle.Service.
+type Vehicles interface {
+	MayDrive(ctx context.Context, vehicleID, driverID types.ID) (bool, error)
+}
+
 // Service implements driver-specific business operations.
 type Service struct {
-	store DriverStore
+	store    DriverStore
+	regions  Regions
+	vehicles Vehicles
 }
 
 func NewService(store DriverStore) *Service {
@@ -49,19 +65,143 @@ func (s *Service) UpdateRating(ctx context.Context, driverID types.ID, newRating
 	return s.store.UpdateRating(ctx, driverID, newRating)
 }
 
+// SetVehicles checks that a driver going online holds the vehicle's current
+// shift. Without it drivers go online with any vehicle.
+func (s *Service) SetVehicles(v Vehicles) {
+	s.vehicles = v
+}
+
 // UpdateStatus updates the authenticated driver's status using driver_id from the request context.
 // The update is protected by a row-level lock to prevent concurrent conflicting writes.
-func (s *Service) UpdateStatus(ctx context.Context, newStatus string) error {
+// A non-empty vehicleID records the vehicle the driver is driving; going
+// available with a fleet vehicle, given or recorded earlier, requires the
+// vehicle's current shift.
+func (s *Service) UpdateStatus(ctx context.Context, newStatus string, vehicleID types.ID) error {
 	driverID, ok := userIDFromCtx(ctx)
 	if !ok {
 		return ErrForbidden
 	}
-	if !isValidStatus(newStatus) {
+	if !isValidStatus(newStatus) || len(vehicleID) > 64 {
 		return ErrBadRequest
 	}
+	if newStatus == StatusAvailable && s.vehicles != nil {
+		vehicle := vehicleID
+		if vehicle == "" {
+			d, err := s.store.Get(ctx, driverID)
+			if err != nil {
+				return err
+			}
+			if d.VehicleID != nil {
+				vehicle = *d.VehicleID
+			}
+		}
+		if vehicle != "" {
+			ok, err := s.vehicles.MayDrive(ctx, vehicle, driverID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrVehicleNotAssigned
+			}
+		}
+	}
+	if vehicleID != "" {
+		if err := s.store.UpdateVehicle(ctx, driverID, vehicleID); err != nil {
+			return err
+		}
+	}
 	return s.store.UpdateStatusWithLock(ctx, driverID, newStatus)
 }
 
+// UpdateCapabilities replaces the authenticated driver's capability flags.
+// Every flag must be one of order.Requirements.
+func (s *Service) UpdateCapabilities(ctx context.Context, capabilities []string) ([]string, error) {
+	driverID, ok := userIDFromCtx(ctx)
+	if !ok {
+		return nil, ErrForbidden
+	}
+	caps, err := order.NormalizeRequirements(capabilities)
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	if err := s.store.UpdateCapabilities(ctx, driverID, caps); err != nil {
+		return nil, err
+	}
+	return caps, nil
+}
+
+// SetTier sets a driver's tier. Called from the ops API, so the driver is
+// named explicitly rather than taken from the context.
+func (s *Service) SetTier(ctx context.Context, driverID types.ID, tier string) error {
+	if driverID == "" || !slices.Contains(Tiers, tier) {
+		return ErrBadRequest
+	}
+	return s.store.UpdateTier(ctx, driverID, tier)
+}
+
+// SetWAVCertified records whether a driver is certified for wheelchair
+// passengers. Called from the ops API, like SetTier.
+func (s *Service) SetWAVCertified(ctx context.Context, driverID types.ID, certified bool) error {
+	if driverID == "" {
+		return ErrBadRequest
+	}
+	return s.store.UpdateWAVCertified(ctx, driverID, certified)
+}
+
+// SetRegions checks region assignments against the configured regions.
+// Without it any region ID is accepted.
+func (s *Service) SetRegions(r Regions) {
+	s.regions = r
+}
+
+// SetRegion assigns a driver to a region. Called from the ops API, like SetTier.
+func (s *Service) SetRegion(ctx context.Context, driverID types.ID, regionID string) error {
+	if driverID == "" || regionID == "" || len(regionID) > 32 {
+		return ErrBadRequest
+	}
+	if s.regions != nil && !s.regions.Exists(regionID) {
+		return ErrBadRequest
+	}
+	return s.store.UpdateRegion(ctx, driverID, regionID)
+}
+
+// DriverRegions returns the region of each driver among ids that has a
+// profile; "" stands for the default region. Called by the Matching module.
+func (s *Service) DriverRegions(ctx context.Context, ids []types.ID) (map[types.ID]string, error) {
+	if len(ids) == 0 {
+		return map[types.ID]string{}, nil
+	}
+	return s.store.Regions(ctx, ids)
+}
+
+// FilterCapable returns the drivers among ids that can serve every requirement.
+// Called by the Matching module before offering an order.
+func (s *Service) FilterCapable(ctx context.Context, ids []types.ID, requirements []string) ([]types.ID, error) {
+	if len(requirements) == 0 {
+		return ids, nil
+	}
+	return s.store.FilterCapable(ctx, ids, requirements)
+}
+
+// Capabilities returns the capability flags the driver can serve; a driver
+// without a profile has none, and the wheelchair flag needs WAV
+// certification. Called by the Order module when assigning a driver.
+func (s *Service) Capabilities(ctx context.Context, driverID types.ID) ([]string, error) {
+	d, err := s.store.Get(ctx, driverID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !d.WAVCertified {
+		return slices.DeleteFunc(slices.Clone(d.Capabilities), func(c string) bool {
+			return c == order.RequireWheelchair
+		}), nil
+	}
+	return d.Capabilities, nil
+}
+
 // DriverInfo returns a driver's profile by explicit driver_id. Called by the Order module.
 func (s *Service) DriverInfo(ctx context.Context, driverID types.ID) (*Driver, error) {
 	return s.store.Get(ctx, driverID)
@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"ark/internal/modules/order"
 	"ark/internal/types"
 )
 
@@ -19,6 +20,15 @@ type DriverStore interface {
 	Get(ctx context.Context, id types.ID) (*Driver, error)
 	UpdateRating(ctx context.Context, id types.ID, newRating float64) error
 	UpdateStatusWithLock(ctx context.Context, id types.ID, newStatus string) error
+	UpdateCapabilities(ctx context.Context, id types.ID, capabilities []string) error
+	UpdateTier(ctx context.Context, id types.ID, tier string) error
+	UpdateRegion(ctx context.Context, id types.ID, regionID string) error
+	UpdateWAVCertified(ctx context.Context, id types.ID, certified bool) error
+	UpdateVehicle(ctx context.Context, id types.ID, vehicleID types.ID) error
+	// Regions returns the region of each of ids that has a driver profile;
+	// "" for drivers in the default region.
+	Regions(ctx context.Context, ids []types.ID) (map[types.ID]string, error)
+	FilterCapable(ctx context.Context, ids []types.ID, required []string) ([]types.ID, error)
 }
 
 // Store is the PostgreSQL implementation of DriverStore.
@@ -48,12 +58,14 @@ func (s *Store) Create(ctx context.Context, d *Driver) error {
 
 func (s *Store) Get(ctx context.Context, id types.ID) (*Driver, error) {
 	row := s.db.QueryRow(ctx, `
-		SELECT driver_id, license_number, vehicle_id, rating, status, onboarded_at
+		SELECT driver_id, license_number, vehicle_id, rating, status, onboarded_at, capabilities, tier,
+		       COALESCE(region_id, ''), wav_certified
 		FROM drivers WHERE driver_id = $1`, string(id))
 
 	var d Driver
 	var vehicleID sql.NullString
-	err := row.Scan(&d.ID, &d.LicenseNumber, &vehicleID, &d.Rating, &d.Status, &d.OnboardedAt)
+	err := row.Scan(&d.ID, &d.LicenseNumber, &vehicleID, &d.Rating, &d.Status, &d.OnboardedAt, &d.Capabilities, &d.Tier,
+		&d.RegionID, &d.WAVCertified)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
@@ -107,6 +119,118 @@ func (s *Store) UpdateStatusWithLock(ctx context.Context, id types.ID, newStatus
 	return tx.Commit(ctx)
 }
 
+// UpdateTier sets the driver's tier.
+func (s *Store) UpdateTier(ctx context.Context, id types.ID, tier string) error {
+	tag, err := s.db.Exec(ctx, `UPDATE drivers SET tier = $1 WHERE driver_id = $2`, tier, string(id))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateWAVCertified sets whether the driver is certified for wheelchair passengers.
+func (s *Store) UpdateWAVCertified(ctx context.Context, id types.ID, certified bool) error {
+	tag, err := s.db.Exec(ctx, `UPDATE drivers SET wav_certified = $1 WHERE driver_id = $2`, certified, string(id))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateVehicle sets the vehicle the driver is driving.
+func (s *Store) UpdateVehicle(ctx context.Context, id types.ID, vehicleID types.ID) error {
+	tag, err := s.db.Exec(ctx, `UPDATE drivers SET vehicle_id = $1 WHERE driver_id = $2`, string(vehicleID), string(id))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateRegion sets the region the driver serves.
+func (s *Store) UpdateRegion(ctx context.Context, id types.ID, regionID string) error {
+	tag, err := s.db.Exec(ctx, `UPDATE drivers SET region_id = $1 WHERE driver_id = $2`, regionID, string(id))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) Regions(ctx context.Context, ids []types.ID) (map[types.ID]string, error) {
+	idStrs := make([]string, len(ids))
+	for i, id := range ids {
+		idStrs[i] = string(id)
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT driver_id, COALESCE(region_id, '') FROM drivers
+		WHERE driver_id = ANY($1)`, idStrs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[types.ID]string, len(ids))
+	for rows.Next() {
+		var id, regionID string
+		if err := rows.Scan(&id, &regionID); err != nil {
+			return nil, err
+		}
+		out[types.ID(id)] = regionID
+	}
+	return out, rows.Err()
+}
+
+// UpdateCapabilities replaces the driver's capability flags.
+func (s *Store) UpdateCapabilities(ctx context.Context, id types.ID, capabilities []string) error {
+	if capabilities == nil {
+		capabilities = []string{}
+	}
+	tag, err := s.db.Exec(ctx, `UPDATE drivers SET capabilities = $1 WHERE driver_id = $2`, capabilities, string(id))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FilterCapable returns the subset of ids whose capabilities include every
+// required flag; the wheelchair flag counts only for WAV-certified drivers.
+func (s *Store) FilterCapable(ctx context.Context, ids []types.ID, required []string) ([]types.ID, error) {
+	idStrs := make([]string, len(ids))
+	for i, id := range ids {
+		idStrs[i] = string(id)
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT driver_id FROM drivers
+		WHERE driver_id = ANY($1) AND capabilities @> $2
+		  AND (wav_certified OR NOT $3 = ANY($2))`, idStrs, required, order.RequireWheelchair)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []types.ID
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, types.ID(id))
+	}
+	return out, rows.Err()
+}
+
 func toStringPtr(id *types.ID) *string {
 	if id == nil {
 		return nil
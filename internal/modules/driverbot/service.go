@@ -0,0 +1,94 @@
+// README: Sandbox driver bot: accepts test-mode orders and walks them through pickup, trip and payment on a timer.
+package driverbot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/sandbox"
+	"ark/internal/types"
+)
+
+// Orders is the subset of order.Service the bot drives.
+type Orders interface {
+	Match(ctx context.Context, cmd order.MatchCommand) error
+	Arrive(ctx context.Context, cmd order.ArriveCommand) error
+	Meet(ctx context.Context, cmd order.MeetCommand) error
+	Complete(ctx context.Context, cmd order.CompleteCommand) error
+	Pay(ctx context.Context, cmd order.PayCommand) error
+}
+
+// Service plays the driver (and the payment provider) for sandbox orders.
+type Service struct {
+	orders Orders
+	step   time.Duration
+
+	mu     sync.Mutex
+	active map[types.ID]struct{}
+}
+
+// NewService returns a bot that waits step between successive order transitions.
+func NewService(orders Orders, step time.Duration) *Service {
+	return &Service{orders: orders, step: step, active: make(map[types.ID]struct{})}
+}
+
+// OrderHook starts a bot run whenever a sandbox instant order is waiting for a
+// driver, including after a rematch. Scheduled sandbox orders are not played.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if !t.Sandbox || t.To != order.StatusWaiting {
+			return
+		}
+		s.mu.Lock()
+		if _, running := s.active[t.OrderID]; running {
+			s.mu.Unlock()
+			return
+		}
+		s.active[t.OrderID] = struct{}{}
+		s.mu.Unlock()
+
+		go func() {
+			defer func() {
+				s.mu.Lock()
+				delete(s.active, t.OrderID)
+				s.mu.Unlock()
+			}()
+			ctx := sandbox.WithContext(context.WithoutCancel(ctx))
+			s.play(ctx, t.OrderID)
+		}()
+	}
+}
+
+// play runs the order from waiting to complete. It stops quietly when the
+// client moves the order out of the expected state (e.g. cancels it).
+func (s *Service) play(ctx context.Context, id types.ID) {
+	driverID := types.ID(sandbox.DriverID)
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"match", func() error {
+			return s.orders.Match(ctx, order.MatchCommand{OrderID: id, DriverID: driverID, MatchedAt: time.Now()})
+		}},
+		{"arrive", func() error { return s.orders.Arrive(ctx, order.ArriveCommand{OrderID: id}) }},
+		{"meet", func() error { return s.orders.Meet(ctx, order.MeetCommand{OrderID: id}) }},
+		{"complete", func() error { return s.orders.Complete(ctx, order.CompleteCommand{OrderID: id}) }},
+		{"pay", func() error { return s.orders.Pay(ctx, order.PayCommand{OrderID: id}) }},
+	}
+	for _, st := range steps {
+		time.Sleep(s.step)
+		if err := st.run(); err != nil {
+			if errors.Is(err, order.ErrInvalidState) || errors.Is(err, order.ErrConflict) {
+				log.Printf("driverbot: order %s left the bot's path before %s: %v", id, st.name, err)
+			} else {
+				log.Printf("driverbot: order %s %s: %v", id, st.name, err)
+			}
+			return
+		}
+	}
+	log.Printf("driverbot: order %s completed", id)
+}
@@ -0,0 +1,120 @@
+package driverbot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/sandbox"
+	"ark/internal/types"
+)
+
+type fakeOrders struct {
+	mu       sync.Mutex
+	calls    []string
+	failAt   string
+	sandbox  []bool
+	driverID types.ID
+	done     chan struct{}
+}
+
+func (f *fakeOrders) record(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, name)
+	f.sandbox = append(f.sandbox, sandbox.Enabled(ctx))
+	if name == f.failAt {
+		close(f.done)
+		return order.ErrInvalidState
+	}
+	if name == "pay" {
+		close(f.done)
+	}
+	return nil
+}
+
+func (f *fakeOrders) Match(ctx context.Context, cmd order.MatchCommand) error {
+	f.mu.Lock()
+	f.driverID = cmd.DriverID
+	f.mu.Unlock()
+	return f.record(ctx, "match")
+}
+func (f *fakeOrders) Arrive(ctx context.Context, _ order.ArriveCommand) error {
+	return f.record(ctx, "arrive")
+}
+func (f *fakeOrders) Meet(ctx context.Context, _ order.MeetCommand) error {
+	return f.record(ctx, "meet")
+}
+func (f *fakeOrders) Complete(ctx context.Context, _ order.CompleteCommand) error {
+	return f.record(ctx, "complete")
+}
+func (f *fakeOrders) Pay(ctx context.Context, _ order.PayCommand) error {
+	return f.record(ctx, "pay")
+}
+
+func waitDone(t *testing.T, ch chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("bot did not finish")
+	}
+}
+
+func TestOrderHook_PlaysSandboxOrderToCompletion(t *testing.T) {
+	f := &fakeOrders{done: make(chan struct{})}
+	hook := NewService(f, time.Millisecond).OrderHook()
+
+	hook(context.Background(), order.Transition{OrderID: "o1", From: order.StatusNone, To: order.StatusWaiting, Sandbox: true})
+	waitDone(t, f.done)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	want := []string{"match", "arrive", "meet", "complete", "pay"}
+	if len(f.calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", f.calls, want)
+	}
+	for i := range want {
+		if f.calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", f.calls, want)
+		}
+		if !f.sandbox[i] {
+			t.Errorf("%s ran without the sandbox context", want[i])
+		}
+	}
+	if f.driverID != sandbox.DriverID {
+		t.Errorf("matched driver = %q", f.driverID)
+	}
+}
+
+func TestOrderHook_StopsWhenOrderLeavesPath(t *testing.T) {
+	f := &fakeOrders{done: make(chan struct{}), failAt: "arrive"}
+	hook := NewService(f, time.Millisecond).OrderHook()
+
+	hook(context.Background(), order.Transition{OrderID: "o1", To: order.StatusWaiting, Sandbox: true})
+	waitDone(t, f.done)
+	time.Sleep(10 * time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.calls) != 2 {
+		t.Fatalf("calls = %v; bot should stop after the failed step", f.calls)
+	}
+}
+
+func TestOrderHook_IgnoresRealOrders(t *testing.T) {
+	f := &fakeOrders{done: make(chan struct{})}
+	hook := NewService(f, time.Millisecond).OrderHook()
+
+	hook(context.Background(), order.Transition{OrderID: "o1", To: order.StatusWaiting})
+	hook(context.Background(), order.Transition{OrderID: "o2", To: order.StatusArrived, Sandbox: true})
+	time.Sleep(20 * time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.calls) != 0 {
+		t.Fatalf("bot touched non-waiting or real orders: %v", f.calls)
+	}
+}
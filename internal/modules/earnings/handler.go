@@ -0,0 +1,76 @@
+// README: Earnings HTTP handlers — a driver's earnings balance and ledger.
+//
+// Endpoints:
+//
+//	GET /api/driver/earnings — balance and recent ledger entries (driver_id from context)
+//
+// Auth: the Auth middleware must set user_id in context.
+package earnings
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the earnings HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type entryResp struct {
+	Amount    int64  `json:"amount"`
+	Kind      string `json:"kind"`
+	Ref       string `json:"ref"`
+	Note      string `json:"note,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	// Trip entries show the fare and the platform commission taken from it.
+	Gross         int64 `json:"gross"`
+	Commission    int64 `json:"commission"`
+	CommissionBps int   `json:"commission_bps"`
+}
+
+// Summary handles GET /api/driver/earnings.
+func (h *Handler) Summary(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	bal, entries, err := h.svc.Summary(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		if errors.Is(err, ErrBadRequest) {
+			writeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "internal error")
+		return
+	}
+	out := make([]entryResp, len(entries))
+	for i, e := range entries {
+		out[i] = entryResp{
+			Amount:        e.Amount,
+			Kind:          e.Kind,
+			Ref:           e.Ref,
+			Note:          e.Note,
+			CreatedAt:     e.CreatedAt.Unix(),
+			Gross:         e.Gross,
+			Commission:    e.Commission,
+			CommissionBps: e.CommissionBps,
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{"balance": bal, "currency": types.DefaultCurrency, "entries": out})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
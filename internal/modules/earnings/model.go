@@ -0,0 +1,42 @@
+// README: Earnings domain model — the driver earnings ledger.
+package earnings
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Entry kinds.
+const (
+	KindTrip  = "trip"
+	KindQuest = "quest"
+	// KindIncentive is an order's scheduled-ride incentive bonus, paid on
+	// top of the trip's net fare with no commission taken.
+	KindIncentive = "incentive"
+	// KindCancelFee is the driver's share of a passenger's cancellation fee,
+	// credited by order.Store.CancelCharged in the cancel's transaction.
+	KindCancelFee = "cancel_fee"
+)
+
+// Entry is one line of a driver's earnings ledger, in TWD minor units.
+// (DriverID, Kind, Ref) identifies it: crediting the same entry twice is a no-op.
+type Entry struct {
+	DriverID  types.ID
+	Amount    int64
+	Kind      string
+	Ref       string
+	Note      string
+	CreatedAt time.Time
+
+	// Gross, Commission and CommissionBps record the split a trip was paid
+	// under (Amount = Gross - Commission); CommissionRule is the rule applied,
+	// nil for the default rate. Other kinds have Gross = Amount and no commission.
+	Gross          int64
+	Commission     int64
+	CommissionBps  int
+	CommissionRule *types.ID
+}
+
+var ErrBadRequest = errors.New("bad request")
@@ -0,0 +1,11 @@
+// README: Earnings route registration — mounts the driver earnings endpoint onto the given router group.
+package earnings
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the earnings endpoints onto the provided authenticated router group.
+//
+//	GET /api/driver/earnings
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/driver/earnings", h.Summary)
+}
@@ -0,0 +1,68 @@
+// README: Earnings service — credits money owed to drivers and reports their balance.
+package earnings
+
+import (
+	"context"
+	"time"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// maxEntries caps the ledger lines returned with a balance.
+const maxEntries = 100
+
+// Service records driver earnings.
+type Service struct {
+	store EarningsStore
+	now   func() time.Time
+}
+
+// NewService creates a Service backed by store.
+func NewService(store EarningsStore) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// Credit adds e to the driver's ledger, stamping CreatedAt when unset. An
+// entry needs a non-zero amount or gross (a fully commissioned trip nets
+// zero). It reports false when the same entry was already credited.
+//
+// Entries other than trips are booked in the double-entry ledger as
+// platform-funded incentives; trips are booked with their fare by TripHook.
+func (s *Service) Credit(ctx context.Context, e Entry) (bool, error) {
+	if e.Kind == KindTrip {
+		return false, ErrBadRequest
+	}
+	return s.credit(ctx, e, func(e *Entry) *ledger.Txn {
+		return ledger.IncentiveTxn(e.DriverID, e.Amount, e.Kind+":"+e.Ref, e.Note, e.CreatedAt)
+	})
+}
+
+func (s *Service) credit(ctx context.Context, e Entry, txn func(e *Entry) *ledger.Txn) (bool, error) {
+	if e.DriverID == "" || e.Kind == "" || e.Ref == "" || (e.Amount == 0 && e.Gross == 0) {
+		return false, ErrBadRequest
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = s.now()
+	}
+	if e.Gross == 0 && e.Commission == 0 {
+		e.Gross = e.Amount
+	}
+	return s.store.Credit(ctx, &e, txn(&e))
+}
+
+// Summary returns the driver's balance and latest ledger entries.
+func (s *Service) Summary(ctx context.Context, driverID types.ID) (int64, []Entry, error) {
+	if driverID == "" {
+		return 0, nil, ErrBadRequest
+	}
+	bal, err := s.store.Balance(ctx, driverID)
+	if err != nil {
+		return 0, nil, err
+	}
+	entries, err := s.store.List(ctx, driverID, maxEntries)
+	if err != nil {
+		return 0, nil, err
+	}
+	return bal, entries, nil
+}
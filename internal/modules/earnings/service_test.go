@@ -0,0 +1,69 @@
+package earnings
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	entries []Entry
+	txns    []*ledger.Txn
+}
+
+func (m *mockStore) Credit(_ context.Context, e *Entry, t *ledger.Txn) (bool, error) {
+	for _, x := range m.entries {
+		if x.DriverID == e.DriverID && x.Kind == e.Kind && x.Ref == e.Ref {
+			return false, nil
+		}
+	}
+	if err := t.Validate(); err != nil {
+		return false, err
+	}
+	m.entries = append(m.entries, *e)
+	m.txns = append(m.txns, t)
+	return true, nil
+}
+
+func (m *mockStore) Balance(_ context.Context, driverID types.ID) (int64, error) {
+	var bal int64
+	for _, e := range m.entries {
+		if e.DriverID == driverID {
+			bal += e.Amount
+		}
+	}
+	return bal, nil
+}
+
+func (m *mockStore) List(_ context.Context, driverID types.ID, limit int) ([]Entry, error) {
+	var out []Entry
+	for _, e := range m.entries {
+		if e.DriverID == driverID && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestCredit_Idempotent(t *testing.T) {
+	svc := NewService(&mockStore{})
+	ctx := context.Background()
+	e := Entry{DriverID: "drv", Amount: 50000, Kind: KindQuest, Ref: "c1"}
+
+	for i, want := range []bool{true, false} {
+		ok, err := svc.Credit(ctx, e)
+		if err != nil || ok != want {
+			t.Fatalf("Credit %d = %v, %v; want %v", i, ok, err, want)
+		}
+	}
+	bal, entries, err := svc.Summary(ctx, "drv")
+	if err != nil || bal != 50000 || len(entries) != 1 || entries[0].CreatedAt.IsZero() {
+		t.Errorf("Summary = %d, %+v, %v; want one stamped 50000 entry", bal, entries, err)
+	}
+	if _, err := svc.Credit(ctx, Entry{DriverID: "drv", Kind: KindQuest, Ref: "c2"}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("zero amount: got %v, want ErrBadRequest", err)
+	}
+}
@@ -0,0 +1,95 @@
+// README: Earnings store — PostgreSQL persistence for the driver_earnings ledger.
+package earnings
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// EarningsStore defines the persistence operations required by the earnings Service.
+type EarningsStore interface {
+	// Credit inserts e together with its ledger transaction t and reports
+	// false when an entry with the same driver, kind and ref already exists.
+	Credit(ctx context.Context, e *Entry, t *ledger.Txn) (bool, error)
+	Balance(ctx context.Context, driverID types.ID) (int64, error)
+	List(ctx context.Context, driverID types.ID, limit int) ([]Entry, error)
+}
+
+// Store is the PostgreSQL implementation of EarningsStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Credit(ctx context.Context, e *Entry, t *ledger.Txn) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO driver_earnings (driver_id, amount, kind, ref, note, created_at, gross, commission, commission_bps, commission_rule)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (driver_id, kind, ref) DO NOTHING`,
+		string(e.DriverID), e.Amount, e.Kind, e.Ref, e.Note, e.CreatedAt,
+		e.Gross, e.Commission, e.CommissionBps, (*string)(e.CommissionRule),
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if _, err := ledger.PostTx(ctx, tx, t); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (s *Store) Balance(ctx context.Context, driverID types.ID) (int64, error) {
+	var bal int64
+	err := s.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM driver_earnings WHERE driver_id = $1`,
+		string(driverID),
+	).Scan(&bal)
+	return bal, err
+}
+
+func (s *Store) List(ctx context.Context, driverID types.ID, limit int) ([]Entry, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT driver_id, amount, kind, ref, note, created_at, gross, commission, commission_bps, commission_rule
+		FROM driver_earnings
+		WHERE driver_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2`,
+		string(driverID), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Entry
+	for rows.Next() {
+		var e Entry
+		var did string
+		var rule *string
+		if err := rows.Scan(&did, &e.Amount, &e.Kind, &e.Ref, &e.Note, &e.CreatedAt,
+			&e.Gross, &e.Commission, &e.CommissionBps, &rule); err != nil {
+			return nil, err
+		}
+		e.DriverID = types.ID(did)
+		if rule != nil {
+			id := types.ID(*rule)
+			e.CommissionRule = &id
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
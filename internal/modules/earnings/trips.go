@@ -0,0 +1,157 @@
+// README: Trip earnings — credits the driver's share of each completed fare at the commission rate in force then.
+package earnings
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ark/internal/modules/commission"
+	"ark/internal/modules/ledger"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// tripTimeout bounds crediting one trip from an order transition.
+const tripTimeout = 15 * time.Second
+
+// Orders is the subset of order.Service used to read completed fares.
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// Commission resolves the platform's cut of a fare.
+type Commission interface {
+	Resolve(ctx context.Context, driverID types.ID, at time.Time, gross int64) (commission.Quote, error)
+}
+
+// TripHook credits the driver's net fare and the order's incentive bonus when
+// an order completes. The commission is resolved at completion time and stored
+// on the entry. Sandbox rides earn nothing.
+func (s *Service) TripHook(orders Orders, rates Commission) order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusComplete || t.Sandbox || t.DriverID == nil {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tripTimeout)
+			defer cancel()
+			if err := s.creditTrip(ctx, orders, rates, *t.DriverID, t.OrderID, t.At); err != nil {
+				log.Printf("earnings: credit trip %s for %s: %v", t.OrderID, *t.DriverID, err)
+			}
+		}()
+	}
+}
+
+// tripSplit resolves the commission on o's fare for driverID at `at`. Trip
+// settlement and payout estimates both go through it so drivers are shown
+// what they are later paid.
+func tripSplit(ctx context.Context, rates Commission, driverID types.ID, o *order.Order, at time.Time) (commission.Quote, error) {
+	// Earnings and the ledger are kept in the platform currency only.
+	if c := o.Fare().Currency; c != types.DefaultCurrency {
+		return commission.Quote{}, fmt.Errorf("fare in %s: %w", c, types.ErrCurrencyMismatch)
+	}
+	// The passenger's ride credits and plan benefits are platform-funded, so
+	// the driver's share is taken from the full fare. A designated driver's
+	// return allowance reimburses their transit back and is paid without
+	// commission.
+	fare := o.Fare().Amount
+	allowance := min(max(o.ReturnAllowance, 0), fare)
+	q, err := rates.Resolve(ctx, driverID, at, fare-allowance)
+	if err != nil {
+		return commission.Quote{}, err
+	}
+	q.Gross += allowance
+	q.Net += allowance
+	return q, nil
+}
+
+func (s *Service) creditTrip(ctx context.Context, orders Orders, rates Commission, driverID, orderID types.ID, at time.Time) error {
+	o, err := orders.Get(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	q, err := tripSplit(ctx, rates, driverID, o, at)
+	if err != nil {
+		return err
+	}
+	_, err = s.credit(ctx, Entry{
+		DriverID:       driverID,
+		Amount:         q.Net,
+		Kind:           KindTrip,
+		Ref:            string(orderID),
+		CreatedAt:      at,
+		Gross:          q.Gross,
+		Commission:     q.Commission,
+		CommissionBps:  q.RateBps,
+		CommissionRule: q.RuleID,
+	}, func(e *Entry) *ledger.Txn {
+		return ledger.RideTxn(ledger.Ride{
+			OrderID:      o.ID,
+			PassengerID:  o.PassengerID,
+			DriverID:     driverID,
+			OrgID:        o.OrgID,
+			Fare:         q.Gross,
+			Credits:      o.CreditsApplied,
+			Subscription: o.SubscriptionApplied,
+			Commission:   q.Commission,
+			At:           e.CreatedAt,
+		})
+	})
+	if err != nil || o.IncentiveBonus <= 0 {
+		return err
+	}
+	_, err = s.Credit(ctx, Entry{
+		DriverID:  driverID,
+		Amount:    o.IncentiveBonus,
+		Kind:      KindIncentive,
+		Ref:       string(orderID),
+		Note:      "scheduled ride incentive",
+		CreatedAt: at,
+	})
+	return err
+}
+
+// Payouts estimates what a driver would earn from an order, using the split
+// trip settlement pays. It implements order.PayoutEstimator.
+type Payouts struct {
+	rates Commission
+}
+
+// NewPayouts creates a Payouts resolving commission with rates.
+func NewPayouts(rates Commission) *Payouts {
+	return &Payouts{rates: rates}
+}
+
+// EstimatePayout splits o's current fare as if driverID completed it at its
+// scheduled pickup time (now for instant orders) and adds the incentive bonus.
+func (p *Payouts) EstimatePayout(ctx context.Context, driverID types.ID, o *order.Order) (order.PayoutEstimate, error) {
+	at := time.Now()
+	if o.ScheduledAt != nil && o.ScheduledAt.After(at) {
+		at = *o.ScheduledAt
+	}
+	q, err := tripSplit(ctx, p.rates, driverID, o, at)
+	if err != nil {
+		return order.PayoutEstimate{}, err
+	}
+	return order.PayoutEstimate{
+		Fare:          q.Gross,
+		Commission:    q.Commission,
+		CommissionBps: q.RateBps,
+		Incentive:     o.IncentiveBonus,
+		Net:           q.Net + o.IncentiveBonus,
+		Currency:      o.Fare().Currency,
+	}, nil
+}
+
+// SplitCancellationFee takes the platform's commission from a cancellation
+// fee at the rate driverID's fares are split at. It implements
+// order.CancellationCommission.
+func (p *Payouts) SplitCancellationFee(ctx context.Context, driverID types.ID, fee int64, at time.Time) (order.FeeSplit, error) {
+	q, err := p.rates.Resolve(ctx, driverID, at, fee)
+	if err != nil {
+		return order.FeeSplit{}, err
+	}
+	return order.FeeSplit{Commission: q.Commission, CommissionBps: q.RateBps, CommissionRule: q.RuleID}, nil
+}
@@ -0,0 +1,135 @@
+package earnings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/commission"
+	"ark/internal/modules/ledger"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type fakeOrders map[types.ID]*order.Order
+
+func (f fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	return f[id], nil
+}
+
+type fakeRates struct{ bps int }
+
+func (f fakeRates) Resolve(_ context.Context, _ types.ID, _ time.Time, gross int64) (commission.Quote, error) {
+	c := gross * int64(f.bps) / 10000
+	rule := types.ID("r1")
+	return commission.Quote{Gross: gross, Commission: c, Net: gross - c, RateBps: f.bps, RuleID: &rule}, nil
+}
+
+func TestCreditTrip_RecordsCommission(t *testing.T) {
+	store := &mockStore{}
+	svc := NewService(store)
+	actual := types.Money{Amount: 18000, Currency: "TWD"}
+	orders := fakeOrders{"o1": {ID: "o1", PassengerID: "pax", EstimatedFee: types.Money{Amount: 15000}, ActualFee: &actual, CreditsApplied: 5000}}
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if err := svc.creditTrip(context.Background(), orders, fakeRates{bps: 2000}, "drv", "o1", at); err != nil {
+			t.Fatalf("creditTrip %d: %v", i, err)
+		}
+	}
+	if len(store.entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(store.entries))
+	}
+	e := store.entries[0]
+	if e.Kind != KindTrip || e.Gross != 18000 || e.Commission != 3600 || e.Amount != 14400 || e.CommissionBps != 2000 {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.CommissionRule == nil || *e.CommissionRule != "r1" || !e.CreatedAt.Equal(at) {
+		t.Errorf("entry rule/time = %v / %v", e.CommissionRule, e.CreatedAt)
+	}
+
+	// The ride is booked with the driver's net, the commission, and the
+	// fare settled by card and credits.
+	txn := store.txns[0]
+	want := map[string]int64{
+		ledger.DriverAccount("drv"):    -14400,
+		ledger.PlatformCommission:      -3600,
+		ledger.ProviderCard:            13000,
+		ledger.CreditsAccount("pax"):   5000,
+		ledger.PassengerAccount("pax"): 0,
+	}
+	got := make(map[string]int64)
+	for _, p := range txn.Postings {
+		got[p.Account] += p.Amount
+	}
+	for acct, amt := range want {
+		if got[acct] != amt {
+			t.Errorf("%s = %d, want %d (postings %+v)", acct, got[acct], amt, txn.Postings)
+		}
+	}
+}
+
+func TestCreditTrip_PaysIncentiveBonus(t *testing.T) {
+	store := &mockStore{}
+	svc := NewService(store)
+	orders := fakeOrders{"o2": {ID: "o2", PassengerID: "pax", EstimatedFee: types.Money{Amount: 20000, Currency: "TWD"}, IncentiveBonus: 75}}
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if err := svc.creditTrip(context.Background(), orders, fakeRates{bps: 2000}, "drv", "o2", at); err != nil {
+			t.Fatalf("creditTrip %d: %v", i, err)
+		}
+	}
+	if len(store.entries) != 2 {
+		t.Fatalf("entries = %+v, want trip and incentive", store.entries)
+	}
+	bonus := store.entries[1]
+	if bonus.Kind != KindIncentive || bonus.Ref != "o2" || bonus.Amount != 75 || bonus.Commission != 0 {
+		t.Errorf("incentive entry = %+v", bonus)
+	}
+
+	// The estimate shown while browsing is what settlement credited.
+	est, err := NewPayouts(fakeRates{bps: 2000}).EstimatePayout(context.Background(), "drv", orders["o2"])
+	if err != nil {
+		t.Fatalf("EstimatePayout: %v", err)
+	}
+	if bal, _ := store.Balance(context.Background(), "drv"); est.Net != bal {
+		t.Errorf("estimated net %d, credited %d", est.Net, bal)
+	}
+	if est.Fare != 20000 || est.Commission != 4000 || est.Incentive != 75 || est.Net != 16075 || est.Currency != "TWD" {
+		t.Errorf("estimate = %+v", est)
+	}
+}
+
+func TestCreditTrip_ReturnAllowanceFreeOfCommission(t *testing.T) {
+	store := &mockStore{}
+	svc := NewService(store)
+	orders := fakeOrders{"o3": {ID: "o3", PassengerID: "pax", RideType: order.RideTypeDesignated, EstimatedFee: types.Money{Amount: 60000, Currency: "TWD"}, ReturnAllowance: 10000}}
+	at := time.Date(2026, 6, 1, 23, 0, 0, 0, time.UTC)
+
+	if err := svc.creditTrip(context.Background(), orders, fakeRates{bps: 2000}, "drv", "o3", at); err != nil {
+		t.Fatalf("creditTrip: %v", err)
+	}
+	e := store.entries[0]
+	if e.Gross != 60000 || e.Commission != 10000 || e.Amount != 50000 {
+		t.Errorf("entry = %+v, want gross 60000, commission 10000, net 50000", e)
+	}
+}
+
+func TestEstimatePayout_RejectsForeignCurrency(t *testing.T) {
+	o := &order.Order{ID: "o3", EstimatedFee: types.Money{Amount: 3000, Currency: "JPY"}}
+	if _, err := NewPayouts(fakeRates{bps: 2000}).EstimatePayout(context.Background(), "drv", o); !errors.Is(err, types.ErrCurrencyMismatch) {
+		t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+	}
+}
+
+func TestSplitCancellationFee_UsesFareRate(t *testing.T) {
+	split, err := NewPayouts(fakeRates{bps: 2000}).SplitCancellationFee(context.Background(), "drv", 10000, time.Now())
+	if err != nil {
+		t.Fatalf("SplitCancellationFee: %v", err)
+	}
+	if split.Commission != 2000 || split.CommissionBps != 2000 || split.CommissionRule == nil || *split.CommissionRule != "r1" {
+		t.Errorf("split = %+v, want 2000 at 2000 bps under r1", split)
+	}
+}
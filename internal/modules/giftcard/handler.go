@@ -0,0 +1,168 @@
+// README: Gift card HTTP handlers — redeeming a code into ride credits, and ops batch generation.
+//
+// Endpoints:
+//
+//	POST /api/gift-cards/redeem                  — redeem a code ({"code"}) into the caller's ride credits
+//	POST /api/ops/gift-card-batches              — generate a batch of codes (ops key)
+//	GET  /api/ops/gift-card-batches              — every batch with its redemption count (ops key)
+//	GET  /api/ops/gift-card-batches/:id/codes    — a batch's codes and their redemptions (ops key)
+//
+// Auth: the redeem route requires the Auth middleware to set user_id in
+// context; ops routes require the ops key middleware instead.
+package giftcard
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the gift card HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type redeemReq struct {
+	Code string `json:"code"`
+}
+
+type createBatchReq struct {
+	Name           string `json:"name"`
+	Amount         int64  `json:"amount"`
+	Count          int    `json:"count"`
+	MaxRedemptions int    `json:"max_redemptions"`
+	Funding        string `json:"funding"`
+	ExpiresAt      int64  `json:"expires_at"`
+}
+
+type batchResp struct {
+	ID             string `json:"batch_id"`
+	Name           string `json:"name"`
+	Amount         int64  `json:"amount"`
+	Currency       string `json:"currency"`
+	Count          int    `json:"count"`
+	MaxRedemptions int    `json:"max_redemptions"`
+	Funding        string `json:"funding"`
+	ExpiresAt      int64  `json:"expires_at"`
+	CreatedAt      int64  `json:"created_at"`
+	Redeemed       int    `json:"redeemed"`
+}
+
+type cardResp struct {
+	Code        string `json:"code"`
+	Redemptions int    `json:"redemptions"`
+}
+
+func toBatchResp(b *Batch) batchResp {
+	return batchResp{
+		ID:             string(b.ID),
+		Name:           b.Name,
+		Amount:         b.Amount,
+		Currency:       types.DefaultCurrency,
+		Count:          b.Count,
+		MaxRedemptions: b.MaxRedemptions,
+		Funding:        b.Funding,
+		ExpiresAt:      b.ExpiresAt.Unix(),
+		CreatedAt:      b.CreatedAt.Unix(),
+		Redeemed:       b.Redeemed,
+	}
+}
+
+// Redeem handles POST /api/gift-cards/redeem.
+func (h *Handler) Redeem(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req redeemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	amount, err := h.svc.Redeem(c.Request.Context(), types.ID(uid), req.Code)
+	if err != nil {
+		writeGiftCardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"credited": amount, "currency": types.DefaultCurrency})
+}
+
+// CreateBatch handles POST /api/ops/gift-card-batches.
+func (h *Handler) CreateBatch(c *gin.Context) {
+	var req createBatchReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	b, codes, err := h.svc.CreateBatch(c.Request.Context(), Batch{
+		Name:           req.Name,
+		Amount:         req.Amount,
+		Count:          req.Count,
+		MaxRedemptions: req.MaxRedemptions,
+		Funding:        req.Funding,
+		ExpiresAt:      time.Unix(req.ExpiresAt, 0),
+	})
+	if err != nil {
+		writeGiftCardError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, map[string]any{"batch": toBatchResp(b), "codes": codes})
+}
+
+// ListBatches handles GET /api/ops/gift-card-batches.
+func (h *Handler) ListBatches(c *gin.Context) {
+	bs, err := h.svc.Batches(c.Request.Context())
+	if err != nil {
+		writeGiftCardError(c, err)
+		return
+	}
+	out := make([]batchResp, len(bs))
+	for i := range bs {
+		out[i] = toBatchResp(&bs[i])
+	}
+	c.JSON(http.StatusOK, map[string]any{"batches": out})
+}
+
+// Codes handles GET /api/ops/gift-card-batches/:id/codes.
+func (h *Handler) Codes(c *gin.Context) {
+	cards, err := h.svc.Codes(c.Request.Context(), types.ID(c.Param("id")))
+	if err != nil {
+		writeGiftCardError(c, err)
+		return
+	}
+	out := make([]cardResp, len(cards))
+	for i, card := range cards {
+		out[i] = cardResp{Code: card.Code, Redemptions: card.Redemptions}
+	}
+	c.JSON(http.StatusOK, map[string]any{"codes": out})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeGiftCardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrUsed):
+		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrExpired):
+		writeError(c, http.StatusGone, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,86 @@
+// README: Gift card domain model — code batches, their funding and limits, and redemptions into ride credits.
+package giftcard
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// Batch funding: prepaid cards were sold to customers, promotional codes are
+// paid for by marketing. The ledger books each redemption against a
+// different platform account.
+const (
+	FundingPrepaid     = "prepaid"
+	FundingPromotional = "promotional"
+)
+
+// Batch limits.
+const (
+	// MaxBatchSize caps the codes generated in one batch.
+	MaxBatchSize = 10000
+	// maxRedemptionsPerCode caps how often one shared marketing code may be
+	// redeemed.
+	maxRedemptionsPerCode = 100000
+)
+
+// Batch is a set of codes generated together, each worth Amount (TWD minor
+// units) of ride credit.
+type Batch struct {
+	ID     types.ID
+	Name   string
+	Amount int64
+	Count  int
+	// MaxRedemptions is how many users may redeem each code: 1 for gift
+	// cards, more for a marketing code shared publicly. A user redeems at
+	// most one code per batch either way.
+	MaxRedemptions int
+	Funding        string
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+	// Redeemed counts redemptions across the batch, filled in for listings.
+	Redeemed int
+}
+
+func (b *Batch) validate(now time.Time) error {
+	if b.Name == "" || b.Amount <= 0 || b.Count <= 0 || b.Count > MaxBatchSize {
+		return ErrBadRequest
+	}
+	if b.MaxRedemptions <= 0 || b.MaxRedemptions > maxRedemptionsPerCode {
+		return ErrBadRequest
+	}
+	if b.Funding != FundingPrepaid && b.Funding != FundingPromotional {
+		return ErrBadRequest
+	}
+	if !b.ExpiresAt.After(now) {
+		return ErrBadRequest
+	}
+	return nil
+}
+
+// source is the platform account that pays for the batch's credit.
+func (b *Batch) source() string {
+	if b.Funding == FundingPrepaid {
+		return ledger.PlatformGiftCards
+	}
+	return ledger.PlatformPromotions
+}
+
+// Card is one code of a batch.
+type Card struct {
+	Code        string
+	BatchID     types.ID
+	Redemptions int
+}
+
+var (
+	ErrNotFound   = errors.New("gift card not found")
+	ErrBadRequest = errors.New("bad request")
+	// ErrExpired is returned for a code past its batch's expiry.
+	ErrExpired = errors.New("gift card expired")
+	// ErrUsed is returned when a code has no redemptions left or the user
+	// already redeemed a code from its batch.
+	ErrUsed = errors.New("gift card already redeemed")
+)
@@ -0,0 +1,22 @@
+// README: Gift card route registration — mounts the redeem and ops batch endpoints.
+package giftcard
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the redeem endpoint onto the provided authenticated router group.
+//
+//	POST /api/gift-cards/redeem
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.POST("/api/gift-cards/redeem", h.Redeem)
+}
+
+// RegisterOpsRoutes mounts the batch endpoints onto the provided ops router group.
+//
+//	POST /api/ops/gift-card-batches
+//	GET  /api/ops/gift-card-batches
+//	GET  /api/ops/gift-card-batches/:id/codes
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.POST("/api/ops/gift-card-batches", h.CreateBatch)
+	rg.GET("/api/ops/gift-card-batches", h.ListBatches)
+	rg.GET("/api/ops/gift-card-batches/:id/codes", h.Codes)
+}
@@ -0,0 +1,92 @@
+// README: Gift card service — batch code generation for marketing and retail, and redeeming a code into the caller's ride credits.
+package giftcard
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"ark/internal/types"
+)
+
+// codeAlphabet leaves out 0/O and 1/I/L so codes survive being read aloud
+// or printed on a card.
+const codeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// codeLen gives about 59 bits per code, far beyond guessing a live one.
+const codeLen = 12
+
+// Service issues gift card batches and redeems their codes.
+type Service struct {
+	store GiftCardStore
+	now   func() time.Time
+}
+
+// NewService creates a Service backed by store.
+func NewService(store GiftCardStore) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// CreateBatch generates b.Count codes for b and returns them.
+func (s *Service) CreateBatch(ctx context.Context, b Batch) (*Batch, []string, error) {
+	now := s.now()
+	if err := b.validate(now); err != nil {
+		return nil, nil, err
+	}
+	b.ID, b.CreatedAt = types.NewID(), now
+	codes := make([]string, 0, b.Count)
+	seen := make(map[string]bool, b.Count)
+	for len(codes) < b.Count {
+		c := newCode()
+		if !seen[c] {
+			seen[c] = true
+			codes = append(codes, c)
+		}
+	}
+	if err := s.store.CreateBatch(ctx, &b, codes); err != nil {
+		return nil, nil, err
+	}
+	return &b, codes, nil
+}
+
+// Batches returns every batch with its redemption count, newest first.
+func (s *Service) Batches(ctx context.Context) ([]Batch, error) {
+	return s.store.ListBatches(ctx)
+}
+
+// Codes returns a batch's codes for printing or distribution.
+func (s *Service) Codes(ctx context.Context, batchID types.ID) ([]Card, error) {
+	if _, err := s.store.GetBatch(ctx, batchID); err != nil {
+		return nil, err
+	}
+	return s.store.ListCards(ctx, batchID)
+}
+
+// Redeem credits userID with the value of code and returns the amount.
+// Codes are matched case-insensitively, ignoring spaces and dashes.
+func (s *Service) Redeem(ctx context.Context, userID types.ID, code string) (int64, error) {
+	code = normalizeCode(code)
+	if userID == "" || code == "" {
+		return 0, ErrBadRequest
+	}
+	return s.store.Redeem(ctx, userID, code, s.now())
+}
+
+func normalizeCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+func newCode() string {
+	b := make([]byte, codeLen)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
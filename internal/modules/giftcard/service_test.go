@@ -0,0 +1,182 @@
+package giftcard
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// mockStore keeps batches and codes in memory and applies the same limits as
+// the SQL store.
+type mockStore struct {
+	batches  map[types.ID]*Batch
+	cards    map[string]*Card
+	redeemed map[string]bool // batch ID + "/" + user ID
+	credits  map[types.ID]int64
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{
+		batches:  make(map[types.ID]*Batch),
+		cards:    make(map[string]*Card),
+		redeemed: make(map[string]bool),
+		credits:  make(map[types.ID]int64),
+	}
+}
+
+func (m *mockStore) CreateBatch(_ context.Context, b *Batch, codes []string) error {
+	cp := *b
+	m.batches[b.ID] = &cp
+	for _, c := range codes {
+		m.cards[c] = &Card{Code: c, BatchID: b.ID}
+	}
+	return nil
+}
+
+func (m *mockStore) GetBatch(_ context.Context, id types.ID) (*Batch, error) {
+	b, ok := m.batches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return b, nil
+}
+
+func (m *mockStore) ListBatches(context.Context) ([]Batch, error) {
+	var out []Batch
+	for _, b := range m.batches {
+		out = append(out, *b)
+	}
+	return out, nil
+}
+
+func (m *mockStore) ListCards(_ context.Context, batchID types.ID) ([]Card, error) {
+	var out []Card
+	for _, c := range m.cards {
+		if c.BatchID == batchID {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) Redeem(_ context.Context, userID types.ID, code string, at time.Time) (int64, error) {
+	c, ok := m.cards[code]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	b := m.batches[c.BatchID]
+	if !at.Before(b.ExpiresAt) {
+		return 0, ErrExpired
+	}
+	key := string(b.ID) + "/" + string(userID)
+	if c.Redemptions >= b.MaxRedemptions || m.redeemed[key] {
+		return 0, ErrUsed
+	}
+	m.redeemed[key] = true
+	c.Redemptions++
+	m.credits[userID] += b.Amount
+	return b.Amount, nil
+}
+
+var t0 = time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func newTestService() (*Service, *mockStore, *time.Time) {
+	store := newMockStore()
+	svc := NewService(store)
+	now := t0
+	svc.now = func() time.Time { return now }
+	return svc, store, &now
+}
+
+func TestCreateBatch_GeneratesUniqueCodes(t *testing.T) {
+	svc, _, _ := newTestService()
+	b, codes, err := svc.CreateBatch(context.Background(), Batch{
+		Name: "Spring retail", Amount: 50000, Count: 500, MaxRedemptions: 1,
+		Funding: FundingPrepaid, ExpiresAt: t0.AddDate(1, 0, 0),
+	})
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	if b.ID == "" || len(codes) != 500 {
+		t.Fatalf("batch %q with %d codes, want an ID and 500 codes", b.ID, len(codes))
+	}
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if len(c) != codeLen || strings.Trim(c, codeAlphabet) != "" || seen[c] {
+			t.Fatalf("bad or repeated code %q", c)
+		}
+		seen[c] = true
+	}
+	if b.source() != ledger.PlatformGiftCards {
+		t.Errorf("prepaid batch funded from %s", b.source())
+	}
+}
+
+func TestCreateBatch_Validates(t *testing.T) {
+	svc, _, _ := newTestService()
+	valid := Batch{Name: "Promo", Amount: 10000, Count: 1, MaxRedemptions: 1000, Funding: FundingPromotional, ExpiresAt: t0.Add(time.Hour)}
+	for name, mutate := range map[string]func(b *Batch){
+		"no amount":       func(b *Batch) { b.Amount = 0 },
+		"too many codes":  func(b *Batch) { b.Count = MaxBatchSize + 1 },
+		"no redemptions":  func(b *Batch) { b.MaxRedemptions = 0 },
+		"unknown funding": func(b *Batch) { b.Funding = "cash" },
+		"already expired": func(b *Batch) { b.ExpiresAt = t0 },
+	} {
+		b := valid
+		mutate(&b)
+		if _, _, err := svc.CreateBatch(context.Background(), b); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("%s: CreateBatch = %v, want ErrBadRequest", name, err)
+		}
+	}
+}
+
+func TestRedeem_LimitsAndExpiry(t *testing.T) {
+	svc, store, now := newTestService()
+	ctx := context.Background()
+	_, codes, err := svc.CreateBatch(ctx, Batch{
+		Name: "Launch", Amount: 10000, Count: 1, MaxRedemptions: 2,
+		Funding: FundingPromotional, ExpiresAt: t0.AddDate(0, 1, 0),
+	})
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	code := codes[0]
+
+	// Codes are matched case-insensitively, ignoring dashes and spaces.
+	typed := strings.ToLower(code[:4] + "-" + code[4:8] + " " + code[8:])
+	if got, err := svc.Redeem(ctx, "u1", typed); err != nil || got != 10000 {
+		t.Fatalf("Redeem = %d, %v; want 10000", got, err)
+	}
+	if _, err := svc.Redeem(ctx, "u1", code); !errors.Is(err, ErrUsed) {
+		t.Errorf("second redemption by the same user = %v, want ErrUsed", err)
+	}
+	if _, err := svc.Redeem(ctx, "u2", code); err != nil {
+		t.Errorf("second user: %v", err)
+	}
+	if _, err := svc.Redeem(ctx, "u3", code); !errors.Is(err, ErrUsed) {
+		t.Errorf("redemption past the limit = %v, want ErrUsed", err)
+	}
+	if store.credits["u1"] != 10000 || store.credits["u3"] != 0 {
+		t.Errorf("credits = %v", store.credits)
+	}
+
+	_, more, _ := svc.CreateBatch(ctx, Batch{
+		Name: "Short", Amount: 5000, Count: 1, MaxRedemptions: 1,
+		Funding: FundingPromotional, ExpiresAt: t0.Add(time.Hour),
+	})
+	*now = t0.Add(time.Hour)
+	if _, err := svc.Redeem(ctx, "u4", more[0]); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired code = %v, want ErrExpired", err)
+	}
+	if _, err := svc.Redeem(ctx, "u4", "NOSUCHCODE22"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown code = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.Redeem(ctx, "u4", " - "); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("blank code = %v, want ErrBadRequest", err)
+	}
+}
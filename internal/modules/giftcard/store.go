@@ -0,0 +1,184 @@
+// README: Gift card store — PostgreSQL persistence for batches, codes and redemptions, crediting the ride-credit ledger on redemption.
+package giftcard
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// creditKind tags gift card grants in the ride-credit ledger.
+const creditKind = "gift_card"
+
+// GiftCardStore defines the persistence operations required by the Service.
+type GiftCardStore interface {
+	// CreateBatch stores b with its codes.
+	CreateBatch(ctx context.Context, b *Batch, codes []string) error
+	GetBatch(ctx context.Context, id types.ID) (*Batch, error)
+	ListBatches(ctx context.Context) ([]Batch, error)
+	ListCards(ctx context.Context, batchID types.ID) ([]Card, error)
+	// Redeem grants code's value to userID as ride credit. It returns
+	// ErrNotFound for an unknown code, ErrExpired past the batch's expiry
+	// and ErrUsed when the code is used up or the user already redeemed a
+	// code from the batch.
+	Redeem(ctx context.Context, userID types.ID, code string, at time.Time) (int64, error)
+}
+
+// Store is the PostgreSQL implementation of GiftCardStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateBatch(ctx context.Context, b *Batch, codes []string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO gift_card_batches (id, name, amount, code_count, max_redemptions, funding, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		string(b.ID), b.Name, b.Amount, b.Count, b.MaxRedemptions, b.Funding, b.ExpiresAt, b.CreatedAt,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO gift_cards (code, batch_id)
+		SELECT unnest($2::text[]), $1`,
+		string(b.ID), codes,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+const batchColumns = `b.id, b.name, b.amount, b.code_count, b.max_redemptions, b.funding, b.expires_at, b.created_at,
+	(SELECT COALESCE(SUM(c.redemptions), 0) FROM gift_cards c WHERE c.batch_id = b.id)`
+
+func scanBatch(row pgx.Row) (*Batch, error) {
+	var b Batch
+	var id string
+	if err := row.Scan(&id, &b.Name, &b.Amount, &b.Count, &b.MaxRedemptions, &b.Funding, &b.ExpiresAt, &b.CreatedAt, &b.Redeemed); err != nil {
+		return nil, err
+	}
+	b.ID = types.ID(id)
+	return &b, nil
+}
+
+func (s *Store) GetBatch(ctx context.Context, id types.ID) (*Batch, error) {
+	b, err := scanBatch(s.db.QueryRow(ctx, `SELECT `+batchColumns+` FROM gift_card_batches b WHERE b.id = $1`, string(id)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
+
+func (s *Store) ListBatches(ctx context.Context) ([]Batch, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+batchColumns+` FROM gift_card_batches b ORDER BY b.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Batch
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *b)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListCards(ctx context.Context, batchID types.ID) ([]Card, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT code, redemptions FROM gift_cards WHERE batch_id = $1 ORDER BY code`,
+		string(batchID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Card
+	for rows.Next() {
+		c := Card{BatchID: batchID}
+		if err := rows.Scan(&c.Code, &c.Redemptions); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Redeem locks the code's row so concurrent redemptions of a shared code
+// cannot exceed its limit.
+func (s *Store) Redeem(ctx context.Context, userID types.ID, code string, at time.Time) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var b Batch
+	var batchID string
+	var redemptions int
+	err = tx.QueryRow(ctx, `
+		SELECT b.id, b.amount, b.max_redemptions, b.funding, b.expires_at, c.redemptions
+		FROM gift_cards c JOIN gift_card_batches b ON b.id = c.batch_id
+		WHERE c.code = $1
+		FOR UPDATE OF c`,
+		code,
+	).Scan(&batchID, &b.Amount, &b.MaxRedemptions, &b.Funding, &b.ExpiresAt, &redemptions)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	b.ID = types.ID(batchID)
+	if !at.Before(b.ExpiresAt) {
+		return 0, ErrExpired
+	}
+	if redemptions >= b.MaxRedemptions {
+		return 0, ErrUsed
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO gift_card_redemptions (batch_id, user_id, code, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (batch_id, user_id) DO NOTHING`,
+		batchID, string(userID), code, b.Amount, at,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, ErrUsed
+	}
+	if _, err := tx.Exec(ctx, `UPDATE gift_cards SET redemptions = redemptions + 1 WHERE code = $1`, code); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO credit_ledger (user_id, amount, kind, ref, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		string(userID), b.Amount, creditKind, code, at,
+	); err != nil {
+		return 0, err
+	}
+	if _, err := ledger.PostTx(ctx, tx, ledger.GiftCardTxn(userID, b.Amount, code, b.source(), at)); err != nil {
+		return 0, err
+	}
+	return b.Amount, tx.Commit(ctx)
+}
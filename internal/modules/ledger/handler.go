@@ -0,0 +1,101 @@
+// README: Ledger HTTP handlers — ops views of account balances and the trial balance.
+//
+// Endpoints:
+//
+//	GET /api/ops/ledger/accounts/:account   — balance and latest postings of one account (ops key)
+//	GET /api/ops/ledger/trial-balance       — every non-zero account and their sum (ops key)
+//
+// Auth: routes require the ops key middleware.
+package ledger
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/types"
+)
+
+// Handler holds the ledger HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type entryResp struct {
+	TxnID     types.ID `json:"txn_id"`
+	Kind      string   `json:"kind"`
+	Ref       string   `json:"ref"`
+	Memo      string   `json:"memo,omitempty"`
+	Amount    int64    `json:"amount"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+type balanceResp struct {
+	Account string `json:"account"`
+	Balance int64  `json:"balance"`
+}
+
+// Account handles GET /api/ops/ledger/accounts/:account.
+func (h *Handler) Account(c *gin.Context) {
+	account := c.Param("account")
+	bal, entries, err := h.svc.Account(c.Request.Context(), account)
+	if err != nil {
+		writeLedgerError(c, err)
+		return
+	}
+	out := make([]entryResp, len(entries))
+	for i, e := range entries {
+		out[i] = entryResp{
+			TxnID:     e.TxnID,
+			Kind:      e.Kind,
+			Ref:       e.Ref,
+			Memo:      e.Memo,
+			Amount:    e.Amount,
+			CreatedAt: e.CreatedAt.Unix(),
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"account":  account,
+		"balance":  bal,
+		"currency": types.DefaultCurrency,
+		"entries":  out,
+	})
+}
+
+// TrialBalance handles GET /api/ops/ledger/trial-balance.
+func (h *Handler) TrialBalance(c *gin.Context) {
+	bs, sum, err := h.svc.TrialBalance(c.Request.Context())
+	if err != nil {
+		writeLedgerError(c, err)
+		return
+	}
+	out := make([]balanceResp, len(bs))
+	for i, b := range bs {
+		out[i] = balanceResp{Account: b.Account, Balance: b.Balance}
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"accounts": out,
+		"total":    sum,
+		"balanced": sum == 0,
+		"currency": types.DefaultCurrency,
+	})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeLedgerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest), errors.Is(err, ErrUnbalanced):
+		writeError(c, http.StatusBadRequest, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,134 @@
+// README: Ledger domain model — double-entry transactions, signed postings and the account naming scheme.
+package ledger
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Platform and provider accounts. Per-user accounts are built with the
+// helpers below; every account is "<type>:<owner>".
+const (
+	// PlatformCommission collects the platform's cut of each fare.
+	PlatformCommission = "platform:commission"
+	// PlatformIncentives funds driver quest rewards and other bonuses.
+	PlatformIncentives = "platform:incentives"
+	// PlatformPromotions funds ride credits granted to passengers.
+	PlatformPromotions = "platform:promotions"
+	// PlatformSubscriptions collects ride plan fees and funds the plan
+	// benefits taken off fares.
+	PlatformSubscriptions = "platform:subscriptions"
+	// PlatformGiftCards holds the value of prepaid gift cards sold and not
+	// yet redeemed; finance books the sale proceeds against it.
+	PlatformGiftCards = "platform:gift_cards"
+	// PlatformOpening funds balances that predate the ledger.
+	PlatformOpening = "platform:opening"
+	// ProviderCard is money collected from passengers by the card processor.
+	ProviderCard = "provider:card"
+	// ProviderBank is money sent to drivers by bank transfer.
+	ProviderBank = "provider:bank"
+)
+
+// Account types.
+const (
+	TypePassenger = "passenger"
+	TypeDriver    = "driver"
+	TypeCredits   = "credits"
+	TypeOrg       = "org"
+	TypePlatform  = "platform"
+	TypeProvider  = "provider"
+)
+
+// PassengerAccount holds what a passenger owes for rides; it returns to zero once a ride is paid.
+func PassengerAccount(id types.ID) string { return TypePassenger + ":" + string(id) }
+
+// DriverAccount holds what the platform owes a driver (a credit balance).
+func DriverAccount(id types.ID) string { return TypeDriver + ":" + string(id) }
+
+// CreditsAccount holds a user's unspent ride credits (a credit balance).
+func CreditsAccount(id types.ID) string { return TypeCredits + ":" + string(id) }
+
+// OrgAccount holds what an organization owes for business rides.
+func OrgAccount(id types.ID) string { return TypeOrg + ":" + string(id) }
+
+// AccountType returns the type prefix of account.
+func AccountType(account string) string {
+	t, _, _ := strings.Cut(account, ":")
+	return t
+}
+
+// Posting is one line of a transaction. Amount is signed in TWD minor units:
+// positive debits the account, negative credits it.
+type Posting struct {
+	Account string
+	Amount  int64
+}
+
+// Txn is one balanced money movement. (Kind, Ref) identifies it, so recording
+// the same movement twice is a no-op.
+type Txn struct {
+	ID        types.ID
+	Kind      string
+	Ref       string
+	Memo      string
+	CreatedAt time.Time
+	Postings  []Posting
+}
+
+// Transaction kinds.
+const (
+	KindRide        = "ride"
+	KindIncentive   = "incentive"
+	KindCreditGrant = "credit_grant"
+	KindPayout      = "payout"
+
+	KindCancellationFee = "cancellation_fee"
+
+	KindGiftCard           = "gift_card"
+	KindSubscription       = "subscription"
+	KindSubscriptionRefund = "subscription_refund"
+)
+
+// Validate checks that t identifies itself, has at least two non-zero
+// postings on named accounts, and that its debits equal its credits.
+func (t *Txn) Validate() error {
+	if t.Kind == "" || t.Ref == "" || len(t.Postings) < 2 {
+		return ErrBadRequest
+	}
+	var sum int64
+	for _, p := range t.Postings {
+		if p.Amount == 0 || !strings.Contains(p.Account, ":") {
+			return ErrBadRequest
+		}
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return ErrUnbalanced
+	}
+	return nil
+}
+
+// Entry is a posting as seen from its account, with its transaction.
+type Entry struct {
+	TxnID     types.ID
+	Kind      string
+	Ref       string
+	Memo      string
+	Amount    int64
+	CreatedAt time.Time
+}
+
+// AccountBalance is the net of every posting on an account: positive is a
+// debit balance, negative a credit balance.
+type AccountBalance struct {
+	Account string
+	Balance int64
+}
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrUnbalanced = errors.New("ledger transaction is unbalanced")
+)
@@ -0,0 +1,13 @@
+// README: Ledger route registration — mounts the ops ledger endpoints.
+package ledger
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the ledger reports onto the provided ops router group.
+//
+//	GET /api/ops/ledger/accounts/:account
+//	GET /api/ops/ledger/trial-balance
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/ledger/accounts/:account", h.Account)
+	rg.GET("/api/ops/ledger/trial-balance", h.TrialBalance)
+}
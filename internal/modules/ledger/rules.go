@@ -0,0 +1,140 @@
+// README: Posting rules — how each kind of money movement is booked as a balanced ledger transaction.
+package ledger
+
+import (
+	"time"
+
+	"ark/internal/types"
+)
+
+// Ride describes a completed, paid ride.
+type Ride struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    types.ID
+	// OrgID bills the fare to an organization instead of the passenger's card.
+	OrgID *types.ID
+	Fare  int64
+	// Credits is the part of the fare paid with the passenger's ride credits.
+	Credits int64
+	// Subscription is the part of the fare covered by the passenger's ride
+	// plan, funded from plan fees.
+	Subscription int64
+	Commission   int64
+	At           time.Time
+}
+
+// RideTxn books a ride: the passenger is charged the fare, which is split
+// between the driver and the platform's commission, and the charge is settled
+// by the card processor (or the organization), the passenger's credits and
+// their ride plan.
+func RideTxn(r Ride) *Txn {
+	settle := []Posting{
+		{CreditsAccount(r.PassengerID), r.Credits},
+		{PlatformSubscriptions, r.Subscription},
+		{ProviderCard, r.Fare - r.Credits - r.Subscription},
+	}
+	if r.OrgID != nil {
+		settle = []Posting{{OrgAccount(*r.OrgID), r.Fare}}
+	}
+	postings := []Posting{
+		{PassengerAccount(r.PassengerID), r.Fare},
+		{DriverAccount(r.DriverID), -(r.Fare - r.Commission)},
+		{PlatformCommission, -r.Commission},
+	}
+	postings = append(postings, settle...)
+	postings = append(postings, Posting{PassengerAccount(r.PassengerID), -r.Fare})
+	return &Txn{Kind: KindRide, Ref: string(r.OrderID), CreatedAt: r.At, Postings: nonZero(postings)}
+}
+
+// CancellationFee describes a fee a passenger paid to cancel a ride after the
+// driver's free-cancel grace period.
+type CancellationFee struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    types.ID
+	// OrgID bills the fee to an organization instead of the passenger's card.
+	OrgID      *types.ID
+	Fee        int64
+	Commission int64
+	At         time.Time
+}
+
+// CancellationFeeTxn books a cancellation fee like a ride fare: the passenger
+// is charged the fee, which is split between the driver and the platform's
+// commission, and the charge is settled by the card processor (or the
+// organization). Credits and ride plans never pay cancellation fees.
+func CancellationFeeTxn(f CancellationFee) *Txn {
+	settle := ProviderCard
+	if f.OrgID != nil {
+		settle = OrgAccount(*f.OrgID)
+	}
+	return &Txn{Kind: KindCancellationFee, Ref: string(f.OrderID), Memo: "cancellation fee", CreatedAt: f.At, Postings: nonZero([]Posting{
+		{PassengerAccount(f.PassengerID), f.Fee},
+		{DriverAccount(f.DriverID), -(f.Fee - f.Commission)},
+		{PlatformCommission, -f.Commission},
+		{settle, f.Fee},
+		{PassengerAccount(f.PassengerID), -f.Fee},
+	})}
+}
+
+// IncentiveTxn books a platform-funded bonus paid to a driver, e.g. a quest reward.
+func IncentiveTxn(driverID types.ID, amount int64, ref, memo string, at time.Time) *Txn {
+	return &Txn{Kind: KindIncentive, Ref: string(driverID) + ":" + ref, Memo: memo, CreatedAt: at, Postings: []Posting{
+		{PlatformIncentives, amount},
+		{DriverAccount(driverID), -amount},
+	}}
+}
+
+// CreditGrantTxn books ride credits granted to a user, e.g. a referral reward.
+func CreditGrantTxn(userID types.ID, amount int64, ref, memo string, at time.Time) *Txn {
+	return &Txn{Kind: KindCreditGrant, Ref: string(userID) + ":" + ref, Memo: memo, CreatedAt: at, Postings: []Posting{
+		{PlatformPromotions, amount},
+		{CreditsAccount(userID), -amount},
+	}}
+}
+
+// GiftCardTxn books a gift card redeemed into a user's ride credits, funded
+// by source: PlatformGiftCards for prepaid cards, PlatformPromotions for
+// marketing codes.
+func GiftCardTxn(userID types.ID, amount int64, code, source string, at time.Time) *Txn {
+	return &Txn{Kind: KindGiftCard, Ref: string(userID) + ":" + code, Memo: "gift card", CreatedAt: at, Postings: []Posting{
+		{source, amount},
+		{CreditsAccount(userID), -amount},
+	}}
+}
+
+// SubscriptionTxn books a ride plan fee charged to a user's card. ref
+// identifies the billing period.
+func SubscriptionTxn(userID types.ID, amount int64, ref string, at time.Time) *Txn {
+	return &Txn{Kind: KindSubscription, Ref: string(userID) + ":" + ref, Memo: "ride plan", CreatedAt: at, Postings: []Posting{
+		{ProviderCard, amount},
+		{PlatformSubscriptions, -amount},
+	}}
+}
+
+// SubscriptionRefundTxn books the prorated refund of a cancelled ride plan.
+func SubscriptionRefundTxn(userID types.ID, amount int64, ref string, at time.Time) *Txn {
+	return &Txn{Kind: KindSubscriptionRefund, Ref: string(userID) + ":" + ref, Memo: "ride plan refund", CreatedAt: at, Postings: []Posting{
+		{PlatformSubscriptions, amount},
+		{ProviderCard, -amount},
+	}}
+}
+
+// PayoutTxn books a bank transfer of a driver's earnings.
+func PayoutTxn(driverID, payoutID types.ID, amount int64, at time.Time) *Txn {
+	return &Txn{Kind: KindPayout, Ref: string(payoutID), CreatedAt: at, Postings: []Posting{
+		{DriverAccount(driverID), amount},
+		{ProviderBank, -amount},
+	}}
+}
+
+func nonZero(ps []Posting) []Posting {
+	out := ps[:0]
+	for _, p := range ps {
+		if p.Amount != 0 {
+			out = append(out, p)
+		}
+	}
+	return out
+}
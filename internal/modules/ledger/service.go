@@ -0,0 +1,65 @@
+// README: Ledger service — records balanced transactions and reports account balances and the trial balance.
+package ledger
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// maxEntries caps the postings returned with an account balance.
+const maxEntries = 100
+
+// Service records and reports on the double-entry ledger.
+type Service struct {
+	store LedgerStore
+	now   func() time.Time
+}
+
+// NewService creates a Service backed by store.
+func NewService(store LedgerStore) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// Post records t, stamping CreatedAt when unset. Unbalanced transactions are
+// rejected with ErrUnbalanced. It reports false when the same movement was
+// already recorded.
+func (s *Service) Post(ctx context.Context, t *Txn) (bool, error) {
+	if err := t.Validate(); err != nil {
+		return false, err
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = s.now()
+	}
+	return s.store.Post(ctx, t)
+}
+
+// Account returns an account's balance and its latest postings.
+func (s *Service) Account(ctx context.Context, account string) (int64, []Entry, error) {
+	if t, owner, ok := strings.Cut(account, ":"); !ok || t == "" || owner == "" {
+		return 0, nil, ErrBadRequest
+	}
+	bal, err := s.store.Balance(ctx, account)
+	if err != nil {
+		return 0, nil, err
+	}
+	entries, err := s.store.Entries(ctx, account, maxEntries)
+	if err != nil {
+		return 0, nil, err
+	}
+	return bal, entries, nil
+}
+
+// TrialBalance returns every account with a non-zero balance. Their sum is
+// zero unless the ledger is corrupt, so it is returned for the caller to check.
+func (s *Service) TrialBalance(ctx context.Context) ([]AccountBalance, int64, error) {
+	bs, err := s.store.TrialBalance(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var sum int64
+	for _, b := range bs {
+		sum += b.Balance
+	}
+	return bs, sum, nil
+}
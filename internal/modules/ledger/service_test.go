@@ -0,0 +1,222 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"ark/internal/types"
+)
+
+// ---------------------------------------------------------------------------
+// In-memory fakes
+// ---------------------------------------------------------------------------
+
+type mockStore struct {
+	txns []*Txn
+}
+
+func (m *mockStore) Post(_ context.Context, t *Txn) (bool, error) {
+	for _, x := range m.txns {
+		if x.Kind == t.Kind && x.Ref == t.Ref {
+			return false, nil
+		}
+	}
+	m.txns = append(m.txns, t)
+	return true, nil
+}
+
+func (m *mockStore) Balance(_ context.Context, account string) (int64, error) {
+	var bal int64
+	for _, t := range m.txns {
+		for _, p := range t.Postings {
+			if p.Account == account {
+				bal += p.Amount
+			}
+		}
+	}
+	return bal, nil
+}
+
+func (m *mockStore) Entries(_ context.Context, account string, limit int) ([]Entry, error) {
+	var out []Entry
+	for _, t := range m.txns {
+		for _, p := range t.Postings {
+			if p.Account == account && len(out) < limit {
+				out = append(out, Entry{TxnID: t.ID, Kind: t.Kind, Ref: t.Ref, Amount: p.Amount, CreatedAt: t.CreatedAt})
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) TrialBalance(_ context.Context) ([]AccountBalance, error) {
+	sums := make(map[string]int64)
+	for _, t := range m.txns {
+		for _, p := range t.Postings {
+			sums[p.Account] += p.Amount
+		}
+	}
+	var out []AccountBalance
+	for a, b := range sums {
+		if b != 0 {
+			out = append(out, AccountBalance{Account: a, Balance: b})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Account < out[j].Account })
+	return out, nil
+}
+
+func sumPostings(t *Txn) int64 {
+	var sum int64
+	for _, p := range t.Postings {
+		sum += p.Amount
+	}
+	return sum
+}
+
+// ---------------------------------------------------------------------------
+// Invariants
+// ---------------------------------------------------------------------------
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		txn  Txn
+		want error
+	}{
+		{"balanced", Txn{Kind: "k", Ref: "r", Postings: []Posting{{"a:1", 100}, {"b:2", -100}}}, nil},
+		{"unbalanced", Txn{Kind: "k", Ref: "r", Postings: []Posting{{"a:1", 100}, {"b:2", -99}}}, ErrUnbalanced},
+		{"one posting", Txn{Kind: "k", Ref: "r", Postings: []Posting{{"a:1", 0}}}, ErrBadRequest},
+		{"zero amount", Txn{Kind: "k", Ref: "r", Postings: []Posting{{"a:1", 0}, {"b:2", 0}}}, ErrBadRequest},
+		{"bad account", Txn{Kind: "k", Ref: "r", Postings: []Posting{{"a", 5}, {"b:2", -5}}}, ErrBadRequest},
+		{"no ref", Txn{Kind: "k", Postings: []Posting{{"a:1", 5}, {"b:2", -5}}}, ErrBadRequest},
+	}
+	for _, tc := range cases {
+		if err := tc.txn.Validate(); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Validate = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestRules_DebitsEqualCredits(t *testing.T) {
+	org := types.ID("org1")
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	txns := map[string]*Txn{
+		"card ride":         RideTxn(Ride{OrderID: "o1", PassengerID: "p", DriverID: "d", Fare: 18000, Commission: 3600, At: at}),
+		"ride with credits": RideTxn(Ride{OrderID: "o2", PassengerID: "p", DriverID: "d", Fare: 18000, Credits: 5000, Commission: 3600, At: at}),
+		"credits only":      RideTxn(Ride{OrderID: "o3", PassengerID: "p", DriverID: "d", Fare: 8000, Credits: 8000, Commission: 1600, At: at}),
+		"no commission":     RideTxn(Ride{OrderID: "o4", PassengerID: "p", DriverID: "d", Fare: 8000, At: at}),
+		"full commission":   RideTxn(Ride{OrderID: "o5", PassengerID: "p", DriverID: "d", Fare: 8000, Commission: 8000, At: at}),
+		"plan ride":         RideTxn(Ride{OrderID: "o7", PassengerID: "p", DriverID: "d", Fare: 18000, Credits: 2000, Subscription: 3600, Commission: 3600, At: at}),
+		"business ride":     RideTxn(Ride{OrderID: "o6", PassengerID: "p", DriverID: "d", OrgID: &org, Fare: 12000, Commission: 2400, At: at}),
+		"cancel fee":        CancellationFeeTxn(CancellationFee{OrderID: "o8", PassengerID: "p", DriverID: "d", Fee: 10000, Commission: 2000, At: at}),
+		"business cancel":   CancellationFeeTxn(CancellationFee{OrderID: "o9", PassengerID: "p", DriverID: "d", OrgID: &org, Fee: 10000, Commission: 2000, At: at}),
+		"incentive":         IncentiveTxn("d", 50000, "quest:c1", "", at),
+		"clawback":          IncentiveTxn("d", -2000, "adjust:a1", "", at),
+		"credit grant":      CreditGrantTxn("p", 10000, "referee:p", "", at),
+		"payout":            PayoutTxn("d", "po1", 64000, at),
+		"gift card":         GiftCardTxn("p", 50000, "GIFTCODE", PlatformGiftCards, at),
+		"promo code":        GiftCardTxn("p", 10000, "PROMO", PlatformPromotions, at),
+		"plan fee":          SubscriptionTxn("p", 29900, "s1:2026-06", at),
+		"plan refund":       SubscriptionRefundTxn("p", 14950, "s1", at),
+	}
+	for name, txn := range txns {
+		if err := txn.Validate(); err != nil {
+			t.Errorf("%s: Validate = %v (postings %+v)", name, err, txn.Postings)
+		}
+	}
+
+	// Random rides must balance for any fare, credit and commission split.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		fare := rng.Int63n(100000) + 1
+		ride := Ride{
+			OrderID: "o", PassengerID: "p", DriverID: "d",
+			Fare: fare, Credits: rng.Int63n(fare + 1), Commission: rng.Int63n(fare + 1), At: at,
+		}
+		if i%5 == 0 {
+			ride.OrgID, ride.Credits = &org, 0
+		}
+		txn := RideTxn(ride)
+		if err := txn.Validate(); err != nil {
+			t.Fatalf("ride %+v: Validate = %v (sum %d)", ride, err, sumPostings(txn))
+		}
+	}
+}
+
+func TestTrialBalance_StaysBalanced(t *testing.T) {
+	ctx := context.Background()
+	store := &mockStore{}
+	svc := NewService(store)
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	movements := []*Txn{
+		CreditGrantTxn("pax", 10000, "referee:pax", "referral", at),
+		RideTxn(Ride{OrderID: "o1", PassengerID: "pax", DriverID: "drv", Fare: 18000, Credits: 5000, Commission: 3600, At: at}),
+		RideTxn(Ride{OrderID: "o2", PassengerID: "pax", DriverID: "drv", Fare: 9000, Credits: 5000, Commission: 1800, At: at}),
+		IncentiveTxn("drv", 50000, "quest:c1", "quest", at),
+		PayoutTxn("drv", "po1", 60000, at),
+		// Recording a movement twice changes nothing.
+		PayoutTxn("drv", "po1", 60000, at),
+	}
+	for i, m := range movements {
+		if _, err := svc.Post(ctx, m); err != nil {
+			t.Fatalf("Post %d: %v", i, err)
+		}
+	}
+	if len(store.txns) != 5 {
+		t.Fatalf("txns = %d, want 5", len(store.txns))
+	}
+
+	bs, sum, err := svc.TrialBalance(ctx)
+	if err != nil {
+		t.Fatalf("TrialBalance: %v", err)
+	}
+	if sum != 0 {
+		t.Fatalf("trial balance sums to %d, want 0: %+v", sum, bs)
+	}
+	want := map[string]int64{
+		DriverAccount("drv"):    -(14400 + 7200 + 50000 - 60000),
+		CreditsAccount("pax"):   0, // granted 10000, spent 5000 + 5000
+		PassengerAccount("pax"): 0,
+		PlatformCommission:      -(3600 + 1800),
+		PlatformIncentives:      50000,
+		PlatformPromotions:      10000,
+		ProviderCard:            13000 + 4000,
+		ProviderBank:            -60000,
+	}
+	for acct, amt := range want {
+		bal, _, err := svc.Account(ctx, acct)
+		if err != nil {
+			t.Fatalf("Account %s: %v", acct, err)
+		}
+		if bal != amt {
+			t.Errorf("%s = %d, want %d", acct, bal, amt)
+		}
+	}
+}
+
+func TestPost_RejectsUnbalanced(t *testing.T) {
+	store := &mockStore{}
+	svc := NewService(store)
+	_, err := svc.Post(context.Background(), &Txn{Kind: "k", Ref: "r", Postings: []Posting{{"a:1", 100}, {"b:2", -90}}})
+	if !errors.Is(err, ErrUnbalanced) {
+		t.Fatalf("Post = %v, want ErrUnbalanced", err)
+	}
+	if len(store.txns) != 0 {
+		t.Error("unbalanced transaction was stored")
+	}
+}
+
+func TestAccount_RejectsMalformedName(t *testing.T) {
+	svc := NewService(&mockStore{})
+	for _, acct := range []string{"", "driver", "driver:", ":x"} {
+		if _, _, err := svc.Account(context.Background(), acct); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("Account(%q) = %v, want ErrBadRequest", acct, err)
+		}
+	}
+}
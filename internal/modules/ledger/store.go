@@ -0,0 +1,129 @@
+// README: Ledger store — PostgreSQL persistence for ledger transactions and postings, usable inside other modules' transactions.
+package ledger
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// LedgerStore defines the persistence operations required by the ledger Service.
+type LedgerStore interface {
+	// Post records t and reports false when a transaction with the same kind
+	// and ref already exists.
+	Post(ctx context.Context, t *Txn) (bool, error)
+	Balance(ctx context.Context, account string) (int64, error)
+	Entries(ctx context.Context, account string, limit int) ([]Entry, error)
+	TrialBalance(ctx context.Context) ([]AccountBalance, error)
+}
+
+// Store is the PostgreSQL implementation of LedgerStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+// PostTx records t inside tx, so a module can commit its own rows and the
+// matching ledger transaction atomically. It validates t, assigns its ID when
+// unset, and reports false when (kind, ref) was already recorded.
+func PostTx(ctx context.Context, tx pgx.Tx, t *Txn) (bool, error) {
+	if err := t.Validate(); err != nil {
+		return false, err
+	}
+	if t.ID == "" {
+		t.ID = types.NewID()
+	}
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO ledger_transactions (id, kind, ref, memo, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (kind, ref) DO NOTHING`,
+		string(t.ID), t.Kind, t.Ref, t.Memo, t.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	for _, p := range t.Postings {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO ledger_postings (txn_id, account, amount, created_at) VALUES ($1, $2, $3, $4)`,
+			string(t.ID), p.Account, p.Amount, t.CreatedAt,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (s *Store) Post(ctx context.Context, t *Txn) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+	ok, err := PostTx(ctx, tx, t)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (s *Store) Balance(ctx context.Context, account string) (int64, error) {
+	var bal int64
+	err := s.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM ledger_postings WHERE account = $1`, account,
+	).Scan(&bal)
+	return bal, err
+}
+
+func (s *Store) Entries(ctx context.Context, account string, limit int) ([]Entry, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT t.id, t.kind, t.ref, t.memo, p.amount, p.created_at
+		FROM ledger_postings p JOIN ledger_transactions t ON t.id = p.txn_id
+		WHERE p.account = $1 ORDER BY p.created_at DESC, p.id DESC LIMIT $2`,
+		account, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Entry
+	for rows.Next() {
+		var e Entry
+		var id string
+		if err := rows.Scan(&id, &e.Kind, &e.Ref, &e.Memo, &e.Amount, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.TxnID = types.ID(id)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) TrialBalance(ctx context.Context) ([]AccountBalance, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT account, SUM(amount) FROM ledger_postings
+		GROUP BY account HAVING SUM(amount) <> 0 ORDER BY account`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AccountBalance
+	for rows.Next() {
+		var b AccountBalance
+		if err := rows.Scan(&b.Account, &b.Balance); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
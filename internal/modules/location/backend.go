@@ -0,0 +1,267 @@
+// README: Pluggable location backends (Redis GEO, Firebase RTDB, dual-write) and their consistency check.
+package location
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// Backend names accepted by ARK_LOCATION_BACKEND.
+const (
+	BackendRedis = "redis"
+	BackendRTDB  = "rtdb"
+	BackendDual  = "dual"
+)
+
+// LocationBackend stores live user positions and answers nearby queries.
+type LocationBackend interface {
+	// Update records the current position of one user.
+	Update(ctx context.Context, userType string, e GeoEntry) error
+	// Nearby returns active users within radiusKm of (lat, lng), nearest first.
+	Nearby(ctx context.Context, lat, lng, radiusKm float64, userType string) ([]NearbyUser, error)
+	// Active returns every active user of the given type (used by the consistency checker).
+	Active(ctx context.Context, userType string) ([]GeoEntry, error)
+	// Remove marks one user as no longer active.
+	Remove(ctx context.Context, userType string, id types.ID) error
+}
+
+// NewBackend builds the backend selected by name. RTDB and dual modes require
+// the store to have a Firebase RTDB client.
+func NewBackend(store *Store, name string) (LocationBackend, error) {
+	redisBackend := &RedisBackend{store: store}
+	switch name {
+	case "", BackendRedis:
+		return redisBackend, nil
+	case BackendRTDB:
+		if !store.RTDBEnabled() {
+			return nil, fmt.Errorf("location backend %q: %w", name, ErrRTDBDisabled)
+		}
+		return &RTDBBackend{store: store}, nil
+	case BackendDual:
+		if !store.RTDBEnabled() {
+			return nil, fmt.Errorf("location backend %q: %w", name, ErrRTDBDisabled)
+		}
+		return &DualBackend{Primary: redisBackend, Secondary: &RTDBBackend{store: store}}, nil
+	default:
+		return nil, fmt.Errorf("unknown location backend %q", name)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Redis GEO
+// ---------------------------------------------------------------------------
+
+// RedisBackend serves positions from the Redis GEO index.
+type RedisBackend struct {
+	store *Store
+}
+
+func (b *RedisBackend) Update(ctx context.Context, userType string, e GeoEntry) error {
+	return b.store.SetGeo(ctx, []GeoEntry{e}, userType)
+}
+
+func (b *RedisBackend) Nearby(ctx context.Context, lat, lng, radiusKm float64, userType string) ([]NearbyUser, error) {
+	return b.store.GetNearbyUsersFromRedis(ctx, lat, lng, radiusKm, userType)
+}
+
+func (b *RedisBackend) Active(ctx context.Context, userType string) ([]GeoEntry, error) {
+	return b.store.GetActiveUsersFromRedis(ctx, userType)
+}
+
+func (b *RedisBackend) Remove(ctx context.Context, userType string, id types.ID) error {
+	return b.store.RemoveGeo(ctx, userType, id)
+}
+
+// ---------------------------------------------------------------------------
+// Firebase RTDB
+// ---------------------------------------------------------------------------
+
+// RTDBBackend serves positions from Firebase RTDB. Nearby queries fetch only the
+// geohash cells covering the radius (see FetchNearbyUsersFromRTDB).
+type RTDBBackend struct {
+	store *Store
+}
+
+func (b *RTDBBackend) Update(ctx context.Context, userType string, e GeoEntry) error {
+	return b.store.WriteUserToRTDB(ctx, userType, e)
+}
+
+func (b *RTDBBackend) Nearby(ctx context.Context, lat, lng, radiusKm float64, userType string) ([]NearbyUser, error) {
+	return b.store.FetchNearbyUsersFromRTDB(ctx, lat, lng, radiusKm, userType)
+}
+
+func (b *RTDBBackend) Active(ctx context.Context, userType string) ([]GeoEntry, error) {
+	return b.store.FetchActiveUsersFromRTDB(ctx, userType)
+}
+
+func (b *RTDBBackend) Remove(ctx context.Context, userType string, id types.ID) error {
+	return b.store.MarkInactiveInRTDB(ctx, userType, id)
+}
+
+// ---------------------------------------------------------------------------
+// Dual write
+// ---------------------------------------------------------------------------
+
+// DualBackend writes to both backends and reads from Primary. Secondary write
+// failures are logged, not returned, so the migration target cannot break the
+// serving path.
+type DualBackend struct {
+	Primary   LocationBackend
+	Secondary LocationBackend
+}
+
+func (b *DualBackend) Update(ctx context.Context, userType string, e GeoEntry) error {
+	if err := b.Primary.Update(ctx, userType, e); err != nil {
+		return err
+	}
+	if err := b.Secondary.Update(ctx, userType, e); err != nil {
+		errreport.Report(ctx, "location", "secondary_update", err, "user_type", userType, "user_id", e.ID)
+	}
+	return nil
+}
+
+func (b *DualBackend) Nearby(ctx context.Context, lat, lng, radiusKm float64, userType string) ([]NearbyUser, error) {
+	return b.Primary.Nearby(ctx, lat, lng, radiusKm, userType)
+}
+
+func (b *DualBackend) Active(ctx context.Context, userType string) ([]GeoEntry, error) {
+	return b.Primary.Active(ctx, userType)
+}
+
+func (b *DualBackend) Remove(ctx context.Context, userType string, id types.ID) error {
+	if err := b.Primary.Remove(ctx, userType, id); err != nil {
+		return err
+	}
+	if err := b.Secondary.Remove(ctx, userType, id); err != nil {
+		errreport.Report(ctx, "location", "secondary_remove", err, "user_type", userType, "user_id", id)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Consistency checker
+// ---------------------------------------------------------------------------
+
+// driftThresholdKm is how far apart the two backends may place the same user
+// before the checker reports it as drifted.
+const driftThresholdKm = 0.2
+
+// ConsistencyReport summarises the differences between two backends for one user type.
+type ConsistencyReport struct {
+	UserType       string
+	PrimaryCount   int
+	SecondaryCount int
+	OnlyPrimary    []types.ID
+	OnlySecondary  []types.ID
+	Drifted        []types.ID
+}
+
+// Consistent reports whether both backends agree.
+func (r ConsistencyReport) Consistent() bool {
+	return len(r.OnlyPrimary) == 0 && len(r.OnlySecondary) == 0 && len(r.Drifted) == 0
+}
+
+// CompareBackends diffs the active sets of primary and secondary for userType.
+func CompareBackends(ctx context.Context, primary, secondary LocationBackend, userType string) (ConsistencyReport, error) {
+	a, err := primary.Active(ctx, userType)
+	if err != nil {
+		return ConsistencyReport{}, fmt.Errorf("primary active %s: %w", userType, err)
+	}
+	b, err := secondary.Active(ctx, userType)
+	if err != nil {
+		return ConsistencyReport{}, fmt.Errorf("secondary active %s: %w", userType, err)
+	}
+	return diffEntries(userType, a, b), nil
+}
+
+func diffEntries(userType string, primary, secondary []GeoEntry) ConsistencyReport {
+	r := ConsistencyReport{UserType: userType, PrimaryCount: len(primary), SecondaryCount: len(secondary)}
+	sec := make(map[types.ID]types.Point, len(secondary))
+	for _, e := range secondary {
+		sec[e.ID] = e.Pos
+	}
+	for _, e := range primary {
+		pos, ok := sec[e.ID]
+		if !ok {
+			r.OnlyPrimary = append(r.OnlyPrimary, e.ID)
+			continue
+		}
+		delete(sec, e.ID)
+		if haversineKm(e.Pos, pos) > driftThresholdKm {
+			r.Drifted = append(r.Drifted, e.ID)
+		}
+	}
+	for id := range sec {
+		r.OnlySecondary = append(r.OnlySecondary, id)
+	}
+	sort.Slice(r.OnlySecondary, func(i, j int) bool { return r.OnlySecondary[i] < r.OnlySecondary[j] })
+	return r
+}
+
+// RunConsistencyChecker periodically compares the dual-write backends and logs
+// any divergence. It returns immediately when the service is not in dual mode.
+func (s *Service) RunConsistencyChecker(ctx context.Context, interval time.Duration) {
+	dual, ok := s.backend.(*DualBackend)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	log.Printf("location: consistency checker started (interval=%s)", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("location: consistency checker stopped")
+			return
+		case <-ticker.C:
+			for _, userType := range []string{"driver", "passenger"} {
+				r, err := CompareBackends(ctx, dual.Primary, dual.Secondary, userType)
+				if err != nil {
+					errreport.Report(ctx, "location", "consistency_check", err, "user_type", userType)
+					continue
+				}
+				if !r.Consistent() {
+					log.Printf("location: backends diverge for %s: primary=%d secondary=%d only_primary=%d only_secondary=%d drifted=%d",
+						userType, r.PrimaryCount, r.SecondaryCount, len(r.OnlyPrimary), len(r.OnlySecondary), len(r.Drifted))
+				}
+			}
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// filterNearby keeps entries within radiusKm of origin, sorted nearest first.
+func filterNearby(entries []GeoEntry, origin types.Point, radiusKm float64) []NearbyUser {
+	var out []NearbyUser
+	for _, e := range entries {
+		d := haversineKm(origin, e.Pos)
+		if d > radiusKm {
+			continue
+		}
+		out = append(out, NearbyUser{ID: e.ID, Lat: e.Pos.Lat, Lng: e.Pos.Lng, Distance: d})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Distance < out[j].Distance })
+	return out
+}
+
+// haversineKm returns the great-circle distance between a and b in kilometres.
+func haversineKm(a, b types.Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,110 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ark/internal/types"
+)
+
+type fakeBackend struct {
+	entries   []GeoEntry
+	updateErr error
+	updated   []GeoEntry
+	removed   []types.ID
+}
+
+func (f *fakeBackend) Update(_ context.Context, _ string, e GeoEntry) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	f.updated = append(f.updated, e)
+	return nil
+}
+
+func (f *fakeBackend) Nearby(_ context.Context, lat, lng, radiusKm float64, _ string) ([]NearbyUser, error) {
+	return filterNearby(f.entries, types.Point{Lat: lat, Lng: lng}, radiusKm), nil
+}
+
+func (f *fakeBackend) Active(context.Context, string) ([]GeoEntry, error) {
+	return f.entries, nil
+}
+
+func (f *fakeBackend) Remove(_ context.Context, _ string, id types.ID) error {
+	f.removed = append(f.removed, id)
+	return nil
+}
+
+var taipei101 = types.Point{Lat: 25.033964, Lng: 121.564468}
+
+func TestFilterNearby_SortsAndFilters(t *testing.T) {
+	entries := []GeoEntry{
+		{ID: "far", Pos: types.Point{Lat: 25.10, Lng: 121.56}},   // ~7 km north
+		{ID: "mid", Pos: types.Point{Lat: 25.045, Lng: 121.564}}, // ~1.2 km
+		{ID: "near", Pos: taipei101},
+	}
+	got := filterNearby(entries, taipei101, 3)
+	if len(got) != 2 || got[0].ID != "near" || got[1].ID != "mid" {
+		t.Fatalf("filterNearby = %+v, want [near mid]", got)
+	}
+	if got[1].Distance < 1 || got[1].Distance > 1.5 {
+		t.Errorf("mid distance = %.3f km, want ~1.2", got[1].Distance)
+	}
+}
+
+func TestDualBackend_SecondaryFailureDoesNotFailWrite(t *testing.T) {
+	primary := &fakeBackend{}
+	secondary := &fakeBackend{updateErr: errors.New("rtdb down")}
+	dual := &DualBackend{Primary: primary, Secondary: secondary}
+
+	if err := dual.Update(context.Background(), "driver", GeoEntry{ID: "d1", Pos: taipei101}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(primary.updated) != 1 {
+		t.Errorf("primary not written: %+v", primary.updated)
+	}
+}
+
+func TestCompareBackends(t *testing.T) {
+	primary := &fakeBackend{entries: []GeoEntry{
+		{ID: "same", Pos: taipei101},
+		{ID: "moved", Pos: taipei101},
+		{ID: "redis-only", Pos: taipei101},
+	}}
+	secondary := &fakeBackend{entries: []GeoEntry{
+		{ID: "same", Pos: types.Point{Lat: 25.0340, Lng: 121.5645}},
+		{ID: "moved", Pos: types.Point{Lat: 25.05, Lng: 121.564468}},
+		{ID: "rtdb-only", Pos: taipei101},
+	}}
+
+	r, err := CompareBackends(context.Background(), primary, secondary, "driver")
+	if err != nil {
+		t.Fatalf("CompareBackends: %v", err)
+	}
+	if r.Consistent() {
+		t.Fatal("expected divergence")
+	}
+	if len(r.OnlyPrimary) != 1 || r.OnlyPrimary[0] != "redis-only" {
+		t.Errorf("OnlyPrimary = %v", r.OnlyPrimary)
+	}
+	if len(r.OnlySecondary) != 1 || r.OnlySecondary[0] != "rtdb-only" {
+		t.Errorf("OnlySecondary = %v", r.OnlySecondary)
+	}
+	if len(r.Drifted) != 1 || r.Drifted[0] != "moved" {
+		t.Errorf("Drifted = %v", r.Drifted)
+	}
+}
+
+func TestNewBackend_RTDBRequiresFirebase(t *testing.T) {
+	store := &Store{}
+	if _, err := NewBackend(store, BackendDual); !errors.Is(err, ErrRTDBDisabled) {
+		t.Errorf("dual without RTDB: err = %v, want ErrRTDBDisabled", err)
+	}
+	if _, err := NewBackend(store, "memcache"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+	if b, err := NewBackend(store, BackendRedis); err != nil || b == nil {
+		t.Errorf("redis backend: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// README: Geohash encoding and radius cover cells used to push RTDB nearby queries down to prefix ranges.
+package location
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+	// geohashStorePrecision is the length written to RTDB entries (~4.8 m cells).
+	geohashStorePrecision = 9
+	kmPerDegreeLat        = 111.32
+)
+
+// Geohash returns the geohash stored alongside an RTDB location entry.
+func Geohash(lat, lng float64) string {
+	return geohashEncode(lat, lng, geohashStorePrecision)
+}
+
+// GeohashCell returns the geohash cell of (lat, lng) with the given length,
+// for bucketing stats by area.
+func GeohashCell(lat, lng float64, precision int) string {
+	return geohashEncode(lat, lng, precision)
+}
+
+// geohashEncode returns the base32 geohash of (lat, lng) with the given length.
+func geohashEncode(lat, lng float64, precision int) string {
+	latLo, latHi := -90.0, 90.0
+	lngLo, lngHi := -180.0, 180.0
+	out := make([]byte, 0, precision)
+	even := true
+	bit, ch := 0, 0
+	for len(out) < precision {
+		if even {
+			mid := (lngLo + lngHi) / 2
+			if lng >= mid {
+				ch = ch<<1 | 1
+				lngLo = mid
+			} else {
+				ch <<= 1
+				lngHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			out = append(out, geohashBase32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return string(out)
+}
+
+// geohashCellDegrees returns the height and width in degrees of a cell of the given length.
+func geohashCellDegrees(precision int) (latDeg, lngDeg float64) {
+	bits := 5 * precision
+	lngBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lngBits))
+}
+
+// geohashPrecisionFor returns the longest geohash length whose cells are at
+// least radiusKm in both directions at lat, so a 3x3 block covers the radius.
+func geohashPrecisionFor(lat, radiusKm float64) int {
+	cosLat := math.Cos(lat * math.Pi / 180)
+	for p := geohashStorePrecision; p > 1; p-- {
+		latDeg, lngDeg := geohashCellDegrees(p)
+		if latDeg*kmPerDegreeLat >= radiusKm && lngDeg*kmPerDegreeLat*cosLat >= radiusKm {
+			return p
+		}
+	}
+	return 1
+}
+
+// geohashCover returns the sorted, de-duplicated prefixes of the cell containing
+// (lat, lng) and its eight neighbours, sized so that together they contain every
+// point within radiusKm.
+func geohashCover(lat, lng, radiusKm float64) []string {
+	p := geohashPrecisionFor(lat, radiusKm)
+	latDeg, lngDeg := geohashCellDegrees(p)
+
+	seen := make(map[string]struct{}, 9)
+	cells := make([]string, 0, 9)
+	for _, dLat := range []float64{-latDeg, 0, latDeg} {
+		for _, dLng := range []float64{-lngDeg, 0, lngDeg} {
+			nLat := math.Max(-90, math.Min(90, lat+dLat))
+			nLng := math.Mod(lng+dLng+540, 360) - 180
+			h := geohashEncode(nLat, nLng, p)
+			if _, ok := seen[h]; ok {
+				continue
+			}
+			seen[h] = struct{}{}
+			cells = append(cells, h)
+		}
+	}
+	sort.Strings(cells)
+	return cells
+}
@@ -0,0 +1,124 @@
+package location
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"ark/internal/types"
+)
+
+func TestGeohashEncode_KnownValues(t *testing.T) {
+	cases := []struct {
+		lat, lng float64
+		prec     int
+		want     string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{-33.8688, 151.2093, 5, "r3gx2"},
+	}
+	for _, tc := range cases {
+		if got := geohashEncode(tc.lat, tc.lng, tc.prec); got != tc.want {
+			t.Errorf("geohashEncode(%v, %v, %d) = %q, want %q", tc.lat, tc.lng, tc.prec, got, tc.want)
+		}
+	}
+}
+
+func TestGeohashCover_ContainsAllPointsInRadius(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, radius := range []float64{0.5, 3, 10} {
+		cells := geohashCover(taipei101.Lat, taipei101.Lng, radius)
+		if len(cells) == 0 || len(cells) > 9 {
+			t.Fatalf("radius %.1f: %d cells", radius, len(cells))
+		}
+		for i := 0; i < 2000; i++ {
+			p := types.Point{
+				Lat: taipei101.Lat + (rng.Float64()-0.5)*radius/55,
+				Lng: taipei101.Lng + (rng.Float64()-0.5)*radius/50,
+			}
+			if haversineKm(taipei101, p) > radius {
+				continue
+			}
+			h := Geohash(p.Lat, p.Lng)
+			if !hasAnyPrefix(h, cells) {
+				t.Fatalf("radius %.1f: point %+v (%s) not covered by %v", radius, p, h, cells)
+			}
+		}
+	}
+}
+
+func hasAnyPrefix(h string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(h, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// Benchmarks: full download + filter vs geohash prefix ranges.
+// The sorted slice stands in for the RTDB "geohash" index.
+// ---------------------------------------------------------------------------
+
+type indexedEntry struct {
+	hash  string
+	entry GeoEntry
+}
+
+func syntheticDrivers(n int) ([]GeoEntry, []indexedEntry) {
+	rng := rand.New(rand.NewSource(42))
+	entries := make([]GeoEntry, n)
+	index := make([]indexedEntry, n)
+	for i := range entries {
+		// Spread over greater Taipei (~40 km x 40 km).
+		p := types.Point{Lat: 24.85 + rng.Float64()*0.36, Lng: 121.35 + rng.Float64()*0.4}
+		entries[i] = GeoEntry{ID: types.ID(strconv.Itoa(i)), Pos: p}
+		index[i] = indexedEntry{hash: Geohash(p.Lat, p.Lng), entry: entries[i]}
+	}
+	sort.Slice(index, func(i, j int) bool { return index[i].hash < index[j].hash })
+	return entries, index
+}
+
+func prefixScan(index []indexedEntry, origin types.Point, radiusKm float64) []NearbyUser {
+	var candidates []GeoEntry
+	for _, prefix := range geohashCover(origin.Lat, origin.Lng, radiusKm) {
+		lo := sort.Search(len(index), func(i int) bool { return index[i].hash >= prefix })
+		for i := lo; i < len(index) && strings.HasPrefix(index[i].hash, prefix); i++ {
+			candidates = append(candidates, index[i].entry)
+		}
+	}
+	return filterNearby(candidates, origin, radiusKm)
+}
+
+func BenchmarkNearby_FullScan(b *testing.B) {
+	for _, n := range []int{1000, 10000, 50000} {
+		entries, _ := syntheticDrivers(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				filterNearby(entries, taipei101, 3)
+			}
+		})
+	}
+}
+
+func BenchmarkNearby_GeohashPrefix(b *testing.B) {
+	for _, n := range []int{1000, 10000, 50000} {
+		_, index := syntheticDrivers(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				prefixScan(index, taipei101, 3)
+			}
+		})
+	}
+}
+
+func BenchmarkGeohashEncode(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Geohash(taipei101.Lat, taipei101.Lng)
+	}
+}
@@ -0,0 +1,138 @@
+// README: Driver heartbeats — drivers whose live position stops refreshing are taken offline, reported to hooks and counted per region.
+package location
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// regionPrecision is the geohash length regions are reported at (cells of
+// roughly 39 x 20 km).
+const regionPrecision = 4
+
+// Heartbeat metrics, published on /debug/vars keyed by region:
+// location_heartbeat_drops counts drivers taken offline since start and
+// location_heartbeat_online is the online count at the latest sweep, so the
+// drop rate of a region is the growth of drops over online.
+var (
+	heartbeatDrops  = expvar.NewMap("location_heartbeat_drops")
+	heartbeatOnline = expvar.NewMap("location_heartbeat_online")
+)
+
+// Region names the area a position is reported under.
+func Region(p types.Point) string {
+	return geohashEncode(p.Lat, p.Lng, regionPrecision)
+}
+
+// heartbeatStore is the part of Store the heartbeat sweep uses.
+type heartbeatStore interface {
+	ScanHeartbeats(ctx context.Context, userType string, cutoff time.Time) (fresh, stale []GeoEntry, err error)
+	RemoveGeo(ctx context.Context, userType string, id types.ID) error
+}
+
+// DriverOffline describes a driver whose heartbeat stopped.
+type DriverOffline struct {
+	DriverID types.ID
+	// Position is the last reported position and Region its area.
+	Position types.Point
+	Region   string
+	At       time.Time
+}
+
+// OfflineHook is called after a driver has been taken offline.
+type OfflineHook func(ctx context.Context, d DriverOffline)
+
+// OnDriverOffline registers h to run for every driver taken offline.
+// Must be called before the heartbeat monitor starts.
+func (s *Service) OnDriverOffline(h OfflineHook) {
+	s.offlineHooks = append(s.offlineHooks, h)
+}
+
+// SweepHeartbeats takes offline every driver whose position has not been
+// refreshed within timeout: they are removed from the live index (and so from
+// matching) and marked offline in the backend. It returns how many were taken
+// offline. Heartbeats are read from the Redis GEO index, so the sweep only
+// applies to the redis and dual backends.
+func (s *Service) SweepHeartbeats(ctx context.Context, timeout time.Duration) (int, error) {
+	now := time.Now()
+	fresh, stale, err := s.heartbeats.ScanHeartbeats(ctx, "driver", now.Add(-timeout))
+	if err != nil {
+		return 0, err
+	}
+
+	online := make(map[string]int64)
+	for _, e := range fresh {
+		online[Region(e.Pos)]++
+	}
+	for region, n := range online {
+		v := new(expvar.Int)
+		v.Set(n)
+		heartbeatOnline.Set(region, v)
+	}
+	// A region with no fresh drivers left reads zero, not its last count.
+	heartbeatOnline.Do(func(kv expvar.KeyValue) {
+		if _, ok := online[kv.Key]; !ok {
+			kv.Value.(*expvar.Int).Set(0)
+		}
+	})
+
+	dropped := 0
+	for _, e := range stale {
+		// Drop from the index the sweep reads, then mark offline where
+		// positions are served (a repeat of the same removal for Redis).
+		if err := s.heartbeats.RemoveGeo(ctx, "driver", e.ID); err != nil {
+			errreport.Report(ctx, "location", "heartbeat_remove", err, "driver_id", e.ID)
+			continue
+		}
+		if err := s.backend.Remove(ctx, "driver", e.ID); err != nil {
+			errreport.Report(ctx, "location", "heartbeat_offline", err, "driver_id", e.ID)
+		}
+		s.snapshots.forget(e.ID)
+		d := DriverOffline{DriverID: e.ID, Position: e.Pos, Region: Region(e.Pos), At: now}
+		heartbeatDrops.Add(d.Region, 1)
+		if _, ok := online[d.Region]; !ok {
+			heartbeatOnline.Set(d.Region, new(expvar.Int))
+		}
+		s.runOfflineHooks(ctx, d)
+		dropped++
+	}
+	return dropped, nil
+}
+
+// RunHeartbeatMonitor sweeps driver heartbeats every interval until ctx is
+// cancelled.
+func (s *Service) RunHeartbeatMonitor(ctx context.Context, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.SweepHeartbeats(ctx, timeout)
+			if err != nil {
+				errreport.Report(ctx, "location", "heartbeat_sweep", err)
+			} else if n > 0 {
+				log.Printf("location: heartbeat sweep took %d drivers offline", n)
+			}
+		}
+	}
+}
+
+func (s *Service) runOfflineHooks(ctx context.Context, d DriverOffline) {
+	for _, h := range s.offlineHooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("location: offline hook panicked for %s: %v", d.DriverID, r)
+				}
+			}()
+			h(ctx, d)
+		}()
+	}
+}
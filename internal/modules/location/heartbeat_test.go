@@ -0,0 +1,86 @@
+package location
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+
+	"ark/internal/types"
+)
+
+type fakeHeartbeats struct {
+	fresh, stale []GeoEntry
+	removed      []types.ID
+}
+
+func (f *fakeHeartbeats) ScanHeartbeats(context.Context, string, time.Time) ([]GeoEntry, []GeoEntry, error) {
+	return f.fresh, f.stale, nil
+}
+
+func (f *fakeHeartbeats) RemoveGeo(_ context.Context, _ string, id types.ID) error {
+	f.removed = append(f.removed, id)
+	return nil
+}
+
+func TestSweepHeartbeats_TakesStaleDriversOffline(t *testing.T) {
+	hb := &fakeHeartbeats{
+		fresh: []GeoEntry{{ID: "d-fresh", Pos: taipei101}},
+		stale: []GeoEntry{{ID: "d-stale", Pos: taipei101}},
+	}
+	backend := &fakeBackend{}
+	svc := &Service{backend: backend, heartbeats: hb}
+
+	var offline []DriverOffline
+	svc.OnDriverOffline(func(_ context.Context, d DriverOffline) { offline = append(offline, d) })
+	svc.OnDriverOffline(func(context.Context, DriverOffline) { panic("boom") })
+
+	region := Region(taipei101)
+	before := heartbeatDrops.Get(region)
+	n, err := svc.SweepHeartbeats(context.Background(), 30*time.Second)
+	if err != nil {
+		t.Fatalf("SweepHeartbeats: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("dropped = %d, want 1", n)
+	}
+	if len(hb.removed) != 1 || hb.removed[0] != "d-stale" || len(backend.removed) != 1 || backend.removed[0] != "d-stale" {
+		t.Errorf("removed index=%v backend=%v, want only d-stale", hb.removed, backend.removed)
+	}
+	if len(offline) != 1 || offline[0].DriverID != "d-stale" || offline[0].Region != region {
+		t.Errorf("offline hooks got %+v", offline)
+	}
+
+	var was int64
+	if before != nil {
+		was = before.(*expvar.Int).Value()
+	}
+	if got := heartbeatDrops.Get(region).(*expvar.Int).Value(); got != was+1 {
+		t.Errorf("drops[%s] = %d, want %d", region, got, was+1)
+	}
+	if got := heartbeatOnline.Get(region).String(); got != "1" {
+		t.Errorf("online[%s] = %s, want 1", region, got)
+	}
+}
+
+func TestSweepHeartbeats_ResetsEmptiedRegions(t *testing.T) {
+	hb := &fakeHeartbeats{fresh: []GeoEntry{{ID: "d1", Pos: taipei101}}}
+	svc := &Service{backend: &fakeBackend{}, heartbeats: hb}
+	region := Region(taipei101)
+
+	if _, err := svc.SweepHeartbeats(context.Background(), 30*time.Second); err != nil {
+		t.Fatalf("SweepHeartbeats: %v", err)
+	}
+	if got := heartbeatOnline.Get(region).String(); got != "1" {
+		t.Fatalf("online[%s] = %s, want 1", region, got)
+	}
+
+	// The region's only driver left the index between sweeps.
+	hb.fresh = nil
+	if _, err := svc.SweepHeartbeats(context.Background(), 30*time.Second); err != nil {
+		t.Fatalf("SweepHeartbeats: %v", err)
+	}
+	if got := heartbeatOnline.Get(region).String(); got != "0" {
+		t.Errorf("online[%s] = %s after the region emptied, want 0", region, got)
+	}
+}
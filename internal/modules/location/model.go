@@ -27,6 +27,8 @@ type Update struct {
 	UserID   types.ID
 	UserType string
 	Position types.Point
+	// Seq is the client's increasing update counter; 0 skips the ordering check.
+	Seq int64
 }
 
 // ---------------------------------------------------------------------------
@@ -0,0 +1,50 @@
+// README: Passenger "looking for ride" presence, set by the app and cleared by the order lifecycle.
+package location
+
+import (
+	"context"
+	"errors"
+
+	"ark/internal/errreport"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+var (
+	// ErrInvalidPosition is returned for coordinates outside the valid lat/lng range.
+	ErrInvalidPosition = errors.New("location: invalid position")
+	// ErrInvalidUserType is returned for a user type other than driver or passenger.
+	ErrInvalidUserType = errors.New("location: user_type must be driver or passenger")
+)
+
+// SetPassengerSeeking marks the passenger as looking for a ride at pos. In the
+// Redis backend presence expires after statusTTL, so clients refresh it while
+// the ride search screen is open.
+func (s *Service) SetPassengerSeeking(ctx context.Context, passengerID types.ID, pos types.Point) error {
+	if passengerID == "" || !validPoint(pos) {
+		return ErrInvalidPosition
+	}
+	return s.backend.Update(ctx, "passenger", GeoEntry{ID: passengerID, Pos: pos})
+}
+
+// ClearPassengerSeeking removes the passenger's ride-seeking presence.
+func (s *Service) ClearPassengerSeeking(ctx context.Context, passengerID types.ID) error {
+	return s.backend.Remove(ctx, "passenger", passengerID)
+}
+
+// OrderPresenceHook clears ride-seeking presence when the passenger creates an
+// order (matching takes over) or cancels one (they must opt in again).
+func (s *Service) OrderPresenceHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.From != order.StatusNone && t.To != order.StatusCancelled {
+			return
+		}
+		if err := s.ClearPassengerSeeking(ctx, t.PassengerID); err != nil {
+			errreport.Report(ctx, "location", "clear_presence", err, "passenger_id", t.PassengerID, "order_id", t.OrderID, "transition", string(t.From)+"→"+string(t.To))
+		}
+	}
+}
+
+func validPoint(p types.Point) bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180 && !(p.Lat == 0 && p.Lng == 0)
+}
@@ -0,0 +1,95 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+func TestSetPassengerSeeking_ValidatesPosition(t *testing.T) {
+	b := &fakeBackend{}
+	svc := &Service{backend: b}
+	ctx := context.Background()
+
+	if err := svc.SetPassengerSeeking(ctx, "p1", types.Point{Lat: 91, Lng: 0}); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("lat 91: err = %v, want ErrInvalidPosition", err)
+	}
+	if err := svc.SetPassengerSeeking(ctx, "p1", types.Point{}); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("null island: err = %v, want ErrInvalidPosition", err)
+	}
+	if err := svc.SetPassengerSeeking(ctx, "p1", taipei101); err != nil {
+		t.Fatalf("SetPassengerSeeking: %v", err)
+	}
+	if len(b.updated) != 1 || b.updated[0].ID != "p1" {
+		t.Errorf("updated = %+v", b.updated)
+	}
+}
+
+func TestUpdatePosition_Validates(t *testing.T) {
+	b := &fakeBackend{}
+	svc := &Service{backend: b}
+	ctx := context.Background()
+
+	if err := svc.UpdatePosition(ctx, Update{UserID: "p1", UserType: "rider", Position: taipei101}); !errors.Is(err, ErrInvalidUserType) {
+		t.Errorf("rider: err = %v, want ErrInvalidUserType", err)
+	}
+	if err := svc.UpdatePosition(ctx, Update{UserID: "d1", UserType: "driver", Position: types.Point{Lat: 123, Lng: 456}}); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("out of range: err = %v, want ErrInvalidPosition", err)
+	}
+	if err := svc.UpdatePosition(ctx, Update{UserType: "passenger", Position: taipei101}); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("no user: err = %v, want ErrInvalidPosition", err)
+	}
+	if err := svc.UpdatePosition(ctx, Update{UserID: "p1", UserType: "passenger", Position: taipei101}); err != nil {
+		t.Fatalf("UpdatePosition: %v", err)
+	}
+	if len(b.updated) != 1 || b.updated[0].ID != "p1" || b.updated[0].Pos != taipei101 {
+		t.Errorf("updated = %+v", b.updated)
+	}
+}
+
+type fakeGate struct{ res string }
+
+func (g fakeGate) Admit(context.Context, string, types.ID, int64, time.Time) (string, error) {
+	return g.res, nil
+}
+
+func TestUpdatePosition_DropsRejectedUpdates(t *testing.T) {
+	b := &fakeBackend{}
+	svc := &Service{backend: b}
+	ctx := context.Background()
+	u := Update{UserID: "d1", UserType: "driver", Position: taipei101, Seq: 7}
+
+	svc.SetUpdateGate(fakeGate{res: AdmitThrottled})
+	if err := svc.UpdatePosition(ctx, u); !errors.Is(err, ErrThrottled) {
+		t.Errorf("throttled: err = %v", err)
+	}
+	svc.SetUpdateGate(fakeGate{res: AdmitStale})
+	if err := svc.UpdatePosition(ctx, u); !errors.Is(err, ErrStaleUpdate) {
+		t.Errorf("stale: err = %v", err)
+	}
+	if len(b.updated) != 0 {
+		t.Fatalf("rejected updates were written: %+v", b.updated)
+	}
+	svc.SetUpdateGate(fakeGate{res: AdmitAccepted})
+	if err := svc.UpdatePosition(ctx, u); err != nil || len(b.updated) != 1 {
+		t.Errorf("accepted: err = %v, updated = %+v", err, b.updated)
+	}
+}
+
+func TestOrderPresenceHook(t *testing.T) {
+	b := &fakeBackend{}
+	hook := (&Service{backend: b}).OrderPresenceHook()
+	ctx := context.Background()
+
+	hook(ctx, order.Transition{PassengerID: "created", From: order.StatusNone, To: order.StatusWaiting})
+	hook(ctx, order.Transition{PassengerID: "matched", From: order.StatusWaiting, To: order.StatusApproaching})
+	hook(ctx, order.Transition{PassengerID: "cancelled", From: order.StatusWaiting, To: order.StatusCancelled})
+
+	if len(b.removed) != 2 || b.removed[0] != "created" || b.removed[1] != "cancelled" {
+		t.Errorf("removed = %v, want [created cancelled]", b.removed)
+	}
+}
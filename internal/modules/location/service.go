@@ -3,16 +3,81 @@ package location
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
 )
 
 type Service struct {
-	store *Store
+	store   *Store
+	backend LocationBackend
+	gate    UpdateGate
+
+	heartbeats   heartbeatStore
+	offlineHooks []OfflineHook
+
+	snapshots     snapshotSampler
+	positionHooks []PositionHook
 }
 
+// NewService serves nearby queries from Redis GEO; use SetBackend to switch
+// to RTDB or dual-write. Position updates are throttled through the store.
 func NewService(store *Store) *Service {
-	return &Service{store: store}
+	return &Service{store: store, backend: &RedisBackend{store: store}, gate: store, heartbeats: store}
+}
+
+// SetBackend replaces the backend used for position updates and nearby queries.
+func (s *Service) SetBackend(b LocationBackend) {
+	s.backend = b
+}
+
+// UpdatePosition records a user's live position in the configured backend.
+// UserType is "driver" or "passenger"; a missing user or an out-of-range
+// position yields ErrInvalidPosition. Updates faster than minUpdateInterval
+// yield ErrThrottled and out-of-order ones ErrStaleUpdate; neither is written.
+// When the gate or backend fails (Redis down) it yields ErrUnavailable.
+func (s *Service) UpdatePosition(ctx context.Context, u Update) error {
+	if u.UserType != "driver" && u.UserType != "passenger" {
+		return ErrInvalidUserType
+	}
+	if u.UserID == "" || !validPoint(u.Position) {
+		return ErrInvalidPosition
+	}
+	if err := s.admit(ctx, u); err != nil {
+		return err
+	}
+	if err := s.backend.Update(ctx, u.UserType, GeoEntry{ID: u.UserID, Pos: u.Position}); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	s.sampleSnapshot(ctx, u)
+	s.runPositionHooks(ctx, u)
+	return nil
+}
+
+// PositionHook is called after a position update has been accepted and written.
+// Hooks run synchronously on the update path and must be cheap.
+type PositionHook func(ctx context.Context, u Update)
+
+// OnPosition registers h to run after every accepted position update.
+// Must be called before the service starts handling requests.
+func (s *Service) OnPosition(h PositionHook) {
+	s.positionHooks = append(s.positionHooks, h)
+}
+
+func (s *Service) runPositionHooks(ctx context.Context, u Update) {
+	for _, h := range s.positionHooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("location: position hook panicked for %s: %v", u.UserID, r)
+				}
+			}()
+			h(ctx, u)
+		}()
+	}
 }
 
 // RunRTDBPoller periodically fetches active user positions from Firebase RTDB
@@ -39,20 +104,21 @@ func (s *Service) syncRTDBToRedis(ctx context.Context) {
 	for _, userType := range []string{"driver", "passenger"} {
 		entries, err := s.store.FetchActiveUsersFromRTDB(ctx, userType)
 		if err != nil {
-			log.Printf("location: poller fetch %s from RTDB: %v", userType, err)
+			errreport.Report(ctx, "location", "rtdb_fetch", err, "user_type", userType)
 			continue
 		}
 		if len(entries) == 0 {
 			continue
 		}
 		if err := s.store.SetGeo(ctx, entries, userType); err != nil {
-			log.Printf("location: poller sync %s to Redis: %v", userType, err)
+			errreport.Report(ctx, "location", "rtdb_sync", err, "user_type", userType)
 			continue
 		}
 		log.Printf("location: poller synced %d %ss from RTDB to Redis", len(entries), userType)
 	}
 }
 
+// FlushSnapshot persists u as a position sample.
 func (s *Service) FlushSnapshot(ctx context.Context, u Update) error {
 	snap := Snapshot{
 		UserID:     u.UserID,
@@ -64,9 +130,9 @@ func (s *Service) FlushSnapshot(ctx context.Context, u Update) error {
 }
 
 // GetNearbyDrivers returns online drivers within radiusKm of (lat, lng),
-// sorted by distance ascending. Presence is determined by the Redis status TTL.
+// sorted by distance ascending, from the configured backend.
 func (s *Service) GetNearbyDrivers(ctx context.Context, lat, lng, radiusKm float64) ([]DriverLocation, error) {
-	users, err := s.store.GetNearbyUsersFromRedis(ctx, lat, lng, radiusKm, "driver")
+	users, err := s.backend.Nearby(ctx, lat, lng, radiusKm, "driver")
 	if err != nil {
 		return nil, err
 	}
@@ -99,10 +165,20 @@ func (s *Service) GetAllDrivers(ctx context.Context) ([]DriverLocation, error) {
 	return result, nil
 }
 
+// LastFix returns the driver's live position and when it was reported; a zero
+// time means none is known, e.g. the driver went offline.
+func (s *Service) LastFix(ctx context.Context, driverID types.ID) (types.Point, time.Time, error) {
+	pos, at, ok, err := s.store.LastFix(ctx, "driver", driverID)
+	if err != nil || !ok {
+		return types.Point{}, time.Time{}, err
+	}
+	return pos, at, nil
+}
+
 // GetNearbyPassengers returns passengers looking for a ride within radiusKm of
 // (lat, lng), sorted by distance ascending.
 func (s *Service) GetNearbyPassengers(ctx context.Context, lat, lng, radiusKm float64) ([]PassengerLocation, error) {
-	users, err := s.store.GetNearbyUsersFromRedis(ctx, lat, lng, radiusKm, "passenger")
+	users, err := s.backend.Nearby(ctx, lat, lng, radiusKm, "passenger")
 	if err != nil {
 		return nil, err
 	}
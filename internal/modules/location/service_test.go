@@ -2,11 +2,16 @@ package location
 
 import (
 	"context"
+	"errors"
 	"math"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+
+	"ark/internal/config"
+	"ark/internal/infra"
 	"ark/internal/types"
 )
 
@@ -26,7 +31,11 @@ func TestService_SyncRTDBToRedis(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	store, err := NewStore(ctx, nil, rdb, []byte(credsJSON))
+	app, err := infra.NewFirebaseApp(ctx, config.FirebaseConfig{CredentialsJSON: credsJSON})
+	if err != nil {
+		t.Skipf("Skipping RTDB integration test due to Firebase init failure: %v", err)
+	}
+	store, err := NewStore(ctx, nil, rdb, app)
 	if err != nil {
 		t.Skipf("Skipping RTDB integration test due to initialization failure (missing creds?): %v", err)
 	}
@@ -91,3 +100,14 @@ func TestService_SyncRTDBToRedis(t *testing.T) {
 		t.Errorf("Expected driver %s to be successfully synced to Redis GEO, but it was not found.", testDriverID)
 	}
 }
+
+func TestService_UpdatePosition_RedisDown(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer rdb.Close()
+	svc := NewService(newTestStore(rdb))
+
+	err := svc.UpdatePosition(context.Background(), Update{UserID: "d1", UserType: "driver", Position: types.Point{Lat: 25.03, Lng: 121.56}})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,96 @@
+// README: Driver position samples persisted to Postgres, the GPS trace trip audits measure, and their retention.
+package location
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// snapshotSampler rate-limits persisted samples per driver; live updates
+// arrive far more often than a trace needs.
+type snapshotSampler struct {
+	every time.Duration
+	mu    sync.Mutex
+	last  map[types.ID]time.Time
+}
+
+// due reports whether id may be sampled at now, and if so records it.
+func (p *snapshotSampler) due(id types.ID, now time.Time) bool {
+	if p.every <= 0 {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now.Sub(p.last[id]) < p.every {
+		return false
+	}
+	if p.last == nil {
+		p.last = make(map[types.ID]time.Time)
+	}
+	p.last[id] = now
+	return true
+}
+
+// forget drops id's sampling state, e.g. when the driver goes offline.
+func (p *snapshotSampler) forget(id types.ID) {
+	p.mu.Lock()
+	delete(p.last, id)
+	p.mu.Unlock()
+}
+
+// SetSnapshotInterval persists at most one driver position per interval to
+// location_snapshots. Zero, the default, persists nothing.
+// Must be called before the service starts handling requests.
+func (s *Service) SetSnapshotInterval(every time.Duration) {
+	s.snapshots.every = every
+}
+
+// sampleSnapshot persists u when its driver is due a sample. A failed write
+// only leaves a gap in the trace, so it never fails the update.
+func (s *Service) sampleSnapshot(ctx context.Context, u Update) {
+	if u.UserType != "driver" || !s.snapshots.due(u.UserID, time.Now()) {
+		return
+	}
+	if err := s.FlushSnapshot(ctx, u); err != nil {
+		errreport.Report(ctx, "location", "snapshot", err, "driver_id", u.UserID)
+	}
+}
+
+// DriverTrace returns the driver's persisted positions between from and to,
+// oldest first.
+func (s *Service) DriverTrace(ctx context.Context, driverID types.ID, from, to time.Time) ([]types.Point, error) {
+	snaps, err := s.store.ListSnapshots(ctx, driverID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]types.Point, len(snaps))
+	for i, snap := range snaps {
+		out[i] = snap.Position
+	}
+	return out, nil
+}
+
+// RunSnapshotPruner deletes samples older than retention every hour until ctx
+// is cancelled.
+func (s *Service) RunSnapshotPruner(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.store.PruneSnapshots(ctx, time.Now().Add(-retention))
+			if err != nil {
+				errreport.Report(ctx, "location", "prune_snapshots", err)
+			} else if n > 0 {
+				log.Printf("location: pruned %d snapshots", n)
+			}
+		}
+	}
+}
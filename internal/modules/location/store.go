@@ -3,18 +3,18 @@ package location
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"strconv"
 	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/db"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
-	"google.golang.org/api/option"
 
+	"ark/internal/errreport"
 	"ark/internal/types"
 )
 
@@ -31,20 +31,15 @@ type Store struct {
 	dbClient *db.Client
 }
 
-// NewStore initialises the location store with Firebase RTDB (for polling) and Redis GEO.
-// firebaseCredsJSON is the raw Firebase service-account JSON (from FIREBASE_CREDENTIALS_JSON env var).
-func NewStore(ctx context.Context, dbPool *pgxpool.Pool, redisClient *redis.Client, firebaseCredsJSON []byte) (*Store, error) {
-	projectID, err := parseProjectIDFromJSON(firebaseCredsJSON)
-	if err != nil {
-		return nil, fmt.Errorf("parsing firebase credentials: %w", err)
-	}
-
-	databaseURL := fmt.Sprintf("https://%s-default-rtdb.asia-southeast1.firebasedatabase.app", projectID)
+// ErrRTDBDisabled is returned by RTDB reads when the store was built without Firebase.
+var ErrRTDBDisabled = errors.New("location: firebase RTDB not configured")
 
-	conf := &firebase.Config{DatabaseURL: databaseURL}
-	app, err := firebase.NewApp(ctx, conf, option.WithCredentialsJSON(firebaseCredsJSON))
-	if err != nil {
-		return nil, fmt.Errorf("initialising firebase app: %w", err)
+// NewStore initialises the location store with Firebase RTDB (for polling) and Redis GEO.
+// app is the shared Firebase app (see infra.NewFirebaseApp) configured with the RTDB URL;
+// when nil the store serves Redis and Postgres only and RTDB reads return ErrRTDBDisabled.
+func NewStore(ctx context.Context, dbPool *pgxpool.Pool, redisClient *redis.Client, app *firebase.App) (*Store, error) {
+	if app == nil {
+		return &Store{db: dbPool, redis: redisClient}, nil
 	}
 
 	dbClient, err := app.Database(ctx)
@@ -60,19 +55,6 @@ func NewStore(ctx context.Context, dbPool *pgxpool.Pool, redisClient *redis.Clie
 	}, nil
 }
 
-func parseProjectIDFromJSON(data []byte) (string, error) {
-	var sa struct {
-		ProjectID string `json:"project_id"`
-	}
-	if err := json.Unmarshal(data, &sa); err != nil {
-		return "", fmt.Errorf("parsing firebase credentials JSON: %w", err)
-	}
-	if sa.ProjectID == "" {
-		return "", fmt.Errorf("project_id is empty in firebase credentials JSON")
-	}
-	return sa.ProjectID, nil
-}
-
 // ---------------------------------------------------------------------------
 // Redis GEO helpers
 // ---------------------------------------------------------------------------
@@ -90,6 +72,8 @@ func statusKey(userType string, id types.ID) string {
 
 // SetGeo writes a batch of user positions into the Redis GEO sorted set and
 // refreshes each status key with a 60-second TTL, all in a single pipeline.
+// The status value is the refresh time in Unix milliseconds: the user's
+// heartbeat (see ScanHeartbeats).
 func (s *Store) SetGeo(ctx context.Context, entries []GeoEntry, userType string) error {
 	if len(entries) == 0 {
 		return nil
@@ -104,10 +88,11 @@ func (s *Store) SetGeo(ctx context.Context, entries []GeoEntry, userType string)
 		}
 	}
 
+	beat := strconv.FormatInt(time.Now().UnixMilli(), 10)
 	pipe := s.redis.Pipeline()
 	pipe.GeoAdd(ctx, geoSetKey(userType), geoMembers...)
 	for _, e := range entries {
-		pipe.Set(ctx, statusKey(userType, e.ID), "1", statusTTL)
+		pipe.Set(ctx, statusKey(userType, e.ID), beat, statusTTL)
 	}
 	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("SetGeo batch %s (%d entries): %w", userType, len(entries), err)
@@ -169,7 +154,7 @@ func (s *Store) GetNearbyUsersFromRedis(ctx context.Context, lat, lng, radiusKm
 		key := geoSetKey(userType)
 		go func() {
 			if err := s.redis.ZRem(context.Background(), key, expired...).Err(); err != nil {
-				log.Printf("location: lazy ZRem failed for %s: %v", key, err)
+				errreport.Report(context.Background(), "location", "lazy_zrem", err, "key", key)
 			}
 		}()
 	}
@@ -177,15 +162,133 @@ func (s *Store) GetNearbyUsersFromRedis(ctx context.Context, lat, lng, radiusKm
 	return active, nil
 }
 
+// RemoveGeo drops a user from the GEO set and deletes its status key.
+func (s *Store) RemoveGeo(ctx context.Context, userType string, id types.ID) error {
+	pipe := s.redis.Pipeline()
+	pipe.ZRem(ctx, geoSetKey(userType), string(id))
+	pipe.Del(ctx, statusKey(userType, id))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("remove geo %s %s: %w", userType, id, err)
+	}
+	return nil
+}
+
+// LastFix returns the user's live position and its heartbeat time. ok is
+// false when the user has none, e.g. their status key expired. A status value
+// that is not a timestamp is taken to be as old as its key can be.
+func (s *Store) LastFix(ctx context.Context, userType string, id types.ID) (pos types.Point, at time.Time, ok bool, err error) {
+	pipe := s.redis.Pipeline()
+	posCmd := pipe.GeoPos(ctx, geoSetKey(userType), string(id))
+	beatCmd := pipe.Get(ctx, statusKey(userType, id))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return types.Point{}, time.Time{}, false, fmt.Errorf("last fix %s %s: %w", userType, id, err)
+	}
+	positions, _ := posCmd.Result()
+	beat, err := beatCmd.Result()
+	if len(positions) == 0 || positions[0] == nil || err != nil {
+		return types.Point{}, time.Time{}, false, nil
+	}
+	at = time.Now().Add(-statusTTL)
+	if ms, err := strconv.ParseInt(beat, 10, 64); err == nil && ms > 1 {
+		at = time.UnixMilli(ms)
+	}
+	return types.Point{Lat: positions[0].Latitude, Lng: positions[0].Longitude}, at, true, nil
+}
+
+// GetActiveUsersFromRedis returns every member of the GEO set whose status key
+// has not expired.
+func (s *Store) GetActiveUsersFromRedis(ctx context.Context, userType string) ([]GeoEntry, error) {
+	key := geoSetKey(userType)
+	members, err := s.redis.ZRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("ZRANGE %s: %w", userType, err)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	statusKeys := make([]string, len(members))
+	for i, m := range members {
+		statusKeys[i] = statusKey(userType, types.ID(m))
+	}
+	statuses, err := s.redis.MGet(ctx, statusKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("MGET status %s: %w", userType, err)
+	}
+	positions, err := s.redis.GeoPos(ctx, key, members...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("GEOPOS %s: %w", userType, err)
+	}
+
+	entries := make([]GeoEntry, 0, len(members))
+	for i, m := range members {
+		if statuses[i] == nil || positions[i] == nil {
+			continue
+		}
+		entries = append(entries, GeoEntry{
+			ID:  types.ID(m),
+			Pos: types.Point{Lat: positions[i].Latitude, Lng: positions[i].Longitude},
+		})
+	}
+	return entries, nil
+}
+
+// ScanHeartbeats splits the GEO set into users whose last heartbeat is at or
+// after cutoff and users whose heartbeat is older or whose status key has
+// expired. A status value that is not a timestamp only counts as expired once
+// its key is gone.
+func (s *Store) ScanHeartbeats(ctx context.Context, userType string, cutoff time.Time) (fresh, stale []GeoEntry, err error) {
+	key := geoSetKey(userType)
+	members, err := s.redis.ZRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ZRANGE %s: %w", userType, err)
+	}
+	if len(members) == 0 {
+		return nil, nil, nil
+	}
+
+	statusKeys := make([]string, len(members))
+	for i, m := range members {
+		statusKeys[i] = statusKey(userType, types.ID(m))
+	}
+	statuses, err := s.redis.MGet(ctx, statusKeys...).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("MGET status %s: %w", userType, err)
+	}
+	positions, err := s.redis.GeoPos(ctx, key, members...).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("GEOPOS %s: %w", userType, err)
+	}
+
+	for i, m := range members {
+		if positions[i] == nil {
+			continue
+		}
+		e := GeoEntry{ID: types.ID(m), Pos: types.Point{Lat: positions[i].Latitude, Lng: positions[i].Longitude}}
+		if statuses[i] == nil {
+			stale = append(stale, e)
+			continue
+		}
+		v, _ := statuses[i].(string)
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil && ms > 1 && ms < cutoff.UnixMilli() {
+			stale = append(stale, e)
+			continue
+		}
+		fresh = append(fresh, e)
+	}
+	return fresh, stale, nil
+}
+
 // ---------------------------------------------------------------------------
 // Firebase RTDB read (used by the background poller)
 // ---------------------------------------------------------------------------
 
 // rtdbUserEntry mirrors a user entry stored in Firebase RTDB.
 type rtdbUserEntry struct {
-	Lat    float64 `json:"lat"`
-	Lng    float64 `json:"lng"`
-	Status string  `json:"status"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+	Status  string  `json:"status"`
+	Geohash string  `json:"geohash"`
 }
 
 // rtdbNodeAndStatus returns the RTDB node path and the active status string
@@ -197,9 +300,25 @@ func rtdbNodeAndStatus(userType string) (node, activeStatus string) {
 	return "driver_locations", "online"
 }
 
+// rtdbInactiveStatus is the status written when a user stops being active.
+func rtdbInactiveStatus(userType string) string {
+	if userType == "passenger" {
+		return "idle"
+	}
+	return "offline"
+}
+
+// RTDBEnabled reports whether the store has a Firebase RTDB client.
+func (s *Store) RTDBEnabled() bool {
+	return s.dbClient != nil
+}
+
 // FetchActiveUsersFromRTDB reads all currently active users of the given type
 // from Firebase RTDB and returns them as GeoEntry slices ready for SetGeo.
 func (s *Store) FetchActiveUsersFromRTDB(ctx context.Context, userType string) ([]GeoEntry, error) {
+	if s.dbClient == nil {
+		return nil, ErrRTDBDisabled
+	}
 	node, activeStatus := rtdbNodeAndStatus(userType)
 	ref := s.dbClient.NewRef(node)
 
@@ -218,10 +337,119 @@ func (s *Store) FetchActiveUsersFromRTDB(ctx context.Context, userType string) (
 	return entries, nil
 }
 
+// FetchNearbyUsersFromRTDB pushes a radius query down to RTDB by fetching only
+// the geohash prefix ranges covering the circle, then filters status and exact
+// distance in process. Entries must carry a "geohash" child (written by
+// WriteUserToRTDB; mobile clients must write it too) and the node needs
+// ".indexOn": ["geohash", "status"] in the RTDB rules.
+func (s *Store) FetchNearbyUsersFromRTDB(ctx context.Context, lat, lng, radiusKm float64, userType string) ([]NearbyUser, error) {
+	if s.dbClient == nil {
+		return nil, ErrRTDBDisabled
+	}
+	node, activeStatus := rtdbNodeAndStatus(userType)
+	ref := s.dbClient.NewRef(node)
+
+	var entries []GeoEntry
+	for _, prefix := range geohashCover(lat, lng, radiusKm) {
+		var data map[string]rtdbUserEntry
+		if err := ref.OrderByChild("geohash").StartAt(prefix).EndAt(prefix+"\uf8ff").Get(ctx, &data); err != nil {
+			return nil, fmt.Errorf("RTDB geohash fetch %s %s: %w", userType, prefix, err)
+		}
+		for id, e := range data {
+			if e.Status != activeStatus {
+				continue
+			}
+			entries = append(entries, GeoEntry{ID: types.ID(id), Pos: types.Point{Lat: e.Lat, Lng: e.Lng}})
+		}
+	}
+	return filterNearby(entries, types.Point{Lat: lat, Lng: lng}, radiusKm), nil
+}
+
+// WriteUserToRTDB writes one user's position to RTDB with the active status
+// for its type, in the same shape the mobile clients write.
+func (s *Store) WriteUserToRTDB(ctx context.Context, userType string, e GeoEntry) error {
+	if s.dbClient == nil {
+		return ErrRTDBDisabled
+	}
+	node, activeStatus := rtdbNodeAndStatus(userType)
+	err := s.dbClient.NewRef(node+"/"+string(e.ID)).Set(ctx, map[string]interface{}{
+		"lat":       e.Pos.Lat,
+		"lng":       e.Pos.Lng,
+		"status":    activeStatus,
+		"geohash":   Geohash(e.Pos.Lat, e.Pos.Lng),
+		"timestamp": time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return fmt.Errorf("RTDB write %s %s: %w", userType, e.ID, err)
+	}
+	return nil
+}
+
+// MarkInactiveInRTDB flips a user's RTDB status to inactive so the poller and
+// nearby queries stop returning it; the last position is kept.
+func (s *Store) MarkInactiveInRTDB(ctx context.Context, userType string, id types.ID) error {
+	if s.dbClient == nil {
+		return ErrRTDBDisabled
+	}
+	node, _ := rtdbNodeAndStatus(userType)
+	err := s.dbClient.NewRef(node+"/"+string(id)).Update(ctx, map[string]interface{}{
+		"status":    rtdbInactiveStatus(userType),
+		"timestamp": time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return fmt.Errorf("RTDB mark inactive %s %s: %w", userType, id, err)
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Postgres
 // ---------------------------------------------------------------------------
 
+// location_snapshots.recorded_at is a TIMESTAMP without time zone, so times
+// are written and queried in UTC.
+
+// AppendSnapshot persists one position sample.
 func (s *Store) AppendSnapshot(ctx context.Context, snap Snapshot) error {
-	return errors.New("not implemented")
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO location_snapshots (user_id, user_type, lat, lng, recorded_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		string(snap.UserID), snap.UserType, snap.Position.Lat, snap.Position.Lng, snap.RecordedAt.UTC(),
+	)
+	return err
+}
+
+// ListSnapshots returns the user's samples recorded in [from, to], oldest first.
+func (s *Store) ListSnapshots(ctx context.Context, userID types.ID, from, to time.Time) ([]Snapshot, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+snapshotColumns+`
+		FROM location_snapshots
+		WHERE user_id = $1 AND recorded_at BETWEEN $2 AND $3
+		ORDER BY recorded_at, id`,
+		string(userID), from.UTC(), to.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanSnapshot)
+}
+
+// snapshotColumns is read by scanSnapshot.
+const snapshotColumns = `id, user_id, user_type, lat, lng, recorded_at`
+
+func scanSnapshot(row pgx.CollectableRow) (Snapshot, error) {
+	var snap Snapshot
+	var id string
+	err := row.Scan(&snap.ID, &id, &snap.UserType, &snap.Position.Lat, &snap.Position.Lng, &snap.RecordedAt)
+	snap.UserID = types.ID(id)
+	return snap, err
+}
+
+// PruneSnapshots deletes samples recorded before `before` and returns how many went.
+func (s *Store) PruneSnapshots(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM location_snapshots WHERE recorded_at < $1`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
@@ -3,6 +3,8 @@ package location
 import (
 	"context"
 	"errors"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,7 +15,7 @@ import (
 
 // newTestRedis connects to a local Redis instance and skips the test if it is
 // unavailable. The connection is closed automatically via t.Cleanup.
-func newTestRedis(t *testing.T) *redis.Client {
+func newTestRedis(t testing.TB) *redis.Client {
 	t.Helper()
 	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -33,7 +35,7 @@ func newTestStore(rdb *redis.Client) *Store {
 
 // cleanupMember removes a GEO member and its status key so each test starts
 // from a clean state.
-func cleanupMember(t *testing.T, rdb *redis.Client, userType string, id types.ID) {
+func cleanupMember(t testing.TB, rdb *redis.Client, userType string, id types.ID) {
 	t.Helper()
 	ctx := context.Background()
 	_ = rdb.ZRem(ctx, geoSetKey(userType), string(id))
@@ -131,3 +133,146 @@ func TestGetNearbyUsersFromRedis_LazyDeletion(t *testing.T) {
 		t.Errorf("expected driver %s to be removed from GEO set by lazy deletion; ZScore err: %v", id, err)
 	}
 }
+
+func TestScanHeartbeats_SplitsFreshAndStale(t *testing.T) {
+	rdb := newTestRedis(t)
+	store := newTestStore(rdb)
+	ctx := context.Background()
+
+	const userType = "driver"
+	fresh := types.ID("test-geo-driver-beat-fresh")
+	stale := types.ID("test-geo-driver-beat-stale")
+	pos := types.Point{Lat: 25.033964, Lng: 121.564468}
+	for _, id := range []types.ID{fresh, stale} {
+		cleanupMember(t, rdb, userType, id)
+		t.Cleanup(func() { cleanupMember(t, rdb, userType, id) })
+	}
+
+	if err := store.SetGeo(ctx, []GeoEntry{{ID: fresh, Pos: pos}, {ID: stale, Pos: pos}}, userType); err != nil {
+		t.Fatalf("SetGeo: %v", err)
+	}
+	old := strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10)
+	if err := rdb.Set(ctx, statusKey(userType, stale), old, time.Minute).Err(); err != nil {
+		t.Fatalf("backdate heartbeat: %v", err)
+	}
+
+	gotFresh, gotStale, err := store.ScanHeartbeats(ctx, userType, time.Now().Add(-30*time.Second))
+	if err != nil {
+		t.Fatalf("ScanHeartbeats: %v", err)
+	}
+	has := func(list []GeoEntry, id types.ID) bool {
+		for _, e := range list {
+			if e.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	if !has(gotFresh, fresh) || has(gotStale, fresh) {
+		t.Errorf("%s should be fresh", fresh)
+	}
+	if !has(gotStale, stale) || has(gotFresh, stale) {
+		t.Errorf("%s should be stale", stale)
+	}
+}
+
+// TestAdmit_ConcurrentUpdates fires simultaneous updates for one driver and
+// checks that exactly one passes the throttle, then that an older sequence
+// number is rejected as stale once the interval has passed.
+func TestAdmit_ConcurrentUpdates(t *testing.T) {
+	rdb := newTestRedis(t)
+	store := newTestStore(rdb)
+	ctx := context.Background()
+	id := types.ID("test-admit-driver")
+	_ = rdb.Del(ctx, metaKey("driver", id))
+	t.Cleanup(func() { _ = rdb.Del(ctx, metaKey("driver", id)) })
+
+	const n = 50
+	now := time.Now()
+	results := make(chan string, n)
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+		go func(seq int64) {
+			defer wg.Done()
+			res, err := store.Admit(ctx, "driver", id, seq, now)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results <- res
+		}(int64(i))
+	}
+	wg.Wait()
+	close(results)
+	counts := map[string]int{}
+	for r := range results {
+		counts[r]++
+	}
+	if counts[AdmitAccepted] != 1 || counts[AdmitAccepted]+counts[AdmitThrottled]+counts[AdmitStale] != n {
+		t.Fatalf("admissions = %v, want exactly one accepted", counts)
+	}
+
+	later := now.Add(minUpdateInterval)
+	if res, _ := store.Admit(ctx, "driver", id, 0, later); res != AdmitAccepted {
+		t.Errorf("unsequenced update after interval = %q, want accepted", res)
+	}
+	seq, _ := rdb.HGet(ctx, metaKey("driver", id), "seq").Int64()
+	if res, _ := store.Admit(ctx, "driver", id, seq, later.Add(minUpdateInterval)); res != AdmitStale {
+		t.Errorf("repeated seq %d = %q, want stale", seq, res)
+	}
+	if res, _ := store.Admit(ctx, "driver", id, seq+1, later.Add(minUpdateInterval)); res != AdmitAccepted {
+		t.Errorf("next seq = %q, want accepted", res)
+	}
+}
+
+// BenchmarkSetGeo_Pipelined measures one driver position update as SetGeo
+// writes it: GEOADD and the status SET with its TTL in a single round trip.
+// Compare with BenchmarkSetGeo_Sequential; at the bench tool's 50–100 updates
+// per second either fits, but the pipelined write leaves headroom for many
+// API instances sharing one Redis. Run with a local Redis:
+//
+//	go test ./internal/modules/location -run '^$' -bench SetGeo
+func BenchmarkSetGeo_Pipelined(b *testing.B) {
+	rdb := newTestRedis(b)
+	store := newTestStore(rdb)
+	ctx := context.Background()
+	id := types.ID("bench-geo-driver")
+	b.Cleanup(func() { cleanupMember(b, rdb, "driver", id) })
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := store.SetGeo(ctx, []GeoEntry{{ID: id, Pos: taipei101}}, "driver"); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "updates/s")
+}
+
+// BenchmarkSetGeo_Sequential is the unpipelined baseline: GEOADD, SET and
+// EXPIRE as three round trips.
+func BenchmarkSetGeo_Sequential(b *testing.B) {
+	rdb := newTestRedis(b)
+	ctx := context.Background()
+	id := types.ID("bench-geo-driver")
+	b.Cleanup(func() { cleanupMember(b, rdb, "driver", id) })
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			err := rdb.GeoAdd(ctx, geoSetKey("driver"), &redis.GeoLocation{Name: string(id), Longitude: taipei101.Lng, Latitude: taipei101.Lat}).Err()
+			if err == nil {
+				err = rdb.Set(ctx, statusKey("driver", id), "1", 0).Err()
+			}
+			if err == nil {
+				err = rdb.Expire(ctx, statusKey("driver", id), statusTTL).Err()
+			}
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "updates/s")
+}
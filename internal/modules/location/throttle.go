@@ -0,0 +1,104 @@
+// README: Live-update admission — per-user throttle and sequence check, done atomically in Redis with a Lua script.
+package location
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ark/internal/types"
+)
+
+// minUpdateInterval is the shortest gap between two accepted position
+// updates from one user; faster updates are dropped as throttled.
+const minUpdateInterval = time.Second
+
+var (
+	// ErrThrottled is returned when a position update arrives within
+	// minUpdateInterval of the user's last accepted one.
+	ErrThrottled = errors.New("location: update throttled")
+	// ErrStaleUpdate is returned when an update's sequence number is not
+	// newer than the last accepted one, e.g. a retried or reordered request.
+	ErrStaleUpdate = errors.New("location: stale update")
+	// ErrUnavailable is returned when a position update cannot be admitted
+	// or stored, e.g. while Redis is down; the client should retry later.
+	ErrUnavailable = errors.New("location: position updates temporarily unavailable")
+)
+
+// Admission outcomes returned by UpdateGate.
+const (
+	AdmitAccepted  = "accepted"
+	AdmitThrottled = "throttled"
+	AdmitStale     = "stale"
+)
+
+// UpdateGate decides whether a live position update is written. seq 0 skips
+// the ordering check. Implemented by Store.
+type UpdateGate interface {
+	Admit(ctx context.Context, userType string, id types.ID, seq int64, at time.Time) (string, error)
+}
+
+func metaKey(userType string, id types.ID) string {
+	return userType + "_meta:" + string(id)
+}
+
+// admitScript compares and sets the user's last accepted sequence number and
+// time in one step, so two concurrent updates cannot both pass the checks.
+//
+//	KEYS[1] meta hash; ARGV: seq, now (ms), min interval (ms), ttl (ms)
+var admitScript = redis.NewScript(`
+local last = redis.call('HMGET', KEYS[1], 'seq', 'at')
+local seq = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+if seq > 0 and last[1] and seq <= tonumber(last[1]) then
+	return 'stale'
+end
+if last[2] and now - tonumber(last[2]) < tonumber(ARGV[3]) then
+	return 'throttled'
+end
+if seq > 0 then
+	redis.call('HSET', KEYS[1], 'seq', ARGV[1], 'at', ARGV[2])
+else
+	redis.call('HSET', KEYS[1], 'at', ARGV[2])
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return 'accepted'
+`)
+
+// Admit runs admitScript for one update. The meta key lives as long as the
+// user's status key, so an offline user's next update is always accepted.
+func (s *Store) Admit(ctx context.Context, userType string, id types.ID, seq int64, at time.Time) (string, error) {
+	res, err := admitScript.Run(ctx, s.redis, []string{metaKey(userType, id)},
+		seq, at.UnixMilli(), minUpdateInterval.Milliseconds(), statusTTL.Milliseconds()).Text()
+	if err != nil {
+		return "", fmt.Errorf("admit %s %s: %w", userType, id, err)
+	}
+	return res, nil
+}
+
+// SetUpdateGate makes UpdatePosition admit updates through g; nil disables
+// throttling and ordering.
+func (s *Service) SetUpdateGate(g UpdateGate) {
+	s.gate = g
+}
+
+// admit maps the gate's answer to ErrThrottled or ErrStaleUpdate.
+func (s *Service) admit(ctx context.Context, u Update) error {
+	if s.gate == nil {
+		return nil
+	}
+	res, err := s.gate.Admit(ctx, u.UserType, u.UserID, u.Seq, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	switch res {
+	case AdmitThrottled:
+		return ErrThrottled
+	case AdmitStale:
+		return ErrStaleUpdate
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// README: Dispatch lock — one Redis key per order held while offers go out or while the passenger edits the pickup, so the two never overlap.
+package matching
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ark/internal/errreport"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// dispatchLockTTL bounds how long a crashed holder can block an order.
+const dispatchLockTTL = 30 * time.Second
+
+func dispatchKey(orderID types.ID) string {
+	return "matching:dispatch:" + string(orderID)
+}
+
+// unlockScript deletes the lock only if it still holds the caller's token, so
+// a holder whose lock expired cannot release someone else's.
+//
+//	KEYS[1] lock key; ARGV[1] token
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// LockDispatch takes the order's dispatch lock with token. It returns false
+// when someone else holds it.
+func (s *Store) LockDispatch(ctx context.Context, orderID types.ID, token string) (bool, error) {
+	ok, err := s.redis.SetNX(ctx, dispatchKey(orderID), token, dispatchLockTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("lock dispatch %s: %w", orderID, err)
+	}
+	return ok, nil
+}
+
+// UnlockDispatch releases the order's dispatch lock if token still holds it.
+func (s *Store) UnlockDispatch(ctx context.Context, orderID types.ID, token string) error {
+	if err := unlockScript.Run(ctx, s.redis, []string{dispatchKey(orderID)}, token).Err(); err != nil {
+		return fmt.Errorf("unlock dispatch %s: %w", orderID, err)
+	}
+	return nil
+}
+
+// OrderVersion returns the order's current status_version.
+func (s *Store) OrderVersion(ctx context.Context, orderID types.ID) (int, error) {
+	var v int
+	err := s.db.QueryRow(ctx, `SELECT status_version FROM orders WHERE id = $1`, string(orderID)).Scan(&v)
+	return v, err
+}
+
+// ResetOrderNotification deletes the order's notification record, ending its
+// cooldown so the next round offers it again.
+func (s *Store) ResetOrderNotification(ctx context.Context, orderID types.ID) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM order_notifications WHERE order_id = $1`, string(orderID))
+	return err
+}
+
+// HoldDispatch implements order.DispatchLock: no offers go out for orderID
+// until release is called or dispatchLockTTL passes.
+func (s *Service) HoldDispatch(ctx context.Context, orderID types.ID) (func(context.Context), error) {
+	token := rand.Text()
+	ok, err := s.store.LockDispatch(ctx, orderID, token)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, order.ErrDispatchInFlight
+	}
+	return func(ctx context.Context) {
+		if err := s.store.UnlockDispatch(ctx, orderID, token); err != nil {
+			// The lock expires on its own after dispatchLockTTL.
+			errreport.Report(ctx, "matching", "unlock_dispatch", err, "order_id", orderID)
+		}
+	}, nil
+}
+
+// ResetDispatch implements order.DispatchLock: earlier offers of orderID are
+// forgotten and the next round searches around its current pickup.
+func (s *Service) ResetDispatch(ctx context.Context, orderID types.ID) error {
+	return s.store.ResetOrderNotification(ctx, orderID)
+}
+
+// beginOffer takes the dispatch lock for an offer round of o. ok is false when
+// a pickup edit holds the lock or has changed o since it was read; the order is
+// picked up again on a later tick.
+func (s *Service) beginOffer(ctx context.Context, o *order.Order) (release func(context.Context), ok bool, err error) {
+	release, err = s.HoldDispatch(ctx, o.ID)
+	if errors.Is(err, order.ErrDispatchInFlight) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	v, err := s.store.OrderVersion(ctx, o.ID)
+	if err != nil || v != o.StatusVersion {
+		release(ctx)
+		return nil, false, err
+	}
+	return release, true, nil
+}
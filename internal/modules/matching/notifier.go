@@ -0,0 +1,85 @@
+// README: Driver notifier that pushes new-order offers directly to driver FCM device tokens.
+package matching
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ark/internal/modules/notification"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// ErrNoDeviceToken is returned when a driver has no registered device to push to.
+var ErrNoDeviceToken = errors.New("matching: driver has no registered device token")
+
+// ErrPushMuted is returned when the driver's notification preferences block the offer.
+var ErrPushMuted = errors.New("matching: driver has muted order push notifications")
+
+// Notifier delivers a new-order offer to a single driver.
+type Notifier interface {
+	NotifyNewOrder(ctx context.Context, driverID types.ID, o *order.Order) error
+}
+
+// DriverPusher is the subset of notification.Service used by FCMNotifier.
+type DriverPusher interface {
+	Allows(ctx context.Context, userID types.ID, c notification.Category, ch notification.Channel) (bool, error)
+	DeviceTokens(ctx context.Context, userID types.ID) ([]string, error)
+	NotifyDriverNewOrder(ctx context.Context, deviceToken string, info notification.OrderInfo) error
+}
+
+// FCMNotifier implements Notifier on top of notification.Service.NotifyDriverNewOrder,
+// looking up the driver's device tokens first.
+type FCMNotifier struct {
+	push DriverPusher
+}
+
+func NewFCMNotifier(push DriverPusher) *FCMNotifier {
+	return &FCMNotifier{push: push}
+}
+
+// NotifyNewOrder sends the offer to every device of the driver and succeeds if
+// at least one send succeeds.
+func (n *FCMNotifier) NotifyNewOrder(ctx context.Context, driverID types.ID, o *order.Order) error {
+	ok, err := n.push.Allows(ctx, driverID, notification.CategoryOrderUpdate, notification.ChannelPush)
+	if err != nil {
+		return fmt.Errorf("check notification preferences: %w", err)
+	}
+	if !ok {
+		return ErrPushMuted
+	}
+
+	tokens, err := n.push.DeviceTokens(ctx, driverID)
+	if err != nil {
+		return fmt.Errorf("lookup device tokens: %w", err)
+	}
+	if len(tokens) == 0 {
+		return ErrNoDeviceToken
+	}
+
+	info := orderInfo(o)
+	var errs []error
+	for _, token := range tokens {
+		if err := n.push.NotifyDriverNewOrder(ctx, token, info); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) < len(tokens) {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func orderInfo(o *order.Order) notification.OrderInfo {
+	return notification.OrderInfo{
+		OrderID:       o.ID,
+		PickupLat:     o.Pickup.Lat,
+		PickupLng:     o.Pickup.Lng,
+		DropoffLat:    o.Dropoff.Lat,
+		DropoffLng:    o.Dropoff.Lng,
+		EstimatedFee:  float64(o.EstimatedFee.Amount),
+		Requirements:  o.Requirements,
+		StatusVersion: o.StatusVersion,
+	}
+}
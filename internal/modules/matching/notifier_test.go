@@ -0,0 +1,90 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ark/internal/modules/notification"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type fakePusher struct {
+	tokens  []string
+	failFor map[string]bool
+	sent    []string
+	info    notification.OrderInfo
+	muted   bool
+}
+
+func (f *fakePusher) Allows(context.Context, types.ID, notification.Category, notification.Channel) (bool, error) {
+	return !f.muted, nil
+}
+
+func (f *fakePusher) DeviceTokens(context.Context, types.ID) ([]string, error) {
+	return f.tokens, nil
+}
+
+func (f *fakePusher) NotifyDriverNewOrder(_ context.Context, token string, info notification.OrderInfo) error {
+	if f.failFor[token] {
+		return errors.New("send failed")
+	}
+	f.sent = append(f.sent, token)
+	f.info = info
+	return nil
+}
+
+func TestFCMNotifier_NoTokens(t *testing.T) {
+	n := NewFCMNotifier(&fakePusher{})
+	err := n.NotifyNewOrder(context.Background(), "d1", &order.Order{ID: "o1"})
+	if !errors.Is(err, ErrNoDeviceToken) {
+		t.Fatalf("err = %v, want ErrNoDeviceToken", err)
+	}
+}
+
+func TestFCMNotifier_FallsThroughFailedToken(t *testing.T) {
+	p := &fakePusher{tokens: []string{"bad", "good"}, failFor: map[string]bool{"bad": true}}
+	o := &order.Order{
+		ID:           "o1",
+		Pickup:       types.Point{Lat: 25.03, Lng: 121.56},
+		EstimatedFee: types.Money{Amount: 150, Currency: "TWD"},
+	}
+	if err := NewFCMNotifier(p).NotifyNewOrder(context.Background(), "d1", o); err != nil {
+		t.Fatalf("NotifyNewOrder: %v", err)
+	}
+	if len(p.sent) != 1 || p.sent[0] != "good" {
+		t.Errorf("sent = %v, want [good]", p.sent)
+	}
+	if p.info.OrderID != "o1" || p.info.PickupLat != 25.03 || p.info.EstimatedFee != 150 {
+		t.Errorf("unexpected order info: %+v", p.info)
+	}
+}
+
+func TestFCMNotifier_SendsToEveryToken(t *testing.T) {
+	p := &fakePusher{tokens: []string{"phone", "tablet"}}
+	if err := NewFCMNotifier(p).NotifyNewOrder(context.Background(), "d1", &order.Order{ID: "o1"}); err != nil {
+		t.Fatalf("NotifyNewOrder: %v", err)
+	}
+	if len(p.sent) != 2 {
+		t.Errorf("sent = %v, want both devices", p.sent)
+	}
+}
+
+func TestFCMNotifier_AllTokensFail(t *testing.T) {
+	p := &fakePusher{tokens: []string{"a"}, failFor: map[string]bool{"a": true}}
+	if err := NewFCMNotifier(p).NotifyNewOrder(context.Background(), "d1", &order.Order{ID: "o1"}); err == nil {
+		t.Fatal("expected error when every send fails")
+	}
+}
+
+func TestFCMNotifier_Muted(t *testing.T) {
+	p := &fakePusher{tokens: []string{"a"}, muted: true}
+	err := NewFCMNotifier(p).NotifyNewOrder(context.Background(), "d1", &order.Order{ID: "o1"})
+	if !errors.Is(err, ErrPushMuted) {
+		t.Fatalf("err = %v, want ErrPushMuted", err)
+	}
+	if len(p.sent) != 0 {
+		t.Fatalf("sent = %v, want none", p.sent)
+	}
+}
@@ -0,0 +1,297 @@
+// README: Dispatch offers — each driver an order is offered to gets a pending offer with a TTL, streamed to the driver app and expired server-side.
+package matching
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Offer statuses.
+const (
+	OfferPending   = "pending"
+	OfferAccepted  = "accepted"
+	OfferExpired   = "expired"
+	OfferWithdrawn = "withdrawn"
+)
+
+// Offer event types sent by StreamOffers.
+const (
+	EventOffer     = "offer"
+	EventExpired   = "expired"
+	EventWithdrawn = "withdrawn"
+	EventKeepalive = "keepalive"
+)
+
+const (
+	// offerExpiryInterval is how often lapsed offers are marked expired.
+	offerExpiryInterval = 5 * time.Second
+	// offerRecheck bounds how long a stream can miss an offer made or closed
+	// by another API instance.
+	offerRecheck = 2 * time.Second
+	// offerKeepalive is the longest a stream stays silent, so proxies do not
+	// drop an idle connection.
+	offerKeepalive = 15 * time.Second
+	// offerHookTimeout bounds closing an order's offers after it leaves dispatch.
+	offerHookTimeout = 5 * time.Second
+)
+
+// ErrOffersDisabled is returned by StreamOffers when no offer store is set.
+var ErrOffersDisabled = errors.New("matching: offers not enabled")
+
+// offerStats counts offers by outcome: "created", "accepted", "withdrawn" and
+// "expired", plus "streamed_only" offers delivered to an open stream after
+// their push failed. Exposed on /api/ops/debug/vars.
+var offerStats = expvar.NewMap("matching_offers")
+
+// Offer is an order offered to one driver until ExpiresAt.
+type Offer struct {
+	ID           int64
+	OrderID      types.ID
+	DriverID     types.ID
+	Pickup       types.Point
+	Dropoff      types.Point
+	RideType     string
+	EstimatedFee types.Money
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// OfferEvent is one message of a driver's offer stream. Offer is set for
+// EventOffer, with the TTL left when it was sent; OfferID and OrderID are set
+// for every event but EventKeepalive.
+type OfferEvent struct {
+	Type    string
+	Offer   *Offer
+	TTL     time.Duration
+	OfferID int64
+	OrderID types.ID
+}
+
+// OfferStore persists offers. Expiry times are computed by the database so
+// every instance agrees on when an offer lapses.
+type OfferStore interface {
+	// CreateOffers records a pending offer of orderID to each driver, expiring after ttl.
+	CreateOffers(ctx context.Context, orderID types.ID, driverIDs []types.ID, ttl time.Duration) error
+	// PendingOffers returns driverID's pending offers that have not lapsed, soonest to expire first.
+	PendingOffers(ctx context.Context, driverID types.ID) ([]Offer, error)
+	// ExpireOffers marks lapsed pending offers expired and returns their drivers.
+	ExpireOffers(ctx context.Context) ([]types.ID, error)
+	// CloseOffers closes orderID's pending offers, as accepted for acceptedBy
+	// and withdrawn for everyone else, and returns the drivers they went to.
+	CloseOffers(ctx context.Context, orderID types.ID, acceptedBy *types.ID) ([]types.ID, error)
+}
+
+// SetOffers records every offer round in store with the given TTL so drivers
+// can stream their offers. Register OfferHook and run RunOfferExpiry with it.
+func (s *Service) SetOffers(store OfferStore, ttl time.Duration) {
+	s.offers = store
+	s.offerTTL = ttl
+}
+
+// offerHub wakes the offer streams open on this instance.
+type offerHub struct {
+	mu   sync.Mutex
+	subs map[types.ID][]chan struct{}
+}
+
+func (h *offerHub) subscribe(driverID types.ID) chan struct{} {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[types.ID][]chan struct{})
+	}
+	h.subs[driverID] = append(h.subs[driverID], ch)
+	return ch
+}
+
+func (h *offerHub) unsubscribe(driverID types.ID, ch chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	list := h.subs[driverID]
+	for i, c := range list {
+		if c == ch {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(h.subs, driverID)
+	} else {
+		h.subs[driverID] = list
+	}
+}
+
+// signal wakes every stream of the given drivers without blocking; a stream
+// that is already due to wake misses nothing.
+func (h *offerHub) signal(driverIDs ...types.ID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, id := range driverIDs {
+		for _, ch := range h.subs[id] {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// streaming reports whether driverID has an offer stream open on this instance.
+func (h *offerHub) streaming(driverID types.ID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[driverID]) > 0
+}
+
+// recordOffers stores an offer of o to each selected driver and wakes their
+// streams. It reports false when no offer was recorded.
+func (s *Service) recordOffers(ctx context.Context, o *order.Order, driverIDs []types.ID) bool {
+	if s.offers == nil {
+		return false
+	}
+	if err := s.offers.CreateOffers(ctx, o.ID, driverIDs, s.offerTTL); err != nil {
+		errreport.Report(ctx, "matching", "create_offers", err, "order_id", o.ID)
+		return false
+	}
+	offerStats.Add("created", int64(len(driverIDs)))
+	s.offerHub.signal(driverIDs...)
+	return true
+}
+
+// StreamOffers sends driverID's pending offers, then every offer made to the
+// driver and every offer that expires or is withdrawn, until ctx is done. A
+// stream without pending offers opens with a keepalive. It returns nil when ctx ends and the first error of send otherwise.
+func (s *Service) StreamOffers(ctx context.Context, driverID types.ID, send func(OfferEvent) error) error {
+	if s.offers == nil {
+		return ErrOffersDisabled
+	}
+	// Subscribe before the first read so an offer made in between still wakes us.
+	wake := s.offerHub.subscribe(driverID)
+	defer s.offerHub.unsubscribe(driverID, wake)
+	recheck := time.NewTicker(offerRecheck)
+	defer recheck.Stop()
+
+	known := map[int64]Offer{}
+	var lastSent time.Time
+	for {
+		offers, err := s.offers.PendingOffers(ctx, driverID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		now := time.Now()
+		current := make(map[int64]Offer, len(offers))
+		for _, o := range offers {
+			current[o.ID] = o
+			if _, ok := known[o.ID]; ok {
+				continue
+			}
+			if err := send(OfferEvent{Type: EventOffer, Offer: &o, TTL: max(o.ExpiresAt.Sub(now), 0), OfferID: o.ID, OrderID: o.OrderID}); err != nil {
+				return err
+			}
+			lastSent = now
+		}
+		for id, o := range known {
+			if _, ok := current[id]; ok {
+				continue
+			}
+			typ := EventWithdrawn
+			if !now.Before(o.ExpiresAt) {
+				typ = EventExpired
+			}
+			if err := send(OfferEvent{Type: typ, OfferID: id, OrderID: o.OrderID}); err != nil {
+				return err
+			}
+			lastSent = now
+		}
+		known = current
+		if lastSent.IsZero() {
+			if err := send(OfferEvent{Type: EventKeepalive}); err != nil {
+				return err
+			}
+			lastSent = now
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-recheck.C:
+			if time.Since(lastSent) >= offerKeepalive {
+				if err := send(OfferEvent{Type: EventKeepalive}); err != nil {
+					return err
+				}
+				lastSent = time.Now()
+			}
+		}
+	}
+}
+
+// RunOfferExpiry marks lapsed offers expired so they close even when the
+// driver never answers, and tells open streams.
+func (s *Service) RunOfferExpiry(ctx context.Context) {
+	ticker := time.NewTicker(offerExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.offers == nil {
+				continue
+			}
+			drivers, err := s.offers.ExpireOffers(ctx)
+			if err != nil {
+				errreport.Report(ctx, "matching", "expire_offers", err)
+				continue
+			}
+			offerStats.Add("expired", int64(len(drivers)))
+			s.offerHub.signal(drivers...)
+		}
+	}
+}
+
+// OfferHook closes an order's pending offers once it leaves dispatch: the
+// accepting driver's offer as accepted, the others as withdrawn.
+func (s *Service) OfferHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if s.offers == nil || t.Sandbox || !dispatching(t.From) || dispatching(t.To) {
+			return
+		}
+		var acceptedBy *types.ID
+		if t.To == order.StatusApproaching || t.To == order.StatusAssigned {
+			acceptedBy = t.DriverID
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), offerHookTimeout)
+		defer cancel()
+		drivers, err := s.offers.CloseOffers(ctx, t.OrderID, acceptedBy)
+		if err != nil {
+			errreport.Report(ctx, "matching", "close_offers", err, "order_id", t.OrderID)
+			return
+		}
+		for _, id := range drivers {
+			if acceptedBy != nil && id == *acceptedBy {
+				offerStats.Add("accepted", 1)
+			} else {
+				offerStats.Add("withdrawn", 1)
+			}
+		}
+		s.offerHub.signal(drivers...)
+	}
+}
+
+// dispatching reports whether an order in status st may still be offered to drivers.
+func dispatching(st order.Status) bool {
+	return st == order.StatusWaiting || st == order.StatusScheduled
+}
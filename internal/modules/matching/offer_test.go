@@ -0,0 +1,186 @@
+package matching
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// fakeOfferStore keeps offers in memory; lapsed offers stay pending until
+// ExpireOffers runs, like the table.
+type fakeOfferStore struct {
+	mu     sync.Mutex
+	nextID int64
+	offers []*fakeOffer
+}
+
+type fakeOffer struct {
+	Offer
+	status string
+}
+
+func (f *fakeOfferStore) CreateOffers(_ context.Context, orderID types.ID, driverIDs []types.ID, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for _, id := range driverIDs {
+		f.nextID++
+		f.offers = append(f.offers, &fakeOffer{
+			Offer:  Offer{ID: f.nextID, OrderID: orderID, DriverID: id, CreatedAt: now, ExpiresAt: now.Add(ttl)},
+			status: OfferPending,
+		})
+	}
+	return nil
+}
+
+func (f *fakeOfferStore) PendingOffers(_ context.Context, driverID types.ID) ([]Offer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Offer
+	for _, o := range f.offers {
+		if o.DriverID == driverID && o.status == OfferPending && o.ExpiresAt.After(time.Now()) {
+			out = append(out, o.Offer)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOfferStore) ExpireOffers(context.Context) ([]types.ID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []types.ID
+	for _, o := range f.offers {
+		if o.status == OfferPending && !o.ExpiresAt.After(time.Now()) {
+			o.status = OfferExpired
+			out = append(out, o.DriverID)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOfferStore) CloseOffers(_ context.Context, orderID types.ID, acceptedBy *types.ID) ([]types.ID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []types.ID
+	for _, o := range f.offers {
+		if o.OrderID != orderID || o.status != OfferPending {
+			continue
+		}
+		o.status = OfferWithdrawn
+		if acceptedBy != nil && o.DriverID == *acceptedBy {
+			o.status = OfferAccepted
+		}
+		out = append(out, o.DriverID)
+	}
+	return out, nil
+}
+
+func (f *fakeOfferStore) status(id int64) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.offers[id-1].status
+}
+
+// streamEvents runs StreamOffers for driverID and returns its events.
+func streamEvents(t *testing.T, svc *Service, driverID types.ID) <-chan OfferEvent {
+	t.Helper()
+	events := make(chan OfferEvent, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.StreamOffers(ctx, driverID, func(e OfferEvent) error {
+			events <- e
+			return nil
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("StreamOffers: %v", err)
+		}
+	})
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan OfferEvent) OfferEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no offer event")
+		return OfferEvent{}
+	}
+}
+
+func TestStreamOffers(t *testing.T) {
+	store := &fakeOfferStore{}
+	svc := &Service{}
+	svc.SetOffers(store, time.Minute)
+	ctx := context.Background()
+
+	events := streamEvents(t, svc, "d1")
+	if e := nextEvent(t, events); e.Type != EventKeepalive {
+		t.Fatalf("first event = %+v, want a keepalive on an empty stream", e)
+	}
+
+	if !svc.recordOffers(ctx, &order.Order{ID: "o1"}, []types.ID{"d1", "d2"}) {
+		t.Fatal("recordOffers reported no offers")
+	}
+	e := nextEvent(t, events)
+	if e.Type != EventOffer || e.OrderID != "o1" || e.Offer == nil || e.TTL <= 0 || e.TTL > time.Minute {
+		t.Fatalf("offer event = %+v", e)
+	}
+
+	// d2 accepts: d1's offer is withdrawn, d2's accepted.
+	d2 := types.ID("d2")
+	svc.OfferHook()(ctx, order.Transition{OrderID: "o1", DriverID: &d2, From: order.StatusWaiting, To: order.StatusApproaching})
+	if e := nextEvent(t, events); e.Type != EventWithdrawn || e.OfferID != 1 {
+		t.Fatalf("event = %+v, want offer 1 withdrawn", e)
+	}
+	if store.status(1) != OfferWithdrawn || store.status(2) != OfferAccepted {
+		t.Fatalf("statuses = %s, %s", store.status(1), store.status(2))
+	}
+}
+
+func TestStreamOffers_ExpiresWithoutAnswer(t *testing.T) {
+	store := &fakeOfferStore{}
+	svc := &Service{}
+	svc.SetOffers(store, 50*time.Millisecond)
+
+	svc.recordOffers(context.Background(), &order.Order{ID: "o1"}, []types.ID{"d1"})
+	events := streamEvents(t, svc, "d1")
+	if e := nextEvent(t, events); e.Type != EventOffer {
+		t.Fatalf("event = %+v, want the pending offer", e)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	drivers, _ := store.ExpireOffers(context.Background())
+	if len(drivers) != 1 || store.status(1) != OfferExpired {
+		t.Fatalf("expired for %v, status %s", drivers, store.status(1))
+	}
+	svc.offerHub.signal(drivers...)
+	if e := nextEvent(t, events); e.Type != EventExpired || e.OrderID != "o1" {
+		t.Fatalf("event = %+v, want offer expired", e)
+	}
+}
+
+func TestOfferHook_IgnoresDispatchMoves(t *testing.T) {
+	store := &fakeOfferStore{}
+	svc := &Service{}
+	svc.SetOffers(store, time.Minute)
+	svc.recordOffers(context.Background(), &order.Order{ID: "o1"}, []types.ID{"d1"})
+
+	svc.OfferHook()(context.Background(), order.Transition{OrderID: "o1", From: order.StatusScheduled, To: order.StatusWaiting})
+	if store.status(1) != OfferPending {
+		t.Fatalf("status = %s, want pending while the order is still dispatched", store.status(1))
+	}
+	svc.OfferHook()(context.Background(), order.Transition{OrderID: "o1", From: order.StatusWaiting, To: order.StatusCancelled})
+	if store.status(1) != OfferWithdrawn {
+		t.Fatalf("status = %s, want withdrawn after cancel", store.status(1))
+	}
+}
@@ -0,0 +1,253 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+var _ OrderMatcher = (*order.Service)(nil)
+
+// orderMatcherHarness is an OrderMatcher with the hooks the contract suite
+// needs around it.
+type orderMatcherHarness struct {
+	matcher OrderMatcher
+	// seed stores an order with the given status and no driver.
+	seed func(id types.ID, st order.Status)
+	// get returns the order's status and driver.
+	get func(id types.ID) (order.Status, *types.ID)
+	// race makes another writer change the order right after the next Match
+	// of it has read it.
+	race func(id types.ID)
+}
+
+// runOrderMatcherContract checks the behavior documented on OrderMatcher, so
+// the test double used by matching cannot drift from order.Service.
+func runOrderMatcherContract(t *testing.T, newHarness func() orderMatcherHarness) {
+	ctx := context.Background()
+	const driver = types.ID("d1")
+
+	t.Run("waiting order is matched", func(t *testing.T) {
+		h := newHarness()
+		h.seed("o1", order.StatusWaiting)
+		if err := h.matcher.Match(ctx, order.MatchCommand{OrderID: "o1", DriverID: driver}); err != nil {
+			t.Fatalf("Match: %v", err)
+		}
+		st, d := h.get("o1")
+		if st != order.StatusApproaching || d == nil || *d != driver {
+			t.Fatalf("after Match: status %s, driver %v; want approaching, %s", st, d, driver)
+		}
+		err := h.matcher.Match(ctx, order.MatchCommand{OrderID: "o1", DriverID: "d2"})
+		if !errors.Is(err, order.ErrInvalidState) {
+			t.Fatalf("second Match: got %v, want ErrInvalidState", err)
+		}
+		if _, d := h.get("o1"); d == nil || *d != driver {
+			t.Fatalf("second Match changed the driver to %v", d)
+		}
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		h := newHarness()
+		err := h.matcher.Match(ctx, order.MatchCommand{OrderID: "missing", DriverID: driver})
+		if !errors.Is(err, order.ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("claimed scheduled order", func(t *testing.T) {
+		h := newHarness()
+		h.seed("o1", order.StatusAssigned)
+		err := h.matcher.Match(ctx, order.MatchCommand{OrderID: "o1", DriverID: driver})
+		if !errors.Is(err, order.ErrActorNotAllowed) {
+			t.Fatalf("got %v, want ErrActorNotAllowed", err)
+		}
+		if st, _ := h.get("o1"); st != order.StatusAssigned {
+			t.Fatalf("status = %s, want assigned", st)
+		}
+	})
+
+	for _, st := range []order.Status{
+		order.StatusScheduled, order.StatusApproaching, order.StatusArrived, order.StatusDriving,
+		order.StatusPayment, order.StatusComplete, order.StatusCancelled, order.StatusExpired,
+	} {
+		t.Run("not waiting/"+string(st), func(t *testing.T) {
+			h := newHarness()
+			h.seed("o1", st)
+			err := h.matcher.Match(ctx, order.MatchCommand{OrderID: "o1", DriverID: driver})
+			if !errors.Is(err, order.ErrInvalidState) {
+				t.Fatalf("got %v, want ErrInvalidState", err)
+			}
+			if got, d := h.get("o1"); got != st || d != nil {
+				t.Fatalf("order changed to %s, driver %v", got, d)
+			}
+		})
+	}
+
+	t.Run("concurrent change", func(t *testing.T) {
+		h := newHarness()
+		h.seed("o1", order.StatusWaiting)
+		h.race("o1")
+		err := h.matcher.Match(ctx, order.MatchCommand{OrderID: "o1", DriverID: driver})
+		if !errors.Is(err, order.ErrConflict) {
+			t.Fatalf("got %v, want ErrConflict", err)
+		}
+		if _, d := h.get("o1"); d != nil {
+			t.Fatalf("driver set to %v despite the conflict", d)
+		}
+	})
+}
+
+func TestOrderMatcherContract(t *testing.T) {
+	t.Run("order.Service", func(t *testing.T) {
+		runOrderMatcherContract(t, func() orderMatcherHarness {
+			store := &memOrderStore{orders: map[types.ID]*order.Order{}, raced: map[types.ID]bool{}}
+			return orderMatcherHarness{
+				matcher: order.NewService(store, nil),
+				seed:    store.seed,
+				get:     store.status,
+				race:    store.race,
+			}
+		})
+	})
+	t.Run("fakeOrderMatcher", func(t *testing.T) {
+		runOrderMatcherContract(t, func() orderMatcherHarness {
+			f := newFakeOrderMatcher()
+			return orderMatcherHarness{
+				matcher: f,
+				seed:    f.seed,
+				get:     f.status,
+				race:    f.race,
+			}
+		})
+	})
+}
+
+// fakeOrderMatcher is the OrderMatcher double for matching tests; the
+// contract suite keeps it honest.
+type fakeOrderMatcher struct {
+	mu      sync.Mutex
+	orders  map[types.ID]order.Status
+	drivers map[types.ID]types.ID
+	raced   map[types.ID]bool
+}
+
+func newFakeOrderMatcher() *fakeOrderMatcher {
+	return &fakeOrderMatcher{
+		orders:  map[types.ID]order.Status{},
+		drivers: map[types.ID]types.ID{},
+		raced:   map[types.ID]bool{},
+	}
+}
+
+func (f *fakeOrderMatcher) Match(_ context.Context, cmd order.MatchCommand) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st, ok := f.orders[cmd.OrderID]
+	switch {
+	case !ok:
+		return order.ErrNotFound
+	case st == order.StatusAssigned:
+		return order.ErrActorNotAllowed
+	case st != order.StatusWaiting:
+		return order.ErrInvalidState
+	case f.raced[cmd.OrderID]:
+		delete(f.raced, cmd.OrderID)
+		return order.ErrConflict
+	}
+	f.orders[cmd.OrderID] = order.StatusApproaching
+	f.drivers[cmd.OrderID] = cmd.DriverID
+	return nil
+}
+
+func (f *fakeOrderMatcher) seed(id types.ID, st order.Status) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.orders[id] = st
+}
+
+func (f *fakeOrderMatcher) status(id types.ID) (order.Status, *types.ID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.drivers[id]
+	if !ok {
+		return f.orders[id], nil
+	}
+	return f.orders[id], &d
+}
+
+func (f *fakeOrderMatcher) race(id types.ID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.raced[id] = true
+}
+
+// memOrderStore is the part of order.OrderStore that Match uses, in memory.
+// Any other method panics on the nil embedded interface.
+type memOrderStore struct {
+	order.OrderStore
+
+	mu     sync.Mutex
+	orders map[types.ID]*order.Order
+	raced  map[types.ID]bool
+}
+
+func (m *memOrderStore) seed(id types.ID, st order.Status) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders[id] = &order.Order{ID: id, PassengerID: "p1", Status: st, OrderType: "instant"}
+}
+
+func (m *memOrderStore) status(id types.ID) (order.Status, *types.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o := m.orders[id]
+	return o.Status, o.DriverID
+}
+
+func (m *memOrderStore) race(id types.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.raced[id] = true
+}
+
+func (m *memOrderStore) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	cp := *o
+	if m.raced[id] {
+		// The caller holds the old copy; another writer moves the stored one on.
+		delete(m.raced, id)
+		o.StatusVersion++
+	}
+	return &cp, nil
+}
+
+func (m *memOrderStore) UpdateStatus(_ context.Context, id types.ID, from, to order.Status, version int, driverID *types.ID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[id]
+	if !ok {
+		return false, order.ErrNotFound
+	}
+	if o.Status != from || o.StatusVersion != version {
+		return false, nil
+	}
+	o.Status = to
+	o.StatusVersion++
+	if driverID != nil {
+		o.DriverID = driverID
+	}
+	return true, nil
+}
+
+func (m *memOrderStore) AppendEvent(context.Context, *order.Event) error {
+	return nil
+}
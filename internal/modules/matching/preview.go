@@ -0,0 +1,144 @@
+// README: Nearby-driver preview for passengers — anonymized car markers, count and pickup ETA.
+package matching
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+const (
+	// previewGridDeg snaps preview positions to a ~150 m grid so exact driver
+	// locations are never exposed to passengers.
+	previewGridDeg = 0.0015
+	// previewMaxMarkers caps how many anonymized positions are returned.
+	previewMaxMarkers = 20
+	// roadFactor converts straight-line distance into an approximate road distance.
+	roadFactor = 1.3
+	// etaFloor is the minimum quoted ETA, covering driver reaction time.
+	etaFloor = 60 * time.Second
+)
+
+// ErrInvalidPosition is returned for coordinates outside the valid lat/lng range.
+var ErrInvalidPosition = errors.New("matching: invalid position")
+
+// NearbyPreview is the "cars around you" view shown before booking.
+type NearbyPreview struct {
+	Count     int           // available drivers within the matching radius
+	Positions []types.Point // anonymized positions, at most previewMaxMarkers
+	// PickupETA is the estimated time for the nearest driver to reach the
+	// passenger; nil when no driver is available.
+	PickupETA *time.Duration
+}
+
+// PreviewNearbyDrivers returns anonymized positions, the count of available
+// drivers within the matching radius and an estimated pickup ETA.
+func (s *Service) PreviewNearbyDrivers(ctx context.Context, p types.Point) (*NearbyPreview, error) {
+	if p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+		return nil, ErrInvalidPosition
+	}
+	if s.location == nil {
+		return nil, errors.New("matching: location service not configured")
+	}
+	drivers, err := s.location.GetNearbyDrivers(ctx, p.Lat, p.Lng, s.radiusAt(p))
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &NearbyPreview{Count: len(drivers), Positions: make([]types.Point, 0, min(len(drivers), previewMaxMarkers))}
+	salt := time.Now().Truncate(time.Hour).Unix()
+	for i, d := range drivers {
+		if i == previewMaxMarkers {
+			break
+		}
+		preview.Positions = append(preview.Positions, anonymizePoint(string(d.DriverID), salt, types.Point{Lat: d.Lat, Lng: d.Lng}))
+	}
+	if len(drivers) > 0 {
+		// Results are sorted by distance, so the first driver is the nearest.
+		eta := s.estimatePickupETA(drivers[0].Distance)
+		preview.PickupETA = &eta
+	}
+	return preview, nil
+}
+
+// EstimatePickup returns the ETA of the best (nearest) available driver able to
+// serve requirements within the matching radius; ok is false when none is
+// available. It implements order.PickupEstimator for the fastest-pickup
+// booking mode; failing to read driver positions wraps order.ErrPickupUnavailable.
+func (s *Service) EstimatePickup(ctx context.Context, pickup types.Point, requirements []string) (time.Duration, bool, error) {
+	if s.location == nil {
+		return 0, false, errors.New("matching: location service not configured")
+	}
+	drivers, err := s.location.GetNearbyDrivers(ctx, pickup.Lat, pickup.Lng, s.pickupRadius(pickup, requirements))
+	if err != nil {
+		return 0, false, fmt.Errorf("%w: %v", order.ErrPickupUnavailable, err)
+	}
+	drivers, err = s.filterCapable(ctx, drivers, requirements)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(drivers) == 0 {
+		return 0, false, nil
+	}
+	best := drivers[0].Distance
+	for _, d := range drivers[1:] {
+		best = math.Min(best, d.Distance)
+	}
+	return s.estimatePickupETA(best), true, nil
+}
+
+// pickupRadius is how far from pickup drivers are looked for. Wheelchair rides
+// look as far as a driver can come within the WAV pickup window, since WAV
+// drivers are few.
+func (s *Service) pickupRadius(pickup types.Point, requirements []string) float64 {
+	radius := s.radiusAt(pickup)
+	if s.cfg.WAVPickupMaxETA <= 0 || !slices.Contains(requirements, order.RequireWheelchair) {
+		return radius
+	}
+	return max(radius, s.pickupSpeed()*s.cfg.WAVPickupMaxETA.Hours()/roadFactor)
+}
+
+func (s *Service) pickupSpeed() float64 {
+	if s.cfg.PickupSpeedKmh <= 0 {
+		return 25
+	}
+	return s.cfg.PickupSpeedKmh
+}
+
+// estimatePickupETA converts a straight-line distance into a pickup ETA using
+// the configured average pickup speed.
+func (s *Service) estimatePickupETA(distanceKm float64) time.Duration {
+	eta := time.Duration(distanceKm * roadFactor / s.pickupSpeed() * float64(time.Hour))
+	if eta < etaFloor {
+		eta = etaFloor
+	}
+	return eta.Round(time.Second)
+}
+
+// anonymizePoint snaps p to the preview grid and offsets it within its cell by
+// a jitter derived from the driver ID and salt, so markers do not stack but
+// stay stable between polls within the same salt period.
+func anonymizePoint(id string, salt int64, p types.Point) types.Point {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(id))
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(salt >> (8 * i))
+	}
+	_, _ = h.Write(b[:])
+	sum := h.Sum64()
+	jLat := (float64(sum&0xffff)/0xffff - 0.5) * previewGridDeg
+	jLng := (float64(sum>>16&0xffff)/0xffff - 0.5) * previewGridDeg
+
+	return types.Point{
+		Lat: math.Floor(p.Lat/previewGridDeg)*previewGridDeg + previewGridDeg/2 + jLat,
+		Lng: math.Floor(p.Lng/previewGridDeg)*previewGridDeg + previewGridDeg/2 + jLng,
+	}
+}
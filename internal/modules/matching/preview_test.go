@@ -0,0 +1,142 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ark/internal/config"
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type fakeLocator struct {
+	nearby []location.DriverLocation
+	radius float64 // of the last GetNearbyDrivers
+}
+
+func (f *fakeLocator) GetAllDrivers(context.Context) ([]location.DriverLocation, error) {
+	return f.nearby, nil
+}
+
+func (f *fakeLocator) GetNearbyDrivers(_ context.Context, _, _, radiusKm float64) ([]location.DriverLocation, error) {
+	f.radius = radiusKm
+	return f.nearby, nil
+}
+
+func TestPreviewNearbyDrivers(t *testing.T) {
+	loc := &fakeLocator{nearby: []location.DriverLocation{
+		{DriverID: "d1", Lat: 25.0340, Lng: 121.5645, Distance: 2.0},
+		{DriverID: "d2", Lat: 25.0400, Lng: 121.5700, Distance: 2.8},
+	}}
+	svc := NewService(nil, nil, nil, loc, config.MatchingConfig{RadiusKm: 3, PickupSpeedKmh: 26})
+
+	p, err := svc.PreviewNearbyDrivers(context.Background(), types.Point{Lat: 25.02, Lng: 121.55})
+	if err != nil {
+		t.Fatalf("PreviewNearbyDrivers: %v", err)
+	}
+	if p.Count != 2 || len(p.Positions) != 2 {
+		t.Fatalf("count=%d positions=%d, want 2/2", p.Count, len(p.Positions))
+	}
+	// 2 km * 1.3 road factor at 26 km/h = 6 minutes.
+	if p.PickupETA == nil || *p.PickupETA != 6*time.Minute {
+		t.Errorf("PickupETA = %v, want 6m", p.PickupETA)
+	}
+	for i, d := range loc.nearby {
+		got := p.Positions[i]
+		if got.Lat == d.Lat && got.Lng == d.Lng {
+			t.Errorf("position %d not anonymized", i)
+		}
+		if math.Abs(got.Lat-d.Lat) > 2*previewGridDeg || math.Abs(got.Lng-d.Lng) > 2*previewGridDeg {
+			t.Errorf("position %d moved too far: %+v vs %+v", i, got, d)
+		}
+	}
+}
+
+func TestPreviewNearbyDrivers_NoDrivers(t *testing.T) {
+	svc := NewService(nil, nil, nil, &fakeLocator{}, config.MatchingConfig{RadiusKm: 3, PickupSpeedKmh: 25})
+	p, err := svc.PreviewNearbyDrivers(context.Background(), types.Point{Lat: 25.02, Lng: 121.55})
+	if err != nil {
+		t.Fatalf("PreviewNearbyDrivers: %v", err)
+	}
+	if p.Count != 0 || p.PickupETA != nil {
+		t.Errorf("preview = %+v, want empty with nil ETA", p)
+	}
+}
+
+func TestAnonymizePoint_StableWithinSalt(t *testing.T) {
+	p := types.Point{Lat: 25.0340, Lng: 121.5645}
+	a := anonymizePoint("d1", 1, p)
+	if b := anonymizePoint("d1", 1, p); a != b {
+		t.Errorf("same salt gave %+v and %+v", a, b)
+	}
+	if c := anonymizePoint("d1", 2, p); a == c {
+		t.Error("different salt gave identical position")
+	}
+}
+
+func TestEstimatePickup(t *testing.T) {
+	cfg := config.MatchingConfig{RadiusKm: 3, PickupSpeedKmh: 26}
+	svc := NewService(nil, nil, nil, &fakeLocator{}, cfg)
+	if _, ok, err := svc.EstimatePickup(context.Background(), types.Point{}, nil); err != nil || ok {
+		t.Errorf("no drivers: ok=%v err=%v", ok, err)
+	}
+
+	svc = NewService(nil, nil, nil, &fakeLocator{nearby: []location.DriverLocation{
+		{DriverID: "far", Distance: 2.5},
+		{DriverID: "near", Distance: 1.0},
+	}}, cfg)
+	eta, ok, err := svc.EstimatePickup(context.Background(), types.Point{}, nil)
+	if err != nil || !ok {
+		t.Fatalf("EstimatePickup: ok=%v err=%v", ok, err)
+	}
+	// 1 km * 1.3 / 26 km/h = 3 minutes.
+	if eta != 3*time.Minute {
+		t.Errorf("eta = %v, want 3m", eta)
+	}
+}
+
+func TestEstimatePickup_WAV(t *testing.T) {
+	cfg := config.MatchingConfig{RadiusKm: 3, PickupSpeedKmh: 26, WAVPickupMaxETA: 30 * time.Minute}
+	loc := &fakeLocator{nearby: []location.DriverLocation{
+		{DriverID: "sedan", Distance: 1.0},
+		{DriverID: "wav", Distance: 6.0},
+	}}
+	svc := NewService(nil, nil, nil, loc, cfg)
+	svc.SetCapabilityFilter(fakeCapabilities{"wav": {"wheelchair"}})
+
+	eta, ok, err := svc.EstimatePickup(context.Background(), types.Point{}, []string{"wheelchair"})
+	if err != nil || !ok {
+		t.Fatalf("EstimatePickup: ok=%v err=%v", ok, err)
+	}
+	// Only the WAV driver counts: 6 km * 1.3 / 26 km/h = 18 minutes.
+	if eta != 18*time.Minute {
+		t.Errorf("eta = %v, want 18m", eta)
+	}
+	// 30 minutes at 26 km/h is 13 km of road, 10 km in a straight line.
+	if loc.radius != 10 {
+		t.Errorf("wheelchair search radius = %v km, want 10", loc.radius)
+	}
+	if _, _, _ = svc.EstimatePickup(context.Background(), types.Point{}, nil); loc.radius != 3 {
+		t.Errorf("normal search radius = %v km, want 3", loc.radius)
+	}
+}
+
+func TestEstimatePickup_RedisDown(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer rdb.Close()
+	store, err := location.NewStore(context.Background(), nil, rdb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService(nil, nil, nil, location.NewService(store), config.MatchingConfig{RadiusKm: 3})
+
+	if _, _, err := svc.EstimatePickup(context.Background(), types.Point{Lat: 25.02, Lng: 121.55}, nil); !errors.Is(err, order.ErrPickupUnavailable) {
+		t.Fatalf("err = %v, want order.ErrPickupUnavailable", err)
+	}
+}
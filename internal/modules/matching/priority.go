@@ -0,0 +1,89 @@
+// README: Priority passengers — wider first offers for VIP and accessibility orders, and wait-time counters per priority class.
+package matching
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// waitTimeout bounds the order lookup behind one wait sample.
+const waitTimeout = 5 * time.Second
+
+// waitSeconds tracks how long instant orders wait for a driver, per priority
+// class ("normal" for passengers without one): <class>.count,
+// <class>.total_secs and <class>.max_secs. Comparing the classes' averages
+// shows what the boost buys; the normal max shows no one is starved by it.
+var (
+	waitSeconds = expvar.NewMap("matching_wait_seconds")
+	waitMu      sync.Mutex // keeps max_secs a true maximum
+)
+
+// Orders reads orders for the wait metrics. Implemented by order.Service.
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// notifyPoolSize returns how many drivers an offer of o goes to. The first
+// offer of a priority order, or of a scheduled order dispatched unclaimed, goes
+// to its region's priority pool when that is larger than the normal one.
+func (s *Service) notifyPoolSize(o *order.Order, prev *OrderNotification) int {
+	if (o.Priority == "" && !o.DispatchedUnclaimed()) || prev != nil || s.regions == nil {
+		return maxNotifyDrivers
+	}
+	return max(s.regions.Get(o.RegionID).PriorityNotifyDrivers, maxNotifyDrivers)
+}
+
+// WaitHook records how long each instant order waited between booking and a
+// driver accepting it in the matching_wait_seconds counters and, with
+// SetMatchStats, in its zone's match latencies.
+func (s *Service) WaitHook(orders Orders) order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.From != order.StatusWaiting || t.To != order.StatusApproaching || t.Sandbox {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), waitTimeout)
+			defer cancel()
+			o, err := orders.Get(ctx, t.OrderID)
+			if err != nil {
+				errreport.Report(ctx, "matching", "wait_time", err, "order_id", t.OrderID)
+				return
+			}
+			// Scheduled orders wait for their pickup time, not for dispatch.
+			if o.OrderType == "scheduled" {
+				return
+			}
+			recordWait(o.Priority, t.At.Sub(o.CreatedAt))
+			if err := s.recordMatch(ctx, o, t.At.Sub(o.CreatedAt), t.At); err != nil {
+				errreport.Report(ctx, "matching", "match_stats", err, "order_id", t.OrderID)
+			}
+		}()
+	}
+}
+
+// recordWait adds one wait sample to the counters of the priority class.
+func recordWait(priority string, wait time.Duration) {
+	class := priority
+	if class == "" {
+		class = "normal"
+	}
+	secs := int64(max(wait, 0) / time.Second)
+	waitMu.Lock()
+	defer waitMu.Unlock()
+	waitSeconds.Add(class+".count", 1)
+	waitSeconds.Add(class+".total_secs", secs)
+	v, _ := waitSeconds.Get(class + ".max_secs").(*expvar.Int)
+	if v == nil {
+		v = new(expvar.Int)
+		waitSeconds.Set(class+".max_secs", v)
+	}
+	if secs > v.Value() {
+		v.Set(secs)
+	}
+}
@@ -6,13 +6,17 @@ import (
 	"errors"
 	"log"
 	"math/rand/v2"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"ark/internal/config"
+	"ark/internal/errreport"
 	"ark/internal/modules/location"
 	"ark/internal/modules/notification"
 	"ark/internal/modules/order"
+	"ark/internal/modules/region"
 	"ark/internal/types"
 )
 
@@ -22,23 +26,78 @@ const (
 	notificationCooldown = 5 * time.Minute
 	// maxNotifyDrivers is the maximum number of drivers to notify per cycle.
 	maxNotifyDrivers = 5
+	// closureRadiusFactor widens the matching radius for pickups inside a
+	// road closure, whose nearby drivers may have no way in.
+	closureRadiusFactor = 1.5
 )
 
+// OrderMatcher assigns a waiting order to a driver. Implemented by
+// order.Service; order_contract_test.go holds the behavior both it and test
+// doubles must follow.
 type OrderMatcher interface {
+	// Match moves a waiting order to approaching with cmd.DriverID. It
+	// returns order.ErrNotFound for an unknown order, order.ErrActorNotAllowed
+	// for a scheduled order already claimed by a driver, order.ErrInvalidState
+	// for any other order that is not waiting, and order.ErrConflict when the
+	// order changed while being matched.
 	Match(ctx context.Context, cmd order.MatchCommand) error
 }
 
 // DriverLocator provides access to online driver location data.
 type DriverLocator interface {
 	GetAllDrivers(ctx context.Context) ([]location.DriverLocation, error)
+	// GetNearbyDrivers returns online drivers within radiusKm, nearest first.
+	GetNearbyDrivers(ctx context.Context, lat, lng, radiusKm float64) ([]location.DriverLocation, error)
+}
+
+// CapabilityFilter narrows candidate drivers to those able to serve an order's
+// requirement flags (wheelchair, child seat, ...).
+type CapabilityFilter interface {
+	FilterCapable(ctx context.Context, ids []types.ID, requirements []string) ([]types.ID, error)
+}
+
+// Regions resolves points and region IDs to a region's configuration.
+// Implemented by region.Service.
+type Regions interface {
+	Locate(p types.Point) (region.Region, bool)
+	Get(id string) region.Region
+}
+
+// Closures reports whether a point lies in a road closure in force.
+// Implemented by closure.Service.
+type Closures interface {
+	Within(p types.Point, at time.Time) bool
+}
+
+// Inspections reports drivers whose vehicle inspection is overdue.
+// Implemented by vehicle.Service.
+type Inspections interface {
+	OverdueInspections(ctx context.Context, ids []types.ID) ([]types.ID, error)
+}
+
+// DriverRegions returns the region of each driver among ids; "" is the
+// default region.
+type DriverRegions interface {
+	DriverRegions(ctx context.Context, ids []types.ID) (map[types.ID]string, error)
 }
 
 type Service struct {
-	store        *Store
-	order        OrderMatcher
-	notification notification.NotificationService
-	location     DriverLocator
-	cfg          config.MatchingConfig
+	store         *Store
+	order         OrderMatcher
+	notification  notification.NotificationService
+	notifier      Notifier
+	location      DriverLocator
+	capabilities  CapabilityFilter
+	regions       Regions
+	driverRegions DriverRegions
+	closures      Closures
+	inspections   Inspections
+	shadow        *shadowState
+	offers        OfferStore
+	offerTTL      time.Duration
+	offerHub      offerHub
+	matchStats    MatchStats
+	cfg           config.MatchingConfig
 }
 
 func NewService(
@@ -57,6 +116,51 @@ func NewService(
 	}
 }
 
+// SetNotifier routes new-order offers through n (e.g. FCMNotifier) instead of the
+// generic NotifyUser message. Passing nil restores the generic path.
+func (s *Service) SetNotifier(n Notifier) {
+	s.notifier = n
+}
+
+// SetCapabilityFilter enables requirement-aware offers. Without a filter,
+// orders that carry requirements are not offered to anyone.
+func (s *Service) SetCapabilityFilter(f CapabilityFilter) {
+	s.capabilities = f
+}
+
+// SetRegions looks drivers up within each region's matching radius and
+// offers orders only to drivers of the order's region. Without it the
+// configured radius applies everywhere and any driver may be offered any order.
+func (s *Service) SetRegions(r Regions, d DriverRegions) {
+	s.regions = r
+	s.driverRegions = d
+}
+
+// SetClosures widens the matching radius for pickups inside road closures.
+func (s *Service) SetClosures(c Closures) {
+	s.closures = c
+}
+
+// SetInspections stops offering orders to drivers whose vehicle inspection is
+// overdue, in regions configured to suspend them.
+func (s *Service) SetInspections(i Inspections) {
+	s.inspections = i
+}
+
+// radiusAt returns the matching radius for a pickup at p.
+func (s *Service) radiusAt(p types.Point) float64 {
+	radius := s.cfg.RadiusKm
+	if s.regions != nil {
+		if r, ok := s.regions.Locate(p); ok && r.MatchRadiusKm > 0 {
+			radius = r.MatchRadiusKm
+		}
+	}
+	if s.closures != nil && s.closures.Within(p, time.Now()) {
+		radius *= closureRadiusFactor
+	}
+	return radius
+}
+
 func (s *Service) AddCandidate(ctx context.Context, c Candidate) error {
 	return errors.New("not implemented")
 }
@@ -98,14 +202,14 @@ func (s *Service) RunNotificationScheduler(ctx context.Context) {
 			return
 		case <-ticker.C:
 			if err := s.notifyMostUrgentOrder(ctx); err != nil {
-				log.Printf("matching: notification scheduler error: %v", err)
+				errreport.Report(ctx, "matching", "notify_most_urgent", err)
 			}
 		}
 	}
 }
 
 // notifyMostUrgentOrder finds the most urgent unmatched order not in cooldown,
-// selects up to maxNotifyDrivers random online drivers, sends push notifications,
+// selects up to notifyPoolSize random online drivers, sends push notifications,
 // and records the attempt with a cooldown timestamp.
 func (s *Service) notifyMostUrgentOrder(ctx context.Context) error {
 	// 1. Get the most urgent order not in cooldown.
@@ -117,11 +221,23 @@ func (s *Service) notifyMostUrgentOrder(ctx context.Context) error {
 		return nil // no eligible orders
 	}
 
-	// 2. Get all online drivers.
+	// 2. Get the online drivers the order may go to.
 	if s.location == nil {
 		return errors.New("matching: location service not configured")
 	}
-	drivers, err := s.location.GetAllDrivers(ctx)
+	drivers, err := s.offerDrivers(ctx, urgentOrder)
+	if err != nil {
+		return err
+	}
+	drivers, err = s.filterCapable(ctx, drivers, urgentOrder.Requirements)
+	if err != nil {
+		return err
+	}
+	drivers, err = s.filterRegion(ctx, drivers, urgentOrder.RegionID)
+	if err != nil {
+		return err
+	}
+	drivers, err = s.filterInspected(ctx, drivers, urgentOrder)
 	if err != nil {
 		return err
 	}
@@ -129,18 +245,38 @@ func (s *Service) notifyMostUrgentOrder(ctx context.Context) error {
 		return nil
 	}
 
-	// 3. Randomly select up to maxNotifyDrivers drivers.
-	selected := pickRandom(drivers, maxNotifyDrivers)
+	// Offers must not go out while the passenger is moving the pickup, nor
+	// carry a pickup that was moved after the order was read.
+	release, ok, err := s.beginOffer(ctx, urgentOrder)
+	if err != nil || !ok {
+		return err
+	}
+	defer release(context.WithoutCancel(ctx))
+
+	// 3. Randomly select up to maxNotifyDrivers drivers (more on a priority
+	// order's first offer).
+	selected := s.selectDrivers(urgentOrder, drivers, s.notifyPoolSize(urgentOrder, existingNotif))
 
-	// 4. Push notification to each selected driver; track whether at least one succeeded.
-	if s.notification == nil {
+	// 4. Record an offer for each selected driver, which reaches drivers with
+	// an open offer stream, then push notification to each; track whether at
+	// least one was delivered.
+	if s.notifier == nil && s.notification == nil {
 		return errors.New("matching: notification service not configured")
 	}
+	offered := s.recordOffers(ctx, urgentOrder, driverIDs(selected))
 	msg := buildOrderNotificationMessage(urgentOrder)
 	anySucceeded := false
 	for _, d := range selected {
-		if err := s.notification.NotifyUser(ctx, d.DriverID, msg); err != nil {
-			log.Printf("matching: failed to notify driver %s for order %s: %v", d.DriverID, urgentOrder.ID, err)
+		if err := s.notifyDriver(ctx, d.DriverID, urgentOrder, msg); err != nil {
+			if offered && s.offerHub.streaming(d.DriverID) {
+				offerStats.Add("streamed_only", 1)
+				anySucceeded = true
+			}
+			if errors.Is(err, ErrNoDeviceToken) || errors.Is(err, ErrPushMuted) {
+				log.Printf("matching: skipped driver %s for order %s: %v", d.DriverID, urgentOrder.ID, err)
+			} else {
+				errreport.Report(ctx, "matching", "notify_driver", err, "order_id", urgentOrder.ID, "driver_id", d.DriverID)
+			}
 		} else {
 			anySucceeded = true
 		}
@@ -157,6 +293,95 @@ func (s *Service) notifyMostUrgentOrder(ctx context.Context) error {
 	return s.store.UpsertOrderNotification(ctx, urgentOrder.ID, notifyCount, notificationCooldown)
 }
 
+// offerDrivers returns the online drivers an offer of o may go to: everyone,
+// or only drivers within the matching radius for a scheduled order dispatched
+// unclaimed, whose pickup is minutes away.
+func (s *Service) offerDrivers(ctx context.Context, o *order.Order) ([]location.DriverLocation, error) {
+	if o.DispatchedUnclaimed() {
+		return s.location.GetNearbyDrivers(ctx, o.Pickup.Lat, o.Pickup.Lng, s.radiusAt(o.Pickup))
+	}
+	return s.location.GetAllDrivers(ctx)
+}
+
+// notifyDriver uses the direct notifier when one is set, otherwise the generic
+// NotifyUser message.
+func (s *Service) notifyDriver(ctx context.Context, driverID types.ID, o *order.Order, msg *notification.NotificationMessage) error {
+	if s.notifier != nil {
+		return s.notifier.NotifyNewOrder(ctx, driverID, o)
+	}
+	return s.notification.NotifyUser(ctx, driverID, msg)
+}
+
+// filterCapable keeps the drivers whose capabilities cover requirements.
+func (s *Service) filterCapable(ctx context.Context, drivers []location.DriverLocation, requirements []string) ([]location.DriverLocation, error) {
+	if len(requirements) == 0 || len(drivers) == 0 {
+		return drivers, nil
+	}
+	if s.capabilities == nil {
+		return nil, nil
+	}
+	ids := make([]types.ID, len(drivers))
+	for i, d := range drivers {
+		ids[i] = d.DriverID
+	}
+	capable, err := s.capabilities.FilterCapable(ctx, ids, requirements)
+	if err != nil {
+		return nil, err
+	}
+	out := drivers[:0:0]
+	for _, d := range drivers {
+		if slices.Contains(capable, d.DriverID) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// filterRegion keeps the drivers serving the order's region. Drivers and
+// orders without a region, including drivers without a profile, belong to
+// the default one.
+func (s *Service) filterRegion(ctx context.Context, drivers []location.DriverLocation, regionID string) ([]location.DriverLocation, error) {
+	if s.regions == nil || s.driverRegions == nil || len(drivers) == 0 {
+		return drivers, nil
+	}
+	ids := make([]types.ID, len(drivers))
+	for i, d := range drivers {
+		ids[i] = d.DriverID
+	}
+	regions, err := s.driverRegions.DriverRegions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	want := s.regions.Get(regionID).ID
+	out := drivers[:0:0]
+	for _, d := range drivers {
+		if s.regions.Get(regions[d.DriverID]).ID == want {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// filterInspected drops the drivers whose vehicle inspection is overdue when
+// the order's region suspends them. Designated drives keep them: the driver
+// drives the passenger's car, not their own.
+func (s *Service) filterInspected(ctx context.Context, drivers []location.DriverLocation, o *order.Order) ([]location.DriverLocation, error) {
+	if s.inspections == nil || s.regions == nil || len(drivers) == 0 || o.RideType == order.RideTypeDesignated || !s.regions.Get(o.RegionID).SuspendOverdueInspection {
+		return drivers, nil
+	}
+	overdue, err := s.inspections.OverdueInspections(ctx, driverIDs(drivers))
+	if err != nil {
+		return nil, err
+	}
+	out := drivers[:0:0]
+	for _, d := range drivers {
+		if !slices.Contains(overdue, d.DriverID) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
 // pickRandom returns up to n randomly selected elements from drivers.
 func pickRandom(drivers []location.DriverLocation, n int) []location.DriverLocation {
 	if len(drivers) <= n {
@@ -180,17 +405,33 @@ func pickRandom(drivers []location.DriverLocation, n int) []location.DriverLocat
 
 // buildOrderNotificationMessage creates a push notification payload for the given order.
 func buildOrderNotificationMessage(o *order.Order) *notification.NotificationMessage {
+	title, body := "New ride request", "A passenger needs a driver. Tap to view details."
+	if o.RideType == order.RideTypeDelivery {
+		title, body = "New delivery request", "A parcel needs a driver. Tap to view details."
+	}
+	if o.RideType == order.RideTypeDesignated {
+		title, body = "New designated drive request", "A passenger needs a driver for their own car. Tap to view details."
+	}
+	if o.RideType == order.RideTypeCharter {
+		title, body = "New charter request", "A passenger wants a driver for "+strconv.Itoa(o.CharterHours)+" hours. Tap to view details."
+	}
 	return &notification.NotificationMessage{
-		Title: "New ride request",
-		Body:  "A passenger needs a driver. Tap to view details.",
+		Title:    title,
+		Body:     body,
+		Category: notification.CategoryOrderUpdate,
+		// The scheduler re-offers the order, so a failed offer is not retried.
+		Transient: true,
 		Data: map[string]interface{}{
-			"type":        "order_notification",
-			"order_id":    string(o.ID),
-			"pickup_lat":  strconv.FormatFloat(o.Pickup.Lat, 'f', 6, 64),
-			"pickup_lng":  strconv.FormatFloat(o.Pickup.Lng, 'f', 6, 64),
-			"dropoff_lat": strconv.FormatFloat(o.Dropoff.Lat, 'f', 6, 64),
-			"dropoff_lng": strconv.FormatFloat(o.Dropoff.Lng, 'f', 6, 64),
-			"order_type":  o.OrderType,
+			"type":           "order_notification",
+			"order_id":       string(o.ID),
+			"pickup_lat":     strconv.FormatFloat(o.Pickup.Lat, 'f', 6, 64),
+			"pickup_lng":     strconv.FormatFloat(o.Pickup.Lng, 'f', 6, 64),
+			"dropoff_lat":    strconv.FormatFloat(o.Dropoff.Lat, 'f', 6, 64),
+			"dropoff_lng":    strconv.FormatFloat(o.Dropoff.Lng, 'f', 6, 64),
+			"order_type":     o.OrderType,
+			"ride_type":      o.RideType,
+			"requirements":   strings.Join(o.Requirements, ","),
+			"status_version": strconv.Itoa(o.StatusVersion),
 		},
 	}
 }
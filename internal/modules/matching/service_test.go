@@ -0,0 +1,247 @@
+package matching
+
+import (
+	"context"
+	"expvar"
+	"slices"
+	"testing"
+	"time"
+
+	"ark/internal/config"
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/modules/region"
+	"ark/internal/types"
+)
+
+type fakeCapabilities map[types.ID][]string
+
+func (f fakeCapabilities) FilterCapable(_ context.Context, ids []types.ID, requirements []string) ([]types.ID, error) {
+	var out []types.ID
+	for _, id := range ids {
+		ok := true
+		for _, r := range requirements {
+			found := false
+			for _, c := range f[id] {
+				found = found || c == r
+			}
+			ok = ok && found
+		}
+		if ok {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func TestFilterCapable(t *testing.T) {
+	drivers := []location.DriverLocation{{DriverID: "d1"}, {DriverID: "d2"}, {DriverID: "d3"}}
+	svc := &Service{}
+
+	got, err := svc.filterCapable(context.Background(), drivers, nil)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("no requirements: got %d drivers, %v; want all 3", len(got), err)
+	}
+
+	// Without a capability filter an order with requirements is offered to nobody.
+	if got, _ := svc.filterCapable(context.Background(), drivers, []string{"wheelchair"}); len(got) != 0 {
+		t.Errorf("no filter configured: got %d drivers, want 0", len(got))
+	}
+
+	svc.SetCapabilityFilter(fakeCapabilities{"d1": {"wheelchair"}, "d3": {"wheelchair", "child_seat"}})
+	got, err = svc.filterCapable(context.Background(), drivers, []string{"wheelchair"})
+	if err != nil || len(got) != 2 || got[0].DriverID != "d1" || got[1].DriverID != "d3" {
+		t.Errorf("wheelchair: got %v, %v; want d1 and d3", got, err)
+	}
+	if len(drivers) != 3 || drivers[1].DriverID != "d2" {
+		t.Error("filterCapable modified its input slice")
+	}
+}
+
+type fakeRegions map[string]region.Region
+
+func (f fakeRegions) Locate(p types.Point) (region.Region, bool) {
+	for _, r := range f {
+		if r.Contains(p) {
+			return r, true
+		}
+	}
+	return f.Get(""), true
+}
+
+func (f fakeRegions) Get(id string) region.Region {
+	if r, ok := f[id]; ok {
+		return r
+	}
+	return f["tpe"]
+}
+
+type fakeDriverRegions map[types.ID]string
+
+func (f fakeDriverRegions) DriverRegions(_ context.Context, ids []types.ID) (map[types.ID]string, error) {
+	out := make(map[types.ID]string)
+	for _, id := range ids {
+		if r, ok := f[id]; ok {
+			out[id] = r
+		}
+	}
+	return out, nil
+}
+
+var testRegions = fakeRegions{
+	"tpe": region.Default("tpe"),
+	"khh": {ID: "khh", MatchRadiusKm: 5, Area: []types.Point{
+		{Lat: 22.5, Lng: 120.2}, {Lat: 22.8, Lng: 120.2}, {Lat: 22.8, Lng: 120.5}, {Lat: 22.5, Lng: 120.5},
+	}},
+}
+
+func TestFilterRegion(t *testing.T) {
+	drivers := []location.DriverLocation{{DriverID: "d1"}, {DriverID: "d2"}, {DriverID: "d3"}}
+	svc := &Service{}
+	svc.SetRegions(testRegions, fakeDriverRegions{"d1": "", "d2": "khh"})
+
+	// d1 has no region and d3 no profile: both are in the default region.
+	got, err := svc.filterRegion(context.Background(), drivers, "")
+	if err != nil || len(got) != 2 || got[0].DriverID != "d1" || got[1].DriverID != "d3" {
+		t.Errorf("default region: got %v, %v; want d1 and d3", got, err)
+	}
+	got, err = svc.filterRegion(context.Background(), drivers, "khh")
+	if err != nil || len(got) != 1 || got[0].DriverID != "d2" {
+		t.Errorf("khh: got %v, %v; want d2", got, err)
+	}
+}
+
+type fakeInspections []types.ID
+
+func (f fakeInspections) OverdueInspections(_ context.Context, ids []types.ID) ([]types.ID, error) {
+	var out []types.ID
+	for _, id := range ids {
+		if slices.Contains(f, id) {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func TestFilterInspected(t *testing.T) {
+	drivers := []location.DriverLocation{{DriverID: "d1"}, {DriverID: "d2"}}
+	regions := fakeRegions{"tpe": region.Default("tpe"), "khh": {ID: "khh", SuspendOverdueInspection: true}}
+	svc := &Service{}
+	svc.SetRegions(regions, nil)
+	svc.SetInspections(fakeInspections{"d2"})
+
+	got, err := svc.filterInspected(context.Background(), drivers, &order.Order{RegionID: "tpe"})
+	if err != nil || len(got) != 2 {
+		t.Errorf("region without suspension: got %v, %v; want both drivers", got, err)
+	}
+	got, err = svc.filterInspected(context.Background(), drivers, &order.Order{RegionID: "khh"})
+	if err != nil || len(got) != 1 || got[0].DriverID != "d1" {
+		t.Errorf("suspending region: got %v, %v; want d1", got, err)
+	}
+	// A designated driver drives the passenger's car, not their own.
+	got, err = svc.filterInspected(context.Background(), drivers, &order.Order{RegionID: "khh", RideType: order.RideTypeDesignated})
+	if err != nil || len(got) != 2 {
+		t.Errorf("designated drive: got %v, %v; want both drivers", got, err)
+	}
+}
+
+func TestRadiusAt(t *testing.T) {
+	svc := &Service{cfg: config.MatchingConfig{RadiusKm: 3}}
+	kaohsiung := types.Point{Lat: 22.63, Lng: 120.30}
+	if got := svc.radiusAt(kaohsiung); got != 3 {
+		t.Errorf("without regions: radius = %v, want 3", got)
+	}
+	svc.SetRegions(testRegions, nil)
+	if got := svc.radiusAt(kaohsiung); got != 5 {
+		t.Errorf("kaohsiung: radius = %v, want the region's 5", got)
+	}
+	if got := svc.radiusAt(types.Point{Lat: 25.03, Lng: 121.56}); got != 3 {
+		t.Errorf("taipei: radius = %v, want the default 3", got)
+	}
+	// Pickups inside a road closure look further out.
+	svc.SetClosures(closedAt{kaohsiung})
+	if got := svc.radiusAt(kaohsiung); got != 7.5 {
+		t.Errorf("closed kaohsiung: radius = %v, want 7.5", got)
+	}
+	if got := svc.radiusAt(types.Point{Lat: 25.03, Lng: 121.56}); got != 3 {
+		t.Errorf("open taipei: radius = %v, want 3", got)
+	}
+}
+
+// closedAt is a Closures with closures at exactly its points.
+type closedAt []types.Point
+
+func (c closedAt) Within(p types.Point, _ time.Time) bool {
+	return slices.Contains(c, p)
+}
+
+func TestNotifyPoolSize(t *testing.T) {
+	regions := fakeRegions{"tpe": region.Default("tpe"), "khh": {ID: "khh", PriorityNotifyDrivers: 12}}
+	svc := &Service{}
+	vip := &order.Order{RegionID: "khh", Priority: "vip"}
+	if got := svc.notifyPoolSize(vip, nil); got != maxNotifyDrivers {
+		t.Errorf("without regions: pool = %d, want %d", got, maxNotifyDrivers)
+	}
+	svc.SetRegions(regions, nil)
+	if got := svc.notifyPoolSize(vip, nil); got != 12 {
+		t.Errorf("first vip offer: pool = %d, want the region's 12", got)
+	}
+	if got := svc.notifyPoolSize(vip, &OrderNotification{NotifyCount: 1}); got != maxNotifyDrivers {
+		t.Errorf("repeat vip offer: pool = %d, want %d", got, maxNotifyDrivers)
+	}
+	if got := svc.notifyPoolSize(&order.Order{RegionID: "khh"}, nil); got != maxNotifyDrivers {
+		t.Errorf("normal order: pool = %d, want %d", got, maxNotifyDrivers)
+	}
+	late := &order.Order{RegionID: "khh", OrderType: "scheduled", Status: order.StatusWaiting}
+	if got := svc.notifyPoolSize(late, nil); got != 12 {
+		t.Errorf("first offer of an unclaimed scheduled order: pool = %d, want the region's 12", got)
+	}
+	if got := svc.notifyPoolSize(&order.Order{Priority: "accessibility"}, nil); got != maxNotifyDrivers {
+		t.Errorf("default region without a priority pool: pool = %d, want %d", got, maxNotifyDrivers)
+	}
+}
+
+func TestOfferDrivers_UnclaimedGoNearby(t *testing.T) {
+	loc := &fakeLocator{nearby: []location.DriverLocation{{DriverID: "d1", Distance: 1.2}}}
+	svc := NewService(nil, nil, nil, loc, config.MatchingConfig{RadiusKm: 4})
+	late := &order.Order{OrderType: "scheduled", Status: order.StatusWaiting, Pickup: types.Point{Lat: 25.03, Lng: 121.56}}
+	drivers, err := svc.offerDrivers(context.Background(), late)
+	if err != nil {
+		t.Fatalf("offerDrivers: %v", err)
+	}
+	if len(drivers) != 1 || loc.radius != 4 {
+		t.Errorf("offerDrivers = %v within %v km, want d1 within the 4 km radius", drivers, loc.radius)
+	}
+
+	loc.radius = 0
+	if _, err := svc.offerDrivers(context.Background(), &order.Order{OrderType: "instant", Status: order.StatusWaiting}); err != nil {
+		t.Fatalf("offerDrivers: %v", err)
+	}
+	if loc.radius != 0 {
+		t.Error("instant orders should be offered to all online drivers")
+	}
+}
+
+func TestRecordWait(t *testing.T) {
+	get := func(key string) int64 {
+		v, _ := waitSeconds.Get(key).(*expvar.Int)
+		if v == nil {
+			return 0
+		}
+		return v.Value()
+	}
+	before := get("accessibility.count")
+	recordWait("accessibility", 90*time.Second)
+	recordWait("accessibility", 30*time.Second)
+	recordWait("", 4*time.Minute)
+
+	if got := get("accessibility.count") - before; got != 2 {
+		t.Errorf("accessibility count grew by %d, want 2", got)
+	}
+	if got := get("accessibility.max_secs"); got < 90 {
+		t.Errorf("accessibility max = %d, want at least 90", got)
+	}
+	if got := get("normal.max_secs"); got < 240 {
+		t.Errorf("normal max = %d, want at least 240", got)
+	}
+}
@@ -0,0 +1,232 @@
+// README: Dispatch strategies and shadow mode — a candidate selector runs beside the live one on every offer round and is measured against it without changing who is offered the order.
+package matching
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Dispatch strategies accepted by NewSelector.
+const (
+	// StrategyRandom offers the order to a random sample of eligible drivers.
+	StrategyRandom = "random"
+	// StrategyScoring offers it to the drivers closest to the pickup.
+	StrategyScoring = "scoring"
+)
+
+// shadowRetention is how long an order's shadow picks are kept to compare
+// against the driver who eventually accepts it.
+const shadowRetention = time.Hour
+
+// shadowStats compares the shadow selector with the live one, exposed on
+// /api/ops/debug/vars:
+//
+//	rounds, identical, diverged  offer rounds and whether both picked the same drivers
+//	live_picks, overlap_picks    drivers picked live, and of those also picked in shadow
+//	live_pickup_m, shadow_pickup_m  summed straight-line pickup distance of each side's picks
+//	accepted, accepted_in_live, accepted_in_shadow  accepted orders and whose picks held the accepting driver
+//	panics                       shadow selector panics, which never affect the live round
+var shadowStats = expvar.NewMap("matching_shadow")
+
+// Selector picks at most n of the eligible drivers to offer o to.
+type Selector interface {
+	Name() string
+	Select(o *order.Order, drivers []location.DriverLocation, n int) []location.DriverLocation
+}
+
+// NewSelector returns the selector implementing the named strategy.
+func NewSelector(strategy string) (Selector, error) {
+	switch strategy {
+	case StrategyRandom:
+		return randomSelector{}, nil
+	case StrategyScoring:
+		return scoringSelector{}, nil
+	}
+	return nil, fmt.Errorf("matching: unknown dispatch strategy %q", strategy)
+}
+
+type randomSelector struct{}
+
+func (randomSelector) Name() string { return StrategyRandom }
+
+func (randomSelector) Select(_ *order.Order, drivers []location.DriverLocation, n int) []location.DriverLocation {
+	return pickRandom(drivers, n)
+}
+
+// scoringSelector ranks drivers by straight-line distance to the pickup; ties
+// go to the lower driver ID so the choice is deterministic.
+type scoringSelector struct{}
+
+func (scoringSelector) Name() string { return StrategyScoring }
+
+func (scoringSelector) Select(o *order.Order, drivers []location.DriverLocation, n int) []location.DriverLocation {
+	ranked := slices.Clone(drivers)
+	slices.SortFunc(ranked, func(a, b location.DriverLocation) int {
+		da, db := pickupKm(o, a), pickupKm(o, b)
+		switch {
+		case da < db:
+			return -1
+		case da > db:
+			return 1
+		}
+		return strings.Compare(string(a.DriverID), string(b.DriverID))
+	})
+	return ranked[:min(n, len(ranked))]
+}
+
+func pickupKm(o *order.Order, d location.DriverLocation) float64 {
+	return haversineKm(o.Pickup, types.Point{Lat: d.Lat, Lng: d.Lng})
+}
+
+// haversineKm returns the great-circle distance between a and b in kilometres.
+func haversineKm(a, b types.Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// shadowOrder is every driver each side picked for one order so far.
+type shadowOrder struct {
+	live, shadow []types.ID
+	updated      time.Time
+}
+
+// shadowState holds the shadow selector and the picks awaiting acceptance.
+type shadowState struct {
+	selector Selector
+
+	mu     sync.Mutex
+	orders map[types.ID]*shadowOrder
+}
+
+// SetShadowSelector runs sel beside the live selector on every offer round
+// and records how its picks diverge; offers still go to the live picks only.
+// Register ShadowHook so acceptances are compared too. nil turns shadow mode off.
+func (s *Service) SetShadowSelector(sel Selector) {
+	if sel == nil {
+		s.shadow = nil
+		return
+	}
+	s.shadow = &shadowState{selector: sel, orders: map[types.ID]*shadowOrder{}}
+}
+
+// selectDrivers picks the drivers an offer of o goes to, and runs the shadow
+// selector on the same input when one is set.
+func (s *Service) selectDrivers(o *order.Order, drivers []location.DriverLocation, n int) []location.DriverLocation {
+	live := randomSelector{}.Select(o, drivers, n)
+	if s.shadow != nil {
+		s.shadow.compare(o, drivers, n, live)
+	}
+	return live
+}
+
+func (sh *shadowState) compare(o *order.Order, drivers []location.DriverLocation, n int, live []location.DriverLocation) {
+	defer func() {
+		if r := recover(); r != nil {
+			shadowStats.Add("panics", 1)
+			log.Printf("matching: shadow %s panicked for order %s: %v", sh.selector.Name(), o.ID, r)
+		}
+	}()
+	shadow := sh.selector.Select(o, slices.Clone(drivers), n)
+
+	liveIDs, shadowIDs := driverIDs(live), driverIDs(shadow)
+	overlap := 0
+	for _, id := range liveIDs {
+		if slices.Contains(shadowIDs, id) {
+			overlap++
+		}
+	}
+	shadowStats.Add("rounds", 1)
+	if overlap == len(liveIDs) && overlap == len(shadowIDs) {
+		shadowStats.Add("identical", 1)
+	} else {
+		shadowStats.Add("diverged", 1)
+		log.Printf("matching: shadow %s diverged for order %s: live=%v shadow=%v", sh.selector.Name(), o.ID, liveIDs, shadowIDs)
+	}
+	shadowStats.Add("live_picks", int64(len(liveIDs)))
+	shadowStats.Add("overlap_picks", int64(overlap))
+	shadowStats.Add("live_pickup_m", pickupMeters(o, live))
+	shadowStats.Add("shadow_pickup_m", pickupMeters(o, shadow))
+
+	now := time.Now()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	for id, so := range sh.orders {
+		if now.Sub(so.updated) > shadowRetention {
+			delete(sh.orders, id)
+		}
+	}
+	so := sh.orders[o.ID]
+	if so == nil {
+		so = &shadowOrder{}
+		sh.orders[o.ID] = so
+	}
+	so.live = appendNew(so.live, liveIDs)
+	so.shadow = appendNew(so.shadow, shadowIDs)
+	so.updated = now
+}
+
+// ShadowHook compares the driver who accepts an order with the drivers each
+// selector picked for it.
+func (s *Service) ShadowHook() order.TransitionHook {
+	return func(_ context.Context, t order.Transition) {
+		sh := s.shadow
+		if sh == nil || t.From != order.StatusWaiting || t.To != order.StatusApproaching || t.DriverID == nil {
+			return
+		}
+		sh.mu.Lock()
+		so := sh.orders[t.OrderID]
+		delete(sh.orders, t.OrderID)
+		sh.mu.Unlock()
+		if so == nil {
+			return
+		}
+		shadowStats.Add("accepted", 1)
+		if slices.Contains(so.live, *t.DriverID) {
+			shadowStats.Add("accepted_in_live", 1)
+		}
+		if slices.Contains(so.shadow, *t.DriverID) {
+			shadowStats.Add("accepted_in_shadow", 1)
+		}
+	}
+}
+
+func driverIDs(drivers []location.DriverLocation) []types.ID {
+	ids := make([]types.ID, len(drivers))
+	for i, d := range drivers {
+		ids[i] = d.DriverID
+	}
+	return ids
+}
+
+func pickupMeters(o *order.Order, drivers []location.DriverLocation) int64 {
+	var km float64
+	for _, d := range drivers {
+		km += pickupKm(o, d)
+	}
+	return int64(km * 1000)
+}
+
+func appendNew(ids, more []types.ID) []types.ID {
+	for _, id := range more {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
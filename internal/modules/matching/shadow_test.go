@@ -0,0 +1,85 @@
+package matching
+
+import (
+	"context"
+	"expvar"
+	"testing"
+
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+func shadowCount(key string) int64 {
+	if v, ok := shadowStats.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+var shadowDrivers = []location.DriverLocation{
+	{DriverID: "far", Lat: 25.10, Lng: 121.60},
+	{DriverID: "near", Lat: 25.031, Lng: 121.561},
+	{DriverID: "mid", Lat: 25.05, Lng: 121.58},
+}
+
+func TestScoringSelector_NearestFirst(t *testing.T) {
+	sel, err := NewSelector(StrategyScoring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &order.Order{Pickup: types.Point{Lat: 25.03, Lng: 121.56}}
+	got := sel.Select(o, shadowDrivers, 2)
+	if len(got) != 2 || got[0].DriverID != "near" || got[1].DriverID != "mid" {
+		t.Fatalf("picked %v, want near and mid", driverIDs(got))
+	}
+	if shadowDrivers[0].DriverID != "far" {
+		t.Error("Select reordered its input")
+	}
+	if _, err := NewSelector("greedy"); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
+
+func TestShadowMode(t *testing.T) {
+	svc := &Service{}
+	svc.SetShadowSelector(scoringSelector{})
+	o := &order.Order{ID: "o-shadow", Pickup: types.Point{Lat: 25.03, Lng: 121.56}}
+
+	rounds, identical := shadowCount("rounds"), shadowCount("identical")
+	live := svc.selectDrivers(o, shadowDrivers, 5)
+	if len(live) != 3 {
+		t.Fatalf("live picked %d drivers, want all 3", len(live))
+	}
+	if shadowCount("rounds")-rounds != 1 || shadowCount("identical")-identical != 1 {
+		t.Fatal("round with the same picks not counted as identical")
+	}
+
+	accepted, inShadow := shadowCount("accepted"), shadowCount("accepted_in_shadow")
+	driver := types.ID("near")
+	svc.ShadowHook()(context.Background(), order.Transition{OrderID: o.ID, DriverID: &driver, From: order.StatusWaiting, To: order.StatusApproaching})
+	if shadowCount("accepted")-accepted != 1 || shadowCount("accepted_in_shadow")-inShadow != 1 {
+		t.Fatal("acceptance by a shadow pick not counted")
+	}
+	if len(svc.shadow.orders) != 0 {
+		t.Error("accepted order still tracked")
+	}
+}
+
+type panickySelector struct{}
+
+func (panickySelector) Name() string { return "panicky" }
+
+func (panickySelector) Select(*order.Order, []location.DriverLocation, int) []location.DriverLocation {
+	panic("boom")
+}
+
+func TestShadowMode_PanicDoesNotAffectLive(t *testing.T) {
+	svc := &Service{}
+	svc.SetShadowSelector(panickySelector{})
+	panics := shadowCount("panics")
+	live := svc.selectDrivers(&order.Order{ID: "o-panic"}, shadowDrivers, 2)
+	if len(live) != 2 || shadowCount("panics")-panics != 1 {
+		t.Fatalf("live=%d panics=%d", len(live), shadowCount("panics")-panics)
+	}
+}
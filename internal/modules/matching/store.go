@@ -1,4 +1,4 @@
-// README: Matching store backed by Redis GEO and Postgres for order notification tracking.
+// README: Matching store backed by Redis GEO and Postgres for order notification tracking and driver offers.
 package matching
 
 import (
@@ -37,21 +37,32 @@ func (s *Store) NearbyDrivers(ctx context.Context, p types.Point, radiusKm float
 
 // GetMostUrgentNotifiable returns the most urgent order with status 'scheduled' or
 // 'waiting' that is not currently in a notification cooldown period, along with its
-// existing notification record (nil if never notified).
-// Returns (nil, nil, nil) when no eligible order exists.
+// existing notification record (nil if never notified). Orders of priority
+// passengers count as placed their region's priority boost earlier; the boost is
+// bounded, so normal orders waiting longer than it still go first. Scheduled
+// orders handed to instant dispatch unclaimed go before all others, earliest
+// pickup first, and stay eligible past their pickup time until they expire.
+// Sandbox orders are left to the driver bot. Returns (nil, nil, nil) when no
+// eligible order exists.
 func (s *Store) GetMostUrgentNotifiable(ctx context.Context) (*order.Order, *OrderNotification, error) {
 	row := s.db.QueryRow(ctx, `
         SELECT o.id, o.passenger_id, o.status, o.status_version,
                o.pickup_lat, o.pickup_lng, o.dropoff_lat, o.dropoff_lng,
-               o.ride_type, o.estimated_fee, o.created_at,
-               o.order_type, o.scheduled_at,
+               o.ride_type, o.estimated_fee, o.currency, o.created_at,
+               o.order_type, o.scheduled_at, o.requirements, COALESCE(o.region_id, ''), o.priority,
                onotif.notify_count, onotif.last_notified_at, onotif.next_notifiable_at
         FROM orders o
         LEFT JOIN order_notifications onotif ON onotif.order_id = o.id
         WHERE o.status IN ('scheduled', 'waiting')
+          AND NOT o.sandbox
           AND (onotif.order_id IS NULL OR onotif.next_notifiable_at <= NOW())
-          AND (o.scheduled_at IS NULL OR o.scheduled_at > NOW())
-        ORDER BY COALESCE(o.scheduled_at, o.created_at) ASC
+          AND (o.scheduled_at IS NULL OR o.scheduled_at > NOW() OR o.status = 'waiting')
+        ORDER BY (o.order_type = 'scheduled' AND o.status = 'waiting') DESC,
+                 COALESCE(o.scheduled_at, o.created_at) - CASE
+                     WHEN o.priority = '' THEN INTERVAL '0'
+                     ELSE make_interval(secs => COALESCE(
+                         (SELECT r.priority_boost_secs FROM regions r WHERE r.id = o.region_id), 0))
+                 END ASC
         LIMIT 1
         FOR UPDATE SKIP LOCKED`)
 
@@ -67,8 +78,8 @@ func (s *Store) GetMostUrgentNotifiable(ctx context.Context) (*order.Order, *Ord
 	err := row.Scan(
 		&o.ID, &o.PassengerID, &o.Status, &o.StatusVersion,
 		&o.Pickup.Lat, &o.Pickup.Lng, &o.Dropoff.Lat, &o.Dropoff.Lng,
-		&o.RideType, &o.EstimatedFee.Amount, &o.CreatedAt,
-		&orderType, &scheduledAt,
+		&o.RideType, &o.EstimatedFee.Amount, &o.EstimatedFee.Currency, &o.CreatedAt,
+		&orderType, &scheduledAt, &o.Requirements, &o.RegionID, &o.Priority,
 		&notifyCount, &lastNotifiedAt, &nextNotifiableAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -82,9 +93,6 @@ func (s *Store) GetMostUrgentNotifiable(ctx context.Context) (*order.Order, *Ord
 		o.OrderType = *orderType
 	}
 	o.ScheduledAt = scheduledAt
-	if o.EstimatedFee.Currency == "" {
-		o.EstimatedFee.Currency = "TWD"
-	}
 
 	var on *OrderNotification
 	if notifyCount != nil && lastNotifiedAt != nil && nextNotifiableAt != nil {
@@ -116,3 +124,93 @@ func (s *Store) UpsertOrderNotification(ctx context.Context, orderID types.ID, n
 	)
 	return err
 }
+
+// CreateOffers records a pending offer of orderID to each of driverIDs.
+// Expiry is computed by the database using NOW() like the notification cooldown.
+func (s *Store) CreateOffers(ctx context.Context, orderID types.ID, driverIDs []types.ID, ttl time.Duration) error {
+	idStrs := make([]string, len(driverIDs))
+	for i, id := range driverIDs {
+		idStrs[i] = string(id)
+	}
+	_, err := s.db.Exec(ctx, `
+        INSERT INTO driver_offers (order_id, driver_id, expires_at)
+        SELECT $1, d, NOW() + ($3 * INTERVAL '1 millisecond')
+        FROM unnest($2::text[]) AS d`,
+		string(orderID),
+		idStrs,
+		ttl.Milliseconds(),
+	)
+	return err
+}
+
+// PendingOffers returns driverID's pending offers that have not lapsed, with
+// the offered order's trip, soonest to expire first.
+func (s *Store) PendingOffers(ctx context.Context, driverID types.ID) ([]Offer, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT f.id, f.order_id, f.driver_id,
+               o.pickup_lat, o.pickup_lng, o.dropoff_lat, o.dropoff_lng,
+               o.ride_type, o.estimated_fee, o.currency, f.created_at, f.expires_at
+        FROM driver_offers f
+        JOIN orders o ON o.id = f.order_id
+        WHERE f.driver_id = $1 AND f.status = 'pending' AND f.expires_at > NOW()
+        ORDER BY f.expires_at, f.id`, string(driverID))
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Offer, error) {
+		var f Offer
+		err := row.Scan(
+			&f.ID, &f.OrderID, &f.DriverID,
+			&f.Pickup.Lat, &f.Pickup.Lng, &f.Dropoff.Lat, &f.Dropoff.Lng,
+			&f.RideType, &f.EstimatedFee.Amount, &f.EstimatedFee.Currency, &f.CreatedAt, &f.ExpiresAt,
+		)
+		return f, err
+	})
+}
+
+// ExpireOffers marks pending offers past their expiry expired and returns the
+// drivers they were made to.
+func (s *Store) ExpireOffers(ctx context.Context) ([]types.ID, error) {
+	rows, err := s.db.Query(ctx, `
+        UPDATE driver_offers SET status = 'expired', closed_at = NOW()
+        WHERE status = 'pending' AND expires_at <= NOW()
+        RETURNING driver_id`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[types.ID])
+}
+
+// OfferOpen reports whether offerID is driverID's pending offer of orderID
+// and has not lapsed.
+func (s *Store) OfferOpen(ctx context.Context, offerID int64, orderID, driverID types.ID) (bool, error) {
+	var open bool
+	err := s.db.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM driver_offers
+            WHERE id = $1 AND order_id = $2 AND driver_id = $3
+              AND status = 'pending' AND expires_at > NOW())`,
+		offerID, string(orderID), string(driverID),
+	).Scan(&open)
+	return open, err
+}
+
+// CloseOffers closes orderID's pending offers: acceptedBy's as accepted, the
+// rest as withdrawn. It returns the drivers whose offers were closed.
+func (s *Store) CloseOffers(ctx context.Context, orderID types.ID, acceptedBy *types.ID) ([]types.ID, error) {
+	var accepted *string
+	if acceptedBy != nil {
+		id := string(*acceptedBy)
+		accepted = &id
+	}
+	rows, err := s.db.Query(ctx, `
+        UPDATE driver_offers
+        SET status = CASE WHEN driver_id = $2::text THEN 'accepted' ELSE 'withdrawn' END,
+            closed_at = NOW()
+        WHERE order_id = $1 AND status = 'pending'
+        RETURNING driver_id`, string(orderID), accepted)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[types.ID])
+}
@@ -0,0 +1,38 @@
+// README: Unclaimed scheduled orders — offered to nearby drivers as soon as they are handed to instant dispatch.
+package matching
+
+import (
+	"context"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/modules/order"
+)
+
+// unclaimedOfferTimeout bounds the offer round started for one order.
+const unclaimedOfferTimeout = 10 * time.Second
+
+// UnclaimedHook starts an offer round as soon as a scheduled order nobody
+// claimed moves to instant dispatch, instead of waiting for the next tick.
+// Offers the order got while it was open for claims are forgotten, so the
+// round is not held back by their cooldown.
+func (s *Service) UnclaimedHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.From != order.StatusScheduled || t.To != order.StatusWaiting || t.Sandbox {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), unclaimedOfferTimeout)
+			defer cancel()
+			if err := s.store.ResetOrderNotification(ctx, t.OrderID); err != nil {
+				errreport.Report(ctx, "matching", "reset_unclaimed_offers", err, "order_id", t.OrderID)
+				return
+			}
+			// Unclaimed orders are the most urgent, so this round offers
+			// t.OrderID or another one dispatched ahead of it.
+			if err := s.notifyMostUrgentOrder(ctx); err != nil {
+				errreport.Report(ctx, "matching", "offer_unclaimed", err, "order_id", t.OrderID)
+			}
+		}()
+	}
+}
@@ -0,0 +1,178 @@
+// README: Wait-time estimate before booking — predicted time-to-match from nearby supply and recent match latencies per zone.
+package matching
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+const (
+	// zonePrecision buckets match latencies by geohash cell (~4.9 x 4.9 km).
+	zonePrecision = 5
+	// matchStatsWindow is how far back match latencies count.
+	matchStatsWindow = 30 * time.Minute
+	// minMatchSamples is how many recent matches a zone needs before its
+	// median is quoted; below it the estimate falls back to supply alone.
+	minMatchSamples = 3
+	// maxMatchSamples caps the latencies kept per zone and ride type.
+	maxMatchSamples = 200
+	// fallbackMatchWait is the quoted wait with a single nearby driver and no
+	// recent matches; more drivers shorten it down to etaFloor.
+	fallbackMatchWait = 3 * time.Minute
+)
+
+// Wait estimate bases.
+const (
+	BasisRecentMatches = "recent_matches"
+	BasisSupply        = "supply"
+	BasisNoSupply      = "no_supply"
+)
+
+// MatchStats keeps rolling match latencies per zone and ride type.
+// Implemented by Store.
+type MatchStats interface {
+	// RecordMatch adds one booking-to-accept latency observed at at.
+	RecordMatch(ctx context.Context, key string, wait time.Duration, at time.Time) error
+	// RecentMatches returns the latencies recorded since since.
+	RecentMatches(ctx context.Context, key string, since time.Time) ([]time.Duration, error)
+}
+
+// SetMatchStats makes WaitHook keep per-zone match latencies in stats and
+// EstimateWait quote from them.
+func (s *Service) SetMatchStats(stats MatchStats) {
+	s.matchStats = stats
+}
+
+// WaitEstimate is the expected time-to-match shown before booking.
+type WaitEstimate struct {
+	Zone    string
+	Drivers int // available drivers able to serve the ride type nearby
+	// Wait is the predicted time until a driver accepts; nil when no driver
+	// is available.
+	Wait    *time.Duration
+	Samples int // recent matches in the zone the estimate is based on
+	Basis   string
+}
+
+// EstimateWait predicts how long an instant order of rideType placed at p
+// would wait for a driver. With enough recent matches in the zone it quotes
+// their median; otherwise it falls back to the nearby supply.
+func (s *Service) EstimateWait(ctx context.Context, p types.Point, rideType string) (*WaitEstimate, error) {
+	if p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+		return nil, ErrInvalidPosition
+	}
+	if s.location == nil {
+		return nil, errors.New("matching: location service not configured")
+	}
+	var requirements []string
+	switch rideType {
+	case order.RideTypeWAV:
+		requirements = []string{order.RequireWheelchair}
+	case order.RideTypeDelivery:
+		requirements = []string{order.RequireParcel}
+	case order.RideTypeDesignated:
+		requirements = []string{order.RequireDesignated}
+	}
+	drivers, err := s.location.GetNearbyDrivers(ctx, p.Lat, p.Lng, s.pickupRadius(p, requirements))
+	if err != nil {
+		return nil, err
+	}
+	drivers, err = s.filterCapable(ctx, drivers, requirements)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := location.GeohashCell(p.Lat, p.Lng, zonePrecision)
+	est := &WaitEstimate{Zone: zone, Drivers: len(drivers), Basis: BasisNoSupply}
+	if len(drivers) == 0 {
+		return est, nil
+	}
+	var samples []time.Duration
+	if s.matchStats != nil {
+		samples, err = s.matchStats.RecentMatches(ctx, matchStatsKey(zone, rideType), time.Now().Add(-matchStatsWindow))
+		if err != nil {
+			return nil, err
+		}
+	}
+	est.Samples = len(samples)
+	wait := max(fallbackMatchWait/time.Duration(len(drivers)), etaFloor)
+	est.Basis = BasisSupply
+	if len(samples) >= minMatchSamples {
+		wait = median(samples)
+		est.Basis = BasisRecentMatches
+	}
+	wait = wait.Round(time.Second)
+	est.Wait = &wait
+	return est, nil
+}
+
+// recordMatch adds o's booking-to-accept latency to its zone's stats.
+func (s *Service) recordMatch(ctx context.Context, o *order.Order, wait time.Duration, at time.Time) error {
+	if s.matchStats == nil {
+		return nil
+	}
+	zone := location.GeohashCell(o.Pickup.Lat, o.Pickup.Lng, zonePrecision)
+	return s.matchStats.RecordMatch(ctx, matchStatsKey(zone, o.RideType), max(wait, 0), at)
+}
+
+func matchStatsKey(zone, rideType string) string {
+	return zone + ":" + rideType
+}
+
+func median(ds []time.Duration) time.Duration {
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func matchWaitKey(key string) string {
+	return "matching:match_wait:" + key
+}
+
+// RecordMatch stores the latency in a sorted set scored by time, trimmed to
+// matchStatsWindow and maxMatchSamples. Members carry the time so equal
+// latencies are kept apart.
+func (s *Store) RecordMatch(ctx context.Context, key string, wait time.Duration, at time.Time) error {
+	k := matchWaitKey(key)
+	pipe := s.redis.TxPipeline()
+	pipe.ZAdd(ctx, k, redis.Z{Score: float64(at.UnixMilli()), Member: fmt.Sprintf("%d:%d", at.UnixNano(), int64(wait/time.Millisecond))})
+	pipe.ZRemRangeByScore(ctx, k, "-inf", fmt.Sprintf("(%d", at.Add(-matchStatsWindow).UnixMilli()))
+	pipe.ZRemRangeByRank(ctx, k, 0, -maxMatchSamples-1)
+	pipe.Expire(ctx, k, matchStatsWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("record match %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *Store) RecentMatches(ctx context.Context, key string, since time.Time) ([]time.Duration, error) {
+	members, err := s.redis.ZRangeByScore(ctx, matchWaitKey(key), &redis.ZRangeBy{
+		Min: fmt.Sprint(since.UnixMilli()),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("recent matches %s: %w", key, err)
+	}
+	out := make([]time.Duration, 0, len(members))
+	for _, m := range members {
+		var nanos, ms int64
+		if _, err := fmt.Sscanf(m, "%d:%d", &nanos, &ms); err != nil {
+			continue
+		}
+		out = append(out, time.Duration(ms)*time.Millisecond)
+	}
+	return out, nil
+}
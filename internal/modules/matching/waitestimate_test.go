@@ -0,0 +1,74 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/config"
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type fakeMatchStats map[string][]time.Duration
+
+func (f fakeMatchStats) RecordMatch(_ context.Context, key string, wait time.Duration, _ time.Time) error {
+	f[key] = append(f[key], wait)
+	return nil
+}
+
+func (f fakeMatchStats) RecentMatches(_ context.Context, key string, _ time.Time) ([]time.Duration, error) {
+	return f[key], nil
+}
+
+func TestEstimateWait(t *testing.T) {
+	loc := &fakeLocator{nearby: []location.DriverLocation{
+		{DriverID: "d1", Distance: 1.0},
+		{DriverID: "d2", Distance: 2.0},
+		{DriverID: "wav", Distance: 2.5},
+	}}
+	svc := NewService(nil, nil, nil, loc, config.MatchingConfig{RadiusKm: 3})
+	svc.SetCapabilityFilter(fakeCapabilities{"wav": {"wheelchair"}})
+	stats := fakeMatchStats{}
+	svc.SetMatchStats(stats)
+	ctx := context.Background()
+	p := types.Point{Lat: 25.0330, Lng: 121.5654}
+
+	// No recent matches: three drivers cut the 3-minute fallback to one.
+	est, err := svc.EstimateWait(ctx, p, "standard")
+	if err != nil {
+		t.Fatalf("EstimateWait: %v", err)
+	}
+	if est.Basis != BasisSupply || est.Drivers != 3 || est.Wait == nil || *est.Wait != time.Minute {
+		t.Errorf("estimate = %+v, want 3 drivers and 1m from supply", est)
+	}
+
+	// Matches in the zone are quoted by their median.
+	o := &order.Order{Pickup: p, RideType: "standard"}
+	for _, w := range []time.Duration{2 * time.Minute, 4 * time.Minute, 9 * time.Minute} {
+		if err := svc.recordMatch(ctx, o, w, time.Now()); err != nil {
+			t.Fatalf("recordMatch: %v", err)
+		}
+	}
+	est, _ = svc.EstimateWait(ctx, p, "standard")
+	if est.Basis != BasisRecentMatches || est.Samples != 3 || *est.Wait != 4*time.Minute {
+		t.Errorf("estimate = %+v, want the 4m median of 3 samples", est)
+	}
+
+	// Stats are kept per ride type, and WAV counts only WAV drivers.
+	est, _ = svc.EstimateWait(ctx, p, order.RideTypeWAV)
+	if est.Basis != BasisSupply || est.Drivers != 1 || *est.Wait != fallbackMatchWait {
+		t.Errorf("WAV estimate = %+v, want 1 driver and the fallback wait", est)
+	}
+
+	loc.nearby = nil
+	est, _ = svc.EstimateWait(ctx, p, "standard")
+	if est.Basis != BasisNoSupply || est.Wait != nil {
+		t.Errorf("estimate without drivers = %+v, want no wait", est)
+	}
+	if _, err := svc.EstimateWait(ctx, types.Point{Lat: 91}, "standard"); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("EstimateWait(invalid) = %v, want ErrInvalidPosition", err)
+	}
+}
@@ -0,0 +1,197 @@
+// README: Notification dead letters — messages that failed every delivery attempt, kept with their error for ops to list and resend.
+package notification
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	"ark/internal/types"
+)
+
+var (
+	// ErrDeadLetterNotFound is returned when retrying an unknown dead letter.
+	ErrDeadLetterNotFound = errors.New("notification: dead letter not found")
+	// ErrDeadLetterResolved is returned when retrying a dead letter that was already delivered.
+	ErrDeadLetterResolved = errors.New("notification: dead letter already delivered")
+	// ErrRetryFailed wraps the delivery error of an ops retry.
+	ErrRetryFailed = errors.New("notification: retry failed")
+)
+
+// deliveries counts failed deliveries by kind: "failed" attempts,
+// "push_failed" device sends, "offer_failed" direct new-order offers,
+// "dead_lettered" messages and ops "retried" / "retry_failed" outcomes.
+// Exposed on /api/ops/debug/vars.
+var deliveries = expvar.NewMap("notification_deliveries")
+
+const (
+	// defaultDeliveryAttempts is how often a message is tried, the first
+	// attempt included, before it is dead-lettered.
+	defaultDeliveryAttempts = 3
+	// defaultRetryDelay is the wait before the first retry; later retries
+	// wait proportionally longer.
+	defaultRetryDelay = 2 * time.Second
+	// deadLetterListLimit caps the ops list.
+	deadLetterListLimit = 200
+)
+
+// DeadLetter is a notification that could not be delivered.
+type DeadLetter struct {
+	ID       int64             `json:"id"`
+	UserID   types.ID          `json:"user_id"`
+	Title    string            `json:"title"`
+	Body     string            `json:"body"`
+	Category Category          `json:"category"`
+	Critical bool              `json:"critical"`
+	Data     map[string]string `json:"data,omitempty"`
+	// Error is the error of the latest attempt.
+	Error      string     `json:"error"`
+	Attempts   int        `json:"attempts"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RetriedAt  *time.Time `json:"retried_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Message rebuilds the notification to resend.
+func (d *DeadLetter) Message() *NotificationMessage {
+	data := make(map[string]interface{}, len(d.Data))
+	for k, v := range d.Data {
+		data[k] = v
+	}
+	return &NotificationMessage{Title: d.Title, Body: d.Body, Category: d.Category, Critical: d.Critical, Data: data}
+}
+
+// DeadLetterStore persists dead letters.
+type DeadLetterStore interface {
+	// SaveDeadLetter inserts d and sets its ID and CreatedAt.
+	SaveDeadLetter(ctx context.Context, d *DeadLetter) error
+	// ListDeadLetters returns the newest dead letters first, only unresolved
+	// ones unless includeResolved.
+	ListDeadLetters(ctx context.Context, includeResolved bool, limit int) ([]DeadLetter, error)
+	// GetDeadLetter returns nil when the dead letter does not exist.
+	GetDeadLetter(ctx context.Context, id int64) (*DeadLetter, error)
+	// MarkDeadLetterRetried records an ops retry at at; errMsg is empty when
+	// it was delivered, which resolves the dead letter.
+	MarkDeadLetterRetried(ctx context.Context, id int64, at time.Time, errMsg string) error
+}
+
+// SetDeadLetterStore makes NotifyUser retry failed deliveries and keep the
+// ones that never go through. Transient messages are exempt.
+func (s *Service) SetDeadLetterStore(store DeadLetterStore) {
+	s.deadLetters = store
+	s.attempts = defaultDeliveryAttempts
+	s.retryDelay = defaultRetryDelay
+}
+
+// deliverOrDeadLetter tries message until it is delivered, fails for a reason
+// retrying cannot fix, or runs out of attempts or time; the last failure is
+// dead-lettered and returned.
+func (s *Service) deliverOrDeadLetter(ctx context.Context, userID types.ID, message *NotificationMessage) error {
+	var err error
+	attempts := 0
+	for attempts < s.attempts {
+		if attempts > 0 && !wait(ctx, time.Duration(attempts)*s.retryDelay) {
+			break
+		}
+		attempts++
+		if err = s.deliver(ctx, userID, message); err == nil {
+			return nil
+		}
+		deliveries.Add("failed", 1)
+		if permanent(err) {
+			break
+		}
+	}
+	s.deadLetter(context.WithoutCancel(ctx), userID, message, err, attempts)
+	return err
+}
+
+// permanent reports whether a delivery error will not clear up by retrying.
+func permanent(err error) bool {
+	return errors.Is(err, ErrNoPhoneNumber) || errors.Is(err, ErrSMSCapReached)
+}
+
+// wait sleeps for d and reports false if ctx ended first.
+func wait(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Service) deadLetter(ctx context.Context, userID types.ID, message *NotificationMessage, cause error, attempts int) {
+	d := &DeadLetter{
+		UserID:   userID,
+		Title:    message.Title,
+		Body:     message.Body,
+		Category: message.Category,
+		Critical: message.Critical,
+		Data:     make(map[string]string, len(message.Data)),
+		Error:    cause.Error(),
+		Attempts: attempts,
+	}
+	for k, v := range message.Data {
+		if sv, ok := v.(string); ok {
+			d.Data[k] = sv
+		}
+	}
+	if err := s.deadLetters.SaveDeadLetter(ctx, d); err != nil {
+		log.Printf("notification: dead-letter %q to %s: %v (delivery: %v)", message.Title, userID, err, cause)
+		return
+	}
+	deliveries.Add("dead_lettered", 1)
+	log.Printf("notification: dead-lettered %q to %s after %d attempts: %v", message.Title, userID, attempts, cause)
+}
+
+// DeadLetters lists dead letters for ops, newest first.
+func (s *Service) DeadLetters(ctx context.Context, includeResolved bool) ([]DeadLetter, error) {
+	if s.deadLetters == nil {
+		return []DeadLetter{}, nil
+	}
+	return s.deadLetters.ListDeadLetters(ctx, includeResolved, deadLetterListLimit)
+}
+
+// RetryDeadLetter resends a dead letter once and records the outcome on it.
+// A failed resend returns the updated dead letter along with ErrRetryFailed.
+func (s *Service) RetryDeadLetter(ctx context.Context, id int64) (*DeadLetter, error) {
+	if s.deadLetters == nil {
+		return nil, ErrDeadLetterNotFound
+	}
+	d, err := s.deadLetters.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDeadLetterNotFound
+	}
+	if d.ResolvedAt != nil {
+		return nil, ErrDeadLetterResolved
+	}
+
+	sendErr := s.deliver(ctx, d.UserID, d.Message())
+	now := s.now()
+	errMsg := ""
+	if sendErr != nil {
+		errMsg = sendErr.Error()
+	}
+	if err := s.deadLetters.MarkDeadLetterRetried(ctx, id, now, errMsg); err != nil {
+		return nil, err
+	}
+	d.Attempts++
+	d.RetriedAt = &now
+	if sendErr != nil {
+		deliveries.Add("retry_failed", 1)
+		d.Error = errMsg
+		return d, fmt.Errorf("%w: %v", ErrRetryFailed, sendErr)
+	}
+	deliveries.Add("retried", 1)
+	d.ResolvedAt = &now
+	return d, nil
+}
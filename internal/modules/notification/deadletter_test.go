@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type mockDeadLetterStore struct {
+	letters []*DeadLetter
+}
+
+func (m *mockDeadLetterStore) SaveDeadLetter(_ context.Context, d *DeadLetter) error {
+	d.ID = int64(len(m.letters) + 1)
+	cp := *d
+	m.letters = append(m.letters, &cp)
+	return nil
+}
+
+func (m *mockDeadLetterStore) ListDeadLetters(_ context.Context, includeResolved bool, limit int) ([]DeadLetter, error) {
+	var out []DeadLetter
+	for _, d := range m.letters {
+		if includeResolved || d.ResolvedAt == nil {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockDeadLetterStore) GetDeadLetter(_ context.Context, id int64) (*DeadLetter, error) {
+	for _, d := range m.letters {
+		if d.ID == id {
+			cp := *d
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDeadLetterStore) MarkDeadLetterRetried(_ context.Context, id int64, at time.Time, errMsg string) error {
+	for _, d := range m.letters {
+		if d.ID == id {
+			d.Attempts++
+			d.RetriedAt = &at
+			if errMsg == "" {
+				d.ResolvedAt = &at
+			} else {
+				d.Error = errMsg
+			}
+		}
+	}
+	return nil
+}
+
+// newDeadLetterTestService is newSMSTestService with dead-lettering on and no
+// wait between attempts.
+func newDeadLetterTestService(t *testing.T) (*Service, *fakeSMSProvider, *mockDeadLetterStore) {
+	t.Helper()
+	svc, provider, _ := newSMSTestService(t, 0)
+	dl := &mockDeadLetterStore{}
+	svc.SetDeadLetterStore(dl)
+	svc.retryDelay = 0
+	return svc, provider, dl
+}
+
+func TestNotifyUser_DeadLettersAfterRetries(t *testing.T) {
+	svc, provider, dl := newDeadLetterTestService(t)
+	provider.err = errors.New("provider down")
+
+	msg := &NotificationMessage{Title: "Arrived", Body: "Driver is here", Critical: true, Data: map[string]interface{}{"order_id": "o1"}}
+	if err := svc.NotifyUser(context.Background(), "u1", msg); err == nil {
+		t.Fatal("expected delivery error")
+	}
+	if len(dl.letters) != 1 {
+		t.Fatalf("dead letters = %d, want 1", len(dl.letters))
+	}
+	d := dl.letters[0]
+	if d.UserID != "u1" || d.Attempts != defaultDeliveryAttempts || d.Error == "" || d.Data["order_id"] != "o1" {
+		t.Fatalf("dead letter = %+v", d)
+	}
+}
+
+func TestNotifyUser_PermanentFailureNotRetried(t *testing.T) {
+	svc, _, dl := newDeadLetterTestService(t)
+	svc.sms.store.(*fakeSMSStore).phones = nil
+
+	err := svc.NotifyUser(context.Background(), "u1", &NotificationMessage{Title: "Arrived", Critical: true})
+	if !errors.Is(err, ErrNoPhoneNumber) {
+		t.Fatalf("err = %v, want ErrNoPhoneNumber", err)
+	}
+	if len(dl.letters) != 1 || dl.letters[0].Attempts != 1 {
+		t.Fatalf("dead letters = %+v, want one after a single attempt", dl.letters)
+	}
+}
+
+func TestNotifyUser_TransientNotDeadLettered(t *testing.T) {
+	svc, provider, dl := newDeadLetterTestService(t)
+	provider.err = errors.New("provider down")
+
+	msg := &NotificationMessage{Title: "Offer", Critical: true, Transient: true}
+	if err := svc.NotifyUser(context.Background(), "u1", msg); err == nil {
+		t.Fatal("expected delivery error")
+	}
+	if len(dl.letters) != 0 {
+		t.Fatalf("dead letters = %d, want 0", len(dl.letters))
+	}
+}
+
+func TestRetryDeadLetter(t *testing.T) {
+	svc, provider, dl := newDeadLetterTestService(t)
+	ctx := context.Background()
+	provider.err = errors.New("provider down")
+	if err := svc.NotifyUser(ctx, "u1", &NotificationMessage{Title: "Arrived", Body: "Driver is here", Critical: true}); err == nil {
+		t.Fatal("expected delivery error")
+	}
+
+	if _, err := svc.RetryDeadLetter(ctx, 1); !errors.Is(err, ErrRetryFailed) {
+		t.Fatalf("retry while provider down: err = %v, want ErrRetryFailed", err)
+	}
+
+	provider.err = nil
+	d, err := svc.RetryDeadLetter(ctx, 1)
+	if err != nil {
+		t.Fatalf("RetryDeadLetter: %v", err)
+	}
+	if d.ResolvedAt == nil || d.Attempts != defaultDeliveryAttempts+2 {
+		t.Fatalf("dead letter = %+v", d)
+	}
+	if len(provider.sent) != 1 || provider.sent[0] != "+886912345678|Arrived: Driver is here" {
+		t.Fatalf("sent = %v", provider.sent)
+	}
+	if dl.letters[0].ResolvedAt == nil {
+		t.Fatal("stored dead letter not resolved")
+	}
+	if open, _ := svc.DeadLetters(ctx, false); len(open) != 0 {
+		t.Fatalf("open dead letters = %d, want 0", len(open))
+	}
+
+	if _, err := svc.RetryDeadLetter(ctx, 1); !errors.Is(err, ErrDeadLetterResolved) {
+		t.Fatalf("second retry: err = %v, want ErrDeadLetterResolved", err)
+	}
+	if _, err := svc.RetryDeadLetter(ctx, 99); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Fatalf("unknown id: err = %v, want ErrDeadLetterNotFound", err)
+	}
+}
@@ -0,0 +1,139 @@
+// README: Transactional email channel — pluggable senders (SMTP, SendGrid) used for receipts and summaries.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark/internal/config"
+)
+
+// Email is a single plain-text message.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// EmailSender delivers one email.
+type EmailSender interface {
+	Send(ctx context.Context, e Email) error
+}
+
+// NewEmailSender builds the sender selected by cfg.Provider, or nil when email is disabled.
+func NewEmailSender(cfg config.EmailConfig) (EmailSender, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "smtp":
+		return &SMTPSender{Host: cfg.SMTPHost, Port: cfg.SMTPPort, User: cfg.SMTPUser, Password: cfg.SMTPPassword, From: cfg.From}, nil
+	case "sendgrid":
+		return &SendGridSender{APIKey: cfg.SendGridAPIKey, From: cfg.From, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// SMTP
+// ---------------------------------------------------------------------------
+
+// SMTPSender sends through an SMTP relay with optional PLAIN auth (STARTTLS is
+// negotiated by net/smtp when the server offers it).
+type SMTPSender struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(_ context.Context, e Email) error {
+	var auth smtp.Auth
+	if s.User != "" {
+		auth = smtp.PlainAuth("", s.User, s.Password, s.Host)
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	if err := smtp.SendMail(addr, auth, s.From, []string{e.To}, buildMIME(s.From, e)); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+// buildMIME renders a UTF-8 plain-text message with an encoded subject so
+// zh-TW subjects survive 7-bit relays.
+func buildMIME(from string, e Email) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", e.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(e.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// ---------------------------------------------------------------------------
+// SendGrid
+// ---------------------------------------------------------------------------
+
+// SendGridSender sends through the SendGrid v3 mail API.
+type SendGridSender struct {
+	APIKey  string
+	From    string
+	Client  *http.Client
+	BaseURL string // overridable for tests; defaults to https://api.sendgrid.com
+}
+
+func (s *SendGridSender) Send(ctx context.Context, e Email) error {
+	base := s.BaseURL
+	if base == "" {
+		base = "https://api.sendgrid.com"
+	}
+	type address struct {
+		Email string `json:"email"`
+	}
+	type content struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+	payload := map[string]any{
+		"personalizations": []map[string]any{{"to": []address{{Email: e.To}}}},
+		"from":             address{Email: s.From},
+		"subject":          e.Subject,
+		"content":          []content{{Type: "text/plain", Value: e.Body}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sendgrid: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sendgrid: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendGridSender_Send(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mail/send" || r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := &SendGridSender{APIKey: "key", From: "no-reply@ark.test", Client: srv.Client(), BaseURL: srv.URL}
+	if err := s.Send(context.Background(), Email{To: "a@b.test", Subject: "收據", Body: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got["subject"] != "收據" {
+		t.Errorf("payload subject = %v", got["subject"])
+	}
+
+	s.APIKey = "wrong"
+	if err := s.Send(context.Background(), Email{To: "a@b.test"}); err == nil {
+		t.Fatal("expected error for rejected key")
+	}
+}
+
+func TestBuildMIME_EncodesSubject(t *testing.T) {
+	msg := string(buildMIME("from@ark.test", Email{To: "to@ark.test", Subject: "Ark 乘車收據", Body: "line1\nline2"}))
+	if !strings.Contains(msg, "Subject: =?utf-8?q?") {
+		t.Errorf("subject not Q-encoded:\n%s", msg)
+	}
+	if !strings.Contains(msg, "line1\r\nline2") {
+		t.Errorf("body lines not CRLF-terminated:\n%s", msg)
+	}
+}
@@ -0,0 +1,390 @@
+// README: Order lifecycle notifications sent to passengers, drivers and parcel recipients from order transition, schedule, departure-advice, pre-trip reminder and navigation handoff hooks.
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ark/internal/modules/departure"
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/modules/pretrip"
+	"ark/internal/modules/triproute"
+	"ark/internal/sandbox"
+	"ark/internal/types"
+)
+
+// orderEventTimeout bounds one lifecycle notification, including any SMS fallback.
+const orderEventTimeout = 15 * time.Second
+
+// maxPushPolyline is the longest route polyline sent in a push; FCM data is
+// capped at 4 KB, and the app fetches longer routes from the navigation
+// endpoint.
+const maxPushPolyline = 2048
+
+// OrderEventHook notifies the passenger when their driver arrives at pickup.
+// Delivery runs in the background so a slow provider cannot delay the transition.
+func (s *Service) OrderEventHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusArrived {
+			return
+		}
+		if t.Sandbox {
+			ctx = sandbox.WithContext(ctx)
+		}
+		msg := &NotificationMessage{
+			Title:    "Your driver has arrived",
+			Body:     "Your driver is waiting at the pickup point.",
+			Category: CategoryOrderUpdate,
+			Critical: true,
+			Data: map[string]interface{}{
+				"type":     "driver_arrived",
+				"order_id": string(t.OrderID),
+			},
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderEventTimeout)
+			defer cancel()
+			if err := s.NotifyUser(ctx, t.PassengerID, msg); err != nil {
+				log.Printf("notification: driver-arrived for order %s: %v", t.OrderID, err)
+			}
+		}()
+	}
+}
+
+// DeliveryEventHook tells the sender of a delivery when their parcel is
+// collected and when it is delivered, and texts the recipient, whose phone
+// the sender gave, that it is on its way. The text counts toward the sender's
+// SMS cap; sandbox orders text nobody.
+func (s *Service) DeliveryEventHook(orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}) order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusDriving && t.To != order.StatusPayment {
+			return
+		}
+		if t.Sandbox {
+			ctx = sandbox.WithContext(ctx)
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderEventTimeout)
+			defer cancel()
+			o, err := orders.Get(ctx, t.OrderID)
+			if err != nil {
+				log.Printf("notification: delivery order %s: %v", t.OrderID, err)
+				return
+			}
+			d := o.Delivery
+			if d == nil {
+				return
+			}
+			msg := &NotificationMessage{
+				Title:    "Your parcel is on its way",
+				Body:     fmt.Sprintf("Your driver has collected the parcel for %s.", d.RecipientName),
+				Category: CategoryOrderUpdate,
+				Data: map[string]interface{}{
+					"type":     "parcel_collected",
+					"order_id": string(t.OrderID),
+				},
+			}
+			if t.To == order.StatusPayment {
+				msg.Title = "Your parcel was delivered"
+				msg.Body = fmt.Sprintf("%s signed for the parcel.", d.SignedBy)
+				msg.Data["type"] = "parcel_delivered"
+			}
+			if err := s.NotifyUser(ctx, t.PassengerID, msg); err != nil {
+				log.Printf("notification: %s for order %s: %v", msg.Data["type"], t.OrderID, err)
+			}
+			if t.To != order.StatusDriving || s.sms == nil || sandbox.Enabled(ctx) {
+				return
+			}
+			text := &NotificationMessage{
+				Title: "Ark",
+				Body:  fmt.Sprintf("A parcel for %s is on its way to you.", d.RecipientName),
+				Data:  map[string]interface{}{"type": "parcel_recipient"},
+			}
+			if err := s.sendSMSTo(ctx, t.PassengerID, d.RecipientPhone, text); err != nil {
+				log.Printf("notification: recipient text for order %s: %v", t.OrderID, err)
+			}
+		}()
+	}
+}
+
+// ScheduleChangeHook tells the assigned driver and the passenger when a
+// scheduled pickup moves, e.g. because the passenger's flight is delayed.
+func (s *Service) ScheduleChangeHook() order.ScheduleHook {
+	return func(ctx context.Context, c order.ScheduleChange) {
+		if c.Sandbox {
+			ctx = sandbox.WithContext(ctx)
+		}
+		data := map[string]interface{}{
+			"type":         "schedule_changed",
+			"order_id":     string(c.OrderID),
+			"scheduled_at": c.To.Format(time.RFC3339),
+			"reason":       c.Reason,
+		}
+		type recipient struct {
+			userID types.ID
+			msg    *NotificationMessage
+		}
+		recipients := []recipient{{c.PassengerID, &NotificationMessage{
+			Title:    "Pickup time updated",
+			Body:     "Your pickup is now at " + c.To.Format("15:04") + ".",
+			Category: CategoryOrderUpdate,
+			Data:     data,
+		}}}
+		if c.DriverID != nil {
+			recipients = append(recipients, recipient{*c.DriverID, &NotificationMessage{
+				Title:    "Pickup time changed",
+				Body:     "A scheduled pickup moved to " + c.To.Format("15:04") + ".",
+				Category: CategoryOrderUpdate,
+				Critical: true,
+				Data:     data,
+			}})
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderEventTimeout)
+			defer cancel()
+			for _, r := range recipients {
+				if err := s.NotifyUser(ctx, r.userID, r.msg); err != nil {
+					log.Printf("notification: schedule-changed for order %s: %v", c.OrderID, err)
+				}
+			}
+		}()
+	}
+}
+
+// DropoffChangeHook asks the driver to acknowledge a dropoff the passenger
+// changed mid-trip, and tells the passenger the driver's answer.
+func (s *Service) DropoffChangeHook() order.DropoffHook {
+	return func(ctx context.Context, c order.DropoffChange) {
+		if c.Sandbox {
+			ctx = sandbox.WithContext(ctx)
+		}
+		data := map[string]interface{}{
+			"type":          "dropoff_" + c.Stage,
+			"order_id":      string(c.OrderID),
+			"dropoff_lat":   c.To.Lat,
+			"dropoff_lng":   c.To.Lng,
+			"estimated_fee": c.NewFare.Amount,
+		}
+		var userID types.ID
+		var msg *NotificationMessage
+		switch c.Stage {
+		case order.DropoffRequested:
+			if c.DriverID == nil {
+				return
+			}
+			userID = *c.DriverID
+			msg = &NotificationMessage{
+				Title:    "New destination requested",
+				Body:     "Your passenger wants to change the dropoff. Please accept or decline.",
+				Category: CategoryOrderUpdate,
+				Critical: true,
+				Data:     data,
+			}
+		case order.DropoffAccepted:
+			userID = c.PassengerID
+			msg = &NotificationMessage{
+				Title:    "Destination updated",
+				Body:     "Your driver accepted the new dropoff.",
+				Category: CategoryOrderUpdate,
+				Data:     data,
+			}
+		default:
+			userID = c.PassengerID
+			msg = &NotificationMessage{
+				Title:    "Destination not changed",
+				Body:     "Your driver declined the new dropoff.",
+				Category: CategoryOrderUpdate,
+				Data:     data,
+			}
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderEventTimeout)
+			defer cancel()
+			if err := s.NotifyUser(ctx, userID, msg); err != nil {
+				log.Printf("notification: dropoff %s for order %s: %v", c.Stage, c.OrderID, err)
+			}
+		}()
+	}
+}
+
+// RequoteHook tells the passenger when a scheduled ride was re-priced after
+// the pricing rules changed: a lower fare is applied, a higher one needs their
+// consent, and declining it cancels the ride.
+func (s *Service) RequoteHook() order.RequoteHook {
+	return func(ctx context.Context, r order.Requote) {
+		if r.Stage == order.RequoteAccepted {
+			return
+		}
+		if r.Sandbox {
+			ctx = sandbox.WithContext(ctx)
+		}
+		data := map[string]interface{}{
+			"type":          "requote_" + r.Stage,
+			"order_id":      string(r.OrderID),
+			"scheduled_at":  r.ScheduledAt.Unix(),
+			"estimated_fee": r.Fare.Amount,
+			"requote_fee":   r.NewFare.Amount,
+			"currency":      r.NewFare.Currency,
+		}
+		var msg *NotificationMessage
+		switch r.Stage {
+		case order.RequoteRequested:
+			msg = &NotificationMessage{
+				Title:    "Fare update for your scheduled ride",
+				Body:     "Fares changed since you booked. Please accept the new fare or cancel the ride.",
+				Category: CategoryOrderUpdate,
+				Critical: true,
+				Data:     data,
+			}
+		case order.RequoteLowered:
+			msg = &NotificationMessage{
+				Title:    "Your scheduled ride got cheaper",
+				Body:     "Fares dropped since you booked, so we lowered your fare.",
+				Category: CategoryOrderUpdate,
+				Data:     data,
+			}
+		default:
+			msg = &NotificationMessage{
+				Title:    "Scheduled ride cancelled",
+				Body:     "You declined the new fare, so your scheduled ride was cancelled.",
+				Category: CategoryOrderUpdate,
+				Data:     data,
+			}
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderEventTimeout)
+			defer cancel()
+			if err := s.NotifyUser(ctx, r.PassengerID, msg); err != nil {
+				log.Printf("notification: requote %s for order %s: %v", r.Stage, r.OrderID, err)
+			}
+		}()
+	}
+}
+
+// DriverOfflineHook alerts ops and the passenger when a driver on an active
+// trip stops sending positions and is taken offline.
+func (s *Service) DriverOfflineHook(orders interface {
+	ListByDriver(ctx context.Context, driverID types.ID, statuses []order.Status) ([]*order.Order, error)
+}) location.OfflineHook {
+	active := []order.Status{order.StatusApproaching, order.StatusArrived, order.StatusDriving}
+	return func(ctx context.Context, d location.DriverOffline) {
+		list, err := orders.ListByDriver(ctx, d.DriverID, active)
+		if err != nil {
+			log.Printf("notification: driver-offline orders for %s: %v", d.DriverID, err)
+			return
+		}
+		for _, o := range list {
+			log.Printf("ops alert: driver %s went offline in region %s on active order %s (%s)", d.DriverID, d.Region, o.ID, o.Status)
+			octx := ctx
+			if o.Sandbox {
+				octx = sandbox.WithContext(ctx)
+			}
+			msg := &NotificationMessage{
+				Title:    "Lost contact with your driver",
+				Body:     "We can't see your driver's location right now. We're looking into it.",
+				Category: CategoryOrderUpdate,
+				Critical: true,
+				Data: map[string]interface{}{
+					"type":     "driver_offline",
+					"order_id": string(o.ID),
+				},
+			}
+			go func(o *order.Order) {
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(octx), orderEventTimeout)
+				defer cancel()
+				if err := s.NotifyUser(ctx, o.PassengerID, msg); err != nil {
+					log.Printf("notification: driver-offline for order %s: %v", o.ID, err)
+				}
+			}(o)
+		}
+	}
+}
+
+// DepartureSuggestionHook asks the passenger to leave earlier when traffic
+// would make their scheduled ride miss its arrive-by time.
+func (s *Service) DepartureSuggestionHook() departure.SuggestionHook {
+	return func(ctx context.Context, sg departure.Suggestion) {
+		msg := &NotificationMessage{
+			Title: "Leave earlier to arrive on time",
+			Body: "Traffic is heavy: leaving at " + sg.ScheduledAt.Format("15:04") +
+				" may get you there late. Move your pickup to " + sg.SuggestedAt.Format("15:04") + "?",
+			Category: CategoryOrderUpdate,
+			Critical: true,
+			Data: map[string]interface{}{
+				"type":         "departure_suggestion",
+				"order_id":     string(sg.OrderID),
+				"scheduled_at": sg.ScheduledAt.Format(time.RFC3339),
+				"suggested_at": sg.SuggestedAt.Format(time.RFC3339),
+				"arrive_by":    sg.ArriveBy.Format(time.RFC3339),
+			},
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderEventTimeout)
+			defer cancel()
+			if err := s.NotifyUser(ctx, sg.PassengerID, msg); err != nil {
+				log.Printf("notification: departure suggestion for order %s: %v", sg.OrderID, err)
+			}
+		}()
+	}
+}
+
+// PretripReminderHook reminds the driver of a claimed scheduled ride to set
+// off, with a link that starts navigation to the pickup.
+func (s *Service) PretripReminderHook() pretrip.ReminderHook {
+	return func(ctx context.Context, r pretrip.Reminder) {
+		msg := &NotificationMessage{
+			Title:    "Time to head to your pickup",
+			Body:     "Your scheduled pickup is at " + r.ScheduledAt.Format("15:04") + ". Tap Depart when you set off.",
+			Category: CategoryOrderUpdate,
+			Critical: true,
+			Data: map[string]interface{}{
+				"type":           "pretrip_reminder",
+				"order_id":       string(r.OrderID),
+				"scheduled_at":   r.ScheduledAt.Format(time.RFC3339),
+				"pickup_lat":     r.Pickup.Lat,
+				"pickup_lng":     r.Pickup.Lng,
+				"navigation_url": r.NavigationURL(),
+			},
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderEventTimeout)
+			defer cancel()
+			if err := s.NotifyUser(ctx, r.DriverID, msg); err != nil {
+				log.Printf("notification: pretrip reminder for order %s: %v", r.OrderID, err)
+			}
+		}()
+	}
+}
+
+// NavigationHook pushes the navigation handoff to the driver who accepted or
+// claimed a ride, or whose dropoff changed, so any of their devices can start
+// Google Maps navigation.
+func (s *Service) NavigationHook() triproute.NavigationHook {
+	return func(ctx context.Context, driverID types.ID, n *triproute.Navigation) {
+		data := map[string]interface{}{
+			"type":                   "navigation",
+			"order_id":               string(n.OrderID),
+			"pickup":                 fmt.Sprintf("%.6f,%.6f", n.Pickup.Lat, n.Pickup.Lng),
+			"dropoff":                fmt.Sprintf("%.6f,%.6f", n.Dropoff.Lat, n.Dropoff.Lng),
+			"navigation_url":         n.ToPickup.Web,
+			"dropoff_navigation_url": n.ToDropoff.Web,
+		}
+		if n.Polyline != "" && len(n.Polyline) <= maxPushPolyline {
+			data["polyline"] = n.Polyline
+		}
+		msg := &NotificationMessage{
+			Title:    "Navigation ready",
+			Body:     "Tap to start navigation to the pickup.",
+			Category: CategoryOrderUpdate,
+			Data:     data,
+		}
+		if err := s.NotifyUser(ctx, driverID, msg); err != nil {
+			log.Printf("notification: navigation for order %s: %v", n.OrderID, err)
+		}
+	}
+}
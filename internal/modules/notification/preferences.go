@@ -0,0 +1,172 @@
+// README: Per-user notification preferences (categories, quiet hours, channels) and their enforcement rules.
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Category classifies a notification for preference filtering.
+type Category string
+
+const (
+	CategoryOrderUpdate Category = "order_update" // ride lifecycle and new-order offers
+	CategoryPromo       Category = "promo"
+	CategoryReminder    Category = "reminder" // e.g. upcoming scheduled ride
+)
+
+// Channel is a delivery channel a user may opt into.
+type Channel string
+
+const (
+	ChannelPush Channel = "push"
+	ChannelSMS  Channel = "sms"
+)
+
+// defaultTimezone is used for quiet hours when the user has not set one.
+const defaultTimezone = "Asia/Taipei"
+
+// Supported message locales.
+const (
+	LocaleZhTW = "zh-TW"
+	LocaleEn   = "en"
+)
+
+// ErrInvalidPreferences is returned when preferences fail validation.
+var ErrInvalidPreferences = errors.New("notification: invalid preferences")
+
+// QuietHours is a daily window, in minutes after local midnight, during which
+// non-critical notifications are held back. Start > End wraps past midnight.
+type QuietHours struct {
+	StartMin int
+	EndMin   int
+}
+
+// Preferences are a user's notification settings.
+type Preferences struct {
+	UserID       types.ID
+	OrderUpdates bool
+	Promos       bool
+	Reminders    bool
+	QuietHours   *QuietHours
+	Timezone     string
+	PushEnabled  bool
+	SMSEnabled   bool
+	// Locale selects the language of emails and SMS (zh-TW or en).
+	Locale string
+	// MonthlySummary opts the user into the monthly trip summary email.
+	MonthlySummary bool
+	// SpokenText adds a plain-text version of every push, ready to be read
+	// aloud, for visually impaired users.
+	SpokenText bool
+	UpdatedAt  time.Time
+}
+
+// DefaultPreferences are applied to users who never saved preferences.
+func DefaultPreferences(userID types.ID) Preferences {
+	return Preferences{
+		UserID:       userID,
+		OrderUpdates: true,
+		Promos:       false,
+		Reminders:    true,
+		Timezone:     defaultTimezone,
+		PushEnabled:  true,
+		Locale:       LocaleZhTW,
+	}
+}
+
+// localeForLanguage picks the message locale for a user's preferred language;
+// there are no Japanese templates, so Japanese speakers get English.
+func localeForLanguage(lang string) string {
+	switch lang {
+	case "", LocaleZhTW:
+		return LocaleZhTW
+	}
+	return LocaleEn
+}
+
+// Validate checks the quiet hours window, timezone and locale.
+func (p Preferences) Validate() error {
+	if q := p.QuietHours; q != nil {
+		if q.StartMin < 0 || q.StartMin >= 24*60 || q.EndMin < 0 || q.EndMin >= 24*60 || q.StartMin == q.EndMin {
+			return fmt.Errorf("%w: quiet hours must be two different times of day", ErrInvalidPreferences)
+		}
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidPreferences, p.Timezone)
+		}
+	}
+	switch p.Locale {
+	case "", LocaleZhTW, LocaleEn:
+	default:
+		return fmt.Errorf("%w: locale must be %s or %s", ErrInvalidPreferences, LocaleZhTW, LocaleEn)
+	}
+	return nil
+}
+
+// wantsCategory reports whether the user opted into the category. Messages
+// without a category are treated as order updates.
+func (p Preferences) wantsCategory(c Category) bool {
+	switch c {
+	case CategoryPromo:
+		return p.Promos
+	case CategoryReminder:
+		return p.Reminders
+	default:
+		return p.OrderUpdates
+	}
+}
+
+// inQuietHours reports whether now falls inside the user's quiet window.
+func (p Preferences) inQuietHours(now time.Time) bool {
+	q := p.QuietHours
+	if q == nil {
+		return false
+	}
+	tz := p.Timezone
+	if tz == "" {
+		tz = defaultTimezone
+	}
+	if loc, err := time.LoadLocation(tz); err == nil {
+		now = now.In(loc)
+	}
+	m := now.Hour()*60 + now.Minute()
+	if q.StartMin < q.EndMin {
+		return m >= q.StartMin && m < q.EndMin
+	}
+	return m >= q.StartMin || m < q.EndMin
+}
+
+// Allows reports whether a message of the given category may be sent on ch at
+// now. Order updates are time-critical and ignore quiet hours.
+func (p Preferences) Allows(c Category, ch Channel, now time.Time) bool {
+	if !p.wantsCategory(c) {
+		return false
+	}
+	switch ch {
+	case ChannelPush:
+		if !p.PushEnabled {
+			return false
+		}
+	case ChannelSMS:
+		if !p.SMSEnabled {
+			return false
+		}
+	}
+	if c != CategoryOrderUpdate && c != "" && p.inQuietHours(now) {
+		return false
+	}
+	return true
+}
+
+// PreferenceStore persists per-user notification preferences.
+type PreferenceStore interface {
+	// GetPreferences returns the saved preferences, or nil when none exist.
+	GetPreferences(ctx context.Context, userID types.ID) (*Preferences, error)
+	UpsertPreferences(ctx context.Context, p Preferences) error
+}
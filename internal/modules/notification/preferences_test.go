@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/types"
+)
+
+type mockPrefStore struct {
+	prefs map[types.ID]Preferences
+}
+
+func (m *mockPrefStore) GetPreferences(_ context.Context, userID types.ID) (*Preferences, error) {
+	p, ok := m.prefs[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func (m *mockPrefStore) UpsertPreferences(_ context.Context, p Preferences) error {
+	m.prefs[p.UserID] = p
+	return nil
+}
+
+func TestPreferences_AllowsCategories(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := DefaultPreferences("u1")
+
+	if !p.Allows(CategoryOrderUpdate, ChannelPush, now) {
+		t.Error("order updates should be allowed by default")
+	}
+	if p.Allows(CategoryPromo, ChannelPush, now) {
+		t.Error("promos should be opt-in")
+	}
+	if p.Allows(CategoryOrderUpdate, ChannelSMS, now) {
+		t.Error("SMS should be opt-in")
+	}
+
+	p.PushEnabled = false
+	if p.Allows(CategoryOrderUpdate, ChannelPush, now) {
+		t.Error("push disabled should block push")
+	}
+}
+
+func TestPreferences_QuietHours(t *testing.T) {
+	p := DefaultPreferences("u1")
+	p.Timezone = "Asia/Taipei"
+	p.QuietHours = &QuietHours{StartMin: 22 * 60, EndMin: 7 * 60} // wraps midnight
+
+	taipei, _ := time.LoadLocation("Asia/Taipei")
+	cases := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2026, 1, 1, 23, 30, 0, 0, taipei), false},
+		{time.Date(2026, 1, 1, 6, 59, 0, 0, taipei), false},
+		{time.Date(2026, 1, 1, 7, 0, 0, 0, taipei), true},
+		{time.Date(2026, 1, 1, 21, 59, 0, 0, taipei), true},
+		// 15:00 UTC is 23:00 in Taipei.
+		{time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC), false},
+	}
+	for _, c := range cases {
+		if got := p.Allows(CategoryReminder, ChannelPush, c.at); got != c.want {
+			t.Errorf("reminder at %s: allowed=%v, want %v", c.at, got, c.want)
+		}
+		if !p.Allows(CategoryOrderUpdate, ChannelPush, c.at) {
+			t.Errorf("order update at %s should ignore quiet hours", c.at)
+		}
+	}
+}
+
+func TestPreferences_Validate(t *testing.T) {
+	p := DefaultPreferences("u1")
+	p.QuietHours = &QuietHours{StartMin: 60, EndMin: 60}
+	if err := p.Validate(); !errors.Is(err, ErrInvalidPreferences) {
+		t.Errorf("equal start/end: err = %v", err)
+	}
+	p.QuietHours = &QuietHours{StartMin: 0, EndMin: 24 * 60}
+	if err := p.Validate(); !errors.Is(err, ErrInvalidPreferences) {
+		t.Errorf("end out of range: err = %v", err)
+	}
+	p.QuietHours = nil
+	p.Timezone = "Mars/Olympus"
+	if err := p.Validate(); !errors.Is(err, ErrInvalidPreferences) {
+		t.Errorf("bad timezone: err = %v", err)
+	}
+}
+
+func TestService_Preferences(t *testing.T) {
+	svc, _ := NewService(newMockStore(), nil)
+	prefs := &mockPrefStore{prefs: map[types.ID]Preferences{}}
+	svc.SetPreferenceStore(prefs)
+	ctx := context.Background()
+
+	p, err := svc.GetPreferences(ctx, "u1")
+	if err != nil || p != DefaultPreferences("u1") {
+		t.Fatalf("GetPreferences = %+v, %v; want defaults", p, err)
+	}
+
+	p.OrderUpdates = false
+	if _, err := svc.UpdatePreferences(ctx, p); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+	ok, err := svc.Allows(ctx, "u1", CategoryOrderUpdate, ChannelPush)
+	if err != nil || ok {
+		t.Fatalf("Allows after opt-out = %v, %v; want false", ok, err)
+	}
+
+	p.QuietHours = &QuietHours{StartMin: 10, EndMin: 10}
+	if _, err := svc.UpdatePreferences(ctx, p); !errors.Is(err, ErrInvalidPreferences) {
+		t.Fatalf("invalid update err = %v", err)
+	}
+}
+
+type fakeLanguages map[types.ID]string
+
+func (f fakeLanguages) Language(_ context.Context, userID types.ID) (string, error) {
+	return f[userID], nil
+}
+
+func TestService_PreferencesFollowLanguage(t *testing.T) {
+	svc, _ := NewService(newMockStore(), nil)
+	prefs := &mockPrefStore{prefs: map[types.ID]Preferences{}}
+	svc.SetPreferenceStore(prefs)
+	svc.SetLanguages(fakeLanguages{"en-user": "en", "ja-user": "ja", "zh-user": "zh-TW"})
+	ctx := context.Background()
+
+	for userID, want := range map[types.ID]string{"en-user": LocaleEn, "ja-user": LocaleEn, "zh-user": LocaleZhTW, "new-user": LocaleZhTW} {
+		if p, err := svc.GetPreferences(ctx, userID); err != nil || p.Locale != want {
+			t.Errorf("%s: locale = %q, %v; want %q", userID, p.Locale, err, want)
+		}
+	}
+
+	// A locale the user chose wins over the detected language.
+	p := DefaultPreferences("en-user")
+	if _, err := svc.UpdatePreferences(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := svc.GetPreferences(ctx, "en-user"); p.Locale != LocaleZhTW {
+		t.Errorf("saved locale = %q, want %q", p.Locale, LocaleZhTW)
+	}
+}
@@ -3,16 +3,19 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
-	"google.golang.org/api/option"
 
+	"ark/internal/sandbox"
 	"ark/internal/types"
 )
 
@@ -21,6 +24,14 @@ import (
 type NotificationMessage struct {
 	Title string
 	Body  string
+	// Category drives preference filtering; empty is treated as an order update.
+	Category Category
+	// Critical messages (driver arrived, scheduled reminder) fall back to SMS
+	// when no push could be delivered.
+	Critical bool
+	// Transient messages go stale within moments (new-order offers); a failed
+	// delivery is neither retried nor dead-lettered.
+	Transient bool
 	// Data contains key-value pairs to include in the notification payload.
 	// Only string values are supported; non-string values will be silently ignored.
 	Data map[string]interface{}
@@ -41,21 +52,25 @@ type NotificationService interface {
 // Service is the concrete implementation of NotificationService.
 type Service struct {
 	store     NotificationStore
+	prefs     PreferenceStore
+	languages Languages
+	sms       *smsFallback
 	messaging *messaging.Client
+	now       func() time.Time
+
+	deadLetters DeadLetterStore
+	attempts    int
+	retryDelay  time.Duration
 }
 
 // NewService creates a Service backed by store.
-// credentialsJSON is optional; if empty, FCM sending is skipped (tokens are still persisted).
-func NewService(store NotificationStore, credentialsJSON []byte) (*Service, error) {
-	svc := &Service{store: store}
-	if len(credentialsJSON) == 0 {
+// app is the shared Firebase app and is optional; if nil, FCM sending is skipped (tokens are still persisted).
+func NewService(store NotificationStore, app *firebase.App) (*Service, error) {
+	svc := &Service{store: store, now: time.Now}
+	if app == nil {
 		return svc, nil
 	}
 
-	app, err := firebase.NewApp(context.Background(), nil, option.WithCredentialsJSON(credentialsJSON))
-	if err != nil {
-		return nil, err
-	}
 	msgClient, err := app.Messaging(context.Background())
 	if err != nil {
 		return nil, err
@@ -71,13 +86,60 @@ func (s *Service) EnsureDevice(ctx context.Context, userID types.ID, token, plat
 
 // NotifyUser retrieves all FCM tokens for the user and sends the notification
 // to each token concurrently. It waits for all goroutines to complete before returning.
+// Messages the user's preferences do not allow are dropped without error.
+// Critical messages that reach no device fall back to SMS when configured.
+// With a dead-letter store set, failed deliveries are retried and finally
+// dead-lettered; see SetDeadLetterStore.
 func (s *Service) NotifyUser(ctx context.Context, userID types.ID, message *NotificationMessage) error {
-	tokens, err := s.store.GetTokensByUserID(ctx, userID)
+	if sandbox.Enabled(ctx) {
+		// Test-mode traffic never reaches FCM or SMS providers.
+		log.Printf("notification: sandbox delivery to %s: %q", userID, message.Title)
+		return nil
+	}
+	if s.deadLetters == nil || message.Transient {
+		err := s.deliver(ctx, userID, message)
+		if err != nil {
+			deliveries.Add("failed", 1)
+		}
+		return err
+	}
+	return s.deliverOrDeadLetter(ctx, userID, message)
+}
+
+// deliver makes one delivery attempt: push first, then SMS for critical
+// messages no device received.
+func (s *Service) deliver(ctx context.Context, userID types.ID, message *NotificationMessage) error {
+	prefs, err := s.GetPreferences(ctx, userID)
 	if err != nil {
 		return err
 	}
+	now := s.now()
+
+	var pushErr error
+	if prefs.Allows(message.Category, ChannelPush, now) {
+		delivered, err := s.push(ctx, userID, message, prefs.SpokenText)
+		if delivered > 0 {
+			return nil
+		}
+		pushErr = err
+	}
+
+	if message.Critical && s.sms != nil && prefs.Allows(message.Category, ChannelSMS, now) {
+		return s.sendSMS(ctx, userID, message)
+	}
+	return pushErr
+}
+
+// push sends message to every FCM token of the user and returns how many sends
+// succeeded. It fails when the user has devices but none of them took the message.
+// spoken adds the message's spoken text to the data payload.
+func (s *Service) push(ctx context.Context, userID types.ID, message *NotificationMessage, spoken bool) (int, error) {
+	tokens, err := s.store.GetTokensByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
 	if len(tokens) == 0 || s.messaging == nil {
-		return nil
+		return 0, nil
 	}
 
 	data := make(map[string]string, len(message.Data))
@@ -86,8 +148,16 @@ func (s *Service) NotifyUser(ctx context.Context, userID types.ID, message *Noti
 			data[k] = sv
 		}
 	}
+	if spoken {
+		data[spokenTextKey] = spokenText(message)
+	}
 
-	var wg sync.WaitGroup
+	var (
+		wg        sync.WaitGroup
+		delivered atomic.Int32
+		mu        sync.Mutex
+		sendErrs  []error
+	)
 	for _, token := range tokens {
 		token := token
 		wg.Add(1)
@@ -107,11 +177,114 @@ func (s *Service) NotifyUser(ctx context.Context, userID types.ID, message *Noti
 				// [TODO] Handle stale/unregistered tokens and other send failures.
 				// See issue discussion: token cleanup on uninstall/account deletion.
 				log.Printf("notification: failed to send to token %s: %v", token, sendErr)
+				deliveries.Add("push_failed", 1)
+				mu.Lock()
+				sendErrs = append(sendErrs, sendErr)
+				mu.Unlock()
+				return
 			}
+			delivered.Add(1)
 		}()
 	}
 	wg.Wait()
-	return nil
+	if n := int(delivered.Load()); n > 0 {
+		return n, nil
+	}
+	return 0, fmt.Errorf("push to %d devices failed: %w", len(tokens), errors.Join(sendErrs...))
+}
+
+// DeviceTokens returns the FCM tokens registered for the user.
+func (s *Service) DeviceTokens(ctx context.Context, userID types.ID) ([]string, error) {
+	return s.store.GetTokensByUserID(ctx, userID)
+}
+
+// PushEnabled reports whether FCM sending is configured.
+func (s *Service) PushEnabled() bool {
+	return s.messaging != nil
+}
+
+// ---------------------------------------------------------------------------
+// Preferences
+// ---------------------------------------------------------------------------
+
+// SetPreferenceStore enables per-user preference enforcement. Without it every
+// user is treated as having DefaultPreferences.
+func (s *Service) SetPreferenceStore(p PreferenceStore) {
+	s.prefs = p
+}
+
+// Languages tells a user's preferred language, as detected by the ride
+// assistant or set on the profile. Implemented by user.Service.
+type Languages interface {
+	Language(ctx context.Context, userID types.ID) (string, error)
+}
+
+// SetLanguages makes users who never saved preferences get messages in their
+// preferred language rather than the default locale.
+func (s *Service) SetLanguages(l Languages) {
+	s.languages = l
+}
+
+// GetPreferences returns the user's preferences, falling back to the defaults.
+func (s *Service) GetPreferences(ctx context.Context, userID types.ID) (Preferences, error) {
+	if s.prefs == nil {
+		return s.defaultPreferences(ctx, userID), nil
+	}
+	p, err := s.prefs.GetPreferences(ctx, userID)
+	if err != nil {
+		return Preferences{}, err
+	}
+	if p == nil {
+		return s.defaultPreferences(ctx, userID), nil
+	}
+	return *p, nil
+}
+
+// defaultPreferences returns DefaultPreferences in the user's preferred
+// language. A failed lookup keeps the default locale and is only logged.
+func (s *Service) defaultPreferences(ctx context.Context, userID types.ID) Preferences {
+	p := DefaultPreferences(userID)
+	if s.languages == nil {
+		return p
+	}
+	lang, err := s.languages.Language(ctx, userID)
+	if err != nil {
+		log.Printf("notification: language of %s: %v", userID, err)
+		return p
+	}
+	p.Locale = localeForLanguage(lang)
+	return p
+}
+
+// UpdatePreferences validates and saves the user's preferences.
+func (s *Service) UpdatePreferences(ctx context.Context, p Preferences) (Preferences, error) {
+	if err := p.Validate(); err != nil {
+		return Preferences{}, err
+	}
+	if p.Timezone == "" {
+		p.Timezone = defaultTimezone
+	}
+	if p.Locale == "" {
+		p.Locale = LocaleZhTW
+	}
+	if s.prefs == nil {
+		return Preferences{}, fmt.Errorf("notification preferences: no store configured")
+	}
+	if err := s.prefs.UpsertPreferences(ctx, p); err != nil {
+		return Preferences{}, err
+	}
+	p.UpdatedAt = s.now()
+	return p, nil
+}
+
+// Allows reports whether a message of category may be delivered to userID on ch
+// right now. Every dispatch path goes through this check.
+func (s *Service) Allows(ctx context.Context, userID types.ID, c Category, ch Channel) (bool, error) {
+	p, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return p.Allows(c, ch, s.now()), nil
 }
 
 // DeleteOutdatedDevices delegates to the store to remove stale device records.
@@ -132,6 +305,10 @@ type OrderInfo struct {
 	DropoffLat   float64
 	DropoffLng   float64
 	EstimatedFee float64
+	Requirements []string
+	// StatusVersion is echoed back on accept so an offer for a pickup that
+	// has since moved is refused.
+	StatusVersion int
 }
 
 // NotifyDriverNewOrder sends an FCM data message directly to a driver's device
@@ -147,13 +324,15 @@ func (s *Service) NotifyDriverNewOrder(ctx context.Context, deviceToken string,
 	msg := &messaging.Message{
 		Token: deviceToken,
 		Data: map[string]string{
-			"type":          "new_order",
-			"order_id":      string(info.OrderID),
-			"pickup_lat":    strconv.FormatFloat(info.PickupLat, 'f', 6, 64),
-			"pickup_lng":    strconv.FormatFloat(info.PickupLng, 'f', 6, 64),
-			"dropoff_lat":   strconv.FormatFloat(info.DropoffLat, 'f', 6, 64),
-			"dropoff_lng":   strconv.FormatFloat(info.DropoffLng, 'f', 6, 64),
-			"estimated_fee": strconv.FormatFloat(info.EstimatedFee, 'f', 2, 64),
+			"type":           "new_order",
+			"order_id":       string(info.OrderID),
+			"pickup_lat":     strconv.FormatFloat(info.PickupLat, 'f', 6, 64),
+			"pickup_lng":     strconv.FormatFloat(info.PickupLng, 'f', 6, 64),
+			"dropoff_lat":    strconv.FormatFloat(info.DropoffLat, 'f', 6, 64),
+			"dropoff_lng":    strconv.FormatFloat(info.DropoffLng, 'f', 6, 64),
+			"estimated_fee":  strconv.FormatFloat(info.EstimatedFee, 'f', 2, 64),
+			"requirements":   strings.Join(info.Requirements, ","),
+			"status_version": strconv.Itoa(info.StatusVersion),
 		},
 		Notification: &messaging.Notification{
 			Title: "New ride request",
@@ -164,6 +343,7 @@ func (s *Service) NotifyDriverNewOrder(ctx context.Context, deviceToken string,
 
 	messageID, err := s.messaging.Send(ctx, msg)
 	if err != nil {
+		deliveries.Add("offer_failed", 1)
 		return fmt.Errorf("sending FCM to token %s: %w", deviceToken, err)
 	}
 
@@ -0,0 +1,257 @@
+// README: SMS fallback channel — pluggable providers (Twilio, Every8d) with per-user monthly spend caps.
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark/internal/config"
+	"ark/internal/types"
+)
+
+var (
+	// ErrNoPhoneNumber is returned when an SMS fallback is needed but the user has no phone on file.
+	ErrNoPhoneNumber = errors.New("notification: user has no phone number")
+	// ErrSMSCapReached is returned when sending would exceed the user's monthly SMS budget.
+	ErrSMSCapReached = errors.New("notification: monthly SMS cap reached")
+)
+
+// SMSResult describes one accepted SMS.
+type SMSResult struct {
+	ProviderMessageID string
+	Segments          int
+}
+
+// SMSProvider sends a single text message.
+type SMSProvider interface {
+	Name() string
+	Send(ctx context.Context, to, body string) (SMSResult, error)
+}
+
+// SMSRecord is one row of the SMS cost ledger.
+type SMSRecord struct {
+	UserID            types.ID
+	Provider          string
+	ProviderMessageID string
+	Event             string
+	Segments          int
+	Cost              int64
+	Err               string
+	CreatedAt         time.Time
+}
+
+// SMSStore resolves phone numbers and keeps the per-message cost ledger.
+type SMSStore interface {
+	GetPhoneNumber(ctx context.Context, userID types.ID) (string, error)
+	// SMSCostSince returns the total SMS cost charged to the user since since.
+	SMSCostSince(ctx context.Context, userID types.ID, since time.Time) (int64, error)
+	RecordSMS(ctx context.Context, r SMSRecord) error
+}
+
+// NewSMSProvider builds the provider selected by cfg.Provider, or nil when SMS is disabled.
+func NewSMSProvider(cfg config.SMSConfig) (SMSProvider, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "twilio":
+		return &TwilioProvider{AccountSID: cfg.TwilioAccountSID, AuthToken: cfg.TwilioAuthToken, From: cfg.From, Client: client}, nil
+	case "every8d":
+		return &Every8dProvider{UID: cfg.Every8dUID, Password: cfg.Every8dPassword, Client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", cfg.Provider)
+	}
+}
+
+// smsFallback holds the SMS channel configuration of a Service.
+type smsFallback struct {
+	provider   SMSProvider
+	store      SMSStore
+	costPerSeg int64
+	monthlyCap int64
+}
+
+// SetSMSFallback enables SMS delivery of critical messages for users without a
+// working push token. costPerSegment is charged per message segment and
+// monthlyCap limits each user's spend per calendar month (0 = no cap).
+func (s *Service) SetSMSFallback(p SMSProvider, store SMSStore, costPerSegment, monthlyCap int64) {
+	s.sms = &smsFallback{provider: p, store: store, costPerSeg: costPerSegment, monthlyCap: monthlyCap}
+}
+
+// sendSMS delivers message as a text to the user's phone, enforcing the monthly
+// cap and recording the cost of every attempt.
+func (s *Service) sendSMS(ctx context.Context, userID types.ID, message *NotificationMessage) error {
+	phone, err := s.sms.store.GetPhoneNumber(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if phone == "" {
+		return ErrNoPhoneNumber
+	}
+	return s.sendSMSTo(ctx, userID, phone, message)
+}
+
+// sendSMSTo texts message to phone, charging it to the user's monthly cap: the
+// user's own phone, or that of someone the user sends to, such as the
+// recipient of their parcel.
+func (s *Service) sendSMSTo(ctx context.Context, userID types.ID, phone string, message *NotificationMessage) error {
+	sms := s.sms
+
+	if sms.monthlyCap > 0 {
+		spent, err := sms.store.SMSCostSince(ctx, userID, monthStart(s.now()))
+		if err != nil {
+			return err
+		}
+		if spent+sms.costPerSeg > sms.monthlyCap {
+			return ErrSMSCapReached
+		}
+	}
+
+	rec := SMSRecord{UserID: userID, Provider: sms.provider.Name(), Event: smsEvent(message)}
+	res, sendErr := sms.provider.Send(ctx, phone, smsBody(message))
+	if sendErr != nil {
+		rec.Err = sendErr.Error()
+	} else {
+		rec.ProviderMessageID = res.ProviderMessageID
+		rec.Segments = max(res.Segments, 1)
+		rec.Cost = int64(rec.Segments) * sms.costPerSeg
+	}
+	if err := sms.store.RecordSMS(ctx, rec); err != nil {
+		return errors.Join(sendErr, fmt.Errorf("record sms: %w", err))
+	}
+	return sendErr
+}
+
+func smsEvent(m *NotificationMessage) string {
+	if t, ok := m.Data["type"].(string); ok && t != "" {
+		return t
+	}
+	return string(m.Category)
+}
+
+func smsBody(m *NotificationMessage) string {
+	if m.Title == "" {
+		return m.Body
+	}
+	return m.Title + ": " + m.Body
+}
+
+// monthStart returns midnight on the first day of t's month in Asia/Taipei.
+func monthStart(t time.Time) time.Time {
+	if loc, err := time.LoadLocation(defaultTimezone); err == nil {
+		t = t.In(loc)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// ---------------------------------------------------------------------------
+// Twilio
+// ---------------------------------------------------------------------------
+
+// TwilioProvider sends through the Twilio Programmable Messaging REST API.
+type TwilioProvider struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	Client     *http.Client
+	BaseURL    string // overridable for tests; defaults to https://api.twilio.com
+}
+
+func (p *TwilioProvider) Name() string { return "twilio" }
+
+func (p *TwilioProvider) Send(ctx context.Context, to, body string) (SMSResult, error) {
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.twilio.com"
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", base, url.PathEscape(p.AccountSID))
+	form := url.Values{"To": {to}, "From": {p.From}, "Body": {body}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return SMSResult{}, err
+	}
+	req.SetBasicAuth(p.AccountSID, p.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return SMSResult{}, fmt.Errorf("twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		SID         string `json:"sid"`
+		NumSegments string `json:"num_segments"`
+		Message     string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return SMSResult{}, fmt.Errorf("twilio: decode response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 300 {
+		return SMSResult{}, fmt.Errorf("twilio: status %d: %s", resp.StatusCode, out.Message)
+	}
+	segments, _ := strconv.Atoi(out.NumSegments)
+	return SMSResult{ProviderMessageID: out.SID, Segments: segments}, nil
+}
+
+// ---------------------------------------------------------------------------
+// Every8d
+// ---------------------------------------------------------------------------
+
+// Every8dProvider sends through the Every8d (e8d.tw) HTTP API used for Taiwan numbers.
+type Every8dProvider struct {
+	UID      string
+	Password string
+	Client   *http.Client
+	BaseURL  string // overridable for tests; defaults to https://api.e8d.tw
+}
+
+func (p *Every8dProvider) Name() string { return "every8d" }
+
+// Send posts one message. Every8d replies with "CREDIT,SENDED,COST,UNSEND,BATCH_ID";
+// a negative CREDIT carries an error code in place of the batch ID.
+func (p *Every8dProvider) Send(ctx context.Context, to, body string) (SMSResult, error) {
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.e8d.tw"
+	}
+	form := url.Values{"UID": {p.UID}, "PWD": {p.Password}, "MSG": {body}, "DEST": {to}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/API21/HTTP/sendSMS.ashx", strings.NewReader(form.Encode()))
+	if err != nil {
+		return SMSResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return SMSResult{}, fmt.Errorf("every8d: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return SMSResult{}, fmt.Errorf("every8d: read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return SMSResult{}, fmt.Errorf("every8d: status %d", resp.StatusCode)
+	}
+	fields := strings.Split(strings.TrimSpace(string(raw)), ",")
+	if len(fields) < 5 {
+		return SMSResult{}, fmt.Errorf("every8d: unexpected response %q", raw)
+	}
+	if credit, err := strconv.ParseFloat(fields[0], 64); err != nil || credit < 0 {
+		return SMSResult{}, fmt.Errorf("every8d: send rejected: %s", strings.TrimSpace(string(raw)))
+	}
+	cost, _ := strconv.ParseFloat(fields[2], 64)
+	return SMSResult{ProviderMessageID: fields[4], Segments: int(cost + 0.5)}, nil
+}
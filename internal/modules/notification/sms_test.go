@@ -0,0 +1,205 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ark/internal/sandbox"
+	"ark/internal/types"
+)
+
+type fakeSMSProvider struct {
+	sent []string
+	err  error
+}
+
+func (f *fakeSMSProvider) Name() string { return "fake" }
+
+func (f *fakeSMSProvider) Send(_ context.Context, to, body string) (SMSResult, error) {
+	if f.err != nil {
+		return SMSResult{}, f.err
+	}
+	f.sent = append(f.sent, to+"|"+body)
+	return SMSResult{ProviderMessageID: fmt.Sprintf("m%d", len(f.sent)), Segments: 1}, nil
+}
+
+type fakeSMSStore struct {
+	phones  map[types.ID]string
+	records []SMSRecord
+}
+
+func (f *fakeSMSStore) GetPhoneNumber(_ context.Context, userID types.ID) (string, error) {
+	return f.phones[userID], nil
+}
+
+func (f *fakeSMSStore) SMSCostSince(_ context.Context, userID types.ID, _ time.Time) (int64, error) {
+	var total int64
+	for _, r := range f.records {
+		if r.UserID == userID {
+			total += r.Cost
+		}
+	}
+	return total, nil
+}
+
+func (f *fakeSMSStore) RecordSMS(_ context.Context, r SMSRecord) error {
+	f.records = append(f.records, r)
+	return nil
+}
+
+// newSMSTestService returns a service with no push (no tokens, no FCM) and a
+// user u1 who opted into SMS.
+func newSMSTestService(t *testing.T, cap int64) (*Service, *fakeSMSProvider, *fakeSMSStore) {
+	t.Helper()
+	svc, _ := NewService(newMockStore(), nil)
+	prefs := &mockPrefStore{prefs: map[types.ID]Preferences{}}
+	p := DefaultPreferences("u1")
+	p.SMSEnabled = true
+	prefs.prefs["u1"] = p
+	svc.SetPreferenceStore(prefs)
+
+	provider := &fakeSMSProvider{}
+	store := &fakeSMSStore{phones: map[types.ID]string{"u1": "+886912345678"}}
+	svc.SetSMSFallback(provider, store, 150, cap)
+	return svc, provider, store
+}
+
+func TestNotifyUser_SMSFallbackForCritical(t *testing.T) {
+	svc, provider, store := newSMSTestService(t, 0)
+	ctx := context.Background()
+
+	msg := &NotificationMessage{Title: "Arrived", Body: "Driver is here", Critical: true, Data: map[string]interface{}{"type": "driver_arrived"}}
+	if err := svc.NotifyUser(ctx, "u1", msg); err != nil {
+		t.Fatalf("NotifyUser: %v", err)
+	}
+	if len(provider.sent) != 1 || provider.sent[0] != "+886912345678|Arrived: Driver is here" {
+		t.Fatalf("sent = %v", provider.sent)
+	}
+	if len(store.records) != 1 || store.records[0].Cost != 150 || store.records[0].Event != "driver_arrived" {
+		t.Fatalf("records = %+v", store.records)
+	}
+
+	// Non-critical messages never fall back.
+	if err := svc.NotifyUser(ctx, "u1", &NotificationMessage{Title: "Hi"}); err != nil {
+		t.Fatalf("NotifyUser: %v", err)
+	}
+	if len(provider.sent) != 1 {
+		t.Fatalf("non-critical message sent via SMS: %v", provider.sent)
+	}
+}
+
+func TestNotifyUser_SMSRespectsPreferences(t *testing.T) {
+	svc, provider, _ := newSMSTestService(t, 0)
+	ctx := context.Background()
+
+	// u2 has default preferences, which leave SMS off.
+	if err := svc.NotifyUser(ctx, "u2", &NotificationMessage{Title: "Arrived", Critical: true}); err != nil {
+		t.Fatalf("NotifyUser: %v", err)
+	}
+	if len(provider.sent) != 0 {
+		t.Fatalf("SMS sent without opt-in: %v", provider.sent)
+	}
+}
+
+func TestNotifyUser_SandboxNeverSends(t *testing.T) {
+	svc, provider, store := newSMSTestService(t, 0)
+	ctx := sandbox.WithContext(context.Background())
+
+	if err := svc.NotifyUser(ctx, "u1", &NotificationMessage{Title: "Arrived", Critical: true}); err != nil {
+		t.Fatalf("NotifyUser: %v", err)
+	}
+	if len(provider.sent) != 0 || len(store.records) != 0 {
+		t.Fatalf("sandbox message reached SMS: sent=%v records=%v", provider.sent, store.records)
+	}
+}
+
+func TestNotifyUser_SMSMonthlyCap(t *testing.T) {
+	svc, provider, store := newSMSTestService(t, 300)
+	ctx := context.Background()
+	msg := &NotificationMessage{Title: "Arrived", Critical: true}
+
+	for i := 0; i < 2; i++ {
+		if err := svc.NotifyUser(ctx, "u1", msg); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if err := svc.NotifyUser(ctx, "u1", msg); !errors.Is(err, ErrSMSCapReached) {
+		t.Fatalf("third send err = %v, want ErrSMSCapReached", err)
+	}
+	if len(provider.sent) != 2 || len(store.records) != 2 {
+		t.Fatalf("sent=%d records=%d, want 2/2", len(provider.sent), len(store.records))
+	}
+}
+
+func TestNotifyUser_SMSFailureRecordedWithoutCost(t *testing.T) {
+	svc, provider, store := newSMSTestService(t, 0)
+	provider.err = errors.New("provider down")
+
+	err := svc.NotifyUser(context.Background(), "u1", &NotificationMessage{Title: "Arrived", Critical: true})
+	if err == nil {
+		t.Fatal("expected provider error")
+	}
+	if len(store.records) != 1 || store.records[0].Cost != 0 || store.records[0].Err == "" {
+		t.Fatalf("records = %+v", store.records)
+	}
+}
+
+func TestTwilioProvider_Send(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if r.URL.Path != "/2010-04-01/Accounts/AC1/Messages.json" || user != "AC1" || pass != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"auth"}`)
+			return
+		}
+		if r.FormValue("To") != "+886900000000" || r.FormValue("From") != "+100" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":"bad form"}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"sid":"SM1","num_segments":"2"}`)
+	}))
+	defer srv.Close()
+
+	p := &TwilioProvider{AccountSID: "AC1", AuthToken: "tok", From: "+100", Client: srv.Client(), BaseURL: srv.URL}
+	res, err := p.Send(context.Background(), "+886900000000", "hello")
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if res.ProviderMessageID != "SM1" || res.Segments != 2 {
+		t.Fatalf("res = %+v", res)
+	}
+
+	p.AuthToken = "wrong"
+	if _, err := p.Send(context.Background(), "+886900000000", "hello"); err == nil {
+		t.Fatal("expected error for rejected credentials")
+	}
+}
+
+func TestEvery8dProvider_Send(t *testing.T) {
+	reply := "97.0,1,1.0,0,batch-1"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, reply)
+	}))
+	defer srv.Close()
+
+	p := &Every8dProvider{UID: "u", Password: "p", Client: srv.Client(), BaseURL: srv.URL}
+	res, err := p.Send(context.Background(), "0912345678", "hello")
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if res.ProviderMessageID != "batch-1" || res.Segments != 1 {
+		t.Fatalf("res = %+v", res)
+	}
+
+	reply = "-27,0,0,0,Invalid password"
+	if _, err := p.Send(context.Background(), "0912345678", "hello"); err == nil {
+		t.Fatal("expected error for negative credit")
+	}
+}
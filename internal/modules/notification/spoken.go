@@ -0,0 +1,73 @@
+// README: Spoken text — the plain version of a push that screen readers and TTS engines read aloud, for users who opted in.
+package notification
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// spokenTextKey is the push data key carrying the spoken text.
+const spokenTextKey = "spoken_text"
+
+var (
+	// markdownLink matches [text](url); only the text is spoken.
+	markdownLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	// markdownBlock matches heading, quote and list markers at line starts.
+	markdownBlock = regexp.MustCompile(`(?m)^\s*(#{1,6}|>|[-*+])\s+`)
+	// markdownInline matches emphasis, strike-through and code markers.
+	markdownInline = strings.NewReplacer("**", "", "__", "", "~~", "", "`", "", "*", "")
+)
+
+// spokenText joins m's title and body into one plain-text announcement
+// without emoji or markdown, each part ending as a sentence so the title is
+// not run into the body when read aloud.
+func spokenText(m *NotificationMessage) string {
+	var b strings.Builder
+	for _, s := range []string{m.Title, m.Body} {
+		s = plainText(s)
+		if s == "" {
+			continue
+		}
+		// Chinese and Japanese sentences follow each other without a space.
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "。") {
+			b.WriteByte(' ')
+		}
+		b.WriteString(endSentence(s))
+	}
+	return b.String()
+}
+
+// plainText strips markdown and emoji from s and collapses its whitespace.
+func plainText(s string) string {
+	s = markdownLink.ReplaceAllString(s, "$1")
+	s = markdownBlock.ReplaceAllString(s, "")
+	s = markdownInline.Replace(s)
+	s = strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// isEmoji reports whether r is an emoji or part of an emoji sequence: a
+// pictograph, skin tone modifier, variation selector or zero-width joiner.
+func isEmoji(r rune) bool {
+	return unicode.Is(unicode.So, r) || (r >= 0x1F3FB && r <= 0x1F3FF) ||
+		unicode.Is(unicode.Variation_Selector, r) || r == '\u200d'
+}
+
+// endSentence ends s with a full stop unless it already ends in punctuation,
+// using the ideographic one after Chinese or Japanese text.
+func endSentence(s string) string {
+	last := []rune(s)[len([]rune(s))-1]
+	switch {
+	case unicode.IsPunct(last):
+		return s
+	case unicode.In(last, unicode.Han, unicode.Hiragana, unicode.Katakana):
+		return s + "。"
+	}
+	return s + "."
+}
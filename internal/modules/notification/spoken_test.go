@@ -0,0 +1,23 @@
+package notification
+
+import "testing"
+
+func TestSpokenText(t *testing.T) {
+	cases := []struct {
+		title, body, want string
+	}{
+		{"Your driver has arrived", "Your driver is waiting at the pickup point.",
+			"Your driver has arrived. Your driver is waiting at the pickup point."},
+		{"🎉 **Spring promo** 🎉", "Get 20% off with code `SPRING`!\n- Valid until 4/30\n- See [terms](https://example.com/terms)",
+			"Spring promo. Get 20% off with code SPRING! Valid until 4/30 See terms."},
+		{"司機已抵達 🚕", "請到上車地點與司機會合", "司機已抵達。請到上車地點與司機會合。"},
+		{"👍🏽 Thanks!", "", "Thanks!"},
+		{"", "Pickup time updated", "Pickup time updated."},
+		{"❤️‍🔥", "", ""},
+	}
+	for _, c := range cases {
+		if got := spokenText(&NotificationMessage{Title: c.title, Body: c.body}); got != c.want {
+			t.Errorf("spokenText(%q, %q) = %q, want %q", c.title, c.body, got, c.want)
+		}
+	}
+}
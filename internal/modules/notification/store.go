@@ -3,10 +3,14 @@ package notification
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"ark/internal/pii"
 	"ark/internal/types"
 )
 
@@ -27,7 +31,8 @@ type NotificationStore interface {
 
 // Store is the PostgreSQL implementation of NotificationStore.
 type Store struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	pii *pii.Keyring
 }
 
 // NewStore returns a Store backed by the given connection pool.
@@ -35,22 +40,33 @@ func NewStore(db *pgxpool.Pool) *Store {
 	return &Store{db: db}
 }
 
-// UpsertDevice inserts or updates a device token row.
+// SetKeyring enables encryption of device tokens (and decryption of phone
+// numbers); nil keeps plaintext.
+func (s *Store) SetKeyring(k *pii.Keyring) {
+	s.pii = k
+}
+
+// UpsertDevice inserts or updates a device token row. Rows are matched on the
+// token's blind index because the stored token is encrypted.
 func (s *Store) UpsertDevice(ctx context.Context, userID types.ID, token, platform, deviceID string) error {
 	var devID *string
 	if deviceID != "" {
 		devID = &deviceID
 	}
-	_, err := s.db.Exec(ctx, `
-		INSERT INTO user_fcm_tokens (user_id, fcm_token, platform, device_id, last_seen_at, updated_at)
-		VALUES ($1, $2, $3, $4, NOW(), NOW())
-		ON CONFLICT (user_id, fcm_token)
+	sealed, err := s.pii.Encrypt(token)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO user_fcm_tokens (user_id, fcm_token, token_hash, platform, device_id, last_seen_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (user_id, token_hash)
 		DO UPDATE SET
 			platform     = EXCLUDED.platform,
 			device_id    = EXCLUDED.device_id,
 			last_seen_at = NOW(),
 			updated_at   = NOW()
-	`, string(userID), token, platform, devID)
+	`, string(userID), sealed, s.pii.BlindIndex(token), platform, devID)
 	return err
 }
 
@@ -70,6 +86,9 @@ func (s *Store) GetTokensByUserID(ctx context.Context, userID types.ID) ([]strin
 		if err := rows.Scan(&t); err != nil {
 			return nil, err
 		}
+		if t, err = s.pii.Decrypt(t); err != nil {
+			return nil, err
+		}
 		tokens = append(tokens, t)
 	}
 	return tokens, rows.Err()
@@ -80,9 +99,13 @@ func (s *Store) DeleteTokens(ctx context.Context, tokens []string) error {
 	if len(tokens) == 0 {
 		return nil
 	}
+	hashes := make([]string, len(tokens))
+	for i, t := range tokens {
+		hashes[i] = s.pii.BlindIndex(t)
+	}
 	_, err := s.db.Exec(ctx, `
-		DELETE FROM user_fcm_tokens WHERE fcm_token = ANY($1)
-	`, tokens)
+		DELETE FROM user_fcm_tokens WHERE token_hash = ANY($1)
+	`, hashes)
 	return err
 }
 
@@ -93,3 +116,250 @@ func (s *Store) DeleteOutdatedDevices(ctx context.Context, before time.Time) err
 	`, before)
 	return err
 }
+
+// ---------------------------------------------------------------------------
+// Preferences
+// ---------------------------------------------------------------------------
+
+// GetPreferences returns the user's saved preferences, or nil when none exist.
+func (s *Store) GetPreferences(ctx context.Context, userID types.ID) (*Preferences, error) {
+	var (
+		p               Preferences
+		quietStart, end *int16
+	)
+	err := s.db.QueryRow(ctx, `
+		SELECT order_updates, promos, reminders, quiet_start_min, quiet_end_min,
+		       timezone, push_enabled, sms_enabled, locale, monthly_summary, spoken_text, updated_at
+		FROM notification_preferences WHERE user_id = $1
+	`, string(userID)).Scan(&p.OrderUpdates, &p.Promos, &p.Reminders, &quietStart, &end,
+		&p.Timezone, &p.PushEnabled, &p.SMSEnabled, &p.Locale, &p.MonthlySummary, &p.SpokenText, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.UserID = userID
+	if quietStart != nil && end != nil {
+		p.QuietHours = &QuietHours{StartMin: int(*quietStart), EndMin: int(*end)}
+	}
+	return &p, nil
+}
+
+// UpsertPreferences inserts or replaces the user's preferences.
+func (s *Store) UpsertPreferences(ctx context.Context, p Preferences) error {
+	var quietStart, quietEnd *int
+	if p.QuietHours != nil {
+		quietStart, quietEnd = &p.QuietHours.StartMin, &p.QuietHours.EndMin
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO notification_preferences
+			(user_id, order_updates, promos, reminders, quiet_start_min, quiet_end_min,
+			 timezone, push_enabled, sms_enabled, locale, monthly_summary, spoken_text, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			order_updates   = EXCLUDED.order_updates,
+			promos          = EXCLUDED.promos,
+			reminders       = EXCLUDED.reminders,
+			quiet_start_min = EXCLUDED.quiet_start_min,
+			quiet_end_min   = EXCLUDED.quiet_end_min,
+			timezone        = EXCLUDED.timezone,
+			push_enabled    = EXCLUDED.push_enabled,
+			sms_enabled     = EXCLUDED.sms_enabled,
+			locale          = EXCLUDED.locale,
+			monthly_summary = EXCLUDED.monthly_summary,
+			spoken_text     = EXCLUDED.spoken_text,
+			updated_at      = NOW()
+	`, string(p.UserID), p.OrderUpdates, p.Promos, p.Reminders, quietStart, quietEnd,
+		p.Timezone, p.PushEnabled, p.SMSEnabled, p.Locale, p.MonthlySummary, p.SpokenText)
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// SMS
+// ---------------------------------------------------------------------------
+
+// GetPhoneNumber returns the phone number on the user's profile ("" when unset).
+func (s *Store) GetPhoneNumber(ctx context.Context, userID types.ID) (string, error) {
+	var phone string
+	err := s.db.QueryRow(ctx, `SELECT phone FROM users WHERE user_id = $1`, string(userID)).Scan(&phone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.pii.Decrypt(phone)
+}
+
+// SMSCostSince sums the cost of SMS charged to the user since the given time.
+func (s *Store) SMSCostSince(ctx context.Context, userID types.ID, since time.Time) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(cost), 0) FROM sms_messages
+		WHERE user_id = $1 AND created_at >= $2
+	`, string(userID), since).Scan(&total)
+	return total, err
+}
+
+// RecordSMS appends one send attempt to the SMS cost ledger.
+func (s *Store) RecordSMS(ctx context.Context, r SMSRecord) error {
+	var sendErr *string
+	if r.Err != "" {
+		sendErr = &r.Err
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO sms_messages (user_id, provider, provider_message_id, event, segments, cost, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, string(r.UserID), r.Provider, r.ProviderMessageID, r.Event, r.Segments, r.Cost, sendErr)
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// Dead letters
+// ---------------------------------------------------------------------------
+
+// deadLetterPayload is the message part of a dead letter, stored as JSON.
+type deadLetterPayload struct {
+	Title    string            `json:"title"`
+	Body     string            `json:"body"`
+	Category Category          `json:"category"`
+	Critical bool              `json:"critical"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+// SaveDeadLetter inserts a dead letter and fills in its ID and CreatedAt.
+func (s *Store) SaveDeadLetter(ctx context.Context, d *DeadLetter) error {
+	payload, err := json.Marshal(deadLetterPayload{d.Title, d.Body, d.Category, d.Critical, d.Data})
+	if err != nil {
+		return err
+	}
+	return s.db.QueryRow(ctx, `
+		INSERT INTO notification_dead_letters (user_id, payload, error, attempts)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, string(d.UserID), payload, d.Error, d.Attempts).Scan(&d.ID, &d.CreatedAt)
+}
+
+const deadLetterColumns = `id, user_id, payload, error, attempts, created_at, retried_at, resolved_at`
+
+func scanDeadLetter(row pgx.Row) (*DeadLetter, error) {
+	var (
+		d       DeadLetter
+		userID  string
+		payload []byte
+	)
+	if err := row.Scan(&d.ID, &userID, &payload, &d.Error, &d.Attempts, &d.CreatedAt, &d.RetriedAt, &d.ResolvedAt); err != nil {
+		return nil, err
+	}
+	var p deadLetterPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, err
+	}
+	d.UserID = types.ID(userID)
+	d.Title, d.Body, d.Category, d.Critical, d.Data = p.Title, p.Body, p.Category, p.Critical, p.Data
+	return &d, nil
+}
+
+// ListDeadLetters returns up to limit dead letters, newest first.
+func (s *Store) ListDeadLetters(ctx context.Context, includeResolved bool, limit int) ([]DeadLetter, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+deadLetterColumns+` FROM notification_dead_letters
+		WHERE $1 OR resolved_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, includeResolved, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []DeadLetter{}
+	for rows.Next() {
+		d, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+// GetDeadLetter returns the dead letter, or nil when there is none.
+func (s *Store) GetDeadLetter(ctx context.Context, id int64) (*DeadLetter, error) {
+	d, err := scanDeadLetter(s.db.QueryRow(ctx, `
+		SELECT `+deadLetterColumns+` FROM notification_dead_letters WHERE id = $1
+	`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// MarkDeadLetterRetried counts an ops retry; a retry without error resolves
+// the dead letter, a failed one replaces the recorded error.
+func (s *Store) MarkDeadLetterRetried(ctx context.Context, id int64, at time.Time, errMsg string) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE notification_dead_letters
+		SET attempts    = attempts + 1,
+		    retried_at  = $2,
+		    error       = COALESCE(NULLIF($3, ''), error),
+		    resolved_at = CASE WHEN $3 = '' THEN $2 END
+		WHERE id = $1
+	`, id, at, errMsg)
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// PII key rotation
+// ---------------------------------------------------------------------------
+
+// RotateTokens re-encrypts up to limit device tokens that are still plaintext,
+// sealed with a retired key, or missing their blind index. Callers loop until
+// it returns 0.
+func (s *Store) RotateTokens(ctx context.Context, limit int) (int, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, fcm_token, COALESCE(token_hash, '') FROM user_fcm_tokens ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	type pending struct {
+		id    int64
+		token string
+	}
+	var todo []pending
+	for rows.Next() && len(todo) < limit {
+		var (
+			id           int64
+			stored, hash string
+		)
+		if err := rows.Scan(&id, &stored, &hash); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		plain, err := s.pii.Decrypt(stored)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if s.pii.NeedsRotation(stored) || hash != s.pii.BlindIndex(plain) {
+			todo = append(todo, pending{id, plain})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range todo {
+		sealed, err := s.pii.Encrypt(p.token)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := s.db.Exec(ctx, `UPDATE user_fcm_tokens SET fcm_token = $2, token_hash = $3 WHERE id = $1`,
+			p.id, sealed, s.pii.BlindIndex(p.token)); err != nil {
+			return 0, err
+		}
+	}
+	return len(todo), nil
+}
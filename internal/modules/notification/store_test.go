@@ -3,6 +3,7 @@ package notification
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"ark/internal/pii"
 	"ark/internal/types"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -91,6 +93,78 @@ func TestDeleteOutdatedDevices(t *testing.T) {
 	}
 }
 
+func TestUpsertAndGetPreferences(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+	userID := types.ID("usr_prefs")
+
+	got, err := store.GetPreferences(ctx, userID)
+	if err != nil || got != nil {
+		t.Fatalf("GetPreferences before save = %+v, %v; want nil, nil", got, err)
+	}
+
+	p := DefaultPreferences(userID)
+	p.Promos = true
+	p.Locale = LocaleEn
+	p.MonthlySummary = true
+	p.SpokenText = true
+	p.QuietHours = &QuietHours{StartMin: 22 * 60, EndMin: 7 * 60}
+	if err := store.UpsertPreferences(ctx, p); err != nil {
+		t.Fatalf("UpsertPreferences: %v", err)
+	}
+	got, err = store.GetPreferences(ctx, userID)
+	if err != nil || got == nil {
+		t.Fatalf("GetPreferences: %+v, %v", got, err)
+	}
+	if !got.Promos || got.Locale != LocaleEn || !got.MonthlySummary || !got.SpokenText || got.QuietHours == nil || got.QuietHours.StartMin != 22*60 || got.QuietHours.EndMin != 7*60 {
+		t.Fatalf("round trip mismatch: %+v", got)
+	}
+
+	p.QuietHours = nil
+	if err := store.UpsertPreferences(ctx, p); err != nil {
+		t.Fatalf("UpsertPreferences (clear quiet hours): %v", err)
+	}
+	got, _ = store.GetPreferences(ctx, userID)
+	if got.QuietHours != nil {
+		t.Fatalf("quiet hours not cleared: %+v", got.QuietHours)
+	}
+}
+
+func TestUpsertDevice_EncryptsToken(t *testing.T) {
+	store := setupTestStore(t)
+	key := bytes.Repeat([]byte{7}, 32)
+	k, err := pii.NewKeyring(map[string][]byte{"k1": key}, "k1", key)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	store.SetKeyring(k)
+	ctx := context.Background()
+	userID := types.ID("usr_enc")
+
+	for i := 0; i < 2; i++ {
+		if err := store.UpsertDevice(ctx, userID, "token_secret", "android", ""); err != nil {
+			t.Fatalf("UpsertDevice %d: %v", i, err)
+		}
+	}
+	var raw string
+	if err := store.db.QueryRow(ctx, `SELECT fcm_token FROM user_fcm_tokens WHERE user_id = $1`, string(userID)).Scan(&raw); err != nil {
+		t.Fatalf("select raw token (duplicate row?): %v", err)
+	}
+	if strings.Contains(raw, "token_secret") {
+		t.Fatalf("token stored in plaintext: %q", raw)
+	}
+	tokens, _ := store.GetTokensByUserID(ctx, userID)
+	if len(tokens) != 1 || tokens[0] != "token_secret" {
+		t.Fatalf("tokens = %v", tokens)
+	}
+	if err := store.DeleteTokens(ctx, []string{"token_secret"}); err != nil {
+		t.Fatalf("DeleteTokens: %v", err)
+	}
+	if tokens, _ := store.GetTokensByUserID(ctx, userID); len(tokens) != 0 {
+		t.Fatalf("tokens after delete = %v", tokens)
+	}
+}
+
 // setupTestStore connects to Postgres, applies migrations, truncates the token
 // table, and returns a Store. The test is skipped when ARK_TEST_DSN is unset.
 func setupTestStore(t *testing.T) *Store {
@@ -112,8 +186,8 @@ func setupTestStore(t *testing.T) *Store {
 		t.Fatalf("apply migration: %v", err)
 	}
 
-	if _, err := db.Exec(ctx, "TRUNCATE TABLE user_fcm_tokens"); err != nil {
-		t.Fatalf("truncate user_fcm_tokens: %v", err)
+	if _, err := db.Exec(ctx, "TRUNCATE TABLE user_fcm_tokens, notification_preferences"); err != nil {
+		t.Fatalf("truncate notification tables: %v", err)
 	}
 
 	return NewStore(db)
@@ -129,6 +203,10 @@ func applyNotificationMigration(ctx context.Context, db *pgxpool.Pool) error {
 		"0002_schedule.sql",
 		"0003_ai_usage.sql",
 		"0004_notifications.sql",
+		"0006_users.sql",
+		"0011_notification_preferences.sql",
+		"0013_email_receipts.sql",
+		"0015_pii_encryption.sql",
 	}
 	for _, name := range migrations {
 		content, err := os.ReadFile(filepath.Join(root, "migrations", name))
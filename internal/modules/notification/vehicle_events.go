@@ -0,0 +1,49 @@
+// README: Vehicle upkeep notifications — inspection, maintenance and odometer reminders pushed to drivers.
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ark/internal/modules/vehicle"
+)
+
+// VehicleReminderHook pushes vehicle upkeep reminders to the driver.
+func (s *Service) VehicleReminderHook() vehicle.ReminderHook {
+	return func(ctx context.Context, r vehicle.Reminder) {
+		msg := &NotificationMessage{
+			Category: CategoryReminder,
+			Data:     map[string]interface{}{"type": "vehicle_" + r.Kind},
+		}
+		switch r.Kind {
+		case vehicle.ReminderInspectionDue:
+			msg.Title = "Vehicle inspection coming up"
+			msg.Body = "Your vehicle inspection is due on " + r.InspectionDue.Format(time.DateOnly) + "."
+			msg.Data["inspection_due"] = r.InspectionDue.Format(time.DateOnly)
+		case vehicle.ReminderInspectionOverdue:
+			msg.Title = "Vehicle inspection overdue"
+			msg.Body = "Your vehicle inspection was due on " + r.InspectionDue.Format(time.DateOnly) +
+				". Ride offers may be paused until a new inspection is recorded."
+			msg.Data["inspection_due"] = r.InspectionDue.Format(time.DateOnly)
+		case vehicle.ReminderServiceDue:
+			msg.Title = "Vehicle service due"
+			msg.Body = fmt.Sprintf("Your vehicle is due for service at %d km; the odometer reads %d km.", r.ServiceDueKm, r.OdometerKm)
+			msg.Data["service_due_km"] = r.ServiceDueKm
+			msg.Data["odometer_km"] = r.OdometerKm
+		case vehicle.ReminderOdometer:
+			msg.Title = "Report your odometer"
+			msg.Body = "Please enter your vehicle's current odometer reading in the app."
+		default:
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderEventTimeout)
+			defer cancel()
+			if err := s.NotifyUser(ctx, r.DriverID, msg); err != nil {
+				log.Printf("notification: vehicle %s reminder for driver %s: %v", r.Kind, r.DriverID, err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,82 @@
+// README: Claimed scheduled rides near pickup — the assigned orders whose schedule window has started, and releasing a driver who has not set off.
+package order
+
+import (
+	"context"
+	"time"
+
+	"ark/internal/types"
+)
+
+// AssignedPickup is a claimed scheduled ride whose schedule window has
+// started, so its driver should be getting ready to leave.
+type AssignedPickup struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    types.ID
+	Pickup      types.Point
+	ScheduledAt time.Time
+	// WindowStart is when the schedule window began: ScheduledAt minus
+	// schedule_window_mins.
+	WindowStart time.Time
+}
+
+// ReleaseAssignedCommand takes a claimed scheduled order back from DriverID,
+// e.g. when ops re-dispatch a ride whose driver has not left in time.
+type ReleaseAssignedCommand struct {
+	OrderID  types.ID
+	DriverID types.ID
+}
+
+// ListAssignedPickups returns the assigned scheduled orders picking up after
+// now whose schedule window has started by now, earliest pickup first.
+func (s *Service) ListAssignedPickups(ctx context.Context, now time.Time) ([]AssignedPickup, error) {
+	return s.store.ListAssignedPickups(ctx, now.UTC())
+}
+
+// ReleaseAssigned re-opens a claimed scheduled order on the system's behalf
+// (StatusAssigned → StatusScheduled) with the same incentive bonus as a driver
+// cancel. It fails with ErrConflict when the order has moved to another
+// driver since DriverID was read. Once claims have closed, the re-opened
+// order goes to instant dispatch on the next expire tick.
+func (s *Service) ReleaseAssigned(ctx context.Context, cmd ReleaseAssignedCommand) error {
+	if cmd.OrderID == "" || cmd.DriverID == "" {
+		return ErrBadRequest
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return err
+	}
+	if o.Status != StatusAssigned {
+		return invalidState(o)
+	}
+	if o.DriverID == nil || *o.DriverID != cmd.DriverID {
+		return conflict(o)
+	}
+	ok, err := s.store.ReopenScheduled(ctx, cmd.OrderID, o.StatusVersion, s.sched.DriverCancelBonus)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return conflict(o)
+	}
+	now := s.now()
+	s.appendEvent(ctx, &Event{
+		OrderID:    cmd.OrderID,
+		FromStatus: StatusAssigned,
+		ToStatus:   StatusScheduled,
+		ActorType:  ActorSystem,
+		CreatedAt:  now,
+	})
+	s.runHooks(ctx, Transition{
+		OrderID:     cmd.OrderID,
+		PassengerID: o.PassengerID,
+		DriverID:    o.DriverID,
+		From:        StatusAssigned,
+		To:          StatusScheduled,
+		ActorType:   ActorSystem,
+		At:          now,
+		Sandbox:     o.Sandbox,
+	})
+	return nil
+}
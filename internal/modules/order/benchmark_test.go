@@ -124,7 +124,7 @@ func BenchmarkNewID(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		id := newID()
+		id := types.NewID()
 		if id == "" {
 			b.Fatal("Generated empty ID")
 		}
@@ -137,7 +137,7 @@ func BenchmarkNewID_Parallel(b *testing.B) {
 
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			id := newID()
+			id := types.NewID()
 			if id == "" {
 				b.Fatal("Generated empty ID")
 			}
@@ -0,0 +1,221 @@
+// README: Passenger cancellation fees for instant rides — free during a grace period after the driver accepts, then priced on the driver's progress toward the pickup.
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// ErrCancelFeeRequired is returned when a passenger cancels an instant ride
+// past its free-cancel grace period without accepting the fee; the quote
+// returned with it is the fee to show before they confirm.
+var ErrCancelFeeRequired = errors.New("cancellation fee applies")
+
+// CancellationPricing prices a passenger's cancellation. Implemented by
+// pricing.Service.
+type CancellationPricing interface {
+	CancellationFee(ctx context.Context, req CancellationFeeRequest) (types.Money, error)
+}
+
+// CancellationFeeRequest is everything a cancellation fee may depend on.
+type CancellationFeeRequest struct {
+	RideType string
+	RegionID string
+	// BookedAt selects the rate version the ride was priced under.
+	BookedAt time.Time
+	// DriverProgressKm is how far the driver has come toward the pickup since
+	// accepting.
+	DriverProgressKm float64
+	// Fare caps the fee.
+	Fare types.Money
+}
+
+// CancellationCommission splits a cancellation fee paid to driverID at `at`
+// between the driver and the platform. Implemented by earnings.Payouts.
+type CancellationCommission interface {
+	SplitCancellationFee(ctx context.Context, driverID types.ID, fee int64, at time.Time) (FeeSplit, error)
+}
+
+// FeeSplit is the platform's cut of a cancellation fee; the driver is paid
+// the rest.
+type FeeSplit struct {
+	Commission    int64
+	CommissionBps int
+	// CommissionRule is the rule applied, nil for the default rate.
+	CommissionRule *types.ID
+}
+
+// CancelCharge is a cancellation fee charged in the cancel's transaction: it
+// is recorded on the order, the driver's share is credited to their earnings
+// and Txn books the charge in the ledger.
+type CancelCharge struct {
+	DriverID types.ID
+	Fee      int64
+	Split    FeeSplit
+	At       time.Time
+	Txn      *ledger.Txn
+}
+
+// DriverTraces returns a driver's recorded positions, oldest first.
+// Implemented by location.Service.
+type DriverTraces interface {
+	DriverTrace(ctx context.Context, driverID types.ID, from, to time.Time) ([]types.Point, error)
+}
+
+// CancellationQuote is what cancelling an order costs the passenger now.
+type CancellationQuote struct {
+	// Fee is zero while cancelling is free.
+	Fee types.Money
+	// FreeUntil is when the grace period after the driver accepted ends; nil
+	// when the order cannot incur a fee.
+	FreeUntil *time.Time
+	// DriverProgressKm is how far the driver has come toward the pickup.
+	DriverProgressKm float64
+}
+
+// SetCancellationFees charges passengers who cancel an instant ride more than
+// grace after a driver accepted it. Without it passengers always cancel free.
+func (s *Service) SetCancellationFees(p CancellationPricing, grace time.Duration) {
+	s.cancelFees = p
+	s.cancelGrace = grace
+}
+
+// SetCancellationCommission takes the platform's commission from cancellation
+// fees. Without it the driver is paid the whole fee.
+func (s *Service) SetCancellationCommission(c CancellationCommission) {
+	s.cancelCommission = c
+}
+
+// SetDriverTraces measures the driver's progress toward the pickup for
+// cancellation fees. Without it only the rate's flat fee is charged.
+func (s *Service) SetDriverTraces(t DriverTraces) {
+	s.traces = t
+}
+
+// QuoteCancellation returns what the passenger of orderID would pay to cancel
+// it now.
+func (s *Service) QuoteCancellation(ctx context.Context, orderID types.ID) (CancellationQuote, error) {
+	o, err := s.store.Get(ctx, orderID)
+	if err != nil {
+		return CancellationQuote{}, err
+	}
+	return s.cancellationQuote(ctx, o), nil
+}
+
+// CancelQuoted is Cancel returning the cancellation charged. A passenger
+// cancelling past the grace period must set cmd.AcceptFee to at least the
+// fee; otherwise it returns ErrCancelFeeRequired with the current quote. The
+// fee is charged in the same transaction as the cancel, so an order is never
+// cancelled without it. A passenger over the daily cancellation limit gets a
+// ThrottleError wrapping ErrCancelLimit.
+func (s *Service) CancelQuoted(ctx context.Context, cmd CancelCommand) (CancellationQuote, error) {
+	if cmd.ActorType != ActorPassenger {
+		return CancellationQuote{}, s.cancel(ctx, cmd)
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return CancellationQuote{}, err
+	}
+	if err := s.checkCancelThrottle(ctx, o.PassengerID); err != nil {
+		return CancellationQuote{}, err
+	}
+	q := s.cancellationQuote(ctx, o)
+	if q.Fee.Amount > 0 && (cmd.AcceptFee == nil || *cmd.AcceptFee < q.Fee.Amount) {
+		return q, ErrCancelFeeRequired
+	}
+	p := transitionParams{to: StatusCancelled, actorType: cmd.ActorType}
+	if q.Fee.Amount > 0 {
+		// The fee was priced and split for o as read above; a cancel racing
+		// any other change to the order fails as a conflict.
+		p.expectVersion = &o.StatusVersion
+		if p.cancelCharge, err = s.cancelCharge(ctx, o, q.Fee.Amount); err != nil {
+			return CancellationQuote{}, err
+		}
+	}
+	if err := s.applyTransition(ctx, o.ID, p); err != nil {
+		return CancellationQuote{}, err
+	}
+	s.recordCancel(ctx, o.PassengerID)
+	return q, nil
+}
+
+// cancelCharge splits fee between o's driver and the platform and builds the
+// ledger transaction booking it. The ledger is kept in the platform currency
+// only.
+func (s *Service) cancelCharge(ctx context.Context, o *Order, fee int64) (*CancelCharge, error) {
+	if c := currencyOf(o.EstimatedFee); c != types.DefaultCurrency {
+		return nil, fmt.Errorf("cancellation fee in %s: %w", c, types.ErrCurrencyMismatch)
+	}
+	c := &CancelCharge{DriverID: *o.DriverID, Fee: fee, At: s.now()}
+	if s.cancelCommission != nil {
+		split, err := s.cancelCommission.SplitCancellationFee(ctx, c.DriverID, fee, c.At)
+		if err != nil {
+			return nil, err
+		}
+		c.Split = split
+	}
+	c.Txn = ledger.CancellationFeeTxn(ledger.CancellationFee{
+		OrderID:     o.ID,
+		PassengerID: o.PassengerID,
+		DriverID:    c.DriverID,
+		OrgID:       o.OrgID,
+		Fee:         fee,
+		Commission:  c.Split.Commission,
+		At:          c.At,
+	})
+	return c, nil
+}
+
+// cancellationQuote prices cancelling o now. Only instant rides a driver is
+// on the way to or waiting at the pickup for can incur a fee; a fee that
+// cannot be priced is waived.
+func (s *Service) cancellationQuote(ctx context.Context, o *Order) CancellationQuote {
+	q := CancellationQuote{Fee: types.Money{Currency: currencyOf(o.EstimatedFee)}}
+	if s.cancelFees == nil || o.OrderType == "scheduled" || o.Sandbox || o.AcceptedAt == nil || o.DriverID == nil ||
+		(o.Status != StatusApproaching && o.Status != StatusArrived) {
+		return q
+	}
+	freeUntil := o.AcceptedAt.Add(s.cancelGrace)
+	q.FreeUntil = &freeUntil
+	now := s.now()
+	if now.Before(freeUntil) {
+		return q
+	}
+	q.DriverProgressKm = s.driverProgress(ctx, o, now)
+	fee, err := s.cancelFees.CancellationFee(ctx, CancellationFeeRequest{
+		RideType:         o.RideType,
+		RegionID:         o.RegionID,
+		BookedAt:         o.CreatedAt,
+		DriverProgressKm: q.DriverProgressKm,
+		Fare:             o.EstimatedFee,
+	})
+	if err != nil {
+		errreport.Report(ctx, "order", "price_cancel_fee", err, "order_id", o.ID)
+		return q
+	}
+	q.Fee = fee
+	return q
+}
+
+// driverProgress is how much closer to the pickup the driver is now than
+// when they accepted, from their recorded positions; 0 without a trace.
+func (s *Service) driverProgress(ctx context.Context, o *Order, now time.Time) float64 {
+	if s.traces == nil || o.DriverID == nil {
+		return 0
+	}
+	trace, err := s.traces.DriverTrace(ctx, *o.DriverID, *o.AcceptedAt, now)
+	if err != nil {
+		errreport.Report(ctx, "order", "driver_trace", err, "order_id", o.ID)
+		return 0
+	}
+	if len(trace) < 2 {
+		return 0
+	}
+	return max(distanceKm(trace[0], o.Pickup)-distanceKm(trace[len(trace)-1], o.Pickup), 0)
+}
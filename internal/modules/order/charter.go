@@ -0,0 +1,166 @@
+// README: Hourly charters — booking a driver for a number of hours, the stops logged while driving, and overtime billed when the charter ends late.
+package order
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// RideTypeCharter books a driver for CharterHours hours at an hourly rate.
+// Its orders are matched and move through the same states as rides, but the
+// Driving phase lasts the booked hours, the passenger may stop anywhere in
+// the pickup's region as often as they like, and time beyond the booking is
+// billed as overtime when the driver completes the charter.
+const RideTypeCharter = "charter"
+
+// MaxCharterHours is the longest charter that can be booked.
+const MaxCharterHours = 12
+
+var (
+	// ErrNotCharter is returned when logging a stop on an order that is not
+	// a charter.
+	ErrNotCharter = errors.New("order is not a charter")
+	// ErrOutsideRegion is returned for a charter stop or dropoff outside the
+	// region of its pickup.
+	ErrOutsideRegion = errors.New("stop is outside the charter's region")
+)
+
+// Stop is a place a chartered driver stopped at, logged by the driver.
+type Stop struct {
+	ID       int64
+	OrderID  types.ID
+	Position types.Point
+	At       time.Time
+}
+
+// CharterPricing prices the overtime of a charter. Implemented by
+// pricing.Service.
+type CharterPricing interface {
+	OvertimeFee(ctx context.Context, req OvertimeFeeRequest) (types.Money, error)
+}
+
+// OvertimeFeeRequest is everything a charter's overtime fee may depend on.
+type OvertimeFeeRequest struct {
+	RideType string
+	RegionID string
+	// BookedAt selects the rate version the charter was priced under.
+	BookedAt time.Time
+	// Overtime is how long the charter ran past its booked hours.
+	Overtime time.Duration
+	Currency string
+}
+
+// SetCharterPricing bills charters that run past their booked hours. Without
+// it overtime is free.
+func (s *Service) SetCharterPricing(p CharterPricing) {
+	s.charterPricing = p
+}
+
+// validCharterHours checks that hours are given exactly for charters.
+func validCharterHours(rideType string, hours int) bool {
+	if rideType != RideTypeCharter {
+		return hours == 0
+	}
+	return hours >= 1 && hours <= MaxCharterHours
+}
+
+// CharterEndsAt is when o's booked hours run out, nil until the charter
+// starts or for other ride types.
+func (o *Order) CharterEndsAt() *time.Time {
+	if o.CharterHours <= 0 || o.StartedAt == nil {
+		return nil
+	}
+	end := o.StartedAt.Add(time.Duration(o.CharterHours) * time.Hour)
+	return &end
+}
+
+// StopCommand is used by the chartered driver to log a stop while driving.
+type StopCommand struct {
+	OrderID  types.ID
+	DriverID types.ID
+	Position types.Point
+}
+
+// LogStop records a stop of a charter under way. Stops are not limited in
+// number but must lie in the region of the pickup.
+func (s *Service) LogStop(ctx context.Context, cmd StopCommand) (*Stop, error) {
+	p := cmd.Position
+	if p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+		return nil, ErrBadRequest
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.RideType != RideTypeCharter {
+		return nil, ErrNotCharter
+	}
+	if o.DriverID == nil || *o.DriverID != cmd.DriverID {
+		return nil, ErrActorNotAllowed
+	}
+	if o.Status != StatusDriving {
+		return nil, invalidState(o)
+	}
+	if err := s.checkCharterRegion(o.RegionID, p); err != nil {
+		return nil, err
+	}
+	stop := &Stop{OrderID: o.ID, Position: p, At: s.now()}
+	if err := s.store.AddStop(ctx, stop); err != nil {
+		return nil, err
+	}
+	return stop, nil
+}
+
+// Stops returns the stops logged on a charter, oldest first.
+func (s *Service) Stops(ctx context.Context, orderID types.ID) ([]Stop, error) {
+	return s.store.ListStops(ctx, orderID)
+}
+
+// checkCharterRegion returns ErrOutsideRegion unless p lies in regionID.
+// Without regions every place is in the default region.
+func (s *Service) checkCharterRegion(regionID string, p types.Point) error {
+	id, err := s.regionAt(p)
+	if errors.Is(err, ErrOutsideServiceArea) || (err == nil && id != regionID) {
+		return ErrOutsideRegion
+	}
+	return err
+}
+
+// applyOvertime bills a charter entering payment for the time it ran past
+// its booked hours, adding the fee to the fare. Failures leave the booked
+// fare due.
+func (s *Service) applyOvertime(ctx context.Context, o *Order) {
+	end := o.CharterEndsAt()
+	if s.charterPricing == nil || end == nil || o.Sandbox {
+		return
+	}
+	over := s.now().Sub(*end)
+	if over <= 0 {
+		return
+	}
+	fee, err := s.charterPricing.OvertimeFee(ctx, OvertimeFeeRequest{
+		RideType: o.RideType,
+		RegionID: o.RegionID,
+		BookedAt: o.CreatedAt,
+		Overtime: over,
+		Currency: currencyOf(o.EstimatedFee),
+	})
+	if err != nil {
+		errreport.Report(ctx, "order", "price_overtime", err, "order_id", o.ID)
+		return
+	}
+	if fee.Amount <= 0 {
+		return
+	}
+	if err := s.store.SetOvertimeFee(ctx, o.ID, fee.Amount); err != nil {
+		errreport.Report(ctx, "order", "record_overtime", err, "order_id", o.ID, "fee", fee.Amount)
+		return
+	}
+	actual := types.Money{Amount: o.EstimatedFee.Amount + fee.Amount, Currency: currencyOf(o.EstimatedFee)}
+	o.ActualFee = &actual
+	o.OvertimeFee = fee.Amount
+}
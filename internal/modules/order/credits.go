@@ -0,0 +1,58 @@
+// README: Ride credits: a passenger's credit balance is applied to the fare when the trip reaches payment.
+package order
+
+import (
+	"context"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// Credits redeems a passenger's ride credits against a fare.
+type Credits interface {
+	// Redeem spends up to fare of the passenger's balance on orderID and returns
+	// the amount applied. Redeeming the same order twice returns the first amount.
+	Redeem(ctx context.Context, passengerID, orderID types.ID, fare types.Money) (int64, error)
+}
+
+// SetCredits applies passenger credits to fares before payment. Without it
+// passengers always pay the full fare.
+func (s *Service) SetCredits(c Credits) {
+	s.credits = c
+}
+
+// Fare returns the actual fare when known, otherwise the estimate.
+func (o *Order) Fare() types.Money {
+	if o.ActualFee != nil {
+		return *o.ActualFee
+	}
+	return o.EstimatedFee
+}
+
+// AmountDue is the fare left to pay after the passenger's ride plan and credits.
+func (o *Order) AmountDue() types.Money {
+	f := o.Fare()
+	f.Amount = max(f.Amount-o.SubscriptionApplied-o.CreditsApplied, 0)
+	return f
+}
+
+// applyCredits redeems credits for an order entering payment against what
+// its ride plan left due. Business rides are billed to the organization and
+// sandbox rides are never charged, so neither spends credits. Failures leave
+// the full fare due.
+func (s *Service) applyCredits(ctx context.Context, o *Order) {
+	if s.credits == nil || o.OrgID != nil || o.Sandbox {
+		return
+	}
+	applied, err := s.credits.Redeem(ctx, o.PassengerID, o.ID, o.AmountDue())
+	if err != nil {
+		errreport.Report(ctx, "order", "redeem_credits", err, "order_id", o.ID)
+		return
+	}
+	if applied <= 0 {
+		return
+	}
+	if err := s.store.SetCreditsApplied(ctx, o.ID, applied); err != nil {
+		errreport.Report(ctx, "order", "record_credits", err, "order_id", o.ID, "credits", applied)
+	}
+}
@@ -0,0 +1,167 @@
+// README: Parcel deliveries — the delivery ride type, its recipient and weight class, and the proof-of-delivery step that completes it.
+package order
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"ark/internal/types"
+)
+
+// RideTypeDelivery carries a parcel instead of a passenger. Its orders are
+// matched and move through the same states as rides, but only drivers who
+// accept parcels are offered them, meeting at the pickup is collecting the
+// parcel, and the trip ends with Deliver rather than Complete.
+const RideTypeDelivery = "delivery"
+
+// RequireParcel is the capability of drivers who carry parcels; every
+// delivery order requires it.
+const RequireParcel = "parcel"
+
+// Parcel weight classes, priced per class by the delivery rate.
+const (
+	WeightSmall  = "small"  // up to 5 kg
+	WeightMedium = "medium" // up to 20 kg
+	WeightLarge  = "large"  // up to 50 kg
+)
+
+// WeightClasses lists every accepted weight class.
+var WeightClasses = []string{WeightSmall, WeightMedium, WeightLarge}
+
+// maxRecipientName is the longest recipient or signer name accepted, in
+// characters.
+const maxRecipientName = 100
+
+var (
+	// ErrProofRequired is returned when a delivery is finished without its
+	// proof: a photo and the recipient's signature, both uploaded, and the
+	// name of who signed.
+	ErrProofRequired = errors.New("proof of delivery required")
+	// ErrNotDelivery is returned by Deliver for an order that is a ride.
+	ErrNotDelivery = errors.New("order is not a delivery")
+)
+
+// Delivery is what a delivery order carries beyond a ride: who receives the
+// parcel and how heavy it is. SignedBy is who signed for it at the dropoff,
+// empty until delivered.
+type Delivery struct {
+	RecipientName  string
+	RecipientPhone string
+	WeightClass    string
+	SignedBy       string
+}
+
+// weightClassOf returns the parcel weight class of a delivery, "" for a ride.
+func weightClassOf(d *Delivery) string {
+	if d == nil {
+		return ""
+	}
+	return d.WeightClass
+}
+
+// normalizeDelivery checks that d is given exactly for delivery orders and
+// returns it cleaned up; rides return nil.
+func normalizeDelivery(rideType string, d *Delivery) (*Delivery, error) {
+	if rideType != RideTypeDelivery {
+		if d != nil {
+			return nil, ErrBadRequest
+		}
+		return nil, nil
+	}
+	if d == nil {
+		return nil, ErrBadRequest
+	}
+	out := &Delivery{
+		RecipientName:  strings.TrimSpace(d.RecipientName),
+		RecipientPhone: strings.Join(strings.Fields(d.RecipientPhone), ""),
+		WeightClass:    d.WeightClass,
+	}
+	if out.WeightClass == "" {
+		out.WeightClass = WeightSmall
+	}
+	if !validName(out.RecipientName) || !validPhone(out.RecipientPhone) || !slices.Contains(WeightClasses, out.WeightClass) {
+		return nil, ErrBadRequest
+	}
+	return out, nil
+}
+
+func validName(name string) bool {
+	return name != "" && utf8.RuneCountInString(name) <= maxRecipientName
+}
+
+// validPhone accepts a number of 8 to 15 digits, optionally after a "+".
+func validPhone(phone string) bool {
+	digits := strings.TrimPrefix(phone, "+")
+	if len(digits) < 8 || len(digits) > 15 {
+		return false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// DeliveryProof reports whether a delivery's proof has been uploaded: a
+// photo of the parcel at the dropoff and the recipient's signature
+// (attachment.Service).
+type DeliveryProof interface {
+	HasDeliveryProof(ctx context.Context, orderID types.ID) (bool, error)
+}
+
+// SetDeliveryProof makes Deliver require an uploaded photo and signature.
+// Without it the signer's name is the only proof asked for.
+func (s *Service) SetDeliveryProof(p DeliveryProof) {
+	s.deliveryProof = p
+}
+
+// DeliverCommand is used by the driver handing a parcel over at the dropoff
+// (StatusDriving → StatusPayment). SignedBy is the name of who signed for it.
+type DeliverCommand struct {
+	OrderID  types.ID
+	DriverID types.ID
+	SignedBy string
+}
+
+// Deliver finishes a delivery once its proof is in, recording who signed for
+// the parcel. It takes the place of Complete, which refuses deliveries.
+func (s *Service) Deliver(ctx context.Context, cmd DeliverCommand) error {
+	signedBy := strings.TrimSpace(cmd.SignedBy)
+	if !validName(signedBy) {
+		return ErrBadRequest
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return err
+	}
+	if o.Delivery == nil {
+		return ErrNotDelivery
+	}
+	if o.DriverID == nil || *o.DriverID != cmd.DriverID {
+		return ErrActorNotAllowed
+	}
+	if o.Status != StatusDriving {
+		return invalidState(o)
+	}
+	if s.deliveryProof != nil {
+		ok, err := s.deliveryProof.HasDeliveryProof(ctx, o.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrProofRequired
+		}
+	}
+	if err := s.store.SetDeliverySignature(ctx, o.ID, signedBy); err != nil {
+		return err
+	}
+	return s.applyTransition(ctx, o.ID, transitionParams{
+		to:        StatusPayment,
+		actorType: ActorDriver,
+		delivered: true,
+	})
+}
@@ -0,0 +1,81 @@
+// README: Designated drives (代駕) — the ride type where the driver drives the passenger's own car, and the car it carries.
+package order
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// RideTypeDesignated sends a driver to drive the passenger home in the
+// passenger's own car. Its orders are matched and move through the same
+// states as rides, but the vehicle is the passenger's: the order carries the
+// car, the driver's own vehicle is never checked against it, and the fare
+// includes an allowance for the driver's public transit back.
+const RideTypeDesignated = "designated"
+
+// RequireDesignated is the capability of drivers cleared to drive customers'
+// cars; every designated order requires it. RequireManual is added for cars
+// with a manual transmission.
+const (
+	RequireDesignated = "designated_driver"
+	RequireManual     = "manual_transmission"
+)
+
+// Transmissions of the passenger's car.
+const (
+	TransmissionAutomatic = "automatic"
+	TransmissionManual    = "manual"
+)
+
+// maxCarField is the longest car model or colour accepted, in characters.
+const maxCarField = 50
+
+var carPlateRe = regexp.MustCompile(`^[A-Z0-9-]{2,16}$`)
+
+// Car is the passenger's own car, driven on a designated drive. It stands in
+// for the driver's vehicle: the plate the driver looks for at the pickup is
+// this one.
+type Car struct {
+	Plate        string
+	Model        string
+	Color        string
+	Transmission string
+}
+
+// normalizeCar checks that c is given exactly for designated orders and
+// returns it cleaned up; other ride types return nil.
+func normalizeCar(rideType string, c *Car) (*Car, error) {
+	if rideType != RideTypeDesignated {
+		if c != nil {
+			return nil, ErrBadRequest
+		}
+		return nil, nil
+	}
+	if c == nil {
+		return nil, ErrBadRequest
+	}
+	out := &Car{
+		Plate:        strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(c.Plate), " ", "")),
+		Model:        strings.TrimSpace(c.Model),
+		Color:        strings.TrimSpace(c.Color),
+		Transmission: c.Transmission,
+	}
+	if out.Transmission == "" {
+		out.Transmission = TransmissionAutomatic
+	}
+	if !carPlateRe.MatchString(out.Plate) ||
+		utf8.RuneCountInString(out.Model) > maxCarField || utf8.RuneCountInString(out.Color) > maxCarField ||
+		(out.Transmission != TransmissionAutomatic && out.Transmission != TransmissionManual) {
+		return nil, ErrBadRequest
+	}
+	return out, nil
+}
+
+// carRequirements returns what a driver must be able to do to drive c.
+func carRequirements(c *Car) []string {
+	if c != nil && c.Transmission == TransmissionManual {
+		return []string{RequireManual}
+	}
+	return nil
+}
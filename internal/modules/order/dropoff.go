@@ -0,0 +1,228 @@
+// README: Mid-trip dropoff changes: the passenger proposes a new dropoff with a re-estimated fare and the driver acknowledges it.
+package order
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// Dropoff change stages reported to DropoffHooks.
+const (
+	DropoffRequested = "requested"
+	DropoffAccepted  = "accepted"
+	DropoffRejected  = "rejected"
+)
+
+// DropoffProposal is a passenger's new dropoff awaiting the driver, with the
+// fare re-estimated for the whole trip to it.
+type DropoffProposal struct {
+	Dropoff     types.Point
+	Fee         types.Money
+	RequestedAt time.Time
+}
+
+// RouteDistance returns the driving distance between two points in km.
+// Implemented by maps.RouteService.
+type RouteDistance interface {
+	GetRouteDistance(ctx context.Context, origin, destination types.Point) (float64, error)
+}
+
+// SetRouteDistance prices dropoff changes on the driving route. Without it, or
+// when the route lookup fails, the straight-line distance is used.
+func (s *Service) SetRouteDistance(r RouteDistance) {
+	s.routes = r
+}
+
+// DropoffChange describes a committed step of a mid-trip dropoff change.
+type DropoffChange struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    *types.ID
+	Stage       string // DropoffRequested, DropoffAccepted or DropoffRejected
+	From        types.Point
+	To          types.Point
+	// Fare is the trip's estimate before the change; NewFare after it is accepted.
+	Fare    types.Money
+	NewFare types.Money
+	Sandbox bool
+}
+
+// DropoffHook is called after a dropoff change step has been persisted.
+type DropoffHook func(ctx context.Context, c DropoffChange)
+
+// OnDropoffChange registers h to run after every dropoff change step.
+// Must be called before the service starts handling requests.
+func (s *Service) OnDropoffChange(h DropoffHook) {
+	s.dropoffHooks = append(s.dropoffHooks, h)
+}
+
+// ChangeDropoffCommand proposes a new dropoff for a trip in progress.
+type ChangeDropoffCommand struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	Dropoff     types.Point
+	// ExpectVersion, when set, must match the order's status_version.
+	ExpectVersion *int
+}
+
+// AckDropoffCommand is the driver's answer to a proposed dropoff.
+type AckDropoffCommand struct {
+	OrderID  types.ID
+	DriverID types.ID
+	Accept   bool
+	// ExpectVersion, when set, must match the order's status_version.
+	ExpectVersion *int
+}
+
+// RequestDropoffChange re-estimates the fare to cmd.Dropoff and stores it as a
+// proposal for the driver. Only the passenger of a driving order may propose,
+// and a new proposal replaces an unanswered one. Business rides are checked
+// against the organization's policy at the new fare.
+func (s *Service) RequestDropoffChange(ctx context.Context, cmd ChangeDropoffCommand) (*DropoffProposal, error) {
+	if cmd.OrderID == "" || !validPoint(cmd.Dropoff) {
+		return nil, ErrBadRequest
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.PassengerID != cmd.PassengerID {
+		return nil, ErrActorNotAllowed
+	}
+	if o.Status != StatusDriving {
+		return nil, invalidState(o)
+	}
+	if cmd.ExpectVersion != nil && *cmd.ExpectVersion != o.StatusVersion {
+		return nil, conflict(o)
+	}
+	if o.RideType == RideTypeCharter {
+		if err := s.checkCharterRegion(o.RegionID, cmd.Dropoff); err != nil {
+			return nil, err
+		}
+	}
+	q, err := s.estimateFare(ctx, o.Pickup, cmd.Dropoff, o.RideType, weightClassOf(o.Delivery), o.CharterHours, o.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	fee := q.Fare
+	if fee.Currency != o.EstimatedFee.Currency {
+		return nil, types.ErrCurrencyMismatch
+	}
+	now := s.now()
+	if err := s.checkOrgPolicy(ctx, o.OrgID, o.PassengerID, o.RegionID, now, fee); err != nil {
+		return nil, err
+	}
+	ok, err := s.store.ProposeDropoff(ctx, o.ID, o.StatusVersion, cmd.Dropoff, q, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, conflict(o)
+	}
+	s.watchers.notify(o.ID)
+	s.runDropoffHooks(ctx, DropoffChange{
+		OrderID:     o.ID,
+		PassengerID: o.PassengerID,
+		DriverID:    o.DriverID,
+		Stage:       DropoffRequested,
+		From:        o.Dropoff,
+		To:          cmd.Dropoff,
+		Fare:        o.EstimatedFee,
+		NewFare:     fee,
+		Sandbox:     o.Sandbox,
+	})
+	return &DropoffProposal{Dropoff: cmd.Dropoff, Fee: fee, RequestedAt: now}, nil
+}
+
+// AckDropoffChange applies (accept) or discards the pending dropoff proposal.
+// Only the assigned driver of a driving order may answer.
+func (s *Service) AckDropoffChange(ctx context.Context, cmd AckDropoffCommand) error {
+	if cmd.OrderID == "" || cmd.DriverID == "" {
+		return ErrBadRequest
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return err
+	}
+	if o.DriverID == nil || *o.DriverID != cmd.DriverID {
+		return ErrActorNotAllowed
+	}
+	if o.Status != StatusDriving || o.PendingDropoff == nil {
+		return invalidState(o)
+	}
+	if cmd.ExpectVersion != nil && *cmd.ExpectVersion != o.StatusVersion {
+		return conflict(o)
+	}
+	ok, err := s.store.ResolveDropoff(ctx, o.ID, o.StatusVersion, cmd.Accept)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return conflict(o)
+	}
+	s.watchers.notify(o.ID)
+	stage := DropoffRejected
+	if cmd.Accept {
+		stage = DropoffAccepted
+	}
+	s.runDropoffHooks(ctx, DropoffChange{
+		OrderID:     o.ID,
+		PassengerID: o.PassengerID,
+		DriverID:    o.DriverID,
+		Stage:       stage,
+		From:        o.Dropoff,
+		To:          o.PendingDropoff.Dropoff,
+		Fare:        o.EstimatedFee,
+		NewFare:     o.PendingDropoff.Fee,
+		Sandbox:     o.Sandbox,
+	})
+	return nil
+}
+
+// estimateFare prices a trip from pickup to dropoff on the driving route,
+// falling back to the straight-line distance.
+func (s *Service) estimateFare(ctx context.Context, pickup, dropoff types.Point, rideType, weightClass string, hours int, regionID string) (Quote, error) {
+	km := distanceKm(pickup, dropoff)
+	if s.routes != nil {
+		if d, err := s.routes.GetRouteDistance(ctx, pickup, dropoff); err == nil {
+			km = d
+		} else {
+			errreport.Report(ctx, "order", "dropoff_route_distance", err)
+		}
+	}
+	if s.pricing == nil {
+		return Quote{Fare: types.Money{Currency: types.DefaultCurrency}}, nil
+	}
+	return s.pricing.Quote(ctx, PricingRequest{
+		RideType:    rideType,
+		Pickup:      pickup,
+		Dropoff:     dropoff,
+		DistanceKm:  km,
+		At:          s.now(),
+		RegionID:    regionID,
+		WeightClass: weightClass,
+		Hours:       hours,
+	})
+}
+
+func (s *Service) runDropoffHooks(ctx context.Context, c DropoffChange) {
+	for _, h := range s.dropoffHooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("order: dropoff hook panicked for %s: %v", c.OrderID, r)
+				}
+			}()
+			h(ctx, c)
+		}()
+	}
+}
+
+func validPoint(p types.Point) bool {
+	return math.Abs(p.Lat) <= 90 && math.Abs(p.Lng) <= 180 && (p.Lat != 0 || p.Lng != 0)
+}
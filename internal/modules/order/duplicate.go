@@ -0,0 +1,76 @@
+// README: Double-booking guard — an order repeating a ride the passenger just cancelled, or one they already booked for about the same time, is refused with the ride it repeats.
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// ErrPossibleDuplicate is returned for an order that repeats another of the
+// passenger's rides. Use errors.As with *DuplicateError for the ride.
+var ErrPossibleDuplicate = errors.New("order looks like a duplicate")
+
+const (
+	// duplicateRadiusKm is how close both the pickups and the dropoffs of two
+	// orders must be for them to be the same trip.
+	duplicateRadiusKm = 0.15
+	// rebookWindow is how long after cancelling a ride an identical order
+	// needs the passenger's confirmation.
+	rebookWindow = 2 * time.Minute
+	// twinRideWindow is how close to a booked ride's pickup time an identical
+	// order counts as booking it twice.
+	twinRideWindow = time.Hour
+)
+
+// DuplicateError reports the ride a new order repeats. It wraps
+// ErrPossibleDuplicate.
+type DuplicateError struct {
+	// Of is the repeated ride: cancelled moments ago, or scheduled (claimed
+	// or not) for about the same time.
+	Of *Order
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%v of order %s (%s)", ErrPossibleDuplicate, e.Of.ID, e.Of.Status)
+}
+
+func (e *DuplicateError) Unwrap() error { return ErrPossibleDuplicate }
+
+// Rebookable reports whether the passenger may confirm the order anyway: only
+// a cancelled ride can be booked again.
+func (e *DuplicateError) Rebookable() bool {
+	return e.Of.Status == StatusCancelled
+}
+
+// checkDuplicate refuses a trip from pickup to dropoff at pickupAt that the
+// passenger cancelled within rebookWindow, unless rebook confirms it, or that
+// they have scheduled within twinRideWindow of pickupAt. A failed lookup lets
+// the order through.
+func (s *Service) checkDuplicate(ctx context.Context, passengerID types.ID, pickup, dropoff types.Point, pickupAt time.Time, rebook bool) error {
+	candidates, err := s.store.ListRebookCandidates(ctx, passengerID, s.now().Add(-rebookWindow))
+	if err != nil {
+		errreport.Report(ctx, "order", "duplicate_check", err, "passenger_id", passengerID)
+		return nil
+	}
+	for _, o := range candidates {
+		if distanceKm(o.Pickup, pickup) > duplicateRadiusKm || distanceKm(o.Dropoff, dropoff) > duplicateRadiusKm {
+			continue
+		}
+		switch {
+		case o.Status == StatusCancelled:
+			if !rebook {
+				return &DuplicateError{Of: o}
+			}
+		case o.ScheduledAt != nil:
+			if gap := o.ScheduledAt.Sub(pickupAt).Abs(); gap <= twinRideWindow {
+				return &DuplicateError{Of: o}
+			}
+		}
+	}
+	return nil
+}
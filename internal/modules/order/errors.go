@@ -0,0 +1,54 @@
+// README: Structured order errors — the order and state behind a refused operation, wrapping the package's sentinel errors.
+package order
+
+import (
+	"fmt"
+
+	"ark/internal/types"
+)
+
+// StateError reports an operation refused because of the order's state. Err
+// is ErrInvalidState when the order is in the wrong status for it and
+// ErrConflict when the order changed under the caller, so errors.Is against
+// either sentinel keeps working. Use errors.As to read the state.
+type StateError struct {
+	OrderID types.ID
+	// Status and Version are the order's status and status_version as last
+	// read; after a lost update the order has since moved past them.
+	Status  Status
+	Version int
+	// To is the status the caller asked for, empty for operations other
+	// than a transition.
+	To  Status
+	Err error
+}
+
+func (e *StateError) Error() string {
+	if e.To != "" {
+		return fmt.Sprintf("%v: order %s is %s (version %d), cannot become %s", e.Err, e.OrderID, e.Status, e.Version, e.To)
+	}
+	return fmt.Sprintf("%v: order %s is %s (version %d)", e.Err, e.OrderID, e.Status, e.Version)
+}
+
+func (e *StateError) Unwrap() error { return e.Err }
+
+// invalidState refuses an operation o's status does not allow.
+func invalidState(o *Order) error {
+	return &StateError{OrderID: o.ID, Status: o.Status, Version: o.StatusVersion, Err: ErrInvalidState}
+}
+
+// conflict refuses an operation because o changed since the caller read it.
+func conflict(o *Order) error {
+	return &StateError{OrderID: o.ID, Status: o.Status, Version: o.StatusVersion, Err: ErrConflict}
+}
+
+// NotFoundError reports an unknown order. It wraps ErrNotFound.
+type NotFoundError struct {
+	OrderID types.ID
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrNotFound, e.OrderID)
+}
+
+func (e *NotFoundError) Unwrap() error { return ErrNotFound }
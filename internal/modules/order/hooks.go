@@ -0,0 +1,48 @@
+// README: Transition hooks let other modules react to order status changes without the order module importing them.
+package order
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Transition describes a committed order status change. From is StatusNone for
+// newly created orders.
+type Transition struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    *types.ID
+	From        Status
+	To          Status
+	ActorType   string
+	At          time.Time
+	// Sandbox is set for test-mode orders; hooks with external side effects skip them.
+	Sandbox bool
+}
+
+// TransitionHook is called after a status change has been persisted. Hooks run
+// synchronously in registration order and cannot fail the transition.
+type TransitionHook func(ctx context.Context, t Transition)
+
+// OnTransition registers h to run after every committed status change.
+// Must be called before the service starts handling requests.
+func (s *Service) OnTransition(h TransitionHook) {
+	s.hooks = append(s.hooks, h)
+}
+
+func (s *Service) runHooks(ctx context.Context, t Transition) {
+	s.watchers.notify(t.OrderID)
+	for _, h := range s.hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("order: transition hook panicked for %s (%s→%s): %v", t.OrderID, t.From, t.To, r)
+				}
+			}()
+			h(ctx, t)
+		}()
+	}
+}
@@ -56,7 +56,71 @@ type Order struct {
 	CancelDeadlineAt   *time.Time
 	IncentiveBonus     int64
 	AssignedAt         *time.Time
-	history            []Event
+	// Sandbox marks test-mode orders: played by the driver bot, never offered to real drivers.
+	Sandbox bool
+	// Notes are free-text instructions for the assigned driver (gate code,
+	// "call on arrival"); Requirements are flags from the Requirements list.
+	Notes        string
+	Requirements []string
+	// PassengerCount and HasPet describe the party; their vehicle needs are
+	// already folded into Requirements.
+	PassengerCount int
+	HasPet         bool
+	// TransitType ("flight"/"train") and TransitNumber mark airport and HSR
+	// pickups whose time follows the arrival of that flight or train.
+	TransitType   string
+	TransitNumber string
+	// ArriveBy is when a scheduled passenger must reach the dropoff (a
+	// flight or train departure); pickups with it are re-checked against
+	// live traffic.
+	ArriveBy *time.Time
+	// ArrivalWindow is the arrival promised for an arrive-by booking; ArrivedAt
+	// is when the driver actually finished the trip at the dropoff.
+	ArrivalWindow *ArrivalWindow
+	ArrivedAt     *time.Time
+	// OrgID is set for business rides billed to an organization's monthly invoice.
+	OrgID *types.ID
+	// RegionID is the service region the pickup lies in; empty means the
+	// default region.
+	RegionID string
+	// Priority is the passenger's priority class ("vip", "accessibility")
+	// when the order was booked; "" for normal passengers.
+	Priority string
+	// ConversationID is the AI ride assistant conversation that booked the
+	// order; empty for orders placed directly.
+	ConversationID string
+	// CreditsApplied is the passenger credit (TWD minor units) taken off the fare at payment.
+	CreditsApplied int64
+	// SubscriptionApplied is what the passenger's ride plan took off the
+	// fare at payment, before credits.
+	SubscriptionApplied int64
+	// CancelFee is what the passenger paid to cancel after the free-cancel
+	// grace period, in the fare's currency.
+	CancelFee int64
+	// OriginalDropoff is the dropoff booked at creation, set once the passenger
+	// changes it mid-trip; PendingDropoff is a change awaiting the driver.
+	OriginalDropoff *types.Point
+	PendingDropoff  *DropoffProposal
+	// PricingVersion is the version of the ride type's pricing rule that
+	// produced EstimatedFee; 0 when no versioned rule applied.
+	PricingVersion int
+	// PendingRequote is a higher fare for a scheduled order awaiting the
+	// passenger's consent after pricing rules changed.
+	PendingRequote *RequoteProposal
+	// Delivery is set for parcel deliveries (RideTypeDelivery), nil for rides.
+	Delivery *Delivery
+	// Car is the passenger's own car on a designated drive
+	// (RideTypeDesignated), nil otherwise. ReturnAllowance is the part of
+	// the fare reimbursing the driver's public transit back; the driver is
+	// paid it without commission.
+	Car             *Car
+	ReturnAllowance int64
+	// CharterHours is the booked length of a charter (RideTypeCharter), 0
+	// otherwise. OvertimeFee is what the charter's time past those hours
+	// added to the fare.
+	CharterHours int
+	OvertimeFee  int64
+	history      []Event
 }
 
 type Event struct {
@@ -69,39 +133,77 @@ type Event struct {
 	CreatedAt  time.Time
 }
 
+// Actor types recorded on order events and checked per transition edge.
+const (
+	ActorPassenger = "passenger"
+	ActorDriver    = "driver"
+	ActorSystem    = "system"
+)
+
+// anyActor may cancel: the passenger, the assigned driver, or the system (timeouts, calendar sync).
+var anyActor = []string{ActorPassenger, ActorDriver, ActorSystem}
+
 // AllowedTransitions represents the order state flow as code (see docs/orderflow.md mermaid diagram).
-// Actor-specific semantics (passenger/driver/system) are enforced by the service layer;
-// this map defines which state pairs are structurally valid.
+// Each edge lists the actor types that may perform it; the service rejects any other actor.
 // TODO: (specific status) ex. Payment fail,
-var AllowedTransitions = map[Status][]Status{
-	// Scheduled order is assigned, cancelled. We obmit the waiting, expired for now since the schedule order is important.
-	StatusScheduled: {StatusAssigned, StatusCancelled},
-	// Awaiting a driver: realtime → Approaching on accept, scheduled → Assigned on accept,
-	// self-loop on matching retry, → Denied on driver decline, → Cancelled on passenger cancel,
-	// → Expired on matching timeout.
-	StatusWaiting: {StatusWaiting, StatusApproaching, StatusCancelled, StatusExpired},
+var AllowedTransitions = map[Status]map[Status][]string{
+	// Scheduled order is claimed by a driver or cancelled; left unclaimed when claims close, the
+	// system hands it to instant dispatch (→ Waiting).
+	StatusScheduled: {
+		StatusAssigned:  {ActorDriver},
+		StatusCancelled: anyActor,
+		StatusWaiting:   {ActorSystem},
+	},
+	// Awaiting a driver: → Approaching on driver accept or system match, self-loop on
+	// matching retry or driver decline, → Cancelled, → Expired on matching timeout.
+	StatusWaiting: {
+		StatusWaiting:     {ActorDriver, ActorSystem},
+		StatusApproaching: {ActorDriver, ActorSystem},
+		StatusCancelled:   anyActor,
+		StatusExpired:     {ActorSystem},
+	},
 	// Driver accepted a scheduled order but has not departed; can start trip (→ Approaching), cancel,
-	// or be re-opened by driver cancel (→ Scheduled).
-	StatusAssigned: {StatusApproaching, StatusCancelled, StatusScheduled},
-	// Driver en route: arrives at pickup (→ Arrived), passenger or driver cancels (→ Cancelled),
-	// or driver cancels for re-matching (→ Waiting).
-	StatusApproaching: {StatusArrived, StatusCancelled, StatusWaiting},
-	// Driver at pickup: passenger boards (→ Driving) or either party cancels.
-	StatusArrived: {StatusDriving, StatusCancelled},
-	// Trip in progress: drop-off (→ Payment) or driver cancels.
-	StatusDriving: {StatusPayment, StatusCancelled},
-	// Awaiting payment confirmation.
-	StatusPayment: {StatusComplete},
+	// or be re-opened by driver cancel or an ops re-dispatch (→ Scheduled).
+	StatusAssigned: {
+		StatusApproaching: {ActorDriver},
+		StatusCancelled:   anyActor,
+		StatusScheduled:   {ActorDriver, ActorSystem},
+	},
+	// Driver en route: arrives at pickup (→ Arrived), cancellation (→ Cancelled),
+	// or driver decline / system re-match (→ Waiting).
+	StatusApproaching: {
+		StatusArrived:   {ActorDriver},
+		StatusCancelled: anyActor,
+		StatusWaiting:   {ActorDriver, ActorSystem},
+	},
+	// Driver at pickup: passenger boards (→ Driving, reported by the driver) or cancellation.
+	StatusArrived: {
+		StatusDriving:   {ActorDriver},
+		StatusCancelled: anyActor,
+	},
+	// Trip in progress: drop-off (→ Payment) or cancellation.
+	StatusDriving: {
+		StatusPayment:   {ActorDriver},
+		StatusCancelled: anyActor,
+	},
+	// Awaiting payment confirmation from the payment flow.
+	StatusPayment: {
+		StatusComplete: {ActorSystem},
+	},
 }
 
 var allowedTransitionSet = buildTransitionSet(AllowedTransitions)
 
-func buildTransitionSet(transitions map[Status][]Status) map[Status]map[Status]struct{} {
-	set := make(map[Status]map[Status]struct{}, len(transitions))
-	for from, tos := range transitions {
-		next := make(map[Status]struct{}, len(tos))
-		for _, to := range tos {
-			next[to] = struct{}{}
+func buildTransitionSet(transitions map[Status]map[Status][]string) map[Status]map[Status]map[string]struct{} {
+	set := make(map[Status]map[Status]map[string]struct{}, len(transitions))
+	for from, edges := range transitions {
+		next := make(map[Status]map[string]struct{}, len(edges))
+		for to, actors := range edges {
+			allowed := make(map[string]struct{}, len(actors))
+			for _, a := range actors {
+				allowed[a] = struct{}{}
+			}
+			next[to] = allowed
 		}
 		set[from] = next
 	}
@@ -117,3 +219,13 @@ func CanTransition(from, to Status) bool {
 	_, ok = next[to]
 	return ok
 }
+
+// CanActorTransition checks that the edge exists and that actor may perform it.
+func CanActorTransition(from, to Status, actor string) bool {
+	allowed, ok := allowedTransitionSet[from][to]
+	if !ok {
+		return false
+	}
+	_, ok = allowed[actor]
+	return ok
+}
@@ -33,6 +33,7 @@ func TestOrder_StatusTransitions(t *testing.T) {
 		{"assigned to approaching", StatusAssigned, StatusApproaching, true},
 		{"assigned to cancelled", StatusAssigned, StatusCancelled, true},
 		{"assigned to scheduled", StatusAssigned, StatusScheduled, true}, // driver cancels
+		{"scheduled to waiting", StatusScheduled, StatusWaiting, true},   // unclaimed, dispatched
 
 		// Re-matching flows
 		{"approaching to waiting", StatusApproaching, StatusWaiting, true}, // driver cancel
@@ -58,6 +59,68 @@ func TestOrder_StatusTransitions(t *testing.T) {
 	}
 }
 
+func TestCanActorTransition(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  Status
+		to    Status
+		actor string
+		want  bool
+	}{
+		// Driver-only trip progress.
+		{"driver arrives", StatusApproaching, StatusArrived, ActorDriver, true},
+		{"passenger cannot arrive", StatusApproaching, StatusArrived, ActorPassenger, false},
+		{"system cannot arrive", StatusApproaching, StatusArrived, ActorSystem, false},
+		{"driver meets", StatusArrived, StatusDriving, ActorDriver, true},
+		{"passenger cannot meet", StatusArrived, StatusDriving, ActorPassenger, false},
+		{"driver completes", StatusDriving, StatusPayment, ActorDriver, true},
+		{"passenger cannot complete", StatusDriving, StatusPayment, ActorPassenger, false},
+
+		// Matching.
+		{"system matches", StatusWaiting, StatusApproaching, ActorSystem, true},
+		{"driver accepts", StatusWaiting, StatusApproaching, ActorDriver, true},
+		{"passenger cannot accept", StatusWaiting, StatusApproaching, ActorPassenger, false},
+		{"driver claims scheduled", StatusScheduled, StatusAssigned, ActorDriver, true},
+		{"passenger cannot claim", StatusScheduled, StatusAssigned, ActorPassenger, false},
+		{"system dispatches unclaimed", StatusScheduled, StatusWaiting, ActorSystem, true},
+		{"system re-dispatches assigned", StatusAssigned, StatusScheduled, ActorSystem, true},
+		{"driver cannot dispatch scheduled", StatusScheduled, StatusWaiting, ActorDriver, false},
+		{"only system expires", StatusWaiting, StatusExpired, ActorPassenger, false},
+
+		// Cancellation is open to every participant.
+		{"passenger cancels waiting", StatusWaiting, StatusCancelled, ActorPassenger, true},
+		{"driver cancels arrived", StatusArrived, StatusCancelled, ActorDriver, true},
+		{"system cancels scheduled", StatusScheduled, StatusCancelled, ActorSystem, true},
+		{"passenger cancels driving", StatusDriving, StatusCancelled, ActorPassenger, true},
+		{"unknown actor cannot cancel", StatusWaiting, StatusCancelled, "stranger", false},
+
+		// Payment is confirmed by the payment flow only.
+		{"system confirms payment", StatusPayment, StatusComplete, ActorSystem, true},
+		{"driver cannot confirm payment", StatusPayment, StatusComplete, ActorDriver, false},
+
+		// Missing edges stay invalid for everyone.
+		{"no edge", StatusComplete, StatusWaiting, ActorSystem, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanActorTransition(tt.from, tt.to, tt.actor); got != tt.want {
+				t.Errorf("CanActorTransition(%s, %s, %s) = %v, want %v", tt.from, tt.to, tt.actor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedTransitions_EveryEdgeHasActors(t *testing.T) {
+	for from, edges := range AllowedTransitions {
+		for to, actors := range edges {
+			if len(actors) == 0 {
+				t.Errorf("edge %s→%s has no allowed actors", from, to)
+			}
+		}
+	}
+}
+
 func TestOrder_Validation(t *testing.T) {
 	now := time.Now()
 
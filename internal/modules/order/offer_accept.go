@@ -0,0 +1,42 @@
+// README: Accepting through a dispatch offer: an accept that names an offer is refused once the offer has expired or been withdrawn.
+package order
+
+import (
+	"context"
+	"errors"
+
+	"ark/internal/types"
+)
+
+// ErrOfferLapsed means the offer a driver accepted through has expired or
+// been withdrawn.
+var ErrOfferLapsed = errors.New("offer expired or withdrawn")
+
+// Offers reports on dispatch offers. Implemented by matching.Store.
+type Offers interface {
+	// OfferOpen reports whether offerID is driverID's offer of orderID and is
+	// still pending and unexpired.
+	OfferOpen(ctx context.Context, offerID int64, orderID, driverID types.ID) (bool, error)
+}
+
+// SetOffers makes accepts that name an offer check it is still open.
+// Without it the offer is ignored and only the order's state is checked.
+func (s *Service) SetOffers(o Offers) {
+	s.offers = o
+}
+
+// checkOffer returns ErrOfferLapsed when cmd accepts through an offer that is
+// no longer open.
+func (s *Service) checkOffer(ctx context.Context, cmd AcceptCommand) error {
+	if s.offers == nil || cmd.OfferID == 0 {
+		return nil
+	}
+	open, err := s.offers.OfferOpen(ctx, cmd.OfferID, cmd.OrderID, cmd.DriverID)
+	if err != nil {
+		return err
+	}
+	if !open {
+		return ErrOfferLapsed
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ package order
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -151,7 +152,7 @@ func TestOrderFlowAcceptSameTime(t *testing.T) {
 			success++
 			continue
 		}
-		if err != ErrConflict && err != ErrInvalidState {
+		if !errors.Is(err, ErrConflict) && !errors.Is(err, ErrInvalidState) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	}
@@ -173,7 +174,7 @@ func TestOrderFlowCancelWaiting(t *testing.T) {
 	}
 	assertStatus(t, svc, orderID, StatusCancelled)
 
-	if err := svc.Accept(ctx, AcceptCommand{OrderID: orderID, DriverID: "d1"}); err != ErrInvalidState {
+	if err := svc.Accept(ctx, AcceptCommand{OrderID: orderID, DriverID: "d1"}); !errors.Is(err, ErrInvalidState) {
 		t.Fatalf("accept after cancel: expected ErrInvalidState, got %v", err)
 	}
 }
@@ -198,23 +199,23 @@ func TestOrderInvalidTransitions(t *testing.T) {
 
 	orderID := mustCreateOrder(t, svc, "p_invalid")
 
-	if err := svc.Arrive(ctx, ArriveCommand{OrderID: orderID}); err != ErrInvalidState {
+	if err := svc.Arrive(ctx, ArriveCommand{OrderID: orderID}); !errors.Is(err, ErrInvalidState) {
 		t.Fatalf("arrive before accept: expected ErrInvalidState, got %v", err)
 	}
-	if err := svc.Meet(ctx, MeetCommand{OrderID: orderID}); err != ErrInvalidState {
+	if err := svc.Meet(ctx, MeetCommand{OrderID: orderID}); !errors.Is(err, ErrInvalidState) {
 		t.Fatalf("meet before arrive: expected ErrInvalidState, got %v", err)
 	}
-	if err := svc.Complete(ctx, CompleteCommand{OrderID: orderID}); err != ErrInvalidState {
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: orderID}); !errors.Is(err, ErrInvalidState) {
 		t.Fatalf("complete before driving: expected ErrInvalidState, got %v", err)
 	}
-	if err := svc.Pay(ctx, PayCommand{OrderID: orderID}); err != ErrInvalidState {
+	if err := svc.Pay(ctx, PayCommand{OrderID: orderID}); !errors.Is(err, ErrInvalidState) {
 		t.Fatalf("pay before payment: expected ErrInvalidState, got %v", err)
 	}
 
 	if err := svc.Accept(ctx, AcceptCommand{OrderID: orderID, DriverID: "d1"}); err != nil {
 		t.Fatalf("accept: %v", err)
 	}
-	if err := svc.Meet(ctx, MeetCommand{OrderID: orderID}); err != ErrInvalidState {
+	if err := svc.Meet(ctx, MeetCommand{OrderID: orderID}); !errors.Is(err, ErrInvalidState) {
 		t.Fatalf("meet before arrive (after accept): expected ErrInvalidState, got %v", err)
 	}
 }
@@ -258,7 +259,7 @@ func TestConcurrentAcceptVsCancel(t *testing.T) {
 			success++
 			continue
 		}
-		if err != ErrConflict && err != ErrInvalidState {
+		if !errors.Is(err, ErrConflict) && !errors.Is(err, ErrInvalidState) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	}
@@ -315,7 +316,7 @@ func TestConcurrentAcceptSameOrder(t *testing.T) {
 			success++
 			continue
 		}
-		if err != ErrConflict && err != ErrInvalidState {
+		if !errors.Is(err, ErrConflict) && !errors.Is(err, ErrInvalidState) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	}
@@ -467,7 +468,7 @@ func TestScheduledOrderValidation(t *testing.T) {
 		ScheduledAt:        time.Now().Add(10 * time.Minute), // too soon
 		ScheduleWindowMins: 30,
 	})
-	if err != ErrBadRequest {
+	if !errors.Is(err, ErrBadRequest) {
 		t.Fatalf("expected ErrBadRequest for near-future scheduled_at, got %v", err)
 	}
 
@@ -480,7 +481,7 @@ func TestScheduledOrderValidation(t *testing.T) {
 		ScheduledAt:        time.Now().Add(2 * time.Hour),
 		ScheduleWindowMins: 0,
 	})
-	if err != ErrBadRequest {
+	if !errors.Is(err, ErrBadRequest) {
 		t.Fatalf("expected ErrBadRequest for zero schedule_window_mins, got %v", err)
 	}
 
@@ -492,7 +493,7 @@ func TestScheduledOrderValidation(t *testing.T) {
 		ScheduledAt:        time.Now().Add(2 * time.Hour),
 		ScheduleWindowMins: 30,
 	})
-	if err != ErrBadRequest {
+	if !errors.Is(err, ErrBadRequest) {
 		t.Fatalf("expected ErrBadRequest for missing ride_type, got %v", err)
 	}
 }
@@ -521,7 +522,7 @@ func TestScheduledOrderActiveConflict(t *testing.T) {
 		ScheduledAt:        time.Now().Add(3 * time.Hour),
 		ScheduleWindowMins: 30,
 	})
-	if err != ErrActiveOrder {
+	if !errors.Is(err, ErrActiveOrder) {
 		t.Fatalf("expected ErrActiveOrder for duplicate scheduled order, got %v", err)
 	}
 
@@ -532,7 +533,7 @@ func TestScheduledOrderActiveConflict(t *testing.T) {
 		Dropoff:     types.Point{Lat: 25.0478, Lng: 121.5318},
 		RideType:    "economy",
 	})
-	if err != ErrActiveOrder {
+	if !errors.Is(err, ErrActiveOrder) {
 		t.Fatalf("expected ErrActiveOrder for instant order while scheduled is active, got %v", err)
 	}
 }
@@ -622,7 +623,7 @@ func TestConcurrentClaimScheduled(t *testing.T) {
 			success++
 			continue
 		}
-		if err != ErrConflict && err != ErrInvalidState {
+		if !errors.Is(err, ErrConflict) && !errors.Is(err, ErrInvalidState) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	}
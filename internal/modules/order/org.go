@@ -0,0 +1,33 @@
+// README: Business rides: orders billed to an organization are checked against its ride policy at creation.
+package order
+
+import (
+	"context"
+	"time"
+
+	"ark/internal/types"
+)
+
+// OrgPolicy decides whether a passenger may bill a ride to an organization.
+type OrgPolicy interface {
+	// CheckRide returns ErrPolicyDenied when the passenger is not an active
+	// member of orgID or the ride at pickupAt costing fare breaks its policy.
+	// pickupAt is in the pickup region's time zone.
+	CheckRide(ctx context.Context, orgID, passengerID types.ID, pickupAt time.Time, fare types.Money) error
+}
+
+// SetOrgPolicy enables business rides. Without it orders carrying an org ID
+// are rejected.
+func (s *Service) SetOrgPolicy(p OrgPolicy) {
+	s.orgPolicy = p
+}
+
+func (s *Service) checkOrgPolicy(ctx context.Context, orgID *types.ID, passengerID types.ID, regionID string, pickupAt time.Time, fare types.Money) error {
+	if orgID == nil {
+		return nil
+	}
+	if *orgID == "" || s.orgPolicy == nil {
+		return ErrBadRequest
+	}
+	return s.orgPolicy.CheckRide(ctx, *orgID, passengerID, s.localTime(regionID, pickupAt), fare)
+}
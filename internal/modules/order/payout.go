@@ -0,0 +1,67 @@
+// README: Driver payout estimates on scheduled orders offered to drivers — fare share plus incentive bonus minus commission.
+package order
+
+import (
+	"context"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// PayoutEstimate is what a driver would be paid for an order at its current
+// fare and incentive bonus, all in minor units of Currency.
+type PayoutEstimate struct {
+	Fare          int64
+	Commission    int64
+	CommissionBps int
+	Incentive     int64
+	// Net is Fare - Commission + Incentive, the amount credited on completion.
+	Net      int64
+	Currency string
+}
+
+// PayoutEstimator estimates a driver's payout for an order with the same split
+// used when the trip settles. Implemented by the earnings module.
+type PayoutEstimator interface {
+	EstimatePayout(ctx context.Context, driverID types.ID, o *Order) (PayoutEstimate, error)
+}
+
+// SetPayoutEstimator makes ListAvailableForDriver quote each order's payout.
+func (s *Service) SetPayoutEstimator(e PayoutEstimator) {
+	s.payouts = e
+}
+
+// AvailableOrder is an open scheduled order with the browsing driver's
+// estimated payout; EstimatedPayout is nil when no estimate could be made.
+type AvailableOrder struct {
+	*Order
+	EstimatedPayout *PayoutEstimate
+}
+
+// ListAvailableForDriver returns ListAvailableScheduled with driverID's
+// estimated payout on each order. An order that cannot be estimated is still
+// listed, without a payout.
+func (s *Service) ListAvailableForDriver(ctx context.Context, driverID types.ID, from, to time.Time) ([]AvailableOrder, error) {
+	if driverID == "" {
+		return nil, ErrBadRequest
+	}
+	orders, err := s.ListAvailableScheduled(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AvailableOrder, len(orders))
+	for i, o := range orders {
+		out[i] = AvailableOrder{Order: o}
+		if s.payouts == nil {
+			continue
+		}
+		p, err := s.payouts.EstimatePayout(ctx, driverID, o)
+		if err != nil {
+			errreport.Report(ctx, "order", "estimate_payout", err, "order_id", o.ID, "driver_id", driverID)
+			continue
+		}
+		out[i].EstimatedPayout = &p
+	}
+	return out, nil
+}
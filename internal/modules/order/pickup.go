@@ -0,0 +1,100 @@
+// README: "Fastest pickup" booking mode — quotes the best nearby driver's ETA before creating an instant order.
+package order
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// ErrPickupTooFar is returned by CreateFastest when no driver can reach the
+// pickup within the configured ETA; the caller should suggest a scheduled order.
+var ErrPickupTooFar = errors.New("no driver can reach the pickup soon; consider a scheduled order")
+
+// ErrPickupUnavailable is wrapped by a PickupEstimator that cannot read driver
+// positions, e.g. while Redis is down.
+var ErrPickupUnavailable = errors.New("pickup ETA unavailable")
+
+// PickupEstimator returns the pickup ETA of the best nearby driver able to
+// serve requirements; ok is false when no driver is available. Implemented by
+// the matching module.
+type PickupEstimator interface {
+	EstimatePickup(ctx context.Context, pickup types.Point, requirements []string) (eta time.Duration, ok bool, err error)
+}
+
+// PickupQuote is the ETA quoted to the passenger for a fastest-pickup booking.
+type PickupQuote struct {
+	ETA              time.Duration
+	DriverAvailable  bool
+	SuggestScheduled bool
+	// Queued is set when no ETA could be quoted and the order was booked to
+	// wait for dispatch anyway.
+	Queued bool
+}
+
+// SetPickupEstimator enables CreateFastest. Quotes above maxETA (or with no
+// driver available) are rejected with ErrPickupTooFar.
+func (s *Service) SetPickupEstimator(e PickupEstimator, maxETA time.Duration) {
+	s.pickup = e
+	s.maxPickupETA = maxETA
+}
+
+// SetWAVPickupWindow allows wheelchair rides pickup ETAs up to maxETA, as WAV
+// drivers are fewer and farther between; 0 holds them to the normal threshold.
+func (s *Service) SetWAVPickupWindow(maxETA time.Duration) {
+	s.maxWAVPickupETA = maxETA
+}
+
+// QuotePickup asks the estimator for the best pickup ETA at the given point
+// among drivers able to serve requirements.
+func (s *Service) QuotePickup(ctx context.Context, pickup types.Point, requirements []string) (*PickupQuote, error) {
+	if s.pickup == nil {
+		return nil, ErrBadRequest
+	}
+	eta, ok, err := s.pickup.EstimatePickup(ctx, pickup, requirements)
+	if err != nil {
+		return nil, err
+	}
+	maxETA := s.maxPickupETA
+	if s.maxWAVPickupETA > 0 && slices.Contains(requirements, RequireWheelchair) {
+		maxETA = max(maxETA, s.maxWAVPickupETA)
+	}
+	q := &PickupQuote{ETA: eta, DriverAvailable: ok}
+	q.SuggestScheduled = !ok || (maxETA > 0 && eta > maxETA)
+	return q, nil
+}
+
+// CreateFastest quotes the pickup ETA and creates the instant order only when
+// a driver can arrive within the threshold. Otherwise it returns the quote with
+// ErrPickupTooFar so the client can offer a scheduled order instead. When
+// driver positions are unavailable the order is booked unquoted and waits in
+// StatusWaiting until dispatch can reach drivers again.
+func (s *Service) CreateFastest(ctx context.Context, cmd CreateCommand) (types.ID, *PickupQuote, error) {
+	if cmd.PassengerID == "" || cmd.RideType == "" {
+		return "", nil, ErrBadRequest
+	}
+	requirements, err := partyRequirements(cmd.RideType, cmd.Requirements, cmd.PassengerCount, cmd.HasPet)
+	if err != nil {
+		return "", nil, err
+	}
+	q, err := s.QuotePickup(ctx, cmd.Pickup, requirements)
+	if errors.Is(err, ErrPickupUnavailable) {
+		errreport.Report(ctx, "order", "quote_pickup", err, "passenger_id", cmd.PassengerID)
+		q, err = &PickupQuote{Queued: true}, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if q.SuggestScheduled {
+		return "", q, ErrPickupTooFar
+	}
+	id, err := s.Create(ctx, cmd)
+	if err != nil {
+		return "", nil, err
+	}
+	return id, q, nil
+}
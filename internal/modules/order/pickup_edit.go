@@ -0,0 +1,104 @@
+// README: Pickup edits while waiting for a driver: the passenger moves the pickup under a soft lock that keeps matching from offering the order meanwhile.
+package order
+
+import (
+	"context"
+	"errors"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// ErrDispatchInFlight means the order is being offered to drivers right now;
+// the pickup can be edited once the offer round is over.
+var ErrDispatchInFlight = errors.New("order is being offered to drivers")
+
+// DispatchLock coordinates pickup edits with the matcher. Implemented by
+// matching.Service.
+type DispatchLock interface {
+	// HoldDispatch keeps the matcher from offering orderID until release is
+	// called. It fails with ErrDispatchInFlight while an offer round for the
+	// order is running.
+	HoldDispatch(ctx context.Context, orderID types.ID) (release func(context.Context), err error)
+	// ResetDispatch forgets earlier offers of orderID so drivers near its new
+	// pickup are searched for on the next round.
+	ResetDispatch(ctx context.Context, orderID types.ID) error
+}
+
+// SetDispatchLock enables pickup edits. Without it they are rejected, since
+// an offer could go out with the old pickup mid-edit.
+func (s *Service) SetDispatchLock(l DispatchLock) {
+	s.dispatch = l
+}
+
+// ChangePickupCommand moves the pickup of an order still waiting for a driver.
+type ChangePickupCommand struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	Pickup      types.Point
+	// ExpectVersion, when set, must match the order's status_version.
+	ExpectVersion *int
+}
+
+// ChangePickup moves the pickup of a waiting order and re-prices it. The edit
+// holds the dispatch lock so no offer is sent while it is applied, and bumps
+// status_version so a driver accepting an offer for the old pickup gets
+// ErrConflict. Earlier offers are then forgotten and matching starts over from
+// the new pickup.
+func (s *Service) ChangePickup(ctx context.Context, cmd ChangePickupCommand) (*Order, error) {
+	if cmd.OrderID == "" || !validPoint(cmd.Pickup) {
+		return nil, ErrBadRequest
+	}
+	if s.dispatch == nil {
+		return nil, ErrInvalidState
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.PassengerID != cmd.PassengerID {
+		return nil, ErrActorNotAllowed
+	}
+	if o.Status != StatusWaiting {
+		return nil, invalidState(o)
+	}
+	if cmd.ExpectVersion != nil && *cmd.ExpectVersion != o.StatusVersion {
+		return nil, conflict(o)
+	}
+	regionID, err := s.regionAt(cmd.Pickup)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.dispatch.HoldDispatch(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer release(context.WithoutCancel(ctx))
+
+	now := s.now()
+	q := s.quote(ctx, cmd.Pickup, o.Dropoff, o.RideType, weightClassOf(o.Delivery), o.CharterHours, regionID, now)
+	if err := s.checkOrgPolicy(ctx, o.OrgID, o.PassengerID, regionID, now, q.Fare); err != nil {
+		return nil, err
+	}
+	ok, err := s.store.UpdatePickup(ctx, o.ID, o.StatusVersion, cmd.Pickup, regionID, q)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, conflict(o)
+	}
+	if err := s.dispatch.ResetDispatch(ctx, o.ID); err != nil {
+		// The order is offered again once its cooldown runs out.
+		errreport.Report(ctx, "order", "reset_dispatch", err, "order_id", o.ID)
+	}
+	s.watchers.notify(o.ID)
+
+	o.Pickup = cmd.Pickup
+	o.RegionID = regionID
+	o.EstimatedFee = q.Fare
+	o.PricingVersion = q.RuleVersion
+	o.ReturnAllowance = q.ReturnAllowance
+	o.StatusVersion++
+	return o, nil
+}
@@ -0,0 +1,27 @@
+// README: Priority passengers: orders snapshot the passenger's priority class, which matching uses to dispatch them sooner.
+package order
+
+import (
+	"context"
+
+	"ark/internal/types"
+)
+
+// PassengerPriority returns a passenger's priority class; "" is a normal
+// passenger. Implemented by user.Service.
+type PassengerPriority interface {
+	Priority(ctx context.Context, passengerID types.ID) (string, error)
+}
+
+// SetPassengerPriority tags new orders with their passenger's priority class.
+// Without it every order is a normal one.
+func (s *Service) SetPassengerPriority(p PassengerPriority) {
+	s.priority = p
+}
+
+func (s *Service) passengerPriority(ctx context.Context, passengerID types.ID) (string, error) {
+	if s.priority == nil {
+		return "", nil
+	}
+	return s.priority.Priority(ctx, passengerID)
+}
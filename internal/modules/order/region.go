@@ -0,0 +1,66 @@
+// README: Service regions: new orders are tagged with the region their pickup lies in, which selects its pricing, matching and local time.
+package order
+
+import (
+	"time"
+
+	"ark/internal/types"
+)
+
+// Regions finds the service region of a point. Implemented by region.Service.
+type Regions interface {
+	// RegionAt returns the ID of the region serving p; ok is false when p
+	// lies outside every service area.
+	RegionAt(p types.Point) (id string, ok bool)
+	// Location returns the time zone of the region with id; "" is the
+	// default region.
+	Location(id string) *time.Location
+}
+
+// SetRegions tags new orders with their pickup's region and rejects pickups
+// outside the service area. Without it every order is in the default region.
+func (s *Service) SetRegions(r Regions) {
+	s.regions = r
+}
+
+func (s *Service) regionAt(pickup types.Point) (string, error) {
+	if s.regions == nil {
+		return "", nil
+	}
+	id, ok := s.regions.RegionAt(pickup)
+	if !ok {
+		return "", ErrOutsideServiceArea
+	}
+	return id, nil
+}
+
+// taipei is the time zone of orders when no regions are configured.
+var taipei = loadTaipei()
+
+func loadTaipei() *time.Location {
+	loc, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return loc
+}
+
+// localTime returns t on the wall clock of regionID. Orders store and compare
+// times in UTC; rules phrased in hours of the day (organization ride hours)
+// read them through this.
+func (s *Service) localTime(regionID string, t time.Time) time.Time {
+	if s.regions == nil {
+		return t.In(taipei)
+	}
+	return t.In(s.regions.Location(regionID))
+}
+
+func utcNow() time.Time { return time.Now().UTC() }
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
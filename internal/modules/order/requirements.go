@@ -0,0 +1,107 @@
+// README: Passenger notes and ride requirement flags; requirements double as the driver capability vocabulary.
+package order
+
+import (
+	"context"
+	"slices"
+	"unicode/utf8"
+
+	"ark/internal/types"
+)
+
+// Requirement flags a passenger can attach to an order. Drivers advertise the
+// same values as capabilities, and matching only offers an order to drivers
+// whose capabilities cover all of its requirements.
+const (
+	RequireWheelchair   = "wheelchair"
+	RequireChildSeat    = "child_seat"
+	RequireExtraLuggage = "extra_luggage"
+	RequirePetFriendly  = "pet_friendly"
+	RequireSixSeater    = "six_seater"
+)
+
+// Requirements lists every accepted requirement flag.
+var Requirements = []string{RequireWheelchair, RequireChildSeat, RequireExtraLuggage, RequirePetFriendly, RequireSixSeater, RequireParcel, RequireDesignated, RequireManual}
+
+// RideTypeWAV is the wheelchair-accessible vehicle ride type. Its orders
+// always carry RequireWheelchair, so only certified WAV drivers serve them.
+const RideTypeWAV = "wav"
+
+// MaxPassengers is the largest party one order can carry (a six-seater).
+const MaxPassengers = 6
+
+// MaxNotesLen is the longest passenger note accepted, in characters.
+const MaxNotesLen = 500
+
+// NormalizeRequirements validates flags against Requirements and returns them
+// sorted and de-duplicated. Unknown flags yield ErrBadRequest.
+func NormalizeRequirements(flags []string) ([]string, error) {
+	out := make([]string, 0, len(flags))
+	for _, f := range flags {
+		if !slices.Contains(Requirements, f) {
+			return nil, ErrBadRequest
+		}
+		out = append(out, f)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// partyRequirements adds the vehicle requirements implied by the ride type and
+// the party: a WAV ride needs a wheelchair-accessible car, a delivery a driver
+// who carries parcels, a pet a pet-friendly car and five or six passengers a
+// six-seater. A designated drive needs a driver cleared to drive the party's
+// own car, whatever the party. passengerCount 0 means one passenger.
+func partyRequirements(rideType string, requirements []string, passengerCount int, hasPet bool) ([]string, error) {
+	if passengerCount < 0 || passengerCount > MaxPassengers {
+		return nil, ErrBadRequest
+	}
+	if rideType == RideTypeDesignated {
+		return NormalizeRequirements(append(requirements, RequireDesignated))
+	}
+	if rideType == RideTypeWAV {
+		requirements = append(requirements, RequireWheelchair)
+	}
+	if rideType == RideTypeDelivery {
+		requirements = append(requirements, RequireParcel)
+	}
+	if hasPet {
+		requirements = append(requirements, RequirePetFriendly)
+	}
+	if passengerCount >= 5 {
+		requirements = append(requirements, RequireSixSeater)
+	}
+	return NormalizeRequirements(requirements)
+}
+
+// DriverCapabilities reports the requirement flags a driver's vehicle can serve.
+type DriverCapabilities interface {
+	Capabilities(ctx context.Context, driverID types.ID) ([]string, error)
+}
+
+// SetDriverCapabilities makes driver assignment check the driver's vehicle
+// against the order's requirements. Without it any driver may be assigned.
+func (s *Service) SetDriverCapabilities(c DriverCapabilities) {
+	s.capabilities = c
+}
+
+// checkVehicle returns ErrVehicleMismatch when driverID cannot serve o.
+func (s *Service) checkVehicle(ctx context.Context, o *Order, driverID types.ID) error {
+	if s.capabilities == nil || len(o.Requirements) == 0 || o.Sandbox {
+		return nil
+	}
+	caps, err := s.capabilities.Capabilities(ctx, driverID)
+	if err != nil {
+		return err
+	}
+	for _, r := range o.Requirements {
+		if !slices.Contains(caps, r) {
+			return ErrVehicleMismatch
+		}
+	}
+	return nil
+}
+
+func validNotes(notes string) bool {
+	return utf8.RuneCountInString(notes) <= MaxNotesLen
+}
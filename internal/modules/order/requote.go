@@ -0,0 +1,222 @@
+// README: Scheduled-order re-quotes: when pricing rules change before pickup, lower fares apply and materially higher ones need the passenger's consent.
+package order
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// Re-quote stages reported to RequoteHooks.
+const (
+	RequoteLowered   = "lowered"   // a cheaper fare was applied without asking
+	RequoteRequested = "requested" // a higher fare awaits the passenger
+	RequoteAccepted  = "accepted"
+	RequoteDeclined  = "declined" // the passenger declined and the ride was cancelled
+)
+
+// RequoteProposal is a higher fare for a scheduled order awaiting the
+// passenger's consent. Until they answer the original quote stands.
+type RequoteProposal struct {
+	Fee         types.Money
+	RuleVersion int
+	RequestedAt time.Time
+}
+
+// Requote describes a committed re-quote step.
+type Requote struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	Stage       string
+	ScheduledAt time.Time
+	Fare        types.Money // fare before the re-quote
+	NewFare     types.Money
+	Sandbox     bool
+}
+
+// RequoteHook is called after each re-quote step has been persisted.
+type RequoteHook func(ctx context.Context, r Requote)
+
+// OnRequote registers h to run after every re-quote step.
+// Must be called before the service starts handling requests.
+func (s *Service) OnRequote(h RequoteHook) {
+	s.requoteHooks = append(s.requoteHooks, h)
+}
+
+// AnswerRequoteCommand is the passenger's answer to a re-quoted fare.
+type AnswerRequoteCommand struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	Accept      bool
+	// ExpectVersion, when set, must match the order's status_version.
+	ExpectVersion *int
+}
+
+// RequoteScheduled re-prices every upcoming scheduled or assigned order at its
+// pickup time and returns how many it changed or asked about. Orders still on
+// the rule version they were quoted under are skipped, as are changes smaller
+// than the configured threshold.
+func (s *Service) RequoteScheduled(ctx context.Context) (int, error) {
+	if s.pricing == nil {
+		return 0, nil
+	}
+	orders, err := s.store.ListRequoteCandidates(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, o := range orders {
+		changed, err := s.requote(ctx, o)
+		if err != nil {
+			errreport.Report(ctx, "order", "requote", err, "order_id", o.ID)
+			continue
+		}
+		if changed {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Service) requote(ctx context.Context, o *Order) (bool, error) {
+	if o.ScheduledAt == nil {
+		return false, nil
+	}
+	q, err := s.pricing.Quote(ctx, PricingRequest{
+		RideType:   o.RideType,
+		Pickup:     o.Pickup,
+		Dropoff:    o.Dropoff,
+		DistanceKm: distanceKm(o.Pickup, o.Dropoff),
+		At:         *o.ScheduledAt,
+		RegionID:   o.RegionID,
+	})
+	if err != nil {
+		return false, err
+	}
+	if q.RuleVersion == o.PricingVersion {
+		return false, nil
+	}
+	diff, err := q.Fare.Sub(o.EstimatedFee)
+	if err != nil {
+		return false, err
+	}
+	if diff.Amount == 0 || abs(diff.Amount)*10000 < o.EstimatedFee.Amount*int64(s.sched.RequoteThresholdBps) {
+		return false, nil
+	}
+
+	stage := RequoteRequested
+	var ok bool
+	if diff.Amount < 0 {
+		stage = RequoteLowered
+		ok, err = s.store.UpdateQuote(ctx, o.ID, o.StatusVersion, q)
+	} else {
+		ok, err = s.store.ProposeRequote(ctx, o.ID, o.StatusVersion, q, s.now())
+	}
+	if err != nil || !ok {
+		// A concurrent change wins; the order is re-checked next tick.
+		return false, err
+	}
+	s.watchers.notify(o.ID)
+	s.runRequoteHooks(ctx, Requote{
+		OrderID:     o.ID,
+		PassengerID: o.PassengerID,
+		Stage:       stage,
+		ScheduledAt: *o.ScheduledAt,
+		Fare:        o.EstimatedFee,
+		NewFare:     q.Fare,
+		Sandbox:     o.Sandbox,
+	})
+	return true, nil
+}
+
+// AnswerRequote applies (accept) a pending re-quote, or cancels the ride when
+// the passenger declines the higher fare.
+func (s *Service) AnswerRequote(ctx context.Context, cmd AnswerRequoteCommand) error {
+	if cmd.OrderID == "" || cmd.PassengerID == "" {
+		return ErrBadRequest
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return err
+	}
+	if o.PassengerID != cmd.PassengerID {
+		return ErrActorNotAllowed
+	}
+	if (o.Status != StatusScheduled && o.Status != StatusAssigned) || o.PendingRequote == nil {
+		return invalidState(o)
+	}
+	if cmd.ExpectVersion != nil && *cmd.ExpectVersion != o.StatusVersion {
+		return conflict(o)
+	}
+	p := o.PendingRequote
+	r := Requote{
+		OrderID:     o.ID,
+		PassengerID: o.PassengerID,
+		Stage:       RequoteAccepted,
+		ScheduledAt: *o.ScheduledAt,
+		Fare:        o.EstimatedFee,
+		NewFare:     p.Fee,
+		Sandbox:     o.Sandbox,
+	}
+	if !cmd.Accept {
+		if err := s.applyTransition(ctx, o.ID, transitionParams{
+			to:        StatusCancelled,
+			actorType: ActorPassenger,
+			actorID:   &cmd.PassengerID,
+		}); err != nil {
+			return err
+		}
+		r.Stage = RequoteDeclined
+		s.runRequoteHooks(ctx, r)
+		return nil
+	}
+	ok, err := s.store.UpdateQuote(ctx, o.ID, o.StatusVersion, Quote{Fare: p.Fee, RuleVersion: p.RuleVersion})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return conflict(o)
+	}
+	s.watchers.notify(o.ID)
+	s.runRequoteHooks(ctx, r)
+	return nil
+}
+
+// RunRequoteTicker periodically re-quotes upcoming scheduled orders.
+func (s *Service) RunRequoteTicker(ctx context.Context) {
+	ticker := time.NewTicker(s.sched.RequoteTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RequoteScheduled(ctx); err != nil {
+				errreport.Report(ctx, "order", "requote_scheduled", err)
+			}
+		}
+	}
+}
+
+func (s *Service) runRequoteHooks(ctx context.Context, r Requote) {
+	for _, h := range s.requoteHooks {
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					log.Printf("order: requote hook panicked for %s: %v", r.OrderID, rec)
+				}
+			}()
+			h(ctx, r)
+		}()
+	}
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -3,8 +3,11 @@ package order
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"ark/internal/errreport"
+	"ark/internal/sandbox"
 	"ark/internal/types"
 )
 
@@ -13,19 +16,6 @@ var (
 	ErrScheduleConflict = ErrConflict
 )
 
-const (
-	// driverCancelBonusIncrement is added to incentive_bonus when a driver cancels a claimed order.
-	driverCancelBonusIncrement int64 = 50
-	// incentiveTickerBump is the amount added to incentive_bonus each tick for approaching orders.
-	incentiveTickerBump int64 = 25
-	// incentiveTickerInterval controls how often the incentive ticker fires.
-	incentiveTickerInterval = 5 * time.Minute
-	// expireTickerInterval controls how often the expiry ticker fires.
-	expireTickerInterval = 1 * time.Minute
-	// expireTickerInterval controls how often the expiry ticker fires.
-	minimumScheduleLeadTime = 30 * time.Minute
-)
-
 // CreateScheduledCommand holds the fields required to create a scheduled order.
 type CreateScheduledCommand struct {
 	PassengerID        types.ID
@@ -34,6 +24,16 @@ type CreateScheduledCommand struct {
 	RideType           string
 	ScheduledAt        time.Time
 	ScheduleWindowMins int
+	Notes              string
+	Requirements       []string
+	PassengerCount     int
+	HasPet             bool
+	TransitType        string // "flight" or "train"; empty for ordinary pickups
+	TransitNumber      string
+	ArriveBy           *time.Time // must reach the dropoff by then; after ScheduledAt
+	OrgID              *types.ID  // bill the ride to this organization
+	ConversationID     string     // AI conversation that booked the ride
+	Rebook             bool       // confirms repeating a ride cancelled moments ago
 }
 
 // ClaimScheduledCommand is used by a driver to claim (accept) a scheduled order.
@@ -64,20 +64,38 @@ type ListScheduledByPassengerCommand struct {
 // CreateScheduled validates and persists a new scheduled order.
 //
 // Business rules:
-//   - scheduled_at must be at least 30 minutes in the future.
+//   - scheduled_at must be at least MinLeadTime (default 30 minutes) in the future.
 //   - cancel_deadline_at is computed as scheduled_at minus schedule_window_mins.
 //   - A passenger may not have another active (including scheduled) order at creation time.
 func (s *Service) CreateScheduled(ctx context.Context, cmd CreateScheduledCommand) (types.ID, error) {
-	if cmd.PassengerID == "" || cmd.RideType == "" {
+	if cmd.PassengerID == "" || cmd.RideType == "" || !validNotes(cmd.Notes) {
+		return "", ErrBadRequest
+	}
+	// Deliveries, designated drives and charters are instant only.
+	if cmd.ScheduleWindowMins <= 0 || cmd.RideType == RideTypeDelivery || cmd.RideType == RideTypeDesignated || cmd.RideType == RideTypeCharter {
 		return "", ErrBadRequest
 	}
-	if cmd.ScheduleWindowMins <= 0 {
+	requirements, err := partyRequirements(cmd.RideType, cmd.Requirements, cmd.PassengerCount, cmd.HasPet)
+	if err != nil {
+		return "", err
+	}
+	transitType, transitNumber, err := normalizeTransit(cmd.TransitType, cmd.TransitNumber)
+	if err != nil {
+		return "", err
+	}
+	// Times are compared and stored in UTC whatever offset the client sent.
+	now := s.now()
+	scheduledAt := cmd.ScheduledAt.UTC()
+	if scheduledAt.Before(now.Add(s.sched.MinLeadTime)) {
 		return "", ErrBadRequest
 	}
-	now := time.Now()
-	if cmd.ScheduledAt.Before(now.Add(minimumScheduleLeadTime)) {
+	arriveBy := utcPtr(cmd.ArriveBy)
+	if arriveBy != nil && !arriveBy.After(scheduledAt) {
 		return "", ErrBadRequest
 	}
+	if err := s.checkDuplicate(ctx, cmd.PassengerID, cmd.Pickup, cmd.Dropoff, scheduledAt, cmd.Rebook); err != nil {
+		return "", err
+	}
 
 	active, err := s.store.HasActiveByPassenger(ctx, cmd.PassengerID)
 	if err != nil {
@@ -86,17 +104,33 @@ func (s *Service) CreateScheduled(ctx context.Context, cmd CreateScheduledComman
 	if active {
 		return "", ErrActiveOrder
 	}
+	if err := s.checkCreateThrottle(ctx, cmd.PassengerID); err != nil {
+		return "", err
+	}
 
-	id := newID()
-	est := types.Money{Amount: 0, Currency: "TWD"}
-	if s.pricing != nil {
-		if m, err := s.pricing.Estimate(ctx, distanceKm(cmd.Pickup, cmd.Dropoff), cmd.RideType); err == nil {
-			est = m
-		}
+	regionID, err := s.regionAt(cmd.Pickup)
+	if err != nil {
+		return "", err
+	}
+
+	priority, err := s.passengerPriority(ctx, cmd.PassengerID)
+	if err != nil {
+		return "", err
 	}
 
-	cancelDeadlineAt := cmd.ScheduledAt.Add(-time.Duration(cmd.ScheduleWindowMins) * time.Minute)
+	id := types.NewID()
+	q := s.quote(ctx, cmd.Pickup, cmd.Dropoff, cmd.RideType, "", 0, regionID, scheduledAt)
+
+	if err := s.checkOrgPolicy(ctx, cmd.OrgID, cmd.PassengerID, regionID, scheduledAt, q.Fare); err != nil {
+		return "", err
+	}
+
+	cancelDeadlineAt := scheduledAt.Add(-time.Duration(cmd.ScheduleWindowMins) * time.Minute)
 	windowMins := cmd.ScheduleWindowMins
+	var arrival *ArrivalWindow
+	if arriveBy != nil {
+		arrival = &ArrivalWindow{From: arriveBy.Add(-s.sched.ArrivalWindow), To: *arriveBy}
+	}
 
 	o := &Order{
 		ID:                 id,
@@ -106,18 +140,33 @@ func (s *Service) CreateScheduled(ctx context.Context, cmd CreateScheduledComman
 		Pickup:             cmd.Pickup,
 		Dropoff:            cmd.Dropoff,
 		RideType:           cmd.RideType,
-		EstimatedFee:       est,
+		EstimatedFee:       q.Fare,
+		PricingVersion:     q.RuleVersion,
 		OrderType:          "scheduled",
-		ScheduledAt:        &cmd.ScheduledAt,
+		ScheduledAt:        &scheduledAt,
 		ScheduleWindowMins: &windowMins,
 		CancelDeadlineAt:   &cancelDeadlineAt,
 		IncentiveBonus:     0,
 		CreatedAt:          now,
+		Sandbox:            sandbox.Enabled(ctx),
+		Notes:              cmd.Notes,
+		Requirements:       requirements,
+		PassengerCount:     max(cmd.PassengerCount, 1),
+		HasPet:             cmd.HasPet,
+		TransitType:        transitType,
+		TransitNumber:      transitNumber,
+		ArriveBy:           arriveBy,
+		ArrivalWindow:      arrival,
+		OrgID:              cmd.OrgID,
+		RegionID:           regionID,
+		Priority:           priority,
+		ConversationID:     cmd.ConversationID,
 	}
 	if err := s.store.CreateScheduled(ctx, o); err != nil {
 		return "", err
 	}
-	_ = s.store.AppendEvent(ctx, &Event{
+	s.recordCreate(ctx, cmd.PassengerID)
+	s.appendEvent(ctx, &Event{
 		OrderID:    id,
 		FromStatus: StatusNone,
 		ToStatus:   StatusScheduled,
@@ -125,6 +174,15 @@ func (s *Service) CreateScheduled(ctx context.Context, cmd CreateScheduledComman
 		ActorID:    &cmd.PassengerID,
 		CreatedAt:  now,
 	})
+	s.runHooks(ctx, Transition{
+		OrderID:     id,
+		PassengerID: cmd.PassengerID,
+		From:        StatusNone,
+		To:          StatusScheduled,
+		ActorType:   "passenger",
+		At:          now,
+		Sandbox:     o.Sandbox,
+	})
 	return id, nil
 }
 
@@ -137,9 +195,31 @@ func (s *Service) ListScheduledByPassenger(ctx context.Context, passengerID type
 }
 
 // ListAvailableScheduled returns all open scheduled orders within the given time window,
-// suitable for drivers browsing available work.
+// suitable for drivers browsing available work. Orders whose pickup is outside
+// the claim window are left out.
 func (s *Service) ListAvailableScheduled(ctx context.Context, from, to time.Time) ([]*Order, error) {
-	return s.store.ListAvailableScheduled(ctx, from, to)
+	// Orders outside the claim window would only be refused on claim.
+	opens, closes := s.claimWindow(s.now())
+	if from.Before(closes) {
+		from = closes
+	}
+	if !opens.IsZero() && to.After(opens) {
+		to = opens
+	}
+	if to.Before(from) {
+		return nil, nil
+	}
+	return s.store.ListAvailableScheduled(ctx, from.UTC(), to.UTC())
+}
+
+// claimWindow returns the pickup times claimable at now: from closes until
+// opens. opens is zero when orders are claimable any time ahead.
+func (s *Service) claimWindow(now time.Time) (opens, closes time.Time) {
+	closes = now.Add(s.sched.ClaimClosesBefore)
+	if s.sched.ClaimOpensBefore > 0 {
+		opens = now.Add(s.sched.ClaimOpensBefore)
+	}
+	return opens, closes
 }
 
 // ClaimScheduled allows a driver to claim a scheduled order (StatusScheduled → StatusAssigned).
@@ -153,17 +233,24 @@ func (s *Service) ClaimScheduled(ctx context.Context, cmd ClaimScheduledCommand)
 		return err
 	}
 	if o.Status != StatusScheduled {
-		return ErrInvalidState
+		return invalidState(o)
+	}
+	if opens, closes := s.claimWindow(s.now()); o.ScheduledAt == nil ||
+		o.ScheduledAt.Before(closes) || (!opens.IsZero() && o.ScheduledAt.After(opens)) {
+		return ErrOutsideClaimWindow
+	}
+	if err := s.checkVehicle(ctx, o, cmd.DriverID); err != nil {
+		return err
 	}
 	ok, err := s.store.ClaimScheduled(ctx, cmd.OrderID, cmd.DriverID, o.StatusVersion)
 	if err != nil {
 		return err
 	}
 	if !ok {
-		return ErrConflict
+		return conflict(o)
 	}
-	now := time.Now()
-	_ = s.store.AppendEvent(ctx, &Event{
+	now := s.now()
+	s.appendEvent(ctx, &Event{
 		OrderID:    cmd.OrderID,
 		FromStatus: StatusScheduled,
 		ToStatus:   StatusAssigned,
@@ -171,20 +258,48 @@ func (s *Service) ClaimScheduled(ctx context.Context, cmd ClaimScheduledCommand)
 		ActorID:    &cmd.DriverID,
 		CreatedAt:  now,
 	})
+	s.runHooks(ctx, Transition{
+		OrderID:     cmd.OrderID,
+		PassengerID: o.PassengerID,
+		DriverID:    &cmd.DriverID,
+		From:        StatusScheduled,
+		To:          StatusAssigned,
+		ActorType:   "driver",
+		At:          now,
+		Sandbox:     o.Sandbox,
+	})
 	return nil
 }
 
 // CancelScheduledByPassenger cancels a scheduled (or assigned) order on behalf of the passenger.
 // If the current time is past cancel_deadline_at, the cancellation is still accepted for MVP
-// (fee enforcement can be added later).
+// (fee enforcement can be added later). It counts toward the passenger's daily
+// cancellation limit.
 func (s *Service) CancelScheduledByPassenger(ctx context.Context, cmd CancelScheduledCommand) error {
 	if cmd.OrderID == "" {
 		return ErrBadRequest
 	}
-	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
+	var passengerID types.ID
+	if s.throttle != nil {
+		o, err := s.store.Get(ctx, cmd.OrderID)
+		if err != nil {
+			return err
+		}
+		passengerID = o.PassengerID
+		if err := s.checkCancelThrottle(ctx, passengerID); err != nil {
+			return err
+		}
+	}
+	if err := s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusCancelled,
-		actorType: "passenger",
-	})
+		actorType: ActorPassenger,
+	}); err != nil {
+		return err
+	}
+	if passengerID != "" {
+		s.recordCancel(ctx, passengerID)
+	}
+	return nil
 }
 
 // CancelScheduledByDriver re-opens a claimed scheduled order (StatusAssigned → StatusScheduled),
@@ -198,17 +313,17 @@ func (s *Service) CancelScheduledByDriver(ctx context.Context, cmd DriverCancelS
 		return err
 	}
 	if o.Status != StatusAssigned {
-		return ErrInvalidState
+		return invalidState(o)
 	}
-	ok, err := s.store.ReopenScheduled(ctx, cmd.OrderID, o.StatusVersion, driverCancelBonusIncrement)
+	ok, err := s.store.ReopenScheduled(ctx, cmd.OrderID, o.StatusVersion, s.sched.DriverCancelBonus)
 	if err != nil {
 		return err
 	}
 	if !ok {
-		return ErrConflict
+		return conflict(o)
 	}
-	now := time.Now()
-	_ = s.store.AppendEvent(ctx, &Event{
+	now := s.now()
+	s.appendEvent(ctx, &Event{
 		OrderID:    cmd.OrderID,
 		FromStatus: StatusAssigned,
 		ToStatus:   StatusScheduled,
@@ -216,35 +331,90 @@ func (s *Service) CancelScheduledByDriver(ctx context.Context, cmd DriverCancelS
 		ActorID:    &cmd.DriverID,
 		CreatedAt:  now,
 	})
+	s.runHooks(ctx, Transition{
+		OrderID:     cmd.OrderID,
+		PassengerID: o.PassengerID,
+		From:        StatusAssigned,
+		To:          StatusScheduled,
+		ActorType:   "driver",
+		At:          now,
+		Sandbox:     o.Sandbox,
+	})
 	return nil
 }
 
 // RunScheduleIncentiveTicker periodically increases the incentive_bonus for scheduled orders
 // that are within the schedule_window_mins window but have not yet been claimed.
 func (s *Service) RunScheduleIncentiveTicker(ctx context.Context) {
-	ticker := time.NewTicker(incentiveTickerInterval)
+	ticker := time.NewTicker(s.sched.IncentiveTick)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			_ = s.store.BumpIncentiveBonusForApproaching(ctx, incentiveTickerBump)
+			if err := s.store.BumpIncentiveBonusForApproaching(ctx, s.sched.IncentiveBump); err != nil {
+				errreport.Report(ctx, "order", "bump_incentive_bonus", err)
+			}
 		}
 	}
 }
 
 // RunScheduleExpireTicker periodically expires scheduled orders whose scheduled_at time
-// has passed the end of their schedule_window_mins without being claimed.
+// has passed the end of their schedule_window_mins without being claimed, and
+// hands the ones left unclaimed when claims close to instant dispatch.
 func (s *Service) RunScheduleExpireTicker(ctx context.Context) {
-	ticker := time.NewTicker(expireTickerInterval)
+	ticker := time.NewTicker(s.sched.ExpireTick)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			_ = s.store.ExpireOverdueScheduled(ctx)
+			if err := s.store.ExpireOverdueScheduled(ctx); err != nil {
+				errreport.Report(ctx, "order", "expire_overdue_scheduled", err)
+			}
+			if _, err := s.DispatchUnclaimedScheduled(ctx); err != nil {
+				errreport.Report(ctx, "order", "dispatch_unclaimed_scheduled", err)
+			}
+		}
+	}
+}
+
+// DispatchedUnclaimed reports whether o is a scheduled order that nobody
+// claimed and that now waits for a driver like an instant order.
+func (o *Order) DispatchedUnclaimed() bool {
+	return o.OrderType == "scheduled" && o.Status == StatusWaiting
+}
+
+// DispatchUnclaimedScheduled moves the scheduled orders nobody claimed before
+// claims closed into instant matching (StatusScheduled → StatusWaiting), where
+// they are offered to nearby drivers ahead of other orders. It returns how many
+// it moved. Without a closing time claims stay open until pickup and nothing
+// is moved.
+func (s *Service) DispatchUnclaimedScheduled(ctx context.Context) (int, error) {
+	if s.sched.ClaimClosesBefore <= 0 {
+		return 0, nil
+	}
+	_, closes := s.claimWindow(s.now())
+	orders, err := s.store.ListUnclaimedScheduled(ctx, closes.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, o := range orders {
+		err := s.applyTransition(ctx, o.ID, transitionParams{
+			to:        StatusWaiting,
+			actorType: ActorSystem,
+		})
+		if err != nil {
+			// Claimed or cancelled since it was listed.
+			if !errors.Is(err, ErrConflict) && !errors.Is(err, ErrInvalidState) {
+				errreport.Report(ctx, "order", "dispatch_unclaimed", err, "order_id", o.ID)
+			}
+			continue
 		}
+		n++
 	}
+	return n, nil
 }
@@ -3,26 +3,100 @@ package order
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"errors"
 	"math"
+	"slices"
 	"time"
 
+	"ark/internal/config"
+	"ark/internal/errreport"
+	"ark/internal/sandbox"
 	"ark/internal/types"
 )
 
 type Pricing interface {
-	Estimate(ctx context.Context, distanceKm float64, rideType string) (types.Money, error)
+	Quote(ctx context.Context, req PricingRequest) (Quote, error)
+}
+
+// PricingRequest is everything a fare may depend on.
+type PricingRequest struct {
+	RideType   string
+	Pickup     types.Point
+	Dropoff    types.Point
+	DistanceKm float64
+	// At is when the ride starts: now for instant rides, the pickup time for
+	// scheduled ones.
+	At time.Time
+	// WeightClass is the parcel's weight class on a delivery, "" for rides.
+	WeightClass string
+	// Hours is the booked length of a charter, 0 for other rides.
+	Hours int
+	// RegionID selects the region's rate set and local time; empty means the
+	// default region.
+	RegionID string
+}
+
+// Quote is a fare estimate and the version of the pricing rule that produced
+// it. RuleVersion 0 means no versioned rule applied. ReturnAllowance is the
+// part of Fare reimbursing a designated driver's trip back, 0 for other rides.
+type Quote struct {
+	Fare            types.Money
+	RuleVersion     int
+	ReturnAllowance int64
 }
 
 type Service struct {
 	store   OrderStore
 	pricing Pricing
+	sched   config.SchedulingConfig
+	hooks   []TransitionHook
+
+	watchers      statusWatchers
+	capabilities  DriverCapabilities
+	scheduleHooks []ScheduleHook
+	orgPolicy     OrgPolicy
+	credits       Credits
+	subscriptions Subscriptions
+
+	pickup          PickupEstimator
+	maxPickupETA    time.Duration
+	maxWAVPickupETA time.Duration
+
+	routes       RouteDistance
+	dropoffHooks []DropoffHook
+	requoteHooks []RequoteHook
+	payouts      PayoutEstimator
+	regions      Regions
+	dispatch     DispatchLock
+	priority     PassengerPriority
+	offers       Offers
+
+	cancelFees       CancellationPricing
+	cancelGrace      time.Duration
+	cancelCommission CancellationCommission
+	traces           DriverTraces
+
+	fixes            DriverFixes
+	tripRegions      TripCheckRegions
+	discrepancyHooks []LocationDiscrepancyHook
+
+	throttle       ThrottleCounter
+	throttleLimits config.OrderThrottleConfig
+
+	deliveryProof  DeliveryProof
+	charterPricing CharterPricing
+
+	now func() time.Time // always UTC; replaced in tests
 }
 
 func NewService(store OrderStore, pricing Pricing) *Service {
-	return &Service{store: store, pricing: pricing}
+	return &Service{store: store, pricing: pricing, sched: config.DefaultScheduling(), now: utcNow}
+}
+
+// ConfigureScheduling overrides the scheduled-order knobs (tick intervals, bonuses, lead time).
+// Must be called before the background tickers are started.
+func (s *Service) ConfigureScheduling(cfg config.SchedulingConfig) {
+	s.sched = cfg
 }
 
 var (
@@ -31,13 +105,46 @@ var (
 	ErrConflict     = errors.New("order state conflict")
 	ErrActiveOrder  = errors.New("passenger has active order")
 	ErrBadRequest   = errors.New("bad request")
+	// ErrActorNotAllowed means the edge exists but the caller's actor type may not perform it.
+	ErrActorNotAllowed = errors.New("actor not allowed for this transition")
+	// ErrVehicleMismatch means the driver's vehicle lacks a capability the order requires.
+	ErrVehicleMismatch = errors.New("driver vehicle does not meet order requirements")
+	// ErrPolicyDenied means the passenger's organization does not allow this business ride.
+	ErrPolicyDenied = errors.New("ride not allowed by organization policy")
+	// ErrOutsideServiceArea means the pickup lies in no service region.
+	ErrOutsideServiceArea = errors.New("pickup is outside the service area")
+	// ErrOutsideClaimWindow is returned when a scheduled order is claimed too
+	// far ahead of its pickup or too close to it.
+	ErrOutsideClaimWindow = errors.New("scheduled order is not claimable now")
 )
 
 type CreateCommand struct {
-	PassengerID types.ID
-	Pickup      types.Point
-	Dropoff     types.Point
-	RideType    string
+	PassengerID  types.ID
+	Pickup       types.Point
+	Dropoff      types.Point
+	RideType     string
+	Notes        string
+	Requirements []string
+	// PassengerCount (0 means 1) and HasPet add the matching vehicle requirements.
+	PassengerCount int
+	HasPet         bool
+	// OrgID bills the ride to the passenger's organization instead of the passenger.
+	OrgID *types.ID
+	// ConversationID links the order to the AI conversation that booked it.
+	ConversationID string
+	// Rebook confirms an order repeating a ride the passenger just cancelled,
+	// which is otherwise refused with a DuplicateError.
+	Rebook bool
+	// Delivery gives the recipient and weight class of a parcel; required
+	// for RideTypeDelivery and refused for other ride types.
+	Delivery *Delivery
+	// Car is the passenger's car a designated driver drives; required for
+	// RideTypeDesignated and refused for other ride types.
+	Car *Car
+	// CharterHours books a RideTypeCharter driver for 1 to MaxCharterHours
+	// hours; refused for other ride types. A charter's dropoff is where it is
+	// expected to end, in the pickup's region; none means back at the pickup.
+	CharterHours int
 }
 
 // DepartCommand is used by a driver to depart for the pickup after claiming a scheduled order
@@ -56,6 +163,12 @@ type MatchCommand struct {
 type AcceptCommand struct {
 	OrderID  types.ID
 	DriverID types.ID
+	// ExpectVersion, when set, is the status_version of the offer the driver
+	// saw; the accept fails with ErrConflict if the order changed since.
+	ExpectVersion *int
+	// OfferID, when set, is the dispatch offer accepted through; the accept
+	// fails with ErrOfferLapsed once it has expired or been withdrawn.
+	OfferID int64
 }
 
 type StartCommand struct {
@@ -78,6 +191,9 @@ type CancelCommand struct {
 	OrderID   types.ID
 	ActorType string
 	Reason    string
+	// AcceptFee is the most the passenger agreed to pay for cancelling; see
+	// CancelQuoted.
+	AcceptFee *int64
 }
 
 type DenyCommand struct {
@@ -96,10 +212,16 @@ type PayCommand struct {
 // --- State flow helpers (kept separate from service methods) ---
 
 type transitionParams struct {
-	to        Status
-	driverID  *types.ID
-	actorType string
-	actorID   *types.ID
+	to            Status
+	driverID      *types.ID
+	actorType     string
+	actorID       *types.ID
+	expectVersion *int
+	// delivered is set by Deliver, the only way a delivery reaches payment.
+	delivered bool
+	// cancelCharge is the passenger's cancellation fee, charged with the
+	// cancel; see CancelQuoted.
+	cancelCharge *CancelCharge
 }
 
 func (s *Service) applyTransition(ctx context.Context, orderID types.ID, p transitionParams) error {
@@ -108,24 +230,72 @@ func (s *Service) applyTransition(ctx context.Context, orderID types.ID, p trans
 		return err
 	}
 	if !CanTransition(o.Status, p.to) {
-		return ErrInvalidState
+		return &StateError{OrderID: o.ID, Status: o.Status, Version: o.StatusVersion, To: p.to, Err: ErrInvalidState}
+	}
+	if !CanActorTransition(o.Status, p.to, p.actorType) {
+		return ErrActorNotAllowed
+	}
+	if p.expectVersion != nil && *p.expectVersion != o.StatusVersion {
+		return conflict(o)
+	}
+	if p.to == StatusPayment && o.Delivery != nil && !p.delivered {
+		return ErrProofRequired
+	}
+	if p.driverID != nil && (p.to == StatusApproaching || p.to == StatusAssigned) {
+		if err := s.checkVehicle(ctx, o, *p.driverID); err != nil {
+			return err
+		}
+	}
+	var discrepancy *LocationDiscrepancy
+	if p.to == StatusDriving || p.to == StatusPayment {
+		if discrepancy, err = s.checkTripLocation(ctx, o, p.to); err != nil {
+			return err
+		}
+	}
+	var ok bool
+	if p.cancelCharge != nil {
+		ok, err = s.store.CancelCharged(ctx, o.ID, o.Status, o.StatusVersion, *p.cancelCharge)
+	} else {
+		ok, err = s.store.UpdateStatus(ctx, o.ID, o.Status, p.to, o.StatusVersion, p.driverID)
 	}
-	ok, err := s.store.UpdateStatus(ctx, o.ID, o.Status, p.to, o.StatusVersion, p.driverID)
 	if err != nil {
 		return err
 	}
 	if !ok {
-		return ErrConflict
+		return conflict(o)
+	}
+	if p.to == StatusPayment {
+		s.applyOvertime(ctx, o)
+		s.applySubscription(ctx, o)
+		s.applyCredits(ctx, o)
 	}
 	actorID := resolveActorID(o, p)
-	_ = s.store.AppendEvent(ctx, &Event{
+	now := s.now()
+	s.appendEvent(ctx, &Event{
 		OrderID:    o.ID,
 		FromStatus: o.Status,
 		ToStatus:   p.to,
 		ActorType:  p.actorType,
 		ActorID:    actorID,
-		CreatedAt:  time.Now(),
+		CreatedAt:  now,
+	})
+	driverID := o.DriverID
+	if p.driverID != nil {
+		driverID = p.driverID
+	}
+	s.runHooks(ctx, Transition{
+		OrderID:     o.ID,
+		PassengerID: o.PassengerID,
+		DriverID:    driverID,
+		From:        o.Status,
+		To:          p.to,
+		ActorType:   p.actorType,
+		At:          now,
+		Sandbox:     o.Sandbox,
 	})
+	if discrepancy != nil {
+		s.reportDiscrepancy(ctx, *discrepancy)
+	}
 	return nil
 }
 
@@ -134,9 +304,9 @@ func resolveActorID(o *Order, p transitionParams) *types.ID {
 		return p.actorID
 	}
 	switch p.actorType {
-	case "passenger":
+	case ActorPassenger:
 		return &o.PassengerID
-	case "driver":
+	case ActorDriver:
 		if p.driverID != nil {
 			return p.driverID
 		}
@@ -146,10 +316,36 @@ func resolveActorID(o *Order, p transitionParams) *types.ID {
 	}
 }
 
+// appendEvent records e in the order's history. The status change it
+// describes is already stored, so a failed append is reported, not returned.
+func (s *Service) appendEvent(ctx context.Context, e *Event) {
+	if err := s.store.AppendEvent(ctx, e); err != nil {
+		errreport.Report(ctx, "order", "append_event", err, "order_id", e.OrderID, "to_status", e.ToStatus)
+	}
+}
+
 func (s *Service) Create(ctx context.Context, cmd CreateCommand) (types.ID, error) {
-	if cmd.PassengerID == "" || cmd.RideType == "" {
+	if cmd.PassengerID == "" || cmd.RideType == "" || !validNotes(cmd.Notes) || !validCharterHours(cmd.RideType, cmd.CharterHours) {
 		return "", ErrBadRequest
 	}
+	if cmd.RideType == RideTypeCharter && cmd.Dropoff == (types.Point{}) {
+		cmd.Dropoff = cmd.Pickup
+	}
+	delivery, err := normalizeDelivery(cmd.RideType, cmd.Delivery)
+	if err != nil {
+		return "", err
+	}
+	car, err := normalizeCar(cmd.RideType, cmd.Car)
+	if err != nil {
+		return "", err
+	}
+	requirements, err := partyRequirements(cmd.RideType, slices.Concat(cmd.Requirements, carRequirements(car)), cmd.PassengerCount, cmd.HasPet)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkDuplicate(ctx, cmd.PassengerID, cmd.Pickup, cmd.Dropoff, s.now(), cmd.Rebook); err != nil {
+		return "", err
+	}
 	active, err := s.store.HasActiveByPassenger(ctx, cmd.PassengerID)
 	if err != nil {
 		return "", err
@@ -157,32 +353,63 @@ func (s *Service) Create(ctx context.Context, cmd CreateCommand) (types.ID, erro
 	if active {
 		return "", ErrActiveOrder
 	}
+	if err := s.checkCreateThrottle(ctx, cmd.PassengerID); err != nil {
+		return "", err
+	}
 
-	id := newID()
-	now := time.Now()
-	est := types.Money{Amount: 0, Currency: "TWD"}
-	if s.pricing != nil {
-		if m, err := s.pricing.Estimate(ctx, distanceKm(cmd.Pickup, cmd.Dropoff), cmd.RideType); err == nil {
-			est = m
+	regionID, err := s.regionAt(cmd.Pickup)
+	if err != nil {
+		return "", err
+	}
+	if cmd.RideType == RideTypeCharter {
+		if err := s.checkCharterRegion(regionID, cmd.Dropoff); err != nil {
+			return "", err
 		}
 	}
 
+	priority, err := s.passengerPriority(ctx, cmd.PassengerID)
+	if err != nil {
+		return "", err
+	}
+
+	id := types.NewID()
+	now := s.now()
+	q := s.quote(ctx, cmd.Pickup, cmd.Dropoff, cmd.RideType, weightClassOf(delivery), cmd.CharterHours, regionID, now)
+	if err := s.checkOrgPolicy(ctx, cmd.OrgID, cmd.PassengerID, regionID, now, q.Fare); err != nil {
+		return "", err
+	}
+
 	o := &Order{
-		ID:            id,
-		PassengerID:   cmd.PassengerID,
-		Status:        StatusWaiting,
-		StatusVersion: 0,
-		Pickup:        cmd.Pickup,
-		Dropoff:       cmd.Dropoff,
-		RideType:      cmd.RideType,
-		EstimatedFee:  est,
-		OrderType:     "instant",
-		CreatedAt:     now,
+		ID:              id,
+		PassengerID:     cmd.PassengerID,
+		Status:          StatusWaiting,
+		StatusVersion:   0,
+		Pickup:          cmd.Pickup,
+		Dropoff:         cmd.Dropoff,
+		RideType:        cmd.RideType,
+		EstimatedFee:    q.Fare,
+		PricingVersion:  q.RuleVersion,
+		OrderType:       "instant",
+		CreatedAt:       now,
+		Sandbox:         sandbox.Enabled(ctx),
+		Notes:           cmd.Notes,
+		Requirements:    requirements,
+		PassengerCount:  max(cmd.PassengerCount, 1),
+		HasPet:          cmd.HasPet,
+		OrgID:           cmd.OrgID,
+		RegionID:        regionID,
+		Priority:        priority,
+		ConversationID:  cmd.ConversationID,
+		Delivery:        delivery,
+		Car:             car,
+		ReturnAllowance: q.ReturnAllowance,
+		CharterHours:    cmd.CharterHours,
 	}
 	if err := s.store.Create(ctx, o); err != nil {
 		return "", err
 	}
-	_ = s.store.AppendEvent(ctx, &Event{
+	s.recordCreate(ctx, cmd.PassengerID)
+	s.appendEvent(ctx, &Event{
 		OrderID:    id,
 		FromStatus: StatusNone,
 		ToStatus:   StatusWaiting,
@@ -190,6 +417,15 @@ func (s *Service) Create(ctx context.Context, cmd CreateCommand) (types.ID, erro
 		ActorID:    &cmd.PassengerID,
 		CreatedAt:  now,
 	})
+	s.runHooks(ctx, Transition{
+		OrderID:     id,
+		PassengerID: cmd.PassengerID,
+		From:        StatusNone,
+		To:          StatusWaiting,
+		ActorType:   "passenger",
+		At:          now,
+		Sandbox:     o.Sandbox,
+	})
 	return id, nil
 }
 
@@ -197,15 +433,19 @@ func (s *Service) Match(ctx context.Context, cmd MatchCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusApproaching,
 		driverID:  &cmd.DriverID,
-		actorType: "system",
+		actorType: ActorSystem,
 	})
 }
 
 func (s *Service) Accept(ctx context.Context, cmd AcceptCommand) error {
+	if err := s.checkOffer(ctx, cmd); err != nil {
+		return err
+	}
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
-		to:        StatusApproaching,
-		driverID:  &cmd.DriverID,
-		actorType: "driver",
+		to:            StatusApproaching,
+		driverID:      &cmd.DriverID,
+		actorType:     ActorDriver,
+		expectVersion: cmd.ExpectVersion,
 	})
 }
 
@@ -215,7 +455,7 @@ func (s *Service) Depart(ctx context.Context, cmd DepartCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusApproaching,
 		driverID:  &cmd.DriverID,
-		actorType: "driver",
+		actorType: ActorDriver,
 	})
 }
 
@@ -226,25 +466,33 @@ func (s *Service) Start(ctx context.Context, cmd StartCommand) error {
 func (s *Service) Arrive(ctx context.Context, cmd ArriveCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusArrived,
-		actorType: "driver",
+		actorType: ActorDriver,
 	})
 }
 
 func (s *Service) Meet(ctx context.Context, cmd MeetCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusDriving,
-		actorType: "driver",
+		actorType: ActorDriver,
 	})
 }
 
+// Complete ends a ride at the dropoff. Deliveries end with Deliver instead.
 func (s *Service) Complete(ctx context.Context, cmd CompleteCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusPayment,
-		actorType: "driver",
+		actorType: ActorDriver,
 	})
 }
 
+// Cancel cancels the order. Passengers may owe a cancellation fee; see
+// CancelQuoted.
 func (s *Service) Cancel(ctx context.Context, cmd CancelCommand) error {
+	_, err := s.CancelQuoted(ctx, cmd)
+	return err
+}
+
+func (s *Service) cancel(ctx context.Context, cmd CancelCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusCancelled,
 		actorType: cmd.ActorType,
@@ -255,18 +503,33 @@ func (s *Service) Get(ctx context.Context, id types.ID) (*Order, error) {
 	return s.store.Get(ctx, id)
 }
 
+// DriverActiveStatuses are the statuses of orders a driver has claimed or is serving.
+var DriverActiveStatuses = []Status{StatusAssigned, StatusApproaching, StatusArrived, StatusDriving, StatusPayment}
+
+// ListByDriver returns the driver's orders in any of statuses, or in
+// DriverActiveStatuses when statuses is empty.
+func (s *Service) ListByDriver(ctx context.Context, driverID types.ID, statuses []Status) ([]*Order, error) {
+	if driverID == "" {
+		return nil, ErrBadRequest
+	}
+	if len(statuses) == 0 {
+		statuses = DriverActiveStatuses
+	}
+	return s.store.ListByDriver(ctx, driverID, statuses)
+}
+
 func (s *Service) Deny(ctx context.Context, cmd DenyCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusWaiting,
 		driverID:  &cmd.DriverID,
-		actorType: "driver",
+		actorType: ActorDriver,
 	})
 }
 
 func (s *Service) Pay(ctx context.Context, cmd PayCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusComplete,
-		actorType: "system",
+		actorType: ActorSystem,
 	})
 }
 
@@ -276,7 +539,7 @@ func (s *Service) Pay(ctx context.Context, cmd PayCommand) error {
 func (s *Service) Rematch(ctx context.Context, cmd RematchCommand) error {
 	return s.applyTransition(ctx, cmd.OrderID, transitionParams{
 		to:        StatusWaiting,
-		actorType: "system",
+		actorType: ActorSystem,
 	})
 }
 
@@ -302,10 +565,25 @@ func (s *Service) RunTimeoutMonitor(ctx context.Context) {
 	}
 }
 
-func newID() types.ID {
-	var b [16]byte
-	_, _ = rand.Read(b[:])
-	return types.ID(hex.EncodeToString(b[:]))
+// quote prices a new order. Without a pricing engine, or when it fails, the
+// order is created with a zero estimate in the platform currency.
+func (s *Service) quote(ctx context.Context, pickup, dropoff types.Point, rideType, weightClass string, hours int, regionID string, at time.Time) Quote {
+	if s.pricing != nil {
+		q, err := s.pricing.Quote(ctx, PricingRequest{
+			RideType:    rideType,
+			Pickup:      pickup,
+			Dropoff:     dropoff,
+			DistanceKm:  distanceKm(pickup, dropoff),
+			At:          at,
+			RegionID:    regionID,
+			WeightClass: weightClass,
+			Hours:       hours,
+		})
+		if err == nil {
+			return q
+		}
+	}
+	return Quote{Fare: types.Money{Currency: types.DefaultCurrency}}
 }
 
 func distanceKm(a, b types.Point) float64 {
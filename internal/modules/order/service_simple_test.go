@@ -45,6 +45,12 @@ func (m *MockPricing) Estimate(ctx context.Context, distanceKm float64, rideType
 	return types.Money{Currency: "USD", Amount: amount}, nil
 }
 
+// Quote implements Pricing; mock estimates carry no rule version.
+func (m *MockPricing) Quote(ctx context.Context, req PricingRequest) (Quote, error) {
+	fare, err := m.Estimate(ctx, req.DistanceKm, req.RideType)
+	return Quote{Fare: fare}, err
+}
+
 func TestService_NewService(t *testing.T) {
 	store := &Store{} // Use actual store
 	pricing := NewMockPricing()
@@ -66,8 +72,8 @@ func TestService_NewService(t *testing.T) {
 
 func TestService_newID(t *testing.T) {
 	// Test the package-level newID function
-	id1 := newID()
-	id2 := newID()
+	id1 := types.NewID()
+	id2 := types.NewID()
 
 	if id1 == "" {
 		t.Error("newID should not return empty string")
@@ -6,10 +6,16 @@ package order
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"ark/internal/config"
+	"ark/internal/modules/ledger"
 	"ark/internal/types"
 )
 
@@ -34,6 +40,9 @@ type mockOrderStore struct {
 	orders    map[types.ID]*Order
 	events    []*Event
 	appendErr error // if set, AppendEvent returns this error
+	pendingPricing map[types.ID]int
+	stops          []Stop
+	charges        []CancelCharge
 }
 
 func newMockStore() *mockOrderStore {
@@ -71,6 +80,10 @@ func (m *mockOrderStore) UpdateStatus(_ context.Context, id types.ID, from, to S
 	}
 	o.Status = to
 	o.StatusVersion++
+	if to == StatusCancelled {
+		now := time.Now().UTC()
+		o.CancelledAt = &now
+	}
 	if driverID != nil {
 		o.DriverID = driverID
 	}
@@ -102,6 +115,23 @@ func (m *mockOrderStore) HasActiveByPassenger(_ context.Context, passengerID typ
 	return false, nil
 }
 
+func (m *mockOrderStore) ListRebookCandidates(_ context.Context, passengerID types.ID, cancelledSince time.Time) ([]*Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*Order
+	for _, o := range m.orders {
+		if o.PassengerID != passengerID {
+			continue
+		}
+		if o.Status == StatusScheduled || o.Status == StatusAssigned ||
+			o.Status == StatusCancelled && o.CancelledAt != nil && !o.CancelledAt.Before(cancelledSince) {
+			cp := *o
+			result = append(result, &cp)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockOrderStore) CreateScheduled(_ context.Context, o *Order) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -170,6 +200,256 @@ func (m *mockOrderStore) ReopenScheduled(_ context.Context, orderID types.ID, ex
 	return true, nil
 }
 
+func (m *mockOrderStore) ListByDriver(_ context.Context, driverID types.ID, statuses []Status) ([]*Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Order
+	for _, o := range m.orders {
+		if o.DriverID == nil || *o.DriverID != driverID {
+			continue
+		}
+		for _, st := range statuses {
+			if o.Status == st {
+				cp := *o
+				out = append(out, &cp)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *mockOrderStore) UpdateSchedule(_ context.Context, orderID types.ID, expectVersion int, scheduledAt, cancelDeadlineAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[orderID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if (o.Status != StatusScheduled && o.Status != StatusAssigned) || o.StatusVersion != expectVersion {
+		return false, nil
+	}
+	o.ScheduledAt = &scheduledAt
+	o.CancelDeadlineAt = &cancelDeadlineAt
+	o.StatusVersion++
+	return true, nil
+}
+
+func (m *mockOrderStore) UpdatePickup(_ context.Context, orderID types.ID, expectVersion int, pickup types.Point, regionID string, fee Quote) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[orderID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if o.Status != StatusWaiting || o.StatusVersion != expectVersion {
+		return false, nil
+	}
+	o.Pickup = pickup
+	o.RegionID = regionID
+	o.EstimatedFee = fee.Fare
+	o.PricingVersion = fee.RuleVersion
+	o.StatusVersion++
+	return true, nil
+}
+
+func (m *mockOrderStore) ProposeDropoff(_ context.Context, orderID types.ID, expectVersion int, dropoff types.Point, fee Quote, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[orderID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if o.Status != StatusDriving || o.StatusVersion != expectVersion {
+		return false, nil
+	}
+	o.PendingDropoff = &DropoffProposal{Dropoff: dropoff, Fee: fee.Fare, RequestedAt: at}
+	if m.pendingPricing == nil {
+		m.pendingPricing = make(map[types.ID]int)
+	}
+	m.pendingPricing[orderID] = fee.RuleVersion
+	o.StatusVersion++
+	return true, nil
+}
+
+func (m *mockOrderStore) ResolveDropoff(_ context.Context, orderID types.ID, expectVersion int, accept bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[orderID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if o.Status != StatusDriving || o.StatusVersion != expectVersion || o.PendingDropoff == nil {
+		return false, nil
+	}
+	if accept {
+		if o.OriginalDropoff == nil {
+			orig := o.Dropoff
+			o.OriginalDropoff = &orig
+		}
+		o.Dropoff = o.PendingDropoff.Dropoff
+		o.EstimatedFee = o.PendingDropoff.Fee
+		o.PricingVersion = m.pendingPricing[orderID]
+	}
+	delete(m.pendingPricing, orderID)
+	o.PendingDropoff = nil
+	o.StatusVersion++
+	return true, nil
+}
+
+func (m *mockOrderStore) ListRequoteCandidates(_ context.Context, now time.Time) ([]*Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Order
+	for _, o := range m.orders {
+		if (o.Status == StatusScheduled || o.Status == StatusAssigned) && o.ScheduledAt != nil &&
+			o.ScheduledAt.After(now) && o.PendingRequote == nil && !o.Sandbox {
+			cp := *o
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockOrderStore) ProposeRequote(_ context.Context, orderID types.ID, expectVersion int, q Quote, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[orderID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if (o.Status != StatusScheduled && o.Status != StatusAssigned) || o.StatusVersion != expectVersion {
+		return false, nil
+	}
+	o.PendingRequote = &RequoteProposal{Fee: q.Fare, RuleVersion: q.RuleVersion, RequestedAt: at}
+	o.StatusVersion++
+	return true, nil
+}
+
+func (m *mockOrderStore) UpdateQuote(_ context.Context, orderID types.ID, expectVersion int, q Quote) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[orderID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if (o.Status != StatusScheduled && o.Status != StatusAssigned) || o.StatusVersion != expectVersion {
+		return false, nil
+	}
+	o.EstimatedFee = q.Fare
+	o.PricingVersion = q.RuleVersion
+	o.PendingRequote = nil
+	o.StatusVersion++
+	return true, nil
+}
+
+func (m *mockOrderStore) CancelCharged(ctx context.Context, id types.ID, from Status, version int, c CancelCharge) (bool, error) {
+	ok, err := m.UpdateStatus(ctx, id, from, StatusCancelled, version, nil)
+	if !ok || err != nil {
+		return ok, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders[id].CancelFee = c.Fee
+	m.charges = append(m.charges, c)
+	return true, nil
+}
+
+func (m *mockOrderStore) SetDeliverySignature(_ context.Context, orderID types.ID, signedBy string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.orders[orderID]; ok && o.Delivery != nil {
+		o.Delivery.SignedBy = signedBy
+	}
+	return nil
+}
+
+func (m *mockOrderStore) AddStop(_ context.Context, stop *Stop) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stops = append(m.stops, *stop)
+	stop.ID = int64(len(m.stops))
+	return nil
+}
+
+func (m *mockOrderStore) ListStops(_ context.Context, orderID types.ID) ([]Stop, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []Stop{}
+	for _, st := range m.stops {
+		if st.OrderID == orderID {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockOrderStore) SetOvertimeFee(_ context.Context, orderID types.ID, amount int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.orders[orderID]; ok {
+		o.OvertimeFee = amount
+		o.ActualFee = &types.Money{Amount: o.EstimatedFee.Amount + amount, Currency: o.EstimatedFee.Currency}
+		o.StatusVersion++
+	}
+	return nil
+}
+
+func (m *mockOrderStore) SetCreditsApplied(_ context.Context, orderID types.ID, amount int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.orders[orderID]; ok {
+		o.CreditsApplied = amount
+		o.StatusVersion++
+	}
+	return nil
+}
+
+func (m *mockOrderStore) SetSubscriptionApplied(_ context.Context, orderID types.ID, amount int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.orders[orderID]; ok {
+		o.SubscriptionApplied = amount
+		o.StatusVersion++
+	}
+	return nil
+}
+
+func (m *mockOrderStore) WAVFulfillment(_ context.Context, _, _ time.Time) ([]WAVStats, error) {
+	return nil, nil
+}
+
+func (m *mockOrderStore) ListTransitPickups(_ context.Context, _, _ time.Time) ([]TransitPickup, error) {
+	return nil, nil
+}
+
+func (m *mockOrderStore) SetTransitETA(_ context.Context, _ types.ID, _ time.Time) error {
+	return nil
+}
+
+func (m *mockOrderStore) ListArriveByPickups(_ context.Context, _, _ time.Time) ([]ArriveByPickup, error) {
+	return nil, nil
+}
+
+func (m *mockOrderStore) ListAssignedPickups(_ context.Context, now time.Time) ([]AssignedPickup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []AssignedPickup
+	for _, o := range m.orders {
+		if o.Status != StatusAssigned || o.DriverID == nil || o.ScheduledAt == nil || o.CancelDeadlineAt == nil {
+			continue
+		}
+		if o.CancelDeadlineAt.After(now) || !o.ScheduledAt.After(now) || o.Sandbox {
+			continue
+		}
+		out = append(out, AssignedPickup{
+			OrderID: o.ID, PassengerID: o.PassengerID, DriverID: *o.DriverID,
+			Pickup: o.Pickup, ScheduledAt: *o.ScheduledAt, WindowStart: *o.CancelDeadlineAt,
+		})
+	}
+	return out, nil
+}
+
 func (m *mockOrderStore) BumpIncentiveBonusForApproaching(_ context.Context, _ int64) error {
 	return nil
 }
@@ -178,6 +458,19 @@ func (m *mockOrderStore) ExpireOverdueScheduled(_ context.Context) error {
 	return nil
 }
 
+func (m *mockOrderStore) ListUnclaimedScheduled(_ context.Context, before time.Time) ([]*Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Order
+	for _, o := range m.orders {
+		if o.Status == StatusScheduled && o.ScheduledAt != nil && !o.ScheduledAt.After(before) {
+			cp := *o
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
 func (m *mockOrderStore) ListUrgentPendingOrders(_ context.Context) ([]*Order, error) {
 	return nil, nil
 }
@@ -188,14 +481,17 @@ func (m *mockOrderStore) ListUrgentPendingOrders(_ context.Context) ([]*Order, e
 type mockPricing struct {
 	amount   int64
 	currency string
+	version  int
 	err      error
+	requests []PricingRequest
 }
 
-func (p *mockPricing) Estimate(_ context.Context, _ float64, _ string) (types.Money, error) {
+func (p *mockPricing) Quote(_ context.Context, req PricingRequest) (Quote, error) {
+	p.requests = append(p.requests, req)
 	if p.err != nil {
-		return types.Money{}, p.err
+		return Quote{}, p.err
 	}
-	return types.Money{Amount: p.amount, Currency: p.currency}, nil
+	return Quote{Fare: types.Money{Amount: p.amount, Currency: p.currency}, RuleVersion: p.version}, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -209,7 +505,7 @@ func newTestSvc() (*Service, *mockOrderStore) {
 }
 
 func makeOrder(store *mockOrderStore, passengerID types.ID, status Status) types.ID {
-	id := newID()
+	id := types.NewID()
 	store.orders[id] = &Order{
 		ID:            id,
 		PassengerID:   passengerID,
@@ -273,93 +569,609 @@ func TestUnit_Create_MissingRideType(t *testing.T) {
 	}
 }
 
-func TestUnit_Create_BlockedByActiveOrder(t *testing.T) {
+func TestUnit_Create_NotesAndRequirements(t *testing.T) {
 	svc, store := newTestSvc()
 	ctx := context.Background()
 
-	// Seed an active order for the same passenger.
-	makeOrder(store, "pax-blocked", StatusWaiting)
-
-	_, err := svc.Create(ctx, CreateCommand{
-		PassengerID: "pax-blocked",
-		RideType:    "economy",
-	})
-	if !errors.Is(err, ErrActiveOrder) {
-		t.Errorf("expected ErrActiveOrder, got %v", err)
-	}
-}
-
-func TestUnit_Create_WithPricing(t *testing.T) {
-	store := newMockStore()
-	pricing := &mockPricing{amount: 18000, currency: "TWD"}
-	svc := NewService(store, pricing)
-
-	id, err := svc.Create(context.Background(), CreateCommand{
-		PassengerID: "pax-pricing",
-		Pickup:      types.Point{Lat: 25.033, Lng: 121.565},
-		Dropoff:     types.Point{Lat: 25.048, Lng: 121.532},
-		RideType:    "economy",
+	id, err := svc.Create(ctx, CreateCommand{
+		PassengerID:  "pax-1",
+		RideType:     "economy",
+		Notes:        "gate code 1234",
+		Requirements: []string{RequireChildSeat, RequireWheelchair, RequireChildSeat},
 	})
 	if err != nil {
 		t.Fatalf("Create: %v", err)
 	}
-	o, _ := store.Get(context.Background(), id)
-	if o.EstimatedFee.Amount != 18000 {
-		t.Errorf("expected fee=18000, got %d", o.EstimatedFee.Amount)
+	o, _ := store.Get(ctx, id)
+	if o.Notes != "gate code 1234" {
+		t.Errorf("expected notes to be stored, got %q", o.Notes)
+	}
+	want := []string{RequireChildSeat, RequireWheelchair}
+	if !slices.Equal(o.Requirements, want) {
+		t.Errorf("expected requirements %v, got %v", want, o.Requirements)
 	}
 }
 
-func TestUnit_Create_PricingErrorFallsBackToZero(t *testing.T) {
-	store := newMockStore()
-	pricing := &mockPricing{err: errors.New("pricing down")}
-	svc := NewService(store, pricing)
-
-	id, err := svc.Create(context.Background(), CreateCommand{
-		PassengerID: "pax-no-price",
-		RideType:    "economy",
-	})
-	if err != nil {
-		t.Fatalf("Create: %v", err)
+func TestUnit_Create_InvalidNotesOrRequirements(t *testing.T) {
+	svc, _ := newTestSvc()
+	cases := map[string]CreateCommand{
+		"unknown requirement": {PassengerID: "pax-1", RideType: "economy", Requirements: []string{"jetpack"}},
+		"notes too long":      {PassengerID: "pax-1", RideType: "economy", Notes: strings.Repeat("字", MaxNotesLen+1)},
 	}
-	o, _ := store.Get(context.Background(), id)
-	if o.EstimatedFee.Amount != 0 {
-		t.Errorf("expected fee=0 on pricing error fallback, got %d", o.EstimatedFee.Amount)
+	for name, cmd := range cases {
+		if _, err := svc.Create(context.Background(), cmd); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("%s: expected ErrBadRequest, got %v", name, err)
+		}
 	}
 }
 
-// ---------------------------------------------------------------------------
-// Service.Get
-// ---------------------------------------------------------------------------
+func TestUnit_Create_PartyRequirements(t *testing.T) {
+	cases := []struct {
+		name  string
+		count int
+		pet   bool
+		want  []string
+	}{
+		{"default party", 0, false, []string{}},
+		{"four passengers", 4, false, []string{}},
+		{"pet", 1, true, []string{RequirePetFriendly}},
+		{"five passengers", 5, false, []string{RequireSixSeater}},
+		{"six with a pet", 6, true, []string{RequirePetFriendly, RequireSixSeater}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, store := newTestSvc()
+			id, err := svc.Create(context.Background(), CreateCommand{
+				PassengerID: "pax-1", RideType: "economy", PassengerCount: tc.count, HasPet: tc.pet,
+			})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			o := store.orders[id]
+			if !slices.Equal(o.Requirements, tc.want) {
+				t.Errorf("requirements: got %v, want %v", o.Requirements, tc.want)
+			}
+			if o.PassengerCount != max(tc.count, 1) || o.HasPet != tc.pet {
+				t.Errorf("party: got %d/%v", o.PassengerCount, o.HasPet)
+			}
+		})
+	}
 
-func TestUnit_Get_NotFound(t *testing.T) {
 	svc, _ := newTestSvc()
-	_, err := svc.Get(context.Background(), "ghost")
-	if !errors.Is(err, ErrNotFound) {
-		t.Errorf("expected ErrNotFound, got %v", err)
+	_, err := svc.Create(context.Background(), CreateCommand{PassengerID: "pax-1", RideType: "economy", PassengerCount: MaxPassengers + 1})
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("oversized party: expected ErrBadRequest, got %v", err)
 	}
 }
 
-func TestUnit_Get_Found(t *testing.T) {
+type fakeOrgPolicy struct{ allowed map[types.ID]bool }
+
+func (f fakeOrgPolicy) CheckRide(_ context.Context, orgID, passengerID types.ID, _ time.Time, _ types.Money) error {
+	if !f.allowed[passengerID] {
+		return ErrPolicyDenied
+	}
+	return nil
+}
+
+func TestUnit_Create_BusinessRide(t *testing.T) {
+	org := types.ID("acme")
 	svc, store := newTestSvc()
-	id := makeOrder(store, "pax-get", StatusWaiting)
+	ctx := context.Background()
 
-	o, err := svc.Get(context.Background(), id)
+	if _, err := svc.Create(ctx, CreateCommand{PassengerID: "emp", RideType: "economy", OrgID: &org}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("no policy configured: expected ErrBadRequest, got %v", err)
+	}
+	svc.SetOrgPolicy(fakeOrgPolicy{allowed: map[types.ID]bool{"emp": true}})
+	if _, err := svc.Create(ctx, CreateCommand{PassengerID: "outsider", RideType: "economy", OrgID: &org}); !errors.Is(err, ErrPolicyDenied) {
+		t.Errorf("denied: expected ErrPolicyDenied, got %v", err)
+	}
+	id, err := svc.Create(ctx, CreateCommand{PassengerID: "emp", RideType: "economy", OrgID: &org})
 	if err != nil {
-		t.Fatalf("Get: %v", err)
+		t.Fatalf("Create: %v", err)
 	}
-	if o.ID != id {
-		t.Errorf("expected ID=%s, got %s", id, o.ID)
+	if o := store.orders[id]; o.OrgID == nil || *o.OrgID != org {
+		t.Errorf("org: got %v, want %s", o.OrgID, org)
+	}
+	// Personal rides never consult the policy.
+	if _, err := svc.Create(ctx, CreateCommand{PassengerID: "outsider", RideType: "economy"}); err != nil {
+		t.Errorf("personal ride: %v", err)
 	}
 }
 
-// ---------------------------------------------------------------------------
-// Service.Accept / Match / Depart
-// ---------------------------------------------------------------------------
+// fakeCredits hands out up to balance per passenger and remembers each order's redemption.
+type fakeCredits struct {
+	balance  map[types.ID]int64
+	redeemed map[types.ID]int64
+}
 
-func TestUnit_Accept_Success(t *testing.T) {
+func (f *fakeCredits) Redeem(_ context.Context, passengerID, orderID types.ID, fare types.Money) (int64, error) {
+	if n, ok := f.redeemed[orderID]; ok {
+		return n, nil
+	}
+	n := min(f.balance[passengerID], fare.Amount)
+	f.balance[passengerID] -= n
+	f.redeemed[orderID] = n
+	return n, nil
+}
+
+func TestUnit_Complete_AppliesCredits(t *testing.T) {
 	svc, store := newTestSvc()
+	credits := &fakeCredits{balance: map[types.ID]int64{"pax": 20000, "emp": 20000}, redeemed: map[types.ID]int64{}}
+	svc.SetCredits(credits)
 	ctx := context.Background()
-	id := makeOrder(store, "pax-accept", StatusWaiting)
+
+	id := makeOrder(store, "pax", StatusDriving)
+	before := store.orders[id].StatusVersion
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	o := store.orders[id]
+	if o.CreditsApplied != 15000 || o.AmountDue().Amount != 0 {
+		t.Errorf("credits = %d, due = %d; want 15000 and 0", o.CreditsApplied, o.AmountDue().Amount)
+	}
+	// The discount changes what is due, so it gets its own version.
+	if o.StatusVersion != before+2 {
+		t.Errorf("version = %d, want %d", o.StatusVersion, before+2)
+	}
+	if credits.balance["pax"] != 5000 {
+		t.Errorf("balance left = %d, want 5000", credits.balance["pax"])
+	}
+
+	// Business rides are invoiced to the organization and keep the credits.
+	org := types.ID("acme")
+	biz := makeOrder(store, "emp", StatusDriving)
+	store.orders[biz].OrgID = &org
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: biz}); err != nil {
+		t.Fatalf("Complete business ride: %v", err)
+	}
+	if store.orders[biz].CreditsApplied != 0 || credits.balance["emp"] != 20000 {
+		t.Errorf("business ride spent credits: %d", store.orders[biz].CreditsApplied)
+	}
+}
+
+// fakeSubscriptions takes pct percent off each fare for passengers on a plan.
+type fakeSubscriptions struct {
+	pct     map[types.ID]int64
+	applied map[types.ID]int64
+}
+
+func (f *fakeSubscriptions) Apply(_ context.Context, passengerID, orderID types.ID, fare types.Money) (int64, error) {
+	if n, ok := f.applied[orderID]; ok {
+		return n, nil
+	}
+	n := fare.Amount * f.pct[passengerID] / 100
+	f.applied[orderID] = n
+	return n, nil
+}
+
+func TestUnit_Complete_AppliesPlanBeforeCredits(t *testing.T) {
+	svc, store := newTestSvc()
+	credits := &fakeCredits{balance: map[types.ID]int64{"pax": 20000}, redeemed: map[types.ID]int64{}}
+	plans := &fakeSubscriptions{pct: map[types.ID]int64{"pax": 20}, applied: map[types.ID]int64{}}
+	svc.SetCredits(credits)
+	svc.SetSubscriptions(plans)
+	ctx := context.Background()
+
+	id := makeOrder(store, "pax", StatusDriving)
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	// 20% of the 15000 fare from the plan; credits only pay what is left.
+	o := store.orders[id]
+	if o.SubscriptionApplied != 3000 || o.CreditsApplied != 12000 || o.AmountDue().Amount != 0 {
+		t.Errorf("plan = %d, credits = %d, due = %d; want 3000, 12000 and 0",
+			o.SubscriptionApplied, o.CreditsApplied, o.AmountDue().Amount)
+	}
+	if credits.balance["pax"] != 8000 {
+		t.Errorf("balance left = %d, want 8000", credits.balance["pax"])
+	}
+
+	// Business rides are invoiced in full.
+	org := types.ID("acme")
+	biz := makeOrder(store, "pax", StatusDriving)
+	store.orders[biz].OrgID = &org
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: biz}); err != nil {
+		t.Fatalf("Complete business ride: %v", err)
+	}
+	if store.orders[biz].SubscriptionApplied != 0 {
+		t.Errorf("business ride used the plan: %d", store.orders[biz].SubscriptionApplied)
+	}
+}
+
+type fakeCapabilities map[types.ID][]string
+
+func (f fakeCapabilities) Capabilities(_ context.Context, id types.ID) ([]string, error) {
+	return f[id], nil
+}
+
+func TestUnit_Accept_VehicleMustMeetRequirements(t *testing.T) {
+	svc, store := newTestSvc()
+	svc.SetDriverCapabilities(fakeCapabilities{"drv-sedan": nil, "drv-van": {RequireSixSeater, RequirePetFriendly}})
+	ctx := context.Background()
+	id := makeOrder(store, "pax-party", StatusWaiting)
+	store.orders[id].Requirements = []string{RequireSixSeater}
+
+	if err := svc.Accept(ctx, AcceptCommand{OrderID: id, DriverID: "drv-sedan"}); !errors.Is(err, ErrVehicleMismatch) {
+		t.Fatalf("sedan: expected ErrVehicleMismatch, got %v", err)
+	}
+	if store.orders[id].Status != StatusWaiting {
+		t.Fatalf("rejected accept moved the order to %s", store.orders[id].Status)
+	}
+	if err := svc.Accept(ctx, AcceptCommand{OrderID: id, DriverID: "drv-van"}); err != nil {
+		t.Fatalf("van: %v", err)
+	}
+}
+
+type fakeOffers map[int64]bool
+
+func (f fakeOffers) OfferOpen(_ context.Context, offerID int64, _, _ types.ID) (bool, error) {
+	return f[offerID], nil
+}
+
+func TestUnit_Accept_RefusesLapsedOffer(t *testing.T) {
+	svc, store := newTestSvc()
+	svc.SetOffers(fakeOffers{1: false, 2: true})
+	ctx := context.Background()
+	id := makeOrder(store, "pax-offer", StatusWaiting)
+
+	if err := svc.Accept(ctx, AcceptCommand{OrderID: id, DriverID: "drv-late", OfferID: 1}); !errors.Is(err, ErrOfferLapsed) {
+		t.Fatalf("lapsed offer: expected ErrOfferLapsed, got %v", err)
+	}
+	if store.orders[id].Status != StatusWaiting {
+		t.Fatalf("rejected accept moved the order to %s", store.orders[id].Status)
+	}
+	if err := svc.Accept(ctx, AcceptCommand{OrderID: id, DriverID: "drv-on-time", OfferID: 2}); err != nil {
+		t.Fatalf("open offer: %v", err)
+	}
+}
+
+func TestUnit_Create_BlockedByActiveOrder(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+
+	// Seed an active order for the same passenger.
+	makeOrder(store, "pax-blocked", StatusWaiting)
+
+	_, err := svc.Create(ctx, CreateCommand{
+		PassengerID: "pax-blocked",
+		RideType:    "economy",
+	})
+	if !errors.Is(err, ErrActiveOrder) {
+		t.Errorf("expected ErrActiveOrder, got %v", err)
+	}
+}
+
+func TestUnit_Create_WithPricing(t *testing.T) {
+	store := newMockStore()
+	pricing := &mockPricing{amount: 18000, currency: "TWD", version: 3}
+	svc := NewService(store, pricing)
+
+	id, err := svc.Create(context.Background(), CreateCommand{
+		PassengerID: "pax-pricing",
+		Pickup:      types.Point{Lat: 25.033, Lng: 121.565},
+		Dropoff:     types.Point{Lat: 25.048, Lng: 121.532},
+		RideType:    "economy",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	o, _ := store.Get(context.Background(), id)
+	if o.EstimatedFee.Amount != 18000 {
+		t.Errorf("expected fee=18000, got %d", o.EstimatedFee.Amount)
+	}
+	if o.PricingVersion != 3 {
+		t.Errorf("expected pricing version 3, got %d", o.PricingVersion)
+	}
+}
+
+func TestUnit_Create_PricingErrorFallsBackToZero(t *testing.T) {
+	store := newMockStore()
+	pricing := &mockPricing{err: errors.New("pricing down")}
+	svc := NewService(store, pricing)
+
+	id, err := svc.Create(context.Background(), CreateCommand{
+		PassengerID: "pax-no-price",
+		RideType:    "economy",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	o, _ := store.Get(context.Background(), id)
+	if o.EstimatedFee.Amount != 0 || o.PricingVersion != 0 {
+		t.Errorf("expected fee=0, version 0 on pricing error fallback, got %d v%d", o.EstimatedFee.Amount, o.PricingVersion)
+	}
+}
+
+type mockRegions map[types.Point]string
+
+func (m mockRegions) RegionAt(p types.Point) (string, bool) {
+	id, ok := m[p]
+	return id, ok
+}
+
+// Location puts region "nrt" on Tokyo time and every other region on Taipei time.
+func (m mockRegions) Location(id string) *time.Location {
+	if id == "nrt" {
+		return time.FixedZone("JST", 9*60*60)
+	}
+	return time.FixedZone("CST", 8*60*60)
+}
+
+func TestUnit_Create_TaggedWithPickupRegion(t *testing.T) {
+	store := newMockStore()
+	pricing := &mockPricing{amount: 12000, currency: "TWD"}
+	svc := NewService(store, pricing)
+	kaohsiung := types.Point{Lat: 22.6163, Lng: 120.2998}
+	svc.SetRegions(mockRegions{kaohsiung: "khh"})
+
+	id, err := svc.Create(context.Background(), CreateCommand{PassengerID: "pax-khh", Pickup: kaohsiung, RideType: "economy"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if o, _ := store.Get(context.Background(), id); o.RegionID != "khh" {
+		t.Errorf("region = %q, want khh", o.RegionID)
+	}
+	if len(pricing.requests) != 1 || pricing.requests[0].RegionID != "khh" {
+		t.Errorf("quote requests = %+v, want one priced in khh", pricing.requests)
+	}
+
+	_, err = svc.Create(context.Background(), CreateCommand{PassengerID: "pax-away", Pickup: types.Point{Lat: 24.15, Lng: 120.67}, RideType: "economy"})
+	if !errors.Is(err, ErrOutsideServiceArea) {
+		t.Errorf("pickup outside every region: err = %v, want ErrOutsideServiceArea", err)
+	}
+}
+
+type fakeOvertime struct{ requests []OvertimeFeeRequest }
+
+func (f *fakeOvertime) OvertimeFee(_ context.Context, req OvertimeFeeRequest) (types.Money, error) {
+	f.requests = append(f.requests, req)
+	return types.Money{Amount: 20000, Currency: req.Currency}, nil
+}
+
+func TestUnit_Charter(t *testing.T) {
+	store := newMockStore()
+	pricing := &mockPricing{amount: 240000, currency: "TWD"}
+	svc := NewService(store, pricing)
+	ctx := context.Background()
+	pickup, stop := types.Point{Lat: 22.6163, Lng: 120.2998}, types.Point{Lat: 22.62, Lng: 120.31}
+	taichung := types.Point{Lat: 24.15, Lng: 120.67}
+	svc.SetRegions(mockRegions{pickup: "khh", stop: "khh", taichung: "txg"})
+
+	for _, hours := range []int{0, MaxCharterHours + 1} {
+		if _, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-c", Pickup: pickup, RideType: RideTypeCharter, CharterHours: hours}); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("%d hours: err = %v, want ErrBadRequest", hours, err)
+		}
+	}
+	if _, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-c", Pickup: pickup, Dropoff: taichung, RideType: RideTypeCharter, CharterHours: 4}); !errors.Is(err, ErrOutsideRegion) {
+		t.Errorf("dropoff in another region: err = %v, want ErrOutsideRegion", err)
+	}
+	id, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-c", Pickup: pickup, RideType: RideTypeCharter, CharterHours: 4})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got := pricing.requests[len(pricing.requests)-1]; got.Hours != 4 || got.Dropoff != pickup {
+		t.Errorf("quote request = %+v, want 4 hours back to the pickup", got)
+	}
+
+	// Four hours and twenty minutes later the driver logs stops and ends it.
+	driver := types.ID("drv-c")
+	started := time.Now().UTC().Add(-4*time.Hour - 20*time.Minute)
+	o := store.orders[id]
+	o.Status, o.DriverID, o.StartedAt = StatusDriving, &driver, &started
+	if _, err := svc.LogStop(ctx, StopCommand{OrderID: id, DriverID: driver, Position: stop}); err != nil {
+		t.Fatalf("LogStop: %v", err)
+	}
+	if _, err := svc.LogStop(ctx, StopCommand{OrderID: id, DriverID: driver, Position: taichung}); !errors.Is(err, ErrOutsideRegion) {
+		t.Errorf("stop in another region: err = %v, want ErrOutsideRegion", err)
+	}
+	if _, err := svc.LogStop(ctx, StopCommand{OrderID: id, DriverID: "other", Position: stop}); !errors.Is(err, ErrActorNotAllowed) {
+		t.Errorf("stop by another driver: err = %v, want ErrActorNotAllowed", err)
+	}
+	if stops, _ := svc.Stops(ctx, id); len(stops) != 1 || stops[0].Position != stop {
+		t.Errorf("stops = %+v, want the one in the region", stops)
+	}
+
+	overtime := &fakeOvertime{}
+	svc.SetCharterPricing(overtime)
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if len(overtime.requests) != 1 || overtime.requests[0].Overtime < 20*time.Minute || overtime.requests[0].Overtime > 21*time.Minute {
+		t.Errorf("overtime requests = %+v, want one for about 20 minutes", overtime.requests)
+	}
+	o, _ = store.Get(ctx, id)
+	if o.Status != StatusPayment || o.OvertimeFee != 20000 || o.Fare().Amount != 260000 {
+		t.Errorf("status %s, overtime %d, fare %d; want payment, 20000 and 260000", o.Status, o.OvertimeFee, o.Fare().Amount)
+	}
+
+	ride := makeOrder(store, "pax-r", StatusDriving)
+	store.orders[ride].DriverID = &driver
+	if _, err := svc.LogStop(ctx, StopCommand{OrderID: ride, DriverID: driver, Position: stop}); !errors.Is(err, ErrNotCharter) {
+		t.Errorf("stop on a ride: err = %v, want ErrNotCharter", err)
+	}
+}
+
+type mockPriority map[types.ID]string
+
+func (m mockPriority) Priority(_ context.Context, id types.ID) (string, error) {
+	return m[id], nil
+}
+
+func TestUnit_Create_SnapshotsPassengerPriority(t *testing.T) {
+	svc, store := newTestSvc()
+	svc.SetPassengerPriority(mockPriority{"pax-vip": "vip"})
+	ctx := context.Background()
+
+	vip, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-vip", RideType: "economy"})
+	if err != nil {
+		t.Fatalf("Create vip: %v", err)
+	}
+	normal, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-normal", RideType: "economy"})
+	if err != nil {
+		t.Fatalf("Create normal: %v", err)
+	}
+	if o, _ := store.Get(ctx, vip); o.Priority != "vip" {
+		t.Errorf("vip order priority = %q, want vip", o.Priority)
+	}
+	if o, _ := store.Get(ctx, normal); o.Priority != "" {
+		t.Errorf("normal order priority = %q, want none", o.Priority)
+	}
+}
+
+func TestUnit_Create_WAVRequiresWheelchair(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	id, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-wav", RideType: RideTypeWAV, Requirements: []string{RequireChildSeat}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	o, _ := store.Get(ctx, id)
+	if !slices.Equal(o.Requirements, []string{RequireChildSeat, RequireWheelchair}) {
+		t.Errorf("requirements = %v, want child_seat and wheelchair", o.Requirements)
+	}
+}
+
+type fakeProof bool
+
+func (f fakeProof) HasDeliveryProof(context.Context, types.ID) (bool, error) { return bool(f), nil }
+
+func TestUnit_Create_Delivery(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	if _, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-d", RideType: RideTypeDelivery}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("delivery without recipient: err = %v, want ErrBadRequest", err)
+	}
+	if _, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-d", RideType: "economy", Delivery: &Delivery{RecipientName: "Lin", RecipientPhone: "0912345678"}}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("ride with recipient: err = %v, want ErrBadRequest", err)
+	}
+	id, err := svc.Create(ctx, CreateCommand{
+		PassengerID: "pax-d",
+		RideType:    RideTypeDelivery,
+		Delivery:    &Delivery{RecipientName: " Lin ", RecipientPhone: "0912 345 678"},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	o, _ := store.Get(ctx, id)
+	if o.Delivery == nil || o.Delivery.RecipientName != "Lin" || o.Delivery.RecipientPhone != "0912345678" || o.Delivery.WeightClass != WeightSmall {
+		t.Errorf("delivery = %+v, want Lin, 0912345678, small", o.Delivery)
+	}
+	if !slices.Contains(o.Requirements, RequireParcel) {
+		t.Errorf("requirements = %v, want parcel", o.Requirements)
+	}
+}
+
+func TestUnit_Deliver(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	driver := types.ID("drv-d")
+	id := makeOrder(store, "pax-d", StatusDriving)
+	store.orders[id].RideType = RideTypeDelivery
+	store.orders[id].DriverID = &driver
+	store.orders[id].Delivery = &Delivery{RecipientName: "Lin", RecipientPhone: "0912345678", WeightClass: WeightSmall}
+
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); !errors.Is(err, ErrProofRequired) {
+		t.Fatalf("Complete: err = %v, want ErrProofRequired", err)
+	}
+	svc.SetDeliveryProof(fakeProof(false))
+	if err := svc.Deliver(ctx, DeliverCommand{OrderID: id, DriverID: driver, SignedBy: "Lin"}); !errors.Is(err, ErrProofRequired) {
+		t.Fatalf("Deliver without proof: err = %v, want ErrProofRequired", err)
+	}
+	svc.SetDeliveryProof(fakeProof(true))
+	if err := svc.Deliver(ctx, DeliverCommand{OrderID: id, DriverID: "other", SignedBy: "Lin"}); !errors.Is(err, ErrActorNotAllowed) {
+		t.Fatalf("Deliver by another driver: err = %v, want ErrActorNotAllowed", err)
+	}
+	if err := svc.Deliver(ctx, DeliverCommand{OrderID: id, DriverID: driver, SignedBy: " Lin "}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	o, _ := store.Get(ctx, id)
+	if o.Status != StatusPayment || o.Delivery.SignedBy != "Lin" {
+		t.Errorf("status = %s, signed by %q; want payment and Lin", o.Status, o.Delivery.SignedBy)
+	}
+
+	ride := makeOrder(store, "pax-r", StatusDriving)
+	if err := svc.Deliver(ctx, DeliverCommand{OrderID: ride, DriverID: driver, SignedBy: "Lin"}); !errors.Is(err, ErrNotDelivery) {
+		t.Errorf("Deliver ride: err = %v, want ErrNotDelivery", err)
+	}
+}
+
+func TestUnit_Create_Designated(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	if _, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-dd", RideType: RideTypeDesignated}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("designated without car: err = %v, want ErrBadRequest", err)
+	}
+	if _, err := svc.Create(ctx, CreateCommand{PassengerID: "pax-dd", RideType: "economy", Car: &Car{Plate: "ABC-1234"}}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("ride with car: err = %v, want ErrBadRequest", err)
+	}
+	id, err := svc.Create(ctx, CreateCommand{
+		PassengerID:    "pax-dd",
+		RideType:       RideTypeDesignated,
+		PassengerCount: 5,
+		HasPet:         true,
+		Car:            &Car{Plate: "abc 1234", Model: "Corolla", Transmission: TransmissionManual},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	o, _ := store.Get(ctx, id)
+	if o.Car == nil || o.Car.Plate != "ABC1234" || o.Car.Transmission != TransmissionManual {
+		t.Errorf("car = %+v, want ABC1234 manual", o.Car)
+	}
+	// The party rides in its own car: only the driver's clearance matters.
+	if !slices.Equal(o.Requirements, []string{RequireDesignated, RequireManual}) {
+		t.Errorf("requirements = %v, want designated_driver and manual_transmission", o.Requirements)
+	}
+}
+
+func TestUnit_WAVStats(t *testing.T) {
+	w := WAVStats{Requested: 10, Served: 6, Unserved: 2}
+	if w.Open() != 2 || w.FulfillmentRate() != 0.75 {
+		t.Errorf("open = %d, rate = %v; want 2 and 0.75", w.Open(), w.FulfillmentRate())
+	}
+	if (WAVStats{Requested: 3}).FulfillmentRate() != 0 {
+		t.Error("rate with nothing decided should be 0")
+	}
+	svc, _ := newTestSvc()
+	now := time.Now()
+	if _, err := svc.WAVFulfillment(context.Background(), now, now); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("empty period: err = %v, want ErrBadRequest", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Service.Get
+// ---------------------------------------------------------------------------
+
+func TestUnit_Get_NotFound(t *testing.T) {
+	svc, _ := newTestSvc()
+	_, err := svc.Get(context.Background(), "ghost")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestUnit_Get_Found(t *testing.T) {
+	svc, store := newTestSvc()
+	id := makeOrder(store, "pax-get", StatusWaiting)
+
+	o, err := svc.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if o.ID != id {
+		t.Errorf("expected ID=%s, got %s", id, o.ID)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Service.Accept / Match / Depart
+// ---------------------------------------------------------------------------
+
+func TestUnit_Accept_Success(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	id := makeOrder(store, "pax-accept", StatusWaiting)
 
 	if err := svc.Accept(ctx, AcceptCommand{OrderID: id, DriverID: "drv-1"}); err != nil {
 		t.Fatalf("Accept: %v", err)
@@ -470,11 +1282,121 @@ func TestUnit_Complete_Success(t *testing.T) {
 	id := makeOrder(store, "pax-complete", StatusDriving)
 
 	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); err != nil {
-		t.Fatalf("Complete: %v", err)
+		t.Fatalf("Complete: %v", err)
+	}
+	o, _ := store.Get(ctx, id)
+	if o.Status != StatusPayment {
+		t.Errorf("expected payment, got %s", o.Status)
+	}
+}
+
+type fakeFix struct {
+	pos types.Point
+	at  time.Time
+}
+
+func (f *fakeFix) LastFix(context.Context, types.ID) (types.Point, time.Time, error) {
+	return f.pos, f.at, nil
+}
+
+type fakeTripRegions map[string]TripCheck
+
+func (f fakeTripRegions) TripCheck(id string) TripCheck { return f[id] }
+
+func newTripCheckSvc(status Status) (*Service, *mockOrderStore, *fakeFix, *[]LocationDiscrepancy, types.ID) {
+	svc, store := newTestSvc()
+	fix := &fakeFix{}
+	svc.SetTripChecks(fix, fakeTripRegions{"wide": {MeetRadiusKm: 2}})
+	var seen []LocationDiscrepancy
+	svc.OnLocationDiscrepancy(func(_ context.Context, d LocationDiscrepancy) { seen = append(seen, d) })
+	driver := types.ID("drv")
+	started := time.Now().UTC().Add(-10 * time.Minute)
+	id := makeOrder(store, "pax-trip", status)
+	store.orders[id].DriverID = &driver
+	store.orders[id].StartedAt = &started
+	return svc, store, fix, &seen, id
+}
+
+func TestUnit_Meet_ChecksDriverPosition(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	away := types.Point{Lat: 25.043, Lng: 121.565} // ~1.1 km north of the pickup
+
+	svc, store, fix, seen, id := newTripCheckSvc(StatusArrived)
+	*fix = fakeFix{pos: away, at: now}
+	if err := svc.Meet(ctx, MeetCommand{OrderID: id}); !errors.Is(err, ErrNotAtPickup) {
+		t.Fatalf("Meet away from the pickup: got %v, want ErrNotAtPickup", err)
+	}
+	if store.orders[id].Status != StatusArrived {
+		t.Fatalf("rejected Meet moved the order to %s", store.orders[id].Status)
+	}
+	store.orders[id].RegionID = "wide"
+	if err := svc.Meet(ctx, MeetCommand{OrderID: id}); err != nil {
+		t.Fatalf("Meet within the region's radius: %v", err)
+	}
+	if len(*seen) != 0 {
+		t.Errorf("discrepancies = %+v, want none", *seen)
+	}
+
+	// A stale position is not trusted: the trip starts and is reported.
+	svc, store, fix, seen, id = newTripCheckSvc(StatusArrived)
+	*fix = fakeFix{pos: away, at: now.Add(-5 * time.Minute)}
+	if err := svc.Meet(ctx, MeetCommand{OrderID: id}); err != nil {
+		t.Fatalf("Meet on a stale position: %v", err)
+	}
+	if len(*seen) != 1 || (*seen)[0].Reason != DiscrepancyStaleFix || (*seen)[0].Step != StatusDriving || (*seen)[0].DistanceKm < 1 {
+		t.Errorf("discrepancies = %+v, want one stale_fix", *seen)
+	}
+
+	// So is a driver with no position at all.
+	svc, _, _, seen, id = newTripCheckSvc(StatusArrived)
+	if err := svc.Meet(ctx, MeetCommand{OrderID: id}); err != nil {
+		t.Fatalf("Meet without a position: %v", err)
+	}
+	if len(*seen) != 1 || (*seen)[0].Reason != DiscrepancyNoFix || (*seen)[0].DistanceKm != -1 {
+		t.Errorf("discrepancies = %+v, want one no_fix", *seen)
+	}
+}
+
+func TestUnit_Complete_ChecksDriverPosition(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	pickup := types.Point{Lat: 25.033, Lng: 121.565}
+	dropoff := types.Point{Lat: 25.048, Lng: 121.532}
+
+	svc, store, fix, seen, id := newTripCheckSvc(StatusDriving)
+	*fix = fakeFix{pos: dropoff, at: now}
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); err != nil {
+		t.Fatalf("Complete at the dropoff: %v", err)
+	}
+
+	// Away from the dropoff a short trip is refused...
+	svc, store, fix, seen, id = newTripCheckSvc(StatusDriving)
+	*fix = fakeFix{pos: pickup, at: now}
+	svc.SetDriverTraces(fakeTraces{pickup, {Lat: 25.035, Lng: 121.565}})
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); !errors.Is(err, ErrNotAtDropoff) {
+		t.Fatalf("short trip away from the dropoff: got %v, want ErrNotAtDropoff", err)
+	}
+	if store.orders[id].Status != StatusDriving {
+		t.Fatalf("rejected Complete moved the order to %s", store.orders[id].Status)
+	}
+	// ...but a trip past the minimum distance may end anywhere.
+	svc.SetDriverTraces(fakeTraces{pickup, {Lat: 25.043, Lng: 121.565}, pickup})
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); err != nil {
+		t.Fatalf("long trip away from the dropoff: %v", err)
+	}
+	if len(*seen) != 0 {
+		t.Errorf("discrepancies = %+v, want none", *seen)
+	}
+
+	// Without a trace the trip's length is unknown: it completes and is reported.
+	svc, _, fix, seen, id = newTripCheckSvc(StatusDriving)
+	*fix = fakeFix{pos: pickup, at: now}
+	if err := svc.Complete(ctx, CompleteCommand{OrderID: id}); err != nil {
+		t.Fatalf("Complete without a trace: %v", err)
 	}
-	o, _ := store.Get(ctx, id)
-	if o.Status != StatusPayment {
-		t.Errorf("expected payment, got %s", o.Status)
+	if len(*seen) != 1 || (*seen)[0].Reason != DiscrepancyNoTrace || (*seen)[0].Step != StatusPayment {
+		t.Errorf("discrepancies = %+v, want one no_trace", *seen)
 	}
 }
 
@@ -534,6 +1456,135 @@ func TestUnit_Cancel_TerminalStateRejected(t *testing.T) {
 	}
 }
 
+func TestUnit_Cancel_UnknownActorRejected(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	id := makeOrder(store, "pax-bad-actor", StatusWaiting)
+
+	err := svc.Cancel(ctx, CancelCommand{OrderID: id, ActorType: "stranger"})
+	if !errors.Is(err, ErrActorNotAllowed) {
+		t.Fatalf("expected ErrActorNotAllowed, got %v", err)
+	}
+	if o, _ := store.Get(ctx, id); o.Status != StatusWaiting {
+		t.Errorf("order moved to %s despite rejected actor", o.Status)
+	}
+}
+
+type fakeCancelPricing struct{ last CancellationFeeRequest }
+
+func (f *fakeCancelPricing) CancellationFee(_ context.Context, req CancellationFeeRequest) (types.Money, error) {
+	f.last = req
+	return types.Money{Amount: 5000 + int64(req.DriverProgressKm*1000), Currency: req.Fare.Currency}, nil
+}
+
+type fakeTraces []types.Point
+
+func (f fakeTraces) DriverTrace(context.Context, types.ID, time.Time, time.Time) ([]types.Point, error) {
+	return f, nil
+}
+
+// fakeFeeCommission takes bps basis points of every cancellation fee.
+type fakeFeeCommission struct{ bps int }
+
+func (f fakeFeeCommission) SplitCancellationFee(_ context.Context, _ types.ID, fee int64, _ time.Time) (FeeSplit, error) {
+	return FeeSplit{Commission: fee * int64(f.bps) / 10000, CommissionBps: f.bps}, nil
+}
+
+func TestUnit_Cancel_PassengerFeeAfterGrace(t *testing.T) {
+	svc, store := newTestSvc()
+	pricing := &fakeCancelPricing{}
+	svc.SetCancellationFees(pricing, 2*time.Minute)
+	svc.SetCancellationCommission(fakeFeeCommission{bps: 2000})
+	// The driver starts ~1.1 km south of the pickup and ends at it.
+	svc.SetDriverTraces(fakeTraces{{Lat: 25.023, Lng: 121.565}, {Lat: 25.033, Lng: 121.565}})
+	ctx := context.Background()
+	accepted := time.Now().UTC()
+	driver := types.ID("drv")
+
+	id := makeOrder(store, "pax-fee", StatusApproaching)
+	store.orders[id].AcceptedAt = &accepted
+	store.orders[id].DriverID = &driver
+
+	svc.now = func() time.Time { return accepted.Add(time.Minute) }
+	q, err := svc.QuoteCancellation(ctx, id)
+	if err != nil || q.Fee.Amount != 0 || q.FreeUntil == nil || !q.FreeUntil.Equal(accepted.Add(2*time.Minute)) {
+		t.Fatalf("within grace: quote = %+v, %v; want free until accepted+2m", q, err)
+	}
+
+	svc.now = func() time.Time { return accepted.Add(3 * time.Minute) }
+	q, err = svc.CancelQuoted(ctx, CancelCommand{OrderID: id, ActorType: ActorPassenger})
+	if !errors.Is(err, ErrCancelFeeRequired) {
+		t.Fatalf("unconfirmed cancel: got %v, want ErrCancelFeeRequired", err)
+	}
+	if q.DriverProgressKm < 1 || q.DriverProgressKm > 1.2 || q.Fee.Amount <= 5000 || q.Fee.Currency != "TWD" {
+		t.Fatalf("quote = %+v", q)
+	}
+	if pricing.last.RideType != "economy" || pricing.last.Fare.Amount != 15000 {
+		t.Errorf("pricing request = %+v", pricing.last)
+	}
+	if store.orders[id].Status != StatusApproaching {
+		t.Fatalf("unconfirmed cancel moved the order to %s", store.orders[id].Status)
+	}
+
+	low := q.Fee.Amount - 1
+	if _, err := svc.CancelQuoted(ctx, CancelCommand{OrderID: id, ActorType: ActorPassenger, AcceptFee: &low}); !errors.Is(err, ErrCancelFeeRequired) {
+		t.Fatalf("accepting less than the fee: got %v, want ErrCancelFeeRequired", err)
+	}
+	version := store.orders[id].StatusVersion
+	got, err := svc.CancelQuoted(ctx, CancelCommand{OrderID: id, ActorType: ActorPassenger, AcceptFee: &q.Fee.Amount})
+	if err != nil {
+		t.Fatalf("confirmed cancel: %v", err)
+	}
+	if o := store.orders[id]; o.Status != StatusCancelled || o.CancelFee != got.Fee.Amount || o.StatusVersion != version+1 {
+		t.Errorf("order = %s v%d with fee %d, want cancelled v%d with %d",
+			o.Status, o.StatusVersion, o.CancelFee, version+1, got.Fee.Amount)
+	}
+
+	// The fee is charged with the cancel: the driver is paid it net of
+	// commission and the charge is booked in the ledger.
+	if len(store.charges) != 1 {
+		t.Fatalf("charges = %d, want 1", len(store.charges))
+	}
+	c := store.charges[0]
+	if c.DriverID != driver || c.Fee != got.Fee.Amount || c.Split.Commission != got.Fee.Amount/5 {
+		t.Errorf("charge = %+v", c)
+	}
+	if err := c.Txn.Validate(); err != nil || c.Txn.Kind != ledger.KindCancellationFee || c.Txn.Ref != string(id) {
+		t.Errorf("ledger txn = %+v, %v", c.Txn, err)
+	}
+}
+
+func TestUnit_Cancel_NoFee(t *testing.T) {
+	svc, store := newTestSvc()
+	svc.SetCancellationFees(&fakeCancelPricing{}, 2*time.Minute)
+	ctx := context.Background()
+	accepted := time.Now().UTC().Add(-time.Hour)
+
+	cases := map[string]func(o *Order){
+		"waiting":   func(o *Order) { o.Status = StatusWaiting; o.AcceptedAt = nil },
+		"scheduled": func(o *Order) { o.OrderType = "scheduled" },
+		"sandbox":   func(o *Order) { o.Sandbox = true },
+	}
+	for name, mut := range cases {
+		id := makeOrder(store, types.ID("pax-"+name), StatusApproaching)
+		store.orders[id].AcceptedAt = &accepted
+		mut(store.orders[id])
+		if err := svc.Cancel(ctx, CancelCommand{OrderID: id, ActorType: ActorPassenger}); err != nil {
+			t.Errorf("%s: Cancel: %v", name, err)
+		}
+		if fee := store.orders[id].CancelFee; fee != 0 {
+			t.Errorf("%s: charged %d", name, fee)
+		}
+	}
+
+	// Drivers never pay to cancel.
+	id := makeOrder(store, "pax-drv", StatusApproaching)
+	store.orders[id].AcceptedAt = &accepted
+	if err := svc.Cancel(ctx, CancelCommand{OrderID: id, ActorType: ActorDriver}); err != nil {
+		t.Fatalf("driver Cancel: %v", err)
+	}
+}
+
 func TestUnit_Deny_Success(t *testing.T) {
 	svc, store := newTestSvc()
 	ctx := context.Background()
@@ -587,6 +1638,25 @@ func TestUnit_ApplyTransition_Conflict(t *testing.T) {
 	if !errors.Is(err, ErrConflict) {
 		t.Errorf("expected ErrConflict on stale version, got %v", err)
 	}
+	var stateErr *StateError
+	if !errors.As(err, &stateErr) || stateErr.OrderID != id || stateErr.Status != StatusWaiting {
+		t.Errorf("expected a StateError for %s in waiting, got %#v", id, err)
+	}
+}
+
+func TestUnit_ApplyTransition_InvalidStateCarriesTarget(t *testing.T) {
+	store := newMockStore()
+	svc := NewService(store, nil)
+	id := makeOrder(store, "pax-target", StatusComplete)
+
+	err := svc.Accept(context.Background(), AcceptCommand{OrderID: id, DriverID: "drv-x"})
+	var stateErr *StateError
+	if !errors.As(err, &stateErr) || !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected a StateError wrapping ErrInvalidState, got %v", err)
+	}
+	if stateErr.Status != StatusComplete || stateErr.To != StatusApproaching {
+		t.Errorf("StateError = %+v, want complete -> approaching", stateErr)
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -819,6 +1889,328 @@ func TestUnit_CreateScheduled_Success(t *testing.T) {
 	}
 }
 
+func TestUnit_CreateScheduled_Transit(t *testing.T) {
+	cases := []struct {
+		kind, number string
+		wantNumber   string
+		wantErr      bool
+	}{
+		{"flight", "br 12", "BR12", false},
+		{"train", "0123", "0123", false},
+		{"flight", "", "", true},
+		{"", "BR12", "", true},
+		{"bus", "12", "", true},
+		{"train", "BR12", "", true},
+	}
+	for _, tc := range cases {
+		svc, store := newTestSvc()
+		id, err := svc.CreateScheduled(context.Background(), CreateScheduledCommand{
+			PassengerID:        "pax-transit",
+			RideType:           "economy",
+			ScheduledAt:        time.Now().Add(2 * time.Hour),
+			ScheduleWindowMins: 30,
+			TransitType:        tc.kind,
+			TransitNumber:      tc.number,
+		})
+		if tc.wantErr {
+			if !errors.Is(err, ErrBadRequest) {
+				t.Errorf("%s %q: expected ErrBadRequest, got %v", tc.kind, tc.number, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s %q: %v", tc.kind, tc.number, err)
+		}
+		if o := store.orders[id]; o.TransitType != tc.kind || o.TransitNumber != tc.wantNumber {
+			t.Errorf("%s %q: stored %s %s", tc.kind, tc.number, o.TransitType, o.TransitNumber)
+		}
+	}
+}
+
+func TestUnit_AmendSchedule(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	pickup := time.Now().Add(2 * time.Hour)
+	id, err := svc.CreateScheduled(ctx, CreateScheduledCommand{
+		PassengerID: "pax-amend", RideType: "economy", ScheduledAt: pickup, ScheduleWindowMins: 30,
+		TransitType: TransitFlight, TransitNumber: "BR12",
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduled: %v", err)
+	}
+	var changes []ScheduleChange
+	svc.OnScheduleChange(func(_ context.Context, c ScheduleChange) { changes = append(changes, c) })
+
+	if err := svc.AmendSchedule(ctx, AmendScheduleCommand{OrderID: id, ScheduledAt: pickup, ActorType: ActorDriver}); !errors.Is(err, ErrActorNotAllowed) {
+		t.Errorf("driver amend: expected ErrActorNotAllowed, got %v", err)
+	}
+	soon := time.Now().Add(5 * time.Minute)
+	if err := svc.AmendSchedule(ctx, AmendScheduleCommand{OrderID: id, ScheduledAt: soon, ActorType: ActorPassenger}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("passenger inside lead time: expected ErrBadRequest, got %v", err)
+	}
+
+	delayed := pickup.Add(45 * time.Minute)
+	if err := svc.AmendSchedule(ctx, AmendScheduleCommand{OrderID: id, ScheduledAt: delayed, ActorType: ActorSystem, Reason: "flight_delayed"}); err != nil {
+		t.Fatalf("system amend: %v", err)
+	}
+	o := store.orders[id]
+	if !o.ScheduledAt.Equal(delayed) || !o.CancelDeadlineAt.Equal(delayed.Add(-30*time.Minute)) {
+		t.Errorf("schedule: got %v / %v", o.ScheduledAt, o.CancelDeadlineAt)
+	}
+	if len(changes) != 1 || !changes[0].From.Equal(pickup) || !changes[0].To.Equal(delayed) || changes[0].Reason != "flight_delayed" {
+		t.Errorf("hook calls: %+v", changes)
+	}
+
+	o.Status = StatusCancelled
+	if err := svc.AmendSchedule(ctx, AmendScheduleCommand{OrderID: id, ScheduledAt: delayed, ActorType: ActorSystem}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("cancelled order: expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestUnit_DropoffChange(t *testing.T) {
+	store := newMockStore()
+	svc := NewService(store, &mockPricing{amount: 22000, currency: "TWD", version: 2})
+	ctx := context.Background()
+	id := makeOrder(store, "pax-dropoff", StatusDriving)
+	driver := types.ID("drv-dropoff")
+	store.orders[id].DriverID = &driver
+	booked := store.orders[id].Dropoff
+	var changes []DropoffChange
+	svc.OnDropoffChange(func(_ context.Context, c DropoffChange) { changes = append(changes, c) })
+
+	newDropoff := types.Point{Lat: 25.061, Lng: 121.520}
+	if _, err := svc.RequestDropoffChange(ctx, ChangeDropoffCommand{OrderID: id, PassengerID: "someone-else", Dropoff: newDropoff}); !errors.Is(err, ErrActorNotAllowed) {
+		t.Errorf("other passenger: expected ErrActorNotAllowed, got %v", err)
+	}
+	if _, err := svc.RequestDropoffChange(ctx, ChangeDropoffCommand{OrderID: id, PassengerID: "pax-dropoff"}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("missing dropoff: expected ErrBadRequest, got %v", err)
+	}
+	stale := 7
+	if _, err := svc.RequestDropoffChange(ctx, ChangeDropoffCommand{OrderID: id, PassengerID: "pax-dropoff", Dropoff: newDropoff, ExpectVersion: &stale}); !errors.Is(err, ErrConflict) {
+		t.Errorf("stale version: expected ErrConflict, got %v", err)
+	}
+	if err := svc.AckDropoffChange(ctx, AckDropoffCommand{OrderID: id, DriverID: driver, Accept: true}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("ack without proposal: expected ErrInvalidState, got %v", err)
+	}
+
+	p, err := svc.RequestDropoffChange(ctx, ChangeDropoffCommand{OrderID: id, PassengerID: "pax-dropoff", Dropoff: newDropoff})
+	if err != nil {
+		t.Fatalf("RequestDropoffChange: %v", err)
+	}
+	if p.Fee.Amount != 22000 {
+		t.Errorf("proposed fee = %d, want 22000", p.Fee.Amount)
+	}
+	o := store.orders[id]
+	if o.Dropoff != booked || o.EstimatedFee.Amount != 15000 || o.PendingDropoff == nil {
+		t.Fatalf("before ack: dropoff %v fee %d pending %v", o.Dropoff, o.EstimatedFee.Amount, o.PendingDropoff)
+	}
+
+	if err := svc.AckDropoffChange(ctx, AckDropoffCommand{OrderID: id, DriverID: "other-driver", Accept: true}); !errors.Is(err, ErrActorNotAllowed) {
+		t.Errorf("other driver: expected ErrActorNotAllowed, got %v", err)
+	}
+	if err := svc.AckDropoffChange(ctx, AckDropoffCommand{OrderID: id, DriverID: driver, Accept: true}); err != nil {
+		t.Fatalf("AckDropoffChange: %v", err)
+	}
+	o = store.orders[id]
+	if o.Dropoff != newDropoff || o.EstimatedFee.Amount != 22000 || o.PricingVersion != 2 || o.PendingDropoff != nil {
+		t.Errorf("after accept: dropoff %v fee %d v%d pending %v", o.Dropoff, o.EstimatedFee.Amount, o.PricingVersion, o.PendingDropoff)
+	}
+	if o.OriginalDropoff == nil || *o.OriginalDropoff != booked {
+		t.Errorf("original dropoff = %v, want %v", o.OriginalDropoff, booked)
+	}
+
+	// A second change that the driver rejects keeps the current dropoff and
+	// the dropoff originally booked.
+	if _, err := svc.RequestDropoffChange(ctx, ChangeDropoffCommand{OrderID: id, PassengerID: "pax-dropoff", Dropoff: types.Point{Lat: 25.1, Lng: 121.5}}); err != nil {
+		t.Fatalf("second RequestDropoffChange: %v", err)
+	}
+	if err := svc.AckDropoffChange(ctx, AckDropoffCommand{OrderID: id, DriverID: driver, Accept: false}); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	o = store.orders[id]
+	if o.Dropoff != newDropoff || *o.OriginalDropoff != booked || o.PendingDropoff != nil {
+		t.Errorf("after reject: dropoff %v original %v pending %v", o.Dropoff, o.OriginalDropoff, o.PendingDropoff)
+	}
+
+	stages := make([]string, len(changes))
+	for i, c := range changes {
+		stages[i] = c.Stage
+	}
+	if !slices.Equal(stages, []string{DropoffRequested, DropoffAccepted, DropoffRequested, DropoffRejected}) {
+		t.Errorf("hook stages = %v", stages)
+	}
+	if changes[1].From != booked || changes[1].To != newDropoff || changes[1].NewFare.Amount != 22000 {
+		t.Errorf("accept hook = %+v", changes[1])
+	}
+
+	o.Status = StatusPayment
+	if _, err := svc.RequestDropoffChange(ctx, ChangeDropoffCommand{OrderID: id, PassengerID: "pax-dropoff", Dropoff: newDropoff}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("after drop-off: expected ErrInvalidState, got %v", err)
+	}
+}
+
+// makeScheduledOrder adds a scheduled order quoted at 15000 TWD under pricing v1.
+func makeScheduledOrder(store *mockOrderStore, passengerID types.ID) types.ID {
+	id := makeOrder(store, passengerID, StatusScheduled)
+	at := time.Now().Add(3 * time.Hour)
+	window := 15
+	o := store.orders[id]
+	o.OrderType = "scheduled"
+	o.ScheduledAt = &at
+	o.ScheduleWindowMins = &window
+	o.PricingVersion = 1
+	return id
+}
+
+func TestUnit_RequoteScheduled(t *testing.T) {
+	store := newMockStore()
+	pricing := &mockPricing{amount: 15500, currency: "TWD", version: 1}
+	svc := NewService(store, pricing)
+	ctx := context.Background()
+	var requotes []Requote
+	svc.OnRequote(func(_ context.Context, r Requote) { requotes = append(requotes, r) })
+
+	id := makeScheduledOrder(store, "pax-requote")
+	scheduledAt := *store.orders[id].ScheduledAt
+
+	// Same rule version: the booked fare stands whatever the quote says now.
+	if n, err := svc.RequoteScheduled(ctx); err != nil || n != 0 {
+		t.Fatalf("same version: n=%d err=%v", n, err)
+	}
+	if len(pricing.requests) != 1 || !pricing.requests[0].At.Equal(scheduledAt) {
+		t.Fatalf("pricing requests = %+v, want one at %v", pricing.requests, scheduledAt)
+	}
+
+	// New version, but under the 10% default threshold.
+	pricing.version = 2
+	if n, _ := svc.RequoteScheduled(ctx); n != 0 || store.orders[id].EstimatedFee.Amount != 15000 {
+		t.Fatalf("below threshold: n=%d fee=%d", n, store.orders[id].EstimatedFee.Amount)
+	}
+
+	// A material drop applies without asking.
+	pricing.amount = 12000
+	if n, err := svc.RequoteScheduled(ctx); err != nil || n != 1 {
+		t.Fatalf("lowered: n=%d err=%v", n, err)
+	}
+	o := store.orders[id]
+	if o.EstimatedFee.Amount != 12000 || o.PricingVersion != 2 || o.PendingRequote != nil {
+		t.Fatalf("after lower: fee %d v%d pending %v", o.EstimatedFee.Amount, o.PricingVersion, o.PendingRequote)
+	}
+
+	// A material rise waits for the passenger.
+	pricing.amount, pricing.version = 16000, 3
+	if n, err := svc.RequoteScheduled(ctx); err != nil || n != 1 {
+		t.Fatalf("requested: n=%d err=%v", n, err)
+	}
+	o = store.orders[id]
+	if o.EstimatedFee.Amount != 12000 || o.PricingVersion != 2 || o.PendingRequote == nil || o.PendingRequote.Fee.Amount != 16000 {
+		t.Fatalf("after rise: fee %d v%d pending %+v", o.EstimatedFee.Amount, o.PricingVersion, o.PendingRequote)
+	}
+	// A pending re-quote is not re-priced again.
+	if n, _ := svc.RequoteScheduled(ctx); n != 0 {
+		t.Errorf("pending requote re-priced: n=%d", n)
+	}
+
+	if err := svc.AnswerRequote(ctx, AnswerRequoteCommand{OrderID: id, PassengerID: "someone-else", Accept: true}); !errors.Is(err, ErrActorNotAllowed) {
+		t.Errorf("other passenger: expected ErrActorNotAllowed, got %v", err)
+	}
+	stale := 0
+	if err := svc.AnswerRequote(ctx, AnswerRequoteCommand{OrderID: id, PassengerID: "pax-requote", Accept: true, ExpectVersion: &stale}); !errors.Is(err, ErrConflict) {
+		t.Errorf("stale version: expected ErrConflict, got %v", err)
+	}
+	if err := svc.AnswerRequote(ctx, AnswerRequoteCommand{OrderID: id, PassengerID: "pax-requote", Accept: true}); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	o = store.orders[id]
+	if o.EstimatedFee.Amount != 16000 || o.PricingVersion != 3 || o.PendingRequote != nil {
+		t.Errorf("after accept: fee %d v%d pending %v", o.EstimatedFee.Amount, o.PricingVersion, o.PendingRequote)
+	}
+	if err := svc.AnswerRequote(ctx, AnswerRequoteCommand{OrderID: id, PassengerID: "pax-requote", Accept: true}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("nothing pending: expected ErrInvalidState, got %v", err)
+	}
+
+	stages := make([]string, len(requotes))
+	for i, r := range requotes {
+		stages[i] = r.Stage
+	}
+	if !slices.Equal(stages, []string{RequoteLowered, RequoteRequested, RequoteAccepted}) {
+		t.Errorf("hook stages = %v", stages)
+	}
+	if requotes[1].Fare.Amount != 12000 || requotes[1].NewFare.Amount != 16000 || !requotes[1].ScheduledAt.Equal(scheduledAt) {
+		t.Errorf("requested hook = %+v", requotes[1])
+	}
+}
+
+func TestUnit_AnswerRequote_DeclineCancels(t *testing.T) {
+	store := newMockStore()
+	pricing := &mockPricing{amount: 20000, currency: "TWD", version: 2}
+	svc := NewService(store, pricing)
+	ctx := context.Background()
+	var requotes []Requote
+	svc.OnRequote(func(_ context.Context, r Requote) { requotes = append(requotes, r) })
+
+	id := makeScheduledOrder(store, "pax-decline")
+	if n, err := svc.RequoteScheduled(ctx); err != nil || n != 1 {
+		t.Fatalf("RequoteScheduled: n=%d err=%v", n, err)
+	}
+	if err := svc.AnswerRequote(ctx, AnswerRequoteCommand{OrderID: id, PassengerID: "pax-decline", Accept: false}); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+	o := store.orders[id]
+	if o.Status != StatusCancelled || o.EstimatedFee.Amount != 15000 {
+		t.Errorf("after decline: status %s fee %d", o.Status, o.EstimatedFee.Amount)
+	}
+	if len(requotes) != 2 || requotes[1].Stage != RequoteDeclined {
+		t.Errorf("hooks = %+v", requotes)
+	}
+}
+
+type fakePayouts struct{ failFor types.ID }
+
+func (f fakePayouts) EstimatePayout(_ context.Context, driverID types.ID, o *Order) (PayoutEstimate, error) {
+	if o.ID == f.failFor {
+		return PayoutEstimate{}, errors.New("no rate")
+	}
+	c := o.EstimatedFee.Amount / 5
+	return PayoutEstimate{Fare: o.EstimatedFee.Amount, Commission: c, Incentive: o.IncentiveBonus, Net: o.EstimatedFee.Amount - c + o.IncentiveBonus}, nil
+}
+
+func TestUnit_ListAvailableForDriver(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	a := makeScheduledOrder(store, "pax-a")
+	b := makeScheduledOrder(store, "pax-b")
+	store.orders[a].IncentiveBonus = 50
+	from, to := time.Now(), time.Now().Add(6*time.Hour)
+
+	if _, err := svc.ListAvailableForDriver(ctx, "", from, to); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("no driver: expected ErrBadRequest, got %v", err)
+	}
+	// Without an estimator orders are listed without a payout.
+	list, err := svc.ListAvailableForDriver(ctx, "drv-1", from, to)
+	if err != nil || len(list) != 2 || list[0].EstimatedPayout != nil {
+		t.Fatalf("no estimator: %+v, %v", list, err)
+	}
+
+	svc.SetPayoutEstimator(fakePayouts{failFor: b})
+	list, err = svc.ListAvailableForDriver(ctx, "drv-1", from, to)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListAvailableForDriver: %+v, %v", list, err)
+	}
+	for _, o := range list {
+		switch o.ID {
+		case a:
+			if o.EstimatedPayout == nil || o.EstimatedPayout.Net != 15000-3000+50 {
+				t.Errorf("order a payout = %+v", o.EstimatedPayout)
+			}
+		case b:
+			if o.EstimatedPayout != nil {
+				t.Errorf("order b: failed estimate should be omitted, got %+v", o.EstimatedPayout)
+			}
+		}
+	}
+}
+
 func TestUnit_CreateScheduled_TooEarly(t *testing.T) {
 	svc, _ := newTestSvc()
 	_, err := svc.CreateScheduled(context.Background(), CreateScheduledCommand{
@@ -877,12 +2269,13 @@ func TestUnit_CreateScheduled_WithPricing(t *testing.T) {
 	pricing := &mockPricing{amount: 20000, currency: "TWD"}
 	svc := NewService(store, pricing)
 
+	scheduledAt := time.Now().Add(2 * time.Hour)
 	id, err := svc.CreateScheduled(context.Background(), CreateScheduledCommand{
 		PassengerID:        "pax-sched-price",
 		Pickup:             types.Point{Lat: 25.033, Lng: 121.565},
 		Dropoff:            types.Point{Lat: 25.048, Lng: 121.532},
-		RideType:           "economy",
-		ScheduledAt:        time.Now().Add(2 * time.Hour),
+		RideType:           "premium",
+		ScheduledAt:        scheduledAt,
 		ScheduleWindowMins: 30,
 	})
 	if err != nil {
@@ -892,6 +2285,14 @@ func TestUnit_CreateScheduled_WithPricing(t *testing.T) {
 	if o.EstimatedFee.Amount != 20000 {
 		t.Errorf("expected fee=20000, got %d", o.EstimatedFee.Amount)
 	}
+	// Scheduled rides are priced for their pickup time, not booking time.
+	if len(pricing.requests) != 1 {
+		t.Fatalf("expected one pricing request, got %d", len(pricing.requests))
+	}
+	req := pricing.requests[0]
+	if req.RideType != "premium" || !req.At.Equal(scheduledAt) || req.DistanceKm <= 0 || req.Pickup != o.Pickup {
+		t.Errorf("pricing request = %+v", req)
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -1011,8 +2412,8 @@ func TestUnit_CancelScheduledByDriver_Success(t *testing.T) {
 	if o.DriverID != nil {
 		t.Errorf("expected driverID to be cleared, got %s", *o.DriverID)
 	}
-	if o.IncentiveBonus != driverCancelBonusIncrement {
-		t.Errorf("expected incentiveBonus=%d, got %d", driverCancelBonusIncrement, o.IncentiveBonus)
+	if want := config.DefaultScheduling().DriverCancelBonus; o.IncentiveBonus != want {
+		t.Errorf("expected incentiveBonus=%d, got %d", want, o.IncentiveBonus)
 	}
 }
 
@@ -1034,13 +2435,201 @@ func TestUnit_CancelScheduledByDriver_WrongStatus(t *testing.T) {
 		ScheduleWindowMins: 30,
 	})
 
-	err := svc.CancelScheduledByDriver(context.Background(), DriverCancelScheduledCommand{
-		OrderID:  id,
-		DriverID: "drv-x",
-	})
-	_ = store
-	if !errors.Is(err, ErrInvalidState) {
-		t.Errorf("expected ErrInvalidState, got %v", err)
+	err := svc.CancelScheduledByDriver(context.Background(), DriverCancelScheduledCommand{
+		OrderID:  id,
+		DriverID: "drv-x",
+	})
+	_ = store
+	if !errors.Is(err, ErrInvalidState) {
+		t.Errorf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// assigned.go — ListAssignedPickups / ReleaseAssigned
+// ---------------------------------------------------------------------------
+
+func TestUnit_ListAssignedPickups_WindowStarted(t *testing.T) {
+	svc, _ := newTestSvc()
+	ctx := context.Background()
+	now := time.Now()
+	id, err := svc.CreateScheduled(ctx, CreateScheduledCommand{
+		PassengerID:        "pax-assigned",
+		RideType:           "economy",
+		ScheduledAt:        now.Add(2 * time.Hour),
+		ScheduleWindowMins: 30,
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduled: %v", err)
+	}
+	if err := svc.ClaimScheduled(ctx, ClaimScheduledCommand{OrderID: id, DriverID: "drv-assigned"}); err != nil {
+		t.Fatalf("ClaimScheduled: %v", err)
+	}
+
+	if got, _ := svc.ListAssignedPickups(ctx, now.Add(89*time.Minute)); len(got) != 0 {
+		t.Errorf("before the window: listed %d pickups, want 0", len(got))
+	}
+	got, err := svc.ListAssignedPickups(ctx, now.Add(90*time.Minute))
+	if err != nil {
+		t.Fatalf("ListAssignedPickups: %v", err)
+	}
+	if len(got) != 1 || got[0].DriverID != "drv-assigned" {
+		t.Fatalf("window started: got %+v, want the pickup of drv-assigned", got)
+	}
+	if want := got[0].ScheduledAt.Add(-30 * time.Minute); !got[0].WindowStart.Equal(want) {
+		t.Errorf("WindowStart = %v, want %v", got[0].WindowStart, want)
+	}
+}
+
+func TestUnit_ReleaseAssigned(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	var got []Transition
+	svc.OnTransition(func(_ context.Context, tr Transition) { got = append(got, tr) })
+	id, _ := svc.CreateScheduled(ctx, CreateScheduledCommand{
+		PassengerID:        "pax-release",
+		RideType:           "economy",
+		ScheduledAt:        time.Now().Add(2 * time.Hour),
+		ScheduleWindowMins: 30,
+	})
+	_ = svc.ClaimScheduled(ctx, ClaimScheduledCommand{OrderID: id, DriverID: "drv-late"})
+	got = nil
+
+	err := svc.ReleaseAssigned(ctx, ReleaseAssignedCommand{OrderID: id, DriverID: "drv-other"})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("release from another driver: err = %v, want ErrConflict", err)
+	}
+	if err := svc.ReleaseAssigned(ctx, ReleaseAssignedCommand{OrderID: id, DriverID: "drv-late"}); err != nil {
+		t.Fatalf("ReleaseAssigned: %v", err)
+	}
+	o, _ := store.Get(ctx, id)
+	if o.Status != StatusScheduled || o.DriverID != nil {
+		t.Errorf("after release: status %s, driver %v; want scheduled without a driver", o.Status, o.DriverID)
+	}
+	if len(got) != 1 || got[0].From != StatusAssigned || got[0].To != StatusScheduled || got[0].ActorType != ActorSystem {
+		t.Errorf("hooks saw %+v, want one assigned→scheduled by system", got)
+	}
+	if err := svc.ReleaseAssigned(ctx, ReleaseAssignedCommand{OrderID: id, DriverID: "drv-late"}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("second release: err = %v, want ErrInvalidState", err)
+	}
+}
+
+func TestUnit_ClaimScheduled_ClaimWindow(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		ahead   time.Duration
+		wantErr error
+	}{
+		{"months ahead", 60 * 24 * time.Hour, ErrOutsideClaimWindow},
+		{"just before the window opens", 48*time.Hour + time.Second, ErrOutsideClaimWindow},
+		{"window opens", 48 * time.Hour, nil},
+		{"window closes", 10 * time.Minute, nil},
+		{"just after the window closes", 10*time.Minute - time.Second, ErrOutsideClaimWindow},
+		{"a minute before pickup", time.Minute, ErrOutsideClaimWindow},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, store := newTestSvc()
+			svc.now = func() time.Time { return now }
+			id := makeOrder(store, "pax-window", StatusScheduled)
+			at := now.Add(tc.ahead)
+			store.orders[id].ScheduledAt = &at
+
+			err := svc.ClaimScheduled(context.Background(), ClaimScheduledCommand{OrderID: id, DriverID: "drv-window"})
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnit_ClaimScheduled_ClaimWindowDisabled(t *testing.T) {
+	svc, store := newTestSvc()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	cfg := config.DefaultScheduling()
+	cfg.ClaimOpensBefore, cfg.ClaimClosesBefore = 0, 0
+	svc.ConfigureScheduling(cfg)
+	id := makeOrder(store, "pax-anytime", StatusScheduled)
+	at := now.AddDate(0, 3, 0)
+	store.orders[id].ScheduledAt = &at
+
+	if err := svc.ClaimScheduled(context.Background(), ClaimScheduledCommand{OrderID: id, DriverID: "drv"}); err != nil {
+		t.Errorf("ClaimScheduled without a window: %v", err)
+	}
+}
+
+func TestUnit_DispatchUnclaimedScheduled(t *testing.T) {
+	svc, store := newTestSvc()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	var got []Transition
+	svc.OnTransition(func(_ context.Context, tr Transition) { got = append(got, tr) })
+
+	scheduled := func(pax types.ID, status Status, ahead time.Duration) types.ID {
+		id := makeOrder(store, pax, status)
+		at := now.Add(ahead)
+		store.orders[id].ScheduledAt = &at
+		store.orders[id].OrderType = "scheduled"
+		return id
+	}
+	soon := scheduled("pax-soon", StatusScheduled, 5*time.Minute)
+	closing := scheduled("pax-closing", StatusScheduled, 10*time.Minute)
+	open := scheduled("pax-open", StatusScheduled, 10*time.Minute+time.Second)
+	claimed := scheduled("pax-claimed", StatusAssigned, 5*time.Minute)
+
+	n, err := svc.DispatchUnclaimedScheduled(context.Background())
+	if err != nil {
+		t.Fatalf("DispatchUnclaimedScheduled: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("dispatched %d orders, want 2", n)
+	}
+	for id, want := range map[types.ID]Status{
+		soon: StatusWaiting, closing: StatusWaiting, open: StatusScheduled, claimed: StatusAssigned,
+	} {
+		if s := store.orders[id].Status; s != want {
+			t.Errorf("%s: status = %s, want %s", store.orders[id].PassengerID, s, want)
+		}
+	}
+	if !store.orders[soon].DispatchedUnclaimed() || store.orders[open].DispatchedUnclaimed() {
+		t.Error("DispatchedUnclaimed should hold only for dispatched scheduled orders")
+	}
+	if len(got) != 2 {
+		t.Fatalf("hooks saw %d transitions, want 2", len(got))
+	}
+	for _, tr := range got {
+		if tr.From != StatusScheduled || tr.To != StatusWaiting || tr.ActorType != ActorSystem {
+			t.Errorf("transition = %s→%s by %s, want scheduled→waiting by system", tr.From, tr.To, tr.ActorType)
+		}
+	}
+
+	// A dispatched order is accepted like an instant one.
+	if err := svc.Accept(context.Background(), AcceptCommand{OrderID: soon, DriverID: "drv-near"}); err != nil {
+		t.Fatalf("Accept dispatched order: %v", err)
+	}
+	if s := store.orders[soon].Status; s != StatusApproaching {
+		t.Errorf("status after accept = %s, want approaching", s)
+	}
+}
+
+func TestUnit_DispatchUnclaimedScheduled_ClaimsNeverClose(t *testing.T) {
+	svc, store := newTestSvc()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	cfg := config.DefaultScheduling()
+	cfg.ClaimClosesBefore = 0
+	svc.ConfigureScheduling(cfg)
+	id := makeOrder(store, "pax-late", StatusScheduled)
+	at := now.Add(time.Minute)
+	store.orders[id].ScheduledAt = &at
+
+	if n, err := svc.DispatchUnclaimedScheduled(context.Background()); err != nil || n != 0 {
+		t.Errorf("DispatchUnclaimedScheduled = %d, %v; want 0, nil", n, err)
+	}
+	if s := store.orders[id].Status; s != StatusScheduled {
+		t.Errorf("status = %s, want scheduled", s)
 	}
 }
 
@@ -1105,6 +2694,48 @@ func TestUnit_ListAvailableScheduled_Empty(t *testing.T) {
 	}
 }
 
+func TestUnit_ListAvailableScheduled_HidesOutsideClaimWindow(t *testing.T) {
+	svc, store := newTestSvc()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ahead := map[string]time.Duration{
+		"too-soon":  10*time.Minute - time.Second,
+		"closes":    10 * time.Minute,
+		"tomorrow":  24 * time.Hour,
+		"opens":     48 * time.Hour,
+		"too-early": 48*time.Hour + time.Second,
+		"next-year": 365 * 24 * time.Hour,
+	}
+	names := map[types.ID]string{}
+	for name, d := range ahead {
+		id := makeOrder(store, types.ID("pax-"+name), StatusScheduled)
+		at := now.Add(d)
+		store.orders[id].ScheduledAt = &at
+		names[id] = name
+	}
+
+	orders, err := svc.ListAvailableScheduled(context.Background(), now, now.AddDate(2, 0, 0))
+	if err != nil {
+		t.Fatalf("ListAvailableScheduled: %v", err)
+	}
+	var got []string
+	for _, o := range orders {
+		got = append(got, names[o.ID])
+	}
+	slices.Sort(got)
+	if want := []string{"closes", "opens", "tomorrow"}; !slices.Equal(got, want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+
+	// A range entirely outside the window lists nothing.
+	if orders, _ := svc.ListAvailableScheduled(context.Background(), now.Add(72*time.Hour), now.Add(96*time.Hour)); len(orders) != 0 {
+		t.Errorf("range after the window listed %d orders", len(orders))
+	}
+	if orders, _ := svc.ListAvailableForDriver(context.Background(), "drv", now, now.AddDate(2, 0, 0)); len(orders) != 3 {
+		t.Errorf("ListAvailableForDriver listed %d orders, want 3", len(orders))
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Full scheduled-order happy path (unit, no DB)
 // ---------------------------------------------------------------------------
@@ -1203,3 +2834,629 @@ func TestUnit_ScheduledDriverCancelReopenFlow(t *testing.T) {
 		t.Errorf("expected driverID=drv-new, got %v", o.DriverID)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Transition hooks
+// ---------------------------------------------------------------------------
+
+func TestService_TransitionHooks(t *testing.T) {
+	svc := NewService(newMockStore(), nil)
+	ctx := context.Background()
+
+	var got []Transition
+	svc.OnTransition(func(_ context.Context, tr Transition) { got = append(got, tr) })
+	svc.OnTransition(func(context.Context, Transition) { panic("hook bug") })
+
+	id, err := svc.Create(ctx, CreateCommand{PassengerID: "p-hook", RideType: "standard"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := svc.Cancel(ctx, CancelCommand{OrderID: id, ActorType: "passenger"}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d transitions, want 2: %+v", len(got), got)
+	}
+	if got[0].From != StatusNone || got[0].To != StatusWaiting || got[0].PassengerID != "p-hook" {
+		t.Errorf("create transition = %+v", got[0])
+	}
+	if got[1].From != StatusWaiting || got[1].To != StatusCancelled || got[1].ActorType != "passenger" {
+		t.Errorf("cancel transition = %+v", got[1])
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Fastest pickup
+// ---------------------------------------------------------------------------
+
+type stubEstimator struct {
+	eta time.Duration
+	ok  bool
+	err error
+}
+
+func (e stubEstimator) EstimatePickup(context.Context, types.Point, []string) (time.Duration, bool, error) {
+	return e.eta, e.ok, e.err
+}
+
+func TestService_CreateFastest(t *testing.T) {
+	ctx := context.Background()
+	cmd := CreateCommand{PassengerID: "p-fast", RideType: "standard"}
+
+	svc := NewService(newMockStore(), nil)
+	if _, _, err := svc.CreateFastest(ctx, cmd); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("no estimator: err = %v, want ErrBadRequest", err)
+	}
+
+	svc.SetPickupEstimator(stubEstimator{eta: 4 * time.Minute, ok: true}, 10*time.Minute)
+	id, q, err := svc.CreateFastest(ctx, cmd)
+	if err != nil || id == "" {
+		t.Fatalf("CreateFastest: id=%q err=%v", id, err)
+	}
+	if q.ETA != 4*time.Minute || q.SuggestScheduled {
+		t.Errorf("quote = %+v", q)
+	}
+
+	slow := NewService(newMockStore(), nil)
+	slow.SetPickupEstimator(stubEstimator{eta: 25 * time.Minute, ok: true}, 10*time.Minute)
+	if _, q, err := slow.CreateFastest(ctx, cmd); !errors.Is(err, ErrPickupTooFar) || !q.SuggestScheduled {
+		t.Errorf("slow: quote=%+v err=%v, want ErrPickupTooFar with suggestion", q, err)
+	}
+
+	none := NewService(newMockStore(), nil)
+	none.SetPickupEstimator(stubEstimator{}, 10*time.Minute)
+	if _, _, err := none.CreateFastest(ctx, cmd); !errors.Is(err, ErrPickupTooFar) {
+		t.Errorf("no drivers: err = %v, want ErrPickupTooFar", err)
+	}
+
+	// Driver positions unreadable (Redis down): booked anyway, left waiting for dispatch.
+	down := NewService(newMockStore(), nil)
+	down.SetPickupEstimator(stubEstimator{err: fmt.Errorf("%w: redis down", ErrPickupUnavailable)}, 10*time.Minute)
+	id, q, err = down.CreateFastest(ctx, cmd)
+	if err != nil || id == "" || !q.Queued {
+		t.Fatalf("positions unavailable: id=%q quote=%+v err=%v, want a queued order", id, q, err)
+	}
+	if o, _ := down.Get(ctx, id); o == nil || o.Status != StatusWaiting {
+		t.Errorf("queued order = %+v, want StatusWaiting", o)
+	}
+}
+
+func TestService_CreateFastest_WAVWindow(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(newMockStore(), nil)
+	svc.SetPickupEstimator(stubEstimator{eta: 25 * time.Minute, ok: true}, 10*time.Minute)
+
+	wav := CreateCommand{PassengerID: "p-wav", RideType: RideTypeWAV}
+	if _, _, err := svc.CreateFastest(ctx, wav); !errors.Is(err, ErrPickupTooFar) {
+		t.Errorf("without a WAV window: err = %v, want ErrPickupTooFar", err)
+	}
+	svc.SetWAVPickupWindow(30 * time.Minute)
+	if _, q, err := svc.CreateFastest(ctx, wav); err != nil || q.SuggestScheduled {
+		t.Errorf("wav within its window: quote=%+v err=%v", q, err)
+	}
+	if _, _, err := svc.CreateFastest(ctx, CreateCommand{PassengerID: "p-std", RideType: "standard"}); !errors.Is(err, ErrPickupTooFar) {
+		t.Errorf("standard ride: err = %v, want ErrPickupTooFar", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Time zones
+// ---------------------------------------------------------------------------
+
+type recordingOrgPolicy struct{ pickupAt time.Time }
+
+func (r *recordingOrgPolicy) CheckRide(_ context.Context, _, _ types.ID, pickupAt time.Time, _ types.Money) error {
+	r.pickupAt = pickupAt
+	return nil
+}
+
+func TestUnit_CreateScheduled_StoresUTC(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	// 12:00 in Taipei is 04:00 UTC.
+	taipeiNoon := time.Date(2026, 3, 10, 12, 0, 0, 0, time.FixedZone("CST", 8*60*60))
+	arriveBy := taipeiNoon.Add(time.Hour)
+	id, err := svc.CreateScheduled(ctx, CreateScheduledCommand{
+		PassengerID:        "pax-utc",
+		RideType:           "economy",
+		ScheduledAt:        taipeiNoon,
+		ScheduleWindowMins: 30,
+		ArriveBy:           &arriveBy,
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduled: %v", err)
+	}
+	o := store.orders[id]
+	for name, got := range map[string]time.Time{
+		"scheduled_at":       *o.ScheduledAt,
+		"cancel_deadline_at": *o.CancelDeadlineAt,
+		"arrive_by":          *o.ArriveBy,
+		"arrival_window_to":  o.ArrivalWindow.To,
+		"created_at":         o.CreatedAt,
+	} {
+		if got.Location() != time.UTC {
+			t.Errorf("%s stored in %v, want UTC", name, got.Location())
+		}
+	}
+	if want := time.Date(2026, 3, 10, 4, 0, 0, 0, time.UTC); !o.ScheduledAt.Equal(want) || o.ScheduledAt.Hour() != 4 {
+		t.Errorf("scheduled_at = %v, want %v", o.ScheduledAt, want)
+	}
+	if want := time.Date(2026, 3, 10, 3, 30, 0, 0, time.UTC); !o.CancelDeadlineAt.Equal(want) {
+		t.Errorf("cancel_deadline_at = %v, want %v", o.CancelDeadlineAt, want)
+	}
+}
+
+func TestUnit_CreateScheduled_LeadTimeIgnoresOffset(t *testing.T) {
+	now := time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		at   time.Time
+		ok   bool
+	}{
+		// 20 minutes ahead, though the wall clock reads 20:20 the day before.
+		{"UTC-5, inside lead time", time.Date(2026, 3, 9, 20, 20, 0, 0, time.FixedZone("EST", -5*60*60)), false},
+		// An hour ahead, though the wall clock reads 16:00 the next day.
+		{"UTC+14, outside lead time", time.Date(2026, 3, 10, 16, 0, 0, 0, time.FixedZone("LINT", 14*60*60)), true},
+		// 09:29 in Taipei is 01:29 UTC, one minute short of the lead time.
+		{"UTC+8, one minute short", time.Date(2026, 3, 10, 9, 29, 0, 0, time.FixedZone("CST", 8*60*60)), false},
+		{"UTC+8, exactly the lead time", time.Date(2026, 3, 10, 9, 30, 0, 0, time.FixedZone("CST", 8*60*60)), true},
+	}
+	for _, tc := range cases {
+		svc, _ := newTestSvc()
+		svc.now = func() time.Time { return now }
+		_, err := svc.CreateScheduled(context.Background(), CreateScheduledCommand{
+			PassengerID:        "pax-offset",
+			RideType:           "economy",
+			ScheduledAt:        tc.at,
+			ScheduleWindowMins: 15,
+		})
+		if tc.ok && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrBadRequest) {
+			t.Errorf("%s: err = %v, want ErrBadRequest", tc.name, err)
+		}
+	}
+}
+
+func TestUnit_AmendSchedule_NormalizesOffset(t *testing.T) {
+	svc, store := newTestSvc()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	id, err := svc.CreateScheduled(ctx, CreateScheduledCommand{
+		PassengerID: "pax-amend", RideType: "economy",
+		ScheduledAt: now.Add(3 * time.Hour), ScheduleWindowMins: 30,
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduled: %v", err)
+	}
+	// 10:00 in Tokyo is 01:00 UTC: now, so inside the lead time.
+	tokyo := time.FixedZone("JST", 9*60*60)
+	if err := svc.AmendSchedule(ctx, AmendScheduleCommand{OrderID: id, ScheduledAt: time.Date(2026, 3, 10, 10, 0, 0, 0, tokyo), ActorType: ActorPassenger}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("amend to now in Tokyo: err = %v, want ErrBadRequest", err)
+	}
+	if err := svc.AmendSchedule(ctx, AmendScheduleCommand{OrderID: id, ScheduledAt: time.Date(2026, 3, 10, 14, 0, 0, 0, tokyo), ActorType: ActorPassenger}); err != nil {
+		t.Fatalf("amend: %v", err)
+	}
+	o := store.orders[id]
+	if want := time.Date(2026, 3, 10, 5, 0, 0, 0, time.UTC); o.ScheduledAt.Location() != time.UTC || !o.ScheduledAt.Equal(want) {
+		t.Errorf("scheduled_at = %v, want %v", o.ScheduledAt, want)
+	}
+	if o.CancelDeadlineAt.Location() != time.UTC {
+		t.Errorf("cancel_deadline_at stored in %v, want UTC", o.CancelDeadlineAt.Location())
+	}
+}
+
+func TestUnit_OrgPolicy_ReadsRegionLocalTime(t *testing.T) {
+	store := newMockStore()
+	svc := NewService(store, &mockPricing{amount: 10000, currency: "TWD"})
+	policy := &recordingOrgPolicy{}
+	svc.SetOrgPolicy(policy)
+	narita := types.Point{Lat: 35.772, Lng: 140.393}
+	taipei := types.Point{Lat: 25.048, Lng: 121.517}
+	svc.SetRegions(mockRegions{narita: "nrt", taipei: "tpe"})
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	org := types.ID("org-1")
+
+	for _, tc := range []struct {
+		pickup   types.Point
+		wantHour int
+	}{{narita, 23}, {taipei, 22}} {
+		id, err := svc.Create(context.Background(), CreateCommand{PassengerID: types.ID("pax-" + strconv.Itoa(tc.wantHour)), Pickup: tc.pickup, RideType: "economy", OrgID: &org})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if !policy.pickupAt.Equal(now) || policy.pickupAt.Hour() != tc.wantHour {
+			t.Errorf("pickup %v: policy saw %v, want %02d:30 local", tc.pickup, policy.pickupAt, tc.wantHour)
+		}
+		if o := store.orders[id]; o.CreatedAt.Location() != time.UTC {
+			t.Errorf("created_at stored in %v, want UTC", o.CreatedAt.Location())
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// pickup_edit.go — ChangePickup
+// ---------------------------------------------------------------------------
+
+// fakeDispatch is an in-memory DispatchLock; offering() simulates the matcher
+// holding the lock for an offer round.
+type fakeDispatch struct {
+	mu       sync.Mutex
+	held     bool
+	releases int
+	resets   []types.ID
+}
+
+func (f *fakeDispatch) HoldDispatch(_ context.Context, _ types.ID) (func(context.Context), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.held {
+		return nil, ErrDispatchInFlight
+	}
+	f.held = true
+	return func(context.Context) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.held = false
+		f.releases++
+	}, nil
+}
+
+func (f *fakeDispatch) ResetDispatch(_ context.Context, orderID types.ID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resets = append(f.resets, orderID)
+	return nil
+}
+
+func (f *fakeDispatch) offering() (done func()) {
+	release, _ := f.HoldDispatch(context.Background(), "")
+	return func() { release(context.Background()) }
+}
+
+var newPickup = types.Point{Lat: 25.0418, Lng: 121.5080}
+
+func newPickupSvc() (*Service, *mockOrderStore, *fakeDispatch, *mockPricing) {
+	store := newMockStore()
+	pricing := &mockPricing{amount: 17500, currency: "TWD", version: 2}
+	svc := NewService(store, pricing)
+	dispatch := &fakeDispatch{}
+	svc.SetDispatchLock(dispatch)
+	return svc, store, dispatch, pricing
+}
+
+func TestUnit_ChangePickup_MovesAndReprices(t *testing.T) {
+	svc, store, dispatch, pricing := newPickupSvc()
+	ctx := context.Background()
+	id := makeOrder(store, "pax-1", StatusWaiting)
+
+	o, err := svc.ChangePickup(ctx, ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup})
+	if err != nil {
+		t.Fatalf("ChangePickup: %v", err)
+	}
+	stored := store.orders[id]
+	if stored.Pickup != newPickup || stored.EstimatedFee.Amount != 17500 || stored.PricingVersion != 2 {
+		t.Errorf("stored pickup %v fee %d v%d, want %v 17500 v2", stored.Pickup, stored.EstimatedFee.Amount, stored.PricingVersion, newPickup)
+	}
+	if stored.StatusVersion != 1 || o.StatusVersion != 1 {
+		t.Errorf("status_version stored %d returned %d, want 1", stored.StatusVersion, o.StatusVersion)
+	}
+	if len(pricing.requests) != 1 || pricing.requests[0].Pickup != newPickup {
+		t.Errorf("quote requests = %+v, want one from the new pickup", pricing.requests)
+	}
+	if dispatch.held || dispatch.releases != 1 {
+		t.Errorf("lock held=%v releases=%d, want released once", dispatch.held, dispatch.releases)
+	}
+	if len(dispatch.resets) != 1 || dispatch.resets[0] != id {
+		t.Errorf("resets = %v, want [%s]", dispatch.resets, id)
+	}
+}
+
+func TestUnit_ChangePickup_Refusals(t *testing.T) {
+	stale := 3
+	cases := []struct {
+		name   string
+		status Status
+		cmd    func(id types.ID) ChangePickupCommand
+		want   error
+	}{
+		{"not the passenger", StatusWaiting, func(id types.ID) ChangePickupCommand {
+			return ChangePickupCommand{OrderID: id, PassengerID: "someone-else", Pickup: newPickup}
+		}, ErrActorNotAllowed},
+		{"driver already accepted", StatusApproaching, func(id types.ID) ChangePickupCommand {
+			return ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup}
+		}, ErrInvalidState},
+		{"scheduled order", StatusScheduled, func(id types.ID) ChangePickupCommand {
+			return ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup}
+		}, ErrInvalidState},
+		{"stale version", StatusWaiting, func(id types.ID) ChangePickupCommand {
+			return ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup, ExpectVersion: &stale}
+		}, ErrConflict},
+		{"no pickup", StatusWaiting, func(id types.ID) ChangePickupCommand {
+			return ChangePickupCommand{OrderID: id, PassengerID: "pax-1"}
+		}, ErrBadRequest},
+	}
+	for _, tc := range cases {
+		svc, store, dispatch, _ := newPickupSvc()
+		id := makeOrder(store, "pax-1", tc.status)
+		if _, err := svc.ChangePickup(context.Background(), tc.cmd(id)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+		if o := store.orders[id]; o.Pickup == newPickup || o.StatusVersion != 0 {
+			t.Errorf("%s: order changed to %v v%d", tc.name, o.Pickup, o.StatusVersion)
+		}
+		if dispatch.held || len(dispatch.resets) != 0 {
+			t.Errorf("%s: lock held=%v resets=%v", tc.name, dispatch.held, dispatch.resets)
+		}
+	}
+}
+
+func TestUnit_ChangePickup_DispatchInFlight(t *testing.T) {
+	svc, store, dispatch, _ := newPickupSvc()
+	ctx := context.Background()
+	id := makeOrder(store, "pax-1", StatusWaiting)
+
+	done := dispatch.offering()
+	if _, err := svc.ChangePickup(ctx, ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup}); !errors.Is(err, ErrDispatchInFlight) {
+		t.Fatalf("edit during offer round: err = %v, want ErrDispatchInFlight", err)
+	}
+	if o := store.orders[id]; o.Pickup == newPickup || o.StatusVersion != 0 {
+		t.Errorf("order changed during offer round: %v v%d", o.Pickup, o.StatusVersion)
+	}
+	done()
+	if _, err := svc.ChangePickup(ctx, ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup}); err != nil {
+		t.Fatalf("edit after offer round: %v", err)
+	}
+
+	// Without a dispatch lock an edit could race an offer, so it is refused.
+	plain, plainStore := newTestSvc()
+	id = makeOrder(plainStore, "pax-1", StatusWaiting)
+	if _, err := plain.ChangePickup(ctx, ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("no dispatch lock: err = %v, want ErrInvalidState", err)
+	}
+}
+
+func TestUnit_ChangePickup_OutsideServiceArea(t *testing.T) {
+	svc, store, dispatch, _ := newPickupSvc()
+	svc.SetRegions(mockRegions{newPickup: "tpe"})
+	id := makeOrder(store, "pax-1", StatusWaiting)
+	away := types.Point{Lat: 24.15, Lng: 120.67}
+	if _, err := svc.ChangePickup(context.Background(), ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: away}); !errors.Is(err, ErrOutsideServiceArea) {
+		t.Errorf("err = %v, want ErrOutsideServiceArea", err)
+	}
+	if dispatch.releases != 0 || dispatch.held {
+		t.Error("lock taken for a pickup outside the service area")
+	}
+}
+
+func TestUnit_Accept_OfferForMovedPickupConflicts(t *testing.T) {
+	svc, store, _, _ := newPickupSvc()
+	ctx := context.Background()
+	id := makeOrder(store, "pax-1", StatusWaiting)
+	offered := store.orders[id].StatusVersion
+
+	if _, err := svc.ChangePickup(ctx, ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup}); err != nil {
+		t.Fatalf("ChangePickup: %v", err)
+	}
+	if err := svc.Accept(ctx, AcceptCommand{OrderID: id, DriverID: "drv-old-offer", ExpectVersion: &offered}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("accept of the old offer: err = %v, want ErrConflict", err)
+	}
+	current := store.orders[id].StatusVersion
+	if err := svc.Accept(ctx, AcceptCommand{OrderID: id, DriverID: "drv-new-offer", ExpectVersion: &current}); err != nil {
+		t.Fatalf("accept of the new offer: %v", err)
+	}
+	if o := store.orders[id]; o.Status != StatusApproaching || *o.DriverID != "drv-new-offer" {
+		t.Errorf("order %s with driver %v, want approaching with drv-new-offer", o.Status, o.DriverID)
+	}
+}
+
+// TestUnit_ChangePickup_RacesAccept runs edits and accepts of the offered
+// version concurrently: whichever commits first wins and the other is refused,
+// so no driver ends up on an order whose pickup moved after the offer.
+func TestUnit_ChangePickup_RacesAccept(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		svc, store, _, _ := newPickupSvc()
+		ctx := context.Background()
+		id := makeOrder(store, "pax-1", StatusWaiting)
+		offered := 0
+
+		var wg sync.WaitGroup
+		var editErr, acceptErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, editErr = svc.ChangePickup(ctx, ChangePickupCommand{OrderID: id, PassengerID: "pax-1", Pickup: newPickup})
+		}()
+		go func() {
+			defer wg.Done()
+			acceptErr = svc.Accept(ctx, AcceptCommand{OrderID: id, DriverID: "drv-1", ExpectVersion: &offered})
+		}()
+		wg.Wait()
+
+		if (editErr == nil) == (acceptErr == nil) {
+			t.Fatalf("run %d: edit err %v, accept err %v; want exactly one to succeed", i, editErr, acceptErr)
+		}
+		o := store.orders[id]
+		if acceptErr == nil {
+			if o.Pickup == newPickup || !errors.Is(editErr, ErrInvalidState) && !errors.Is(editErr, ErrConflict) {
+				t.Fatalf("run %d: accepted, yet pickup %v and edit err %v", i, o.Pickup, editErr)
+			}
+		} else if o.Status != StatusWaiting || o.Pickup != newPickup || !errors.Is(acceptErr, ErrConflict) {
+			t.Fatalf("run %d: edited, yet status %s pickup %v accept err %v", i, o.Status, o.Pickup, acceptErr)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Throttles
+// ---------------------------------------------------------------------------
+
+type fakeThrottleCounter struct {
+	actions map[string][]time.Time
+	err     error
+}
+
+func (f *fakeThrottleCounter) Recent(_ context.Context, key string, since time.Time) ([]time.Time, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var out []time.Time
+	for _, at := range f.actions[key] {
+		if at.After(since) {
+			out = append(out, at)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeThrottleCounter) Add(_ context.Context, key string, at time.Time, _ time.Duration) error {
+	f.actions[key] = append(f.actions[key], at)
+	return f.err
+}
+
+func TestUnit_Throttles_CreateAndCancel(t *testing.T) {
+	svc, _ := newTestSvc()
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	svc.SetThrottles(&fakeThrottleCounter{actions: map[string][]time.Time{}}, config.OrderThrottleConfig{MaxCreatesPerHour: 2, MaxCancelsPerDay: 2})
+	cmd := CreateCommand{
+		PassengerID: "pax-1",
+		Pickup:      types.Point{Lat: 25.033, Lng: 121.565},
+		Dropoff:     types.Point{Lat: 25.048, Lng: 121.532},
+		RideType:    "economy",
+		Rebook:      true,
+	}
+	createAndCancel := func() error {
+		id, err := svc.Create(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		return svc.Cancel(ctx, CancelCommand{OrderID: id, ActorType: ActorPassenger})
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := createAndCancel(); err != nil {
+			t.Fatalf("order %d: %v", i+1, err)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+	_, err := svc.Create(ctx, cmd)
+	var throttled *ThrottleError
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrCreateThrottled) || throttled.RetryAfter != 40*time.Minute {
+		t.Fatalf("third create = %v, want ErrCreateThrottled retrying in 40m", err)
+	}
+
+	// An hour after the first order creating is allowed again, but both
+	// cancellations still count for the day.
+	now = now.Add(40 * time.Minute)
+	id, err := svc.Create(ctx, cmd)
+	if err != nil {
+		t.Fatalf("create after the window: %v", err)
+	}
+	err = svc.Cancel(ctx, CancelCommand{OrderID: id, ActorType: ActorPassenger})
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrCancelLimit) {
+		t.Fatalf("third cancel = %v, want ErrCancelLimit", err)
+	}
+	if err := svc.Cancel(ctx, CancelCommand{OrderID: id, ActorType: ActorDriver}); err != nil {
+		t.Errorf("driver cancel = %v, want no passenger limit", err)
+	}
+}
+
+func TestUnit_Throttles_FailOpen(t *testing.T) {
+	svc, _ := newTestSvc()
+	svc.SetThrottles(&fakeThrottleCounter{actions: map[string][]time.Time{}, err: errors.New("redis down")}, config.OrderThrottleConfig{MaxCreatesPerHour: 1})
+	_, err := svc.Create(context.Background(), CreateCommand{
+		PassengerID: "pax-1",
+		Pickup:      types.Point{Lat: 25.033, Lng: 121.565},
+		Dropoff:     types.Point{Lat: 25.048, Lng: 121.532},
+		RideType:    "economy",
+	})
+	if err != nil {
+		t.Errorf("Create with the counter down = %v, want it allowed", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Duplicate detection
+// ---------------------------------------------------------------------------
+
+func TestUnit_Create_RebookAfterCancel(t *testing.T) {
+	svc, _ := newTestSvc()
+	ctx := context.Background()
+	cmd := CreateCommand{
+		PassengerID: "pax-dup",
+		Pickup:      types.Point{Lat: 25.033, Lng: 121.565},
+		Dropoff:     types.Point{Lat: 25.048, Lng: 121.532},
+		RideType:    "economy",
+	}
+	first, err := svc.Create(ctx, cmd)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := svc.Cancel(ctx, CancelCommand{OrderID: first, ActorType: ActorPassenger}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	// A few metres off is still the same trip.
+	again := cmd
+	again.Pickup.Lat += 0.0003
+	_, err = svc.Create(ctx, again)
+	var dup *DuplicateError
+	if !errors.As(err, &dup) || !errors.Is(err, ErrPossibleDuplicate) || dup.Of.ID != first || !dup.Rebookable() {
+		t.Fatalf("Create again = %v, want a rebookable DuplicateError of %s", err, first)
+	}
+
+	elsewhere := cmd
+	elsewhere.Dropoff = types.Point{Lat: 25.080, Lng: 121.560}
+	id, err := svc.Create(ctx, elsewhere)
+	if err != nil {
+		t.Fatalf("Create to another dropoff: %v", err)
+	}
+	if err := svc.Cancel(ctx, CancelCommand{OrderID: id, ActorType: ActorPassenger}); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	again.Rebook = true
+	if _, err := svc.Create(ctx, again); err != nil {
+		t.Errorf("confirmed rebook = %v, want it created", err)
+	}
+}
+
+func TestUnit_CreateScheduled_TwinRide(t *testing.T) {
+	svc, _ := newTestSvc()
+	ctx := context.Background()
+	at := time.Now().Add(3 * time.Hour).UTC()
+	cmd := CreateScheduledCommand{
+		PassengerID:        "pax-twin",
+		Pickup:             types.Point{Lat: 25.033, Lng: 121.565},
+		Dropoff:            types.Point{Lat: 25.048, Lng: 121.532},
+		RideType:           "economy",
+		ScheduledAt:        at,
+		ScheduleWindowMins: 30,
+	}
+	first, err := svc.CreateScheduled(ctx, cmd)
+	if err != nil {
+		t.Fatalf("CreateScheduled: %v", err)
+	}
+
+	cmd.ScheduledAt = at.Add(20 * time.Minute)
+	cmd.Rebook = true
+	_, err = svc.CreateScheduled(ctx, cmd)
+	var dup *DuplicateError
+	if !errors.As(err, &dup) || dup.Of.ID != first || dup.Rebookable() {
+		t.Fatalf("twin = %v, want a DuplicateError of %s that cannot be rebooked", err, first)
+	}
+
+	// The same trip on another day is not a twin, only a second active order.
+	cmd.ScheduledAt = at.Add(24 * time.Hour)
+	if _, err := svc.CreateScheduled(ctx, cmd); !errors.Is(err, ErrActiveOrder) {
+		t.Errorf("next day = %v, want ErrActiveOrder", err)
+	}
+}
@@ -5,10 +5,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"ark/internal/modules/ledger"
+	"ark/internal/pii"
 	"ark/internal/types"
 )
 
@@ -23,24 +27,87 @@ var activeStatuses = []Status{
 	StatusPayment,
 }
 
+// Column lists for the scanners below. A query selecting one of them must
+// be read with its scanner, so a column is added or moved in both at once.
+const (
+	// orderColumns is every column of an order, read by scanOrder.
+	orderColumns = `id, passenger_id, driver_id, status, status_version,
+               pickup_lat, pickup_lng, dropoff_lat, dropoff_lng,
+               ride_type, estimated_fee, actual_fee,
+               created_at, matched_at, accepted_at, started_at, completed_at, cancelled_at, cancellation_reason,
+               order_type, scheduled_at, schedule_window_mins, cancel_deadline_at, incentive_bonus, assigned_at,
+               sandbox, notes, requirements, passenger_count, has_pet,
+               transit_type, transit_number, org_id, credits_applied,
+               original_dropoff_lat, original_dropoff_lng,
+               pending_dropoff_lat, pending_dropoff_lng, pending_fee, dropoff_requested_at, currency, pricing_version,
+               requote_fee, requote_pricing_version, requoted_at, conversation_id, arrive_by,
+               arrival_window_from, arrival_window_to, arrived_at, COALESCE(region_id, ''), priority, cancel_fee,
+               subscription_applied, recipient_name, recipient_phone, parcel_weight_class, delivery_signed_by,
+               car_plate, car_model, car_color, car_transmission, return_allowance, charter_hours, overtime_fee`
+
+	// orderSummaryColumns is the subset listed to drivers and passengers,
+	// read by scanOrderSummary. Queries that must hide the passenger's notes
+	// select orderSummaryHead followed by an empty notes column instead.
+	orderSummaryColumns = orderSummaryHead + `, notes`
+	orderSummaryHead    = `id, passenger_id, driver_id, status, status_version,
+               pickup_lat, pickup_lng, dropoff_lat, dropoff_lng,
+               ride_type, estimated_fee, currency, pricing_version,
+               created_at, scheduled_at, cancel_deadline_at, incentive_bonus, assigned_at,
+               order_type, schedule_window_mins, requirements, passenger_count, has_pet,
+               COALESCE(region_id, '')`
+)
+
 type Store struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	pii *pii.Keyring
 }
 
 func NewStore(db *pgxpool.Pool) *Store {
 	return &Store{db: db}
 }
 
+// SetKeyring enables encryption of a delivery's recipient and signer; nil
+// keeps plaintext.
+func (s *Store) SetKeyring(k *pii.Keyring) {
+	s.pii = k
+}
+
 func (s *Store) Create(ctx context.Context, o *Order) error {
+	var recipientName, recipientPhone, weightClass *string
+	if d := o.Delivery; d != nil {
+		name, err := s.pii.Encrypt(d.RecipientName)
+		if err != nil {
+			return err
+		}
+		phone, err := s.pii.Encrypt(d.RecipientPhone)
+		if err != nil {
+			return err
+		}
+		recipientName, recipientPhone, weightClass = &name, &phone, &d.WeightClass
+	}
+	var carPlate, carModel, carColor, carTransmission *string
+	if c := o.Car; c != nil {
+		carPlate, carModel, carColor, carTransmission = &c.Plate, &c.Model, &c.Color, &c.Transmission
+	}
 	_, err := s.db.Exec(ctx, `
         INSERT INTO orders (
             id, passenger_id, driver_id, status, status_version,
             pickup_lat, pickup_lng, dropoff_lat, dropoff_lng,
-            ride_type, estimated_fee, actual_fee, order_type, created_at
+            ride_type, estimated_fee, actual_fee, order_type, created_at, sandbox,
+            notes, requirements, passenger_count, has_pet, org_id, currency, pricing_version,
+            conversation_id, region_id, priority,
+            recipient_name, recipient_phone, parcel_weight_class,
+            car_plate, car_model, car_color, car_transmission, return_allowance,
+            charter_hours
         ) VALUES (
             $1, $2, $3, $4, $5,
             $6, $7, $8, $9,
-            $10, $11, $12, $13, $14
+            $10, $11, $12, $13, $14, $15,
+            $16, $17, $18, $19, $20, $21, $22,
+            NULLIF($23, ''), NULLIF($24, ''), $25,
+            $26, $27, $28,
+            $29, $30, $31, $32, $33,
+            $34
         )`,
 		string(o.ID),
 		string(o.PassengerID),
@@ -54,23 +121,46 @@ func (s *Store) Create(ctx context.Context, o *Order) error {
 		toIntPtr(o.ActualFee),
 		o.OrderType,
 		o.CreatedAt,
+		o.Sandbox,
+		o.Notes,
+		nonNilStrings(o.Requirements),
+		max(o.PassengerCount, 1),
+		o.HasPet,
+		toStringPtr(o.OrgID),
+		currencyOf(o.EstimatedFee),
+		o.PricingVersion,
+		o.ConversationID,
+		o.RegionID,
+		o.Priority,
+		recipientName,
+		recipientPhone,
+		weightClass,
+		carPlate,
+		carModel,
+		carColor,
+		carTransmission,
+		o.ReturnAllowance,
+		o.CharterHours,
 	)
 	return err
 }
 
 func (s *Store) Get(ctx context.Context, id types.ID) (*Order, error) {
-	row := s.db.QueryRow(ctx, `
-        SELECT id, passenger_id, driver_id, status, status_version,
-               pickup_lat, pickup_lng, dropoff_lat, dropoff_lng,
-               ride_type, estimated_fee, actual_fee,
-               created_at, matched_at, accepted_at, started_at, completed_at, cancelled_at, cancellation_reason,
-               order_type, scheduled_at, schedule_window_mins, cancel_deadline_at, incentive_bonus, assigned_at
+	o, err := s.scanOrder(s.db.QueryRow(ctx, `
+        SELECT `+orderColumns+`
         FROM orders
         WHERE id = $1`, string(id),
-	)
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, &NotFoundError{OrderID: id}
+	}
+	return o, err
+}
 
+// scanOrder reads a row selected with orderColumns.
+func (s *Store) scanOrder(row pgx.Row) (*Order, error) {
 	var o Order
-	var driverID sql.NullString
+	var driverID, orgID sql.NullString
 	var actualFee sql.NullInt64
 	var matchedAt, acceptedAt, startedAt, completedAt, cancelledAt sql.NullTime
 	var cancelReason sql.NullString
@@ -78,6 +168,17 @@ func (s *Store) Get(ctx context.Context, id types.ID) (*Order, error) {
 	var scheduledAt, cancelDeadlineAt, assignedAt sql.NullTime
 	var scheduleWindowMins sql.NullInt32
 	var incentiveBonus sql.NullInt64
+	var origLat, origLng, pendLat, pendLng sql.NullFloat64
+	var pendFee sql.NullInt64
+	var dropoffRequestedAt sql.NullTime
+	var requoteFee sql.NullInt64
+	var requoteVersion sql.NullInt32
+	var requotedAt sql.NullTime
+	var conversationID sql.NullString
+	var arriveBy sql.NullTime
+	var windowFrom, windowTo, arrivedAt sql.NullTime
+	var recipientName, recipientPhone, weightClass, signedBy sql.NullString
+	var carPlate, carModel, carColor, carTransmission sql.NullString
 
 	err := row.Scan(
 		&o.ID, &o.PassengerID, &driverID, &o.Status, &o.StatusVersion,
@@ -85,10 +186,15 @@ func (s *Store) Get(ctx context.Context, id types.ID) (*Order, error) {
 		&o.RideType, &o.EstimatedFee.Amount, &actualFee,
 		&o.CreatedAt, &matchedAt, &acceptedAt, &startedAt, &completedAt, &cancelledAt, &cancelReason,
 		&orderType, &scheduledAt, &scheduleWindowMins, &cancelDeadlineAt, &incentiveBonus, &assignedAt,
+		&o.Sandbox, &o.Notes, &o.Requirements, &o.PassengerCount, &o.HasPet,
+		&o.TransitType, &o.TransitNumber, &orgID, &o.CreditsApplied,
+		&origLat, &origLng,
+		&pendLat, &pendLng, &pendFee, &dropoffRequestedAt, &o.EstimatedFee.Currency, &o.PricingVersion,
+		&requoteFee, &requoteVersion, &requotedAt, &conversationID, &arriveBy,
+		&windowFrom, &windowTo, &arrivedAt, &o.RegionID, &o.Priority, &o.CancelFee,
+		&o.SubscriptionApplied, &recipientName, &recipientPhone, &weightClass, &signedBy,
+		&carPlate, &carModel, &carColor, &carTransmission, &o.ReturnAllowance, &o.CharterHours, &o.OvertimeFee,
 	)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, ErrNotFound
-	}
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +203,17 @@ func (s *Store) Get(ctx context.Context, id types.ID) (*Order, error) {
 		d := types.ID(driverID.String)
 		o.DriverID = &d
 	}
+	if orgID.Valid {
+		id := types.ID(orgID.String)
+		o.OrgID = &id
+	}
+	o.CreatedAt = o.CreatedAt.UTC()
+	o.ConversationID = conversationID.String
+	o.ArriveBy = toTimePtr(arriveBy)
+	if windowFrom.Valid && windowTo.Valid {
+		o.ArrivalWindow = &ArrivalWindow{From: windowFrom.Time.UTC(), To: windowTo.Time.UTC()}
+	}
+	o.ArrivedAt = toTimePtr(arrivedAt)
 	if actualFee.Valid {
 		v := types.Money{Amount: actualFee.Int64, Currency: o.EstimatedFee.Currency}
 		o.ActualFee = &v
@@ -109,9 +226,6 @@ func (s *Store) Get(ctx context.Context, id types.ID) (*Order, error) {
 	if cancelReason.Valid {
 		o.CancelReason = &cancelReason.String
 	}
-	if o.EstimatedFee.Currency == "" {
-		o.EstimatedFee.Currency = "TWD"
-	}
 	if orderType.Valid {
 		o.OrderType = orderType.String
 	}
@@ -125,6 +239,44 @@ func (s *Store) Get(ctx context.Context, id types.ID) (*Order, error) {
 	if incentiveBonus.Valid {
 		o.IncentiveBonus = incentiveBonus.Int64
 	}
+	if origLat.Valid && origLng.Valid {
+		o.OriginalDropoff = &types.Point{Lat: origLat.Float64, Lng: origLng.Float64}
+	}
+	if pendLat.Valid && pendLng.Valid && pendFee.Valid && dropoffRequestedAt.Valid {
+		o.PendingDropoff = &DropoffProposal{
+			Dropoff:     types.Point{Lat: pendLat.Float64, Lng: pendLng.Float64},
+			Fee:         types.Money{Amount: pendFee.Int64, Currency: o.EstimatedFee.Currency},
+			RequestedAt: dropoffRequestedAt.Time.UTC(),
+		}
+	}
+	if requoteFee.Valid && requotedAt.Valid {
+		o.PendingRequote = &RequoteProposal{
+			Fee:         types.Money{Amount: requoteFee.Int64, Currency: o.EstimatedFee.Currency},
+			RuleVersion: int(requoteVersion.Int32),
+			RequestedAt: requotedAt.Time.UTC(),
+		}
+	}
+	if weightClass.Valid {
+		d := &Delivery{WeightClass: weightClass.String}
+		if d.RecipientName, err = s.pii.Decrypt(recipientName.String); err != nil {
+			return nil, err
+		}
+		if d.RecipientPhone, err = s.pii.Decrypt(recipientPhone.String); err != nil {
+			return nil, err
+		}
+		if d.SignedBy, err = s.pii.Decrypt(signedBy.String); err != nil {
+			return nil, err
+		}
+		o.Delivery = d
+	}
+	if carPlate.Valid {
+		o.Car = &Car{
+			Plate:        carPlate.String,
+			Model:        carModel.String,
+			Color:        carColor.String,
+			Transmission: carTransmission.String,
+		}
+	}
 	return &o, nil
 }
 
@@ -143,6 +295,7 @@ func (s *Store) UpdateStatus(ctx context.Context, id types.ID, from, to Status,
             accepted_at = CASE WHEN $1 = 'approaching' THEN NOW() ELSE accepted_at END,
             started_at = CASE WHEN $1 = 'driving' THEN NOW() ELSE started_at END,
             completed_at = CASE WHEN $1 IN ('payment','complete') THEN NOW() ELSE completed_at END,
+            arrived_at = CASE WHEN $1 = 'payment' THEN NOW() ELSE arrived_at END,
             cancelled_at = CASE WHEN $1 = 'cancelled' THEN NOW() ELSE cancelled_at END
         WHERE id = $3 AND status = $4 AND status_version = $5`,
 		string(to),
@@ -191,6 +344,48 @@ func (s *Store) HasActiveByPassenger(ctx context.Context, passengerID types.ID)
 	return exists, nil
 }
 
+// ListRebookCandidates implements OrderStore.
+func (s *Store) ListRebookCandidates(ctx context.Context, passengerID types.ID, cancelledSince time.Time) ([]*Order, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT `+orderColumns+`
+        FROM orders
+        WHERE passenger_id = $1
+          AND ((status = 'cancelled' AND cancelled_at >= $2) OR status IN ('scheduled', 'assigned'))
+        ORDER BY created_at DESC`, string(passengerID), cancelledSince,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*Order, error) { return s.scanOrder(row) })
+}
+
+// ListByDriver returns the driver's orders whose status is one of statuses,
+// soonest first (scheduled time, or creation time for instant orders).
+func (s *Store) ListByDriver(ctx context.Context, driverID types.ID, statuses []Status) ([]*Order, error) {
+	sts := make([]string, len(statuses))
+	for i, st := range statuses {
+		sts[i] = string(st)
+	}
+	rows, err := s.db.Query(ctx, `
+        SELECT `+orderSummaryColumns+`
+        FROM orders
+        WHERE driver_id = $1 AND status = ANY($2)
+        ORDER BY COALESCE(scheduled_at, created_at) ASC`, string(driverID), sts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanOrderSummary)
+}
+
+// nonNilStrings keeps NOT NULL array columns from receiving a nil slice, which pgx encodes as NULL.
+func nonNilStrings(v []string) []string {
+	if v == nil {
+		return []string{}
+	}
+	return v
+}
+
 func toStringPtr(v *types.ID) *string {
 	if v == nil {
 		return nil
@@ -207,11 +402,35 @@ func toIntPtr(v *types.Money) *int64 {
 	return &n
 }
 
+// currencyOf returns m's currency, defaulting to the platform currency.
+func currencyOf(m types.Money) string {
+	if m.Currency == "" {
+		return types.DefaultCurrency
+	}
+	return m.Currency
+}
+
+func arrivalFrom(w *ArrivalWindow) *time.Time {
+	if w == nil {
+		return nil
+	}
+	return &w.From
+}
+
+func arrivalTo(w *ArrivalWindow) *time.Time {
+	if w == nil {
+		return nil
+	}
+	return &w.To
+}
+
+// toTimePtr converts a nullable column to UTC like every time the service
+// works with, whatever the session time zone is.
 func toTimePtr(v sql.NullTime) *time.Time {
 	if !v.Valid {
 		return nil
 	}
-	t := v.Time
+	t := v.Time.UTC()
 	return &t
 }
 
@@ -223,13 +442,19 @@ func (s *Store) CreateScheduled(ctx context.Context, o *Order) error {
             pickup_lat, pickup_lng, dropoff_lat, dropoff_lng,
             ride_type, estimated_fee, order_type,
             scheduled_at, schedule_window_mins, cancel_deadline_at, incentive_bonus,
-            created_at
+            created_at, sandbox, notes, requirements, passenger_count, has_pet,
+            transit_type, transit_number, org_id, currency, pricing_version,
+            conversation_id, arrive_by, arrival_window_from, arrival_window_to, region_id,
+            priority
         ) VALUES (
             $1, $2, $3, $4,
             $5, $6, $7, $8,
             $9, $10, $11,
             $12, $13, $14, $15,
-            $16
+            $16, $17, $18, $19, $20, $21,
+            $22, $23, $24, $25, $26,
+            NULLIF($27, ''), $28, $29, $30, NULLIF($31, ''),
+            $32
         )`,
 		string(o.ID),
 		string(o.PassengerID),
@@ -245,6 +470,22 @@ func (s *Store) CreateScheduled(ctx context.Context, o *Order) error {
 		o.CancelDeadlineAt,
 		o.IncentiveBonus,
 		o.CreatedAt,
+		o.Sandbox,
+		o.Notes,
+		nonNilStrings(o.Requirements),
+		max(o.PassengerCount, 1),
+		o.HasPet,
+		o.TransitType,
+		o.TransitNumber,
+		toStringPtr(o.OrgID),
+		currencyOf(o.EstimatedFee),
+		o.PricingVersion,
+		o.ConversationID,
+		o.ArriveBy,
+		arrivalFrom(o.ArrivalWindow),
+		arrivalTo(o.ArrivalWindow),
+		o.RegionID,
+		o.Priority,
 	)
 	return err
 }
@@ -252,11 +493,7 @@ func (s *Store) CreateScheduled(ctx context.Context, o *Order) error {
 // ListScheduledByPassenger returns all scheduled-type orders for a passenger, newest first.
 func (s *Store) ListScheduledByPassenger(ctx context.Context, passengerID types.ID) ([]*Order, error) {
 	rows, err := s.db.Query(ctx, `
-        SELECT id, passenger_id, driver_id, status, status_version,
-               pickup_lat, pickup_lng, dropoff_lat, dropoff_lng,
-               ride_type, estimated_fee,
-               created_at, scheduled_at, cancel_deadline_at, incentive_bonus, assigned_at,
-               order_type, schedule_window_mins
+        SELECT `+orderSummaryColumns+`
         FROM orders
         WHERE passenger_id = $1 AND order_type = 'scheduled'
         ORDER BY created_at DESC`, string(passengerID),
@@ -264,29 +501,22 @@ func (s *Store) ListScheduledByPassenger(ctx context.Context, passengerID types.
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	return scanOrderRows(rows)
+	return pgx.CollectRows(rows, scanOrderSummary)
 }
 
 // ListAvailableScheduled returns open (status='scheduled') orders within the given time window.
-// driver_id is included in the SELECT for consistency with scanOrderRows; it will be NULL
-// for unclaimed scheduled orders.
 func (s *Store) ListAvailableScheduled(ctx context.Context, from, to time.Time) ([]*Order, error) {
 	rows, err := s.db.Query(ctx, `
-        SELECT id, passenger_id, driver_id, status, status_version,
-               pickup_lat, pickup_lng, dropoff_lat, dropoff_lng,
-               ride_type, estimated_fee,
-               created_at, scheduled_at, cancel_deadline_at, incentive_bonus, assigned_at,
-               order_type, schedule_window_mins
+        SELECT `+orderSummaryHead+`,
+               '' AS notes -- notes are for the assigned driver only
         FROM orders
-        WHERE status = 'scheduled' AND scheduled_at BETWEEN $1 AND $2
+        WHERE status = 'scheduled' AND scheduled_at BETWEEN $1 AND $2 AND NOT sandbox
         ORDER BY scheduled_at ASC`, from, to,
 	)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	return scanOrderRows(rows)
+	return pgx.CollectRows(rows, scanOrderSummary)
 }
 
 // ClaimScheduled atomically moves a scheduled order from 'scheduled' to 'assigned' for a driver.
@@ -309,6 +539,382 @@ func (s *Store) ClaimScheduled(ctx context.Context, orderID, driverID types.ID,
 	return tag.RowsAffected() == 1, nil
 }
 
+// UpdateSchedule moves a scheduled or assigned order's pickup time and free-cancel deadline.
+// Returns (false, nil) if the optimistic-lock check failed.
+func (s *Store) UpdateSchedule(ctx context.Context, orderID types.ID, expectVersion int, scheduledAt, cancelDeadlineAt time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+        UPDATE orders
+        SET scheduled_at = $1,
+            cancel_deadline_at = $2,
+            status_version = status_version + 1
+        WHERE id = $3 AND status IN ('scheduled', 'assigned') AND status_version = $4`,
+		scheduledAt,
+		cancelDeadlineAt,
+		string(orderID),
+		expectVersion,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdatePickup moves the pickup of a waiting order and stores its new region
+// and fare. Returns (false, nil) if the optimistic-lock check failed.
+func (s *Store) UpdatePickup(ctx context.Context, orderID types.ID, expectVersion int, pickup types.Point, regionID string, fee Quote) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+        UPDATE orders
+        SET pickup_lat = $1,
+            pickup_lng = $2,
+            region_id = NULLIF($3, ''),
+            estimated_fee = $4,
+            currency = $5,
+            pricing_version = $6,
+            return_allowance = $9,
+            status_version = status_version + 1
+        WHERE id = $7 AND status = 'waiting' AND status_version = $8`,
+		pickup.Lat,
+		pickup.Lng,
+		regionID,
+		fee.Fare.Amount,
+		fee.Fare.Currency,
+		fee.RuleVersion,
+		string(orderID),
+		expectVersion,
+		fee.ReturnAllowance,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ProposeDropoff stores a passenger's proposed dropoff and its fare on a
+// driving order, replacing any earlier proposal.
+func (s *Store) ProposeDropoff(ctx context.Context, orderID types.ID, expectVersion int, dropoff types.Point, fee Quote, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+        UPDATE orders
+        SET pending_dropoff_lat = $1,
+            pending_dropoff_lng = $2,
+            pending_fee = $3,
+            pending_pricing_version = $4,
+            dropoff_requested_at = $5,
+            status_version = status_version + 1
+        WHERE id = $6 AND status = 'driving' AND status_version = $7`,
+		dropoff.Lat,
+		dropoff.Lng,
+		fee.Fare.Amount,
+		fee.RuleVersion,
+		at,
+		string(orderID),
+		expectVersion,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ResolveDropoff clears the pending dropoff of a driving order. On accept the
+// proposal replaces the dropoff and estimated fee, keeping the first dropoff
+// in original_dropoff_*.
+func (s *Store) ResolveDropoff(ctx context.Context, orderID types.ID, expectVersion int, accept bool) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+        UPDATE orders
+        SET original_dropoff_lat = CASE WHEN $1 THEN COALESCE(original_dropoff_lat, dropoff_lat) ELSE original_dropoff_lat END,
+            original_dropoff_lng = CASE WHEN $1 THEN COALESCE(original_dropoff_lng, dropoff_lng) ELSE original_dropoff_lng END,
+            dropoff_lat = CASE WHEN $1 THEN pending_dropoff_lat ELSE dropoff_lat END,
+            dropoff_lng = CASE WHEN $1 THEN pending_dropoff_lng ELSE dropoff_lng END,
+            estimated_fee = CASE WHEN $1 THEN pending_fee ELSE estimated_fee END,
+            pricing_version = CASE WHEN $1 THEN COALESCE(pending_pricing_version, 0) ELSE pricing_version END,
+            pending_dropoff_lat = NULL,
+            pending_dropoff_lng = NULL,
+            pending_fee = NULL,
+            pending_pricing_version = NULL,
+            dropoff_requested_at = NULL,
+            status_version = status_version + 1
+        WHERE id = $2 AND status = 'driving' AND status_version = $3 AND pending_dropoff_lat IS NOT NULL`,
+		accept,
+		string(orderID),
+		expectVersion,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ListRequoteCandidates returns live scheduled or assigned orders picking up
+// after now with no re-quote awaiting the passenger.
+func (s *Store) ListRequoteCandidates(ctx context.Context, now time.Time) ([]*Order, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT `+orderSummaryColumns+`
+        FROM orders
+        WHERE status IN ('scheduled', 'assigned') AND scheduled_at > $1
+          AND requote_fee IS NULL AND NOT sandbox
+        ORDER BY scheduled_at ASC`, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanOrderSummary)
+}
+
+// ProposeRequote stores a higher fare for a scheduled or assigned order
+// pending the passenger's consent.
+func (s *Store) ProposeRequote(ctx context.Context, orderID types.ID, expectVersion int, q Quote, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+        UPDATE orders
+        SET requote_fee = $1,
+            requote_pricing_version = $2,
+            requoted_at = $3,
+            status_version = status_version + 1
+        WHERE id = $4 AND status IN ('scheduled', 'assigned') AND status_version = $5`,
+		q.Fare.Amount,
+		q.RuleVersion,
+		at,
+		string(orderID),
+		expectVersion,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdateQuote replaces the estimated fee and pricing version of a scheduled
+// or assigned order and clears any pending re-quote.
+func (s *Store) UpdateQuote(ctx context.Context, orderID types.ID, expectVersion int, q Quote) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+        UPDATE orders
+        SET estimated_fee = $1,
+            pricing_version = $2,
+            requote_fee = NULL,
+            requote_pricing_version = NULL,
+            requoted_at = NULL,
+            status_version = status_version + 1
+        WHERE id = $3 AND status IN ('scheduled', 'assigned') AND status_version = $4`,
+		q.Fare.Amount,
+		q.RuleVersion,
+		string(orderID),
+		expectVersion,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// SetCreditsApplied records the passenger credits taken off an order's fare.
+// Like every change to what is due it bumps status_version, so cached status
+// ETags go stale.
+func (s *Store) SetCreditsApplied(ctx context.Context, orderID types.ID, amount int64) error {
+	_, err := s.db.Exec(ctx, `
+        UPDATE orders SET credits_applied = $1, status_version = status_version + 1 WHERE id = $2`,
+		amount, string(orderID),
+	)
+	return err
+}
+
+// SetSubscriptionApplied records what the passenger's ride plan took off an
+// order's fare and bumps status_version.
+func (s *Store) SetSubscriptionApplied(ctx context.Context, orderID types.ID, amount int64) error {
+	_, err := s.db.Exec(ctx, `
+        UPDATE orders SET subscription_applied = $1, status_version = status_version + 1 WHERE id = $2`,
+		amount, string(orderID),
+	)
+	return err
+}
+
+// CancelCharged cancels an order like UpdateStatus and, in the same
+// transaction, records the passenger's cancellation fee, credits the driver's
+// share to their earnings (earnings.KindCancelFee) and books c.Txn in the
+// ledger.
+func (s *Store) CancelCharged(ctx context.Context, id types.ID, from Status, version int, c CancelCharge) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE orders
+        SET status = 'cancelled',
+            status_version = status_version + 1,
+            cancelled_at = NOW(),
+            cancel_fee = $4
+        WHERE id = $1 AND status = $2 AND status_version = $3`,
+		string(id), string(from), version, c.Fee,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO driver_earnings (driver_id, amount, kind, ref, note, created_at, gross, commission, commission_bps, commission_rule)
+        VALUES ($1, $2, 'cancel_fee', $3, 'cancellation fee', $4, $5, $6, $7, $8)
+        ON CONFLICT (driver_id, kind, ref) DO NOTHING`,
+		string(c.DriverID), c.Fee-c.Split.Commission, string(id), c.At,
+		c.Fee, c.Split.Commission, c.Split.CommissionBps, (*string)(c.Split.CommissionRule),
+	); err != nil {
+		return false, err
+	}
+	if _, err := ledger.PostTx(ctx, tx, c.Txn); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// SetDeliverySignature records who signed for a delivered parcel.
+func (s *Store) SetDeliverySignature(ctx context.Context, orderID types.ID, signedBy string) error {
+	sealed, err := s.pii.Encrypt(signedBy)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, `UPDATE orders SET delivery_signed_by = $2 WHERE id = $1`, string(orderID), sealed)
+	return err
+}
+
+// AddStop logs a stop of a charter and sets its ID.
+func (s *Store) AddStop(ctx context.Context, stop *Stop) error {
+	return s.db.QueryRow(ctx, `
+        INSERT INTO order_stops (order_id, lat, lng, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id`,
+		string(stop.OrderID), stop.Position.Lat, stop.Position.Lng, stop.At,
+	).Scan(&stop.ID)
+}
+
+// ListStops returns the stops logged on a charter, oldest first.
+func (s *Store) ListStops(ctx context.Context, orderID types.ID) ([]Stop, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT id, order_id, lat, lng, created_at
+        FROM order_stops
+        WHERE order_id = $1
+        ORDER BY created_at, id`, string(orderID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Stop{}
+	for rows.Next() {
+		var st Stop
+		if err := rows.Scan(&st.ID, &st.OrderID, &st.Position.Lat, &st.Position.Lng, &st.At); err != nil {
+			return nil, err
+		}
+		st.At = st.At.UTC()
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// SetOvertimeFee records a charter's overtime fee and makes the booked fare
+// plus it the actual fare, bumping status_version.
+func (s *Store) SetOvertimeFee(ctx context.Context, orderID types.ID, amount int64) error {
+	_, err := s.db.Exec(ctx, `
+        UPDATE orders
+        SET overtime_fee = $1, actual_fee = estimated_fee + $1, status_version = status_version + 1
+        WHERE id = $2`,
+		amount, string(orderID),
+	)
+	return err
+}
+
+// ListTransitPickups returns scheduled or assigned flight/train pickups due in [from, to].
+func (s *Store) ListTransitPickups(ctx context.Context, from, to time.Time) ([]TransitPickup, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT id, transit_type, transit_number, scheduled_at, transit_eta
+        FROM orders
+        WHERE status IN ('scheduled', 'assigned')
+          AND transit_number <> ''
+          AND scheduled_at BETWEEN $1 AND $2
+          AND NOT sandbox
+        ORDER BY scheduled_at ASC`, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TransitPickup
+	for rows.Next() {
+		var p TransitPickup
+		var eta sql.NullTime
+		if err := rows.Scan(&p.OrderID, &p.TransitType, &p.TransitNumber, &p.ScheduledAt, &eta); err != nil {
+			return nil, err
+		}
+		p.ScheduledAt = p.ScheduledAt.UTC()
+		p.ETA = toTimePtr(eta)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ListArriveByPickups returns scheduled or assigned pickups with an arrive-by
+// time that are due in [from, to].
+func (s *Store) ListArriveByPickups(ctx context.Context, from, to time.Time) ([]ArriveByPickup, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT id, passenger_id, pickup_lat, pickup_lng, dropoff_lat, dropoff_lng, scheduled_at, arrive_by
+        FROM orders
+        WHERE status IN ('scheduled', 'assigned')
+          AND arrive_by IS NOT NULL
+          AND scheduled_at BETWEEN $1 AND $2
+          AND NOT sandbox
+        ORDER BY scheduled_at ASC`, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ArriveByPickup
+	for rows.Next() {
+		var p ArriveByPickup
+		if err := rows.Scan(&p.OrderID, &p.PassengerID, &p.Pickup.Lat, &p.Pickup.Lng, &p.Dropoff.Lat, &p.Dropoff.Lng, &p.ScheduledAt, &p.ArriveBy); err != nil {
+			return nil, err
+		}
+		p.ScheduledAt, p.ArriveBy = p.ScheduledAt.UTC(), p.ArriveBy.UTC()
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ListAssignedPickups returns assigned scheduled orders picking up after now
+// whose schedule window (cancel_deadline_at) has started by now.
+func (s *Store) ListAssignedPickups(ctx context.Context, now time.Time) ([]AssignedPickup, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT id, passenger_id, driver_id, pickup_lat, pickup_lng, scheduled_at, cancel_deadline_at
+        FROM orders
+        WHERE status = 'assigned'
+          AND driver_id IS NOT NULL
+          AND cancel_deadline_at <= $1
+          AND scheduled_at > $1
+          AND NOT sandbox
+        ORDER BY scheduled_at ASC`, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AssignedPickup
+	for rows.Next() {
+		var p AssignedPickup
+		if err := rows.Scan(&p.OrderID, &p.PassengerID, &p.DriverID, &p.Pickup.Lat, &p.Pickup.Lng, &p.ScheduledAt, &p.WindowStart); err != nil {
+			return nil, err
+		}
+		p.ScheduledAt, p.WindowStart = p.ScheduledAt.UTC(), p.WindowStart.UTC()
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SetTransitETA stores the latest provider arrival estimate for a transit pickup.
+func (s *Store) SetTransitETA(ctx context.Context, orderID types.ID, eta time.Time) error {
+	_, err := s.db.Exec(ctx, `UPDATE orders SET transit_eta = $1 WHERE id = $2`, eta, string(orderID))
+	return err
+}
+
 // ReopenScheduled moves an 'assigned' order back to 'scheduled' (driver cancel),
 // clears the driver assignment, and adds bonus to incentive_bonus.
 // Returns (false, nil) if the optimistic-lock check failed.
@@ -348,95 +954,190 @@ func (s *Store) BumpIncentiveBonusForApproaching(ctx context.Context, bump int64
 }
 
 // ExpireOverdueScheduled marks scheduled orders as 'expired' when scheduled_at has passed
-// the end of their schedule_window_mins without being claimed.
+// the end of their schedule_window_mins without being claimed, or without a driver
+// accepting them after they were handed to instant dispatch.
 func (s *Store) ExpireOverdueScheduled(ctx context.Context) error {
 	_, err := s.db.Exec(ctx, `
-        WITH expired_orders AS (
-            UPDATE orders
-            SET status = 'expired',
-                status_version = status_version + 1
-            WHERE status = 'scheduled'
+        WITH overdue AS (
+            SELECT id, status
+            FROM orders
+            WHERE order_type = 'scheduled' AND status IN ('scheduled', 'waiting')
               AND scheduled_at + (schedule_window_mins * INTERVAL '1 minute') < NOW()
-            RETURNING id
+            FOR UPDATE
+        ),
+        expired_orders AS (
+            UPDATE orders o
+            SET status = 'expired',
+                status_version = o.status_version + 1
+            FROM overdue
+            WHERE o.id = overdue.id
+            RETURNING o.id, overdue.status
         )
         INSERT INTO order_state_events (order_id, from_status, to_status, actor_type, created_at)
-        SELECT id, 'scheduled', 'expired', 'system', NOW()
+        SELECT id, status, 'expired', 'system', NOW()
         FROM expired_orders`,
 	)
 	return err
 }
 
+// ListUnclaimedScheduled returns the scheduled orders still unclaimed that pick
+// up at or before before, earliest first.
+func (s *Store) ListUnclaimedScheduled(ctx context.Context, before time.Time) ([]*Order, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT `+orderSummaryColumns+`
+        FROM orders
+        WHERE status = 'scheduled' AND scheduled_at <= $1
+        ORDER BY scheduled_at ASC`, before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanOrderSummary)
+}
+
 // ListUrgentPendingOrders returns all orders with status 'scheduled' or 'waiting' that have
 // not yet passed their scheduled time, ordered by urgency (earliest scheduled_at / created_at first).
 // This is used by the matching module to find orders that need driver notification.
 func (s *Store) ListUrgentPendingOrders(ctx context.Context) ([]*Order, error) {
 	rows, err := s.db.Query(ctx, `
-        SELECT id, passenger_id, driver_id, status, status_version,
-               pickup_lat, pickup_lng, dropoff_lat, dropoff_lng,
-               ride_type, estimated_fee,
-               created_at, scheduled_at, cancel_deadline_at, incentive_bonus, assigned_at,
-               order_type, schedule_window_mins
+        SELECT `+orderSummaryColumns+`
         FROM orders
         WHERE status IN ('scheduled', 'waiting')
           AND (scheduled_at IS NULL OR scheduled_at > NOW())
+          AND NOT sandbox
         ORDER BY COALESCE(scheduled_at, created_at) ASC`)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	return scanOrderRows(rows)
+	return pgx.CollectRows(rows, scanOrderSummary)
 }
 
-// scanOrderRows is a helper that scans the subset of columns returned by ListScheduledByPassenger
-// and ListAvailableScheduled.
-func scanOrderRows(rows interface {
-	Next() bool
-	Scan(...any) error
-	Err() error
-}) ([]*Order, error) {
-	var orders []*Order
+// scanOrderSummary reads a row selected with orderSummaryColumns.
+func scanOrderSummary(row pgx.CollectableRow) (*Order, error) {
+	var o Order
+	var driverID sql.NullString
+	var scheduledAt, cancelDeadlineAt, assignedAt sql.NullTime
+	var scheduleWindowMins sql.NullInt32
+	var incentiveBonus sql.NullInt64
+	var orderType sql.NullString
+
+	err := row.Scan(
+		&o.ID, &o.PassengerID, &driverID, &o.Status, &o.StatusVersion,
+		&o.Pickup.Lat, &o.Pickup.Lng, &o.Dropoff.Lat, &o.Dropoff.Lng,
+		&o.RideType, &o.EstimatedFee.Amount, &o.EstimatedFee.Currency, &o.PricingVersion,
+		&o.CreatedAt, &scheduledAt, &cancelDeadlineAt, &incentiveBonus, &assignedAt,
+		&orderType, &scheduleWindowMins, &o.Requirements, &o.PassengerCount, &o.HasPet,
+		&o.RegionID, &o.Notes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if driverID.Valid {
+		d := types.ID(driverID.String)
+		o.DriverID = &d
+	}
+	o.CreatedAt = o.CreatedAt.UTC()
+	o.ScheduledAt = toTimePtr(scheduledAt)
+	o.CancelDeadlineAt = toTimePtr(cancelDeadlineAt)
+	o.AssignedAt = toTimePtr(assignedAt)
+	if scheduleWindowMins.Valid {
+		v := int(scheduleWindowMins.Int32)
+		o.ScheduleWindowMins = &v
+	}
+	if incentiveBonus.Valid {
+		o.IncentiveBonus = incentiveBonus.Int64
+	}
+	if orderType.Valid {
+		o.OrderType = orderType.String
+	}
+	return &o, nil
+}
+
+// WAVFulfillment summarises the non-sandbox wheelchair orders created in
+// [from, to) per region, busiest region first.
+func (s *Store) WAVFulfillment(ctx context.Context, from, to time.Time) ([]WAVStats, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT COALESCE(region_id, ''),
+               COUNT(*),
+               COUNT(*) FILTER (WHERE accepted_at IS NOT NULL OR assigned_at IS NOT NULL),
+               COUNT(*) FILTER (WHERE status = 'complete'),
+               COUNT(*) FILTER (WHERE status IN ('cancelled', 'expired', 'denied')
+                                  AND accepted_at IS NULL AND assigned_at IS NULL),
+               COALESCE(AVG(EXTRACT(EPOCH FROM accepted_at - created_at))
+                        FILTER (WHERE order_type = 'instant' AND accepted_at IS NOT NULL), 0)
+        FROM orders
+        WHERE $1 = ANY(requirements)
+          AND created_at >= $2 AND created_at < $3
+          AND NOT sandbox
+        GROUP BY 1
+        ORDER BY 2 DESC, 1`, RequireWheelchair, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []WAVStats
 	for rows.Next() {
-		var o Order
-		var driverID sql.NullString
-		var scheduledAt, cancelDeadlineAt, assignedAt sql.NullTime
-		var scheduleWindowMins sql.NullInt32
-		var incentiveBonus sql.NullInt64
-		var orderType sql.NullString
-
-		err := rows.Scan(
-			&o.ID, &o.PassengerID, &driverID, &o.Status, &o.StatusVersion,
-			&o.Pickup.Lat, &o.Pickup.Lng, &o.Dropoff.Lat, &o.Dropoff.Lng,
-			&o.RideType, &o.EstimatedFee.Amount,
-			&o.CreatedAt, &scheduledAt, &cancelDeadlineAt, &incentiveBonus, &assignedAt,
-			&orderType, &scheduleWindowMins,
-		)
-		if err != nil {
+		var w WAVStats
+		var waitSecs float64
+		if err := rows.Scan(&w.RegionID, &w.Requested, &w.Served, &w.Completed, &w.Unserved, &waitSecs); err != nil {
 			return nil, err
 		}
-		if driverID.Valid {
-			d := types.ID(driverID.String)
-			o.DriverID = &d
-		}
-		o.ScheduledAt = toTimePtr(scheduledAt)
-		o.CancelDeadlineAt = toTimePtr(cancelDeadlineAt)
-		o.AssignedAt = toTimePtr(assignedAt)
-		if scheduleWindowMins.Valid {
-			v := int(scheduleWindowMins.Int32)
-			o.ScheduleWindowMins = &v
-		}
-		if incentiveBonus.Valid {
-			o.IncentiveBonus = incentiveBonus.Int64
+		w.AvgWait = time.Duration(waitSecs * float64(time.Second)).Round(time.Second)
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// RotateRecipients re-encrypts up to limit deliveries whose recipient or
+// signer is still plaintext or sealed with a retired key. It returns how many
+// rows were rewritten; callers loop until it returns 0.
+func (s *Store) RotateRecipients(ctx context.Context, limit int) (int, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT id, COALESCE(recipient_name, ''), COALESCE(recipient_phone, ''), COALESCE(delivery_signed_by, '')
+        FROM orders
+        WHERE parcel_weight_class IS NOT NULL
+        ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	type pending struct{ id, name, phone, signedBy string }
+	var todo []pending
+	for rows.Next() && len(todo) < limit {
+		var p pending
+		if err := rows.Scan(&p.id, &p.name, &p.phone, &p.signedBy); err != nil {
+			rows.Close()
+			return 0, err
 		}
-		if orderType.Valid {
-			o.OrderType = orderType.String
+		if !s.pii.NeedsRotation(p.name) && !s.pii.NeedsRotation(p.phone) && !s.pii.NeedsRotation(p.signedBy) {
+			continue
 		}
-		if o.EstimatedFee.Currency == "" {
-			o.EstimatedFee.Currency = "TWD"
+		for _, v := range []*string{&p.name, &p.phone, &p.signedBy} {
+			if *v, err = s.pii.Decrypt(*v); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("decrypt recipient of %s: %w", p.id, err)
+			}
 		}
-		orders = append(orders, &o)
+		todo = append(todo, p)
 	}
+	rows.Close()
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
+	}
+
+	for _, p := range todo {
+		var sealed [3]string
+		for i, v := range []string{p.name, p.phone, p.signedBy} {
+			if sealed[i], err = s.pii.Encrypt(v); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := s.db.Exec(ctx, `
+            UPDATE orders
+            SET recipient_name = $2, recipient_phone = $3, delivery_signed_by = NULLIF($4, '')
+            WHERE id = $1`,
+			p.id, sealed[0], sealed[1], sealed[2]); err != nil {
+			return 0, err
+		}
 	}
-	return orders, nil
+	return len(todo), nil
 }
@@ -167,7 +167,17 @@ func setupTestSchema(ctx context.Context, db *pgxpool.Pool) error {
 				schedule_window_mins INTEGER,
 				cancel_deadline_at TIMESTAMP,
 				incentive_bonus BIGINT DEFAULT 0,
-				assigned_at TIMESTAMP
+				assigned_at TIMESTAMP,
+				sandbox BOOLEAN NOT NULL DEFAULT false,
+				notes TEXT NOT NULL DEFAULT '',
+				requirements TEXT[] NOT NULL DEFAULT '{}',
+				passenger_count SMALLINT NOT NULL DEFAULT 1,
+				has_pet BOOLEAN NOT NULL DEFAULT false,
+				transit_type TEXT NOT NULL DEFAULT '',
+				transit_number TEXT NOT NULL DEFAULT '',
+				transit_eta TIMESTAMP,
+				org_id TEXT,
+				credits_applied BIGINT NOT NULL DEFAULT 0
 			);
 
 			CREATE TABLE IF NOT EXISTS order_state_events (
@@ -221,7 +231,17 @@ func setupTestTables(ctx context.Context, db *pgxpool.Pool, schema string) error
 			schedule_window_mins INTEGER,
 			cancel_deadline_at TIMESTAMP,
 			incentive_bonus BIGINT DEFAULT 0,
-			assigned_at TIMESTAMP
+			assigned_at TIMESTAMP,
+			sandbox BOOLEAN NOT NULL DEFAULT false,
+			notes TEXT NOT NULL DEFAULT '',
+			requirements TEXT[] NOT NULL DEFAULT '{}',
+			passenger_count SMALLINT NOT NULL DEFAULT 1,
+			has_pet BOOLEAN NOT NULL DEFAULT false,
+			transit_type TEXT NOT NULL DEFAULT '',
+			transit_number TEXT NOT NULL DEFAULT '',
+			transit_eta TIMESTAMP,
+			org_id TEXT,
+			credits_applied BIGINT NOT NULL DEFAULT 0
 		);
 
 		CREATE TABLE %s.order_state_events (
@@ -18,6 +18,10 @@ type OrderStore interface {
 
 	// Query operations
 	HasActiveByPassenger(ctx context.Context, passengerID types.ID) (bool, error)
+	// ListRebookCandidates returns the passenger's orders cancelled since
+	// cancelledSince and their scheduled rides not yet under way.
+	ListRebookCandidates(ctx context.Context, passengerID types.ID, cancelledSince time.Time) ([]*Order, error)
+	ListByDriver(ctx context.Context, driverID types.ID, statuses []Status) ([]*Order, error)
 
 	// Scheduled order operations
 	CreateScheduled(ctx context.Context, o *Order) error
@@ -25,10 +29,52 @@ type OrderStore interface {
 	ListAvailableScheduled(ctx context.Context, from, to time.Time) ([]*Order, error)
 	ClaimScheduled(ctx context.Context, orderID, driverID types.ID, expectVersion int) (bool, error)
 	ReopenScheduled(ctx context.Context, orderID types.ID, expectVersion int, bonus int64) (bool, error)
+	UpdateSchedule(ctx context.Context, orderID types.ID, expectVersion int, scheduledAt, cancelDeadlineAt time.Time) (bool, error)
+
+	// Airport/HSR pickups
+	ListTransitPickups(ctx context.Context, from, to time.Time) ([]TransitPickup, error)
+	SetTransitETA(ctx context.Context, orderID types.ID, eta time.Time) error
+	ListArriveByPickups(ctx context.Context, from, to time.Time) ([]ArriveByPickup, error)
+
+	// Claimed scheduled rides near pickup
+	ListAssignedPickups(ctx context.Context, now time.Time) ([]AssignedPickup, error)
+
+	// Pickup edits while waiting
+	UpdatePickup(ctx context.Context, orderID types.ID, expectVersion int, pickup types.Point, regionID string, fee Quote) (bool, error)
+
+	// Mid-trip dropoff changes
+	ProposeDropoff(ctx context.Context, orderID types.ID, expectVersion int, dropoff types.Point, fee Quote, at time.Time) (bool, error)
+	ResolveDropoff(ctx context.Context, orderID types.ID, expectVersion int, accept bool) (bool, error)
+
+	// Scheduled-order re-quotes
+	ListRequoteCandidates(ctx context.Context, now time.Time) ([]*Order, error)
+	ProposeRequote(ctx context.Context, orderID types.ID, expectVersion int, q Quote, at time.Time) (bool, error)
+	UpdateQuote(ctx context.Context, orderID types.ID, expectVersion int, q Quote) (bool, error)
+
+	// Credits
+	SetCreditsApplied(ctx context.Context, orderID types.ID, amount int64) error
+
+	// Ride plans
+	SetSubscriptionApplied(ctx context.Context, orderID types.ID, amount int64) error
+
+	// Cancellation fees
+	CancelCharged(ctx context.Context, id types.ID, from Status, version int, c CancelCharge) (bool, error)
+
+	// Deliveries
+	SetDeliverySignature(ctx context.Context, orderID types.ID, signedBy string) error
+
+	// Charters
+	AddStop(ctx context.Context, stop *Stop) error
+	ListStops(ctx context.Context, orderID types.ID) ([]Stop, error)
+	SetOvertimeFee(ctx context.Context, orderID types.ID, amount int64) error
+
+	// Reports
+	WAVFulfillment(ctx context.Context, from, to time.Time) ([]WAVStats, error)
 
 	// Background operations
 	BumpIncentiveBonusForApproaching(ctx context.Context, bump int64) error
 	ExpireOverdueScheduled(ctx context.Context) error
+	ListUnclaimedScheduled(ctx context.Context, before time.Time) ([]*Order, error)
 
 	// ListUrgentPendingOrders returns all scheduled and waiting orders that have not
 	// yet passed their effective scheduled time, ordered by urgency (earliest first).
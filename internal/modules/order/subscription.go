@@ -0,0 +1,45 @@
+// README: Ride plan benefits: a subscribed passenger's plan discount and included ride credit are taken off the fare when the trip reaches payment, before their ride credits.
+package order
+
+import (
+	"context"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+// Subscriptions applies a passenger's ride plan to a fare.
+type Subscriptions interface {
+	// Apply takes the passenger's plan benefit off fare for orderID and
+	// returns the amount covered; 0 without an active plan. Applying the same
+	// order twice returns the first amount.
+	Apply(ctx context.Context, passengerID, orderID types.ID, fare types.Money) (int64, error)
+}
+
+// SetSubscriptions applies passenger ride plans to fares before payment.
+// Without it no fare is discounted.
+func (s *Service) SetSubscriptions(sub Subscriptions) {
+	s.subscriptions = sub
+}
+
+// applySubscription applies the passenger's ride plan to an order entering
+// payment, under the same exclusions as ride credits. Failures leave the full
+// fare due.
+func (s *Service) applySubscription(ctx context.Context, o *Order) {
+	if s.subscriptions == nil || o.OrgID != nil || o.Sandbox {
+		return
+	}
+	covered, err := s.subscriptions.Apply(ctx, o.PassengerID, o.ID, o.Fare())
+	if err != nil {
+		errreport.Report(ctx, "order", "apply_subscription", err, "order_id", o.ID)
+		return
+	}
+	if covered <= 0 {
+		return
+	}
+	if err := s.store.SetSubscriptionApplied(ctx, o.ID, covered); err != nil {
+		errreport.Report(ctx, "order", "record_subscription", err, "order_id", o.ID, "covered", covered)
+		return
+	}
+	o.SubscriptionApplied = covered
+}
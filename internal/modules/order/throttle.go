@@ -0,0 +1,166 @@
+// README: Anti-abuse throttles — caps on how many orders a passenger creates per hour and cancels per day, counted in Redis.
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ark/internal/config"
+	"ark/internal/errreport"
+	"ark/internal/sandbox"
+	"ark/internal/types"
+)
+
+var (
+	// ErrCreateThrottled is returned when a passenger created too many orders
+	// in the last hour.
+	ErrCreateThrottled = errors.New("too many orders created recently")
+	// ErrCancelLimit is returned when a passenger cancelled too many orders in
+	// the last day; further cancellations go through support.
+	ErrCancelLimit = errors.New("cancellation limit reached; contact support to cancel")
+)
+
+const (
+	createThrottleWindow = time.Hour
+	cancelThrottleWindow = 24 * time.Hour
+)
+
+// ThrottleError reports a passenger over a throttle. Err is ErrCreateThrottled
+// or ErrCancelLimit.
+type ThrottleError struct {
+	PassengerID types.ID
+	Limit       int
+	Window      time.Duration
+	// RetryAfter is how long until the oldest counted action leaves the window.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("%v: %d in %s", e.Err, e.Limit, e.Window)
+}
+
+func (e *ThrottleError) Unwrap() error { return e.Err }
+
+// ThrottleCounter keeps the recent actions of a key. Implemented by
+// RedisThrottleCounter.
+type ThrottleCounter interface {
+	// Recent returns the times of key's actions after since, oldest first.
+	Recent(ctx context.Context, key string, since time.Time) ([]time.Time, error)
+	// Add records an action of key at at, kept for window.
+	Add(ctx context.Context, key string, at time.Time, window time.Duration) error
+}
+
+// SetThrottles caps passenger order creations and cancellations as limits
+// says. Without it, or with a zero limit, there is no cap. Test-mode orders
+// are never throttled.
+func (s *Service) SetThrottles(c ThrottleCounter, limits config.OrderThrottleConfig) {
+	s.throttle = c
+	s.throttleLimits = limits
+}
+
+func createThrottleKey(passengerID types.ID) string {
+	return "order:throttle:create:" + string(passengerID)
+}
+
+func cancelThrottleKey(passengerID types.ID) string {
+	return "order:throttle:cancel:" + string(passengerID)
+}
+
+// checkCreateThrottle refuses a passenger who created MaxCreatesPerHour
+// orders in the last hour.
+func (s *Service) checkCreateThrottle(ctx context.Context, passengerID types.ID) error {
+	return s.checkThrottle(ctx, createThrottleKey(passengerID), passengerID, s.throttleLimits.MaxCreatesPerHour, createThrottleWindow, ErrCreateThrottled)
+}
+
+// checkCancelThrottle refuses a passenger who cancelled MaxCancelsPerDay
+// orders in the last day.
+func (s *Service) checkCancelThrottle(ctx context.Context, passengerID types.ID) error {
+	return s.checkThrottle(ctx, cancelThrottleKey(passengerID), passengerID, s.throttleLimits.MaxCancelsPerDay, cancelThrottleWindow, ErrCancelLimit)
+}
+
+func (s *Service) recordCreate(ctx context.Context, passengerID types.ID) {
+	s.recordThrottled(ctx, createThrottleKey(passengerID), s.throttleLimits.MaxCreatesPerHour, createThrottleWindow)
+}
+
+func (s *Service) recordCancel(ctx context.Context, passengerID types.ID) {
+	s.recordThrottled(ctx, cancelThrottleKey(passengerID), s.throttleLimits.MaxCancelsPerDay, cancelThrottleWindow)
+}
+
+// checkThrottle fails open: a passenger is not refused because the counter is
+// unreachable.
+func (s *Service) checkThrottle(ctx context.Context, key string, passengerID types.ID, limit int, window time.Duration, sentinel error) error {
+	if s.throttle == nil || limit <= 0 || sandbox.Enabled(ctx) {
+		return nil
+	}
+	now := s.now()
+	recent, err := s.throttle.Recent(ctx, key, now.Add(-window))
+	if err != nil {
+		errreport.Report(ctx, "order", "throttle_check", err, "key", key)
+		return nil
+	}
+	if len(recent) < limit {
+		return nil
+	}
+	return &ThrottleError{
+		PassengerID: passengerID,
+		Limit:       limit,
+		Window:      window,
+		RetryAfter:  max(recent[len(recent)-limit].Add(window).Sub(now), 0),
+		Err:         sentinel,
+	}
+}
+
+func (s *Service) recordThrottled(ctx context.Context, key string, limit int, window time.Duration) {
+	if s.throttle == nil || limit <= 0 || sandbox.Enabled(ctx) {
+		return
+	}
+	if err := s.throttle.Add(ctx, key, s.now(), window); err != nil {
+		errreport.Report(ctx, "order", "throttle_record", err, "key", key)
+	}
+}
+
+// RedisThrottleCounter keeps each key's actions in a sorted set scored by
+// time, trimmed to the window on every write.
+type RedisThrottleCounter struct {
+	redis *redis.Client
+}
+
+// NewRedisThrottleCounter returns a ThrottleCounter backed by rdb.
+func NewRedisThrottleCounter(rdb *redis.Client) *RedisThrottleCounter {
+	return &RedisThrottleCounter{redis: rdb}
+}
+
+// Recent implements ThrottleCounter.
+func (c *RedisThrottleCounter) Recent(ctx context.Context, key string, since time.Time) ([]time.Time, error) {
+	scores, err := c.redis.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(since.UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("throttle %s: %w", key, err)
+	}
+	out := make([]time.Time, len(scores))
+	for i, z := range scores {
+		out[i] = time.UnixMilli(int64(z.Score)).UTC()
+	}
+	return out, nil
+}
+
+// Add implements ThrottleCounter. Members carry the time in nanoseconds so
+// actions in the same millisecond are kept apart.
+func (c *RedisThrottleCounter) Add(ctx context.Context, key string, at time.Time, window time.Duration) error {
+	pipe := c.redis.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixMilli()), Member: strconv.FormatInt(at.UnixNano(), 10)})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(at.Add(-window).UnixMilli(), 10))
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("throttle %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// README: Airport/HSR pickups: flight or train numbers and arrive-by times on scheduled orders, and pickup-time amendments.
+package order
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Transit types for airport and high-speed-rail pickups.
+const (
+	TransitFlight = "flight"
+	TransitTrain  = "train"
+)
+
+var (
+	flightNumberRe = regexp.MustCompile(`^[A-Z0-9]{2}[0-9]{1,4}[A-Z]?$`)
+	trainNumberRe  = regexp.MustCompile(`^[0-9]{1,4}$`)
+)
+
+// normalizeTransit upper-cases and strips spaces from number and checks it
+// against kind. Both empty means the order is not a transit pickup.
+func normalizeTransit(kind, number string) (string, string, error) {
+	number = strings.ToUpper(strings.ReplaceAll(number, " ", ""))
+	switch {
+	case kind == "" && number == "":
+		return "", "", nil
+	case kind == TransitFlight && flightNumberRe.MatchString(number):
+		return kind, number, nil
+	case kind == TransitTrain && trainNumberRe.MatchString(number):
+		return kind, number, nil
+	default:
+		return "", "", ErrBadRequest
+	}
+}
+
+// TransitPickup is an upcoming scheduled pickup tied to a flight or train.
+type TransitPickup struct {
+	OrderID       types.ID
+	TransitType   string
+	TransitNumber string
+	ScheduledAt   time.Time
+	// ETA is the arrival time last reported by the provider; nil before the first check.
+	ETA *time.Time
+}
+
+// ListTransitPickups returns scheduled or assigned transit pickups whose
+// scheduled time falls in [from, to].
+func (s *Service) ListTransitPickups(ctx context.Context, from, to time.Time) ([]TransitPickup, error) {
+	return s.store.ListTransitPickups(ctx, from, to)
+}
+
+// SetTransitETA records the latest arrival estimate for a transit pickup.
+func (s *Service) SetTransitETA(ctx context.Context, orderID types.ID, eta time.Time) error {
+	return s.store.SetTransitETA(ctx, orderID, eta.UTC())
+}
+
+// ArriveByPickup is an upcoming scheduled pickup whose passenger must reach
+// the dropoff by ArriveBy.
+type ArriveByPickup struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	Pickup      types.Point
+	Dropoff     types.Point
+	ScheduledAt time.Time
+	ArriveBy    time.Time
+}
+
+// ArrivalWindow is the span in which the platform promises an arrive-by
+// booking reaches its dropoff. To is the passenger's arrive-by time.
+type ArrivalWindow struct {
+	From time.Time
+	To   time.Time
+}
+
+// Late returns how long after the window an arrival at t was; zero when it
+// arrived in time.
+func (w ArrivalWindow) Late(t time.Time) time.Duration {
+	return max(t.Sub(w.To), 0)
+}
+
+// ListArriveByPickups returns scheduled or assigned pickups with an arrive-by
+// time whose scheduled time falls in [from, to].
+func (s *Service) ListArriveByPickups(ctx context.Context, from, to time.Time) ([]ArriveByPickup, error) {
+	return s.store.ListArriveByPickups(ctx, from, to)
+}
+
+// ---------------------------------------------------------------------------
+// Schedule amendments
+// ---------------------------------------------------------------------------
+
+// AmendScheduleCommand moves the pickup time of a scheduled or assigned order.
+type AmendScheduleCommand struct {
+	OrderID     types.ID
+	ScheduledAt time.Time
+	ActorType   string
+	Reason      string // e.g. "flight_delayed", "passenger"
+}
+
+// ScheduleChange describes a committed pickup-time amendment.
+type ScheduleChange struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    *types.ID
+	From        time.Time
+	To          time.Time
+	Reason      string
+	Sandbox     bool
+}
+
+// ScheduleHook is called after a pickup-time amendment has been persisted.
+type ScheduleHook func(ctx context.Context, c ScheduleChange)
+
+// OnScheduleChange registers h to run after every pickup-time amendment.
+// Must be called before the service starts handling requests.
+func (s *Service) OnScheduleChange(h ScheduleHook) {
+	s.scheduleHooks = append(s.scheduleHooks, h)
+}
+
+// AmendSchedule moves a scheduled or assigned order to cmd.ScheduledAt and
+// shifts its free-cancel deadline with it. Passengers must keep the minimum
+// lead time; the system (flight tracking) may move a pickup at any time.
+func (s *Service) AmendSchedule(ctx context.Context, cmd AmendScheduleCommand) error {
+	if cmd.OrderID == "" || cmd.ScheduledAt.IsZero() {
+		return ErrBadRequest
+	}
+	cmd.ScheduledAt = cmd.ScheduledAt.UTC()
+	if cmd.ActorType != ActorPassenger && cmd.ActorType != ActorSystem {
+		return ErrActorNotAllowed
+	}
+	if cmd.ActorType == ActorPassenger && cmd.ScheduledAt.Before(s.now().Add(s.sched.MinLeadTime)) {
+		return ErrBadRequest
+	}
+	o, err := s.store.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return err
+	}
+	if (o.Status != StatusScheduled && o.Status != StatusAssigned) || o.ScheduledAt == nil || o.ScheduleWindowMins == nil {
+		return invalidState(o)
+	}
+	deadline := cmd.ScheduledAt.Add(-time.Duration(*o.ScheduleWindowMins) * time.Minute)
+	ok, err := s.store.UpdateSchedule(ctx, o.ID, o.StatusVersion, cmd.ScheduledAt, deadline)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return conflict(o)
+	}
+	s.watchers.notify(o.ID)
+
+	c := ScheduleChange{
+		OrderID:     o.ID,
+		PassengerID: o.PassengerID,
+		DriverID:    o.DriverID,
+		From:        *o.ScheduledAt,
+		To:          cmd.ScheduledAt,
+		Reason:      cmd.Reason,
+		Sandbox:     o.Sandbox,
+	}
+	for _, h := range s.scheduleHooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("order: schedule hook panicked for %s: %v", o.ID, r)
+				}
+			}()
+			h(ctx, c)
+		}()
+	}
+	return nil
+}
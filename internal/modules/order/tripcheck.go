@@ -0,0 +1,203 @@
+// README: Trip location checks — a driver starts a trip near the pickup and completes it near the dropoff or after a minimum trip, judged from their live GPS; when the GPS cannot be trusted the step goes through and the discrepancy is reported instead.
+package order
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ark/internal/errreport"
+	"ark/internal/types"
+)
+
+var (
+	// ErrNotAtPickup is returned when a driver starts a trip while their live
+	// position is away from the pickup.
+	ErrNotAtPickup = errors.New("driver is not at the pickup")
+	// ErrNotAtDropoff is returned when a driver completes a short trip while
+	// their live position is away from the dropoff.
+	ErrNotAtDropoff = errors.New("driver is not at the dropoff")
+)
+
+// Platform trip check thresholds, used where a region sets none.
+const (
+	DefaultMeetRadiusKm     = 0.3
+	DefaultCompleteRadiusKm = 0.5
+	DefaultMinTripKm        = 1.0
+)
+
+// fixMaxAge is how old a driver's live position may be and still be trusted;
+// drivers report every few seconds while online.
+const fixMaxAge = time.Minute
+
+// TripCheck is where trips in a region may start and complete; zero fields
+// use the platform defaults.
+type TripCheck struct {
+	// MeetRadiusKm is how close to the pickup the driver must be to start.
+	MeetRadiusKm float64
+	// CompleteRadiusKm is how close to the dropoff the driver must be to
+	// complete a trip shorter than MinTripKm.
+	CompleteRadiusKm float64
+	MinTripKm        float64
+}
+
+// TripCheckRegions returns the trip check of a region; "" is the default
+// region. Implemented by region.Service.
+type TripCheckRegions interface {
+	TripCheck(regionID string) TripCheck
+}
+
+// DriverFixes returns a driver's live position and when it was reported; a
+// zero time means none is known. Implemented by location.Service.
+type DriverFixes interface {
+	LastFix(ctx context.Context, driverID types.ID) (types.Point, time.Time, error)
+}
+
+// Location discrepancy reasons.
+const (
+	// DiscrepancyNoFix means the driver had no live position.
+	DiscrepancyNoFix = "no_fix"
+	// DiscrepancyStaleFix means the driver's live position was away from the
+	// pickup or dropoff but too old to be trusted.
+	DiscrepancyStaleFix = "stale_fix"
+	// DiscrepancyNoTrace means the driver completed away from the dropoff and
+	// the trip's length could not be measured.
+	DiscrepancyNoTrace = "no_trace"
+)
+
+// LocationDiscrepancy is a trip started or completed without GPS evidence
+// that the driver was where they should have been.
+type LocationDiscrepancy struct {
+	OrderID  types.ID
+	DriverID types.ID
+	// Step is StatusDriving when the trip started, StatusPayment when it
+	// completed.
+	Step   Status
+	Reason string
+	// DistanceKm is from the driver's last position to the pickup or dropoff
+	// and FixAge that position's age; DistanceKm is -1 without a position.
+	DistanceKm float64
+	FixAge     time.Duration
+	At         time.Time
+}
+
+// LocationDiscrepancyHook is called after a trip step with a location
+// discrepancy has been committed.
+type LocationDiscrepancyHook func(ctx context.Context, d LocationDiscrepancy)
+
+// SetTripChecks makes starting and completing a trip check the driver's live
+// position against the thresholds of the order's region. regions may be nil
+// to use the platform defaults. Without it trips start and complete anywhere.
+func (s *Service) SetTripChecks(fixes DriverFixes, regions TripCheckRegions) {
+	s.fixes = fixes
+	s.tripRegions = regions
+}
+
+// OnLocationDiscrepancy registers h to run for every trip step let through
+// on unreliable GPS. Must be called before the service starts handling
+// requests.
+func (s *Service) OnLocationDiscrepancy(h LocationDiscrepancyHook) {
+	s.discrepancyHooks = append(s.discrepancyHooks, h)
+}
+
+func (s *Service) tripCheck(regionID string) TripCheck {
+	var c TripCheck
+	if s.tripRegions != nil {
+		c = s.tripRegions.TripCheck(regionID)
+	}
+	if c.MeetRadiusKm <= 0 {
+		c.MeetRadiusKm = DefaultMeetRadiusKm
+	}
+	if c.CompleteRadiusKm <= 0 {
+		c.CompleteRadiusKm = DefaultCompleteRadiusKm
+	}
+	if c.MinTripKm <= 0 {
+		c.MinTripKm = DefaultMinTripKm
+	}
+	return c
+}
+
+// checkTripLocation decides whether o's driver may move it to `to`
+// (StatusDriving or StatusPayment). A trusted position in the wrong place
+// fails the step; an untrusted one lets it through with a discrepancy to
+// report once it commits.
+func (s *Service) checkTripLocation(ctx context.Context, o *Order, to Status) (*LocationDiscrepancy, error) {
+	if s.fixes == nil || o.Sandbox || o.DriverID == nil {
+		return nil, nil
+	}
+	// A charter ends wherever the passenger lets the driver go.
+	if to == StatusPayment && o.RideType == RideTypeCharter {
+		return nil, nil
+	}
+	c := s.tripCheck(o.RegionID)
+	target, radius := o.Pickup, c.MeetRadiusKm
+	if to == StatusPayment {
+		target, radius = o.Dropoff, c.CompleteRadiusKm
+	}
+	now := s.now()
+	d := &LocationDiscrepancy{OrderID: o.ID, DriverID: *o.DriverID, Step: to, DistanceKm: -1, At: now}
+
+	pos, at, err := s.fixes.LastFix(ctx, *o.DriverID)
+	if err != nil {
+		errreport.Report(ctx, "order", "driver_fix", err, "order_id", o.ID)
+	}
+	if err != nil || at.IsZero() {
+		d.Reason = DiscrepancyNoFix
+		return d, nil
+	}
+	d.DistanceKm, d.FixAge = distanceKm(pos, target), now.Sub(at)
+	if d.DistanceKm <= radius {
+		return nil, nil
+	}
+	if d.FixAge > fixMaxAge {
+		d.Reason = DiscrepancyStaleFix
+		return d, nil
+	}
+	if to == StatusDriving {
+		return nil, ErrNotAtPickup
+	}
+	km, ok := s.tripKm(ctx, o, now)
+	if !ok {
+		d.Reason = DiscrepancyNoTrace
+		return d, nil
+	}
+	if km < c.MinTripKm {
+		return nil, ErrNotAtDropoff
+	}
+	return nil, nil
+}
+
+// tripKm is how far o's driver has driven since the trip started, from their
+// recorded positions; ok is false when there is no trace to measure.
+func (s *Service) tripKm(ctx context.Context, o *Order, now time.Time) (float64, bool) {
+	if s.traces == nil || o.StartedAt == nil {
+		return 0, false
+	}
+	trace, err := s.traces.DriverTrace(ctx, *o.DriverID, *o.StartedAt, now)
+	if err != nil {
+		errreport.Report(ctx, "order", "driver_trace", err, "order_id", o.ID)
+		return 0, false
+	}
+	if len(trace) < 2 {
+		return 0, false
+	}
+	var km float64
+	for i := 1; i < len(trace); i++ {
+		km += distanceKm(trace[i-1], trace[i])
+	}
+	return km, true
+}
+
+func (s *Service) reportDiscrepancy(ctx context.Context, d LocationDiscrepancy) {
+	for _, h := range s.discrepancyHooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("order: location discrepancy hook panicked for %s: %v", d.OrderID, r)
+				}
+			}()
+			h(ctx, d)
+		}()
+	}
+}
@@ -0,0 +1,92 @@
+// README: Long-poll support: wait for an order's status_version to move past a version the client already has.
+package order
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ark/internal/types"
+)
+
+// watchRecheck bounds how long a waiter can miss a transition committed by
+// another API instance, whose hooks never reach this process.
+const watchRecheck = 2 * time.Second
+
+// statusWatchers wakes long-poll waiters when an order transitions in this process.
+type statusWatchers struct {
+	mu      sync.Mutex
+	waiters map[types.ID][]chan struct{}
+}
+
+func (w *statusWatchers) add(id types.ID) chan struct{} {
+	ch := make(chan struct{})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.waiters == nil {
+		w.waiters = make(map[types.ID][]chan struct{})
+	}
+	w.waiters[id] = append(w.waiters[id], ch)
+	return ch
+}
+
+func (w *statusWatchers) remove(id types.ID, ch chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	list := w.waiters[id]
+	for i, c := range list {
+		if c == ch {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(w.waiters, id)
+	} else {
+		w.waiters[id] = list
+	}
+}
+
+// notify wakes and drops every waiter on id.
+func (w *statusWatchers) notify(id types.ID) {
+	w.mu.Lock()
+	list := w.waiters[id]
+	delete(w.waiters, id)
+	w.mu.Unlock()
+	for _, ch := range list {
+		close(ch)
+	}
+}
+
+// WaitForChange blocks until order id has a status_version different from
+// version or ctx is done, and returns the latest order either way. Callers bound
+// the wait with a context deadline; a deadline is not an error.
+func (s *Service) WaitForChange(ctx context.Context, id types.ID, version int) (*Order, error) {
+	recheck := time.NewTicker(watchRecheck)
+	defer recheck.Stop()
+	for {
+		// Register before reading so a transition between the read and the
+		// select still wakes us.
+		ch := s.watchers.add(id)
+		o, err := s.store.Get(ctx, id)
+		if err != nil {
+			s.watchers.remove(id, ch)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if o.StatusVersion != version {
+			s.watchers.remove(id, ch)
+			return o, nil
+		}
+		select {
+		case <-ch:
+		case <-recheck.C:
+			s.watchers.remove(id, ch)
+		case <-ctx.Done():
+			s.watchers.remove(id, ch)
+			return o, nil
+		}
+	}
+}
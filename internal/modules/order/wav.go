@@ -0,0 +1,44 @@
+// README: Wheelchair-accessible vehicle reporting — how many wheelchair orders found a driver, per region.
+package order
+
+import (
+	"context"
+	"time"
+)
+
+// WAVStats summarises the wheelchair orders of one region placed over a
+// period.
+type WAVStats struct {
+	RegionID  string // "" is the default region
+	Requested int
+	// Served orders were accepted or claimed by a driver; Completed is the
+	// subset that finished. Unserved orders ended without any driver. The
+	// rest are still waiting.
+	Served    int
+	Completed int
+	Unserved  int
+	// AvgWait is the mean time instant orders waited for a driver to accept.
+	AvgWait time.Duration
+}
+
+// Open counts the orders still waiting for a driver.
+func (w WAVStats) Open() int {
+	return w.Requested - w.Served - w.Unserved
+}
+
+// FulfillmentRate is the share of decided orders that a driver served.
+func (w WAVStats) FulfillmentRate() float64 {
+	if w.Served+w.Unserved == 0 {
+		return 0
+	}
+	return float64(w.Served) / float64(w.Served+w.Unserved)
+}
+
+// WAVFulfillment returns per-region statistics of the wheelchair orders placed
+// in [from, to).
+func (s *Service) WAVFulfillment(ctx context.Context, from, to time.Time) ([]WAVStats, error) {
+	if !from.Before(to) {
+		return nil, ErrBadRequest
+	}
+	return s.store.WAVFulfillment(ctx, from.UTC(), to.UTC())
+}
@@ -0,0 +1,283 @@
+// README: Organization HTTP handlers — corporate account setup, members, ride policy and invoices.
+//
+// Endpoints:
+//
+//	POST   /api/orgs                          — register an organization ({"name", "billing_email"}); caller becomes admin
+//	GET    /api/orgs                          — list the caller's memberships and invitations
+//	GET    /api/orgs/:id                      — organization details and policy (members only)
+//	PUT    /api/orgs/:id/policy               — replace the ride policy (admins only)
+//	POST   /api/orgs/:id/members              — invite employees ({"user_ids": [...]}, admins only)
+//	GET    /api/orgs/:id/members              — list members and invitations (admins only)
+//	DELETE /api/orgs/:id/members/:user_id     — remove a member, or leave when :user_id is the caller
+//	POST   /api/orgs/:id/join                 — accept an invitation
+//	GET    /api/orgs/:id/invoices             — monthly invoices, newest first (admins only)
+//
+// Auth: all routes require the Auth middleware to set user_id in context.
+package organization
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the organization HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type createReq struct {
+	Name         string `json:"name"`
+	BillingEmail string `json:"billing_email"`
+}
+
+type policyReq struct {
+	AllowedFromMin int   `json:"allowed_from_min"`
+	AllowedToMin   int   `json:"allowed_to_min"`
+	MonthlyBudget  int64 `json:"monthly_budget"`
+}
+
+type inviteReq struct {
+	UserIDs []types.ID `json:"user_ids"`
+}
+
+type orgResp struct {
+	ID           types.ID  `json:"org_id"`
+	Name         string    `json:"name"`
+	BillingEmail string    `json:"billing_email"`
+	Policy       policyReq `json:"policy"`
+	CreatedAt    int64     `json:"created_at"`
+}
+
+func toOrgResp(o *Organization) orgResp {
+	return orgResp{
+		ID:           o.ID,
+		Name:         o.Name,
+		BillingEmail: o.BillingEmail,
+		Policy: policyReq{
+			AllowedFromMin: o.Policy.AllowedFromMin,
+			AllowedToMin:   o.Policy.AllowedToMin,
+			MonthlyBudget:  o.Policy.MonthlyBudget,
+		},
+		CreatedAt: o.CreatedAt.Unix(),
+	}
+}
+
+type memberResp struct {
+	OrgID    types.ID `json:"org_id"`
+	OrgName  string   `json:"org_name,omitempty"`
+	UserID   types.ID `json:"user_id"`
+	Role     string   `json:"role"`
+	Status   string   `json:"status"`
+	JoinedAt *int64   `json:"joined_at,omitempty"`
+}
+
+func toMemberResps(ms []Member) []memberResp {
+	out := make([]memberResp, len(ms))
+	for i, m := range ms {
+		out[i] = memberResp{OrgID: m.OrgID, OrgName: m.OrgName, UserID: m.UserID, Role: m.Role, Status: m.Status}
+		if m.JoinedAt != nil {
+			ts := m.JoinedAt.Unix()
+			out[i].JoinedAt = &ts
+		}
+	}
+	return out
+}
+
+type invoiceResp struct {
+	Month    string `json:"month"` // YYYY-MM
+	Trips    int    `json:"trips"`
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+	SentAt   *int64 `json:"sent_at,omitempty"`
+}
+
+// Create handles POST /api/orgs.
+func (h *Handler) Create(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req createReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	o, err := h.svc.Create(c.Request.Context(), types.ID(uid), req.Name, req.BillingEmail)
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toOrgResp(o))
+}
+
+// ListMine handles GET /api/orgs.
+func (h *Handler) ListMine(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	ms, err := h.svc.ListMine(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"memberships": toMemberResps(ms)})
+}
+
+// Get handles GET /api/orgs/:id.
+func (h *Handler) Get(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	o, err := h.svc.Get(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toOrgResp(o))
+}
+
+// UpdatePolicy handles PUT /api/orgs/:id/policy.
+func (h *Handler) UpdatePolicy(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req policyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	p := Policy{AllowedFromMin: req.AllowedFromMin, AllowedToMin: req.AllowedToMin, MonthlyBudget: req.MonthlyBudget}
+	if err := h.svc.UpdatePolicy(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid), p); err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// Invite handles POST /api/orgs/:id/members.
+func (h *Handler) Invite(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req inviteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	n, err := h.svc.Invite(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid), req.UserIDs)
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"invited": n})
+}
+
+// ListMembers handles GET /api/orgs/:id/members.
+func (h *Handler) ListMembers(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	ms, err := h.svc.ListMembers(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"members": toMemberResps(ms)})
+}
+
+// RemoveMember handles DELETE /api/orgs/:id/members/:user_id.
+func (h *Handler) RemoveMember(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	err := h.svc.RemoveMember(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid), types.ID(c.Param("user_id")))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Join handles POST /api/orgs/:id/join.
+func (h *Handler) Join(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.Join(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid)); err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListInvoices handles GET /api/orgs/:id/invoices.
+func (h *Handler) ListInvoices(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	invoices, err := h.svc.ListInvoices(c.Request.Context(), types.ID(c.Param("id")), types.ID(uid))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	out := make([]invoiceResp, len(invoices))
+	for i, inv := range invoices {
+		out[i] = invoiceResp{
+			Month:    inv.Month.Format("2006-01"),
+			Trips:    inv.Trips,
+			Total:    inv.Total.Amount,
+			Currency: inv.Total.Currency,
+		}
+		if inv.SentAt != nil {
+			ts := inv.SentAt.Unix()
+			out[i].SentAt = &ts
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{"invoices": out})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeOrgError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrForbidden):
+		writeError(c, http.StatusForbidden, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
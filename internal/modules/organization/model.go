@@ -0,0 +1,107 @@
+// README: Organization domain model — corporate accounts, members, ride policy and monthly invoices.
+package organization
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Member roles.
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Member statuses: invited members may not bill rides until they join.
+const (
+	MemberInvited = "invited"
+	MemberActive  = "active"
+)
+
+// MaxInvites caps the user IDs accepted by one invite call.
+const MaxInvites = 100
+
+// Organization is a company whose employees bill rides to a monthly invoice.
+type Organization struct {
+	ID           types.ID
+	Name         string
+	BillingEmail string
+	CreatedBy    types.ID
+	Policy       Policy
+	CreatedAt    time.Time
+}
+
+// Policy restricts the business rides members may take.
+type Policy struct {
+	// AllowedFromMin and AllowedToMin bound the pickup time in minutes after
+	// midnight, local to the pickup's region. Equal values allow any time; a window may wrap
+	// midnight (e.g. 1320–360 for 22:00–06:00).
+	AllowedFromMin int
+	AllowedToMin   int
+	// MonthlyBudget caps the organization's spend per calendar month in minor
+	// units of the platform currency. 0 means no cap.
+	MonthlyBudget int64
+}
+
+// Allows reports whether a pickup at t falls inside the allowed hours, read
+// on t's own clock. Times in UTC carry no region and are read in Asia/Taipei.
+func (p Policy) Allows(t time.Time) bool {
+	if p.AllowedFromMin == p.AllowedToMin {
+		return true
+	}
+	local := t
+	if t.Location() == time.UTC {
+		local = t.In(taipei)
+	}
+	m := local.Hour()*60 + local.Minute()
+	if p.AllowedFromMin < p.AllowedToMin {
+		return m >= p.AllowedFromMin && m < p.AllowedToMin
+	}
+	return m >= p.AllowedFromMin || m < p.AllowedToMin
+}
+
+func (p Policy) valid() bool {
+	return p.AllowedFromMin >= 0 && p.AllowedFromMin < 24*60 &&
+		p.AllowedToMin >= 0 && p.AllowedToMin < 24*60 &&
+		p.MonthlyBudget >= 0
+}
+
+// Member is one user's membership in an organization.
+type Member struct {
+	OrgID     types.ID
+	OrgName   string // filled when listing a user's memberships
+	UserID    types.ID
+	Role      string
+	Status    string
+	InvitedAt time.Time
+	JoinedAt  *time.Time
+}
+
+// Invoice is an organization's consolidated bill for a calendar month.
+type Invoice struct {
+	OrgID     types.ID
+	Month     time.Time // first instant of the month, Asia/Taipei
+	Trips     int
+	Total     types.Money
+	CreatedAt time.Time
+	SentAt    *time.Time
+}
+
+var (
+	ErrNotFound   = errors.New("organization: not found")
+	ErrBadRequest = errors.New("organization: bad request")
+	ErrForbidden  = errors.New("organization: forbidden")
+	ErrConflict   = errors.New("organization: already a member")
+)
+
+var taipei = loadTaipei()
+
+func loadTaipei() *time.Location {
+	loc, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return loc
+}
@@ -0,0 +1,28 @@
+// README: Organization route registration — mounts the corporate account endpoints onto the given router group.
+package organization
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the organization endpoints onto the provided authenticated router group.
+//
+//	POST   /api/orgs
+//	GET    /api/orgs
+//	GET    /api/orgs/:id
+//	PUT    /api/orgs/:id/policy
+//	POST   /api/orgs/:id/members
+//	GET    /api/orgs/:id/members
+//	DELETE /api/orgs/:id/members/:user_id
+//	POST   /api/orgs/:id/join
+//	GET    /api/orgs/:id/invoices
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	orgs := rg.Group("/api/orgs")
+	orgs.POST("", h.Create)
+	orgs.GET("", h.ListMine)
+	orgs.GET("/:id", h.Get)
+	orgs.PUT("/:id/policy", h.UpdatePolicy)
+	orgs.POST("/:id/members", h.Invite)
+	orgs.GET("/:id/members", h.ListMembers)
+	orgs.DELETE("/:id/members/:user_id", h.RemoveMember)
+	orgs.POST("/:id/join", h.Join)
+	orgs.GET("/:id/invoices", h.ListInvoices)
+}
@@ -0,0 +1,356 @@
+// README: Organization service — membership, ride policy checks for business rides, and consolidated monthly invoices.
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"ark/internal/modules/notification"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// settleTimeout bounds settling one business ride from an order transition.
+const settleTimeout = 15 * time.Second
+
+// Orders is the subset of order.Service used to settle business rides.
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+	Pay(ctx context.Context, cmd order.PayCommand) error
+}
+
+// Service manages organizations and bills their members' rides.
+type Service struct {
+	store  OrgStore
+	orders Orders
+	sender notification.EmailSender
+	now    func() time.Time
+}
+
+// NewService creates a Service. Invoices are stored but not emailed until
+// SetInvoiceSender is called.
+func NewService(store OrgStore, orders Orders) *Service {
+	return &Service{store: store, orders: orders, now: time.Now}
+}
+
+// SetInvoiceSender emails each monthly invoice to the organization's billing address.
+func (s *Service) SetInvoiceSender(sender notification.EmailSender) {
+	s.sender = sender
+}
+
+// ---------------------------------------------------------------------------
+// Organizations and members
+// ---------------------------------------------------------------------------
+
+// Create registers an organization with ownerID as its first admin.
+func (s *Service) Create(ctx context.Context, ownerID types.ID, name, billingEmail string) (*Organization, error) {
+	name = strings.TrimSpace(name)
+	if ownerID == "" || name == "" {
+		return nil, ErrBadRequest
+	}
+	if _, err := mail.ParseAddress(billingEmail); err != nil {
+		return nil, ErrBadRequest
+	}
+	o := &Organization{
+		ID:           types.NewID(),
+		Name:         name,
+		BillingEmail: billingEmail,
+		CreatedBy:    ownerID,
+		CreatedAt:    s.now(),
+	}
+	if err := s.store.CreateOrg(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Get returns the organization to any of its members, invited or active.
+func (s *Service) Get(ctx context.Context, orgID, userID types.ID) (*Organization, error) {
+	if _, err := s.member(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+	return s.store.GetOrg(ctx, orgID)
+}
+
+// ListMine returns the user's memberships and invitations.
+func (s *Service) ListMine(ctx context.Context, userID types.ID) ([]Member, error) {
+	return s.store.ListForUser(ctx, userID)
+}
+
+// Invite adds userIDs as invited members; existing members are skipped. It
+// returns how many invitations were created.
+func (s *Service) Invite(ctx context.Context, orgID, adminID types.ID, userIDs []types.ID) (int, error) {
+	if len(userIDs) == 0 || len(userIDs) > MaxInvites {
+		return 0, ErrBadRequest
+	}
+	for _, id := range userIDs {
+		if id == "" {
+			return 0, ErrBadRequest
+		}
+	}
+	if err := s.requireAdmin(ctx, orgID, adminID); err != nil {
+		return 0, err
+	}
+	return s.store.AddInvites(ctx, orgID, userIDs, s.now())
+}
+
+// Join accepts the user's pending invitation to orgID.
+func (s *Service) Join(ctx context.Context, orgID, userID types.ID) error {
+	m, err := s.store.GetMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if m.Status == MemberActive {
+		return ErrConflict
+	}
+	return s.store.ActivateMember(ctx, orgID, userID, s.now())
+}
+
+// RemoveMember removes userID from the organization. Admins may remove any
+// other member and members may leave; an admin cannot remove themselves, so
+// every organization keeps at least one admin.
+func (s *Service) RemoveMember(ctx context.Context, orgID, actorID, userID types.ID) error {
+	if actorID != userID {
+		if err := s.requireAdmin(ctx, orgID, actorID); err != nil {
+			return err
+		}
+	} else {
+		m, err := s.member(ctx, orgID, actorID)
+		if err != nil {
+			return err
+		}
+		if m.Role == RoleAdmin {
+			return ErrBadRequest
+		}
+	}
+	return s.store.RemoveMember(ctx, orgID, userID)
+}
+
+// ListMembers returns every member and invitation; admins only.
+func (s *Service) ListMembers(ctx context.Context, orgID, adminID types.ID) ([]Member, error) {
+	if err := s.requireAdmin(ctx, orgID, adminID); err != nil {
+		return nil, err
+	}
+	return s.store.ListMembers(ctx, orgID)
+}
+
+// UpdatePolicy replaces the organization's ride policy; admins only.
+func (s *Service) UpdatePolicy(ctx context.Context, orgID, adminID types.ID, p Policy) error {
+	if !p.valid() {
+		return ErrBadRequest
+	}
+	if err := s.requireAdmin(ctx, orgID, adminID); err != nil {
+		return err
+	}
+	return s.store.UpdatePolicy(ctx, orgID, p)
+}
+
+// member returns userID's membership, or ErrForbidden when there is none.
+func (s *Service) member(ctx context.Context, orgID, userID types.ID) (*Member, error) {
+	if orgID == "" || userID == "" {
+		return nil, ErrBadRequest
+	}
+	m, err := s.store.GetMember(ctx, orgID, userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrForbidden
+	}
+	return m, err
+}
+
+func (s *Service) requireAdmin(ctx context.Context, orgID, userID types.ID) error {
+	m, err := s.member(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if m.Role != RoleAdmin || m.Status != MemberActive {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Business rides
+// ---------------------------------------------------------------------------
+
+// CheckRide implements order.OrgPolicy: the passenger must be an active member
+// and the ride must fall inside the allowed hours and the monthly budget.
+func (s *Service) CheckRide(ctx context.Context, orgID, passengerID types.ID, pickupAt time.Time, fare types.Money) error {
+	m, err := s.store.GetMember(ctx, orgID, passengerID)
+	if errors.Is(err, ErrNotFound) {
+		return order.ErrPolicyDenied
+	}
+	if err != nil {
+		return err
+	}
+	if m.Status != MemberActive {
+		return order.ErrPolicyDenied
+	}
+	o, err := s.store.GetOrg(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if !o.Policy.Allows(pickupAt) {
+		return order.ErrPolicyDenied
+	}
+	if o.Policy.MonthlyBudget > 0 {
+		from, to := monthBounds(s.now())
+		spent, err := s.store.Spend(ctx, orgID, from, to)
+		if err != nil {
+			return err
+		}
+		// The budget is set in the platform currency; a fare or spend in
+		// another currency cannot be counted against it.
+		total, err := spent.Add(fare)
+		if errors.Is(err, types.ErrCurrencyMismatch) {
+			return order.ErrPolicyDenied
+		}
+		if err != nil {
+			return err
+		}
+		over, err := total.Cmp(types.Money{Amount: o.Policy.MonthlyBudget, Currency: types.DefaultCurrency})
+		if errors.Is(err, types.ErrCurrencyMismatch) || over > 0 {
+			return order.ErrPolicyDenied
+		}
+	}
+	return nil
+}
+
+// OrderHook settles business rides as soon as they reach payment: the fare
+// goes on the organization's monthly invoice instead of being charged to the
+// passenger.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusPayment {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), settleTimeout)
+			defer cancel()
+			o, err := s.orders.Get(ctx, t.OrderID)
+			if err != nil {
+				log.Printf("organization: load order %s: %v", t.OrderID, err)
+				return
+			}
+			if o.OrgID == nil {
+				return
+			}
+			if err := s.orders.Pay(ctx, order.PayCommand{OrderID: o.ID}); err != nil {
+				log.Printf("organization: settle order %s for org %s: %v", o.ID, *o.OrgID, err)
+			}
+		}()
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Invoices
+// ---------------------------------------------------------------------------
+
+// ListInvoices returns the organization's invoices, newest first; admins only.
+func (s *Service) ListInvoices(ctx context.Context, orgID, adminID types.ID) ([]Invoice, error) {
+	if err := s.requireAdmin(ctx, orgID, adminID); err != nil {
+		return nil, err
+	}
+	return s.store.ListInvoices(ctx, orgID)
+}
+
+// GenerateInvoices totals the business rides completed in the calendar month
+// containing month (Asia/Taipei), stores one invoice per organization and
+// emails the ones not sent yet. It returns the number of invoices emailed.
+func (s *Service) GenerateInvoices(ctx context.Context, month time.Time) (int, error) {
+	from, to := monthBounds(month)
+	invoices, err := s.store.InvoiceRollup(ctx, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("invoice rollup: %w", err)
+	}
+	// One invoice cannot total rides in two currencies; such organizations
+	// are reported and left for finance to bill by hand.
+	mixed := make(map[types.ID]bool)
+	for i := 1; i < len(invoices); i++ {
+		if invoices[i].OrgID == invoices[i-1].OrgID {
+			mixed[invoices[i].OrgID] = true
+		}
+	}
+	sent := 0
+	var errs []error
+	for i := range invoices {
+		inv := &invoices[i]
+		if mixed[inv.OrgID] {
+			if i == 0 || invoices[i-1].OrgID != inv.OrgID {
+				errs = append(errs, fmt.Errorf("%s: %w", inv.OrgID, types.ErrCurrencyMismatch))
+			}
+			continue
+		}
+		pending, err := s.store.SaveInvoice(ctx, inv)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", inv.OrgID, err))
+			continue
+		}
+		if !pending || s.sender == nil {
+			continue
+		}
+		if err := s.sendInvoice(ctx, inv); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", inv.OrgID, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// RunInvoiceJob invoices last month during the first days of each month.
+// Sent invoices are never re-sent, so it simply checks hourly.
+func (s *Service) RunInvoiceJob(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := s.now().In(taipei)
+			if now.Day() > 3 {
+				continue
+			}
+			prev := now.AddDate(0, 0, -now.Day())
+			n, err := s.GenerateInvoices(ctx, prev)
+			if err != nil {
+				log.Printf("organization: invoices for %s: %v", prev.Format("2006-01"), err)
+			}
+			if n > 0 {
+				log.Printf("organization: sent %d invoices for %s", n, prev.Format("2006-01"))
+			}
+		}
+	}
+}
+
+func (s *Service) sendInvoice(ctx context.Context, inv *Invoice) error {
+	o, err := s.store.GetOrg(ctx, inv.OrgID)
+	if err != nil {
+		return err
+	}
+	month := inv.Month.Format("2006-01")
+	// Amounts are minor units; invoices show whole dollars like receipts do.
+	body := fmt.Sprintf("%s\n\nBusiness rides in %s: %d\nTotal due: %s %d\n",
+		o.Name, month, inv.Trips, inv.Total.Currency, inv.Total.Amount/100)
+	err = s.sender.Send(ctx, notification.Email{
+		To:      o.BillingEmail,
+		Subject: "Ark business rides invoice " + month,
+		Body:    body,
+	})
+	if err != nil {
+		return err
+	}
+	return s.store.MarkInvoiceSent(ctx, inv.OrgID, inv.Month, s.now())
+}
+
+// monthBounds returns the calendar month containing t in Asia/Taipei as [from, to).
+func monthBounds(t time.Time) (time.Time, time.Time) {
+	m := t.In(taipei)
+	from := time.Date(m.Year(), m.Month(), 1, 0, 0, 0, 0, taipei)
+	return from, from.AddDate(0, 1, 0)
+}
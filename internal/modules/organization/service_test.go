@@ -0,0 +1,400 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"ark/internal/modules/notification"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// ---------------------------------------------------------------------------
+// In-memory fakes
+// ---------------------------------------------------------------------------
+
+type memberKey struct{ org, user types.ID }
+
+type mockStore struct {
+	orgs     map[types.ID]*Organization
+	members  map[memberKey]*Member
+	spend    int64
+	rollup   []Invoice
+	invoices map[memberKey]*Invoice // keyed by org and month
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{
+		orgs:     make(map[types.ID]*Organization),
+		members:  make(map[memberKey]*Member),
+		invoices: make(map[memberKey]*Invoice),
+	}
+}
+
+func (m *mockStore) CreateOrg(_ context.Context, o *Organization) error {
+	m.orgs[o.ID] = o
+	m.members[memberKey{o.ID, o.CreatedBy}] = &Member{OrgID: o.ID, UserID: o.CreatedBy, Role: RoleAdmin, Status: MemberActive}
+	return nil
+}
+
+func (m *mockStore) GetOrg(_ context.Context, id types.ID) (*Organization, error) {
+	o, ok := m.orgs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *o
+	return &cp, nil
+}
+
+func (m *mockStore) UpdatePolicy(_ context.Context, id types.ID, p Policy) error {
+	o, ok := m.orgs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	o.Policy = p
+	return nil
+}
+
+func (m *mockStore) GetMember(_ context.Context, orgID, userID types.ID) (*Member, error) {
+	mem, ok := m.members[memberKey{orgID, userID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *mem
+	return &cp, nil
+}
+
+func (m *mockStore) AddInvites(_ context.Context, orgID types.ID, userIDs []types.ID, at time.Time) (int, error) {
+	n := 0
+	for _, id := range userIDs {
+		k := memberKey{orgID, id}
+		if _, ok := m.members[k]; ok {
+			continue
+		}
+		m.members[k] = &Member{OrgID: orgID, UserID: id, Role: RoleMember, Status: MemberInvited, InvitedAt: at}
+		n++
+	}
+	return n, nil
+}
+
+func (m *mockStore) ActivateMember(_ context.Context, orgID, userID types.ID, at time.Time) error {
+	mem, ok := m.members[memberKey{orgID, userID}]
+	if !ok || mem.Status != MemberInvited {
+		return ErrNotFound
+	}
+	mem.Status = MemberActive
+	mem.JoinedAt = &at
+	return nil
+}
+
+func (m *mockStore) RemoveMember(_ context.Context, orgID, userID types.ID) error {
+	k := memberKey{orgID, userID}
+	if _, ok := m.members[k]; !ok {
+		return ErrNotFound
+	}
+	delete(m.members, k)
+	return nil
+}
+
+func (m *mockStore) ListMembers(_ context.Context, orgID types.ID) ([]Member, error) {
+	var out []Member
+	for k, mem := range m.members {
+		if k.org == orgID {
+			out = append(out, *mem)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) ListForUser(_ context.Context, userID types.ID) ([]Member, error) {
+	var out []Member
+	for k, mem := range m.members {
+		if k.user == userID {
+			out = append(out, *mem)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) Spend(context.Context, types.ID, time.Time, time.Time) (types.Money, error) {
+	return types.Money{Amount: m.spend, Currency: "TWD"}, nil
+}
+
+func (m *mockStore) InvoiceRollup(context.Context, time.Time, time.Time) ([]Invoice, error) {
+	return append([]Invoice(nil), m.rollup...), nil
+}
+
+func (m *mockStore) SaveInvoice(_ context.Context, inv *Invoice) (bool, error) {
+	k := memberKey{inv.OrgID, types.ID(inv.Month.Format("2006-01"))}
+	if e, ok := m.invoices[k]; ok && e.SentAt != nil {
+		return false, nil
+	}
+	cp := *inv
+	m.invoices[k] = &cp
+	return true, nil
+}
+
+func (m *mockStore) MarkInvoiceSent(_ context.Context, orgID types.ID, month, at time.Time) error {
+	m.invoices[memberKey{orgID, types.ID(month.Format("2006-01"))}].SentAt = &at
+	return nil
+}
+
+func (m *mockStore) ListInvoices(_ context.Context, orgID types.ID) ([]Invoice, error) {
+	var out []Invoice
+	for k, inv := range m.invoices {
+		if k.org == orgID {
+			out = append(out, *inv)
+		}
+	}
+	return out, nil
+}
+
+type fakeOrders struct {
+	mu     sync.Mutex
+	orders map[types.ID]*order.Order
+	paid   chan types.ID
+}
+
+func (f *fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	return o, nil
+}
+
+func (f *fakeOrders) Pay(_ context.Context, cmd order.PayCommand) error {
+	f.paid <- cmd.OrderID
+	return nil
+}
+
+type fakeSender struct {
+	sent []notification.Email
+	err  error
+}
+
+func (f *fakeSender) Send(_ context.Context, e notification.Email) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, e)
+	return nil
+}
+
+// newTestOrg returns a service with org "acme" administered by "boss" and
+// active member "emp".
+func newTestOrg(t *testing.T) (*Service, *mockStore, types.ID) {
+	t.Helper()
+	store := newMockStore()
+	svc := NewService(store, nil)
+	o, err := svc.Create(context.Background(), "boss", "Acme", "billing@acme.test")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := svc.Invite(context.Background(), o.ID, "boss", []types.ID{"emp"}); err != nil {
+		t.Fatalf("Invite: %v", err)
+	}
+	if err := svc.Join(context.Background(), o.ID, "emp"); err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	return svc, store, o.ID
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestCreate_Validation(t *testing.T) {
+	svc := NewService(newMockStore(), nil)
+	if _, err := svc.Create(context.Background(), "boss", " ", "billing@acme.test"); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("blank name: expected ErrBadRequest, got %v", err)
+	}
+	if _, err := svc.Create(context.Background(), "boss", "Acme", "not-an-email"); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("bad email: expected ErrBadRequest, got %v", err)
+	}
+}
+
+func TestMembership(t *testing.T) {
+	svc, store, orgID := newTestOrg(t)
+	ctx := context.Background()
+
+	if _, err := svc.Invite(ctx, orgID, "emp", []types.ID{"other"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("member invites: expected ErrForbidden, got %v", err)
+	}
+	if n, err := svc.Invite(ctx, orgID, "boss", []types.ID{"emp", "new"}); err != nil || n != 1 {
+		t.Errorf("invite: got %d, %v; want 1 new invitation", n, err)
+	}
+	if err := svc.Join(ctx, orgID, "emp"); !errors.Is(err, ErrConflict) {
+		t.Errorf("join twice: expected ErrConflict, got %v", err)
+	}
+	if err := svc.Join(ctx, orgID, "stranger"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("join uninvited: expected ErrNotFound, got %v", err)
+	}
+	if _, err := svc.Get(ctx, orgID, "stranger"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("stranger get: expected ErrForbidden, got %v", err)
+	}
+	if err := svc.RemoveMember(ctx, orgID, "boss", "boss"); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("admin leaves: expected ErrBadRequest, got %v", err)
+	}
+	if err := svc.RemoveMember(ctx, orgID, "emp", "new"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("member removes other: expected ErrForbidden, got %v", err)
+	}
+	if err := svc.RemoveMember(ctx, orgID, "emp", "emp"); err != nil {
+		t.Errorf("member leaves: %v", err)
+	}
+	if _, ok := store.members[memberKey{orgID, "emp"}]; ok {
+		t.Error("member still present after leaving")
+	}
+}
+
+func TestCheckRide(t *testing.T) {
+	svc, store, orgID := newTestOrg(t)
+	ctx := context.Background()
+	svc.now = func() time.Time { return time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC) }
+	if _, err := svc.Invite(ctx, orgID, "boss", []types.ID{"pending"}); err != nil {
+		t.Fatal(err)
+	}
+	// 09:00–19:00 Taipei, NT$1,000 per month.
+	if err := svc.UpdatePolicy(ctx, orgID, "boss", Policy{AllowedFromMin: 9 * 60, AllowedToMin: 19 * 60, MonthlyBudget: 100000}); err != nil {
+		t.Fatalf("UpdatePolicy: %v", err)
+	}
+	store.spend = 90000
+
+	morning := time.Date(2026, 3, 10, 10, 0, 0, 0, taipei)
+	night := time.Date(2026, 3, 10, 23, 0, 0, 0, taipei)
+	cases := []struct {
+		name      string
+		passenger types.ID
+		at        time.Time
+		fare      int64
+		currency  string
+		wantErr   error
+	}{
+		{"allowed", "emp", morning, 5000, "TWD", nil},
+		{"budget exactly used", "emp", morning, 10000, "TWD", nil},
+		{"over budget", "emp", morning, 10001, "TWD", order.ErrPolicyDenied},
+		{"foreign currency", "emp", morning, 100, "USD", order.ErrPolicyDenied},
+		{"outside hours", "emp", night, 5000, "TWD", order.ErrPolicyDenied},
+		{"invited only", "pending", morning, 5000, "TWD", order.ErrPolicyDenied},
+		{"not a member", "stranger", morning, 5000, "TWD", order.ErrPolicyDenied},
+	}
+	for _, tc := range cases {
+		err := svc.CheckRide(ctx, orgID, tc.passenger, tc.at, types.Money{Amount: tc.fare, Currency: tc.currency})
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 10, h, m, 0, 0, taipei) }
+	overnight := Policy{AllowedFromMin: 22 * 60, AllowedToMin: 6 * 60}
+	if !overnight.Allows(at(23, 30)) || !overnight.Allows(at(5, 59)) || overnight.Allows(at(12, 0)) {
+		t.Error("overnight window misclassified")
+	}
+	// 23:30 in Tokyo is 22:30 in Taipei: both inside, but 06:30 Tokyo is
+	// outside even though it is 05:30 in Taipei.
+	tokyo := time.FixedZone("JST", 9*60*60)
+	if !overnight.Allows(time.Date(2026, 3, 10, 23, 30, 0, 0, tokyo)) || overnight.Allows(time.Date(2026, 3, 10, 6, 30, 0, 0, tokyo)) {
+		t.Error("window not read on the pickup's local clock")
+	}
+	if !overnight.Allows(at(5, 30).UTC()) {
+		t.Error("UTC time not read in Asia/Taipei")
+	}
+	if !(Policy{}).Allows(at(3, 0)) {
+		t.Error("empty policy should allow any time")
+	}
+	if (Policy{AllowedFromMin: 24 * 60}).valid() || (Policy{MonthlyBudget: -1}).valid() {
+		t.Error("out-of-range policy accepted")
+	}
+}
+
+func TestOrderHook_SettlesBusinessRides(t *testing.T) {
+	org := types.ID("acme")
+	orders := &fakeOrders{
+		orders: map[types.ID]*order.Order{
+			"biz":      {ID: "biz", OrgID: &org, Status: order.StatusPayment},
+			"personal": {ID: "personal", Status: order.StatusPayment},
+		},
+		paid: make(chan types.ID, 2),
+	}
+	svc := NewService(newMockStore(), orders)
+	hook := svc.OrderHook()
+	hook(context.Background(), order.Transition{OrderID: "personal", To: order.StatusPayment})
+	hook(context.Background(), order.Transition{OrderID: "biz", To: order.StatusPayment})
+
+	select {
+	case id := <-orders.paid:
+		if id != "biz" {
+			t.Fatalf("paid %s, want biz", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("business ride was not settled")
+	}
+	select {
+	case id := <-orders.paid:
+		t.Fatalf("personal ride %s settled by the organization", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestGenerateInvoices(t *testing.T) {
+	svc, store, orgID := newTestOrg(t)
+	ctx := context.Background()
+	month := time.Date(2026, 2, 1, 0, 0, 0, 0, taipei)
+	store.rollup = []Invoice{{OrgID: orgID, Month: month, Trips: 3, Total: types.Money{Amount: 123400, Currency: "TWD"}}}
+
+	// Without a sender invoices are stored but not emailed.
+	if n, err := svc.GenerateInvoices(ctx, month); err != nil || n != 0 {
+		t.Fatalf("no sender: got %d, %v", n, err)
+	}
+	if invs, _ := svc.ListInvoices(ctx, orgID, "boss"); len(invs) != 1 || invs[0].SentAt != nil {
+		t.Fatalf("stored invoices: %+v", invs)
+	}
+
+	failing := &fakeSender{err: errors.New("smtp down")}
+	svc.SetInvoiceSender(failing)
+	if n, err := svc.GenerateInvoices(ctx, month); err == nil || n != 0 {
+		t.Fatalf("failing sender: got %d, %v", n, err)
+	}
+
+	sender := &fakeSender{}
+	svc.SetInvoiceSender(sender)
+	if n, err := svc.GenerateInvoices(ctx, month); err != nil || n != 1 {
+		t.Fatalf("send: got %d, %v", n, err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].To != "billing@acme.test" {
+		t.Fatalf("sent: %+v", sender.sent)
+	}
+	if n, _ := svc.GenerateInvoices(ctx, month); n != 0 {
+		t.Errorf("re-run sent %d invoices, want 0", n)
+	}
+	if _, err := svc.ListInvoices(ctx, orgID, "emp"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("member lists invoices: expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestGenerateInvoices_MixedCurrencySkipped(t *testing.T) {
+	svc, store, orgID := newTestOrg(t)
+	ctx := context.Background()
+	month := time.Date(2026, 2, 1, 0, 0, 0, 0, taipei)
+	store.rollup = []Invoice{
+		{OrgID: orgID, Month: month, Trips: 2, Total: types.Money{Amount: 50000, Currency: "TWD"}},
+		{OrgID: orgID, Month: month, Trips: 1, Total: types.Money{Amount: 2000, Currency: "USD"}},
+	}
+	sender := &fakeSender{}
+	svc.SetInvoiceSender(sender)
+
+	n, err := svc.GenerateInvoices(ctx, month)
+	if !errors.Is(err, types.ErrCurrencyMismatch) || n != 0 {
+		t.Fatalf("got %d, %v; want 0 and ErrCurrencyMismatch", n, err)
+	}
+	if invs, _ := svc.ListInvoices(ctx, orgID, "boss"); len(invs) != 0 {
+		t.Errorf("stored invoices: %+v", invs)
+	}
+}
@@ -0,0 +1,324 @@
+// README: Organization store — PostgreSQL persistence for organizations, org_members, org_invoices and business-ride spend.
+package organization
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// OrgStore defines the persistence operations required by the organization Service.
+type OrgStore interface {
+	// CreateOrg inserts the organization and its creator as an active admin.
+	CreateOrg(ctx context.Context, o *Organization) error
+	GetOrg(ctx context.Context, id types.ID) (*Organization, error)
+	UpdatePolicy(ctx context.Context, id types.ID, p Policy) error
+
+	GetMember(ctx context.Context, orgID, userID types.ID) (*Member, error)
+	// AddInvites records invitations, skipping users who are already members,
+	// and returns how many were added.
+	AddInvites(ctx context.Context, orgID types.ID, userIDs []types.ID, at time.Time) (int, error)
+	// ActivateMember turns a pending invitation into an active membership.
+	ActivateMember(ctx context.Context, orgID, userID types.ID, at time.Time) error
+	RemoveMember(ctx context.Context, orgID, userID types.ID) error
+	ListMembers(ctx context.Context, orgID types.ID) ([]Member, error)
+	ListForUser(ctx context.Context, userID types.ID) ([]Member, error)
+
+	// Spend returns the fares of the organization's rides created in [from, to)
+	// that were not cancelled, counting estimates for unfinished rides. Rides in
+	// more than one currency yield types.ErrCurrencyMismatch.
+	Spend(ctx context.Context, orgID types.ID, from, to time.Time) (types.Money, error)
+	// InvoiceRollup totals completed business rides in [from, to) per
+	// organization and currency, ordered by organization.
+	InvoiceRollup(ctx context.Context, from, to time.Time) ([]Invoice, error)
+	// SaveInvoice stores inv, refreshing the totals of an unsent invoice for the
+	// same month. It reports false when that month's invoice was already sent.
+	SaveInvoice(ctx context.Context, inv *Invoice) (bool, error)
+	MarkInvoiceSent(ctx context.Context, orgID types.ID, month, at time.Time) error
+	ListInvoices(ctx context.Context, orgID types.ID) ([]Invoice, error)
+}
+
+// Store is the PostgreSQL implementation of OrgStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateOrg(ctx context.Context, o *Organization) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO organizations (id, name, billing_email, created_by, allowed_from_min, allowed_to_min, monthly_budget, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		string(o.ID), o.Name, o.BillingEmail, string(o.CreatedBy),
+		o.Policy.AllowedFromMin, o.Policy.AllowedToMin, o.Policy.MonthlyBudget, o.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO org_members (org_id, user_id, role, status, invited_at, joined_at)
+		VALUES ($1, $2, 'admin', 'active', $3, $3)`,
+		string(o.ID), string(o.CreatedBy), o.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) GetOrg(ctx context.Context, id types.ID) (*Organization, error) {
+	var o Organization
+	err := s.db.QueryRow(ctx, `
+		SELECT id, name, billing_email, created_by, allowed_from_min, allowed_to_min, monthly_budget, created_at
+		FROM organizations WHERE id = $1`, string(id),
+	).Scan(&o.ID, &o.Name, &o.BillingEmail, &o.CreatedBy,
+		&o.Policy.AllowedFromMin, &o.Policy.AllowedToMin, &o.Policy.MonthlyBudget, &o.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (s *Store) UpdatePolicy(ctx context.Context, id types.ID, p Policy) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE organizations SET allowed_from_min = $2, allowed_to_min = $3, monthly_budget = $4
+		WHERE id = $1`,
+		string(id), p.AllowedFromMin, p.AllowedToMin, p.MonthlyBudget,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) GetMember(ctx context.Context, orgID, userID types.ID) (*Member, error) {
+	var m Member
+	err := s.db.QueryRow(ctx, `
+		SELECT org_id, user_id, role, status, invited_at, joined_at
+		FROM org_members WHERE org_id = $1 AND user_id = $2`,
+		string(orgID), string(userID),
+	).Scan(&m.OrgID, &m.UserID, &m.Role, &m.Status, &m.InvitedAt, &m.JoinedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *Store) AddInvites(ctx context.Context, orgID types.ID, userIDs []types.ID, at time.Time) (int, error) {
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = string(id)
+	}
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO org_members (org_id, user_id, role, status, invited_at)
+		SELECT $1, u, 'member', 'invited', $3 FROM unnest($2::text[]) AS u
+		ON CONFLICT (org_id, user_id) DO NOTHING`,
+		string(orgID), ids, at,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *Store) ActivateMember(ctx context.Context, orgID, userID types.ID, at time.Time) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE org_members SET status = 'active', joined_at = $3
+		WHERE org_id = $1 AND user_id = $2 AND status = 'invited'`,
+		string(orgID), string(userID), at,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) RemoveMember(ctx context.Context, orgID, userID types.ID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM org_members WHERE org_id = $1 AND user_id = $2`,
+		string(orgID), string(userID))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) ListMembers(ctx context.Context, orgID types.ID) ([]Member, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT org_id, user_id, role, status, invited_at, joined_at
+		FROM org_members WHERE org_id = $1
+		ORDER BY invited_at, user_id`, string(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Member
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.OrgID, &m.UserID, &m.Role, &m.Status, &m.InvitedAt, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListForUser(ctx context.Context, userID types.ID) ([]Member, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT m.org_id, o.name, m.user_id, m.role, m.status, m.invited_at, m.joined_at
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		WHERE m.user_id = $1
+		ORDER BY o.name`, string(userID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Member
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.OrgID, &m.OrgName, &m.UserID, &m.Role, &m.Status, &m.InvitedAt, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) Spend(ctx context.Context, orgID types.ID, from, to time.Time) (types.Money, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT currency, SUM(COALESCE(actual_fee, estimated_fee))
+		FROM orders
+		WHERE org_id = $1 AND created_at >= $2 AND created_at < $3
+		  AND status NOT IN ('cancelled', 'denied', 'expired')
+		GROUP BY currency`,
+		string(orgID), from, to,
+	)
+	if err != nil {
+		return types.Money{}, err
+	}
+	defer rows.Close()
+
+	var total *types.Money
+	for rows.Next() {
+		var m types.Money
+		if err := rows.Scan(&m.Currency, &m.Amount); err != nil {
+			return types.Money{}, err
+		}
+		if total == nil {
+			total = &m
+			continue
+		}
+		if *total, err = total.Add(m); err != nil {
+			return types.Money{}, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return types.Money{}, err
+	}
+	if total == nil {
+		return types.Money{Currency: types.DefaultCurrency}, nil
+	}
+	return *total, nil
+}
+
+func (s *Store) InvoiceRollup(ctx context.Context, from, to time.Time) ([]Invoice, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT org_id, currency, COUNT(*), COALESCE(SUM(COALESCE(actual_fee, estimated_fee)), 0)
+		FROM orders
+		WHERE org_id IS NOT NULL AND NOT sandbox
+		  AND status = 'complete' AND completed_at >= $1 AND completed_at < $2
+		GROUP BY org_id, currency
+		ORDER BY org_id, currency`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Invoice
+	for rows.Next() {
+		inv := Invoice{Month: from}
+		if err := rows.Scan(&inv.OrgID, &inv.Total.Currency, &inv.Trips, &inv.Total.Amount); err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) SaveInvoice(ctx context.Context, inv *Invoice) (bool, error) {
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO org_invoices (org_id, month, trips, total, currency)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (org_id, month) DO UPDATE
+		SET trips = EXCLUDED.trips, total = EXCLUDED.total
+		WHERE org_invoices.sent_at IS NULL
+		RETURNING created_at`,
+		string(inv.OrgID), inv.Month, inv.Trips, inv.Total.Amount, inv.Total.Currency,
+	).Scan(&inv.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The conflicting row was already sent and is left untouched.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Store) MarkInvoiceSent(ctx context.Context, orgID types.ID, month, at time.Time) error {
+	_, err := s.db.Exec(ctx, `UPDATE org_invoices SET sent_at = $3 WHERE org_id = $1 AND month = $2`,
+		string(orgID), month, at)
+	return err
+}
+
+func (s *Store) ListInvoices(ctx context.Context, orgID types.ID) ([]Invoice, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT org_id, month, trips, total, currency, created_at, sent_at
+		FROM org_invoices WHERE org_id = $1
+		ORDER BY month DESC`, string(orgID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Invoice
+	for rows.Next() {
+		var inv Invoice
+		if err := rows.Scan(&inv.OrgID, &inv.Month, &inv.Trips, &inv.Total.Amount, &inv.Total.Currency, &inv.CreatedAt, &inv.SentAt); err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,64 @@
+// README: Bank transfer file submitter — writes each payout batch as a CSV file for the bank's bulk-transfer upload.
+package payout
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"ark/internal/types"
+)
+
+// FileSubmitter writes one CSV per batch into Dir. Finance uploads the file to
+// the bank, mapping driver IDs to the accounts on file. The file is named by
+// settle date and rewritten whole, so resubmitting a batch replaces it.
+type FileSubmitter struct {
+	Dir string
+}
+
+// NewFileSubmitter returns a FileSubmitter writing into dir.
+func NewFileSubmitter(dir string) *FileSubmitter {
+	return &FileSubmitter{Dir: dir}
+}
+
+// Submit writes the batch file and returns its path.
+func (f *FileSubmitter) Submit(_ context.Context, b *Batch, payouts []Payout) (string, error) {
+	if err := os.MkdirAll(f.Dir, 0o750); err != nil {
+		return "", err
+	}
+	path := filepath.Join(f.Dir, "payouts-"+b.SettleDate.Format(time.DateOnly)+".csv")
+	tmp, err := os.CreateTemp(f.Dir, ".payouts-*.csv")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := csv.NewWriter(tmp)
+	_ = w.Write([]string{"payout_id", "driver_id", "amount", "currency", "reference"})
+	for _, p := range payouts {
+		// Banks take whole dollars; TWD fares carry no cents in practice.
+		_ = w.Write([]string{
+			string(p.ID),
+			string(p.DriverID),
+			strconv.FormatInt(types.Money{Amount: p.Amount, Currency: types.DefaultCurrency}.Major(), 10),
+			types.DefaultCurrency,
+			fmt.Sprintf("ARK %s", b.SettleDate.Format("20060102")),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
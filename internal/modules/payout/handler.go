@@ -0,0 +1,154 @@
+// README: Payout HTTP handlers — driver payout history and ops settlement controls.
+//
+// Endpoints:
+//
+//	GET  /api/driver/payouts            — the caller's payouts, newest first (driver_id from context)
+//	GET  /api/ops/payouts/batches       — recent settlement batches (ops key)
+//	POST /api/ops/payouts/settle        — settle or retry one Taipei day (ops key)
+//
+// Auth: driver routes require the Auth middleware to set user_id in context;
+// ops routes require the ops key middleware instead.
+package payout
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the payout HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type settleReq struct {
+	Date string `json:"date"` // YYYY-MM-DD, Asia/Taipei
+}
+
+type payoutResp struct {
+	ID         types.ID `json:"payout_id"`
+	SettleDate string   `json:"settle_date"`
+	Amount     int64    `json:"amount"`
+	Currency   string   `json:"currency"`
+	EntryCount int      `json:"entry_count"`
+	Status     string   `json:"status"`
+	CreatedAt  int64    `json:"created_at"`
+}
+
+type batchResp struct {
+	ID          types.ID `json:"batch_id"`
+	SettleDate  string   `json:"settle_date"`
+	Status      string   `json:"status"`
+	Total       int64    `json:"total"`
+	Currency    string   `json:"currency"`
+	Count       int      `json:"payout_count"`
+	FileRef     string   `json:"file_ref,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	CreatedAt   int64    `json:"created_at"`
+	SubmittedAt *int64   `json:"submitted_at,omitempty"`
+}
+
+func toBatchResp(b *Batch) batchResp {
+	out := batchResp{
+		ID:         b.ID,
+		SettleDate: b.SettleDate.In(taipei).Format(time.DateOnly),
+		Status:     b.Status,
+		Total:      b.Total,
+		Currency:   types.DefaultCurrency,
+		Count:      b.Count,
+		FileRef:    b.FileRef,
+		Error:      b.Error,
+		CreatedAt:  b.CreatedAt.Unix(),
+	}
+	if b.SubmittedAt != nil {
+		ts := b.SubmittedAt.Unix()
+		out.SubmittedAt = &ts
+	}
+	return out
+}
+
+// Payouts handles GET /api/driver/payouts.
+func (h *Handler) Payouts(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	ps, err := h.svc.DriverPayouts(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writePayoutError(c, err)
+		return
+	}
+	out := make([]payoutResp, len(ps))
+	for i, p := range ps {
+		out[i] = payoutResp{
+			ID:         p.ID,
+			SettleDate: p.SettleDate.In(taipei).Format(time.DateOnly),
+			Amount:     p.Amount,
+			Currency:   types.DefaultCurrency,
+			EntryCount: p.EntryCount,
+			Status:     p.Status,
+			CreatedAt:  p.CreatedAt.Unix(),
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{"payouts": out})
+}
+
+// Batches handles GET /api/ops/payouts/batches.
+func (h *Handler) Batches(c *gin.Context) {
+	bs, err := h.svc.Batches(c.Request.Context())
+	if err != nil {
+		writePayoutError(c, err)
+		return
+	}
+	out := make([]batchResp, len(bs))
+	for i := range bs {
+		out[i] = toBatchResp(&bs[i])
+	}
+	c.JSON(http.StatusOK, map[string]any{"batches": out})
+}
+
+// Settle handles POST /api/ops/payouts/settle.
+func (h *Handler) Settle(c *gin.Context) {
+	var req settleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	day, err := time.ParseInLocation(time.DateOnly, req.Date, taipei)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "date must be YYYY-MM-DD")
+		return
+	}
+	b, err := h.svc.Settle(c.Request.Context(), day)
+	if err != nil {
+		writePayoutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toBatchResp(b))
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writePayoutError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,27 @@
+// README: Payout holds — earnings under review (e.g. high-risk trips) are left out of settlement until released.
+package payout
+
+import (
+	"context"
+
+	"ark/internal/types"
+)
+
+// Hold keeps the driver's earnings entries for ref (an order ID for trip
+// earnings) out of every settlement until Release is called. Entries credited
+// after the hold are held too.
+func (s *Service) Hold(ctx context.Context, driverID types.ID, ref, reason string) error {
+	if driverID == "" || ref == "" {
+		return ErrBadRequest
+	}
+	return s.store.Hold(ctx, driverID, ref, reason, s.now())
+}
+
+// Release lifts a hold so the entries settle in the next batch. Releasing an
+// entry that is not held is a no-op.
+func (s *Service) Release(ctx context.Context, driverID types.ID, ref string) error {
+	if driverID == "" || ref == "" {
+		return ErrBadRequest
+	}
+	return s.store.Release(ctx, driverID, ref, s.now())
+}
@@ -0,0 +1,73 @@
+// README: Payout domain model — daily settlement batches and the per-driver payouts in them.
+package payout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Batch statuses. A batch is created pending with its payouts, then handed to
+// the Submitter; a failed submission is retried when the day is settled again.
+const (
+	BatchPending   = "pending"
+	BatchSubmitted = "submitted"
+	BatchFailed    = "failed"
+)
+
+// Batch settles every unsettled earnings entry up to the end of one Taipei
+// calendar day.
+type Batch struct {
+	ID          types.ID
+	SettleDate  time.Time // midnight, Asia/Taipei
+	Status      string
+	Total       int64 // TWD minor units
+	Count       int
+	FileRef     string // transfer file path or provider reference
+	Error       string
+	CreatedAt   time.Time
+	SubmittedAt *time.Time
+}
+
+// Payout is one driver's transfer within a batch.
+type Payout struct {
+	ID         types.ID
+	BatchID    types.ID
+	DriverID   types.ID
+	SettleDate time.Time
+	Amount     int64 // TWD minor units
+	EntryCount int
+	CreatedAt  time.Time
+	// Status is the batch status, filled in for driver history.
+	Status string
+}
+
+// Submitter hands a batch's payouts to the bank or payout provider and
+// returns a reference to the submission. Submitting the same batch again
+// must not pay twice: implementations key on the batch or payout IDs.
+type Submitter interface {
+	Submit(ctx context.Context, b *Batch, payouts []Payout) (string, error)
+}
+
+var (
+	ErrNotFound   = errors.New("payout batch not found")
+	ErrBadRequest = errors.New("bad request")
+)
+
+var taipei = loadTaipei()
+
+func loadTaipei() *time.Location {
+	loc, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return loc
+}
+
+// dayStart returns midnight in Taipei of the day containing t.
+func dayStart(t time.Time) time.Time {
+	t = t.In(taipei)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, taipei)
+}
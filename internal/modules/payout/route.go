@@ -0,0 +1,20 @@
+// README: Payout route registration — mounts the driver payout history and ops settlement endpoints.
+package payout
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the driver-facing payout history onto the provided authenticated router group.
+//
+//	GET /api/driver/payouts
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/driver/payouts", h.Payouts)
+}
+
+// RegisterOpsRoutes mounts the settlement endpoints onto the provided ops router group.
+//
+//	GET  /api/ops/payouts/batches
+//	POST /api/ops/payouts/settle
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/payouts/batches", h.Batches)
+	rg.POST("/api/ops/payouts/settle", h.Settle)
+}
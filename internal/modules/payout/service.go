@@ -0,0 +1,114 @@
+// README: Payout service — daily driver settlement into payout batches, submission, and payout history.
+package payout
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ark/internal/types"
+)
+
+const (
+	// maxHistory caps the payouts and batches returned by the list endpoints.
+	maxHistory = 90
+	// submitErrLen caps the submission error kept on a failed batch.
+	submitErrLen = 500
+)
+
+// Service settles driver earnings into daily payout batches.
+type Service struct {
+	store     PayoutStore
+	submitter Submitter
+	now       func() time.Time
+}
+
+// NewService creates a Service. Batches stay pending until SetSubmitter is called.
+func NewService(store PayoutStore) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// SetSubmitter hands settled batches to the bank or payout provider.
+func (s *Service) SetSubmitter(sub Submitter) {
+	s.submitter = sub
+}
+
+// Settle settles the Taipei day containing day: every unsettled earnings entry
+// created before the day ended is paid out in that day's batch, so entries
+// missed by an earlier run roll into the next one. Re-running a settled day
+// returns its batch, retrying the submission unless it already succeeded.
+func (s *Service) Settle(ctx context.Context, day time.Time) (*Batch, error) {
+	start := dayStart(day)
+	cutoff := start.AddDate(0, 0, 1)
+	now := s.now()
+	if cutoff.After(now) {
+		return nil, ErrBadRequest // the day is not over yet
+	}
+	b, err := s.store.Settle(ctx, &Batch{ID: types.NewID(), SettleDate: start, Status: BatchPending, CreatedAt: now}, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status == BatchSubmitted || s.submitter == nil {
+		return b, nil
+	}
+	var ref string
+	if b.Count > 0 {
+		ps, err := s.store.ListPayouts(ctx, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		ref, err = s.submitter.Submit(ctx, b, ps)
+		if err != nil {
+			msg := err.Error()
+			if len(msg) > submitErrLen {
+				msg = msg[:submitErrLen]
+			}
+			if mErr := s.store.MarkFailed(ctx, b.ID, msg); mErr != nil {
+				log.Printf("payout: mark batch %s failed: %v", b.ID, mErr)
+			}
+			return nil, err
+		}
+	}
+	if err := s.store.MarkSubmitted(ctx, b.ID, ref, now); err != nil {
+		return nil, err
+	}
+	b.Status, b.FileRef, b.Error, b.SubmittedAt = BatchSubmitted, ref, "", &now
+	return b, nil
+}
+
+// RunSettlementJob settles the previous Taipei day. Settling is idempotent,
+// so it simply checks hourly, which also retries failed submissions.
+func (s *Service) RunSettlementJob(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			started := s.now()
+			yesterday := dayStart(started).AddDate(0, 0, -1)
+			b, err := s.Settle(ctx, yesterday)
+			if err != nil {
+				log.Printf("payout: settle %s: %v", yesterday.Format(time.DateOnly), err)
+				continue
+			}
+			if b.SubmittedAt != nil && !b.SubmittedAt.Before(started) {
+				log.Printf("payout: submitted %s: %d payouts, total %d", yesterday.Format(time.DateOnly), b.Count, b.Total)
+			}
+		}
+	}
+}
+
+// DriverPayouts returns the driver's payouts, newest first.
+func (s *Service) DriverPayouts(ctx context.Context, driverID types.ID) ([]Payout, error) {
+	if driverID == "" {
+		return nil, ErrBadRequest
+	}
+	return s.store.ListForDriver(ctx, driverID, maxHistory)
+}
+
+// Batches returns recent batches, newest first.
+func (s *Service) Batches(ctx context.Context) ([]Batch, error) {
+	return s.store.ListBatches(ctx, maxHistory)
+}
@@ -0,0 +1,332 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ark/internal/types"
+)
+
+// ---------------------------------------------------------------------------
+// In-memory fakes
+// ---------------------------------------------------------------------------
+
+type entry struct {
+	driverID  types.ID
+	ref       string
+	amount    int64
+	createdAt time.Time
+	payoutID  types.ID
+}
+
+type mockStore struct {
+	entries []*entry
+	batches map[string]*Batch // by settle date
+	payouts []Payout
+	held    map[string]bool // driver/ref
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{batches: make(map[string]*Batch), held: make(map[string]bool)}
+}
+
+func (m *mockStore) earn(driverID types.ID, amount int64, at time.Time) {
+	m.entries = append(m.entries, &entry{driverID: driverID, amount: amount, createdAt: at})
+}
+
+func (m *mockStore) Settle(_ context.Context, b *Batch, cutoff time.Time) (*Batch, error) {
+	date := b.SettleDate.Format(time.DateOnly)
+	if existing, ok := m.batches[date]; ok {
+		cp := *existing
+		return &cp, nil
+	}
+	nb := *b
+	byDriver := make(map[types.ID][]*entry)
+	var drivers []types.ID
+	for _, e := range m.entries {
+		if e.payoutID != "" || !e.createdAt.Before(cutoff) || m.held[string(e.driverID)+"/"+e.ref] {
+			continue
+		}
+		if byDriver[e.driverID] == nil {
+			drivers = append(drivers, e.driverID)
+		}
+		byDriver[e.driverID] = append(byDriver[e.driverID], e)
+	}
+	for _, d := range drivers {
+		var total int64
+		for _, e := range byDriver[d] {
+			total += e.amount
+		}
+		if total <= 0 {
+			continue
+		}
+		p := Payout{ID: types.NewID(), BatchID: b.ID, DriverID: d, SettleDate: b.SettleDate, Amount: total, EntryCount: len(byDriver[d])}
+		for _, e := range byDriver[d] {
+			e.payoutID = p.ID
+		}
+		m.payouts = append(m.payouts, p)
+		nb.Total += total
+		nb.Count++
+	}
+	m.batches[date] = &nb
+	cp := nb
+	return &cp, nil
+}
+
+func (m *mockStore) Hold(_ context.Context, driverID types.ID, ref, _ string, _ time.Time) error {
+	m.held[string(driverID)+"/"+ref] = true
+	return nil
+}
+
+func (m *mockStore) Release(_ context.Context, driverID types.ID, ref string, _ time.Time) error {
+	delete(m.held, string(driverID)+"/"+ref)
+	return nil
+}
+
+func (m *mockStore) batch(id types.ID) *Batch {
+	for _, b := range m.batches {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (m *mockStore) ListPayouts(_ context.Context, batchID types.ID) ([]Payout, error) {
+	var out []Payout
+	for _, p := range m.payouts {
+		if p.BatchID == batchID {
+			p.Status = m.batch(batchID).Status
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) ListForDriver(_ context.Context, driverID types.ID, _ int) ([]Payout, error) {
+	var out []Payout
+	for _, p := range m.payouts {
+		if p.DriverID == driverID {
+			p.Status = m.batch(p.BatchID).Status
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) MarkSubmitted(_ context.Context, batchID types.ID, ref string, at time.Time) error {
+	b := m.batch(batchID)
+	b.Status, b.FileRef, b.Error, b.SubmittedAt = BatchSubmitted, ref, "", &at
+	return nil
+}
+
+func (m *mockStore) MarkFailed(_ context.Context, batchID types.ID, msg string) error {
+	b := m.batch(batchID)
+	b.Status, b.Error = BatchFailed, msg
+	return nil
+}
+
+func (m *mockStore) ListBatches(_ context.Context, _ int) ([]Batch, error) {
+	var out []Batch
+	for _, b := range m.batches {
+		out = append(out, *b)
+	}
+	return out, nil
+}
+
+type stubSubmitter struct {
+	err   error
+	calls int
+	last  []Payout
+}
+
+func (s *stubSubmitter) Submit(_ context.Context, b *Batch, payouts []Payout) (string, error) {
+	s.calls++
+	s.last = payouts
+	if s.err != nil {
+		return "", s.err
+	}
+	return "ref-" + b.SettleDate.Format(time.DateOnly), nil
+}
+
+func newTestService(now time.Time) (*Service, *mockStore, *stubSubmitter) {
+	st := newMockStore()
+	sub := &stubSubmitter{}
+	svc := NewService(st)
+	svc.SetSubmitter(sub)
+	svc.now = func() time.Time { return now }
+	return svc, st, sub
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestSettle_AggregatesPerDriver(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, taipei)
+	svc, st, sub := newTestService(day.Add(26 * time.Hour))
+	st.earn("d1", 20000, day.Add(9*time.Hour))
+	st.earn("d1", 15000, day.Add(20*time.Hour))
+	st.earn("d2", 30000, day.Add(12*time.Hour))
+	st.earn("d3", 5000, day.Add(10*time.Hour))
+	st.earn("d3", -5000, day.Add(11*time.Hour)) // nothing owed
+	st.earn("d1", 9000, day.Add(25*time.Hour))  // next day
+
+	b, err := svc.Settle(context.Background(), day.Add(15*time.Hour))
+	if err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if b.Status != BatchSubmitted || b.FileRef != "ref-2025-03-10" {
+		t.Fatalf("batch = %+v, want submitted with ref", b)
+	}
+	if b.Total != 65000 || b.Count != 2 {
+		t.Errorf("total, count = %d, %d; want 65000, 2", b.Total, b.Count)
+	}
+	if sub.calls != 1 || len(sub.last) != 2 {
+		t.Errorf("submitter calls = %d with %d payouts; want 1 with 2", sub.calls, len(sub.last))
+	}
+	ps, _ := svc.DriverPayouts(context.Background(), "d1")
+	if len(ps) != 1 || ps[0].Amount != 35000 || ps[0].EntryCount != 2 || ps[0].Status != BatchSubmitted {
+		t.Errorf("d1 payouts = %+v", ps)
+	}
+}
+
+func TestSettle_Idempotent(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, taipei)
+	svc, st, sub := newTestService(day.Add(48 * time.Hour))
+	st.earn("d1", 20000, day.Add(9*time.Hour))
+
+	first, err := svc.Settle(context.Background(), day)
+	if err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	st.earn("d1", 7000, day.Add(10*time.Hour)) // arrives after the run
+	again, err := svc.Settle(context.Background(), day)
+	if err != nil {
+		t.Fatalf("Settle again: %v", err)
+	}
+	if again.ID != first.ID || again.Total != 20000 || sub.calls != 1 {
+		t.Errorf("re-run = %+v after %d submissions; want the first batch unchanged", again, sub.calls)
+	}
+
+	// The late entry rolls into the next day's batch.
+	next, err := svc.Settle(context.Background(), day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Settle next day: %v", err)
+	}
+	if next.Total != 7000 || next.Count != 1 {
+		t.Errorf("next day total, count = %d, %d; want 7000, 1", next.Total, next.Count)
+	}
+}
+
+func TestSettle_FailedSubmissionRetried(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, taipei)
+	svc, st, sub := newTestService(day.Add(30 * time.Hour))
+	st.earn("d1", 20000, day.Add(9*time.Hour))
+	sub.err = errors.New("bank unavailable")
+
+	if _, err := svc.Settle(context.Background(), day); err == nil {
+		t.Fatal("Settle: want submission error")
+	}
+	if b := st.batches["2025-03-10"]; b.Status != BatchFailed || b.Error != "bank unavailable" {
+		t.Fatalf("batch after failure = %+v", b)
+	}
+
+	sub.err = nil
+	b, err := svc.Settle(context.Background(), day)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if b.Status != BatchSubmitted || b.Total != 20000 || sub.calls != 2 {
+		t.Errorf("retry = %+v after %d submissions", b, sub.calls)
+	}
+	if len(st.payouts) != 1 {
+		t.Errorf("payouts = %d, want 1 (retry must not re-aggregate)", len(st.payouts))
+	}
+}
+
+func TestSettle_DayNotOver(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, taipei)
+	svc, _, sub := newTestService(day.Add(23 * time.Hour))
+	if _, err := svc.Settle(context.Background(), day); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("err = %v, want ErrBadRequest", err)
+	}
+	if sub.calls != 0 {
+		t.Error("submitter called for an open day")
+	}
+}
+
+func TestSettle_NoSubmitterLeavesPending(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, taipei)
+	st := newMockStore()
+	svc := NewService(st)
+	svc.now = func() time.Time { return day.Add(30 * time.Hour) }
+	st.earn("d1", 20000, day.Add(9*time.Hour))
+
+	b, err := svc.Settle(context.Background(), day)
+	if err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if b.Status != BatchPending || b.Total != 20000 {
+		t.Errorf("batch = %+v, want pending", b)
+	}
+}
+
+func TestSettle_HeldEntriesCarryForward(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, taipei)
+	svc, st, _ := newTestService(day.Add(50 * time.Hour))
+	st.earn("d1", 20000, day.Add(9*time.Hour))
+	st.entries = append(st.entries, &entry{driverID: "d1", ref: "order-risky", amount: 15000, createdAt: day.Add(10 * time.Hour)})
+	ctx := context.Background()
+
+	if err := svc.Hold(ctx, "d1", "order-risky", "risk review"); err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	b, err := svc.Settle(ctx, day)
+	if err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if b.Total != 20000 {
+		t.Errorf("total with hold = %d, want 20000", b.Total)
+	}
+
+	if err := svc.Release(ctx, "d1", "order-risky"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	next, err := svc.Settle(ctx, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Settle next day: %v", err)
+	}
+	if next.Total != 15000 {
+		t.Errorf("next day total = %d, want the released 15000", next.Total)
+	}
+}
+
+func TestFileSubmitter(t *testing.T) {
+	dir := t.TempDir()
+	b := &Batch{ID: "b1", SettleDate: time.Date(2025, 3, 10, 0, 0, 0, 0, taipei)}
+	ref, err := NewFileSubmitter(dir).Submit(context.Background(), b, []Payout{
+		{ID: "p1", DriverID: "d1", Amount: 35000},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if ref != filepath.Join(dir, "payouts-2025-03-10.csv") {
+		t.Errorf("ref = %q", ref)
+	}
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "payout_id,driver_id,amount,currency,reference\np1,d1,350,TWD,ARK 20250310\n"
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".payouts-*")); len(matches) != 0 {
+		t.Errorf("temp files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,277 @@
+// README: Payout store — PostgreSQL persistence for payout_batches, payouts and settling driver_earnings.
+package payout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// PayoutStore defines the persistence operations required by the payout Service.
+type PayoutStore interface {
+	// Settle creates the batch for b.SettleDate, settling every unsettled
+	// earnings entry created before cutoff into one payout per driver with a
+	// positive total. When the day already has a batch it is returned unchanged.
+	Settle(ctx context.Context, b *Batch, cutoff time.Time) (*Batch, error)
+	ListPayouts(ctx context.Context, batchID types.ID) ([]Payout, error)
+	// MarkSubmitted records the submission and books each payout in the
+	// ledger as money leaving for the driver's bank account.
+	MarkSubmitted(ctx context.Context, batchID types.ID, ref string, at time.Time) error
+	MarkFailed(ctx context.Context, batchID types.ID, msg string) error
+	ListBatches(ctx context.Context, limit int) ([]Batch, error)
+	ListForDriver(ctx context.Context, driverID types.ID, limit int) ([]Payout, error)
+	// Hold keeps the driver's earnings entries with ref out of settlement
+	// until released; holding twice keeps the first reason.
+	Hold(ctx context.Context, driverID types.ID, ref, reason string, at time.Time) error
+	// Release lifts an active hold, if any.
+	Release(ctx context.Context, driverID types.ID, ref string, at time.Time) error
+}
+
+// Store is the PostgreSQL implementation of PayoutStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+const batchColumns = `id, settle_date, status, total, payout_count, file_ref, error, created_at, submitted_at`
+
+func scanBatch(row pgx.Row) (*Batch, error) {
+	var b Batch
+	var id string
+	if err := row.Scan(&id, &b.SettleDate, &b.Status, &b.Total, &b.Count, &b.FileRef, &b.Error, &b.CreatedAt, &b.SubmittedAt); err != nil {
+		return nil, err
+	}
+	b.ID = types.ID(id)
+	// DATE columns scan as UTC midnight; report the Taipei day.
+	b.SettleDate = time.Date(b.SettleDate.Year(), b.SettleDate.Month(), b.SettleDate.Day(), 0, 0, 0, 0, taipei)
+	return &b, nil
+}
+
+// Settle serialises runs with an advisory lock, so two instances settling the
+// same day cannot both sweep the same entries.
+func (s *Store) Settle(ctx context.Context, b *Batch, cutoff time.Time) (*Batch, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('payout-settlement'))`); err != nil {
+		return nil, err
+	}
+	date := b.SettleDate.Format(time.DateOnly)
+	existing, err := scanBatch(tx.QueryRow(ctx, `SELECT `+batchColumns+` FROM payout_batches WHERE settle_date = $1`, date))
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT e.id, e.driver_id, e.amount FROM driver_earnings e
+		WHERE e.payout_id IS NULL AND e.created_at < $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM payout_holds h
+			WHERE h.driver_id = e.driver_id AND h.ref = e.ref AND h.released_at IS NULL)`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	type pending struct {
+		ids    []int64
+		amount int64
+	}
+	byDriver := make(map[string]*pending)
+	var order []string
+	for rows.Next() {
+		var id, amount int64
+		var driverID string
+		if err := rows.Scan(&id, &driverID, &amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p := byDriver[driverID]
+		if p == nil {
+			p = &pending{}
+			byDriver[driverID] = p
+			order = append(order, driverID)
+		}
+		p.ids = append(p.ids, id)
+		p.amount += amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := *b
+	_, err = tx.Exec(ctx, `
+		INSERT INTO payout_batches (id, settle_date, status, created_at) VALUES ($1, $2, $3, $4)`,
+		string(b.ID), date, BatchPending, b.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, driverID := range order {
+		p := byDriver[driverID]
+		// Drivers whose adjustments leave nothing owed carry the entries forward.
+		if p.amount <= 0 {
+			continue
+		}
+		payoutID := types.NewID()
+		_, err := tx.Exec(ctx, `
+			INSERT INTO payouts (id, batch_id, driver_id, settle_date, amount, entry_count, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			string(payoutID), string(b.ID), driverID, date, p.amount, len(p.ids), b.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE driver_earnings SET payout_id = $1, settled_at = $2 WHERE id = ANY($3)`,
+			string(payoutID), b.CreatedAt, p.ids,
+		)
+		if err != nil {
+			return nil, err
+		}
+		out.Total += p.amount
+		out.Count++
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE payout_batches SET total = $2, payout_count = $3 WHERE id = $1`,
+		string(b.ID), out.Total, out.Count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	out.Status = BatchPending
+	return &out, tx.Commit(ctx)
+}
+
+func (s *Store) ListPayouts(ctx context.Context, batchID types.ID) ([]Payout, error) {
+	return s.queryPayouts(ctx, `
+		SELECT p.id, p.batch_id, p.driver_id, p.settle_date, p.amount, p.entry_count, p.created_at, b.status
+		FROM payouts p JOIN payout_batches b ON b.id = p.batch_id
+		WHERE p.batch_id = $1 ORDER BY p.driver_id`, string(batchID))
+}
+
+func (s *Store) ListForDriver(ctx context.Context, driverID types.ID, limit int) ([]Payout, error) {
+	return s.queryPayouts(ctx, `
+		SELECT p.id, p.batch_id, p.driver_id, p.settle_date, p.amount, p.entry_count, p.created_at, b.status
+		FROM payouts p JOIN payout_batches b ON b.id = p.batch_id
+		WHERE p.driver_id = $1 ORDER BY p.settle_date DESC LIMIT $2`, string(driverID), limit)
+}
+
+func (s *Store) queryPayouts(ctx context.Context, sql string, args ...any) ([]Payout, error) {
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Payout
+	for rows.Next() {
+		var p Payout
+		var id, batchID, driverID string
+		if err := rows.Scan(&id, &batchID, &driverID, &p.SettleDate, &p.Amount, &p.EntryCount, &p.CreatedAt, &p.Status); err != nil {
+			return nil, err
+		}
+		p.ID, p.BatchID, p.DriverID = types.ID(id), types.ID(batchID), types.ID(driverID)
+		p.SettleDate = time.Date(p.SettleDate.Year(), p.SettleDate.Month(), p.SettleDate.Day(), 0, 0, 0, 0, taipei)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) MarkSubmitted(ctx context.Context, batchID types.ID, ref string, at time.Time) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE payout_batches SET status = $2, file_ref = $3, error = '', submitted_at = $4 WHERE id = $1`,
+		string(batchID), BatchSubmitted, ref, at,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := tx.Query(ctx, `SELECT id, driver_id, amount FROM payouts WHERE batch_id = $1`, string(batchID))
+	if err != nil {
+		return err
+	}
+	var txns []*ledger.Txn
+	for rows.Next() {
+		var id, driverID string
+		var amount int64
+		if err := rows.Scan(&id, &driverID, &amount); err != nil {
+			rows.Close()
+			return err
+		}
+		txns = append(txns, ledger.PayoutTxn(types.ID(driverID), types.ID(id), amount, at))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, t := range txns {
+		if _, err := ledger.PostTx(ctx, tx, t); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) MarkFailed(ctx context.Context, batchID types.ID, msg string) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE payout_batches SET status = $2, error = $3 WHERE id = $1 AND status <> $4`,
+		string(batchID), BatchFailed, msg, BatchSubmitted,
+	)
+	return err
+}
+
+func (s *Store) ListBatches(ctx context.Context, limit int) ([]Batch, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+batchColumns+` FROM payout_batches ORDER BY settle_date DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Batch
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *b)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) Hold(ctx context.Context, driverID types.ID, ref, reason string, at time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO payout_holds (driver_id, ref, reason, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (driver_id, ref) DO UPDATE SET reason = EXCLUDED.reason, created_at = EXCLUDED.created_at, released_at = NULL
+		WHERE payout_holds.released_at IS NOT NULL`,
+		string(driverID), ref, reason, at,
+	)
+	return err
+}
+
+func (s *Store) Release(ctx context.Context, driverID types.ID, ref string, at time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE payout_holds SET released_at = $3
+		WHERE driver_id = $1 AND ref = $2 AND released_at IS NULL`,
+		string(driverID), ref, at,
+	)
+	return err
+}
@@ -0,0 +1,177 @@
+// README: Saved places HTTP handlers — a passenger's favorite and recent destinations.
+//
+// Endpoints:
+//
+//	GET    /api/places      — the caller's places, favorites first (?favorites=true for favorites only)
+//	POST   /api/places      — save a place ({"label", "address", "lat", "lng", "favorite"})
+//	PATCH  /api/places/:id  — rename, re-address or (un)favorite a place
+//	DELETE /api/places/:id  — forget a place
+//
+// Auth: all routes require the Auth middleware to set user_id in context.
+package place
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the saved places HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type createReq struct {
+	Label    string  `json:"label"`
+	Address  string  `json:"address"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+	Favorite bool    `json:"favorite"`
+}
+
+type updateReq struct {
+	Label    *string `json:"label"`
+	Address  *string `json:"address"`
+	Favorite *bool   `json:"favorite"`
+}
+
+type placeResp struct {
+	ID            types.ID `json:"place_id"`
+	Label         string   `json:"label"`
+	Address       string   `json:"address"`
+	Lat           float64  `json:"lat"`
+	Lng           float64  `json:"lng"`
+	Favorite      bool     `json:"favorite"`
+	Visits        int      `json:"visits"`
+	LastVisitedAt *int64   `json:"last_visited_at,omitempty"`
+	CreatedAt     int64    `json:"created_at"`
+}
+
+func toPlaceResp(p *Place) placeResp {
+	out := placeResp{
+		ID:        p.ID,
+		Label:     p.Label,
+		Address:   p.Address,
+		Lat:       p.Point.Lat,
+		Lng:       p.Point.Lng,
+		Favorite:  p.Favorite,
+		Visits:    p.Visits,
+		CreatedAt: p.CreatedAt.Unix(),
+	}
+	if p.LastVisitedAt != nil {
+		ts := p.LastVisitedAt.Unix()
+		out.LastVisitedAt = &ts
+	}
+	return out
+}
+
+// List handles GET /api/places.
+func (h *Handler) List(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	places, err := h.svc.List(c.Request.Context(), types.ID(uid), c.Query("favorites") == "true")
+	if err != nil {
+		writePlaceError(c, err)
+		return
+	}
+	out := make([]placeResp, 0, len(places))
+	for i := range places {
+		out = append(out, toPlaceResp(&places[i]))
+	}
+	c.JSON(http.StatusOK, map[string]any{"places": out})
+}
+
+// Create handles POST /api/places.
+func (h *Handler) Create(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req createReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	p, err := h.svc.Create(c.Request.Context(), CreateCommand{
+		UserID:   types.ID(uid),
+		Label:    req.Label,
+		Address:  req.Address,
+		Point:    types.Point{Lat: req.Lat, Lng: req.Lng},
+		Favorite: req.Favorite,
+	})
+	if err != nil {
+		writePlaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toPlaceResp(p))
+}
+
+// Update handles PATCH /api/places/:id.
+func (h *Handler) Update(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req updateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	p, err := h.svc.Update(c.Request.Context(), UpdateCommand{
+		UserID:   types.ID(uid),
+		ID:       types.ID(c.Param("id")),
+		Label:    req.Label,
+		Address:  req.Address,
+		Favorite: req.Favorite,
+	})
+	if err != nil {
+		writePlaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toPlaceResp(p))
+}
+
+// Delete handles DELETE /api/places/:id.
+func (h *Handler) Delete(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.Delete(c.Request.Context(), types.ID(uid), types.ID(c.Param("id"))); err != nil {
+		writePlaceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writePlaceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrLimit):
+		writeError(c, http.StatusConflict, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
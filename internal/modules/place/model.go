@@ -0,0 +1,56 @@
+// README: Saved places domain model — a user's favorite and recent destinations, fed to the AI ride assistant.
+package place
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"ark/internal/types"
+)
+
+const (
+	MaxLabelLen   = 50
+	MaxAddressLen = 200
+	// MaxFavorites bounds how many places one user may favorite.
+	MaxFavorites = 50
+	// contextLimit is how many places are described to the AI planner.
+	contextLimit = 8
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("place not found")
+	// ErrLimit is returned when a user already has MaxFavorites favorites.
+	ErrLimit = errors.New("too many favorite places")
+)
+
+// Place is a destination a user favorited or was driven to. Recent places are
+// captured from completed rides; the user can name and favorite them.
+type Place struct {
+	ID     types.ID
+	UserID types.ID
+	// Label is the user's name for the place ("家", "常去的咖啡店"); empty for
+	// recent places the user has not named.
+	Label   string
+	Address string
+	Point   types.Point
+	// Favorite places are kept and listed first; others age out of the
+	// recent list as new destinations come in.
+	Favorite      bool
+	Visits        int
+	LastVisitedAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// cell identifies the ~100 m square around p. Rides to the same building
+// often end a few metres apart, so places are keyed by cell, not by point.
+func cell(p types.Point) string {
+	return fmt.Sprintf("%.3f,%.3f", math.Round(p.Lat*1000)/1000, math.Round(p.Lng*1000)/1000)
+}
+
+func validPoint(p types.Point) bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180 && (p.Lat != 0 || p.Lng != 0)
+}
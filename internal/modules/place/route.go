@@ -0,0 +1,18 @@
+// README: Saved places route registration — mounts the passenger places endpoints onto the given router group.
+package place
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the saved places endpoints onto the provided authenticated router group.
+//
+//	GET    /api/places
+//	POST   /api/places
+//	PATCH  /api/places/:id
+//	DELETE /api/places/:id
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	places := rg.Group("/api/places")
+	places.GET("", h.List)
+	places.POST("", h.Create)
+	places.PATCH("/:id", h.Update)
+	places.DELETE("/:id", h.Delete)
+}
@@ -0,0 +1,241 @@
+// README: Saved places service — CRUD for a user's places, auto-capture of ride destinations, and the summary given to the AI planner.
+package place
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+const (
+	// captureTimeout bounds recording one completed ride's destination.
+	captureTimeout = 15 * time.Second
+	// maxRecent is how many non-favorite places are kept per user.
+	maxRecent = 20
+)
+
+// Orders is the subset of order.Service the service uses.
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// AddressLookup turns a coordinate into a street address (maps.RouteService).
+type AddressLookup interface {
+	ReverseGeocode(ctx context.Context, p types.Point) (string, error)
+}
+
+// Service manages users' saved places.
+type Service struct {
+	store   PlaceStore
+	orders  Orders
+	address AddressLookup
+	now     func() time.Time
+}
+
+// NewService returns a Service backed by store.
+func NewService(store PlaceStore, orders Orders) *Service {
+	return &Service{store: store, orders: orders, now: time.Now}
+}
+
+// SetAddressLookup lets the service name the address of places captured from
+// rides. Without it they are kept with coordinates only.
+func (s *Service) SetAddressLookup(a AddressLookup) {
+	s.address = a
+}
+
+// CreateCommand saves a place for a user.
+type CreateCommand struct {
+	UserID   types.ID
+	Label    string
+	Address  string
+	Point    types.Point
+	Favorite bool
+}
+
+// UpdateCommand changes a saved place; nil fields are left as they are.
+type UpdateCommand struct {
+	UserID   types.ID
+	ID       types.ID
+	Label    *string
+	Address  *string
+	Favorite *bool
+}
+
+// List returns the user's places, favorites first.
+func (s *Service) List(ctx context.Context, userID types.ID, favoritesOnly bool) ([]Place, error) {
+	return s.store.List(ctx, userID, favoritesOnly, MaxFavorites+maxRecent)
+}
+
+// Create saves a place. Saving a place in the same cell as an existing one
+// updates that place instead of adding a second.
+func (s *Service) Create(ctx context.Context, cmd CreateCommand) (*Place, error) {
+	label, address := strings.TrimSpace(cmd.Label), strings.TrimSpace(cmd.Address)
+	if cmd.UserID == "" || !validPoint(cmd.Point) || !validText(label, address) {
+		return nil, ErrBadRequest
+	}
+	if cmd.Favorite {
+		if err := s.checkFavoriteLimit(ctx, cmd.UserID); err != nil {
+			return nil, err
+		}
+	}
+	now := s.now()
+	return s.store.Save(ctx, &Place{
+		ID:        types.NewID(),
+		UserID:    cmd.UserID,
+		Label:     label,
+		Address:   address,
+		Point:     cmd.Point,
+		Favorite:  cmd.Favorite,
+		CreatedAt: now,
+	}, cell(cmd.Point))
+}
+
+// Update renames, re-addresses, favorites or unfavorites one of the user's places.
+func (s *Service) Update(ctx context.Context, cmd UpdateCommand) (*Place, error) {
+	p, err := s.store.Get(ctx, cmd.UserID, cmd.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Label != nil {
+		p.Label = strings.TrimSpace(*cmd.Label)
+	}
+	if cmd.Address != nil {
+		p.Address = strings.TrimSpace(*cmd.Address)
+	}
+	if !validText(p.Label, p.Address) {
+		return nil, ErrBadRequest
+	}
+	if cmd.Favorite != nil {
+		if *cmd.Favorite && !p.Favorite {
+			if err := s.checkFavoriteLimit(ctx, cmd.UserID); err != nil {
+				return nil, err
+			}
+		}
+		p.Favorite = *cmd.Favorite
+	}
+	p.UpdatedAt = s.now()
+	if err := s.store.Update(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Delete removes one of the user's places.
+func (s *Service) Delete(ctx context.Context, userID, id types.ID) error {
+	return s.store.Delete(ctx, userID, id)
+}
+
+func (s *Service) checkFavoriteLimit(ctx context.Context, userID types.ID) error {
+	n, err := s.store.CountFavorites(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if n >= MaxFavorites {
+		return ErrLimit
+	}
+	return nil
+}
+
+func validText(label, address string) bool {
+	return utf8.RuneCountInString(label) <= MaxLabelLen && utf8.RuneCountInString(address) <= MaxAddressLen
+}
+
+// OrderHook records the dropoff of every completed ride as a recent place of
+// its passenger, in the background.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusComplete || t.Sandbox {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), captureTimeout)
+			defer cancel()
+			if err := s.RecordRide(ctx, t.OrderID); err != nil {
+				log.Printf("place: order %s: %v", t.OrderID, err)
+			}
+		}()
+	}
+}
+
+// RecordRide counts a completed ride's dropoff as a visit by its passenger
+// and looks up the address of places seen for the first time.
+func (s *Service) RecordRide(ctx context.Context, orderID types.ID) error {
+	o, err := s.orders.Get(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("get order: %w", err)
+	}
+	if !validPoint(o.Dropoff) {
+		return nil
+	}
+	at := s.now()
+	if o.CompletedAt != nil {
+		at = *o.CompletedAt
+	}
+	p, err := s.store.RecordVisit(ctx, types.NewID(), o.PassengerID, o.Dropoff, cell(o.Dropoff), at)
+	if err != nil {
+		return fmt.Errorf("record visit: %w", err)
+	}
+	if p.Visits == 1 {
+		if err := s.store.PruneRecent(ctx, o.PassengerID, maxRecent); err != nil {
+			log.Printf("place: prune recent places of %s: %v", o.PassengerID, err)
+		}
+	}
+	if p.Address != "" || s.address == nil {
+		return nil
+	}
+	addr, err := s.address.ReverseGeocode(ctx, o.Dropoff)
+	if err != nil {
+		return fmt.Errorf("reverse geocode: %w", err)
+	}
+	if utf8.RuneCountInString(addr) > MaxAddressLen {
+		addr = string([]rune(addr)[:MaxAddressLen])
+	}
+	return s.store.SetAddress(ctx, p.ID, addr)
+}
+
+// ContextInfo describes the user's top places for the AI planner, so a
+// request like "去常去的那家咖啡" can be resolved to an address. It returns ""
+// when the user has no places.
+func (s *Service) ContextInfo(ctx context.Context, userID types.ID) (string, error) {
+	places, err := s.store.List(ctx, userID, false, contextLimit)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, p := range places {
+		if p.Label == "" && p.Address == "" {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("使用者常用地點：")
+		}
+		b.WriteString("\n- ")
+		if p.Label != "" {
+			b.WriteString(p.Label)
+			if p.Address != "" {
+				b.WriteString("：")
+			}
+		}
+		b.WriteString(p.Address)
+		if p.Address == "" {
+			fmt.Fprintf(&b, "（%.6f,%.6f）", p.Point.Lat, p.Point.Lng)
+		}
+		var notes []string
+		if p.Favorite {
+			notes = append(notes, "收藏")
+		}
+		if p.Visits > 0 {
+			notes = append(notes, fmt.Sprintf("去過 %d 次", p.Visits))
+		}
+		if len(notes) > 0 {
+			b.WriteString("（" + strings.Join(notes, "，") + "）")
+		}
+	}
+	return b.String(), nil
+}
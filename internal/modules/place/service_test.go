@@ -0,0 +1,277 @@
+package place
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/pii"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	places map[types.ID]*Place
+	cells  map[types.ID]string
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{places: make(map[types.ID]*Place), cells: make(map[types.ID]string)}
+}
+
+func (m *mockStore) byCell(userID types.ID, c string) *Place {
+	for id, p := range m.places {
+		if p.UserID == userID && m.cells[id] == c {
+			return p
+		}
+	}
+	return nil
+}
+
+func (m *mockStore) List(_ context.Context, userID types.ID, favoritesOnly bool, limit int) ([]Place, error) {
+	var out []Place
+	for _, p := range m.places {
+		if p.UserID == userID && (p.Favorite || !favoritesOnly) {
+			out = append(out, *p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Favorite != out[j].Favorite {
+			return out[i].Favorite
+		}
+		return out[i].Visits > out[j].Visits
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *mockStore) Get(_ context.Context, userID, id types.ID) (*Place, error) {
+	p, ok := m.places[id]
+	if !ok || p.UserID != userID {
+		return nil, ErrNotFound
+	}
+	cp := *p
+	return &cp, nil
+}
+
+func (m *mockStore) Save(_ context.Context, p *Place, c string) (*Place, error) {
+	if existing := m.byCell(p.UserID, c); existing != nil {
+		existing.Label, existing.Favorite = p.Label, p.Favorite
+		if p.Address != "" {
+			existing.Address = p.Address
+		}
+		cp := *existing
+		return &cp, nil
+	}
+	cp := *p
+	m.places[p.ID], m.cells[p.ID] = &cp, c
+	return p, nil
+}
+
+func (m *mockStore) Update(_ context.Context, p *Place) error {
+	if _, ok := m.places[p.ID]; !ok {
+		return ErrNotFound
+	}
+	cp := *p
+	m.places[p.ID] = &cp
+	return nil
+}
+
+func (m *mockStore) Delete(_ context.Context, userID, id types.ID) error {
+	if p, ok := m.places[id]; !ok || p.UserID != userID {
+		return ErrNotFound
+	}
+	delete(m.places, id)
+	return nil
+}
+
+func (m *mockStore) CountFavorites(_ context.Context, userID types.ID) (int, error) {
+	n := 0
+	for _, p := range m.places {
+		if p.UserID == userID && p.Favorite {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockStore) RecordVisit(_ context.Context, id, userID types.ID, pt types.Point, c string, at time.Time) (*Place, error) {
+	p := m.byCell(userID, c)
+	if p == nil {
+		p = &Place{ID: id, UserID: userID, Point: pt, CreatedAt: at}
+		m.places[id], m.cells[id] = p, c
+	}
+	p.Visits++
+	p.LastVisitedAt = &at
+	cp := *p
+	return &cp, nil
+}
+
+func (m *mockStore) SetAddress(_ context.Context, id types.ID, address string) error {
+	if p, ok := m.places[id]; ok && p.Address == "" {
+		p.Address = address
+	}
+	return nil
+}
+
+func (m *mockStore) PruneRecent(context.Context, types.ID, int) error {
+	return nil
+}
+
+type fakeOrders map[types.ID]*order.Order
+
+func (f fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	o, ok := f[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	return o, nil
+}
+
+type fakeLookup struct {
+	calls int
+}
+
+func (f *fakeLookup) ReverseGeocode(context.Context, types.Point) (string, error) {
+	f.calls++
+	return "台北市信義區松高路1號", nil
+}
+
+var cafe = types.Point{Lat: 25.039312, Lng: 121.567241}
+
+func TestCreate_Validation(t *testing.T) {
+	svc := NewService(newMockStore(), fakeOrders{})
+	ctx := context.Background()
+	bad := []CreateCommand{
+		{UserID: "u1", Label: "家"},
+		{UserID: "u1", Label: strings.Repeat("家", MaxLabelLen+1), Point: cafe},
+		{UserID: "u1", Point: types.Point{Lat: 91, Lng: 121}},
+	}
+	for _, cmd := range bad {
+		if _, err := svc.Create(ctx, cmd); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("Create(%+v) err = %v, want ErrBadRequest", cmd, err)
+		}
+	}
+}
+
+func TestCreate_SameCellUpdates(t *testing.T) {
+	store := newMockStore()
+	svc := NewService(store, fakeOrders{})
+	ctx := context.Background()
+
+	first, err := svc.Create(ctx, CreateCommand{UserID: "u1", Label: "咖啡", Point: cafe})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// A few metres away is the same place.
+	second, err := svc.Create(ctx, CreateCommand{UserID: "u1", Label: "常去的咖啡店", Point: types.Point{Lat: 25.03935, Lng: 121.56720}, Favorite: true})
+	if err != nil {
+		t.Fatalf("create again: %v", err)
+	}
+	if second.ID != first.ID || second.Label != "常去的咖啡店" || !second.Favorite || len(store.places) != 1 {
+		t.Errorf("second = %+v with %d places; want the first place updated", second, len(store.places))
+	}
+}
+
+func TestUpdate_FavoriteLimit(t *testing.T) {
+	store := newMockStore()
+	svc := NewService(store, fakeOrders{})
+	ctx := context.Background()
+	for i := 0; i < MaxFavorites; i++ {
+		store.places[types.ID(fmt.Sprintf("f%d", i))] = &Place{UserID: "u1", Favorite: true}
+	}
+	p, err := svc.Create(ctx, CreateCommand{UserID: "u1", Point: cafe})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	fav := true
+	if _, err := svc.Update(ctx, UpdateCommand{UserID: "u1", ID: p.ID, Favorite: &fav}); !errors.Is(err, ErrLimit) {
+		t.Errorf("err = %v, want ErrLimit", err)
+	}
+	if _, err := svc.Update(ctx, UpdateCommand{UserID: "u2", ID: p.ID, Favorite: &fav}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other user: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRecordRide_CapturesDropoff(t *testing.T) {
+	store := newMockStore()
+	done := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	orders := fakeOrders{
+		"o1": {ID: "o1", PassengerID: "u1", Dropoff: cafe, CompletedAt: &done},
+		"o2": {ID: "o2", PassengerID: "u1", Dropoff: types.Point{Lat: 25.03928, Lng: 121.56731}, CompletedAt: &done},
+	}
+	lookup := &fakeLookup{}
+	svc := NewService(store, orders)
+	svc.SetAddressLookup(lookup)
+	ctx := context.Background()
+
+	for _, id := range []types.ID{"o1", "o2"} {
+		if err := svc.RecordRide(ctx, id); err != nil {
+			t.Fatalf("record %s: %v", id, err)
+		}
+	}
+	places, _ := svc.List(ctx, "u1", false)
+	if len(places) != 1 {
+		t.Fatalf("got %d places, want 1", len(places))
+	}
+	if p := places[0]; p.Visits != 2 || p.Address != "台北市信義區松高路1號" || !p.LastVisitedAt.Equal(done) {
+		t.Errorf("place = %+v", p)
+	}
+	if lookup.calls != 1 {
+		t.Errorf("reverse geocoded %d times, want once", lookup.calls)
+	}
+}
+
+func TestContextInfo(t *testing.T) {
+	store := newMockStore()
+	svc := NewService(store, fakeOrders{})
+	ctx := context.Background()
+
+	if info, err := svc.ContextInfo(ctx, "u1"); err != nil || info != "" {
+		t.Fatalf("no places: info = %q, err = %v", info, err)
+	}
+	store.places["p1"] = &Place{ID: "p1", UserID: "u1", Label: "常去的咖啡店", Address: "台北市信義區松高路1號", Favorite: true, Visits: 5}
+	store.places["p2"] = &Place{ID: "p2", UserID: "u1", Address: "台北市中正區北平西路3號", Visits: 2}
+	store.places["p3"] = &Place{ID: "p3", UserID: "u1", Visits: 1}
+
+	info, err := svc.ContextInfo(ctx, "u1")
+	if err != nil {
+		t.Fatalf("context: %v", err)
+	}
+	want := "使用者常用地點：\n- 常去的咖啡店：台北市信義區松高路1號（收藏，去過 5 次）\n- 台北市中正區北平西路3號（去過 2 次）"
+	if info != want {
+		t.Errorf("info = %q, want %q", info, want)
+	}
+}
+
+func TestStore_SealedPointRoundTrip(t *testing.T) {
+	k, err := pii.NewKeyring(map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}, "k1", bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	s := &Store{}
+	s.SetKeyring(k)
+	p := types.Point{Lat: 25.033964, Lng: 121.564468}
+	sealed, err := s.sealPoint(p)
+	if err != nil {
+		t.Fatalf("sealPoint: %v", err)
+	}
+	if strings.Contains(sealed, "25.03") {
+		t.Fatalf("position stored in plaintext: %q", sealed)
+	}
+	got, err := s.openPoint(sealed)
+	if err != nil || got != p {
+		t.Fatalf("openPoint = %v, %v; want %v", got, err, p)
+	}
+	// Places in one cell share a blind index, so the per-cell dedup holds.
+	if k.BlindIndex(cell(p)) != k.BlindIndex(cell(types.Point{Lat: 25.0341, Lng: 121.5643})) {
+		t.Error("nearby points index to different cells")
+	}
+}
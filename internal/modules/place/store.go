@@ -0,0 +1,321 @@
+// README: Saved places store — PostgreSQL persistence for user_places.
+package place
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/pii"
+	"ark/internal/types"
+)
+
+// PlaceStore defines the persistence operations required by the Service.
+// A user has at most one place per cell.
+type PlaceStore interface {
+	// List returns up to limit of the user's places: favorites first, then by
+	// visits and recency.
+	List(ctx context.Context, userID types.ID, favoritesOnly bool, limit int) ([]Place, error)
+	Get(ctx context.Context, userID, id types.ID) (*Place, error)
+	// Save creates p, or updates the label, address and favorite flag of the
+	// user's place in the same cell, and returns the stored place.
+	Save(ctx context.Context, p *Place, cell string) (*Place, error)
+	// Update writes p's label, address and favorite flag.
+	Update(ctx context.Context, p *Place) error
+	Delete(ctx context.Context, userID, id types.ID) error
+	// CountFavorites returns how many places the user has favorited.
+	CountFavorites(ctx context.Context, userID types.ID) (int, error)
+	// RecordVisit counts a ride to the cell, creating a recent place at p if
+	// the user has none there, and returns it.
+	RecordVisit(ctx context.Context, id, userID types.ID, p types.Point, cell string, at time.Time) (*Place, error)
+	// SetAddress fills in the address of a place that has none.
+	SetAddress(ctx context.Context, id types.ID, address string) error
+	// PruneRecent deletes the user's non-favorite places beyond the keep most
+	// recently visited.
+	PruneRecent(ctx context.Context, userID types.ID, keep int) error
+}
+
+// Store is the PostgreSQL implementation of PlaceStore. Labels, addresses and
+// positions are sealed with the PII keyring and cells are stored as blind
+// indexes, so the table never holds where a user goes in plaintext.
+type Store struct {
+	db  *pgxpool.Pool
+	pii *pii.Keyring
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+// SetKeyring enables encryption of labels, addresses and positions; nil keeps
+// plaintext.
+func (s *Store) SetKeyring(k *pii.Keyring) {
+	s.pii = k
+}
+
+const placeColumns = `id, user_id, label, address, position, lat, lng, favorite, visits, last_visited_at, created_at, updated_at`
+
+func (s *Store) scanPlace(row pgx.Row) (*Place, error) {
+	var p Place
+	var position string
+	var lat, lng sql.NullFloat64
+	err := row.Scan(&p.ID, &p.UserID, &p.Label, &p.Address, &position, &lat, &lng,
+		&p.Favorite, &p.Visits, &p.LastVisitedAt, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Label, err = s.pii.Decrypt(p.Label); err != nil {
+		return nil, err
+	}
+	if p.Address, err = s.pii.Decrypt(p.Address); err != nil {
+		return nil, err
+	}
+	if position == "" {
+		p.Point = types.Point{Lat: lat.Float64, Lng: lng.Float64}
+	} else if p.Point, err = s.openPoint(position); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// sealPoint encrypts p as "lat,lng".
+func (s *Store) sealPoint(p types.Point) (string, error) {
+	return s.pii.Encrypt(strconv.FormatFloat(p.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(p.Lng, 'f', -1, 64))
+}
+
+// openPoint decrypts a position sealed by sealPoint.
+func (s *Store) openPoint(stored string) (types.Point, error) {
+	plain, err := s.pii.Decrypt(stored)
+	if err != nil {
+		return types.Point{}, err
+	}
+	lat, lng, ok := strings.Cut(plain, ",")
+	if !ok {
+		return types.Point{}, pii.ErrMalformed
+	}
+	var p types.Point
+	if p.Lat, err = strconv.ParseFloat(lat, 64); err != nil {
+		return types.Point{}, pii.ErrMalformed
+	}
+	if p.Lng, err = strconv.ParseFloat(lng, 64); err != nil {
+		return types.Point{}, pii.ErrMalformed
+	}
+	return p, nil
+}
+
+func (s *Store) List(ctx context.Context, userID types.ID, favoritesOnly bool, limit int) ([]Place, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+placeColumns+`
+		FROM user_places
+		WHERE user_id = $1 AND (favorite OR NOT $2)
+		ORDER BY favorite DESC, visits DESC, last_visited_at DESC NULLS LAST, created_at DESC
+		LIMIT $3`,
+		string(userID), favoritesOnly, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Place
+	for rows.Next() {
+		p, err := s.scanPlace(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) Get(ctx context.Context, userID, id types.ID) (*Place, error) {
+	return s.scanPlace(s.db.QueryRow(ctx, `
+		SELECT `+placeColumns+` FROM user_places WHERE id = $1 AND user_id = $2`,
+		string(id), string(userID),
+	))
+}
+
+func (s *Store) Save(ctx context.Context, p *Place, cell string) (*Place, error) {
+	label, err := s.pii.Encrypt(p.Label)
+	if err != nil {
+		return nil, err
+	}
+	address, err := s.pii.Encrypt(p.Address)
+	if err != nil {
+		return nil, err
+	}
+	position, err := s.sealPoint(p.Point)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanPlace(s.db.QueryRow(ctx, `
+		INSERT INTO user_places (id, user_id, cell, label, address, position, favorite, visits, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8, $8)
+		ON CONFLICT (user_id, cell) DO UPDATE SET
+		    label      = EXCLUDED.label,
+		    address    = CASE WHEN EXCLUDED.address <> '' THEN EXCLUDED.address ELSE user_places.address END,
+		    favorite   = EXCLUDED.favorite,
+		    updated_at = EXCLUDED.updated_at
+		RETURNING `+placeColumns,
+		string(p.ID), string(p.UserID), s.pii.BlindIndex(cell), label, address, position, p.Favorite, p.CreatedAt,
+	))
+}
+
+func (s *Store) Update(ctx context.Context, p *Place) error {
+	label, err := s.pii.Encrypt(p.Label)
+	if err != nil {
+		return err
+	}
+	address, err := s.pii.Encrypt(p.Address)
+	if err != nil {
+		return err
+	}
+	tag, err := s.db.Exec(ctx, `
+		UPDATE user_places SET label = $3, address = $4, favorite = $5, updated_at = $6
+		WHERE id = $1 AND user_id = $2`,
+		string(p.ID), string(p.UserID), label, address, p.Favorite, p.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, userID, id types.ID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM user_places WHERE id = $1 AND user_id = $2`, string(id), string(userID))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) CountFavorites(ctx context.Context, userID types.ID) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `SELECT count(*) FROM user_places WHERE user_id = $1 AND favorite`, string(userID)).Scan(&n)
+	return n, err
+}
+
+func (s *Store) RecordVisit(ctx context.Context, id, userID types.ID, p types.Point, cell string, at time.Time) (*Place, error) {
+	position, err := s.sealPoint(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanPlace(s.db.QueryRow(ctx, `
+		INSERT INTO user_places (id, user_id, cell, position, visits, last_visited_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 1, $5, $5, $5)
+		ON CONFLICT (user_id, cell) DO UPDATE SET
+		    visits          = user_places.visits + 1,
+		    last_visited_at = EXCLUDED.last_visited_at
+		RETURNING `+placeColumns,
+		string(id), string(userID), s.pii.BlindIndex(cell), position, at,
+	))
+}
+
+func (s *Store) SetAddress(ctx context.Context, id types.ID, address string) error {
+	sealed, err := s.pii.Encrypt(address)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, `UPDATE user_places SET address = $2 WHERE id = $1 AND address = ''`, string(id), sealed)
+	return err
+}
+
+func (s *Store) PruneRecent(ctx context.Context, userID types.ID, keep int) error {
+	_, err := s.db.Exec(ctx, `
+		DELETE FROM user_places
+		WHERE user_id = $1 AND NOT favorite AND id NOT IN (
+		    SELECT id FROM user_places
+		    WHERE user_id = $1 AND NOT favorite
+		    ORDER BY last_visited_at DESC NULLS LAST
+		    LIMIT $2
+		)`,
+		string(userID), keep,
+	)
+	return err
+}
+
+// RotatePlaces re-encrypts up to limit places whose label, address or
+// position is plaintext or sealed with a retired key, or whose cell is not
+// the blind index of their position. It returns how many rows were
+// rewritten; callers loop until it returns 0.
+func (s *Store) RotatePlaces(ctx context.Context, limit int) (int, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+placeColumns+`, cell FROM user_places ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	type pending struct {
+		p    *Place
+		cell string
+	}
+	var todo []pending
+	for rows.Next() && len(todo) < limit {
+		var p Place
+		var label, address, position, stored string
+		var lat, lng sql.NullFloat64
+		if err := rows.Scan(&p.ID, &p.UserID, &label, &address, &position, &lat, &lng,
+			&p.Favorite, &p.Visits, &p.LastVisitedAt, &p.CreatedAt, &p.UpdatedAt, &stored); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if p.Label, err = s.pii.Decrypt(label); err == nil {
+			p.Address, err = s.pii.Decrypt(address)
+		}
+		if err == nil && position != "" {
+			p.Point, err = s.openPoint(position)
+		} else if err == nil {
+			p.Point = types.Point{Lat: lat.Float64, Lng: lng.Float64}
+		}
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("decrypt place %s: %w", p.ID, err)
+		}
+		index := s.pii.BlindIndex(cell(p.Point))
+		if position == "" || lat.Valid || stored != index ||
+			s.pii.NeedsRotation(label) || s.pii.NeedsRotation(address) || s.pii.NeedsRotation(position) {
+			todo = append(todo, pending{&p, index})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, t := range todo {
+		label, err := s.pii.Encrypt(t.p.Label)
+		if err != nil {
+			return 0, err
+		}
+		address, err := s.pii.Encrypt(t.p.Address)
+		if err != nil {
+			return 0, err
+		}
+		position, err := s.sealPoint(t.p.Point)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := s.db.Exec(ctx, `
+			UPDATE user_places
+			SET label = $2, address = $3, position = $4, cell = $5, lat = NULL, lng = NULL
+			WHERE id = $1`,
+			string(t.p.ID), label, address, position, t.cell); err != nil {
+			return 0, err
+		}
+	}
+	return len(todo), nil
+}
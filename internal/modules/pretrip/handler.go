@@ -0,0 +1,142 @@
+// README: Pre-trip reminder HTTP handlers — the ops escalation queue of drivers who have not departed, re-dispatch, and per-driver response times.
+//
+// Endpoints:
+//
+//	GET  /api/ops/pretrip/escalations                       — drivers not departed in time (ops key)
+//	POST /api/ops/pretrip/escalations/:order_id/redispatch  — take the ride from the driver (ops key)
+//	GET  /api/ops/reports/pretrip                           — reminder response times per driver (ops key)
+//
+// Auth: routes require the ops key middleware.
+package pretrip
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Handler holds the pre-trip reminder HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type escalationResp struct {
+	OrderID       types.ID  `json:"order_id"`
+	DriverID      types.ID  `json:"driver_id"`
+	PickupLat     float64   `json:"pickup_lat"`
+	PickupLng     float64   `json:"pickup_lng"`
+	ScheduledAt   time.Time `json:"scheduled_at"`
+	RemindedAt    time.Time `json:"reminded_at"`
+	EscalatedAt   time.Time `json:"escalated_at"`
+	NavigationURL string    `json:"navigation_url"`
+}
+
+type driverStatsResp struct {
+	DriverID           types.ID `json:"driver_id"`
+	Reminders          int      `json:"reminders"`
+	Departed           int      `json:"departed"`
+	Escalated          int      `json:"escalated"`
+	AvgResponseSeconds int64    `json:"avg_response_seconds"`
+	MaxResponseSeconds int64    `json:"max_response_seconds"`
+}
+
+// Escalations handles GET /api/ops/pretrip/escalations.
+func (h *Handler) Escalations(c *gin.Context) {
+	rs, err := h.svc.Escalations(c.Request.Context())
+	if err != nil {
+		writePretripError(c, err)
+		return
+	}
+	out := make([]escalationResp, len(rs))
+	for i, r := range rs {
+		out[i] = escalationResp{
+			OrderID:       r.OrderID,
+			DriverID:      r.DriverID,
+			PickupLat:     r.Pickup.Lat,
+			PickupLng:     r.Pickup.Lng,
+			ScheduledAt:   r.ScheduledAt,
+			RemindedAt:    r.RemindedAt,
+			EscalatedAt:   *r.EscalatedAt,
+			NavigationURL: r.NavigationURL(),
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{"escalations": out})
+}
+
+// Redispatch handles POST /api/ops/pretrip/escalations/:order_id/redispatch.
+func (h *Handler) Redispatch(c *gin.Context) {
+	if err := h.svc.Redispatch(c.Request.Context(), types.ID(c.Param("order_id"))); err != nil {
+		writePretripError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Report handles GET /api/ops/reports/pretrip?from=&to=&driver_id=. The
+// period defaults to the last 7 days.
+func (h *Handler) Report(c *gin.Context) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+	var err error
+	if s := c.Query("from"); s != "" {
+		if from, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid from; expected RFC3339")
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid to; expected RFC3339")
+			return
+		}
+	}
+
+	stats, err := h.svc.DriverStats(c.Request.Context(), from, to, types.ID(c.Query("driver_id")))
+	if err != nil {
+		writePretripError(c, err)
+		return
+	}
+	out := make([]driverStatsResp, len(stats))
+	for i, d := range stats {
+		out[i] = driverStatsResp{
+			DriverID:           d.DriverID,
+			Reminders:          d.Reminders,
+			Departed:           d.Departed,
+			Escalated:          d.Escalated,
+			AvgResponseSeconds: int64(d.AvgResponse.Seconds()),
+			MaxResponseSeconds: int64(d.MaxResponse.Seconds()),
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"from":    from,
+		"to":      to,
+		"drivers": out,
+	})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writePretripError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, "from must be before to")
+	case errors.Is(err, ErrNotFound), errors.Is(err, order.ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		writeError(c, http.StatusConflict, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,74 @@
+// README: Pre-trip reminder domain model — the push sent to the driver of a claimed scheduled ride when its window starts, the driver's response, and per-driver response statistics.
+package pretrip
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/maps"
+	"ark/internal/types"
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("no pre-trip reminder for this order")
+	// ErrConflict is returned when re-dispatching a ride that is no longer
+	// escalated, e.g. because its driver has departed meanwhile.
+	ErrConflict = errors.New("pre-trip escalation is no longer open")
+)
+
+// Reminder is the pre-trip push sent to the driver of a claimed scheduled ride
+// when its schedule window starts, and what became of it.
+type Reminder struct {
+	OrderID     types.ID
+	DriverID    types.ID
+	Pickup      types.Point
+	ScheduledAt time.Time
+	RemindedAt  time.Time
+	// DepartedAt is when the driver tapped "depart".
+	DepartedAt *time.Time
+	// EscalatedAt is when ops were asked to step in because the driver had
+	// not departed in time.
+	EscalatedAt *time.Time
+	// ReleasedAt is when the driver lost the ride without departing: they
+	// released it, it was cancelled, or ops re-dispatched it.
+	ReleasedAt *time.Time
+}
+
+// NavigationURL opens turn-by-turn driving directions to the pickup in the
+// driver's maps app.
+func (r Reminder) NavigationURL() string {
+	return NavigationURL(r.Pickup)
+}
+
+// NavigationURL returns a Google Maps directions link to p. It opens the Maps
+// app where installed and the browser elsewhere.
+func NavigationURL(p types.Point) string {
+	return maps.NavigationLinks(p).Web
+}
+
+// ResponseTime is how long the driver took to depart after the reminder; ok
+// is false while they have not.
+func (r Reminder) ResponseTime() (d time.Duration, ok bool) {
+	if r.DepartedAt == nil {
+		return 0, false
+	}
+	return max(r.DepartedAt.Sub(r.RemindedAt), 0), true
+}
+
+// Open reports whether the reminder still awaits the driver's departure.
+func (r Reminder) Open() bool {
+	return r.DepartedAt == nil && r.ReleasedAt == nil
+}
+
+// DriverStats summarises how one driver responded to pre-trip reminders over
+// a period.
+type DriverStats struct {
+	DriverID  types.ID
+	Reminders int
+	Departed  int
+	Escalated int
+	// AvgResponse and MaxResponse are over the reminders the driver departed on.
+	AvgResponse time.Duration
+	MaxResponse time.Duration
+}
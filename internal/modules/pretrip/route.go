@@ -0,0 +1,16 @@
+// README: Pre-trip reminder route registration — mounts the ops escalation queue and response-time report.
+package pretrip
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the escalation queue and report onto the provided
+// ops router group.
+//
+//	GET  /api/ops/pretrip/escalations
+//	POST /api/ops/pretrip/escalations/:order_id/redispatch
+//	GET  /api/ops/reports/pretrip
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/pretrip/escalations", h.Escalations)
+	rg.POST("/api/ops/pretrip/escalations/:order_id/redispatch", h.Redispatch)
+	rg.GET("/api/ops/reports/pretrip", h.Report)
+}
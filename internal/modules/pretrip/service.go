@@ -0,0 +1,186 @@
+// README: Pre-trip reminder service — reminds drivers of claimed scheduled rides to set off when the schedule window starts, escalates those who have not to ops, and tracks how fast each driver responds.
+package pretrip
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// hookTimeout bounds recording one departure or release from an order hook.
+const hookTimeout = 10 * time.Second
+
+// Orders is the subset of order.Service the service uses.
+type Orders interface {
+	ListAssignedPickups(ctx context.Context, now time.Time) ([]order.AssignedPickup, error)
+	ReleaseAssigned(ctx context.Context, cmd order.ReleaseAssignedCommand) error
+}
+
+// ReminderHook is called after a new reminder has been stored, e.g. to push
+// it to the driver.
+type ReminderHook func(ctx context.Context, r Reminder)
+
+// Service sends pre-trip reminders and escalates drivers who do not depart.
+type Service struct {
+	store          ReminderStore
+	orders         Orders
+	interval       time.Duration
+	escalateBefore time.Duration
+	hooks          []ReminderHook
+	now            func() time.Time
+}
+
+// NewService returns a Service that checks claimed rides every interval and
+// escalates drivers who have not departed escalateBefore ahead of pickup.
+func NewService(store ReminderStore, orders Orders, interval, escalateBefore time.Duration) *Service {
+	return &Service{store: store, orders: orders, interval: interval, escalateBefore: escalateBefore, now: time.Now}
+}
+
+// OnReminder registers h to run after every new reminder. Must be called
+// before the service starts.
+func (s *Service) OnReminder(h ReminderHook) {
+	s.hooks = append(s.hooks, h)
+}
+
+// RunJob checks claimed rides near pickup every interval until ctx is done.
+func (s *Service) RunJob(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckOnce(ctx)
+		}
+	}
+}
+
+// CheckOnce reminds the driver of every claimed ride whose schedule window
+// has started, once per driver and pickup time, and escalates the reminded
+// drivers who have not departed escalateBefore ahead of pickup.
+func (s *Service) CheckOnce(ctx context.Context) {
+	now := s.now()
+	pickups, err := s.orders.ListAssignedPickups(ctx, now)
+	if err != nil {
+		log.Printf("pretrip: list pickups: %v", err)
+		return
+	}
+	for _, p := range pickups {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.check(ctx, p, now); err != nil {
+			log.Printf("pretrip: order %s: %v", p.OrderID, err)
+		}
+	}
+}
+
+func (s *Service) check(ctx context.Context, p order.AssignedPickup, now time.Time) error {
+	r, err := s.store.Get(ctx, p.OrderID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if r == nil || r.DriverID != p.DriverID || !r.ScheduledAt.Equal(p.ScheduledAt) {
+		// The driver gets at least one interval to respond before escalation.
+		return s.remind(ctx, p, now)
+	}
+	if !r.Open() || r.EscalatedAt != nil || now.Before(p.ScheduledAt.Add(-s.escalateBefore)) {
+		return nil
+	}
+	ok, err := s.store.Escalate(ctx, p.OrderID, now)
+	if err != nil || !ok {
+		return err
+	}
+	log.Printf("pretrip: driver %s has not departed for order %s picking up at %s; escalated to ops",
+		p.DriverID, p.OrderID, p.ScheduledAt.Format(time.RFC3339))
+	return nil
+}
+
+func (s *Service) remind(ctx context.Context, p order.AssignedPickup, now time.Time) error {
+	r := Reminder{
+		OrderID:     p.OrderID,
+		DriverID:    p.DriverID,
+		Pickup:      p.Pickup,
+		ScheduledAt: p.ScheduledAt,
+		RemindedAt:  now,
+	}
+	if err := s.store.Save(ctx, &r); err != nil {
+		return err
+	}
+	for _, h := range s.hooks {
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					log.Printf("pretrip: reminder hook panicked for %s: %v", p.OrderID, rec)
+				}
+			}()
+			h(ctx, r)
+		}()
+	}
+	return nil
+}
+
+// OrderHook records in the background when the driver of a reminded ride
+// departs (Assigned → Approaching) or loses the ride without departing.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.From != order.StatusAssigned || t.Sandbox {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), hookTimeout)
+			defer cancel()
+			var err error
+			switch {
+			case t.To == order.StatusApproaching && t.DriverID != nil:
+				_, err = s.store.Depart(ctx, t.OrderID, *t.DriverID, t.At)
+			case t.To == order.StatusScheduled || t.To == order.StatusCancelled:
+				_, err = s.store.Release(ctx, t.OrderID, t.At)
+			}
+			if err != nil {
+				log.Printf("pretrip: order %s %s→%s: %v", t.OrderID, t.From, t.To, err)
+			}
+		}()
+	}
+}
+
+// Escalations returns the rides whose driver was escalated and has neither
+// departed nor lost the ride, earliest pickup first.
+func (s *Service) Escalations(ctx context.Context) ([]Reminder, error) {
+	return s.store.ListEscalated(ctx)
+}
+
+// Redispatch takes an escalated ride from its driver and re-opens it for
+// other drivers; once claims have closed it goes to instant dispatch.
+func (s *Service) Redispatch(ctx context.Context, orderID types.ID) error {
+	r, err := s.store.Get(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if r.EscalatedAt == nil || !r.Open() {
+		return ErrConflict
+	}
+	err = s.orders.ReleaseAssigned(ctx, order.ReleaseAssignedCommand{OrderID: orderID, DriverID: r.DriverID})
+	if errors.Is(err, order.ErrConflict) || errors.Is(err, order.ErrInvalidState) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.store.Release(ctx, orderID, s.now())
+	return err
+}
+
+// DriverStats returns reminder response statistics for reminders sent in
+// [from, to), per driver or for driverID alone when it is set.
+func (s *Service) DriverStats(ctx context.Context, from, to time.Time, driverID types.ID) ([]DriverStats, error) {
+	if !from.Before(to) {
+		return nil, ErrBadRequest
+	}
+	return s.store.DriverStats(ctx, from, to, driverID)
+}
@@ -0,0 +1,237 @@
+package pretrip
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	reminders map[types.ID]*Reminder
+}
+
+func (m *mockStore) Get(_ context.Context, orderID types.ID) (*Reminder, error) {
+	r, ok := m.reminders[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *r
+	return &cp, nil
+}
+
+func (m *mockStore) Save(_ context.Context, r *Reminder) error {
+	cp := *r
+	m.reminders[r.OrderID] = &cp
+	return nil
+}
+
+func (m *mockStore) Depart(_ context.Context, orderID, driverID types.ID, at time.Time) (bool, error) {
+	r, ok := m.reminders[orderID]
+	if !ok || r.DriverID != driverID || !r.Open() {
+		return false, nil
+	}
+	r.DepartedAt = &at
+	return true, nil
+}
+
+func (m *mockStore) Escalate(_ context.Context, orderID types.ID, at time.Time) (bool, error) {
+	r, ok := m.reminders[orderID]
+	if !ok || r.EscalatedAt != nil || !r.Open() {
+		return false, nil
+	}
+	r.EscalatedAt = &at
+	return true, nil
+}
+
+func (m *mockStore) Release(_ context.Context, orderID types.ID, at time.Time) (bool, error) {
+	r, ok := m.reminders[orderID]
+	if !ok || !r.Open() {
+		return false, nil
+	}
+	r.ReleasedAt = &at
+	return true, nil
+}
+
+func (m *mockStore) ListEscalated(context.Context) ([]Reminder, error) {
+	var out []Reminder
+	for _, r := range m.reminders {
+		if r.EscalatedAt != nil && r.Open() {
+			out = append(out, *r)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) DriverStats(context.Context, time.Time, time.Time, types.ID) ([]DriverStats, error) {
+	return nil, nil
+}
+
+type fakeOrders struct {
+	pickups  []order.AssignedPickup
+	released []order.ReleaseAssignedCommand
+	err      error
+}
+
+func (f *fakeOrders) ListAssignedPickups(_ context.Context, now time.Time) ([]order.AssignedPickup, error) {
+	var out []order.AssignedPickup
+	for _, p := range f.pickups {
+		if !p.WindowStart.After(now) && p.ScheduledAt.After(now) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrders) ReleaseAssigned(_ context.Context, cmd order.ReleaseAssignedCommand) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.released = append(f.released, cmd)
+	return nil
+}
+
+var pickupAt = time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+
+func newTestService() (*Service, *mockStore, *fakeOrders, *[]Reminder) {
+	store := &mockStore{reminders: map[types.ID]*Reminder{}}
+	orders := &fakeOrders{pickups: []order.AssignedPickup{{
+		OrderID:     "o1",
+		PassengerID: "p1",
+		DriverID:    "d1",
+		Pickup:      types.Point{Lat: 25.0478, Lng: 121.517},
+		ScheduledAt: pickupAt,
+		WindowStart: pickupAt.Add(-30 * time.Minute),
+	}}}
+	svc := NewService(store, orders, time.Minute, 10*time.Minute)
+	var sent []Reminder
+	svc.OnReminder(func(_ context.Context, r Reminder) { sent = append(sent, r) })
+	return svc, store, orders, &sent
+}
+
+func runAt(s *Service, t time.Time) {
+	s.now = func() time.Time { return t }
+	s.CheckOnce(context.Background())
+}
+
+func TestCheckOnce_RemindsOncePerDriverAndPickup(t *testing.T) {
+	svc, _, orders, sent := newTestService()
+
+	runAt(svc, pickupAt.Add(-31*time.Minute))
+	if len(*sent) != 0 {
+		t.Fatalf("before the window: %d reminders sent", len(*sent))
+	}
+	runAt(svc, pickupAt.Add(-30*time.Minute))
+	runAt(svc, pickupAt.Add(-29*time.Minute))
+	if len(*sent) != 1 {
+		t.Fatalf("window started: %d reminders sent, want 1", len(*sent))
+	}
+	r := (*sent)[0]
+	if r.DriverID != "d1" || !r.RemindedAt.Equal(pickupAt.Add(-30*time.Minute)) {
+		t.Errorf("reminder = %+v", r)
+	}
+	if want := "https://www.google.com/maps/dir/?api=1&destination=25.047800,121.517000&travelmode=driving"; r.NavigationURL() != want {
+		t.Errorf("NavigationURL = %q, want %q", r.NavigationURL(), want)
+	}
+
+	// A new driver claimed the ride: they are reminded too.
+	orders.pickups[0].DriverID = "d2"
+	runAt(svc, pickupAt.Add(-20*time.Minute))
+	if len(*sent) != 2 || (*sent)[1].DriverID != "d2" {
+		t.Errorf("after the ride changed drivers: reminders %+v", *sent)
+	}
+}
+
+func TestCheckOnce_EscalatesDriverNotDeparted(t *testing.T) {
+	svc, store, _, _ := newTestService()
+
+	runAt(svc, pickupAt.Add(-30*time.Minute))
+	runAt(svc, pickupAt.Add(-10*time.Minute-time.Second))
+	if store.reminders["o1"].EscalatedAt != nil {
+		t.Fatal("escalated before EscalateBefore")
+	}
+	runAt(svc, pickupAt.Add(-10*time.Minute))
+	if got := store.reminders["o1"].EscalatedAt; got == nil || !got.Equal(pickupAt.Add(-10*time.Minute)) {
+		t.Fatalf("EscalatedAt = %v, want 10 minutes before pickup", got)
+	}
+	if esc, _ := svc.Escalations(context.Background()); len(esc) != 1 {
+		t.Errorf("escalation queue holds %d rides, want 1", len(esc))
+	}
+}
+
+func TestCheckOnce_DepartedIsNotEscalated(t *testing.T) {
+	svc, store, _, _ := newTestService()
+
+	runAt(svc, pickupAt.Add(-30*time.Minute))
+	departed := pickupAt.Add(-22 * time.Minute)
+	if ok, _ := store.Depart(context.Background(), "o1", "d1", departed); !ok {
+		t.Fatal("Depart of an open reminder failed")
+	}
+	runAt(svc, pickupAt.Add(-5*time.Minute))
+	if store.reminders["o1"].EscalatedAt != nil {
+		t.Error("a driver who departed was escalated")
+	}
+	if d, ok := store.reminders["o1"].ResponseTime(); !ok || d != 8*time.Minute {
+		t.Errorf("ResponseTime = %v, %v; want 8m", d, ok)
+	}
+}
+
+func TestCheckOnce_LateReminderGetsAnInterval(t *testing.T) {
+	svc, store, _, sent := newTestService()
+
+	// Claimed inside the escalation lead: remind first, escalate next run.
+	runAt(svc, pickupAt.Add(-5*time.Minute))
+	if len(*sent) != 1 || store.reminders["o1"].EscalatedAt != nil {
+		t.Fatalf("first run: %d reminders, escalated %v", len(*sent), store.reminders["o1"].EscalatedAt)
+	}
+	runAt(svc, pickupAt.Add(-4*time.Minute))
+	if store.reminders["o1"].EscalatedAt == nil {
+		t.Error("second run did not escalate")
+	}
+}
+
+func TestRedispatch(t *testing.T) {
+	svc, store, orders, _ := newTestService()
+	ctx := context.Background()
+
+	runAt(svc, pickupAt.Add(-30*time.Minute))
+	if err := svc.Redispatch(ctx, "o1"); !errors.Is(err, ErrConflict) {
+		t.Errorf("redispatch before escalation: err = %v, want ErrConflict", err)
+	}
+	runAt(svc, pickupAt.Add(-10*time.Minute))
+	if err := svc.Redispatch(ctx, "o1"); err != nil {
+		t.Fatalf("Redispatch: %v", err)
+	}
+	if len(orders.released) != 1 || orders.released[0].DriverID != "d1" {
+		t.Errorf("released %+v, want o1 from d1", orders.released)
+	}
+	if store.reminders["o1"].ReleasedAt == nil {
+		t.Error("reminder not closed after re-dispatch")
+	}
+	if esc, _ := svc.Escalations(ctx); len(esc) != 0 {
+		t.Errorf("escalation queue still holds %d rides", len(esc))
+	}
+	if err := svc.Redispatch(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown order: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRedispatch_DriverChangedMeanwhile(t *testing.T) {
+	svc, _, orders, _ := newTestService()
+	runAt(svc, pickupAt.Add(-30*time.Minute))
+	runAt(svc, pickupAt.Add(-10*time.Minute))
+	orders.err = order.ErrConflict
+	if err := svc.Redispatch(context.Background(), "o1"); !errors.Is(err, ErrConflict) {
+		t.Errorf("err = %v, want ErrConflict", err)
+	}
+}
+
+func TestDriverStats_RejectsEmptyPeriod(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	if _, err := svc.DriverStats(context.Background(), pickupAt, pickupAt, ""); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("err = %v, want ErrBadRequest", err)
+	}
+}
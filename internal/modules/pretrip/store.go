@@ -0,0 +1,175 @@
+// README: Pre-trip reminder store — PostgreSQL persistence for pretrip_reminders.
+package pretrip
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// ReminderStore defines the persistence operations required by the Service.
+// An order has at most one reminder; a newer one replaces it.
+type ReminderStore interface {
+	// Get returns the order's reminder or ErrNotFound.
+	Get(ctx context.Context, orderID types.ID) (*Reminder, error)
+	// Save stores r, replacing any earlier reminder for the order.
+	Save(ctx context.Context, r *Reminder) error
+	// Depart records driverID's departure on an open reminder; false when
+	// there is none.
+	Depart(ctx context.Context, orderID, driverID types.ID, at time.Time) (bool, error)
+	// Escalate marks an open, not yet escalated reminder escalated; false
+	// when there is none.
+	Escalate(ctx context.Context, orderID types.ID, at time.Time) (bool, error)
+	// Release closes an open reminder whose driver lost the ride; false when
+	// there is none.
+	Release(ctx context.Context, orderID types.ID, at time.Time) (bool, error)
+	// ListEscalated returns the open escalated reminders, earliest pickup first.
+	ListEscalated(ctx context.Context) ([]Reminder, error)
+	// DriverStats summarises reminders sent in [from, to) per driver, or only
+	// driverID's when it is set, most reminders first.
+	DriverStats(ctx context.Context, from, to time.Time, driverID types.ID) ([]DriverStats, error)
+}
+
+// Store is the PostgreSQL implementation of ReminderStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+const reminderColumns = `order_id, driver_id, pickup_lat, pickup_lng, scheduled_at,
+		       reminded_at, departed_at, escalated_at, released_at`
+
+func scanReminder(row pgx.Row) (*Reminder, error) {
+	var r Reminder
+	err := row.Scan(&r.OrderID, &r.DriverID, &r.Pickup.Lat, &r.Pickup.Lng, &r.ScheduledAt,
+		&r.RemindedAt, &r.DepartedAt, &r.EscalatedAt, &r.ReleasedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *Store) Get(ctx context.Context, orderID types.ID) (*Reminder, error) {
+	r, err := scanReminder(s.db.QueryRow(ctx, `
+		SELECT `+reminderColumns+`
+		FROM pretrip_reminders
+		WHERE order_id = $1`, string(orderID)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+func (s *Store) Save(ctx context.Context, r *Reminder) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO pretrip_reminders
+		    (order_id, driver_id, pickup_lat, pickup_lng, scheduled_at, reminded_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (order_id) DO UPDATE SET
+		    driver_id    = EXCLUDED.driver_id,
+		    pickup_lat   = EXCLUDED.pickup_lat,
+		    pickup_lng   = EXCLUDED.pickup_lng,
+		    scheduled_at = EXCLUDED.scheduled_at,
+		    reminded_at  = EXCLUDED.reminded_at,
+		    departed_at  = NULL,
+		    escalated_at = NULL,
+		    released_at  = NULL`,
+		string(r.OrderID), string(r.DriverID), r.Pickup.Lat, r.Pickup.Lng, r.ScheduledAt, r.RemindedAt,
+	)
+	return err
+}
+
+func (s *Store) Depart(ctx context.Context, orderID, driverID types.ID, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE pretrip_reminders SET departed_at = $3
+		WHERE order_id = $1 AND driver_id = $2 AND departed_at IS NULL AND released_at IS NULL`,
+		string(orderID), string(driverID), at,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) Escalate(ctx context.Context, orderID types.ID, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE pretrip_reminders SET escalated_at = $2
+		WHERE order_id = $1 AND escalated_at IS NULL AND departed_at IS NULL AND released_at IS NULL`,
+		string(orderID), at,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) Release(ctx context.Context, orderID types.ID, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE pretrip_reminders SET released_at = $2
+		WHERE order_id = $1 AND departed_at IS NULL AND released_at IS NULL`,
+		string(orderID), at,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) ListEscalated(ctx context.Context) ([]Reminder, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+reminderColumns+`
+		FROM pretrip_reminders
+		WHERE escalated_at IS NOT NULL AND departed_at IS NULL AND released_at IS NULL
+		ORDER BY scheduled_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Reminder
+	for rows.Next() {
+		r, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) DriverStats(ctx context.Context, from, to time.Time, driverID types.ID) ([]DriverStats, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT driver_id, COUNT(*), COUNT(departed_at), COUNT(escalated_at),
+		       COALESCE(AVG(EXTRACT(EPOCH FROM departed_at - reminded_at)), 0)::BIGINT,
+		       COALESCE(MAX(EXTRACT(EPOCH FROM departed_at - reminded_at)), 0)::BIGINT
+		FROM pretrip_reminders
+		WHERE reminded_at >= $1 AND reminded_at < $2 AND ($3 = '' OR driver_id = $3)
+		GROUP BY driver_id
+		ORDER BY COUNT(*) DESC, driver_id`,
+		from, to, string(driverID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DriverStats
+	for rows.Next() {
+		var d DriverStats
+		var avgSecs, maxSecs int64
+		if err := rows.Scan(&d.DriverID, &d.Reminders, &d.Departed, &d.Escalated, &avgSecs, &maxSecs); err != nil {
+			return nil, err
+		}
+		d.AvgResponse = time.Duration(avgSecs) * time.Second
+		d.MaxResponse = time.Duration(maxSecs) * time.Second
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
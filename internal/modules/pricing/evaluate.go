@@ -0,0 +1,162 @@
+// README: Fare evaluation — the pure function from a rate version and trip to a fare breakdown.
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// fallbackFare is quoted, in the platform currency, for ride types without a
+// configured rate.
+const fallbackFare = 15000
+
+// Night surcharge hours, local time: from nightFrom until nightTo.
+const (
+	nightFrom = 23
+	nightTo   = 6
+)
+
+// overtimeBlock is the unit charter overtime is billed in; a started block
+// is billed in full.
+const overtimeBlock = 15 * time.Minute
+
+// Weekday rush hours, local time, as [from, to) pairs of hours.
+var peakHours = [][2]int{{7, 9}, {17, 19}}
+
+// taipei is the time zone of trips priced without a region.
+var taipei = loadTaipei()
+
+func loadTaipei() *time.Location {
+	loc, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return loc
+}
+
+// isNight reports whether t falls in the night surcharge hours at loc.
+func isNight(t time.Time, loc *time.Location) bool {
+	h := t.In(loc).Hour()
+	return h >= nightFrom || h < nightTo
+}
+
+// isPeak reports whether t falls in the weekday rush hours at loc.
+func isPeak(t time.Time, loc *time.Location) bool {
+	t = t.In(loc)
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	for _, p := range peakHours {
+		if t.Hour() >= p[0] && t.Hour() < p[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate prices trip under r. Negative distances count as zero; the
+// distance fare and each surcharge are rounded to the nearest minor unit. A
+// charter's booked hours are priced at PerHour and count with the base and
+// distance fare.
+// Surcharges, the parcel weight class one included, are taken on the base and
+// distance fare and do not compound; the return allowance is added as is; the
+// accessibility subsidy comes off the result and never makes it negative.
+//
+// Any change to the result for an existing rate changes what passengers pay
+// under an already published version; the golden tests in testdata/golden
+// pin it.
+func Evaluate(r Rate, trip Trip) (Breakdown, error) {
+	if !types.ValidCurrency(r.Currency) {
+		return Breakdown{}, fmt.Errorf("pricing: rate %s v%d has unsupported currency %q", r.RideType, r.Version, r.Currency)
+	}
+	km := max(trip.DistanceKm, 0)
+	b := Breakdown{
+		RideType:     r.RideType,
+		RuleVersion:  r.Version,
+		Currency:     r.Currency,
+		DistanceKm:   km,
+		BaseFare:     r.BaseFare,
+		DistanceFare: int64(math.Round(float64(r.PerKm) * km)),
+	}
+	if trip.Hours > 0 {
+		b.Hours = trip.Hours
+		b.HourlyFare = max(r.PerHour, 0) * int64(trip.Hours)
+	}
+	subtotal := b.BaseFare + b.DistanceFare + b.HourlyFare
+	loc := trip.Location
+	if loc == nil {
+		loc = taipei
+	}
+	if !trip.At.IsZero() && isNight(trip.At, loc) {
+		b.NightSurcharge = bps(subtotal, r.NightSurchargeBps)
+	}
+	if !trip.At.IsZero() && isPeak(trip.At, loc) {
+		b.PeakSurcharge = bps(subtotal, r.PeakSurchargeBps)
+	}
+	if trip.AdverseWeather {
+		b.WeatherSurcharge = bps(subtotal, r.WeatherSurchargeBps)
+	}
+	b.CalendarSurcharge = bps(subtotal, max(trip.CalendarSurchargeBps, 0))
+	b.ParcelSurcharge = bps(subtotal, parcelBps(r, trip.WeightClass))
+	b.ReturnAllowance = max(r.ReturnAllowance, 0)
+	b.Total = subtotal + b.NightSurcharge + b.PeakSurcharge + b.WeatherSurcharge + b.CalendarSurcharge + b.ParcelSurcharge + b.ReturnAllowance
+	b.AccessibilitySubsidy = min(max(r.AccessibilitySubsidy, 0), b.Total)
+	b.Total -= b.AccessibilitySubsidy
+	return b, nil
+}
+
+// EvaluateCancellation returns the fee r charges for a passenger
+// cancellation once the driver has come progressKm toward the pickup, capped
+// at fare.
+func EvaluateCancellation(r Rate, progressKm float64, fare int64) int64 {
+	fee := r.CancelFee + int64(math.Round(float64(r.CancelPerKm)*max(progressKm, 0)))
+	return min(max(fee, 0), max(fare, 0))
+}
+
+// EvaluateOvertime returns what r charges for a charter running overtime past
+// its booked hours: OvertimePerHour for every started quarter hour, rounded
+// to the nearest minor unit.
+func EvaluateOvertime(r Rate, overtime time.Duration) int64 {
+	if overtime <= 0 || r.OvertimePerHour <= 0 {
+		return 0
+	}
+	blocks := int64((overtime + overtimeBlock - 1) / overtimeBlock)
+	perHour := int64(time.Hour / overtimeBlock)
+	return (r.OvertimePerHour*blocks + perHour/2) / perHour
+}
+
+// parcelBps returns r's surcharge for a parcel of weightClass.
+func parcelBps(r Rate, weightClass string) int {
+	switch weightClass {
+	case order.WeightMedium:
+		return max(r.ParcelMediumBps, 0)
+	case order.WeightLarge:
+		return max(r.ParcelLargeBps, 0)
+	}
+	return 0
+}
+
+// bps returns rate basis points of amount, rounded half away from zero.
+func bps(amount int64, rate int) int64 {
+	return int64(math.Round(float64(amount) * float64(rate) / 10000))
+}
+
+// fallback is the breakdown quoted for a ride type without a rate.
+func fallback(rideType string, distanceKm float64) Breakdown {
+	return Breakdown{
+		RideType:   rideType,
+		Currency:   types.DefaultCurrency,
+		DistanceKm: max(distanceKm, 0),
+		BaseFare:   fallbackFare,
+		Total:      fallbackFare,
+	}
+}
+
+// Money returns the breakdown's total.
+func (b Breakdown) Money() types.Money {
+	return types.Money{Amount: b.Total, Currency: b.Currency}
+}
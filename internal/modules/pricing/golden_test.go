@@ -0,0 +1,112 @@
+package pricing
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Run "go test ./internal/modules/pricing -update" after a deliberate pricing
+// change and review the fixture diff with the change.
+var update = flag.Bool("update", false, "rewrite golden pricing fixtures with the current results")
+
+// goldenCase is one fixture in testdata/golden: a rate version (null for a
+// ride type without one), a trip, and the expected breakdown or error.
+type goldenCase struct {
+	Rate *struct {
+		RideType             string `json:"ride_type"`
+		Version              int    `json:"version"`
+		BaseFare             int64  `json:"base_fare"`
+		PerKm                int64  `json:"per_km"`
+		Currency             string `json:"currency"`
+		NightSurchargeBps    int    `json:"night_surcharge_bps,omitempty"`
+		WeatherSurchargeBps  int    `json:"weather_surcharge_bps,omitempty"`
+		PeakSurchargeBps     int    `json:"peak_surcharge_bps,omitempty"`
+		AccessibilitySubsidy int64  `json:"accessibility_subsidy,omitempty"`
+		ParcelMediumBps      int    `json:"parcel_medium_bps,omitempty"`
+		ParcelLargeBps       int    `json:"parcel_large_bps,omitempty"`
+		ReturnAllowance      int64  `json:"return_allowance,omitempty"`
+		PerHour              int64  `json:"per_hour,omitempty"`
+	} `json:"rate"`
+	RideType       string     `json:"ride_type,omitempty"`
+	DistanceKm     float64    `json:"distance_km"`
+	At             time.Time  `json:"at,omitzero"`
+	AdverseWeather bool       `json:"adverse_weather,omitempty"`
+	WeightClass    string     `json:"weight_class,omitempty"`
+	Hours          int        `json:"hours,omitempty"`
+	Want           *Breakdown `json:"want,omitempty"`
+	WantError      bool       `json:"want_error,omitempty"`
+}
+
+func (c *goldenCase) evaluate() (Breakdown, error) {
+	if c.Rate == nil {
+		return fallback(c.RideType, c.DistanceKm), nil
+	}
+	return Evaluate(Rate{
+		RideType:             c.Rate.RideType,
+		Version:              c.Rate.Version,
+		BaseFare:             c.Rate.BaseFare,
+		PerKm:                c.Rate.PerKm,
+		Currency:             c.Rate.Currency,
+		NightSurchargeBps:    c.Rate.NightSurchargeBps,
+		WeatherSurchargeBps:  c.Rate.WeatherSurchargeBps,
+		PeakSurchargeBps:     c.Rate.PeakSurchargeBps,
+		AccessibilitySubsidy: c.Rate.AccessibilitySubsidy,
+		ParcelMediumBps:      c.Rate.ParcelMediumBps,
+		ParcelLargeBps:       c.Rate.ParcelLargeBps,
+		ReturnAllowance:      c.Rate.ReturnAllowance,
+		PerHour:              c.Rate.PerHour,
+	}, Trip{DistanceKm: c.DistanceKm, At: c.At, AdverseWeather: c.AdverseWeather, WeightClass: c.WeightClass, Hours: c.Hours})
+}
+
+func TestEvaluate_Golden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "golden", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no golden fixtures")
+	}
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			raw, err := os.ReadFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var c goldenCase
+			if err := json.Unmarshal(raw, &c); err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := c.evaluate()
+
+			if *update {
+				c.Want, c.WantError = nil, err != nil
+				if err == nil {
+					c.Want = &got
+				}
+				out, _ := json.MarshalIndent(&c, "", "  ")
+				if err := os.WriteFile(f, append(out, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if c.WantError {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Evaluate: %v", err)
+			}
+			if c.Want == nil || !reflect.DeepEqual(got, *c.Want) {
+				t.Errorf("breakdown changed:\n got  %+v\n want %+v", got, c.Want)
+			}
+		})
+	}
+}
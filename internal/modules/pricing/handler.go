@@ -0,0 +1,139 @@
+// README: Pricing HTTP handlers — ops export and import of rates and surcharge calendars as CSV or JSON.
+//
+// Endpoints:
+//
+//	GET  /api/ops/pricing/rates                  — latest version of every rate (ops key)
+//	POST /api/ops/pricing/rates/import           — publish changed rates as new versions (ops key)
+//	GET  /api/ops/pricing/surcharge-days         — every surcharge calendar (ops key)
+//	POST /api/ops/pricing/surcharge-days/import  — replace the calendars of the file's rate sets (ops key)
+//
+// Every endpoint takes ?format=csv or json (the default). Imports take the
+// file as the request body and ?dry_run=true to only report the changes.
+//
+// Auth: routes require the ops key middleware.
+package pricing
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/bulk"
+)
+
+// maxImportBytes caps an import's request body.
+const maxImportBytes = 8 << 20
+
+// Handler holds the pricing HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// ExportRates handles GET /api/ops/pricing/rates.
+func (h *Handler) ExportRates(c *gin.Context) {
+	f, err := bulk.ParseFormat(c.Query("format"))
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	rs, err := h.svc.ExportRates(c.Request.Context())
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	writeExport(c, f, "pricing-rates", func(w io.Writer) error { return EncodeRates(w, f, rs) })
+}
+
+// ImportRates handles POST /api/ops/pricing/rates/import.
+func (h *Handler) ImportRates(c *gin.Context) {
+	f, err := bulk.ParseFormat(c.Query("format"))
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	rs, err := DecodeRates(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes), f)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	res, err := h.svc.ImportRates(c.Request.Context(), rs, c.Query("dry_run") == "true")
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ExportSurchargeDays handles GET /api/ops/pricing/surcharge-days.
+func (h *Handler) ExportSurchargeDays(c *gin.Context) {
+	f, err := bulk.ParseFormat(c.Query("format"))
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	days, err := h.svc.ExportSurchargeDays(c.Request.Context())
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	writeExport(c, f, "surcharge-days", func(w io.Writer) error { return EncodeSurchargeDays(w, f, days) })
+}
+
+// ImportSurchargeDays handles POST /api/ops/pricing/surcharge-days/import.
+func (h *Handler) ImportSurchargeDays(c *gin.Context) {
+	f, err := bulk.ParseFormat(c.Query("format"))
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	days, err := DecodeSurchargeDays(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes), f)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	res, err := h.svc.ImportSurchargeDays(c.Request.Context(), days, c.Query("dry_run") == "true")
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// writeExport sends the file encode writes as a download named name.
+func writeExport(c *gin.Context, f bulk.Format, name string, encode func(io.Writer) error) {
+	c.Header("Content-Type", f.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+name+`.`+string(f)+`"`)
+	c.Status(http.StatusOK)
+	if err := encode(c.Writer); err != nil {
+		log.Printf("pricing: export %s: %v", name, err)
+	}
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeTransferError(c *gin.Context, err error) {
+	var verr *bulk.ValidationError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &verr):
+		c.JSON(http.StatusUnprocessableEntity, map[string]any{"error": bulk.ErrInvalid.Error(), "rows": verr.Rows})
+	case errors.As(err, &tooLarge):
+		writeError(c, http.StatusRequestEntityTooLarge, "file too large")
+	case errors.Is(err, bulk.ErrFormat), errors.Is(err, bulk.ErrMalformed):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNoConfigStore):
+		writeError(c, http.StatusServiceUnavailable, "pricing configuration unavailable")
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -1,9 +1,114 @@
 // README: Pricing rate definition for each ride type.
 package pricing
 
+import (
+    "errors"
+    "time"
+)
+
+var ErrNotFound = errors.New("rate not found")
+
+// Rate prices one ride type: BaseFare plus PerKm for every kilometre, both in
+// minor units of Currency. A ride type is priced in exactly one currency.
+//
+// Rates are versioned per ride type and never edited: a fare change publishes
+// a new Version effective from a later time, so an order's recorded version
+// always identifies the rule that priced it. Each region prices from its own
+// RateSet; "" is the original (Taipei) set.
 type Rate struct {
-    RideType string
-    BaseFare int64
-    PerKm    int64
-    Currency string
+    RateSet       string    `json:"rate_set"`
+    RideType      string    `json:"ride_type"`
+    Version       int       `json:"version"`
+    EffectiveFrom time.Time `json:"effective_from"`
+    BaseFare      int64     `json:"base_fare"`
+    PerKm         int64     `json:"per_km"`
+    Currency      string    `json:"currency"`
+    // NightSurchargeBps is added for rides starting at night (23:00–06:00
+    // local time) and WeatherSurchargeBps in rain or storms, both in basis points
+    // of the base and distance fare. 0 disables them.
+    NightSurchargeBps   int `json:"night_surcharge_bps"`
+    WeatherSurchargeBps int `json:"weather_surcharge_bps"`
+    // PeakSurchargeBps is added for rides starting in the weekday rush hours
+    // (07:00–09:00 and 17:00–19:00 local time), on the same base.
+    PeakSurchargeBps int `json:"peak_surcharge_bps"`
+    // AccessibilitySubsidy is taken off every fare under the rate, in minor
+    // units and capped at the fare; published on the WAV ride type's rates.
+    AccessibilitySubsidy int64 `json:"accessibility_subsidy"`
+    // CancelFee plus CancelPerKm for every kilometre the driver has already
+    // come toward the pickup is charged when a passenger cancels an instant
+    // ride after the free-cancel grace period, capped at the fare. 0 for both
+    // keeps cancellations free.
+    CancelFee   int64 `json:"cancel_fee"`
+    CancelPerKm int64 `json:"cancel_per_km"`
+    // ParcelMediumBps and ParcelLargeBps are added for medium and large
+    // parcels, in basis points of the base and distance fare; published on
+    // the delivery ride type's rates. Small parcels pay the plain fare.
+    ParcelMediumBps int `json:"parcel_medium_bps"`
+    ParcelLargeBps  int `json:"parcel_large_bps"`
+    // ReturnAllowance is added to every fare under the rate to reimburse the
+    // driver's public transit back, in minor units and free of surcharges;
+    // published on the designated ride type's rates.
+    ReturnAllowance int64 `json:"return_allowance"`
+    // PerHour is charged for every booked hour of a charter, on top of the
+    // base and distance fare and surcharged with them. OvertimePerHour bills
+    // the time a charter runs past its booking, in started quarter hours.
+    // Both are published on the charter ride type's rates.
+    PerHour         int64 `json:"per_hour"`
+    OvertimePerHour int64 `json:"overtime_per_hour"`
+}
+
+// SurchargeDay is a date on a rate set's surcharge calendar, such as a public
+// holiday: rides starting that local day pay SurchargeBps of the base and
+// distance fare on top of the rate. The calendar is configuration beside the
+// versioned rates, edited in place.
+type SurchargeDay struct {
+    RateSet      string `json:"rate_set"`
+    Date         string `json:"date"` // YYYY-MM-DD in the region's time zone
+    Name         string `json:"name"`
+    SurchargeBps int    `json:"surcharge_bps"`
+}
+
+// Trip is what one evaluation prices.
+type Trip struct {
+    DistanceKm float64
+    At         time.Time // ride start
+    // Location is the region's time zone for night and peak hours; nil is
+    // Asia/Taipei.
+    Location *time.Location
+    // AdverseWeather is set when it is raining or stormy at the pickup.
+    AdverseWeather bool
+    // CalendarSurchargeBps is the surcharge calendar's rate for the ride's
+    // local day, 0 for an ordinary day.
+    CalendarSurchargeBps int
+    // WeightClass is the parcel's weight class on a delivery, "" for rides.
+    WeightClass string
+    // Hours is the booked length of a charter, 0 for other rides.
+    Hours int
+}
+
+// Breakdown is an evaluated fare and how it was reached. RuleVersion 0 means
+// no rate was configured and the fallback fare was quoted.
+type Breakdown struct {
+    RideType         string  `json:"ride_type"`
+    RuleVersion      int     `json:"rule_version"`
+    Currency         string  `json:"currency"`
+    DistanceKm       float64 `json:"distance_km"`
+    BaseFare         int64   `json:"base_fare"`
+    DistanceFare     int64   `json:"distance_fare"`
+    // Hours and HourlyFare are a charter's booked hours and their price.
+    Hours      int   `json:"hours,omitempty"`
+    HourlyFare int64 `json:"hourly_fare,omitempty"`
+    NightSurcharge   int64   `json:"night_surcharge"`
+    PeakSurcharge    int64   `json:"peak_surcharge"`
+    WeatherSurcharge int64   `json:"weather_surcharge"`
+    // CalendarSurcharge is the surcharge calendar's, e.g. on a holiday.
+    CalendarSurcharge int64 `json:"calendar_surcharge,omitempty"`
+    // ParcelSurcharge is the weight class surcharge of a delivery.
+    ParcelSurcharge int64 `json:"parcel_surcharge,omitempty"`
+    // ReturnAllowance reimburses a designated driver's trip back.
+    ReturnAllowance int64 `json:"return_allowance,omitempty"`
+    // AccessibilitySubsidy is deducted from the fare, so Total is the sum of
+    // the lines above less this one.
+    AccessibilitySubsidy int64 `json:"accessibility_subsidy"`
+    Total                int64 `json:"total"`
 }
@@ -0,0 +1,94 @@
+// README: Open-Meteo current-weather lookup used for the weather surcharge; keyless, cached per area.
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"ark/internal/types"
+)
+
+// weatherTTL is how long one area's conditions are reused.
+const weatherTTL = 10 * time.Minute
+
+// OpenMeteo reads current conditions from the Open-Meteo forecast API. Points
+// are bucketed to 0.1° (about 10 km) so nearby pickups share one lookup.
+type OpenMeteo struct {
+	BaseURL string // defaults to the public API; overridden in tests
+	Client  *http.Client
+
+	mu    sync.Mutex
+	cache map[[2]int]weatherEntry
+}
+
+type weatherEntry struct {
+	adverse bool
+	at      time.Time
+}
+
+// NewOpenMeteo returns a provider using the public API.
+func NewOpenMeteo() *OpenMeteo {
+	return &OpenMeteo{Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Adverse reports rain, snow or thunderstorms at p: a WMO weather code of 61
+// or above, or at least 1 mm of precipitation in the last interval.
+func (w *OpenMeteo) Adverse(ctx context.Context, p types.Point) (bool, error) {
+	key := [2]int{int(math.Round(p.Lat * 10)), int(math.Round(p.Lng * 10))}
+	w.mu.Lock()
+	if e, ok := w.cache[key]; ok && time.Since(e.at) < weatherTTL {
+		w.mu.Unlock()
+		return e.adverse, nil
+	}
+	w.mu.Unlock()
+
+	base := w.BaseURL
+	if base == "" {
+		base = "https://api.open-meteo.com"
+	}
+	q := url.Values{
+		"latitude":  {strconv.FormatFloat(float64(key[0])/10, 'f', 1, 64)},
+		"longitude": {strconv.FormatFloat(float64(key[1])/10, 'f', 1, 64)},
+		"current":   {"precipitation,weather_code"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/forecast?"+q.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("open-meteo: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("open-meteo: status %d", resp.StatusCode)
+	}
+	var out struct {
+		Current *struct {
+			Precipitation float64 `json:"precipitation"`
+			WeatherCode   int     `json:"weather_code"`
+		} `json:"current"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("open-meteo: decode response: %w", err)
+	}
+	if out.Current == nil {
+		return false, fmt.Errorf("open-meteo: no current conditions")
+	}
+	adverse := out.Current.WeatherCode >= 61 || out.Current.Precipitation >= 1
+
+	w.mu.Lock()
+	if w.cache == nil {
+		w.cache = make(map[[2]int]weatherEntry)
+	}
+	w.cache[key] = weatherEntry{adverse: adverse, at: time.Now()}
+	w.mu.Unlock()
+	return adverse, nil
+}
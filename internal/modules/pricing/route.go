@@ -0,0 +1,17 @@
+// README: Pricing route registration — mounts the ops pricing configuration endpoints.
+package pricing
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the pricing endpoints onto the provided ops router group.
+//
+//	GET  /api/ops/pricing/rates
+//	POST /api/ops/pricing/rates/import
+//	GET  /api/ops/pricing/surcharge-days
+//	POST /api/ops/pricing/surcharge-days/import
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/pricing/rates", h.ExportRates)
+	rg.POST("/api/ops/pricing/rates/import", h.ImportRates)
+	rg.GET("/api/ops/pricing/surcharge-days", h.ExportSurchargeDays)
+	rg.POST("/api/ops/pricing/surcharge-days/import", h.ImportSurchargeDays)
+}
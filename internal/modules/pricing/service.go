@@ -3,19 +3,165 @@ package pricing
 
 import (
 	"context"
+	"errors"
+	"log"
+	"time"
 
+	"ark/internal/modules/order"
+	"ark/internal/modules/region"
 	"ark/internal/types"
 )
 
+// weatherHorizon is how far ahead of now a ride may start and still have the
+// current weather priced in; a forecast days out is not a fare.
+const weatherHorizon = time.Hour
+
+// Weather reports whether conditions at a point are bad enough for the
+// weather surcharge. Implemented by OpenMeteo.
+type Weather interface {
+	Adverse(ctx context.Context, p types.Point) (bool, error)
+}
+
+// Regions resolves a region ID to its configuration. Implemented by
+// region.Service.
+type Regions interface {
+	Get(id string) region.Region
+}
+
 type Service struct {
-	store *Store
+	store   PricingStore
+	weather Weather
+	regions Regions
+	config  ConfigStore
+	now     func() time.Time
+}
+
+func NewService(store PricingStore) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// SetWeather enables weather surcharges. Without it rides are priced as in
+// fair weather.
+func (s *Service) SetWeather(w Weather) {
+	s.weather = w
+}
+
+// SetRegions prices each request from its region's rate set and local time.
+// Without it every ride is priced from the original set in Taipei time.
+func (s *Service) SetRegions(r Regions) {
+	s.regions = r
+}
+
+// SetConfigStore enables the surcharge calendars and the ops import and
+// export of rates and calendars. Without it no calendar surcharge applies.
+func (s *Service) SetConfigStore(c ConfigStore) {
+	s.config = c
+}
+
+// Breakdown prices req under the rate version of its ride type in force when
+// the ride starts.
+func (s *Service) Breakdown(ctx context.Context, req order.PricingRequest) (Breakdown, error) {
+	at := req.At
+	if at.IsZero() {
+		at = s.now()
+	}
+	var rateSet string
+	var loc *time.Location
+	if s.regions != nil {
+		reg := s.regions.Get(req.RegionID)
+		rateSet, loc = reg.RateSet, reg.Location()
+	}
+	r, err := s.store.GetRate(ctx, rateSet, req.RideType, at)
+	if errors.Is(err, ErrNotFound) {
+		return fallback(req.RideType, req.DistanceKm), nil
+	}
+	if err != nil {
+		return Breakdown{}, err
+	}
+	return Evaluate(r, Trip{
+		DistanceKm:           req.DistanceKm,
+		At:                   at,
+		Location:             loc,
+		AdverseWeather:       r.WeatherSurchargeBps > 0 && s.adverseWeather(ctx, req.Pickup, at),
+		CalendarSurchargeBps: s.calendarSurcharge(ctx, rateSet, at, loc),
+		WeightClass:          req.WeightClass,
+		Hours:                req.Hours,
+	})
+}
+
+// Quote implements order.Pricing.
+func (s *Service) Quote(ctx context.Context, req order.PricingRequest) (order.Quote, error) {
+	b, err := s.Breakdown(ctx, req)
+	if err != nil {
+		return order.Quote{}, err
+	}
+	return order.Quote{Fare: b.Money(), RuleVersion: b.RuleVersion, ReturnAllowance: b.ReturnAllowance}, nil
+}
+
+// CancellationFee implements order.CancellationPricing: the fee is priced by
+// the rate version in force when the ride was booked. Ride types without a
+// rate cancel free.
+func (s *Service) CancellationFee(ctx context.Context, req order.CancellationFeeRequest) (types.Money, error) {
+	var rateSet string
+	if s.regions != nil {
+		rateSet = s.regions.Get(req.RegionID).RateSet
+	}
+	r, err := s.store.GetRate(ctx, rateSet, req.RideType, req.BookedAt)
+	if errors.Is(err, ErrNotFound) {
+		return types.Money{Currency: req.Fare.Currency}, nil
+	}
+	if err != nil {
+		return types.Money{}, err
+	}
+	return types.Money{Amount: EvaluateCancellation(r, req.DriverProgressKm, req.Fare.Amount), Currency: r.Currency}, nil
+}
+
+// OvertimeFee implements order.CharterPricing: overtime is priced by the rate
+// version in force when the charter was booked. Ride types without a rate
+// run overtime free.
+func (s *Service) OvertimeFee(ctx context.Context, req order.OvertimeFeeRequest) (types.Money, error) {
+	var rateSet string
+	if s.regions != nil {
+		rateSet = s.regions.Get(req.RegionID).RateSet
+	}
+	r, err := s.store.GetRate(ctx, rateSet, req.RideType, req.BookedAt)
+	if errors.Is(err, ErrNotFound) {
+		return types.Money{Currency: req.Currency}, nil
+	}
+	if err != nil {
+		return types.Money{}, err
+	}
+	return types.Money{Amount: EvaluateOvertime(r, req.Overtime), Currency: r.Currency}, nil
 }
 
-func NewService(store *Store) *Service {
-	return &Service{store: store}
+// calendarSurcharge returns rateSet's calendar surcharge on the local day of
+// at. A failed lookup prices the ride as on an ordinary day.
+func (s *Service) calendarSurcharge(ctx context.Context, rateSet string, at time.Time, loc *time.Location) int {
+	if s.config == nil {
+		return 0
+	}
+	if loc == nil {
+		loc = taipei
+	}
+	date := at.In(loc).Format(time.DateOnly)
+	bps, err := s.config.SurchargeBps(ctx, rateSet, date)
+	if err != nil {
+		log.Printf("pricing: surcharge calendar %q on %s: %v", rateSet, date, err)
+		return 0
+	}
+	return bps
 }
 
-func (s *Service) Estimate(ctx context.Context, distanceKm float64, rideType string) (types.Money, error) {
-	// TODO: implement real pricing from DB
-	return types.Money{Amount: 15000, Currency: "TWD"}, nil
+// adverseWeather checks the weather at p for rides starting within the
+// horizon. A failed lookup prices the ride as in fair weather.
+func (s *Service) adverseWeather(ctx context.Context, p types.Point, at time.Time) bool {
+	if s.weather == nil || at.After(s.now().Add(weatherHorizon)) {
+		return false
+	}
+	bad, err := s.weather.Adverse(ctx, p)
+	if err != nil {
+		log.Printf("pricing: weather at %.3f,%.3f: %v", p.Lat, p.Lng, err)
+		return false
+	}
+	return bad
 }
@@ -0,0 +1,454 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"ark/internal/bulk"
+	"ark/internal/modules/order"
+	"ark/internal/modules/region"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	rates []Rate
+	days  []SurchargeDay
+	err   error
+}
+
+func (m *mockStore) GetRate(_ context.Context, rateSet, rideType string, at time.Time) (Rate, error) {
+	if m.err != nil {
+		return Rate{}, m.err
+	}
+	var best *Rate
+	for i, r := range m.rates {
+		if r.RateSet == rateSet && r.RideType == rideType && !r.EffectiveFrom.After(at) && (best == nil || r.Version > best.Version) {
+			best = &m.rates[i]
+		}
+	}
+	if best == nil {
+		return Rate{}, ErrNotFound
+	}
+	return *best, nil
+}
+
+func (m *mockStore) LatestRates(context.Context) ([]Rate, error) {
+	latest := map[string]Rate{}
+	for _, r := range m.rates {
+		if old, ok := latest[rateKey(r.RateSet, r.RideType)]; !ok || r.Version > old.Version {
+			latest[rateKey(r.RateSet, r.RideType)] = r
+		}
+	}
+	var out []Rate
+	for _, r := range latest {
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (m *mockStore) PublishRates(_ context.Context, rs []Rate) error {
+	for i := range rs {
+		rs[i].Version = 1
+		for _, r := range m.rates {
+			if r.RateSet == rs[i].RateSet && r.RideType == rs[i].RideType && r.Version >= rs[i].Version {
+				rs[i].Version = r.Version + 1
+			}
+		}
+		m.rates = append(m.rates, rs[i])
+	}
+	return nil
+}
+
+func (m *mockStore) SurchargeDays(context.Context) ([]SurchargeDay, error) {
+	return append([]SurchargeDay(nil), m.days...), nil
+}
+
+func (m *mockStore) ReplaceSurchargeDays(_ context.Context, rateSets []string, days []SurchargeDay) error {
+	kept := m.days[:0]
+	for _, d := range m.days {
+		if !slices.Contains(rateSets, d.RateSet) {
+			kept = append(kept, d)
+		}
+	}
+	m.days = append(kept, days...)
+	return nil
+}
+
+func (m *mockStore) SurchargeBps(_ context.Context, rateSet, date string) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	for _, d := range m.days {
+		if d.RateSet == rateSet && d.Date == date {
+			return d.SurchargeBps, nil
+		}
+	}
+	return 0, nil
+}
+
+type stubWeather struct {
+	adverse bool
+	err     error
+	calls   int
+}
+
+func (w *stubWeather) Adverse(context.Context, types.Point) (bool, error) {
+	w.calls++
+	return w.adverse, w.err
+}
+
+func TestBreakdown_UsesVersionInForce(t *testing.T) {
+	repriced := time.Date(2026, 7, 1, 4, 0, 0, 0, time.UTC) // 12:00 Taipei
+	svc := NewService(&mockStore{rates: []Rate{
+		{RideType: "standard", Version: 1, BaseFare: 8500, PerKm: 2000, Currency: "TWD"},
+		{RideType: "standard", Version: 2, EffectiveFrom: repriced, BaseFare: 9000, PerKm: 2200, Currency: "TWD"},
+	}})
+	ctx := context.Background()
+
+	before, err := svc.Breakdown(ctx, order.PricingRequest{RideType: "standard", DistanceKm: 5, At: repriced.Add(-time.Second)})
+	if err != nil || before.RuleVersion != 1 || before.Total != 18500 {
+		t.Errorf("before repricing: %+v, %v", before, err)
+	}
+	after, err := svc.Breakdown(ctx, order.PricingRequest{RideType: "standard", DistanceKm: 5, At: repriced})
+	if err != nil || after.RuleVersion != 2 || after.Total != 20000 {
+		t.Errorf("after repricing: %+v, %v", after, err)
+	}
+}
+
+func TestBreakdown_RideTypeTimeAndWeather(t *testing.T) {
+	now := time.Date(2026, 7, 1, 16, 0, 0, 0, time.UTC) // 00:00 Taipei
+	weather := &stubWeather{adverse: true}
+	svc := NewService(&mockStore{rates: []Rate{
+		{RideType: "standard", Version: 1, BaseFare: 8000, PerKm: 2000, Currency: "TWD", NightSurchargeBps: 2000, WeatherSurchargeBps: 1000},
+		{RideType: "premium", Version: 1, BaseFare: 15000, PerKm: 3000, Currency: "TWD"},
+	}})
+	svc.SetWeather(weather)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+	req := order.PricingRequest{RideType: "standard", DistanceKm: 1, Pickup: types.Point{Lat: 25.03, Lng: 121.56}, At: now}
+
+	got, _ := svc.Breakdown(ctx, req)
+	if got.NightSurcharge != 2000 || got.WeatherSurcharge != 1000 || got.Total != 13000 {
+		t.Errorf("rainy night: %+v", got)
+	}
+
+	req.At = now.Add(10 * time.Hour) // 10:00 Taipei, beyond the weather horizon
+	got, _ = svc.Breakdown(ctx, req)
+	if got.NightSurcharge != 0 || got.WeatherSurcharge != 0 || got.Total != 10000 {
+		t.Errorf("scheduled daytime ride: %+v", got)
+	}
+
+	weather.err = errors.New("timeout")
+	req.At = now
+	got, _ = svc.Breakdown(ctx, req)
+	if got.WeatherSurcharge != 0 || got.Total != 12000 {
+		t.Errorf("weather lookup failed: %+v", got)
+	}
+
+	calls := weather.calls
+	req.RideType = "premium"
+	got, _ = svc.Breakdown(ctx, req)
+	if got.Total != 18000 || got.NightSurcharge != 0 || weather.calls != calls {
+		t.Errorf("premium without surcharges: %+v (weather calls %d→%d)", got, calls, weather.calls)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	svc := NewService(&mockStore{rates: []Rate{
+		{RideType: "standard_jp", Version: 4, BaseFare: 500, PerKm: 350, Currency: "JPY"},
+	}})
+	ctx := context.Background()
+
+	q, err := svc.Quote(ctx, order.PricingRequest{RideType: "standard_jp", DistanceKm: 3})
+	want := order.Quote{Fare: types.Money{Amount: 1550, Currency: "JPY"}, RuleVersion: 4}
+	if err != nil || q != want {
+		t.Errorf("Quote = %+v, %v; want %+v", q, err, want)
+	}
+	q, err = svc.Quote(ctx, order.PricingRequest{RideType: "premium", DistanceKm: 3})
+	want = order.Quote{Fare: types.Money{Amount: fallbackFare, Currency: types.DefaultCurrency}}
+	if err != nil || q != want {
+		t.Errorf("fallback Quote = %+v, %v; want %+v", q, err, want)
+	}
+}
+
+type stubRegions map[string]region.Region
+
+func (r stubRegions) Get(id string) region.Region {
+	if reg, ok := r[id]; ok {
+		return reg
+	}
+	return r[""]
+}
+
+func TestBreakdown_RegionRateSetAndLocalTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	svc := NewService(&mockStore{rates: []Rate{
+		{RideType: "standard", Version: 1, BaseFare: 8000, PerKm: 2000, Currency: "TWD", NightSurchargeBps: 2000},
+		{RateSet: "tyo", RideType: "standard", Version: 1, BaseFare: 500, PerKm: 400, Currency: "JPY", NightSurchargeBps: 2000},
+	}})
+	svc.SetRegions(stubRegions{
+		"":    region.Default("tpe"),
+		"tyo": {ID: "tyo", Timezone: tokyo.String(), Currency: "JPY", RateSet: "tyo"},
+	})
+	ctx := context.Background()
+	at := time.Date(2026, 7, 1, 14, 30, 0, 0, time.UTC) // 22:30 Taipei, 23:30 Tokyo
+
+	tpe, err := svc.Breakdown(ctx, order.PricingRequest{RideType: "standard", DistanceKm: 1, At: at})
+	if err != nil || tpe.Currency != "TWD" || tpe.NightSurcharge != 0 {
+		t.Errorf("default region: %+v, %v", tpe, err)
+	}
+	tyo, err := svc.Breakdown(ctx, order.PricingRequest{RideType: "standard", DistanceKm: 1, At: at, RegionID: "tyo"})
+	if err != nil || tyo.Currency != "JPY" || tyo.NightSurcharge != 180 || tyo.Total != 1080 {
+		t.Errorf("tokyo region: %+v, %v", tyo, err)
+	}
+}
+
+func TestQuote_StoreError(t *testing.T) {
+	boom := errors.New("db down")
+	svc := NewService(&mockStore{err: boom})
+	if _, err := svc.Quote(context.Background(), order.PricingRequest{RideType: "standard", DistanceKm: 1}); !errors.Is(err, boom) {
+		t.Fatalf("got %v, want %v", err, boom)
+	}
+}
+
+func TestCancellationFee(t *testing.T) {
+	svc := NewService(&mockStore{rates: []Rate{
+		{RideType: "standard", Version: 1, BaseFare: 8500, PerKm: 2000, CancelFee: 3000, CancelPerKm: 1000, Currency: "TWD"},
+	}})
+	ctx := context.Background()
+	fare := types.Money{Amount: 20000, Currency: "TWD"}
+
+	for _, tc := range []struct {
+		km   float64
+		fare int64
+		want int64
+	}{
+		{0, 20000, 3000},
+		{2.5, 20000, 5500},
+		{-1, 20000, 3000},
+		{40, 20000, 20000}, // capped at the fare
+	} {
+		fare.Amount = tc.fare
+		got, err := svc.CancellationFee(ctx, order.CancellationFeeRequest{RideType: "standard", DriverProgressKm: tc.km, Fare: fare})
+		if err != nil || got.Amount != tc.want || got.Currency != "TWD" {
+			t.Errorf("%.1f km: got %+v, %v; want %d", tc.km, got, err, tc.want)
+		}
+	}
+
+	got, err := svc.CancellationFee(ctx, order.CancellationFeeRequest{RideType: "lux", DriverProgressKm: 3, Fare: fare})
+	if err != nil || got.Amount != 0 {
+		t.Errorf("ride type without a rate: got %+v, %v; want free", got, err)
+	}
+}
+
+func TestOvertimeFee(t *testing.T) {
+	svc := NewService(&mockStore{rates: []Rate{
+		{RideType: "charter", Version: 1, BaseFare: 20000, PerHour: 60000, OvertimePerHour: 80000, Currency: "TWD"},
+	}})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		over time.Duration
+		want int64
+	}{
+		{0, 0},
+		{-time.Minute, 0},
+		{time.Minute, 20000}, // a started quarter hour is billed in full
+		{15 * time.Minute, 20000},
+		{16 * time.Minute, 40000},
+		{time.Hour + 5*time.Minute, 100000},
+	} {
+		got, err := svc.OvertimeFee(ctx, order.OvertimeFeeRequest{RideType: "charter", Overtime: tc.over, Currency: "TWD"})
+		if err != nil || got.Amount != tc.want || got.Currency != "TWD" {
+			t.Errorf("%v over: got %+v, %v; want %d", tc.over, got, err, tc.want)
+		}
+	}
+
+	got, err := svc.OvertimeFee(ctx, order.OvertimeFeeRequest{RideType: "lux", Overtime: time.Hour, Currency: "TWD"})
+	if err != nil || got.Amount != 0 {
+		t.Errorf("ride type without a rate: got %+v, %v; want free", got, err)
+	}
+}
+
+func TestOpenMeteo_Adverse(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("latitude") != "25.0" || r.URL.Query().Get("longitude") != "121.6" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"current":{"precipitation":0.4,"weather_code":63}}`))
+	}))
+	defer srv.Close()
+
+	w := NewOpenMeteo()
+	w.BaseURL = srv.URL
+	ctx := context.Background()
+	if bad, err := w.Adverse(ctx, types.Point{Lat: 25.033, Lng: 121.565}); err != nil || !bad {
+		t.Fatalf("rain: %v, %v", bad, err)
+	}
+	// A nearby pickup reuses the cached conditions.
+	if bad, err := w.Adverse(ctx, types.Point{Lat: 25.041, Lng: 121.57}); err != nil || !bad || requests != 1 {
+		t.Errorf("cached: %v, %v after %d requests", bad, err, requests)
+	}
+}
+
+func TestBreakdown_CalendarSurcharge(t *testing.T) {
+	store := &mockStore{
+		rates: []Rate{{RideType: "standard", Version: 1, BaseFare: 8500, PerKm: 2000, Currency: "TWD"}},
+		days:  []SurchargeDay{{Date: "2026-10-10", Name: "National Day", SurchargeBps: 2000}},
+	}
+	svc := NewService(store)
+	svc.SetConfigStore(store)
+	ctx := context.Background()
+
+	// 12:00 Taipei on the holiday; the day before is ordinary.
+	holiday := time.Date(2026, 10, 10, 4, 0, 0, 0, time.UTC)
+	b, err := svc.Breakdown(ctx, order.PricingRequest{RideType: "standard", DistanceKm: 5, At: holiday})
+	if err != nil || b.CalendarSurcharge != 3700 || b.Total != 22200 {
+		t.Errorf("holiday: %+v, %v; want 3700 surcharge", b, err)
+	}
+	b, err = svc.Breakdown(ctx, order.PricingRequest{RideType: "standard", DistanceKm: 5, At: holiday.Add(-24 * time.Hour)})
+	if err != nil || b.CalendarSurcharge != 0 || b.Total != 18500 {
+		t.Errorf("ordinary day: %+v, %v", b, err)
+	}
+	// 23:30 UTC on the 9th is already the 10th in Taipei.
+	b, _ = svc.Breakdown(ctx, order.PricingRequest{RideType: "standard", DistanceKm: 5, At: time.Date(2026, 10, 9, 23, 30, 0, 0, time.UTC)})
+	if b.CalendarSurcharge != 3700 {
+		t.Errorf("local date: surcharge %d, want 3700", b.CalendarSurcharge)
+	}
+}
+
+func TestImportRates_DiffDryRunAndPublish(t *testing.T) {
+	store := &mockStore{rates: []Rate{
+		{RideType: "standard", Version: 2, BaseFare: 8500, PerKm: 2000, Currency: "TWD"},
+		{RideType: "lux", Version: 1, BaseFare: 15000, PerKm: 3000, Currency: "TWD"},
+	}}
+	svc := NewService(store)
+	svc.SetConfigStore(store)
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	csv := "ride_type,currency,base_fare,per_km,night_surcharge_bps,weather_surcharge_bps,peak_surcharge_bps,accessibility_subsidy,cancel_fee,cancel_per_km\n" +
+		"standard,TWD,9000,2000,0,0,0,0,0,0\n" +
+		"lux,TWD,15000,3000,0,0,0,0,0,0\n" +
+		"wav,TWD,8500,2000,0,0,0,3000,0,0\n"
+	rs, err := DecodeRates(strings.NewReader(csv), bulk.CSV)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	res, err := svc.ImportRates(ctx, rs, true)
+	want := []bulk.Change{
+		{Action: bulk.Update, Key: "/standard", Fields: []string{"base_fare"}},
+		{Action: bulk.Add, Key: "/wav"},
+	}
+	if err != nil || !res.DryRun || res.Unchanged != 1 || !reflect.DeepEqual(res.Changes, want) {
+		t.Fatalf("dry run = %+v, %v; want %+v", res, err, want)
+	}
+	if len(store.rates) != 2 {
+		t.Fatalf("dry run published %d rates", len(store.rates)-2)
+	}
+
+	if _, err := svc.ImportRates(ctx, rs, false); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	r, err := store.GetRate(ctx, "", "standard", now)
+	if err != nil || r.Version != 3 || r.BaseFare != 9000 || !r.EffectiveFrom.Equal(now) {
+		t.Errorf("published standard = %+v, %v; want v3 at 9000 from now", r, err)
+	}
+	if r, err := store.GetRate(ctx, "", "wav", now); err != nil || r.Version != 1 {
+		t.Errorf("published wav = %+v, %v", r, err)
+	}
+	// Importing the same file again changes nothing.
+	if res, err := svc.ImportRates(ctx, rs, false); err != nil || len(res.Changes) != 0 || res.Unchanged != 3 {
+		t.Errorf("re-import = %+v, %v", res, err)
+	}
+}
+
+func TestImportRates_RejectsInvalidRowsWhole(t *testing.T) {
+	store := &mockStore{}
+	svc := NewService(store)
+	svc.SetConfigStore(store)
+
+	csv := "ride_type,currency,base_fare,per_km,night_surcharge_bps,weather_surcharge_bps,peak_surcharge_bps,accessibility_subsidy,cancel_fee,cancel_per_km\n" +
+		"standard,TWD,9000,2000,0,0,0,0,0,0\n" +
+		"lux,TWD,lots,3000,0,0,0,0,0,0\n"
+	if _, err := DecodeRates(strings.NewReader(csv), bulk.CSV); !errors.Is(err, bulk.ErrInvalid) {
+		t.Errorf("unparsable fare: err = %v, want ErrInvalid", err)
+	}
+
+	rs := []Rate{
+		{RideType: "standard", Currency: "TWD", BaseFare: 9000},
+		{RideType: "standard", Currency: "TWD", BaseFare: 9500},
+		{RideType: "lux", Currency: "XXX", PeakSurchargeBps: -1},
+	}
+	_, err := svc.ImportRates(context.Background(), rs, false)
+	var verr *bulk.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want a ValidationError", err)
+	}
+	want := []bulk.RowError{
+		{Row: 2, Field: "ride_type", Message: "repeats row 1"},
+		{Row: 3, Field: "currency", Message: "unsupported currency"},
+		{Row: 3, Field: "peak_surcharge_bps", Message: "must be between 0 and 50000"},
+	}
+	if !reflect.DeepEqual(verr.Rows, want) {
+		t.Errorf("rows = %+v, want %+v", verr.Rows, want)
+	}
+	if len(store.rates) != 0 {
+		t.Errorf("published %d rates from an invalid import", len(store.rates))
+	}
+}
+
+func TestImportSurchargeDays_ReplacesFileRateSets(t *testing.T) {
+	store := &mockStore{days: []SurchargeDay{
+		{Date: "2026-10-10", Name: "National Day", SurchargeBps: 2000},
+		{Date: "2026-12-25", Name: "Christmas", SurchargeBps: 1000},
+		{RateSet: "khh", Date: "2026-10-10", Name: "National Day", SurchargeBps: 1500},
+	}}
+	svc := NewService(store)
+	svc.SetConfigStore(store)
+	ctx := context.Background()
+
+	days := []SurchargeDay{
+		{Date: "2026-10-10", Name: "National Day", SurchargeBps: 2500},
+		{Date: "2027-01-01", Name: "New Year", SurchargeBps: 2000},
+	}
+	res, err := svc.ImportSurchargeDays(ctx, days, false)
+	want := []bulk.Change{
+		{Action: bulk.Update, Key: "/2026-10-10", Fields: []string{"surcharge_bps"}},
+		{Action: bulk.Remove, Key: "/2026-12-25"},
+		{Action: bulk.Add, Key: "/2027-01-01"},
+	}
+	if err != nil || !reflect.DeepEqual(res.Changes, want) {
+		t.Fatalf("import = %+v, %v; want %+v", res, err, want)
+	}
+	got, _ := svc.ExportSurchargeDays(ctx)
+	if len(got) != 3 || !slices.Contains(got, store.days[0]) || !slices.ContainsFunc(got, func(d SurchargeDay) bool { return d.RateSet == "khh" }) {
+		t.Errorf("calendars after import = %+v; khh must be untouched", got)
+	}
+
+	var buf strings.Builder
+	if err := EncodeSurchargeDays(&buf, bulk.CSV, got); err != nil {
+		t.Fatal(err)
+	}
+	back, err := DecodeSurchargeDays(strings.NewReader(buf.String()), bulk.CSV)
+	if err != nil || !reflect.DeepEqual(back, got) {
+		t.Errorf("CSV round trip = %+v, %v; want %+v", back, err, got)
+	}
+
+	if _, err := svc.ImportSurchargeDays(ctx, []SurchargeDay{{Date: "10/10/2026", SurchargeBps: 100}}, false); !errors.Is(err, bulk.ErrInvalid) {
+		t.Errorf("bad date: err = %v, want ErrInvalid", err)
+	}
+}
@@ -4,10 +4,19 @@ package pricing
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// PricingStore reads configured rates.
+type PricingStore interface {
+	// GetRate returns the latest version of rideType's rate in rateSet
+	// effective at at, or ErrNotFound.
+	GetRate(ctx context.Context, rateSet, rideType string, at time.Time) (Rate, error)
+}
+
 type Store struct {
 	db *pgxpool.Pool
 }
@@ -16,6 +25,154 @@ func NewStore(db *pgxpool.Pool) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) GetRate(ctx context.Context, rideType string) (Rate, error) {
-	return Rate{}, errors.New("not implemented")
+// rateColumns is read by scanRate.
+const rateColumns = `rate_set, ride_type, version, effective_from, base_fare, per_km, currency,
+		       night_surcharge_bps, weather_surcharge_bps, peak_surcharge_bps, accessibility_subsidy,
+		       cancel_fee, cancel_per_km, parcel_medium_bps, parcel_large_bps, return_allowance,
+		       per_hour, overtime_per_hour`
+
+func (s *Store) GetRate(ctx context.Context, rateSet, rideType string, at time.Time) (Rate, error) {
+	r, err := scanRate(s.db.QueryRow(ctx, `
+		SELECT `+rateColumns+`
+		FROM pricing_rates
+		WHERE rate_set = $1 AND ride_type = $2 AND effective_from <= $3
+		ORDER BY version DESC
+		LIMIT 1`, rateSet, rideType, at,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Rate{}, ErrNotFound
+	}
+	return r, err
+}
+
+// scanRate reads a row selected with rateColumns.
+func scanRate(row pgx.Row) (Rate, error) {
+	var r Rate
+	err := row.Scan(&r.RateSet, &r.RideType, &r.Version, &r.EffectiveFrom, &r.BaseFare, &r.PerKm, &r.Currency,
+		&r.NightSurchargeBps, &r.WeatherSurchargeBps, &r.PeakSurchargeBps, &r.AccessibilitySubsidy,
+		&r.CancelFee, &r.CancelPerKm, &r.ParcelMediumBps, &r.ParcelLargeBps, &r.ReturnAllowance,
+		&r.PerHour, &r.OvertimePerHour)
+	return r, err
+}
+
+// ConfigStore manages the configuration ops import and export: rates and
+// the surcharge calendars.
+type ConfigStore interface {
+	// LatestRates returns the highest version of every rate, ordered by rate
+	// set and ride type.
+	LatestRates(ctx context.Context) ([]Rate, error)
+	// PublishRates inserts each rate as the next version of its rate set and
+	// ride type, all or none, and sets the versions in rs.
+	PublishRates(ctx context.Context, rs []Rate) error
+	// SurchargeDays returns every surcharge calendar, ordered by rate set and
+	// date.
+	SurchargeDays(ctx context.Context) ([]SurchargeDay, error)
+	// ReplaceSurchargeDays replaces the calendars of rateSets with days, all
+	// or none.
+	ReplaceSurchargeDays(ctx context.Context, rateSets []string, days []SurchargeDay) error
+	// SurchargeBps returns rateSet's surcharge on date (YYYY-MM-DD), 0 on a
+	// day not in its calendar.
+	SurchargeBps(ctx context.Context, rateSet, date string) (int, error)
+}
+
+func (s *Store) LatestRates(ctx context.Context) ([]Rate, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT ON (rate_set, ride_type) `+rateColumns+`
+		FROM pricing_rates
+		ORDER BY rate_set, ride_type, version DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Rate
+	for rows.Next() {
+		r, err := scanRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) PublishRates(ctx context.Context, rs []Rate) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for i := range rs {
+		r := &rs[i]
+		err := tx.QueryRow(ctx, `
+			INSERT INTO pricing_rates (rate_set, ride_type, version, effective_from, base_fare, per_km, currency,
+			                           night_surcharge_bps, weather_surcharge_bps, peak_surcharge_bps, accessibility_subsidy,
+			                           cancel_fee, cancel_per_km, parcel_medium_bps, parcel_large_bps, return_allowance,
+			                           per_hour, overtime_per_hour)
+			SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+			FROM pricing_rates
+			WHERE rate_set = $1 AND ride_type = $2
+			RETURNING version`,
+			r.RateSet, r.RideType, r.EffectiveFrom, r.BaseFare, r.PerKm, r.Currency,
+			r.NightSurchargeBps, r.WeatherSurchargeBps, r.PeakSurchargeBps, r.AccessibilitySubsidy,
+			r.CancelFee, r.CancelPerKm, r.ParcelMediumBps, r.ParcelLargeBps, r.ReturnAllowance,
+			r.PerHour, r.OvertimePerHour,
+		).Scan(&r.Version)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) SurchargeDays(ctx context.Context) ([]SurchargeDay, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT rate_set, to_char(day, 'YYYY-MM-DD'), name, surcharge_bps
+		FROM pricing_surcharge_days
+		ORDER BY rate_set, day`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SurchargeDay
+	for rows.Next() {
+		var d SurchargeDay
+		if err := rows.Scan(&d.RateSet, &d.Date, &d.Name, &d.SurchargeBps); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ReplaceSurchargeDays(ctx context.Context, rateSets []string, days []SurchargeDay) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `DELETE FROM pricing_surcharge_days WHERE rate_set = ANY($1)`, rateSets); err != nil {
+		return err
+	}
+	for _, d := range days {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO pricing_surcharge_days (rate_set, day, name, surcharge_bps)
+			VALUES ($1, $2::date, $3, $4)`,
+			d.RateSet, d.Date, d.Name, d.SurchargeBps,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) SurchargeBps(ctx context.Context, rateSet, date string) (int, error) {
+	var bps int
+	err := s.db.QueryRow(ctx, `
+		SELECT surcharge_bps FROM pricing_surcharge_days
+		WHERE rate_set = $1 AND day = $2::date`, rateSet, date,
+	).Scan(&bps)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return bps, err
 }
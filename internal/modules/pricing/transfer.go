@@ -0,0 +1,429 @@
+// README: Pricing configuration transfer — CSV/JSON export and validated, diffed, all-or-nothing import of rates and surcharge calendars.
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"ark/internal/bulk"
+	"ark/internal/types"
+)
+
+// ErrNoConfigStore is returned by the import and export calls of a Service
+// without SetConfigStore.
+var ErrNoConfigStore = errors.New("pricing configuration store not configured")
+
+// maxSurchargeBps caps every surcharge an import may set: 500%.
+const maxSurchargeBps = 50000
+
+var (
+	rateSetRe  = regexp.MustCompile(`^([a-z0-9][a-z0-9_-]{0,31})?$`)
+	rideTypeRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+)
+
+// rateColumnNames lay out rates in CSV files, matching Rate's JSON names.
+// version is informational: an import publishes the next version.
+var rateColumnNames = []string{
+	"rate_set", "ride_type", "version", "effective_from", "currency", "base_fare", "per_km",
+	"night_surcharge_bps", "weather_surcharge_bps", "peak_surcharge_bps", "accessibility_subsidy",
+	"cancel_fee", "cancel_per_km", "parcel_medium_bps", "parcel_large_bps", "return_allowance",
+	"per_hour", "overtime_per_hour",
+}
+
+var rateOptionalColumns = []string{"rate_set", "version", "effective_from", "parcel_medium_bps", "parcel_large_bps", "return_allowance",
+	"per_hour", "overtime_per_hour",
+}
+
+// surchargeDayColumns lay out surcharge calendars in CSV files.
+var surchargeDayColumns = []string{"rate_set", "date", "name", "surcharge_bps"}
+
+func rateKey(rateSet, rideType string) string {
+	return rateSet + "/" + rideType
+}
+
+// rateRow lays r out as rateColumnNames. effective_from is left empty for a
+// rate in force since before versioning.
+func rateRow(r Rate) []string {
+	var from string
+	if r.EffectiveFrom.Year() > 1 {
+		from = r.EffectiveFrom.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		r.RateSet, r.RideType, strconv.Itoa(r.Version), from, r.Currency,
+		strconv.FormatInt(r.BaseFare, 10), strconv.FormatInt(r.PerKm, 10),
+		strconv.Itoa(r.NightSurchargeBps), strconv.Itoa(r.WeatherSurchargeBps), strconv.Itoa(r.PeakSurchargeBps),
+		strconv.FormatInt(r.AccessibilitySubsidy, 10),
+		strconv.FormatInt(r.CancelFee, 10), strconv.FormatInt(r.CancelPerKm, 10),
+		strconv.Itoa(r.ParcelMediumBps), strconv.Itoa(r.ParcelLargeBps),
+		strconv.FormatInt(r.ReturnAllowance, 10),
+		strconv.FormatInt(r.PerHour, 10), strconv.FormatInt(r.OvertimePerHour, 10),
+	}
+}
+
+// fareColumns are the rateColumnNames an import compares: a rate whose fare
+// columns all match the latest version is left alone.
+var fareColumns = rateColumnNames[4:]
+
+func fareRow(r Rate) []string {
+	return rateRow(r)[4:]
+}
+
+// EncodeRates writes rs as f: a CSV file, or JSON {"rates": [...]}.
+func EncodeRates(w io.Writer, f bulk.Format, rs []Rate) error {
+	if f == bulk.CSV {
+		rows := make([][]string, len(rs))
+		for i, r := range rs {
+			rows[i] = rateRow(r)
+		}
+		return bulk.WriteCSV(w, rateColumnNames, rows)
+	}
+	if rs == nil {
+		rs = []Rate{}
+	}
+	return json.NewEncoder(w).Encode(map[string]any{"rates": rs})
+}
+
+// DecodeRates reads rates written as f, in EncodeRates' layout. A CSV cell
+// that is not a number where one is expected is reported for its row.
+func DecodeRates(r io.Reader, f bulk.Format) ([]Rate, error) {
+	if f == bulk.JSON {
+		var body struct {
+			Rates []Rate `json:"rates"`
+		}
+		if err := json.NewDecoder(r).Decode(&body); err != nil {
+			return nil, fmt.Errorf("%w: %w", bulk.ErrMalformed, err)
+		}
+		if len(body.Rates) > bulk.MaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", bulk.ErrMalformed, bulk.MaxRows)
+		}
+		return body.Rates, nil
+	}
+	rows, err := bulk.ReadCSV(r, rateColumnNames, rateOptionalColumns)
+	if err != nil {
+		return nil, err
+	}
+	var verr bulk.ValidationError
+	out := make([]Rate, len(rows))
+	for i, row := range rows {
+		c := cells{row: i + 1, cols: rateColumnNames, vals: row, errs: &verr}
+		out[i] = Rate{
+			RateSet:              c.str("rate_set"),
+			RideType:             c.str("ride_type"),
+			EffectiveFrom:        c.time("effective_from"),
+			Currency:             c.str("currency"),
+			BaseFare:             c.int64("base_fare"),
+			PerKm:                c.int64("per_km"),
+			NightSurchargeBps:    int(c.int64("night_surcharge_bps")),
+			WeatherSurchargeBps:  int(c.int64("weather_surcharge_bps")),
+			PeakSurchargeBps:     int(c.int64("peak_surcharge_bps")),
+			AccessibilitySubsidy: c.int64("accessibility_subsidy"),
+			CancelFee:            c.int64("cancel_fee"),
+			CancelPerKm:          c.int64("cancel_per_km"),
+			ParcelMediumBps:      int(c.int64("parcel_medium_bps")),
+			ParcelLargeBps:       int(c.int64("parcel_large_bps")),
+			ReturnAllowance:      c.int64("return_allowance"),
+			PerHour:              c.int64("per_hour"),
+			OvertimePerHour:      c.int64("overtime_per_hour"),
+		}
+	}
+	return out, verr.Err()
+}
+
+// EncodeSurchargeDays writes days as f: a CSV file, or JSON
+// {"surcharge_days": [...]}.
+func EncodeSurchargeDays(w io.Writer, f bulk.Format, days []SurchargeDay) error {
+	if f == bulk.CSV {
+		rows := make([][]string, len(days))
+		for i, d := range days {
+			rows[i] = surchargeDayRow(d)
+		}
+		return bulk.WriteCSV(w, surchargeDayColumns, rows)
+	}
+	if days == nil {
+		days = []SurchargeDay{}
+	}
+	return json.NewEncoder(w).Encode(map[string]any{"surcharge_days": days})
+}
+
+// DecodeSurchargeDays reads a calendar written as f, in EncodeSurchargeDays'
+// layout.
+func DecodeSurchargeDays(r io.Reader, f bulk.Format) ([]SurchargeDay, error) {
+	if f == bulk.JSON {
+		var body struct {
+			Days []SurchargeDay `json:"surcharge_days"`
+		}
+		if err := json.NewDecoder(r).Decode(&body); err != nil {
+			return nil, fmt.Errorf("%w: %w", bulk.ErrMalformed, err)
+		}
+		if len(body.Days) > bulk.MaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", bulk.ErrMalformed, bulk.MaxRows)
+		}
+		return body.Days, nil
+	}
+	rows, err := bulk.ReadCSV(r, surchargeDayColumns, []string{"rate_set", "name"})
+	if err != nil {
+		return nil, err
+	}
+	var verr bulk.ValidationError
+	out := make([]SurchargeDay, len(rows))
+	for i, row := range rows {
+		c := cells{row: i + 1, cols: surchargeDayColumns, vals: row, errs: &verr}
+		out[i] = SurchargeDay{
+			RateSet:      c.str("rate_set"),
+			Date:         c.str("date"),
+			Name:         c.str("name"),
+			SurchargeBps: int(c.int64("surcharge_bps")),
+		}
+	}
+	return out, verr.Err()
+}
+
+func surchargeDayRow(d SurchargeDay) []string {
+	return []string{d.RateSet, d.Date, d.Name, strconv.Itoa(d.SurchargeBps)}
+}
+
+// cells reads the typed values of one CSV row, recording unparsable cells in
+// errs.
+type cells struct {
+	row  int
+	cols []string
+	vals []string
+	errs *bulk.ValidationError
+}
+
+func (c cells) str(col string) string {
+	return c.vals[slices.Index(c.cols, col)]
+}
+
+func (c cells) int64(col string) int64 {
+	s := c.str(col)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		c.errs.Add(c.row, col, "not a whole number")
+	}
+	return n
+}
+
+func (c cells) time(col string) time.Time {
+	s := c.str(col)
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		c.errs.Add(c.row, col, "not an RFC 3339 time")
+	}
+	return t
+}
+
+// ExportRates returns the latest version of every rate.
+func (s *Service) ExportRates(ctx context.Context) ([]Rate, error) {
+	if s.config == nil {
+		return nil, ErrNoConfigStore
+	}
+	return s.config.LatestRates(ctx)
+}
+
+// ImportRates publishes each rate in rs that differs from the latest version
+// of its rate set and ride type as that ride type's next version, all or
+// none; with dryRun it only reports what would be published. Rates are
+// never removed, so ride types missing from rs keep their rates. A rate
+// takes effect at its EffectiveFrom, or immediately when that is unset or
+// already past: published fares are never changed retroactively.
+func (s *Service) ImportRates(ctx context.Context, rs []Rate, dryRun bool) (bulk.Result, error) {
+	if s.config == nil {
+		return bulk.Result{}, ErrNoConfigStore
+	}
+	if err := validateRates(rs); err != nil {
+		return bulk.Result{}, err
+	}
+	current, err := s.config.LatestRates(ctx)
+	if err != nil {
+		return bulk.Result{}, err
+	}
+	latest := make(map[string]Rate, len(current))
+	for _, r := range current {
+		latest[rateKey(r.RateSet, r.RideType)] = r
+	}
+	now := s.now()
+	res := bulk.Result{DryRun: dryRun, Changes: []bulk.Change{}}
+	var publish []Rate
+	for _, r := range rs {
+		key := rateKey(r.RateSet, r.RideType)
+		change := bulk.Change{Action: bulk.Add, Key: key}
+		if old, ok := latest[key]; ok {
+			change.Action = bulk.Update
+			change.Fields = bulk.Diff(fareColumns, fareRow(old), fareRow(r))
+			if len(change.Fields) == 0 {
+				res.Unchanged++
+				continue
+			}
+		}
+		if r.EffectiveFrom.Before(now) {
+			r.EffectiveFrom = now
+		}
+		r.Version = 0
+		publish = append(publish, r)
+		res.Changes = append(res.Changes, change)
+	}
+	if dryRun || len(publish) == 0 {
+		return res, nil
+	}
+	if err := s.config.PublishRates(ctx, publish); err != nil {
+		return bulk.Result{}, err
+	}
+	return res, nil
+}
+
+func validateRates(rs []Rate) error {
+	var verr bulk.ValidationError
+	seen := make(map[string]int, len(rs))
+	for i, r := range rs {
+		row := i + 1
+		if !rateSetRe.MatchString(r.RateSet) {
+			verr.Add(row, "rate_set", "must be up to 32 lowercase letters, digits, - or _")
+		}
+		if !rideTypeRe.MatchString(r.RideType) {
+			verr.Add(row, "ride_type", "must be 1 to 32 lowercase letters, digits, - or _")
+		}
+		if !types.ValidCurrency(r.Currency) {
+			verr.Add(row, "currency", "unsupported currency")
+		}
+		for _, v := range []struct {
+			field string
+			n     int64
+		}{
+			{"base_fare", r.BaseFare},
+			{"per_km", r.PerKm},
+			{"accessibility_subsidy", r.AccessibilitySubsidy},
+			{"cancel_fee", r.CancelFee},
+			{"cancel_per_km", r.CancelPerKm},
+			{"return_allowance", r.ReturnAllowance},
+			{"per_hour", r.PerHour},
+			{"overtime_per_hour", r.OvertimePerHour},
+		} {
+			if v.n < 0 {
+				verr.Add(row, v.field, "must not be negative")
+			}
+		}
+		for _, v := range []struct {
+			field string
+			bps   int
+		}{
+			{"night_surcharge_bps", r.NightSurchargeBps},
+			{"weather_surcharge_bps", r.WeatherSurchargeBps},
+			{"peak_surcharge_bps", r.PeakSurchargeBps},
+			{"parcel_medium_bps", r.ParcelMediumBps},
+			{"parcel_large_bps", r.ParcelLargeBps},
+		} {
+			if v.bps < 0 || v.bps > maxSurchargeBps {
+				verr.Add(row, v.field, fmt.Sprintf("must be between 0 and %d", maxSurchargeBps))
+			}
+		}
+		key := rateKey(r.RateSet, r.RideType)
+		if first, ok := seen[key]; ok {
+			verr.Add(row, "ride_type", fmt.Sprintf("repeats row %d", first))
+		} else {
+			seen[key] = row
+		}
+	}
+	return verr.Err()
+}
+
+// ExportSurchargeDays returns every surcharge calendar.
+func (s *Service) ExportSurchargeDays(ctx context.Context) ([]SurchargeDay, error) {
+	if s.config == nil {
+		return nil, ErrNoConfigStore
+	}
+	return s.config.SurchargeDays(ctx)
+}
+
+// ImportSurchargeDays replaces the calendar of every rate set named in days
+// with its days in the file, all or none; with dryRun it only reports the
+// difference. Calendars of rate sets not in the file are left alone.
+func (s *Service) ImportSurchargeDays(ctx context.Context, days []SurchargeDay, dryRun bool) (bulk.Result, error) {
+	if s.config == nil {
+		return bulk.Result{}, ErrNoConfigStore
+	}
+	if err := validateSurchargeDays(days); err != nil {
+		return bulk.Result{}, err
+	}
+	current, err := s.config.SurchargeDays(ctx)
+	if err != nil {
+		return bulk.Result{}, err
+	}
+	var rateSets []string
+	incoming := make(map[string]SurchargeDay, len(days))
+	for _, d := range days {
+		if !slices.Contains(rateSets, d.RateSet) {
+			rateSets = append(rateSets, d.RateSet)
+		}
+		incoming[rateKey(d.RateSet, d.Date)] = d
+	}
+	res := bulk.Result{DryRun: dryRun, Changes: []bulk.Change{}}
+	for _, old := range current {
+		key := rateKey(old.RateSet, old.Date)
+		d, ok := incoming[key]
+		switch {
+		case !ok && slices.Contains(rateSets, old.RateSet):
+			res.Changes = append(res.Changes, bulk.Change{Action: bulk.Remove, Key: key})
+		case !ok:
+		default:
+			delete(incoming, key)
+			if fields := bulk.Diff(surchargeDayColumns, surchargeDayRow(old), surchargeDayRow(d)); len(fields) > 0 {
+				res.Changes = append(res.Changes, bulk.Change{Action: bulk.Update, Key: key, Fields: fields})
+			} else {
+				res.Unchanged++
+			}
+		}
+	}
+	for _, d := range days {
+		if key := rateKey(d.RateSet, d.Date); incoming[key] == d {
+			res.Changes = append(res.Changes, bulk.Change{Action: bulk.Add, Key: key})
+		}
+	}
+	if dryRun || len(res.Changes) == 0 {
+		return res, nil
+	}
+	if err := s.config.ReplaceSurchargeDays(ctx, rateSets, days); err != nil {
+		return bulk.Result{}, err
+	}
+	return res, nil
+}
+
+func validateSurchargeDays(days []SurchargeDay) error {
+	var verr bulk.ValidationError
+	seen := make(map[string]int, len(days))
+	for i, d := range days {
+		row := i + 1
+		if !rateSetRe.MatchString(d.RateSet) {
+			verr.Add(row, "rate_set", "must be up to 32 lowercase letters, digits, - or _")
+		}
+		if _, err := time.Parse(time.DateOnly, d.Date); err != nil {
+			verr.Add(row, "date", "must be a YYYY-MM-DD date")
+		}
+		if len(d.Name) > 100 {
+			verr.Add(row, "name", "must be at most 100 characters")
+		}
+		if d.SurchargeBps < 0 || d.SurchargeBps > maxSurchargeBps {
+			verr.Add(row, "surcharge_bps", fmt.Sprintf("must be between 0 and %d", maxSurchargeBps))
+		}
+		key := rateKey(d.RateSet, d.Date)
+		if first, ok := seen[key]; ok {
+			verr.Add(row, "date", fmt.Sprintf("repeats row %d", first))
+		} else {
+			seen[key] = row
+		}
+	}
+	return verr.Err()
+}
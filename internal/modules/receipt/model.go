@@ -0,0 +1,37 @@
+// README: Receipt domain model — email recipients, per-trip receipts and monthly trip summaries.
+package receipt
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Email kinds recorded in the send log.
+const (
+	KindReceipt        = "receipt"
+	KindMonthlySummary = "monthly_summary"
+)
+
+var (
+	ErrNotFound = errors.New("receipt: not found")
+	// ErrNoEmail is returned when the user has no email address on file.
+	ErrNoEmail = errors.New("receipt: user has no email address")
+)
+
+// Recipient is the addressee of a receipt or summary email.
+type Recipient struct {
+	UserID types.ID
+	Name   string
+	Email  string
+	Locale string
+}
+
+// MonthlySummary is one passenger's rolled-up trip totals for a calendar month.
+type MonthlySummary struct {
+	UserID    types.ID
+	Month     time.Time // first instant of the month, Asia/Taipei
+	Trips     int
+	TotalFare types.Money
+}
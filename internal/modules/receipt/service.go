@@ -0,0 +1,222 @@
+// README: Receipt service — emails a receipt on trip completion and runs the monthly summary rollup job.
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ark/internal/modules/notification"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// sendTimeout bounds one receipt email triggered from an order transition.
+const sendTimeout = 30 * time.Second
+
+// OrderReader is the subset of order.Service used to load completed trips.
+type OrderReader interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// Service renders and sends receipt and summary emails.
+type Service struct {
+	store  ReceiptStore
+	orders OrderReader
+	sender notification.EmailSender
+	now    func() time.Time
+}
+
+func NewService(store ReceiptStore, orders OrderReader, sender notification.EmailSender) *Service {
+	return &Service{store: store, orders: orders, sender: sender, now: time.Now}
+}
+
+// receiptData is the template input for a trip receipt.
+type receiptData struct {
+	Name        string
+	OrderID     types.ID
+	CompletedAt time.Time
+	RideType    string
+	Pickup      types.Point
+	Dropoff     types.Point
+	Fare        types.Money
+	// Subscription and Credits are the ride plan benefit and ride credit
+	// taken off Fare; Paid is what the passenger paid.
+	Subscription types.Money
+	Credits      types.Money
+	Paid         types.Money
+}
+
+// summaryData is the template input for a monthly summary.
+type summaryData struct {
+	Name      string
+	Month     time.Time
+	Trips     int
+	TotalFare types.Money
+}
+
+// SendReceipt emails the passenger a receipt for a completed order. Sending
+// the same receipt twice is a no-op.
+func (s *Service) SendReceipt(ctx context.Context, orderID types.ID) error {
+	o, err := s.orders.Get(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if o.Status != order.StatusComplete {
+		return fmt.Errorf("receipt: order %s is %s, not complete", orderID, o.Status)
+	}
+	rcpt, err := s.recipient(ctx, o.PassengerID)
+	if err != nil {
+		return err
+	}
+
+	data := receiptData{
+		Name:         rcpt.Name,
+		OrderID:      o.ID,
+		CompletedAt:  s.now(),
+		RideType:     o.RideType,
+		Pickup:       o.Pickup,
+		Dropoff:      o.Dropoff,
+		Fare:         o.Fare(),
+		Subscription: types.Money{Amount: o.SubscriptionApplied, Currency: o.Fare().Currency},
+		Credits:      types.Money{Amount: o.CreditsApplied, Currency: o.Fare().Currency},
+		Paid:         o.AmountDue(),
+	}
+	if o.CompletedAt != nil {
+		data.CompletedAt = *o.CompletedAt
+	}
+	_, err = s.deliver(ctx, KindReceipt, rcpt, string(o.ID), "receipt", data)
+	return err
+}
+
+// OrderHook sends the receipt in the background once an order completes.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusComplete {
+			return
+		}
+		if t.Sandbox {
+			log.Printf("receipt: order %s is a sandbox order; receipt not emailed", t.OrderID)
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+			defer cancel()
+			if err := s.SendReceipt(ctx, t.OrderID); err != nil {
+				log.Printf("receipt: order %s: %v", t.OrderID, err)
+			}
+		}()
+	}
+}
+
+// SendMonthlySummaries rolls up the calendar month containing month (Asia/Taipei)
+// and emails every opted-in passenger who took at least one trip. It returns the
+// number of emails sent; already-sent summaries are skipped.
+func (s *Service) SendMonthlySummaries(ctx context.Context, month time.Time) (int, error) {
+	m := month.In(taipei)
+	from := time.Date(m.Year(), m.Month(), 1, 0, 0, 0, 0, taipei)
+	to := from.AddDate(0, 1, 0)
+
+	summaries, err := s.store.MonthlyRollup(ctx, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("monthly rollup: %w", err)
+	}
+
+	// A summary shows a single total, so passengers who rode in more than one
+	// currency that month are reported instead of mailed.
+	mixed := make(map[types.ID]bool)
+	for i := 1; i < len(summaries); i++ {
+		if summaries[i].UserID == summaries[i-1].UserID {
+			mixed[summaries[i].UserID] = true
+		}
+	}
+
+	ref := from.Format("2006-01")
+	sent := 0
+	var errs []error
+	for i, sum := range summaries {
+		if mixed[sum.UserID] {
+			if i == 0 || summaries[i-1].UserID != sum.UserID {
+				errs = append(errs, fmt.Errorf("%s: %w", sum.UserID, types.ErrCurrencyMismatch))
+			}
+			continue
+		}
+		rcpt, err := s.recipient(ctx, sum.UserID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sum.UserID, err))
+			continue
+		}
+		data := summaryData{Name: rcpt.Name, Month: from, Trips: sum.Trips, TotalFare: sum.TotalFare}
+		ok, err := s.deliver(ctx, KindMonthlySummary, rcpt, ref, "summary", data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sum.UserID, err))
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// RunMonthlyRollup sends last month's summaries during the first days of each
+// month. The send log makes repeated runs safe, so it simply checks hourly.
+func (s *Service) RunMonthlyRollup(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	log.Println("receipt: monthly rollup started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("receipt: monthly rollup stopped")
+			return
+		case <-ticker.C:
+			now := s.now().In(taipei)
+			if now.Day() > 3 {
+				continue
+			}
+			prev := now.AddDate(0, 0, -now.Day())
+			n, err := s.SendMonthlySummaries(ctx, prev)
+			if err != nil {
+				log.Printf("receipt: monthly summaries for %s: %v", prev.Format("2006-01"), err)
+			}
+			if n > 0 {
+				log.Printf("receipt: sent %d monthly summaries for %s", n, prev.Format("2006-01"))
+			}
+		}
+	}
+}
+
+func (s *Service) recipient(ctx context.Context, userID types.ID) (*Recipient, error) {
+	r, err := s.store.GetRecipient(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Email == "" {
+		return nil, ErrNoEmail
+	}
+	return r, nil
+}
+
+// deliver renders the template and sends it once per (kind, user, ref). It
+// reports false when the email had already been sent.
+func (s *Service) deliver(ctx context.Context, kind string, rcpt *Recipient, ref, tmpl string, data any) (bool, error) {
+	subject, body, err := render(tmpl, rcpt.Locale, data)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := s.store.ClaimSend(ctx, kind, rcpt.UserID, ref)
+	if err != nil || !claimed {
+		return false, err
+	}
+	if err := s.sender.Send(ctx, notification.Email{To: rcpt.Email, Subject: subject, Body: body}); err != nil {
+		if relErr := s.store.ReleaseSend(ctx, kind, rcpt.UserID, ref); relErr != nil {
+			log.Printf("receipt: release %s/%s for %s: %v", kind, ref, rcpt.UserID, relErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,224 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ark/internal/modules/notification"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type fakeStore struct {
+	recipients map[types.ID]*Recipient
+	rollup     []MonthlySummary
+	sent       map[string]bool
+	rollupFrom time.Time
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{recipients: map[types.ID]*Recipient{}, sent: map[string]bool{}}
+}
+
+func (f *fakeStore) GetRecipient(_ context.Context, userID types.ID) (*Recipient, error) {
+	r, ok := f.recipients[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+func (f *fakeStore) MonthlyRollup(_ context.Context, from, _ time.Time) ([]MonthlySummary, error) {
+	f.rollupFrom = from
+	return f.rollup, nil
+}
+
+func (f *fakeStore) ClaimSend(_ context.Context, kind string, userID types.ID, ref string) (bool, error) {
+	key := kind + "/" + string(userID) + "/" + ref
+	if f.sent[key] {
+		return false, nil
+	}
+	f.sent[key] = true
+	return true, nil
+}
+
+func (f *fakeStore) ReleaseSend(_ context.Context, kind string, userID types.ID, ref string) error {
+	delete(f.sent, kind+"/"+string(userID)+"/"+ref)
+	return nil
+}
+
+type fakeOrders map[types.ID]*order.Order
+
+func (f fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	o, ok := f[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	return o, nil
+}
+
+type fakeSender struct {
+	sent []notification.Email
+	err  error
+}
+
+func (f *fakeSender) Send(_ context.Context, e notification.Email) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, e)
+	return nil
+}
+
+func completedOrder() *order.Order {
+	done := time.Date(2026, 3, 5, 10, 30, 0, 0, time.UTC) // 18:30 in Taipei
+	return &order.Order{
+		ID:           "o1",
+		PassengerID:  "p1",
+		Status:       order.StatusComplete,
+		RideType:     "standard",
+		EstimatedFee: types.Money{Amount: 15000, Currency: "TWD"},
+		ActualFee:    &types.Money{Amount: 18000, Currency: "TWD"},
+		CompletedAt:  &done,
+	}
+}
+
+func TestSendReceipt_Localized(t *testing.T) {
+	cases := []struct {
+		locale, subject, total string
+	}{
+		{"en", "Your Ark receipt for Mar 5, 2026", "TWD 180"},
+		{"zh-TW", "Ark 乘車收據 2026/03/05", "NT$180"},
+	}
+	for _, c := range cases {
+		store := newFakeStore()
+		store.recipients["p1"] = &Recipient{UserID: "p1", Name: "Mei", Email: "mei@example.com", Locale: c.locale}
+		sender := &fakeSender{}
+		svc := NewService(store, fakeOrders{"o1": completedOrder()}, sender)
+
+		if err := svc.SendReceipt(context.Background(), "o1"); err != nil {
+			t.Fatalf("%s: SendReceipt: %v", c.locale, err)
+		}
+		if len(sender.sent) != 1 {
+			t.Fatalf("%s: sent %d emails", c.locale, len(sender.sent))
+		}
+		e := sender.sent[0]
+		if e.To != "mei@example.com" || e.Subject != c.subject {
+			t.Errorf("%s: email = %q / %q", c.locale, e.To, e.Subject)
+		}
+		if !strings.Contains(e.Body, c.total) || !strings.Contains(e.Body, "18:30") {
+			t.Errorf("%s: body missing fare or local time:\n%s", c.locale, e.Body)
+		}
+	}
+}
+
+func TestSendReceipt_Credits(t *testing.T) {
+	store := newFakeStore()
+	store.recipients["p1"] = &Recipient{UserID: "p1", Email: "p@example.com", Locale: "en"}
+	sender := &fakeSender{}
+	o := completedOrder()
+	o.CreditsApplied = 5000
+	svc := NewService(store, fakeOrders{"o1": o}, sender)
+
+	if err := svc.SendReceipt(context.Background(), "o1"); err != nil {
+		t.Fatalf("SendReceipt: %v", err)
+	}
+	body := sender.sent[0].Body
+	if !strings.Contains(body, "-TWD 50") || !strings.Contains(body, "TWD 130") {
+		t.Errorf("body missing credits or amount paid:\n%s", body)
+	}
+}
+
+func TestSendReceipt_Idempotent(t *testing.T) {
+	store := newFakeStore()
+	store.recipients["p1"] = &Recipient{UserID: "p1", Email: "p@example.com", Locale: "en"}
+	sender := &fakeSender{}
+	svc := NewService(store, fakeOrders{"o1": completedOrder()}, sender)
+
+	for i := 0; i < 2; i++ {
+		if err := svc.SendReceipt(context.Background(), "o1"); err != nil {
+			t.Fatalf("SendReceipt %d: %v", i, err)
+		}
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(sender.sent))
+	}
+}
+
+func TestSendReceipt_FailureCanRetry(t *testing.T) {
+	store := newFakeStore()
+	store.recipients["p1"] = &Recipient{UserID: "p1", Email: "p@example.com", Locale: "en"}
+	sender := &fakeSender{err: errors.New("smtp down")}
+	svc := NewService(store, fakeOrders{"o1": completedOrder()}, sender)
+
+	if err := svc.SendReceipt(context.Background(), "o1"); err == nil {
+		t.Fatal("expected send error")
+	}
+	sender.err = nil
+	if err := svc.SendReceipt(context.Background(), "o1"); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d emails after retry, want 1", len(sender.sent))
+	}
+}
+
+func TestSendReceipt_RejectsIncompleteAndNoEmail(t *testing.T) {
+	store := newFakeStore()
+	store.recipients["p1"] = &Recipient{UserID: "p1", Locale: "en"}
+	o := completedOrder()
+	svc := NewService(store, fakeOrders{"o1": o}, &fakeSender{})
+
+	if err := svc.SendReceipt(context.Background(), "o1"); !errors.Is(err, ErrNoEmail) {
+		t.Fatalf("err = %v, want ErrNoEmail", err)
+	}
+	o.Status = order.StatusDriving
+	if err := svc.SendReceipt(context.Background(), "o1"); err == nil {
+		t.Fatal("expected error for incomplete order")
+	}
+}
+
+func TestSendMonthlySummaries(t *testing.T) {
+	store := newFakeStore()
+	store.recipients["p1"] = &Recipient{UserID: "p1", Name: "Mei", Email: "p1@example.com", Locale: "zh-TW"}
+	store.recipients["p2"] = &Recipient{UserID: "p2", Name: "Sam", Email: "p2@example.com", Locale: "en"}
+	store.rollup = []MonthlySummary{
+		{UserID: "p1", Trips: 3, TotalFare: types.Money{Amount: 45000, Currency: "TWD"}},
+		{UserID: "p2", Trips: 1, TotalFare: types.Money{Amount: 15000, Currency: "TWD"}},
+	}
+	sender := &fakeSender{}
+	svc := NewService(store, fakeOrders{}, sender)
+
+	month := time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC)
+	n, err := svc.SendMonthlySummaries(context.Background(), month)
+	if err != nil || n != 2 {
+		t.Fatalf("SendMonthlySummaries = %d, %v", n, err)
+	}
+	if want := time.Date(2026, 2, 1, 0, 0, 0, 0, taipei); !store.rollupFrom.Equal(want) {
+		t.Errorf("rollup from = %s, want %s", store.rollupFrom, want)
+	}
+	if sender.sent[0].Subject != "Ark 2026 年 2 月 乘車月報" || !strings.Contains(sender.sent[0].Body, "NT$450") {
+		t.Errorf("zh-TW summary = %q\n%s", sender.sent[0].Subject, sender.sent[0].Body)
+	}
+	if sender.sent[1].Subject != "Your Ark trips in February 2026" {
+		t.Errorf("en summary subject = %q", sender.sent[1].Subject)
+	}
+
+	// A second run for the same month sends nothing.
+	if n, _ := svc.SendMonthlySummaries(context.Background(), month); n != 0 {
+		t.Fatalf("second run sent %d", n)
+	}
+}
+
+func TestRender_UnknownLocaleFallsBack(t *testing.T) {
+	subject, _, err := render("summary", "fr", summaryData{Month: time.Date(2026, 1, 1, 0, 0, 0, 0, taipei)})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(subject, "月報") {
+		t.Errorf("subject = %q, want zh-TW fallback", subject)
+	}
+}
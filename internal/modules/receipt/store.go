@@ -0,0 +1,97 @@
+// README: Receipt persistence — recipient lookup, monthly trip rollup, and the idempotent email send log.
+package receipt
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// ReceiptStore is the persistence interface for receipts and summaries.
+type ReceiptStore interface {
+	// GetRecipient returns the user's email address and locale.
+	GetRecipient(ctx context.Context, userID types.ID) (*Recipient, error)
+	// MonthlyRollup aggregates completed trips in [from, to) per currency for
+	// passengers who opted into the monthly summary, ordered by passenger.
+	MonthlyRollup(ctx context.Context, from, to time.Time) ([]MonthlySummary, error)
+	// ClaimSend records an email as sent and reports false if it already was.
+	ClaimSend(ctx context.Context, kind string, userID types.ID, ref string) (bool, error)
+	// ReleaseSend removes a claim so a failed send can be retried.
+	ReleaseSend(ctx context.Context, kind string, userID types.ID, ref string) error
+}
+
+// Store is the Postgres implementation of ReceiptStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetRecipient(ctx context.Context, userID types.ID) (*Recipient, error) {
+	r := Recipient{UserID: userID}
+	err := s.db.QueryRow(ctx, `
+		SELECT u.name, u.email,
+		       COALESCE(p.locale, CASE WHEN u.preferred_language IN ('', 'zh-TW') THEN 'zh-TW' ELSE 'en' END)
+		FROM users u
+		LEFT JOIN notification_preferences p ON p.user_id = u.user_id
+		WHERE u.user_id = $1
+	`, string(userID)).Scan(&r.Name, &r.Email, &r.Locale)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *Store) MonthlyRollup(ctx context.Context, from, to time.Time) ([]MonthlySummary, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT o.passenger_id, o.currency, COUNT(*), COALESCE(SUM(COALESCE(o.actual_fee, o.estimated_fee)), 0)
+		FROM orders o
+		JOIN notification_preferences p ON p.user_id = o.passenger_id AND p.monthly_summary
+		WHERE o.status = 'complete' AND o.completed_at >= $1 AND o.completed_at < $2
+		GROUP BY o.passenger_id, o.currency
+		ORDER BY o.passenger_id, o.currency
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MonthlySummary
+	for rows.Next() {
+		m := MonthlySummary{Month: from}
+		var uid string
+		if err := rows.Scan(&uid, &m.TotalFare.Currency, &m.Trips, &m.TotalFare.Amount); err != nil {
+			return nil, err
+		}
+		m.UserID = types.ID(uid)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ClaimSend(ctx context.Context, kind string, userID types.ID, ref string) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO email_log (kind, user_id, ref) VALUES ($1, $2, $3)
+		ON CONFLICT (kind, user_id, ref) DO NOTHING
+	`, kind, string(userID), ref)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) ReleaseSend(ctx context.Context, kind string, userID types.ID, ref string) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM email_log WHERE kind = $1 AND user_id = $2 AND ref = $3`,
+		kind, string(userID), ref)
+	return err
+}
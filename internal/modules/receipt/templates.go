@@ -0,0 +1,97 @@
+// README: Localized (zh-TW / en) email templates for receipts and monthly summaries.
+package receipt
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"ark/internal/types"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+const (
+	localeZhTW    = "zh-TW"
+	localeEn      = "en"
+	defaultLocale = localeZhTW
+)
+
+var taipei = loadTaipei()
+
+func loadTaipei() *time.Location {
+	loc, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return loc
+}
+
+// templates maps "<kind>.<locale>" to its parsed template.
+var templates = parseTemplates()
+
+func parseTemplates() map[string]*template.Template {
+	out := make(map[string]*template.Template)
+	for _, kind := range []string{"receipt", "summary"} {
+		for _, locale := range []string{localeZhTW, localeEn} {
+			name := kind + "." + locale
+			t := template.Must(template.New(name).Funcs(funcsFor(locale)).ParseFS(templateFS, "templates/"+name+".tmpl"))
+			out[name] = t
+		}
+	}
+	return out
+}
+
+func funcsFor(locale string) template.FuncMap {
+	zh := locale == localeZhTW
+	return template.FuncMap{
+		"money": func(m types.Money) string {
+			if zh && (m.Currency == types.DefaultCurrency || m.Currency == "") {
+				return fmt.Sprintf("NT$%d", m.Major())
+			}
+			return fmt.Sprintf("%s %d", m.Currency, m.Major())
+		},
+		"date": func(t time.Time) string {
+			if zh {
+				return t.In(taipei).Format("2006/01/02")
+			}
+			return t.In(taipei).Format("Jan 2, 2006")
+		},
+		"datetime": func(t time.Time) string {
+			if zh {
+				return t.In(taipei).Format("2006/01/02 15:04")
+			}
+			return t.In(taipei).Format("Jan 2, 2006 15:04")
+		},
+		"month": func(t time.Time) string {
+			if zh {
+				return t.In(taipei).Format("2006 年 1 月")
+			}
+			return t.In(taipei).Format("January 2006")
+		},
+		"coord": func(p types.Point) string {
+			return fmt.Sprintf("%.5f, %.5f", p.Lat, p.Lng)
+		},
+	}
+}
+
+// render executes the subject and body of kind in locale, falling back to the
+// default locale for unknown values.
+func render(kind, locale string, data any) (subject, body string, err error) {
+	t, ok := templates[kind+"."+locale]
+	if !ok {
+		t = templates[kind+"."+defaultLocale]
+	}
+	var sb, bb bytes.Buffer
+	if err := t.ExecuteTemplate(&sb, "subject", data); err != nil {
+		return "", "", fmt.Errorf("render %s subject: %w", kind, err)
+	}
+	if err := t.ExecuteTemplate(&bb, "body", data); err != nil {
+		return "", "", fmt.Errorf("render %s body: %w", kind, err)
+	}
+	return strings.TrimSpace(sb.String()), strings.TrimLeft(bb.String(), "\n"), nil
+}
@@ -0,0 +1,115 @@
+// README: Referral HTTP handlers — the caller's referral code, redeeming a friend's code, and the ride-credit balance.
+//
+// Endpoints:
+//
+//	GET  /api/referrals/code    — the caller's referral code, created on first request
+//	POST /api/referrals/redeem  — redeem a friend's code ({"code", "device_id"}) before the first ride
+//	GET  /api/credits           — credit balance and recent ledger entries
+//
+// Auth: all routes require the Auth middleware to set user_id in context.
+package referral
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the referral HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type redeemReq struct {
+	Code     string `json:"code"`
+	DeviceID string `json:"device_id"`
+}
+
+type entryResp struct {
+	Amount    int64  `json:"amount"`
+	Kind      string `json:"kind"`
+	Ref       string `json:"ref"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// MyCode handles GET /api/referrals/code.
+func (h *Handler) MyCode(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	code, err := h.svc.MyCode(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"code": code})
+}
+
+// Redeem handles POST /api/referrals/redeem.
+func (h *Handler) Redeem(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req redeemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	r, err := h.svc.Apply(c.Request.Context(), types.ID(uid), req.Code, req.DeviceID)
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, map[string]any{"code": r.Code, "status": r.Status})
+}
+
+// Credits handles GET /api/credits.
+func (h *Handler) Credits(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	bal, entries, err := h.svc.Balance(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+	out := make([]entryResp, len(entries))
+	for i, e := range entries {
+		out[i] = entryResp{Amount: e.Amount, Kind: e.Kind, Ref: e.Ref, CreatedAt: e.CreatedAt.Unix()}
+	}
+	c.JSON(http.StatusOK, map[string]any{"balance": bal, "currency": types.DefaultCurrency, "entries": out})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeReferralError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrIneligible):
+		writeError(c, http.StatusForbidden, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
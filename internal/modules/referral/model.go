@@ -0,0 +1,67 @@
+// README: Referral domain model — referral codes, referrals and the ride-credit ledger.
+package referral
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Referral statuses: a pending referral is rewarded when the referee
+// completes their first ride, or rejected when a fraud guard trips first.
+const (
+	StatusPending  = "pending"
+	StatusRewarded = "rewarded"
+	StatusRejected = "rejected"
+)
+
+// Ledger entry kinds.
+const (
+	KindReferrer = "referral_referrer"
+	KindReferee  = "referral_referee"
+	KindRide     = "ride"
+)
+
+// Referral links a new rider to the user whose code they redeemed.
+type Referral struct {
+	RefereeID  types.ID
+	ReferrerID types.ID
+	Code       string
+	// DeviceID and IdentityHash (a digest of the referee's phone) may each
+	// back only one referral.
+	DeviceID     string
+	IdentityHash string
+	Status       string
+	Reason       string
+	CreatedAt    time.Time
+	RewardedAt   *time.Time
+}
+
+// Entry is one line of a user's credit ledger: grants are positive,
+// redemptions negative. Amounts are TWD minor units.
+type Entry struct {
+	Amount    int64
+	Kind      string
+	Ref       string
+	CreatedAt time.Time
+}
+
+// Rewards configures the credits granted per referral.
+type Rewards struct {
+	ReferrerCredit int64
+	RefereeCredit  int64
+	// MaxPerReferrer caps the referrals one user can be rewarded for; 0 means no cap.
+	MaxPerReferrer int
+}
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrBadRequest = errors.New("bad request")
+	// ErrIneligible rejects a code the caller may not redeem: their own code,
+	// an account that already rides, or an account without a phone number.
+	ErrIneligible = errors.New("not eligible for referral")
+	// ErrConflict is returned when the account, device or phone number has
+	// already been used for a referral.
+	ErrConflict = errors.New("referral already used")
+)
@@ -0,0 +1,15 @@
+// README: Referral route registration — mounts the referral and credit endpoints onto the given router group.
+package referral
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the referral endpoints onto the provided authenticated router group.
+//
+//	GET  /api/referrals/code
+//	POST /api/referrals/redeem
+//	GET  /api/credits
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/referrals/code", h.MyCode)
+	rg.POST("/api/referrals/redeem", h.Redeem)
+	rg.GET("/api/credits", h.Credits)
+}
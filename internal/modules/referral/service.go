@@ -0,0 +1,231 @@
+// README: Referral service — referral codes, fraud-guarded redemption, rewards on a referee's first ride, and ride credits.
+package referral
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// codeAlphabet leaves out 0/O and 1/I/L so codes survive being read aloud.
+const codeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+const (
+	codeLen = 8
+	// codeAttempts bounds retries after a generated code collides.
+	codeAttempts = 5
+	// rewardTimeout bounds rewarding one referral from an order transition.
+	rewardTimeout = 15 * time.Second
+	// maxEntries caps the ledger lines returned with a balance.
+	maxEntries = 50
+	// maxDeviceAccounts is the most other accounts a device may have been
+	// used by before referrals from it are refused as account farming.
+	maxDeviceAccounts = 1
+)
+
+// Service issues referral codes and manages ride credits.
+type Service struct {
+	store   ReferralStore
+	rewards Rewards
+	now     func() time.Time
+}
+
+// NewService creates a Service granting the given rewards.
+func NewService(store ReferralStore, rewards Rewards) *Service {
+	return &Service{store: store, rewards: rewards, now: time.Now}
+}
+
+// ---------------------------------------------------------------------------
+// Codes and referrals
+// ---------------------------------------------------------------------------
+
+// MyCode returns userID's referral code, creating it on first use.
+func (s *Service) MyCode(ctx context.Context, userID types.ID) (string, error) {
+	if userID == "" {
+		return "", ErrBadRequest
+	}
+	for range codeAttempts {
+		code, err := s.store.GetCode(ctx, userID)
+		if !errors.Is(err, ErrNotFound) {
+			return code, err
+		}
+		// ErrConflict means the code collided or a concurrent call created
+		// the user's code; either way the next lookup or attempt settles it.
+		if err := s.store.InsertCode(ctx, userID, newCode(), s.now()); err != nil && !errors.Is(err, ErrConflict) {
+			return "", err
+		}
+	}
+	return "", ErrConflict
+}
+
+func newCode() string {
+	b := make([]byte, codeLen)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
+
+// Apply redeems code for refereeID, who installs the app on deviceID. Fraud
+// guards: no self-referral (by account, phone or the referrer's own device),
+// only accounts that have never ridden, no device shared by several other
+// accounts, and each account, device and phone number backs at most one
+// referral. Rewards follow the referee's first ride.
+func (s *Service) Apply(ctx context.Context, refereeID types.ID, code, deviceID string) (*Referral, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	deviceID = strings.TrimSpace(deviceID)
+	if refereeID == "" || code == "" || deviceID == "" || len(deviceID) > 128 {
+		return nil, ErrBadRequest
+	}
+	referrerID, err := s.store.OwnerOfCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if referrerID == refereeID {
+		return nil, ErrIneligible
+	}
+	if _, err := s.store.GetReferral(ctx, refereeID); err == nil {
+		return nil, ErrConflict
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	rides, err := s.store.RideCount(ctx, refereeID)
+	if err != nil {
+		return nil, err
+	}
+	if rides > 0 {
+		return nil, ErrIneligible
+	}
+	identity, err := s.store.IdentityHash(ctx, refereeID)
+	if err != nil {
+		return nil, err
+	}
+	if identity == "" {
+		return nil, ErrIneligible
+	}
+	referrerIdentity, err := s.store.IdentityHash(ctx, referrerID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if referrerIdentity == identity {
+		return nil, ErrIneligible
+	}
+	shared, err := s.store.DeviceOwnedBy(ctx, deviceID, referrerID)
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		return nil, ErrIneligible
+	}
+	others, err := s.store.DeviceAccounts(ctx, deviceID, refereeID)
+	if err != nil {
+		return nil, err
+	}
+	if others > maxDeviceAccounts {
+		return nil, ErrIneligible
+	}
+
+	r := &Referral{
+		RefereeID:    refereeID,
+		ReferrerID:   referrerID,
+		Code:         code,
+		DeviceID:     deviceID,
+		IdentityHash: identity,
+		Status:       StatusPending,
+		CreatedAt:    s.now(),
+	}
+	if err := s.store.CreateReferral(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// OrderHook rewards a pending referral when the referee completes a ride.
+// Rides the referrer drove themselves are rejected rather than rewarded.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusComplete || t.Sandbox {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rewardTimeout)
+			defer cancel()
+			if err := s.reward(ctx, t); err != nil {
+				log.Printf("referral: reward for order %s: %v", t.OrderID, err)
+			}
+		}()
+	}
+}
+
+func (s *Service) reward(ctx context.Context, t order.Transition) error {
+	r, err := s.store.GetReferral(ctx, t.PassengerID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if r.Status != StatusPending {
+		return nil
+	}
+	if t.DriverID != nil && *t.DriverID == r.ReferrerID {
+		return s.store.Reject(ctx, r.RefereeID, "referrer_drove")
+	}
+	if s.rewards.MaxPerReferrer > 0 {
+		n, err := s.store.CountRewarded(ctx, r.ReferrerID)
+		if err != nil {
+			return err
+		}
+		if n >= s.rewards.MaxPerReferrer {
+			return s.store.Reject(ctx, r.RefereeID, "referrer_cap")
+		}
+	}
+	_, err = s.store.Reward(ctx, r, s.rewards, s.now())
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// Credits
+// ---------------------------------------------------------------------------
+
+// Balance returns userID's credit balance and their latest ledger entries.
+func (s *Service) Balance(ctx context.Context, userID types.ID) (int64, []Entry, error) {
+	if userID == "" {
+		return 0, nil, ErrBadRequest
+	}
+	bal, err := s.store.Balance(ctx, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+	entries, err := s.store.ListEntries(ctx, userID, maxEntries)
+	if err != nil {
+		return 0, nil, err
+	}
+	return bal, entries, nil
+}
+
+// Grant credits amount (TWD minor units) to userID for kind and ref, e.g. a
+// missed arrival guarantee. Repeating a grant is a no-op that reports false.
+func (s *Service) Grant(ctx context.Context, userID types.ID, amount int64, kind, ref string) (bool, error) {
+	if userID == "" || amount <= 0 || kind == "" || ref == "" {
+		return false, ErrBadRequest
+	}
+	return s.store.Grant(ctx, userID, amount, kind, ref, s.now())
+}
+
+// Redeem implements order.Credits: it spends up to the fare from the
+// passenger's balance on the order.
+func (s *Service) Redeem(ctx context.Context, passengerID, orderID types.ID, fare types.Money) (int64, error) {
+	// Credits are held in the platform currency and never converted.
+	if fare.Amount <= 0 || fare.Currency != types.DefaultCurrency {
+		return 0, nil
+	}
+	return s.store.Redeem(ctx, passengerID, orderID, fare.Amount, s.now())
+}
@@ -0,0 +1,366 @@
+package referral
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// ---------------------------------------------------------------------------
+// In-memory fake
+// ---------------------------------------------------------------------------
+
+type mockStore struct {
+	mu         sync.Mutex
+	codes      map[types.ID]string
+	identities map[types.ID]string
+	rides      map[types.ID]int
+	devices    map[string]types.ID   // device_id -> push-registered user
+	installs   map[string][]types.ID // device_id -> accounts seen on the install
+	referrals  map[types.ID]*Referral
+	ledger     map[types.ID][]Entry
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{
+		codes:      make(map[types.ID]string),
+		identities: make(map[types.ID]string),
+		rides:      make(map[types.ID]int),
+		devices:    make(map[string]types.ID),
+		installs:   make(map[string][]types.ID),
+		referrals:  make(map[types.ID]*Referral),
+		ledger:     make(map[types.ID][]Entry),
+	}
+}
+
+func (m *mockStore) GetCode(_ context.Context, userID types.ID) (string, error) {
+	code, ok := m.codes[userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return code, nil
+}
+
+func (m *mockStore) InsertCode(_ context.Context, userID types.ID, code string, _ time.Time) error {
+	if _, ok := m.codes[userID]; ok {
+		return ErrConflict
+	}
+	for _, c := range m.codes {
+		if c == code {
+			return ErrConflict
+		}
+	}
+	m.codes[userID] = code
+	return nil
+}
+
+func (m *mockStore) OwnerOfCode(_ context.Context, code string) (types.ID, error) {
+	for uid, c := range m.codes {
+		if c == code {
+			return uid, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (m *mockStore) IdentityHash(_ context.Context, userID types.ID) (string, error) {
+	h, ok := m.identities[userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return h, nil
+}
+
+func (m *mockStore) RideCount(_ context.Context, passengerID types.ID) (int, error) {
+	return m.rides[passengerID], nil
+}
+
+func (m *mockStore) DeviceOwnedBy(_ context.Context, deviceID string, userID types.ID) (bool, error) {
+	return m.devices[deviceID] == userID || slices.Contains(m.installs[deviceID], userID), nil
+}
+
+func (m *mockStore) DeviceAccounts(_ context.Context, deviceID string, userID types.ID) (int, error) {
+	seen := map[types.ID]bool{}
+	if u, ok := m.devices[deviceID]; ok {
+		seen[u] = true
+	}
+	for _, u := range m.installs[deviceID] {
+		seen[u] = true
+	}
+	delete(seen, userID)
+	return len(seen), nil
+}
+
+func (m *mockStore) CreateReferral(_ context.Context, r *Referral) error {
+	for _, o := range m.referrals {
+		if o.RefereeID == r.RefereeID || o.DeviceID == r.DeviceID || o.IdentityHash == r.IdentityHash {
+			return ErrConflict
+		}
+	}
+	cp := *r
+	m.referrals[r.RefereeID] = &cp
+	return nil
+}
+
+func (m *mockStore) GetReferral(_ context.Context, refereeID types.ID) (*Referral, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.referrals[refereeID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *r
+	return &cp, nil
+}
+
+func (m *mockStore) CountRewarded(_ context.Context, referrerID types.ID) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, r := range m.referrals {
+		if r.ReferrerID == referrerID && r.Status == StatusRewarded {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockStore) Reward(_ context.Context, r *Referral, rewards Rewards, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur := m.referrals[r.RefereeID]
+	if cur == nil || cur.Status != StatusPending {
+		return false, nil
+	}
+	cur.Status = StatusRewarded
+	cur.RewardedAt = &at
+	m.ledger[r.ReferrerID] = append(m.ledger[r.ReferrerID], Entry{Amount: rewards.ReferrerCredit, Kind: KindReferrer, Ref: string(r.RefereeID), CreatedAt: at})
+	m.ledger[r.RefereeID] = append(m.ledger[r.RefereeID], Entry{Amount: rewards.RefereeCredit, Kind: KindReferee, Ref: string(r.RefereeID), CreatedAt: at})
+	return true, nil
+}
+
+func (m *mockStore) Reject(_ context.Context, refereeID types.ID, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r := m.referrals[refereeID]; r != nil && r.Status == StatusPending {
+		r.Status, r.Reason = StatusRejected, reason
+	}
+	return nil
+}
+
+func (m *mockStore) Balance(_ context.Context, userID types.ID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var bal int64
+	for _, e := range m.ledger[userID] {
+		bal += e.Amount
+	}
+	return bal, nil
+}
+
+func (m *mockStore) Grant(_ context.Context, userID types.ID, amount int64, kind, ref string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.ledger[userID] {
+		if e.Kind == kind && e.Ref == ref {
+			return false, nil
+		}
+	}
+	m.ledger[userID] = append(m.ledger[userID], Entry{Amount: amount, Kind: kind, Ref: ref, CreatedAt: at})
+	return true, nil
+}
+
+func (m *mockStore) Redeem(ctx context.Context, userID, orderID types.ID, limit int64, at time.Time) (int64, error) {
+	for _, e := range m.ledger[userID] {
+		if e.Kind == KindRide && e.Ref == string(orderID) {
+			return -e.Amount, nil
+		}
+	}
+	bal, _ := m.Balance(ctx, userID)
+	amount := min(bal, limit)
+	if amount <= 0 {
+		return 0, nil
+	}
+	m.ledger[userID] = append(m.ledger[userID], Entry{Amount: -amount, Kind: KindRide, Ref: string(orderID), CreatedAt: at})
+	return amount, nil
+}
+
+func (m *mockStore) ListEntries(_ context.Context, userID types.ID, limit int) ([]Entry, error) {
+	es := m.ledger[userID]
+	return es[:min(len(es), limit)], nil
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+var testRewards = Rewards{ReferrerCredit: 10000, RefereeCredit: 5000, MaxPerReferrer: 2}
+
+// newTestSvc returns a service where "alice" owns a code and "bob" and
+// "carol" are new riders with distinct phones.
+func newTestSvc(t *testing.T) (*Service, *mockStore, string) {
+	t.Helper()
+	store := newMockStore()
+	store.identities["alice"] = "h-alice"
+	store.identities["bob"] = "h-bob"
+	store.identities["carol"] = "h-carol"
+	store.devices["dev-alice"] = "alice"
+	svc := NewService(store, testRewards)
+	code, err := svc.MyCode(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("MyCode: %v", err)
+	}
+	return svc, store, code
+}
+
+// complete runs the order hook for a completed ride and waits for the reward.
+func complete(t *testing.T, svc *Service, passenger types.ID, driver types.ID) {
+	t.Helper()
+	if err := svc.reward(context.Background(), order.Transition{OrderID: "o-" + passenger, PassengerID: passenger, DriverID: &driver, To: order.StatusComplete}); err != nil {
+		t.Fatalf("reward: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestMyCode_Stable(t *testing.T) {
+	svc, _, code := newTestSvc(t)
+	if len(code) != codeLen {
+		t.Errorf("code %q: want %d characters", code, codeLen)
+	}
+	again, err := svc.MyCode(context.Background(), "alice")
+	if err != nil || again != code {
+		t.Errorf("second MyCode = %q, %v; want %q", again, err, code)
+	}
+}
+
+func TestApply_FraudGuards(t *testing.T) {
+	svc, store, code := newTestSvc(t)
+	ctx := context.Background()
+	store.identities["dave"] = "h-alice" // alice's phone on a second account
+	store.identities["erin"] = ""
+	store.identities["frank"] = "h-frank"
+	store.rides["frank"] = 1
+	store.installs["dev-farm"] = []types.ID{"u1", "u2"}
+
+	cases := []struct {
+		name    string
+		referee types.ID
+		code    string
+		device  string
+		want    error
+	}{
+		{"unknown code", "bob", "NOPE2345", "dev-bob", ErrNotFound},
+		{"missing device", "bob", code, "", ErrBadRequest},
+		{"own code", "alice", code, "dev-x", ErrIneligible},
+		{"referrer's phone", "dave", code, "dev-dave", ErrIneligible},
+		{"no phone", "erin", code, "dev-erin", ErrIneligible},
+		{"already rides", "frank", code, "dev-frank", ErrIneligible},
+		{"referrer's device", "bob", code, "dev-alice", ErrIneligible},
+		{"farmed device", "bob", code, "dev-farm", ErrIneligible},
+	}
+	for _, c := range cases {
+		if _, err := svc.Apply(ctx, c.referee, c.code, c.device); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	if _, err := svc.Apply(ctx, "bob", " "+code+" ", "dev-bob"); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if _, err := svc.Apply(ctx, "bob", code, "dev-bob-2"); !errors.Is(err, ErrConflict) {
+		t.Errorf("second referral for bob: got %v, want ErrConflict", err)
+	}
+	if _, err := svc.Apply(ctx, "carol", code, "dev-bob"); !errors.Is(err, ErrConflict) {
+		t.Errorf("reused device: got %v, want ErrConflict", err)
+	}
+}
+
+func TestReward_FirstRideCreditsBoth(t *testing.T) {
+	svc, store, code := newTestSvc(t)
+	ctx := context.Background()
+	if _, err := svc.Apply(ctx, "bob", code, "dev-bob"); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	complete(t, svc, "bob", "drv-1")
+	complete(t, svc, "bob", "drv-1") // a second ride grants nothing more
+
+	if bal, _, _ := svc.Balance(ctx, "alice"); bal != testRewards.ReferrerCredit {
+		t.Errorf("referrer balance = %d, want %d", bal, testRewards.ReferrerCredit)
+	}
+	if bal, _, _ := svc.Balance(ctx, "bob"); bal != testRewards.RefereeCredit {
+		t.Errorf("referee balance = %d, want %d", bal, testRewards.RefereeCredit)
+	}
+	if r := store.referrals["bob"]; r.Status != StatusRewarded || r.RewardedAt == nil {
+		t.Errorf("referral = %+v, want rewarded", r)
+	}
+}
+
+func TestReward_RejectsSelfDrivenAndOverCap(t *testing.T) {
+	svc, store, code := newTestSvc(t)
+	ctx := context.Background()
+	if _, err := svc.Apply(ctx, "bob", code, "dev-bob"); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	complete(t, svc, "bob", "alice")
+	if r := store.referrals["bob"]; r.Status != StatusRejected || r.Reason != "referrer_drove" {
+		t.Errorf("self-driven referral = %+v, want rejected", r)
+	}
+
+	// Two prior rewards exhaust alice's cap.
+	store.referrals["x1"] = &Referral{RefereeID: "x1", ReferrerID: "alice", Status: StatusRewarded}
+	store.referrals["x2"] = &Referral{RefereeID: "x2", ReferrerID: "alice", Status: StatusRewarded}
+	if _, err := svc.Apply(ctx, "carol", code, "dev-carol"); err != nil {
+		t.Fatalf("Apply carol: %v", err)
+	}
+	complete(t, svc, "carol", "drv-1")
+	if r := store.referrals["carol"]; r.Status != StatusRejected || r.Reason != "referrer_cap" {
+		t.Errorf("over-cap referral = %+v, want rejected", r)
+	}
+	if bal, _, _ := svc.Balance(ctx, "alice"); bal != 0 {
+		t.Errorf("referrer balance = %d, want 0", bal)
+	}
+}
+
+func TestRedeem_CapsAtFareAndIsIdempotent(t *testing.T) {
+	svc, store, _ := newTestSvc(t)
+	ctx := context.Background()
+	store.ledger["bob"] = []Entry{{Amount: 12000, Kind: KindReferee, Ref: "bob"}}
+	fare := types.Money{Amount: 8000, Currency: "TWD"}
+
+	for i := 0; i < 2; i++ {
+		n, err := svc.Redeem(ctx, "bob", "o1", fare)
+		if err != nil || n != 8000 {
+			t.Fatalf("Redeem %d = %d, %v; want 8000", i, n, err)
+		}
+	}
+	n, err := svc.Redeem(ctx, "bob", "o2", fare)
+	if err != nil || n != 4000 {
+		t.Errorf("Redeem o2 = %d, %v; want the remaining 4000", n, err)
+	}
+	if bal, _, _ := svc.Balance(ctx, "bob"); bal != 0 {
+		t.Errorf("balance = %d, want 0", bal)
+	}
+}
+
+func TestRedeem_ForeignCurrencyFare(t *testing.T) {
+	svc, store, _ := newTestSvc(t)
+	ctx := context.Background()
+	store.ledger["bob"] = []Entry{{Amount: 12000, Kind: KindReferee, Ref: "bob"}}
+
+	n, err := svc.Redeem(ctx, "bob", "o1", types.Money{Amount: 2000, Currency: "USD"})
+	if err != nil || n != 0 {
+		t.Fatalf("Redeem = %d, %v; want 0", n, err)
+	}
+	if bal, _, _ := svc.Balance(ctx, "bob"); bal != 12000 {
+		t.Errorf("balance = %d, want 12000", bal)
+	}
+}
@@ -0,0 +1,362 @@
+// README: Referral store — PostgreSQL persistence for referral codes, referrals and the credit ledger.
+package referral
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// ReferralStore defines the persistence operations required by the referral Service.
+type ReferralStore interface {
+	GetCode(ctx context.Context, userID types.ID) (string, error)
+	// InsertCode returns ErrConflict when the user already has a code or the code is taken.
+	InsertCode(ctx context.Context, userID types.ID, code string, at time.Time) error
+	OwnerOfCode(ctx context.Context, code string) (types.ID, error)
+
+	// IdentityHash returns a digest of the user's phone number, "" when they
+	// have none, or ErrNotFound for an unknown user.
+	IdentityHash(ctx context.Context, userID types.ID) (string, error)
+	// RideCount counts the passenger's orders that reached payment or completion.
+	RideCount(ctx context.Context, passengerID types.ID) (int, error)
+	// DeviceOwnedBy reports whether deviceID has been registered for push by
+	// userID or seen as userID's app install.
+	DeviceOwnedBy(ctx context.Context, deviceID string, userID types.ID) (bool, error)
+	// DeviceAccounts counts the accounts other than userID that deviceID has
+	// been registered for or seen with.
+	DeviceAccounts(ctx context.Context, deviceID string, userID types.ID) (int, error)
+
+	// CreateReferral returns ErrConflict when the referee, device or identity
+	// already backs a referral.
+	CreateReferral(ctx context.Context, r *Referral) error
+	GetReferral(ctx context.Context, refereeID types.ID) (*Referral, error)
+	CountRewarded(ctx context.Context, referrerID types.ID) (int, error)
+	// Reward marks a pending referral rewarded and grants both credits, booked
+	// in the ledger, in one transaction. It reports false when the referral was no longer pending.
+	Reward(ctx context.Context, r *Referral, rewards Rewards, at time.Time) (bool, error)
+	Reject(ctx context.Context, refereeID types.ID, reason string) error
+
+	Balance(ctx context.Context, userID types.ID) (int64, error)
+	// Grant credits amount to the user, booked in the ledger, once per kind
+	// and ref. It reports false when that grant was already made.
+	Grant(ctx context.Context, userID types.ID, amount int64, kind, ref string, at time.Time) (bool, error)
+	// Redeem spends up to limit of the user's balance on orderID. Repeating it
+	// for the same order returns the amount first spent.
+	Redeem(ctx context.Context, userID, orderID types.ID, limit int64, at time.Time) (int64, error)
+	ListEntries(ctx context.Context, userID types.ID, limit int) ([]Entry, error)
+}
+
+// Store is the PostgreSQL implementation of ReferralStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// ---------------------------------------------------------------------------
+// Codes
+// ---------------------------------------------------------------------------
+
+func (s *Store) GetCode(ctx context.Context, userID types.ID) (string, error) {
+	var code string
+	err := s.db.QueryRow(ctx, `SELECT code FROM referral_codes WHERE user_id = $1`, string(userID)).Scan(&code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return code, err
+}
+
+func (s *Store) InsertCode(ctx context.Context, userID types.ID, code string, at time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO referral_codes (user_id, code, created_at) VALUES ($1, $2, $3)`,
+		string(userID), code, at,
+	)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *Store) OwnerOfCode(ctx context.Context, code string) (types.ID, error) {
+	var uid string
+	err := s.db.QueryRow(ctx, `SELECT user_id FROM referral_codes WHERE code = $1`, code).Scan(&uid)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return types.ID(uid), err
+}
+
+// ---------------------------------------------------------------------------
+// Fraud-guard lookups
+// ---------------------------------------------------------------------------
+
+// IdentityHash digests phone_hash when PII encryption is on (phone itself is
+// then randomised ciphertext) and the plaintext phone otherwise.
+func (s *Store) IdentityHash(ctx context.Context, userID types.ID) (string, error) {
+	var h string
+	err := s.db.QueryRow(ctx, `
+		SELECT CASE WHEN COALESCE(NULLIF(phone_hash, ''), phone) = '' THEN ''
+		            ELSE encode(sha256(convert_to(COALESCE(NULLIF(phone_hash, ''), phone), 'UTF8')), 'hex')
+		       END
+		FROM users WHERE user_id = $1`, string(userID),
+	).Scan(&h)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return h, err
+}
+
+func (s *Store) RideCount(ctx context.Context, passengerID types.ID) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM orders
+		WHERE passenger_id = $1 AND status IN ('payment', 'complete') AND NOT sandbox`,
+		string(passengerID),
+	).Scan(&n)
+	return n, err
+}
+
+func (s *Store) DeviceOwnedBy(ctx context.Context, deviceID string, userID types.ID) (bool, error) {
+	var ok bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM user_fcm_tokens WHERE device_id = $1 AND user_id = $2)
+		    OR EXISTS (SELECT 1 FROM devices WHERE install_id = $1 AND user_id = $2)`,
+		deviceID, string(userID),
+	).Scan(&ok)
+	return ok, err
+}
+
+func (s *Store) DeviceAccounts(ctx context.Context, deviceID string, userID types.ID) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT user_id) FROM (
+			SELECT user_id FROM user_fcm_tokens WHERE device_id = $1
+			UNION
+			SELECT user_id FROM devices WHERE install_id = $1
+		) seen
+		WHERE user_id <> $2`,
+		deviceID, string(userID),
+	).Scan(&n)
+	return n, err
+}
+
+// ---------------------------------------------------------------------------
+// Referrals
+// ---------------------------------------------------------------------------
+
+func (s *Store) CreateReferral(ctx context.Context, r *Referral) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO referrals (referee_id, referrer_id, code, device_id, identity_hash, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		string(r.RefereeID), string(r.ReferrerID), r.Code, r.DeviceID, r.IdentityHash, r.Status, r.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *Store) GetReferral(ctx context.Context, refereeID types.ID) (*Referral, error) {
+	var r Referral
+	var referee, referrer string
+	err := s.db.QueryRow(ctx, `
+		SELECT referee_id, referrer_id, code, device_id, identity_hash, status, reason, created_at, rewarded_at
+		FROM referrals WHERE referee_id = $1`, string(refereeID),
+	).Scan(&referee, &referrer, &r.Code, &r.DeviceID, &r.IdentityHash, &r.Status, &r.Reason, &r.CreatedAt, &r.RewardedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.RefereeID, r.ReferrerID = types.ID(referee), types.ID(referrer)
+	return &r, nil
+}
+
+func (s *Store) CountRewarded(ctx context.Context, referrerID types.ID) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM referrals WHERE referrer_id = $1 AND status = 'rewarded'`,
+		string(referrerID),
+	).Scan(&n)
+	return n, err
+}
+
+func (s *Store) Reward(ctx context.Context, r *Referral, rewards Rewards, at time.Time) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE referrals SET status = 'rewarded', rewarded_at = $2
+		WHERE referee_id = $1 AND status = 'pending'`,
+		string(r.RefereeID), at,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	grants := []struct {
+		user   types.ID
+		amount int64
+		kind   string
+	}{
+		{r.ReferrerID, rewards.ReferrerCredit, KindReferrer},
+		{r.RefereeID, rewards.RefereeCredit, KindReferee},
+	}
+	for _, g := range grants {
+		if g.amount <= 0 {
+			continue
+		}
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO credit_ledger (user_id, amount, kind, ref, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, kind, ref) DO NOTHING`,
+			string(g.user), g.amount, g.kind, string(r.RefereeID), at,
+		)
+		if err != nil {
+			return false, err
+		}
+		if tag.RowsAffected() == 0 {
+			continue
+		}
+		grant := ledger.CreditGrantTxn(g.user, g.amount, g.kind+":"+string(r.RefereeID), "referral", at)
+		if _, err := ledger.PostTx(ctx, tx, grant); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (s *Store) Reject(ctx context.Context, refereeID types.ID, reason string) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE referrals SET status = 'rejected', reason = $2
+		WHERE referee_id = $1 AND status = 'pending'`,
+		string(refereeID), reason,
+	)
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// Credit ledger
+// ---------------------------------------------------------------------------
+
+func (s *Store) Balance(ctx context.Context, userID types.ID) (int64, error) {
+	var bal int64
+	err := s.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM credit_ledger WHERE user_id = $1`,
+		string(userID),
+	).Scan(&bal)
+	return bal, err
+}
+
+func (s *Store) Grant(ctx context.Context, userID types.ID, amount int64, kind, ref string, at time.Time) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO credit_ledger (user_id, amount, kind, ref, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, kind, ref) DO NOTHING`,
+		string(userID), amount, kind, ref, at,
+	)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	grant := ledger.CreditGrantTxn(userID, amount, kind+":"+ref, kind, at)
+	if _, err := ledger.PostTx(ctx, tx, grant); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// Redeem serialises a user's redemptions with a transaction-scoped advisory
+// lock so two orders reaching payment together cannot overdraw the balance.
+func (s *Store) Redeem(ctx context.Context, userID, orderID types.ID, limit int64, at time.Time) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('credits:' || $1))`, string(userID)); err != nil {
+		return 0, err
+	}
+	var spent int64
+	err = tx.QueryRow(ctx, `
+		SELECT -amount FROM credit_ledger WHERE user_id = $1 AND kind = 'ride' AND ref = $2`,
+		string(userID), string(orderID),
+	).Scan(&spent)
+	if err == nil {
+		return spent, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	var bal int64
+	if err := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM credit_ledger WHERE user_id = $1`,
+		string(userID),
+	).Scan(&bal); err != nil {
+		return 0, err
+	}
+	amount := min(bal, limit)
+	if amount <= 0 {
+		return 0, nil
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO credit_ledger (user_id, amount, kind, ref, created_at)
+		VALUES ($1, $2, 'ride', $3, $4)`,
+		string(userID), -amount, string(orderID), at,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return amount, tx.Commit(ctx)
+}
+
+func (s *Store) ListEntries(ctx context.Context, userID types.ID, limit int) ([]Entry, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT amount, kind, ref, created_at FROM credit_ledger
+		WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2`,
+		string(userID), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.Amount, &e.Kind, &e.Ref, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
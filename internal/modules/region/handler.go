@@ -0,0 +1,224 @@
+// README: Region HTTP handlers — ops management of the regions the platform operates in.
+//
+// Endpoints:
+//
+//	GET /api/ops/regions      — every region and its configuration (ops key)
+//	PUT /api/ops/regions/:id  — create or replace a region (ops key)
+//	GET /api/ops/regions/export?format=geojson|csv — download the regions (ops key)
+//	POST /api/ops/regions/import?format=geojson|csv[&dry_run=true] — create or replace the file's regions (ops key)
+//
+// Auth: routes require the ops key middleware.
+package region
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/bulk"
+	"ark/internal/types"
+)
+
+// maxImportBytes caps an import's request body; areas run to hundreds of
+// vertices.
+const maxImportBytes = 8 << 20
+
+// Handler holds the region HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type pointJSON struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type saveRegionReq struct {
+	Name          string      `json:"name"`
+	Timezone      string      `json:"timezone"`
+	Currency      string      `json:"currency"`
+	Area          []pointJSON `json:"area"`
+	RateSet       string      `json:"rate_set"`
+	MatchRadiusKm float64     `json:"match_radius_km"`
+	// PriorityBoostSecs and PriorityNotifyDrivers tune dispatch of VIP and
+	// accessibility passengers' orders.
+	PriorityBoostSecs     int `json:"priority_boost_secs"`
+	PriorityNotifyDrivers int `json:"priority_notify_drivers"`
+	// MeetRadiusKm, CompleteRadiusKm and MinTripKm bound where drivers may
+	// start and complete trips.
+	MeetRadiusKm     float64 `json:"meet_radius_km"`
+	CompleteRadiusKm float64 `json:"complete_radius_km"`
+	MinTripKm        float64 `json:"min_trip_km"`
+	// SuspendOverdueInspection keeps orders from drivers whose vehicle
+	// inspection is overdue.
+	SuspendOverdueInspection bool `json:"suspend_overdue_inspection"`
+}
+
+type regionResp struct {
+	ID                       string      `json:"region_id"`
+	Name                     string      `json:"name"`
+	Timezone                 string      `json:"timezone"`
+	Currency                 string      `json:"currency"`
+	Area                     []pointJSON `json:"area"`
+	RateSet                  string      `json:"rate_set"`
+	MatchRadiusKm            float64     `json:"match_radius_km"`
+	PriorityBoostSecs        int         `json:"priority_boost_secs"`
+	PriorityNotifyDrivers    int         `json:"priority_notify_drivers"`
+	MeetRadiusKm             float64     `json:"meet_radius_km"`
+	CompleteRadiusKm         float64     `json:"complete_radius_km"`
+	MinTripKm                float64     `json:"min_trip_km"`
+	SuspendOverdueInspection bool        `json:"suspend_overdue_inspection"`
+	UpdatedAt                int64       `json:"updated_at,omitempty"`
+}
+
+func toRegionResp(r Region) regionResp {
+	out := regionResp{
+		ID:                       r.ID,
+		Name:                     r.Name,
+		Timezone:                 r.Timezone,
+		Currency:                 r.Currency,
+		Area:                     make([]pointJSON, len(r.Area)),
+		RateSet:                  r.RateSet,
+		MatchRadiusKm:            r.MatchRadiusKm,
+		PriorityBoostSecs:        int(r.PriorityBoost / time.Second),
+		PriorityNotifyDrivers:    r.PriorityNotifyDrivers,
+		MeetRadiusKm:             r.MeetRadiusKm,
+		CompleteRadiusKm:         r.CompleteRadiusKm,
+		MinTripKm:                r.MinTripKm,
+		SuspendOverdueInspection: r.SuspendOverdueInspection,
+	}
+	for i, p := range r.Area {
+		out.Area[i] = pointJSON{Lat: p.Lat, Lng: p.Lng}
+	}
+	if !r.UpdatedAt.IsZero() {
+		out.UpdatedAt = r.UpdatedAt.Unix()
+	}
+	return out
+}
+
+// List handles GET /api/ops/regions.
+func (h *Handler) List(c *gin.Context) {
+	rs := h.svc.List()
+	out := make([]regionResp, len(rs))
+	for i, r := range rs {
+		out[i] = toRegionResp(r)
+	}
+	c.JSON(http.StatusOK, map[string]any{"regions": out})
+}
+
+// Save handles PUT /api/ops/regions/:id.
+func (h *Handler) Save(c *gin.Context) {
+	var req saveRegionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	r := Region{
+		ID:                       c.Param("id"),
+		Name:                     req.Name,
+		Timezone:                 req.Timezone,
+		Currency:                 req.Currency,
+		RateSet:                  req.RateSet,
+		MatchRadiusKm:            req.MatchRadiusKm,
+		PriorityBoost:            time.Duration(req.PriorityBoostSecs) * time.Second,
+		PriorityNotifyDrivers:    req.PriorityNotifyDrivers,
+		MeetRadiusKm:             req.MeetRadiusKm,
+		CompleteRadiusKm:         req.CompleteRadiusKm,
+		MinTripKm:                req.MinTripKm,
+		SuspendOverdueInspection: req.SuspendOverdueInspection,
+	}
+	for _, p := range req.Area {
+		r.Area = append(r.Area, types.Point{Lat: p.Lat, Lng: p.Lng})
+	}
+	saved, err := h.svc.Save(c.Request.Context(), r)
+	if err != nil {
+		writeRegionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toRegionResp(*saved))
+}
+
+// parseTransferFormat reads ?format=: geojson (the default) is the JSON
+// layout.
+func parseTransferFormat(c *gin.Context) (bulk.Format, error) {
+	f := c.Query("format")
+	if f == "geojson" {
+		f = "json"
+	}
+	return bulk.ParseFormat(f)
+}
+
+// Export handles GET /api/ops/regions/export.
+func (h *Handler) Export(c *gin.Context) {
+	f, err := parseTransferFormat(c)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	name := "regions.geojson"
+	if f == bulk.CSV {
+		name = "regions.csv"
+	}
+	c.Header("Content-Type", f.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Status(http.StatusOK)
+	if err := EncodeRegions(c.Writer, f, h.svc.List()); err != nil {
+		log.Printf("region: export: %v", err)
+	}
+}
+
+// Import handles POST /api/ops/regions/import.
+func (h *Handler) Import(c *gin.Context) {
+	f, err := parseTransferFormat(c)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	rs, err := DecodeRegions(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes), f)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	res, err := h.svc.Import(c.Request.Context(), rs, c.Query("dry_run") == "true")
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeRegionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func writeTransferError(c *gin.Context, err error) {
+	var verr *bulk.ValidationError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &verr):
+		c.JSON(http.StatusUnprocessableEntity, map[string]any{"error": bulk.ErrInvalid.Error(), "rows": verr.Rows})
+	case errors.As(err, &tooLarge):
+		writeError(c, http.StatusRequestEntityTooLarge, "file too large")
+	case errors.Is(err, bulk.ErrFormat), errors.Is(err, bulk.ErrMalformed):
+		writeError(c, http.StatusBadRequest, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
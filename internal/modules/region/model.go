@@ -0,0 +1,156 @@
+// README: Region domain model — a city the platform operates in, with its timezone, currency, service area, rate set and matching radius.
+package region
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"ark/internal/types"
+)
+
+const (
+	// maxAreaPoints caps the vertices of a service area polygon.
+	maxAreaPoints = 500
+	// maxPriorityBoost caps how far ahead of earlier orders a priority order
+	// may jump, so normal orders are delayed but never starved.
+	maxPriorityBoost = 30 * time.Minute
+	// maxPriorityNotifyDrivers caps the first offer of a priority order.
+	maxPriorityNotifyDrivers = 20
+)
+
+var ErrBadRequest = errors.New("bad request")
+
+// FieldError reports the first invalid field of a region, by its JSON name.
+// It wraps ErrBadRequest.
+type FieldError struct {
+	Field string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%v: invalid %s", ErrBadRequest, e.Field)
+}
+
+func (e *FieldError) Unwrap() error { return ErrBadRequest }
+
+var idRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Region is one city's operating configuration. Orders carry the region
+// their pickup lies in, drivers the region they serve.
+type Region struct {
+	ID       string
+	Name     string
+	Timezone string // IANA name; night and peak hours are local to it
+	Currency string // what the region's rates are quoted in
+	// Area is the service polygon, vertices in order. Pickups in no region's
+	// area belong to the default region if it has no area of its own, and are
+	// outside the service area otherwise.
+	Area []types.Point
+	// RateSet names the pricing rates of the region's ride types; "" is the
+	// platform's original set.
+	RateSet string
+	// MatchRadiusKm is how far from a pickup drivers are looked for; 0 uses
+	// the platform default.
+	MatchRadiusKm float64
+	// PriorityBoost moves orders of priority passengers (VIP, accessibility)
+	// ahead in dispatch as if they had been placed that much earlier; orders
+	// waiting longer than that still go first.
+	PriorityBoost time.Duration
+	// PriorityNotifyDrivers is how many drivers the first offer of a priority
+	// order goes to; 0 keeps the normal pool.
+	PriorityNotifyDrivers int
+	// MeetRadiusKm and CompleteRadiusKm are how close to the pickup and the
+	// dropoff the driver must be to start and to complete a trip; a trip of
+	// at least MinTripKm may complete anywhere. 0 uses the platform default.
+	MeetRadiusKm     float64
+	CompleteRadiusKm float64
+	MinTripKm        float64
+	// SuspendOverdueInspection stops offering orders to drivers whose vehicle
+	// inspection is overdue; they are only reminded otherwise.
+	SuspendOverdueInspection bool
+	UpdatedAt                time.Time
+
+	loc *time.Location
+}
+
+// Default returns the region used before any region is configured: Taipei,
+// serving everywhere.
+func Default(id string) Region {
+	r := Region{ID: id, Name: "Taipei", Timezone: "Asia/Taipei", Currency: types.DefaultCurrency}
+	r.loc, _ = time.LoadLocation(r.Timezone)
+	return r
+}
+
+// Location returns the region's time zone, UTC+8 when it cannot be loaded.
+func (r Region) Location() *time.Location {
+	if r.loc != nil {
+		return r.loc
+	}
+	if loc, err := time.LoadLocation(r.Timezone); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*60*60)
+}
+
+// Contains reports whether p lies inside the region's service area. A region
+// without an area contains nothing.
+func (r Region) Contains(p types.Point) bool {
+	n := len(r.Area)
+	if n < 3 {
+		return false
+	}
+	// Ray casting along the latitude through p; the area is small enough for
+	// lat/lng to be treated as plane coordinates.
+	in := false
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := r.Area[i], r.Area[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			in = !in
+		}
+	}
+	return in
+}
+
+// validate checks r and loads its time zone. The error is a *FieldError.
+func (r *Region) validate() error {
+	invalid := func(field string) error {
+		return &FieldError{Field: field}
+	}
+	switch {
+	case !idRe.MatchString(r.ID):
+		return invalid("region_id")
+	case r.Name == "" || len(r.Name) > 100:
+		return invalid("name")
+	case len(r.RateSet) > 32:
+		return invalid("rate_set")
+	case !types.ValidCurrency(r.Currency):
+		return invalid("currency")
+	case r.MatchRadiusKm < 0 || r.MatchRadiusKm > 50:
+		return invalid("match_radius_km")
+	case r.PriorityBoost < 0 || r.PriorityBoost > maxPriorityBoost || r.PriorityBoost%time.Second != 0:
+		return invalid("priority_boost_secs")
+	case r.PriorityNotifyDrivers < 0 || r.PriorityNotifyDrivers > maxPriorityNotifyDrivers:
+		return invalid("priority_notify_drivers")
+	case r.MeetRadiusKm < 0 || r.MeetRadiusKm > 5:
+		return invalid("meet_radius_km")
+	case r.CompleteRadiusKm < 0 || r.CompleteRadiusKm > 5:
+		return invalid("complete_radius_km")
+	case r.MinTripKm < 0 || r.MinTripKm > 50:
+		return invalid("min_trip_km")
+	case len(r.Area) > maxAreaPoints || (len(r.Area) > 0 && len(r.Area) < 3):
+		return invalid("area")
+	}
+	for _, p := range r.Area {
+		if p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+			return invalid("area")
+		}
+	}
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil || r.Timezone == "" {
+		return invalid("timezone")
+	}
+	r.loc = loc
+	return nil
+}
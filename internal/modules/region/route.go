@@ -0,0 +1,17 @@
+// README: Region route registration — mounts the ops region endpoints.
+package region
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the region endpoints onto the provided ops router group.
+//
+//	GET /api/ops/regions
+//	PUT /api/ops/regions/:id
+//	GET /api/ops/regions/export
+//	POST /api/ops/regions/import
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/regions", h.List)
+	rg.PUT("/api/ops/regions/:id", h.Save)
+	rg.GET("/api/ops/regions/export", h.Export)
+	rg.POST("/api/ops/regions/import", h.Import)
+}
@@ -0,0 +1,173 @@
+// README: Region service — keeps the configured regions in memory and resolves points and IDs to a region's configuration for pricing, matching and scheduling.
+package region
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Service resolves regions. Lookups are served from memory; Refresh reloads
+// the regions from the store so edits made on another instance are picked up.
+type Service struct {
+	store    RegionStore
+	fallback Region
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	regions []Region
+}
+
+// NewService returns a Service that treats fallback as the default region
+// until one with its ID is stored.
+func NewService(store RegionStore, fallback Region) *Service {
+	return &Service{store: store, fallback: fallback, now: time.Now}
+}
+
+// Refresh reloads the regions from the store. Rows that no longer validate
+// (e.g. a time zone unknown to this instance) are skipped with a log line.
+func (s *Service) Refresh(ctx context.Context) error {
+	rs, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	loaded := rs[:0]
+	for _, r := range rs {
+		if err := r.validate(); err != nil {
+			log.Printf("region: skipping %s: invalid configuration", r.ID)
+			continue
+		}
+		loaded = append(loaded, r)
+	}
+	s.mu.Lock()
+	s.regions = loaded
+	s.mu.Unlock()
+	return nil
+}
+
+// RunRefresh calls Refresh every interval until ctx is done.
+func (s *Service) RunRefresh(ctx context.Context, interval time.Duration) {
+	if err := s.Refresh(ctx); err != nil {
+		log.Printf("region: refresh: %v", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				log.Printf("region: refresh: %v", err)
+			}
+		}
+	}
+}
+
+// List returns every configured region; the default one is included even
+// when it has not been stored.
+func (s *Service) List() []Region {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Region, 0, len(s.regions)+1)
+	found := false
+	for _, r := range s.regions {
+		found = found || r.ID == s.fallback.ID
+		out = append(out, r)
+	}
+	if !found {
+		out = append(out, s.fallback)
+	}
+	return out
+}
+
+// Get returns the region with id. An empty or unknown id is the default
+// region, so orders and drivers recorded before regions existed keep working.
+func (s *Service) Get(id string) Region {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if id == "" {
+		id = s.fallback.ID
+	}
+	for _, r := range s.regions {
+		if r.ID == id {
+			return r
+		}
+	}
+	for _, r := range s.regions {
+		if r.ID == s.fallback.ID {
+			return r
+		}
+	}
+	return s.fallback
+}
+
+// TripCheck returns where trips in the region with id may start and
+// complete.
+func (s *Service) TripCheck(id string) order.TripCheck {
+	r := s.Get(id)
+	return order.TripCheck{MeetRadiusKm: r.MeetRadiusKm, CompleteRadiusKm: r.CompleteRadiusKm, MinTripKm: r.MinTripKm}
+}
+
+// Exists reports whether id names a configured region.
+func (s *Service) Exists(id string) bool {
+	if id == s.fallback.ID {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.regions {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Locate returns the region serving p: the first whose area contains it,
+// otherwise the default region when it has no area. ok is false when p is
+// outside every service area.
+func (s *Service) Locate(p types.Point) (Region, bool) {
+	s.mu.RLock()
+	for _, r := range s.regions {
+		if r.Contains(p) {
+			s.mu.RUnlock()
+			return r, true
+		}
+	}
+	s.mu.RUnlock()
+	if d := s.Get(""); len(d.Area) == 0 {
+		return d, true
+	}
+	return Region{}, false
+}
+
+// RegionAt implements order.Regions.
+func (s *Service) RegionAt(p types.Point) (string, bool) {
+	r, ok := s.Locate(p)
+	return r.ID, ok
+}
+
+// Location implements order.Regions: the time zone of the region with id.
+func (s *Service) Location(id string) *time.Location {
+	return s.Get(id).Location()
+}
+
+// Save validates and stores r, then reloads the regions.
+func (s *Service) Save(ctx context.Context, r Region) (*Region, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	r.UpdatedAt = s.now()
+	if err := s.store.Save(ctx, &r); err != nil {
+		return nil, err
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
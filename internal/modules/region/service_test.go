@@ -0,0 +1,198 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ark/internal/bulk"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	regions []Region
+}
+
+func (m *mockStore) List(context.Context) ([]Region, error) {
+	return append([]Region(nil), m.regions...), nil
+}
+
+func (m *mockStore) Save(_ context.Context, r *Region) error {
+	for i := range m.regions {
+		if m.regions[i].ID == r.ID {
+			m.regions[i] = *r
+			return nil
+		}
+	}
+	m.regions = append(m.regions, *r)
+	return nil
+}
+
+func (m *mockStore) SaveAll(ctx context.Context, rs []*Region) error {
+	for _, r := range rs {
+		if err := m.Save(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var kaohsiung = Region{
+	ID: "khh", Name: "Kaohsiung", Timezone: "Asia/Taipei", Currency: "TWD", RateSet: "khh", MatchRadiusKm: 5,
+	Area: []types.Point{{Lat: 22.5, Lng: 120.2}, {Lat: 22.8, Lng: 120.2}, {Lat: 22.8, Lng: 120.5}, {Lat: 22.5, Lng: 120.5}},
+}
+
+var (
+	taipeiStation = types.Point{Lat: 25.0478, Lng: 121.5170}
+	kaohsiungPort = types.Point{Lat: 22.6163, Lng: 120.2998}
+)
+
+func TestLocate_AreaThenDefault(t *testing.T) {
+	svc := NewService(&mockStore{}, Default("tpe"))
+	ctx := context.Background()
+
+	// Before any region is stored everything is the default region.
+	if id, ok := svc.RegionAt(kaohsiungPort); !ok || id != "tpe" {
+		t.Errorf("unconfigured: RegionAt = %q, %v; want tpe", id, ok)
+	}
+	if _, err := svc.Save(ctx, kaohsiung); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if id, ok := svc.RegionAt(kaohsiungPort); !ok || id != "khh" {
+		t.Errorf("kaohsiung: RegionAt = %q, %v; want khh", id, ok)
+	}
+	if id, ok := svc.RegionAt(taipeiStation); !ok || id != "tpe" {
+		t.Errorf("taipei: RegionAt = %q, %v; want tpe", id, ok)
+	}
+	if got := svc.Get(""); got.ID != "tpe" || got.Location().String() != "Asia/Taipei" {
+		t.Errorf("Get(\"\") = %+v, want the default region", got)
+	}
+	if got := svc.Get("gone"); got.ID != "tpe" {
+		t.Errorf("unknown id resolved to %q, want tpe", got.ID)
+	}
+
+	// Once the default region gets an area, pickups outside all areas are
+	// outside the service area.
+	tpe := Default("tpe")
+	tpe.Area = []types.Point{{Lat: 24.9, Lng: 121.4}, {Lat: 25.2, Lng: 121.4}, {Lat: 25.2, Lng: 121.7}, {Lat: 24.9, Lng: 121.7}}
+	if _, err := svc.Save(ctx, tpe); err != nil {
+		t.Fatalf("save tpe: %v", err)
+	}
+	if _, ok := svc.RegionAt(types.Point{Lat: 24.15, Lng: 120.67}); ok {
+		t.Error("taichung is inside the service area")
+	}
+	if id, _ := svc.RegionAt(taipeiStation); id != "tpe" {
+		t.Errorf("taipei after area: %q, want tpe", id)
+	}
+}
+
+func TestSave_Validates(t *testing.T) {
+	svc := NewService(&mockStore{}, Default("tpe"))
+	bad := []func(r *Region){
+		func(r *Region) { r.ID = "Kaohsiung City" },
+		func(r *Region) { r.Timezone = "Mars/Olympus" },
+		func(r *Region) { r.Currency = "XXX" },
+		func(r *Region) { r.Area = r.Area[:2] },
+		func(r *Region) { r.MatchRadiusKm = -1 },
+		func(r *Region) { r.PriorityBoost = time.Hour },
+		func(r *Region) { r.PriorityBoost = 1500 * time.Millisecond },
+		func(r *Region) { r.PriorityNotifyDrivers = -1 },
+		func(r *Region) { r.MeetRadiusKm = -0.1 },
+		func(r *Region) { r.CompleteRadiusKm = 10 },
+		func(r *Region) { r.MinTripKm = -1 },
+	}
+	for i, mutate := range bad {
+		r := kaohsiung
+		r.Area = append([]types.Point(nil), kaohsiung.Area...)
+		mutate(&r)
+		if _, err := svc.Save(context.Background(), r); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("case %d: err = %v, want ErrBadRequest", i, err)
+		}
+	}
+	if got := len(svc.List()); got != 1 {
+		t.Errorf("List has %d regions after rejected saves, want only the default", got)
+	}
+}
+
+func TestImport_DiffDryRunAndApply(t *testing.T) {
+	store := &mockStore{}
+	svc := NewService(store, Default("tpe"))
+	ctx := context.Background()
+	if _, err := svc.Save(ctx, kaohsiung); err != nil {
+		t.Fatal(err)
+	}
+
+	// A GIS round trip: export, move one vertex, add a region.
+	var buf strings.Builder
+	if err := EncodeRegions(&buf, bulk.JSON, []Region{kaohsiung}); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := DecodeRegions(strings.NewReader(buf.String()), bulk.JSON)
+	if err != nil || len(rs) != 1 || !reflect.DeepEqual(rs[0].Area, kaohsiung.Area) {
+		t.Fatalf("GeoJSON round trip = %+v, %v", rs, err)
+	}
+	rs[0].Area[2].Lng = 120.6
+	taichung := Region{
+		ID: "txg", Name: "Taichung", Timezone: "Asia/Taipei", Currency: "TWD",
+		Area: []types.Point{{Lat: 24.0, Lng: 120.5}, {Lat: 24.3, Lng: 120.5}, {Lat: 24.3, Lng: 120.8}},
+	}
+	rs = append(rs, taichung)
+
+	res, err := svc.Import(ctx, rs, true)
+	want := []bulk.Change{{Action: bulk.Update, Key: "khh", Fields: []string{"area"}}, {Action: bulk.Add, Key: "txg"}}
+	if err != nil || !reflect.DeepEqual(res.Changes, want) {
+		t.Fatalf("dry run = %+v, %v; want %+v", res, err, want)
+	}
+	if svc.Exists("txg") {
+		t.Fatal("dry run saved a region")
+	}
+
+	if _, err := svc.Import(ctx, rs, false); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if id, ok := svc.RegionAt(types.Point{Lat: 24.25, Lng: 120.6}); !ok || id != "txg" {
+		t.Errorf("taichung after import: RegionAt = %q, %v", id, ok)
+	}
+	if got := svc.Get("khh").Area[2].Lng; got != 120.6 {
+		t.Errorf("khh vertex = %v, want the imported 120.6", got)
+	}
+}
+
+func TestImport_InvalidRowsApplyNothing(t *testing.T) {
+	svc := NewService(&mockStore{}, Default("tpe"))
+	csv := "region_id,name,timezone,currency,match_radius_km,meet_radius_km,complete_radius_km,min_trip_km,area\n" +
+		"khh,Kaohsiung,Asia/Taipei,TWD,5,0,0,0,\"POLYGON((120.2 22.5, 120.2 22.8, 120.5 22.8, 120.5 22.5, 120.2 22.5))\"\n" +
+		"txg,Taichung,Mars/Olympus,TWD,5,0,0,0,\n" +
+		"khh,Kaohsiung,Asia/Taipei,TWD,5,0,0,0,\n"
+	rs, err := DecodeRegions(strings.NewReader(csv), bulk.CSV)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(rs[0].Area) != 4 || rs[0].Area[1] != (types.Point{Lat: 22.8, Lng: 120.2}) {
+		t.Errorf("WKT area = %+v", rs[0].Area)
+	}
+	_, err = svc.Import(context.Background(), rs, false)
+	var verr *bulk.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want a ValidationError", err)
+	}
+	want := []bulk.RowError{
+		{Row: 2, Field: "timezone", Message: "invalid value"},
+		{Row: 3, Field: "region_id", Message: "repeats row 1"},
+	}
+	if !reflect.DeepEqual(verr.Rows, want) {
+		t.Errorf("rows = %+v, want %+v", verr.Rows, want)
+	}
+	if svc.Exists("khh") {
+		t.Error("a valid row of an invalid import was saved")
+	}
+
+	bad := "region_id,name,timezone,currency,match_radius_km,meet_radius_km,complete_radius_km,min_trip_km,area\n" +
+		"khh,Kaohsiung,Asia/Taipei,TWD,5,0,0,0,\"POLYGON((120.2 22.5, 120.2))x\"\n"
+	if _, err := DecodeRegions(strings.NewReader(bad), bulk.CSV); !errors.Is(err, bulk.ErrInvalid) {
+		t.Errorf("bad WKT: err = %v, want ErrInvalid", err)
+	}
+}
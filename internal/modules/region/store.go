@@ -0,0 +1,133 @@
+// README: Region store — PostgreSQL persistence for regions.
+package region
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// RegionStore defines the persistence operations required by the Service.
+type RegionStore interface {
+	// List returns every region ordered by ID.
+	List(ctx context.Context) ([]Region, error)
+	// Save creates r or replaces the region with its ID.
+	Save(ctx context.Context, r *Region) error
+	// SaveAll saves every region in rs, all or none.
+	SaveAll(ctx context.Context, rs []*Region) error
+}
+
+// Store is the PostgreSQL implementation of RegionStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) List(ctx context.Context) ([]Region, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, name, timezone, currency, area, rate_set, match_radius_km,
+		       priority_boost_secs, priority_notify_drivers,
+		       meet_radius_km, complete_radius_km, min_trip_km,
+		       suspend_overdue_inspection, updated_at
+		FROM regions
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Region
+	for rows.Next() {
+		var r Region
+		var area []byte
+		var boostSecs int
+		if err := rows.Scan(&r.ID, &r.Name, &r.Timezone, &r.Currency, &area, &r.RateSet, &r.MatchRadiusKm,
+			&boostSecs, &r.PriorityNotifyDrivers, &r.MeetRadiusKm, &r.CompleteRadiusKm, &r.MinTripKm,
+			&r.SuspendOverdueInspection, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		r.PriorityBoost = time.Duration(boostSecs) * time.Second
+		var stored [][2]float64
+		if err := json.Unmarshal(area, &stored); err != nil {
+			return nil, err
+		}
+		for _, v := range stored {
+			r.Area = append(r.Area, types.Point{Lat: v[0], Lng: v[1]})
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// upsertRegionSQL stores the region whose values regionArgs lists.
+const upsertRegionSQL = `
+		INSERT INTO regions (id, name, timezone, currency, area, rate_set, match_radius_km,
+		                     priority_boost_secs, priority_notify_drivers,
+		                     meet_radius_km, complete_radius_km, min_trip_km,
+		                     suspend_overdue_inspection, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO UPDATE SET
+		    name                    = EXCLUDED.name,
+		    timezone                = EXCLUDED.timezone,
+		    currency                = EXCLUDED.currency,
+		    area                    = EXCLUDED.area,
+		    rate_set                = EXCLUDED.rate_set,
+		    match_radius_km         = EXCLUDED.match_radius_km,
+		    priority_boost_secs     = EXCLUDED.priority_boost_secs,
+		    priority_notify_drivers = EXCLUDED.priority_notify_drivers,
+		    meet_radius_km          = EXCLUDED.meet_radius_km,
+		    complete_radius_km      = EXCLUDED.complete_radius_km,
+		    min_trip_km             = EXCLUDED.min_trip_km,
+		    suspend_overdue_inspection = EXCLUDED.suspend_overdue_inspection,
+		    updated_at              = EXCLUDED.updated_at`
+
+func regionArgs(r *Region) ([]any, error) {
+	stored := make([][2]float64, len(r.Area))
+	for i, p := range r.Area {
+		stored[i] = [2]float64{p.Lat, p.Lng}
+	}
+	area, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		r.ID, r.Name, r.Timezone, r.Currency, area, r.RateSet, r.MatchRadiusKm,
+		int(r.PriorityBoost / time.Second), r.PriorityNotifyDrivers,
+		r.MeetRadiusKm, r.CompleteRadiusKm, r.MinTripKm,
+		r.SuspendOverdueInspection, r.UpdatedAt,
+	}, nil
+}
+
+func (s *Store) Save(ctx context.Context, r *Region) error {
+	args, err := regionArgs(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, upsertRegionSQL, args...)
+	return err
+}
+
+func (s *Store) SaveAll(ctx context.Context, rs []*Region) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for _, r := range rs {
+		args, err := regionArgs(r)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, upsertRegionSQL, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,329 @@
+// README: Region transfer — export of the regions and their service areas as GeoJSON or CSV (areas as WKT), and validated, diffed, all-or-nothing import.
+package region
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ark/internal/bulk"
+	"ark/internal/types"
+)
+
+// regionColumns lay out regions in CSV files. area is the service polygon
+// as WKT, POLYGON((lng lat, ...)), and empty for a region without one.
+var regionColumns = []string{
+	"region_id", "name", "timezone", "currency", "rate_set", "match_radius_km",
+	"priority_boost_secs", "priority_notify_drivers",
+	"meet_radius_km", "complete_radius_km", "min_trip_km", "area",
+	"suspend_overdue_inspection",
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func regionRow(r Region) []string {
+	return []string{
+		r.ID, r.Name, r.Timezone, r.Currency, r.RateSet, formatFloat(r.MatchRadiusKm),
+		strconv.Itoa(int(r.PriorityBoost / time.Second)), strconv.Itoa(r.PriorityNotifyDrivers),
+		formatFloat(r.MeetRadiusKm), formatFloat(r.CompleteRadiusKm), formatFloat(r.MinTripKm),
+		formatWKT(r.Area), strconv.FormatBool(r.SuspendOverdueInspection),
+	}
+}
+
+// formatWKT writes area as a closed WKT polygon, "" for no area.
+func formatWKT(area []types.Point) string {
+	if len(area) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("POLYGON((")
+	for _, p := range append(slices.Clip(area), area[0]) {
+		if b.Len() > len("POLYGON((") {
+			b.WriteString(", ")
+		}
+		b.WriteString(formatFloat(p.Lng) + " " + formatFloat(p.Lat))
+	}
+	b.WriteString("))")
+	return b.String()
+}
+
+// parseWKT reads a WKT polygon without holes; "" is no area.
+func parseWKT(s string) ([]types.Point, error) {
+	if s == "" {
+		return nil, nil
+	}
+	body, ok := strings.CutPrefix(strings.ToUpper(strings.TrimSpace(s)), "POLYGON")
+	body = strings.TrimSpace(body)
+	if !ok || !strings.HasPrefix(body, "((") || !strings.HasSuffix(body, "))") {
+		return nil, errors.New("not a WKT POLYGON")
+	}
+	body = body[2 : len(body)-2]
+	if strings.ContainsAny(body, "()") {
+		return nil, errors.New("polygons with holes are not supported")
+	}
+	var ring [][2]float64
+	for _, v := range strings.Split(body, ",") {
+		f := strings.Fields(v)
+		if len(f) != 2 {
+			return nil, errors.New("vertices must be \"lng lat\"")
+		}
+		lng, err1 := strconv.ParseFloat(f[0], 64)
+		lat, err2 := strconv.ParseFloat(f[1], 64)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("vertices must be \"lng lat\"")
+		}
+		ring = append(ring, [2]float64{lng, lat})
+	}
+	return fromRing(ring), nil
+}
+
+// fromRing turns a [lng, lat] ring, closed or not, into an area.
+func fromRing(ring [][2]float64) []types.Point {
+	if n := len(ring); n > 1 && ring[0] == ring[n-1] {
+		ring = ring[:n-1]
+	}
+	area := make([]types.Point, len(ring))
+	for i, v := range ring {
+		area[i] = types.Point{Lat: v[1], Lng: v[0]}
+	}
+	return area
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string          `json:"type"`
+	Properties regionProps     `json:"properties"`
+	Geometry   *polygonGeoJSON `json:"geometry"`
+}
+
+// polygonGeoJSON is a GeoJSON Polygon; rings are closed [lng, lat] lists.
+type polygonGeoJSON struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// regionProps is a region without its area, as GeoJSON feature properties.
+type regionProps struct {
+	ID                       string  `json:"region_id"`
+	Name                     string  `json:"name"`
+	Timezone                 string  `json:"timezone"`
+	Currency                 string  `json:"currency"`
+	RateSet                  string  `json:"rate_set"`
+	MatchRadiusKm            float64 `json:"match_radius_km"`
+	PriorityBoostSecs        int     `json:"priority_boost_secs"`
+	PriorityNotifyDrivers    int     `json:"priority_notify_drivers"`
+	MeetRadiusKm             float64 `json:"meet_radius_km"`
+	CompleteRadiusKm         float64 `json:"complete_radius_km"`
+	MinTripKm                float64 `json:"min_trip_km"`
+	SuspendOverdueInspection bool    `json:"suspend_overdue_inspection"`
+}
+
+func toFeature(r Region) feature {
+	f := feature{
+		Type: "Feature",
+		Properties: regionProps{
+			ID:                       r.ID,
+			Name:                     r.Name,
+			Timezone:                 r.Timezone,
+			Currency:                 r.Currency,
+			RateSet:                  r.RateSet,
+			MatchRadiusKm:            r.MatchRadiusKm,
+			PriorityBoostSecs:        int(r.PriorityBoost / time.Second),
+			PriorityNotifyDrivers:    r.PriorityNotifyDrivers,
+			MeetRadiusKm:             r.MeetRadiusKm,
+			CompleteRadiusKm:         r.CompleteRadiusKm,
+			MinTripKm:                r.MinTripKm,
+			SuspendOverdueInspection: r.SuspendOverdueInspection,
+		},
+	}
+	if len(r.Area) > 0 {
+		ring := make([][2]float64, 0, len(r.Area)+1)
+		for _, p := range append(slices.Clip(r.Area), r.Area[0]) {
+			ring = append(ring, [2]float64{p.Lng, p.Lat})
+		}
+		f.Geometry = &polygonGeoJSON{Type: "Polygon", Coordinates: [][][2]float64{ring}}
+	}
+	return f
+}
+
+func fromProps(p regionProps) Region {
+	return Region{
+		ID:                       p.ID,
+		Name:                     p.Name,
+		Timezone:                 p.Timezone,
+		Currency:                 p.Currency,
+		RateSet:                  p.RateSet,
+		MatchRadiusKm:            p.MatchRadiusKm,
+		PriorityBoost:            time.Duration(p.PriorityBoostSecs) * time.Second,
+		PriorityNotifyDrivers:    p.PriorityNotifyDrivers,
+		MeetRadiusKm:             p.MeetRadiusKm,
+		CompleteRadiusKm:         p.CompleteRadiusKm,
+		MinTripKm:                p.MinTripKm,
+		SuspendOverdueInspection: p.SuspendOverdueInspection,
+	}
+}
+
+// EncodeRegions writes rs as f: a CSV file, or a GeoJSON FeatureCollection
+// with one feature per region, its area as the geometry (null without one).
+func EncodeRegions(w io.Writer, f bulk.Format, rs []Region) error {
+	if f == bulk.CSV {
+		rows := make([][]string, len(rs))
+		for i, r := range rs {
+			rows[i] = regionRow(r)
+		}
+		return bulk.WriteCSV(w, regionColumns, rows)
+	}
+	fc := featureCollection{Type: "FeatureCollection", Features: make([]feature, len(rs))}
+	for i, r := range rs {
+		fc.Features[i] = toFeature(r)
+	}
+	return json.NewEncoder(w).Encode(fc)
+}
+
+// DecodeRegions reads regions written as f, in EncodeRegions' layout. Cells
+// and geometries that cannot be read are reported for their row.
+func DecodeRegions(r io.Reader, f bulk.Format) ([]Region, error) {
+	var verr bulk.ValidationError
+	if f == bulk.JSON {
+		var fc featureCollection
+		if err := json.NewDecoder(r).Decode(&fc); err != nil {
+			return nil, fmt.Errorf("%w: %w", bulk.ErrMalformed, err)
+		}
+		if fc.Type != "FeatureCollection" {
+			return nil, fmt.Errorf("%w: not a GeoJSON FeatureCollection", bulk.ErrMalformed)
+		}
+		if len(fc.Features) > bulk.MaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", bulk.ErrMalformed, bulk.MaxRows)
+		}
+		out := make([]Region, len(fc.Features))
+		for i, ft := range fc.Features {
+			out[i] = fromProps(ft.Properties)
+			switch g := ft.Geometry; {
+			case g == nil:
+			case g.Type != "Polygon" || len(g.Coordinates) != 1:
+				verr.Add(i+1, "area", "geometry must be a Polygon without holes")
+			default:
+				out[i].Area = fromRing(g.Coordinates[0])
+			}
+		}
+		return out, verr.Err()
+	}
+	rows, err := bulk.ReadCSV(r, regionColumns, []string{"rate_set", "priority_boost_secs", "priority_notify_drivers", "area", "suspend_overdue_inspection"})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Region, len(rows))
+	for i, row := range rows {
+		var p regionProps
+		p.ID, p.Name, p.Timezone, p.Currency, p.RateSet = row[0], row[1], row[2], row[3], row[4]
+		for _, v := range []struct {
+			col int
+			dst *float64
+		}{{5, &p.MatchRadiusKm}, {8, &p.MeetRadiusKm}, {9, &p.CompleteRadiusKm}, {10, &p.MinTripKm}} {
+			if row[v.col] == "" {
+				continue
+			}
+			n, err := strconv.ParseFloat(row[v.col], 64)
+			if err != nil {
+				verr.Add(i+1, regionColumns[v.col], "not a number")
+			}
+			*v.dst = n
+		}
+		for _, v := range []struct {
+			col int
+			dst *int
+		}{{6, &p.PriorityBoostSecs}, {7, &p.PriorityNotifyDrivers}} {
+			if row[v.col] == "" {
+				continue
+			}
+			n, err := strconv.Atoi(row[v.col])
+			if err != nil {
+				verr.Add(i+1, regionColumns[v.col], "not a whole number")
+			}
+			*v.dst = n
+		}
+		if row[12] != "" {
+			b, err := strconv.ParseBool(row[12])
+			if err != nil {
+				verr.Add(i+1, regionColumns[12], "not true or false")
+			}
+			p.SuspendOverdueInspection = b
+		}
+		out[i] = fromProps(p)
+		area, err := parseWKT(row[11])
+		if err != nil {
+			verr.Add(i+1, "area", err.Error())
+		}
+		out[i].Area = area
+	}
+	return out, verr.Err()
+}
+
+// Import creates or replaces every region in rs that differs from the
+// stored one, all or none, and reloads the regions; with dryRun it only
+// reports the difference. Regions not in rs are left alone.
+func (s *Service) Import(ctx context.Context, rs []Region, dryRun bool) (bulk.Result, error) {
+	var verr bulk.ValidationError
+	seen := make(map[string]int, len(rs))
+	for i := range rs {
+		row := i + 1
+		var ferr *FieldError
+		if err := rs[i].validate(); errors.As(err, &ferr) {
+			verr.Add(row, ferr.Field, "invalid value")
+		} else if err != nil {
+			verr.Add(row, "", err.Error())
+		}
+		if first, ok := seen[rs[i].ID]; ok {
+			verr.Add(row, "region_id", fmt.Sprintf("repeats row %d", first))
+		} else {
+			seen[rs[i].ID] = row
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return bulk.Result{}, err
+	}
+	current, err := s.store.List(ctx)
+	if err != nil {
+		return bulk.Result{}, err
+	}
+	now := s.now()
+	res := bulk.Result{DryRun: dryRun, Changes: []bulk.Change{}}
+	var save []*Region
+	for i := range rs {
+		r := &rs[i]
+		change := bulk.Change{Action: bulk.Add, Key: r.ID}
+		if at := slices.IndexFunc(current, func(c Region) bool { return c.ID == r.ID }); at >= 0 {
+			change.Action = bulk.Update
+			change.Fields = bulk.Diff(regionColumns, regionRow(current[at]), regionRow(*r))
+			if len(change.Fields) == 0 {
+				res.Unchanged++
+				continue
+			}
+		}
+		r.UpdatedAt = now
+		save = append(save, r)
+		res.Changes = append(res.Changes, change)
+	}
+	if dryRun || len(save) == 0 {
+		return res, nil
+	}
+	if err := s.store.SaveAll(ctx, save); err != nil {
+		return bulk.Result{}, err
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return bulk.Result{}, err
+	}
+	return res, nil
+}
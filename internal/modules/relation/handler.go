@@ -18,6 +18,7 @@
 package relation
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -248,14 +249,14 @@ func writeError(c *gin.Context, status int, msg string) {
 }
 
 func writeRelationError(c *gin.Context, err error) {
-	switch err {
-	case ErrBadRequest:
+	switch {
+	case errors.Is(err, ErrBadRequest):
 		writeError(c, http.StatusBadRequest, err.Error())
-	case ErrNotFound:
+	case errors.Is(err, ErrNotFound):
 		writeError(c, http.StatusNotFound, err.Error())
-	case ErrConflict:
+	case errors.Is(err, ErrConflict):
 		writeError(c, http.StatusConflict, err.Error())
-	case ErrForbidden:
+	case errors.Is(err, ErrForbidden):
 		writeError(c, http.StatusForbidden, err.Error())
 	default:
 		writeError(c, http.StatusInternalServerError, "internal error")
@@ -3,6 +3,7 @@ package relation
 
 import (
 	"context"
+	"errors"
 
 	"ark/internal/http/middleware"
 )
@@ -121,7 +122,7 @@ func (s *Service) RemoveFriend(ctx context.Context, userID, friendID UserID) err
 // IsFriend reports whether uid1 and uid2 have an accepted friendship.
 func (s *Service) IsFriend(ctx context.Context, uid1, uid2 UserID) (bool, error) {
 	_, err := s.store.GetFriendship(ctx, uid1, uid2)
-	if err == ErrNotFound {
+	if errors.Is(err, ErrNotFound) {
 		return false, nil
 	}
 	if err != nil {
@@ -10,6 +10,8 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/pii"
 )
 
 // RelationStore defines the persistence operations required by the relation Service.
@@ -29,7 +31,8 @@ type RelationStore interface {
 
 // Store is the PostgreSQL implementation of RelationStore.
 type Store struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	pii *pii.Keyring
 }
 
 // NewStore creates a Store backed by the given connection pool.
@@ -37,6 +40,11 @@ func NewStore(db *pgxpool.Pool) *Store {
 	return &Store{db: db}
 }
 
+// SetKeyring sets the keyring used to decrypt and index users.phone.
+func (s *Store) SetKeyring(k *pii.Keyring) {
+	s.pii = k
+}
+
 // CreateRequest inserts a pending friend request row.
 func (s *Store) CreateRequest(ctx context.Context, from, to UserID) error {
 	now := time.Now()
@@ -228,6 +236,9 @@ func (s *Store) ListFriends(ctx context.Context, userID UserID) ([]Friend, error
 		if err := rows.Scan(&fr.UserID, &fr.Name, &fr.Phone, &remark, &groupID, &fr.Since); err != nil {
 			return nil, err
 		}
+		if fr.Phone, err = s.pii.Decrypt(fr.Phone); err != nil {
+			return nil, err
+		}
 		if remark.Valid {
 			fr.Remark = &remark.String
 		}
@@ -240,10 +251,10 @@ func (s *Store) ListFriends(ctx context.Context, userID UserID) ([]Friend, error
 	return friends, rows.Err()
 }
 
-// FindByPhone looks up a user by their phone number.
+// FindByPhone looks up a user by their phone number via its blind index.
 func (s *Store) FindByPhone(ctx context.Context, phone string) (*User, error) {
 	row := s.db.QueryRow(ctx, `
-		SELECT user_id, name, phone FROM users WHERE phone = $1`, phone,
+		SELECT user_id, name, phone FROM users WHERE phone_hash = $1`, s.pii.BlindIndex(phone),
 	)
 	var u User
 	err := row.Scan(&u.UserID, &u.Name, &u.Phone)
@@ -253,17 +264,22 @@ func (s *Store) FindByPhone(ctx context.Context, phone string) (*User, error) {
 	if err != nil {
 		return nil, err
 	}
+	if u.Phone, err = s.pii.Decrypt(u.Phone); err != nil {
+		return nil, err
+	}
 	return &u, nil
 }
 
-// SearchUsers returns up to 20 users whose name or phone matches the query string.
+// SearchUsers returns up to 20 users whose name contains the query string or
+// whose phone number equals it. Phones are encrypted, so partial phone matches
+// are not supported.
 func (s *Store) SearchUsers(ctx context.Context, query string) ([]User, error) {
 	rows, err := s.db.Query(ctx, `
 		SELECT user_id, name, phone FROM users
-		WHERE name ILIKE '%' || $1 || '%' OR phone LIKE '%' || $1 || '%'
+		WHERE name ILIKE '%' || $1 || '%' OR phone_hash = $2
 		ORDER BY name
 		LIMIT 20`,
-		query,
+		query, s.pii.BlindIndex(query),
 	)
 	if err != nil {
 		return nil, err
@@ -276,6 +292,9 @@ func (s *Store) SearchUsers(ctx context.Context, query string) ([]User, error) {
 		if err := rows.Scan(&u.UserID, &u.Name, &u.Phone); err != nil {
 			return nil, err
 		}
+		if u.Phone, err = s.pii.Decrypt(u.Phone); err != nil {
+			return nil, err
+		}
 		users = append(users, u)
 	}
 	return users, rows.Err()
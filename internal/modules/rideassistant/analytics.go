@@ -0,0 +1,111 @@
+// README: Conversation analytics for the ride assistant — per-turn log, conversion and abandonment report.
+package rideassistant
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ark/internal/sandbox"
+)
+
+// ErrNoConversationLog is returned by ConversationReport when no
+// ConversationLog was set.
+var ErrNoConversationLog = errors.New("conversation analytics not configured")
+
+// Turn is one processed message of a conversation, as the log sees it.
+type Turn struct {
+	ConversationID string // the session ID
+	UserID         string
+	Message        string // what the user said
+	Reply          string // what the assistant answered
+	Summary        string // the trip summary awaiting confirmation, if any
+	Status         string // response status: clarification, confirmation, completed, cancelled, chat
+	Stage          string
+	MissingFields  []string
+	OrderID        string // set on the turn that booked
+	At             time.Time
+}
+
+// ConversationLog stores conversation progress and summarises it for ops.
+type ConversationLog interface {
+	RecordTurn(ctx context.Context, t Turn) error
+	// Report summarises the conversations started in [from, to).
+	// Conversations without an order, not cancelled and idle since before
+	// idleBefore count as abandoned.
+	Report(ctx context.Context, from, to, idleBefore time.Time) (*ConversationReport, error)
+}
+
+// ConversationReport is the conversion summary for conversations started in
+// a time range.
+type ConversationReport struct {
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Conversations int       `json:"conversations"`
+	Booked        int       `json:"booked"`
+	Cancelled     int       `json:"cancelled"`
+	Abandoned     int       `json:"abandoned"`
+	// ConversionRate is Booked / Conversations.
+	ConversionRate float64 `json:"conversion_rate"`
+	// AvgTurnsToBooking is the mean number of messages in booked conversations.
+	AvgTurnsToBooking float64 `json:"avg_turns_to_booking"`
+	// Abandonment breaks Abandoned down by where the user stopped, most
+	// common first.
+	Abandonment []AbandonmentPoint `json:"abandonment"`
+}
+
+// AbandonmentPoint counts abandoned conversations that stopped at Stage
+// while MissingField was the first slot still unfilled ("" once all were).
+type AbandonmentPoint struct {
+	Stage        string `json:"stage"`
+	MissingField string `json:"missing_field"`
+	Count        int    `json:"count"`
+}
+
+// ConversationReport summarises the conversations started in [from, to).
+// A conversation is abandoned once its session would have expired.
+func (s *Service) ConversationReport(ctx context.Context, from, to time.Time) (*ConversationReport, error) {
+	if s.conversations == nil {
+		return nil, ErrNoConversationLog
+	}
+	r, err := s.conversations.Report(ctx, from, to, time.Now().Add(-sessionTTL))
+	if err != nil {
+		return nil, err
+	}
+	r.From, r.To = from, to
+	if r.Conversations > 0 {
+		r.ConversionRate = float64(r.Booked) / float64(r.Conversations)
+	}
+	if r.Abandonment == nil {
+		r.Abandonment = []AbandonmentPoint{}
+	}
+	return r, nil
+}
+
+// recordTurn logs the user's message and resp to the conversation log.
+// Test-mode conversations are left out of the analytics and the history.
+func (s *Service) recordTurn(ctx context.Context, userID, message string, resp *MessageResponse) {
+	if s.conversations == nil || resp.Session == nil || sandbox.Enabled(ctx) {
+		return
+	}
+	t := Turn{
+		ConversationID: resp.Session.ID,
+		UserID:         userID,
+		Message:        message,
+		Reply:          resp.Reply,
+		Status:         resp.Status,
+		Stage:          resp.Session.Stage,
+		MissingFields:  resp.Session.MissingFields,
+		At:             time.Now().UTC(),
+	}
+	if resp.Booking != nil {
+		t.OrderID = resp.Booking.OrderID
+	}
+	if sess, err := s.store.GetSession(t.ConversationID); err == nil && sess != nil {
+		t.Summary = sess.Summary
+	}
+	if err := s.conversations.RecordTurn(ctx, t); err != nil {
+		log.Printf("rideassistant: record turn for session %s: %v", t.ConversationID, err)
+	}
+}
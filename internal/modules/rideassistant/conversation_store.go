@@ -0,0 +1,199 @@
+// README: Postgres-backed ConversationLog and ConversationHistory (ai_conversations and ai_messages tables).
+package rideassistant
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConversationStore implements ConversationLog and ConversationHistory on
+// Postgres. It keeps one row per conversation with its latest stage and
+// summary, and the messages in ai_messages.
+type ConversationStore struct {
+	db *pgxpool.Pool
+}
+
+// NewConversationStore creates a ConversationStore.
+func NewConversationStore(db *pgxpool.Pool) *ConversationStore {
+	return &ConversationStore{db: db}
+}
+
+// RecordTurn counts the turn, moves the conversation to its stage and appends
+// its messages. The order ID, once set, is kept, and so is the summary until a
+// new one is made. A deleted conversation is still counted but keeps no owner,
+// summary or messages.
+func (s *ConversationStore) RecordTurn(ctx context.Context, t Turn) error {
+	missing := t.MissingFields
+	if missing == nil {
+		missing = []string{}
+	}
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO ai_conversations (id, started_at, updated_at, turns, last_status, stage, missing_fields, order_id, user_id, summary)
+        VALUES ($1, $2, $2, 1, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+        ON CONFLICT (id) DO UPDATE SET
+            updated_at     = EXCLUDED.updated_at,
+            turns          = ai_conversations.turns + 1,
+            last_status    = EXCLUDED.last_status,
+            stage          = EXCLUDED.stage,
+            missing_fields = EXCLUDED.missing_fields,
+            order_id       = COALESCE(ai_conversations.order_id, EXCLUDED.order_id),
+            user_id        = CASE WHEN ai_conversations.deleted_at IS NULL
+                                  THEN COALESCE(ai_conversations.user_id, EXCLUDED.user_id) END,
+            summary        = CASE WHEN ai_conversations.deleted_at IS NULL
+                                  THEN COALESCE(NULLIF(EXCLUDED.summary, ''), ai_conversations.summary) ELSE '' END`,
+		t.ConversationID, t.At, t.Status, t.Stage, missing, t.OrderID, t.UserID, t.Summary,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+        INSERT INTO ai_messages (conversation_id, role, text, created_at)
+        SELECT c.id, m.role, m.text, $2
+        FROM ai_conversations c,
+             (VALUES (1, $3::text, $4::text), (2, $5::text, $6::text)) AS m(seq, role, text)
+        WHERE c.id = $1 AND c.deleted_at IS NULL AND m.text <> ''
+        ORDER BY m.seq`,
+		t.ConversationID, t.At, RoleUser, t.Message, RoleAssistant, t.Reply,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Report implements ConversationLog.
+func (s *ConversationStore) Report(ctx context.Context, from, to, idleBefore time.Time) (*ConversationReport, error) {
+	var r ConversationReport
+	err := s.db.QueryRow(ctx, `
+        SELECT count(*),
+               count(*) FILTER (WHERE order_id IS NOT NULL),
+               count(*) FILTER (WHERE order_id IS NULL AND last_status = 'cancelled'),
+               count(*) FILTER (WHERE order_id IS NULL AND last_status <> 'cancelled' AND updated_at < $3),
+               COALESCE(avg(turns) FILTER (WHERE order_id IS NOT NULL), 0)
+        FROM ai_conversations
+        WHERE started_at >= $1 AND started_at < $2`,
+		from, to, idleBefore,
+	).Scan(&r.Conversations, &r.Booked, &r.Cancelled, &r.Abandoned, &r.AvgTurnsToBooking)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+        SELECT stage, COALESCE(missing_fields[1], ''), count(*)
+        FROM ai_conversations
+        WHERE started_at >= $1 AND started_at < $2
+          AND order_id IS NULL AND last_status <> 'cancelled' AND updated_at < $3
+        GROUP BY 1, 2
+        ORDER BY 3 DESC, 1, 2`,
+		from, to, idleBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p AbandonmentPoint
+		if err := rows.Scan(&p.Stage, &p.MissingField, &p.Count); err != nil {
+			return nil, err
+		}
+		r.Abandonment = append(r.Abandonment, p)
+	}
+	return &r, rows.Err()
+}
+
+// ListConversations implements ConversationHistory.
+func (s *ConversationStore) ListConversations(ctx context.Context, userID string) ([]Conversation, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT id, started_at, updated_at, turns, last_status, COALESCE(order_id, ''), summary
+        FROM ai_conversations
+        WHERE user_id = $1 AND deleted_at IS NULL
+        ORDER BY started_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Conversation
+	for rows.Next() {
+		var c Conversation
+		if err := rows.Scan(&c.ID, &c.StartedAt, &c.UpdatedAt, &c.Turns, &c.LastStatus, &c.OrderID, &c.Summary); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ListMessages implements ConversationHistory.
+func (s *ConversationStore) ListMessages(ctx context.Context, userID string) ([]Message, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT m.conversation_id, m.role, m.text, m.created_at
+        FROM ai_messages m
+        JOIN ai_conversations c ON c.id = m.conversation_id
+        WHERE c.user_id = $1 AND c.deleted_at IS NULL
+        ORDER BY m.id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Message
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.ConversationID, &m.Role, &m.Text, &m.At); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// DeleteConversation implements ConversationHistory.
+func (s *ConversationStore) DeleteConversation(ctx context.Context, userID, id string, at time.Time) (bool, error) {
+	n, err := s.softDelete(ctx, userID, id, at)
+	return n > 0, err
+}
+
+// DeleteUserConversations implements ConversationHistory.
+func (s *ConversationStore) DeleteUserConversations(ctx context.Context, userID string, at time.Time) (int, error) {
+	return s.softDelete(ctx, userID, "", at)
+}
+
+// softDelete drops the messages of the user's conversation id, or of all the
+// user's conversations when id is empty, and clears their owner and summary,
+// keeping the rows for the conversion report.
+func (s *ConversationStore) softDelete(ctx context.Context, userID, id string, at time.Time) (int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+        DELETE FROM ai_messages WHERE conversation_id IN (
+            SELECT id FROM ai_conversations
+            WHERE user_id = $1 AND ($2 = '' OR id = $2) AND deleted_at IS NULL)`,
+		userID, id,
+	); err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, `
+        UPDATE ai_conversations SET user_id = NULL, summary = '', deleted_at = $3
+        WHERE user_id = $1 AND ($2 = '' OR id = $2) AND deleted_at IS NULL`,
+		userID, id, at,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), tx.Commit(ctx)
+}
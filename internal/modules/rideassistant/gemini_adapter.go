@@ -32,6 +32,7 @@ func (a *GeminiAdapter) Parse(ctx context.Context, req ParserRequest) (*ParserRe
 		"current_time":      now.Format(time.RFC3339),
 		"user_location":     req.SessionState["pickup_text"],
 		"user_context_info": req.ContextInfo,
+		"reply_language":    req.Language,
 	}
 
 	// Include session state as conversation context so AI can see what's already known.
@@ -40,7 +41,7 @@ func (a *GeminiAdapter) Parse(ctx context.Context, req ParserRequest) (*ParserRe
 		prompt = fmt.Sprintf("Conversation Context:\n%s\n\nUser Message: %s", sessionCtx, req.UserMessage)
 	}
 
-	intent, err := a.provider.ParseUserIntent(ctx, prompt, ctxMap)
+	intent, err := a.provider.ParseUserIntent(ai.WithUsageUser(ctx, req.UserID), prompt, ctxMap)
 	if err != nil {
 		return nil, fmt.Errorf("gemini parse: %w", err)
 	}
@@ -70,6 +71,9 @@ func (a *GeminiAdapter) toParserResponse(ir *ai.IntentResult) *ParserResponse {
 		resp.DepartureAt = ir.ISOTime
 	}
 
+	resp.PassengerCount = ir.PassengerCount
+	resp.HasPet = ir.HasPet
+
 	// Build missing fields list from what the AI tells us.
 	resp.MissingFields = a.inferMissingFields(ir)
 
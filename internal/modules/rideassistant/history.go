@@ -0,0 +1,131 @@
+// README: Ride assistant chat history — listing, JSON export and soft deletion of a user's conversations.
+package rideassistant
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNoConversationHistory is returned when no ConversationHistory was set.
+	ErrNoConversationHistory = errors.New("conversation history not configured")
+	// ErrConversationNotFound is returned for a conversation the user does
+	// not own or already deleted.
+	ErrConversationNotFound = errors.New("conversation not found")
+)
+
+// Conversation is one of a user's ride assistant conversations.
+type Conversation struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Turns      int       `json:"turns"`
+	LastStatus string    `json:"last_status"`
+	OrderID    string    `json:"order_id,omitempty"`
+	Summary    string    `json:"summary,omitempty"`
+	// Messages is only filled in exports.
+	Messages []Message `json:"messages,omitempty"`
+}
+
+// Message roles.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is one message of a conversation.
+type Message struct {
+	ConversationID string    `json:"-"`
+	Role           string    `json:"role"`
+	Text           string    `json:"text"`
+	At             time.Time `json:"at"`
+}
+
+// ConversationExport is everything the assistant keeps about a user's
+// conversations, for a data access request.
+type ConversationExport struct {
+	UserID        string         `json:"user_id"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	Conversations []Conversation `json:"conversations"`
+}
+
+// ConversationHistory stores what users said to the assistant. Deleting a
+// conversation is soft: the analytics row stays, without its owner, summary
+// or messages. Implemented by ConversationStore.
+type ConversationHistory interface {
+	// ListConversations returns the user's conversations, newest first.
+	ListConversations(ctx context.Context, userID string) ([]Conversation, error)
+	// ListMessages returns the messages of the user's conversations, oldest
+	// first.
+	ListMessages(ctx context.Context, userID string) ([]Message, error)
+	// DeleteConversation reports whether the user had conversation id.
+	DeleteConversation(ctx context.Context, userID, id string, at time.Time) (bool, error)
+	// DeleteUserConversations returns how many conversations were deleted.
+	DeleteUserConversations(ctx context.Context, userID string, at time.Time) (int, error)
+}
+
+// SetConversationHistory lets users list, export and delete their
+// conversations. It is usually the same store as the ConversationLog.
+func (s *Service) SetConversationHistory(h ConversationHistory) {
+	s.history = h
+}
+
+// Conversations returns the user's conversations, newest first.
+func (s *Service) Conversations(ctx context.Context, userID string) ([]Conversation, error) {
+	if s.history == nil {
+		return nil, ErrNoConversationHistory
+	}
+	convs, err := s.history.ListConversations(ctx, userID)
+	if convs == nil {
+		convs = []Conversation{}
+	}
+	return convs, err
+}
+
+// ExportConversations returns the user's conversations with their messages.
+func (s *Service) ExportConversations(ctx context.Context, userID string) (*ConversationExport, error) {
+	convs, err := s.Conversations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.history.ListMessages(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byConv := make(map[string][]Message, len(convs))
+	for _, m := range msgs {
+		byConv[m.ConversationID] = append(byConv[m.ConversationID], m)
+	}
+	for i := range convs {
+		convs[i].Messages = byConv[convs[i].ID]
+	}
+	return &ConversationExport{UserID: userID, ExportedAt: time.Now().UTC(), Conversations: convs}, nil
+}
+
+// DeleteConversation deletes the user's conversation id, dropping its live
+// session and cached summary too.
+func (s *Service) DeleteConversation(ctx context.Context, userID, id string) error {
+	if s.history == nil {
+		return ErrNoConversationHistory
+	}
+	dropped := s.store.DeleteSession(userID, id)
+	ok, err := s.history.DeleteConversation(ctx, userID, id, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !ok && !dropped {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+// DeleteHistory deletes all of the user's conversations and live sessions and
+// returns how many conversations were deleted.
+func (s *Service) DeleteHistory(ctx context.Context, userID string) (int, error) {
+	if s.history == nil {
+		return 0, ErrNoConversationHistory
+	}
+	s.store.DeleteUserSessions(userID)
+	return s.history.DeleteUserConversations(ctx, userID, time.Now().UTC())
+}
@@ -1,7 +1,10 @@
 // README: Models for the ride assistant module — session state machine, API shapes, AI parser contract.
 package rideassistant
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // ---------------------------------------------------------------------------
 // Session stages
@@ -26,6 +29,8 @@ type Session struct {
 	PickupText      string
 	DropoffText     string
 	DepartureAt     *time.Time
+	PassengerCount  int  // 0 until the AI reports one
+	HasPet          bool
 	IsScheduled     bool
 	PendingQuestion string
 	Summary         string
@@ -98,6 +103,12 @@ func NewSessionView(s *Session) *SessionView {
 	if s.DepartureAt != nil {
 		known["departure_at"] = s.DepartureAt.Format(time.RFC3339)
 	}
+	if s.PassengerCount > 0 {
+		known["passenger_count"] = strconv.Itoa(s.PassengerCount)
+	}
+	if s.HasPet {
+		known["has_pet"] = "true"
+	}
 	return &SessionView{
 		ID:            s.ID,
 		Stage:         s.Stage,
@@ -115,6 +126,10 @@ type ParserRequest struct {
 	UserMessage  string            `json:"user_message"`
 	SessionState map[string]string `json:"session_state"`
 	ContextInfo  string            `json:"context_info,omitempty"`
+	// Language is the language the reply must be written in (ai.Language*).
+	Language string `json:"language,omitempty"`
+	// UserID attributes the planner's LLM usage; it is not sent to the model.
+	UserID string `json:"-"`
 }
 
 // ParserResponse is the structured output expected from the AI parser.
@@ -124,6 +139,8 @@ type ParserResponse struct {
 	PickupText        *string `json:"pickup_text,omitempty"`
 	DropoffText       *string `json:"dropoff_text,omitempty"`
 	DepartureAt       *string `json:"departure_at,omitempty"` // RFC3339
+	PassengerCount    int     `json:"passenger_count,omitempty"`
+	HasPet            bool    `json:"has_pet,omitempty"`
 	MissingFields     []string `json:"missing_fields,omitempty"`
 	NeedsConfirmation bool    `json:"needs_confirmation"`
 	ReadyToBook       bool    `json:"ready_to_book"`
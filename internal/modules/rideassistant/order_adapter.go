@@ -20,10 +20,13 @@ func NewOrderServiceAdapter(svc *order.Service) *OrderServiceAdapter {
 
 func (a *OrderServiceAdapter) Create(ctx context.Context, cmd CreateOrderCommand) (types.ID, error) {
 	return a.svc.Create(ctx, order.CreateCommand{
-		PassengerID: cmd.PassengerID,
-		Pickup:      cmd.Pickup,
-		Dropoff:     cmd.Dropoff,
-		RideType:    cmd.RideType,
+		PassengerID:    cmd.PassengerID,
+		Pickup:         cmd.Pickup,
+		Dropoff:        cmd.Dropoff,
+		RideType:       cmd.RideType,
+		PassengerCount: cmd.PassengerCount,
+		HasPet:         cmd.HasPet,
+		ConversationID: cmd.ConversationID,
 	})
 }
 
@@ -35,5 +38,8 @@ func (a *OrderServiceAdapter) CreateScheduled(ctx context.Context, cmd CreateSch
 		RideType:           cmd.RideType,
 		ScheduledAt:        cmd.ScheduledAt,
 		ScheduleWindowMins: cmd.ScheduleWindowMins,
+		PassengerCount:     cmd.PassengerCount,
+		HasPet:             cmd.HasPet,
+		ConversationID:     cmd.ConversationID,
 	})
 }
@@ -4,9 +4,13 @@ package rideassistant
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"log"
+	"strconv"
 	"time"
 
+	"ark/internal/ai"
+	"ark/internal/sandbox"
 	"ark/internal/types"
 )
 
@@ -18,10 +22,13 @@ type OrderCreator interface {
 
 // CreateOrderCommand mirrors order.CreateCommand to avoid circular imports.
 type CreateOrderCommand struct {
-	PassengerID types.ID
-	Pickup      types.Point
-	Dropoff     types.Point
-	RideType    string
+	PassengerID    types.ID
+	Pickup         types.Point
+	Dropoff        types.Point
+	RideType       string
+	PassengerCount int
+	HasPet         bool
+	ConversationID string
 }
 
 // CreateScheduledOrderCommand mirrors order.CreateScheduledCommand.
@@ -32,6 +39,9 @@ type CreateScheduledOrderCommand struct {
 	RideType           string
 	ScheduledAt        time.Time
 	ScheduleWindowMins int
+	PassengerCount     int
+	HasPet             bool
+	ConversationID     string
 }
 
 // Planner is the AI planner/parser interface.
@@ -44,6 +54,20 @@ type Geocoder interface {
 	Geocode(ctx context.Context, address string) (lat, lng float64, err error)
 }
 
+// UserContext describes what the assistant should know about a user when
+// parsing their messages, such as their saved places (place.Service).
+type UserContext interface {
+	ContextInfo(ctx context.Context, userID types.ID) (string, error)
+}
+
+// Languages stores each user's preferred language (user.Service), so the
+// assistant keeps replying in it when a message is too short to tell, and
+// notifications can follow it.
+type Languages interface {
+	Language(ctx context.Context, userID types.ID) (string, error)
+	SetLanguage(ctx context.Context, userID types.ID, lang string) error
+}
+
 // Service is the main ride assistant service.
 type Service struct {
 	store    *Store
@@ -51,6 +75,11 @@ type Service struct {
 	orders   OrderCreator // nil until order integration is wired
 	geocoder Geocoder     // nil if geocoding is not available
 	loc      *time.Location
+
+	conversations ConversationLog     // nil disables conversation analytics
+	history       ConversationHistory // nil disables listing, export and deletion
+	userContext   UserContext         // nil sends the client's context only
+	languages     Languages           // nil detects the language per message only
 }
 
 // NewService creates a ride assistant service.
@@ -68,21 +97,54 @@ func NewService(store *Store, planner Planner, orders OrderCreator, geocoder Geo
 	}
 }
 
+// SetConversationLog records every turn to log for conversion analytics.
+func (s *Service) SetConversationLog(log ConversationLog) {
+	s.conversations = log
+}
+
+// SetUserContext adds what uc knows about the user to every parser request,
+// after any context the client sent.
+func (s *Service) SetUserContext(uc UserContext) {
+	s.userContext = uc
+}
+
+// SetLanguages remembers the language detected in users' messages in l and
+// falls back to it when a message does not show its language.
+func (s *Service) SetLanguages(l Languages) {
+	s.languages = l
+}
+
 // HandleMessage is the main entry point for processing a user message.
 // It follows a synchronous flow: get/create session → call AI → merge → respond.
 func (s *Service) HandleMessage(ctx context.Context, userID string, req MessageRequest) (*MessageResponse, error) {
+	resp, err := s.handleMessage(ctx, userID, req)
+	if err == nil {
+		s.recordTurn(ctx, userID, req.Message, resp)
+	}
+	return resp, err
+}
+
+func (s *Service) handleMessage(ctx context.Context, userID string, req MessageRequest) (*MessageResponse, error) {
 	// 1. Get or create session.
 	sess, err := s.getOrCreateSession(userID, req.SessionID)
 	if err != nil {
 		return nil, fmt.Errorf("session lookup: %w", err)
 	}
 
-	// 2. Call AI parser with timeout.
-	aiCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	// 2. Call AI parser with timeout; it leaves room for the provider's retries.
+	aiCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	parserReq := s.buildParserRequest(sess, req)
-	parsed, err := s.planner.Parse(aiCtx, parserReq)
+	parserReq.ContextInfo = s.contextInfo(ctx, userID, req.ContextInfo)
+	parserReq.Language = s.replyLanguage(ctx, userID, req.Message)
+	parserReq.UserID = userID
+	planner := s.planner
+	if sandbox.Enabled(ctx) {
+		// Test mode never calls Gemini.
+		planner = NewStubPlanner()
+	}
+	parsed, err := planner.Parse(aiCtx, parserReq)
 	if err != nil {
 		return nil, fmt.Errorf("ai planner: %w", err)
 	}
@@ -111,7 +173,7 @@ func (s *Service) HandleMessage(ctx context.Context, userID string, req MessageR
 	s.store.UpdateSession(sess)
 
 	// 6. Decide response.
-	return s.buildResponse(ctx, sess, parsed)
+	return s.buildResponse(ctx, sess, parsed, parserReq.Language)
 }
 
 // ---------------------------------------------------------------------------
@@ -161,6 +223,12 @@ func (s *Service) buildParserRequest(sess *Session, req MessageRequest) ParserRe
 	if sess.DepartureAt != nil {
 		state["departure_at"] = sess.DepartureAt.Format(time.RFC3339)
 	}
+	if sess.PassengerCount > 0 {
+		state["passenger_count"] = strconv.Itoa(sess.PassengerCount)
+	}
+	if sess.HasPet {
+		state["has_pet"] = "true"
+	}
 	if sess.PendingQuestion != "" {
 		state["pending_question"] = sess.PendingQuestion
 	}
@@ -172,6 +240,52 @@ func (s *Service) buildParserRequest(sess *Session, req MessageRequest) ParserRe
 	}
 }
 
+// contextInfo appends the user's stored context to the client's. A lookup
+// failure only costs the planner some hints, so it is logged, not returned.
+func (s *Service) contextInfo(ctx context.Context, userID, client string) string {
+	if s.userContext == nil {
+		return client
+	}
+	info, err := s.userContext.ContextInfo(ctx, types.ID(userID))
+	if err != nil {
+		log.Printf("rideassistant: user context for %s: %v", userID, err)
+		return client
+	}
+	if client == "" || info == "" {
+		return client + info
+	}
+	return client + "\n" + info
+}
+
+// replyLanguage returns the language to reply to message in: the one it is
+// written in, which also becomes the user's preferred language, or else the
+// preferred one. Like contextInfo, store failures are only logged.
+func (s *Service) replyLanguage(ctx context.Context, userID, message string) string {
+	detected := ai.DetectLanguage(message)
+	if s.languages == nil {
+		if detected == "" {
+			return ai.DefaultLanguage
+		}
+		return detected
+	}
+	stored, err := s.languages.Language(ctx, types.ID(userID))
+	if err != nil {
+		log.Printf("rideassistant: language of %s: %v", userID, err)
+	}
+	switch {
+	case detected != "" && detected != stored:
+		if err := s.languages.SetLanguage(ctx, types.ID(userID), detected); err != nil {
+			log.Printf("rideassistant: set language of %s: %v", userID, err)
+		}
+		return detected
+	case detected != "":
+		return detected
+	case stored != "":
+		return stored
+	}
+	return ai.DefaultLanguage
+}
+
 // ---------------------------------------------------------------------------
 // Merge AI results into session
 // ---------------------------------------------------------------------------
@@ -189,6 +303,13 @@ func (s *Service) mergeSession(sess *Session, parsed *ParserResponse) {
 			sess.DepartureAt = &t
 		}
 	}
+	// Party details stick once mentioned; later turns rarely repeat them.
+	if parsed.PassengerCount > 0 {
+		sess.PassengerCount = parsed.PassengerCount
+	}
+	if parsed.HasPet {
+		sess.HasPet = true
+	}
 
 	// Update pending question and summary from AI reply.
 	if len(parsed.MissingFields) > 0 {
@@ -207,7 +328,29 @@ func (s *Service) mergeSession(sess *Session, parsed *ParserResponse) {
 // Response builder
 // ---------------------------------------------------------------------------
 
-func (s *Service) buildResponse(ctx context.Context, sess *Session, parsed *ParserResponse) (*MessageResponse, error) {
+// Replies the service writes itself rather than the planner, per language.
+var (
+	replyPastDeparture = map[string]string{
+		ai.LanguageZhTW: "您指定的出發時間已經過了，請提供一個未來的時間。",
+		ai.LanguageEn:   "The departure time you gave has already passed. Please give a time in the future.",
+		ai.LanguageJa:   "指定された出発時刻はすでに過ぎています。これからの時刻を教えてください。",
+	}
+	replyBookingFailed = map[string]string{
+		ai.LanguageZhTW: "抱歉，建立訂單時發生錯誤，請稍後再試。",
+		ai.LanguageEn:   "Sorry, something went wrong while booking your ride. Please try again later.",
+		ai.LanguageJa:   "申し訳ありません。配車の手配中にエラーが発生しました。しばらくしてからもう一度お試しください。",
+	}
+)
+
+// localized returns replies in lang, falling back to the default language.
+func localized(replies map[string]string, lang string) string {
+	if r, ok := replies[lang]; ok {
+		return r
+	}
+	return replies[ai.DefaultLanguage]
+}
+
+func (s *Service) buildResponse(ctx context.Context, sess *Session, parsed *ParserResponse, lang string) (*MessageResponse, error) {
 	view := NewSessionView(sess)
 
 	// Validation before booking: departure must be in the future.
@@ -215,7 +358,7 @@ func (s *Service) buildResponse(ctx context.Context, sess *Session, parsed *Pars
 		if sess.DepartureAt.Before(time.Now()) {
 			return &MessageResponse{
 				Status:  "clarification",
-				Reply:   "您指定的出發時間已經過了，請提供一個未來的時間。",
+				Reply:   localized(replyPastDeparture, lang),
 				Session: view,
 			}, nil
 		}
@@ -228,7 +371,7 @@ func (s *Service) buildResponse(ctx context.Context, sess *Session, parsed *Pars
 			log.Printf("rideassistant: booking failed for session %s: %v", sess.ID, err)
 			return &MessageResponse{
 				Status:  "clarification",
-				Reply:   "抱歉，建立訂單時發生錯誤，請稍後再試。",
+				Reply:   localized(replyBookingFailed, lang),
 				Session: view,
 			}, nil
 		}
@@ -295,6 +438,9 @@ func (s *Service) createBooking(ctx context.Context, sess *Session) (*BookingRes
 			RideType:           "standard",
 			ScheduledAt:        *sess.DepartureAt,
 			ScheduleWindowMins: 15,
+			PassengerCount:     sess.PassengerCount,
+			HasPet:             sess.HasPet,
+			ConversationID:     sess.ID,
 		})
 		if err != nil {
 			return nil, err
@@ -303,10 +449,13 @@ func (s *Service) createBooking(ctx context.Context, sess *Session) (*BookingRes
 	}
 
 	orderID, err := s.orders.Create(ctx, CreateOrderCommand{
-		PassengerID: userID,
-		Pickup:      pickup,
-		Dropoff:     dropoff,
-		RideType:    "standard",
+		PassengerID:    userID,
+		Pickup:         pickup,
+		Dropoff:        dropoff,
+		RideType:       "standard",
+		PassengerCount: sess.PassengerCount,
+		HasPet:         sess.HasPet,
+		ConversationID: sess.ID,
 	})
 	if err != nil {
 		return nil, err
@@ -315,6 +464,9 @@ func (s *Service) createBooking(ctx context.Context, sess *Session) (*BookingRes
 }
 
 func (s *Service) geocodeAddress(ctx context.Context, address string) (types.Point, error) {
+	if sandbox.Enabled(ctx) {
+		return sandboxGeocode(address), nil
+	}
 	if s.geocoder == nil {
 		return types.Point{}, nil
 	}
@@ -324,3 +476,15 @@ func (s *Service) geocodeAddress(ctx context.Context, address string) (types.Poi
 	}
 	return types.Point{Lat: lat, Lng: lng}, nil
 }
+
+// sandboxGeocode places test-mode addresses at a stable point within ~1 km of
+// Taipei 101 so E2E runs get realistic, repeatable coordinates without Maps.
+func sandboxGeocode(address string) types.Point {
+	h := fnv.New32a()
+	h.Write([]byte(address))
+	v := h.Sum32()
+	return types.Point{
+		Lat: 25.033964 + (float64(v&0xffff)/0xffff-0.5)*0.02,
+		Lng: 121.564468 + (float64(v>>16)/0xffff-0.5)*0.02,
+	}
+}
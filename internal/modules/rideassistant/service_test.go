@@ -2,17 +2,24 @@ package rideassistant
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
+
+	"ark/internal/ai"
+	"ark/internal/sandbox"
+	"ark/internal/types"
 )
 
 // mockPlanner lets tests control AI responses.
 type mockPlanner struct {
 	response *ParserResponse
 	err      error
+	last     ParserRequest
 }
 
-func (m *mockPlanner) Parse(_ context.Context, _ ParserRequest) (*ParserResponse, error) {
+func (m *mockPlanner) Parse(_ context.Context, req ParserRequest) (*ParserResponse, error) {
+	m.last = req
 	return m.response, m.err
 }
 
@@ -187,3 +194,326 @@ func TestHandleMessage_SessionPersistence(t *testing.T) {
 		t.Error("expected dropoff_text to be merged")
 	}
 }
+
+// recordingOrders captures the commands the service sends to the order module.
+type recordingOrders struct {
+	scheduled []CreateScheduledOrderCommand
+}
+
+func (r *recordingOrders) Create(context.Context, CreateOrderCommand) (types.ID, error) {
+	return "o-instant", nil
+}
+
+func (r *recordingOrders) CreateScheduled(_ context.Context, cmd CreateScheduledOrderCommand) (types.ID, error) {
+	r.scheduled = append(r.scheduled, cmd)
+	return "o-scheduled", nil
+}
+
+func TestHandleMessage_PartyFlowsIntoOrder(t *testing.T) {
+	pickup := "台北車站"
+	dropoff := "桃園機場"
+	dep := time.Now().Add(2 * time.Hour).Format(time.RFC3339)
+	planner := &mockPlanner{response: &ParserResponse{
+		Intent:         "booking",
+		Reply:          "確認嗎？",
+		PickupText:     &pickup,
+		DropoffText:    &dropoff,
+		DepartureAt:    &dep,
+		PassengerCount: 5,
+		HasPet:         true,
+	}}
+	orders := &recordingOrders{}
+	svc := NewService(NewStore(), planner, orders, nil)
+
+	resp, err := svc.HandleMessage(context.Background(), "user1", MessageRequest{Message: "五個人帶一隻狗"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Session.KnownFields["passenger_count"] != "5" || resp.Session.KnownFields["has_pet"] != "true" {
+		t.Errorf("expected party in known fields, got %v", resp.Session.KnownFields)
+	}
+
+	// The confirming turn does not repeat the party; the session keeps it.
+	planner.response = &ParserResponse{Intent: "completed", Reply: "已預約", ReadyToBook: true}
+	if _, err := svc.HandleMessage(context.Background(), "user1", MessageRequest{Message: "確認"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(orders.scheduled) != 1 {
+		t.Fatalf("expected one scheduled order, got %d", len(orders.scheduled))
+	}
+	if cmd := orders.scheduled[0]; cmd.PassengerCount != 5 || !cmd.HasPet {
+		t.Errorf("expected passenger_count=5 has_pet=true, got %d %v", cmd.PassengerCount, cmd.HasPet)
+	}
+	if cmd := orders.scheduled[0]; cmd.ConversationID != resp.Session.ID {
+		t.Errorf("expected conversation_id=%s, got %q", resp.Session.ID, cmd.ConversationID)
+	}
+}
+
+// fakeConversationLog keeps the turns it is given.
+type fakeConversationLog struct {
+	turns  []Turn
+	report ConversationReport
+}
+
+func (f *fakeConversationLog) RecordTurn(_ context.Context, t Turn) error {
+	f.turns = append(f.turns, t)
+	return nil
+}
+
+func (f *fakeConversationLog) Report(context.Context, time.Time, time.Time, time.Time) (*ConversationReport, error) {
+	r := f.report
+	return &r, nil
+}
+
+func TestHandleMessage_RecordsTurns(t *testing.T) {
+	pickup := "台北車站"
+	dropoff := "桃園機場"
+	dep := time.Now().Add(2 * time.Hour).Format(time.RFC3339)
+	planner := &mockPlanner{response: &ParserResponse{
+		Intent:        "booking",
+		Reply:         "幾點出發？",
+		PickupText:    &pickup,
+		DropoffText:   &dropoff,
+		MissingFields: []string{"departure_time"},
+	}}
+	convs := &fakeConversationLog{}
+	svc := newTestService(planner)
+	svc.SetConversationLog(convs)
+
+	ctx := context.Background()
+	first, _ := svc.HandleMessage(ctx, "user1", MessageRequest{Message: "台北車站到桃園機場"})
+	planner.response = &ParserResponse{Intent: "completed", Reply: "已預約", DepartureAt: &dep, ReadyToBook: true}
+	if _, err := svc.HandleMessage(ctx, "user1", MessageRequest{Message: "兩小時後"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(convs.turns) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(convs.turns))
+	}
+	if got := convs.turns[0]; got.ConversationID != first.Session.ID || got.Stage != StageCollecting || len(got.MissingFields) != 1 || got.MissingFields[0] != "departure_time" || got.OrderID != "" {
+		t.Errorf("first turn = %+v", got)
+	}
+	if got := convs.turns[0]; got.UserID != "user1" || got.Message != "台北車站到桃園機場" || got.Reply != "幾點出發？" {
+		t.Errorf("first turn messages = %+v", got)
+	}
+	if got := convs.turns[1]; got.ConversationID != first.Session.ID || got.Status != "completed" || got.OrderID != "stub_"+first.Session.ID {
+		t.Errorf("booking turn = %+v", got)
+	}
+
+	// Test-mode conversations stay out of the analytics.
+	if _, err := svc.HandleMessage(sandbox.WithContext(ctx), "user2", MessageRequest{Message: "hi"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(convs.turns) != 2 {
+		t.Errorf("sandbox turn was recorded")
+	}
+}
+
+func TestConversationReport(t *testing.T) {
+	svc := newTestService(&mockPlanner{})
+	if _, err := svc.ConversationReport(context.Background(), time.Now().Add(-time.Hour), time.Now()); err != ErrNoConversationLog {
+		t.Fatalf("expected ErrNoConversationLog, got %v", err)
+	}
+
+	svc.SetConversationLog(&fakeConversationLog{report: ConversationReport{Conversations: 8, Booked: 2, Abandoned: 5, Cancelled: 1}})
+	r, err := svc.ConversationReport(context.Background(), time.Now().Add(-time.Hour), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.ConversionRate != 0.25 || r.Abandonment == nil {
+		t.Errorf("report = %+v, want 25%% conversion and an empty abandonment list", r)
+	}
+}
+
+// capturingPlanner records the requests it parses.
+type capturingPlanner struct {
+	reqs []ParserRequest
+}
+
+func (c *capturingPlanner) Parse(_ context.Context, req ParserRequest) (*ParserResponse, error) {
+	c.reqs = append(c.reqs, req)
+	return &ParserResponse{Intent: "chat"}, nil
+}
+
+type fakeUserContext map[types.ID]string
+
+func (f fakeUserContext) ContextInfo(_ context.Context, userID types.ID) (string, error) {
+	return f[userID], nil
+}
+
+func TestHandleMessage_InjectsUserContext(t *testing.T) {
+	planner := &capturingPlanner{}
+	svc := newTestService(planner)
+	svc.SetUserContext(fakeUserContext{"user1": "使用者常用地點：\n- 咖啡店：台北市信義區松高路1號"})
+	ctx := context.Background()
+
+	if _, err := svc.HandleMessage(ctx, "user1", MessageRequest{Message: "去常去的那家咖啡", ContextInfo: "目前位置：台北車站"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.HandleMessage(ctx, "user2", MessageRequest{Message: "hi", ContextInfo: "目前位置：台北車站"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := planner.reqs[0].ContextInfo, "目前位置：台北車站\n使用者常用地點：\n- 咖啡店：台北市信義區松高路1號"; got != want {
+		t.Errorf("context = %q, want %q", got, want)
+	}
+	if got := planner.reqs[1].ContextInfo; got != "目前位置：台北車站" {
+		t.Errorf("context without places = %q", got)
+	}
+}
+
+// fakeHistory keeps the recorded turns as conversations and messages.
+type fakeHistory struct {
+	fakeConversationLog
+	owner   map[string]string // conversation ID -> user ID
+	deleted map[string]bool
+}
+
+func (f *fakeHistory) RecordTurn(ctx context.Context, t Turn) error {
+	if f.owner[t.ConversationID] == "" && !f.deleted[t.ConversationID] {
+		f.owner[t.ConversationID] = t.UserID
+	}
+	return f.fakeConversationLog.RecordTurn(ctx, t)
+}
+
+func (f *fakeHistory) ListConversations(_ context.Context, userID string) ([]Conversation, error) {
+	var out []Conversation
+	for id, owner := range f.owner {
+		if owner == userID && !f.deleted[id] {
+			out = append(out, Conversation{ID: id})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeHistory) ListMessages(_ context.Context, userID string) ([]Message, error) {
+	var out []Message
+	for _, t := range f.turns {
+		if f.owner[t.ConversationID] == userID && !f.deleted[t.ConversationID] {
+			out = append(out,
+				Message{ConversationID: t.ConversationID, Role: RoleUser, Text: t.Message, At: t.At},
+				Message{ConversationID: t.ConversationID, Role: RoleAssistant, Text: t.Reply, At: t.At})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeHistory) DeleteConversation(_ context.Context, userID, id string, _ time.Time) (bool, error) {
+	if f.owner[id] != userID || f.deleted[id] {
+		return false, nil
+	}
+	f.deleted[id] = true
+	return true, nil
+}
+
+func (f *fakeHistory) DeleteUserConversations(ctx context.Context, userID string, at time.Time) (int, error) {
+	n := 0
+	for id := range f.owner {
+		if ok, _ := f.DeleteConversation(ctx, userID, id, at); ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestConversationHistory_ExportAndDelete(t *testing.T) {
+	pickup := "台北車站"
+	planner := &mockPlanner{response: &ParserResponse{
+		Intent:        "booking",
+		Reply:         "請問您要去哪裡？",
+		PickupText:    &pickup,
+		MissingFields: []string{"dropoff", "departure_time"},
+	}}
+	history := &fakeHistory{owner: map[string]string{}, deleted: map[string]bool{}}
+	svc := newTestService(planner)
+	svc.SetConversationLog(history)
+	svc.SetConversationHistory(history)
+	ctx := context.Background()
+
+	first, _ := svc.HandleMessage(ctx, "user1", MessageRequest{Message: "我在台北車站"})
+	if _, err := svc.HandleMessage(ctx, "user2", MessageRequest{Message: "hi"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	export, err := svc.ExportConversations(ctx, "user1")
+	if err != nil {
+		t.Fatalf("ExportConversations: %v", err)
+	}
+	if len(export.Conversations) != 1 || export.Conversations[0].ID != first.Session.ID {
+		t.Fatalf("export = %+v, want only user1's conversation", export)
+	}
+	if msgs := export.Conversations[0].Messages; len(msgs) != 2 || msgs[0].Text != "我在台北車站" || msgs[1].Role != RoleAssistant {
+		t.Errorf("messages = %+v", msgs)
+	}
+
+	// Another user cannot delete it; its owner can, which also ends the
+	// live session and its summary.
+	if err := svc.DeleteConversation(ctx, "user2", first.Session.ID); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("DeleteConversation by another user = %v, want ErrConversationNotFound", err)
+	}
+	if err := svc.DeleteConversation(ctx, "user1", first.Session.ID); err != nil {
+		t.Fatalf("DeleteConversation: %v", err)
+	}
+	if sess, _ := svc.store.GetActiveSessionByUserID("user1"); sess != nil {
+		t.Errorf("session %s survived deletion", sess.ID)
+	}
+	if convs, _ := svc.Conversations(ctx, "user1"); len(convs) != 0 {
+		t.Errorf("conversations after deletion = %+v", convs)
+	}
+
+	if n, err := svc.DeleteHistory(ctx, "user2"); err != nil || n != 1 {
+		t.Errorf("DeleteHistory = %d, %v; want 1", n, err)
+	}
+}
+
+type fakeLanguages map[types.ID]string
+
+func (f fakeLanguages) Language(_ context.Context, userID types.ID) (string, error) {
+	return f[userID], nil
+}
+
+func (f fakeLanguages) SetLanguage(_ context.Context, userID types.ID, lang string) error {
+	f[userID] = lang
+	return nil
+}
+
+func TestHandleMessage_RepliesInUserLanguage(t *testing.T) {
+	planner := &mockPlanner{response: &ParserResponse{Intent: "chat", Reply: "ok"}}
+	svc := newTestService(planner)
+	langs := fakeLanguages{}
+	svc.SetLanguages(langs)
+	ctx := context.Background()
+
+	send := func(msg string) string {
+		t.Helper()
+		if _, err := svc.HandleMessage(ctx, "user1", MessageRequest{Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+		return planner.last.Language
+	}
+
+	if got := send("10:30"); got != ai.LanguageZhTW {
+		t.Errorf("unknown language: planner asked for %q, want the default", got)
+	}
+	if got := send("明日の朝、羽田空港まで"); got != ai.LanguageJa || langs["user1"] != ai.LanguageJa {
+		t.Errorf("Japanese message: planner asked for %q, stored %q", got, langs["user1"])
+	}
+	// A message that does not show its language keeps the stored one.
+	if got := send("10:30"); got != ai.LanguageJa {
+		t.Errorf("follow-up: planner asked for %q, want ja", got)
+	}
+	if got := send("Actually, take me to Taipei 101"); got != ai.LanguageEn || langs["user1"] != ai.LanguageEn {
+		t.Errorf("switch to English: planner asked for %q, stored %q", got, langs["user1"])
+	}
+
+	// Replies the service writes itself follow the language too.
+	pickup, dropoff := "Taipei Main Station", "Taipei 101"
+	dep := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	planner.response = &ParserResponse{Intent: "booking", PickupText: &pickup, DropoffText: &dropoff, DepartureAt: &dep, ReadyToBook: true}
+	resp, err := svc.HandleMessage(ctx, "user1", MessageRequest{Message: "Yes, book it"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Reply != replyPastDeparture[ai.LanguageEn] {
+		t.Errorf("past departure reply = %q, want English", resp.Reply)
+	}
+}
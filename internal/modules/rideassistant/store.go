@@ -91,6 +91,38 @@ func (s *Store) CancelSession(id string) error {
 	return s.setStage(id, StageCancelled)
 }
 
+// DeleteSession drops the user's session id with its cached summary. It
+// reports whether there was one.
+func (s *Store) DeleteSession(userID, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok || sess.UserID != userID {
+		return false
+	}
+	delete(s.sessions, id)
+	if s.byUser[userID] == id {
+		delete(s.byUser, userID)
+	}
+	return true
+}
+
+// DeleteUserSessions drops every session (and its conversation) owned by the
+// user. Used by account deletion.
+func (s *Store) DeleteUserSessions(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for id, sess := range s.sessions {
+		if sess.UserID == userID {
+			delete(s.sessions, id)
+			n++
+		}
+	}
+	delete(s.byUser, userID)
+	return n
+}
+
 // ---------------------------------------------------------------------------
 // Internals
 // ---------------------------------------------------------------------------
@@ -0,0 +1,150 @@
+// README: Risk HTTP handlers — the staff review queue of users and orders with high fraud scores.
+//
+// Endpoints:
+//
+//	GET  /api/ops/risk/cases                      — cases by status (?status=, default open; ?limit=)
+//	GET  /api/ops/risk/cases/:type/:id            — one case with its signals (type is user or order)
+//	POST /api/ops/risk/cases/:type/:id/resolve    — close a case ({"outcome": "cleared"|"confirmed", "note": "..."})
+//	GET  /api/ops/risk/scores/:type/:id           — current score of a user or order
+//
+// Auth: all routes require the ops key middleware.
+package risk
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/types"
+)
+
+// Handler holds the risk HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type resolveReq struct {
+	Outcome string `json:"outcome"`
+	Note    string `json:"note"`
+}
+
+type caseResp struct {
+	SubjectType string   `json:"subject_type"`
+	SubjectID   types.ID `json:"subject_id"`
+	Score       int      `json:"score"`
+	Status      string   `json:"status"`
+	OpenedAt    int64    `json:"opened_at"`
+	UpdatedAt   int64    `json:"updated_at"`
+	ResolvedAt  *int64   `json:"resolved_at,omitempty"`
+	Note        string   `json:"note,omitempty"`
+}
+
+type signalResp struct {
+	Kind      string    `json:"kind"`
+	UserID    types.ID  `json:"user_id"`
+	OrderID   *types.ID `json:"order_id,omitempty"`
+	Weight    int       `json:"weight"`
+	Detail    string    `json:"detail"`
+	CreatedAt int64     `json:"created_at"`
+}
+
+func toCaseResp(c *Case) caseResp {
+	out := caseResp{
+		SubjectType: c.SubjectType,
+		SubjectID:   c.SubjectID,
+		Score:       c.Score,
+		Status:      c.Status,
+		OpenedAt:    c.OpenedAt.Unix(),
+		UpdatedAt:   c.UpdatedAt.Unix(),
+		Note:        c.Note,
+	}
+	if c.ResolvedAt != nil {
+		ts := c.ResolvedAt.Unix()
+		out.ResolvedAt = &ts
+	}
+	return out
+}
+
+// ListCases handles GET /api/ops/risk/cases.
+func (h *Handler) ListCases(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	cases, err := h.svc.ListCases(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	out := make([]caseResp, len(cases))
+	for i := range cases {
+		out[i] = toCaseResp(&cases[i])
+	}
+	c.JSON(http.StatusOK, map[string]any{"cases": out})
+}
+
+// GetCase handles GET /api/ops/risk/cases/:type/:id.
+func (h *Handler) GetCase(c *gin.Context) {
+	rc, signals, err := h.svc.GetCase(c.Request.Context(), c.Param("type"), types.ID(c.Param("id")))
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	out := make([]signalResp, len(signals))
+	for i, s := range signals {
+		out[i] = signalResp{Kind: s.Kind, UserID: s.UserID, OrderID: s.OrderID, Weight: s.Weight, Detail: s.Detail, CreatedAt: s.CreatedAt.Unix()}
+	}
+	c.JSON(http.StatusOK, map[string]any{"case": toCaseResp(rc), "signals": out})
+}
+
+// Resolve handles POST /api/ops/risk/cases/:type/:id/resolve.
+func (h *Handler) Resolve(c *gin.Context) {
+	var req resolveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	rc, err := h.svc.Resolve(c.Request.Context(), ResolveCommand{
+		SubjectType: c.Param("type"),
+		SubjectID:   types.ID(c.Param("id")),
+		Outcome:     req.Outcome,
+		Note:        req.Note,
+	})
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toCaseResp(rc))
+}
+
+// Score handles GET /api/ops/risk/scores/:type/:id.
+func (h *Handler) Score(c *gin.Context) {
+	subjectType, id := c.Param("type"), types.ID(c.Param("id"))
+	score, err := h.svc.Score(c.Request.Context(), subjectType, id)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"subject_type": subjectType, "subject_id": id, "score": score})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeRiskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidState):
+		writeError(c, http.StatusConflict, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,96 @@
+// README: Risk domain model — fraud signals against users and orders, their scores, and the staff review cases they open.
+package risk
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Signal kinds.
+const (
+	// SignalSpeedJump is a driver position implying an impossible speed since
+	// the previous one, typical of GPS spoofing.
+	SignalSpeedJump = "speed_jump"
+	// SignalSharedDevice is a trip whose driver and passenger accounts were
+	// registered from the same device.
+	SignalSharedDevice = "shared_device"
+	// SignalRepeatPair is a driver and passenger repeatedly completing trips
+	// together almost as soon as the passenger boards.
+	SignalRepeatPair = "repeat_pair"
+	// SignalPromoVelocity is a passenger redeeming ride credits unusually fast.
+	SignalPromoVelocity = "promo_velocity"
+	// SignalMultiAccount is an account signing in on an install already used
+	// by several other accounts.
+	SignalMultiAccount = "multi_account"
+	// SignalTripLocation is a trip started or completed while the driver's
+	// GPS could not show them at the pickup or dropoff.
+	SignalTripLocation = "trip_location"
+)
+
+// signalWeights is each kind's contribution to a score. A lone speed jump or
+// trip location discrepancy (a GPS glitch can cause either) stays below
+// ReviewScore.
+var signalWeights = map[string]int{
+	SignalSpeedJump:     25,
+	SignalSharedDevice:  60,
+	SignalRepeatPair:    40,
+	SignalPromoVelocity: 30,
+	SignalMultiAccount:  30,
+	SignalTripLocation:  20,
+}
+
+// Subjects a score and a case are kept for.
+const (
+	SubjectUser  = "user"
+	SubjectOrder = "order"
+)
+
+// Case statuses. Staff close an open case as cleared (no fraud) or confirmed.
+const (
+	CaseOpen      = "open"
+	CaseCleared   = "cleared"
+	CaseConfirmed = "confirmed"
+)
+
+const (
+	// ReviewScore is the score at which a subject is queued for review.
+	ReviewScore = 50
+	// HoldScore is the order score at which the driver's payout for the trip
+	// is held, when holds are enabled.
+	HoldScore = 60
+	// scoreWindow is how long a user's signals count towards their score.
+	scoreWindow = 30 * 24 * time.Hour
+)
+
+// Signal is one observation of suspicious activity by UserID, on OrderID when
+// it concerns a specific trip.
+type Signal struct {
+	ID        int64
+	Kind      string
+	UserID    types.ID
+	OrderID   *types.ID
+	Weight    int
+	Detail    string
+	CreatedAt time.Time
+}
+
+// Case is a subject queued for staff review. Score is the subject's score
+// when the case was last refreshed.
+type Case struct {
+	SubjectType string
+	SubjectID   types.ID
+	Score       int
+	Status      string
+	OpenedAt    time.Time
+	UpdatedAt   time.Time
+	ResolvedAt  *time.Time
+	Note        string
+}
+
+var (
+	ErrNotFound     = errors.New("risk case not found")
+	ErrBadRequest   = errors.New("bad request")
+	ErrInvalidState = errors.New("risk case is not open")
+)
@@ -0,0 +1,17 @@
+// README: Risk route registration — mounts the fraud review queue onto the ops router group.
+package risk
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the risk endpoints onto the provided ops router group.
+//
+//	GET  /api/ops/risk/cases
+//	GET  /api/ops/risk/cases/:type/:id
+//	POST /api/ops/risk/cases/:type/:id/resolve
+//	GET  /api/ops/risk/scores/:type/:id
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/risk/cases", h.ListCases)
+	rg.GET("/api/ops/risk/cases/:type/:id", h.GetCase)
+	rg.POST("/api/ops/risk/cases/:type/:id/resolve", h.Resolve)
+	rg.GET("/api/ops/risk/scores/:type/:id", h.Score)
+}
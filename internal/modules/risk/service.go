@@ -0,0 +1,176 @@
+// README: Risk service — records fraud signals, keeps user and order scores, opens review cases and holds payouts on high-risk trips.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Orders loads the order behind a signal or case.
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// PayoutHolder keeps a driver's earnings for an order out of settlement
+// (payout.Service).
+type PayoutHolder interface {
+	Hold(ctx context.Context, driverID types.ID, ref, reason string) error
+	Release(ctx context.Context, driverID types.ID, ref string) error
+}
+
+// Service scores users and orders from fraud signals.
+type Service struct {
+	store   RiskStore
+	orders  Orders
+	payouts PayoutHolder
+	now     func() time.Time
+
+	// fixes is the last position of each driver, for the speed check.
+	mu    sync.Mutex
+	fixes map[types.ID]fix
+}
+
+// NewService creates a Service. Payouts are never held until SetPayoutHolder is called.
+func NewService(store RiskStore, orders Orders) *Service {
+	return &Service{store: store, orders: orders, now: time.Now, fixes: make(map[types.ID]fix)}
+}
+
+// SetPayoutHolder holds the driver's payout for trips scoring HoldScore or
+// more until staff clear the order's case.
+func (s *Service) SetPayoutHolder(h PayoutHolder) {
+	s.payouts = h
+}
+
+// record stores sig and refreshes the scores and cases it affects.
+func (s *Service) record(ctx context.Context, sig Signal) error {
+	sig.Weight = signalWeights[sig.Kind]
+	sig.CreatedAt = s.now()
+	if err := s.store.AddSignal(ctx, &sig); err != nil {
+		return err
+	}
+	score, err := s.store.UserScore(ctx, sig.UserID, sig.CreatedAt.Add(-scoreWindow))
+	if err != nil {
+		return err
+	}
+	if err := s.refreshCase(ctx, SubjectUser, sig.UserID, score); err != nil {
+		return err
+	}
+	if sig.OrderID == nil {
+		return nil
+	}
+	score, err = s.store.OrderScore(ctx, *sig.OrderID)
+	if err != nil {
+		return err
+	}
+	if err := s.refreshCase(ctx, SubjectOrder, *sig.OrderID, score); err != nil {
+		return err
+	}
+	if s.payouts != nil && score >= HoldScore {
+		if err := s.payouts.Hold(ctx, sig.UserID, string(*sig.OrderID), fmt.Sprintf("risk score %d", score)); err != nil {
+			return fmt.Errorf("hold payout: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) refreshCase(ctx context.Context, subjectType string, id types.ID, score int) error {
+	if score < ReviewScore {
+		return nil
+	}
+	return s.store.UpsertCase(ctx, &Case{SubjectType: subjectType, SubjectID: id, Score: score, UpdatedAt: s.now()})
+}
+
+// Score returns the current score of a user (signals in the last 30 days) or
+// an order (all its signals).
+func (s *Service) Score(ctx context.Context, subjectType string, id types.ID) (int, error) {
+	switch subjectType {
+	case SubjectUser:
+		return s.store.UserScore(ctx, id, s.now().Add(-scoreWindow))
+	case SubjectOrder:
+		return s.store.OrderScore(ctx, id)
+	default:
+		return 0, ErrBadRequest
+	}
+}
+
+// ListCases returns up to limit cases in status; an empty status lists the
+// review queue (open cases).
+func (s *Service) ListCases(ctx context.Context, status string, limit int) ([]Case, error) {
+	if status == "" {
+		status = CaseOpen
+	}
+	if status != CaseOpen && status != CaseCleared && status != CaseConfirmed {
+		return nil, ErrBadRequest
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.store.ListCases(ctx, status, limit)
+}
+
+// GetCase returns a case with its subject's most recent signals.
+func (s *Service) GetCase(ctx context.Context, subjectType string, id types.ID) (*Case, []Signal, error) {
+	if subjectType != SubjectUser && subjectType != SubjectOrder {
+		return nil, nil, ErrBadRequest
+	}
+	c, err := s.store.GetCase(ctx, subjectType, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	signals, err := s.store.ListSignals(ctx, subjectType, id, 100)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, signals, nil
+}
+
+// ResolveCommand closes an open case.
+type ResolveCommand struct {
+	SubjectType string
+	SubjectID   types.ID
+	Outcome     string // CaseCleared or CaseConfirmed
+	Note        string
+}
+
+// Resolve records staff's decision on an open case. Clearing an order's case
+// releases its held payout; a confirmed order keeps it held.
+func (s *Service) Resolve(ctx context.Context, cmd ResolveCommand) (*Case, error) {
+	note := strings.TrimSpace(cmd.Note)
+	if (cmd.SubjectType != SubjectUser && cmd.SubjectType != SubjectOrder) || cmd.SubjectID == "" ||
+		(cmd.Outcome != CaseCleared && cmd.Outcome != CaseConfirmed) || len(note) > 1000 {
+		return nil, ErrBadRequest
+	}
+	ok, err := s.store.ResolveCase(ctx, cmd.SubjectType, cmd.SubjectID, cmd.Outcome, note, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if _, err := s.store.GetCase(ctx, cmd.SubjectType, cmd.SubjectID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidState
+	}
+	if cmd.SubjectType == SubjectOrder && cmd.Outcome == CaseCleared && s.payouts != nil {
+		if err := s.releasePayout(ctx, cmd.SubjectID); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.GetCase(ctx, cmd.SubjectType, cmd.SubjectID)
+}
+
+func (s *Service) releasePayout(ctx context.Context, orderID types.ID) error {
+	o, err := s.orders.Get(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if o.DriverID == nil {
+		return nil
+	}
+	return s.payouts.Release(ctx, *o.DriverID, string(orderID))
+}
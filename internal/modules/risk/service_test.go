@@ -0,0 +1,280 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/device"
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	signals []Signal
+	cases   map[string]*Case
+
+	shared      bool
+	pairTrips   int
+	redemptions int
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{cases: make(map[string]*Case)}
+}
+
+func caseKey(subjectType string, id types.ID) string { return subjectType + "/" + string(id) }
+
+func (m *mockStore) AddSignal(_ context.Context, sig *Signal) error {
+	sig.ID = int64(len(m.signals) + 1)
+	m.signals = append(m.signals, *sig)
+	return nil
+}
+
+func (m *mockStore) UserScore(_ context.Context, userID types.ID, since time.Time) (int, error) {
+	n := 0
+	for _, s := range m.signals {
+		if s.UserID == userID && !s.CreatedAt.Before(since) {
+			n += s.Weight
+		}
+	}
+	return n, nil
+}
+
+func (m *mockStore) OrderScore(_ context.Context, orderID types.ID) (int, error) {
+	n := 0
+	for _, s := range m.signals {
+		if s.OrderID != nil && *s.OrderID == orderID {
+			n += s.Weight
+		}
+	}
+	return n, nil
+}
+
+func (m *mockStore) UpsertCase(_ context.Context, c *Case) error {
+	k := caseKey(c.SubjectType, c.SubjectID)
+	existing, ok := m.cases[k]
+	switch {
+	case !ok:
+		cp := *c
+		cp.Status, cp.OpenedAt = CaseOpen, c.UpdatedAt
+		m.cases[k] = &cp
+	case existing.Status != CaseOpen && c.Score > existing.Score:
+		existing.Status, existing.OpenedAt = CaseOpen, c.UpdatedAt
+		fallthrough
+	default:
+		existing.Score, existing.UpdatedAt = c.Score, c.UpdatedAt
+	}
+	return nil
+}
+
+func (m *mockStore) GetCase(_ context.Context, subjectType string, id types.ID) (*Case, error) {
+	c, ok := m.cases[caseKey(subjectType, id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (m *mockStore) ListCases(_ context.Context, status string, limit int) ([]Case, error) {
+	var out []Case
+	for _, c := range m.cases {
+		if c.Status == status && len(out) < limit {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) ListSignals(_ context.Context, subjectType string, id types.ID, limit int) ([]Signal, error) {
+	var out []Signal
+	for _, s := range m.signals {
+		if (subjectType == SubjectUser && s.UserID == id) || (subjectType == SubjectOrder && s.OrderID != nil && *s.OrderID == id) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) ResolveCase(_ context.Context, subjectType string, id types.ID, status, note string, at time.Time) (bool, error) {
+	c, ok := m.cases[caseKey(subjectType, id)]
+	if !ok || c.Status != CaseOpen {
+		return false, nil
+	}
+	c.Status, c.Note, c.ResolvedAt = status, note, &at
+	return true, nil
+}
+
+func (m *mockStore) SharedDevice(context.Context, types.ID, types.ID) (bool, error) {
+	return m.shared, nil
+}
+
+func (m *mockStore) InstantPairTrips(context.Context, types.ID, types.ID, time.Time, time.Duration) (int, error) {
+	return m.pairTrips, nil
+}
+
+func (m *mockStore) Redemptions(context.Context, types.ID, time.Time) (int, error) {
+	return m.redemptions, nil
+}
+
+type fakeOrders map[types.ID]*order.Order
+
+func (f fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	o, ok := f[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	return o, nil
+}
+
+type fakeHolder struct {
+	held map[string]string // driver/ref → reason
+}
+
+func (f *fakeHolder) Hold(_ context.Context, driverID types.ID, ref, reason string) error {
+	f.held[string(driverID)+"/"+ref] = reason
+	return nil
+}
+
+func (f *fakeHolder) Release(_ context.Context, driverID types.ID, ref string) error {
+	delete(f.held, string(driverID)+"/"+ref)
+	return nil
+}
+
+func newTestService() (*Service, *mockStore, *fakeHolder, *time.Time) {
+	store := newMockStore()
+	driver := types.ID("d1")
+	orders := fakeOrders{"o1": {ID: "o1", PassengerID: "p1", DriverID: &driver}}
+	svc := NewService(store, orders)
+	holder := &fakeHolder{held: make(map[string]string)}
+	svc.SetPayoutHolder(holder)
+	now := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, store, holder, &now
+}
+
+func TestSpeedHook_FlagsImpossibleJumps(t *testing.T) {
+	svc, store, _, now := newTestService()
+	hook := svc.SpeedHook()
+	ctx := context.Background()
+	move := func(d time.Duration, lat float64) {
+		*now = now.Add(d)
+		hook(ctx, location.Update{UserID: "d1", UserType: "driver", Position: types.Point{Lat: lat, Lng: 121.5}})
+	}
+
+	move(0, 25.00)
+	move(time.Minute, 25.005)  // ~0.5 km in a minute
+	move(10*time.Second, 25.2) // ~22 km in 10 s
+	move(10*time.Second, 25.4) // again, within the cooldown
+	move(15*time.Minute, 25.6) // ~22 km in 15 min: plausible
+	move(time.Second, 25.6045) // ~0.5 km jitter in a second: ignored
+	hook(ctx, location.Update{UserID: "p1", UserType: "passenger", Position: types.Point{Lat: 30, Lng: 121.5}})
+
+	if len(store.signals) != 1 || store.signals[0].Kind != SignalSpeedJump || store.signals[0].UserID != "d1" {
+		t.Fatalf("signals = %+v, want one speed_jump for d1", store.signals)
+	}
+	if len(store.cases) != 0 {
+		t.Errorf("a lone speed jump opened cases %v", store.cases)
+	}
+}
+
+func TestCheckTrip_SelfDealingHoldsPayout(t *testing.T) {
+	svc, store, holder, _ := newTestService()
+	store.shared = true
+	store.pairTrips = 3
+	ctx := context.Background()
+
+	if err := svc.CheckTrip(ctx, "o1", "p1", "d1"); err != nil {
+		t.Fatalf("CheckTrip: %v", err)
+	}
+	score, _ := svc.Score(ctx, SubjectOrder, "o1")
+	if score != 100 {
+		t.Errorf("order score = %d, want 100 (shared device + repeat pair)", score)
+	}
+	for _, k := range []string{caseKey(SubjectOrder, "o1"), caseKey(SubjectUser, "d1"), caseKey(SubjectUser, "p1")} {
+		if c := store.cases[k]; c == nil || c.Status != CaseOpen {
+			t.Errorf("case %s = %+v, want open", k, c)
+		}
+	}
+	if _, ok := holder.held["d1/o1"]; !ok {
+		t.Fatalf("payout not held: %v", holder.held)
+	}
+
+	c, err := svc.Resolve(ctx, ResolveCommand{SubjectType: SubjectOrder, SubjectID: "o1", Outcome: CaseCleared, Note: "family account"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if c.Status != CaseCleared || len(holder.held) != 0 {
+		t.Errorf("after clearing: case %+v, holds %v", c, holder.held)
+	}
+	if _, err := svc.Resolve(ctx, ResolveCommand{SubjectType: SubjectOrder, SubjectID: "o1", Outcome: CaseConfirmed}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("resolving twice: err = %v, want ErrInvalidState", err)
+	}
+}
+
+func TestCheckTrip_PromoVelocity(t *testing.T) {
+	svc, store, holder, _ := newTestService()
+	store.redemptions = promoRedemptions
+
+	if err := svc.CheckTrip(context.Background(), "o1", "p1", "d1"); err != nil {
+		t.Fatalf("CheckTrip: %v", err)
+	}
+	if len(store.signals) != 1 || store.signals[0].Kind != SignalPromoVelocity || store.signals[0].UserID != "p1" || store.signals[0].OrderID != nil {
+		t.Fatalf("signals = %+v, want one promo_velocity on p1", store.signals)
+	}
+	if len(holder.held) != 0 {
+		t.Errorf("passenger promo abuse held the driver's payout: %v", holder.held)
+	}
+}
+
+func TestDeviceHook_FlagsNewAccountOnCrowdedInstall(t *testing.T) {
+	svc, store, _, _ := newTestService()
+	hook := svc.DeviceHook()
+	ctx := context.Background()
+
+	hook(ctx, device.Sighting{UserID: "u2", Info: device.Info{InstallID: "i1"}, New: true, Accounts: 2})
+	hook(ctx, device.Sighting{UserID: "u1", Info: device.Info{InstallID: "i1"}, New: false, Accounts: 3})
+	hook(ctx, device.Sighting{UserID: "u3", Info: device.Info{InstallID: "i1"}, New: true, Accounts: 3})
+
+	if len(store.signals) != 1 || store.signals[0].Kind != SignalMultiAccount || store.signals[0].UserID != "u3" {
+		t.Fatalf("signals = %+v, want one multi_account for u3", store.signals)
+	}
+}
+
+func TestLocationHook_SignalsDriverOnOrder(t *testing.T) {
+	svc, store, holder, _ := newTestService()
+	hook := svc.LocationHook()
+	ctx := context.Background()
+
+	hook(ctx, order.LocationDiscrepancy{OrderID: "o1", DriverID: "d1", Step: order.StatusDriving, Reason: order.DiscrepancyStaleFix, DistanceKm: 2.5, FixAge: 3 * time.Minute})
+	hook(ctx, order.LocationDiscrepancy{OrderID: "o1", DriverID: "d1", Step: order.StatusPayment, Reason: order.DiscrepancyNoFix, DistanceKm: -1})
+
+	if len(store.signals) != 2 {
+		t.Fatalf("signals = %+v, want two", store.signals)
+	}
+	sig := store.signals[0]
+	if sig.Kind != SignalTripLocation || sig.UserID != "d1" || sig.OrderID == nil || *sig.OrderID != "o1" {
+		t.Fatalf("signal = %+v", sig)
+	}
+	if sig.Detail != "trip started with stale_fix: 2.50 km away, position 3m0s old" || store.signals[1].Detail != "trip completed with no_fix" {
+		t.Errorf("details = %q, %q", sig.Detail, store.signals[1].Detail)
+	}
+	// Two discrepancies on one trip are not enough to hold the payout.
+	if len(holder.held) != 0 || len(store.cases) != 0 {
+		t.Errorf("held = %v, cases = %v", holder.held, store.cases)
+	}
+}
+
+func TestResolve_Validates(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	ctx := context.Background()
+	if _, err := svc.Resolve(ctx, ResolveCommand{SubjectType: "device", SubjectID: "x", Outcome: CaseCleared}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("bad subject: err = %v", err)
+	}
+	if _, err := svc.Resolve(ctx, ResolveCommand{SubjectType: SubjectUser, SubjectID: "nobody", Outcome: CaseCleared}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown case: err = %v", err)
+	}
+}
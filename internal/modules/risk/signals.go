@@ -0,0 +1,204 @@
+// README: Risk signal collectors — impossible driver speeds from position updates, trips started or completed away from their stops, and self-dealing and promo abuse checks on completed trips.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"ark/internal/modules/device"
+	"ark/internal/modules/location"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+const (
+	// maxPlausibleKmh is the fastest a car is believed to travel between fixes.
+	maxPlausibleKmh = 200
+	// minJumpKm ignores short hops, where GPS jitter over a second or two
+	// would otherwise read as a high speed.
+	minJumpKm = 1.0
+	// speedCooldown is the least time between speed signals for one driver,
+	// so one spoofing session is not counted on every update.
+	speedCooldown = 10 * time.Minute
+
+	// pairWindow, instantRide and pairTrips define repeat-pair self-dealing:
+	// pairTrips trips within pairWindow between the same driver and passenger,
+	// each ending within instantRide of boarding.
+	pairWindow  = 7 * 24 * time.Hour
+	instantRide = 2 * time.Minute
+	pairTrips   = 3
+
+	// promoWindow and promoRedemptions define promo abuse velocity.
+	promoWindow      = 24 * time.Hour
+	promoRedemptions = 5
+
+	// multiAccountDevice is the account count on one install, the new
+	// account included, at which signing in raises a multi_account signal.
+	multiAccountDevice = 3
+
+	// checkTimeout bounds the checks run for one completed trip.
+	checkTimeout = 15 * time.Second
+)
+
+type fix struct {
+	pos       types.Point
+	at        time.Time
+	flaggedAt time.Time
+}
+
+// SpeedHook raises a speed_jump signal when a driver's position moves faster
+// than a car can between two updates. Only the rare flagged update touches
+// the store.
+func (s *Service) SpeedHook() location.PositionHook {
+	return func(ctx context.Context, u location.Update) {
+		if u.UserType != "driver" {
+			return
+		}
+		now := s.now()
+		s.mu.Lock()
+		prev, seen := s.fixes[u.UserID]
+		next := fix{pos: u.Position, at: now, flaggedAt: prev.flaggedAt}
+		var kmh, km float64
+		if seen {
+			km = haversineKm(prev.pos, u.Position)
+			if hours := now.Sub(prev.at).Hours(); hours > 0 {
+				kmh = km / hours
+			}
+		}
+		flag := km >= minJumpKm && kmh > maxPlausibleKmh && now.Sub(prev.flaggedAt) >= speedCooldown
+		if flag {
+			next.flaggedAt = now
+		}
+		s.fixes[u.UserID] = next
+		s.mu.Unlock()
+		if !flag {
+			return
+		}
+		err := s.record(ctx, Signal{
+			Kind:   SignalSpeedJump,
+			UserID: u.UserID,
+			Detail: fmt.Sprintf("%.1f km in %s (%.0f km/h)", km, now.Sub(prev.at).Round(time.Second), kmh),
+		})
+		if err != nil {
+			log.Printf("risk: speed signal for %s: %v", u.UserID, err)
+		}
+	}
+}
+
+// TripHook checks every completed trip for self-dealing and promo abuse.
+// Checks run in the background; sandbox rides are skipped.
+func (s *Service) TripHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.To != order.StatusComplete || t.Sandbox || t.DriverID == nil {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkTimeout)
+			defer cancel()
+			if err := s.CheckTrip(ctx, t.OrderID, t.PassengerID, *t.DriverID); err != nil {
+				log.Printf("risk: check trip %s: %v", t.OrderID, err)
+			}
+		}()
+	}
+}
+
+// LocationHook raises a trip_location signal on the order against the driver
+// for every trip step let through without GPS evidence of where they were.
+// Discrepancies are rare, so the signal is recorded inline.
+func (s *Service) LocationHook() order.LocationDiscrepancyHook {
+	return func(ctx context.Context, d order.LocationDiscrepancy) {
+		step := "started"
+		if d.Step == order.StatusPayment {
+			step = "completed"
+		}
+		detail := fmt.Sprintf("trip %s with %s", step, d.Reason)
+		if d.DistanceKm >= 0 {
+			detail += fmt.Sprintf(": %.2f km away, position %s old", d.DistanceKm, d.FixAge.Round(time.Second))
+		}
+		orderID := d.OrderID
+		if err := s.record(ctx, Signal{Kind: SignalTripLocation, UserID: d.DriverID, OrderID: &orderID, Detail: detail}); err != nil {
+			log.Printf("risk: trip location signal for %s: %v", d.OrderID, err)
+		}
+	}
+}
+
+// DeviceHook raises a multi_account signal against an account the first time
+// it is seen on an install that multiAccountDevice or more accounts have used.
+func (s *Service) DeviceHook() device.SightingHook {
+	return func(ctx context.Context, sg device.Sighting) {
+		if !sg.New || sg.Accounts < multiAccountDevice {
+			return
+		}
+		err := s.record(ctx, Signal{
+			Kind:   SignalMultiAccount,
+			UserID: sg.UserID,
+			Detail: fmt.Sprintf("install %s used by %d accounts", sg.Info.InstallID, sg.Accounts),
+		})
+		if err != nil {
+			log.Printf("risk: multi-account signal for %s: %v", sg.UserID, err)
+		}
+	}
+}
+
+// CheckTrip raises the signals a completed trip warrants. Pair signals go on
+// the order against the driver, whose payout is at stake, and on the passenger.
+func (s *Service) CheckTrip(ctx context.Context, orderID, passengerID, driverID types.ID) error {
+	now := s.now()
+	var pair []Signal
+
+	shared, err := s.store.SharedDevice(ctx, passengerID, driverID)
+	if err != nil {
+		return err
+	}
+	if shared {
+		pair = append(pair, Signal{Kind: SignalSharedDevice, Detail: "driver and passenger share a device"})
+	}
+
+	n, err := s.store.InstantPairTrips(ctx, passengerID, driverID, now.Add(-pairWindow), instantRide)
+	if err != nil {
+		return err
+	}
+	if n >= pairTrips {
+		pair = append(pair, Signal{Kind: SignalRepeatPair, Detail: fmt.Sprintf("%d trips together in %.0f days ending within %.0f min of boarding", n, pairWindow.Hours()/24, instantRide.Minutes())})
+	}
+
+	for _, sig := range pair {
+		onOrder := sig
+		onOrder.UserID, onOrder.OrderID = driverID, &orderID
+		onOrder.Detail += " with passenger " + string(passengerID)
+		if err := s.record(ctx, onOrder); err != nil {
+			return err
+		}
+		sig.UserID = passengerID
+		sig.Detail += " with driver " + string(driverID) + " on order " + string(orderID)
+		if err := s.record(ctx, sig); err != nil {
+			return err
+		}
+	}
+
+	redeemed, err := s.store.Redemptions(ctx, passengerID, now.Add(-promoWindow))
+	if err != nil {
+		return err
+	}
+	if redeemed >= promoRedemptions {
+		return s.record(ctx, Signal{
+			Kind:   SignalPromoVelocity,
+			UserID: passengerID,
+			Detail: fmt.Sprintf("%d credit redemptions in %.0f hours", redeemed, promoWindow.Hours()),
+		})
+	}
+	return nil
+}
+
+func haversineKm(a, b types.Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
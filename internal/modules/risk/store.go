@@ -0,0 +1,229 @@
+// README: Risk store — PostgreSQL persistence for risk_signals and risk_cases, and the trip, device and credit queries behind the signals.
+package risk
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// RiskStore defines the persistence operations required by the risk Service.
+type RiskStore interface {
+	AddSignal(ctx context.Context, sig *Signal) error
+	// UserScore sums the weights of the user's signals since `since`.
+	UserScore(ctx context.Context, userID types.ID, since time.Time) (int, error)
+	// OrderScore sums the weights of the signals raised on the order.
+	OrderScore(ctx context.Context, orderID types.ID) (int, error)
+	// UpsertCase opens the subject's case or refreshes its score. A resolved
+	// case reopens when the score rises above the one it was resolved at.
+	UpsertCase(ctx context.Context, c *Case) error
+	GetCase(ctx context.Context, subjectType string, subjectID types.ID) (*Case, error)
+	// ListCases returns cases in status, highest score first.
+	ListCases(ctx context.Context, status string, limit int) ([]Case, error)
+	// ListSignals returns the subject's most recent signals.
+	ListSignals(ctx context.Context, subjectType string, subjectID types.ID, limit int) ([]Signal, error)
+	// ResolveCase closes an open case; false when it is not open.
+	ResolveCase(ctx context.Context, subjectType string, subjectID types.ID, status, note string, at time.Time) (bool, error)
+
+	// SharedDevice reports whether the two users registered the same device
+	// or push token, or were seen on the same app install.
+	SharedDevice(ctx context.Context, a, b types.ID) (bool, error)
+	// InstantPairTrips counts the pair's trips completed since `since` whose
+	// ride, from boarding to the fare, took less than maxRide.
+	InstantPairTrips(ctx context.Context, passengerID, driverID types.ID, since time.Time, maxRide time.Duration) (int, error)
+	// Redemptions counts the user's ride-credit redemptions since `since`.
+	Redemptions(ctx context.Context, userID types.ID, since time.Time) (int, error)
+}
+
+// Store is the PostgreSQL implementation of RiskStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) AddSignal(ctx context.Context, sig *Signal) error {
+	var orderID *string
+	if sig.OrderID != nil {
+		id := string(*sig.OrderID)
+		orderID = &id
+	}
+	return s.db.QueryRow(ctx, `
+		INSERT INTO risk_signals (kind, user_id, order_id, weight, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		sig.Kind, string(sig.UserID), orderID, sig.Weight, sig.Detail, sig.CreatedAt,
+	).Scan(&sig.ID)
+}
+
+func (s *Store) UserScore(ctx context.Context, userID types.ID, since time.Time) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(weight), 0) FROM risk_signals WHERE user_id = $1 AND created_at >= $2`,
+		string(userID), since,
+	).Scan(&n)
+	return n, err
+}
+
+func (s *Store) OrderScore(ctx context.Context, orderID types.ID) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `SELECT COALESCE(SUM(weight), 0) FROM risk_signals WHERE order_id = $1`, string(orderID)).Scan(&n)
+	return n, err
+}
+
+func (s *Store) UpsertCase(ctx context.Context, c *Case) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO risk_cases (subject_type, subject_id, score, status, opened_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (subject_type, subject_id) DO UPDATE SET
+			score      = EXCLUDED.score,
+			updated_at = EXCLUDED.updated_at,
+			status     = CASE WHEN risk_cases.status <> $4 AND EXCLUDED.score > risk_cases.score
+			                  THEN $4 ELSE risk_cases.status END,
+			opened_at  = CASE WHEN risk_cases.status <> $4 AND EXCLUDED.score > risk_cases.score
+			                  THEN EXCLUDED.opened_at ELSE risk_cases.opened_at END`,
+		c.SubjectType, string(c.SubjectID), c.Score, CaseOpen, c.UpdatedAt,
+	)
+	return err
+}
+
+const caseColumns = `subject_type, subject_id, score, status, opened_at, updated_at, resolved_at, note`
+
+func scanCase(row pgx.Row) (*Case, error) {
+	var c Case
+	var id string
+	if err := row.Scan(&c.SubjectType, &id, &c.Score, &c.Status, &c.OpenedAt, &c.UpdatedAt, &c.ResolvedAt, &c.Note); err != nil {
+		return nil, err
+	}
+	c.SubjectID = types.ID(id)
+	return &c, nil
+}
+
+func (s *Store) GetCase(ctx context.Context, subjectType string, subjectID types.ID) (*Case, error) {
+	c, err := scanCase(s.db.QueryRow(ctx, `
+		SELECT `+caseColumns+` FROM risk_cases WHERE subject_type = $1 AND subject_id = $2`,
+		subjectType, string(subjectID)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *Store) ListCases(ctx context.Context, status string, limit int) ([]Case, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+caseColumns+` FROM risk_cases
+		WHERE status = $1
+		ORDER BY score DESC, updated_at DESC
+		LIMIT $2`,
+		status, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Case
+	for rows.Next() {
+		c, err := scanCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListSignals(ctx context.Context, subjectType string, subjectID types.ID, limit int) ([]Signal, error) {
+	column := "user_id"
+	if subjectType == SubjectOrder {
+		column = "order_id"
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT id, kind, user_id, order_id, weight, detail, created_at FROM risk_signals
+		WHERE `+column+` = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		string(subjectID), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Signal
+	for rows.Next() {
+		var sig Signal
+		var userID string
+		var orderID *string
+		if err := rows.Scan(&sig.ID, &sig.Kind, &userID, &orderID, &sig.Weight, &sig.Detail, &sig.CreatedAt); err != nil {
+			return nil, err
+		}
+		sig.UserID = types.ID(userID)
+		if orderID != nil {
+			id := types.ID(*orderID)
+			sig.OrderID = &id
+		}
+		out = append(out, sig)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ResolveCase(ctx context.Context, subjectType string, subjectID types.ID, status, note string, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE risk_cases SET status = $3, note = $4, resolved_at = $5, updated_at = $5
+		WHERE subject_type = $1 AND subject_id = $2 AND status = $6`,
+		subjectType, string(subjectID), status, note, at, CaseOpen,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) SharedDevice(ctx context.Context, a, b types.ID) (bool, error) {
+	var shared bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_fcm_tokens x
+			JOIN user_fcm_tokens y ON x.token_hash = y.token_hash OR x.device_id = y.device_id
+			WHERE x.user_id = $1 AND y.user_id = $2)
+		OR EXISTS (
+			SELECT 1 FROM devices x JOIN devices y ON x.install_id = y.install_id
+			WHERE x.user_id = $1 AND y.user_id = $2)`,
+		string(a), string(b),
+	).Scan(&shared)
+	return shared, err
+}
+
+func (s *Store) InstantPairTrips(ctx context.Context, passengerID, driverID types.ID, since time.Time, maxRide time.Duration) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM orders o
+		JOIN LATERAL (
+			SELECT MAX(created_at) FILTER (WHERE to_status = 'driving') AS boarded,
+			       MAX(created_at) FILTER (WHERE to_status = 'payment') AS ended
+			FROM order_state_events WHERE order_id = o.id
+		) e ON TRUE
+		WHERE o.passenger_id = $1 AND o.driver_id = $2 AND o.status = 'complete'
+		  AND o.created_at >= $3
+		  AND EXTRACT(EPOCH FROM e.ended - e.boarded) < $4`,
+		string(passengerID), string(driverID), since, maxRide.Seconds(),
+	).Scan(&n)
+	return n, err
+}
+
+func (s *Store) Redemptions(ctx context.Context, userID types.ID, since time.Time) (int, error) {
+	var n int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM credit_ledger
+		WHERE user_id = $1 AND kind = 'ride' AND amount < 0 AND created_at >= $2`,
+		string(userID), since,
+	).Scan(&n)
+	return n, err
+}
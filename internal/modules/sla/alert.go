@@ -0,0 +1,78 @@
+// README: Order SLA alerts — posts newly stuck orders to a Slack incoming webhook.
+package sla
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Alerter reports newly stuck orders, e.g. to an ops chat channel.
+type Alerter interface {
+	Alert(ctx context.Context, stuck []StuckOrder) error
+}
+
+// SlackAlerter posts one message per batch of newly stuck orders to a Slack
+// incoming webhook.
+type SlackAlerter struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+// NewSlackAlerter returns a SlackAlerter posting to webhookURL.
+func NewSlackAlerter(webhookURL string) *SlackAlerter {
+	return &SlackAlerter{WebhookURL: webhookURL, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// reasonText describes each reason in alert messages.
+var reasonText = map[string]string{
+	ReasonNotDispatched:  "waiting for a driver",
+	ReasonNotArrived:     "driver not at pickup",
+	ReasonPaymentPending: "payment pending",
+}
+
+// Alert posts stuck to the webhook.
+func (a *SlackAlerter) Alert(ctx context.Context, stuck []StuckOrder) error {
+	if len(stuck) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string]string{"text": slackText(stuck)})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func slackText(stuck []StuckOrder) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ":rotating_light: %d order(s) stuck", len(stuck))
+	for _, o := range stuck {
+		fmt.Fprintf(&b, "\n• `%s` %s for %s", o.OrderID, reasonText[o.Reason], o.For.Round(time.Minute))
+		if o.DriverID != nil {
+			fmt.Fprintf(&b, " (driver `%s`)", *o.DriverID)
+		}
+	}
+	return b.String()
+}
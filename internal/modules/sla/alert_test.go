@@ -0,0 +1,53 @@
+package sla
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+func TestSlackAlerter_PostsStuckOrders(t *testing.T) {
+	var text string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		text = body["text"]
+	}))
+	defer srv.Close()
+
+	drv := types.ID("d9")
+	a := &SlackAlerter{WebhookURL: srv.URL, Client: srv.Client()}
+	err := a.Alert(context.Background(), []StuckOrder{
+		{OrderID: "o1", Status: order.StatusWaiting, Reason: ReasonNotDispatched, For: 12*time.Minute + 20*time.Second},
+		{OrderID: "o2", DriverID: &drv, Status: order.StatusApproaching, Reason: ReasonNotArrived, For: 41 * time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Alert: %v", err)
+	}
+	for _, want := range []string{"2 order(s) stuck", "`o1` waiting for a driver for 12m0s", "`o2` driver not at pickup for 41m0s (driver `d9`)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("message %q lacks %q", text, want)
+		}
+	}
+}
+
+func TestSlackAlerter_ReportsHTTPFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	a := &SlackAlerter{WebhookURL: srv.URL, Client: srv.Client()}
+	if err := a.Alert(context.Background(), []StuckOrder{{OrderID: "o1", Reason: ReasonPaymentPending}}); err == nil {
+		t.Error("Alert should fail on a non-2xx response")
+	}
+}
@@ -0,0 +1,60 @@
+// README: Order SLA HTTP handlers — ops list of the orders currently stuck and why.
+//
+// Endpoints:
+//
+//	GET /api/ops/orders/stuck  — live orders past their status limit (ops key)
+//
+// Auth: routes require the ops key middleware.
+package sla
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Handler holds the order SLA HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type stuckOrderResp struct {
+	OrderID      types.ID     `json:"order_id"`
+	PassengerID  types.ID     `json:"passenger_id"`
+	DriverID     *types.ID    `json:"driver_id,omitempty"`
+	Status       order.Status `json:"status"`
+	Reason       string       `json:"reason"`
+	Since        time.Time    `json:"since"`
+	StuckSeconds int64        `json:"stuck_seconds"`
+}
+
+// Stuck handles GET /api/ops/orders/stuck.
+func (h *Handler) Stuck(c *gin.Context) {
+	stuck, err := h.svc.Stuck(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]any{"error": "internal error"})
+		return
+	}
+	out := make([]stuckOrderResp, len(stuck))
+	for i, o := range stuck {
+		out[i] = stuckOrderResp{
+			OrderID:      o.OrderID,
+			PassengerID:  o.PassengerID,
+			DriverID:     o.DriverID,
+			Status:       o.Status,
+			Reason:       o.Reason,
+			Since:        o.Since,
+			StuckSeconds: int64(o.For.Seconds()),
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{"orders": out})
+}
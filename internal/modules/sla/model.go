@@ -0,0 +1,53 @@
+// README: Order SLA domain model — why a live order counts as stuck and the limits that decide it.
+package sla
+
+import (
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// Reasons an order is stuck.
+const (
+	ReasonNotDispatched  = "not_dispatched"  // waiting for a driver too long
+	ReasonNotArrived     = "not_arrived"     // driver approaching the pickup too long
+	ReasonPaymentPending = "payment_pending" // payment not confirmed in time
+)
+
+// Reasons lists every reason, in lifecycle order.
+var Reasons = []string{ReasonNotDispatched, ReasonNotArrived, ReasonPaymentPending}
+
+// Limits is how long an order may stay in each monitored status.
+type Limits struct {
+	Waiting     time.Duration
+	Approaching time.Duration
+	Payment     time.Duration
+}
+
+// StuckOrder is a live order that has stayed in its status past its limit.
+type StuckOrder struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    *types.ID
+	Status      order.Status
+	Reason      string
+	// Since is when the order entered Status.
+	Since time.Time
+	// For is how long it has been in Status.
+	For time.Duration
+}
+
+// reasonFor returns why an order in status is stuck and the limit that
+// applies; ok is false for statuses that are not monitored.
+func (l Limits) reasonFor(status order.Status) (reason string, limit time.Duration, ok bool) {
+	switch status {
+	case order.StatusWaiting:
+		return ReasonNotDispatched, l.Waiting, true
+	case order.StatusApproaching:
+		return ReasonNotArrived, l.Approaching, true
+	case order.StatusPayment:
+		return ReasonPaymentPending, l.Payment, true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,12 @@
+// README: Order SLA route registration — mounts the stuck-order list onto the ops router group.
+package sla
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the stuck-order list onto the provided ops router
+// group.
+//
+//	GET /api/ops/orders/stuck
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/orders/stuck", h.Stuck)
+}
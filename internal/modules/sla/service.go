@@ -0,0 +1,142 @@
+// README: Order SLA monitor — periodically finds orders stuck in a live status, counts them per reason, and alerts on each newly stuck one.
+package sla
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+
+	"ark/internal/types"
+)
+
+// stuckOrders counts stuck orders per reason: <reason>.current is the number
+// found by the latest check and <reason>.alerts the total ever alerted.
+var stuckOrders = expvar.NewMap("sla_stuck_orders")
+
+// alertKey identifies one alert: an order stuck for one reason. An order that
+// moves on and gets stuck in its next status is alerted again.
+type alertKey struct {
+	orderID types.ID
+	reason  string
+}
+
+// Service monitors live orders against their status limits.
+type Service struct {
+	store    OrderStore
+	limits   Limits
+	interval time.Duration
+	alerter  Alerter
+	now      func() time.Time
+
+	mu      sync.Mutex
+	alerted map[alertKey]struct{} // stuck at the latest check and already alerted
+}
+
+// NewService returns a Service that checks live orders against limits every
+// interval.
+func NewService(store OrderStore, limits Limits, interval time.Duration) *Service {
+	return &Service{
+		store:    store,
+		limits:   limits,
+		interval: interval,
+		now:      time.Now,
+		alerted:  map[alertKey]struct{}{},
+	}
+}
+
+// SetAlerter sends alerts for newly stuck orders to a as well as the log.
+func (s *Service) SetAlerter(a Alerter) {
+	s.alerter = a
+}
+
+// RunJob checks live orders every interval until ctx is done.
+func (s *Service) RunJob(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CheckOnce(ctx); err != nil {
+				log.Printf("sla: %v", err)
+			}
+		}
+	}
+}
+
+// Stuck returns the live orders past their status limit, longest stuck
+// first.
+func (s *Service) Stuck(ctx context.Context) ([]StuckOrder, error) {
+	now := s.now()
+	live, err := s.store.ListLive(ctx, now.Add(-s.limits.Waiting), now.Add(-s.limits.Approaching), now.Add(-s.limits.Payment))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StuckOrder, 0, len(live))
+	for _, o := range live {
+		reason, limit, ok := s.limits.reasonFor(o.Status)
+		if !ok || now.Sub(o.Since) <= limit {
+			continue
+		}
+		out = append(out, StuckOrder{
+			OrderID:     o.OrderID,
+			PassengerID: o.PassengerID,
+			DriverID:    o.DriverID,
+			Status:      o.Status,
+			Reason:      reason,
+			Since:       o.Since,
+			For:         now.Sub(o.Since),
+		})
+	}
+	return out, nil
+}
+
+// CheckOnce updates the stuck-order counts and alerts on the orders that were
+// not stuck for the same reason at the previous check.
+func (s *Service) CheckOnce(ctx context.Context) error {
+	stuck, err := s.Stuck(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	current := make(map[string]int64, len(Reasons))
+	seen := make(map[alertKey]struct{}, len(stuck))
+	var fresh []StuckOrder
+	for _, o := range stuck {
+		current[o.Reason]++
+		k := alertKey{o.OrderID, o.Reason}
+		seen[k] = struct{}{}
+		if _, ok := s.alerted[k]; !ok {
+			fresh = append(fresh, o)
+		}
+	}
+	s.alerted = seen
+	s.mu.Unlock()
+
+	for _, r := range Reasons {
+		v := new(expvar.Int)
+		v.Set(current[r])
+		stuckOrders.Set(r+".current", v)
+	}
+	for _, o := range fresh {
+		stuckOrders.Add(o.Reason+".alerts", 1)
+		log.Printf("sla: order %s stuck %s: %s since %s", o.OrderID, o.Reason, o.Status, o.Since.Format(time.RFC3339))
+	}
+	if s.alerter == nil || len(fresh) == 0 {
+		return nil
+	}
+	if err := s.alerter.Alert(ctx, fresh); err != nil {
+		// Alert them again on the next check.
+		s.mu.Lock()
+		for _, o := range fresh {
+			delete(s.alerted, alertKey{o.OrderID, o.Reason})
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package sla
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type fakeStore struct {
+	live []LiveOrder
+}
+
+func (f *fakeStore) ListLive(_ context.Context, waitingBefore, approachingBefore, paymentBefore time.Time) ([]LiveOrder, error) {
+	cutoff := map[order.Status]time.Time{
+		order.StatusWaiting:     waitingBefore,
+		order.StatusApproaching: approachingBefore,
+		order.StatusPayment:     paymentBefore,
+	}
+	var out []LiveOrder
+	for _, o := range f.live {
+		if c, ok := cutoff[o.Status]; ok && o.Since.Before(c) {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+type fakeAlerter struct {
+	batches [][]StuckOrder
+	err     error
+}
+
+func (f *fakeAlerter) Alert(_ context.Context, stuck []StuckOrder) error {
+	f.batches = append(f.batches, stuck)
+	return f.err
+}
+
+var (
+	now    = time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	limits = Limits{Waiting: 10 * time.Minute, Approaching: 30 * time.Minute, Payment: 15 * time.Minute}
+)
+
+func newTestService(live ...LiveOrder) (*Service, *fakeStore, *fakeAlerter) {
+	store := &fakeStore{live: live}
+	alerter := &fakeAlerter{}
+	svc := NewService(store, limits, time.Minute)
+	svc.SetAlerter(alerter)
+	svc.now = func() time.Time { return now }
+	return svc, store, alerter
+}
+
+func TestStuck_PerStatusLimits(t *testing.T) {
+	drv := types.ID("d1")
+	svc, _, _ := newTestService(
+		LiveOrder{OrderID: "waiting-ok", Status: order.StatusWaiting, Since: now.Add(-10 * time.Minute)},
+		LiveOrder{OrderID: "waiting-stuck", Status: order.StatusWaiting, Since: now.Add(-10*time.Minute - time.Second)},
+		LiveOrder{OrderID: "approaching-ok", Status: order.StatusApproaching, DriverID: &drv, Since: now.Add(-29 * time.Minute)},
+		LiveOrder{OrderID: "approaching-stuck", Status: order.StatusApproaching, DriverID: &drv, Since: now.Add(-45 * time.Minute)},
+		LiveOrder{OrderID: "payment-stuck", Status: order.StatusPayment, DriverID: &drv, Since: now.Add(-time.Hour)},
+	)
+
+	stuck, err := svc.Stuck(context.Background())
+	if err != nil {
+		t.Fatalf("Stuck: %v", err)
+	}
+	want := map[types.ID]string{
+		"waiting-stuck":     ReasonNotDispatched,
+		"approaching-stuck": ReasonNotArrived,
+		"payment-stuck":     ReasonPaymentPending,
+	}
+	if len(stuck) != len(want) {
+		t.Fatalf("stuck = %+v, want %d orders", stuck, len(want))
+	}
+	for _, o := range stuck {
+		if want[o.OrderID] != o.Reason {
+			t.Errorf("%s: reason %q, want %q", o.OrderID, o.Reason, want[o.OrderID])
+		}
+		if o.For != now.Sub(o.Since) {
+			t.Errorf("%s: For = %v, want %v", o.OrderID, o.For, now.Sub(o.Since))
+		}
+	}
+}
+
+func TestCheckOnce_AlertsEachStuckOrderOnce(t *testing.T) {
+	svc, store, alerter := newTestService(
+		LiveOrder{OrderID: "o1", Status: order.StatusWaiting, Since: now.Add(-20 * time.Minute)},
+	)
+	ctx := context.Background()
+	before := counter(ReasonNotDispatched + ".alerts")
+
+	if err := svc.CheckOnce(ctx); err != nil {
+		t.Fatalf("CheckOnce: %v", err)
+	}
+	if err := svc.CheckOnce(ctx); err != nil {
+		t.Fatalf("CheckOnce: %v", err)
+	}
+	if len(alerter.batches) != 1 || len(alerter.batches[0]) != 1 {
+		t.Fatalf("alert batches = %+v, want one alert for o1", alerter.batches)
+	}
+	if got := counter(ReasonNotDispatched + ".alerts"); got != before+1 {
+		t.Errorf("alerts counter = %d, want %d", got, before+1)
+	}
+	if got := counter(ReasonNotDispatched + ".current"); got != 1 {
+		t.Errorf("current = %d, want 1", got)
+	}
+
+	// Matched, then stuck on the way to the pickup: a new alert.
+	store.live[0].Status, store.live[0].Since = order.StatusApproaching, now.Add(-40*time.Minute)
+	if err := svc.CheckOnce(ctx); err != nil {
+		t.Fatalf("CheckOnce: %v", err)
+	}
+	if len(alerter.batches) != 2 || alerter.batches[1][0].Reason != ReasonNotArrived {
+		t.Errorf("alert batches = %+v, want a not_arrived alert", alerter.batches)
+	}
+	if got := counter(ReasonNotDispatched + ".current"); got != 0 {
+		t.Errorf("not_dispatched current = %d, want 0", got)
+	}
+}
+
+func TestCheckOnce_FailedAlertIsRetried(t *testing.T) {
+	svc, _, alerter := newTestService(
+		LiveOrder{OrderID: "o1", Status: order.StatusPayment, Since: now.Add(-time.Hour)},
+	)
+	alerter.err = errors.New("webhook down")
+	if err := svc.CheckOnce(context.Background()); err == nil {
+		t.Fatal("CheckOnce should report the failed alert")
+	}
+	alerter.err = nil
+	if err := svc.CheckOnce(context.Background()); err != nil {
+		t.Fatalf("CheckOnce: %v", err)
+	}
+	if len(alerter.batches) != 2 {
+		t.Errorf("alerted %d times, want the failed alert retried once", len(alerter.batches))
+	}
+}
+
+func counter(key string) int64 {
+	v, _ := stuckOrders.Get(key).(*expvar.Int)
+	if v == nil {
+		return 0
+	}
+	return v.Value()
+}
@@ -0,0 +1,77 @@
+// README: Order SLA store — PostgreSQL lookup of live orders and when they entered their status.
+package sla
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// LiveOrder is a monitored order with the time it entered its status.
+type LiveOrder struct {
+	OrderID     types.ID
+	PassengerID types.ID
+	DriverID    *types.ID
+	Status      order.Status
+	Since       time.Time
+}
+
+// OrderStore defines the persistence operations required by the Service.
+type OrderStore interface {
+	// ListLive returns the non-sandbox orders waiting, approaching or in
+	// payment that entered that status before the status's cutoff.
+	ListLive(ctx context.Context, waitingBefore, approachingBefore, paymentBefore time.Time) ([]LiveOrder, error)
+}
+
+// Store is the PostgreSQL implementation of OrderStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+// ListLive takes an order's latest transition into its status as the time it
+// entered it, and its creation when it has none (instant orders start waiting).
+func (s *Store) ListLive(ctx context.Context, waitingBefore, approachingBefore, paymentBefore time.Time) ([]LiveOrder, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, passenger_id, driver_id, status, since
+		FROM (
+		    SELECT o.id, o.passenger_id, o.driver_id, o.status,
+		           COALESCE((SELECT MAX(e.created_at) FROM order_state_events e
+		                     WHERE e.order_id = o.id AND e.to_status = o.status), o.created_at) AS since
+		    FROM orders o
+		    WHERE o.status IN ('waiting', 'approaching', 'payment') AND NOT o.sandbox
+		) live
+		WHERE (status = 'waiting' AND since < $1)
+		   OR (status = 'approaching' AND since < $2)
+		   OR (status = 'payment' AND since < $3)
+		ORDER BY since ASC`,
+		waitingBefore, approachingBefore, paymentBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LiveOrder
+	for rows.Next() {
+		var o LiveOrder
+		var driverID *string
+		if err := rows.Scan(&o.OrderID, &o.PassengerID, &driverID, &o.Status, &o.Since); err != nil {
+			return nil, err
+		}
+		if driverID != nil {
+			id := types.ID(*driverID)
+			o.DriverID = &id
+		}
+		o.Since = o.Since.UTC()
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
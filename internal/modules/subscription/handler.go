@@ -0,0 +1,211 @@
+// README: Subscription HTTP handlers — the ride plans on sale, the caller's subscription, subscribing and cancelling, and ops plan management.
+//
+// Endpoints:
+//
+//	GET    /api/subscription-plans           — plans on sale
+//	GET    /api/subscription                 — the caller's subscription and what is left of it this month
+//	POST   /api/subscription                 — subscribe to a plan ({"plan_id"}), charging the first month
+//	DELETE /api/subscription                 — cancel now with a prorated refund
+//	GET    /api/ops/subscription-plans       — every plan, including retired ones (ops key)
+//	PUT    /api/ops/subscription-plans/:id   — create or replace a plan (ops key)
+//
+// Auth: passenger routes require the Auth middleware to set user_id in
+// context; ops routes require the ops key middleware instead.
+package subscription
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the subscription HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type subscribeReq struct {
+	PlanID string `json:"plan_id"`
+}
+
+type savePlanReq struct {
+	Name            string `json:"name"`
+	Price           int64  `json:"price"`
+	RideCredit      int64  `json:"ride_credit"`
+	DiscountPercent int    `json:"discount_percent"`
+	Active          bool   `json:"active"`
+}
+
+type planResp struct {
+	ID              string `json:"plan_id"`
+	Name            string `json:"name"`
+	Price           int64  `json:"price"`
+	RideCredit      int64  `json:"ride_credit"`
+	DiscountPercent int    `json:"discount_percent"`
+	Currency        string `json:"currency"`
+	Active          bool   `json:"active"`
+}
+
+type subscriptionResp struct {
+	ID          string   `json:"subscription_id"`
+	Status      string   `json:"status"`
+	Plan        planResp `json:"plan"`
+	PeriodStart int64    `json:"period_start"`
+	PeriodEnd   int64    `json:"period_end"`
+	CreditLeft  int64    `json:"credit_left"`
+	Discounted  int64    `json:"discounted"`
+}
+
+func toPlanResp(p *Plan) planResp {
+	return planResp{
+		ID:              string(p.ID),
+		Name:            p.Name,
+		Price:           p.Price,
+		RideCredit:      p.RideCredit,
+		DiscountPercent: p.DiscountPercent,
+		Currency:        types.DefaultCurrency,
+		Active:          p.Active,
+	}
+}
+
+func toSubscriptionResp(s *Subscription, p *Plan) subscriptionResp {
+	return subscriptionResp{
+		ID:          string(s.ID),
+		Status:      s.Status,
+		Plan:        toPlanResp(p),
+		PeriodStart: s.PeriodStart.Unix(),
+		PeriodEnd:   s.PeriodEnd.Unix(),
+		CreditLeft:  s.CreditLeft(p),
+		Discounted:  s.Discounted,
+	}
+}
+
+// Plans handles GET /api/subscription-plans.
+func (h *Handler) Plans(c *gin.Context) {
+	h.listPlans(c, false)
+}
+
+// AllPlans handles GET /api/ops/subscription-plans.
+func (h *Handler) AllPlans(c *gin.Context) {
+	h.listPlans(c, true)
+}
+
+func (h *Handler) listPlans(c *gin.Context, all bool) {
+	ps, err := h.svc.Plans(c.Request.Context(), all)
+	if err != nil {
+		writeSubscriptionError(c, err)
+		return
+	}
+	out := make([]planResp, len(ps))
+	for i := range ps {
+		out[i] = toPlanResp(&ps[i])
+	}
+	c.JSON(http.StatusOK, map[string]any{"plans": out})
+}
+
+// SavePlan handles PUT /api/ops/subscription-plans/:id.
+func (h *Handler) SavePlan(c *gin.Context) {
+	var req savePlanReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	p, err := h.svc.SavePlan(c.Request.Context(), Plan{
+		ID:              types.ID(c.Param("id")),
+		Name:            req.Name,
+		Price:           req.Price,
+		RideCredit:      req.RideCredit,
+		DiscountPercent: req.DiscountPercent,
+		Active:          req.Active,
+	})
+	if err != nil {
+		writeSubscriptionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toPlanResp(p))
+}
+
+// Mine handles GET /api/subscription.
+func (h *Handler) Mine(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	s, p, err := h.svc.Mine(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writeSubscriptionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toSubscriptionResp(s, p))
+}
+
+// Subscribe handles POST /api/subscription.
+func (h *Handler) Subscribe(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req subscribeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	s, p, err := h.svc.Subscribe(c.Request.Context(), types.ID(uid), types.ID(req.PlanID))
+	if err != nil {
+		writeSubscriptionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toSubscriptionResp(s, p))
+}
+
+// Cancel handles DELETE /api/subscription.
+func (h *Handler) Cancel(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	s, err := h.svc.Cancel(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writeSubscriptionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"subscription_id": string(s.ID),
+		"status":          s.Status,
+		"refund":          s.Refund,
+		"currency":        types.DefaultCurrency,
+	})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeSubscriptionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrPaymentFailed):
+		writeError(c, http.StatusPaymentRequired, ErrPaymentFailed.Error())
+	case errors.Is(err, ErrBillingUnavailable):
+		writeError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,118 @@
+// README: Subscription domain model — monthly ride plans, passenger subscriptions, the benefit a plan takes off a fare and the refund on cancellation.
+package subscription
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Subscription statuses. A subscription is active while its current period
+// is paid; a failed renewal leaves it past_due, without benefits, until a
+// retry succeeds or it is cancelled.
+const (
+	StatusActive    = "active"
+	StatusPastDue   = "past_due"
+	StatusCancelled = "cancelled"
+)
+
+// Plan is a monthly ride plan. Price, RideCredit and the benefits are in TWD
+// minor units.
+type Plan struct {
+	ID    types.ID
+	Name  string
+	Price int64
+	// RideCredit is included every month and pays fares until spent.
+	RideCredit int64
+	// DiscountPercent is taken off every fare before the ride credit.
+	DiscountPercent int
+	// Active plans can be subscribed to; retiring a plan keeps existing
+	// subscriptions renewing.
+	Active    bool
+	CreatedAt time.Time
+}
+
+func (p *Plan) validate() error {
+	if p.ID == "" || p.Name == "" || p.Price <= 0 || p.RideCredit < 0 {
+		return ErrBadRequest
+	}
+	if p.DiscountPercent < 0 || p.DiscountPercent > 100 {
+		return ErrBadRequest
+	}
+	if p.RideCredit == 0 && p.DiscountPercent == 0 {
+		return ErrBadRequest // a plan must give something
+	}
+	return nil
+}
+
+// Subscription is a user's plan. The current period runs [PeriodStart,
+// PeriodEnd); CreditUsed and Discounted are the benefits taken in it.
+type Subscription struct {
+	ID          types.ID
+	UserID      types.ID
+	PlanID      types.ID
+	Status      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	CreditUsed  int64
+	Discounted  int64
+	// ChargeRef is the payment provider's reference for the current
+	// period's charge.
+	ChargeRef string
+	// Refund is what was paid back on cancellation.
+	Refund      int64
+	CreatedAt   time.Time
+	CancelledAt *time.Time
+}
+
+// CreditLeft is the plan's ride credit still unspent this period.
+func (s *Subscription) CreditLeft(p *Plan) int64 {
+	return max(p.RideCredit-s.CreditUsed, 0)
+}
+
+// benefit splits what the plan takes off fare into the discount and the part
+// of the rest paid from creditLeft.
+func benefit(p *Plan, creditLeft, fare int64) (discount, credit int64) {
+	if fare <= 0 {
+		return 0, 0
+	}
+	discount = fare * int64(p.DiscountPercent) / 100
+	credit = min(max(creditLeft, 0), fare-discount)
+	return discount, credit
+}
+
+// proratedRefund is the share of the period's fee for the time left in it,
+// less the benefits already taken beyond the time used, so spending a month's
+// credit and cancelling the next day returns nothing.
+func proratedRefund(p *Plan, s *Subscription, now time.Time) int64 {
+	period := s.PeriodEnd.Sub(s.PeriodStart)
+	left := s.PeriodEnd.Sub(now)
+	if period <= 0 || left <= 0 {
+		return 0
+	}
+	unused := p.Price * int64(min(left, period)/time.Second) / int64(period/time.Second)
+	return max(min(unused, p.Price-s.CreditUsed-s.Discounted), 0)
+}
+
+// Biller charges and refunds plan fees through the payment provider.
+type Biller interface {
+	// Charge bills userID amount for the period identified by ref and returns
+	// the provider's reference. Charging the same ref again must not bill
+	// twice: implementations pass it as the idempotency key.
+	Charge(ctx context.Context, userID types.ID, amount int64, ref string) (string, error)
+	// Refund pays back amount of the charge chargeRef.
+	Refund(ctx context.Context, userID types.ID, chargeRef string, amount int64) error
+}
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrBadRequest = errors.New("bad request")
+	// ErrConflict is returned when the user already has a subscription.
+	ErrConflict = errors.New("already subscribed")
+	// ErrPaymentFailed is returned when the payment provider declines a charge.
+	ErrPaymentFailed = errors.New("payment failed")
+	// ErrBillingUnavailable is returned when no payment provider is configured.
+	ErrBillingUnavailable = errors.New("billing unavailable")
+)
@@ -0,0 +1,26 @@
+// README: Subscription route registration — mounts the passenger ride plan and ops plan endpoints.
+package subscription
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the ride plan endpoints onto the provided authenticated router group.
+//
+//	GET    /api/subscription-plans
+//	GET    /api/subscription
+//	POST   /api/subscription
+//	DELETE /api/subscription
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/subscription-plans", h.Plans)
+	rg.GET("/api/subscription", h.Mine)
+	rg.POST("/api/subscription", h.Subscribe)
+	rg.DELETE("/api/subscription", h.Cancel)
+}
+
+// RegisterOpsRoutes mounts the plan management endpoints onto the provided ops router group.
+//
+//	GET /api/ops/subscription-plans
+//	PUT /api/ops/subscription-plans/:id
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/subscription-plans", h.AllPlans)
+	rg.PUT("/api/ops/subscription-plans/:id", h.SavePlan)
+}
@@ -0,0 +1,244 @@
+// README: Subscription service — ride plans, subscribing and cancelling with a prorated refund, monthly renewal through the payment provider, and plan benefits applied to fares.
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ark/internal/types"
+)
+
+const (
+	// renewBatch caps the subscriptions renewed per job run.
+	renewBatch = 200
+	// pastDueGrace is how long a failed renewal is retried before the
+	// subscription is cancelled.
+	pastDueGrace = 7 * 24 * time.Hour
+)
+
+// Service sells ride plans and applies them to fares.
+type Service struct {
+	store  SubscriptionStore
+	biller Biller
+	now    func() time.Time
+}
+
+// NewService creates a Service. Plans cannot be bought until SetBiller is called.
+func NewService(store SubscriptionStore) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// SetBiller charges plan fees through the payment provider.
+func (s *Service) SetBiller(b Biller) {
+	s.biller = b
+}
+
+// ---------------------------------------------------------------------------
+// Plans
+// ---------------------------------------------------------------------------
+
+// Plans returns the plans on sale, or every plan when all is set.
+func (s *Service) Plans(ctx context.Context, all bool) ([]Plan, error) {
+	return s.store.ListPlans(ctx, !all)
+}
+
+// SavePlan creates p or replaces the plan with its ID. Price changes apply
+// from each subscription's next renewal.
+func (s *Service) SavePlan(ctx context.Context, p Plan) (*Plan, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	p.CreatedAt = s.now()
+	if err := s.store.SavePlan(ctx, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ---------------------------------------------------------------------------
+// Subscriptions
+// ---------------------------------------------------------------------------
+
+// Mine returns userID's active or past-due subscription and its plan.
+func (s *Service) Mine(ctx context.Context, userID types.ID) (*Subscription, *Plan, error) {
+	if userID == "" {
+		return nil, nil, ErrBadRequest
+	}
+	sub, err := s.store.Live(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := s.store.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sub, p, nil
+}
+
+// Subscribe charges userID the first month of planID and starts the plan.
+func (s *Service) Subscribe(ctx context.Context, userID, planID types.ID) (*Subscription, *Plan, error) {
+	if userID == "" || planID == "" {
+		return nil, nil, ErrBadRequest
+	}
+	if s.biller == nil {
+		return nil, nil, ErrBillingUnavailable
+	}
+	p, err := s.store.GetPlan(ctx, planID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !p.Active {
+		return nil, nil, ErrNotFound
+	}
+	if _, err := s.store.Live(ctx, userID); err == nil {
+		return nil, nil, ErrConflict
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, nil, err
+	}
+
+	now := s.now()
+	sub := &Subscription{
+		ID:          types.NewID(),
+		UserID:      userID,
+		PlanID:      p.ID,
+		Status:      StatusActive,
+		PeriodStart: now,
+		PeriodEnd:   now.AddDate(0, 1, 0),
+		CreatedAt:   now,
+	}
+	sub.ChargeRef, err = s.biller.Charge(ctx, userID, p.Price, chargeKey(sub.ID, sub.PeriodStart))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	if err := s.store.Create(ctx, sub, p.Price); err != nil {
+		// Usually a concurrent subscribe won; either way hand this charge back.
+		if rErr := s.biller.Refund(ctx, userID, sub.ChargeRef, p.Price); rErr != nil {
+			log.Printf("subscription: refund charge %s for %s: %v", sub.ChargeRef, userID, rErr)
+		}
+		return nil, nil, err
+	}
+	return sub, p, nil
+}
+
+// Cancel ends userID's subscription now and refunds the unused part of the
+// current period. A past-due subscription is unpaid, so nothing is refunded.
+func (s *Service) Cancel(ctx context.Context, userID types.ID) (*Subscription, error) {
+	sub, p, err := s.Mine(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	var refund int64
+	if sub.Status == StatusActive {
+		refund = proratedRefund(p, sub, now)
+	}
+	if refund > 0 {
+		if s.biller == nil {
+			return nil, ErrBillingUnavailable
+		}
+		if err := s.biller.Refund(ctx, userID, sub.ChargeRef, refund); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+		}
+	}
+	if err := s.store.Cancel(ctx, sub, refund, now); err != nil {
+		return nil, err
+	}
+	sub.Status, sub.Refund, sub.CancelledAt = StatusCancelled, refund, &now
+	return sub, nil
+}
+
+// ---------------------------------------------------------------------------
+// Renewal
+// ---------------------------------------------------------------------------
+
+// Renew charges sub's next period. An active subscription renews from the
+// end of its period; a past-due one from now, so the lapsed days are not
+// billed. A declined charge leaves it past due, and one past due for longer
+// than pastDueGrace is cancelled.
+func (s *Service) Renew(ctx context.Context, sub *Subscription) error {
+	now := s.now()
+	if sub.Status == StatusPastDue && now.Sub(sub.PeriodEnd) > pastDueGrace {
+		return s.store.Cancel(ctx, sub, 0, now)
+	}
+	p, err := s.store.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return err
+	}
+	start := sub.PeriodEnd
+	if sub.Status == StatusPastDue {
+		start = now
+	}
+	ref, err := s.biller.Charge(ctx, sub.UserID, p.Price, chargeKey(sub.ID, start))
+	if err != nil {
+		if mErr := s.store.MarkPastDue(ctx, sub.ID); mErr != nil {
+			log.Printf("subscription: mark %s past due: %v", sub.ID, mErr)
+		}
+		return fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	return s.store.Renew(ctx, sub, start, start.AddDate(0, 1, 0), p.Price, ref, now)
+}
+
+// RenewDue renews every subscription whose period has ended and returns how
+// many renewed.
+func (s *Service) RenewDue(ctx context.Context) (int, error) {
+	if s.biller == nil {
+		return 0, nil
+	}
+	due, err := s.store.ListDue(ctx, s.now(), renewBatch)
+	if err != nil {
+		return 0, err
+	}
+	renewed := 0
+	for i := range due {
+		if err := s.Renew(ctx, &due[i]); err != nil {
+			log.Printf("subscription: renew %s: %v", due[i].ID, err)
+			continue
+		}
+		renewed++
+	}
+	return renewed, nil
+}
+
+// RunRenewalJob renews due subscriptions hourly, which also retries declined
+// renewals.
+func (s *Service) RunRenewalJob(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.RenewDue(ctx)
+			if err != nil {
+				log.Printf("subscription: renewals: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("subscription: renewed %d subscriptions", n)
+			}
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Fares
+// ---------------------------------------------------------------------------
+
+// Apply implements order.Subscriptions: it takes the passenger's plan
+// discount and then their included ride credit off the fare.
+func (s *Service) Apply(ctx context.Context, passengerID, orderID types.ID, fare types.Money) (int64, error) {
+	// Plans are sold in the platform currency and never converted.
+	if fare.Amount <= 0 || fare.Currency != types.DefaultCurrency {
+		return 0, nil
+	}
+	return s.store.Apply(ctx, passengerID, orderID, fare.Amount, s.now())
+}
+
+// chargeKey identifies one period's charge to the payment provider.
+func chargeKey(id types.ID, start time.Time) string {
+	return fmt.Sprintf("%s:%d", id, start.Unix())
+}
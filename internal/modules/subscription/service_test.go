@@ -0,0 +1,392 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+var _ order.Subscriptions = (*Service)(nil)
+
+// ---------------------------------------------------------------------------
+// In-memory fakes
+// ---------------------------------------------------------------------------
+
+type mockStore struct {
+	mu      sync.Mutex
+	plans   map[types.ID]*Plan
+	subs    map[types.ID]*Subscription
+	usage   map[types.ID]int64 // order ID -> benefit applied
+	charges []string           // "kind:subscription:amount"
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{
+		plans: make(map[types.ID]*Plan),
+		subs:  make(map[types.ID]*Subscription),
+		usage: make(map[types.ID]int64),
+	}
+}
+
+func (m *mockStore) SavePlan(_ context.Context, p *Plan) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *p
+	m.plans[p.ID] = &cp
+	return nil
+}
+
+func (m *mockStore) GetPlan(_ context.Context, id types.ID) (*Plan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *p
+	return &cp, nil
+}
+
+func (m *mockStore) ListPlans(_ context.Context, activeOnly bool) ([]Plan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Plan
+	for _, p := range m.plans {
+		if p.Active || !activeOnly {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) live(userID types.ID) *Subscription {
+	for _, s := range m.subs {
+		if s.UserID == userID && (s.Status == StatusActive || s.Status == StatusPastDue) {
+			return s
+		}
+	}
+	return nil
+}
+
+func (m *mockStore) Live(_ context.Context, userID types.ID) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.live(userID)
+	if s == nil {
+		return nil, ErrNotFound
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (m *mockStore) Create(_ context.Context, sub *Subscription, price int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.live(sub.UserID) != nil {
+		return ErrConflict
+	}
+	cp := *sub
+	m.subs[sub.ID] = &cp
+	m.charges = append(m.charges, chargeEntry(chargeKindCharge, sub.ID, price))
+	return nil
+}
+
+func (m *mockStore) Renew(_ context.Context, sub *Subscription, start, end time.Time, price int64, ref string, _ time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.subs[sub.ID]
+	s.Status, s.PeriodStart, s.PeriodEnd, s.ChargeRef = StatusActive, start, end, ref
+	s.CreditUsed, s.Discounted = 0, 0
+	m.charges = append(m.charges, chargeEntry(chargeKindCharge, sub.ID, price))
+	return nil
+}
+
+func (m *mockStore) MarkPastDue(_ context.Context, id types.ID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s := m.subs[id]; s.Status == StatusActive {
+		s.Status = StatusPastDue
+	}
+	return nil
+}
+
+func (m *mockStore) Cancel(_ context.Context, sub *Subscription, refund int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.subs[sub.ID]
+	s.Status, s.Refund, s.CancelledAt = StatusCancelled, refund, &at
+	if refund > 0 {
+		m.charges = append(m.charges, chargeEntry(chargeKindRefund, sub.ID, refund))
+	}
+	return nil
+}
+
+func (m *mockStore) ListDue(_ context.Context, now time.Time, _ int) ([]Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Subscription
+	for _, s := range m.subs {
+		if (s.Status == StatusActive || s.Status == StatusPastDue) && !s.PeriodEnd.After(now) {
+			out = append(out, *s)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) Apply(_ context.Context, userID, orderID types.ID, fare int64, at time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n, ok := m.usage[orderID]; ok {
+		return n, nil
+	}
+	s := m.live(userID)
+	if s == nil || s.Status != StatusActive || at.Before(s.PeriodStart) || !at.Before(s.PeriodEnd) {
+		return 0, nil
+	}
+	discount, credit := benefit(m.plans[s.PlanID], s.CreditLeft(m.plans[s.PlanID]), fare)
+	s.CreditUsed += credit
+	s.Discounted += discount
+	m.usage[orderID] = discount + credit
+	return discount + credit, nil
+}
+
+func chargeEntry(kind string, id types.ID, amount int64) string {
+	return fmt.Sprintf("%s:%s:%d", kind, id, amount)
+}
+
+// fakeBiller accepts every charge unless decline is set and records refunds.
+type fakeBiller struct {
+	decline bool
+	charged []string
+	refunds map[string]int64
+}
+
+func (f *fakeBiller) Charge(_ context.Context, _ types.ID, _ int64, ref string) (string, error) {
+	if f.decline {
+		return "", errors.New("card declined")
+	}
+	f.charged = append(f.charged, ref)
+	return "ch_" + ref, nil
+}
+
+func (f *fakeBiller) Refund(_ context.Context, _ types.ID, chargeRef string, amount int64) error {
+	if f.refunds == nil {
+		f.refunds = make(map[string]int64)
+	}
+	f.refunds[chargeRef] += amount
+	return nil
+}
+
+var t0 = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func newTestService(t *testing.T) (*Service, *mockStore, *fakeBiller, *time.Time) {
+	t.Helper()
+	store := newMockStore()
+	biller := &fakeBiller{}
+	svc := NewService(store)
+	svc.SetBiller(biller)
+	now := t0
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+	for _, p := range []Plan{
+		{ID: "commuter", Name: "Commuter", Price: 99900, RideCredit: 60000, DiscountPercent: 10, Active: true},
+		{ID: "saver", Name: "Saver", Price: 19900, DiscountPercent: 15, Active: true},
+		{ID: "retired", Name: "Old", Price: 9900, RideCredit: 10000, Active: false},
+	} {
+		if _, err := svc.SavePlan(ctx, p); err != nil {
+			t.Fatalf("SavePlan %s: %v", p.ID, err)
+		}
+	}
+	return svc, store, biller, &now
+}
+
+func twd(amount int64) types.Money {
+	return types.Money{Amount: amount, Currency: types.DefaultCurrency}
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestSavePlan_Validates(t *testing.T) {
+	svc, _, _, _ := newTestService(t)
+	for _, p := range []Plan{
+		{ID: "", Name: "x", Price: 100, RideCredit: 100},
+		{ID: "p", Name: "x", Price: 0, RideCredit: 100},
+		{ID: "p", Name: "x", Price: 100, DiscountPercent: 101},
+		{ID: "p", Name: "x", Price: 100}, // gives nothing
+	} {
+		if _, err := svc.SavePlan(context.Background(), p); !errors.Is(err, ErrBadRequest) {
+			t.Errorf("SavePlan(%+v) = %v, want ErrBadRequest", p, err)
+		}
+	}
+}
+
+func TestSubscribe_ChargesFirstMonth(t *testing.T) {
+	svc, store, biller, _ := newTestService(t)
+	ctx := context.Background()
+
+	sub, _, err := svc.Subscribe(ctx, "pax", "commuter")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if sub.Status != StatusActive || !sub.PeriodEnd.Equal(t0.AddDate(0, 1, 0)) {
+		t.Errorf("subscription = %+v, want active for one month", sub)
+	}
+	if len(biller.charged) != 1 || len(store.charges) != 1 || sub.ChargeRef == "" {
+		t.Errorf("charged %v, recorded %v, ref %q; want one charge", biller.charged, store.charges, sub.ChargeRef)
+	}
+
+	if _, _, err := svc.Subscribe(ctx, "pax", "saver"); !errors.Is(err, ErrConflict) {
+		t.Errorf("second Subscribe = %v, want ErrConflict", err)
+	}
+	if _, _, err := svc.Subscribe(ctx, "other", "retired"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Subscribe to retired plan = %v, want ErrNotFound", err)
+	}
+	biller.decline = true
+	if _, _, err := svc.Subscribe(ctx, "other", "saver"); !errors.Is(err, ErrPaymentFailed) {
+		t.Errorf("declined Subscribe = %v, want ErrPaymentFailed", err)
+	}
+	if _, err := store.Live(ctx, "other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("declined charge left a subscription: %v", err)
+	}
+
+	svc.SetBiller(nil)
+	if _, _, err := svc.Subscribe(ctx, "third", "saver"); !errors.Is(err, ErrBillingUnavailable) {
+		t.Errorf("Subscribe without biller = %v, want ErrBillingUnavailable", err)
+	}
+}
+
+func TestApply_DiscountThenIncludedCredit(t *testing.T) {
+	svc, _, _, _ := newTestService(t)
+	ctx := context.Background()
+	if _, _, err := svc.Subscribe(ctx, "pax", "commuter"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// 10% off 50000, the remaining 45000 from the 60000 credit.
+	if got, _ := svc.Apply(ctx, "pax", "o1", twd(50000)); got != 50000 {
+		t.Errorf("first ride covered %d, want 50000", got)
+	}
+	// 10% off 30000, then the 15000 credit left.
+	if got, _ := svc.Apply(ctx, "pax", "o2", twd(30000)); got != 3000+15000 {
+		t.Errorf("second ride covered %d, want 18000", got)
+	}
+	// Credit spent: only the discount.
+	if got, _ := svc.Apply(ctx, "pax", "o3", twd(20000)); got != 2000 {
+		t.Errorf("third ride covered %d, want 2000", got)
+	}
+	// Applying an order again returns the first amount.
+	if got, _ := svc.Apply(ctx, "pax", "o1", twd(50000)); got != 50000 {
+		t.Errorf("repeat Apply = %d, want 50000", got)
+	}
+	if got, _ := svc.Apply(ctx, "nobody", "o4", twd(20000)); got != 0 {
+		t.Errorf("passenger without plan covered %d, want 0", got)
+	}
+	if got, _ := svc.Apply(ctx, "pax", "o5", types.Money{Amount: 20000, Currency: "USD"}); got != 0 {
+		t.Errorf("foreign-currency fare covered %d, want 0", got)
+	}
+}
+
+func TestCancel_ProratesRefund(t *testing.T) {
+	svc, store, biller, now := newTestService(t)
+	ctx := context.Background()
+
+	// Saver: 19900 for March (31 days); cancelled after 10 days with 900 of
+	// discounts taken, well under the 21 days' share.
+	sub, _, err := svc.Subscribe(ctx, "pax", "saver")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err := svc.Apply(ctx, "pax", "o1", twd(6000)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	*now = t0.Add(10 * 24 * time.Hour)
+	got, err := svc.Cancel(ctx, "pax")
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	want := int64(19900 * 21 / 31)
+	if got.Status != StatusCancelled || got.Refund != want {
+		t.Errorf("cancelled = %s refund %d, want cancelled refund %d", got.Status, got.Refund, want)
+	}
+	if biller.refunds[sub.ChargeRef] != want || len(store.charges) != 2 {
+		t.Errorf("refunds %v, charges %v", biller.refunds, store.charges)
+	}
+	if _, err := svc.Cancel(ctx, "pax"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Cancel = %v, want ErrNotFound", err)
+	}
+
+	// Commuter: the whole month's credit spent on day one leaves nothing to refund.
+	*now = t0
+	if _, _, err := svc.Subscribe(ctx, "heavy", "commuter"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err := svc.Apply(ctx, "heavy", "o2", twd(80000)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	*now = t0.Add(24 * time.Hour)
+	got, err = svc.Cancel(ctx, "heavy")
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if got.Refund != 99900-60000-8000 {
+		t.Errorf("refund after spending the benefit = %d, want %d", got.Refund, 99900-60000-8000)
+	}
+}
+
+func TestRenewDue_RetriesDeclinedCharges(t *testing.T) {
+	svc, store, biller, now := newTestService(t)
+	ctx := context.Background()
+	sub, _, err := svc.Subscribe(ctx, "pax", "commuter")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err := svc.Apply(ctx, "pax", "o1", twd(20000)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	// The renewal is declined: no benefits while past due.
+	*now = sub.PeriodEnd.Add(time.Minute)
+	biller.decline = true
+	if n, _ := svc.RenewDue(ctx); n != 0 {
+		t.Fatalf("renewed %d with a declined card", n)
+	}
+	if s, _ := store.Live(ctx, "pax"); s.Status != StatusPastDue {
+		t.Fatalf("status = %s, want past_due", s.Status)
+	}
+	if got, _ := svc.Apply(ctx, "pax", "o2", twd(20000)); got != 0 {
+		t.Errorf("past-due plan covered %d, want 0", got)
+	}
+
+	// A day later the retry succeeds and the new month starts then, with a
+	// fresh credit.
+	*now = sub.PeriodEnd.Add(24 * time.Hour)
+	biller.decline = false
+	if n, err := svc.RenewDue(ctx); n != 1 || err != nil {
+		t.Fatalf("RenewDue = %d, %v; want 1", n, err)
+	}
+	s, _ := store.Live(ctx, "pax")
+	if s.Status != StatusActive || !s.PeriodStart.Equal(*now) || s.CreditUsed != 0 {
+		t.Errorf("renewed = %+v, want active from now with no credit used", s)
+	}
+
+	// Declined for longer than the grace period: cancelled without refund.
+	*now = s.PeriodEnd.Add(time.Minute)
+	biller.decline = true
+	svc.RenewDue(ctx)
+	*now = s.PeriodEnd.Add(pastDueGrace + time.Hour)
+	svc.RenewDue(ctx)
+	if _, err := store.Live(ctx, "pax"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("subscription still live after the grace period: %v", err)
+	}
+	if store.subs[sub.ID].Refund != 0 {
+		t.Errorf("lapsed subscription refunded %d", store.subs[sub.ID].Refund)
+	}
+}
@@ -0,0 +1,345 @@
+// README: Subscription store — PostgreSQL persistence for ride plans, subscriptions, their charges and the benefit applied to each order.
+package subscription
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/modules/ledger"
+	"ark/internal/types"
+)
+
+// Charge kinds in subscription_charges.
+const (
+	chargeKindCharge = "charge"
+	chargeKindRefund = "refund"
+)
+
+// SubscriptionStore defines the persistence operations required by the Service.
+type SubscriptionStore interface {
+	// SavePlan creates p or replaces the plan with its ID.
+	SavePlan(ctx context.Context, p *Plan) error
+	GetPlan(ctx context.Context, id types.ID) (*Plan, error)
+	// ListPlans returns plans ordered by price, only active ones when activeOnly is set.
+	ListPlans(ctx context.Context, activeOnly bool) ([]Plan, error)
+
+	// Live returns the user's active or past-due subscription, or ErrNotFound.
+	Live(ctx context.Context, userID types.ID) (*Subscription, error)
+	// Create stores sub with the charge for its first period. It returns
+	// ErrConflict when the user already has a live subscription.
+	Create(ctx context.Context, sub *Subscription, price int64) error
+	// Renew moves sub into the period [start, end) paid at `at` by the charge
+	// ref, making it active with no benefits taken.
+	Renew(ctx context.Context, sub *Subscription, start, end time.Time, price int64, ref string, at time.Time) error
+	MarkPastDue(ctx context.Context, id types.ID) error
+	// Cancel ends sub, recording the refund paid back.
+	Cancel(ctx context.Context, sub *Subscription, refund int64, at time.Time) error
+	// ListDue returns live subscriptions whose period ended by now, oldest first.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]Subscription, error)
+
+	// Apply takes the benefit of the user's active plan off fare for
+	// orderID and returns it; 0 without an active plan. Applying the same
+	// order again returns the first amount.
+	Apply(ctx context.Context, userID, orderID types.ID, fare int64, at time.Time) (int64, error)
+}
+
+// Store is the PostgreSQL implementation of SubscriptionStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// ---------------------------------------------------------------------------
+// Plans
+// ---------------------------------------------------------------------------
+
+const planColumns = `id, name, price, ride_credit, discount_percent, active, created_at`
+
+func scanPlan(row pgx.Row) (*Plan, error) {
+	var p Plan
+	var id string
+	if err := row.Scan(&id, &p.Name, &p.Price, &p.RideCredit, &p.DiscountPercent, &p.Active, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	p.ID = types.ID(id)
+	return &p, nil
+}
+
+func (s *Store) SavePlan(ctx context.Context, p *Plan) error {
+	return s.db.QueryRow(ctx, `
+		INSERT INTO subscription_plans (id, name, price, ride_credit, discount_percent, active, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+		    name             = EXCLUDED.name,
+		    price            = EXCLUDED.price,
+		    ride_credit      = EXCLUDED.ride_credit,
+		    discount_percent = EXCLUDED.discount_percent,
+		    active           = EXCLUDED.active
+		RETURNING created_at`,
+		string(p.ID), p.Name, p.Price, p.RideCredit, p.DiscountPercent, p.Active, p.CreatedAt,
+	).Scan(&p.CreatedAt)
+}
+
+func (s *Store) GetPlan(ctx context.Context, id types.ID) (*Plan, error) {
+	p, err := scanPlan(s.db.QueryRow(ctx, `SELECT `+planColumns+` FROM subscription_plans WHERE id = $1`, string(id)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return p, err
+}
+
+func (s *Store) ListPlans(ctx context.Context, activeOnly bool) ([]Plan, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+planColumns+` FROM subscription_plans
+		WHERE active OR NOT $1
+		ORDER BY price, id`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Plan
+	for rows.Next() {
+		p, err := scanPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+// ---------------------------------------------------------------------------
+// Subscriptions
+// ---------------------------------------------------------------------------
+
+const subscriptionColumns = `id, user_id, plan_id, status, period_start, period_end,
+	credit_used, discounted, charge_ref, refund, created_at, cancelled_at`
+
+func scanSubscription(row pgx.Row) (*Subscription, error) {
+	var sub Subscription
+	var id, userID, planID string
+	if err := row.Scan(&id, &userID, &planID, &sub.Status, &sub.PeriodStart, &sub.PeriodEnd,
+		&sub.CreditUsed, &sub.Discounted, &sub.ChargeRef, &sub.Refund, &sub.CreatedAt, &sub.CancelledAt); err != nil {
+		return nil, err
+	}
+	sub.ID, sub.UserID, sub.PlanID = types.ID(id), types.ID(userID), types.ID(planID)
+	return &sub, nil
+}
+
+func (s *Store) Live(ctx context.Context, userID types.ID) (*Subscription, error) {
+	sub, err := scanSubscription(s.db.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE user_id = $1 AND status IN ('active', 'past_due')`,
+		string(userID),
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return sub, err
+}
+
+func (s *Store) Create(ctx context.Context, sub *Subscription, price int64) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO subscriptions (id, user_id, plan_id, status, period_start, period_end, charge_ref, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		string(sub.ID), string(sub.UserID), string(sub.PlanID), sub.Status,
+		sub.PeriodStart, sub.PeriodEnd, sub.ChargeRef, sub.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if err := recordCharge(ctx, tx, sub, chargeKindCharge, price, sub.PeriodStart, sub.ChargeRef, sub.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) Renew(ctx context.Context, sub *Subscription, start, end time.Time, price int64, ref string, at time.Time) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE subscriptions
+		SET status = 'active', period_start = $2, period_end = $3,
+		    credit_used = 0, discounted = 0, charge_ref = $4
+		WHERE id = $1 AND status IN ('active', 'past_due')`,
+		string(sub.ID), start, end, ref,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if err := recordCharge(ctx, tx, sub, chargeKindCharge, price, start, ref, at); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) MarkPastDue(ctx context.Context, id types.ID) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE subscriptions SET status = 'past_due'
+		WHERE id = $1 AND status = 'active'`,
+		string(id),
+	)
+	return err
+}
+
+func (s *Store) Cancel(ctx context.Context, sub *Subscription, refund int64, at time.Time) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE subscriptions SET status = 'cancelled', refund = $2, cancelled_at = $3
+		WHERE id = $1 AND status IN ('active', 'past_due')`,
+		string(sub.ID), refund, at,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if refund > 0 {
+		if err := recordCharge(ctx, tx, sub, chargeKindRefund, refund, sub.PeriodStart, sub.ChargeRef, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// recordCharge records a plan fee charged or refunded and books it in the
+// ledger, once per subscription, kind and period.
+func recordCharge(ctx context.Context, tx pgx.Tx, sub *Subscription, kind string, amount int64, periodStart time.Time, ref string, at time.Time) error {
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO subscription_charges (subscription_id, kind, amount, period_start, provider_ref, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (subscription_id, kind, period_start) DO NOTHING`,
+		string(sub.ID), kind, amount, periodStart, ref, at,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		return err
+	}
+	key := chargeKey(sub.ID, periodStart)
+	txn := ledger.SubscriptionTxn(sub.UserID, amount, key, at)
+	if kind == chargeKindRefund {
+		txn = ledger.SubscriptionRefundTxn(sub.UserID, amount, key, at)
+	}
+	_, err = ledger.PostTx(ctx, tx, txn)
+	return err
+}
+
+func (s *Store) ListDue(ctx context.Context, now time.Time, limit int) ([]Subscription, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE status IN ('active', 'past_due') AND period_end <= $1
+		ORDER BY period_end
+		LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *sub)
+	}
+	return out, rows.Err()
+}
+
+// ---------------------------------------------------------------------------
+// Fares
+// ---------------------------------------------------------------------------
+
+// Apply locks the subscription row so two orders reaching payment together
+// cannot overspend the period's credit.
+func (s *Store) Apply(ctx context.Context, userID, orderID types.ID, fare int64, at time.Time) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var applied int64
+	err = tx.QueryRow(ctx, `
+		SELECT credit + discount FROM subscription_usage WHERE order_id = $1`,
+		string(orderID),
+	).Scan(&applied)
+	if err == nil {
+		return applied, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	sub, err := scanSubscription(tx.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE user_id = $1 AND status = 'active' AND period_start <= $2 AND period_end > $2
+		FOR UPDATE`,
+		string(userID), at,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	p, err := scanPlan(tx.QueryRow(ctx, `SELECT `+planColumns+` FROM subscription_plans WHERE id = $1`, string(sub.PlanID)))
+	if err != nil {
+		return 0, err
+	}
+	discount, credit := benefit(p, sub.CreditLeft(p), fare)
+	if discount+credit <= 0 {
+		return 0, nil
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO subscription_usage (order_id, subscription_id, credit, discount, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		string(orderID), string(sub.ID), credit, discount, at,
+	); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE subscriptions SET credit_used = credit_used + $2, discounted = discounted + $3
+		WHERE id = $1`,
+		string(sub.ID), credit, discount,
+	); err != nil {
+		return 0, err
+	}
+	return discount + credit, tx.Commit(ctx)
+}
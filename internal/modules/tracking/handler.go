@@ -0,0 +1,119 @@
+// README: Trip tracking HTTP handlers — passengers manage who can follow their live trip.
+//
+// Endpoints:
+//
+//	POST   /api/orders/:id/tracking/grants             — grant a friend access ({"viewer_id": "..."})
+//	GET    /api/orders/:id/tracking/grants             — list active grants
+//	DELETE /api/orders/:id/tracking/grants/:viewer_id  — revoke a friend's access
+//
+// Auth: all routes require the Auth middleware to set user_id in context.
+package tracking
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the trip tracking HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type grantReq struct {
+	ViewerID string `json:"viewer_id"`
+}
+
+type grantResp struct {
+	OrderID   types.ID `json:"order_id"`
+	ViewerID  types.ID `json:"viewer_id"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+func toGrantResp(g Grant) grantResp {
+	return grantResp{OrderID: g.OrderID, ViewerID: g.ViewerID, CreatedAt: g.CreatedAt.Unix()}
+}
+
+// CreateGrant handles POST /api/orders/:id/tracking/grants.
+func (h *Handler) CreateGrant(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req grantReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.ViewerID == "" {
+		writeError(c, http.StatusBadRequest, "missing viewer_id")
+		return
+	}
+	g, err := h.svc.Grant(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")), types.ID(req.ViewerID))
+	if err != nil {
+		writeTrackingError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toGrantResp(*g))
+}
+
+// ListGrants handles GET /api/orders/:id/tracking/grants.
+func (h *Handler) ListGrants(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	grants, err := h.svc.List(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")))
+	if err != nil {
+		writeTrackingError(c, err)
+		return
+	}
+	out := make([]grantResp, len(grants))
+	for i, g := range grants {
+		out[i] = toGrantResp(g)
+	}
+	c.JSON(http.StatusOK, map[string]any{"grants": out})
+}
+
+// RevokeGrant handles DELETE /api/orders/:id/tracking/grants/:viewer_id.
+func (h *Handler) RevokeGrant(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	err := h.svc.Revoke(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")), types.ID(c.Param("viewer_id")))
+	if err != nil {
+		writeTrackingError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeTrackingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrForbidden):
+		writeError(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrUnavailable):
+		writeError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,28 @@
+// README: Trip tracking domain model — per-trip grants letting friends/family watch a passenger's live location.
+package tracking
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Grant allows ViewerID to read PassengerID's live location while OrderID is in progress.
+type Grant struct {
+	ID          int64
+	OrderID     types.ID
+	PassengerID types.ID
+	ViewerID    types.ID
+	CreatedAt   time.Time
+	RevokedAt   *time.Time
+}
+
+var (
+	ErrNotFound   = errors.New("tracking: not found")
+	ErrBadRequest = errors.New("tracking: bad request")
+	ErrConflict   = errors.New("tracking: grant already exists")
+	ErrForbidden  = errors.New("tracking: forbidden")
+	// ErrUnavailable is returned when no GrantWriter (Firebase RTDB) is configured.
+	ErrUnavailable = errors.New("tracking: live tracking not configured")
+)
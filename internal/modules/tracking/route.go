@@ -0,0 +1,16 @@
+// README: Trip tracking route registration — mounts the grant endpoints onto the given router group.
+package tracking
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the trip tracking endpoints onto the provided authenticated router group.
+//
+//	POST   /api/orders/:id/tracking/grants
+//	GET    /api/orders/:id/tracking/grants
+//	DELETE /api/orders/:id/tracking/grants/:viewer_id
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	grants := rg.Group("/api/orders/:id/tracking/grants")
+	grants.POST("", h.CreateGrant)
+	grants.GET("", h.ListGrants)
+	grants.DELETE("/:viewer_id", h.RevokeGrant)
+}
@@ -0,0 +1,65 @@
+// README: RTDB grant nodes consumed by the Firebase security rules for live trip tracking.
+package tracking
+
+import (
+	"context"
+	"fmt"
+
+	"firebase.google.com/go/v4/db"
+
+	"ark/internal/types"
+)
+
+// grantsNode is the RTDB root holding grant nodes, keyed passenger → viewer.
+// The matching security rule for passenger locations is:
+//
+//	"passenger_locations": {
+//	  "$uid": {
+//	    ".read": "auth.uid === $uid || root.child('trip_tracking_grants/' + $uid + '/' + auth.uid).exists()"
+//	  }
+//	},
+//	"trip_tracking_grants": { ".read": false, ".write": false }
+//
+// Only the backend (admin SDK) writes grant nodes, and deleting one revokes
+// access on the viewer's next read.
+const grantsNode = "trip_tracking_grants"
+
+// GrantWriter publishes grants to wherever the access rules are evaluated.
+type GrantWriter interface {
+	WriteGrant(ctx context.Context, g Grant) error
+	DeleteGrant(ctx context.Context, passengerID, viewerID types.ID) error
+}
+
+// RTDBGrants writes grant nodes to Firebase RTDB.
+type RTDBGrants struct {
+	client *db.Client
+}
+
+// NewRTDBGrants returns a GrantWriter backed by the given RTDB client.
+func NewRTDBGrants(client *db.Client) *RTDBGrants {
+	return &RTDBGrants{client: client}
+}
+
+func grantPath(passengerID, viewerID types.ID) string {
+	return grantsNode + "/" + string(passengerID) + "/" + string(viewerID)
+}
+
+// WriteGrant creates trip_tracking_grants/{passenger}/{viewer}.
+func (r *RTDBGrants) WriteGrant(ctx context.Context, g Grant) error {
+	err := r.client.NewRef(grantPath(g.PassengerID, g.ViewerID)).Set(ctx, map[string]interface{}{
+		"order_id":   string(g.OrderID),
+		"granted_at": g.CreatedAt.UnixMilli(),
+	})
+	if err != nil {
+		return fmt.Errorf("tracking: write RTDB grant: %w", err)
+	}
+	return nil
+}
+
+// DeleteGrant removes trip_tracking_grants/{passenger}/{viewer}.
+func (r *RTDBGrants) DeleteGrant(ctx context.Context, passengerID, viewerID types.ID) error {
+	if err := r.client.NewRef(grantPath(passengerID, viewerID)).Delete(ctx); err != nil {
+		return fmt.Errorf("tracking: delete RTDB grant: %w", err)
+	}
+	return nil
+}
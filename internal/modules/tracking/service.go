@@ -0,0 +1,169 @@
+// README: Trip tracking service — grant/revoke friend access to a passenger's live trip location.
+package tracking
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// OrderReader is the subset of order.Service used to check trip ownership and state.
+type OrderReader interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// FriendChecker reports whether two users have an accepted friendship.
+type FriendChecker interface {
+	IsFriend(ctx context.Context, uid1, uid2 types.ID) (bool, error)
+}
+
+// Service manages trip tracking grants.
+type Service struct {
+	store   TrackingStore
+	grants  GrantWriter
+	orders  OrderReader
+	friends FriendChecker
+}
+
+// NewService creates a Service. grants may be nil when Firebase RTDB is not
+// configured, in which case Grant returns ErrUnavailable.
+func NewService(store TrackingStore, grants GrantWriter, orders OrderReader, friends FriendChecker) *Service {
+	return &Service{store: store, grants: grants, orders: orders, friends: friends}
+}
+
+// Grant lets viewerID follow passengerID's live location for orderID. The order
+// must belong to the passenger and still be in progress, and the viewer must be
+// an accepted friend.
+func (s *Service) Grant(ctx context.Context, passengerID, orderID, viewerID types.ID) (*Grant, error) {
+	if passengerID == "" || orderID == "" || viewerID == "" || passengerID == viewerID {
+		return nil, ErrBadRequest
+	}
+	if s.grants == nil {
+		return nil, ErrUnavailable
+	}
+	if err := s.checkOwnedActiveOrder(ctx, passengerID, orderID); err != nil {
+		return nil, err
+	}
+	if s.friends != nil {
+		ok, err := s.friends.IsFriend(ctx, passengerID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrForbidden
+		}
+	}
+
+	g := &Grant{OrderID: orderID, PassengerID: passengerID, ViewerID: viewerID, CreatedAt: time.Now()}
+	if err := s.store.CreateGrant(ctx, g); err != nil {
+		return nil, err
+	}
+	if err := s.grants.WriteGrant(ctx, *g); err != nil {
+		// Keep Postgres in step with RTDB so the grant is not listed as active.
+		if rerr := s.store.RevokeGrant(ctx, orderID, viewerID); rerr != nil {
+			log.Printf("tracking: rollback grant %s/%s: %v", orderID, viewerID, rerr)
+		}
+		return nil, err
+	}
+	return g, nil
+}
+
+// Revoke removes viewerID's access to the order's live location. The RTDB node
+// is deleted first so access stops even if the database update fails.
+func (s *Service) Revoke(ctx context.Context, passengerID, orderID, viewerID types.ID) error {
+	if passengerID == "" || orderID == "" || viewerID == "" {
+		return ErrBadRequest
+	}
+	if err := s.checkOwnedOrder(ctx, passengerID, orderID); err != nil {
+		return err
+	}
+	if s.grants != nil {
+		if err := s.grants.DeleteGrant(ctx, passengerID, viewerID); err != nil {
+			return err
+		}
+	}
+	return s.store.RevokeGrant(ctx, orderID, viewerID)
+}
+
+// List returns the active grants of an order owned by passengerID.
+func (s *Service) List(ctx context.Context, passengerID, orderID types.ID) ([]Grant, error) {
+	if passengerID == "" || orderID == "" {
+		return nil, ErrBadRequest
+	}
+	if err := s.checkOwnedOrder(ctx, passengerID, orderID); err != nil {
+		return nil, err
+	}
+	return s.store.ListActiveGrants(ctx, orderID)
+}
+
+// RevokeAllForOrder removes every grant of the order (used when the trip ends).
+func (s *Service) RevokeAllForOrder(ctx context.Context, orderID types.ID) error {
+	revoked, err := s.store.RevokeAllForOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if s.grants == nil {
+		return nil
+	}
+	var errs []error
+	for _, g := range revoked {
+		if err := s.grants.DeleteGrant(ctx, g.PassengerID, g.ViewerID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OrderHook revokes all of an order's grants once the trip is over.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if !tripEnded(t.To) {
+			return
+		}
+		if err := s.RevokeAllForOrder(ctx, t.OrderID); err != nil {
+			log.Printf("tracking: revoke grants for order %s (%s): %v", t.OrderID, t.To, err)
+		}
+	}
+}
+
+func tripEnded(st order.Status) bool {
+	switch st {
+	case order.StatusPayment, order.StatusComplete, order.StatusCancelled, order.StatusExpired:
+		return true
+	}
+	return false
+}
+
+func (s *Service) checkOwnedOrder(ctx context.Context, passengerID, orderID types.ID) error {
+	_, err := s.ownedOrder(ctx, passengerID, orderID)
+	return err
+}
+
+func (s *Service) checkOwnedActiveOrder(ctx context.Context, passengerID, orderID types.ID) error {
+	o, err := s.ownedOrder(ctx, passengerID, orderID)
+	if err != nil {
+		return err
+	}
+	if tripEnded(o.Status) || o.Status == order.StatusDenied {
+		return ErrBadRequest
+	}
+	return nil
+}
+
+func (s *Service) ownedOrder(ctx context.Context, passengerID, orderID types.ID) (*order.Order, error) {
+	o, err := s.orders.Get(ctx, orderID)
+	if errors.Is(err, order.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.PassengerID != passengerID {
+		return nil, ErrForbidden
+	}
+	return o, nil
+}
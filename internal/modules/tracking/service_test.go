@@ -0,0 +1,183 @@
+package tracking
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// ---------------------------------------------------------------------------
+// In-memory fakes
+// ---------------------------------------------------------------------------
+
+type mockStore struct {
+	active map[types.ID][]Grant
+}
+
+func newMockStore() *mockStore { return &mockStore{active: make(map[types.ID][]Grant)} }
+
+func (m *mockStore) CreateGrant(_ context.Context, g *Grant) error {
+	for _, e := range m.active[g.OrderID] {
+		if e.ViewerID == g.ViewerID {
+			return ErrConflict
+		}
+	}
+	m.active[g.OrderID] = append(m.active[g.OrderID], *g)
+	return nil
+}
+
+func (m *mockStore) RevokeGrant(_ context.Context, orderID, viewerID types.ID) error {
+	grants := m.active[orderID]
+	for i, g := range grants {
+		if g.ViewerID == viewerID {
+			m.active[orderID] = append(grants[:i], grants[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *mockStore) RevokeAllForOrder(_ context.Context, orderID types.ID) ([]Grant, error) {
+	out := m.active[orderID]
+	delete(m.active, orderID)
+	return out, nil
+}
+
+func (m *mockStore) ListActiveGrants(_ context.Context, orderID types.ID) ([]Grant, error) {
+	return m.active[orderID], nil
+}
+
+type mockGrants struct {
+	nodes    map[string]bool
+	writeErr error
+}
+
+func newMockGrants() *mockGrants { return &mockGrants{nodes: make(map[string]bool)} }
+
+func (m *mockGrants) WriteGrant(_ context.Context, g Grant) error {
+	if m.writeErr != nil {
+		return m.writeErr
+	}
+	m.nodes[grantPath(g.PassengerID, g.ViewerID)] = true
+	return nil
+}
+
+func (m *mockGrants) DeleteGrant(_ context.Context, passengerID, viewerID types.ID) error {
+	delete(m.nodes, grantPath(passengerID, viewerID))
+	return nil
+}
+
+type mockOrders map[types.ID]*order.Order
+
+func (m mockOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	if o, ok := m[id]; ok {
+		return o, nil
+	}
+	return nil, order.ErrNotFound
+}
+
+type mockFriends map[types.ID]bool
+
+func (m mockFriends) IsFriend(_ context.Context, _, uid2 types.ID) (bool, error) {
+	return m[uid2], nil
+}
+
+func newTestService() (*Service, *mockStore, *mockGrants) {
+	store, grants := newMockStore(), newMockGrants()
+	orders := mockOrders{
+		"o-live": {ID: "o-live", PassengerID: "p1", Status: order.StatusDriving},
+		"o-done": {ID: "o-done", PassengerID: "p1", Status: order.StatusComplete},
+	}
+	return NewService(store, grants, orders, mockFriends{"mom": true}), store, grants
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestGrant_WritesRTDBNode(t *testing.T) {
+	svc, store, grants := newTestService()
+	if _, err := svc.Grant(context.Background(), "p1", "o-live", "mom"); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+	if !grants.nodes[grantPath("p1", "mom")] {
+		t.Error("RTDB grant node not written")
+	}
+	if len(store.active["o-live"]) != 1 {
+		t.Errorf("active grants = %v", store.active["o-live"])
+	}
+}
+
+func TestGrant_Rejections(t *testing.T) {
+	svc, _, _ := newTestService()
+	ctx := context.Background()
+	cases := []struct {
+		name                       string
+		passenger, orderID, viewer types.ID
+		want                       error
+	}{
+		{"not a friend", "p1", "o-live", "stranger", ErrForbidden},
+		{"not the passenger's order", "p2", "o-live", "mom", ErrForbidden},
+		{"trip over", "p1", "o-done", "mom", ErrBadRequest},
+		{"unknown order", "p1", "missing", "mom", ErrNotFound},
+		{"self grant", "p1", "o-live", "p1", ErrBadRequest},
+	}
+	for _, tc := range cases {
+		if _, err := svc.Grant(ctx, tc.passenger, tc.orderID, tc.viewer); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestGrant_RollsBackWhenRTDBWriteFails(t *testing.T) {
+	svc, store, grants := newTestService()
+	grants.writeErr = errors.New("rtdb down")
+	if _, err := svc.Grant(context.Background(), "p1", "o-live", "mom"); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(store.active["o-live"]) != 0 {
+		t.Errorf("grant left active after RTDB failure: %v", store.active["o-live"])
+	}
+}
+
+func TestGrant_UnavailableWithoutRTDB(t *testing.T) {
+	svc := NewService(newMockStore(), nil, mockOrders{}, nil)
+	if _, err := svc.Grant(context.Background(), "p1", "o-live", "mom"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestRevoke_DeletesRTDBNode(t *testing.T) {
+	svc, _, grants := newTestService()
+	ctx := context.Background()
+	if _, err := svc.Grant(ctx, "p1", "o-live", "mom"); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+	if err := svc.Revoke(ctx, "p1", "o-live", "mom"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if grants.nodes[grantPath("p1", "mom")] {
+		t.Error("RTDB grant node still present after revoke")
+	}
+}
+
+func TestOrderHook_RevokesWhenTripEnds(t *testing.T) {
+	svc, _, grants := newTestService()
+	ctx := context.Background()
+	if _, err := svc.Grant(ctx, "p1", "o-live", "mom"); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+	hook := svc.OrderHook()
+
+	hook(ctx, order.Transition{OrderID: "o-live", From: order.StatusArrived, To: order.StatusDriving})
+	if !grants.nodes[grantPath("p1", "mom")] {
+		t.Fatal("grant revoked mid-trip")
+	}
+	hook(ctx, order.Transition{OrderID: "o-live", From: order.StatusDriving, To: order.StatusPayment})
+	if grants.nodes[grantPath("p1", "mom")] {
+		t.Error("grant not revoked when trip ended")
+	}
+}
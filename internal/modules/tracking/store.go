@@ -0,0 +1,117 @@
+// README: Trip tracking store — PostgreSQL-backed persistence for trip_tracking_grants.
+package tracking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// TrackingStore defines the persistence operations required by the tracking Service.
+type TrackingStore interface {
+	CreateGrant(ctx context.Context, g *Grant) error
+	RevokeGrant(ctx context.Context, orderID, viewerID types.ID) error
+	RevokeAllForOrder(ctx context.Context, orderID types.ID) ([]Grant, error)
+	ListActiveGrants(ctx context.Context, orderID types.ID) ([]Grant, error)
+}
+
+// Store is the PostgreSQL implementation of TrackingStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+// CreateGrant inserts an active grant; a second active grant for the same
+// order and viewer returns ErrConflict.
+func (s *Store) CreateGrant(ctx context.Context, g *Grant) error {
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO trip_tracking_grants (order_id, passenger_id, viewer_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		string(g.OrderID), string(g.PassengerID), string(g.ViewerID), g.CreatedAt,
+	).Scan(&g.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// RevokeGrant marks the active grant for (orderID, viewerID) as revoked.
+func (s *Store) RevokeGrant(ctx context.Context, orderID, viewerID types.ID) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE trip_tracking_grants SET revoked_at = $3
+		WHERE order_id = $1 AND viewer_id = $2 AND revoked_at IS NULL`,
+		string(orderID), string(viewerID), time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAllForOrder revokes every active grant of the order and returns them.
+func (s *Store) RevokeAllForOrder(ctx context.Context, orderID types.ID) ([]Grant, error) {
+	rows, err := s.db.Query(ctx, `
+		UPDATE trip_tracking_grants SET revoked_at = $2
+		WHERE order_id = $1 AND revoked_at IS NULL
+		RETURNING id, order_id, passenger_id, viewer_id, created_at, revoked_at`,
+		string(orderID), time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanGrants(rows)
+}
+
+// ListActiveGrants returns the order's grants that have not been revoked.
+func (s *Store) ListActiveGrants(ctx context.Context, orderID types.ID) ([]Grant, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, order_id, passenger_id, viewer_id, created_at, revoked_at
+		FROM trip_tracking_grants
+		WHERE order_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at`,
+		string(orderID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanGrants(rows)
+}
+
+type grantRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Close()
+	Err() error
+}
+
+func scanGrants(rows grantRows) ([]Grant, error) {
+	defer rows.Close()
+	var out []Grant
+	for rows.Next() {
+		var g Grant
+		var orderID, passengerID, viewerID string
+		if err := rows.Scan(&g.ID, &orderID, &passengerID, &viewerID, &g.CreatedAt, &g.RevokedAt); err != nil {
+			return nil, err
+		}
+		g.OrderID, g.PassengerID, g.ViewerID = types.ID(orderID), types.ID(passengerID), types.ID(viewerID)
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
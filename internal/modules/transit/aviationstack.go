@@ -0,0 +1,88 @@
+// README: AviationStack flight-status provider; trains are not covered.
+package transit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"ark/internal/modules/order"
+)
+
+// AviationStackProvider looks flights up in the AviationStack real-time flights API.
+type AviationStackProvider struct {
+	APIKey  string
+	BaseURL string // defaults to the public API; overridden in tests
+	Client  *http.Client
+}
+
+// NewAviationStackProvider returns a provider using apiKey.
+func NewAviationStackProvider(apiKey string) *AviationStackProvider {
+	return &AviationStackProvider{APIKey: apiKey, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Arrival returns the estimated (or else scheduled) arrival of the flight whose
+// scheduled arrival is nearest to around.
+func (p *AviationStackProvider) Arrival(ctx context.Context, kind, number string, around time.Time) (time.Time, error) {
+	if kind != order.TransitFlight {
+		return time.Time{}, ErrUnsupported
+	}
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.aviationstack.com"
+	}
+	q := url.Values{"access_key": {p.APIKey}, "flight_iata": {number}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/flights?"+q.Encode(), nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("aviationstack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Data []struct {
+			Arrival struct {
+				Scheduled string `json:"scheduled"`
+				Estimated string `json:"estimated"`
+			} `json:"arrival"`
+		} `json:"data"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return time.Time{}, fmt.Errorf("aviationstack: decode response (status %d): %w", resp.StatusCode, err)
+	}
+	if out.Error != nil {
+		return time.Time{}, fmt.Errorf("aviationstack: status %d: %s", resp.StatusCode, out.Error.Message)
+	}
+	if resp.StatusCode >= 300 {
+		return time.Time{}, fmt.Errorf("aviationstack: status %d", resp.StatusCode)
+	}
+
+	var best time.Time
+	var bestGap time.Duration
+	for _, f := range out.Data {
+		scheduled, err := time.Parse(time.RFC3339, f.Arrival.Scheduled)
+		if err != nil {
+			continue
+		}
+		arrival := scheduled
+		if est, err := time.Parse(time.RFC3339, f.Arrival.Estimated); err == nil {
+			arrival = est
+		}
+		if gap := scheduled.Sub(around).Abs(); best.IsZero() || gap < bestGap {
+			best, bestGap = arrival, gap
+		}
+	}
+	if best.IsZero() {
+		return time.Time{}, ErrNotFound
+	}
+	return best, nil
+}
@@ -0,0 +1,32 @@
+// README: Transit status types: the arrival-provider interface and the order operations the watcher needs.
+package transit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+var (
+	// ErrUnsupported is returned by a Provider for transit types it cannot track.
+	ErrUnsupported = errors.New("transit: unsupported transit type")
+	// ErrNotFound is returned when the provider has no record of the flight or train.
+	ErrNotFound = errors.New("transit: not found")
+)
+
+// Provider reports the expected arrival of a flight or train.
+type Provider interface {
+	// Arrival returns the best current arrival estimate for the flight or train
+	// numbered number that is due closest to around.
+	Arrival(ctx context.Context, kind, number string, around time.Time) (time.Time, error)
+}
+
+// Orders is the subset of order.Service the watcher uses.
+type Orders interface {
+	ListTransitPickups(ctx context.Context, from, to time.Time) ([]order.TransitPickup, error)
+	SetTransitETA(ctx context.Context, orderID types.ID, eta time.Time) error
+	AmendSchedule(ctx context.Context, cmd order.AmendScheduleCommand) error
+}
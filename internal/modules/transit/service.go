@@ -0,0 +1,101 @@
+// README: Transit watcher: polls flight/train arrivals for upcoming pickups and shifts the pickup when the arrival moves.
+package transit
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ark/internal/modules/order"
+)
+
+// minShift is the smallest arrival change that moves a pickup. Smaller drift is
+// left to accumulate against the last recorded ETA.
+const minShift = 5 * time.Minute
+
+// Service keeps scheduled transit pickups in step with their arrivals.
+type Service struct {
+	orders    Orders
+	provider  Provider
+	interval  time.Duration
+	lookahead time.Duration
+}
+
+// NewService returns a watcher that checks pickups due within lookahead every interval.
+func NewService(orders Orders, provider Provider, interval, lookahead time.Duration) *Service {
+	return &Service{orders: orders, provider: provider, interval: interval, lookahead: lookahead}
+}
+
+// RunWatcher checks upcoming transit pickups every interval until ctx is done.
+func (s *Service) RunWatcher(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckOnce(ctx, time.Now())
+		}
+	}
+}
+
+// CheckOnce refreshes the arrival of every transit pickup due in [now, now+lookahead].
+// The first estimate for an order is only recorded; later estimates that differ
+// from it by at least minShift move the pickup by the same amount.
+func (s *Service) CheckOnce(ctx context.Context, now time.Time) {
+	pickups, err := s.orders.ListTransitPickups(ctx, now, now.Add(s.lookahead))
+	if err != nil {
+		log.Printf("transit: list pickups: %v", err)
+		return
+	}
+	for _, p := range pickups {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.check(ctx, p, now); err != nil {
+			log.Printf("transit: order %s (%s %s): %v", p.OrderID, p.TransitType, p.TransitNumber, err)
+		}
+	}
+}
+
+func (s *Service) check(ctx context.Context, p order.TransitPickup, now time.Time) error {
+	eta, err := s.provider.Arrival(ctx, p.TransitType, p.TransitNumber, p.ScheduledAt)
+	if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if p.ETA == nil {
+		return s.orders.SetTransitETA(ctx, p.OrderID, eta)
+	}
+	shift := eta.Sub(*p.ETA)
+	if shift.Abs() < minShift {
+		return nil
+	}
+	reason := p.TransitType + "_delayed"
+	if shift < 0 {
+		reason = p.TransitType + "_early"
+	}
+	next := p.ScheduledAt.Add(shift)
+	if next.Before(now) {
+		next = now
+	}
+	err = s.orders.AmendSchedule(ctx, order.AmendScheduleCommand{
+		OrderID:     p.OrderID,
+		ScheduledAt: next,
+		ActorType:   order.ActorSystem,
+		Reason:      reason,
+	})
+	if errors.Is(err, order.ErrInvalidState) || errors.Is(err, order.ErrConflict) {
+		// The order moved on (claimed, cancelled, amended) since it was listed;
+		// the next pass sees its new state.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.orders.SetTransitETA(ctx, p.OrderID, eta)
+}
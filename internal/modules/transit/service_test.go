@@ -0,0 +1,151 @@
+package transit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type fakeOrders struct {
+	pickups []order.TransitPickup
+	etas    map[types.ID]time.Time
+	amends  []order.AmendScheduleCommand
+}
+
+func (f *fakeOrders) ListTransitPickups(_ context.Context, _, _ time.Time) ([]order.TransitPickup, error) {
+	return f.pickups, nil
+}
+
+func (f *fakeOrders) SetTransitETA(_ context.Context, id types.ID, eta time.Time) error {
+	if f.etas == nil {
+		f.etas = make(map[types.ID]time.Time)
+	}
+	f.etas[id] = eta
+	return nil
+}
+
+func (f *fakeOrders) AmendSchedule(_ context.Context, cmd order.AmendScheduleCommand) error {
+	f.amends = append(f.amends, cmd)
+	return nil
+}
+
+type fakeProvider map[string]time.Time
+
+func (f fakeProvider) Arrival(_ context.Context, kind, number string, _ time.Time) (time.Time, error) {
+	if kind != order.TransitFlight {
+		return time.Time{}, ErrUnsupported
+	}
+	eta, ok := f[number]
+	if !ok {
+		return time.Time{}, ErrNotFound
+	}
+	return eta, nil
+}
+
+func TestCheckOnce(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	pickupAt := now.Add(2 * time.Hour)
+	landing := pickupAt.Add(-15 * time.Minute)
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name       string
+		pickup     order.TransitPickup
+		arrival    time.Time
+		wantAmend  *time.Time
+		wantReason string
+		wantETA    *time.Time
+	}{
+		{
+			name:    "first estimate is only recorded",
+			pickup:  order.TransitPickup{OrderID: "o1", TransitType: order.TransitFlight, TransitNumber: "BR12", ScheduledAt: pickupAt},
+			arrival: landing.Add(40 * time.Minute),
+			wantETA: ptr(landing.Add(40 * time.Minute)),
+		},
+		{
+			name:       "delay moves the pickup",
+			pickup:     order.TransitPickup{OrderID: "o1", TransitType: order.TransitFlight, TransitNumber: "BR12", ScheduledAt: pickupAt, ETA: &landing},
+			arrival:    landing.Add(40 * time.Minute),
+			wantAmend:  ptr(pickupAt.Add(40 * time.Minute)),
+			wantReason: "flight_delayed",
+			wantETA:    ptr(landing.Add(40 * time.Minute)),
+		},
+		{
+			name:       "early arrival moves the pickup forward",
+			pickup:     order.TransitPickup{OrderID: "o1", TransitType: order.TransitFlight, TransitNumber: "BR12", ScheduledAt: pickupAt, ETA: &landing},
+			arrival:    landing.Add(-20 * time.Minute),
+			wantAmend:  ptr(pickupAt.Add(-20 * time.Minute)),
+			wantReason: "flight_early",
+			wantETA:    ptr(landing.Add(-20 * time.Minute)),
+		},
+		{
+			name:    "small drift is ignored",
+			pickup:  order.TransitPickup{OrderID: "o1", TransitType: order.TransitFlight, TransitNumber: "BR12", ScheduledAt: pickupAt, ETA: &landing},
+			arrival: landing.Add(3 * time.Minute),
+		},
+		{
+			name:   "unsupported train is skipped",
+			pickup: order.TransitPickup{OrderID: "o1", TransitType: order.TransitTrain, TransitNumber: "123", ScheduledAt: pickupAt, ETA: &landing},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &fakeOrders{pickups: []order.TransitPickup{tt.pickup}}
+			svc := NewService(orders, fakeProvider{"BR12": tt.arrival}, time.Minute, 12*time.Hour)
+			svc.CheckOnce(context.Background(), now)
+
+			if tt.wantAmend == nil {
+				if len(orders.amends) != 0 {
+					t.Fatalf("amends = %+v, want none", orders.amends)
+				}
+			} else {
+				if len(orders.amends) != 1 {
+					t.Fatalf("amends = %+v, want one", orders.amends)
+				}
+				a := orders.amends[0]
+				if !a.ScheduledAt.Equal(*tt.wantAmend) || a.Reason != tt.wantReason || a.ActorType != order.ActorSystem {
+					t.Errorf("amend = %+v, want %v %s by system", a, *tt.wantAmend, tt.wantReason)
+				}
+			}
+			got, ok := orders.etas["o1"]
+			if tt.wantETA == nil {
+				if ok {
+					t.Errorf("eta recorded as %v, want none", got)
+				}
+			} else if !ok || !got.Equal(*tt.wantETA) {
+				t.Errorf("eta = %v (set %v), want %v", got, ok, *tt.wantETA)
+			}
+		})
+	}
+}
+
+func TestAviationStackProvider_Arrival(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("flight_iata") != "BR12" || r.URL.Query().Get("access_key") != "k" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"data":[
+			{"arrival":{"scheduled":"2026-02-28T10:00:00+00:00","estimated":null}},
+			{"arrival":{"scheduled":"2026-03-01T10:00:00+00:00","estimated":"2026-03-01T10:45:00+00:00"}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	p := NewAviationStackProvider("k")
+	p.BaseURL = srv.URL
+	got, err := p.Arrival(context.Background(), order.TransitFlight, "BR12", time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 1, 10, 45, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("arrival = %v, want %v", got, want)
+	}
+	if _, err := p.Arrival(context.Background(), order.TransitTrain, "123", time.Now()); err != ErrUnsupported {
+		t.Errorf("train err = %v, want ErrUnsupported", err)
+	}
+}
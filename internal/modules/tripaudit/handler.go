@@ -0,0 +1,122 @@
+// README: Trip audit HTTP handlers — the staff fraud-review queue for trips whose GPS trace is much longer than the Maps route.
+//
+// Endpoints:
+//
+//	GET  /api/ops/trip-audits                    — audits by status (?status=, default flagged; ?limit=)
+//	POST /api/ops/trip-audits/:order_id/review   — close a flagged trip ({"outcome": "confirmed"|"dismissed", "note": "..."})
+//
+// Auth: all routes require the ops key middleware.
+package tripaudit
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/types"
+)
+
+// Handler holds the trip audit HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type reviewReq struct {
+	Outcome string `json:"outcome"`
+	Note    string `json:"note"`
+}
+
+type auditResp struct {
+	OrderID     types.ID `json:"order_id"`
+	DriverID    types.ID `json:"driver_id"`
+	Status      string   `json:"status"`
+	StartedAt   int64    `json:"started_at"`
+	CompletedAt *int64   `json:"completed_at,omitempty"`
+	TraceKm     *float64 `json:"trace_km,omitempty"`
+	EstimatedKm *float64 `json:"estimated_km,omitempty"`
+	TracePoints *int     `json:"trace_points,omitempty"`
+	Ratio       float64  `json:"ratio,omitempty"`
+	ReviewNote  string   `json:"review_note,omitempty"`
+	ReviewedAt  *int64   `json:"reviewed_at,omitempty"`
+}
+
+func toAuditResp(a *Audit) auditResp {
+	out := auditResp{
+		OrderID:     a.OrderID,
+		DriverID:    a.DriverID,
+		Status:      a.Status,
+		StartedAt:   a.StartedAt.Unix(),
+		TraceKm:     a.TraceKm,
+		EstimatedKm: a.EstimatedKm,
+		TracePoints: a.TracePoints,
+		Ratio:       a.Ratio(),
+		ReviewNote:  a.ReviewNote,
+	}
+	if a.CompletedAt != nil {
+		ts := a.CompletedAt.Unix()
+		out.CompletedAt = &ts
+	}
+	if a.ReviewedAt != nil {
+		ts := a.ReviewedAt.Unix()
+		out.ReviewedAt = &ts
+	}
+	return out
+}
+
+// List handles GET /api/ops/trip-audits.
+func (h *Handler) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	audits, err := h.svc.ListAudits(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		writeAuditError(c, err)
+		return
+	}
+	out := make([]auditResp, len(audits))
+	for i := range audits {
+		out[i] = toAuditResp(&audits[i])
+	}
+	c.JSON(http.StatusOK, map[string]any{"audits": out})
+}
+
+// Review handles POST /api/ops/trip-audits/:order_id/review.
+func (h *Handler) Review(c *gin.Context) {
+	var req reviewReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	a, err := h.svc.Review(c.Request.Context(), ReviewCommand{
+		OrderID: types.ID(c.Param("order_id")),
+		Outcome: req.Outcome,
+		Note:    req.Note,
+	})
+	if err != nil {
+		writeAuditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toAuditResp(a))
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeAuditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidState):
+		writeError(c, http.StatusConflict, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
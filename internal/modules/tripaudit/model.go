@@ -0,0 +1,71 @@
+// README: Trip audit domain model — completed trips' GPS-trace distance against the Maps estimate, and the staff review of padded routes.
+package tripaudit
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Audit statuses. A trip is recording while the passenger is aboard, pending
+// once it ends, and then clear, flagged or unverifiable. Staff close flagged
+// trips as confirmed or dismissed.
+const (
+	StatusRecording    = "recording"
+	StatusPending      = "pending"
+	StatusClear        = "clear"
+	StatusFlagged      = "flagged"
+	StatusUnverifiable = "unverifiable"
+	StatusConfirmed    = "confirmed"
+	StatusDismissed    = "dismissed"
+)
+
+// PaddingRatio is the trace-to-estimate ratio at which a trip is flagged:
+// a GPS trace 30% longer than the Maps route.
+const PaddingRatio = 1.3
+
+// minTracePoints is the fewest samples a trace needs to be measured; fewer
+// means the driver app stopped reporting and the trip is unverifiable.
+const minTracePoints = 5
+
+// Audit is the distance check of one trip.
+type Audit struct {
+	OrderID  types.ID
+	DriverID types.ID
+	Status   string
+	// StartedAt is when the passenger boarded and CompletedAt when the driver
+	// ended the trip; the trace is the driver's positions in between.
+	StartedAt   time.Time
+	CompletedAt *time.Time
+	// TraceKm, EstimatedKm and TracePoints are set once the trip is audited.
+	TraceKm     *float64
+	EstimatedKm *float64
+	TracePoints *int
+	AuditedAt   *time.Time
+	ReviewNote  string
+	ReviewedAt  *time.Time
+}
+
+// Ratio is TraceKm over EstimatedKm, or 0 before the trip is measured.
+func (a *Audit) Ratio() float64 {
+	if a.TraceKm == nil || a.EstimatedKm == nil || *a.EstimatedKm <= 0 {
+		return 0
+	}
+	return *a.TraceKm / *a.EstimatedKm
+}
+
+// Result is the outcome of measuring one trip.
+type Result struct {
+	Status      string
+	TraceKm     float64
+	EstimatedKm float64
+	TracePoints int
+	At          time.Time
+}
+
+var (
+	ErrNotFound     = errors.New("trip audit not found")
+	ErrBadRequest   = errors.New("bad request")
+	ErrInvalidState = errors.New("trip audit is not awaiting review")
+)
@@ -0,0 +1,13 @@
+// README: Trip audit route registration — mounts the fraud-review queue onto the ops router group.
+package tripaudit
+
+import "github.com/gin-gonic/gin"
+
+// RegisterOpsRoutes mounts the trip audit endpoints onto the provided ops router group.
+//
+//	GET  /api/ops/trip-audits
+//	POST /api/ops/trip-audits/:order_id/review
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/trip-audits", h.List)
+	rg.POST("/api/ops/trip-audits/:order_id/review", h.Review)
+}
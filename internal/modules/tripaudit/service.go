@@ -0,0 +1,227 @@
+// README: Trip audit service — records trip windows from order transitions and measures finished trips' GPS traces against Maps.
+package tripaudit
+
+import (
+	"context"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// auditBatch bounds the trips measured per run.
+const auditBatch = 100
+
+// Orders loads the trip's pickup and dropoff.
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// Traces returns a driver's recorded positions (location.Service).
+type Traces interface {
+	DriverTrace(ctx context.Context, driverID types.ID, from, to time.Time) ([]types.Point, error)
+}
+
+// RouteDistance returns the driving distance between two points in km (maps.RouteService).
+type RouteDistance interface {
+	GetRouteDistance(ctx context.Context, origin, destination types.Point) (float64, error)
+}
+
+// Service audits trip distances.
+type Service struct {
+	store    AuditStore
+	orders   Orders
+	traces   Traces
+	routes   RouteDistance
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewService returns a Service that measures pending trips every interval
+// against routes.
+func NewService(store AuditStore, orders Orders, traces Traces, routes RouteDistance, interval time.Duration) *Service {
+	return &Service{store: store, orders: orders, traces: traces, routes: routes, interval: interval, now: time.Now}
+}
+
+// TripHook records when each trip starts and ends. Sandbox orders are not audited.
+func (s *Service) TripHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.Sandbox {
+			return
+		}
+		var err error
+		switch {
+		case t.To == order.StatusDriving && t.DriverID != nil:
+			err = s.store.StartTrip(ctx, t.OrderID, *t.DriverID, t.At)
+		case t.From == order.StatusDriving && t.To == order.StatusPayment:
+			err = s.store.CompleteTrip(ctx, t.OrderID, t.At)
+		case t.From == order.StatusDriving && t.To == order.StatusCancelled:
+			err = s.store.DropTrip(ctx, t.OrderID)
+		default:
+			return
+		}
+		if err != nil {
+			log.Printf("tripaudit: record %s→%s for order %s: %v", t.From, t.To, t.OrderID, err)
+		}
+	}
+}
+
+// RunAuditJob measures pending trips every interval until ctx is done.
+func (s *Service) RunAuditJob(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.AuditOnce(ctx)
+		}
+	}
+}
+
+// AuditOnce measures up to auditBatch pending trips. A trip whose Maps lookup
+// fails stays pending and is retried on the next run.
+func (s *Service) AuditOnce(ctx context.Context) {
+	pending, err := s.store.ListPending(ctx, auditBatch)
+	if err != nil {
+		log.Printf("tripaudit: list pending: %v", err)
+		return
+	}
+	flagged := 0
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		r, err := s.measure(ctx, &pending[i])
+		if err != nil {
+			log.Printf("tripaudit: order %s: %v", pending[i].OrderID, err)
+			continue
+		}
+		if err := s.store.SetResult(ctx, pending[i].OrderID, r); err != nil {
+			log.Printf("tripaudit: save order %s: %v", pending[i].OrderID, err)
+			continue
+		}
+		if r.Status == StatusFlagged {
+			flagged++
+			log.Printf("tripaudit: order %s flagged for review: trace %.2f km vs estimate %.2f km", pending[i].OrderID, r.TraceKm, r.EstimatedKm)
+		}
+	}
+	if len(pending) > 0 {
+		log.Printf("tripaudit: audited %d trips, %d flagged", len(pending), flagged)
+	}
+}
+
+// measure compares the trip's trace with the Maps route from pickup to
+// dropoff. After a mid-trip dropoff change the route runs via the original
+// dropoff, which over- rather than under-estimates the legitimate distance.
+func (s *Service) measure(ctx context.Context, a *Audit) (Result, error) {
+	r := Result{At: s.now()}
+	if a.CompletedAt == nil {
+		r.Status = StatusUnverifiable
+		return r, nil
+	}
+	o, err := s.orders.Get(ctx, a.OrderID)
+	if err != nil {
+		return r, err
+	}
+	// A charter is billed by the hour and goes wherever the passenger asks;
+	// there is no route to hold its trace to.
+	if o.RideType == order.RideTypeCharter {
+		r.Status = StatusUnverifiable
+		return r, nil
+	}
+	trace, err := s.traces.DriverTrace(ctx, a.DriverID, a.StartedAt, *a.CompletedAt)
+	if err != nil {
+		return r, err
+	}
+	r.TracePoints = len(trace)
+	r.TraceKm = pathKm(trace)
+
+	stops := []types.Point{o.Pickup, o.Dropoff}
+	if o.OriginalDropoff != nil {
+		stops = []types.Point{o.Pickup, *o.OriginalDropoff, o.Dropoff}
+	}
+	for i := 1; i < len(stops); i++ {
+		km, err := s.routes.GetRouteDistance(ctx, stops[i-1], stops[i])
+		if err != nil {
+			return r, err
+		}
+		r.EstimatedKm += km
+	}
+
+	switch {
+	case r.TracePoints < minTracePoints || r.EstimatedKm <= 0:
+		r.Status = StatusUnverifiable
+	case r.TraceKm >= r.EstimatedKm*PaddingRatio:
+		r.Status = StatusFlagged
+	default:
+		r.Status = StatusClear
+	}
+	return r, nil
+}
+
+// ListAudits returns up to limit audits in status; an empty status lists the
+// review queue (flagged trips).
+func (s *Service) ListAudits(ctx context.Context, status string, limit int) ([]Audit, error) {
+	if status == "" {
+		status = StatusFlagged
+	}
+	switch status {
+	case StatusRecording, StatusPending, StatusClear, StatusFlagged, StatusUnverifiable, StatusConfirmed, StatusDismissed:
+	default:
+		return nil, ErrBadRequest
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.store.List(ctx, status, limit)
+}
+
+// ReviewCommand closes a flagged trip. Outcome is StatusConfirmed when the
+// route was padded and StatusDismissed when the detour was legitimate.
+type ReviewCommand struct {
+	OrderID types.ID
+	Outcome string
+	Note    string
+}
+
+// Review records staff's decision on a flagged trip.
+func (s *Service) Review(ctx context.Context, cmd ReviewCommand) (*Audit, error) {
+	note := strings.TrimSpace(cmd.Note)
+	if cmd.OrderID == "" || (cmd.Outcome != StatusConfirmed && cmd.Outcome != StatusDismissed) || len(note) > 1000 {
+		return nil, ErrBadRequest
+	}
+	ok, err := s.store.Review(ctx, cmd.OrderID, cmd.Outcome, note, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if _, err := s.store.Get(ctx, cmd.OrderID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidState
+	}
+	return s.store.Get(ctx, cmd.OrderID)
+}
+
+func pathKm(path []types.Point) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += haversineKm(path[i-1], path[i])
+	}
+	return total
+}
+
+func haversineKm(a, b types.Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,215 @@
+package tripaudit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	audits map[types.ID]*Audit
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{audits: make(map[types.ID]*Audit)}
+}
+
+func (m *mockStore) StartTrip(_ context.Context, orderID, driverID types.ID, at time.Time) error {
+	if _, ok := m.audits[orderID]; !ok {
+		m.audits[orderID] = &Audit{OrderID: orderID, DriverID: driverID, Status: StatusRecording, StartedAt: at}
+	}
+	return nil
+}
+
+func (m *mockStore) CompleteTrip(_ context.Context, orderID types.ID, at time.Time) error {
+	if a, ok := m.audits[orderID]; ok && a.Status == StatusRecording {
+		a.Status = StatusPending
+		a.CompletedAt = &at
+	}
+	return nil
+}
+
+func (m *mockStore) DropTrip(_ context.Context, orderID types.ID) error {
+	if a, ok := m.audits[orderID]; ok && a.Status == StatusRecording {
+		delete(m.audits, orderID)
+	}
+	return nil
+}
+
+func (m *mockStore) ListPending(_ context.Context, limit int) ([]Audit, error) {
+	var out []Audit
+	for _, a := range m.audits {
+		if a.Status == StatusPending && len(out) < limit {
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) SetResult(_ context.Context, orderID types.ID, r Result) error {
+	a := m.audits[orderID]
+	a.Status = r.Status
+	a.TraceKm, a.EstimatedKm, a.TracePoints, a.AuditedAt = &r.TraceKm, &r.EstimatedKm, &r.TracePoints, &r.At
+	return nil
+}
+
+func (m *mockStore) Get(_ context.Context, orderID types.ID) (*Audit, error) {
+	a, ok := m.audits[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (m *mockStore) List(_ context.Context, status string, limit int) ([]Audit, error) {
+	var out []Audit
+	for _, a := range m.audits {
+		if a.Status == status && len(out) < limit {
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) Review(_ context.Context, orderID types.ID, status, note string, at time.Time) (bool, error) {
+	a, ok := m.audits[orderID]
+	if !ok || a.Status != StatusFlagged {
+		return false, nil
+	}
+	a.Status, a.ReviewNote, a.ReviewedAt = status, note, &at
+	return true, nil
+}
+
+type fakeOrders map[types.ID]*order.Order
+
+func (f fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	o, ok := f[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	return o, nil
+}
+
+// fakeTraces returns a straight eastward trace of n points spanning km.
+type fakeTraces map[types.ID]struct {
+	n  int
+	km float64
+}
+
+func (f fakeTraces) DriverTrace(_ context.Context, driverID types.ID, _, _ time.Time) ([]types.Point, error) {
+	t := f[driverID]
+	out := make([]types.Point, t.n)
+	for i := range out {
+		// One degree of longitude is ~101 km at 25°N.
+		out[i] = types.Point{Lat: 25, Lng: 121 + t.km/101.0*float64(i)/float64(max(t.n-1, 1))}
+	}
+	return out, nil
+}
+
+type fakeRoutes struct {
+	km  float64
+	err error
+}
+
+func (f fakeRoutes) GetRouteDistance(context.Context, types.Point, types.Point) (float64, error) {
+	return f.km, f.err
+}
+
+func newTestService(traces fakeTraces, routes RouteDistance) (*Service, *mockStore) {
+	store := newMockStore()
+	orders := fakeOrders{}
+	driver := types.ID("d1")
+	for _, id := range []types.ID{"o-padded", "o-fair", "o-sparse"} {
+		orders[id] = &order.Order{ID: id, DriverID: &driver, Pickup: types.Point{Lat: 25, Lng: 121}, Dropoff: types.Point{Lat: 25, Lng: 121.1}}
+	}
+	return NewService(store, orders, traces, routes, time.Minute), store
+}
+
+func runTrip(t *testing.T, svc *Service, orderID, driverID types.ID) {
+	t.Helper()
+	hook := svc.TripHook()
+	start := time.Now().Add(-20 * time.Minute)
+	hook(context.Background(), order.Transition{OrderID: orderID, DriverID: &driverID, From: order.StatusArrived, To: order.StatusDriving, At: start})
+	hook(context.Background(), order.Transition{OrderID: orderID, DriverID: &driverID, From: order.StatusDriving, To: order.StatusPayment, At: start.Add(15 * time.Minute)})
+}
+
+func TestAuditOnce_FlagsPaddedRoutes(t *testing.T) {
+	traces := fakeTraces{
+		"d-padded": {n: 50, km: 14},
+		"d-fair":   {n: 50, km: 11},
+		"d-sparse": {n: 3, km: 30},
+	}
+	svc, store := newTestService(traces, fakeRoutes{km: 10})
+	runTrip(t, svc, "o-padded", "d-padded")
+	runTrip(t, svc, "o-fair", "d-fair")
+	runTrip(t, svc, "o-sparse", "d-sparse")
+
+	svc.AuditOnce(context.Background())
+
+	for id, want := range map[types.ID]string{"o-padded": StatusFlagged, "o-fair": StatusClear, "o-sparse": StatusUnverifiable} {
+		if got := store.audits[id].Status; got != want {
+			t.Errorf("%s status = %s, want %s", id, got, want)
+		}
+	}
+	if r := store.audits["o-padded"].Ratio(); r < 1.35 || r > 1.45 {
+		t.Errorf("o-padded ratio = %.2f, want ~1.4", r)
+	}
+
+	queue, err := svc.ListAudits(context.Background(), "", 0)
+	if err != nil || len(queue) != 1 || queue[0].OrderID != "o-padded" {
+		t.Fatalf("review queue = %+v, %v; want only o-padded", queue, err)
+	}
+}
+
+func TestAuditOnce_MapsFailureLeavesTripPending(t *testing.T) {
+	svc, store := newTestService(fakeTraces{"d-fair": {n: 50, km: 11}}, fakeRoutes{err: errors.New("quota")})
+	runTrip(t, svc, "o-fair", "d-fair")
+
+	svc.AuditOnce(context.Background())
+
+	if got := store.audits["o-fair"].Status; got != StatusPending {
+		t.Errorf("status = %s, want pending for a retry", got)
+	}
+}
+
+func TestTripHook_CancelledTripIsDropped(t *testing.T) {
+	svc, store := newTestService(fakeTraces{}, fakeRoutes{km: 10})
+	driver := types.ID("d1")
+	hook := svc.TripHook()
+	hook(context.Background(), order.Transition{OrderID: "o-fair", DriverID: &driver, To: order.StatusDriving, At: time.Now()})
+	hook(context.Background(), order.Transition{OrderID: "o-fair", From: order.StatusDriving, To: order.StatusCancelled, At: time.Now()})
+	hook(context.Background(), order.Transition{OrderID: "o-padded", DriverID: &driver, To: order.StatusDriving, At: time.Now(), Sandbox: true})
+
+	if len(store.audits) != 0 {
+		t.Errorf("audits = %v, want none for cancelled and sandbox trips", store.audits)
+	}
+}
+
+func TestReview(t *testing.T) {
+	svc, store := newTestService(fakeTraces{"d-padded": {n: 50, km: 14}}, fakeRoutes{km: 10})
+	runTrip(t, svc, "o-padded", "d-padded")
+	ctx := context.Background()
+
+	if _, err := svc.Review(ctx, ReviewCommand{OrderID: "o-padded", Outcome: StatusConfirmed}); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("review before audit: err = %v, want ErrInvalidState", err)
+	}
+	svc.AuditOnce(ctx)
+	if _, err := svc.Review(ctx, ReviewCommand{OrderID: "o-padded", Outcome: StatusFlagged}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("bad outcome: err = %v, want ErrBadRequest", err)
+	}
+	a, err := svc.Review(ctx, ReviewCommand{OrderID: "o-padded", Outcome: StatusDismissed, Note: " road closure "})
+	if err != nil {
+		t.Fatalf("Review: %v", err)
+	}
+	if a.Status != StatusDismissed || a.ReviewNote != "road closure" || store.audits["o-padded"].ReviewedAt == nil {
+		t.Errorf("reviewed audit = %+v", a)
+	}
+	if _, err := svc.Review(ctx, ReviewCommand{OrderID: "o-missing", Outcome: StatusConfirmed}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown order: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,152 @@
+// README: Trip audit store — PostgreSQL persistence for trip_distance_audits.
+package tripaudit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// AuditStore defines the persistence operations required by the trip audit Service.
+type AuditStore interface {
+	// StartTrip opens a recording audit; a repeat for the same order is ignored.
+	StartTrip(ctx context.Context, orderID, driverID types.ID, at time.Time) error
+	// CompleteTrip moves a recording audit to pending.
+	CompleteTrip(ctx context.Context, orderID types.ID, at time.Time) error
+	// DropTrip deletes a recording audit, e.g. when the trip is cancelled.
+	DropTrip(ctx context.Context, orderID types.ID) error
+	// ListPending returns up to limit pending audits, oldest trip first.
+	ListPending(ctx context.Context, limit int) ([]Audit, error)
+	// SetResult records the measurement of a pending audit.
+	SetResult(ctx context.Context, orderID types.ID, r Result) error
+	Get(ctx context.Context, orderID types.ID) (*Audit, error)
+	// List returns audits in status, most recently completed first.
+	List(ctx context.Context, status string, limit int) ([]Audit, error)
+	// Review closes a flagged audit with status; false when it is not flagged.
+	Review(ctx context.Context, orderID types.ID, status, note string, at time.Time) (bool, error)
+}
+
+// Store is the PostgreSQL implementation of AuditStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) StartTrip(ctx context.Context, orderID, driverID types.ID, at time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO trip_distance_audits (order_id, driver_id, status, started_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (order_id) DO NOTHING`,
+		string(orderID), string(driverID), StatusRecording, at,
+	)
+	return err
+}
+
+func (s *Store) CompleteTrip(ctx context.Context, orderID types.ID, at time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE trip_distance_audits SET status = $2, completed_at = $3
+		WHERE order_id = $1 AND status = $4`,
+		string(orderID), StatusPending, at, StatusRecording,
+	)
+	return err
+}
+
+func (s *Store) DropTrip(ctx context.Context, orderID types.ID) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM trip_distance_audits WHERE order_id = $1 AND status = $2`,
+		string(orderID), StatusRecording)
+	return err
+}
+
+const auditColumns = `order_id, driver_id, status, started_at, completed_at, trace_km, estimated_km, trace_points, audited_at, review_note, reviewed_at`
+
+func scanAudit(row pgx.Row) (*Audit, error) {
+	var a Audit
+	var orderID, driverID string
+	var note *string
+	if err := row.Scan(&orderID, &driverID, &a.Status, &a.StartedAt, &a.CompletedAt, &a.TraceKm, &a.EstimatedKm,
+		&a.TracePoints, &a.AuditedAt, &note, &a.ReviewedAt); err != nil {
+		return nil, err
+	}
+	a.OrderID = types.ID(orderID)
+	a.DriverID = types.ID(driverID)
+	if note != nil {
+		a.ReviewNote = *note
+	}
+	return &a, nil
+}
+
+func (s *Store) queryAudits(ctx context.Context, sql string, args ...any) ([]Audit, error) {
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Audit
+	for rows.Next() {
+		a, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListPending(ctx context.Context, limit int) ([]Audit, error) {
+	return s.queryAudits(ctx, `
+		SELECT `+auditColumns+` FROM trip_distance_audits
+		WHERE status = $1
+		ORDER BY completed_at
+		LIMIT $2`,
+		StatusPending, limit,
+	)
+}
+
+func (s *Store) SetResult(ctx context.Context, orderID types.ID, r Result) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE trip_distance_audits
+		SET status = $2, trace_km = $3, estimated_km = $4, trace_points = $5, audited_at = $6
+		WHERE order_id = $1 AND status = $7`,
+		string(orderID), r.Status, r.TraceKm, r.EstimatedKm, r.TracePoints, r.At, StatusPending,
+	)
+	return err
+}
+
+func (s *Store) Get(ctx context.Context, orderID types.ID) (*Audit, error) {
+	a, err := scanAudit(s.db.QueryRow(ctx, `SELECT `+auditColumns+` FROM trip_distance_audits WHERE order_id = $1`, string(orderID)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+func (s *Store) List(ctx context.Context, status string, limit int) ([]Audit, error) {
+	return s.queryAudits(ctx, `
+		SELECT `+auditColumns+` FROM trip_distance_audits
+		WHERE status = $1
+		ORDER BY completed_at DESC NULLS LAST
+		LIMIT $2`,
+		status, limit,
+	)
+}
+
+func (s *Store) Review(ctx context.Context, orderID types.ID, status, note string, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE trip_distance_audits SET status = $2, review_note = $3, reviewed_at = $4
+		WHERE order_id = $1 AND status = $5`,
+		string(orderID), status, note, at, StatusFlagged,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,179 @@
+// README: Trip route HTTP handlers — the route of an accepted order for map rendering.
+//
+// Endpoints:
+//
+//	GET /api/orders/:id/route       — encoded polyline, distance, duration and turn-by-turn steps
+//	GET /api/orders/:id/navigation  — the driver's navigation handoff: stops, route and Google Maps links
+//
+// Auth: the authenticated passenger or driver of the order; navigation is
+// for the driver only.
+package triproute
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// Handler holds the trip route HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type pointResp struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type stepResp struct {
+	Instruction     string    `json:"instruction"`
+	DistanceMeters  int       `json:"distance_meters"`
+	DurationSeconds int64     `json:"duration_seconds"`
+	Start           pointResp `json:"start"`
+	End             pointResp `json:"end"`
+}
+
+type closureResp struct {
+	ID     types.ID `json:"closure_id"`
+	Name   string   `json:"name"`
+	EndsAt int64    `json:"ends_at"`
+}
+
+func toClosureResps(cs []ClosureNotice) []closureResp {
+	if len(cs) == 0 {
+		return nil
+	}
+	out := make([]closureResp, len(cs))
+	for i, c := range cs {
+		out[i] = closureResp{ID: c.ID, Name: c.Name, EndsAt: c.EndsAt.Unix()}
+	}
+	return out
+}
+
+type routeResp struct {
+	OrderID         types.ID   `json:"order_id"`
+	Origin          pointResp  `json:"origin"`
+	Destination     pointResp  `json:"destination"`
+	Polyline        string     `json:"polyline"`
+	DistanceMeters  int        `json:"distance_meters"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	Steps           []stepResp `json:"steps"`
+	// Closures flags road closures the route runs through.
+	Closures  []closureResp `json:"closures,omitempty"`
+	CreatedAt int64         `json:"created_at"`
+}
+
+func toPointResp(p types.Point) pointResp {
+	return pointResp{Lat: p.Lat, Lng: p.Lng}
+}
+
+func toRouteResp(r *Route) routeResp {
+	out := routeResp{
+		OrderID:         r.OrderID,
+		Origin:          toPointResp(r.Origin),
+		Destination:     toPointResp(r.Destination),
+		Polyline:        r.Polyline,
+		DistanceMeters:  r.DistanceMeters,
+		DurationSeconds: int64(r.Duration.Seconds()),
+		Steps:           make([]stepResp, len(r.Steps)),
+		Closures:        toClosureResps(r.Closures),
+		CreatedAt:       r.CreatedAt.Unix(),
+	}
+	for i, st := range r.Steps {
+		out.Steps[i] = stepResp{
+			Instruction:     st.Instruction,
+			DistanceMeters:  st.DistanceMeters,
+			DurationSeconds: int64(st.Duration.Seconds()),
+			Start:           toPointResp(st.Start),
+			End:             toPointResp(st.End),
+		}
+	}
+	return out
+}
+
+type navLinksResp struct {
+	Web     string `json:"web"`
+	Android string `json:"android"`
+	IOS     string `json:"ios"`
+}
+
+// NavigationResp is the JSON form of a Navigation, also returned when a
+// driver accepts or claims a ride.
+type NavigationResp struct {
+	OrderID         types.ID      `json:"order_id"`
+	Pickup          pointResp     `json:"pickup"`
+	Dropoff         pointResp     `json:"dropoff"`
+	Polyline        string        `json:"polyline"`
+	DistanceMeters  int           `json:"distance_meters"`
+	DurationSeconds int64         `json:"duration_seconds"`
+	Closures        []closureResp `json:"closures,omitempty"`
+	ToPickup        navLinksResp  `json:"to_pickup"`
+	ToDropoff       navLinksResp  `json:"to_dropoff"`
+}
+
+// ToNavigationResp converts n to its JSON form.
+func ToNavigationResp(n *Navigation) NavigationResp {
+	return NavigationResp{
+		OrderID:         n.OrderID,
+		Pickup:          toPointResp(n.Pickup),
+		Dropoff:         toPointResp(n.Dropoff),
+		Polyline:        n.Polyline,
+		DistanceMeters:  n.DistanceMeters,
+		DurationSeconds: int64(n.Duration.Seconds()),
+		Closures:        toClosureResps(n.Closures),
+		ToPickup:        navLinksResp(n.ToPickup),
+		ToDropoff:       navLinksResp(n.ToDropoff),
+	}
+}
+
+// Get handles GET /api/orders/:id/route.
+func (h *Handler) Get(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	r, err := h.svc.Get(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")))
+	if err != nil {
+		writeRouteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toRouteResp(r))
+}
+
+// Navigation handles GET /api/orders/:id/navigation.
+func (h *Handler) Navigation(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	n, err := h.svc.Navigation(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")))
+	if err != nil {
+		writeRouteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ToNavigationResp(n))
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeRouteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,68 @@
+// README: Trip route domain model — the driving route chosen for an order when the driver accepts it, for drawing on the map, and the navigation handoff given to its driver.
+package triproute
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ark/internal/maps"
+	"ark/internal/types"
+)
+
+var ErrNotFound = errors.New("no route for this order")
+
+// Route is the driving route from an order's pickup to its dropoff, looked
+// up when the driver accepted the ride and again when the dropoff changed.
+type Route struct {
+	OrderID     types.ID
+	Origin      types.Point
+	Destination types.Point
+	// Polyline is the route overview in Google's encoded polyline format.
+	Polyline       string
+	DistanceMeters int
+	Duration       time.Duration
+	Steps          []Step
+	// Closures are the road closures the route still runs through when no
+	// detour around them was found.
+	Closures  []ClosureNotice
+	CreatedAt time.Time
+}
+
+// ClosureNotice flags a road closure on a trip's route.
+type ClosureNotice struct {
+	ID     types.ID
+	Name   string
+	EndsAt time.Time
+}
+
+// Step is one turn-by-turn instruction of a Route.
+type Step struct {
+	Instruction    string
+	DistanceMeters int
+	Duration       time.Duration
+	Start          types.Point
+	End            types.Point
+}
+
+// Navigation is what the driver app needs to hand a trip over to Google Maps
+// without Directions calls of its own.
+type Navigation struct {
+	OrderID types.ID
+	Pickup  types.Point
+	Dropoff types.Point
+	// Polyline, DistanceMeters and Duration describe the route from pickup
+	// to dropoff; they are empty when no route could be looked up.
+	Polyline       string
+	DistanceMeters int
+	Duration       time.Duration
+	// Closures are the road closures on the route.
+	Closures []ClosureNotice
+	// ToPickup and ToDropoff start navigation from the driver's position.
+	ToPickup  maps.NavLinks
+	ToDropoff maps.NavLinks
+}
+
+// NavigationHook is called with the navigation of a driver's order whenever
+// it is handed to them: on accept, on claim and after a dropoff change.
+type NavigationHook func(ctx context.Context, driverID types.ID, n *Navigation)
@@ -0,0 +1,13 @@
+// README: Trip route registration — mounts the order route and navigation endpoints onto the given router group.
+package triproute
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the trip route endpoints onto the provided authenticated router group.
+//
+//	GET /api/orders/:id/route
+//	GET /api/orders/:id/navigation
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/orders/:id/route", h.Get)
+	rg.GET("/api/orders/:id/navigation", h.Navigation)
+}
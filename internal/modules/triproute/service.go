@@ -0,0 +1,270 @@
+// README: Trip route service — looks up the driving route when a driver accepts an order or its dropoff changes, serves it to the trip's passenger and driver, and hands the driver a navigation payload.
+package triproute
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"ark/internal/maps"
+	"ark/internal/modules/closure"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+// lookupTimeout bounds one route lookup from an order hook.
+const lookupTimeout = 15 * time.Second
+
+// Orders is the subset of order.Service the service uses.
+type Orders interface {
+	Get(ctx context.Context, id types.ID) (*order.Order, error)
+}
+
+// Directions returns the driving route between two points (maps.RouteService).
+type Directions interface {
+	GetPointRoute(ctx context.Context, origin, destination types.Point) (*maps.Route, error)
+	// GetPointRouteVia passes through each of via on the way.
+	GetPointRouteVia(ctx context.Context, origin, destination types.Point, via []types.Point) (*maps.Route, error)
+}
+
+// Closures reports the road closures a path runs through. Implemented by
+// closure.Service.
+type Closures interface {
+	Crossing(path []types.Point, at time.Time) []closure.Closure
+}
+
+// Service keeps the route of every accepted order.
+type Service struct {
+	store      RouteStore
+	orders     Orders
+	directions Directions
+	closures   Closures
+	now        func() time.Time
+
+	navHooks []NavigationHook
+}
+
+// NewService returns a Service looking routes up with directions.
+func NewService(store RouteStore, orders Orders, directions Directions) *Service {
+	return &Service{store: store, orders: orders, directions: directions, now: time.Now}
+}
+
+// SetClosures routes trips around road closures in force: a route through
+// one is looked up again via waypoints outside it, and flagged when that
+// does not clear it. Without it closures are ignored.
+func (s *Service) SetClosures(c Closures) {
+	s.closures = c
+}
+
+// OnNavigation registers h to receive the navigation handed to drivers.
+// Must be called before the service starts handling requests.
+func (s *Service) OnNavigation(h NavigationHook) {
+	s.navHooks = append(s.navHooks, h)
+}
+
+// OrderHook records the route in the background once a driver is on the way,
+// whether accepting an instant ride or departing for a scheduled one, and
+// hands the driver their navigation when they accept or claim a ride.
+func (s *Service) OrderHook() order.TransitionHook {
+	return func(ctx context.Context, t order.Transition) {
+		if t.Sandbox || t.DriverID == nil {
+			return
+		}
+		switch {
+		case t.To == order.StatusApproaching:
+			// Departing for a claimed ride was already handed over on claim.
+			s.recordAsync(ctx, t.OrderID, t.DriverID, t.From != order.StatusAssigned)
+		case t.To == order.StatusAssigned:
+			s.recordAsync(ctx, t.OrderID, t.DriverID, true)
+		}
+	}
+}
+
+// DropoffHook re-records the route when the driver accepts a new dropoff and
+// hands them the new navigation.
+func (s *Service) DropoffHook() order.DropoffHook {
+	return func(ctx context.Context, c order.DropoffChange) {
+		if c.Stage != order.DropoffAccepted || c.Sandbox || c.DriverID == nil {
+			return
+		}
+		s.recordAsync(ctx, c.OrderID, c.DriverID, true)
+	}
+}
+
+// recordAsync records the order's route unless it is only claimed, then
+// hands the driver their navigation if handOver is set.
+func (s *Service) recordAsync(ctx context.Context, orderID types.ID, driverID *types.ID, handOver bool) {
+	driver := *driverID
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lookupTimeout)
+		defer cancel()
+		o, err := s.orders.Get(ctx, orderID)
+		if err != nil {
+			log.Printf("triproute: order %s: %v", orderID, err)
+			return
+		}
+		if o.Status != order.StatusAssigned {
+			if err := s.Record(ctx, orderID); err != nil {
+				log.Printf("triproute: order %s: %v", orderID, err)
+			}
+		}
+		if !handOver || len(s.navHooks) == 0 {
+			return
+		}
+		n, err := s.Navigation(ctx, driver, orderID)
+		if err != nil {
+			log.Printf("triproute: navigation for order %s: %v", orderID, err)
+			return
+		}
+		for _, h := range s.navHooks {
+			h(ctx, driver, n)
+		}
+	}()
+}
+
+// Record looks up the driving route from the order's pickup to its current
+// dropoff and stores it.
+func (s *Service) Record(ctx context.Context, orderID types.ID) error {
+	o, err := s.orders.Get(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("get order: %w", err)
+	}
+	mr, err := s.directions.GetPointRoute(ctx, o.Pickup, o.Dropoff)
+	if err != nil {
+		return fmt.Errorf("directions: %w", err)
+	}
+	mr, closures := s.avoidClosures(ctx, o.Pickup, o.Dropoff, mr)
+	r := &Route{
+		OrderID:        o.ID,
+		Origin:         o.Pickup,
+		Destination:    o.Dropoff,
+		Polyline:       mr.Polyline,
+		DistanceMeters: mr.DistanceMeters,
+		Duration:       mr.Duration,
+		Steps:          make([]Step, len(mr.Steps)),
+		Closures:       closures,
+		CreatedAt:      s.now(),
+	}
+	for i, st := range mr.Steps {
+		r.Steps[i] = Step{
+			Instruction:    st.Instruction,
+			DistanceMeters: st.DistanceMeters,
+			Duration:       st.Duration,
+			Start:          st.Start,
+			End:            st.End,
+		}
+	}
+	return s.store.Save(ctx, r)
+}
+
+// Navigation returns the navigation of the order to its driver; other callers
+// get ErrNotFound. The route is the recorded one when it still ends at the
+// dropoff, otherwise it is looked up; without one only the links are set.
+func (s *Service) Navigation(ctx context.Context, driverID, orderID types.ID) (*Navigation, error) {
+	o, err := s.orders.Get(ctx, orderID)
+	if errors.Is(err, order.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.DriverID == nil || *o.DriverID != driverID {
+		return nil, ErrNotFound
+	}
+	n := &Navigation{
+		OrderID:   o.ID,
+		Pickup:    o.Pickup,
+		Dropoff:   o.Dropoff,
+		ToPickup:  maps.NavigationLinks(o.Pickup),
+		ToDropoff: maps.NavigationLinks(o.Dropoff),
+	}
+	r, err := s.store.Get(ctx, o.ID)
+	switch {
+	case err == nil && r.Origin == o.Pickup && r.Destination == o.Dropoff:
+		n.Polyline, n.DistanceMeters, n.Duration, n.Closures = r.Polyline, r.DistanceMeters, r.Duration, r.Closures
+	case err == nil || errors.Is(err, ErrNotFound):
+		mr, err := s.directions.GetPointRoute(ctx, o.Pickup, o.Dropoff)
+		if err != nil {
+			log.Printf("triproute: navigation route for order %s: %v", o.ID, err)
+			break
+		}
+		mr, n.Closures = s.avoidClosures(ctx, o.Pickup, o.Dropoff, mr)
+		n.Polyline, n.DistanceMeters, n.Duration = mr.Polyline, mr.DistanceMeters, mr.Duration
+	default:
+		return nil, err
+	}
+	return n, nil
+}
+
+// Get returns the order's route to its passenger or driver. Other callers get
+// ErrNotFound, as for an order without a route.
+func (s *Service) Get(ctx context.Context, userID, orderID types.ID) (*Route, error) {
+	o, err := s.orders.Get(ctx, orderID)
+	if errors.Is(err, order.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.PassengerID != userID && (o.DriverID == nil || *o.DriverID != userID) {
+		return nil, ErrNotFound
+	}
+	return s.store.Get(ctx, orderID)
+}
+
+// avoidClosures returns mr, or when it runs through closures in force a
+// route via waypoints outside each of them if that runs through fewer, with
+// the closures the returned route still runs through.
+func (s *Service) avoidClosures(ctx context.Context, origin, destination types.Point, mr *maps.Route) (*maps.Route, []ClosureNotice) {
+	if s.closures == nil {
+		return mr, nil
+	}
+	now := s.now()
+	crossed := s.crossing(mr, now)
+	if len(crossed) == 0 {
+		return mr, nil
+	}
+	// Pass the closures in the order the trip reaches them.
+	slices.SortFunc(crossed, func(a, b closure.Closure) int {
+		return cmp.Compare(distanceKm(origin, a.Area[0]), distanceKm(origin, b.Area[0]))
+	})
+	var via []types.Point
+	for _, c := range crossed {
+		via = append(via, closure.Detour(c, origin, destination)...)
+	}
+	detour, err := s.directions.GetPointRouteVia(ctx, origin, destination, via)
+	if err != nil {
+		log.Printf("triproute: detour around %d closures: %v", len(crossed), err)
+	} else if still := s.crossing(detour, now); len(still) < len(crossed) {
+		mr, crossed = detour, still
+	}
+	notices := make([]ClosureNotice, len(crossed))
+	for i, c := range crossed {
+		notices[i] = ClosureNotice{ID: c.ID, Name: c.Name, EndsAt: c.EndsAt}
+	}
+	return mr, notices
+}
+
+// crossing returns the closures in force at at that mr runs through.
+func (s *Service) crossing(mr *maps.Route, at time.Time) []closure.Closure {
+	path, err := maps.DecodePath(mr.Polyline)
+	if err != nil {
+		log.Printf("triproute: decode polyline: %v", err)
+		return nil
+	}
+	return s.closures.Crossing(path, at)
+}
+
+// distanceKm is the great-circle distance between a and b.
+func distanceKm(a, b types.Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat, dLng := lat2-lat1, (b.Lng-a.Lng)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
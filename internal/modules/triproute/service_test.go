@@ -0,0 +1,264 @@
+package triproute
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gmaps "googlemaps.github.io/maps"
+
+	"ark/internal/maps"
+	"ark/internal/modules/closure"
+	"ark/internal/modules/order"
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	routes map[types.ID]*Route
+}
+
+func (m *mockStore) Get(_ context.Context, orderID types.ID) (*Route, error) {
+	r, ok := m.routes[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+func (m *mockStore) Save(_ context.Context, r *Route) error {
+	m.routes[r.OrderID] = r
+	return nil
+}
+
+type fakeOrders map[types.ID]*order.Order
+
+func (f fakeOrders) Get(_ context.Context, id types.ID) (*order.Order, error) {
+	o, ok := f[id]
+	if !ok {
+		return nil, order.ErrNotFound
+	}
+	return o, nil
+}
+
+type fakeDirections struct {
+	calls []types.Point
+	via   [][]types.Point
+}
+
+// encodePath is the route overview of a straight drive through path.
+func encodePath(path ...types.Point) string {
+	ll := make([]gmaps.LatLng, len(path))
+	for i, p := range path {
+		ll[i] = gmaps.LatLng{Lat: p.Lat, Lng: p.Lng}
+	}
+	return gmaps.Encode(ll)
+}
+
+func (f *fakeDirections) GetPointRouteVia(_ context.Context, origin, destination types.Point, via []types.Point) (*maps.Route, error) {
+	f.via = append(f.via, via)
+	path := append(append([]types.Point{origin}, via...), destination)
+	return &maps.Route{Polyline: encodePath(path...), DistanceMeters: 6100, Duration: 17 * time.Minute}, nil
+}
+
+func (f *fakeDirections) GetPointRoute(_ context.Context, origin, destination types.Point) (*maps.Route, error) {
+	f.calls = append(f.calls, origin, destination)
+	return &maps.Route{
+		Polyline:       encodePath(origin, destination),
+		DistanceMeters: 5200,
+		Duration:       14 * time.Minute,
+		Steps: []maps.Step{
+			{Instruction: "往北走中山北路", DistanceMeters: 3000, Duration: 8 * time.Minute, Start: origin},
+			{Instruction: "向右轉，朝民生東路前進", DistanceMeters: 2200, Duration: 6 * time.Minute, End: destination},
+		},
+	}, nil
+}
+
+func newTestService() (*Service, *mockStore, fakeOrders, *fakeDirections) {
+	driver := types.ID("d1")
+	orders := fakeOrders{"o1": {
+		ID:          "o1",
+		PassengerID: "p1",
+		DriverID:    &driver,
+		Pickup:      types.Point{Lat: 25.0478, Lng: 121.5170},
+		Dropoff:     types.Point{Lat: 25.0330, Lng: 121.5654},
+	}}
+	store := &mockStore{routes: make(map[types.ID]*Route)}
+	dirs := &fakeDirections{}
+	return NewService(store, orders, dirs), store, orders, dirs
+}
+
+func TestRecord_StoresPickupToDropoff(t *testing.T) {
+	svc, store, orders, dirs := newTestService()
+	ctx := context.Background()
+	if err := svc.Record(ctx, "o1"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	r := store.routes["o1"]
+	if r == nil || r.Polyline == "" || r.DistanceMeters != 5200 || len(r.Steps) != 2 {
+		t.Fatalf("route = %+v", r)
+	}
+	if dirs.calls[0] != orders["o1"].Pickup || dirs.calls[1] != orders["o1"].Dropoff {
+		t.Errorf("looked up %v, want pickup to dropoff", dirs.calls)
+	}
+
+	// A changed dropoff replaces the route.
+	orders["o1"].Dropoff = types.Point{Lat: 25.0418, Lng: 121.5436}
+	if err := svc.Record(ctx, "o1"); err != nil {
+		t.Fatalf("record again: %v", err)
+	}
+	if got := store.routes["o1"].Destination; got != orders["o1"].Dropoff {
+		t.Errorf("destination = %v, want the new dropoff", got)
+	}
+}
+
+func TestGet_OnlyParticipants(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	ctx := context.Background()
+	if _, err := svc.Get(ctx, "p1", "o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("before accept: err = %v, want ErrNotFound", err)
+	}
+	if err := svc.Record(ctx, "o1"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	for _, uid := range []types.ID{"p1", "d1"} {
+		if _, err := svc.Get(ctx, uid, "o1"); err != nil {
+			t.Errorf("%s: %v", uid, err)
+		}
+	}
+	if _, err := svc.Get(ctx, "p2", "o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("stranger: err = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.Get(ctx, "p1", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown order: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestNavigation_DriverOnly(t *testing.T) {
+	svc, _, orders, dirs := newTestService()
+	ctx := context.Background()
+
+	n, err := svc.Navigation(ctx, "d1", "o1")
+	if err != nil {
+		t.Fatalf("navigation: %v", err)
+	}
+	if n.Pickup != orders["o1"].Pickup || n.Polyline == "" || n.DistanceMeters != 5200 || len(dirs.calls) != 2 {
+		t.Fatalf("navigation = %+v after %d lookups", n, len(dirs.calls)/2)
+	}
+	if want := maps.NavigationLinks(orders["o1"].Dropoff); n.ToDropoff != want || n.ToPickup.Android != "google.navigation:q=25.047800,121.517000&mode=d" {
+		t.Errorf("links = %+v / %+v", n.ToPickup, n.ToDropoff)
+	}
+
+	// The recorded route is reused while it still ends at the dropoff.
+	if err := svc.Record(ctx, "o1"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	calls := len(dirs.calls)
+	if _, err := svc.Navigation(ctx, "d1", "o1"); err != nil || len(dirs.calls) != calls {
+		t.Errorf("with a recorded route: err = %v, %d new lookups", err, (len(dirs.calls)-calls)/2)
+	}
+	orders["o1"].Dropoff = types.Point{Lat: 25.0418, Lng: 121.5436}
+	if n, err := svc.Navigation(ctx, "d1", "o1"); err != nil || len(dirs.calls) != calls+2 || n.Dropoff != orders["o1"].Dropoff {
+		t.Errorf("after a dropoff change: %+v, %v", n, err)
+	}
+
+	for _, uid := range []types.ID{"p1", "d2"} {
+		if _, err := svc.Navigation(ctx, uid, "o1"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: err = %v, want ErrNotFound", uid, err)
+		}
+	}
+}
+
+func TestOrderHook_HandsOverNavigationOnClaim(t *testing.T) {
+	svc, store, orders, _ := newTestService()
+	orders["o1"].Status = order.StatusAssigned
+	got := make(chan types.ID, 1)
+	svc.OnNavigation(func(_ context.Context, driverID types.ID, n *Navigation) {
+		got <- driverID
+	})
+	driver := types.ID("d1")
+
+	svc.OrderHook()(context.Background(), order.Transition{OrderID: "o1", DriverID: &driver, From: order.StatusScheduled, To: order.StatusAssigned})
+	select {
+	case d := <-got:
+		if d != driver {
+			t.Errorf("handed to %s, want %s", d, driver)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no navigation handed over on claim")
+	}
+	if len(store.routes) != 0 {
+		t.Errorf("claim recorded a route: %+v", store.routes)
+	}
+}
+
+type closureStore struct {
+	closures []closure.Closure
+}
+
+func (m *closureStore) Create(_ context.Context, c *closure.Closure) error {
+	m.closures = append(m.closures, *c)
+	return nil
+}
+
+func (m *closureStore) ListCurrent(context.Context, time.Time) ([]closure.Closure, error) {
+	return m.closures, nil
+}
+
+func (m *closureStore) End(context.Context, types.ID, time.Time) error {
+	return nil
+}
+
+// square is a closure area of side 2*half degrees centred on p.
+func square(p types.Point, half float64) []types.Point {
+	return []types.Point{
+		{Lat: p.Lat - half, Lng: p.Lng - half}, {Lat: p.Lat + half, Lng: p.Lng - half},
+		{Lat: p.Lat + half, Lng: p.Lng + half}, {Lat: p.Lat - half, Lng: p.Lng + half},
+	}
+}
+
+func TestRecord_DetoursAroundClosures(t *testing.T) {
+	svc, store, orders, dirs := newTestService()
+	closures := closure.NewService(&closureStore{})
+	svc.SetClosures(closures)
+	ctx := context.Background()
+	now := time.Now()
+	o := orders["o1"]
+
+	// Roadworks halfway along the direct route: the trip goes round them.
+	mid := types.Point{Lat: (o.Pickup.Lat + o.Dropoff.Lat) / 2, Lng: (o.Pickup.Lng + o.Dropoff.Lng) / 2}
+	if _, err := closures.Create(ctx, closure.Closure{
+		Kind: closure.KindRoadworks, Name: "忠孝東路施工", Area: square(mid, 0.002),
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := svc.Record(ctx, "o1"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	r := store.routes["o1"]
+	if len(dirs.via) != 1 || len(dirs.via[0]) == 0 || r.DistanceMeters != 6100 || len(r.Closures) != 0 {
+		t.Fatalf("route = %+v via %v; want the detour, unflagged", r, dirs.via)
+	}
+
+	// An event around the dropoff cannot be driven round: the route is
+	// flagged.
+	event, err := closures.Create(ctx, closure.Closure{
+		Kind: closure.KindEvent, Name: "跨年晚會", Area: square(o.Dropoff, 0.003),
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	if err := svc.Record(ctx, "o1"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	r = store.routes["o1"]
+	if len(r.Closures) != 1 || r.Closures[0].ID != event.ID || r.Closures[0].Name != "跨年晚會" {
+		t.Errorf("closures = %+v, want the event flagged", r.Closures)
+	}
+	n, err := svc.Navigation(ctx, "d1", "o1")
+	if err != nil || len(n.Closures) != 1 {
+		t.Errorf("navigation = %+v, %v; want the event flagged", n, err)
+	}
+}
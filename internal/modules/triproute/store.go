@@ -0,0 +1,136 @@
+// README: Trip route store — PostgreSQL persistence for order_routes.
+package triproute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// RouteStore defines the persistence operations required by the Service.
+// An order has at most one route; a newer one replaces it.
+type RouteStore interface {
+	// Get returns the order's route or ErrNotFound.
+	Get(ctx context.Context, orderID types.ID) (*Route, error)
+	// Save stores r, replacing any earlier route of the order.
+	Save(ctx context.Context, r *Route) error
+}
+
+// Store is the PostgreSQL implementation of RouteStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+// storedStep is a Step as kept in the steps JSONB column.
+type storedStep struct {
+	Instruction string     `json:"instruction"`
+	Meters      int        `json:"meters"`
+	Secs        int64      `json:"secs"`
+	Start       [2]float64 `json:"start"`
+	End         [2]float64 `json:"end"`
+}
+
+// storedClosure is a ClosureNotice as kept in the closures JSONB column.
+type storedClosure struct {
+	ID     types.ID  `json:"id"`
+	Name   string    `json:"name"`
+	EndsAt time.Time `json:"ends_at"`
+}
+
+func (s *Store) Get(ctx context.Context, orderID types.ID) (*Route, error) {
+	var r Route
+	var durationSecs int64
+	var steps, closures []byte
+	err := s.db.QueryRow(ctx, `
+		SELECT order_id, origin_lat, origin_lng, dest_lat, dest_lng, polyline,
+		       distance_m, duration_secs, steps, closures, created_at
+		FROM order_routes
+		WHERE order_id = $1`, string(orderID),
+	).Scan(&r.OrderID, &r.Origin.Lat, &r.Origin.Lng, &r.Destination.Lat, &r.Destination.Lng, &r.Polyline,
+		&r.DistanceMeters, &durationSecs, &steps, &closures, &r.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.Duration = time.Duration(durationSecs) * time.Second
+
+	var stored []storedStep
+	if err := json.Unmarshal(steps, &stored); err != nil {
+		return nil, err
+	}
+	r.Steps = make([]Step, len(stored))
+	for i, st := range stored {
+		r.Steps[i] = Step{
+			Instruction:    st.Instruction,
+			DistanceMeters: st.Meters,
+			Duration:       time.Duration(st.Secs) * time.Second,
+			Start:          types.Point{Lat: st.Start[0], Lng: st.Start[1]},
+			End:            types.Point{Lat: st.End[0], Lng: st.End[1]},
+		}
+	}
+	var storedClosures []storedClosure
+	if err := json.Unmarshal(closures, &storedClosures); err != nil {
+		return nil, err
+	}
+	for _, c := range storedClosures {
+		r.Closures = append(r.Closures, ClosureNotice(c))
+	}
+	return &r, nil
+}
+
+func (s *Store) Save(ctx context.Context, r *Route) error {
+	stored := make([]storedStep, len(r.Steps))
+	for i, st := range r.Steps {
+		stored[i] = storedStep{
+			Instruction: st.Instruction,
+			Meters:      st.DistanceMeters,
+			Secs:        int64(st.Duration / time.Second),
+			Start:       [2]float64{st.Start.Lat, st.Start.Lng},
+			End:         [2]float64{st.End.Lat, st.End.Lng},
+		}
+	}
+	steps, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	storedClosures := make([]storedClosure, len(r.Closures))
+	for i, c := range r.Closures {
+		storedClosures[i] = storedClosure(c)
+	}
+	closures, err := json.Marshal(storedClosures)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO order_routes
+		    (order_id, origin_lat, origin_lng, dest_lat, dest_lng, polyline, distance_m, duration_secs, steps, closures, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (order_id) DO UPDATE SET
+		    origin_lat    = EXCLUDED.origin_lat,
+		    origin_lng    = EXCLUDED.origin_lng,
+		    dest_lat      = EXCLUDED.dest_lat,
+		    dest_lng      = EXCLUDED.dest_lng,
+		    polyline      = EXCLUDED.polyline,
+		    distance_m    = EXCLUDED.distance_m,
+		    duration_secs = EXCLUDED.duration_secs,
+		    steps         = EXCLUDED.steps,
+		    closures      = EXCLUDED.closures,
+		    created_at    = EXCLUDED.created_at`,
+		string(r.OrderID), r.Origin.Lat, r.Origin.Lng, r.Destination.Lat, r.Destination.Lng, r.Polyline,
+		r.DistanceMeters, int64(r.Duration/time.Second), steps, closures, r.CreatedAt,
+	)
+	return err
+}
@@ -0,0 +1,159 @@
+// README: Account deletion workflow — request with grace period, session revocation, and delayed PII purge.
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ark/internal/types"
+)
+
+// Audit actions recorded by the deletion workflow.
+const (
+	AuditDeletionRequested = "account.deletion_requested"
+	AuditPurged            = "account.purged"
+)
+
+var (
+	// ErrActiveOrders is returned when the user still has an order in progress or scheduled.
+	ErrActiveOrders = errors.New("user: account has active orders")
+	// ErrDeletionUnavailable is returned when the deletion workflow is not configured.
+	ErrDeletionUnavailable = errors.New("user: account deletion not configured")
+)
+
+// DeletionRequest tracks one account deletion through its grace period.
+type DeletionRequest struct {
+	UserID      types.ID
+	RequestedAt time.Time
+	PurgeAfter  time.Time
+	PurgedAt    *time.Time
+}
+
+// DeletionStore persists deletion requests and performs the PII purge.
+type DeletionStore interface {
+	// HasActiveOrders reports whether the user has a non-terminal order as passenger or driver.
+	HasActiveOrders(ctx context.Context, userID types.ID) (bool, error)
+	// RequestDeletion records the request, drops device tokens and writes the
+	// audit entry. An existing request is returned unchanged.
+	RequestDeletion(ctx context.Context, userID types.ID, requestedAt, purgeAfter time.Time) (*DeletionRequest, error)
+	// DueDeletions returns unpurged requests whose grace period ended before now.
+	DueDeletions(ctx context.Context, now time.Time, limit int) ([]DeletionRequest, error)
+	// Purge anonymizes the user's PII across modules and writes the audit entry.
+	Purge(ctx context.Context, userID types.ID, now time.Time) error
+}
+
+// IdentityProvider manages the user's login identity (Firebase Auth).
+type IdentityProvider interface {
+	// RevokeSessions invalidates refresh tokens and blocks new sign-ins.
+	RevokeSessions(ctx context.Context, uid string) error
+	// DeleteIdentity removes the login identity; a missing identity is not an error.
+	DeleteIdentity(ctx context.Context, uid string) error
+}
+
+// DeletionHook lets other modules drop in-memory or cached user data (sessions,
+// live presence) as soon as deletion is requested.
+type DeletionHook func(ctx context.Context, userID types.ID)
+
+// purgeBatch bounds how many accounts one purge pass handles.
+const purgeBatch = 50
+
+// ConfigureDeletion enables the deletion workflow. identity may be nil in dev
+// mode, in which case login sessions are left untouched.
+func (s *Service) ConfigureDeletion(store DeletionStore, identity IdentityProvider, grace time.Duration) {
+	s.deletion = store
+	s.identity = identity
+	s.deletionGrace = grace
+}
+
+// OnDeletionRequested registers h to run after a deletion request is recorded.
+func (s *Service) OnDeletionRequested(h DeletionHook) {
+	s.deletionHooks = append(s.deletionHooks, h)
+}
+
+// RequestDeletion starts the deletion of the user's account. It fails with
+// ErrActiveOrders while any order is in progress or scheduled. Repeating the
+// request is safe and returns the original schedule.
+func (s *Service) RequestDeletion(ctx context.Context, id types.ID) (*DeletionRequest, error) {
+	if id == "" {
+		return nil, ErrBadRequest
+	}
+	if s.deletion == nil {
+		return nil, ErrDeletionUnavailable
+	}
+	active, err := s.deletion.HasActiveOrders(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, ErrActiveOrders
+	}
+
+	now := s.now()
+	req, err := s.deletion.RequestDeletion(ctx, id, now, now.Add(s.deletionGrace))
+	if err != nil {
+		return nil, err
+	}
+	if s.identity != nil {
+		if err := s.identity.RevokeSessions(ctx, string(id)); err != nil {
+			return nil, fmt.Errorf("revoke sessions: %w", err)
+		}
+	}
+	for _, h := range s.deletionHooks {
+		h(ctx, id)
+	}
+	return req, nil
+}
+
+// PurgeDue anonymizes every account whose grace period has ended and returns
+// how many were purged.
+func (s *Service) PurgeDue(ctx context.Context) (int, error) {
+	if s.deletion == nil {
+		return 0, ErrDeletionUnavailable
+	}
+	now := s.now()
+	due, err := s.deletion.DueDeletions(ctx, now, purgeBatch)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	var errs []error
+	for _, d := range due {
+		if err := s.deletion.Purge(ctx, d.UserID, now); err != nil {
+			errs = append(errs, fmt.Errorf("purge %s: %w", d.UserID, err))
+			continue
+		}
+		purged++
+		if s.identity != nil {
+			if err := s.identity.DeleteIdentity(ctx, string(d.UserID)); err != nil {
+				errs = append(errs, fmt.Errorf("delete identity %s: %w", d.UserID, err))
+			}
+		}
+	}
+	return purged, errors.Join(errs...)
+}
+
+// RunPurgeJob periodically purges accounts whose grace period has ended.
+func (s *Service) RunPurgeJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	log.Printf("user: account purge job started (interval=%s)", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("user: account purge job stopped")
+			return
+		case <-ticker.C:
+			n, err := s.PurgeDue(ctx)
+			if err != nil {
+				log.Printf("user: account purge: %v", err)
+			}
+			if n > 0 {
+				log.Printf("user: purged %d accounts", n)
+			}
+		}
+	}
+}
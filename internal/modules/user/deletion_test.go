@@ -0,0 +1,144 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ark/internal/types"
+)
+
+type fakeDeletionStore struct {
+	active   bool
+	requests map[types.ID]*DeletionRequest
+	purged   []types.ID
+}
+
+func newFakeDeletionStore() *fakeDeletionStore {
+	return &fakeDeletionStore{requests: map[types.ID]*DeletionRequest{}}
+}
+
+func (f *fakeDeletionStore) HasActiveOrders(context.Context, types.ID) (bool, error) {
+	return f.active, nil
+}
+
+func (f *fakeDeletionStore) RequestDeletion(_ context.Context, id types.ID, at, purgeAfter time.Time) (*DeletionRequest, error) {
+	if r, ok := f.requests[id]; ok {
+		return r, nil
+	}
+	r := &DeletionRequest{UserID: id, RequestedAt: at, PurgeAfter: purgeAfter}
+	f.requests[id] = r
+	return r, nil
+}
+
+func (f *fakeDeletionStore) DueDeletions(_ context.Context, now time.Time, _ int) ([]DeletionRequest, error) {
+	var out []DeletionRequest
+	for _, r := range f.requests {
+		if r.PurgedAt == nil && !r.PurgeAfter.After(now) {
+			out = append(out, *r)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDeletionStore) Purge(_ context.Context, id types.ID, now time.Time) error {
+	f.requests[id].PurgedAt = &now
+	f.purged = append(f.purged, id)
+	return nil
+}
+
+type fakeIdentity struct {
+	revoked, deleted []string
+}
+
+func (f *fakeIdentity) RevokeSessions(_ context.Context, uid string) error {
+	f.revoked = append(f.revoked, uid)
+	return nil
+}
+
+func (f *fakeIdentity) DeleteIdentity(_ context.Context, uid string) error {
+	f.deleted = append(f.deleted, uid)
+	return nil
+}
+
+func newDeletionService(store DeletionStore, identity IdentityProvider, now *time.Time) *Service {
+	svc := NewService(nil)
+	svc.ConfigureDeletion(store, identity, 30*24*time.Hour)
+	svc.now = func() time.Time { return *now }
+	return svc
+}
+
+func TestRequestDeletion_BlockedByActiveOrders(t *testing.T) {
+	store := newFakeDeletionStore()
+	store.active = true
+	now := time.Now()
+	identity := &fakeIdentity{}
+	svc := newDeletionService(store, identity, &now)
+
+	if _, err := svc.RequestDeletion(context.Background(), "u1"); !errors.Is(err, ErrActiveOrders) {
+		t.Fatalf("err = %v, want ErrActiveOrders", err)
+	}
+	if len(store.requests) != 0 || len(identity.revoked) != 0 {
+		t.Fatal("blocked request must not record deletion or revoke sessions")
+	}
+}
+
+func TestRequestDeletion_RevokesAndRunsHooks(t *testing.T) {
+	store := newFakeDeletionStore()
+	identity := &fakeIdentity{}
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	svc := newDeletionService(store, identity, &now)
+	var hooked []types.ID
+	svc.OnDeletionRequested(func(_ context.Context, id types.ID) { hooked = append(hooked, id) })
+
+	req, err := svc.RequestDeletion(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("RequestDeletion: %v", err)
+	}
+	if want := now.Add(30 * 24 * time.Hour); !req.PurgeAfter.Equal(want) {
+		t.Errorf("PurgeAfter = %s, want %s", req.PurgeAfter, want)
+	}
+	if len(identity.revoked) != 1 || len(hooked) != 1 {
+		t.Fatalf("revoked=%v hooked=%v", identity.revoked, hooked)
+	}
+
+	// Repeating the request keeps the original schedule.
+	now = now.Add(time.Hour)
+	again, err := svc.RequestDeletion(context.Background(), "u1")
+	if err != nil || !again.PurgeAfter.Equal(req.PurgeAfter) {
+		t.Fatalf("repeat = %+v, %v", again, err)
+	}
+}
+
+func TestPurgeDue_WaitsForGracePeriod(t *testing.T) {
+	store := newFakeDeletionStore()
+	identity := &fakeIdentity{}
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	svc := newDeletionService(store, identity, &now)
+	ctx := context.Background()
+
+	if _, err := svc.RequestDeletion(ctx, "u1"); err != nil {
+		t.Fatalf("RequestDeletion: %v", err)
+	}
+	if n, err := svc.PurgeDue(ctx); err != nil || n != 0 {
+		t.Fatalf("PurgeDue during grace = %d, %v", n, err)
+	}
+
+	now = now.Add(31 * 24 * time.Hour)
+	if n, err := svc.PurgeDue(ctx); err != nil || n != 1 {
+		t.Fatalf("PurgeDue after grace = %d, %v", n, err)
+	}
+	if len(store.purged) != 1 || len(identity.deleted) != 1 {
+		t.Fatalf("purged=%v deleted=%v", store.purged, identity.deleted)
+	}
+	if n, _ := svc.PurgeDue(ctx); n != 0 {
+		t.Fatalf("second PurgeDue purged %d", n)
+	}
+}
+
+func TestRequestDeletion_NotConfigured(t *testing.T) {
+	if _, err := NewService(nil).RequestDeletion(context.Background(), "u1"); !errors.Is(err, ErrDeletionUnavailable) {
+		t.Fatalf("err = %v, want ErrDeletionUnavailable", err)
+	}
+}
@@ -0,0 +1,65 @@
+// README: Preferred language — what the ride assistant replies in and what notifications default to.
+package user
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	"ark/internal/types"
+)
+
+// Preferred languages; they match the languages the ride assistant detects.
+const (
+	LanguageUnknown = ""
+	LanguageZhTW    = "zh-TW"
+	LanguageEn      = "en"
+	LanguageJa      = "ja"
+)
+
+// Languages lists the accepted preferred languages.
+var Languages = []string{LanguageZhTW, LanguageEn, LanguageJa}
+
+// UpdateLanguage sets the preferred language of the user with the given id.
+func (s *Store) UpdateLanguage(ctx context.Context, id types.ID, lang string) error {
+	tag, err := s.db.Exec(ctx, `UPDATE users SET preferred_language = $1 WHERE user_id = $2`, lang, string(id))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Language returns the preferred language of the user with the given id.
+func (s *Store) Language(ctx context.Context, id types.ID) (string, error) {
+	var lang string
+	err := s.db.QueryRow(ctx, `SELECT preferred_language FROM users WHERE user_id = $1`, string(id)).Scan(&lang)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return lang, err
+}
+
+// SetLanguage sets a user's preferred language, whether they chose it or the
+// ride assistant detected it.
+func (s *Service) SetLanguage(ctx context.Context, id types.ID, lang string) error {
+	if id == "" || !slices.Contains(Languages, lang) {
+		return ErrBadRequest
+	}
+	return s.store.UpdateLanguage(ctx, id, lang)
+}
+
+// Language returns a user's preferred language, LanguageUnknown for users
+// without one or without a profile. Called by the ride assistant and the
+// Notification module.
+func (s *Service) Language(ctx context.Context, id types.ID) (string, error) {
+	lang, err := s.store.Language(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return LanguageUnknown, nil
+	}
+	return lang, err
+}
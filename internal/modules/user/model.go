@@ -17,10 +17,12 @@ const (
 
 // User represents a natural person in the system.
 type User struct {
-	UserID    types.ID
-	Name      string
-	Email     string
-	Phone     string
-	UserType  UserType
+	UserID   types.ID
+	Name     string
+	Email    string
+	Phone    string
+	UserType UserType
+	// Language is the preferred language, LanguageUnknown until known.
+	Language  string
 	CreatedAt time.Time
 }
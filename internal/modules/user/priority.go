@@ -0,0 +1,64 @@
+// README: Passenger priority — VIP and accessibility riders whose orders dispatch boosts.
+package user
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	"ark/internal/types"
+)
+
+// Priority classes. Orders snapshot their passenger's class when booked; the
+// region decides how much the class boosts dispatch.
+const (
+	PriorityNone          = ""
+	PriorityVIP           = "vip"
+	PriorityAccessibility = "accessibility"
+)
+
+// Priorities lists the classes ops may assign.
+var Priorities = []string{PriorityNone, PriorityVIP, PriorityAccessibility}
+
+// UpdatePriority sets the priority class of the user with the given id.
+func (s *Store) UpdatePriority(ctx context.Context, id types.ID, priority string) error {
+	tag, err := s.db.Exec(ctx, `UPDATE users SET priority = $1 WHERE user_id = $2`, priority, string(id))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Priority returns the priority class of the user with the given id.
+func (s *Store) Priority(ctx context.Context, id types.ID) (string, error) {
+	var p string
+	err := s.db.QueryRow(ctx, `SELECT priority FROM users WHERE user_id = $1`, string(id)).Scan(&p)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return p, err
+}
+
+// SetPriority sets a passenger's priority class. Called from the ops API, so
+// the user is named explicitly rather than taken from the context.
+func (s *Service) SetPriority(ctx context.Context, id types.ID, priority string) error {
+	if id == "" || !slices.Contains(Priorities, priority) {
+		return ErrBadRequest
+	}
+	return s.store.UpdatePriority(ctx, id, priority)
+}
+
+// Priority returns a passenger's priority class; unknown users are normal
+// passengers. Called by the Order module when an order is booked.
+func (s *Service) Priority(ctx context.Context, id types.ID) (string, error) {
+	p, err := s.store.Priority(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return PriorityNone, nil
+	}
+	return p, err
+}
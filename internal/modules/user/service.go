@@ -3,8 +3,6 @@ package user
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"errors"
 	"time"
 
@@ -19,11 +17,17 @@ var (
 // Service orchestrates user creation and management.
 type Service struct {
 	store *Store
+
+	deletion      DeletionStore
+	identity      IdentityProvider
+	deletionGrace time.Duration
+	deletionHooks []DeletionHook
+	now           func() time.Time
 }
 
 // NewService creates a Service backed by the given Store.
 func NewService(store *Store) *Service {
-	return &Service{store: store}
+	return &Service{store: store, now: time.Now}
 }
 
 // CreateCommand holds the fields required to create a new user.
@@ -43,7 +47,7 @@ func (s *Service) Create(ctx context.Context, cmd CreateCommand) (*User, error)
 		return nil, ErrBadRequest
 	}
 	u := &User{
-		UserID:    newID(),
+		UserID:    types.NewID(),
 		Name:      cmd.Name,
 		Email:     cmd.Email,
 		Phone:     cmd.Phone,
@@ -79,9 +83,3 @@ func (s *Service) Delete(ctx context.Context, id types.ID) error {
 	}
 	return s.store.Delete(ctx, id)
 }
-
-func newID() types.ID {
-	var b [16]byte
-	_, _ = rand.Read(b[:])
-	return types.ID(hex.EncodeToString(b[:]))
-}
@@ -3,17 +3,22 @@ package user
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"ark/internal/pii"
 	"ark/internal/types"
 )
 
 // Store handles persistence for the users table.
 type Store struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	pii *pii.Keyring
 }
 
 // NewStore creates a Store backed by the given connection pool.
@@ -21,12 +26,21 @@ func NewStore(db *pgxpool.Pool) *Store {
 	return &Store{db: db}
 }
 
+// SetKeyring enables encryption of the phone column; nil keeps plaintext.
+func (s *Store) SetKeyring(k *pii.Keyring) {
+	s.pii = k
+}
+
 // Create inserts a new user; UserID must be set by the caller.
 func (s *Store) Create(ctx context.Context, u *User) error {
-	_, err := s.db.Exec(ctx, `
-        INSERT INTO users (user_id, name, email, phone, user_type, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6)`,
-		string(u.UserID), u.Name, u.Email, u.Phone, string(u.UserType), u.CreatedAt,
+	phone, err := s.pii.Encrypt(u.Phone)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, `
+        INSERT INTO users (user_id, name, email, phone, phone_hash, user_type, created_at)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)`,
+		string(u.UserID), u.Name, u.Email, phone, s.pii.BlindIndex(u.Phone), string(u.UserType), u.CreatedAt,
 	)
 	return err
 }
@@ -34,18 +48,21 @@ func (s *Store) Create(ctx context.Context, u *User) error {
 // GetByID retrieves a user by their user_id.
 func (s *Store) GetByID(ctx context.Context, id types.ID) (*User, error) {
 	row := s.db.QueryRow(ctx, `
-        SELECT user_id, name, email, phone, user_type, created_at
+        SELECT user_id, name, email, phone, user_type, preferred_language, created_at
         FROM users
         WHERE user_id = $1`, string(id),
 	)
 	var u User
-	err := row.Scan(&u.UserID, &u.Name, &u.Email, &u.Phone, &u.UserType, &u.CreatedAt)
+	err := row.Scan(&u.UserID, &u.Name, &u.Email, &u.Phone, &u.UserType, &u.Language, &u.CreatedAt)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
+	if u.Phone, err = s.pii.Decrypt(u.Phone); err != nil {
+		return nil, err
+	}
 	return &u, nil
 }
 
@@ -75,3 +92,216 @@ func (s *Store) Delete(ctx context.Context, id types.ID) error {
 	}
 	return nil
 }
+
+// ---------------------------------------------------------------------------
+// Account deletion
+// ---------------------------------------------------------------------------
+
+// auditActorSystem is the actor recorded for background jobs.
+const auditActorSystem = "system"
+
+// HasActiveOrders reports whether the user has a non-terminal order as passenger or driver.
+func (s *Store) HasActiveOrders(ctx context.Context, id types.ID) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM orders
+			WHERE (passenger_id = $1 OR driver_id = $1)
+			  AND status NOT IN ('complete', 'cancelled', 'denied', 'expired')
+		)`, string(id),
+	).Scan(&exists)
+	return exists, err
+}
+
+// RequestDeletion records the request, removes push device tokens and writes the
+// audit entry in one transaction. An existing request is returned unchanged.
+func (s *Store) RequestDeletion(ctx context.Context, id types.ID, requestedAt, purgeAfter time.Time) (*DeletionRequest, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO account_deletions (user_id, requested_at, purge_after)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING`,
+		string(id), requestedAt, purgeAfter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 1 {
+		if _, err := tx.Exec(ctx, `DELETE FROM user_fcm_tokens WHERE user_id = $1`, string(id)); err != nil {
+			return nil, err
+		}
+		detail := map[string]any{"purge_after": purgeAfter.UTC().Format(time.RFC3339)}
+		if err := appendAudit(ctx, tx, string(id), AuditDeletionRequested, id, detail); err != nil {
+			return nil, err
+		}
+	}
+
+	req := DeletionRequest{UserID: id}
+	err = tx.QueryRow(ctx, `
+		SELECT requested_at, purge_after, purged_at FROM account_deletions WHERE user_id = $1`,
+		string(id),
+	).Scan(&req.RequestedAt, &req.PurgeAfter, &req.PurgedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// DueDeletions returns unpurged requests whose grace period ended before now.
+func (s *Store) DueDeletions(ctx context.Context, now time.Time, limit int) ([]DeletionRequest, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT user_id, requested_at, purge_after
+		FROM account_deletions
+		WHERE purged_at IS NULL AND purge_after <= $1
+		ORDER BY purge_after
+		LIMIT $2`, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DeletionRequest
+	for rows.Next() {
+		var d DeletionRequest
+		var uid string
+		if err := rows.Scan(&uid, &d.RequestedAt, &d.PurgeAfter); err != nil {
+			return nil, err
+		}
+		d.UserID = types.ID(uid)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// purgeStatements anonymize or delete the user's PII. Orders and ledgers keep
+// their rows for accounting but lose locations and free text.
+var purgeStatements = []string{
+	`UPDATE users SET name = 'Deleted user', email = 'deleted+' || user_id || '@invalid', phone = '', phone_hash = NULL WHERE user_id = $1`,
+	`UPDATE orders SET pickup_lat = NULL, pickup_lng = NULL, dropoff_lat = NULL, dropoff_lng = NULL, cancellation_reason = NULL, notes = '', transit_number = '',
+		car_plate = NULL, car_model = NULL, car_color = NULL, car_transmission = NULL,
+		original_dropoff_lat = NULL, original_dropoff_lng = NULL, pending_dropoff_lat = NULL, pending_dropoff_lng = NULL WHERE passenger_id = $1`,
+	`UPDATE orders SET recipient_name = NULL, recipient_phone = NULL, delivery_signed_by = NULL WHERE passenger_id = $1`,
+	`UPDATE drivers SET license_number = '' WHERE driver_id = $1`,
+	`DELETE FROM location_snapshots WHERE user_id = $1`,
+	`DELETE FROM user_fcm_tokens WHERE user_id = $1`,
+	`DELETE FROM devices WHERE user_id = $1`,
+	`DELETE FROM friendships WHERE user_id = $1 OR friend_id = $1`,
+	`DELETE FROM trip_tracking_grants WHERE passenger_id = $1 OR viewer_id = $1`,
+	`DELETE FROM calendar_events e WHERE e.id IN (SELECT event_id FROM calendar_schedules WHERE uid = $1)
+		AND NOT EXISTS (SELECT 1 FROM calendar_schedules o WHERE o.event_id = e.id AND o.uid <> $1)`,
+	`DELETE FROM calendar_schedules WHERE uid = $1`,
+	`DELETE FROM ai_usage WHERE uid = $1`,
+	`UPDATE ai_usage_events SET user_id = '' WHERE user_id = $1`,
+	`DELETE FROM ai_messages WHERE conversation_id IN (SELECT id FROM ai_conversations WHERE user_id = $1)`,
+	`UPDATE ai_conversations SET user_id = NULL, summary = '', deleted_at = COALESCE(deleted_at, NOW()) WHERE user_id = $1`,
+	`DELETE FROM notification_preferences WHERE user_id = $1`,
+	`DELETE FROM user_places WHERE user_id = $1`,
+	`DELETE FROM order_routes WHERE order_id IN (SELECT id FROM orders WHERE passenger_id = $1)`,
+	`DELETE FROM order_stops WHERE order_id IN (SELECT id FROM orders WHERE passenger_id = $1)`,
+	// Photos are queued for the attachment cleanup to delete from storage.
+	`WITH gone AS (DELETE FROM order_attachments WHERE order_id IN (SELECT id FROM orders WHERE passenger_id = $1) RETURNING object)
+		INSERT INTO attachment_purges (object, purged_at) SELECT object, NOW() FROM gone ON CONFLICT (object) DO NOTHING`,
+}
+
+// Purge anonymizes the user's PII across modules, marks the request purged and
+// writes the audit entry in one transaction.
+func (s *Store) Purge(ctx context.Context, id types.ID, now time.Time) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, stmt := range purgeStatements {
+		if _, err := tx.Exec(ctx, stmt, string(id)); err != nil {
+			return err
+		}
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE account_deletions SET purged_at = $2 WHERE user_id = $1 AND purged_at IS NULL`,
+		string(id), now,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if err := appendAudit(ctx, tx, auditActorSystem, AuditPurged, id, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func appendAudit(ctx context.Context, tx pgx.Tx, actor, action string, subject types.ID, detail map[string]any) error {
+	if detail == nil {
+		detail = map[string]any{}
+	}
+	b, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_log (actor_id, action, subject_id, detail) VALUES ($1, $2, $3, $4)`,
+		actor, action, string(subject), b,
+	)
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// PII key rotation
+// ---------------------------------------------------------------------------
+
+// RotatePhones re-encrypts up to limit phone numbers that are still plaintext,
+// sealed with a retired key, or missing their blind index. It returns how many
+// rows were rewritten; callers loop until it returns 0.
+func (s *Store) RotatePhones(ctx context.Context, limit int) (int, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT user_id, phone, COALESCE(phone_hash, '') FROM users
+		WHERE phone <> '' ORDER BY user_id`)
+	if err != nil {
+		return 0, err
+	}
+	type pending struct{ id, phone string }
+	var todo []pending
+	for rows.Next() && len(todo) < limit {
+		var id, stored, hash string
+		if err := rows.Scan(&id, &stored, &hash); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		plain, err := s.pii.Decrypt(stored)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("decrypt phone of %s: %w", id, err)
+		}
+		if s.pii.NeedsRotation(stored) || hash != s.pii.BlindIndex(plain) {
+			todo = append(todo, pending{id, plain})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range todo {
+		sealed, err := s.pii.Encrypt(p.phone)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := s.db.Exec(ctx, `UPDATE users SET phone = $2, phone_hash = $3 WHERE user_id = $1`,
+			p.id, sealed, s.pii.BlindIndex(p.phone)); err != nil {
+			return 0, err
+		}
+	}
+	return len(todo), nil
+}
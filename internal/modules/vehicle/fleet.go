@@ -0,0 +1,192 @@
+// README: Fleet mode — vehicles a fleet owner shares between drivers in shifts, who may go online with each, and per-vehicle utilization.
+package vehicle
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"ark/internal/types"
+)
+
+const (
+	// maxShift caps one shift; longer stretches are booked as several.
+	maxShift = 24 * time.Hour
+	// maxShiftsAhead is how far ahead shifts may be booked.
+	maxShiftsAhead = 60 * 24 * time.Hour
+	// maxReportWindow caps the utilization report window.
+	maxReportWindow = 92 * 24 * time.Hour
+)
+
+var (
+	ErrVehicleNotFound = errors.New("fleet vehicle not found")
+	ErrShiftNotFound   = errors.New("shift not found or already ended")
+	ErrForbidden       = errors.New("forbidden")
+	// ErrShiftConflict is returned for a shift overlapping another of the
+	// vehicle or of the driver.
+	ErrShiftConflict = errors.New("shift overlaps another shift of the vehicle or driver")
+	// ErrPlateTaken is returned when registering a plate already in a fleet.
+	ErrPlateTaken = errors.New("plate already registered")
+	// ErrNoFleetStore is returned by fleet operations when no FleetStore is
+	// configured.
+	ErrNoFleetStore = errors.New("fleet mode not configured")
+)
+
+var plateRe = regexp.MustCompile(`^[A-Z0-9-]{2,16}$`)
+
+// FleetVehicle is a vehicle owned by a fleet operator and driven in shifts.
+type FleetVehicle struct {
+	ID        types.ID
+	OwnerID   types.ID
+	Plate     string
+	CreatedAt time.Time
+}
+
+// Assignment is a shift: DriverID has the vehicle in [StartsAt, EndsAt).
+type Assignment struct {
+	ID        types.ID
+	VehicleID types.ID
+	DriverID  types.ID
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedAt time.Time
+}
+
+// Utilization is how much a vehicle was used in a report window.
+type Utilization struct {
+	VehicleID types.ID
+	Plate     string
+	// Assigned is the shift time within the window, over Drivers drivers.
+	Assigned time.Duration
+	Drivers  int
+	// Trips are the completed trips started on a shift of the vehicle, which
+	// took TripTime from start to completion.
+	Trips    int
+	TripTime time.Duration
+}
+
+// SetFleetStore enables fleet mode. Without it fleet operations return
+// ErrNoFleetStore and every driver may go online with any vehicle.
+func (s *Service) SetFleetStore(fs FleetStore) {
+	s.fleet = fs
+}
+
+// RegisterVehicle adds a vehicle with plate to ownerID's fleet.
+func (s *Service) RegisterVehicle(ctx context.Context, ownerID types.ID, plate string) (*FleetVehicle, error) {
+	if s.fleet == nil {
+		return nil, ErrNoFleetStore
+	}
+	plate = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(plate), " ", ""))
+	if ownerID == "" || !plateRe.MatchString(plate) {
+		return nil, ErrBadRequest
+	}
+	v := &FleetVehicle{ID: types.NewID(), OwnerID: ownerID, Plate: plate, CreatedAt: s.now()}
+	if err := s.fleet.CreateVehicle(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Vehicles returns ownerID's fleet by plate.
+func (s *Service) Vehicles(ctx context.Context, ownerID types.ID) ([]FleetVehicle, error) {
+	if s.fleet == nil {
+		return nil, ErrNoFleetStore
+	}
+	return s.fleet.ListVehicles(ctx, ownerID)
+}
+
+// ownedVehicle returns vehicleID if ownerID owns it.
+func (s *Service) ownedVehicle(ctx context.Context, ownerID, vehicleID types.ID) (*FleetVehicle, error) {
+	if s.fleet == nil {
+		return nil, ErrNoFleetStore
+	}
+	v, err := s.fleet.GetVehicle(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if v.OwnerID != ownerID {
+		return nil, ErrForbidden
+	}
+	return v, nil
+}
+
+// Shifts returns the shifts of ownerID's vehicle that have not ended, by
+// start.
+func (s *Service) Shifts(ctx context.Context, ownerID, vehicleID types.ID) ([]Assignment, error) {
+	if _, err := s.ownedVehicle(ctx, ownerID, vehicleID); err != nil {
+		return nil, err
+	}
+	return s.fleet.VehicleAssignments(ctx, vehicleID, s.now())
+}
+
+// DriverShifts returns driverID's shifts that have not ended, by start, so the
+// driver knows which vehicle to go online with.
+func (s *Service) DriverShifts(ctx context.Context, driverID types.ID) ([]Assignment, error) {
+	if s.fleet == nil {
+		return nil, ErrNoFleetStore
+	}
+	return s.fleet.DriverAssignments(ctx, driverID, s.now())
+}
+
+// AssignShift gives a driver ownerID's vehicle in [startsAt, endsAt). Shifts
+// end in the future, last at most maxShift, start within maxShiftsAhead, and
+// overlap no other shift of the vehicle or the driver; handing a vehicle over
+// is booking the next driver's shift to start when the current one ends.
+func (s *Service) AssignShift(ctx context.Context, ownerID, vehicleID, driverID types.ID, startsAt, endsAt time.Time) (*Assignment, error) {
+	if _, err := s.ownedVehicle(ctx, ownerID, vehicleID); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if driverID == "" || !endsAt.After(startsAt) || !endsAt.After(now) ||
+		endsAt.Sub(startsAt) > maxShift || startsAt.Sub(now) > maxShiftsAhead {
+		return nil, ErrBadRequest
+	}
+	a := &Assignment{
+		ID:        types.NewID(),
+		VehicleID: vehicleID,
+		DriverID:  driverID,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		CreatedAt: now,
+	}
+	if err := s.fleet.CreateAssignment(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// EndShift ends a shift of ownerID's vehicle now, or removes it if it has
+// not started.
+func (s *Service) EndShift(ctx context.Context, ownerID, vehicleID, assignmentID types.ID) error {
+	if _, err := s.ownedVehicle(ctx, ownerID, vehicleID); err != nil {
+		return err
+	}
+	return s.fleet.EndAssignment(ctx, vehicleID, assignmentID, s.now())
+}
+
+// MayDrive reports whether driverID may go online with vehicleID now. A
+// vehicle outside any fleet may be driven by anyone; a fleet vehicle only by
+// the driver of its current shift.
+func (s *Service) MayDrive(ctx context.Context, vehicleID, driverID types.ID) (bool, error) {
+	if s.fleet == nil {
+		return true, nil
+	}
+	current, fleet, err := s.fleet.CurrentDriver(ctx, vehicleID, s.now())
+	if err != nil {
+		return false, err
+	}
+	return !fleet || current == driverID, nil
+}
+
+// Utilization reports how ownerID's vehicles were used in [from, to).
+func (s *Service) Utilization(ctx context.Context, ownerID types.ID, from, to time.Time) ([]Utilization, error) {
+	if s.fleet == nil {
+		return nil, ErrNoFleetStore
+	}
+	if !from.Before(to) || to.Sub(from) > maxReportWindow {
+		return nil, ErrBadRequest
+	}
+	return s.fleet.Utilization(ctx, ownerID, from, to)
+}
@@ -0,0 +1,421 @@
+// README: Vehicle HTTP handlers — drivers report their odometer and read their vehicle's upkeep and shifts; fleet owners manage vehicles, shifts and utilization; ops record inspections and services and list vehicles due.
+//
+// Endpoints:
+//
+//	GET    /api/driver/vehicle                     — the driver's vehicle upkeep
+//	POST   /api/driver/vehicle/odometer            — report the odometer ({"odometer_km"})
+//	GET    /api/driver/shifts                      — the driver's current and upcoming fleet shifts
+//	GET    /api/fleet/vehicles                     — the owner's fleet
+//	POST   /api/fleet/vehicles                     — register a vehicle ({"plate"})
+//	GET    /api/fleet/vehicles/:id/shifts          — the vehicle's current and upcoming shifts
+//	POST   /api/fleet/vehicles/:id/shifts          — book a shift ({"driver_id", "starts_at", "ends_at"})
+//	DELETE /api/fleet/vehicles/:id/shifts/:shift_id — end a shift now, or cancel one not started
+//	GET    /api/fleet/utilization?from=&to=        — shift time, trips and trip time per vehicle
+//	GET    /api/ops/vehicles/due                   — vehicles due for inspection or service (ops key)
+//	GET    /api/ops/drivers/:id/vehicle            — a driver's vehicle upkeep (ops key)
+//	PUT    /api/ops/drivers/:id/vehicle/inspection — set the next inspection date ({"due_date": "2027-03-31"}) (ops key)
+//	POST   /api/ops/drivers/:id/vehicle/service    — record a service ({"odometer_km", "interval_km"}) (ops key)
+//
+// Auth: driver and fleet routes require the authenticated driver or fleet
+// owner; ops routes the ops key middleware.
+package vehicle
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ark/internal/http/middleware"
+	"ark/internal/types"
+)
+
+// dateLayout is how inspection dates are written in requests and responses.
+const dateLayout = time.DateOnly
+
+// Handler holds the vehicle upkeep HTTP handlers.
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler returns a Handler backed by the given Service.
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type odometerReq struct {
+	OdometerKm *int `json:"odometer_km"`
+}
+
+type inspectionReq struct {
+	DueDate string `json:"due_date"`
+}
+
+type serviceReq struct {
+	OdometerKm *int `json:"odometer_km"`
+	// IntervalKm, when set, is the distance to the next service from now on.
+	IntervalKm int `json:"interval_km"`
+}
+
+type upkeepResp struct {
+	DriverID          types.ID `json:"driver_id"`
+	InspectionDue     string   `json:"inspection_due,omitempty"`
+	InspectionOverdue bool     `json:"inspection_overdue"`
+	OdometerKm        int      `json:"odometer_km"`
+	OdometerAt        *int64   `json:"odometer_at,omitempty"`
+	// ServiceIntervalKm is the vehicle's own interval, 0 for the default.
+	ServiceIntervalKm int    `json:"service_interval_km"`
+	LastServiceKm     int    `json:"last_service_km"`
+	LastServiceAt     *int64 `json:"last_service_at,omitempty"`
+	NextServiceKm     int    `json:"next_service_km"`
+	UpdatedAt         int64  `json:"updated_at"`
+}
+
+type registerVehicleReq struct {
+	Plate string `json:"plate"`
+}
+
+type fleetVehicleResp struct {
+	ID        types.ID `json:"vehicle_id"`
+	Plate     string   `json:"plate"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+func toFleetVehicleResp(v FleetVehicle) fleetVehicleResp {
+	return fleetVehicleResp{ID: v.ID, Plate: v.Plate, CreatedAt: v.CreatedAt.Unix()}
+}
+
+// shiftReq times are Unix seconds.
+type shiftReq struct {
+	DriverID types.ID `json:"driver_id"`
+	StartsAt int64    `json:"starts_at"`
+	EndsAt   int64    `json:"ends_at"`
+}
+
+type shiftResp struct {
+	ID        types.ID `json:"shift_id"`
+	VehicleID types.ID `json:"vehicle_id"`
+	DriverID  types.ID `json:"driver_id"`
+	StartsAt  int64    `json:"starts_at"`
+	EndsAt    int64    `json:"ends_at"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+func toShiftResp(a Assignment) shiftResp {
+	return shiftResp{
+		ID:        a.ID,
+		VehicleID: a.VehicleID,
+		DriverID:  a.DriverID,
+		StartsAt:  a.StartsAt.Unix(),
+		EndsAt:    a.EndsAt.Unix(),
+		CreatedAt: a.CreatedAt.Unix(),
+	}
+}
+
+func toShiftResps(as []Assignment) []shiftResp {
+	out := make([]shiftResp, len(as))
+	for i, a := range as {
+		out[i] = toShiftResp(a)
+	}
+	return out
+}
+
+type utilizationResp struct {
+	VehicleID    types.ID `json:"vehicle_id"`
+	Plate        string   `json:"plate"`
+	AssignedSecs int64    `json:"assigned_secs"`
+	Drivers      int      `json:"drivers"`
+	Trips        int      `json:"trips"`
+	TripSecs     int64    `json:"trip_secs"`
+	// Utilization is the share of the report window spent on trips.
+	Utilization float64 `json:"utilization"`
+}
+
+func unixPtr(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ts := t.Unix()
+	return &ts
+}
+
+func (h *Handler) toUpkeepResp(u Upkeep) upkeepResp {
+	out := upkeepResp{
+		DriverID:          u.DriverID,
+		InspectionOverdue: u.InspectionOverdue(h.svc.now()),
+		OdometerKm:        u.OdometerKm,
+		OdometerAt:        unixPtr(u.OdometerAt),
+		ServiceIntervalKm: u.ServiceIntervalKm,
+		LastServiceKm:     u.LastServiceKm,
+		LastServiceAt:     unixPtr(u.LastServiceAt),
+		NextServiceKm:     h.svc.NextServiceKm(u),
+		UpdatedAt:         u.UpdatedAt.Unix(),
+	}
+	if u.InspectionDue != nil {
+		out.InspectionDue = u.InspectionDue.Format(dateLayout)
+	}
+	return out
+}
+
+// Mine handles GET /api/driver/vehicle.
+func (h *Handler) Mine(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.get(c, types.ID(uid))
+}
+
+// ReportOdometer handles POST /api/driver/vehicle/odometer.
+func (h *Handler) ReportOdometer(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req odometerReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.OdometerKm == nil {
+		writeError(c, http.StatusBadRequest, "odometer_km is required")
+		return
+	}
+	u, err := h.svc.ReportOdometer(c.Request.Context(), types.ID(uid), *req.OdometerKm)
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, h.toUpkeepResp(*u))
+}
+
+// Due handles GET /api/ops/vehicles/due.
+func (h *Handler) Due(c *gin.Context) {
+	us, err := h.svc.Due(c.Request.Context())
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	out := make([]upkeepResp, len(us))
+	for i, u := range us {
+		out[i] = h.toUpkeepResp(u)
+	}
+	c.JSON(http.StatusOK, map[string]any{"vehicles": out})
+}
+
+// Get handles GET /api/ops/drivers/:id/vehicle.
+func (h *Handler) Get(c *gin.Context) {
+	h.get(c, types.ID(c.Param("id")))
+}
+
+func (h *Handler) get(c *gin.Context, driverID types.ID) {
+	u, err := h.svc.Get(c.Request.Context(), driverID)
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, h.toUpkeepResp(*u))
+}
+
+// SetInspection handles PUT /api/ops/drivers/:id/vehicle/inspection.
+func (h *Handler) SetInspection(c *gin.Context) {
+	var req inspectionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	due, err := time.Parse(dateLayout, req.DueDate)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "due_date must be YYYY-MM-DD")
+		return
+	}
+	u, err := h.svc.SetInspectionDue(c.Request.Context(), types.ID(c.Param("id")), due)
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, h.toUpkeepResp(*u))
+}
+
+// RecordService handles POST /api/ops/drivers/:id/vehicle/service.
+func (h *Handler) RecordService(c *gin.Context) {
+	var req serviceReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.OdometerKm == nil {
+		writeError(c, http.StatusBadRequest, "odometer_km is required")
+		return
+	}
+	u, err := h.svc.RecordService(c.Request.Context(), types.ID(c.Param("id")), *req.OdometerKm, req.IntervalKm)
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, h.toUpkeepResp(*u))
+}
+
+// DriverShifts handles GET /api/driver/shifts.
+func (h *Handler) DriverShifts(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	as, err := h.svc.DriverShifts(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"shifts": toShiftResps(as)})
+}
+
+// FleetVehicles handles GET /api/fleet/vehicles.
+func (h *Handler) FleetVehicles(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	vs, err := h.svc.Vehicles(c.Request.Context(), types.ID(uid))
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	out := make([]fleetVehicleResp, len(vs))
+	for i, v := range vs {
+		out[i] = toFleetVehicleResp(v)
+	}
+	c.JSON(http.StatusOK, map[string]any{"vehicles": out})
+}
+
+// RegisterVehicle handles POST /api/fleet/vehicles.
+func (h *Handler) RegisterVehicle(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req registerVehicleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	v, err := h.svc.RegisterVehicle(c.Request.Context(), types.ID(uid), req.Plate)
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toFleetVehicleResp(*v))
+}
+
+// Shifts handles GET /api/fleet/vehicles/:id/shifts.
+func (h *Handler) Shifts(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	as, err := h.svc.Shifts(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")))
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"shifts": toShiftResps(as)})
+}
+
+// AssignShift handles POST /api/fleet/vehicles/:id/shifts.
+func (h *Handler) AssignShift(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req shiftReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid json")
+		return
+	}
+	a, err := h.svc.AssignShift(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")), req.DriverID,
+		time.Unix(req.StartsAt, 0).UTC(), time.Unix(req.EndsAt, 0).UTC())
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toShiftResp(*a))
+}
+
+// EndShift handles DELETE /api/fleet/vehicles/:id/shifts/:shift_id.
+func (h *Handler) EndShift(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.EndShift(c.Request.Context(), types.ID(uid), types.ID(c.Param("id")), types.ID(c.Param("shift_id"))); err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Utilization handles GET /api/fleet/utilization?from=&to=. Times are RFC3339
+// and the period defaults to the last 7 days.
+func (h *Handler) Utilization(c *gin.Context) {
+	uid, ok := middleware.UserIDFromContext(c.Request.Context())
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+	var err error
+	if s := c.Query("from"); s != "" {
+		if from, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid from; expected RFC3339")
+			return
+		}
+	}
+	if s := c.Query("to"); s != "" {
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid to; expected RFC3339")
+			return
+		}
+	}
+	us, err := h.svc.Utilization(c.Request.Context(), types.ID(uid), from, to)
+	if err != nil {
+		writeVehicleError(c, err)
+		return
+	}
+	window := to.Sub(from)
+	out := make([]utilizationResp, len(us))
+	for i, u := range us {
+		out[i] = utilizationResp{
+			VehicleID:    u.VehicleID,
+			Plate:        u.Plate,
+			AssignedSecs: int64(u.Assigned.Seconds()),
+			Drivers:      u.Drivers,
+			Trips:        u.Trips,
+			TripSecs:     int64(u.TripTime.Seconds()),
+			Utilization:  float64(u.TripTime) / float64(window),
+		}
+	}
+	c.JSON(http.StatusOK, map[string]any{"from": from, "to": to, "vehicles": out})
+}
+
+func writeError(c *gin.Context, status int, msg string) {
+	c.JSON(status, map[string]any{"error": msg})
+}
+
+func writeVehicleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		writeError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrForbidden):
+		writeError(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrVehicleNotFound), errors.Is(err, ErrShiftNotFound):
+		writeError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrOdometerBackwards), errors.Is(err, ErrShiftConflict), errors.Is(err, ErrPlateTaken):
+		writeError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrNoFleetStore):
+		writeError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "internal error")
+	}
+}
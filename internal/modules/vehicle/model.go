@@ -0,0 +1,126 @@
+// README: Vehicle upkeep domain model — a driver's vehicle inspection due date, reported odometer and maintenance interval, and the reminders they lead to.
+package vehicle
+
+import (
+	"errors"
+	"time"
+
+	"ark/internal/types"
+)
+
+const (
+	// reminderRepeat is how long before a driver is reminded again of an
+	// inspection still due or of an odometer still not reported.
+	reminderRepeat = 7 * 24 * time.Hour
+	// odometerStaleAfter is how long after their last report a driver is asked
+	// for the odometer again.
+	odometerStaleAfter = 30 * 24 * time.Hour
+	// serviceLeadKm is how far ahead of the next service the driver is
+	// reminded of it.
+	serviceLeadKm = 500
+	// maxOdometerKm caps a reading, catching a mistyped extra digit.
+	maxOdometerKm = 2_000_000
+	// maxServiceIntervalKm caps the maintenance interval ops may set.
+	maxServiceIntervalKm = 100_000
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("no vehicle upkeep recorded for this driver")
+	// ErrOdometerBackwards is returned for an odometer reading below the last
+	// one reported.
+	ErrOdometerBackwards = errors.New("odometer reading is below the last one reported")
+)
+
+// Reminder kinds.
+const (
+	ReminderInspectionDue     = "inspection_due"
+	ReminderInspectionOverdue = "inspection_overdue"
+	ReminderServiceDue        = "service_due"
+	ReminderOdometer          = "odometer_report"
+)
+
+// Upkeep is the inspection and maintenance record of a driver's vehicle.
+// Drivers have one vehicle each, so it is keyed by driver.
+type Upkeep struct {
+	DriverID types.ID
+	// InspectionDue is the day (midnight UTC) the next inspection is due; nil
+	// until ops record one.
+	InspectionDue *time.Time
+	// OdometerKm is the last reading the driver reported, at OdometerAt.
+	OdometerKm int
+	OdometerAt *time.Time
+	// ServiceIntervalKm is the distance between services; 0 uses the
+	// platform default.
+	ServiceIntervalKm int
+	// LastServiceKm is the odometer reading at the last service, at
+	// LastServiceAt.
+	LastServiceKm int
+	LastServiceAt *time.Time
+	// InspectionRemindedAt is when the driver was last reminded of the
+	// current due date; ServiceRemindedKm is the service mileage last
+	// reminded of; OdometerRemindedAt is when the driver was last asked for
+	// the odometer.
+	InspectionRemindedAt *time.Time
+	ServiceRemindedKm    int
+	OdometerRemindedAt   *time.Time
+	UpdatedAt            time.Time
+}
+
+// InspectionOverdue reports whether the inspection due date has passed at
+// now. The due day itself still counts as in time.
+func (u Upkeep) InspectionOverdue(now time.Time) bool {
+	return u.InspectionDue != nil && !now.Before(u.InspectionDue.AddDate(0, 0, 1))
+}
+
+// NextServiceKm returns the odometer reading the next service is due at,
+// with defaultIntervalKm for a vehicle without an interval of its own.
+func (u Upkeep) NextServiceKm(defaultIntervalKm int) int {
+	interval := u.ServiceIntervalKm
+	if interval == 0 {
+		interval = defaultIntervalKm
+	}
+	return u.LastServiceKm + interval
+}
+
+// Reminder is a push sent to a driver about their vehicle's upkeep.
+type Reminder struct {
+	DriverID types.ID
+	Kind     string
+	// InspectionDue is set for inspection reminders.
+	InspectionDue *time.Time
+	// ServiceDueKm and OdometerKm are set for service reminders.
+	ServiceDueKm int
+	OdometerKm   int
+}
+
+// dueReminders returns the reminders u calls for at now: an inspection due
+// within lead or overdue, repeated weekly until a new due date is set; a
+// service within serviceLeadKm, once per service; and an odometer not
+// reported for odometerStaleAfter, repeated weekly.
+func dueReminders(u Upkeep, now time.Time, lead time.Duration, defaultIntervalKm int) []Reminder {
+	var out []Reminder
+	if u.InspectionDue != nil && now.After(u.InspectionDue.Add(-lead)) &&
+		(u.InspectionRemindedAt == nil || now.Sub(*u.InspectionRemindedAt) >= reminderRepeat) {
+		kind := ReminderInspectionDue
+		if u.InspectionOverdue(now) {
+			kind = ReminderInspectionOverdue
+		}
+		out = append(out, Reminder{DriverID: u.DriverID, Kind: kind, InspectionDue: u.InspectionDue})
+	}
+	if next := u.NextServiceKm(defaultIntervalKm); u.OdometerAt != nil &&
+		u.OdometerKm >= next-serviceLeadKm && u.ServiceRemindedKm != next {
+		out = append(out, Reminder{DriverID: u.DriverID, Kind: ReminderServiceDue, ServiceDueKm: next, OdometerKm: u.OdometerKm})
+	}
+	if (u.OdometerAt == nil || now.Sub(*u.OdometerAt) >= odometerStaleAfter) &&
+		(u.OdometerRemindedAt == nil || now.Sub(*u.OdometerRemindedAt) >= reminderRepeat) {
+		out = append(out, Reminder{DriverID: u.DriverID, Kind: ReminderOdometer})
+	}
+	return out
+}
+
+// day truncates t to its UTC date.
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,42 @@
+// README: Vehicle route registration — mounts the driver odometer and shift endpoints, the fleet owner endpoints, and the ops inspection and service endpoints.
+package vehicle
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the driver and fleet owner endpoints onto the
+// provided authenticated router group.
+//
+//	GET    /api/driver/vehicle
+//	POST   /api/driver/vehicle/odometer
+//	GET    /api/driver/shifts
+//	GET    /api/fleet/vehicles
+//	POST   /api/fleet/vehicles
+//	GET    /api/fleet/vehicles/:id/shifts
+//	POST   /api/fleet/vehicles/:id/shifts
+//	DELETE /api/fleet/vehicles/:id/shifts/:shift_id
+//	GET    /api/fleet/utilization
+func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/driver/vehicle", h.Mine)
+	rg.POST("/api/driver/vehicle/odometer", h.ReportOdometer)
+	rg.GET("/api/driver/shifts", h.DriverShifts)
+	rg.GET("/api/fleet/vehicles", h.FleetVehicles)
+	rg.POST("/api/fleet/vehicles", h.RegisterVehicle)
+	rg.GET("/api/fleet/vehicles/:id/shifts", h.Shifts)
+	rg.POST("/api/fleet/vehicles/:id/shifts", h.AssignShift)
+	rg.DELETE("/api/fleet/vehicles/:id/shifts/:shift_id", h.EndShift)
+	rg.GET("/api/fleet/utilization", h.Utilization)
+}
+
+// RegisterOpsRoutes mounts the ops endpoints onto the provided ops router
+// group.
+//
+//	GET  /api/ops/vehicles/due
+//	GET  /api/ops/drivers/:id/vehicle
+//	PUT  /api/ops/drivers/:id/vehicle/inspection
+//	POST /api/ops/drivers/:id/vehicle/service
+func RegisterOpsRoutes(rg *gin.RouterGroup, h *Handler) {
+	rg.GET("/api/ops/vehicles/due", h.Due)
+	rg.GET("/api/ops/drivers/:id/vehicle", h.Get)
+	rg.PUT("/api/ops/drivers/:id/vehicle/inspection", h.SetInspection)
+	rg.POST("/api/ops/drivers/:id/vehicle/service", h.RecordService)
+}
@@ -0,0 +1,173 @@
+// README: Vehicle upkeep service — records inspection due dates, odometer reports and services, reminds drivers of upcoming inspections and maintenance, and tells matching whose inspection is overdue.
+package vehicle
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ark/internal/types"
+)
+
+// ReminderHook is called after a reminder is due, e.g. to push it to the
+// driver.
+type ReminderHook func(ctx context.Context, r Reminder)
+
+// Service keeps vehicle upkeep and sends its reminders.
+type Service struct {
+	store             UpkeepStore
+	fleet             FleetStore
+	interval          time.Duration
+	inspectionLead    time.Duration
+	serviceIntervalKm int
+	hooks             []ReminderHook
+	now               func() time.Time
+}
+
+// NewService returns a Service that checks for reminders due every interval,
+// reminds drivers inspectionLead ahead of an inspection, and services
+// vehicles without an interval of their own every serviceIntervalKm.
+func NewService(store UpkeepStore, interval, inspectionLead time.Duration, serviceIntervalKm int) *Service {
+	return &Service{
+		store:             store,
+		interval:          interval,
+		inspectionLead:    inspectionLead,
+		serviceIntervalKm: serviceIntervalKm,
+		now:               time.Now,
+	}
+}
+
+// OnReminder registers h to run for every reminder sent. Must be called
+// before the service starts.
+func (s *Service) OnReminder(h ReminderHook) {
+	s.hooks = append(s.hooks, h)
+}
+
+// RunJob checks for reminders due every interval until ctx is done.
+func (s *Service) RunJob(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckOnce(ctx)
+		}
+	}
+}
+
+// CheckOnce sends every reminder due now, recording each so it is not
+// repeated before its time.
+func (s *Service) CheckOnce(ctx context.Context) {
+	now := s.now()
+	us, err := s.store.List(ctx)
+	if err != nil {
+		log.Printf("vehicle: list upkeep: %v", err)
+		return
+	}
+	for _, u := range us {
+		for _, r := range dueReminders(u, now, s.inspectionLead, s.serviceIntervalKm) {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := s.store.MarkReminded(ctx, r, now); err != nil {
+				log.Printf("vehicle: driver %s %s reminder: %v", r.DriverID, r.Kind, err)
+				continue
+			}
+			s.remind(ctx, r)
+		}
+	}
+}
+
+func (s *Service) remind(ctx context.Context, r Reminder) {
+	for _, h := range s.hooks {
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					log.Printf("vehicle: reminder hook panicked for %s: %v", r.DriverID, rec)
+				}
+			}()
+			h(ctx, r)
+		}()
+	}
+}
+
+// Get returns a driver's vehicle upkeep.
+func (s *Service) Get(ctx context.Context, driverID types.ID) (*Upkeep, error) {
+	return s.store.Get(ctx, driverID)
+}
+
+// Due returns the vehicles whose inspection is overdue or due within the
+// reminder lead, or whose last reported odometer is within the service lead
+// of the next service.
+func (s *Service) Due(ctx context.Context) ([]Upkeep, error) {
+	us, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	out := []Upkeep{}
+	for _, u := range us {
+		inspection := u.InspectionDue != nil && now.After(u.InspectionDue.Add(-s.inspectionLead))
+		service := u.OdometerAt != nil && u.OdometerKm >= u.NextServiceKm(s.serviceIntervalKm)-serviceLeadKm
+		if inspection || service {
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+// NextServiceKm returns the odometer reading u's next service is due at.
+func (s *Service) NextServiceKm(u Upkeep) int {
+	return u.NextServiceKm(s.serviceIntervalKm)
+}
+
+// ReportOdometer records the driver's odometer reading, which may not go
+// below the last one.
+func (s *Service) ReportOdometer(ctx context.Context, driverID types.ID, km int) (*Upkeep, error) {
+	if driverID == "" || km < 0 || km > maxOdometerKm {
+		return nil, ErrBadRequest
+	}
+	ok, err := s.store.ReportOdometer(ctx, driverID, km, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrOdometerBackwards
+	}
+	return s.store.Get(ctx, driverID)
+}
+
+// SetInspectionDue records the day the driver's next inspection is due, after
+// one was passed or when the vehicle is first registered.
+func (s *Service) SetInspectionDue(ctx context.Context, driverID types.ID, due time.Time) (*Upkeep, error) {
+	if driverID == "" || due.IsZero() {
+		return nil, ErrBadRequest
+	}
+	if err := s.store.SetInspectionDue(ctx, driverID, day(due), s.now()); err != nil {
+		return nil, err
+	}
+	return s.store.Get(ctx, driverID)
+}
+
+// RecordService records a service of the driver's vehicle at odometer reading
+// km; intervalKm > 0 also sets the distance to the next one.
+func (s *Service) RecordService(ctx context.Context, driverID types.ID, km, intervalKm int) (*Upkeep, error) {
+	if driverID == "" || km < 0 || km > maxOdometerKm || intervalKm < 0 || intervalKm > maxServiceIntervalKm {
+		return nil, ErrBadRequest
+	}
+	if err := s.store.RecordService(ctx, driverID, km, intervalKm, s.now()); err != nil {
+		return nil, err
+	}
+	return s.store.Get(ctx, driverID)
+}
+
+// OverdueInspections returns the drivers among ids whose vehicle inspection
+// is overdue.
+func (s *Service) OverdueInspections(ctx context.Context, ids []types.ID) ([]types.ID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return s.store.OverdueInspections(ctx, ids, day(s.now()))
+}
@@ -0,0 +1,421 @@
+package vehicle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"ark/internal/types"
+)
+
+type mockStore struct {
+	upkeep map[types.ID]*Upkeep
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{upkeep: make(map[types.ID]*Upkeep)}
+}
+
+func (m *mockStore) row(driverID types.ID, now time.Time) *Upkeep {
+	u, ok := m.upkeep[driverID]
+	if !ok {
+		u = &Upkeep{DriverID: driverID}
+		m.upkeep[driverID] = u
+	}
+	u.UpdatedAt = now
+	return u
+}
+
+func (m *mockStore) Get(_ context.Context, driverID types.ID) (*Upkeep, error) {
+	u, ok := m.upkeep[driverID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (m *mockStore) List(context.Context) ([]Upkeep, error) {
+	var out []Upkeep
+	for _, u := range m.upkeep {
+		out = append(out, *u)
+	}
+	return out, nil
+}
+
+func (m *mockStore) SetInspectionDue(_ context.Context, driverID types.ID, due, now time.Time) error {
+	u := m.row(driverID, now)
+	u.InspectionDue = &due
+	u.InspectionRemindedAt = nil
+	return nil
+}
+
+func (m *mockStore) ReportOdometer(_ context.Context, driverID types.ID, km int, now time.Time) (bool, error) {
+	if u, ok := m.upkeep[driverID]; ok && u.OdometerKm > km {
+		return false, nil
+	}
+	u := m.row(driverID, now)
+	u.OdometerKm, u.OdometerAt = km, &now
+	return true, nil
+}
+
+func (m *mockStore) RecordService(_ context.Context, driverID types.ID, km, intervalKm int, now time.Time) error {
+	u := m.row(driverID, now)
+	u.LastServiceKm, u.LastServiceAt = km, &now
+	if intervalKm > 0 {
+		u.ServiceIntervalKm = intervalKm
+	}
+	if km >= u.OdometerKm {
+		u.OdometerKm, u.OdometerAt = km, &now
+	}
+	return nil
+}
+
+func (m *mockStore) MarkReminded(_ context.Context, r Reminder, now time.Time) error {
+	u := m.upkeep[r.DriverID]
+	switch r.Kind {
+	case ReminderInspectionDue, ReminderInspectionOverdue:
+		u.InspectionRemindedAt = &now
+	case ReminderServiceDue:
+		u.ServiceRemindedKm = r.ServiceDueKm
+	case ReminderOdometer:
+		u.OdometerRemindedAt = &now
+	}
+	return nil
+}
+
+func (m *mockStore) OverdueInspections(_ context.Context, ids []types.ID, today time.Time) ([]types.ID, error) {
+	var out []types.ID
+	for _, id := range ids {
+		if u, ok := m.upkeep[id]; ok && u.InspectionDue != nil && u.InspectionDue.Before(today) {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func newTestService(now *time.Time) (*Service, *mockStore, *[]Reminder) {
+	store := newMockStore()
+	svc := NewService(store, time.Hour, 14*24*time.Hour, 10000)
+	svc.now = func() time.Time { return *now }
+	var sent []Reminder
+	svc.OnReminder(func(_ context.Context, r Reminder) { sent = append(sent, r) })
+	return svc, store, &sent
+}
+
+func kinds(rs []Reminder) []string {
+	out := make([]string, len(rs))
+	for i, r := range rs {
+		out[i] = r.Kind
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestReportOdometer_RefusesGoingBackwards(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	svc, _, _ := newTestService(&now)
+	ctx := context.Background()
+
+	if _, err := svc.ReportOdometer(ctx, "d1", -1); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("negative reading: err = %v, want ErrBadRequest", err)
+	}
+	u, err := svc.ReportOdometer(ctx, "d1", 42000)
+	if err != nil || u.OdometerKm != 42000 {
+		t.Fatalf("first report = %+v, %v", u, err)
+	}
+	if _, err := svc.ReportOdometer(ctx, "d1", 41000); !errors.Is(err, ErrOdometerBackwards) {
+		t.Errorf("lower reading: err = %v, want ErrOdometerBackwards", err)
+	}
+}
+
+func TestCheckOnce_InspectionReminders(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	svc, _, sent := newTestService(&now)
+	ctx := context.Background()
+	if _, err := svc.ReportOdometer(ctx, "d1", 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetInspectionDue(ctx, "d1", time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.CheckOnce(ctx)
+	if len(*sent) != 0 {
+		t.Fatalf("inspection five weeks out: sent %v, want nothing", kinds(*sent))
+	}
+
+	now = time.Date(2026, 11, 10, 9, 0, 0, 0, time.UTC)
+	svc.CheckOnce(ctx)
+	svc.CheckOnce(ctx)
+	if got := kinds(*sent); !slices.Equal(got, []string{ReminderInspectionDue}) {
+		t.Fatalf("inspection ten days out: sent %v, want one inspection_due", got)
+	}
+
+	// The driver is reminded again a week after the last reminder, and once
+	// the due day has passed the inspection is overdue.
+	*sent = nil
+	now = time.Date(2026, 11, 17, 9, 0, 0, 0, time.UTC)
+	svc.CheckOnce(ctx)
+	if got := kinds(*sent); !slices.Contains(got, ReminderInspectionDue) {
+		t.Errorf("a week later: sent %v, want inspection_due", got)
+	}
+	*sent = nil
+	now = time.Date(2026, 11, 24, 9, 0, 0, 0, time.UTC)
+	svc.CheckOnce(ctx)
+	if got := kinds(*sent); !slices.Contains(got, ReminderInspectionOverdue) {
+		t.Errorf("after the due date: sent %v, want inspection_overdue", got)
+	}
+
+	// A new due date re-arms the reminders.
+	*sent = nil
+	if _, err := svc.SetInspectionDue(ctx, "d1", time.Date(2027, 11, 20, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	svc.CheckOnce(ctx)
+	if got := kinds(*sent); slices.Contains(got, ReminderInspectionDue) || slices.Contains(got, ReminderInspectionOverdue) {
+		t.Errorf("after a new inspection: sent %v, want no inspection reminder", got)
+	}
+}
+
+func TestCheckOnce_ServiceAndOdometerReminders(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	svc, _, sent := newTestService(&now)
+	ctx := context.Background()
+	if _, err := svc.RecordService(ctx, "d1", 20000, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ReportOdometer(ctx, "d1", 29600); err != nil {
+		t.Fatal(err)
+	}
+	svc.CheckOnce(ctx)
+	svc.CheckOnce(ctx)
+	if len(*sent) != 1 || (*sent)[0].Kind != ReminderServiceDue || (*sent)[0].ServiceDueKm != 30000 {
+		t.Fatalf("400 km before service: sent %+v, want one service_due at 30000", *sent)
+	}
+
+	// A service with its own interval moves the next one.
+	u, err := svc.RecordService(ctx, "d1", 30100, 15000)
+	if err != nil || svc.NextServiceKm(*u) != 45100 {
+		t.Fatalf("after service: %+v, %v; want next service at 45100", u, err)
+	}
+
+	*sent = nil
+	now = now.Add(31 * 24 * time.Hour)
+	svc.CheckOnce(ctx)
+	svc.CheckOnce(ctx)
+	if got := kinds(*sent); !slices.Equal(got, []string{ReminderOdometer}) {
+		t.Errorf("a month without a report: sent %v, want one odometer_report", got)
+	}
+}
+
+func TestOverdueInspectionsAndDue(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	svc, _, _ := newTestService(&now)
+	ctx := context.Background()
+	for id, due := range map[types.ID]time.Time{
+		"late":  time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC),
+		"today": time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		"later": time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if _, err := svc.SetInspectionDue(ctx, id, due); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := svc.OverdueInspections(ctx, []types.ID{"late", "today", "later", "unknown"})
+	if err != nil || !slices.Equal(got, []types.ID{"late"}) {
+		t.Errorf("OverdueInspections = %v, %v; want [late]", got, err)
+	}
+	due, err := svc.Due(ctx)
+	if err != nil || len(due) != 2 {
+		t.Errorf("Due = %d vehicles, %v; want late and today", len(due), err)
+	}
+}
+
+type mockFleet struct {
+	vehicles map[types.ID]*FleetVehicle
+	shifts   []*Assignment
+}
+
+func newMockFleet() *mockFleet {
+	return &mockFleet{vehicles: make(map[types.ID]*FleetVehicle)}
+}
+
+func (m *mockFleet) CreateVehicle(_ context.Context, v *FleetVehicle) error {
+	for _, o := range m.vehicles {
+		if o.Plate == v.Plate {
+			return ErrPlateTaken
+		}
+	}
+	cp := *v
+	m.vehicles[v.ID] = &cp
+	return nil
+}
+
+func (m *mockFleet) GetVehicle(_ context.Context, id types.ID) (*FleetVehicle, error) {
+	v, ok := m.vehicles[id]
+	if !ok {
+		return nil, ErrVehicleNotFound
+	}
+	cp := *v
+	return &cp, nil
+}
+
+func (m *mockFleet) ListVehicles(_ context.Context, ownerID types.ID) ([]FleetVehicle, error) {
+	var out []FleetVehicle
+	for _, v := range m.vehicles {
+		if v.OwnerID == ownerID {
+			out = append(out, *v)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockFleet) assignments(now time.Time, keep func(*Assignment) bool) []Assignment {
+	var out []Assignment
+	for _, a := range m.shifts {
+		if a.EndsAt.After(now) && keep(a) {
+			out = append(out, *a)
+		}
+	}
+	return out
+}
+
+func (m *mockFleet) VehicleAssignments(_ context.Context, vehicleID types.ID, now time.Time) ([]Assignment, error) {
+	return m.assignments(now, func(a *Assignment) bool { return a.VehicleID == vehicleID }), nil
+}
+
+func (m *mockFleet) DriverAssignments(_ context.Context, driverID types.ID, now time.Time) ([]Assignment, error) {
+	return m.assignments(now, func(a *Assignment) bool { return a.DriverID == driverID }), nil
+}
+
+func (m *mockFleet) CreateAssignment(_ context.Context, a *Assignment) error {
+	for _, o := range m.shifts {
+		if (o.VehicleID == a.VehicleID || o.DriverID == a.DriverID) &&
+			o.StartsAt.Before(a.EndsAt) && a.StartsAt.Before(o.EndsAt) {
+			return ErrShiftConflict
+		}
+	}
+	cp := *a
+	m.shifts = append(m.shifts, &cp)
+	return nil
+}
+
+func (m *mockFleet) EndAssignment(_ context.Context, vehicleID, id types.ID, now time.Time) error {
+	for i, a := range m.shifts {
+		if a.ID != id || a.VehicleID != vehicleID || !a.EndsAt.After(now) {
+			continue
+		}
+		if a.StartsAt.After(now) {
+			m.shifts = slices.Delete(m.shifts, i, i+1)
+		} else {
+			a.EndsAt = now
+		}
+		return nil
+	}
+	return ErrShiftNotFound
+}
+
+func (m *mockFleet) CurrentDriver(_ context.Context, vehicleID types.ID, now time.Time) (types.ID, bool, error) {
+	if _, ok := m.vehicles[vehicleID]; !ok {
+		return "", false, nil
+	}
+	for _, a := range m.shifts {
+		if a.VehicleID == vehicleID && !a.StartsAt.After(now) && a.EndsAt.After(now) {
+			return a.DriverID, true, nil
+		}
+	}
+	return "", true, nil
+}
+
+func (m *mockFleet) Utilization(context.Context, types.ID, time.Time, time.Time) ([]Utilization, error) {
+	return nil, nil
+}
+
+func TestFleetShiftHandover(t *testing.T) {
+	now := time.Date(2026, 10, 15, 6, 0, 0, 0, time.UTC)
+	svc, _, _ := newTestService(&now)
+	ctx := context.Background()
+
+	if ok, err := svc.MayDrive(ctx, "car", "d1"); err != nil || !ok {
+		t.Errorf("without fleet mode: MayDrive = %v, %v; want true", ok, err)
+	}
+	if _, err := svc.RegisterVehicle(ctx, "owner", "ABC-123"); !errors.Is(err, ErrNoFleetStore) {
+		t.Errorf("without fleet mode: err = %v, want ErrNoFleetStore", err)
+	}
+
+	svc.SetFleetStore(newMockFleet())
+	v, err := svc.RegisterVehicle(ctx, "owner", " abc 123 ")
+	if err != nil || v.Plate != "ABC123" {
+		t.Fatalf("RegisterVehicle = %+v, %v", v, err)
+	}
+	if _, err := svc.RegisterVehicle(ctx, "other", "ABC123"); !errors.Is(err, ErrPlateTaken) {
+		t.Errorf("duplicate plate: err = %v, want ErrPlateTaken", err)
+	}
+
+	day := now.Add(time.Hour)
+	night := day.Add(12 * time.Hour)
+	if _, err := svc.AssignShift(ctx, "other", v.ID, "d1", day, night); !errors.Is(err, ErrForbidden) {
+		t.Errorf("someone else's vehicle: err = %v, want ErrForbidden", err)
+	}
+	if _, err := svc.AssignShift(ctx, "owner", v.ID, "d1", day, day.Add(25*time.Hour)); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("over-long shift: err = %v, want ErrBadRequest", err)
+	}
+	if _, err := svc.AssignShift(ctx, "owner", v.ID, "d1", day, night); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.AssignShift(ctx, "owner", v.ID, "d2", night.Add(-time.Hour), night.Add(11*time.Hour)); !errors.Is(err, ErrShiftConflict) {
+		t.Errorf("overlapping shift: err = %v, want ErrShiftConflict", err)
+	}
+	// Handover: the night driver's shift starts when the day driver's ends.
+	if _, err := svc.AssignShift(ctx, "owner", v.ID, "d2", night, night.Add(12*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	mayDrive := func(driverID types.ID) bool {
+		t.Helper()
+		ok, err := svc.MayDrive(ctx, v.ID, driverID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	if mayDrive("d1") || mayDrive("d2") {
+		t.Error("before the first shift nobody may drive the vehicle")
+	}
+	now = day.Add(time.Minute)
+	if !mayDrive("d1") || mayDrive("d2") {
+		t.Error("during the day shift only d1 may drive")
+	}
+	now = night.Add(time.Minute)
+	if mayDrive("d1") || !mayDrive("d2") {
+		t.Error("during the night shift only d2 may drive")
+	}
+	if ok, _ := svc.MayDrive(ctx, "private-car", "d1"); !ok {
+		t.Error("a vehicle outside any fleet may be driven by anyone")
+	}
+
+	shifts, err := svc.Shifts(ctx, "owner", v.ID)
+	if err != nil || len(shifts) != 1 || shifts[0].DriverID != "d2" {
+		t.Fatalf("Shifts = %+v, %v; want only d2's", shifts, err)
+	}
+	if err := svc.EndShift(ctx, "owner", v.ID, shifts[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if mayDrive("d2") {
+		t.Error("d2 may still drive after the shift ended")
+	}
+	if err := svc.EndShift(ctx, "owner", v.ID, shifts[0].ID); !errors.Is(err, ErrShiftNotFound) {
+		t.Errorf("ending twice: err = %v, want ErrShiftNotFound", err)
+	}
+
+	if _, err := svc.Utilization(ctx, "owner", now, now.Add(-time.Hour)); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("inverted window: err = %v, want ErrBadRequest", err)
+	}
+	if _, err := svc.Utilization(ctx, "owner", now.Add(-100*24*time.Hour), now); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("over-long window: err = %v, want ErrBadRequest", err)
+	}
+}
@@ -0,0 +1,412 @@
+// README: Vehicle store — PostgreSQL persistence for vehicle_upkeep, fleet_vehicles and vehicle_assignments.
+package vehicle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/types"
+)
+
+// UpkeepStore defines the persistence operations required by the Service.
+type UpkeepStore interface {
+	// Get returns a driver's upkeep, ErrNotFound if nothing was recorded.
+	Get(ctx context.Context, driverID types.ID) (*Upkeep, error)
+	// List returns every driver's upkeep ordered by driver.
+	List(ctx context.Context) ([]Upkeep, error)
+	// SetInspectionDue records the day a driver's next inspection is due and
+	// re-arms its reminders.
+	SetInspectionDue(ctx context.Context, driverID types.ID, due, now time.Time) error
+	// ReportOdometer records a driver's odometer reading. It returns false,
+	// recording nothing, when km is below the stored reading.
+	ReportOdometer(ctx context.Context, driverID types.ID, km int, now time.Time) (bool, error)
+	// RecordService records a service at odometer reading km, which also
+	// counts as a reading when above the stored one. intervalKm > 0 replaces
+	// the vehicle's service interval.
+	RecordService(ctx context.Context, driverID types.ID, km, intervalKm int, now time.Time) error
+	// MarkReminded records that r was sent at now.
+	MarkReminded(ctx context.Context, r Reminder, now time.Time) error
+	// OverdueInspections returns the drivers among ids whose inspection was
+	// due before today.
+	OverdueInspections(ctx context.Context, ids []types.ID, today time.Time) ([]types.ID, error)
+}
+
+// FleetStore persists fleet vehicles and their shifts.
+type FleetStore interface {
+	// CreateVehicle stores a new vehicle, ErrPlateTaken if its plate is.
+	CreateVehicle(ctx context.Context, v *FleetVehicle) error
+	// GetVehicle returns a vehicle, ErrVehicleNotFound if there is none.
+	GetVehicle(ctx context.Context, id types.ID) (*FleetVehicle, error)
+	// ListVehicles returns ownerID's vehicles by plate.
+	ListVehicles(ctx context.Context, ownerID types.ID) ([]FleetVehicle, error)
+	// VehicleAssignments and DriverAssignments return the shifts of a
+	// vehicle or a driver that have not ended at now, by start.
+	VehicleAssignments(ctx context.Context, vehicleID types.ID, now time.Time) ([]Assignment, error)
+	DriverAssignments(ctx context.Context, driverID types.ID, now time.Time) ([]Assignment, error)
+	// CreateAssignment stores a shift, ErrShiftConflict if it overlaps
+	// another shift of its vehicle or driver.
+	CreateAssignment(ctx context.Context, a *Assignment) error
+	// EndAssignment ends a shift of vehicleID at now: one not started yet is
+	// removed, one under way ends now. ErrShiftNotFound if it has already ended.
+	EndAssignment(ctx context.Context, vehicleID, id types.ID, now time.Time) error
+	// CurrentDriver returns the driver of vehicleID's shift at now, "" for
+	// none; fleet is false for a vehicle in no fleet.
+	CurrentDriver(ctx context.Context, vehicleID types.ID, now time.Time) (driverID types.ID, fleet bool, err error)
+	// Utilization reports ownerID's vehicles over [from, to), by plate.
+	Utilization(ctx context.Context, ownerID types.ID, from, to time.Time) ([]Utilization, error)
+}
+
+// Store is the PostgreSQL implementation of UpkeepStore and FleetStore.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store backed by the given connection pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+const upkeepColumns = `driver_id, inspection_due, odometer_km, odometer_at, service_interval_km,
+		       last_service_km, last_service_at, inspection_reminded_at, service_reminded_km,
+		       odometer_reminded_at, updated_at`
+
+func scanUpkeep(row pgx.Row) (*Upkeep, error) {
+	var u Upkeep
+	if err := row.Scan(&u.DriverID, &u.InspectionDue, &u.OdometerKm, &u.OdometerAt, &u.ServiceIntervalKm,
+		&u.LastServiceKm, &u.LastServiceAt, &u.InspectionRemindedAt, &u.ServiceRemindedKm,
+		&u.OdometerRemindedAt, &u.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *Store) Get(ctx context.Context, driverID types.ID) (*Upkeep, error) {
+	u, err := scanUpkeep(s.db.QueryRow(ctx, `
+		SELECT `+upkeepColumns+`
+		FROM vehicle_upkeep
+		WHERE driver_id = $1`, string(driverID)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return u, err
+}
+
+func (s *Store) List(ctx context.Context) ([]Upkeep, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+upkeepColumns+`
+		FROM vehicle_upkeep
+		ORDER BY driver_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Upkeep
+	for rows.Next() {
+		u, err := scanUpkeep(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *u)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) SetInspectionDue(ctx context.Context, driverID types.ID, due, now time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO vehicle_upkeep (driver_id, inspection_due, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (driver_id) DO UPDATE SET
+		    inspection_due         = EXCLUDED.inspection_due,
+		    inspection_reminded_at = NULL,
+		    updated_at             = EXCLUDED.updated_at`,
+		string(driverID), due, now,
+	)
+	return err
+}
+
+func (s *Store) ReportOdometer(ctx context.Context, driverID types.ID, km int, now time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO vehicle_upkeep (driver_id, odometer_km, odometer_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (driver_id) DO UPDATE SET
+		    odometer_km = EXCLUDED.odometer_km,
+		    odometer_at = EXCLUDED.odometer_at,
+		    updated_at  = EXCLUDED.updated_at
+		WHERE vehicle_upkeep.odometer_km <= EXCLUDED.odometer_km`,
+		string(driverID), km, now,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *Store) RecordService(ctx context.Context, driverID types.ID, km, intervalKm int, now time.Time) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO vehicle_upkeep (driver_id, odometer_km, odometer_at, service_interval_km,
+		                            last_service_km, last_service_at, updated_at)
+		VALUES ($1, $2, $4, $3, $2, $4, $4)
+		ON CONFLICT (driver_id) DO UPDATE SET
+		    last_service_km     = EXCLUDED.last_service_km,
+		    last_service_at     = EXCLUDED.last_service_at,
+		    service_interval_km = CASE WHEN EXCLUDED.service_interval_km > 0
+		                               THEN EXCLUDED.service_interval_km
+		                               ELSE vehicle_upkeep.service_interval_km END,
+		    odometer_at         = CASE WHEN EXCLUDED.odometer_km >= vehicle_upkeep.odometer_km
+		                               THEN EXCLUDED.odometer_at
+		                               ELSE vehicle_upkeep.odometer_at END,
+		    odometer_km         = GREATEST(vehicle_upkeep.odometer_km, EXCLUDED.odometer_km),
+		    updated_at          = EXCLUDED.updated_at`,
+		string(driverID), km, intervalKm, now,
+	)
+	return err
+}
+
+func (s *Store) MarkReminded(ctx context.Context, r Reminder, now time.Time) error {
+	var err error
+	switch r.Kind {
+	case ReminderInspectionDue, ReminderInspectionOverdue:
+		_, err = s.db.Exec(ctx, `
+			UPDATE vehicle_upkeep SET inspection_reminded_at = $2 WHERE driver_id = $1`,
+			string(r.DriverID), now)
+	case ReminderServiceDue:
+		_, err = s.db.Exec(ctx, `
+			UPDATE vehicle_upkeep SET service_reminded_km = $2 WHERE driver_id = $1`,
+			string(r.DriverID), r.ServiceDueKm)
+	case ReminderOdometer:
+		_, err = s.db.Exec(ctx, `
+			UPDATE vehicle_upkeep SET odometer_reminded_at = $2 WHERE driver_id = $1`,
+			string(r.DriverID), now)
+	}
+	return err
+}
+
+func (s *Store) OverdueInspections(ctx context.Context, ids []types.ID, today time.Time) ([]types.ID, error) {
+	strIDs := make([]string, len(ids))
+	for i, id := range ids {
+		strIDs[i] = string(id)
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT driver_id
+		FROM vehicle_upkeep
+		WHERE driver_id = ANY($1) AND inspection_due < $2`,
+		strIDs, today,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []types.ID
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, types.ID(id))
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) CreateVehicle(ctx context.Context, v *FleetVehicle) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO fleet_vehicles (id, owner_id, plate, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		string(v.ID), string(v.OwnerID), v.Plate, v.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrPlateTaken
+	}
+	return err
+}
+
+func (s *Store) GetVehicle(ctx context.Context, id types.ID) (*FleetVehicle, error) {
+	var v FleetVehicle
+	err := s.db.QueryRow(ctx, `
+		SELECT id, owner_id, plate, created_at FROM fleet_vehicles WHERE id = $1`, string(id),
+	).Scan(&v.ID, &v.OwnerID, &v.Plate, &v.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrVehicleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (s *Store) ListVehicles(ctx context.Context, ownerID types.ID) ([]FleetVehicle, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, owner_id, plate, created_at
+		FROM fleet_vehicles
+		WHERE owner_id = $1
+		ORDER BY plate`, string(ownerID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []FleetVehicle{}
+	for rows.Next() {
+		var v FleetVehicle
+		if err := rows.Scan(&v.ID, &v.OwnerID, &v.Plate, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) VehicleAssignments(ctx context.Context, vehicleID types.ID, now time.Time) ([]Assignment, error) {
+	return s.listAssignments(ctx, "vehicle_id", vehicleID, now)
+}
+
+func (s *Store) DriverAssignments(ctx context.Context, driverID types.ID, now time.Time) ([]Assignment, error) {
+	return s.listAssignments(ctx, "driver_id", driverID, now)
+}
+
+// listAssignments lists the shifts whose column, vehicle_id or driver_id,
+// is id and that have not ended at now.
+func (s *Store) listAssignments(ctx context.Context, column string, id types.ID, now time.Time) ([]Assignment, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, vehicle_id, driver_id, starts_at, ends_at, created_at
+		FROM vehicle_assignments
+		WHERE `+column+` = $1 AND ends_at > $2
+		ORDER BY starts_at, id`, string(id), now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Assignment{}
+	for rows.Next() {
+		var a Assignment
+		if err := rows.Scan(&a.ID, &a.VehicleID, &a.DriverID, &a.StartsAt, &a.EndsAt, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// CreateAssignment locks the vehicle and the driver's shifts so two
+// overlapping shifts cannot be booked at once.
+func (s *Store) CreateAssignment(ctx context.Context, a *Assignment) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM fleet_vehicles WHERE id = $1 FOR UPDATE`, string(a.VehicleID)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('vehicle_assignments:' || $1))`, string(a.DriverID)); err != nil {
+		return err
+	}
+	var overlaps bool
+	if err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+		    SELECT 1 FROM vehicle_assignments
+		    WHERE (vehicle_id = $1 OR driver_id = $2) AND starts_at < $4 AND ends_at > $3
+		)`, string(a.VehicleID), string(a.DriverID), a.StartsAt, a.EndsAt,
+	).Scan(&overlaps); err != nil {
+		return err
+	}
+	if overlaps {
+		return ErrShiftConflict
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO vehicle_assignments (id, vehicle_id, driver_id, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		string(a.ID), string(a.VehicleID), string(a.DriverID), a.StartsAt, a.EndsAt, a.CreatedAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) EndAssignment(ctx context.Context, vehicleID, id types.ID, now time.Time) error {
+	tag, err := s.db.Exec(ctx, `
+		DELETE FROM vehicle_assignments WHERE id = $1 AND vehicle_id = $2 AND starts_at >= $3`,
+		string(id), string(vehicleID), now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	tag, err = s.db.Exec(ctx, `
+		UPDATE vehicle_assignments SET ends_at = $3 WHERE id = $1 AND vehicle_id = $2 AND ends_at > $3`,
+		string(id), string(vehicleID), now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrShiftNotFound
+	}
+	return nil
+}
+
+func (s *Store) CurrentDriver(ctx context.Context, vehicleID types.ID, now time.Time) (types.ID, bool, error) {
+	var fleet bool
+	var driverID *string
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM fleet_vehicles WHERE id = $1),
+		       (SELECT driver_id FROM vehicle_assignments
+		        WHERE vehicle_id = $1 AND starts_at <= $2 AND ends_at > $2)`,
+		string(vehicleID), now,
+	).Scan(&fleet, &driverID)
+	if err != nil || driverID == nil {
+		return "", fleet, err
+	}
+	return types.ID(*driverID), fleet, nil
+}
+
+// Utilization counts a trip toward the vehicle whose shift its driver was on
+// when it started.
+func (s *Store) Utilization(ctx context.Context, ownerID types.ID, from, to time.Time) ([]Utilization, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT v.id, v.plate,
+		       COALESCE(sh.assigned_secs, 0), COALESCE(sh.drivers, 0),
+		       COALESCE(tr.trips, 0), COALESCE(tr.trip_secs, 0)
+		FROM fleet_vehicles v
+		LEFT JOIN (
+		    SELECT vehicle_id,
+		           SUM(EXTRACT(EPOCH FROM LEAST(ends_at, $3) - GREATEST(starts_at, $2)))::BIGINT AS assigned_secs,
+		           COUNT(DISTINCT driver_id) AS drivers
+		    FROM vehicle_assignments
+		    WHERE starts_at < $3 AND ends_at > $2
+		    GROUP BY vehicle_id
+		) sh ON sh.vehicle_id = v.id
+		LEFT JOIN (
+		    SELECT a.vehicle_id, COUNT(*) AS trips,
+		           SUM(EXTRACT(EPOCH FROM o.completed_at - o.started_at))::BIGINT AS trip_secs
+		    FROM orders o
+		    JOIN vehicle_assignments a
+		      ON a.driver_id = o.driver_id AND o.started_at >= a.starts_at AND o.started_at < a.ends_at
+		    WHERE o.status = 'complete' AND o.started_at >= $2 AND o.started_at < $3
+		    GROUP BY a.vehicle_id
+		) tr ON tr.vehicle_id = v.id
+		WHERE v.owner_id = $1
+		ORDER BY v.plate`,
+		string(ownerID), from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Utilization{}
+	for rows.Next() {
+		var u Utilization
+		var assignedSecs, tripSecs int64
+		if err := rows.Scan(&u.VehicleID, &u.Plate, &assignedSecs, &u.Drivers, &u.Trips, &tripSecs); err != nil {
+			return nil, err
+		}
+		u.Assigned = time.Duration(assignedSecs) * time.Second
+		u.TripTime = time.Duration(tripSecs) * time.Second
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,168 @@
+// README: Application-layer PII encryption — AES-GCM keyring with rotation and HMAC blind indexes for lookups.
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ark/internal/config"
+)
+
+// prefix marks an encrypted value: "enc:v1:<key id>:<base64(nonce|ciphertext)>".
+// Values without it are legacy plaintext and are returned as-is by Decrypt.
+const prefix = "enc:v1:"
+
+var (
+	ErrUnknownKey = errors.New("pii: unknown encryption key")
+	ErrMalformed  = errors.New("pii: malformed ciphertext")
+)
+
+// Keyring encrypts with the active key and decrypts with any known key, so keys
+// can be rotated by adding a new active key and re-encrypting in the background.
+// A nil *Keyring stores values in plaintext (dev mode).
+type Keyring struct {
+	aeads    map[string]cipher.AEAD
+	active   string
+	indexKey []byte
+}
+
+// NewKeyring builds a keyring from 32-byte AES keys keyed by ID.
+func NewKeyring(keys map[string][]byte, active string, indexKey []byte) (*Keyring, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, active)
+	}
+	if len(indexKey) < 32 {
+		return nil, errors.New("pii: blind index key must be at least 32 bytes")
+	}
+	k := &Keyring{aeads: make(map[string]cipher.AEAD, len(keys)), active: active, indexKey: indexKey}
+	for id, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("pii: key %q must be 32 bytes, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.aeads[id] = aead
+	}
+	return k, nil
+}
+
+// NewKeyringFromConfig parses cfg and returns nil when no keys are configured.
+func NewKeyringFromConfig(cfg config.PIIConfig) (*Keyring, error) {
+	if cfg.Keys == "" {
+		return nil, nil
+	}
+	keys, err := ParseKeys(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	indexKey, err := base64.StdEncoding.DecodeString(cfg.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("pii: decode blind index key: %w", err)
+	}
+	return NewKeyring(keys, cfg.ActiveKey, indexKey)
+}
+
+// ParseKeys parses "id1:base64key,id2:base64key".
+func ParseKeys(spec string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, b64, ok := strings.Cut(part, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("pii: key entry %q must be id:base64", part)
+		}
+		key, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("pii: decode key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("pii: no keys configured")
+	}
+	return keys, nil
+}
+
+// Encrypt seals plaintext with the active key. Empty strings stay empty.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if k == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	aead := k.aeads[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + k.active + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Legacy plaintext is returned unchanged.
+func (k *Keyring) Decrypt(stored string) (string, error) {
+	if !strings.HasPrefix(stored, prefix) {
+		return stored, nil
+	}
+	if k == nil {
+		return "", fmt.Errorf("%w: no keyring configured", ErrUnknownKey)
+	}
+	id, b64, ok := strings.Cut(strings.TrimPrefix(stored, prefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(b64)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	nonce, ct := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ct, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return string(plain), nil
+}
+
+// NeedsRotation reports whether stored is plaintext or sealed with a non-active key.
+func (k *Keyring) NeedsRotation(stored string) bool {
+	if k == nil || stored == "" {
+		return false
+	}
+	return !strings.HasPrefix(stored, prefix+k.active+":")
+}
+
+// BlindIndex returns a deterministic digest of value for equality lookups on
+// encrypted columns. It is keyed with HMAC-SHA256 when a keyring is configured
+// and falls back to plain SHA-256 in dev mode. Empty values index to "".
+func (k *Keyring) BlindIndex(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if k == nil {
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
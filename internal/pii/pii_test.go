@@ -0,0 +1,109 @@
+package pii
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"ark/internal/config"
+)
+
+func testKeyring(t *testing.T, active string) *Keyring {
+	t.Helper()
+	keys := map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	}
+	k, err := NewKeyring(keys, active, bytes.Repeat([]byte{9}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+func TestKeyring_RoundTrip(t *testing.T) {
+	k := testKeyring(t, "k1")
+	sealed, err := k.Encrypt("+886912345678")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(sealed, "enc:v1:k1:") || strings.Contains(sealed, "912345678") {
+		t.Fatalf("unexpected ciphertext %q", sealed)
+	}
+	again, _ := k.Encrypt("+886912345678")
+	if again == sealed {
+		t.Error("encryption must be randomized")
+	}
+	plain, err := k.Decrypt(sealed)
+	if err != nil || plain != "+886912345678" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old := testKeyring(t, "k1")
+	sealed, _ := old.Encrypt("token")
+
+	rotated := testKeyring(t, "k2")
+	if !rotated.NeedsRotation(sealed) {
+		t.Error("value sealed with k1 should need rotation when k2 is active")
+	}
+	if plain, err := rotated.Decrypt(sealed); err != nil || plain != "token" {
+		t.Fatalf("retired key must still decrypt: %q, %v", plain, err)
+	}
+	fresh, _ := rotated.Encrypt("token")
+	if rotated.NeedsRotation(fresh) {
+		t.Error("freshly sealed value should not need rotation")
+	}
+	if !rotated.NeedsRotation("legacy plaintext") {
+		t.Error("plaintext should need rotation")
+	}
+	if old.BlindIndex("token") != rotated.BlindIndex("token") {
+		t.Error("blind index must not depend on the active key")
+	}
+}
+
+func TestKeyring_Errors(t *testing.T) {
+	k := testKeyring(t, "k1")
+	if _, err := k.Decrypt("enc:v1:k9:AAAA"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key err = %v", err)
+	}
+	sealed, _ := k.Encrypt("x")
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if _, err := k.Decrypt(tampered); !errors.Is(err, ErrMalformed) {
+		t.Errorf("tampered err = %v", err)
+	}
+	if plain, err := k.Decrypt("0912345678"); err != nil || plain != "0912345678" {
+		t.Errorf("legacy plaintext = %q, %v", plain, err)
+	}
+	var nilRing *Keyring
+	if _, err := nilRing.Decrypt(sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("nil keyring decrypting ciphertext err = %v", err)
+	}
+	if v, _ := nilRing.Encrypt("plain"); v != "plain" {
+		t.Errorf("nil keyring Encrypt = %q", v)
+	}
+}
+
+func TestNewKeyringFromConfig(t *testing.T) {
+	if k, err := NewKeyringFromConfig(config.PIIConfig{}); k != nil || err != nil {
+		t.Fatalf("empty config = %v, %v; want nil, nil", k, err)
+	}
+	b64 := func(b byte) string { return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32)) }
+	cfg := config.PIIConfig{Keys: "old:" + b64(1) + ", new:" + b64(2), ActiveKey: "new", IndexKey: b64(3)}
+	k, err := NewKeyringFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewKeyringFromConfig: %v", err)
+	}
+	sealed, _ := k.Encrypt("v")
+	if !strings.HasPrefix(sealed, "enc:v1:new:") {
+		t.Errorf("sealed with wrong key: %q", sealed)
+	}
+
+	cfg.ActiveKey = "missing"
+	if _, err := NewKeyringFromConfig(cfg); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("missing active key err = %v", err)
+	}
+}
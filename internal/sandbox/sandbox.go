@@ -0,0 +1,24 @@
+// README: Request-scoped test-mode flag shared by every module that must fake side effects for sandbox traffic.
+package sandbox
+
+import "context"
+
+// Header carries the sandbox API key on requests from E2E test clients.
+const Header = "X-Ark-Sandbox"
+
+// DriverID is the simulated driver that plays every sandbox order.
+const DriverID = "sandbox-driver-bot"
+
+type contextKey struct{}
+
+// WithContext marks ctx as sandbox traffic. The flag survives
+// context.WithoutCancel, so async hooks started from a sandbox request keep it.
+func WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, true)
+}
+
+// Enabled reports whether ctx belongs to sandbox traffic.
+func Enabled(ctx context.Context) bool {
+	v, _ := ctx.Value(contextKey{}).(bool)
+	return v
+}
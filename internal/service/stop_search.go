@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"ark/internal/maps"
+	"ark/internal/maps/ranking"
+)
+
+// StopSearch finds places worth stopping at on the way from an origin to a
+// destination, ranked by detour, rating, opening hours and price.
+type StopSearch struct {
+	routeService  *maps.RouteService
+	placesService *maps.PlacesService
+	weights       ranking.Weights
+}
+
+// NewStopSearch creates a StopSearch ranking with ranking.DefaultWeights.
+func NewStopSearch(routeService *maps.RouteService, placesService *maps.PlacesService) *StopSearch {
+	return &StopSearch{routeService: routeService, placesService: placesService, weights: ranking.DefaultWeights}
+}
+
+// SearchAlong searches for category near points along the route and returns
+// up to limit candidates, best first. When the route cannot be computed it
+// searches near the origin instead. opts may be nil.
+func (s *StopSearch) SearchAlong(ctx context.Context, origin, destination, category string, opts *maps.SearchOptions, limit int) ([]ranking.Candidate, error) {
+	var places []maps.Place
+	waypoints, err := s.routeService.GetRouteWaypoints(ctx, origin, destination)
+	if err != nil {
+		log.Printf("Route Waypoints Error: %v", err)
+		places, err = s.placesService.SearchNearby(ctx, origin, category, opts)
+	} else {
+		places, err = s.placesService.SearchAlongRoute(ctx, waypoints, category, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("places search: %w", err)
+	}
+
+	var candidates []ranking.Candidate
+	for _, place := range places {
+		detour, err := s.routeService.GetDetourEstimate(ctx, origin, place.Address, destination)
+		if err != nil {
+			continue // Skip if calc fails
+		}
+		candidates = append(candidates, ranking.Candidate{Place: place, Detour: detour})
+	}
+	return ranking.Rank(candidates, ranking.Options{Weights: s.weights, Limit: limit}), nil
+}
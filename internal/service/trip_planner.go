@@ -9,6 +9,7 @@ import (
 
 	"ark/internal/ai"
 	"ark/internal/maps"
+	"ark/internal/maps/ranking"
 )
 
 // DefaultTrafficBuffer is the extra time added to ensure on-time arrival.
@@ -16,10 +17,10 @@ const DefaultTrafficBuffer = 10 * time.Minute
 
 // TripPlanner orchestrates the AI intent parsing and Google Maps routing.
 type TripPlanner struct {
-	aiProvider    *ai.GeminiProvider
-	routeService  *maps.RouteService
-	placesService *maps.PlacesService
-	loc           *time.Location
+	aiProvider   *ai.GeminiProvider
+	routeService *maps.RouteService
+	stopSearch   *StopSearch
+	loc          *time.Location
 }
 
 // NewTripPlanner creates a TripPlanner with initialized dependencies.
@@ -29,10 +30,10 @@ func NewTripPlanner(aiProvider *ai.GeminiProvider, routeService *maps.RouteServi
 		return nil, fmt.Errorf("failed to load Asia/Taipei location: %w", err)
 	}
 	return &TripPlanner{
-		aiProvider:    aiProvider,
-		routeService:  routeService,
-		placesService: placesService,
-		loc:           loc,
+		aiProvider:   aiProvider,
+		routeService: routeService,
+		stopSearch:   NewStopSearch(routeService, placesService),
+		loc:          loc,
 	}, nil
 }
 
@@ -121,7 +122,6 @@ func (p *TripPlanner) PlanTrip(ctx context.Context, userMessage string, userLoca
 		}
 
 		// Search Along Route (V3)
-		var places []maps.Place
 		dest := "Typical Destination" // Fallback
 		if intent.Destination != nil && *intent.Destination != "" {
 			dest = *intent.Destination
@@ -136,26 +136,6 @@ func (p *TripPlanner) PlanTrip(ctx context.Context, userMessage string, userLoca
 			searchOpts.ExcludeKeywords = intent.ExcludeKeywords
 		}
 
-		// 1. Get Route Waypoints (Origin -> Mid -> Dest)
-		waypoints, err := p.routeService.GetRouteWaypoints(ctx, origin, dest)
-		if err != nil {
-			log.Printf("Route Waypoints Error: %v", err)
-			// Fallback to simple search near origin if route fails
-			places, err = p.placesService.SearchNearby(ctx, origin, category, searchOpts)
-		} else {
-			// 2. Search at Waypoints
-			places, err = p.placesService.SearchAlongRoute(ctx, waypoints, category, searchOpts)
-		}
-
-		if err != nil {
-			log.Printf("Places Search Error: %v", err)
-			return fmt.Sprintf("抱歉，搜尋 %s 時發生錯誤，請稍後再試。", category), nil
-		}
-
-		if len(places) == 0 {
-			return fmt.Sprintf("抱歉，沿著去 %s 的路徑上找不到合適的 %s。", dest, category), nil
-		}
-
 		// Parse Target Time for Feasibility Check
 		var targetTime time.Time
 		if intent.ISOTime != nil {
@@ -178,72 +158,13 @@ func (p *TripPlanner) PlanTrip(ctx context.Context, userMessage string, userLoca
 		directDur, _, _ := p.routeService.GetTravelEstimate(ctx, origin, dest)
 		activityBuffer := 10 * time.Minute
 
-		// Recommendation Struct for Sorting
-		type Recommendation struct {
-			Place  maps.Place
-			Detour time.Duration
-		}
-		var recommendations []Recommendation
-
-		// Calculate Detours & Filter
-		for _, place := range places {
-			detour, err := p.routeService.GetDetourEstimate(ctx, origin, place.Address, dest)
-			if err != nil {
-				continue // Skip if calc fails
-			}
-
-			// Filter: Detour > 15 mins is too much
-			if detour.Minutes() > 15 {
-				continue
-			}
-
-			recommendations = append(recommendations, Recommendation{
-				Place:  place,
-				Detour: detour,
-			})
+		recommendations, err := p.stopSearch.SearchAlong(ctx, origin, dest, category, searchOpts, 3)
+		if err != nil {
+			log.Printf("Places Search Error: %v", err)
+			return fmt.Sprintf("抱歉，搜尋 %s 時發生錯誤，請稍後再試。", category), nil
 		}
-
 		if len(recommendations) == 0 {
-			return fmt.Sprintf("抱歉，雖然找到了 %s，但在順路 15 分鐘範圍內沒有合適的選擇。", category), nil
-		}
-
-		// Sort Logic: Detour Ascending, Tie-breaker Rating Descending
-		// Simple bubble sort or similar for small list (max ~3-5 from Places API?)
-		// Actually maps returns up to 20, we limited to top 3 in search?
-		// SearchAlongRoute returns broader list. Let's sort all valid ones.
-		// Go 1.21+ has slices.SortFunc, but let's stick to simple logic or sort.Slice
-		// We can't import "sort" easily without messing imports?
-		// Let's just use a simple bubble sort for this small list.
-		for i := 0; i < len(recommendations)-1; i++ {
-			for j := 0; j < len(recommendations)-i-1; j++ {
-				a := recommendations[j]
-				b := recommendations[j+1]
-
-				swap := false
-				detourDiff := a.Detour.Minutes() - b.Detour.Minutes()
-
-				// Primary: Detour Ascending
-				if detourDiff > 2.0 { // A is significantly longer -> Swap
-					swap = true
-				} else if detourDiff < -2.0 { // A is significantly shorter -> Keep
-					swap = false
-				} else {
-					// Tie-breaker: Rating Descending
-					if b.Place.Rating > a.Place.Rating {
-						swap = true
-					}
-				}
-
-				if swap {
-					recommendations[j], recommendations[j+1] = recommendations[j+1], recommendations[j]
-				}
-			}
-		}
-
-		// Pick Top 3
-		topN := 3
-		if len(recommendations) < topN {
-			topN = len(recommendations)
+			return fmt.Sprintf("抱歉，沿著去 %s 的路徑上找不到 %.0f 分鐘內順路的 %s。", dest, ranking.DefaultMaxDetour.Minutes(), category), nil
 		}
 
 		bestOption := recommendations[0]
@@ -264,8 +185,7 @@ func (p *TripPlanner) PlanTrip(ctx context.Context, userMessage string, userLoca
 
 		// Build Response
 		var suggestions []string
-		for i := 0; i < topN; i++ {
-			rec := recommendations[i]
+		for _, rec := range recommendations {
 			detourStr := fmt.Sprintf("繞路 %.0f 分鐘", rec.Detour.Minutes())
 			suggestions = append(suggestions, fmt.Sprintf("[%s] (⭐%.1f, %s)", rec.Place.Name, rec.Place.Rating, detourStr))
 		}
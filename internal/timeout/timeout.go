@@ -0,0 +1,58 @@
+// README: Per-call timeout policy for Google Maps and LLM providers; Postgres and Redis calls are bounded by their drivers (see infra).
+package timeout
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Policy is the longest one call to each dependency may take, so a slow
+// dependency cannot hold a request. A call made under a context that ends
+// sooner keeps the earlier deadline.
+type Policy struct {
+	Maps time.Duration
+	// LLM bounds a whole provider call, retries included.
+	LLM time.Duration
+}
+
+// Default is the policy in effect until Set is called.
+var Default = Policy{
+	Maps: 5 * time.Second,
+	LLM:  20 * time.Second,
+}
+
+var current atomic.Pointer[Policy]
+
+func init() {
+	p := Default
+	current.Store(&p)
+}
+
+// Set replaces the policy for subsequent calls. Zero durations leave that
+// dependency unbounded.
+func Set(p Policy) {
+	current.Store(&p)
+}
+
+// Current returns the policy in effect.
+func Current() Policy {
+	return *current.Load()
+}
+
+// Maps bounds ctx for one Google Maps call.
+func Maps(ctx context.Context) (context.Context, context.CancelFunc) {
+	return bound(ctx, Current().Maps)
+}
+
+// LLM bounds ctx for one LLM provider call.
+func LLM(ctx context.Context) (context.Context, context.CancelFunc) {
+	return bound(ctx, Current().LLM)
+}
+
+func bound(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,44 @@
+package timeout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMaps_BoundsCall(t *testing.T) {
+	defer Set(Default)
+	Set(Policy{Maps: time.Second})
+
+	ctx, cancel := Maps(context.Background())
+	defer cancel()
+	dl, ok := ctx.Deadline()
+	if !ok || time.Until(dl) > time.Second {
+		t.Fatalf("deadline = %v (set %t), want within 1s", dl, ok)
+	}
+}
+
+func TestMaps_KeepsEarlierDeadline(t *testing.T) {
+	defer Set(Default)
+	Set(Policy{Maps: time.Hour})
+
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	want, _ := parent.Deadline()
+	ctx, cancel := Maps(parent)
+	defer cancel()
+	if got, _ := ctx.Deadline(); !got.Equal(want) {
+		t.Fatalf("deadline = %v, want the caller's %v", got, want)
+	}
+}
+
+func TestLLM_ZeroIsUnbounded(t *testing.T) {
+	defer Set(Default)
+	Set(Policy{})
+
+	ctx, cancel := LLM(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("zero budget should leave the call unbounded")
+	}
+}
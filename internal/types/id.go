@@ -1,4 +1,119 @@
-// README: Common ID type for MVP to avoid external UUID dependency.
+// README: Common ID type — ULID generation and the ID format checks shared by handlers and the database.
 package types
 
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
 type ID string
+
+// crockford is the ULID alphabet: Crockford's base32, without I, L, O and U.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidLen is the length of an encoded ULID: 48 bits of milliseconds and 80
+// random bits in 26 characters.
+const ulidLen = 26
+
+// legacyIDLen is the length of the 32-hex random IDs issued before ULIDs.
+const legacyIDLen = 32
+
+// maxExternalIDLen bounds IDs issued outside Ark, such as Firebase UIDs.
+const maxExternalIDLen = 128
+
+// NewID returns a new ULID. ULIDs sort by creation time to the millisecond,
+// so new rows land at the end of an ID index instead of all over it.
+func NewID() ID {
+	return newULID(time.Now())
+}
+
+func newULID(t time.Time) ID {
+	var entropy [10]byte
+	_, _ = rand.Read(entropy[:])
+	hi := uint64(t.UnixMilli())<<16 | uint64(binary.BigEndian.Uint16(entropy[:2]))
+	lo := binary.BigEndian.Uint64(entropy[2:])
+
+	var out [ulidLen]byte
+	for i := ulidLen - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return ID(out[:])
+}
+
+// ValidID reports whether v is an ID Ark issues: a ULID or, for rows created
+// before ULIDs, 32 lowercase hex digits.
+func ValidID(v string) bool {
+	return IsULID(v) || isLegacyID(v)
+}
+
+// IsULID reports whether v is a canonical (upper-case) ULID.
+func IsULID(v string) bool {
+	if len(v) != ulidLen || v[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		if !isCrockford(v[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ULIDTime returns the creation time encoded in a ULID; ok is false for any
+// other ID.
+func ULIDTime(id ID) (t time.Time, ok bool) {
+	if !IsULID(string(id)) {
+		return time.Time{}, false
+	}
+	var ms int64
+	for i := 0; i < 10; i++ {
+		ms = ms<<5 | int64(crockfordValue(id[i]))
+	}
+	return time.UnixMilli(ms), true
+}
+
+// ValidExternalID reports whether v may be used as an ID issued outside Ark,
+// such as a Firebase UID or a calendar event ID: 1 to 128 ASCII letters,
+// digits, hyphens and underscores. Every ValidID is also a ValidExternalID.
+func ValidExternalID(v string) bool {
+	if len(v) == 0 || len(v) > maxExternalIDLen {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '-' || c == '_' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func isLegacyID(v string) bool {
+	if len(v) != legacyIDLen {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isCrockford(c byte) bool {
+	return crockfordValue(c) >= 0
+}
+
+func crockfordValue(c byte) int {
+	for i := 0; i < len(crockford); i++ {
+		if crockford[i] == c {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewID_SortsByTime(t *testing.T) {
+	at := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	a := newULID(at)
+	b := newULID(at.Add(time.Millisecond))
+	if !IsULID(string(a)) || !ValidID(string(a)) {
+		t.Fatalf("%q is not a valid ULID", a)
+	}
+	if a >= b {
+		t.Errorf("%q sorts after the later %q", a, b)
+	}
+	if got, ok := ULIDTime(a); !ok || !got.Equal(at) {
+		t.Errorf("ULIDTime(%q) = %v, %v; want %v", a, got, ok, at)
+	}
+	if NewID() == NewID() {
+		t.Error("two IDs in a row are equal")
+	}
+}
+
+func TestValidID(t *testing.T) {
+	for v, want := range map[string]bool{
+		"01JBQ9Z8Y2V4M6XK3T5R7N1P0A":       true,  // ULID
+		"3f2a9c0e1b7d4e6f8a0b1c2d3e4f5a6b": true,  // legacy hex
+		"01jbq9z8y2v4m6xk3t5r7n1p0a":       false, // not canonical
+		"01JBQ9Z8Y2V4M6XK3T5R7N1P0U":       false, // U is not in the alphabet
+		"81JBQ9Z8Y2V4M6XK3T5R7N1P0A":       false, // overflows 128 bits
+		"3F2A9C0E1B7D4E6F8A0B1C2D3E4F5A6B": false,
+		"o1":                               false,
+		"":                                 false,
+	} {
+		if got := ValidID(v); got != want {
+			t.Errorf("ValidID(%q) = %v, want %v", v, got, want)
+		}
+	}
+	if !ValidExternalID("Kx9_firebase-UID") || ValidExternalID("a/b") || ValidExternalID("") {
+		t.Error("ValidExternalID accepts or rejects the wrong IDs")
+	}
+}
@@ -1,7 +1,83 @@
 // README: Common money value object used across modules.
 package types
 
+import "errors"
+
+// DefaultCurrency is the platform's base currency. Driver earnings, ride
+// credits, the ledger and payouts are all kept in it.
+const DefaultCurrency = "TWD"
+
+// ErrCurrencyMismatch is returned by Money arithmetic on values in different currencies.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// currencyExponents maps each supported ISO 4217 code to its number of minor
+// units per major unit, as a power of ten.
+var currencyExponents = map[string]int{
+    "TWD": 2,
+    "USD": 2,
+    "EUR": 2,
+    "HKD": 2,
+    "SGD": 2,
+    "MYR": 2,
+    "THB": 2,
+    "JPY": 0,
+    "KRW": 0,
+}
+
+// ValidCurrency reports whether code is a supported ISO 4217 currency code.
+func ValidCurrency(code string) bool {
+    _, ok := currencyExponents[code]
+    return ok
+}
+
+// Money is an amount in minor units of Currency (cents for TWD, yen for JPY).
 type Money struct {
     Amount   int64
     Currency string
 }
+
+// Add returns m+o, or ErrCurrencyMismatch when the currencies differ.
+func (m Money) Add(o Money) (Money, error) {
+    if m.Currency != o.Currency {
+        return Money{}, ErrCurrencyMismatch
+    }
+    return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m-o, or ErrCurrencyMismatch when the currencies differ.
+func (m Money) Sub(o Money) (Money, error) {
+    if m.Currency != o.Currency {
+        return Money{}, ErrCurrencyMismatch
+    }
+    return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Cmp compares m and o: -1 if m < o, 0 if equal, +1 if m > o. It returns
+// ErrCurrencyMismatch when the currencies differ.
+func (m Money) Cmp(o Money) (int, error) {
+    if m.Currency != o.Currency {
+        return 0, ErrCurrencyMismatch
+    }
+    switch {
+    case m.Amount < o.Amount:
+        return -1, nil
+    case m.Amount > o.Amount:
+        return 1, nil
+    }
+    return 0, nil
+}
+
+// Major returns the amount in whole major units, truncating any fraction
+// (NT$150 for 15000 TWD, ¥150 for 150 JPY). Unknown currencies are treated
+// as having two decimals.
+func (m Money) Major() int64 {
+    exp, ok := currencyExponents[m.Currency]
+    if !ok {
+        exp = 2
+    }
+    div := int64(1)
+    for range exp {
+        div *= 10
+    }
+    return m.Amount / div
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a := Money{Amount: 15000, Currency: "TWD"}
+	b := Money{Amount: 2500, Currency: "TWD"}
+
+	if got, err := a.Add(b); err != nil || got != (Money{Amount: 17500, Currency: "TWD"}) {
+		t.Errorf("Add = %+v, %v", got, err)
+	}
+	if got, err := b.Sub(a); err != nil || got != (Money{Amount: -12500, Currency: "TWD"}) {
+		t.Errorf("Sub = %+v, %v", got, err)
+	}
+	if c, err := a.Cmp(b); err != nil || c != 1 {
+		t.Errorf("Cmp = %d, %v; want 1", c, err)
+	}
+	if c, err := a.Cmp(a); err != nil || c != 0 {
+		t.Errorf("Cmp self = %d, %v; want 0", c, err)
+	}
+}
+
+func TestMoney_RejectsMixedCurrency(t *testing.T) {
+	twd := Money{Amount: 15000, Currency: "TWD"}
+	usd := Money{Amount: 500, Currency: "USD"}
+
+	if _, err := twd.Add(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add: got %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := twd.Sub(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub: got %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := twd.Cmp(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp: got %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoney_Major(t *testing.T) {
+	cases := []struct {
+		m    Money
+		want int64
+	}{
+		{Money{Amount: 15099, Currency: "TWD"}, 150},
+		{Money{Amount: 1500, Currency: "JPY"}, 1500},
+		{Money{Amount: 250, Currency: "USD"}, 2},
+	}
+	for _, tc := range cases {
+		if got := tc.m.Major(); got != tc.want {
+			t.Errorf("%+v.Major() = %d, want %d", tc.m, got, tc.want)
+		}
+	}
+	if !ValidCurrency("JPY") || ValidCurrency("twd") || ValidCurrency("") {
+		t.Error("ValidCurrency")
+	}
+}
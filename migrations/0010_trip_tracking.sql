@@ -0,0 +1,18 @@
+-- README: Trip tracking grants — which friends may follow a passenger's live location during a trip.
+
+CREATE TABLE IF NOT EXISTS trip_tracking_grants (
+    id            BIGSERIAL PRIMARY KEY,
+    order_id      TEXT      NOT NULL,
+    passenger_id  TEXT      NOT NULL,
+    viewer_id     TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at    TIMESTAMP
+);
+
+-- At most one active grant per viewer and trip.
+CREATE UNIQUE INDEX IF NOT EXISTS uidx_trip_tracking_grants_active
+    ON trip_tracking_grants (order_id, viewer_id)
+    WHERE revoked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_trip_tracking_grants_passenger
+    ON trip_tracking_grants (passenger_id);
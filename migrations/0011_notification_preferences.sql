@@ -0,0 +1,19 @@
+-- README: Per-user notification preferences (categories, quiet hours, channels).
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id          VARCHAR(64) PRIMARY KEY,
+    order_updates    BOOLEAN     NOT NULL DEFAULT TRUE,
+    promos           BOOLEAN     NOT NULL DEFAULT FALSE,
+    reminders        BOOLEAN     NOT NULL DEFAULT TRUE,
+    quiet_start_min  SMALLINT,                 -- minutes after local midnight; NULL = no quiet hours
+    quiet_end_min    SMALLINT,
+    timezone         VARCHAR(64) NOT NULL DEFAULT 'Asia/Taipei',
+    push_enabled     BOOLEAN     NOT NULL DEFAULT TRUE,
+    sms_enabled      BOOLEAN     NOT NULL DEFAULT FALSE,
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_quiet_hours CHECK (
+        (quiet_start_min IS NULL AND quiet_end_min IS NULL) OR
+        (quiet_start_min BETWEEN 0 AND 1439 AND quiet_end_min BETWEEN 0 AND 1439)
+    )
+);
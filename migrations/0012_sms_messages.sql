@@ -0,0 +1,15 @@
+-- README: SMS fallback ledger — one row per send attempt, used for cost tracking and monthly caps.
+
+CREATE TABLE IF NOT EXISTS sms_messages (
+    id                  BIGSERIAL   PRIMARY KEY,
+    user_id             VARCHAR(64) NOT NULL,
+    provider            VARCHAR(32) NOT NULL,            -- twilio, every8d
+    provider_message_id TEXT        NOT NULL DEFAULT '',
+    event               VARCHAR(64) NOT NULL DEFAULT '', -- e.g. driver_arrived
+    segments            INT         NOT NULL DEFAULT 0,
+    cost                BIGINT      NOT NULL DEFAULT 0,  -- TWD minor units; 0 for failed sends
+    error               TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sms_messages_user_created ON sms_messages (user_id, created_at);
@@ -0,0 +1,17 @@
+-- README: Email receipts and monthly summaries — locale/opt-in preferences and an idempotent send log.
+
+ALTER TABLE notification_preferences
+    ADD COLUMN IF NOT EXISTS locale          VARCHAR(8) NOT NULL DEFAULT 'zh-TW',
+    ADD COLUMN IF NOT EXISTS monthly_summary BOOLEAN    NOT NULL DEFAULT FALSE;
+
+-- One row per email sent; (kind, user_id, ref) makes retries and restarts safe.
+-- ref is the order ID for receipts and the month (YYYY-MM) for summaries.
+CREATE TABLE IF NOT EXISTS email_log (
+    id       BIGSERIAL   PRIMARY KEY,
+    kind     VARCHAR(32) NOT NULL, -- receipt, monthly_summary
+    user_id  VARCHAR(64) NOT NULL,
+    ref      TEXT        NOT NULL,
+    sent_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_email_log UNIQUE (kind, user_id, ref)
+);
@@ -0,0 +1,23 @@
+-- README: Account deletion requests (grace period before PII purge) and the append-only audit log.
+
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id       TEXT        PRIMARY KEY,
+    requested_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    purge_after   TIMESTAMPTZ NOT NULL,
+    purged_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_deletions_due
+    ON account_deletions (purge_after)
+    WHERE purged_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          BIGSERIAL   PRIMARY KEY,
+    actor_id    TEXT        NOT NULL, -- user ID, or 'system' for background jobs
+    action      TEXT        NOT NULL, -- e.g. account.deletion_requested, account.purged
+    subject_id  TEXT        NOT NULL,
+    detail      JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_subject ON audit_log (subject_id, created_at);
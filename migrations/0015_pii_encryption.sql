@@ -0,0 +1,11 @@
+-- README: Blind-index columns for application-encrypted PII (users.phone, user_fcm_tokens.fcm_token).
+
+-- phone and fcm_token now hold AES-GCM ciphertext ("enc:v1:..."); equality
+-- lookups go through these HMAC digests instead. Existing rows are backfilled
+-- and encrypted by cmd/pii-rotate.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_users_phone_hash ON users (phone_hash);
+
+ALTER TABLE user_fcm_tokens ADD COLUMN IF NOT EXISTS token_hash TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS uidx_user_fcm_tokens_user_hash ON user_fcm_tokens (user_id, token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_fcm_tokens_hash ON user_fcm_tokens (token_hash);
@@ -0,0 +1,5 @@
+-- README: Sandbox (test-mode) flag on orders; sandbox orders are played by the driver bot and hidden from real drivers.
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS sandbox BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_orders_sandbox ON orders (created_at) WHERE sandbox;
@@ -0,0 +1,5 @@
+-- README: Index for the driver home screen, which lists a driver's orders by status.
+
+CREATE INDEX IF NOT EXISTS idx_orders_driver_status
+    ON orders (driver_id, status)
+    WHERE driver_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- README: Passenger notes and requirement flags on orders, and the matching capability flags on drivers.
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS requirements TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS capabilities TEXT[] NOT NULL DEFAULT '{}';
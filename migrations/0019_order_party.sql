@@ -0,0 +1,4 @@
+-- README: Party size and pet flag on orders; their vehicle needs are also stored as requirements.
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS passenger_count SMALLINT NOT NULL DEFAULT 1;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS has_pet BOOLEAN NOT NULL DEFAULT false;
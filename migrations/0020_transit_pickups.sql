@@ -0,0 +1,10 @@
+-- README: Flight/train numbers on airport and HSR pickups, with the last arrival estimate from the status provider.
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS transit_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS transit_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS transit_eta TIMESTAMP;
+
+-- The transit watcher scans upcoming transit pickups every few minutes.
+CREATE INDEX IF NOT EXISTS idx_orders_transit_upcoming
+    ON orders (scheduled_at)
+    WHERE transit_number <> '' AND status IN ('scheduled', 'assigned');
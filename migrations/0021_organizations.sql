@@ -0,0 +1,45 @@
+-- README: Corporate accounts — organizations, their members and ride policy, business rides on orders, and monthly invoices.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id               VARCHAR(64)  PRIMARY KEY,
+    name             TEXT         NOT NULL,
+    billing_email    TEXT         NOT NULL,
+    created_by       VARCHAR(64)  NOT NULL,
+    -- Allowed ride hours as minutes after midnight (Asia/Taipei); equal values allow any time.
+    allowed_from_min INT          NOT NULL DEFAULT 0,
+    allowed_to_min   INT          NOT NULL DEFAULT 0,
+    -- Monthly spend across all members in TWD minor units; 0 means no cap.
+    monthly_budget   BIGINT       NOT NULL DEFAULT 0,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id     VARCHAR(64) NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id    VARCHAR(64) NOT NULL,
+    role       VARCHAR(16) NOT NULL DEFAULT 'member', -- admin, member
+    status     VARCHAR(16) NOT NULL DEFAULT 'invited', -- invited, active
+    invited_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    joined_at  TIMESTAMPTZ,
+
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_members_user ON org_members (user_id);
+
+-- Business rides are billed to the organization instead of the passenger.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS org_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_orders_org_created ON orders (org_id, created_at) WHERE org_id IS NOT NULL;
+
+-- One consolidated invoice per organization and month (first day, Asia/Taipei).
+CREATE TABLE IF NOT EXISTS org_invoices (
+    org_id     VARCHAR(64) NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    month      DATE        NOT NULL,
+    trips      INT         NOT NULL,
+    total      BIGINT      NOT NULL,
+    currency   VARCHAR(8)  NOT NULL DEFAULT 'TWD',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at    TIMESTAMPTZ,
+
+    PRIMARY KEY (org_id, month)
+);
@@ -0,0 +1,44 @@
+-- README: Referral codes, referrals with fraud-guard keys, the ride-credit ledger, and credits applied to each order.
+
+CREATE TABLE IF NOT EXISTS referral_codes (
+    user_id    VARCHAR(64) PRIMARY KEY,
+    code       VARCHAR(16) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One referral per referee. device_id and identity_hash (a digest of the
+-- referee's phone) may each back only one referral, so reinstalling or
+-- re-registering the same phone cannot collect the reward twice.
+CREATE TABLE IF NOT EXISTS referrals (
+    referee_id    VARCHAR(64)  PRIMARY KEY,
+    referrer_id   VARCHAR(64)  NOT NULL,
+    code          VARCHAR(16)  NOT NULL,
+    device_id     VARCHAR(128) NOT NULL,
+    identity_hash TEXT         NOT NULL,
+    status        VARCHAR(16)  NOT NULL DEFAULT 'pending', -- pending, rewarded, rejected
+    reason        TEXT         NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    rewarded_at   TIMESTAMPTZ,
+
+    CONSTRAINT uq_referrals_device UNIQUE (device_id),
+    CONSTRAINT uq_referrals_identity UNIQUE (identity_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals (referrer_id, status);
+
+-- Credits are a ledger: grants are positive, redemptions negative, and the
+-- balance is the sum. (user_id, kind, ref) makes grants and redemptions idempotent.
+CREATE TABLE IF NOT EXISTS credit_ledger (
+    id         BIGSERIAL   PRIMARY KEY,
+    user_id    VARCHAR(64) NOT NULL,
+    amount     BIGINT      NOT NULL, -- TWD minor units
+    kind       VARCHAR(32) NOT NULL, -- referral_referrer, referral_referee, ride
+    ref        TEXT        NOT NULL, -- referee ID for grants, order ID for redemptions
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_credit_ledger UNIQUE (user_id, kind, ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_ledger_user ON credit_ledger (user_id, created_at);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS credits_applied BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,53 @@
+-- README: Driver earnings ledger and quest campaigns (ops-defined trip targets with a bonus payout).
+
+-- Earnings are a ledger: each line is money owed to the driver. (driver_id,
+-- kind, ref) makes every credit idempotent, e.g. one payout per quest.
+CREATE TABLE IF NOT EXISTS driver_earnings (
+    id         BIGSERIAL   PRIMARY KEY,
+    driver_id  VARCHAR(64) NOT NULL,
+    amount     BIGINT      NOT NULL, -- TWD minor units
+    kind       VARCHAR(32) NOT NULL, -- quest
+    ref        TEXT        NOT NULL, -- campaign ID for quests
+    note       TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_driver_earnings UNIQUE (driver_id, kind, ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_earnings_driver ON driver_earnings (driver_id, created_at);
+
+CREATE TABLE IF NOT EXISTS campaigns (
+    id           VARCHAR(64) PRIMARY KEY,
+    name         TEXT        NOT NULL,
+    description  TEXT        NOT NULL DEFAULT '',
+    target_trips INT         NOT NULL CHECK (target_trips > 0),
+    reward       BIGINT      NOT NULL CHECK (reward > 0), -- TWD minor units
+    starts_at    TIMESTAMPTZ NOT NULL,
+    ends_at      TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_campaigns_window CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_window ON campaigns (starts_at, ends_at);
+
+-- quest_trips records which orders counted, so a replayed completion event
+-- never counts twice.
+CREATE TABLE IF NOT EXISTS quest_trips (
+    campaign_id VARCHAR(64) NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    order_id    VARCHAR(64) NOT NULL,
+    driver_id   VARCHAR(64) NOT NULL,
+    counted_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (campaign_id, order_id)
+);
+
+CREATE TABLE IF NOT EXISTS quest_progress (
+    campaign_id VARCHAR(64) NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    driver_id   VARCHAR(64) NOT NULL,
+    trips       INT         NOT NULL DEFAULT 0,
+    paid_at     TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (campaign_id, driver_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quest_progress_driver ON quest_progress (driver_id);
@@ -0,0 +1,28 @@
+-- README: Commission rules by driver tier, tenure and campaign; driver tiers; commission recorded on each earnings entry.
+
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS tier VARCHAR(16) NOT NULL DEFAULT 'standard';
+
+-- A trip resolves to the highest-priority rule in force at completion whose
+-- scopes all match the driver (NULL scope = any). Without a match the
+-- configured default rate applies.
+CREATE TABLE IF NOT EXISTS commission_rules (
+    id              VARCHAR(64) PRIMARY KEY,
+    name            TEXT        NOT NULL,
+    tier            VARCHAR(16),
+    min_tenure_days INT         CHECK (min_tenure_days >= 0),
+    campaign_id     VARCHAR(64) REFERENCES campaigns(id),
+    rate_bps        INT         NOT NULL CHECK (rate_bps BETWEEN 0 AND 10000), -- 2000 = 20%
+    priority        INT         NOT NULL DEFAULT 0,
+    starts_at       TIMESTAMPTZ NOT NULL,
+    ends_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_commission_rules_window ON commission_rules (starts_at, ends_at);
+
+-- Each earnings entry keeps the split it was paid under, so past payouts stay
+-- auditable after rules change. For non-trip entries gross = amount.
+ALTER TABLE driver_earnings ADD COLUMN IF NOT EXISTS gross BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE driver_earnings ADD COLUMN IF NOT EXISTS commission BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE driver_earnings ADD COLUMN IF NOT EXISTS commission_bps INT NOT NULL DEFAULT 0;
+ALTER TABLE driver_earnings ADD COLUMN IF NOT EXISTS commission_rule VARCHAR(64);
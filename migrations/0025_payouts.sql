@@ -0,0 +1,34 @@
+-- README: Daily driver settlement: payout batches, per-driver payouts, and settled markers on earnings entries.
+
+-- One batch per Taipei calendar day. A batch is created pending, then
+-- submitted to the bank/provider; failed submissions are retried on re-run.
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id           VARCHAR(64) PRIMARY KEY,
+    settle_date  DATE        NOT NULL UNIQUE,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending', -- pending, submitted, failed
+    total        BIGINT      NOT NULL DEFAULT 0,         -- TWD minor units
+    payout_count INT         NOT NULL DEFAULT 0,
+    file_ref     TEXT        NOT NULL DEFAULT '',
+    error        TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL,
+    submitted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS payouts (
+    id          VARCHAR(64) PRIMARY KEY,
+    batch_id    VARCHAR(64) NOT NULL REFERENCES payout_batches(id),
+    driver_id   VARCHAR(64) NOT NULL,
+    settle_date DATE        NOT NULL,
+    amount      BIGINT      NOT NULL CHECK (amount > 0),
+    entry_count INT         NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+
+    CONSTRAINT uq_payouts_driver_date UNIQUE (driver_id, settle_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_driver ON payouts (driver_id, settle_date DESC);
+
+ALTER TABLE driver_earnings ADD COLUMN IF NOT EXISTS payout_id VARCHAR(64) REFERENCES payouts(id);
+ALTER TABLE driver_earnings ADD COLUMN IF NOT EXISTS settled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_driver_earnings_unsettled ON driver_earnings (created_at) WHERE payout_id IS NULL;
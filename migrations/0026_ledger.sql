@@ -0,0 +1,82 @@
+-- README: Double-entry ledger: balanced, append-only transactions recording every money movement between accounts.
+
+-- A transaction is one money movement, identified by (kind, ref) so that
+-- recording the same movement twice is a no-op.
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id         VARCHAR(64)  PRIMARY KEY,
+    kind       VARCHAR(32)  NOT NULL, -- ride, incentive, credit_grant, payout, opening_balance
+    ref        VARCHAR(200) NOT NULL,
+    memo       TEXT         NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ  NOT NULL,
+
+    CONSTRAINT uq_ledger_transactions_kind_ref UNIQUE (kind, ref)
+);
+
+-- Postings are signed: debits positive, credits negative, TWD minor units.
+-- Accounts are "<type>:<owner>", e.g. driver:<uid>, platform:commission.
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id         BIGSERIAL    PRIMARY KEY,
+    txn_id     VARCHAR(64)  NOT NULL REFERENCES ledger_transactions(id),
+    account    VARCHAR(100) NOT NULL,
+    amount     BIGINT       NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account ON ledger_postings (account, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_txn ON ledger_postings (txn_id);
+
+-- Every transaction's postings must sum to zero when its database transaction commits.
+CREATE OR REPLACE FUNCTION ledger_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM ledger_postings WHERE txn_id = NEW.txn_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger transaction % is unbalanced', NEW.txn_id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_postings_balanced ON ledger_postings;
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
+
+-- The ledger is append-only; corrections are new reversing transactions.
+CREATE OR REPLACE FUNCTION ledger_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger rows cannot be changed or deleted';
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_transactions_append_only ON ledger_transactions;
+CREATE TRIGGER ledger_transactions_append_only
+    BEFORE UPDATE OR DELETE ON ledger_transactions
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+DROP TRIGGER IF EXISTS ledger_postings_append_only ON ledger_postings;
+CREATE TRIGGER ledger_postings_append_only
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+-- Opening balances: what the platform already owed drivers and credit holders
+-- before the ledger existed, funded from platform:opening.
+WITH owed AS (
+    SELECT 'driver:' || e.driver_id AS account,
+           SUM(e.amount) - COALESCE((
+               SELECT SUM(p.amount) FROM payouts p JOIN payout_batches b ON b.id = p.batch_id
+               WHERE p.driver_id = e.driver_id AND b.status = 'submitted'), 0) AS amount
+    FROM driver_earnings e GROUP BY e.driver_id
+    UNION ALL
+    SELECT 'credits:' || user_id, SUM(amount) FROM credit_ledger GROUP BY user_id
+), opening AS (
+    SELECT account, amount FROM owed WHERE amount <> 0
+), txns AS (
+    INSERT INTO ledger_transactions (id, kind, ref, memo, created_at)
+    SELECT md5('opening:' || account), 'opening_balance', account, 'balance before the ledger', NOW() FROM opening
+    ON CONFLICT (kind, ref) DO NOTHING
+    RETURNING id, ref
+)
+INSERT INTO ledger_postings (txn_id, account, amount, created_at)
+SELECT t.id, o.account, -o.amount, NOW() FROM txns t JOIN opening o ON o.account = t.ref
+UNION ALL
+SELECT t.id, 'platform:opening', o.amount, NOW() FROM txns t JOIN opening o ON o.account = t.ref;
@@ -0,0 +1,12 @@
+-- README: Mid-trip dropoff changes: the passenger's proposed dropoff awaiting driver acknowledgment and the original dropoff.
+
+-- A passenger may propose a new dropoff while driving. The proposal and its
+-- re-estimated fare wait here until the driver accepts or rejects it; on
+-- accept the order's dropoff and estimated fee are replaced and the first
+-- dropoff is kept in original_dropoff_*.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS original_dropoff_lat DOUBLE PRECISION;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS original_dropoff_lng DOUBLE PRECISION;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS pending_dropoff_lat  DOUBLE PRECISION;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS pending_dropoff_lng  DOUBLE PRECISION;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS pending_fee          BIGINT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS dropoff_requested_at TIMESTAMPTZ;
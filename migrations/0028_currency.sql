@@ -0,0 +1,7 @@
+-- README: Currency on orders and pricing rates, so fares are no longer implicitly TWD.
+
+-- Every fare on an order (estimate, actual, pending dropoff re-estimate) is
+-- in the order's currency, taken from the ride type's rate when it is created.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency VARCHAR(8) NOT NULL DEFAULT 'TWD';
+
+ALTER TABLE pricing_rates ALTER COLUMN currency SET DEFAULT 'TWD';
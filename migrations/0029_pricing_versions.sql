@@ -0,0 +1,26 @@
+-- README: Versioned pricing rates and the rule version each order was priced under.
+
+-- A ride type's rate is never edited in place: a fare change inserts the next
+-- version with a later effective_from, and quotes use the highest version
+-- already in effect.
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS version        INT         NOT NULL DEFAULT 1;
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS effective_from TIMESTAMPTZ NOT NULL DEFAULT '-infinity';
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TABLE pricing_rates DROP CONSTRAINT IF EXISTS pricing_rates_pkey;
+ALTER TABLE pricing_rates ADD PRIMARY KEY (ride_type, version);
+
+CREATE OR REPLACE FUNCTION pricing_rates_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'pricing_rates rows are immutable; publish a new version instead';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS pricing_rates_no_update ON pricing_rates;
+CREATE TRIGGER pricing_rates_no_update
+    BEFORE UPDATE OR DELETE ON pricing_rates
+    FOR EACH ROW EXECUTE FUNCTION pricing_rates_immutable();
+
+-- 0 means the order was priced without a versioned rule (fallback fare).
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS pricing_version         INT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS pending_pricing_version INT;
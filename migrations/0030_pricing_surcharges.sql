@@ -0,0 +1,6 @@
+-- README: Night and weather surcharges on pricing rates, in basis points of the base and distance fare.
+
+-- Existing versions keep 0 and price exactly as before; a surcharge takes
+-- effect by publishing a new version that sets it.
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS night_surcharge_bps   INT NOT NULL DEFAULT 0 CHECK (night_surcharge_bps >= 0);
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS weather_surcharge_bps INT NOT NULL DEFAULT 0 CHECK (weather_surcharge_bps >= 0);
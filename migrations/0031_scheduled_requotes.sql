@@ -0,0 +1,11 @@
+-- README: Peak-hour surcharge on pricing rates and pending re-quotes for scheduled orders.
+
+-- Weekday rush-hour surcharge in basis points of the base and distance fare;
+-- existing versions keep 0 and price exactly as before.
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS peak_surcharge_bps INT NOT NULL DEFAULT 0 CHECK (peak_surcharge_bps >= 0);
+
+-- A higher fare proposed after the pricing rules changed, awaiting the
+-- passenger's consent; all NULL when nothing is pending.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS requote_fee             BIGINT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS requote_pricing_version INT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS requoted_at             TIMESTAMPTZ;
@@ -0,0 +1,22 @@
+-- README: Trip distance audits: each trip's GPS trace against the Maps route, and the staff review queue for padded routes.
+
+-- One row per trip, opened when the passenger boards and closed when the
+-- driver ends the trip. The audit job measures the driver's location_snapshots
+-- in between against the Maps driving distance and flags traces at least 30%
+-- longer; staff then confirm or dismiss the flag.
+CREATE TABLE IF NOT EXISTS trip_distance_audits (
+    order_id     TEXT             PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+    driver_id    TEXT             NOT NULL,
+    status       TEXT             NOT NULL, -- recording, pending, clear, flagged, unverifiable, confirmed, dismissed
+    started_at   TIMESTAMPTZ      NOT NULL,
+    completed_at TIMESTAMPTZ,
+    trace_km     DOUBLE PRECISION,
+    estimated_km DOUBLE PRECISION,
+    trace_points INT,
+    audited_at   TIMESTAMPTZ,
+    review_note  TEXT,
+    reviewed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_trip_distance_audits_status
+    ON trip_distance_audits (status, completed_at);
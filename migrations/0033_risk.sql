@@ -0,0 +1,46 @@
+-- README: Fraud signals and the review cases they open, and payout holds on earnings under review.
+
+-- Each signal is one observation against a user, and against an order when it
+-- concerns a trip. Scores are sums of signal weights: a user's over the last
+-- 30 days, an order's over all its signals.
+CREATE TABLE IF NOT EXISTS risk_signals (
+    id         BIGSERIAL   PRIMARY KEY,
+    kind       VARCHAR(32) NOT NULL, -- speed_jump, shared_device, repeat_pair, promo_velocity
+    user_id    VARCHAR(64) NOT NULL,
+    order_id   TEXT,
+    weight     INT         NOT NULL,
+    detail     TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_signals_user ON risk_signals (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_risk_signals_order ON risk_signals (order_id) WHERE order_id IS NOT NULL;
+
+-- One case per user or order whose score reached the review threshold. Staff
+-- close it as cleared or confirmed; a higher score later reopens it.
+CREATE TABLE IF NOT EXISTS risk_cases (
+    subject_type VARCHAR(16) NOT NULL, -- user, order
+    subject_id   TEXT        NOT NULL,
+    score        INT         NOT NULL,
+    status       VARCHAR(16) NOT NULL, -- open, cleared, confirmed
+    opened_at    TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    resolved_at  TIMESTAMPTZ,
+    note         TEXT        NOT NULL DEFAULT '',
+
+    PRIMARY KEY (subject_type, subject_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_cases_status ON risk_cases (status, score DESC);
+
+-- Earnings entries of a driver with a held ref (an order ID for trip earnings)
+-- are skipped by settlement until the hold is released.
+CREATE TABLE IF NOT EXISTS payout_holds (
+    driver_id   VARCHAR(64) NOT NULL,
+    ref         TEXT        NOT NULL,
+    reason      TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL,
+    released_at TIMESTAMPTZ,
+
+    PRIMARY KEY (driver_id, ref)
+);
@@ -0,0 +1,17 @@
+-- README: App installs seen per account, for multi-account and shared-device fraud checks.
+
+-- One row per (install, account) pairing. install_id is the app's per-install
+-- identifier sent in X-Install-ID; several accounts on one install is the
+-- signal risk scoring and referral eligibility look for.
+CREATE TABLE IF NOT EXISTS devices (
+    install_id    VARCHAR(128) NOT NULL,
+    user_id       VARCHAR(64)  NOT NULL,
+    model         VARCHAR(128) NOT NULL DEFAULT '',
+    platform      VARCHAR(16)  NOT NULL DEFAULT '', -- ios, android, web
+    source        VARCHAR(16)  NOT NULL,            -- registration, order
+    first_seen_at TIMESTAMPTZ  NOT NULL,
+    last_seen_at  TIMESTAMPTZ  NOT NULL,
+    PRIMARY KEY (install_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user ON devices (user_id);
@@ -0,0 +1,10 @@
+-- README: Active version of each AI prompt template, switchable at runtime from the ops API.
+
+-- Prompt templates ship in the binary or ARK_AI_PROMPT_DIR as
+-- <name>.<version>.tmpl. A row here overrides the latest embedded version
+-- on every instance; rolling back a prompt is pointing it at an older version.
+CREATE TABLE IF NOT EXISTS ai_prompt_versions (
+    name       VARCHAR(64) PRIMARY KEY,
+    version    VARCHAR(32) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,20 @@
+-- README: Links orders to the AI ride assistant conversation that booked them, and logs conversation progress for conversion analytics.
+
+-- Set for orders booked through the ride assistant; the session ID.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS conversation_id VARCHAR(64);
+
+-- One row per ride assistant conversation, updated on every turn. Holds the
+-- latest stage and unfilled slots, so a conversation that stops without an
+-- order shows where it was abandoned. Message text is not stored.
+CREATE TABLE IF NOT EXISTS ai_conversations (
+    id             VARCHAR(64) PRIMARY KEY,
+    started_at     TIMESTAMPTZ NOT NULL,
+    updated_at     TIMESTAMPTZ NOT NULL,
+    turns          INT         NOT NULL DEFAULT 0,
+    last_status    VARCHAR(16) NOT NULL, -- clarification, confirmation, completed, cancelled, chat
+    stage          VARCHAR(16) NOT NULL,
+    missing_fields TEXT[]      NOT NULL DEFAULT '{}',
+    order_id       VARCHAR(64)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_conversations_started ON ai_conversations (started_at);
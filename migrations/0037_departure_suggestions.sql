@@ -0,0 +1,18 @@
+-- README: Arrive-by times on scheduled orders and the earlier-pickup suggestions made when traffic would make them late.
+
+-- When the passenger must reach the dropoff (a flight or train departure).
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS arrive_by TIMESTAMPTZ;
+
+-- The latest earlier-pickup suggestion per order. scheduled_at is the pickup
+-- the suggestion was made against, suggested_at the proposed one.
+CREATE TABLE IF NOT EXISTS departure_suggestions (
+    order_id     VARCHAR(64) PRIMARY KEY,
+    passenger_id VARCHAR(64) NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    suggested_at TIMESTAMPTZ NOT NULL,
+    arrive_by    TIMESTAMPTZ NOT NULL,
+    travel_secs  BIGINT      NOT NULL,
+    status       VARCHAR(16) NOT NULL, -- pending, accepted, declined, expired
+    created_at   TIMESTAMPTZ NOT NULL,
+    responded_at TIMESTAMPTZ
+);
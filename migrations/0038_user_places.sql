@@ -0,0 +1,19 @@
+-- README: Saved places — each user's favorite and recently visited destinations.
+
+-- cell is the place's coordinate rounded to three decimals (~100 m); a user
+-- has one place per cell, so repeated rides to a building count as visits.
+CREATE TABLE IF NOT EXISTS user_places (
+    id              VARCHAR(64)      PRIMARY KEY,
+    user_id         VARCHAR(64)      NOT NULL,
+    cell            VARCHAR(32)      NOT NULL,
+    label           VARCHAR(50)      NOT NULL DEFAULT '',
+    address         VARCHAR(200)     NOT NULL DEFAULT '',
+    lat             DOUBLE PRECISION NOT NULL,
+    lng             DOUBLE PRECISION NOT NULL,
+    favorite        BOOLEAN          NOT NULL DEFAULT FALSE,
+    visits          INT              NOT NULL DEFAULT 0,
+    last_visited_at TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ      NOT NULL,
+    updated_at      TIMESTAMPTZ      NOT NULL,
+    UNIQUE (user_id, cell)
+);
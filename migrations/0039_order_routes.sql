@@ -0,0 +1,16 @@
+-- README: Driving routes of accepted orders, for drawing the trip on the map.
+
+-- One route per order, from pickup to the current dropoff; replaced when the
+-- dropoff changes mid-trip. steps holds the turn-by-turn instructions.
+CREATE TABLE IF NOT EXISTS order_routes (
+    order_id      VARCHAR(64)      PRIMARY KEY,
+    origin_lat    DOUBLE PRECISION NOT NULL,
+    origin_lng    DOUBLE PRECISION NOT NULL,
+    dest_lat      DOUBLE PRECISION NOT NULL,
+    dest_lng      DOUBLE PRECISION NOT NULL,
+    polyline      TEXT             NOT NULL,
+    distance_m    INT              NOT NULL,
+    duration_secs BIGINT           NOT NULL,
+    steps         JSONB            NOT NULL,
+    created_at    TIMESTAMPTZ      NOT NULL
+);
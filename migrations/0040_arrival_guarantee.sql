@@ -0,0 +1,24 @@
+-- README: Arrival-time guarantees — the promised arrival window of arrive-by bookings and how each trip did against it.
+
+-- The window the platform promised for an arrive-by booking, and when the
+-- trip actually reached the dropoff (set when the driver completes it).
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS arrival_window_from TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS arrival_window_to   TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS arrived_at          TIMESTAMPTZ;
+
+-- One row per completed arrive-by trip. late_secs is 0 for an on-time
+-- arrival; credit is the partial credit granted for a missed guarantee.
+CREATE TABLE IF NOT EXISTS arrival_outcomes (
+    order_id     VARCHAR(64) PRIMARY KEY,
+    passenger_id VARCHAR(64) NOT NULL,
+    driver_id    VARCHAR(64) NOT NULL,
+    window_from  TIMESTAMPTZ NOT NULL,
+    window_to    TIMESTAMPTZ NOT NULL,
+    arrived_at   TIMESTAMPTZ NOT NULL,
+    late_secs    BIGINT      NOT NULL DEFAULT 0,
+    credit       BIGINT      NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_arrival_outcomes_driver ON arrival_outcomes (driver_id, arrived_at);
+CREATE INDEX IF NOT EXISTS idx_arrival_outcomes_arrived ON arrival_outcomes (arrived_at);
@@ -0,0 +1,31 @@
+-- README: Regions — the cities the platform operates in, each with its own timezone, currency, service area, rate set and matching radius.
+
+-- area is the service polygon as [[lat, lng], ...]; an empty area on the
+-- default region serves every pickup no other region claims. rate_set names
+-- the pricing_rates rows the region quotes from ('' is the original set) and
+-- match_radius_km 0 uses the platform default.
+CREATE TABLE IF NOT EXISTS regions (
+    id              VARCHAR(32)      PRIMARY KEY,
+    name            VARCHAR(100)     NOT NULL,
+    timezone        VARCHAR(64)      NOT NULL,
+    currency        VARCHAR(3)       NOT NULL,
+    area            JSONB            NOT NULL DEFAULT '[]',
+    rate_set        VARCHAR(32)      NOT NULL DEFAULT '',
+    match_radius_km DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at      TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO regions (id, name, timezone, currency)
+VALUES ('tpe', 'Taipei', 'Asia/Taipei', 'TWD')
+ON CONFLICT (id) DO NOTHING;
+
+-- NULL is the default region, so existing orders and drivers keep working.
+ALTER TABLE orders  ADD COLUMN IF NOT EXISTS region_id VARCHAR(32);
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS region_id VARCHAR(32);
+
+CREATE INDEX IF NOT EXISTS idx_drivers_region ON drivers (region_id);
+
+-- Each region's rates are versioned independently.
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS rate_set VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE pricing_rates DROP CONSTRAINT IF EXISTS pricing_rates_pkey;
+ALTER TABLE pricing_rates ADD PRIMARY KEY (rate_set, ride_type, version);
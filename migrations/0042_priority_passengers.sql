@@ -0,0 +1,12 @@
+-- README: Priority passengers — VIP and accessibility riders whose orders are offered sooner and to more drivers, tuned per region.
+
+-- '' is a normal passenger; 'vip' or 'accessibility' otherwise. Orders keep
+-- the passenger's priority as it was when they were booked.
+ALTER TABLE users  ADD COLUMN IF NOT EXISTS priority VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS priority VARCHAR(16) NOT NULL DEFAULT '';
+
+-- A priority order is dispatched as if it had been placed
+-- priority_boost_secs earlier, and its first offer goes to up to
+-- priority_notify_drivers drivers (0 keeps the normal pool).
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS priority_boost_secs     INT NOT NULL DEFAULT 0;
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS priority_notify_drivers INT NOT NULL DEFAULT 0;
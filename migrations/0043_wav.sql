@@ -0,0 +1,9 @@
+-- README: Wheelchair-accessible vehicles — driver WAV certification and the accessibility subsidy on rates.
+
+-- Drivers may list the 'wheelchair' capability themselves, but only drivers
+-- certified by ops are offered or assigned wheelchair orders.
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS wav_certified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Taken off the fare of every ride priced under the rate (minor units,
+-- capped at the fare); publish it on the 'wav' ride type's rates.
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS accessibility_subsidy BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+-- README: Pre-trip reminders — the push sent to the driver of a claimed scheduled ride when its schedule window starts, and how the driver responded.
+
+-- One row per claimed ride; a new driver or pickup time replaces it.
+-- departed_at is when the driver set off for the pickup, escalated_at when
+-- ops were asked to step in, and released_at when the driver no longer had
+-- the ride (released, cancelled or re-dispatched).
+CREATE TABLE IF NOT EXISTS pretrip_reminders (
+    order_id     VARCHAR(64) PRIMARY KEY,
+    driver_id    VARCHAR(64) NOT NULL,
+    pickup_lat   DOUBLE PRECISION NOT NULL,
+    pickup_lng   DOUBLE PRECISION NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    reminded_at  TIMESTAMPTZ NOT NULL,
+    departed_at  TIMESTAMPTZ,
+    escalated_at TIMESTAMPTZ,
+    released_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_pretrip_reminders_driver ON pretrip_reminders (driver_id, reminded_at);
+CREATE INDEX IF NOT EXISTS idx_pretrip_reminders_open_escalations ON pretrip_reminders (scheduled_at)
+    WHERE escalated_at IS NOT NULL AND departed_at IS NULL AND released_at IS NULL;
@@ -0,0 +1,4 @@
+-- README: Order SLA monitor — finding when each live order entered its current status.
+
+-- The monitor looks up each live order's latest transition into its status.
+CREATE INDEX IF NOT EXISTS idx_order_state_events_order ON order_state_events (order_id, created_at);
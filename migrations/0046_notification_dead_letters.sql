@@ -0,0 +1,18 @@
+-- README: Notification dead letters — messages that could not be delivered after every retry, kept for ops to inspect and resend.
+
+-- payload holds the message (title, body, category, critical, data) so a retry
+-- resends exactly what the user should have received. retried_at is the last
+-- ops retry, resolved_at when a retry went through.
+CREATE TABLE IF NOT EXISTS notification_dead_letters (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     VARCHAR(64) NOT NULL,
+    payload     JSONB NOT NULL,
+    error       TEXT NOT NULL,
+    attempts    INT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    retried_at  TIMESTAMPTZ,
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_dead_letters_open ON notification_dead_letters (created_at)
+    WHERE resolved_at IS NULL;
@@ -0,0 +1,12 @@
+-- README: Feature flags — per-feature switch rolled out to a percentage of users, optionally only in some regions.
+
+-- A flag is on for a user when enabled, the user's region is listed in regions
+-- (or regions is empty) and the user falls in the first percent of buckets.
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key         VARCHAR(64) PRIMARY KEY,
+    enabled     BOOLEAN NOT NULL DEFAULT FALSE,
+    percent     INT NOT NULL DEFAULT 100 CHECK (percent BETWEEN 0 AND 100),
+    regions     TEXT[] NOT NULL DEFAULT '{}',
+    description TEXT NOT NULL DEFAULT '',
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
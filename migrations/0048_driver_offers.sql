@@ -0,0 +1,17 @@
+-- README: Dispatch offers made to drivers, with a server-side expiry so an offer lapses even if the driver never answers.
+
+CREATE TABLE IF NOT EXISTS driver_offers (
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    driver_id  TEXT NOT NULL,
+    status     VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    closed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_offers_driver_pending
+    ON driver_offers (driver_id, expires_at) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_driver_offers_order_pending
+    ON driver_offers (order_id) WHERE status = 'pending';
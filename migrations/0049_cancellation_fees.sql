@@ -0,0 +1,10 @@
+-- README: Passenger cancellation fees for instant rides — fee rules on rates and the fee charged on the order.
+
+-- Charged when a passenger cancels an instant ride after the free-cancel
+-- grace period: cancel_fee plus cancel_per_km for every kilometre the driver
+-- has already come toward the pickup, capped at the fare (minor units).
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS cancel_fee BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS cancel_per_km BIGINT NOT NULL DEFAULT 0;
+
+-- The cancellation fee the passenger accepted, in the order's currency.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cancel_fee BIGINT NOT NULL DEFAULT 0;
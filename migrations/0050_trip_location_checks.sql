@@ -0,0 +1,8 @@
+-- README: Trip location checks — per-region thresholds for where drivers may start and complete trips.
+
+-- A trip starts within meet_radius_km of the pickup and completes within
+-- complete_radius_km of the dropoff, or anywhere after min_trip_km of
+-- driving. 0 uses the platform default.
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS meet_radius_km     DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS complete_radius_km DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE regions ADD COLUMN IF NOT EXISTS min_trip_km        DOUBLE PRECISION NOT NULL DEFAULT 0;
@@ -0,0 +1,59 @@
+-- README: Ride plans — monthly plans with included ride credit or a fare discount, passenger subscriptions, their billing and the benefit applied to each order.
+
+CREATE TABLE IF NOT EXISTS subscription_plans (
+    id               VARCHAR(64)  PRIMARY KEY,
+    name             VARCHAR(100) NOT NULL,
+    price            BIGINT       NOT NULL, -- per month, TWD minor units
+    ride_credit      BIGINT       NOT NULL DEFAULT 0, -- included each month, TWD minor units
+    discount_percent INT          NOT NULL DEFAULT 0, -- off every fare, 0-100
+    active           BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- A user has at most one live (active or past_due) subscription. The
+-- current billing period runs [period_start, period_end); credit_used and
+-- discounted restart at zero each period.
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id           VARCHAR(64) PRIMARY KEY,
+    user_id      VARCHAR(64) NOT NULL,
+    plan_id      VARCHAR(64) NOT NULL REFERENCES subscription_plans (id),
+    status       VARCHAR(16) NOT NULL, -- active, past_due, cancelled
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end   TIMESTAMPTZ NOT NULL,
+    credit_used  BIGINT      NOT NULL DEFAULT 0,
+    discounted   BIGINT      NOT NULL DEFAULT 0,
+    refund       BIGINT      NOT NULL DEFAULT 0,
+    charge_ref   TEXT        NOT NULL DEFAULT '', -- provider reference of the current period's charge
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    cancelled_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_subscriptions_live ON subscriptions (user_id)
+    WHERE status IN ('active', 'past_due');
+CREATE INDEX IF NOT EXISTS idx_subscriptions_due ON subscriptions (period_end)
+    WHERE status IN ('active', 'past_due');
+
+-- Plan fees charged and refunded through the payment provider. (subscription_id,
+-- kind, period_start) makes each period's charge and the refund idempotent.
+CREATE TABLE IF NOT EXISTS subscription_charges (
+    id              BIGSERIAL   PRIMARY KEY,
+    subscription_id VARCHAR(64) NOT NULL,
+    kind            VARCHAR(16) NOT NULL, -- charge, refund
+    amount          BIGINT      NOT NULL,
+    period_start    TIMESTAMPTZ NOT NULL,
+    provider_ref    TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_subscription_charges UNIQUE (subscription_id, kind, period_start)
+);
+
+-- The plan benefit taken off each order's fare, once per order.
+CREATE TABLE IF NOT EXISTS subscription_usage (
+    order_id        VARCHAR(64) PRIMARY KEY,
+    subscription_id VARCHAR(64) NOT NULL,
+    credit          BIGINT      NOT NULL,
+    discount        BIGINT      NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subscription_applied BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,35 @@
+-- README: Gift cards — batches of codes redeemable into ride credits, each code's redemption count, and who redeemed what.
+
+-- funding is prepaid for cards sold to customers and promotional for
+-- marketing codes; it decides which platform account pays for the credit.
+CREATE TABLE IF NOT EXISTS gift_card_batches (
+    id              VARCHAR(64)  PRIMARY KEY,
+    name            VARCHAR(100) NOT NULL,
+    amount          BIGINT       NOT NULL, -- credit per redemption, TWD minor units
+    code_count      INT          NOT NULL,
+    max_redemptions INT          NOT NULL, -- per code
+    funding         VARCHAR(16)  NOT NULL, -- prepaid, promotional
+    expires_at      TIMESTAMPTZ  NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS gift_cards (
+    code        VARCHAR(32) PRIMARY KEY,
+    batch_id    VARCHAR(64) NOT NULL REFERENCES gift_card_batches (id),
+    redemptions INT         NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_cards_batch ON gift_cards (batch_id);
+
+-- A user redeems at most one code from each batch.
+CREATE TABLE IF NOT EXISTS gift_card_redemptions (
+    batch_id   VARCHAR(64) NOT NULL,
+    user_id    VARCHAR(64) NOT NULL,
+    code       VARCHAR(32) NOT NULL,
+    amount     BIGINT      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (batch_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_card_redemptions_code ON gift_card_redemptions (code);
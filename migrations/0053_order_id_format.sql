@@ -0,0 +1,13 @@
+-- README: Order ID format — new orders get ULIDs; rows created before keep their 32-hex IDs.
+
+-- IDs are never rewritten: order IDs live in event logs, ledger refs, receipts
+-- and client caches, so legacy rows keep their random hex IDs and the check
+-- accepts both forms. Adding the constraint NOT VALID first keeps the ALTER
+-- from blocking writes; VALIDATE then scans existing rows under a weaker lock
+-- and fails the migration if any row carries some other format.
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_id_format;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_id_format CHECK (
+    id ~ '^[0-7][0-9A-HJKMNP-TV-Z]{25}$' OR  -- ULID
+    id ~ '^[0-9a-f]{32}$'                    -- legacy random hex
+) NOT VALID;
+ALTER TABLE orders VALIDATE CONSTRAINT chk_orders_id_format;
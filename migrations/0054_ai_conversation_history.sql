@@ -0,0 +1,21 @@
+-- README: AI conversation history — each ride assistant conversation's owner, messages and confirmation summary, with soft deletion.
+
+-- Conversations now belong to a user, who can list, export and delete them.
+-- Deleting keeps the row for the conversion report but clears its owner and
+-- summary and drops its messages.
+ALTER TABLE ai_conversations ADD COLUMN IF NOT EXISTS user_id    VARCHAR(64);
+ALTER TABLE ai_conversations ADD COLUMN IF NOT EXISTS summary    TEXT NOT NULL DEFAULT '';
+ALTER TABLE ai_conversations ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_ai_conversations_user ON ai_conversations (user_id, started_at) WHERE deleted_at IS NULL;
+
+-- What the user said and what the assistant answered, in order.
+CREATE TABLE IF NOT EXISTS ai_messages (
+    id              BIGSERIAL   PRIMARY KEY,
+    conversation_id VARCHAR(64) NOT NULL REFERENCES ai_conversations (id),
+    role            VARCHAR(16) NOT NULL, -- user, assistant
+    text            TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_messages_conversation ON ai_messages (conversation_id, id);
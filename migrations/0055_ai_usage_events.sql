@@ -0,0 +1,18 @@
+-- README: AI usage events — one row per LLM call with its tokens, estimated cost and outcome, for the admin spend dashboard.
+
+CREATE TABLE IF NOT EXISTS ai_usage_events (
+    id            BIGSERIAL   PRIMARY KEY,
+    provider      VARCHAR(32) NOT NULL, -- gemini
+    model         VARCHAR(64) NOT NULL,
+    operation     VARCHAR(32) NOT NULL, -- chat, intent, transcribe
+    user_id       VARCHAR(128) NOT NULL DEFAULT '', -- empty when not made for a user
+    input_tokens  INT         NOT NULL DEFAULT 0,
+    output_tokens INT         NOT NULL DEFAULT 0,
+    cost_micros   BIGINT      NOT NULL DEFAULT 0, -- estimated, in millionths of a US dollar
+    error         VARCHAR(16) NOT NULL DEFAULT '', -- empty, quota, unavailable or error
+    latency_ms    INT         NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_usage_events_created ON ai_usage_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_ai_usage_events_user ON ai_usage_events (user_id, created_at) WHERE user_id <> '';
@@ -0,0 +1,13 @@
+-- README: Surcharge calendars — dates (e.g. public holidays) on which a rate set's rides pay an extra surcharge, managed by ops through the pricing import.
+
+-- day is local to the regions quoting from the rate set. Unlike
+-- pricing_rates the calendar is edited in place: an import replaces the rate
+-- set's days.
+CREATE TABLE IF NOT EXISTS pricing_surcharge_days (
+    rate_set      VARCHAR(32)  NOT NULL DEFAULT '',
+    day           DATE         NOT NULL,
+    name          VARCHAR(100) NOT NULL DEFAULT '',
+    surcharge_bps INT          NOT NULL CHECK (surcharge_bps >= 0),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (rate_set, day)
+);
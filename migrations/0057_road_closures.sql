@@ -0,0 +1,20 @@
+-- README: Road closures — temporary roadworks and event areas ops register, which trip routes detour around or flag and matching searches wider in.
+
+-- area is the closed-off polygon as [[lat, lng], ...]. A closure applies in
+-- [starts_at, ends_at); ending one early moves ends_at to the moment it was
+-- lifted, so the history is kept.
+CREATE TABLE IF NOT EXISTS road_closures (
+    id         VARCHAR(64)  PRIMARY KEY,
+    kind       VARCHAR(16)  NOT NULL, -- roadworks, event
+    name       VARCHAR(100) NOT NULL,
+    area       JSONB        NOT NULL,
+    starts_at  TIMESTAMPTZ  NOT NULL,
+    ends_at    TIMESTAMPTZ  NOT NULL CHECK (ends_at > starts_at),
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_road_closures_ends ON road_closures (ends_at);
+
+-- The closures a trip's recorded route still runs through after detouring,
+-- as [{"id", "name", "ends_at"}, ...].
+ALTER TABLE order_routes ADD COLUMN IF NOT EXISTS closures JSONB NOT NULL DEFAULT '[]';
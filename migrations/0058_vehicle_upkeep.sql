@@ -0,0 +1,22 @@
+-- README: Vehicle upkeep — each driver's vehicle inspection due date and odometer-based maintenance, with the reminders sent about them.
+
+-- One row per driver, created by the first inspection date, odometer report
+-- or service recorded. The next service is due at last_service_km plus
+-- service_interval_km (0 uses the platform default). The *_reminded_* columns
+-- keep a reminder from repeating: inspection_reminded_at is cleared when a new
+-- due date is set, service_reminded_km holds the service mileage last reminded.
+CREATE TABLE IF NOT EXISTS vehicle_upkeep (
+    driver_id              VARCHAR(64) PRIMARY KEY,
+    inspection_due         DATE,
+    odometer_km            INTEGER     NOT NULL DEFAULT 0 CHECK (odometer_km >= 0),
+    odometer_at            TIMESTAMPTZ,
+    service_interval_km    INTEGER     NOT NULL DEFAULT 0 CHECK (service_interval_km >= 0),
+    last_service_km        INTEGER     NOT NULL DEFAULT 0 CHECK (last_service_km >= 0),
+    last_service_at        TIMESTAMPTZ,
+    inspection_reminded_at TIMESTAMPTZ,
+    service_reminded_km    INTEGER     NOT NULL DEFAULT 0,
+    odometer_reminded_at   TIMESTAMPTZ,
+    updated_at             TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_upkeep_inspection_due ON vehicle_upkeep (inspection_due);
@@ -0,0 +1,25 @@
+-- README: Fleet vehicles — vehicles owned by a fleet operator and shared by drivers in shifts; only the driver of the current shift may go online with one.
+
+CREATE TABLE IF NOT EXISTS fleet_vehicles (
+    id         VARCHAR(64) PRIMARY KEY,
+    owner_id   VARCHAR(64) NOT NULL,
+    plate      VARCHAR(16) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fleet_vehicles_owner ON fleet_vehicles (owner_id);
+
+-- A shift gives driver_id the vehicle in [starts_at, ends_at). Shifts of a
+-- vehicle, and of a driver, never overlap; ending one early moves ends_at to
+-- the moment it was ended, so the history is kept for utilization reports.
+CREATE TABLE IF NOT EXISTS vehicle_assignments (
+    id         VARCHAR(64) PRIMARY KEY,
+    vehicle_id VARCHAR(64) NOT NULL,
+    driver_id  VARCHAR(64) NOT NULL,
+    starts_at  TIMESTAMPTZ NOT NULL,
+    ends_at    TIMESTAMPTZ NOT NULL CHECK (ends_at > starts_at),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_assignments_vehicle ON vehicle_assignments (vehicle_id, ends_at);
+CREATE INDEX IF NOT EXISTS idx_vehicle_assignments_driver ON vehicle_assignments (driver_id, ends_at);
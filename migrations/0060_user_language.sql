@@ -0,0 +1,6 @@
+-- README: Preferred language — the language the ride assistant replies in, detected from what the user writes, reused for notifications.
+
+-- '' until the user writes something the language can be told from, or sets
+-- it; otherwise 'zh-TW', 'en' or 'ja'. Emails and SMS follow it for users who
+-- never chose a notification locale.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferred_language VARCHAR(8) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- README: Spoken text — an accessibility preference adding a plain, read-aloud version of every push for visually impaired users.
+
+ALTER TABLE notification_preferences
+    ADD COLUMN IF NOT EXISTS spoken_text BOOLEAN NOT NULL DEFAULT FALSE;
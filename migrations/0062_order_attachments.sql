@@ -0,0 +1,15 @@
+-- README: Photos attached to orders (luggage, meeting point, proof of delivery), stored in Cloud Storage and deleted some time after the order ends.
+
+CREATE TABLE IF NOT EXISTS order_attachments (
+    id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL,
+    uploader_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    object TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL,
+    uploaded_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS order_attachments_order_idx ON order_attachments (order_id, created_at);
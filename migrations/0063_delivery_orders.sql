@@ -0,0 +1,10 @@
+-- README: Parcel delivery orders — the recipient, weight class and signer of a delivery, and the weight class surcharges of delivery rates.
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS recipient_name TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS recipient_phone TEXT;
+-- parcel_weight_class is set exactly for delivery orders.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS parcel_weight_class TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_signed_by TEXT;
+
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS parcel_medium_bps INT NOT NULL DEFAULT 0;
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS parcel_large_bps INT NOT NULL DEFAULT 0;
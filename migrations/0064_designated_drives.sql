@@ -0,0 +1,10 @@
+-- README: Designated drives — the passenger's car a designated driver drives and the driver's return allowance, and the return allowance of rates.
+
+-- car_plate is set exactly for designated orders.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS car_plate TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS car_model TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS car_color TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS car_transmission TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS return_allowance BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS return_allowance BIGINT NOT NULL DEFAULT 0;
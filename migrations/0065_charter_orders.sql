@@ -0,0 +1,17 @@
+-- README: Hourly charters — the booked hours and overtime of a charter, the stops its driver logs, and the hourly and overtime rates.
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS charter_hours INT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS overtime_fee BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS order_stops (
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    lat        DOUBLE PRECISION NOT NULL,
+    lng        DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_stops_order ON order_stops (order_id, created_at);
+
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS per_hour BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE pricing_rates ADD COLUMN IF NOT EXISTS overtime_per_hour BIGINT NOT NULL DEFAULT 0;
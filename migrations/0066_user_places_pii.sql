@@ -0,0 +1,12 @@
+-- README: Application-encrypted saved places — sealed label, address and position, and a blind-index cell.
+
+-- label, address and position hold AES-GCM ciphertext ("enc:v1:...") and cell
+-- holds the HMAC digest of the rounded coordinate, so UNIQUE (user_id, cell)
+-- still keeps one place per cell. position is "lat,lng"; lat and lng are only
+-- read for legacy rows, which cmd/pii-rotate seals and re-indexes.
+ALTER TABLE user_places ALTER COLUMN label TYPE TEXT;
+ALTER TABLE user_places ALTER COLUMN address TYPE TEXT;
+ALTER TABLE user_places ALTER COLUMN cell TYPE VARCHAR(64);
+ALTER TABLE user_places ADD COLUMN IF NOT EXISTS position TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_places ALTER COLUMN lat DROP NOT NULL;
+ALTER TABLE user_places ALTER COLUMN lng DROP NOT NULL;
@@ -0,0 +1,9 @@
+-- README: Photo objects left behind by account purges, queued for the attachment cleanup to delete from Cloud Storage.
+
+-- The account purge deletes the order_attachments rows of the user's orders
+-- and moves their object names here in the same statement; the attachment
+-- cleanup deletes each object and then its row.
+CREATE TABLE IF NOT EXISTS attachment_purges (
+    object    TEXT        PRIMARY KEY,
+    purged_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,9 @@
+// README: Embeds the SQL migrations so the API binary can check which ones a database has applied.
+package migrations
+
+import "embed"
+
+// FS holds every NNNN_name.sql migration in this directory.
+//
+//go:embed *.sql
+var FS embed.FS
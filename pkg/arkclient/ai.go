@@ -0,0 +1,31 @@
+// README: AI assistant endpoint — text chat metered against the user's monthly token allowance.
+package arkclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// ChatRequest is the text body of POST /api/ai/chat.
+type ChatRequest struct {
+	UID     string `json:"uid"`
+	Message string `json:"message"`
+	// AudioRef names a recording transcribed on the device into Message.
+	AudioRef string `json:"audio_ref,omitempty"`
+}
+
+// ChatReply is the assistant's answer.
+type ChatReply struct {
+	Reply      string `json:"reply"`
+	Transcript string `json:"transcript,omitempty"`
+	AudioRef   string `json:"audio_ref,omitempty"`
+}
+
+// Chat sends one message to the assistant. An exhausted allowance answers 429.
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (*ChatReply, error) {
+	var out ChatReply
+	if err := c.Do(ctx, http.MethodPost, "/api/ai/chat", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
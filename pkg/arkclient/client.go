@@ -0,0 +1,188 @@
+// README: Typed Go client for the Ark HTTP API, shared by the bench tool, simulators, integration tests and partners.
+package arkclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"ark/internal/sandbox"
+)
+
+// maxErrorBody caps how much of an error response is kept on APIError.
+const maxErrorBody = 64 << 10
+
+// APIError is returned for any response outside 2xx.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Message is the "error" field of the JSON body, or the raw body when it
+	// has none.
+	Message string
+	// Body is the raw response body, for endpoints that send more than an
+	// error message (e.g. the scheduled-order suggestion of a fastest booking).
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("arkclient: %s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// StatusCode returns the HTTP status of err when it is an *APIError, and 0
+// otherwise (transport errors, cancelled contexts).
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// TokenSource returns the bearer token for a request; an empty token sends
+// the request unauthenticated.
+type TokenSource func(ctx context.Context) (string, error)
+
+// Client calls one Ark API deployment. It is safe for concurrent use once
+// configured.
+type Client struct {
+	base    string
+	http    *http.Client
+	token   TokenSource
+	sandbox string
+}
+
+// New returns a client for the API at baseURL (e.g. http://localhost:8080)
+// that sends requests unauthenticated with a 30s timeout.
+func New(baseURL string) *Client {
+	return &Client{
+		base: strings.TrimRight(baseURL, "/"),
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetHTTPClient replaces the underlying HTTP client, e.g. to change timeouts.
+// Streams need a client without a timeout; StreamOffers uses one of its own.
+func (c *Client) SetHTTPClient(h *http.Client) {
+	c.http = h
+}
+
+// SetToken authenticates every request with a fixed Firebase ID token.
+func (c *Client) SetToken(token string) {
+	c.token = func(context.Context) (string, error) { return token, nil }
+}
+
+// SetTokenSource authenticates every request with the token ts returns, for
+// callers that refresh short-lived ID tokens.
+func (c *Client) SetTokenSource(ts TokenSource) {
+	c.token = ts
+}
+
+// SetSandboxKey sends requests in test mode (see package sandbox); empty turns
+// it off.
+func (c *Client) SetSandboxKey(key string) {
+	c.sandbox = key
+}
+
+// Do sends a request with in as its JSON body (none when nil) and decodes a
+// 2xx JSON response into out (ignored when nil). Other statuses return an
+// *APIError. It covers endpoints without a typed method.
+func (c *Client) Do(ctx context.Context, method, path string, in, out any) error {
+	resp, err := c.send(ctx, c.http, method, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return decode(method, path, resp.Body, out)
+}
+
+func decode(method, path string, body io.Reader, out any) error {
+	if err := json.NewDecoder(body).Decode(out); err != nil {
+		return fmt.Errorf("arkclient: %s %s: decode response: %w", method, path, err)
+	}
+	return nil
+}
+
+// send issues the request, after applying edit when set, and returns the
+// response when it is 2xx; the caller closes its body.
+func (c *Client) send(ctx context.Context, hc *http.Client, method, path string, in any, edit ...func(*http.Request)) (*http.Response, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("arkclient: %s %s: encode request: %w", method, path, err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("arkclient: token: %w", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	if c.sandbox != "" {
+		req.Header.Set(sandbox.Header, c.sandbox)
+	}
+	for _, f := range edit {
+		f(req)
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("arkclient: %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	apiErr := &APIError{Method: method, Path: path, StatusCode: resp.StatusCode, Body: raw, Message: strings.TrimSpace(string(raw))}
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(raw, &e) == nil && e.Error != "" {
+		apiErr.Message = e.Error
+	}
+	return nil, apiErr
+}
+
+// Health is the /health report.
+type Health struct {
+	// Status is "ok" or "degraded".
+	Status string `json:"status"`
+	DB     string `json:"db"`
+	Redis  string `json:"redis"`
+	// Degraded lists features out of service while Redis is down.
+	Degraded []string          `json:"degraded,omitempty"`
+	Workers  map[string]string `json:"workers,omitempty"`
+}
+
+// Health calls GET /health. A failing check answers 503, returned as an
+// *APIError.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var h Health
+	if err := c.Do(ctx, http.MethodGet, "/health", nil, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
@@ -0,0 +1,110 @@
+package arkclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ark/internal/sandbox"
+)
+
+func TestDo_HeadersAndAPIError(t *testing.T) {
+	var auth, sandboxKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, sandboxKey = r.Header.Get("Authorization"), r.Header.Get(sandbox.Header)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/orders":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"order_id":"o1","status":"waiting"}`)
+		default:
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"error":"invalid state"}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL + "/")
+	c.SetToken("tok")
+	c.SetSandboxKey("sbx")
+	ctx := context.Background()
+
+	created, err := c.CreateOrder(ctx, CreateOrderRequest{RideType: "economy"})
+	if err != nil || created.OrderID != "o1" || created.Status != StatusWaiting {
+		t.Fatalf("CreateOrder = %+v, %v", created, err)
+	}
+	if auth != "Bearer tok" || sandboxKey != "sbx" {
+		t.Fatalf("auth = %q, sandbox = %q", auth, sandboxKey)
+	}
+
+	_, err = c.Arrive(ctx, "o1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || apiErr.Message != "invalid state" {
+		t.Fatalf("Arrive err = %v", err)
+	}
+	if apiErr.Path != "/api/orders/o1/arrived" || StatusCode(err) != http.StatusConflict {
+		t.Fatalf("APIError = %+v", apiErr)
+	}
+	if StatusCode(errors.New("dial tcp: refused")) != 0 {
+		t.Fatal("StatusCode of a transport error should be 0")
+	}
+}
+
+func TestWaitOrderStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("wait") != "5s" {
+			t.Errorf("wait = %q", r.URL.Query().Get("wait"))
+		}
+		if r.Header.Get("If-None-Match") == `"3"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, `{"order_id":"o1","status":"approaching","status_version":4,"driver_id":"d1"}`)
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	st, err := c.WaitOrderStatus(context.Background(), "o1", 3, 5*time.Second)
+	if err != nil || st != nil {
+		t.Fatalf("unchanged: got %+v, %v; want nil, nil", st, err)
+	}
+	st, err = c.WaitOrderStatus(context.Background(), "o1", 2, 5*time.Second)
+	if err != nil || st == nil || st.StatusVersion != 4 || st.DriverID != "d1" {
+		t.Fatalf("changed: got %+v, %v", st, err)
+	}
+}
+
+func TestStreamOffers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/drivers/d1/offers/stream" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keepalive\n\n")
+		fmt.Fprint(w, "event: offer\ndata: {\"offer_id\":7,\"order_id\":\"o1\",\"pickup\":{\"lat\":25.03,\"lng\":121.56},\"ttl_seconds\":30}\n\n")
+		fmt.Fprint(w, "event: withdrawn\ndata: {\"offer_id\":7,\"order_id\":\"o1\"}\n\n")
+	}))
+	defer srv.Close()
+
+	var got []OfferEvent
+	err := New(srv.URL).StreamOffers(context.Background(), "d1", func(e OfferEvent) error {
+		got = append(got, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamOffers: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("events = %+v, want offer and withdrawn", got)
+	}
+	if e := got[0]; e.Type != OfferEventOffer || e.OfferID != 7 || e.Pickup == nil || e.Pickup.Lat != 25.03 || e.TTLSeconds != 30 {
+		t.Fatalf("offer = %+v", e)
+	}
+	if e := got[1]; e.Type != OfferEventWithdrawn || e.OrderID != "o1" {
+		t.Fatalf("withdrawn = %+v", e)
+	}
+}
@@ -0,0 +1,145 @@
+// README: Location and dispatch endpoints — position updates, the nearby-driver preview, the wait estimate and the driver's offer stream.
+package arkclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocationUpdate is the body of POST /api/location/update.
+type LocationUpdate struct {
+	// UserType is "driver" or "passenger".
+	UserType string  `json:"user_type"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+	// Seq orders updates from one client; the API rejects updates not newer
+	// than the last accepted one with 409. Zero skips the check.
+	Seq int64 `json:"seq,omitempty"`
+}
+
+// UpdateLocation records the authenticated user's position. Throttled updates
+// answer 429 and, while live positions cannot be stored, 503.
+func (c *Client) UpdateLocation(ctx context.Context, u LocationUpdate) error {
+	return c.Do(ctx, http.MethodPost, "/api/location/update", u, nil)
+}
+
+// NearbyDrivers is the anonymized "cars around you" preview.
+type NearbyDrivers struct {
+	Count   int     `json:"count"`
+	Drivers []Point `json:"drivers"`
+	// ETASeconds is the pickup estimate of the nearest driver, nil when none.
+	ETASeconds *int `json:"eta_seconds"`
+}
+
+// NearbyDrivers calls GET /api/passengers/nearby-drivers for a pickup at p.
+func (c *Client) NearbyDrivers(ctx context.Context, p Point) (*NearbyDrivers, error) {
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(p.Lat, 'f', -1, 64))
+	q.Set("lng", strconv.FormatFloat(p.Lng, 'f', -1, 64))
+	var out NearbyDrivers
+	if err := c.Do(ctx, http.MethodGet, "/api/passengers/nearby-drivers?"+q.Encode(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WaitEstimate is the expected time-to-match shown before booking.
+type WaitEstimate struct {
+	Zone    string `json:"zone"`
+	Drivers int    `json:"drivers"`
+	// WaitSeconds is nil when no driver can serve the ride type nearby.
+	WaitSeconds *int `json:"wait_seconds"`
+	Samples     int  `json:"samples"`
+	// Basis is "recent_matches", "supply" or "no_supply".
+	Basis string `json:"basis"`
+}
+
+// EstimateWait calls GET /api/estimates/wait for a rideType pickup at p.
+func (c *Client) EstimateWait(ctx context.Context, p Point, rideType string) (*WaitEstimate, error) {
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(p.Lat, 'f', -1, 64))
+	q.Set("lng", strconv.FormatFloat(p.Lng, 'f', -1, 64))
+	q.Set("ride_type", rideType)
+	var out WaitEstimate
+	if err := c.Do(ctx, http.MethodGet, "/api/estimates/wait?"+q.Encode(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Offer event types of the driver offer stream.
+const (
+	OfferEventOffer     = "offer"
+	OfferEventExpired   = "expired"
+	OfferEventWithdrawn = "withdrawn"
+)
+
+// OfferEvent is one event of the driver offer stream. The trip fields are
+// only set on OfferEventOffer.
+type OfferEvent struct {
+	Type         string    `json:"-"`
+	OfferID      int64     `json:"offer_id"`
+	OrderID      string    `json:"order_id"`
+	Pickup       *Point    `json:"pickup,omitempty"`
+	Dropoff      *Point    `json:"dropoff,omitempty"`
+	RideType     string    `json:"ride_type,omitempty"`
+	EstimatedFee int64     `json:"estimated_fee,omitempty"`
+	Currency     string    `json:"currency,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+	TTLSeconds   int       `json:"ttl_seconds,omitempty"`
+}
+
+// StreamOffers follows the dispatch offers of driverID, who must be the
+// authenticated user, and calls fn for each event until ctx is done, the
+// server closes the stream or fn returns an error. Keepalives are skipped. It
+// returns nil when ctx ends.
+func (c *Client) StreamOffers(ctx context.Context, driverID string, fn func(OfferEvent) error) error {
+	path := "/api/drivers/" + url.PathEscape(driverID) + "/offers/stream"
+	// The stream outlives any request timeout; ctx bounds it instead.
+	hc := *c.http
+	hc.Timeout = 0
+	resp, err := c.send(ctx, &hc, http.MethodGet, path, nil, func(r *http.Request) {
+		r.Header.Set("Accept", "text/event-stream")
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var event, data string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if event != "" && data != "" {
+				e := OfferEvent{Type: event}
+				if err := json.Unmarshal([]byte(data), &e); err != nil {
+					return fmt.Errorf("arkclient: %s event: %w", event, err)
+				}
+				if err := fn(e); err != nil {
+					return err
+				}
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("arkclient: GET %s: %w", path, err)
+	}
+	return nil
+}
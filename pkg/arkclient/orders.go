@@ -0,0 +1,305 @@
+// README: Order endpoints — booking, status polls and the passenger and driver transitions of a trip.
+package arkclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Order statuses as reported by the API.
+const (
+	StatusScheduled   = "scheduled"
+	StatusWaiting     = "waiting"
+	StatusAssigned    = "assigned"
+	StatusApproaching = "approaching"
+	StatusArrived     = "arrived"
+	StatusDriving     = "driving"
+	StatusPayment     = "payment"
+	StatusComplete    = "complete"
+	StatusCancelled   = "cancelled"
+)
+
+// Point is a WGS84 position.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// CreateOrderRequest is the body of POST /api/orders.
+type CreateOrderRequest struct {
+	PickupLat  float64 `json:"pickup_lat"`
+	PickupLng  float64 `json:"pickup_lng"`
+	DropoffLat float64 `json:"dropoff_lat"`
+	DropoffLng float64 `json:"dropoff_lng"`
+	RideType   string  `json:"ride_type"`
+	// Mode "fastest" only books when a nearby driver can make the pickup in time.
+	Mode           string   `json:"mode,omitempty"`
+	Notes          string   `json:"notes,omitempty"`
+	Requirements   []string `json:"requirements,omitempty"`
+	PassengerCount int      `json:"passenger_count,omitempty"`
+	HasPet         bool     `json:"has_pet,omitempty"`
+	OrgID          string   `json:"org_id,omitempty"`
+}
+
+// CreatedOrder is the response of POST /api/orders.
+type CreatedOrder struct {
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+	// PickupETASeconds is set for fastest-pickup bookings with a quote.
+	PickupETASeconds *int `json:"pickup_eta_seconds,omitempty"`
+	// Queued is set when a fastest-pickup booking could not be quoted and
+	// waits for dispatch.
+	Queued bool `json:"queued,omitempty"`
+}
+
+// CreateOrder books an instant ride as the authenticated passenger.
+func (c *Client) CreateOrder(ctx context.Context, req CreateOrderRequest) (*CreatedOrder, error) {
+	var out CreatedOrder
+	if err := c.Do(ctx, http.MethodPost, "/api/orders", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateScheduledRequest is the body of POST /api/orders/scheduled.
+type CreateScheduledRequest struct {
+	PickupLat   float64   `json:"pickup_lat"`
+	PickupLng   float64   `json:"pickup_lng"`
+	DropoffLat  float64   `json:"dropoff_lat"`
+	DropoffLng  float64   `json:"dropoff_lng"`
+	RideType    string    `json:"ride_type"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	// ScheduleWindowMins is how long before ScheduledAt the order may still
+	// be cancelled for free.
+	ScheduleWindowMins int      `json:"schedule_window_mins"`
+	Notes              string   `json:"notes,omitempty"`
+	Requirements       []string `json:"requirements,omitempty"`
+	PassengerCount     int      `json:"passenger_count,omitempty"`
+	HasPet             bool     `json:"has_pet,omitempty"`
+	// TransitType ("flight" or "train") and TransitNumber mark airport and
+	// HSR pickups.
+	TransitType   string     `json:"transit_type,omitempty"`
+	TransitNumber string     `json:"transit_number,omitempty"`
+	ArriveBy      *time.Time `json:"arrive_by,omitempty"`
+	OrgID         string     `json:"org_id,omitempty"`
+}
+
+// CreateScheduledOrder books a ride for later as the authenticated passenger.
+func (c *Client) CreateScheduledOrder(ctx context.Context, req CreateScheduledRequest) (*CreatedOrder, error) {
+	var out CreatedOrder
+	if err := c.Do(ctx, http.MethodPost, "/api/orders/scheduled", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Fare is the fare breakdown shown to an order's participants, in minor units.
+type Fare struct {
+	Estimated           int64  `json:"estimated"`
+	Actual              *int64 `json:"actual,omitempty"`
+	SubscriptionApplied int64  `json:"subscription_applied"`
+	CreditsApplied      int64  `json:"credits_applied"`
+	Due                 int64  `json:"due"`
+	Currency            string `json:"currency"`
+}
+
+// OrderStatus is the response of GET /api/orders/:id/status. Notes,
+// Requirements, Fare and Dropoff are only sent to the passenger and the
+// assigned driver.
+type OrderStatus struct {
+	OrderID       string   `json:"order_id"`
+	Status        string   `json:"status"`
+	StatusVersion int      `json:"status_version"`
+	DriverID      string   `json:"driver_id,omitempty"`
+	Notes         string   `json:"notes,omitempty"`
+	Requirements  []string `json:"requirements,omitempty"`
+	Fare          *Fare    `json:"fare,omitempty"`
+	Dropoff       *Point   `json:"dropoff,omitempty"`
+}
+
+// OrderStatus returns the order's current status.
+func (c *Client) OrderStatus(ctx context.Context, orderID string) (*OrderStatus, error) {
+	var out OrderStatus
+	if err := c.Do(ctx, http.MethodGet, orderPath(orderID, "status"), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WaitOrderStatus long-polls the order's status: it returns once the
+// status_version moves past version, or nil when it has not within wait
+// (capped by the server at 30s).
+func (c *Client) WaitOrderStatus(ctx context.Context, orderID string, version int, wait time.Duration) (*OrderStatus, error) {
+	path := orderPath(orderID, "status") + "?wait=" + url.QueryEscape(wait.String())
+	resp, err := c.send(ctx, c.http, http.MethodGet, path, nil, func(r *http.Request) {
+		r.Header.Set("If-None-Match", `"`+strconv.Itoa(version)+`"`)
+	})
+	if StatusCode(err) == http.StatusNotModified {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out OrderStatus
+	if err := decode(http.MethodGet, path, resp.Body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Cancellation is the response of POST /api/orders/:id/cancel.
+type Cancellation struct {
+	Status string `json:"status"`
+	// LateCancel is set when a scheduled order was cancelled past its
+	// free-cancel deadline.
+	LateCancel bool `json:"late_cancel"`
+	// CancellationFee is what the passenger was charged for cancelling.
+	CancellationFee int64  `json:"cancellation_fee"`
+	Currency        string `json:"currency"`
+}
+
+// CancelOrder cancels the order as its passenger or assigned driver. A
+// passenger past the free-cancel grace period gets 409 until they confirm the
+// fee with CancelOrderWithFee.
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (*Cancellation, error) {
+	var out Cancellation
+	if err := c.Do(ctx, http.MethodPost, orderPath(orderID, "cancel"), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelOrderWithFee cancels the order as its passenger, accepting a
+// cancellation fee of up to acceptFee.
+func (c *Client) CancelOrderWithFee(ctx context.Context, orderID string, acceptFee int64) (*Cancellation, error) {
+	body := struct {
+		AcceptFee int64 `json:"accept_fee"`
+	}{acceptFee}
+	var out Cancellation
+	if err := c.Do(ctx, http.MethodPost, orderPath(orderID, "cancel"), body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancellationQuote is the response of GET /api/orders/:id/cancellation.
+type CancellationQuote struct {
+	Fee      int64  `json:"fee"`
+	Currency string `json:"currency"`
+	// FreeUntil is when cancelling stops being free; nil when it always is.
+	FreeUntil        *time.Time `json:"free_until"`
+	DriverProgressKm float64    `json:"driver_progress_km"`
+}
+
+// QuoteCancellation returns what the passenger would pay to cancel now.
+func (c *Client) QuoteCancellation(ctx context.Context, orderID string) (*CancellationQuote, error) {
+	var out CancellationQuote
+	if err := c.Do(ctx, http.MethodGet, orderPath(orderID, "cancellation"), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AcceptOrder accepts an offered order as the authenticated driver. With a
+// version, the accept is refused with 409 when the order changed since the
+// offer.
+func (c *Client) AcceptOrder(ctx context.Context, orderID string, version *int) (string, error) {
+	body := struct {
+		StatusVersion *int `json:"status_version,omitempty"`
+	}{version}
+	return c.transition(ctx, orderID, "accept", body)
+}
+
+// DenyOrder turns down an offered order as the authenticated driver.
+func (c *Client) DenyOrder(ctx context.Context, orderID string) (string, error) {
+	return c.transition(ctx, orderID, "deny", nil)
+}
+
+// ClaimOrder claims a scheduled order as the authenticated driver. Orders
+// already claimed or outside the claim window answer 409.
+func (c *Client) ClaimOrder(ctx context.Context, orderID string) (string, error) {
+	return c.transition(ctx, orderID, "claim", nil)
+}
+
+// ReleaseOrder gives a claimed scheduled order back as its driver; it is
+// re-opened to other drivers with a higher incentive bonus.
+func (c *Client) ReleaseOrder(ctx context.Context, orderID, reason string) (string, error) {
+	body := struct {
+		Reason string `json:"reason,omitempty"`
+	}{reason}
+	return c.transition(ctx, orderID, "driver-cancel", body)
+}
+
+// Depart reports the driver of a claimed scheduled order on the way to the pickup.
+func (c *Client) Depart(ctx context.Context, orderID string) (string, error) {
+	return c.transition(ctx, orderID, "depart", nil)
+}
+
+// Arrive reports the driver at the pickup.
+func (c *Client) Arrive(ctx context.Context, orderID string) (string, error) {
+	return c.transition(ctx, orderID, "arrived", nil)
+}
+
+// Meet reports the passenger on board.
+func (c *Client) Meet(ctx context.Context, orderID string) (string, error) {
+	return c.transition(ctx, orderID, "meet", nil)
+}
+
+// Complete ends the trip at the dropoff.
+func (c *Client) Complete(ctx context.Context, orderID string) (string, error) {
+	return c.transition(ctx, orderID, "complete", nil)
+}
+
+// Pay settles the trip as its passenger.
+func (c *Client) Pay(ctx context.Context, orderID string) (string, error) {
+	return c.transition(ctx, orderID, "pay", nil)
+}
+
+// transition posts to /api/orders/:id/<action> and returns the order's new status.
+// NavLinks open Google Maps navigation to one stop.
+type NavLinks struct {
+	Web     string `json:"web"`
+	Android string `json:"android"`
+	IOS     string `json:"ios"`
+}
+
+// Navigation is the driver's navigation handoff, also returned by the accept
+// and claim endpoints.
+type Navigation struct {
+	OrderID         string   `json:"order_id"`
+	Pickup          Point    `json:"pickup"`
+	Dropoff         Point    `json:"dropoff"`
+	Polyline        string   `json:"polyline"`
+	DistanceMeters  int      `json:"distance_meters"`
+	DurationSeconds int64    `json:"duration_seconds"`
+	ToPickup        NavLinks `json:"to_pickup"`
+	ToDropoff       NavLinks `json:"to_dropoff"`
+}
+
+// Navigation returns the navigation handoff of the authenticated driver's
+// order. Servers without Maps answer 404.
+func (c *Client) Navigation(ctx context.Context, orderID string) (*Navigation, error) {
+	var out Navigation
+	if err := c.Do(ctx, http.MethodGet, orderPath(orderID, "navigation"), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) transition(ctx context.Context, orderID, action string, body any) (string, error) {
+	var out struct {
+		Status string `json:"status"`
+	}
+	if err := c.Do(ctx, http.MethodPost, orderPath(orderID, action), body, &out); err != nil {
+		return "", err
+	}
+	return out.Status, nil
+}
+
+func orderPath(orderID, action string) string {
+	return "/api/orders/" + url.PathEscape(orderID) + "/" + action
+}
@@ -0,0 +1,376 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"ark/internal/config"
+	httptransport "ark/internal/http"
+	"ark/internal/modules/order"
+	"ark/migrations"
+	"ark/pkg/arkclient"
+)
+
+// uidVerifier stands in for Firebase: the bearer token is the caller's UID.
+type uidVerifier struct{}
+
+func (uidVerifier) VerifyIDToken(_ context.Context, idToken string) (*auth.Token, error) {
+	return &auth.Token{UID: idToken}, nil
+}
+
+// scheduledEnv is the API router serving the order module over a real
+// database, with one client per participant of the ride.
+type scheduledEnv struct {
+	db        *pgxpool.Pool
+	orders    *order.Service
+	sched     config.SchedulingConfig
+	passenger *arkclient.Client
+	// Every UID ends in suffix, unique per test, so runs can share a database.
+	suffix      string
+	passengerID string
+	drivers     map[string]*arkclient.Client
+}
+
+// driverID is the UID of the driver whose client is drivers[name].
+func (e *scheduledEnv) driverID(name string) string {
+	return name + "-" + e.suffix
+}
+
+func newScheduledEnv(t *testing.T, ctx context.Context) *scheduledEnv {
+	t.Helper()
+	dsn := os.Getenv("ARK_TEST_DSN")
+	if dsn == "" {
+		t.Skip("ARK_TEST_DSN not set; skipping integration test")
+	}
+	db, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect db: %v", err)
+	}
+	t.Cleanup(db.Close)
+	if err := applyMigrations(ctx, db); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+
+	sched := config.DefaultScheduling()
+	sched.IncentiveTick = 50 * time.Millisecond
+	orderSvc := order.NewService(order.NewStore(db), nil)
+	orderSvc.ConfigureScheduling(sched)
+	srv := httptest.NewServer(httptransport.NewServer(httptransport.ServerDeps{
+		Order: orderSvc,
+		Auth:  uidVerifier{},
+		DB:    db,
+	}).Engine)
+	t.Cleanup(srv.Close)
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	client := func(uid string) *arkclient.Client {
+		c := arkclient.New(srv.URL)
+		c.SetToken(uid)
+		return c
+	}
+	env := &scheduledEnv{
+		db:          db,
+		orders:      orderSvc,
+		sched:       sched,
+		suffix:      suffix,
+		passengerID: "p-" + suffix,
+		drivers:     map[string]*arkclient.Client{},
+	}
+	env.passenger = client(env.passengerID)
+	for _, name := range []string{"d1", "d2"} {
+		env.drivers[name] = client(env.driverID(name))
+	}
+	return env
+}
+
+// applyMigrations runs every embedded migration in order; they are all
+// idempotent, so a database that already has them is left as is.
+func applyMigrations(ctx context.Context, db *pgxpool.Pool) error {
+	names, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		return err
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		sql, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// book creates a scheduled ride two hours ahead whose incentive window is
+// already open.
+func (e *scheduledEnv) book(t *testing.T, ctx context.Context) string {
+	t.Helper()
+	created, err := e.passenger.CreateScheduledOrder(ctx, arkclient.CreateScheduledRequest{
+		PickupLat:          25.0330,
+		PickupLng:          121.5654,
+		DropoffLat:         25.0478,
+		DropoffLng:         121.5170,
+		RideType:           "economy",
+		ScheduledAt:        time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second),
+		ScheduleWindowMins: 180,
+	})
+	if err != nil {
+		t.Fatalf("create scheduled: %v", err)
+	}
+	if created.Status != arkclient.StatusScheduled {
+		t.Fatalf("create scheduled: status = %q", created.Status)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = e.db.Exec(ctx, "DELETE FROM order_state_events WHERE order_id = $1", created.OrderID)
+		_, _ = e.db.Exec(ctx, "DELETE FROM orders WHERE id = $1", created.OrderID)
+	})
+	return created.OrderID
+}
+
+// listedOrder is the part of a listed order these tests look at.
+type listedOrder struct {
+	ID             string
+	Status         string
+	DriverID       *string
+	IncentiveBonus int64
+}
+
+func (e *scheduledEnv) passengerOrders(t *testing.T, ctx context.Context) []listedOrder {
+	t.Helper()
+	var out struct {
+		Orders []listedOrder `json:"orders"`
+	}
+	if err := e.passenger.Do(ctx, http.MethodGet, "/api/orders/scheduled", nil, &out); err != nil {
+		t.Fatalf("list scheduled: %v", err)
+	}
+	return out.Orders
+}
+
+func (e *scheduledEnv) available(t *testing.T, ctx context.Context, driver string) []listedOrder {
+	t.Helper()
+	q := url.Values{}
+	q.Set("from", time.Now().UTC().Format(time.RFC3339))
+	q.Set("to", time.Now().Add(24*time.Hour).UTC().Format(time.RFC3339))
+	var out struct {
+		Orders []listedOrder `json:"orders"`
+	}
+	if err := e.drivers[driver].Do(ctx, http.MethodGet, "/api/orders/scheduled/available?"+q.Encode(), nil, &out); err != nil {
+		t.Fatalf("list available as %s: %v", driver, err)
+	}
+	return out.Orders
+}
+
+func findOrder(orders []listedOrder, id string) *listedOrder {
+	for i := range orders {
+		if orders[i].ID == id {
+			return &orders[i]
+		}
+	}
+	return nil
+}
+
+type orderRow struct {
+	status         string
+	driverID       *string
+	incentiveBonus int64
+	assigned       bool
+}
+
+func (e *scheduledEnv) row(t *testing.T, ctx context.Context, id string) orderRow {
+	t.Helper()
+	var r orderRow
+	err := e.db.QueryRow(ctx, `
+		SELECT status, driver_id, incentive_bonus, assigned_at IS NOT NULL
+		FROM orders WHERE id = $1`, id,
+	).Scan(&r.status, &r.driverID, &r.incentiveBonus, &r.assigned)
+	if err != nil {
+		t.Fatalf("load order %s: %v", id, err)
+	}
+	return r
+}
+
+func (e *scheduledEnv) events(t *testing.T, ctx context.Context, id string) []string {
+	t.Helper()
+	rows, err := e.db.Query(ctx, `
+		SELECT from_status, to_status, actor_type, COALESCE(actor_id, '')
+		FROM order_state_events WHERE order_id = $1 ORDER BY id`, id)
+	if err != nil {
+		t.Fatalf("load events: %v", err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var from, to, actorType, actorID string
+		if err := rows.Scan(&from, &to, &actorType, &actorID); err != nil {
+			t.Fatalf("scan event: %v", err)
+		}
+		out = append(out, fmt.Sprintf("%s>%s %s:%s", from, to, actorType, actorID))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("load events: %v", err)
+	}
+	return out
+}
+
+func wantStatus(t *testing.T, step, got string, err error, want string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", step, err)
+	}
+	if got != want {
+		t.Fatalf("%s: status = %q, want %q", step, got, want)
+	}
+}
+
+func TestScheduledOrderLifecycle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	env := newScheduledEnv(t, ctx)
+	d1, d2 := env.drivers["d1"], env.drivers["d2"]
+	d1ID, d2ID := env.driverID("d1"), env.driverID("d2")
+
+	id := env.book(t, ctx)
+
+	// A second booking is refused while this one is active.
+	_, err := env.passenger.CreateScheduledOrder(ctx, arkclient.CreateScheduledRequest{
+		RideType:           "economy",
+		ScheduledAt:        time.Now().Add(3 * time.Hour),
+		ScheduleWindowMins: 60,
+	})
+	if arkclient.StatusCode(err) != http.StatusConflict {
+		t.Fatalf("second booking: got %v, want 409", err)
+	}
+
+	listed := findOrder(env.passengerOrders(t, ctx), id)
+	if listed == nil || listed.Status != arkclient.StatusScheduled || listed.DriverID != nil {
+		t.Fatalf("passenger list: got %+v", listed)
+	}
+	if findOrder(env.available(t, ctx, "d1"), id) == nil {
+		t.Fatal("driver d1 does not see the open order")
+	}
+
+	// d1 claims, then gives the ride back: it re-opens with the cancel bonus.
+	st, err := d1.ClaimOrder(ctx, id)
+	wantStatus(t, "d1 claim", st, err, arkclient.StatusAssigned)
+	if _, err := d2.ClaimOrder(ctx, id); arkclient.StatusCode(err) != http.StatusConflict {
+		t.Fatalf("d2 claim of a claimed order: got %v, want 409", err)
+	}
+	if _, err := d2.Depart(ctx, id); arkclient.StatusCode(err) != http.StatusForbidden {
+		t.Fatalf("d2 depart of d1's order: got %v, want 403", err)
+	}
+	if r := env.row(t, ctx, id); r.status != "assigned" || r.driverID == nil || *r.driverID != d1ID || !r.assigned {
+		t.Fatalf("after claim: row = %+v", r)
+	}
+	st, err = d1.ReleaseOrder(ctx, id, "car trouble")
+	wantStatus(t, "d1 release", st, err, arkclient.StatusScheduled)
+	r := env.row(t, ctx, id)
+	if r.status != "scheduled" || r.driverID != nil || r.incentiveBonus != env.sched.DriverCancelBonus {
+		t.Fatalf("after release: row = %+v, want scheduled, no driver, bonus %d", r, env.sched.DriverCancelBonus)
+	}
+	if o := findOrder(env.available(t, ctx, "d2"), id); o == nil || o.IncentiveBonus != env.sched.DriverCancelBonus {
+		t.Fatalf("driver d2 sees %+v, want the order with bonus %d", o, env.sched.DriverCancelBonus)
+	}
+
+	// d2 claims and drives the ride to the end.
+	st, err = d2.ClaimOrder(ctx, id)
+	wantStatus(t, "d2 claim", st, err, arkclient.StatusAssigned)
+	if findOrder(env.available(t, ctx, "d1"), id) != nil {
+		t.Fatal("claimed order still listed as available")
+	}
+	if _, err := env.passenger.Depart(ctx, id); arkclient.StatusCode(err) != http.StatusForbidden {
+		t.Fatalf("passenger depart: got %v, want 403", err)
+	}
+	st, err = d2.Depart(ctx, id)
+	wantStatus(t, "depart", st, err, arkclient.StatusApproaching)
+	st, err = d2.Arrive(ctx, id)
+	wantStatus(t, "arrive", st, err, arkclient.StatusArrived)
+	st, err = d2.Meet(ctx, id)
+	wantStatus(t, "meet", st, err, arkclient.StatusDriving)
+	st, err = d2.Complete(ctx, id)
+	wantStatus(t, "complete", st, err, arkclient.StatusPayment)
+	if _, err := d2.Pay(ctx, id); arkclient.StatusCode(err) != http.StatusForbidden {
+		t.Fatalf("driver pay: got %v, want 403", err)
+	}
+	st, err = env.passenger.Pay(ctx, id)
+	wantStatus(t, "pay", st, err, arkclient.StatusComplete)
+
+	status, err := env.passenger.OrderStatus(ctx, id)
+	if err != nil || status.Status != arkclient.StatusComplete || status.DriverID != d2ID {
+		t.Fatalf("status = %+v, %v", status, err)
+	}
+	r = env.row(t, ctx, id)
+	if r.status != "complete" || r.driverID == nil || *r.driverID != d2ID || r.incentiveBonus != env.sched.DriverCancelBonus {
+		t.Fatalf("final row = %+v", r)
+	}
+	if o := findOrder(env.passengerOrders(t, ctx), id); o == nil || o.Status != arkclient.StatusComplete {
+		t.Fatalf("passenger list: got %+v, want the ride complete", o)
+	}
+
+	want := []string{
+		"none>scheduled passenger:" + env.passengerID,
+		"scheduled>assigned driver:" + d1ID,
+		"assigned>scheduled driver:" + d1ID,
+		"scheduled>assigned driver:" + d2ID,
+		"assigned>approaching driver:" + d2ID,
+		"approaching>arrived driver:" + d2ID,
+		"arrived>driving driver:" + d2ID,
+		"driving>payment driver:" + d2ID,
+		"payment>complete passenger:" + env.passengerID,
+	}
+	if got := env.events(t, ctx, id); !slices.Equal(got, want) {
+		t.Fatalf("events:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestScheduledOrderIncentiveStopsOnClaim(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	env := newScheduledEnv(t, ctx)
+	id := env.book(t, ctx)
+
+	tickCtx, stopTicker := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		env.orders.RunScheduleIncentiveTicker(tickCtx)
+	}()
+	defer func() {
+		stopTicker()
+		<-done
+	}()
+
+	// Unclaimed inside its window, the order gains a bonus every tick.
+	deadline := time.Now().Add(10 * time.Second)
+	for env.row(t, ctx, id).incentiveBonus < 2*env.sched.IncentiveBump {
+		if time.Now().After(deadline) {
+			t.Fatalf("incentive bonus still %d after 10s", env.row(t, ctx, id).incentiveBonus)
+		}
+		time.Sleep(env.sched.IncentiveTick)
+	}
+	if o := findOrder(env.passengerOrders(t, ctx), id); o == nil || o.IncentiveBonus < 2*env.sched.IncentiveBump {
+		t.Fatalf("passenger list: got %+v", o)
+	}
+
+	st, err := env.drivers["d1"].ClaimOrder(ctx, id)
+	wantStatus(t, "claim", st, err, arkclient.StatusAssigned)
+	claimed := env.row(t, ctx, id).incentiveBonus
+	time.Sleep(5 * env.sched.IncentiveTick)
+	if got := env.row(t, ctx, id).incentiveBonus; got != claimed {
+		t.Fatalf("bonus moved from %d to %d after the claim", claimed, got)
+	}
+}